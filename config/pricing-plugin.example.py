@@ -0,0 +1,60 @@
+#!/usr/bin/env python3
+"""
+Example executable pricing plugin
+
+Prices the instance types of an internal rate card for the "acme" cloud.
+Copy it into PRICING_PLUGIN_DIR as an executable file (e.g. acme-rates)
+and add its name to PRICING_PROVIDERS (PRICING_PROVIDERS=acme-rates,static).
+Requests arrive one JSON object per line on stdin and are answered one
+per line on stdout; see src/pricing/plugins.py for the protocol.
+"""
+
+import json
+import sys
+
+# region -> instance type -> USD per hour
+RATES = {
+    "dc1": {"gold": 0.21, "silver": 0.12},
+    "dc2": {"gold": 0.24},
+}
+
+
+def describe(params):
+    return {"protocol": 1, "clouds": ["acme"]}
+
+
+def get_price(params):
+    price = RATES.get(params["region"], {}).get(params["sku"])
+    if params.get("service") != "compute" or params.get("pricing_model") != "on_demand" or price is None:
+        raise LookupError(f"No acme price for {params['sku']} in {params['region']}")
+    return {"price": price, "unit": "hour"}
+
+
+def instance_types(params):
+    return {region: sorted(types) for region, types in RATES.items()}
+
+
+def refresh(params):
+    return None
+
+
+METHODS = {"describe": describe, "get_price": get_price, "instance_types": instance_types, "refresh": refresh}
+
+
+def main():
+    for line in sys.stdin:
+        request = json.loads(line)
+        method = METHODS.get(request["method"])
+        if method is None:
+            response = {"error": {"code": "not_implemented", "message": request["method"]}}
+        else:
+            try:
+                response = {"result": method(request.get("params") or {})}
+            except LookupError as e:
+                response = {"error": {"code": "not_found", "message": str(e)}}
+        response["id"] = request["id"]
+        print(json.dumps(response), flush=True)
+
+
+if __name__ == "__main__":
+    main()
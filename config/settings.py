@@ -1,48 +1,523 @@
 """
 Application settings and environment configuration
+
+Each setting is resolved from, highest precedence first:
+
+1. overrides (command line flags)
+2. environment variables
+3. the YAML config file (CONFIG_FILE)
+4. built-in defaults
+
+The environment variable name is the setting name in upper case. YAML keys
+use the same names in lower case and may be grouped in sections, whose
+names are joined with an underscore: `api: {port: 8001}` sets API_PORT.
 """
 import os
-from typing import Optional
+from typing import Any, Dict, List, Mapping, Optional, Set
+
+import yaml
+
+
+class ConfigError(ValueError):
+    """Raised for unreadable config files or unknown settings"""
+
+
+def _flatten(document: Mapping[str, Any], prefix: str = "") -> Dict[str, str]:
+    """Flatten nested YAML sections to setting names with string values"""
+    values: Dict[str, str] = {}
+    for key, value in document.items():
+        name = f"{prefix}_{key}" if prefix else str(key)
+        if isinstance(value, Mapping):
+            values.update(_flatten(value, name))
+        elif isinstance(value, bool):
+            values[name.upper()] = "true" if value else "false"
+        elif isinstance(value, (list, tuple)):
+            values[name.upper()] = ",".join(str(v) for v in value)
+        elif value is not None:
+            values[name.upper()] = str(value)
+    return values
+
+
+def load_config_file(path: str) -> Dict[str, str]:
+    """
+    Read a YAML config file as setting name -> value
+
+    Raises:
+        ConfigError: If the file cannot be read or is not a mapping
+    """
+    try:
+        with open(path) as f:
+            document = yaml.safe_load(f) or {}
+    except (OSError, yaml.YAMLError) as e:
+        raise ConfigError(f"Cannot read config file {path}: {e}") from e
+    if not isinstance(document, Mapping):
+        raise ConfigError(f"Config file {path} must contain a mapping")
+    return _flatten(document)
 
 
 class Settings:
     """Application settings"""
 
-    def __init__(self):
+    def __init__(self, config_file: Optional[str] = None, overrides: Optional[Mapping[str, Any]] = None):
+        """
+        Load settings
+
+        Args:
+            config_file: YAML config file. Defaults to the CONFIG_FILE environment variable.
+            overrides: Setting name -> value taking precedence over every other source
+
+        Raises:
+            ConfigError: If the config file is unreadable or names unknown settings
+        """
+        config_file = config_file or os.getenv("CONFIG_FILE", "")
+        self.config_file = config_file
+        self._file = load_config_file(config_file) if config_file else {}
+        self._overrides = _flatten(overrides or {})
+        self._names: Set[str] = set()
+
         # Prometheus connection
-        self.power_prometheus_url = os.getenv(
+        self.power_prometheus_url = self._get(
             "POWER_PROMETHEUS_URL",
             "http://prometheus:9090"
         )
-        self.power_metrics_interval = int(os.getenv("POWER_METRICS_INTERVAL", "15"))
+        self.power_metrics_interval = int(self._get("POWER_METRICS_INTERVAL", "15"))
 
         # Cost calculation parameters
-        self.electricity_rate = float(os.getenv("ELECTRICITY_RATE", "0.12"))
-        self.cooling_factor = float(os.getenv("COOLING_FACTOR", "1.3"))
-        self.carbon_rate = float(os.getenv("CARBON_RATE", "0.05"))
+        self.electricity_rate = float(self._get("ELECTRICITY_RATE", "0.12"))
+        self.cooling_factor = float(self._get("COOLING_FACTOR", "1.3"))
+        self.carbon_rate = float(self._get("CARBON_RATE", "0.05"))
 
         # Data storage
-        self.redis_url = os.getenv("REDIS_URL", "redis://localhost:6379")
-        self.influxdb_url = os.getenv("INFLUXDB_URL", "http://influxdb:8086")
-        self.influxdb_bucket = os.getenv("INFLUXDB_BUCKET", "power_metrics")
+        self.redis_url = self._get("REDIS_URL", "redis://localhost:6379")
+        self.influxdb_url = self._get("INFLUXDB_URL", "http://influxdb:8086")
+        self.influxdb_bucket = self._get("INFLUXDB_BUCKET", "power_metrics")
+
+        # Cost estimation
+        self.pricing_providers = self._list("PRICING_PROVIDERS", "static")
+        self.price_catalog_path = self._get("PRICE_CATALOG_PATH", "")
+        # Out-of-tree providers: executables speaking the JSON plugin protocol and Python
+        # modules registering factories, enabled through PRICING_PROVIDERS by name
+        self.pricing_plugin_dir = self._get("PRICING_PLUGIN_DIR", "")
+        self.pricing_plugin_modules = self._list("PRICING_PLUGIN_MODULES", "")
+        self.pricing_plugin_timeout = float(self._get("PRICING_PLUGIN_TIMEOUT", "10"))
+        # Data transfer rates (JSON, provider -> region -> direction -> tiers; empty = built-in rates)
+        self.transfer_rates_path = self._get("TRANSFER_RATES_PATH", "")
+        # Software license rates (JSON, license name or provider/name -> rate; empty = built-in rates)
+        self.license_rates_path = self._get("LICENSE_RATES_PATH", "")
+        self.pricing_cache_dir = self._get("PRICING_CACHE_DIR", "/tmp/kcloud-pricing")
+        self.pricing_cache_ttl = int(self._get("PRICING_CACHE_TTL", "86400"))
+        # Hours per month converting hourly, monthly and yearly figures of every provider:
+        # 730 (8760 / 12), 720 (30 days) or calendar (days of the current month)
+        self.units_hours_per_month = self._get("UNITS_HOURS_PER_MONTH", "730").lower()
+
+        # Each provider's catalog is refreshed every interval (spread by the jitter fraction),
+        # failed refreshes are retried with exponential backoff; catalogs older than the max age
+        # mark their provider degraded in /health
+        self.catalog_refresh_interval = float(self._get("CATALOG_REFRESH_INTERVAL", str(self.pricing_cache_ttl)))
+        self.catalog_refresh_jitter = float(self._get("CATALOG_REFRESH_JITTER", "0.1"))
+        self.catalog_refresh_backoff = float(self._get("CATALOG_REFRESH_BACKOFF", "60"))
+        self.catalog_refresh_max_backoff = float(self._get("CATALOG_REFRESH_MAX_BACKOFF", "3600"))
+        self.catalog_max_age = float(self._get("CATALOG_MAX_AGE", "172800"))
+        # Catalog snapshot days whose providers stay loaded for pinned estimates (?catalog_version=)
+        self.catalog_pinned_snapshots = int(self._get("CATALOG_PINNED_SNAPSHOTS", "4"))
+        # Most recent estimates of a tenant checked against catalog price changes
+        self.price_change_estimate_limit = int(self._get("PRICE_CHANGE_ESTIMATE_LIMIT", "500"))
+
+        # Spot prices are averaged over this many trailing days of price history
+        self.spot_price_window_days = float(self._get("SPOT_PRICE_WINDOW_DAYS", "30"))
+        self.spot_price_cache_ttl = int(self._get("SPOT_PRICE_CACHE_TTL", "3600"))
+
+        # Persistent store (sqlite:///path.db for local development, postgresql://... in production)
+        self.store_url = self._get("STORE_URL", "sqlite:////tmp/kcloud-cost-estimator.db")
+        self.store_migrate_on_startup = self._get("STORE_MIGRATE_ON_STARTUP", "true").lower() == "true"
+
+        # Offline mode: never call cloud pricing APIs, price from a snapshot and the static rate card
+        self.offline = self._get("OFFLINE", "false").lower() == "true"
+        self.pricing_snapshot_path = self._get("PRICING_SNAPSHOT_PATH", "")
+
+        # Currency conversion: "ecb" (daily reference rates, static rates as fallback) or "static"
+        self.currency_rate_source = self._get("CURRENCY_RATE_SOURCE", "ecb").lower()
+        self.currency_static_rates = self._get("CURRENCY_STATIC_RATES", "EUR=0.92,KRW=1380,JPY=150")
+        self.ecb_rates_url = self._get(
+            "ECB_RATES_URL",
+            "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+        )
+        self.currency_rate_ttl = int(self._get("CURRENCY_RATE_TTL", "21600"))
+        # Rounding of amounts (half_up, half_even or down) and per-currency minor units
+        # and modes of invoicing statements, e.g. "CHF=2:half_even,KRW=0:down"
+        self.money_rounding = self._get("MONEY_ROUNDING", "half_up").lower()
+        self.money_currency_rounding = self._get("MONEY_CURRENCY_ROUNDING", "")
+
+        # Least confidence (0-1) of the closest instance type priced for one
+        # that is not found, on requests with ?fuzzy=true
+        self.fuzzy_match_min_confidence = float(self._get("FUZZY_MATCH_MIN_CONFIDENCE", "0.8"))
+
+        # Default thresholds of POST /estimate/diff (empty: no limit): monthly
+        # cost increase (USD), increase in percent and monthly cost of the head side
+        self.diff_max_increase = self._get("DIFF_MAX_INCREASE", "")
+        self.diff_max_increase_percent = self._get("DIFF_MAX_INCREASE_PERCENT", "")
+        self.diff_max_monthly_cost = self._get("DIFF_MAX_MONTHLY_COST", "")
+
+        # Rego cost policies verdicting /estimate and /estimate/diff (empty: none): a .rego file
+        # or directory whose package defines deny and warn rules, evaluated in process
+        # ("embedded", needs regopy) or with the opa CLI ("opa")
+        self.policy_path = self._get("POLICY_PATH", "")
+        self.policy_engine = self._get("POLICY_ENGINE", "embedded").lower()
+        self.policy_package = self._get("POLICY_PACKAGE", "kcloud.cost")
+        self.policy_opa_binary = self._get("POLICY_OPA_BINARY", "opa")
+        self.policy_timeout_seconds = float(self._get("POLICY_TIMEOUT_SECONDS", "5"))
+
+        # Carbon footprint of estimates: grid intensity from "static" (annual
+        # averages, with provider:region=gCO2e/kWh overrides) or "electricitymaps"
+        # (latest measured, static table as fallback), at an average CPU utilization
+        self.carbon_estimates = self._get("CARBON_ESTIMATES", "true").lower() == "true"
+        self.carbon_intensity_source = self._get("CARBON_INTENSITY_SOURCE", "static").lower()
+        self.carbon_intensity_overrides = self._get("CARBON_INTENSITY_OVERRIDES", "")
+        self.carbon_intensity_ttl = int(self._get("CARBON_INTENSITY_TTL", "3600"))
+        self.carbon_cpu_utilization = float(self._get("CARBON_CPU_UTILIZATION", "0.5"))
+        self.electricitymaps_api_token = self._get("ELECTRICITYMAPS_API_TOKEN", "")
+        self.electricitymaps_url = self._get("ELECTRICITYMAPS_URL", "https://api.electricitymap.org/v3")
+
+        # Batch estimates: largest batch accepted, and price lookups running at once across batches
+        self.batch_max_items = int(self._get("BATCH_MAX_ITEMS", "5000"))
+        self.batch_workers = int(self._get("BATCH_WORKERS", "16"))
+
+        # Background estimation jobs (needs STORE_URL): jobs running at once on
+        # this replica, how often queued jobs are picked up and heartbeats
+        # written, and how old a running job's heartbeat gets before another
+        # replica resumes it
+        self.job_workers = int(self._get("JOB_WORKERS", "2"))
+        self.job_poll_interval = float(self._get("JOB_POLL_INTERVAL", "5"))
+        self.job_lease_seconds = float(self._get("JOB_LEASE_SECONDS", "120"))
+        self.job_max_attempts = int(self._get("JOB_MAX_ATTEMPTS", "3"))
+        # Billing ingestion, cluster scan and report jobs failing on an outage
+        # are retried after a backoff doubling each time, then dead-lettered.
+        # A replica with no workers only queues jobs for the other replicas.
+        self.job_retries = int(self._get("JOB_RETRIES", "3"))
+        self.job_retry_backoff_seconds = float(self._get("JOB_RETRY_BACKOFF_SECONDS", "30"))
+
+        # Estimate results served again for identical requests (0 disables).
+        # With a Redis URL the cache is shared by all replicas, each keeping
+        # recently used results in memory for at most the local TTL.
+        self.result_cache_ttl = float(self._get("RESULT_CACHE_TTL", "300"))
+        self.result_cache_max_entries = int(self._get("RESULT_CACHE_MAX_ENTRIES", "1000"))
+        self.result_cache_redis_url = self._get("RESULT_CACHE_REDIS_URL", "")
+        self.result_cache_redis_prefix = self._get("RESULT_CACHE_REDIS_PREFIX", "kcloud-cost:")
+        self.result_cache_local_ttl = float(self._get("RESULT_CACHE_LOCAL_TTL", "30"))
+
+        # Locks letting one replica at a time refresh a catalog or run a
+        # periodic job; without a Redis URL they only coordinate this replica.
+        # A refresh lock held by a replica that died expires after the TTL.
+        self.lock_redis_url = self._get("LOCK_REDIS_URL", "")
+        self.lock_redis_prefix = self._get("LOCK_REDIS_PREFIX", "kcloud-cost:lock:")
+        self.lock_ttl_seconds = float(self._get("LOCK_TTL_SECONDS", "300"))
+
+        self.k8s_cpu_to_memory_cost_ratio = float(self._get("K8S_CPU_TO_MEMORY_COST_RATIO", "7.46"))
+        # Running clusters' nodes whose providerID or labels do not name a
+        # provider or region are priced in these
+        self.k8s_node_provider = self._get("K8S_NODE_PROVIDER", "aws").lower()
+        self.k8s_node_region = self._get("K8S_NODE_REGION", "")
+        # Prometheus with cAdvisor and kube-state-metrics series for usage-based
+        # estimates (disabled without a URL)
+        self.k8s_usage_prometheus_url = self._get("K8S_USAGE_PROMETHEUS_URL", "")
+        self.k8s_usage_query_timeout = int(self._get("K8S_USAGE_QUERY_TIMEOUT", "30"))
+        self.k8s_usage_window = self._get("K8S_USAGE_WINDOW", "7d")
+        # Right-sizing from that usage: capacity kept above usage (percent),
+        # namespaces, namespace/name workload patterns and node name or instance
+        # type patterns never right-sized, and the savings a recommendation needs (USD/month)
+        self.rightsizing_headroom_percent = float(self._get("RIGHTSIZING_HEADROOM_PERCENT", "20"))
+        self.rightsizing_exclude_namespaces = self._list("RIGHTSIZING_EXCLUDE_NAMESPACES", "kube-system")
+        self.rightsizing_exclude_workloads = self._list("RIGHTSIZING_EXCLUDE_WORKLOADS")
+        self.rightsizing_exclude_nodes = self._list("RIGHTSIZING_EXCLUDE_NODES")
+        self.rightsizing_min_savings = float(self._get("RIGHTSIZING_MIN_SAVINGS", "1"))
+        # Live cluster scans through the API server: kubeconfig (in-cluster credentials
+        # if empty), name recorded as the estimates' project, scan interval in
+        # seconds (0 disables scheduled scans), minutes between namespace cost
+        # metric collections (0 disables them), load balancer hourly rates and the
+        # control plane tier (empty detects it from the nodes' labels)
+        self.k8s_kubeconfig = self._get("K8S_KUBECONFIG", "")
+        self.k8s_context = self._get("K8S_CONTEXT", "")
+        self.k8s_cluster_name = self._get("K8S_CLUSTER_NAME", "default")
+        self.k8s_cluster_scan_interval = int(self._get("K8S_CLUSTER_SCAN_INTERVAL", "0"))
+        self.k8s_namespace_cost_interval_minutes = int(self._get("K8S_NAMESPACE_COST_INTERVAL_MINUTES", "0"))
+        self.k8s_load_balancer_hourly = self._get("K8S_LOAD_BALANCER_HOURLY", "aws=0.0225,gcp=0.025,azure=0.025")
+        self.k8s_cluster_tier = self._get("K8S_CLUSTER_TIER", "")
+
+        # Cost allocation: label keys with the label and annotation names they are
+        # read from, namespaces whose costs are shared by every group, and how
+        # shared costs are split (proportional, even or weighted)
+        self.allocation_label_keys = self._get(
+            "ALLOCATION_LABEL_KEYS",
+            "team=team|owner,cost-center=cost-center|costcenter|cost_center,namespace=namespace|k8s-namespace"
+        )
+        self.allocation_shared_namespaces = self._list(
+            "ALLOCATION_SHARED_NAMESPACES", "kube-system,kube-public,kube-node-lease,monitoring"
+        )
+        self.allocation_split = self._get("ALLOCATION_SPLIT", "proportional").lower()
+
+        # Spend forecasts: default model (linear, exponential_smoothing or seasonal),
+        # days of actual spend fitted and the seasonal model's period in days
+        self.forecast_model = self._get("FORECAST_MODEL", "linear").lower()
+        self.forecast_history_days = int(self._get("FORECAST_HISTORY_DAYS", "180"))
+        self.forecast_season_days = int(self._get("FORECAST_SEASON_DAYS", "7"))
+
+        # Spend anomalies: detector (zscore or mad), days of each day's baseline,
+        # score threshold, increase a spike needs and a new SKU's first-day spend (USD),
+        # and seconds between background checks notifying webhooks (0 disables)
+        self.anomaly_detector = self._get("ANOMALY_DETECTOR", "mad").lower()
+        self.anomaly_window_days = int(self._get("ANOMALY_WINDOW_DAYS", "28"))
+        self.anomaly_threshold = float(self._get("ANOMALY_THRESHOLD", "3.5"))
+        self.anomaly_min_increase = float(self._get("ANOMALY_MIN_INCREASE", "10"))
+        self.anomaly_new_sku_min_cost = float(self._get("ANOMALY_NEW_SKU_MIN_COST", "25"))
+        self.anomaly_check_interval = int(self._get("ANOMALY_CHECK_INTERVAL", "3600"))
+
+        # Commitment purchase recommendations: days of ingested usage analyzed
+        # and share of usage hours commitments should cover (percent)
+        self.commitment_lookback_days = int(self._get("COMMITMENT_LOOKBACK_DAYS", "30"))
+        self.commitment_coverage_percent = float(self._get("COMMITMENT_COVERAGE_PERCENT", "80"))
+
+        # Helm chart rendering
+        self.helm_binary = self._get("HELM_BINARY", "helm")
+        self.helm_timeout_seconds = int(self._get("HELM_TIMEOUT_SECONDS", "60"))
+        self.helm_max_chart_bytes = int(self._get("HELM_MAX_CHART_BYTES", str(10 * 1024 * 1024)))
+
+        # Crossplane compositions (YAML file or directory) claims are rendered with
+        self.crossplane_compositions_path = self._get("CROSSPLANE_COMPOSITIONS_PATH", "")
+
+        # AWS Price List API
+        self.aws_price_list_url = self._get(
+            "AWS_PRICE_LIST_URL",
+            "https://pricing.us-east-1.amazonaws.com"
+        )
+        self.aws_pricing_regions = self._list("AWS_PRICING_REGIONS", "us-east-1,ap-northeast-2")
+        self.aws_pricing_services = self._list(
+            "AWS_PRICING_SERVICES", "AmazonEC2,AmazonS3,AmazonRDS,AWSComputeSavingsPlan"
+        )
+        self.aws_instance_families = self._list("AWS_INSTANCE_FAMILIES")
+
+        # GCP Cloud Billing Catalog API
+        self.gcp_billing_api_url = self._get(
+            "GCP_BILLING_API_URL",
+            "https://cloudbilling.googleapis.com"
+        )
+        self.gcp_billing_api_key = self._get("GCP_BILLING_API_KEY", "")
+        self.gcp_pricing_regions = self._list("GCP_PRICING_REGIONS", "us-central1,asia-northeast3")
+
+        # Billing exports ingested as actual costs of BILLING_TENANT, every
+        # BILLING_INGEST_INTERVAL seconds (0 disables scheduled ingestion)
+        self.billing_tenant = self._get("BILLING_TENANT", "default")
+        self.billing_ingest_interval = int(self._get("BILLING_INGEST_INTERVAL", "21600"))
+        # Tag or label naming the project of a cost line
+        self.billing_project_label = self._get("BILLING_PROJECT_LABEL", "project")
+        # Actual costs are kept raw for ACTUALS_RAW_RETENTION_DAYS, as daily roll-ups
+        # until ACTUALS_DAILY_RETENTION_DAYS, then as monthly roll-ups until
+        # ACTUALS_MONTHLY_RETENTION_DAYS (0 keeps a resolution forever); compacted every
+        # ACTUALS_COMPACTION_INTERVAL seconds (0 disables compaction)
+        self.actuals_raw_retention_days = int(self._get("ACTUALS_RAW_RETENTION_DAYS", "90"))
+        self.actuals_daily_retention_days = int(self._get("ACTUALS_DAILY_RETENTION_DAYS", "730"))
+        self.actuals_monthly_retention_days = int(self._get("ACTUALS_MONTHLY_RETENTION_DAYS", "0"))
+        self.actuals_compaction_interval = int(self._get("ACTUALS_COMPACTION_INTERVAL", "86400"))
+        # AWS Cost and Usage Reports in S3 (disabled without a bucket); cost type
+        # unblended, net_unblended or blended
+        self.aws_cur_bucket = self._get("AWS_CUR_BUCKET", "")
+        self.aws_cur_prefix = self._get("AWS_CUR_PREFIX", "")
+        self.aws_cur_cost = self._get("AWS_CUR_COST", "unblended").lower()
+        # GCP Cloud Billing export to BigQuery (disabled without a table); query jobs
+        # run in GCP_BILLING_EXPORT_PROJECT, default the table's project; cost type
+        # net (credits subtracted) or gross
+        self.gcp_billing_export_table = self._get("GCP_BILLING_EXPORT_TABLE", "")
+        self.gcp_billing_export_project = self._get("GCP_BILLING_EXPORT_PROJECT", "")
+        self.gcp_billing_export_cost = self._get("GCP_BILLING_EXPORT_COST", "net").lower()
+        # Azure Cost Management exports in blob storage (disabled without a container);
+        # the account is reached with the connection string if set, else with
+        # DefaultAzureCredential at the account URL
+        self.azure_cost_export_account_url = self._get("AZURE_COST_EXPORT_ACCOUNT_URL", "")
+        self.azure_cost_export_container = self._get("AZURE_COST_EXPORT_CONTAINER", "")
+        self.azure_cost_export_prefix = self._get("AZURE_COST_EXPORT_PREFIX", "")
+        self.azure_cost_export_connection_string = self._get("AZURE_COST_EXPORT_CONNECTION_STRING", "")
+
+        # Resource inventories listed from cloud APIs for INVENTORY_TENANT, every
+        # INVENTORY_COLLECT_INTERVAL seconds (0 disables scheduled collection); each
+        # cloud is disabled without regions, projects or subscriptions
+        self.inventory_tenant = self._get("INVENTORY_TENANT", "default")
+        self.inventory_collect_interval = int(self._get("INVENTORY_COLLECT_INTERVAL", "3600"))
+        self.inventory_aws_regions = self._list("INVENTORY_AWS_REGIONS")
+        self.inventory_gcp_projects = self._list("INVENTORY_GCP_PROJECTS")
+        self.inventory_azure_subscriptions = self._list("INVENTORY_AZURE_SUBSCRIPTIONS")
+
+        # Azure Retail Prices API
+        self.azure_retail_prices_url = self._get(
+            "AZURE_RETAIL_PRICES_URL",
+            "https://prices.azure.com/api/retail/prices"
+        )
+        self.azure_pricing_regions = self._list("AZURE_PRICING_REGIONS", "eastus,koreacentral")
+        self.azure_pricing_cache_ttl = int(
+            self._get("AZURE_PRICING_CACHE_TTL", str(self.pricing_cache_ttl))
+        )
+
+        # NCP Billing API (prices in KRW); GOV endpoint https://billingapi.apigw.gov-ntruss.com
+        self.ncp_billing_api_url = self._get("NCP_BILLING_API_URL", "https://billingapi.apigw.ntruss.com")
+        self.ncp_access_key = self._get("NCP_ACCESS_KEY", "")
+        self.ncp_secret_key = self._get("NCP_SECRET_KEY", "")
+        self.ncp_pricing_regions = self._list("NCP_PRICING_REGIONS", "KR")
+        self.ncp_pricing_cache_ttl = int(
+            self._get("NCP_PRICING_CACHE_TTL", str(self.pricing_cache_ttl))
+        )
+
+        # Alibaba Cloud BSS OpenAPI price quotes; China site endpoint https://business.aliyuncs.com
+        self.alibaba_bss_endpoint = self._get("ALIBABA_BSS_ENDPOINT", "https://business.ap-southeast-1.aliyuncs.com")
+        self.alibaba_access_key_id = self._get("ALIBABA_ACCESS_KEY_ID", "")
+        self.alibaba_access_key_secret = self._get("ALIBABA_ACCESS_KEY_SECRET", "")
+        self.alibaba_pricing_regions = self._list("ALIBABA_PRICING_REGIONS", "ap-southeast-1,ap-northeast-2")
+        self.alibaba_pricing_cache_ttl = int(
+            self._get("ALIBABA_PRICING_CACHE_TTL", str(self.pricing_cache_ttl))
+        )
+
+        # OpenStack / private cloud rate card (JSON), priced by the openstack provider
+        self.openstack_rates_path = self._get("OPENSTACK_RATES_PATH", "")
 
         # API settings
-        self.api_host = os.getenv("API_HOST", "0.0.0.0")
-        self.api_port = int(os.getenv("API_PORT", "8001"))
+        self.api_host = self._get("API_HOST", "0.0.0.0")
+        self.api_port = int(self._get("API_PORT", "8001"))
+        # Server timeouts (seconds): idle keep-alive connections, handling one request
+        # (0 disables), and draining requests and catalog refreshes on shutdown
+        self.server_keepalive_timeout = int(self._get("SERVER_KEEPALIVE_TIMEOUT", "5"))
+        self.server_request_timeout = float(self._get("SERVER_REQUEST_TIMEOUT", "120"))
+        self.server_shutdown_timeout = int(self._get("SERVER_SHUTDOWN_TIMEOUT", "25"))
+        # Requests expensive routes handle at once ("/path=limit" entries); requests over
+        # the limit wait up to the queue timeout (seconds) in a queue of the max size each,
+        # then are refused with 503 and Retry-After
+        self.concurrency_limits = self._list(
+            "CONCURRENCY_LIMITS", "/estimate/cluster=2,/estimate/terraform=4,/estimate/helm=2"
+        )
+        self.concurrency_queue_timeout = float(self._get("CONCURRENCY_QUEUE_TIMEOUT", "10"))
+        self.concurrency_max_queue = int(self._get("CONCURRENCY_MAX_QUEUE", "20"))
+        # Seconds each dependency check of /healthz and /readyz may take
+        self.health_check_timeout = float(self._get("HEALTH_CHECK_TIMEOUT", "2"))
+        # gRPC EstimateService, served on its own port next to HTTP
+        self.grpc_enabled = self._get("GRPC_ENABLED", "true").lower() == "true"
+        self.grpc_port = int(self._get("GRPC_PORT", "50051"))
+        # Web dashboard at /dashboard, and at / for browsers
+        self.dashboard_enabled = self._get("DASHBOARD_ENABLED", "true").lower() == "true"
+        # Admission webhook: reviews Deployments and StatefulSets against their
+        # namespace's budgets on its own HTTPS port, warning or (deny mode)
+        # rejecting changes that take projected spend past a budget
+        self.admission_enabled = self._get("ADMISSION_ENABLED", "false").lower() == "true"
+        self.admission_port = int(self._get("ADMISSION_PORT", "8443"))
+        self.admission_tls_cert_file = self._get("ADMISSION_TLS_CERT_FILE", "")
+        self.admission_tls_key_file = self._get("ADMISSION_TLS_KEY_FILE", "")
+        self.admission_mode = self._get("ADMISSION_MODE", "warn").lower()
+        self.admission_node_instance_type = self._get("ADMISSION_NODE_INSTANCE_TYPE", "")
+        self.admission_tenant = self._get("ADMISSION_TENANT", "default")
+        self.admission_exempt_namespaces = self._list("ADMISSION_EXEMPT_NAMESPACES", "kube-system")
+        # Authentication: API keys ("name:key:scope+scope[:limit]" entries, or issued
+        # via /admin/api-keys) and OIDC bearer tokens; probes and /metrics stay open
+        self.auth_enabled = self._get("AUTH_ENABLED", "false").lower() == "true"
+        self.auth_static_keys = self._list("AUTH_STATIC_KEYS")
+        # Requests per minute of a key or token without its own limit (0 = unlimited)
+        self.auth_rate_limit = int(self._get("AUTH_RATE_LIMIT", "60"))
+        # Requests a caller may send at once before its limit's rate applies (0 = its per-minute limit)
+        self.rate_limit_burst = int(self._get("RATE_LIMIT_BURST", "0"))
+        # Requests per minute of each client address while authentication is disabled (0 = unlimited)
+        self.rate_limit_anonymous = int(self._get("RATE_LIMIT_ANONYMOUS", "0"))
+        # Proxies in front of the service whose X-Forwarded-For entries are trusted for client addresses
+        self.rate_limit_trusted_proxies = int(self._get("RATE_LIMIT_TRUSTED_PROXIES", "0"))
+        # Callers whose budget is tracked at once, least recently seen dropped first
+        self.rate_limit_max_clients = int(self._get("RATE_LIMIT_MAX_CLIENTS", "10000"))
+        self.oidc_issuer = self._get("OIDC_ISSUER", "")
+        self.oidc_audience = self._get("OIDC_AUDIENCE", "kcloud-cost-estimator")
+        self.oidc_jwks_url = self._get("OIDC_JWKS_URL", "")
+        # Token claim listing the caller's scopes, and the prefix of service scopes in it
+        self.oidc_scope_claim = self._get("OIDC_SCOPE_CLAIM", "scope")
+        self.oidc_scope_prefix = self._get("OIDC_SCOPE_PREFIX", "")
+        # Token claim holding the caller's tenant ID
+        self.oidc_tenant_claim = self._get("OIDC_TENANT_CLAIM", "tenant")
+
+        # Multi-tenancy: seconds a tenant's price sheet is cached per replica
+        self.tenant_price_sheet_ttl = float(self._get("TENANT_PRICE_SHEET_TTL", "60"))
+        # Largest price list accepted by POST /catalog/custom
+        self.custom_price_sheet_max_bytes = int(
+            self._get("CUSTOM_PRICE_SHEET_MAX_BYTES", str(5 * 1024 * 1024))
+        )
+        # Seconds a tenant's discount rules are cached per replica
+        self.discount_rules_ttl = float(self._get("DISCOUNT_RULES_TTL", "60"))
+        # Seconds a tenant's usage profiles are cached per replica
+        self.usage_profiles_ttl = float(self._get("USAGE_PROFILES_TTL", "60"))
+        # Seconds a tenant's role assignments are cached per replica
+        self.iam_roles_ttl = float(self._get("IAM_ROLES_TTL", "60"))
+        # Webhook notifications: seconds to wait for a receiver, attempts per delivery,
+        # and seconds before the first retry (doubled for each further retry)
+        self.webhook_timeout_seconds = float(self._get("WEBHOOK_TIMEOUT_SECONDS", "10"))
+        self.webhook_max_attempts = int(self._get("WEBHOOK_MAX_ATTEMPTS", "5"))
+        self.webhook_backoff_seconds = float(self._get("WEBHOOK_BACKOFF_SECONDS", "2"))
+        # Chat commands: signing secret of the Slack app serving /kcost and security
+        # token of the Teams outgoing webhook (an empty value disables the platform)
+        self.slack_signing_secret = self._get("SLACK_SIGNING_SECRET", "")
+        self.teams_webhook_token = self._get("TEAMS_WEBHOOK_TOKEN", "")
+        # Chargeback statements: default grouping, and whether the tenant's discount
+        # rules apply to billed spend (disable when exports already net them)
+        self.chargeback_group_by = self._get("CHARGEBACK_GROUP_BY", "label:team")
+        self.chargeback_apply_discounts = self._get("CHARGEBACK_APPLY_DISCOUNTS", "true").lower() == "true"
+        # BILLING_TENANT's statement of the previous month is mailed to these
+        # addresses on this day of every month (disabled without recipients)
+        self.chargeback_email_to = self._list("CHARGEBACK_EMAIL_TO")
+        self.chargeback_email_day = int(self._get("CHARGEBACK_EMAIL_DAY", "3"))
+        # SMTP relay sending mail
+        self.smtp_host = self._get("SMTP_HOST", "")
+        self.smtp_port = int(self._get("SMTP_PORT", "587"))
+        self.smtp_username = self._get("SMTP_USERNAME", "")
+        self.smtp_password = self._get("SMTP_PASSWORD", "")
+        self.smtp_from = self._get("SMTP_FROM", "kcloud-cost-estimator@localhost")
+        self.smtp_starttls = self._get("SMTP_STARTTLS", "true").lower() == "true"
+        # Cost changes and recommendations listed in the cost reports tenants schedule
+        # through /reports/schedules (mailed through SMTP_HOST)
+        self.report_top_items = int(self._get("REPORT_TOP_ITEMS", "10"))
         # CORS
-        self.cors_allow_origins = [o.strip() for o in os.getenv("CORS_ALLOW_ORIGINS", "*").split(",")]
-        self.cors_allow_methods = [m.strip() for m in os.getenv("CORS_ALLOW_METHODS", "GET,POST,PUT,DELETE,OPTIONS").split(",")]
-        self.cors_allow_headers = [h.strip() for h in os.getenv("CORS_ALLOW_HEADERS", "Origin,Content-Type,Accept,Authorization,X-Requested-With").split(",")]
+        self.cors_allow_origins = self._list("CORS_ALLOW_ORIGINS", "*")
+        self.cors_allow_methods = self._list("CORS_ALLOW_METHODS", "GET,POST,PUT,DELETE,OPTIONS")
+        self.cors_allow_headers = self._list("CORS_ALLOW_HEADERS", "Origin,Content-Type,Accept,Authorization,X-API-Key,X-Requested-With")
 
         # Logging
-        self.log_level = os.getenv("LOG_LEVEL", "INFO")
+        self.log_level = self._get("LOG_LEVEL", "INFO")
+        # "text" for local development, "json" for log collectors
+        self.log_format = self._get("LOG_FORMAT", "text").lower()
+
+        # Tracing: OpenTelemetry spans exported over OTLP ("grpc" or "http/protobuf")
+        self.tracing_enabled = self._get("TRACING_ENABLED", "false").lower() == "true"
+        # Collector endpoint, e.g. http://otel-collector:4317 (empty = OTEL_EXPORTER_OTLP_ENDPOINT or localhost)
+        self.tracing_otlp_endpoint = self._get("TRACING_OTLP_ENDPOINT", "")
+        self.tracing_otlp_protocol = self._get("TRACING_OTLP_PROTOCOL", "grpc").lower()
+        # Share of traces started here that are recorded (callers' traceparent decides for theirs)
+        self.tracing_sample_ratio = float(self._get("TRACING_SAMPLE_RATIO", "1.0"))
+
+        unknown = sorted((set(self._file) | set(self._overrides)) - self._names)
+        if unknown:
+            raise ConfigError(f"Unknown settings: {', '.join(unknown)}")
+
+    @property
+    def names(self) -> Set[str]:
+        """Names of every setting (environment variable names)"""
+        return set(self._names)
+
+    def _get(self, name: str, default: str = "") -> str:
+        """Resolve a setting from overrides, environment, config file, then default"""
+        self._names.add(name)
+        if name in self._overrides:
+            return self._overrides[name]
+        if name in os.environ:
+            return os.environ[name]
+        return self._file.get(name, default)
+
+    def _list(self, name: str, default: str = "") -> List[str]:
+        """Resolve a comma separated setting as a list"""
+        return [v.strip() for v in self._get(name, default).split(",") if v.strip()]
 
 
 _settings: Optional[Settings] = None
 
 
 def get_settings() -> Settings:
-    """Get application settings (singleton)"""
+    """Get application settings (singleton), loaded from CONFIG_FILE and the environment"""
     global _settings
     if _settings is None:
         _settings = Settings()
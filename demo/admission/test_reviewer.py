@@ -0,0 +1,137 @@
+"""Unit tests for cost-aware admission review"""
+
+from datetime import date, datetime, timezone
+
+import pytest
+
+from src.admission import AdmissionReviewer, MODE_DENY
+from src.budgets import ActualCostEntry, BudgetEvaluator, BudgetSpec
+from src.k8s import KubernetesEstimator
+from src.pricing import ProviderRegistry, StaticProvider
+from src.store import SQLiteStore
+
+# Ten days into a 30 day month
+NOW = datetime(2026, 6, 11, tzinfo=timezone.utc)
+
+
+def _deployment(replicas, cpu="2", memory="4Gi", namespace="web"):
+    return {
+        "apiVersion": "apps/v1",
+        "kind": "Deployment",
+        "metadata": {"name": "api", "namespace": namespace},
+        "spec": {
+            "replicas": replicas,
+            "template": {"spec": {"containers": [
+                {"name": "api", "resources": {"requests": {"cpu": cpu, "memory": memory}}},
+            ]}},
+        },
+    }
+
+
+def _review(obj, old=None, operation="CREATE", kind="Deployment"):
+    return {
+        "apiVersion": "admission.k8s.io/v1",
+        "kind": "AdmissionReview",
+        "request": {
+            "uid": "705ab4f5",
+            "kind": {"group": "apps", "version": "v1", "kind": kind},
+            "operation": operation,
+            "namespace": (obj or old)["metadata"]["namespace"],
+            "name": (obj or old)["metadata"]["name"],
+            "object": obj,
+            "oldObject": old,
+        },
+    }
+
+
+@pytest.fixture
+def store():
+    store = SQLiteStore(":memory:")
+    store.migrate()
+    # $100 spent in web in the first third of the month: $300 projected
+    store.add_actual_costs([ActualCostEntry(
+        usage_date=date(2026, 6, 5), amount=100, labels={"namespace": "web"},
+    ).to_record("default")])
+    budget = BudgetSpec(name="web", amount=400, thresholds=[0.9, 1.0], labels={"namespace": "web"})
+    store.save_budget(budget.to_record("default"))
+    return store
+
+
+@pytest.fixture
+def reviewer(store):
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+
+    def create(**kwargs):
+        return AdmissionReviewer(
+            KubernetesEstimator(registry=registry),
+            BudgetEvaluator(store, clock=lambda: NOW),
+            provider="aws",
+            region="us-east-1",
+            node_instance_type="m5.xlarge",
+            exempt_namespaces=["kube-system"],
+            **kwargs,
+        )
+    return create
+
+
+class TestAdmissionReviewer:
+    """Test cases for AdmissionReviewer class"""
+
+    def test_within_budget_is_allowed(self, reviewer):
+        """Test a small deployment is admitted without warnings"""
+        response = reviewer().review(_review(_deployment(1, cpu="100m", memory="128Mi")))["response"]
+
+        assert response == {"uid": "705ab4f5", "allowed": True}
+
+    def test_warn_mode(self, reviewer):
+        """Test warn mode admits an over-budget deployment with budget warnings"""
+        decision = reviewer().decide(_review(_deployment(3))["request"])
+
+        assert decision.allowed
+        assert decision.result == "warned"
+        assert decision.monthly_delta > 100
+        assert decision.budget_warnings[0].name == "web"
+        assert decision.messages[0].startswith("Deployment api adds $")
+
+    def test_deny_mode(self, reviewer):
+        """Test deny mode rejects a deployment taking projected spend past the budget"""
+        response = reviewer(mode=MODE_DENY).review(_review(_deployment(3)))["response"]
+
+        assert not response["allowed"]
+        assert response["status"]["code"] == 403
+        assert "budget 'web'" in response["status"]["message"]
+
+    def test_update_prices_the_delta(self, reviewer):
+        """Test updates are priced against the old object and scale-downs admitted"""
+        deny = reviewer(mode=MODE_DENY)
+
+        scale_up = deny.decide(_review(_deployment(4), _deployment(3), operation="UPDATE")["request"])
+        assert scale_up.monthly_delta == pytest.approx(scale_up.monthly_cost / 4, rel=1e-3)
+
+        scale_down = deny.decide(_review(_deployment(1), _deployment(3), operation="UPDATE")["request"])
+        assert scale_down.allowed
+        assert scale_down.monthly_delta < 0
+        assert scale_down.budget_warnings == []
+
+    def test_unreviewed_requests(self, reviewer, store):
+        """Test other kinds, deletions, exempt namespaces and budgets of no namespace are left alone"""
+        deny = reviewer(mode=MODE_DENY)
+
+        assert not deny.decide(_review(_deployment(3), kind="ConfigMap")["request"]).reviewed
+        assert not deny.decide(_review(None, _deployment(3), operation="DELETE")["request"]).reviewed
+        assert not deny.decide(_review(_deployment(3, namespace="kube-system"))["request"]).reviewed
+
+        store.save_budget(BudgetSpec(name="tenant", amount=1).to_record("default"))
+        decision = deny.decide(_review(_deployment(3, namespace="api"))["request"])
+        assert decision.allowed and decision.budget_warnings == []
+
+    def test_unpriced_objects_fail_open(self, reviewer):
+        """Test objects that cannot be priced are admitted with a warning"""
+        response = reviewer(mode=MODE_DENY).review(_review(_deployment(3, cpu="lots")))["response"]
+
+        assert response["allowed"]
+        assert response["warnings"][0].startswith("Cost of Deployment api not estimated")
+
+        with pytest.raises(ValueError):
+            reviewer().review({"kind": "Pod"})
@@ -0,0 +1 @@
+"""Tests for allocation module"""
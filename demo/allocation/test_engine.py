@@ -0,0 +1,220 @@
+"""Unit tests for label-based cost allocation"""
+
+from datetime import date, datetime, timezone
+
+import pytest
+
+from src.allocation import (
+    AllocationEngine,
+    parse_label_keys,
+    parse_weights,
+    split_shared,
+    window_days,
+    SPLIT_EVEN,
+    SPLIT_PROPORTIONAL,
+    SPLIT_WEIGHTED,
+)
+from src.store import ActualCostRecord, EstimateRecord, KIND_CLUSTER
+
+NOW = datetime(2026, 7, 31, 12, tzinfo=timezone.utc)
+
+
+def _engine(store, **kwargs):
+    return AllocationEngine(store, clock=lambda: NOW, **kwargs)
+
+
+def _spend(store, amount, labels=None, project=None, usage_date=date(2026, 7, 20)):
+    store.add_actual_costs([ActualCostRecord(
+        usage_date=usage_date,
+        amount=amount,
+        project=project,
+        labels=labels or {},
+        source="aws-cur",
+    )])
+
+
+def _workload(name, namespace, monthly_cost, labels=None, annotations=None):
+    return {
+        "kind": "Deployment",
+        "name": name,
+        "namespace": namespace,
+        "monthly_cost": monthly_cost,
+        "labels": labels or {},
+        "annotations": annotations or {},
+    }
+
+
+def _cluster_estimate(store, result, created_at=datetime(2026, 7, 30, tzinfo=timezone.utc), project="prod"):
+    return store.save_estimate(EstimateRecord(
+        kind=KIND_CLUSTER,
+        project=project,
+        monthly_cost=result.get("monthly_cost", 0.0),
+        request={"hours": 730.0},
+        result=result,
+        created_at=created_at,
+    ))
+
+
+class TestParsing:
+    """Test cases for allocation settings and parameters"""
+
+    def test_label_keys(self):
+        """Test label keys map to their aliases"""
+        keys = parse_label_keys("team=team|owner, cost-center=costcenter")
+        assert keys == {"team": ["team", "owner"], "cost-center": ["costcenter"]}
+
+        with pytest.raises(ValueError):
+            parse_label_keys("team")
+
+    def test_weights(self):
+        """Test weights parse and reject negatives"""
+        assert parse_weights("web=2,data=1") == {"web": 2.0, "data": 1.0}
+
+        with pytest.raises(ValueError):
+            parse_weights("web=-1")
+        with pytest.raises(ValueError):
+            parse_weights("web")
+
+    def test_window(self):
+        """Test windows in days or weeks"""
+        assert window_days("30d") == 30
+        assert window_days("2w") == 14
+
+        for window in ("0d", "30", "1m", "400d"):
+            with pytest.raises(ValueError):
+                window_days(window)
+
+
+class TestSplitShared:
+    """Test cases for split strategies"""
+
+    def test_proportional(self):
+        """Test shares follow direct cost"""
+        shares = split_shared(30.0, {"web": 20.0, "data": 10.0}, SPLIT_PROPORTIONAL)
+        assert shares == pytest.approx({"web": 20.0, "data": 10.0})
+
+    def test_proportional_without_direct_cost_is_even(self):
+        """Test groups without direct cost share evenly"""
+        shares = split_shared(30.0, {"web": 0.0, "data": 0.0}, SPLIT_PROPORTIONAL)
+        assert shares == pytest.approx({"web": 15.0, "data": 15.0})
+
+    def test_even(self):
+        """Test every group gets the same share"""
+        shares = split_shared(30.0, {"web": 20.0, "data": 10.0}, SPLIT_EVEN)
+        assert shares == pytest.approx({"web": 15.0, "data": 15.0})
+
+    def test_weighted(self):
+        """Test weights decide shares and unweighted groups get nothing"""
+        shares = split_shared(30.0, {"web": 20.0, "data": 10.0, "ml": 5.0}, SPLIT_WEIGHTED, {"web": 1, "data": 2})
+        assert shares == pytest.approx({"web": 10.0, "data": 20.0, "ml": 0.0})
+
+        with pytest.raises(ValueError):
+            split_shared(30.0, {"web": 20.0}, SPLIT_WEIGHTED, {"web": 0})
+
+    def test_no_groups(self):
+        """Test nothing is split without groups"""
+        assert split_shared(30.0, {}, SPLIT_EVEN) == {}
+
+
+class TestAllocationEngine:
+    """Test cases for attributing actual and estimated costs"""
+
+    def test_actual_costs_by_label_alias(self, store):
+        """Test billing tags resolve through aliases and untagged spend is shared"""
+        _spend(store, 60.0, {"team": "web"})
+        _spend(store, 20.0, {"owner": "data"})
+        _spend(store, 40.0)
+        _spend(store, 500.0, {"team": "web"}, usage_date=date(2026, 6, 1))
+
+        report = _engine(store).allocate("default", "label:team", window="30d")
+
+        assert report.period_start == date(2026, 7, 1)
+        assert report.period_end == date(2026, 7, 31)
+        assert report.actual_total == pytest.approx(120.0)
+        assert report.actual_shared == pytest.approx(40.0)
+        groups = {g.group: g for g in report.groups}
+        assert groups["web"].actual_direct == pytest.approx(60.0)
+        assert groups["web"].actual_shared == pytest.approx(30.0)
+        assert groups["data"].actual_total == pytest.approx(30.0)
+        assert report.estimate_id is None
+
+    def test_estimated_costs_from_cluster_estimate(self, store):
+        """Test workloads, volumes, load balancers and idle cost of the latest cluster estimate"""
+        _cluster_estimate(store, {"workloads": [], "idle_monthly_cost": 999.0},
+                          created_at=datetime(2026, 7, 10, tzinfo=timezone.utc))
+        record = _cluster_estimate(store, {
+            "workloads": [
+                _workload("api", "web", 73.0, labels={"team": "web"}),
+                _workload("etl", "data", 146.0, annotations={"owner": "data"}),
+                _workload("coredns", "kube-system", 7.3, labels={"team": "platform"}),
+            ],
+            "volumes": [
+                {"name": "db", "namespace": "data", "monthly_cost": 73.0},
+                {"name": "cache", "namespace": "mixed", "monthly_cost": 7.3},
+            ],
+            "load_balancers": [{"name": "api", "namespace": "web", "monthly_cost": 14.6, "labels": {"team": "edge"}}],
+            "idle_monthly_cost": 73.0,
+        })
+
+        report = _engine(store).allocate("default", "label:team", window="10d")
+
+        factor = 10 * 24 / 730
+        assert report.estimate_id == record.id
+        assert report.estimated_idle == pytest.approx(73.0 * factor)
+        assert report.estimated_shared == pytest.approx((73.0 + 7.3 + 7.3) * factor)
+        groups = {g.group: g for g in report.groups}
+        assert set(groups) == {"web", "data", "edge"}
+        assert groups["web"].estimated_direct == pytest.approx(73.0 * factor)
+        assert groups["data"].estimated_direct == pytest.approx((146.0 + 73.0) * factor)
+        assert groups["edge"].estimated_direct == pytest.approx(14.6 * factor)
+        assert sum(g.estimated_total for g in report.groups) == pytest.approx(report.estimated_total, abs=1e-3)
+
+    def test_group_by_namespace(self, store):
+        """Test namespaces group estimates and shared namespaces stay shared"""
+        _cluster_estimate(store, {
+            "workloads": [_workload("api", "web", 73.0), _workload("prometheus", "monitoring", 73.0)],
+            "idle_monthly_cost": 0.0,
+        })
+
+        report = _engine(store).allocate("default", "namespace", window="30d", split=SPLIT_EVEN)
+
+        assert [g.group for g in report.groups] == ["web"]
+        assert report.groups[0].estimated_shared == pytest.approx(report.estimated_shared)
+
+    def test_weighted_split(self, store):
+        """Test weighted groups take the shared spend"""
+        _spend(store, 10.0, {"team": "web"})
+        _spend(store, 10.0, {"team": "data"})
+        _spend(store, 30.0)
+
+        report = _engine(store).allocate(
+            "default", "label:team", split=SPLIT_WEIGHTED, weights=parse_weights("data=1,platform=2")
+        )
+
+        groups = {g.group: g for g in report.groups}
+        assert groups["web"].actual_shared == 0.0
+        assert groups["data"].actual_shared == pytest.approx(10.0)
+        assert groups["platform"].actual_total == pytest.approx(20.0)
+
+    def test_unallocated_without_groups(self, store):
+        """Test shared spend stays unallocated when no group has costs"""
+        _spend(store, 30.0)
+
+        report = _engine(store).allocate("default", "label:team")
+
+        assert report.groups == []
+        assert report.unallocated_actual == pytest.approx(30.0)
+
+    def test_invalid_parameters(self, store):
+        """Test malformed grouping, split and weights are rejected"""
+        engine = _engine(store)
+        with pytest.raises(ValueError):
+            engine.allocate("default", "label:")
+        with pytest.raises(ValueError):
+            engine.allocate("default", "team")
+        with pytest.raises(ValueError):
+            engine.allocate("default", "label:team", split="random")
+        with pytest.raises(ValueError):
+            engine.allocate("default", "label:team", weights={"web": 1.0})
+        with pytest.raises(ValueError):
+            engine.allocate("default", "label:team", split=SPLIT_WEIGHTED)
@@ -0,0 +1,121 @@
+"""Unit tests for spend anomaly detection"""
+
+from datetime import date, datetime, timedelta, timezone
+
+import pytest
+
+from src.anomalies import (
+    AnomalyDetector,
+    score,
+    DETECTOR_ZSCORE,
+    DIMENSION_PROJECT,
+    KIND_NEW_SKU,
+    KIND_SPIKE,
+)
+from src.store import ActualCostRecord
+
+TODAY = date(2026, 7, 1)
+
+
+def _history(store, amounts, **fields):
+    """Daily spend ending yesterday"""
+    start = TODAY - timedelta(days=len(amounts))
+    store.add_actual_costs([
+        ActualCostRecord(usage_date=start + timedelta(days=i), amount=amount, **fields)
+        for i, amount in enumerate(amounts) if amount
+    ])
+
+
+def _detector(store, **kwargs):
+    return AnomalyDetector(store, clock=lambda: datetime(2026, 7, 1, 8, tzinfo=timezone.utc), **kwargs)
+
+
+class TestScore:
+    """Test cases for the detectors"""
+
+    def test_mad_ignores_past_spikes(self):
+        """Test a past spike inflates the z-score's spread but not the MAD's"""
+        baseline = [100.0, 102.0, 98.0, 101.0, 99.0, 400.0, 100.0, 103.0, 97.0, 100.0]
+
+        expected, deviation = score(300.0, baseline, "mad")
+        assert expected == pytest.approx(100.0)
+        assert deviation > 3.5
+
+        _, deviation = score(300.0, baseline, DETECTOR_ZSCORE)
+        assert deviation < 3.5
+
+    def test_flat_baseline(self):
+        """Test a flat baseline scores against 1% of its level"""
+        assert score(101.0, [100.0] * 10, "mad") == (100.0, pytest.approx(1.0))
+
+    def test_unknown_detector(self):
+        with pytest.raises(ValueError):
+            score(1.0, [1.0], "prophet")
+
+
+class TestDetector:
+    """Test cases for spikes and new SKUs"""
+
+    def test_spike(self, store):
+        """Test a spike is flagged per service and per project"""
+        _history(store, [100.0 + i % 3 for i in range(20)] + [400.0], provider="aws", service="compute", project="web")
+
+        report = _detector(store).detect("default")
+        assert report.period_start == date(2026, 6, 24)
+        assert report.period_end == TODAY
+
+        spikes = [a for a in report.anomalies if a.kind == KIND_SPIKE]
+        assert sorted(a.key for a in spikes) == ["aws/compute", "web"]
+        spike = spikes[0]
+        assert spike.date == date(2026, 6, 30)
+        assert spike.cost == 400.0
+        assert spike.expected == pytest.approx(101.0)
+        assert spike.id == f"spike:{spike.dimension}:{spike.key}:2026-06-30"
+
+        only = _detector(store).detect("default", dimensions=[DIMENSION_PROJECT])
+        assert [a.key for a in only.anomalies] == ["web"]
+
+    def test_drop_and_small_increase(self, store):
+        """Test drops and increases below the minimum are not flagged"""
+        _history(store, [10.0] * 20 + [15.0, 10.0, 1.0], project="web")
+
+        assert _detector(store).detect("default").anomalies == []
+        assert len(_detector(store, min_increase=1.0).detect("default").anomalies) == 2
+
+    def test_short_history(self, store):
+        """Test days without enough baseline are not scored"""
+        _history(store, [100.0] * 5 + [1000.0], project="web")
+
+        assert _detector(store).detect("default").anomalies == []
+
+    def test_new_sku(self, store):
+        """Test a SKU first spending in the period is flagged"""
+        _history(store, [100.0] * 20, provider="aws", service="compute", sku="m5.large")
+        _history(store, [50.0, 60.0], provider="aws", service="compute", sku="p4d.24xlarge")
+        _history(store, [5.0], provider="aws", service="compute", sku="t3.micro")
+
+        new = [a for a in _detector(store).detect("default").anomalies if a.kind == KIND_NEW_SKU]
+        assert len(new) == 1
+        assert new[0].key == "aws/compute/p4d.24xlarge"
+        assert new[0].date == date(2026, 6, 29)
+        assert new[0].cost == 50.0
+
+    def test_new_sku_needs_history(self, store):
+        """Test SKUs of a tenant just starting to ingest are not new"""
+        _history(store, [100.0] * 3, sku="m5.large")
+        _history(store, [50.0], sku="p4d.24xlarge")
+
+        assert _detector(store).detect("default").anomalies == []
+
+    def test_invalid_parameters(self, store):
+        detector = _detector(store)
+        with pytest.raises(ValueError):
+            detector.detect("default", days=0)
+        with pytest.raises(ValueError):
+            detector.detect("default", detector="prophet")
+        with pytest.raises(ValueError):
+            detector.detect("default", dimensions=["region"])
+        with pytest.raises(ValueError):
+            detector.detect("default", threshold=0)
+        with pytest.raises(ValueError):
+            AnomalyDetector(store, detector="prophet")
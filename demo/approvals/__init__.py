@@ -0,0 +1 @@
+"""Tests for approvals module"""
@@ -0,0 +1,67 @@
+"""Unit tests for estimate reviews"""
+
+from datetime import datetime, timezone
+
+import pytest
+from pydantic import ValidationError
+
+from src.approvals import CommentSpec, EstimateReviews, DECISION_APPROVED, DECISION_REJECTED, STATUS_PENDING
+from src.notifications import EVENT_ESTIMATE_REVIEWED, Event, event_card
+from src.store import EstimateRecord
+
+
+@pytest.fixture
+def estimate(store):
+    return store.save_estimate(EstimateRecord(
+        tenant_id="acme", kind="resources", project="shop", monthly_cost=258.42,
+    ))
+
+
+class TestEstimateReviews:
+    """Test cases for EstimateReviews class"""
+
+    def test_pending_until_decided(self, store, estimate):
+        """Test comments keep an estimate pending and the latest decision is its status"""
+        reviews = EstimateReviews(store)
+        review = reviews.review("acme", estimate.id)
+        assert (review.status, review.decided_by, review.annotations) == (STATUS_PENDING, None, [])
+
+        review = reviews.comment("acme", estimate.id, "ci", "RDS goes reserved next month")
+        assert review.status == STATUS_PENDING and review.annotations[0].decision is None
+
+        reviews.decide("acme", estimate.id, "lead", DECISION_REJECTED, "over budget")
+        review = reviews.decide("acme", estimate.id, "lead", DECISION_APPROVED)
+        assert (review.status, review.decided_by, review.project) == (DECISION_APPROVED, "lead", "shop")
+        assert [a.decision for a in review.annotations] == [None, DECISION_REJECTED, DECISION_APPROVED]
+        assert review.annotations[1].comment == "over budget"
+        assert review.decided_at == review.annotations[-1].created_at
+
+    def test_unknown_estimate_and_decision(self, store, estimate):
+        """Test estimates of other tenants are not found and decisions are checked"""
+        reviews = EstimateReviews(store)
+        with pytest.raises(LookupError):
+            reviews.comment("globex", estimate.id, "ci", "hello")
+        with pytest.raises(LookupError):
+            reviews.decide("acme", "missing", "lead", DECISION_APPROVED)
+        with pytest.raises(ValueError, match="decision must be one of"):
+            reviews.decide("acme", estimate.id, "lead", "maybe")
+        assert store.list_estimate_annotations(estimate.id) == []
+
+    def test_comment_required(self):
+        """Test empty comments are refused"""
+        with pytest.raises(ValidationError):
+            CommentSpec(comment="")
+
+    def test_review_card(self, store, estimate):
+        """Test rejections are alert cards with the status, cost and reason"""
+        review = EstimateReviews(store).decide("acme", estimate.id, "lead", DECISION_REJECTED, "over budget")
+        card = event_card(Event(
+            type=EVENT_ESTIMATE_REVIEWED,
+            tenant_id="acme",
+            summary="Estimate rejected",
+            data={"review": review.dict(exclude={"annotations"}), "comment": "over budget"},
+            occurred_at=datetime(2026, 1, 15, tzinfo=timezone.utc),
+        ))
+        assert card.title == "Estimate reviewed" and card.alert
+        assert dict(card.facts)["Status"] == "rejected" and dict(card.facts)["By"] == "lead"
+        assert card.lines == ["over budget"]
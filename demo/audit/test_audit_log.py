@@ -0,0 +1,88 @@
+"""Unit tests for the audit log"""
+
+from datetime import datetime, timedelta, timezone
+
+import pytest
+
+from src.audit import AuditLog, audit_target, created_resource_id
+from src.auth import Principal, SCOPE_ADMIN
+from src.store import AuditEventRecord, StoreError
+
+
+def _event(**fields):
+    defaults = {"tenant_id": "acme", "action": "create", "resource_type": "budget", "method": "POST",
+                "path": "/budgets", "status": 200}
+    return AuditEventRecord(**dict(defaults, **fields))
+
+
+class TestAuditTargets:
+    """Test cases for the requests audited"""
+
+    def test_changes_are_audited(self):
+        """Test changing routes map to their resource and action"""
+        assert audit_target("POST", "/discounts") == ("discount_rule", "create", None)
+        assert audit_target("PUT", "/budgets/b1") == ("budget", "update", "b1")
+        assert audit_target("DELETE", "/admin/api-keys/k1") == ("api_key", "revoke", "k1")
+        assert audit_target("PUT", "/admin/tenants/acme/prices") == ("price_overrides", "update", "acme")
+        assert audit_target("POST", "/catalog/custom") == ("price_sheet", "upload", None)
+        assert audit_target("PUT", "/iam/roles/alice") == ("role_assignment", "update", "alice")
+        assert audit_target("PUT", "/profiles/nightly") == ("usage_profile", "update", "nightly")
+        assert audit_target("POST", "/admin/actuals/compact") == ("actual_costs", "compact", None)
+
+    def test_reads_and_estimates_are_not(self):
+        """Test reads and requests computing estimates are not audited"""
+        assert audit_target("GET", "/budgets") is None
+        assert audit_target("POST", "/estimate") is None
+        assert audit_target("POST", "/estimate/terraform") is None
+        assert audit_target("POST", "/webhooks/w1/test") is None
+
+    def test_created_resource_id(self):
+        """Test created resources are named by the resource the response returns"""
+        assert created_resource_id(b'{"budget": {"id": "b1", "name": "prod"}, "timestamp": "t"}') == "b1"
+        assert created_resource_id(b'{"count": 2}') is None
+        assert created_resource_id(b"not json") is None
+
+
+class TestAuditLog:
+    """Test cases for recording and querying audit events"""
+
+    def test_record(self, store):
+        """Test successful changes are recorded with their caller, rejected ones are not"""
+        log = AuditLog(store)
+        principal = Principal(subject="ops", method="api_key", key_id="k1", tenant_id="acme", scopes=[SCOPE_ADMIN])
+
+        event = log.record("POST", "/budgets", 200, "acme", principal, client="10.0.0.1", resource_id="b1")
+        assert (event.actor, event.actor_method, event.resource_id) == ("k1", "api_key", "b1")
+        assert log.record("POST", "/budgets", 422, "acme", principal) is None
+        assert log.record("GET", "/budgets", 200, "acme", principal) is None
+        # Without authentication the caller is unknown
+        assert log.record("DELETE", "/budgets/b1", 200, "acme").actor is None
+
+        events = log.events("acme")
+        assert [(e.action, e.resource_id) for e in events] == [("delete", "b1"), ("create", "b1")]
+        assert log.events("globex") == []
+
+    def test_filters(self, store):
+        """Test events are filtered by caller, resource, action and time, newest first"""
+        first = store.append_audit_event(_event(actor="alice", resource_id="b1"))
+        store.append_audit_event(_event(actor="bob", action="update", path="/budgets/b1", resource_id="b1"))
+        store.append_audit_event(_event(actor="alice", resource_type="webhook", path="/webhooks", resource_id="w1"))
+
+        assert [e.resource_id for e in store.list_audit_events("acme", actor="alice")] == ["w1", "b1"]
+        assert len(store.list_audit_events("acme", resource_type="budget", resource_id="b1")) == 2
+        assert [e.actor for e in store.list_audit_events("acme", action="update")] == ["bob"]
+        assert len(store.list_audit_events("acme", limit=1)) == 1
+        assert store.list_audit_events("acme", until=first.created_at) == []
+        later = datetime.now(timezone.utc) + timedelta(minutes=1)
+        assert store.list_audit_events("acme", since=later) == []
+
+    def test_append_only(self, store):
+        """Test recorded events cannot be changed or removed"""
+        store.append_audit_event(_event())
+        with pytest.raises(StoreError):
+            with store._cursor() as cur:
+                cur.execute("UPDATE audit_events SET actor = 'mallory'")
+        with pytest.raises(StoreError):
+            with store._cursor() as cur:
+                cur.execute("DELETE FROM audit_events")
+        assert len(store.list_audit_events("acme")) == 1
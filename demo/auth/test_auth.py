@@ -0,0 +1,219 @@
+"""Unit tests for API key and OIDC authentication"""
+
+import time
+import types
+
+import pytest
+
+from src.auth import (
+    ApiKeyCreateRequest,
+    Authenticator,
+    AuthError,
+    KeyResolver,
+    OIDCVerifier,
+    RateLimiter,
+    client_address,
+    InvalidTokenError,
+    issue_api_key,
+    parse_static_keys,
+    required_scope,
+    token_scopes,
+    SCOPE_ADMIN,
+    SCOPE_ESTIMATE,
+    SCOPE_READ,
+)
+
+
+class TestScopes:
+    """Test cases for route scopes"""
+
+    def test_required_scope(self):
+        """Test probes are public, reads need read, writes need estimate"""
+        assert required_scope("GET", "/health") is None
+        assert required_scope("OPTIONS", "/estimate") is None
+        assert required_scope("GET", "/estimates") == SCOPE_READ
+        assert required_scope("POST", "/estimate") == SCOPE_ESTIMATE
+        assert required_scope("GET", "/admin/api-keys") == SCOPE_ADMIN
+        assert required_scope("POST", "/catalog/refresh") == SCOPE_ADMIN
+        assert required_scope("GET", "/discounts") == SCOPE_READ
+        assert required_scope("PUT", "/discounts/r1") == SCOPE_ADMIN
+        assert required_scope("GET", "/profiles/business-hours") == SCOPE_READ
+        assert required_scope("DELETE", "/profiles/nightly") == SCOPE_ADMIN
+        assert required_scope("GET", "/pricing/openstack/rates") == SCOPE_READ
+        assert required_scope("PUT", "/pricing/openstack/rates") == SCOPE_ADMIN
+        assert required_scope("POST", "/discountsx") == SCOPE_ESTIMATE
+        assert required_scope("POST", "/budgets") == SCOPE_ESTIMATE
+        assert required_scope("POST", "/actuals") == SCOPE_ADMIN
+        assert required_scope("GET", "/webhooks") == SCOPE_ADMIN
+        assert required_scope("POST", "/webhooks/w1/test") == SCOPE_ADMIN
+        assert required_scope("GET", "/iam/roles") == SCOPE_ADMIN
+        assert required_scope("GET", "/iam/me") == SCOPE_READ
+        assert required_scope("GET", "/audit") == SCOPE_ADMIN
+        assert required_scope("POST", "/grafana/query") == SCOPE_READ
+        assert required_scope("POST", "/estimates/e1/comments") == SCOPE_ESTIMATE
+        assert required_scope("POST", "/estimates/e1/approve") == SCOPE_ADMIN
+        assert required_scope("POST", "/estimates/e1/reject") == SCOPE_ADMIN
+        assert required_scope("GET", "/estimates/e1/review") == SCOPE_READ
+
+    def test_create_request_scopes(self):
+        """Test unknown scopes are rejected and scopes are ordered"""
+        assert ApiKeyCreateRequest(name="ci", scopes=["estimate", "read"]).scopes == ["read", "estimate"]
+        with pytest.raises(ValueError, match="write"):
+            ApiKeyCreateRequest(name="ci", scopes=["write"])
+
+
+class TestApiKeys:
+    """Test cases for static and issued API keys"""
+
+    def test_parse_static_keys(self):
+        """Test name:key:scopes[:limit] entries"""
+        keys = parse_static_keys(["ci:s3cret:read+estimate", "ops:adm1n:admin:600"])
+
+        assert keys["s3cret"].subject == "ci"
+        assert keys["s3cret"].has_scope(SCOPE_ESTIMATE)
+        assert not keys["s3cret"].has_scope(SCOPE_ADMIN)
+        assert keys["adm1n"].has_scope(SCOPE_READ)
+        assert keys["adm1n"].rate_limit == 600
+
+        with pytest.raises(ValueError, match="scopes"):
+            parse_static_keys(["ci:s3cret:write"])
+        with pytest.raises(ValueError, match="expected"):
+            parse_static_keys(["ci"])
+
+    def test_issue_and_revoke(self, store):
+        """Test issued keys resolve until revoked and only hashes are stored"""
+        record, key = issue_api_key(store, "dashboard", [SCOPE_READ], rate_limit=10)
+        resolver = KeyResolver(store=store)
+
+        assert key.startswith(record.prefix)
+        assert key not in str(store.list_api_keys())
+        principal = resolver.resolve(key)
+        assert (principal.subject, principal.key_id, principal.rate_limit) == ("dashboard", record.id, 10)
+
+        assert store.revoke_api_key(record.id).revoked
+        assert resolver.resolve(key) is None
+        assert store.list_api_keys() == []
+        assert len(store.list_api_keys(include_revoked=True)) == 1
+        assert store.revoke_api_key("missing") is None
+
+
+class TestAuthenticator:
+    """Test cases for request authentication"""
+
+    @pytest.fixture
+    def authenticator(self):
+        return Authenticator(
+            keys=KeyResolver(parse_static_keys(["viewer:v1ew:read", "ci:s3cret:estimate:2"])),
+            limiter=RateLimiter(clock=lambda: 0.0),
+        )
+
+    def test_api_key_header_and_bearer(self, authenticator):
+        """Test keys are accepted in X-API-Key and as bearer tokens"""
+        assert authenticator.check(SCOPE_READ, api_key="v1ew").subject == "viewer"
+        assert authenticator.check(SCOPE_READ, authorization="Bearer v1ew").subject == "viewer"
+
+    def test_rejections(self, authenticator):
+        """Test missing, invalid and under-scoped credentials"""
+        for kwargs in ({}, {"api_key": "nope"}, {"authorization": "Basic dmlldzo="}):
+            with pytest.raises(AuthError) as e:
+                authenticator.check(SCOPE_READ, **kwargs)
+            assert e.value.status_code == 401
+
+        with pytest.raises(AuthError) as e:
+            authenticator.check(SCOPE_ESTIMATE, api_key="v1ew")
+        assert e.value.status_code == 403
+
+        with pytest.raises(AuthError, match="OIDC"):
+            authenticator.check(SCOPE_READ, authorization="Bearer a.b.c")
+
+    def test_rate_limit(self, authenticator):
+        """Test per-key limits with Retry-After"""
+        authenticator.check(SCOPE_ESTIMATE, api_key="s3cret")
+        authenticator.check(SCOPE_ESTIMATE, api_key="s3cret")
+        with pytest.raises(AuthError) as e:
+            authenticator.check(SCOPE_ESTIMATE, api_key="s3cret")
+
+        assert e.value.status_code == 429
+        assert e.value.headers["Retry-After"] == "30"
+        # Other keys have their own budget
+        authenticator.check(SCOPE_READ, api_key="v1ew")
+
+
+class TestRateLimiter:
+    """Test cases for token buckets"""
+
+    def test_refill(self):
+        """Test the budget refills at the per-minute rate"""
+        now = [0.0]
+        limiter = RateLimiter(clock=lambda: now[0])
+
+        assert limiter.acquire("k", 1) == 0
+        assert limiter.acquire("k", 1) == pytest.approx(60)
+        now[0] = 60.0
+        assert limiter.acquire("k", 1) == 0
+        assert all(limiter.acquire("k", 0) == 0 for _ in range(100))
+
+    def test_burst(self):
+        """Test a burst smaller than the limit caps the bucket"""
+        now = [0.0]
+        limiter = RateLimiter(clock=lambda: now[0])
+
+        assert [limiter.acquire("k", 60, burst=2) for _ in range(3)] == [0, 0, pytest.approx(1)]
+        now[0] = 600.0
+        # Idle time refills up to the burst only
+        assert [limiter.acquire("k", 60, burst=2) > 0 for _ in range(3)] == [False, False, True]
+
+    def test_max_callers(self):
+        """Test the least recently seen callers are dropped"""
+        limiter = RateLimiter(clock=lambda: 0.0, max_callers=2)
+        for caller in ("a", "b", "c"):
+            limiter.acquire(caller, 1)
+
+        assert len(limiter) == 2
+        # "a" was dropped and starts over with a full bucket
+        assert limiter.acquire("a", 1) == 0
+        assert limiter.acquire("c", 1) > 0
+
+    def test_client_address(self):
+        """Test X-Forwarded-For is read only as far as the trusted proxies"""
+        assert client_address("10.0.0.5") == "10.0.0.5"
+        assert client_address("10.0.0.5", "1.2.3.4") == "10.0.0.5"
+        assert client_address("10.0.0.5", "6.6.6.6, 1.2.3.4", trusted_proxies=1) == "1.2.3.4"
+        assert client_address("10.0.0.5", "6.6.6.6, 1.2.3.4, 10.0.0.9", trusted_proxies=2) == "1.2.3.4"
+        assert client_address("10.0.0.5", "1.2.3.4", trusted_proxies=3) == "1.2.3.4"
+        assert client_address(None) == "unknown"
+
+
+class TestOIDC:
+    """Test cases for bearer token verification"""
+
+    def test_token_scopes(self):
+        """Test scope strings, lists and prefixes"""
+        assert token_scopes({"scope": "openid read estimate"}) == ["read", "estimate"]
+        assert token_scopes({"roles": ["cost:admin", "admin"]}, "roles", "cost:") == ["admin"]
+
+    def test_verify(self):
+        """Test signature, issuer and audience checks"""
+        jwt = pytest.importorskip("jwt")
+        rsa = pytest.importorskip("cryptography.hazmat.primitives.asymmetric.rsa")
+        key = rsa.generate_private_key(public_exponent=65537, key_size=2048)
+
+        verifier = OIDCVerifier(issuer="https://idp.example.com", audience="kcloud-cost-estimator")
+        verifier._jwks_client = types.SimpleNamespace(
+            get_signing_key_from_jwt=lambda token: types.SimpleNamespace(key=key.public_key())
+        )
+        claims = {
+            "sub": "alice",
+            "iss": "https://idp.example.com",
+            "aud": "kcloud-cost-estimator",
+            "exp": int(time.time()) + 300,
+            "scope": "openid read",
+        }
+
+        principal = verifier.verify(jwt.encode(claims, key, algorithm="RS256"))
+        assert (principal.subject, principal.scopes) == ("alice", ["read"])
+
+        with pytest.raises(InvalidTokenError):
+            verifier.verify(jwt.encode({**claims, "aud": "other"}, key, algorithm="RS256"))
+        with pytest.raises(InvalidTokenError):
+            verifier.verify(jwt.encode({**claims, "exp": int(time.time()) - 60}, key, algorithm="RS256"))
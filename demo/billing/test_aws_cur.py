@@ -0,0 +1,208 @@
+"""Unit tests for AWS Cost and Usage Report ingestion"""
+
+import csv
+import gzip
+import io
+import json
+from datetime import date, datetime, timezone
+
+import pytest
+
+from src.billing import (
+    AWSCURIngester,
+    BillingIngestion,
+    IngestRequest,
+    S3ReportSource,
+    classify,
+    csv_rows,
+    parse_cur_rows,
+    select_report_keys,
+    snake_case,
+)
+from src.store import SQLiteStore
+
+JUNE = (date(2026, 6, 1), date(2026, 7, 1))
+
+LEGACY_COLUMNS = [
+    "identity/LineItemId", "lineItem/UsageStartDate", "lineItem/UsageAccountId", "lineItem/ProductCode",
+    "lineItem/UsageType", "lineItem/UsageAmount", "lineItem/UnblendedCost", "lineItem/CurrencyCode",
+    "product/region", "pricing/unit", "resourceTags/user:Project", "resourceTags/user:env",
+]
+
+
+def _legacy_row(start, usage_type, cost, product="AmazonEC2", amount="1", project="web", env="prod"):
+    return dict(zip(LEGACY_COLUMNS, [
+        "id", start, "123456789012", product, usage_type, amount, cost, "USD",
+        "ap-northeast-2", "Hrs", project, env,
+    ]))
+
+
+def _gzipped_csv(rows):
+    buffer = io.StringIO()
+    writer = csv.DictWriter(buffer, fieldnames=LEGACY_COLUMNS)
+    writer.writeheader()
+    writer.writerows(rows)
+    return gzip.compress(buffer.getvalue().encode())
+
+
+class FakeBody:
+    def __init__(self, data):
+        self.data = data
+
+    def read(self):
+        return self.data
+
+
+class FakeS3:
+    """S3 client serving objects from a dict"""
+
+    def __init__(self, objects):
+        self.objects = objects
+
+    def get_paginator(self, operation):
+        assert operation == "list_objects_v2"
+        return self
+
+    def paginate(self, Bucket, Prefix):
+        return [{"Contents": [{"Key": key} for key in sorted(self.objects) if key.startswith(Prefix)]}]
+
+    def get_object(self, Bucket, Key):
+        return {"Body": FakeBody(self.objects[Key])}
+
+
+class TestParsing:
+    """Test cases for CUR row normalization"""
+
+    def test_column_names(self):
+        """Test legacy and Athena column names normalize alike"""
+        assert snake_case("lineItem/UsageStartDate") == "line_item_usage_start_date"
+        assert snake_case("line_item_usage_start_date") == "line_item_usage_start_date"
+
+    def test_classify(self):
+        """Test instance hours and EBS volumes map to estimator services and SKUs"""
+        assert classify("AmazonEC2", "APN2-BoxUsage:m5.large") == ("compute", "m5.large")
+        assert classify("AmazonEC2", "SpotUsage:t3.micro") == ("compute", "t3.micro")
+        assert classify("AmazonEC2", "APN2-EBS:VolumeUsage.gp3") == ("block_storage", "gp3")
+        assert classify("AmazonEC2", "APN2-DataTransfer-Out-Bytes") == ("AmazonEC2", "APN2-DataTransfer-Out-Bytes")
+        assert classify("AmazonS3", "APN2-TimedStorage-ByteHrs") == ("object_storage", "APN2-TimedStorage-ByteHrs")
+
+    def test_legacy_row(self):
+        """Test tags become lower case labels and the project tag names the project"""
+        [line] = parse_cur_rows([_legacy_row("2026-06-03T05:00:00Z", "APN2-BoxUsage:m5.large", "0.118")])
+
+        assert line.usage_date == date(2026, 6, 3)
+        assert (line.service, line.sku, line.region) == ("compute", "m5.large", "ap-northeast-2")
+        assert line.project == "web"
+        assert line.labels == {"project": "web", "env": "prod"}
+        assert line.amount == pytest.approx(0.118)
+
+    def test_cur2_row(self):
+        """Test CUR 2.0 rows with a resource_tags map"""
+        row = {
+            "line_item_usage_start_date": datetime(2026, 6, 3, 5, tzinfo=timezone.utc),
+            "line_item_product_code": "AmazonRDS",
+            "line_item_usage_type": "APN2-InstanceUsage:db.r6g.large",
+            "line_item_net_unblended_cost": 0.2,
+            "line_item_unblended_cost": 0.25,
+            "product_region_code": "ap-northeast-2",
+            "resource_tags": json.dumps({"user_project": "api", "aws_created_by": "x"}),
+        }
+        [line] = parse_cur_rows([row], cost="net_unblended")
+
+        assert line.service == "database"
+        assert line.project == "api"
+        assert line.labels == {"project": "api"}
+        assert line.amount == pytest.approx(0.2)
+
+
+class TestReportFiles:
+    """Test cases for selecting a billing period's report files"""
+
+    def test_manifest_selects_current_assembly(self):
+        """Test only the files of the manifest's assembly are read"""
+        keys = [
+            "cur/r/20260601-20260701/r-Manifest.json",
+            "cur/r/20260601-20260701/old-id/r-00001.csv.gz",
+            "cur/r/20260601-20260701/new-id/r-00001.csv.gz",
+        ]
+        manifests = {keys[0]: ["cur/r/20260601-20260701/new-id/r-00001.csv.gz"]}
+
+        assert select_report_keys(keys, *JUNE, manifests) == [keys[2]]
+
+    def test_parquet_partitions(self):
+        """Test Parquet reports are selected by their year/month or billing period partition"""
+        keys = [
+            "cur/r/r/year=2026/month=6/r-00001.snappy.parquet",
+            "cur/r/r/year=2026/month=5/r-00001.snappy.parquet",
+            "exports/data/BILLING_PERIOD=2026-06/x-00001.snappy.parquet",
+            "cur/r/r/year=2026/month=6/metadata.json",
+        ]
+
+        assert select_report_keys(keys, *JUNE, {}) == [keys[0], keys[2]]
+
+    def test_parquet_rows(self):
+        """Test Parquet report files are read row by row"""
+        pa = pytest.importorskip("pyarrow")
+        import pyarrow.parquet as pq
+        from src.billing import parquet_rows
+
+        buffer = io.BytesIO()
+        pq.write_table(pa.table({"line_item_unblended_cost": [1.5], "line_item_usage_start_date": ["2026-06-01"]}), buffer)
+
+        assert [r["line_item_unblended_cost"] for r in parquet_rows(buffer.getvalue())] == [1.5]
+
+
+class TestIngestion:
+    """Test cases for storing a CUR as actual costs"""
+
+    @pytest.fixture
+    def store(self):
+        store = SQLiteStore(":memory:")
+        store.migrate()
+        return store
+
+    def _objects(self, rows):
+        data_key = "cur/r/20260601-20260701/a1/r-00001.csv.gz"
+        return {
+            "cur/r/20260601-20260701/r-Manifest.json": json.dumps({"reportKeys": [data_key]}).encode(),
+            data_key: _gzipped_csv(rows),
+        }
+
+    def test_hourly_lines_roll_up(self, store):
+        """Test hourly lines are stored as daily costs and re-ingestion replaces them"""
+        s3 = FakeS3(self._objects([
+            _legacy_row("2026-06-03T00:00:00Z", "APN2-BoxUsage:m5.large", "0.118"),
+            _legacy_row("2026-06-03T01:00:00Z", "APN2-BoxUsage:m5.large", "0.118"),
+            _legacy_row("2026-06-04T00:00:00Z", "APN2-BoxUsage:m5.large", "0.118"),
+            _legacy_row("2026-06-04T00:00:00Z", "Credit", "-0.05", product="AWSSupport", project=""),
+        ]))
+        ingestion = BillingIngestion(
+            store, "default", [AWSCURIngester(S3ReportSource("billing", "cur/r", client=s3))],
+            clock=lambda: datetime(2026, 6, 10, tzinfo=timezone.utc),
+        )
+
+        result = ingestion.ingest("aws-cur")
+        assert (result.rows, result.records) == (4, 3)
+        assert result.files == ["cur/r/20260601-20260701/a1/r-00001.csv.gz"]
+        assert result.total_amount == pytest.approx(0.304)
+
+        costs = store.list_actual_costs("default", *JUNE)
+        compute = [c for c in costs if c.service == "compute"]
+        assert [(c.usage_date.day, c.usage_quantity) for c in compute] == [(3, 2.0), (4, 1.0)]
+        assert compute[0].account_id == "123456789012"
+
+        ingestion.ingest("aws-cur", date(2026, 6, 1))
+        assert len(store.list_actual_costs("default", *JUNE)) == 3
+
+    def test_unknown_source(self, store):
+        """Test unconfigured sources and invalid months are rejected"""
+        ingestion = BillingIngestion(store, "default", [])
+        with pytest.raises(KeyError):
+            ingestion.ingest("aws-cur")
+        with pytest.raises(ValueError):
+            IngestRequest(source="aws-cur", month="2026-13")
+
+    def test_csv_rows(self):
+        """Test gzipped CSV files are decompressed"""
+        rows = list(csv_rows(_gzipped_csv([_legacy_row("2026-06-03T00:00:00Z", "BoxUsage:t3.micro", "1")]), "a.csv.gz"))
+        assert rows[0]["lineItem/UsageType"] == "BoxUsage:t3.micro"
@@ -0,0 +1,134 @@
+"""Unit tests for Azure Cost Management export ingestion"""
+
+import json
+from datetime import date, datetime, timezone
+from types import SimpleNamespace
+
+import pytest
+
+from src.billing import (
+    AzureCostExportIngester,
+    BillingIngestion,
+    BlobExportSource,
+    classify_azure,
+    parse_azure_export_rows,
+)
+from src.store import SQLiteStore
+
+JUNE = (date(2026, 6, 1), date(2026, 7, 1))
+PREFIX = "exports/kcloud-costs"
+
+EA_CSV = (
+    "Date,SubscriptionId,MeterCategory,MeterSubCategory,MeterName,ResourceLocation,"
+    "Quantity,UnitOfMeasure,CostInBillingCurrency,BillingCurrencyCode,Tags,AdditionalInfo\n"
+    '06/03/2026,sub-1,Virtual Machines,Dv5 Series,D4s v5,koreacentral,24,1 Hour,9.6,USD,'
+    '"""Project"": ""web""","{""ServiceType"": ""Standard_D4s_v5""}"\n'
+    "06/03/2026,sub-1,Storage,Premium SSD Managed Disks,P10 LRS Disk,koreacentral,1,1/Month,0.7,USD,,\n"
+)
+
+
+def _time(day):
+    return datetime(2026, 6, day, tzinfo=timezone.utc)
+
+
+class FakeContainer:
+    """Container client serving blobs from memory"""
+
+    def __init__(self, blobs):
+        # name -> (last modified, body)
+        self.blobs = blobs
+
+    def list_blobs(self, name_starts_with=None):
+        return [
+            SimpleNamespace(name=name, last_modified=modified)
+            for name, (modified, _) in self.blobs.items()
+            if name.startswith(name_starts_with or "")
+        ]
+
+    def download_blob(self, name):
+        body = self.blobs[name][1]
+        return SimpleNamespace(readall=lambda: body)
+
+
+class TestAzureCostExport:
+    """Test cases for Cost Management export parsing and run selection"""
+
+    def test_classify(self):
+        """Test VMs, managed disks and databases map to estimator services"""
+        assert classify_azure("Virtual Machines", "Dv5 Series", "D4s v5", "Standard_D4s_v5") == (
+            "compute", "Standard_D4s_v5"
+        )
+        assert classify_azure("Storage", "Premium SSD Managed Disks", "P10 LRS Disk")[0] == "block_storage"
+        assert classify_azure("Storage", "Hot Block Blob", "Hot LRS Data Stored")[0] == "object_storage"
+        assert classify_azure("Azure Database for PostgreSQL", "", "vCore")[0] == "database"
+        assert classify_azure("Bandwidth", "", "Standard Data Transfer Out") == (
+            "Bandwidth", "Standard Data Transfer Out"
+        )
+
+    def test_mca_rows(self):
+        """Test MCA column names, ISO dates and the USD cost column are recognized"""
+        [line] = parse_azure_export_rows([{
+            "date": "2026-06-04",
+            "subscriptionGuid": "sub-2",
+            "meterCategory": "SQL Database",
+            "meterName": "vCore",
+            "resourceLocation": "eastus",
+            "quantity": "24",
+            "costInBillingCurrency": "41000",
+            "billingCurrency": "KRW",
+            "costInUsd": "30.5",
+            "tags": '{"Team": "data"}',
+        }])
+
+        assert (line.usage_date, line.service, line.account_id) == (date(2026, 6, 4), "database", "sub-2")
+        assert (line.amount, line.currency) == (pytest.approx(30.5), "USD")
+        assert line.labels == {"team": "data"}
+        assert line.project is None
+
+    def test_latest_run_only(self):
+        """Test only the newest run of the period folder is read"""
+        folder = f"{PREFIX}/20260601-20260630"
+        container = FakeContainer({
+            f"{folder}/run-1/part_0.csv": (_time(2), b""),
+            f"{folder}/run-2/part_0.csv": (_time(3), b""),
+            f"{folder}/run-2/part_1.csv": (_time(3), b""),
+            f"{PREFIX}/20260501-20260531/run-9/part_0.csv": (_time(4), b""),
+        })
+        source = BlobExportSource("costs", PREFIX, client=container)
+
+        assert source.period_blobs(*JUNE) == [f"{folder}/run-2/part_0.csv", f"{folder}/run-2/part_1.csv"]
+
+    def test_manifest(self):
+        """Test a partitioned export's newest manifest names the files read"""
+        folder = f"{PREFIX}/20260601-20260630"
+        manifest = json.dumps({"blobs": [{"blobName": f"{folder}/202606031200/part_1.csv.gz"}]}).encode()
+        container = FakeContainer({
+            f"{folder}/202606021200/manifest.json": (_time(2), b'{"blobs": []}'),
+            f"{folder}/202606031200/manifest.json": (_time(3), manifest),
+            f"{folder}/202606031200/part_1.csv.gz": (_time(3), b""),
+        })
+        source = BlobExportSource("costs", PREFIX, client=container)
+
+        assert source.period_blobs(*JUNE) == [f"{folder}/202606031200/part_1.csv.gz"]
+
+    def test_ingest(self):
+        """Test the latest legacy export file is stored as azure-cost-export actual costs"""
+        store = SQLiteStore(":memory:")
+        store.migrate()
+        folder = f"{PREFIX}/20260601-20260630"
+        container = FakeContainer({
+            f"{folder}/kcloud-costs_old.csv": (_time(2), EA_CSV.replace("9.6", "4.8").encode()),
+            f"{folder}/kcloud-costs_new.csv": (_time(3), EA_CSV.encode()),
+        })
+        ingestion = BillingIngestion(
+            store, "default", [AzureCostExportIngester(BlobExportSource("costs", PREFIX, client=container))],
+            clock=lambda: _time(10),
+        )
+
+        result = ingestion.ingest("azure-cost-export")
+
+        assert result.files == [f"{folder}/kcloud-costs_new.csv"]
+        costs = store.list_actual_costs("default", *JUNE)
+        assert sum(c.amount for c in costs) == pytest.approx(10.3)
+        compute = next(c for c in costs if c.service == "compute")
+        assert (compute.sku, compute.project, compute.region) == ("Standard_D4s_v5", "web", "koreacentral")
@@ -0,0 +1,100 @@
+"""Unit tests for GCP BigQuery billing export ingestion"""
+
+from datetime import date, datetime, timezone
+
+import pytest
+
+from src.billing import (
+    BillingIngestion,
+    GCPBillingExportIngester,
+    classify_gcp,
+    export_query,
+    parse_export_rows,
+)
+from src.store import SQLiteStore
+
+JUNE = (date(2026, 6, 1), date(2026, 7, 1))
+TABLE = "billing-prj.billing.gcp_billing_export_v1_012345"
+
+
+def _row(day, sku, amount, service="Compute Engine", labels='[{"key":"project","value":"web"}]'):
+    return {
+        "usage_date": date(2026, 6, day),
+        "service": service,
+        "sku": sku,
+        "region": "asia-northeast3",
+        "project_id": "web-prod-1234",
+        "labels": labels,
+        "usage_unit": "hour",
+        "currency": "USD",
+        "usage_quantity": 24.0,
+        "amount": amount,
+    }
+
+
+class FakeExport:
+    """Export source answering with fixed daily rows"""
+
+    table = TABLE
+
+    def __init__(self, rows):
+        self._rows = rows
+        self.periods = []
+
+    def rows(self, start, end):
+        self.periods.append((start, end))
+        return iter(self._rows)
+
+
+class TestBigQueryExport:
+    """Test cases for the export query and row normalization"""
+
+    def test_query(self):
+        """Test the query nets out credits and rejects malformed table names"""
+        assert "UNNEST(credits)" in export_query(TABLE)
+        assert "UNNEST(credits)" not in export_query(TABLE, cost="gross")
+        assert f"`{TABLE}`" in export_query(TABLE)
+        with pytest.raises(ValueError):
+            export_query("billing`; DROP TABLE x; --")
+        with pytest.raises(ValueError):
+            export_query(TABLE, cost="amortized")
+
+    def test_classify(self):
+        """Test instance cores, memory and disks map to estimator services"""
+        assert classify_gcp("Compute Engine", "N2 Instance Core running in Seoul")[0] == "compute"
+        assert classify_gcp("Compute Engine", "Balanced PD Capacity in Seoul")[0] == "block_storage"
+        assert classify_gcp("Cloud Storage", "Standard Storage Seoul")[0] == "object_storage"
+        assert classify_gcp("BigQuery", "Analysis") == ("BigQuery", "Analysis")
+
+    def test_rows(self):
+        """Test labels are parsed and the project label names the project"""
+        [line] = parse_export_rows([_row(3, "N2 Instance Core running in Seoul", 12.5)])
+
+        assert (line.provider, line.service, line.region) == ("gcp", "compute", "asia-northeast3")
+        assert line.account_id == "web-prod-1234"
+        assert line.project == "web"
+        assert line.labels == {"project": "web"}
+        assert line.amount == pytest.approx(12.5)
+
+    def test_ingest(self):
+        """Test export rows are stored as the period's gcp-bigquery actual costs"""
+        store = SQLiteStore(":memory:")
+        store.migrate()
+        export = FakeExport([
+            _row(3, "N2 Instance Core running in Seoul", 12.5),
+            _row(3, "N2 Instance Ram running in Seoul", 3.5),
+            _row(4, "Standard Storage Seoul", 0.8, service="Cloud Storage", labels="[]"),
+        ])
+        ingestion = BillingIngestion(
+            store, "default", [GCPBillingExportIngester(export)],
+            clock=lambda: datetime(2026, 6, 10, tzinfo=timezone.utc),
+        )
+
+        result = ingestion.ingest("gcp-bigquery")
+
+        assert export.periods == [JUNE]
+        assert result.files == [TABLE]
+        assert (result.rows, result.records) == (3, 3)
+        costs = store.list_actual_costs("default", *JUNE)
+        assert sum(c.amount for c in costs) == pytest.approx(16.8)
+        assert {c.project for c in costs} == {"web", None}
@@ -0,0 +1,124 @@
+"""Unit tests for actual cost retention"""
+
+from datetime import date, datetime, timedelta, timezone
+
+import pytest
+
+from src.billing import ActualsRetention, roll_up
+from src.store import ActualCostRecord
+
+TODAY = date(2026, 10, 14)
+
+
+def _cost(day, amount, sku="m5.large", region="us-east-1", tenant_id="default", **fields):
+    return ActualCostRecord(
+        tenant_id=tenant_id, usage_date=day, amount=amount, provider="aws", service="compute",
+        region=region, sku=sku, usage_quantity=1.0, usage_unit="Hrs", **fields,
+    )
+
+
+@pytest.fixture
+def retention(store):
+    return ActualsRetention(
+        store, raw_days=30, daily_days=365, monthly_days=0,
+        clock=lambda: datetime(2026, 10, 14, 3, tzinfo=timezone.utc),
+    )
+
+
+class TestRollUp:
+    """Test cases for roll_up"""
+
+    def test_daily(self):
+        """Test SKUs of a day add up while regions and tenants stay apart"""
+        day = date(2026, 6, 3)
+        rollups = roll_up([
+            _cost(day, 1.0), _cost(day, 2.0, sku="m5.xlarge"), _cost(day, 4.0, region="us-west-2"),
+            _cost(day, 8.0, tenant_id="acme"),
+        ], "daily")
+
+        assert [(r.tenant_id, r.region, r.amount) for r in rollups] == [
+            ("acme", "us-east-1", 8.0), ("default", "us-east-1", 3.0), ("default", "us-west-2", 4.0),
+        ]
+        assert all(r.resolution == "daily" and r.sku == "" for r in rollups)
+        assert rollups[1].usage_quantity == 2.0
+
+    def test_monthly(self):
+        """Test the days of a month add up to a cost dated the first"""
+        rollups = roll_up([_cost(date(2026, 6, d), 1.5) for d in (3, 17, 30)] + [_cost(date(2026, 7, 1), 2.0)],
+                          "monthly")
+        assert [(r.usage_date, r.amount, r.resolution) for r in rollups] == [
+            (date(2026, 6, 1), 4.5, "monthly"), (date(2026, 7, 1), 2.0, "monthly"),
+        ]
+
+
+class TestActualsRetention:
+    """Test cases for ActualsRetention"""
+
+    def test_cutoffs(self, retention):
+        """Test raw costs are kept by day and roll-ups by whole month"""
+        assert retention.cutoffs(TODAY) == (date(2026, 9, 14), date(2025, 10, 1), None)
+        assert ActualsRetention(None, 0, 0, 400).cutoffs(TODAY) == (None, None, date(2025, 9, 1))
+
+    def test_invalid_periods(self):
+        """Test negative periods and periods not longer than a shorter one are rejected"""
+        with pytest.raises(ValueError, match="negative"):
+            ActualsRetention(None, raw_days=-1)
+        with pytest.raises(ValueError, match="longer"):
+            ActualsRetention(None, raw_days=90, daily_days=60)
+        ActualsRetention(None, raw_days=0, daily_days=60)
+
+    def test_compact(self, store, retention):
+        """Test aged costs are rolled up without changing their totals"""
+        recent = [_cost(TODAY - timedelta(days=d), 1.0) for d in range(1, 6)]
+        aged = [_cost(date(2026, 8, 20), 1.0), _cost(date(2026, 8, 20), 2.0, sku="m5.xlarge")]
+        old = [_cost(date(2025, 3, d), 0.5) for d in range(1, 32)]
+        store.add_actual_costs(recent + aged + old)
+
+        result = retention.compact()
+        assert (result.raw_compacted, result.daily_written) == (33, 32)
+        assert (result.daily_compacted, result.monthly_written, result.expired) == (31, 1, 0)
+
+        costs = store.list_actual_costs("default", date(2025, 1, 1), TODAY)
+        by_resolution = {}
+        for cost in costs:
+            by_resolution.setdefault(cost.resolution, []).append(cost)
+        assert len(by_resolution["raw"]) == 5
+        assert [(c.usage_date, c.amount, c.sku) for c in by_resolution["daily"]] == [(date(2026, 8, 20), 3.0, "")]
+        assert [(c.usage_date, c.amount) for c in by_resolution["monthly"]] == [(date(2025, 3, 1), 15.5)]
+        assert sum(c.amount for c in costs) == pytest.approx(sum(c.amount for c in recent + aged + old))
+
+        # Compacting again changes nothing
+        retention.compact()
+        assert store.list_actual_costs("default", date(2025, 1, 1), TODAY) == costs
+
+    def test_expire(self, store):
+        """Test costs older than the monthly retention period are deleted"""
+        store.add_actual_costs([_cost(date(2024, 1, 5), 1.0), _cost(date(2026, 1, 5), 1.0)])
+        retention = ActualsRetention(store, raw_days=30, daily_days=365, monthly_days=730)
+
+        result = retention.compact(TODAY)
+        assert result.expire_before == date(2024, 10, 1) and result.expired == 1
+        costs = store.list_actual_costs("default", date(2020, 1, 1), TODAY)
+        assert [(c.usage_date, c.resolution) for c in costs] == [(date(2026, 1, 5), "daily")]
+
+    def test_prorated_months(self, store, retention):
+        """Test a range covering part of a rolled-up month reads its share of the days"""
+        store.add_actual_costs([_cost(date(2025, 6, d), 1.0) for d in range(1, 31)])
+        retention.compact()
+
+        (cost,) = store.list_actual_costs("default", date(2025, 6, 21), date(2025, 7, 1))
+        assert (cost.usage_date, cost.amount, cost.resolution) == (date(2025, 6, 21), 10.0, "monthly")
+        (cost,) = store.list_actual_costs("default", date(2025, 6, 1), date(2025, 6, 16))
+        assert cost.amount == 15.0
+        assert store.list_actual_costs("default", date(2025, 7, 1), date(2025, 8, 1)) == []
+
+    def test_reingested_month_replaces_rollups(self, store, retention):
+        """Test replacing a compacted month restates its roll-ups too"""
+        store.add_actual_costs([_cost(date(2025, 6, d), 1.0, source="aws-cur") for d in range(1, 31)])
+        retention.compact()
+
+        store.replace_actual_costs("default", "aws-cur", date(2025, 6, 1), date(2025, 7, 1), [
+            _cost(date(2025, 6, 2), 7.0, source="aws-cur"),
+        ])
+        costs = store.list_actual_costs("default", date(2025, 6, 1), date(2025, 7, 1))
+        assert [(c.amount, c.resolution) for c in costs] == [(7.0, "raw")]
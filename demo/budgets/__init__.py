@@ -0,0 +1 @@
+"""Tests for budgets module"""
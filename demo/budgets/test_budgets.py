@@ -0,0 +1,130 @@
+"""Unit tests for budgets and budget evaluation"""
+
+from datetime import date, datetime, timezone
+
+import pytest
+
+from src.budgets import (
+    ActualCostEntry,
+    Budget,
+    BudgetEvaluator,
+    BudgetSpec,
+    highest_threshold,
+    month_period,
+)
+from src.store import StoreError
+from src.tenancy import tenant_context
+
+# Ten days into a 30 day month
+NOW = datetime(2026, 6, 11, tzinfo=timezone.utc)
+
+
+@pytest.fixture
+def evaluator(store):
+    return BudgetEvaluator(store, clock=lambda: NOW)
+
+
+def _spend(store, tenant_id, amount, day=5, **fields):
+    store.add_actual_costs([
+        ActualCostEntry(usage_date=date(2026, 6, day), amount=amount, **fields).to_record(tenant_id)
+    ])
+
+
+class TestBudgetSpec:
+    """Test cases for budget definitions"""
+
+    def test_thresholds(self):
+        """Test thresholds default to 50/80/100% and are kept sorted"""
+        assert BudgetSpec(name="team", amount=100).thresholds == [0.5, 0.8, 1.0]
+        assert BudgetSpec(name="team", amount=100, thresholds=[1.2, 0.9, 0.9]).thresholds == [0.9, 1.2]
+        with pytest.raises(ValueError):
+            BudgetSpec(name="team", amount=100, thresholds=[0])
+        with pytest.raises(ValueError):
+            BudgetSpec(name="team", amount=0)
+
+    def test_scope(self):
+        """Test budgets apply to their project and labels only"""
+        tenant = Budget(id="b1", name="all", amount=100)
+        project = Budget(id="b2", name="web", amount=100, project="web", labels={"env": "prod"})
+
+        assert tenant.applies_to(None, {})
+        assert project.applies_to("web", {"env": "prod", "team": "a"})
+        assert not project.applies_to("web", {"env": "dev"})
+        assert not project.applies_to("api", {"env": "prod"})
+
+    def test_highest_threshold(self):
+        """Test the highest reached threshold is reported"""
+        assert highest_threshold([0.5, 0.8, 1.0], 0.85) == 0.8
+        assert highest_threshold([0.5, 0.8, 1.0], 1.5) == 1.0
+        assert highest_threshold([0.5, 0.8, 1.0], 0.2) is None
+
+    def test_month_period(self):
+        """Test the calendar month and the elapsed fraction"""
+        start, end, elapsed = month_period(NOW)
+        assert (start, end) == (date(2026, 6, 1), date(2026, 7, 1))
+        assert elapsed == pytest.approx(10 / 30)
+        assert month_period(datetime(2026, 2, 1, 3, tzinfo=timezone.utc))[2] == pytest.approx(1 / 28)
+
+
+class TestBudgetEvaluator:
+    """Test cases for evaluating spend against budgets"""
+
+    def test_status_projects_run_rate(self, store, evaluator):
+        """Test actual spend is extrapolated to the end of the month"""
+        budget = store.save_budget(BudgetSpec(name="team", amount=1000).to_record("default"))
+        _spend(store, "default", 300)
+        _spend(store, "default", 50, day=30)
+        store.add_actual_costs([
+            ActualCostEntry(usage_date=date(2026, 5, 31), amount=999).to_record("default")
+        ])
+
+        status = evaluator.status(Budget.from_record(budget))
+
+        assert status.actual_to_date == pytest.approx(350)
+        assert status.projected_actual == pytest.approx(1050)
+        assert status.threshold_reached == 1.0
+
+    def test_estimate_warnings(self, store, evaluator):
+        """Test estimates report the budgets their projected spend reaches"""
+        store.save_budget(BudgetSpec(name="web", amount=1000, project="web").to_record("default"))
+        store.save_budget(BudgetSpec(name="prod", amount=10000, labels={"env": "prod"}).to_record("default"))
+        _spend(store, "default", 100, project="web", labels={"env": "prod"})
+
+        assert evaluator.warnings(100, project="web") == []
+
+        warnings = evaluator.warnings(300, project="web", labels={"env": "prod"})
+
+        assert [(w.name, w.threshold) for w in warnings] == [("web", 0.5)]
+        assert warnings[0].projected_actual == pytest.approx(300)
+        assert warnings[0].projected_spend == pytest.approx(600)
+        assert "60%" in warnings[0].message
+
+    def test_budgets_are_per_tenant(self, store, evaluator):
+        """Test only the current tenant's budgets and spend are evaluated"""
+        store.save_budget(BudgetSpec(name="acme", amount=100).to_record("acme"))
+        _spend(store, "globex", 1000)
+
+        assert evaluator.warnings(40) == []
+        with tenant_context("acme"):
+            assert [w.threshold for w in evaluator.warnings(40)] == []
+            assert [w.threshold for w in evaluator.warnings(80)] == [0.8]
+
+    def test_store_failure_is_not_raised(self, store, evaluator, monkeypatch):
+        """Test an unavailable store yields no warnings rather than an error"""
+        def fail(tenant_id):
+            raise StoreError("database down")
+        monkeypatch.setattr(store, "list_budgets", fail)
+
+        assert evaluator.warnings(1000) == []
+
+    def test_replace_and_delete(self, store):
+        """Test budgets are replaced by id within their tenant only"""
+        saved = store.save_budget(BudgetSpec(name="v1", amount=100).to_record("acme"))
+
+        store.save_budget(BudgetSpec(name="v2", amount=200).to_record("acme", budget_id=saved.id))
+        store.save_budget(BudgetSpec(name="hijack", amount=1).to_record("globex", budget_id=saved.id))
+
+        assert [(b.name, b.amount) for b in store.list_budgets("acme")] == [("v2", 200)]
+        assert store.get_budget(saved.id, tenant_id="globex") is None
+        assert store.delete_budget(saved.id, tenant_id="acme") is True
+        assert store.list_budgets("acme") == []
@@ -0,0 +1,198 @@
+"""Unit tests for result caching and entity tags"""
+
+import pytest
+from pydantic import BaseModel
+
+from src.cache import (
+    MemoryCache,
+    RedisCache,
+    ResultCache,
+    TieredCache,
+    etag_matches,
+    json_etag,
+    request_hash,
+)
+
+
+class Clock:
+    def __init__(self):
+        self.now = 0.0
+
+    def __call__(self) -> float:
+        return self.now
+
+
+class FakeRedis:
+    def __init__(self, fail: bool = False):
+        self.data = {}
+        self.fail = fail
+
+    def _check(self):
+        if self.fail:
+            raise ConnectionError("Connection refused")
+
+    def get(self, key):
+        self._check()
+        return self.data.get(key)
+
+    def set(self, key, value, px=None):
+        self._check()
+        self.data[key] = value if isinstance(value, bytes) else str(value).encode()
+
+    def incr(self, key):
+        self._check()
+        value = int(self.data.get(key, b"0")) + 1
+        self.data[key] = str(value).encode()
+        return value
+
+
+class Result(BaseModel):
+    monthly_cost: float
+
+
+class TestMemoryCache:
+    """Test cases for MemoryCache class"""
+
+    def test_values_expire(self):
+        clock = Clock()
+        cache = MemoryCache(clock=clock)
+        cache.set("a", b"1", ttl=10)
+
+        clock.now = 9.9
+        assert cache.get("a") == b"1"
+        clock.now = 10
+        assert cache.get("a") is None
+
+    def test_least_recently_used_are_evicted(self):
+        cache = MemoryCache(max_entries=2)
+        cache.set("a", b"1", 60)
+        cache.set("b", b"2", 60)
+        cache.get("a")
+        cache.set("c", b"3", 60)
+
+        assert (cache.get("a"), cache.get("b"), cache.get("c")) == (b"1", None, b"3")
+        assert len(cache) == 2
+
+    def test_counters(self):
+        cache = MemoryCache()
+        assert cache.counter("generation") == 0
+        assert cache.incr("generation") == 1
+        assert cache.counter("generation") == 1
+
+
+class TestRedisCache:
+    """Test cases for RedisCache class"""
+
+    def test_keys_are_prefixed(self):
+        client = FakeRedis()
+        cache = RedisCache("redis://redis:6379/1", prefix="kc:", client=client)
+        cache.set("a", b"1", 30)
+
+        assert client.data == {"kc:a": b"1"}
+        assert cache.get("a") == b"1"
+        assert cache.incr("gen") == 1
+        assert cache.counter("gen") == 1
+
+    def test_errors_are_misses(self):
+        cache = RedisCache("redis://redis:6379/1", client=FakeRedis(fail=True))
+        cache.set("a", b"1", 30)
+
+        assert cache.get("a") is None
+        assert cache.counter("gen") == 0
+        assert cache.incr("gen") == 0
+
+
+class TestTieredCache:
+    """Test cases for TieredCache class"""
+
+    def test_shared_values_are_kept_locally(self):
+        clock = Clock()
+        shared = RedisCache("redis://redis", client=FakeRedis())
+        shared.set("a", b"1", 300)
+        local = MemoryCache(clock=clock)
+        cache = TieredCache(local, shared, local_ttl=30)
+
+        assert cache.get("a") == b"1"
+        assert local.get("a") == b"1"
+        clock.now = 31
+        assert local.get("a") is None
+        assert cache.get("a") == b"1"
+
+    def test_counters_are_shared(self):
+        shared = MemoryCache()
+        first, second = TieredCache(MemoryCache(), shared), TieredCache(MemoryCache(), shared)
+        first.incr("generation")
+
+        assert second.counter("generation") == 1
+
+
+class TestResultCache:
+    """Test cases for ResultCache class"""
+
+    def test_results_are_cached_per_tenant(self):
+        cache = ResultCache(MemoryCache(), ttl=60)
+        calls = []
+
+        def compute():
+            calls.append(1)
+            return Result(monthly_cost=70.08)
+
+        first = cache.get_or_compute("estimate", "acme", {"region": "us-east-1"}, compute, Result)
+        second = cache.get_or_compute("estimate", "acme", {"region": "us-east-1"}, compute, Result)
+        cache.get_or_compute("estimate", "globex", {"region": "us-east-1"}, compute, Result)
+
+        assert first == second == Result(monthly_cost=70.08)
+        assert len(calls) == 2
+
+    def test_invalidation(self):
+        cache = ResultCache(MemoryCache(), ttl=60)
+        calls = []
+
+        def compute():
+            calls.append(1)
+            return Result(monthly_cost=len(calls))
+
+        for tenant in ("acme", "globex"):
+            cache.get_or_compute("estimate", tenant, {}, compute, Result)
+        cache.invalidate("acme")
+        assert cache.get_or_compute("estimate", "acme", {}, compute, Result).monthly_cost == 3
+        assert cache.get_or_compute("estimate", "globex", {}, compute, Result).monthly_cost == 2
+
+        cache.invalidate()
+        assert cache.get_or_compute("estimate", "globex", {}, compute, Result).monthly_cost == 4
+
+    def test_failures_are_not_cached(self):
+        cache = ResultCache(MemoryCache(), ttl=60)
+
+        def fail():
+            raise ValueError("Unknown instance type")
+
+        for _ in range(2):
+            with pytest.raises(ValueError):
+                cache.get_or_compute("estimate", "acme", {}, fail, Result)
+
+    def test_request_hash_ignores_key_order(self):
+        assert request_hash({"a": 1, "b": [1, 2]}) == request_hash({"b": [1, 2], "a": 1})
+        assert request_hash({"a": 1}) != request_hash({"a": 2})
+
+
+class TestETag:
+    """Test cases for entity tags"""
+
+    def test_timestamp_is_ignored(self):
+        first = json_etag(b'{"estimates": [], "timestamp": "2026-01-01T00:00:00"}')
+        second = json_etag(b'{"timestamp": "2026-01-02T00:00:00", "estimates": []}')
+
+        assert first == second
+        assert first.startswith('"') and first.endswith('"')
+        assert json_etag(b'{"estimates": [1]}') != first
+        assert json_etag(b"not json") is None
+
+    def test_matching(self):
+        etag = json_etag(b'{"a": 1}')
+
+        assert etag_matches(etag, etag)
+        assert etag_matches(f'"other", W/{etag}', etag)
+        assert etag_matches("*", etag)
+        assert not etag_matches('"other"', etag)
+        assert not etag_matches(None, etag)
@@ -0,0 +1,151 @@
+"""Unit tests for distributed locks"""
+
+import threading
+
+from src.cache import MemoryLocks, RedisLocks, build_locks
+
+
+class Clock:
+    def __init__(self):
+        self.now = 0.0
+
+    def __call__(self) -> float:
+        return self.now
+
+
+class FakeRedis:
+    """Redis client implementing SET NX PX and the lock scripts, with expiry on a clock"""
+
+    def __init__(self, clock, fail: bool = False):
+        self.clock = clock
+        self.data = {}
+        self.fail = fail
+        self.lock = threading.Lock()
+
+    def _check(self):
+        if self.fail:
+            raise ConnectionError("Connection refused")
+
+    def _get(self, key):
+        value = self.data.get(key)
+        if value is not None and value[1] <= self.clock():
+            del self.data[key]
+            return None
+        return value
+
+    def set(self, key, value, nx=False, px=None):
+        self._check()
+        with self.lock:
+            if nx and self._get(key) is not None:
+                return None
+            self.data[key] = (value.encode(), self.clock() + px / 1000)
+            return True
+
+    def eval(self, script, numkeys, key, token, *args):
+        self._check()
+        with self.lock:
+            held = self._get(key)
+            if held is None or held[0] != token.encode():
+                return 0
+            if "pexpire" in script:
+                self.data[key] = (held[0], self.clock() + int(args[0]) / 1000)
+            else:
+                del self.data[key]
+            return 1
+
+
+class Settings:
+    lock_redis_url = ""
+    lock_redis_prefix = "kcloud-cost:lock:"
+
+
+class TestMemoryLocks:
+    """Test cases for MemoryLocks class"""
+
+    def test_acquire_until_released_or_expired(self):
+        clock = Clock()
+        locks = MemoryLocks(clock=clock)
+
+        token = locks.acquire("catalog:aws", 10)
+        assert token is not None
+        assert locks.acquire("catalog:aws", 10) is None
+        assert locks.acquire("catalog:gcp", 10) is not None
+
+        locks.release("catalog:aws", "not-the-token")
+        assert locks.acquire("catalog:aws", 10) is None
+        locks.release("catalog:aws", token)
+        assert locks.acquire("catalog:aws", 10) is not None
+
+        clock.now = 10
+        assert locks.claim("catalog:aws", 10)
+
+    def test_extend(self):
+        clock = Clock()
+        locks = MemoryLocks(clock=clock)
+        token = locks.acquire("job:scan", 10)
+
+        clock.now = 9
+        assert locks.extend("job:scan", token, 10)
+        clock.now = 18
+        assert not locks.claim("job:scan", 10)
+        clock.now = 19
+        assert not locks.extend("job:scan", token, 10)
+
+    def test_hold(self):
+        locks = MemoryLocks()
+
+        with locks.hold("catalog:aws", 30) as held:
+            assert held
+            with locks.hold("catalog:aws", 30) as nested:
+                assert not nested
+        assert locks.claim("catalog:aws", 30)
+
+    def test_hold_renews(self):
+        locks = MemoryLocks()
+        renewed = threading.Event()
+        extend = locks.extend
+
+        def spy(key, token, ttl):
+            renewed.set()
+            return extend(key, token, ttl)
+
+        locks.extend = spy
+        with locks.hold("catalog:aws", 0.03) as held:
+            assert held and renewed.wait(1)
+
+
+class TestRedisLocks:
+    """Test cases for RedisLocks class"""
+
+    def test_shared_between_replicas(self):
+        clock = Clock()
+        redis = FakeRedis(clock)
+        first, second = RedisLocks("redis://", client=redis), RedisLocks("redis://", client=redis)
+
+        token = first.acquire("catalog:aws", 5)
+        assert "kcloud-cost:lock:catalog:aws" in redis.data
+        assert second.acquire("catalog:aws", 5) is None
+
+        second.release("catalog:aws", "stolen")
+        assert not second.claim("catalog:aws", 5)
+        assert first.extend("catalog:aws", token, 5)
+        first.release("catalog:aws", token)
+        assert second.claim("catalog:aws", 5)
+
+        clock.now = 5
+        assert first.claim("catalog:aws", 5)
+
+    def test_redis_failure_runs_unguarded(self):
+        redis = FakeRedis(Clock(), fail=True)
+        locks = RedisLocks("redis://", client=redis)
+
+        assert locks.claim("job:scan", 60) and locks.claim("job:scan", 60)
+        with locks.hold("catalog:aws", 60) as held:
+            assert held
+
+    def test_build_locks(self):
+        settings = Settings()
+        assert isinstance(build_locks(settings), MemoryLocks)
+        settings.lock_redis_url = "redis://redis:6379/2"
+        locks = build_locks(settings)
+        assert isinstance(locks, RedisLocks) and locks.prefix == "kcloud-cost:lock:"
@@ -0,0 +1,149 @@
+"""Unit tests for carbon footprint estimation"""
+
+import pytest
+
+from src.carbon import (
+    CarbonEstimator,
+    CarbonIntensity,
+    CarbonIntensityProvider,
+    IntensityNotFoundError,
+    PowerModel,
+    StaticIntensityProvider,
+    get_power_model,
+    parse_intensity_overrides,
+    register_power_model,
+)
+from src.estimator import CostEstimator, EstimateRequest
+from src.pricing import PriceCatalog, ProviderRegistry, StaticProvider
+
+
+class FlakyProvider(CarbonIntensityProvider):
+    """Provider that serves one intensity until told to fail"""
+
+    name = "flaky"
+
+    def __init__(self, grams):
+        self.grams = grams
+        self.failing = False
+        self.calls = 0
+
+    def get_intensity(self, provider, region):
+        self.calls += 1
+        if self.failing:
+            raise ConnectionError("API unreachable")
+        return CarbonIntensity(provider=provider, region=region, grams_per_kwh=self.grams, source=self.name)
+
+
+class TestPowerModels:
+    """Test cases for instance power models"""
+
+    def test_shape_coefficients(self):
+        """Test vCPU, memory and architecture coefficients"""
+        m5 = get_power_model("aws", "m5.large")
+        assert m5.idle_watts == pytest.approx(2 * 0.74 + 8 * 0.392)
+        assert m5.max_watts == pytest.approx(2 * 3.5 + 8 * 0.392)
+        assert m5.watts(0.5) == pytest.approx((m5.idle_watts + m5.max_watts) / 2)
+
+        graviton = get_power_model("aws", "m6g.large")
+        assert graviton.max_watts < m5.max_watts
+
+    def test_registered_model_and_unknown_types(self):
+        """Test measured models take precedence and unknown types raise"""
+        register_power_model("aws", "m5.metal", PowerModel(120.0, 480.0))
+        assert get_power_model("aws", "m5.metal").watts(0.25) == pytest.approx(210.0)
+
+        with pytest.raises(KeyError):
+            get_power_model("aws", "x9.huge")
+        with pytest.raises(KeyError):
+            get_power_model("oracle", "VM.Standard3")
+
+
+class TestIntensityProviders:
+    """Test cases for carbon intensity providers"""
+
+    def test_static_table_and_overrides(self):
+        """Test built-in annual averages, overrides and unknown regions"""
+        provider = StaticIntensityProvider(parse_intensity_overrides("aws:us-east-1=350, gcp:us-west1=90"))
+        assert provider.get_intensity("aws", "us-east-1").grams_per_kwh == 350.0
+        assert provider.get_intensity("aws", "eu-north-1").grams_per_kwh == 8.8
+        assert provider.get_intensity("aws", "eu-north-1").zone == "SE-SE3"
+
+        with pytest.raises(IntensityNotFoundError):
+            provider.get_intensity("aws", "mars-central-1")
+
+    def test_parse_overrides(self):
+        with pytest.raises(ValueError, match="us-east-1"):
+            parse_intensity_overrides("us-east-1=350")
+        assert parse_intensity_overrides("") == {}
+
+
+class TestCarbonEstimator:
+    """Test cases for CarbonEstimator class"""
+
+    @pytest.fixture
+    def estimator(self):
+        return CarbonEstimator([StaticIntensityProvider()], cpu_utilization=0.5)
+
+    @pytest.fixture
+    def registry(self):
+        """Registry pricing m5.large and an instance type without a power model"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider(PriceCatalog({
+            "aws": {
+                "us-east-1": {"m5.large": 0.096, "x1e.xlarge": 0.834},
+                "eu-north-1": {"m5.large": 0.102},
+            },
+        }, storage_prices={})))
+        return registry
+
+    def test_cost_estimate_section(self, estimator, registry):
+        """Test the estimate's carbon section per resource and in total"""
+        costs = CostEstimator(registry=registry, carbon=estimator)
+        result = costs.estimate(EstimateRequest(resources=[
+            {"name": "web", "instance_type": "m5.large", "region": "us-east-1", "count": 2},
+            {"name": "batch", "instance_type": "m5.large", "region": "eu-north-1", "hours": 365},
+        ]))
+
+        web, batch = result.carbon.line_items
+        watts = get_power_model("aws", "m5.large").watts(0.5)
+        assert web.power_watts == pytest.approx(watts, abs=1e-4)
+        assert web.monthly_kwh == pytest.approx(watts * 1.135 * 2 * 730 / 1000, abs=1e-4)
+        assert web.monthly_kg_co2e == pytest.approx(web.monthly_kwh * 379.1 / 1000, abs=1e-3)
+        assert batch.monthly_kg_co2e < web.monthly_kg_co2e / 50
+        assert result.carbon.monthly_kg_co2e == pytest.approx(web.monthly_kg_co2e + batch.monthly_kg_co2e)
+        assert result.carbon.yearly_kg_co2e == pytest.approx(result.carbon.monthly_kg_co2e * 12)
+
+        assert CostEstimator(registry=registry).estimate(
+            EstimateRequest(resources=[{"instance_type": "m5.large", "region": "us-east-1"}])
+        ).carbon is None
+
+    def test_unestimated_resources(self, estimator, registry):
+        """Test resources without a power model or intensity are left out of the totals"""
+        result = CostEstimator(registry=registry, carbon=estimator).estimate(EstimateRequest(resources=[
+            {"instance_type": "m5.large", "region": "us-east-1"},
+            {"name": "memory", "instance_type": "x1e.xlarge", "region": "us-east-1"},
+        ]))
+
+        assert [item.instance_type for item in result.carbon.line_items] == ["m5.large"]
+        assert result.carbon.unestimated == ["memory"]
+
+    def test_provider_fallback_and_cache(self):
+        """Test failing providers fall through, and the last intensity is served while all fail"""
+        live = FlakyProvider(200.0)
+        estimator = CarbonEstimator([live, StaticIntensityProvider()], ttl=0)
+        assert estimator.intensity("aws", "us-east-1").source == "flaky"
+
+        live.failing = True
+        assert estimator.intensity("aws", "us-east-1").source == "static"
+
+        only_live = CarbonEstimator([live], ttl=0)
+        live.failing = False
+        only_live.intensity("aws", "us-east-1")
+        live.failing = True
+        assert only_live.intensity("aws", "us-east-1").grams_per_kwh == 200.0
+        with pytest.raises(IntensityNotFoundError):
+            only_live.intensity("aws", "eu-west-1")
+
+    def test_invalid_utilization(self):
+        with pytest.raises(ValueError):
+            CarbonEstimator([StaticIntensityProvider()], cpu_utilization=1.5)
@@ -0,0 +1,112 @@
+"""Unit tests for Slack and Teams commands"""
+
+import base64
+import time
+
+import pytest
+
+from src.chatops import (
+    CommandHandler,
+    command_text,
+    guess_provider,
+    slack_signature,
+    teams_authorization,
+    verify_slack,
+    verify_teams,
+)
+from src.estimator import CostEstimator
+from src.pricing import PriceCatalog, ProviderRegistry, StaticProvider
+
+SIGNING_SECRET = "8f742231b10e8888abcd99yyyzzz85a5"
+TEAMS_TOKEN = base64.b64encode(b"teams-security-token").decode()
+
+
+@pytest.fixture
+def handler():
+    registry = ProviderRegistry()
+    registry.register(StaticProvider(PriceCatalog({
+        "aws": {"us-east-1": {"m5.large": 0.096, "m5.xlarge": 0.192}, "ap-northeast-2": {"m5.large": 0.118}},
+    }, storage_prices={})))
+    return CommandHandler(CostEstimator(registry), {"aws": "us-east-1", "gcp": "us-central1"})
+
+
+class TestCommands:
+    """Test cases for answering chat commands"""
+
+    def test_estimate(self, handler):
+        """Test an instance type and count are priced in the provider's default region"""
+        card = handler.handle("estimate m5.xlarge x3")
+
+        assert not card.alert
+        assert card.title == "m5.xlarge x3 in aws us-east-1"
+        assert card.summary == "$420.48/month ($0.58/hour)"
+        assert ("Unit price", "$0.1920/hour") in card.facts
+
+    def test_arguments_in_any_order(self, handler):
+        """Test the count, provider, region and hours may follow the instance type in any order"""
+        resource = handler.parse_estimate(["m5.large", "200h", "ap-northeast-2", "2x", "aws"])
+
+        assert (resource.provider, resource.region, resource.count, resource.hours) == (
+            "aws", "ap-northeast-2", 2, 200.0
+        )
+        assert handler.handle("estimate m5.large 200h ap-northeast-2 2x").summary.startswith("$47.20/month")
+
+    def test_errors(self, handler):
+        """Test commands that cannot be answered explain why, suggesting instance types"""
+        card = handler.handle("estimate m5.xlarg")
+        assert card.alert
+        assert card.lines[0] == "Did you mean m5.xlarge, m5.large?"
+
+        assert handler.handle("estimate m5.large x2 3x").summary == "More than one count given: 2 and 3"
+        assert handler.handle("estimate m5.large 800h").summary.startswith("hours:")
+        assert handler.handle("estimate").summary == "Name an instance type to estimate"
+        assert handler.handle("price m5.large").title == "Unknown command"
+        aws_only = CommandHandler(handler.estimator, {"aws": "us-east-1"})
+        assert aws_only.handle("estimate n2-standard-4").summary == "Name a region of gcp"
+
+    def test_help(self, handler):
+        """Test an empty command or help lists examples"""
+        assert handler.handle("").title == "kcost commands"
+        assert handler.handle("help").lines[0] == "/kcost estimate m5.xlarge x3"
+
+    def test_command_text(self):
+        """Test Teams mentions and markup are left out"""
+        assert command_text("<at>kcost</at>&nbsp;estimate <b>m5.large</b> x2\n") == "estimate m5.large x2"
+
+    def test_guess_provider(self):
+        """Test providers are told from instance type names"""
+        assert guess_provider("m5.xlarge") == "aws"
+        assert guess_provider("n2-standard-4") == "gcp"
+        assert guess_provider("e2-medium") == "gcp"
+        assert guess_provider("Standard_D4s_v5") == "azure"
+
+
+class TestSigning:
+    """Test cases for verifying chat platform requests"""
+
+    def test_slack(self):
+        """Test Slack signatures cover the timestamp and body and expire"""
+        body = b"command=%2Fkcost&text=estimate+m5.large"
+        now = int(time.time())
+        signature = slack_signature(SIGNING_SECRET, str(now), body)
+
+        assert signature.startswith("v0=")
+        assert verify_slack(SIGNING_SECRET, str(now), body, signature)
+        assert not verify_slack(SIGNING_SECRET, str(now), body + b"x3", signature)
+        stale = str(now - 600)
+        assert not verify_slack(SIGNING_SECRET, stale, body, slack_signature(SIGNING_SECRET, stale, body))
+        assert not verify_slack("other", str(now), body, signature)
+        assert not verify_slack(SIGNING_SECRET, None, body, signature)
+        assert not verify_slack(SIGNING_SECRET, "soon", body, signature)
+
+    def test_teams(self):
+        """Test Teams signatures are HMACs of the body under the decoded token"""
+        body = b'{"type": "message", "text": "<at>kcost</at> help"}'
+        authorization = teams_authorization(TEAMS_TOKEN, body)
+
+        assert authorization.startswith("HMAC ")
+        assert verify_teams(TEAMS_TOKEN, body, authorization)
+        assert not verify_teams(TEAMS_TOKEN, body + b" ", authorization)
+        assert not verify_teams(base64.b64encode(b"other").decode(), body, authorization)
+        assert not verify_teams("not base64!", body, authorization)
+        assert not verify_teams(TEAMS_TOKEN, body, None)
@@ -0,0 +1,149 @@
+"""Unit tests for CloudFormation template cost estimation"""
+
+import pytest
+
+from src.cloudformation import (
+    UNRESOLVED,
+    CloudFormationEstimateRequest,
+    CloudFormationEstimator,
+    TemplateResolver,
+    load_template,
+    resource_changes,
+)
+from src.pricing import ProviderRegistry, StaticProvider
+from src.terraform import TerraformEstimator
+
+TEMPLATE = """
+AWSTemplateFormatVersion: "2010-09-09"
+Parameters:
+  Env:
+    Type: String
+    Default: dev
+    AllowedValues: [dev, prod]
+  RootSize:
+    Type: Number
+    Default: "50"
+Mappings:
+  Sizes:
+    dev: {Web: t3.small}
+    prod: {Web: m5.large}
+Conditions:
+  IsProd: !Equals [!Ref Env, prod]
+Resources:
+  Subnet:
+    Type: AWS::EC2::Subnet
+    Properties:
+      CidrBlock: 10.0.0.0/24
+  Web:
+    Type: AWS::EC2::Instance
+    Properties:
+      InstanceType: !FindInMap [Sizes, !Ref Env, Web]
+      AvailabilityZone: !Select [0, !GetAZs ""]
+      SubnetId: !Ref Subnet
+      BlockDeviceMappings:
+        - DeviceName: /dev/xvda
+          Ebs: {VolumeSize: !Ref RootSize, VolumeType: gp3}
+  Data:
+    Type: AWS::EC2::Volume
+    Properties:
+      Size: !If [IsProd, 100, 20]
+      AvailabilityZone: !GetAtt Web.AvailabilityZone
+  Database:
+    Type: AWS::RDS::DBInstance
+    Condition: IsProd
+    Properties:
+      DBInstanceClass: db.m5.large
+      Engine: postgres
+      MultiAZ: "true"
+      AllocatedStorage: "100"
+"""
+
+
+def request(**parameters):
+    return CloudFormationEstimateRequest(template=TEMPLATE, parameters=parameters, region="us-east-1")
+
+
+class TestTemplate:
+    """Test cases for reading and resolving templates"""
+
+    def test_short_form_tags(self):
+        """Test YAML short-form tags load as their long-form functions"""
+        template = load_template(TEMPLATE)
+        web = template["Resources"]["Web"]["Properties"]
+        assert web["InstanceType"] == {"Fn::FindInMap": ["Sizes", {"Ref": "Env"}, "Web"]}
+        assert template["Resources"]["Data"]["Properties"]["AvailabilityZone"] == {
+            "Fn::GetAtt": ["Web", "AvailabilityZone"]}
+        assert load_template('{"Resources": {}}') == {"Resources": {}}
+        with pytest.raises(ValueError):
+            load_template({"resource_changes": []})
+
+    def test_functions(self):
+        """Test intrinsic functions resolve against parameters and pseudo parameters"""
+        resolver = TemplateResolver(load_template(TEMPLATE), {"Env": "prod"}, "eu-west-1")
+        assert resolver.resolve({"Fn::Sub": "${AWS::StackName}-${Env}-${!Literal}"}) == "estimate-prod-${Literal}"
+        assert resolver.resolve({"Fn::Sub": ["${Name}.${AWS::URLSuffix}", {"Name": "api"}]}) == "api.amazonaws.com"
+        assert resolver.resolve({"Fn::Join": ["-", ["a", {"Ref": "Env"}]]}) == "a-prod"
+        assert resolver.resolve({"Fn::Select": ["1", {"Fn::Split": [",", "a,b,c"]}]}) == "b"
+        assert resolver.resolve({"Fn::If": ["IsProd", {"Ref": "AWS::Region"}, "other"]}) == "eu-west-1"
+        assert resolver.condition("IsProd") is True
+
+    def test_unresolved_values(self):
+        """Test values known only once the stack is created are left out"""
+        resolver = TemplateResolver(load_template(TEMPLATE), region="us-east-1")
+        assert resolver.resolve({"Ref": "Subnet"}) is UNRESOLVED
+        assert resolver.resolve({"Fn::Join": ["-", ["a", {"Ref": "Subnet"}]]}) is UNRESOLVED
+        assert resolver.resolve({"Fn::Sub": "${Web.PublicIp}:80"}) is UNRESOLVED
+        assert resolver.resolve({
+            "A": {"Fn::GetAtt": ["Web", "Arn"]}, "B": {"Ref": "AWS::NoValue"}, "C": [{"Ref": "Subnet"}, "x"],
+        }) == {"C": ["x"]}
+
+    def test_parameter_errors(self):
+        """Test undeclared, missing and disallowed parameter values are rejected"""
+        template = load_template(TEMPLATE)
+        with pytest.raises(ValueError, match="not declared"):
+            TemplateResolver(template, {"Unknown": "x"})
+        with pytest.raises(ValueError, match="must be one of"):
+            TemplateResolver(template, {"Env": "staging"})
+        with pytest.raises(ValueError, match="no value"):
+            TemplateResolver({"Parameters": {"Key": {"Type": "String"}}, "Resources": {}})
+
+    def test_resource_changes(self):
+        """Test each created logical resource becomes a Terraform-typed creation"""
+        changes, unconverted = resource_changes(request())
+        assert unconverted == []
+        assert [(c.address, c.type) for c in changes] == [
+            ("Subnet", "AWS::EC2::Subnet"), ("Web", "aws_instance"), ("Data", "aws_ebs_volume"),
+        ]
+        web, data = changes[1].after, changes[2].after
+        assert web["instance_type"] == "t3.small"
+        assert web["availability_zone"] == "us-east-1a"
+        assert web["root_block_device"] == [{"volume_size": 50.0, "volume_type": "gp3"}]
+        assert data["size"] == 20.0
+
+        changes, _ = resource_changes(request(Env="prod"))
+        database = changes[-1]
+        assert (database.address, database.type) == ("Database", "aws_db_instance")
+        assert database.after["multi_az"] is True
+        assert database.after["allocated_storage"] == 100.0
+
+
+class TestCloudFormationEstimator:
+    """Test cases for CloudFormationEstimator class"""
+
+    def test_stack_cost(self):
+        """Test logical resources are priced by the Terraform mappers"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        estimator = CloudFormationEstimator(TerraformEstimator(registry=registry))
+        template = {"Resources": {
+            "Web": {"Type": "AWS::EC2::Instance", "Properties": {"InstanceType": "m5.large"}},
+            "Fleet": {"Type": "AWS::EC2::Instance", "Properties": {"LaunchTemplate": {"LaunchTemplateId": "lt-1"}}},
+            "Bucket": {"Type": "AWS::S3::Bucket"},
+        }}
+
+        result = estimator.estimate(CloudFormationEstimateRequest(template=template, region="us-east-1"))
+
+        (web,) = result.added
+        assert (web.address, web.after_monthly_cost) == ("Web", pytest.approx(0.096 * 730))
+        assert {u.address for u in result.unpriced} == {"Fleet", "Bucket"}
+        assert result.monthly_delta == pytest.approx(0.096 * 730)
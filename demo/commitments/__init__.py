@@ -0,0 +1 @@
+"""Tests for commitments module"""
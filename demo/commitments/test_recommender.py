@@ -0,0 +1,126 @@
+"""Unit tests for commitment purchase recommendations"""
+
+from datetime import date, datetime, timedelta, timezone
+
+import pytest
+
+from src.commitments import CommitmentRecommender, CommitmentRequest, unit_hours
+from src.pricing import ProviderRegistry, StaticProvider
+from src.store import ActualCostRecord
+
+TODAY = date(2026, 7, 1)
+
+
+@pytest.fixture
+def recommender(store):
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return CommitmentRecommender(
+        store, registry, clock=lambda: datetime(2026, 7, 1, 8, tzinfo=timezone.utc)
+    )
+
+
+def _usage(store, instances, sku="m5.xlarge", provider="aws", region="us-east-1", unit="Hrs"):
+    """Daily instance hours ending yesterday, one entry per day of running instances"""
+    start = TODAY - timedelta(days=len(instances))
+    store.add_actual_costs([
+        ActualCostRecord(
+            usage_date=start + timedelta(days=i),
+            provider=provider,
+            service="compute",
+            region=region,
+            sku=sku,
+            amount=count * 24 * 0.192,
+            usage_quantity=count * 24,
+            usage_unit=unit,
+        )
+        for i, count in enumerate(instances) if count
+    ])
+
+
+class TestUnitHours:
+    """Test cases for usage units"""
+
+    def test_hour_units(self):
+        """Test AWS and Azure hour units and units that are not hours"""
+        assert unit_hours("Hrs") == 1
+        assert unit_hours("1 Hour") == 1
+        assert unit_hours("10 Hours") == 10
+        assert unit_hours("GB-Mo") is None
+
+
+class TestCommitmentRecommender:
+    """Test cases for CommitmentRecommender class"""
+
+    def test_steady_usage(self, store, recommender):
+        """Test steady usage is committed to up to the coverage target with the option saving the most"""
+        _usage(store, [10] * 30)
+
+        report = recommender.recommend("default", CommitmentRequest())
+
+        rec = report.recommendations[0]
+        assert (rec.type, rec.term, rec.payment_option) == ("reserved", "3yr", "all_upfront")
+        assert rec.count == 8
+        assert rec.coverage == pytest.approx(0.8)
+        assert rec.utilization == pytest.approx(1.0)
+        assert rec.monthly_savings_cost == pytest.approx(8 * 0.192 * 730 * 0.618, rel=1e-3)
+        assert report.on_demand_monthly_cost == pytest.approx(10 * 0.192 * 730)
+        assert report.coverage == pytest.approx(0.8)
+        assert rec.options[0].monthly_savings_cost == rec.monthly_savings_cost
+        assert rec.summary.startswith("buy 8 x m5.xlarge reserved 3yr all_upfront in us-east-1")
+
+    def test_spiky_usage_left_on_demand(self, store, recommender):
+        """Test instances running too few days to pay off are not committed to"""
+        _usage(store, [2] * 20 + [6] * 10)
+
+        report = recommender.recommend(
+            "default", CommitmentRequest(types=["reserved"], terms=["1yr"], payment_options=["no_upfront"])
+        )
+
+        rec = report.recommendations[0]
+        assert rec.count == 2
+        assert rec.utilization == pytest.approx(1.0)
+        assert rec.coverage == pytest.approx(60 / 100)
+
+    def test_break_even_chart(self, store, recommender):
+        """Test the chart holds the upfront fee at month 0 and breaks even when savings turn positive"""
+        _usage(store, [4] * 30)
+
+        report = recommender.recommend(
+            "default",
+            CommitmentRequest(coverage_percent=100, terms=["1yr"], payment_options=["all_upfront"], types=["reserved"]),
+        )
+
+        rec = report.recommendations[0]
+        assert len(rec.break_even) == 13
+        assert rec.break_even[0].commitment_cost == rec.upfront_cost
+        assert rec.break_even[-1].savings_cost == pytest.approx(rec.term_savings_cost, rel=1e-3)
+        month = rec.break_even_month
+        assert rec.break_even[month].savings_cost >= 0 > rec.break_even[month - 1].savings_cost
+        assert month == 7
+
+    def test_unmatched_usage(self, store, recommender):
+        """Test compute usage without an on-demand price is listed and left out"""
+        _usage(store, [4] * 30, sku="N2 Instance Core running in Americas", provider="gcp", region="us-central1")
+
+        report = recommender.recommend("default", CommitmentRequest())
+
+        assert report.recommendations == []
+        assert report.unmatched == ["gcp/us-central1/N2 Instance Core running in Americas"]
+
+    def test_filters(self, store, recommender):
+        """Test the provider filter and the minimum savings"""
+        _usage(store, [1] * 30)
+
+        assert recommender.recommend("default", CommitmentRequest(provider="azure")).recommendations == []
+        assert recommender.recommend("default", CommitmentRequest(min_monthly_savings=1000)).recommendations == []
+        assert len(recommender.recommend("default", CommitmentRequest()).recommendations) == 1
+
+    def test_request_validation(self):
+        """Test unknown commitment types, terms and payment options are rejected"""
+        with pytest.raises(ValueError):
+            CommitmentRequest(types=["lease"])
+        with pytest.raises(ValueError):
+            CommitmentRequest(terms=["5yr"])
+        with pytest.raises(ValueError):
+            CommitmentRequest(payment_options=["monthly"])
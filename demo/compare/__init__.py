@@ -0,0 +1 @@
+"""Tests for cost comparison module"""
@@ -0,0 +1,108 @@
+"""Unit tests for cross-provider cost comparison"""
+
+import pytest
+
+import src.providers  # noqa: F401  (registers GCP machine shapes)
+from src.compare import Comparer, CompareRequest, matching_instance_types, regions_for
+from src.pricing import ProviderRegistry, StaticProvider
+
+
+class TestNormalize:
+    """Test cases for requirement normalization"""
+
+    def test_matching_instance_types(self):
+        """Test shape, architecture and burstable filters"""
+        request = CompareRequest(vcpus=2, memory_gb=8)
+        aws = dict(matching_instance_types("aws", request))
+
+        assert "m5.large" in aws and "r5.large" in aws
+        assert "c5.large" not in aws  # 4 GiB
+        assert "t3.large" not in aws  # burstable excluded by default
+        assert all(shape.vcpus >= 2 and shape.memory_gb >= 8 for shape in aws.values())
+
+        arm = dict(matching_instance_types("gcp", CompareRequest(vcpus=2, memory_gb=8, arch="arm64")))
+        assert arm and all(name.startswith("t2a-") for name in arm)
+
+        burstable = dict(matching_instance_types("aws", CompareRequest(vcpus=2, memory_gb=8, include_burstable=True)))
+        assert "t3.large" in burstable
+
+    def test_regions(self):
+        """Test explicit regions, geographies and the default"""
+        assert regions_for("aws", CompareRequest(vcpus=1, memory_gb=1))[0] == "us-east-1"
+        assert regions_for("azure", CompareRequest(vcpus=1, memory_gb=1, geography="kr")) == ["koreacentral"]
+
+        explicit = CompareRequest(vcpus=1, memory_gb=1, regions={"aws": ["eu-west-1"]})
+        assert regions_for("aws", explicit) == ["eu-west-1"]
+        assert regions_for("gcp", explicit) == []
+
+        with pytest.raises(ValueError, match="mars"):
+            regions_for("aws", CompareRequest(vcpus=1, memory_gb=1, geography="mars"))
+
+    def test_invalid_request(self):
+        """Test request validation"""
+        with pytest.raises(ValueError):
+            CompareRequest(vcpus=2, memory_gb=8, arch="sparc")
+        with pytest.raises(ValueError):
+            CompareRequest(vcpus=2, memory_gb=8, storage_tier="tape")
+
+
+class TestComparer:
+    """Test cases for Comparer class"""
+
+    @pytest.fixture
+    def comparer(self):
+        """Create comparer over the built-in rate card"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        return Comparer(registry=registry)
+
+    def test_side_by_side(self, comparer):
+        """Test options are sorted by monthly cost with the cheapest per provider"""
+        result = comparer.compare(CompareRequest(vcpus=2, memory_gb=8))
+
+        costs = [o.monthly_cost for o in result.options]
+        assert costs == sorted(costs)
+        assert result.cheapest["aws"].instance_type == "m5.large"
+        assert result.cheapest["aws"].monthly_cost == pytest.approx(0.096 * 730)
+        assert result.cheapest["gcp"].instance_type == "e2-standard-2"
+        assert result.cheapest["azure"].instance_type == "Standard_D2s_v3"
+        assert result.options[0].provider == "gcp"
+        assert len([o for o in result.options if o.provider == "aws"]) <= 3
+
+    def test_spot_comparison(self, comparer):
+        """Test spot comparisons only include types with spot prices"""
+        result = comparer.compare(CompareRequest(vcpus=2, memory_gb=8, pricing_model="spot"))
+
+        assert all(o.pricing_model == "spot" for o in result.options)
+        assert result.cheapest["aws"].monthly_cost == pytest.approx(0.0384 * 730)
+        assert result.cheapest["azure"].instance_type == "Standard_D2s_v3"
+
+    def test_storage_and_count(self, comparer):
+        """Test storage tiers are priced per provider and multiplied by count"""
+        result = comparer.compare(CompareRequest(
+            vcpus=2, memory_gb=8, storage_gb=100, count=2, geography="us", providers=["aws", "azure"],
+        ))
+
+        aws = result.cheapest["aws"]
+        assert aws.storage_type == "gp3"
+        assert aws.storage_monthly_cost == pytest.approx(0.08 * 100 * 2)
+        assert aws.monthly_cost == pytest.approx(0.096 * 730 * 2 + 16.0)
+        assert result.cheapest["azure"].storage_type == "E10 LRS"
+
+    def test_unavailable_providers(self, comparer):
+        """Test providers without priced options are reported"""
+        result = comparer.compare(CompareRequest(
+            vcpus=2, memory_gb=8, regions={"aws": ["eu-west-1"], "gcp": ["us-central1"]},
+        ))
+
+        assert "No prices available" in result.unavailable["aws"]
+        assert result.unavailable["azure"] == "No regions allowed"
+        assert "gcp" in result.cheapest
+
+    def test_max_options(self, comparer):
+        """Test options per provider are limited"""
+        result = comparer.compare(CompareRequest(
+            vcpus=2, memory_gb=8, providers=["gcp"], max_options_per_provider=1,
+        ))
+
+        assert [o.instance_type for o in result.options] == ["e2-standard-2"]
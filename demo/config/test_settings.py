@@ -0,0 +1,86 @@
+"""Unit tests for layered settings"""
+
+import os
+
+import pytest
+
+from config.settings import ConfigError, Settings, load_config_file
+
+EXAMPLE_CONFIG = os.path.join(os.path.dirname(__file__), "..", "..", "config", "config.example.yaml")
+
+
+@pytest.fixture(autouse=True)
+def clean_environment(monkeypatch):
+    """Keep settings read by the tests out of the environment"""
+    for name in ("CONFIG_FILE", "API_PORT", "LOG_LEVEL", "STORE_URL", "PRICING_PROVIDERS", "GRPC_ENABLED"):
+        monkeypatch.delenv(name, raising=False)
+
+
+class TestSettings:
+    """Test cases for setting sources and precedence"""
+
+    def test_defaults(self):
+        """Test built-in defaults without a config file"""
+        settings = Settings()
+
+        assert settings.api_port == 8001
+        assert settings.pricing_providers == ["static"]
+        assert "API_PORT" in settings.names
+
+    def test_config_file_sections(self, tmp_path):
+        """Test nested sections, lists and booleans in YAML"""
+        path = tmp_path / "config.yaml"
+        path.write_text(
+            "api:\n  port: 9000\n"
+            "grpc:\n  enabled: false\n"
+            "pricing:\n  providers: [aws, static]\n"
+            "log_level: DEBUG\n"
+        )
+        settings = Settings(config_file=str(path))
+
+        assert settings.api_port == 9000
+        assert settings.grpc_enabled is False
+        assert settings.pricing_providers == ["aws", "static"]
+        assert settings.log_level == "DEBUG"
+
+    def test_precedence(self, tmp_path, monkeypatch):
+        """Test overrides beat environment, which beats the config file"""
+        path = tmp_path / "config.yaml"
+        path.write_text("api:\n  port: 9000\nlog_level: DEBUG\nstore:\n  url: sqlite:///file.db\n")
+        monkeypatch.setenv("CONFIG_FILE", str(path))
+        monkeypatch.setenv("API_PORT", "9100")
+        monkeypatch.setenv("LOG_LEVEL", "WARNING")
+
+        settings = Settings(overrides={"log_level": "ERROR"})
+
+        assert settings.config_file == str(path)
+        assert settings.store_url == "sqlite:///file.db"
+        assert settings.api_port == 9100
+        assert settings.log_level == "ERROR"
+
+    def test_unknown_settings(self, tmp_path):
+        """Test misspelled keys are rejected"""
+        path = tmp_path / "config.yaml"
+        path.write_text("api:\n  prot: 9000\n")
+
+        with pytest.raises(ConfigError, match="API_PROT"):
+            Settings(config_file=str(path))
+        with pytest.raises(ConfigError, match="NOPE"):
+            Settings(overrides={"nope": 1})
+
+    def test_invalid_config_file(self, tmp_path):
+        """Test unreadable and non-mapping files"""
+        with pytest.raises(ConfigError, match="Cannot read"):
+            Settings(config_file=str(tmp_path / "missing.yaml"))
+
+        path = tmp_path / "list.yaml"
+        path.write_text("- a\n- b\n")
+        with pytest.raises(ConfigError, match="mapping"):
+            load_config_file(str(path))
+
+    def test_example_config(self):
+        """Test the shipped example only names known settings"""
+        settings = Settings(config_file=EXAMPLE_CONFIG)
+
+        assert settings.pricing_providers == ["aws", "gcp", "azure", "static"]
+        assert settings.gcp_billing_api_key == ""
@@ -0,0 +1,13 @@
+"""Fixtures shared by the test suite"""
+
+import pytest
+
+from src.store import SQLiteStore
+
+
+@pytest.fixture
+def store():
+    """Empty in-memory store with the current schema"""
+    store = SQLiteStore(":memory:")
+    store.migrate()
+    return store
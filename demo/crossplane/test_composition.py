@@ -0,0 +1,194 @@
+"""Unit tests for Crossplane cost estimation"""
+
+import pytest
+
+from src.crossplane import (
+    CompositionCatalog,
+    CrossplaneEstimateRequest,
+    CrossplaneEstimator,
+    apply_patches,
+    apply_transforms,
+    load_compositions,
+    terraform_type,
+)
+from src.pricing import ProviderRegistry, StaticProvider
+from src.terraform import TerraformEstimator
+
+DEFINITION = """
+apiVersion: apiextensions.crossplane.io/v1
+kind: CompositeResourceDefinition
+metadata:
+  name: xdatabases.platform.example.org
+spec:
+  group: platform.example.org
+  names: {kind: XDatabase, plural: xdatabases}
+  claimNames: {kind: Database, plural: databases}
+"""
+
+COMPOSITION = """
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+metadata:
+  name: database-aws
+spec:
+  compositeTypeRef: {apiVersion: platform.example.org/v1alpha1, kind: XDatabase}
+  patchSets:
+    - name: region
+      patches:
+        - fromFieldPath: spec.parameters.region
+          toFieldPath: spec.forProvider.region
+  resources:
+    - name: rds
+      base:
+        apiVersion: rds.aws.upbound.io/v1beta1
+        kind: Instance
+        spec:
+          forProvider: {engine: postgres, instanceClass: db.t3.micro, allocatedStorage: 20}
+      patches:
+        - type: PatchSet
+          patchSetName: region
+        - fromFieldPath: spec.parameters.size
+          toFieldPath: spec.forProvider.instanceClass
+          transforms:
+            - type: map
+              map: {small: db.t3.medium, large: db.m5.large}
+        - fromFieldPath: spec.parameters.storageGB
+          toFieldPath: spec.forProvider.allocatedStorage
+    - name: bucket
+      base:
+        apiVersion: s3.aws.upbound.io/v1beta1
+        kind: Bucket
+        spec: {forProvider: {}}
+      patches:
+        - type: PatchSet
+          patchSetName: region
+"""
+
+CLAIM = """
+apiVersion: platform.example.org/v1alpha1
+kind: Database
+metadata: {name: orders, namespace: shop}
+spec:
+  parameters: {region: us-east-1, size: large, storageGB: 100}
+"""
+
+
+@pytest.fixture
+def estimator():
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return CrossplaneEstimator(TerraformEstimator(registry=registry))
+
+
+class TestManagedResources:
+    """Test cases for typing managed resources"""
+
+    def test_types(self):
+        """Test managed resource kinds map to the Terraform resources they are generated from"""
+        assert terraform_type("ec2.aws.upbound.io/v1beta1", "Instance") == "aws_instance"
+        assert terraform_type("ec2.aws.upbound.io/v1beta1", "EBSVolume") == "aws_ebs_volume"
+        assert terraform_type("rds.aws.upbound.io/v1beta1", "Instance") == "aws_db_instance"
+        assert terraform_type("eks.aws.upbound.io/v1beta1", "NodeGroup") == "aws_eks_node_group"
+        assert terraform_type("compute.gcp.upbound.io/v1beta1", "Instance") == "google_compute_instance"
+        assert terraform_type("compute.azure.upbound.io/v1beta1", "LinuxVirtualMachine") == \
+            "azurerm_linux_virtual_machine"
+        assert terraform_type("ec2.aws.m.upbound.io/v1beta1", "Instance") == "aws_instance"
+        assert terraform_type("s3.aws.upbound.io/v1beta1", "Bucket") is None
+        assert terraform_type("apps/v1", "Deployment") is None
+
+
+class TestPatches:
+    """Test cases for patches and transforms"""
+
+    def test_transforms(self):
+        """Test the supported transforms apply in order"""
+        assert apply_transforms([{"type": "map", "map": {"small": "t3.small"}}], "small") == "t3.small"
+        assert apply_transforms([
+            {"type": "math", "math": {"type": "Multiply", "multiply": 1024}},
+            {"type": "convert", "convert": {"toType": "string"}},
+            {"type": "string", "string": {"type": "Format", "fmt": "%sMi"}},
+        ], 2) == "2048Mi"
+        match = {"type": "match", "match": {"patterns": [
+            {"type": "regexp", "regexp": "^prod", "result": "m5.large"}], "fallbackValue": "t3.small"}}
+        assert apply_transforms([match], "production") == "m5.large"
+        assert apply_transforms([match], "dev") == "t3.small"
+        with pytest.raises(ValueError):
+            apply_transforms([{"type": "map", "map": {}}], "huge")
+
+    def test_patches(self):
+        """Test composite fields are patched into list items, combined, and missing optional ones skipped"""
+        composite = {"metadata": {"labels": {"team": "shop"}}, "spec": {"size": 40, "env": "dev"}}
+        resource = {"spec": {"forProvider": {"engine": "mysql"}}}
+        apply_patches(composite, resource, [
+            {"fromFieldPath": "spec.size", "toFieldPath": "spec.forProvider.rootBlockDevice[0].volumeSize"},
+            {"type": "CombineFromComposite", "toFieldPath": "spec.forProvider.tags[Name]", "combine": {
+                "variables": [{"fromFieldPath": "metadata.labels[team]"}, {"fromFieldPath": "spec.env"}],
+                "strategy": "string", "string": {"fmt": "%s-%s"},
+            }},
+            {"fromFieldPath": "spec.missing", "toFieldPath": "spec.forProvider.engine"},
+        ])
+        assert resource["spec"]["forProvider"] == {
+            "engine": "mysql", "rootBlockDevice": [{"volumeSize": 40}], "tags": {"Name": "shop-dev"},
+        }
+        with pytest.raises(ValueError, match="Required"):
+            apply_patches(composite, resource, [{
+                "fromFieldPath": "spec.missing", "toFieldPath": "spec.x", "policy": {"fromFieldPath": "Required"},
+            }])
+
+
+class TestCrossplaneEstimator:
+    """Test cases for CrossplaneEstimator class"""
+
+    def test_claim(self, estimator):
+        """Test a claim is priced by the managed resources its composition composes"""
+        request = CrossplaneEstimateRequest(manifests=[DEFINITION, COMPOSITION, CLAIM])
+
+        result = estimator.estimate(request)
+
+        (rds,) = result.added
+        assert (rds.address, rds.type) == ("Database/shop/orders/rds", "aws_db_instance")
+        assert rds.components[0].sku == "db.m5.large"
+        (bucket,) = result.unpriced
+        assert (bucket.address, bucket.type) == ("Database/shop/orders/bucket", "Bucket.s3.aws.upbound.io")
+
+    def test_managed_resources(self, estimator):
+        """Test submitted managed resources are priced, observe-only ones left out"""
+        manifests = """
+apiVersion: ec2.aws.upbound.io/v1beta1
+kind: Instance
+metadata: {name: bastion}
+spec:
+  forProvider: {region: us-east-1, instanceType: m5.large}
+---
+apiVersion: ec2.aws.upbound.io/v1beta1
+kind: EBSVolume
+metadata: {name: imported}
+spec:
+  managementPolicies: [Observe]
+  forProvider: {availabilityZone: us-east-1a, size: 100}
+---
+apiVersion: v1
+kind: Namespace
+metadata: {name: shop}
+"""
+        result = estimator.estimate(CrossplaneEstimateRequest(manifests=manifests))
+
+        (bastion,) = result.added
+        assert bastion.address == "Instance/bastion"
+        assert bastion.after_monthly_cost == pytest.approx(0.096 * 730)
+        assert result.unpriced == []
+
+    def test_configured_compositions(self, estimator, tmp_path):
+        """Test claims are rendered with configured compositions, and unrendered claims reported"""
+        (tmp_path / "database.yaml").write_text(DEFINITION + "---" + COMPOSITION)
+        estimator.compositions = load_compositions(str(tmp_path))
+        assert isinstance(estimator.compositions, CompositionCatalog)
+
+        result = estimator.estimate(CrossplaneEstimateRequest(manifests=CLAIM))
+        assert [change.address for change in result.added] == ["Database/shop/orders/rds"]
+
+        claim = CLAIM.replace("size: large", "size: huge")
+        result = estimator.estimate(CrossplaneEstimateRequest(manifests=claim))
+        (orders,) = result.unpriced
+        assert orders.address == "Database/shop/orders"
+        assert "map transform" in orders.reason
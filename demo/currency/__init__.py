@@ -0,0 +1 @@
+"""Tests for currency conversion module"""
@@ -0,0 +1,163 @@
+"""Unit tests for currency conversion"""
+
+import time
+from datetime import datetime, timezone
+
+import pytest
+
+from src.currency import (
+    CurrencyConverter,
+    RateSource,
+    StaticRateSource,
+    UnsupportedCurrencyError,
+    convert_amounts,
+    parse_ecb_rates,
+    parse_static_rates,
+)
+from src.estimator import CostEstimator, EstimateRequest
+from src.pricing import ProviderRegistry, StaticProvider
+from src.responses import EstimateResponse
+
+ECB_DOCUMENT = """<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+  <gesmes:subject>Reference rates</gesmes:subject>
+  <Cube>
+    <Cube time="2024-05-10">
+      <Cube currency="USD" rate="1.0772"/>
+      <Cube currency="JPY" rate="167.63"/>
+      <Cube currency="KRW" rate="1474.56"/>
+    </Cube>
+  </Cube>
+</gesmes:Envelope>
+"""
+
+
+class FlakySource(RateSource):
+    """Source that serves its table until told to fail"""
+
+    name = "flaky"
+
+    def __init__(self, rates):
+        self.table = StaticRateSource(rates).fetch()
+        self.failing = False
+        self.calls = 0
+
+    def fetch(self):
+        self.calls += 1
+        if self.failing:
+            raise ConnectionError("feed unreachable")
+        return self.table
+
+
+class TestSources:
+    """Test cases for exchange rate sources"""
+
+    def test_parse_static_rates(self):
+        """Test CODE=rate lists"""
+        assert parse_static_rates("eur=0.92, KRW=1380,,JPY=150") == {"EUR": 0.92, "KRW": 1380.0, "JPY": 150.0}
+
+        with pytest.raises(ValueError, match="KRW"):
+            parse_static_rates("EUR=0.92,KRW")
+
+    def test_parse_ecb_rates(self):
+        """Test euro reference rates are rebased on USD"""
+        table = parse_ecb_rates(ECB_DOCUMENT)
+
+        assert table.source == "ecb"
+        assert table.as_of == datetime(2024, 5, 10, tzinfo=timezone.utc)
+        assert table.rate("EUR") == pytest.approx(1 / 1.0772)
+        assert table.rate("JPY") == pytest.approx(167.63 / 1.0772)
+        assert table.rate("USD") == 1.0
+        assert "USD" not in table.rates
+
+    def test_parse_ecb_rates_without_usd(self):
+        """Test a feed without USD cannot be rebased"""
+        with pytest.raises(ValueError, match="USD"):
+            parse_ecb_rates(ECB_DOCUMENT.replace('<Cube currency="USD" rate="1.0772"/>', ""))
+
+
+class TestConverter:
+    """Test cases for result conversion"""
+
+    def test_convert_amounts(self):
+        """Test only monetary fields are converted"""
+        result = {
+            "line_items": [{"unit_price_hourly": 0.1, "hours": 730, "count": 2, "monthly_cost": 146.0}],
+            "discounts": [{"rate": 0.2, "monthly_amount": 10.0}],
+            "break_even_utilization": 0.6,
+            "monthly_cost": 146.0,
+            "currency": "USD",
+        }
+        converted = convert_amounts(result, 2.0, "EUR")
+
+        assert converted["line_items"][0] == {"unit_price_hourly": 0.2, "hours": 730, "count": 2, "monthly_cost": 292.0}
+        assert converted["discounts"][0] == {"rate": 0.2, "monthly_amount": 20.0}
+        assert converted["break_even_utilization"] == 0.6
+        assert convert_amounts({"monthly_cost": 1.23456, "unit_price": 0.123456}, 1.0, "EUR") == {
+            "monthly_cost": 1.23, "unit_price": 0.1235,
+        }
+        assert converted["currency"] == "EUR"
+        assert result["monthly_cost"] == 146.0
+
+    def test_convert_estimate(self):
+        """Test every monetary field of an estimate converts with one rate"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        estimator = CostEstimator(registry=registry)
+        result = estimator.estimate(EstimateRequest(resources=[
+            {"instance_type": "m5.large", "region": "us-east-1", "count": 2},
+        ])).dict()
+        converter = CurrencyConverter(StaticRateSource({"KRW": 1380.0}))
+
+        converted, exchange_rate = converter.convert(result, "krw")
+
+        assert exchange_rate["currency"] == "KRW" and exchange_rate["rate"] == 1380.0
+        assert exchange_rate["base"] == "USD" and exchange_rate["source"] == "static"
+        assert converted["currency"] == "KRW"
+        # $140.16 is 193420.8 won, rounded to whole won
+        assert result["monthly_cost"] == 140.16 and converted["monthly_cost"] == 193421.0
+        item, original = converted["line_items"][0], result["line_items"][0]
+        assert item["unit_price_hourly"] == pytest.approx(original["unit_price_hourly"] * 1380.0)
+        assert item["count"] == original["count"] and item["hours"] == original["hours"]
+
+        # The converted result still matches the documented response model
+        response = EstimateResponse(estimate=converted, exchange_rate=exchange_rate, timestamp="now")
+        assert response.exchange_rate.as_of.tzinfo is not None
+
+    def test_unsupported_currency(self):
+        """Test unknown currencies list the available ones"""
+        converter = CurrencyConverter(StaticRateSource({"EUR": 0.92}))
+
+        with pytest.raises(UnsupportedCurrencyError, match="EUR, USD"):
+            converter.convert({"monthly_cost": 1.0}, "GBP")
+
+        converted, exchange_rate = converter.convert({"monthly_cost": 1.0, "currency": "USD"}, "USD")
+        assert converted["monthly_cost"] == 1.0 and exchange_rate["rate"] == 1.0
+
+    def test_rates_cached_and_kept_on_failure(self, monkeypatch):
+        """Test rates are reused within the TTL and kept when the source fails"""
+        now = [1_000_000.0]
+        monkeypatch.setattr(time, "time", lambda: now[0])
+        source = FlakySource({"EUR": 0.9})
+        converter = CurrencyConverter(source, ttl=60)
+
+        assert converter.rate("EUR")[0] == 0.9
+        converter.rate("EUR")
+        assert source.calls == 1
+
+        now[0] += 120
+        source.failing = True
+        assert converter.rate("EUR")[0] == 0.9
+        assert source.calls == 2
+
+    def test_fallback_source(self):
+        """Test the fallback serves rates when the primary never loaded"""
+        primary = FlakySource({"EUR": 0.9})
+        primary.failing = True
+        converter = CurrencyConverter(primary, fallback=StaticRateSource({"EUR": 0.95}))
+
+        rate, table = converter.rate("EUR")
+        assert rate == 0.95 and table.source == "static"
+
+        with pytest.raises(UnsupportedCurrencyError):
+            CurrencyConverter(primary).rate("EUR")
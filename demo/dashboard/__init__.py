@@ -0,0 +1 @@
+"""Tests for dashboard module"""
@@ -0,0 +1,31 @@
+"""Unit tests for the dashboard page"""
+
+from src.auth import required_scope
+from src.dashboard import DASHBOARD_PATH, dashboard_html, wants_dashboard
+
+BROWSER_ACCEPT = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8"
+
+
+class TestDashboard:
+    """Test cases for the dashboard page"""
+
+    def test_page_is_bundled(self):
+        """Test the page ships with the package and reads the panels' APIs"""
+        page = dashboard_html()
+
+        assert page.startswith("<!DOCTYPE html>")
+        for path in ("/reports/chargeback/", "/budgets", "/estimates?limit=", "/recommendations/idle"):
+            assert path in page
+        assert "<script src=" not in page and "<link " not in page
+
+    def test_browsers_get_the_dashboard(self):
+        """Test / serves the dashboard to browsers and JSON to API clients"""
+        assert wants_dashboard(BROWSER_ACCEPT)
+        assert not wants_dashboard("application/json")
+        assert not wants_dashboard("*/*")
+        assert not wants_dashboard(None)
+
+    def test_page_is_public(self):
+        """Test the page needs no credentials while the APIs it calls do"""
+        assert required_scope("GET", DASHBOARD_PATH) is None
+        assert required_scope("GET", "/budgets") is not None
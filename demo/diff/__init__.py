@@ -0,0 +1 @@
+"""Tests for diff module"""
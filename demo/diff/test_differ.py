@@ -0,0 +1,141 @@
+"""Unit tests for estimate diffs"""
+
+import pytest
+
+from src.diff import DiffRequest, DiffThresholds, EstimateDiffer, parse_threshold
+from src.estimator import CostEstimator, EstimateRequest
+from src.k8s import KubernetesEstimator
+from src.pricing import ProviderRegistry, StaticProvider
+from src.store import KIND_HELM, EstimateRecord
+from src.store.history import KIND_RESOURCES
+from src.terraform import TerraformEstimator
+
+from ..terraform.test_plan import change, make_plan
+
+WEB = change("aws_instance.web", "aws_instance", ["update"],
+             before={"instance_type": "m5.large"}, after={"instance_type": "m5.large"})
+
+
+@pytest.fixture
+def differ(store):
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return EstimateDiffer(
+        CostEstimator(registry=registry),
+        KubernetesEstimator(registry=registry),
+        TerraformEstimator(registry=registry),
+        store=store,
+        thresholds=DiffThresholds(max_increase_percent=10),
+    )
+
+
+def _plan(*changes):
+    return make_plan(*changes, regions={"aws": "us-east-1"})
+
+
+class TestEstimateDiffer:
+    """Test cases for EstimateDiffer class"""
+
+    def test_terraform_plans(self, differ):
+        """Test resources only one plan changes keep their current cost on the other side"""
+        base = _plan(
+            WEB,
+            change("aws_instance.batch", "aws_instance", ["create"], after={"instance_type": "c5.large"}),
+        )
+        head = _plan(
+            change("aws_instance.web", "aws_instance", ["update"],
+                   before={"instance_type": "m5.large"}, after={"instance_type": "m5.2xlarge"}),
+            change("aws_instance.api", "aws_instance", ["create"], after={"instance_type": "m5.large"}),
+        )
+
+        result = differ.diff(DiffRequest(
+            base={"label": "main", "terraform": base}, head={"label": "PR #42", "terraform": head},
+        ))
+
+        changes = {c.key: c for c in result.changes}
+        assert changes["aws_instance.web"].monthly_delta == pytest.approx((0.384 - 0.096) * 730)
+        assert changes["aws_instance.api"].change == "added"
+        assert changes["aws_instance.batch"].change == "removed"
+        assert result.base_monthly_cost == pytest.approx((0.096 + 0.085) * 730)
+        assert result.head_monthly_cost == pytest.approx((0.384 + 0.096) * 730)
+        assert [c.key for c in result.changes][0] == "aws_instance.web"
+
+        assert not result.passed
+        assert result.failures == [f"Monthly cost grows by {result.delta_percent:.1f}%, more than 10%"]
+        assert "### Cost diff: ❌ failed" in result.markdown
+        assert "| PR #42 | 350.40 USD |" in result.markdown
+        assert "`aws_instance.web` (changed)" in result.markdown
+
+    def test_pulumi_previews(self, differ):
+        """Test previews are diffed resource by resource like plans"""
+        urn = "urn:pulumi:dev::shop::aws:ec2/instance:Instance::web"
+
+        def preview(instance_type):
+            return {"config": {"aws:region": "us-east-1"}, "steps": [{
+                "op": "create", "urn": urn,
+                "newState": {"type": "aws:ec2/instance:Instance", "inputs": {"instanceType": instance_type}},
+            }]}
+
+        result = differ.diff(DiffRequest(base={"pulumi": preview("m5.large")}, head={"pulumi": preview("m5.xlarge")}))
+
+        assert result.kind == "pulumi"
+        (web,) = result.changes
+        assert web.key == "aws:ec2/instance:Instance::web"
+        assert web.monthly_delta == pytest.approx((0.192 - 0.096) * 730)
+
+    def test_request_thresholds_override_defaults(self, differ):
+        """Test limits set in the request replace the configured ones"""
+        base = _plan(WEB)
+        head = _plan(change("aws_instance.web", "aws_instance", ["update"],
+                            before={"instance_type": "m5.large"}, after={"instance_type": "m5.xlarge"}))
+
+        result = differ.diff(DiffRequest(
+            base={"terraform": base}, head={"terraform": head},
+            thresholds={"max_increase_percent": 200, "max_monthly_cost": 100},
+        ))
+
+        assert result.thresholds.max_increase_percent == 200
+        assert result.failures == ["Monthly cost 140.16 USD exceeds 100.00 USD"]
+        assert (result.base_label, result.head_label) == ("base", "head")
+
+    def test_recorded_and_inline_resources(self, differ, store):
+        """Test a recorded estimate diffed against resources estimated for the diff"""
+        recorded = differ.cost_estimator.estimate(EstimateRequest(resources=[
+            {"name": "web", "instance_type": "m5.large", "region": "us-east-1", "count": 2},
+        ]))
+        record = store.save_estimate(EstimateRecord(
+            kind=KIND_RESOURCES, result=recorded.dict(), monthly_cost=recorded.monthly_cost,
+        ))
+
+        result = differ.diff(DiffRequest(
+            base={"estimate_id": record.id},
+            head={"resources": {"resources": [
+                {"name": "web", "instance_type": "m5.large", "region": "us-east-1", "count": 2},
+                {"name": "worker", "instance_type": "c5.large", "region": "us-east-1"},
+            ]}},
+        ))
+
+        assert [(c.key, c.change) for c in result.changes] == [("worker", "added")]
+        assert result.delta_percent == pytest.approx(0.085 / (2 * 0.096) * 100, abs=0.01)
+        assert not result.passed
+
+    def test_invalid_sides(self, differ, store):
+        with pytest.raises(ValueError):
+            DiffRequest(base={"estimate_id": "a", "terraform": _plan(WEB)}, head={"estimate_id": "b"})
+        with pytest.raises(LookupError):
+            differ.diff(DiffRequest(base={"estimate_id": "missing"}, head={"terraform": _plan(WEB)}))
+        with pytest.raises(ValueError, match="Cannot diff"):
+            differ.diff(DiffRequest(
+                base={"terraform": _plan(WEB)},
+                head={"resources": {"resources": [{"instance_type": "m5.large", "region": "us-east-1"}]}},
+            ))
+
+        helm = store.save_estimate(EstimateRecord(kind=KIND_HELM, result={}, monthly_cost=1.0))
+        with pytest.raises(ValueError, match="helm"):
+            differ.diff(DiffRequest(base={"estimate_id": helm.id}, head={"estimate_id": helm.id}))
+
+    def test_parse_threshold(self):
+        assert parse_threshold("") is None
+        assert parse_threshold(" 12.5 ") == 12.5
+        with pytest.raises(ValueError):
+            parse_threshold("-1")
@@ -0,0 +1 @@
+"""Tests for discounts module"""
@@ -0,0 +1,176 @@
+"""Unit tests for discount rules"""
+
+import pytest
+
+from src.discounts import (
+    DiscountEngine,
+    DiscountRule,
+    DiscountRuleSpec,
+    DiscountSession,
+    DiscountTier,
+    KIND_TIERED,
+    tiered_rate,
+)
+from src.estimator import CostEstimator, EstimateRequest, ResourceSpec
+from src.pricing import Price, PriceCatalog, ProviderRegistry, StaticProvider
+from src.tenancy import tenant_context
+
+
+def _rule(rule_id, **spec):
+    return DiscountRule(id=rule_id, **spec)
+
+
+def _price(sku="m5.large", region="ap-northeast-2", provider="aws"):
+    return Price(provider=provider, region=region, sku=sku, service="compute", unit="hour", price=0.1, source="static")
+
+
+class TestDiscountRuleSpec:
+    """Test cases for rule validation"""
+
+    def test_percentage_needs_rate(self):
+        """Test a percentage rule without a rate is rejected"""
+        with pytest.raises(ValueError, match="needs a rate"):
+            DiscountRuleSpec(name="ec2")
+        with pytest.raises(ValueError, match="no tiers"):
+            DiscountRuleSpec(name="ec2", rate=0.1, tiers=[{"up_to": 10, "rate": 1}])
+
+    def test_tiers_must_increase(self):
+        """Test tier bounds must increase and only the last may be open"""
+        with pytest.raises(ValueError, match="increase"):
+            DiscountRuleSpec(name="t", kind="tiered", tiers=[{"up_to": 100, "rate": 1}, {"up_to": 50, "rate": 0.5}])
+        with pytest.raises(ValueError, match="last"):
+            DiscountRuleSpec(name="t", kind="tiered", tiers=[{"rate": 1}, {"up_to": 50, "rate": 0.5}])
+        with pytest.raises(ValueError, match="kind"):
+            DiscountRuleSpec(name="t", kind="bogus", rate=0.1)
+
+    def test_record_round_trip(self, store):
+        """Test a rule is stored and read back unchanged"""
+        spec = DiscountRuleSpec(
+            name="free-tier", kind="tiered", priority=5,
+            match={"provider": "AWS", "sku": "t3.micro"}, tiers=[{"up_to": 750, "rate": 1.0}],
+        )
+        saved = store.save_discount_rule(spec.to_record("acme"))
+
+        rule = DiscountRule.from_record(store.get_discount_rule(saved.id, tenant_id="acme"))
+
+        assert rule.id == saved.id
+        assert rule.tenant_id == "acme"
+        assert rule.kind == KIND_TIERED
+        assert rule.match.provider == "aws"
+        assert rule.tiers == [DiscountTier(up_to=750, rate=1.0)]
+        assert store.get_discount_rule(saved.id, tenant_id="globex") is None
+
+
+class TestSession:
+    """Test cases for rule evaluation"""
+
+    def test_tiered_rate(self):
+        """Test the discounted share of usage spanning tiers"""
+        tiers = [DiscountTier(up_to=100, rate=1.0), DiscountTier(up_to=200, rate=0.5), DiscountTier(rate=0.0)]
+        assert tiered_rate(tiers, 0, 50) == pytest.approx(1.0)
+        assert tiered_rate(tiers, 50, 100) == pytest.approx(0.75)
+        assert tiered_rate(tiers, 150, 100) == pytest.approx(0.25)
+        assert tiered_rate(tiers, 300, 100) == 0.0
+        assert tiered_rate(tiers, 0, 0) == 0.0
+
+    def test_rules_match_patterns(self):
+        """Test only prices matching every pattern are discounted"""
+        session = DiscountSession([
+            _rule("r1", name="apne2", match={"provider": "aws", "region": "ap-northeast-2"}, rate=0.15),
+        ])
+
+        discounts, cost = session.apply(_price(), 730, 73.0)
+        assert [d.rule.id for d in discounts] == ["r1"]
+        assert cost == pytest.approx(73.0 * 0.85)
+
+        discounts, cost = session.apply(_price(region="us-east-1"), 730, 73.0)
+        assert discounts == []
+        assert cost == 73.0
+
+    def test_rules_compound_in_order(self):
+        """Test each rule discounts what earlier rules left, and exclusive rules stop evaluation"""
+        rules = [
+            _rule("a", name="ten", rate=0.1, priority=1),
+            _rule("b", name="half", rate=0.5, priority=2, exclusive=True),
+            _rule("c", name="never", rate=0.5, priority=3),
+        ]
+
+        discounts, cost = DiscountSession(rules).apply(_price(), 730, 100.0)
+
+        assert [d.rule.id for d in discounts] == ["a", "b"]
+        assert [d.amount for d in discounts] == pytest.approx([10.0, 45.0])
+        assert cost == pytest.approx(45.0)
+
+    def test_free_tier_is_shared(self):
+        """Test tier usage accumulates across costs of one session"""
+        free_tier = _rule(
+            "f", name="t3.micro free tier", kind="tiered",
+            match={"sku": "t3.micro"}, tiers=[{"up_to": 750, "rate": 1.0}],
+        )
+        session = DiscountSession([free_tier])
+
+        _, first = session.apply(_price("t3.micro"), 500, 5.0)
+        _, second = session.apply(_price("t3.micro"), 500, 5.0)
+
+        assert first == pytest.approx(0.0)
+        assert second == pytest.approx(2.5)
+
+
+class TestDiscountEngine:
+    """Test cases for rules applied in estimates"""
+
+    @pytest.fixture
+    def registry(self):
+        catalog = PriceCatalog({
+            "aws": {"ap-northeast-2": {"m5.large": 0.1, "t3.micro": 0.01}, "us-east-1": {"m5.large": 0.1}},
+        }, storage_prices={})
+        registry = ProviderRegistry()
+        registry.register(StaticProvider(catalog))
+        return registry
+
+    def test_estimate_lists_applied_rules(self, store, registry):
+        """Test line items and the estimate list the rules that applied"""
+        rule = store.save_discount_rule(DiscountRuleSpec(
+            name="apne2-ec2", match={"provider": "aws", "region": "ap-northeast-2"}, rate=0.15,
+        ).to_record("default"))
+        estimator = CostEstimator(registry, discounts=DiscountEngine(store))
+
+        result = estimator.estimate(EstimateRequest(resources=[
+            ResourceSpec(instance_type="m5.large", region="ap-northeast-2", hours=100),
+            ResourceSpec(instance_type="m5.large", region="us-east-1", hours=100),
+        ]))
+
+        discounted, list_price = result.line_items
+        assert discounted.monthly_cost == pytest.approx(8.5)
+        assert discounted.discounts[0].rule_id == rule.id
+        assert discounted.discounts[0].rate == pytest.approx(0.15)
+        assert list_price.discounts == []
+        assert [(r.rule_id, r.monthly_amount) for r in result.applied_rules] == [(rule.id, pytest.approx(1.5))]
+
+    def test_rules_are_per_tenant_and_cached(self, store, registry):
+        """Test only the current tenant's enabled rules apply, until the cache is invalidated"""
+        now = [0.0]
+        engine = DiscountEngine(store, ttl=60, clock=lambda: now[0])
+        store.save_discount_rule(DiscountRuleSpec(name="acme-10", rate=0.1).to_record("acme"))
+        store.save_discount_rule(DiscountRuleSpec(name="off", rate=0.5, enabled=False).to_record("acme"))
+
+        with tenant_context("acme"):
+            assert [r.name for r in engine.session().rules] == ["acme-10"]
+        assert engine.session().rules == []
+
+        store.save_discount_rule(DiscountRuleSpec(name="acme-20", rate=0.2, priority=1).to_record("acme"))
+        assert [r.name for r in engine.rules("acme")] == ["acme-10"]
+        engine.invalidate("acme")
+        assert [r.name for r in engine.rules("acme")] == ["acme-20", "acme-10"]
+
+    def test_replace_and_delete(self, store):
+        """Test saving with an existing id replaces the rule, and rules of other tenants are untouched"""
+        saved = store.save_discount_rule(DiscountRuleSpec(name="v1", rate=0.1).to_record("acme"))
+
+        store.save_discount_rule(DiscountRuleSpec(name="v2", rate=0.2).to_record("acme", rule_id=saved.id))
+        store.save_discount_rule(DiscountRuleSpec(name="hijack", rate=0.9).to_record("globex", rule_id=saved.id))
+
+        assert [r.name for r in store.list_discount_rules("acme")] == ["v2"]
+        assert store.delete_discount_rule(saved.id, tenant_id="globex") is False
+        assert store.delete_discount_rule(saved.id, tenant_id="acme") is True
+        assert store.list_discount_rules("acme") == []
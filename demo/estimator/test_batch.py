@@ -0,0 +1,91 @@
+"""Unit tests for batch estimation"""
+
+import pytest
+
+from src.estimator import BatchEstimateRequest, BatchEstimator, BatchItem, CostEstimator, EstimateRequest
+from src.pricing import Price, PriceCatalog, ProviderRegistry, StaticProvider
+from src.tenancy import current_tenant, tenant_context
+
+
+class TestBatchEstimator:
+    """Test cases for BatchEstimator class"""
+
+    @pytest.fixture
+    def registry(self):
+        registry = ProviderRegistry()
+        registry.register(StaticProvider(PriceCatalog({
+            "aws": {"us-east-1": {"m5.large": 0.1, "t3.micro": 0.01}},
+        }, storage_prices={})))
+        return registry
+
+    @pytest.fixture
+    def batch(self, registry):
+        batch = BatchEstimator(CostEstimator(registry=registry), workers=4, max_items=500)
+        yield batch
+        batch.close()
+
+    def test_results_in_order_with_errors(self, batch):
+        items = [
+            BatchItem(id=f"aws_instance.web[{i}]", instance_type="m5.large" if i % 2 else "t3.micro",
+                      region="us-east-1", count=2)
+            for i in range(200)
+        ]
+        items.insert(3, BatchItem(id="aws_instance.legacy", instance_type="m1.small", region="us-east-1"))
+        items.append(BatchItem(id="oci.vm", provider="oci", instance_type="VM.Standard", region="eu"))
+
+        result = batch.estimate(BatchEstimateRequest(items=items))
+
+        assert [r.index for r in result.items] == list(range(202))
+        assert [r.id for r in result.items] == [item.id for item in items]
+        assert (result.succeeded, result.failed) == (200, 2)
+        assert "No price for aws/us-east-1/m1.small" in result.items[3].error
+        assert result.items[3].line_item is None and result.items[-1].error
+        assert (result.items[3].status, result.items[4].status) == ("unsupported", "priced")
+        assert (result.coverage.priced, result.coverage.unsupported, result.coverage.percent) == (200, 2, 99.01)
+
+        priced = [item for item in items if item.instance_type in ("m5.large", "t3.micro")]
+        sequential = batch.estimator.estimate(EstimateRequest(resources=priced))
+        totals = (result.hourly_cost, result.monthly_cost, result.yearly_cost)
+        assert totals == (sequential.hourly_cost, sequential.monthly_cost, sequential.yearly_cost)
+
+    def test_workers_see_the_tenant(self, registry, batch):
+        seen = []
+
+        def overrides(query):
+            seen.append(current_tenant())
+            if current_tenant() == "acme" and query.sku == "m5.large":
+                return Price(provider="aws", region=query.region, sku=query.sku, price=0.05, source="tenant")
+            return None
+
+        registry.set_overrides(overrides)
+        request = BatchEstimateRequest(items=[BatchItem(instance_type="m5.large", region="us-east-1")] * 20)
+        with tenant_context("acme"):
+            result = batch.estimate(request)
+
+        assert set(seen) == {"acme"}
+        assert {r.line_item.unit_price_hourly for r in result.items} == {0.05}
+
+    def test_batch_size_limit(self, batch):
+        with pytest.raises(ValueError, match="at most 500 items"):
+            batch.estimate(BatchEstimateRequest(items=[BatchItem(instance_type="m5.large", region="us-east-1")] * 501))
+
+    def test_progress_counts_failed_items(self, batch):
+        items = [
+            BatchItem(instance_type="m5.large", region="us-east-1"),
+            BatchItem(instance_type="m1.small", region="us-east-1"),
+        ]
+        reported = []
+
+        batch.estimate(BatchEstimateRequest(items=items), progress=lambda done, total: reported.append((done, total)))
+
+        assert reported == [(1, 2), (2, 2)]
+
+    def test_on_item_in_request_order(self, batch):
+        items = [BatchItem(id=f"web-{i}", instance_type="m5.large", region="us-east-1") for i in range(50)]
+        items[7] = BatchItem(id="legacy", instance_type="m1.small", region="us-east-1")
+        seen = []
+
+        batch.estimate(BatchEstimateRequest(items=items), on_item=seen.append)
+
+        assert [r.id for r in seen] == [item.id for item in items]
+        assert seen[7].error and seen[8].line_item.unit_price_hourly == 0.1
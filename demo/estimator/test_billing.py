@@ -0,0 +1,102 @@
+"""Unit tests for billing granularity and billing periods"""
+
+from datetime import date
+
+import pytest
+from pydantic import ValidationError
+
+from src.estimator import BillingPeriod, CostEstimator, EstimateRequest, ResourceSpec, billed_hours, month_segments
+from src.pricing import ProviderRegistry, StaticProvider
+
+
+@pytest.fixture
+def estimator():
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return CostEstimator(registry)
+
+
+class TestBillingGranularity:
+    """Test cases for billing short instance runs"""
+
+    def test_billed_hours(self):
+        """Test runs are rounded up to the cloud's increment, at least its minimum"""
+        # 300 runs of 45 seconds
+        assert billed_hours("aws", 3.75, 300) == pytest.approx(5.0)
+        assert billed_hours("azure", 3.75, 300) == pytest.approx(5.0)
+        assert billed_hours("ncp", 3.75, 300) == pytest.approx(300.0)
+        # 10 runs of 90.5 seconds
+        assert billed_hours("gcp", 905 / 3600, 10) == pytest.approx(910 / 3600)
+        assert billed_hours("azure", 905 / 3600, 10) == pytest.approx(1200 / 3600)
+        # Runs of whole hours are billed as they are everywhere
+        assert billed_hours("openstack", 730, 10) == pytest.approx(730)
+
+    def test_runs_in_estimates(self, estimator):
+        """Test resources with runs are priced by their billed hours"""
+        result = estimator.estimate(EstimateRequest(resources=[
+            ResourceSpec(instance_type="m5.large", region="us-east-1", hours=3.75, runs=300),
+            ResourceSpec(instance_type="m5.large", region="us-east-1", hours=3.75),
+        ]))
+
+        runs, continuous = result.line_items
+        assert runs.hours == 3.75
+        assert runs.billed_hours == 5.0
+        assert runs.monthly_cost == pytest.approx(0.096 * 5)
+        assert continuous.billed_hours is None
+        assert continuous.monthly_cost == pytest.approx(0.096 * 3.75)
+
+
+class TestBillingPeriods:
+    """Test cases for estimates of date ranges"""
+
+    def test_month_segments(self):
+        """Test a period is split at month ends, both ends included"""
+        period = BillingPeriod(start=date(2026, 11, 15), end=date(2027, 1, 3))
+
+        assert list(month_segments(period)) == [
+            (date(2026, 11, 15), date(2026, 11, 30)),
+            (date(2026, 12, 1), date(2026, 12, 31)),
+            (date(2027, 1, 1), date(2027, 1, 3)),
+        ]
+
+    def test_partial_months(self, estimator):
+        """Test instances are priced by the hours of each month's days and storage by the share of its days"""
+        result = estimator.estimate(EstimateRequest(
+            resources=[{"instance_type": "m5.large", "region": "us-east-1", "count": 2}],
+            object_storage=[{"region": "us-east-1", "storage_gb": 1000}],
+            period={"start": "2026-11-15", "end": "2026-12-20"},
+        ))
+        storage = result.object_storage_items[0].monthly_cost
+
+        period = result.period
+        assert (period.days, period.hours) == (36, 864.0)
+        november, december = period.months
+        assert (november.month, november.days, november.hours, november.fraction) == ("2026-11", 16, 384.0, 0.533333)
+        assert november.prorated_cost == pytest.approx(2 * 0.096 * 384 + storage * 16 / 30)
+        assert (december.end, december.days) == (date(2026, 12, 20), 20)
+        assert december.prorated_cost == pytest.approx(2 * 0.096 * 480 + storage * 20 / 31)
+        assert period.total_cost == pytest.approx(november.prorated_cost + december.prorated_cost)
+
+    def test_calendar_months_differ(self, estimator):
+        """Test a whole 31-day month of an always-on instance costs more than its 730-hour month"""
+        def cost(start, end):
+            request = EstimateRequest(
+                resources=[{"instance_type": "m5.large", "region": "us-east-1"}],
+                period={"start": start, "end": end},
+            )
+            return estimator.estimate(request)
+
+        march = cost("2026-03-01", "2026-03-31")
+        assert march.period.total_cost == pytest.approx(0.096 * 744)
+        assert march.period.total_cost > march.monthly_cost
+        assert cost("2026-02-01", "2026-02-28").period.total_cost == pytest.approx(0.096 * 672)
+
+    def test_period_validation(self):
+        """Test periods end on or after their start and span at most three years"""
+        assert BillingPeriod(start=date(2026, 1, 1), end=date(2026, 1, 1))
+        with pytest.raises(ValidationError, match="before start"):
+            BillingPeriod(start=date(2026, 2, 1), end=date(2026, 1, 31))
+        with pytest.raises(ValidationError, match="at most"):
+            BillingPeriod(start=date(2026, 1, 1), end=date(2030, 1, 1))
+        with pytest.raises(ValidationError):
+            ResourceSpec(instance_type="m5.large", region="us-east-1", runs=0)
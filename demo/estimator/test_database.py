@@ -0,0 +1,129 @@
+"""Unit tests for managed database pricing"""
+
+import pytest
+from pydantic import ValidationError
+
+from src.discounts import DiscountRule, DiscountSession
+from src.estimator import (
+    CostEstimator,
+    DatabaseSpec,
+    EstimateRequest,
+    HOURS_PER_MONTH,
+    billable_backup_gb,
+    billable_iops,
+)
+from src.pricing import PriceNotFoundError, ProviderRegistry, StaticProvider
+from src.terraform import TerraformEstimateRequest, TerraformEstimator
+
+from ..terraform.test_plan import change, make_plan
+
+
+@pytest.fixture
+def registry():
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return registry
+
+
+@pytest.fixture
+def estimator(registry):
+    return CostEstimator(registry)
+
+
+class TestDatabasePricing:
+    """Test cases for managed databases in estimates"""
+
+    def test_components(self, estimator):
+        """Test instance, storage, IOPS beyond the baseline and backups beyond the allowance"""
+        item = estimator.price_database(DatabaseSpec(
+            region="us-east-1", engine="postgres", instance_class="db.m5.large",
+            storage_gb=500, storage_type="io1", iops=5000, backup_storage_gb=800,
+        ))
+
+        costs = {c.component: (c.quantity, c.monthly_cost) for c in item.components}
+        assert costs == {
+            "instance": (730, pytest.approx(0.178 * 730)),
+            "storage": (500, pytest.approx(0.125 * 500)),
+            "iops": (5000, pytest.approx(0.10 * 5000)),
+            "backup": (300, pytest.approx(0.095 * 300)),
+        }
+        assert item.deployment == "single_az"
+        assert item.monthly_cost == pytest.approx(sum(cost for _, cost in costs.values()), abs=1e-3)
+        assert item.hourly_cost == pytest.approx(item.monthly_cost / HOURS_PER_MONTH, abs=1e-4)
+
+    def test_high_availability(self, estimator):
+        """Test a standby doubles instance and storage but not backups"""
+        single = estimator.price_database(DatabaseSpec(
+            region="us-east-1", instance_class="db.m5.large", storage_gb=100, backup_storage_gb=300))
+        multi_az = estimator.price_database(DatabaseSpec(
+            region="us-east-1", instance_class="db.m5.large", storage_gb=100, backup_storage_gb=300,
+            high_availability=True))
+
+        def cost(item, component):
+            return next(c.monthly_cost for c in item.components if c.component == component)
+
+        assert multi_az.deployment == "multi_az"
+        assert cost(multi_az, "instance") == pytest.approx(2 * cost(single, "instance"))
+        assert cost(multi_az, "storage") == pytest.approx(2 * cost(single, "storage"))
+        assert cost(multi_az, "backup") == pytest.approx(cost(single, "backup"))
+
+    def test_provider_defaults(self, estimator):
+        """Test default storage types and free allowances per provider"""
+        cloud_sql = estimator.price_database(DatabaseSpec(
+            provider="gcp", region="us-central1", engine="postgresql", instance_class="db-custom-2-7680",
+            storage_gb=100, backup_storage_gb=50,
+        ))
+
+        assert cloud_sql.engine == "postgres"
+        assert cloud_sql.storage_type == "ssd"
+        assert [c.component for c in cloud_sql.components] == ["instance", "storage", "backup"]
+        assert billable_iops("aws", "gp3", 100, 3000) == 0
+        assert billable_iops("azure", "premium_ssd", 100, 1000) == 400
+        assert billable_backup_gb("gcp", 100, 50) == 50
+
+    def test_estimate_totals_and_discounts(self, estimator):
+        """Test database items add to the totals and discount rules match their components"""
+        database = DatabaseSpec(provider="azure", region="eastus", instance_class="Standard_D2ds_v4", storage_gb=128)
+        result = estimator.estimate(EstimateRequest(databases=[database]))
+        (item,) = result.database_items
+        assert result.line_items == []
+        assert result.monthly_cost == pytest.approx(item.monthly_cost)
+
+        rule = DiscountRule(id="r1", name="db-storage", match={"service": "database_storage"}, rate=0.5)
+        discounted = estimator.price_database(database, DiscountSession([rule]))
+        (discount,) = discounted.discounts
+        assert discount.monthly_amount == pytest.approx(0.115 * 128 / 2, abs=1e-4)
+
+    def test_validation(self, estimator):
+        """Test unknown engines, empty requests and unpriced classes are rejected"""
+        with pytest.raises(ValidationError):
+            DatabaseSpec(region="us-east-1", engine="oracle", instance_class="db.m5.large")
+        with pytest.raises(ValidationError):
+            EstimateRequest()
+        with pytest.raises(PriceNotFoundError):
+            estimator.price_database(DatabaseSpec(region="us-east-1", instance_class="db.x2g.16xlarge"))
+
+    def test_terraform_databases(self, registry):
+        """Test RDS, Cloud SQL and flexible server resources in a plan"""
+        plan = make_plan(
+            change("aws_db_instance.db", "aws_db_instance", ["create"], after={
+                "instance_class": "db.m5.large", "engine": "postgres", "multi_az": True,
+                "allocated_storage": 100, "storage_type": "gp3", "iops": 12000,
+            }),
+            change("google_sql_database_instance.db", "google_sql_database_instance", ["create"], after={
+                "region": "us-central1", "database_version": "POSTGRES_15",
+                "settings": [{"tier": "db-custom-2-7680", "availability_type": "REGIONAL", "disk_size": 50}],
+            }, provider="google"),
+            change("azurerm_postgresql_flexible_server.db", "azurerm_postgresql_flexible_server", ["create"],
+                   after={"location": "eastus", "sku_name": "GP_Standard_D2ds_v4", "storage_mb": 65536},
+                   provider="azurerm"),
+            regions={"aws": "us-east-1"},
+        )
+
+        result = TerraformEstimator(registry=registry).estimate(TerraformEstimateRequest(plan=plan))
+
+        rds, cloud_sql, azure = result.added
+        assert [c.name for c in rds.components] == ["db_instance", "db_storage", "db_iops"]
+        assert rds.after_monthly_cost == pytest.approx(2 * (0.178 * 730 + 0.115 * 100 + 0.02 * 9000))
+        assert cloud_sql.after_monthly_cost == pytest.approx(2 * (0.1351 * 730 + 0.17 * 50))
+        assert azure.after_monthly_cost == pytest.approx(0.178 * 730 + 0.115 * 64)
@@ -0,0 +1,116 @@
+"""Unit tests for disaster recovery costs"""
+
+import pytest
+
+from src.estimator import (
+    CostEstimator,
+    DatabaseSpec,
+    DisasterRecoverySpec,
+    EstimateRequest,
+    ObjectStorageSpec,
+    ResourceSpec,
+    normalize_strategy,
+    replication_gb,
+    snapshot_gb,
+)
+from src.pricing import PriceNotFoundError, ProviderRegistry, StaticProvider
+from src.validation import validate_estimate
+
+
+@pytest.fixture
+def estimator():
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return CostEstimator(registry)
+
+
+def _request(strategy, **dr):
+    return EstimateRequest(
+        resources=[ResourceSpec(provider="aws", instance_type="m5.large", region="us-east-1", count=6)],
+        databases=[DatabaseSpec(region="us-east-1", instance_class="db.m5.large", storage_gb=500)],
+        object_storage=[ObjectStorageSpec(region="us-east-1", storage_gb=2000)],
+        disaster_recovery={"strategy": strategy, "region": "ap-northeast-2", **dr},
+    )
+
+
+def _components(result):
+    return {component.component: component for component in result.disaster_recovery.components}
+
+
+class TestDisasterRecoverySpec:
+    """Test cases for DR strategies and volumes"""
+
+    def test_strategies_and_regions(self):
+        """Test strategy names are normalized and items map to their recovery region"""
+        assert normalize_strategy("Warm-Standby") == "warm_standby"
+        with pytest.raises(ValueError):
+            normalize_strategy("cold_site")
+
+        spec = DisasterRecoverySpec(strategy="pilot light", regions={"us-east-1": "us-west-2"})
+        assert spec.recovery_region("us-east-1") == "us-west-2"
+        with pytest.raises(ValueError, match="No recovery region for eu-west-1"):
+            spec.recovery_region("eu-west-1")
+        with pytest.raises(ValueError):
+            DisasterRecoverySpec(strategy="backup_only")
+
+    def test_volumes(self):
+        """Test snapshots keep a full copy and the retained daily changes"""
+        assert snapshot_gb(500, 7, 0.05) == pytest.approx(675)
+        assert replication_gb(100, 0.1) == pytest.approx(304.375)
+
+
+class TestDisasterRecoveryCosts:
+    """Test cases for CostEstimator.price_disaster_recovery"""
+
+    def test_backup_only(self, estimator):
+        """Test backups are snapshots, infrequent copies and replication, and leave the totals alone"""
+        plain = estimator.estimate(EstimateRequest(
+            resources=[ResourceSpec(provider="aws", instance_type="m5.large", region="us-east-1", count=6)],
+            databases=[DatabaseSpec(region="us-east-1", instance_class="db.m5.large", storage_gb=500)],
+            object_storage=[ObjectStorageSpec(region="us-east-1", storage_gb=2000)],
+        ))
+        result = estimator.estimate(_request("backup_only"))
+        dr = result.disaster_recovery
+        components = _components(result)
+
+        assert result.monthly_cost == plain.monthly_cost
+        assert set(components) == {"snapshots", "object_storage", "replication"}
+        assert components["snapshots"].region == "ap-northeast-2" and components["snapshots"].quantity == 675
+        assert components["object_storage"].sku == "Standard - Infrequent Access"
+        assert components["replication"].region == "us-east-1"
+        assert dr.replication_gb == pytest.approx(replication_gb(2500, 0.05), abs=1e-4)
+        assert dr.monthly_cost == pytest.approx(sum(c.monthly_cost for c in dr.components), abs=1e-3)
+        assert dr.total_monthly_cost == pytest.approx(result.monthly_cost + dr.monthly_cost, abs=1e-3)
+
+    def test_standby_compute(self, estimator):
+        """Test warm standby runs a share of the instances and active-active all of them"""
+        pilot = _components(estimator.estimate(_request("pilot_light")))
+        assert "standby_compute" not in pilot and pilot["standby_database"].quantity == 1
+
+        warm = estimator.estimate(_request("warm_standby"))
+        assert _components(warm)["standby_compute"].quantity == 2
+        assert _components(estimator.estimate(_request("warm_standby", standby_fraction=0.5)))[
+            "standby_compute"].quantity == 3
+
+        active = estimator.estimate(_request("active_active"))
+        compute = _components(active)["standby_compute"]
+        assert compute.quantity == 6 and compute.region == "ap-northeast-2"
+        assert active.disaster_recovery.monthly_cost > warm.disaster_recovery.monthly_cost
+
+    def test_same_region(self, estimator):
+        """Test nothing is replicated to a recovery region that is the primary region"""
+        result = estimator.estimate(_request("backup_only", region="us-east-1"))
+        assert "replication" not in _components(result) and result.disaster_recovery.replication_gb == 0
+
+    def test_unpriced_components(self, estimator):
+        """Test components without prices in the recovery region fail, or are listed with continue_on_error"""
+        request = _request("pilot_light", region="us-west-2")
+        with pytest.raises(PriceNotFoundError):
+            estimator.estimate(request)
+        violations = validate_estimate(estimator, request)
+        assert [v.field for v in violations] == ["disaster_recovery"]
+
+        result = estimator.estimate(request.copy(update={"continue_on_error": True}))
+        assert [item.field for item in result.disaster_recovery.unsupported] == [
+            "disaster_recovery.databases[0]", "disaster_recovery.object_storage[0]",
+        ]
@@ -0,0 +1,225 @@
+"""Unit tests for cost estimator"""
+
+import pytest
+
+from src.estimator.estimator import CostEstimator, HOURS_PER_MONTH
+from src.estimator.models import CommitmentOption, EstimateRequest, ResourceSpec
+from src.pricing import (
+    PriceCatalog,
+    PriceNotFoundError,
+    ProviderNotFoundError,
+    ProviderRegistry,
+    StaticProvider,
+)
+
+
+class TestCostEstimator:
+    """Test cases for CostEstimator class"""
+
+    @pytest.fixture
+    def catalog(self):
+        """Create a small price catalog"""
+        return PriceCatalog({
+            "aws": {"us-east-1": {"m5.large": 0.1, "t3.micro": 0.01}},
+            "gcp": {"us-central1": {"e2-standard-2": 0.07}},
+        }, storage_prices={})
+
+    @pytest.fixture
+    def registry(self, catalog):
+        """Create registry with the static provider"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider(catalog))
+        return registry
+
+    @pytest.fixture
+    def estimator(self, registry):
+        """Create estimator instance"""
+        return CostEstimator(registry=registry)
+
+    def test_single_resource_breakdown(self, estimator):
+        """Test monthly and yearly cost for one resource"""
+        request = EstimateRequest(resources=[
+            ResourceSpec(instance_type="m5.large", region="us-east-1", count=2)
+        ])
+
+        result = estimator.estimate(request)
+
+        item = result.line_items[0]
+        assert item.unit_price_hourly == pytest.approx(0.1)
+        assert item.hourly_cost == pytest.approx(0.2)
+        assert item.monthly_cost == pytest.approx(0.2 * HOURS_PER_MONTH)
+        assert item.yearly_cost == pytest.approx(0.2 * HOURS_PER_MONTH * 12)
+        assert item.price_source == "static"
+        assert result.currency == "USD"
+
+    def test_totals_across_providers(self, estimator):
+        """Test totals sum line items across providers"""
+        request = EstimateRequest(resources=[
+            ResourceSpec(instance_type="t3.micro", region="us-east-1", hours=100),
+            ResourceSpec(provider="GCP", instance_type="e2-standard-2", region="us-central1", hours=200),
+        ])
+
+        result = estimator.estimate(request)
+
+        assert len(result.line_items) == 2
+        assert result.line_items[1].provider == "gcp"
+        assert result.monthly_cost == pytest.approx(0.01 * 100 + 0.07 * 200)
+        assert result.yearly_cost == pytest.approx(result.monthly_cost * 12)
+
+    def test_unknown_instance_type(self, estimator):
+        """Test error for unpriced resources"""
+        request = EstimateRequest(resources=[
+            ResourceSpec(instance_type="x9.huge", region="us-east-1")
+        ])
+
+        with pytest.raises(PriceNotFoundError, match="x9.huge"):
+            estimator.estimate(request)
+
+    def test_unknown_provider(self, estimator):
+        """Test error for clouds without a pricing provider"""
+        request = EstimateRequest(resources=[
+            ResourceSpec(provider="azure", instance_type="Standard_B2s", region="eastus")
+        ])
+
+        with pytest.raises(ProviderNotFoundError):
+            estimator.estimate(request)
+
+    def test_continue_on_error(self, estimator):
+        """Test items that cannot be priced are reported at zero cost with continue_on_error"""
+        request = EstimateRequest(
+            resources=[
+                ResourceSpec(instance_type="m5.large", region="us-east-1"),
+                ResourceSpec(instance_type="x9.huge", region="us-east-1", name="legacy"),
+                ResourceSpec(provider="azure", instance_type="Standard_B2s", region="eastus"),
+            ],
+            continue_on_error=True,
+        )
+
+        result = estimator.estimate(request)
+
+        assert [item.instance_type for item in result.line_items] == ["m5.large"]
+        assert result.monthly_cost == result.line_items[0].monthly_cost
+        legacy, azure = result.unsupported
+        assert (legacy.field, legacy.name, legacy.status, legacy.monthly_cost) == (
+            "resources[1]", "legacy", "unsupported", 0.0,
+        )
+        assert "x9.huge" in legacy.reason and azure.field == "resources[2]"
+        assert (result.coverage.items, result.coverage.priced, result.coverage.percent) == (3, 1, 33.33)
+        assert estimator.estimate(EstimateRequest(resources=request.resources[:1])).coverage.percent == 100.0
+
+    def test_invalid_resource_spec(self):
+        """Test validation of count and hours"""
+        with pytest.raises(ValueError):
+            ResourceSpec(instance_type="m5.large", region="us-east-1", count=0)
+
+        with pytest.raises(ValueError):
+            ResourceSpec(instance_type="m5.large", region="us-east-1", hours=800)
+
+        with pytest.raises(ValueError):
+            ResourceSpec(instance_type="m5.large", region="us-east-1", pricing_model="reserved")
+
+    def test_spot_pricing_model(self):
+        """Test spot resources are priced from spot rates"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        estimator = CostEstimator(registry=registry)
+
+        result = estimator.estimate(EstimateRequest(resources=[
+            ResourceSpec(instance_type="m5.large", region="us-east-1", pricing_model="Spot"),
+        ]))
+
+        item = result.line_items[0]
+        assert item.pricing_model == "spot"
+        assert item.unit_price_hourly == pytest.approx(0.0384)
+        assert item.monthly_cost == pytest.approx(0.0384 * HOURS_PER_MONTH)
+
+    def test_default_catalog(self):
+        """Test estimator works with the built-in rate card"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        estimator = CostEstimator(registry=registry)
+        request = EstimateRequest(resources=[
+            ResourceSpec(instance_type="m5.large", region="ap-northeast-2")
+        ])
+
+        result = estimator.estimate(request)
+
+        assert result.monthly_cost > 0
+
+
+class TestCommitmentComparison:
+    """Test cases for on-demand versus commitment comparison"""
+
+    @pytest.fixture
+    def estimator(self):
+        """Create estimator over the built-in rate card"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        return CostEstimator(registry=registry)
+
+    def test_no_commitments_requested(self, estimator):
+        """Test the comparison block is empty unless commitments are requested"""
+        result = estimator.estimate(EstimateRequest(resources=[
+            ResourceSpec(instance_type="m5.large", region="us-east-1"),
+        ]))
+
+        assert result.commitment_comparison == []
+
+    def test_reserved_versus_on_demand(self, estimator):
+        """Test term totals, savings and break-even utilization"""
+        result = estimator.estimate(EstimateRequest(
+            resources=[ResourceSpec(instance_type="m5.large", region="us-east-1", count=2)],
+            commitments=[
+                CommitmentOption(type="reserved", term="1yr", payment_option="all_upfront"),
+                CommitmentOption(type="savings_plan", term="3yr", payment_option="no_upfront"),
+            ],
+        ))
+
+        reserved, plan = result.commitment_comparison
+        term_hours = HOURS_PER_MONTH * 12
+        assert reserved.on_demand_total_cost == pytest.approx(0.096 * 2 * term_hours)
+        assert reserved.upfront_cost == pytest.approx(0.096 * (1 - 0.418) * 2 * term_hours)
+        assert reserved.commitment_total_cost == pytest.approx(reserved.upfront_cost)
+        assert reserved.break_even_utilization == pytest.approx(1 - 0.418)
+        assert reserved.savings_rate == pytest.approx(0.418)
+
+        assert plan.term_months == 36
+        assert plan.upfront_cost == 0
+        assert plan.line_items[0].recurring_hourly_cost == pytest.approx(0.096 * 0.49 * 2, abs=1e-4)
+        assert plan.break_even_utilization == pytest.approx(0.49)
+
+    def test_part_time_usage_and_missing_prices(self, estimator):
+        """Test partial usage lowers savings and unpriced resources stay on-demand"""
+        result = estimator.estimate(EstimateRequest(
+            resources=[
+                ResourceSpec(instance_type="m5.large", region="us-east-1", hours=292),
+                ResourceSpec(provider="gcp", instance_type="e2-standard-2", region="us-central1"),
+            ],
+            commitments=[CommitmentOption(type="savings_plan")],
+        ))
+
+        (comparison,) = result.commitment_comparison
+        aws, gcp = comparison.line_items
+        assert aws.break_even_utilization == pytest.approx(0.71)
+        assert aws.commitment_term_cost > aws.on_demand_term_cost
+        assert gcp.commitment_term_cost is None
+        assert "savings_plan" in gcp.unavailable_reason
+        assert comparison.commitment_total_cost == pytest.approx(
+            aws.commitment_term_cost + gcp.on_demand_term_cost, abs=1e-3)
+        assert comparison.savings < 0
+
+    def test_spot_resources_compared_at_on_demand(self, estimator):
+        """Test commitments are compared with on-demand prices of spot resources"""
+        result = estimator.estimate(EstimateRequest(
+            resources=[ResourceSpec(instance_type="m5.large", region="us-east-1", pricing_model="spot")],
+            commitments=[CommitmentOption(type="reserved")],
+        ))
+
+        assert result.commitment_comparison[0].on_demand_total_cost == pytest.approx(0.096 * HOURS_PER_MONTH * 12)
+
+    def test_invalid_commitment_option(self):
+        """Test validation of commitment options"""
+        with pytest.raises(ValueError):
+            CommitmentOption(type="reserved", term="2yr")
+        with pytest.raises(ValueError):
+            CommitmentOption(type="spot")
@@ -0,0 +1,88 @@
+"""Unit tests for estimate explanations"""
+
+from datetime import date
+
+import pytest
+
+from src.estimator import (
+    CostEstimator,
+    DatabaseSpec,
+    EstimateRequest,
+    ResourceSpec,
+    TrafficSpec,
+    explain_estimate,
+)
+from src.pricing import ProviderRegistry, StaticProvider, UsageDiscount
+
+
+class SustainedUseProvider(StaticProvider):
+    """Static prices with a sustained use discount on GCP machines"""
+
+    def usage_discount(self, price, hours_per_month):
+        return UsageDiscount(name="Sustained use", rate=0.2) if price.provider == "gcp" else None
+
+
+@pytest.fixture
+def estimator():
+    registry = ProviderRegistry()
+    registry.register(SustainedUseProvider())
+    return CostEstimator(registry)
+
+
+class TestExplainEstimate:
+    """Test cases for explain_estimate"""
+
+    def test_instances_with_discount(self, estimator):
+        """Test the steps of an instance line item add up to its monthly cost"""
+        result = estimator.estimate(EstimateRequest(resources=[
+            ResourceSpec(provider="gcp", instance_type="n1-standard-4", region="us-central1", count=2),
+        ]))
+        item = result.line_items[0]
+        (explanation,) = explain_estimate(result, date(2026, 10, 14))
+
+        assert explanation.item == "line_items[0]" and explanation.sku == "n1-standard-4"
+        assert (explanation.unit_price, explanation.unit) == (item.unit_price_hourly, "hour")
+        assert explanation.catalog_version == date(2026, 10, 14) and explanation.price_source == "static"
+        steps = {step.description: step for step in explanation.steps}
+        assert steps["Hourly cost"].amount == pytest.approx(item.hourly_cost)
+        gross = steps["Gross monthly cost"].amount
+        assert gross == pytest.approx(item.unit_price_hourly * 2 * item.hours, abs=1e-4)
+
+        # Sustained use discount taken off the gross cost
+        assert explanation.discounts == item.discounts and item.discounts[0].name == "Sustained use"
+        discount = next(step for step in explanation.steps if step.description.startswith("Discount"))
+        assert discount.amount == pytest.approx(gross - item.discounts[0].monthly_amount, abs=1e-4)
+        assert discount.amount == pytest.approx(item.monthly_cost, abs=1e-4)
+        assert steps["Monthly cost"].amount == explanation.monthly_cost == item.monthly_cost
+        assert steps["Yearly cost"].amount == item.yearly_cost
+
+    def test_gpus_and_billed_hours(self, estimator):
+        """Test attached GPUs and runs billed by granularity are steps of their own"""
+        result = estimator.estimate(EstimateRequest(resources=[ResourceSpec(
+            provider="gcp", instance_type="n1-standard-4", region="us-central1",
+            accelerator_type="t4", accelerator_count=2, count=3, hours=10.5, runs=21,
+        )]))
+        item = result.line_items[0]
+        steps = {step.description: step for step in explain_estimate(result)[0].steps}
+
+        assert steps["Unit price of nvidia-tesla-t4 GPUs"].amount == item.accelerator_unit_price_hourly
+        assert "6 × $0.35" in steps["Hourly cost"].expression
+        assert steps["Billed hours"].amount == item.billed_hours
+
+    def test_transfer_and_database_items(self, estimator):
+        """Test transfer tiers and database components are explained with their gross costs"""
+        result = estimator.estimate(EstimateRequest(
+            databases=[DatabaseSpec(region="us-east-1", instance_class="db.m5.large", storage_gb=100)],
+            traffic=[TrafficSpec(region="us-east-1", internet_egress_gb=20480)],
+        ))
+        explanations = {e.item: e for e in explain_estimate(result)}
+
+        egress = explanations["transfer_items[0]"]
+        assert [step.amount for step in egress.steps[:3]] == [t.monthly_cost for t in result.transfer_items[0].tiers]
+        assert egress.steps[-2].amount == result.transfer_items[0].monthly_cost
+
+        database = explanations["database_items[0]"]
+        assert database.sku == "db.m5.large" and database.unit == "hour"
+        gross = next(step for step in database.steps if step.description == "Gross monthly cost")
+        assert gross.amount == pytest.approx(result.database_items[0].monthly_cost, abs=1e-3)
+        assert database.steps[0].description == "instance (db.m5.large)"
@@ -0,0 +1,53 @@
+"""Unit tests for GPU instance and attached accelerator pricing"""
+
+import pytest
+from pydantic import ValidationError
+
+from src.estimator import CostEstimator, EstimateRequest, ResourceSpec, HOURS_PER_MONTH
+from src.pricing import ProviderRegistry, StaticProvider
+
+
+@pytest.fixture
+def estimator():
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return CostEstimator(registry)
+
+
+class TestGPUPricing:
+    """Test cases for GPUs in estimates"""
+
+    def test_gpu_instance(self, estimator):
+        """Test GPU instance types are priced like other instances"""
+        item = estimator.price_resource(ResourceSpec(instance_type="g4dn.xlarge", region="us-east-1"))
+
+        assert item.unit_price_hourly == pytest.approx(0.526)
+        assert item.accelerator_count == 0
+
+    def test_attached_accelerators(self, estimator):
+        """Test attached GPUs are priced per GPU on top of the machine"""
+        item = estimator.price_resource(ResourceSpec(
+            provider="gcp", instance_type="n1-standard-4", region="us-central1",
+            accelerator_type="t4", accelerator_count=2, count=3,
+        ))
+
+        assert item.accelerator_type == "nvidia-tesla-t4"
+        assert item.accelerator_unit_price_hourly == pytest.approx(0.35)
+        assert item.hourly_cost == pytest.approx(3 * (0.189999 + 2 * 0.35), abs=1e-4)
+        assert item.monthly_cost == pytest.approx(item.hourly_cost * HOURS_PER_MONTH, rel=1e-4)
+
+    def test_spot_accelerators(self, estimator):
+        """Test spot machines get spot GPU prices"""
+        result = estimator.estimate(EstimateRequest(resources=[ResourceSpec(
+            provider="gcp", instance_type="n1-standard-4", region="us-central1", pricing_model="spot",
+            accelerator_type="nvidia-tesla-v100",
+        )]))
+
+        assert result.line_items[0].hourly_cost == pytest.approx(0.04 + 0.992, abs=1e-4)
+
+    def test_validation(self):
+        """Test unknown accelerator types and counts without a type are rejected"""
+        with pytest.raises(ValidationError):
+            ResourceSpec(instance_type="n1-standard-4", region="us-central1", accelerator_type="tpu-v4")
+        with pytest.raises(ValidationError):
+            ResourceSpec(instance_type="n1-standard-4", region="us-central1", accelerator_count=1)
@@ -0,0 +1,116 @@
+"""Unit tests for software license pricing"""
+
+import json
+
+import pytest
+
+from src.estimator import (
+    CostEstimator,
+    CommitmentOption,
+    EstimateRequest,
+    LicenseRates,
+    ResourceSpec,
+    UNIT_INSTANCE_HOUR,
+    explain_estimate,
+)
+from src.pricing import PriceNotFoundError, ProviderRegistry, StaticProvider
+from src.validation import CODE_UNPRICED, validate_estimate
+
+
+@pytest.fixture
+def registry():
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return registry
+
+
+def _resource(**spec):
+    return ResourceSpec(**{"provider": "aws", "instance_type": "m5.xlarge", "region": "us-east-1", **spec})
+
+
+class TestLicenseRates:
+    """Test cases for LicenseRates class"""
+
+    def test_units(self):
+        """Test per-vCPU licenses bill the vCPUs, at least min_vcpus, and agents every month"""
+        rates = LicenseRates({
+            "windows-server": {"unit": "vcpu_hour", "price": 0.05},
+            "sql-server-standard": {"unit": "vcpu_hour", "price": 0.2, "min_vcpus": 4},
+            "vendor-ami": {"unit": UNIT_INSTANCE_HOUR, "price": 0.3},
+            "agent": {"unit": "instance_month", "price": 10},
+        })
+        names = ["windows-server", "sql-server-standard", "vendor-ami", "agent"]
+        costs, hourly = rates.price("aws", "m5.large", names, 3, 100)
+        by_name = {cost.name: cost for cost in costs}
+
+        assert by_name["windows-server"].quantity == 2 * 3 * 100
+        assert by_name["windows-server"].monthly_cost == pytest.approx(30.0)
+        assert by_name["sql-server-standard"].quantity == 4 * 3 * 100
+        assert by_name["vendor-ami"].monthly_cost == pytest.approx(90.0)
+        assert by_name["agent"].monthly_cost == 30.0 and by_name["agent"].quantity == 3
+        assert hourly == pytest.approx(0.3 + 2.4 + 0.9 + 30 / 730, abs=1e-6)
+
+    def test_provider_rates(self):
+        """Test a provider's own entry takes the place of the shared one"""
+        rates = LicenseRates()
+        assert rates.rate("aws", "rhel")[1] == "aws/rhel"
+        assert rates.rate("gcp", "rhel")[1] == "rhel"
+        with pytest.raises(PriceNotFoundError):
+            rates.rate("aws", "oracle-db")
+        with pytest.raises(ValueError):
+            rates.price("aws", "m99.huge", ["windows-server"], 1, 730)
+
+    def test_from_file(self, tmp_path):
+        """Test rate cards are loaded from JSON and units are checked"""
+        path = tmp_path / "licenses.json"
+        path.write_text(json.dumps({"Vendor-AMI": {"unit": "instance_hour", "price": 0.5}}))
+        assert LicenseRates.from_file(str(path)).rate("aws", "vendor-ami")[0].price == 0.5
+
+        path.write_text(json.dumps({"vendor-ami": {"unit": "core_year", "price": 0.5}}))
+        with pytest.raises(ValueError, match="Unknown license unit"):
+            LicenseRates.from_file(str(path))
+
+
+class TestLicenseEstimates:
+    """Test cases for estimates with licenses"""
+
+    def test_line_item_costs(self, registry):
+        """Test licenses add to the line item's costs and explanation"""
+        estimator = CostEstimator(registry)
+        plain = estimator.price_resource(_resource(count=2))
+        item = estimator.price_resource(_resource(count=2, licenses=["Windows-Server", "datadog-infra"]))
+
+        assert [license.name for license in item.licenses] == ["windows-server", "datadog-infra"]
+        licensed = sum(license.monthly_cost for license in item.licenses)
+        assert item.monthly_cost == pytest.approx(plain.monthly_cost + licensed, abs=1e-3)
+        assert item.hourly_cost > plain.hourly_cost
+
+        result = estimator.estimate(EstimateRequest(resources=[_resource(licenses=["windows-server"])]))
+        steps = explain_estimate(result)[0].steps
+        assert steps[-3].description == "License windows-server (windows-server)"
+        assert steps[-2].amount == result.line_items[0].monthly_cost
+
+    def test_billed_hours(self, registry):
+        """Test hourly licenses are billed for the instances' billed hours"""
+        item = CostEstimator(registry).price_resource(_resource(hours=3.75, runs=300, licenses=["windows-server"]))
+        assert item.licenses[0].quantity == pytest.approx(4 * item.billed_hours)
+
+    def test_commitments_leave_out_licenses(self, registry):
+        """Test licenses are not counted as savings of commitments"""
+        estimator = CostEstimator(registry)
+        option = CommitmentOption(type="reserved", term="1yr")
+        plain = estimator.estimate(EstimateRequest(resources=[_resource()], commitments=[option]))
+        licensed = estimator.estimate(EstimateRequest(resources=[_resource(licenses=["rhel"])], commitments=[option]))
+        assert (
+            licensed.commitment_comparison[0].on_demand_total_cost ==
+            plain.commitment_comparison[0].on_demand_total_cost
+        )
+
+    def test_unknown_license(self, registry):
+        """Test licenses missing from the rate card are violations"""
+        estimator = CostEstimator(registry)
+        request = EstimateRequest(resources=[_resource(licenses=["oracle-db"])])
+        with pytest.raises(PriceNotFoundError):
+            estimator.estimate(request)
+        violations = validate_estimate(estimator, request)
+        assert [(v.field, v.code) for v in violations] == [("resources[0].licenses", CODE_UNPRICED)]
@@ -0,0 +1,96 @@
+"""Unit tests for data transfer pricing"""
+
+import json
+
+import pytest
+
+from src.discounts import DiscountRule, DiscountSession
+from src.estimator import (
+    CostEstimator,
+    EstimateRequest,
+    ResourceSpec,
+    TrafficSpec,
+    TransferRates,
+    HOURS_PER_MONTH,
+)
+from src.pricing import PriceCatalog, PriceNotFoundError, ProviderRegistry, StaticProvider
+
+
+@pytest.fixture
+def estimator():
+    registry = ProviderRegistry()
+    registry.register(StaticProvider(PriceCatalog({"aws": {"us-east-1": {"m5.large": 0.1}}}, storage_prices={})))
+    return CostEstimator(registry)
+
+
+class TestTransferPricing:
+    """Test cases for traffic assumptions in estimates"""
+
+    def test_tiered_internet_egress(self, estimator):
+        """Test egress is billed over the free allowance and volume tiers"""
+        items = estimator.price_traffic(TrafficSpec(region="us-east-1", internet_egress_gb=20480))
+
+        (egress,) = items
+        assert [(t.gb, t.unit_price) for t in egress.tiers] == [(100, 0.0), (10140, 0.09), (10240, 0.085)]
+        assert egress.monthly_cost == pytest.approx(10140 * 0.09 + 10240 * 0.085)
+        assert egress.effective_unit_price == pytest.approx(egress.monthly_cost / 20480, abs=1e-6)
+        assert egress.hourly_cost == pytest.approx(egress.monthly_cost / HOURS_PER_MONTH, abs=1e-4)
+        assert egress.price_source == "aws/default"
+
+    def test_estimate_totals_include_transfer(self, estimator):
+        """Test transfer items add to the totals, one per direction with traffic"""
+        result = estimator.estimate(EstimateRequest(
+            resources=[ResourceSpec(instance_type="m5.large", region="us-east-1")],
+            traffic=[TrafficSpec(region="ap-northeast-2", inter_az_gb=1000, inter_region_gb=500)],
+        ))
+
+        assert [(i.direction, i.monthly_cost) for i in result.transfer_items] == [
+            ("inter_az", pytest.approx(20.0)),
+            ("inter_region", pytest.approx(40.0)),
+        ]
+        assert result.transfer_items[0].price_source == "aws/ap-northeast-2"
+        assert result.monthly_cost == pytest.approx(0.1 * HOURS_PER_MONTH + 60.0)
+        assert result.yearly_cost == pytest.approx(result.monthly_cost * 12, abs=1e-3)
+
+    def test_no_traffic(self, estimator):
+        """Test estimates without traffic assumptions have no transfer items"""
+        result = estimator.estimate(EstimateRequest(resources=[
+            ResourceSpec(instance_type="m5.large", region="us-east-1"),
+        ]))
+
+        assert result.transfer_items == []
+        assert estimator.price_traffic(TrafficSpec(provider="gcp", region="us-central1")) == []
+
+    def test_discount_rules(self, estimator):
+        """Test rules on the data_transfer service discount transfer items"""
+        rule = DiscountRule(
+            id="r1", name="egress-20", match={"service": "data_transfer", "sku": "internet_egress"}, rate=0.2,
+        )
+        items = estimator.price_traffic(
+            TrafficSpec(provider="gcp", region="us-central1", inter_az_gb=100, internet_egress_gb=100),
+            DiscountSession([rule]),
+        )
+
+        inter_az, egress = items
+        assert inter_az.discounts == []
+        assert egress.monthly_cost == pytest.approx(100 * 0.12 * 0.8)
+        assert egress.discounts[0].rule_id == "r1"
+
+    def test_unknown_provider(self, estimator):
+        """Test providers without rates are rejected"""
+        with pytest.raises(PriceNotFoundError):
+            estimator.price_traffic(TrafficSpec(provider="oci", region="us-ashburn-1", internet_egress_gb=1))
+
+    def test_rates_file(self, tmp_path):
+        """Test rates are loaded from JSON and validated"""
+        path = tmp_path / "rates.json"
+        path.write_text(json.dumps({"aws": {"default": {"internet_egress": [[None, 0.05]]}}}))
+        rates = TransferRates.from_file(str(path))
+
+        assert rates.tiers("aws", "eu-west-1", "internet_egress") == ([[None, 0.05]], "aws/default")
+        with pytest.raises(ValueError, match="open tier"):
+            TransferRates({"aws": {"default": {"internet_egress": [[100, 0.0]]}}})
+        with pytest.raises(ValueError, match="Unknown transfer direction"):
+            TransferRates({"aws": {"default": {"ingress": [[None, 0.0]]}}})
+        with pytest.raises(ValueError, match="increasing"):
+            TransferRates({"aws": {"default": {"inter_az": [[100, 0.01], [50, 0.01], [None, 0.01]]}}})
@@ -0,0 +1,89 @@
+"""Unit tests for object storage pricing"""
+
+import pytest
+from pydantic import ValidationError
+
+from src.discounts import DiscountRule, DiscountSession
+from src.estimator import CostEstimator, EstimateRequest, ObjectStorageSpec, HOURS_PER_MONTH
+from src.pricing import ProviderRegistry, StaticProvider
+
+
+@pytest.fixture
+def estimator():
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return CostEstimator(registry)
+
+
+def _costs(item):
+    return {c.component: pytest.approx(c.monthly_cost, abs=1e-4) for c in item.components}
+
+
+class TestObjectStoragePricing:
+    """Test cases for object storage classes in estimates"""
+
+    def test_tiered_storage_and_requests(self, estimator):
+        """Test standard storage is billed over its volume tiers and requests per 1000"""
+        item = estimator.price_object_storage(ObjectStorageSpec(
+            region="us-east-1", storage_gb=100000, write_requests=1_000_000, read_requests=10_000_000,
+        ))
+
+        assert item.sku == "Standard"
+        assert _costs(item) == {
+            "storage": 51200 * 0.023 + 48800 * 0.022,
+            "write_requests": 5.0,
+            "read_requests": 4.0,
+        }
+        storage = item.components[0]
+        assert storage.effective_unit_price == pytest.approx(storage.monthly_cost / 100000, abs=1e-6)
+        assert item.hourly_cost == pytest.approx(item.monthly_cost / HOURS_PER_MONTH, abs=1e-4)
+
+    def test_small_objects_in_infrequent_access(self, estimator):
+        """Test objects below the minimum billable size are billed as 128 KB"""
+        item = estimator.price_object_storage(ObjectStorageSpec(
+            region="us-east-1", storage_class="ia", storage_gb=100,
+            object_size_distribution=[{"size_kb": 64, "share": 0.5}, {"size_kb": 1024, "share": 0.5}],
+        ))
+
+        assert item.storage_class == "infrequent"
+        assert item.objects == round(100 * 1024 * 1024 / 544)
+        assert item.billable_storage_gb == pytest.approx(100 * 576 / 544, abs=1e-6)
+
+    def test_lifecycle_transitions_into_archive(self, estimator):
+        """Test transitions cost one request per object and archived objects carry overhead"""
+        item = estimator.price_object_storage(ObjectStorageSpec(
+            region="us-east-1", storage_class="archive", storage_gb=1024, transition_in_gb=1024,
+        ))
+
+        assert item.billable_storage_gb == pytest.approx(1064)
+        assert _costs(item) == {"storage": 1064 * 0.0036, "transitions": 1048.576 * 0.03}
+
+    def test_retrieval_and_discounts(self, estimator):
+        """Test retrieval fees and discount rules matching object storage services"""
+        rule = DiscountRule(id="r1", name="gcs-ops", match={"service": "object_requests"}, rate=0.5)
+
+        item = estimator.price_object_storage(ObjectStorageSpec(
+            provider="gcp", region="us-central1", storage_class="nearline", storage_gb=100,
+            read_requests=100_000, retrieval_gb=100,
+        ), DiscountSession([rule]))
+
+        assert item.sku == "NEARLINE"
+        assert _costs(item) == {"storage": 1.0, "read_requests": 0.05, "retrieval": 1.0}
+        assert [d.monthly_amount for d in item.discounts] == [pytest.approx(0.05)]
+
+    def test_estimate_totals(self, estimator):
+        """Test object storage items add to the totals of an estimate"""
+        result = estimator.estimate(EstimateRequest(object_storage=[
+            ObjectStorageSpec(provider="azure", region="eastus", storage_class="cool", storage_gb=1000),
+            ObjectStorageSpec(provider="azure", region="eastus", storage_class="archive", storage_gb=10000),
+        ]))
+
+        assert [i.sku for i in result.object_storage_items] == ["Cool LRS", "Archive LRS"]
+        assert result.monthly_cost == pytest.approx(10.0 + 9.9, abs=1e-3)
+
+    def test_validation(self):
+        """Test unknown classes and size distributions not adding up are rejected"""
+        with pytest.raises(ValidationError):
+            ObjectStorageSpec(region="us-east-1", storage_class="deep_freeze")
+        with pytest.raises(ValidationError):
+            ObjectStorageSpec(region="us-east-1", object_size_distribution=[{"size_kb": 10, "share": 0.5}])
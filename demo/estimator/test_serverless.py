@@ -0,0 +1,101 @@
+"""Unit tests for serverless pricing"""
+
+import pytest
+from pydantic import ValidationError
+
+from src.discounts import DiscountRule, DiscountSession
+from src.estimator import CostEstimator, EstimateRequest, ServerlessSpec, FreeTier
+from src.pricing import ProviderRegistry, StaticProvider
+
+
+@pytest.fixture
+def estimator():
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return CostEstimator(registry)
+
+
+def _costs(item):
+    return {c.component: pytest.approx(c.monthly_cost, abs=1e-4) for c in item.components}
+
+
+class TestServerlessPricing:
+    """Test cases for serverless items in estimates"""
+
+    def test_lambda_with_free_tier(self, estimator):
+        """Test Lambda requests and GB-seconds past the free grant, duration rounded up to 1 ms"""
+        item = estimator.price_serverless(ServerlessSpec(
+            region="us-east-1", invocations=10_000_000, duration_ms=120.4, memory_mb=512,
+        ))
+
+        assert item.platform == "lambda"
+        assert item.billed_seconds == pytest.approx(1_210_000)
+        assert item.vcpus is None
+        memory = item.components[1]
+        assert memory.quantity == pytest.approx(605_000)
+        assert memory.free_quantity == pytest.approx(400_000)
+        assert _costs(item) == {"requests": 9 * 0.20, "memory": 205_000 * 0.0000166667}
+
+    def test_arm64_without_free_tier(self, estimator):
+        """Test Graviton functions use the arm64 GB-second rate"""
+        item = estimator.price_serverless(ServerlessSpec(
+            region="us-east-1", invocations=1_000_000, duration_ms=1000, memory_mb=1024,
+            architecture="arm64", free_tier=False,
+        ))
+
+        assert _costs(item) == {"requests": 0.20, "memory": 1_000_000 * 0.0000133334}
+
+    def test_cloud_run_concurrency(self, estimator):
+        """Test Cloud Run bills vCPU and memory time shared by concurrent requests, in 100 ms steps"""
+        item = estimator.price_serverless(ServerlessSpec(
+            provider="gcp", region="us-central1", platform="cloud_run", invocations=4_000_000,
+            duration_ms=250, memory_mb=1024, vcpus=2, concurrency=10, free_tier=False,
+        ))
+
+        assert item.billed_seconds == pytest.approx(120_000)
+        assert _costs(item) == {"requests": 1.6, "memory": 120_000 * 0.0000025, "cpu": 240_000 * 0.000024}
+
+    def test_functions_defaults(self, estimator):
+        """Test Cloud Run functions derive vCPUs from memory and Azure rounds memory and duration up"""
+        gcf = estimator.price_serverless(ServerlessSpec(
+            provider="gcp", region="us-central1", memory_mb=256, invocations=1, free_tier=False,
+        ))
+        azure = estimator.price_serverless(ServerlessSpec(
+            provider="azure", region="eastus", memory_mb=200, duration_ms=20, invocations=1000, free_tier=False,
+        ))
+
+        assert gcf.platform == "cloud_functions"
+        assert gcf.vcpus == pytest.approx(0.167)
+        assert azure.memory_gb == pytest.approx(0.25)
+        assert azure.billed_seconds == pytest.approx(100)
+
+    def test_free_tier_shared_in_estimate(self, estimator):
+        """Test the free grant is used up in request order across an estimate's items"""
+        spec = {"region": "us-east-1", "invocations": 600_000, "duration_ms": 10}
+        result = estimator.estimate(EstimateRequest(serverless=[ServerlessSpec(**spec), ServerlessSpec(**spec)]))
+
+        first, second = result.serverless_items
+        assert first.components[0].free_quantity == pytest.approx(0.6)
+        assert second.components[0].free_quantity == pytest.approx(0.4)
+        assert result.monthly_cost == pytest.approx(0.2 * 0.2, abs=1e-4)
+
+    def test_discount_rules(self, estimator):
+        """Test discount rules match serverless services on the usage past the free grant"""
+        rule = DiscountRule(id="r1", name="run-cpu", match={"service": "serverless_cpu"}, rate=0.25)
+
+        item = estimator.price_serverless(ServerlessSpec(
+            provider="gcp", region="us-central1", platform="cloud_run", invocations=1_000_000, duration_ms=1000,
+        ), DiscountSession([rule]), FreeTier({}))
+
+        assert [d.monthly_amount for d in item.discounts] == [pytest.approx(0.25 * 1_000_000 * 0.000024)]
+
+    def test_validation(self):
+        """Test platforms of other providers and options the platform lacks are rejected"""
+        with pytest.raises(ValidationError):
+            ServerlessSpec(region="us-east-1", platform="cloud_run")
+        with pytest.raises(ValidationError):
+            ServerlessSpec(region="us-east-1", concurrency=4)
+        with pytest.raises(ValidationError):
+            ServerlessSpec(provider="azure", region="eastus", memory_mb=4096)
+        with pytest.raises(ValidationError):
+            ServerlessSpec(provider="gcp", region="us-central1", architecture="arm64")
@@ -0,0 +1 @@
+"""Tests for forecast module"""
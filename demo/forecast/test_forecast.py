@@ -0,0 +1,138 @@
+"""Unit tests for spend forecasts"""
+
+from datetime import date, datetime, timedelta, timezone
+
+import pytest
+
+from src.forecast import (
+    Forecaster,
+    exponential_smoothing,
+    linear,
+    seasonal,
+    MODEL_EXPONENTIAL_SMOOTHING,
+    MODEL_SEASONAL,
+)
+from src.store import ActualCostRecord
+
+TODAY = date(2026, 7, 1)
+
+
+def _history(store, amounts, project="web", labels=None):
+    start = TODAY - timedelta(days=len(amounts))
+    store.add_actual_costs([
+        ActualCostRecord(usage_date=start + timedelta(days=i), amount=amount, project=project, labels=labels or {})
+        for i, amount in enumerate(amounts) if amount
+    ])
+
+
+def _forecaster(store, **kwargs):
+    return Forecaster(store, clock=lambda: datetime(2026, 7, 1, 8, tzinfo=timezone.utc), **kwargs)
+
+
+class TestMethods:
+    """Test cases for the forecasting methods"""
+
+    def test_linear_extends_trend(self):
+        """Test a perfect line is extended exactly, with errors growing"""
+        projection = linear([10.0 + 2 * t for t in range(10)], 3)
+
+        assert projection.values == pytest.approx([30.0, 32.0, 34.0])
+        assert projection.residual_std == pytest.approx(0.0)
+
+        noisy = linear([10.0, 12.5, 13.0, 16.5, 17.0, 20.5], 5)
+        assert noisy.errors == sorted(noisy.errors)
+        assert noisy.errors[0] > 0
+
+    def test_exponential_smoothing_follows_level(self):
+        """Test Holt's method projects a flat series flat and a trend forward"""
+        flat = exponential_smoothing([50.0] * 20, 5)
+        assert flat.values == pytest.approx([50.0] * 5)
+
+        trend = exponential_smoothing([float(t) for t in range(20)], 2)
+        assert trend.values == pytest.approx([20.0, 21.0])
+        assert flat.errors == sorted(flat.errors)
+
+    def test_seasonal_repeats_weekly_pattern(self):
+        """Test the weekly pattern is carried into the forecast"""
+        week = [100.0, 100.0, 100.0, 100.0, 100.0, 20.0, 20.0]
+        projection = seasonal(week * 4, 7)
+
+        assert projection.values == pytest.approx(week, abs=1e-6)
+
+    def test_too_little_history(self):
+        """Test short series are rejected"""
+        with pytest.raises(ValueError):
+            linear([1.0, 2.0], 5)
+        with pytest.raises(ValueError):
+            seasonal([1.0] * 13, 5)
+
+
+class TestForecaster:
+    """Test cases for forecasting from actual costs"""
+
+    def test_linear_forecast_with_intervals(self, store):
+        """Test daily points, totals and intervals"""
+        _history(store, [100.0 + t + (3 if t % 2 else -3) for t in range(60)])
+
+        forecast = _forecaster(store).forecast("default", horizon_days=30)
+
+        assert forecast.history_start == TODAY - timedelta(days=60)
+        assert forecast.history_days == 60
+        assert len(forecast.points) == 30
+        assert forecast.points[0].date == TODAY
+        assert forecast.points[0].cost == pytest.approx(160.0, abs=1.0)
+        assert all(p.lower <= p.cost <= p.upper for p in forecast.points)
+        assert forecast.total_lower < forecast.total_cost < forecast.total_upper
+        assert forecast.total_cost == pytest.approx(sum(p.cost for p in forecast.points), abs=0.01)
+
+    def test_narrower_interval_at_lower_confidence(self, store):
+        """Test the confidence level sets the interval width"""
+        _history(store, [100.0 + (5 if t % 3 else -5) for t in range(30)])
+        forecaster = _forecaster(store)
+
+        wide = forecaster.forecast("default", confidence=0.99)
+        narrow = forecaster.forecast("default", confidence=0.8)
+
+        assert narrow.total_upper - narrow.total_lower < wide.total_upper - wide.total_lower
+
+    def test_history_starts_at_first_spend(self, store):
+        """Test days before the first cost are not counted, gaps count as zero"""
+        _history(store, [0.0] * 20 + [10.0, 0.0, 10.0, 10.0, 10.0])
+
+        forecast = _forecaster(store).forecast("default", model=MODEL_EXPONENTIAL_SMOOTHING)
+
+        assert forecast.history_days == 5
+        assert forecast.history_cost == pytest.approx(40.0)
+
+    def test_filters(self, store):
+        """Test project and label filters"""
+        _history(store, [10.0] * 14, project="web", labels={"env": "prod"})
+        _history(store, [90.0] * 14, project="data")
+
+        assert _forecaster(store).forecast("default", project="web").points[0].cost == pytest.approx(10.0)
+        forecast = _forecaster(store).forecast("default", labels={"env": "prod"}, model=MODEL_SEASONAL)
+        assert forecast.points[0].cost == pytest.approx(10.0)
+
+    def test_forecast_never_negative(self, store):
+        """Test a falling trend stops at zero"""
+        _history(store, [float(30 - t) for t in range(30)])
+
+        forecast = _forecaster(store).forecast("default", horizon_days=90)
+
+        assert all(p.cost >= 0 and p.lower >= 0 for p in forecast.points)
+
+    def test_invalid_parameters(self, store):
+        """Test invalid parameters and missing history"""
+        forecaster = _forecaster(store)
+        with pytest.raises(ValueError, match="No actual costs"):
+            forecaster.forecast("default")
+
+        _history(store, [10.0] * 10)
+        with pytest.raises(ValueError):
+            forecaster.forecast("default", horizon_days=400)
+        with pytest.raises(ValueError):
+            forecaster.forecast("default", model="arima")
+        with pytest.raises(ValueError):
+            forecaster.forecast("default", confidence=1.5)
+        with pytest.raises(ValueError, match="14 days"):
+            forecaster.forecast("default", model=MODEL_SEASONAL)
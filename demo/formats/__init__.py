@@ -0,0 +1 @@
+"""Tests for Formats module"""
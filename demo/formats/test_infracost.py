@@ -0,0 +1,133 @@
+"""Unit tests for Infracost-compatible output"""
+
+from datetime import datetime, timezone
+
+import pytest
+
+from src.formats import FORMAT_INFRACOST, INFRACOST_VERSION, infracost_document, negotiate_format
+
+NOW = datetime(2026, 8, 3, 9, tzinfo=timezone.utc)
+
+
+def _component(name="instance", sku="t3.medium", unit="hour", unit_price=0.0416, count=1, monthly_cost=30.368):
+    return {
+        "name": name, "sku": sku, "region": "us-east-1", "pricing_model": "on_demand", "unit": unit,
+        "unit_price": unit_price, "count": count, "size_gb": None, "monthly_cost": monthly_cost,
+    }
+
+
+class TestInfracostDocument:
+    """Test cases for the Infracost output layout"""
+
+    def test_plan_estimate(self):
+        """Test a plan estimate fills the past breakdown, breakdown and diff of one project"""
+        estimate = {
+            "added": [{
+                "address": "aws_instance.web", "type": "aws_instance", "action": "create",
+                "before_monthly_cost": 0.0, "after_monthly_cost": 30.368, "monthly_delta": 30.368,
+                "components": [_component()],
+            }],
+            "changed": [],
+            "destroyed": [{
+                "address": "aws_ebs_volume.old", "type": "aws_ebs_volume", "action": "delete",
+                "before_monthly_cost": 8.0, "after_monthly_cost": 0.0, "monthly_delta": -8.0,
+                "components": [_component("volume", "gp3", "GB-month", 0.08, 1, 8.0) | {"size_gb": 100}],
+            }],
+            "unpriced": [{"address": "aws_lambda_function.f", "type": "aws_lambda_function", "action": "create",
+                          "reason": "no mapper"}],
+            "before_monthly_cost": 8.0,
+            "after_monthly_cost": 30.368,
+            "monthly_delta": 22.368,
+            "currency": "USD",
+        }
+
+        document = infracost_document(estimate, "terraform", project="infra", estimate_id="e1", generated_at=NOW)
+
+        assert document["version"] == INFRACOST_VERSION
+        assert document["metadata"]["infracostCommand"] == "diff"
+        assert (document["totalMonthlyCost"], document["pastTotalMonthlyCost"]) == ("30.368", "8")
+        assert document["diffTotalMonthlyCost"] == "22.368"
+        assert document["timeGenerated"] == "2026-08-03T09:00:00Z"
+        project = document["projects"][0]
+        assert (project["name"], project["metadata"]) == ("infra", {"type": "terraform", "estimateId": "e1"})
+        web = project["breakdown"]["resources"][0]
+        assert (web["name"], web["resourceType"], web["monthlyCost"]) == ("aws_instance.web", "aws_instance", "30.368")
+        component = web["costComponents"][0]
+        assert (component["unit"], component["hourlyQuantity"], component["monthlyQuantity"]) == ("hours", "1", "730")
+        assert component["price"] == "0.0416"
+        old = project["pastBreakdown"]["resources"][0]
+        assert old["costComponents"][0]["monthlyQuantity"] == "100"
+        assert [r["monthlyCost"] for r in project["diff"]["resources"]] == ["30.368", "-8"]
+        assert project["summary"]["totalUnsupportedResources"] == 1
+        assert project["summary"]["unsupportedResourceCounts"] == {"aws_lambda_function": 1}
+
+    def test_resource_estimate(self):
+        """Test line items become resources of a breakdown, usage-based for data transfer"""
+        estimate = {
+            "line_items": [{
+                "name": "web", "provider": "aws", "region": "us-east-1", "instance_type": "m5.large", "count": 2,
+                "hours": 730, "pricing_model": "on_demand", "unit_price_hourly": 0.096, "hourly_cost": 0.192,
+                "monthly_cost": 140.16, "yearly_cost": 1681.92,
+            }],
+            "transfer_items": [{
+                "provider": "aws", "region": "us-east-1", "direction": "internet_egress", "gb_per_month": 100,
+                "effective_unit_price": 0.09, "price_source": "aws/us-east-1", "hourly_cost": 0.0123,
+                "monthly_cost": 9.0, "yearly_cost": 108.0,
+            }],
+            "hourly_cost": 0.2043,
+            "monthly_cost": 149.16,
+            "yearly_cost": 1789.92,
+            "currency": "EUR",
+        }
+
+        document = infracost_document(estimate, "resources", generated_at=NOW)
+
+        assert document["currency"] == "EUR"
+        assert document["metadata"]["infracostCommand"] == "breakdown"
+        assert document["pastTotalMonthlyCost"] is None and document["diffTotalMonthlyCost"] is None
+        assert document["totalMonthlyCost"] == "149.16"
+        assert document["totalMonthlyUsageCost"] == "9"
+        web, transfer = document["projects"][0]["breakdown"]["resources"]
+        assert web["costComponents"][0]["hourlyQuantity"] == "2"
+        assert web["monthlyUsageCost"] is None
+        assert transfer["costComponents"][0]["usageBased"] is True
+        assert document["summary"]["totalUsageBasedResources"] == 1
+
+    def test_kubernetes_estimate(self):
+        """Test workloads and volumes become resources, skipped objects unsupported"""
+        estimate = {
+            "node_rates": {"instance_type": "m5.large", "hourly_price": 0.096, "vcpus": 2, "memory_gb": 8,
+                           "cpu_hourly": 0.03, "memory_hourly": 0.004},
+            "workloads": [{
+                "kind": "Deployment", "name": "api", "namespace": "web", "replicas": 2, "cpu_cores": 1,
+                "memory_gb": 2, "cpu_monthly_cost": 43.8, "memory_monthly_cost": 11.68, "monthly_cost": 55.48,
+            }],
+            "volumes": [{
+                "name": "data", "namespace": "web", "storage_class": "gp3", "volume_type": "gp3", "size_gb": 50,
+                "count": 1, "unit_price": 0.08, "unit": "GB-month", "monthly_cost": 4.0,
+            }],
+            "compute_monthly_cost": 55.48,
+            "storage_monthly_cost": 4.0,
+            "monthly_cost": 59.48,
+            "yearly_cost": 713.76,
+            "skipped": ["CronJob/cleanup"],
+        }
+
+        document = infracost_document(estimate, "kubernetes", project="web", generated_at=NOW)
+
+        api, data = document["projects"][0]["breakdown"]["resources"]
+        assert (api["name"], api["resourceType"]) == ("web/Deployment/api", "kubernetes_deployment")
+        assert api["costComponents"][0]["monthlyQuantity"] == "1460"
+        assert data["costComponents"][0]["monthlyQuantity"] == "50"
+        assert document["summary"]["unsupportedResourceCounts"] == {"CronJob": 1}
+
+    def test_unsupported_layout(self):
+        """Test estimates without resources, line items or workloads are rejected"""
+        with pytest.raises(ValueError):
+            infracost_document({"nodes": []}, "cluster")
+
+    def test_format_parameter(self):
+        """Test infracost is a format only where it is allowed"""
+        assert negotiate_format("infracost", None, allowed=("json", FORMAT_INFRACOST)) == FORMAT_INFRACOST
+        with pytest.raises(ValueError):
+            negotiate_format("infracost", None)
@@ -0,0 +1,115 @@
+"""Unit tests for estimate and report output formats"""
+
+import csv
+import io
+
+import pytest
+
+from src.formats import (
+    FORMAT_CSV,
+    FORMAT_HTML,
+    FORMAT_JSON,
+    FORMAT_MARKDOWN,
+    negotiate_format,
+    render_csv,
+    render_document,
+    render_html,
+    render_markdown,
+    tabulate,
+)
+
+DOCUMENT = {
+    "estimate_id": "e-1",
+    "monthly_cost": 280.32,
+    "currency": "USD",
+    "line_items": [
+        {"name": "web", "instance_type": "m5.large", "count": 2, "monthly_cost": 140.16, "tags": ["a", "b"]},
+        {"name": "db|primary", "instance_type": "r5.large", "count": 1, "monthly_cost": 140.16,
+         "price": {"hourly": 0.192, "unit": "Hrs"}},
+    ],
+    "exchange_rate": {"currency": "USD", "rate": 1.0},
+    "budget_warnings": [],
+    "unpriced": ["Node/fargate"],
+    "spot": None,
+}
+
+
+class TestNegotiateFormat:
+    """Test cases for output format negotiation"""
+
+    def test_parameter_wins(self):
+        """Test the format parameter overrides the Accept header and accepts aliases"""
+        assert negotiate_format("CSV", "text/html") == FORMAT_CSV
+        assert negotiate_format("md", None) == FORMAT_MARKDOWN
+
+    def test_accept_header(self):
+        """Test the most preferred known type of the Accept header, JSON otherwise"""
+        assert negotiate_format(None, "text/html,application/xhtml+xml,*/*;q=0.8") == FORMAT_HTML
+        assert negotiate_format(None, "text/csv;q=0.5, text/markdown") == FORMAT_MARKDOWN
+        assert negotiate_format(None, "text/csv;q=0") == FORMAT_JSON
+        assert negotiate_format(None, "*/*") == FORMAT_JSON
+        assert negotiate_format(None, None) == FORMAT_JSON
+
+    def test_unknown_format(self):
+        """Test unknown and disallowed formats are rejected"""
+        with pytest.raises(ValueError, match="Unknown format"):
+            negotiate_format("xlsx", None)
+        with pytest.raises(ValueError):
+            negotiate_format("pdf", None)
+        assert negotiate_format("pdf", None, allowed=("json", "pdf")) == "pdf"
+
+
+class TestRender:
+    """Test cases for the CSV, Markdown and HTML renderers"""
+
+    def test_tabulate(self):
+        """Test scalars make up the summary and lists of objects become tables"""
+        summary, tables = tabulate(DOCUMENT)
+
+        assert summary == [
+            ("estimate_id", "e-1"),
+            ("monthly_cost", 280.32),
+            ("currency", "USD"),
+            ("exchange_rate.currency", "USD"),
+            ("exchange_rate.rate", 1.0),
+            ("unpriced", "Node/fargate"),
+        ]
+        table, = tables
+        assert table.name == "line_items"
+        assert table.columns == ["name", "instance_type", "count", "monthly_cost", "tags", "price.hourly", "price.unit"]
+        assert table.rows[0][4] == "a, b"
+        assert table.rows[1][-2:] == [0.192, "Hrs"]
+
+    def test_csv(self):
+        """Test the CSV holds the summary and each table as named sections"""
+        rows = list(csv.reader(io.StringIO(render_csv(DOCUMENT))))
+
+        assert rows[:3] == [["summary"], ["field", "value"], ["estimate_id", "e-1"]]
+        section = rows.index(["line_items"])
+        assert rows[section - 1] == []
+        assert rows[section + 1][:2] == ["name", "instance_type"]
+        assert rows[section + 2][:4] == ["web", "m5.large", "2", "140.16"]
+
+    def test_markdown(self):
+        """Test Markdown tables right-align numbers and escape pipes"""
+        text = render_markdown(DOCUMENT, "Cost estimate")
+
+        assert text.startswith("### Cost estimate\n")
+        assert "| monthly_cost | 280.32 |" in text
+        assert "|---|---|---:|---:|---|---:|---|" in text
+        assert "| db\\|primary | r5.large |" in text
+
+    def test_html(self):
+        """Test the HTML report is a standalone page with escaped values"""
+        text = render_html(DOCUMENT, "Cost <estimate>")
+
+        assert text.startswith("<!DOCTYPE html>")
+        assert "<h1>Cost &lt;estimate&gt;</h1>" in text
+        assert '<td class="number">280.32</td>' in text
+        assert "<script" not in text and "http" not in text
+
+    def test_render_document(self):
+        """Test the dispatcher rejects JSON, which the API serializes itself"""
+        assert render_document(DOCUMENT, FORMAT_CSV, "x") == render_csv(DOCUMENT)
+        with pytest.raises(ValueError):
+            render_document(DOCUMENT, FORMAT_JSON, "x")
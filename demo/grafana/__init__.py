@@ -0,0 +1 @@
+"""Tests for Grafana module"""
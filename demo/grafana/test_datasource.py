@@ -0,0 +1,166 @@
+"""Unit tests for the Grafana JSON datasource"""
+
+from datetime import date, datetime, timedelta, timezone
+
+import pytest
+
+from src.forecast import Forecaster
+from src.grafana import (
+    GrafanaAnnotationRequest,
+    GrafanaDatasource,
+    GrafanaQueryRequest,
+    parse_target,
+)
+from src.store import ActualCostRecord, BudgetRecord, EstimateRecord
+
+NOW = datetime(2026, 7, 15, 12, tzinfo=timezone.utc)
+RANGE = {"from": "2026-07-01T00:00:00Z", "to": "2026-07-14T23:59:59Z"}
+
+
+def ms(day):
+    return int(datetime(day.year, day.month, day.day, tzinfo=timezone.utc).timestamp() * 1000)
+
+
+@pytest.fixture
+def datasource(store):
+    forecaster = Forecaster(store, clock=lambda: NOW)
+    return GrafanaDatasource(store, forecaster, clock=lambda: NOW)
+
+
+def _actuals(store, days, project, service="compute", amount=10.0, tenant_id="default"):
+    store.add_actual_costs([
+        ActualCostRecord(
+            tenant_id=tenant_id,
+            usage_date=date(2026, 6, 20) + timedelta(days=i),
+            provider="aws",
+            service=service,
+            project=project,
+            labels={"team": "core"},
+            amount=amount,
+        )
+        for i in range(days)
+    ])
+
+
+def _query(*targets, **extra):
+    return GrafanaQueryRequest(range=RANGE, targets=[{"target": t, "refId": "A"} for t in targets], **extra)
+
+
+class TestParseTarget:
+    """Test cases for target parsing"""
+
+    def test_filters(self):
+        """Test the metric and its braced key=value filters"""
+        assert parse_target("actual_cost") == ("actual_cost", {})
+        assert parse_target(' actual_cost{service=compute, label.team="core"} ') == (
+            "actual_cost", {"service": "compute", "label.team": "core"}
+        )
+
+    def test_malformed(self):
+        """Test unknown metrics and filters without a value are rejected"""
+        with pytest.raises(ValueError, match="Unknown target"):
+            parse_target("spend")
+        with pytest.raises(ValueError, match="Malformed filter"):
+            parse_target("actual_cost{service}")
+
+
+class TestGrafanaDatasource:
+    """Test cases for GrafanaDatasource class"""
+
+    def test_search(self, datasource):
+        """Test every metric is listed and the search string narrows them"""
+        assert "budget" in datasource.search()
+        assert datasource.search("forecast") == ["forecast", "forecast_lower", "forecast_upper"]
+
+    def test_actual_cost(self, store, datasource):
+        """Test daily actuals in the range, filtered and split by a field"""
+        _actuals(store, 25, "web")
+        _actuals(store, 25, "batch", service="storage", amount=4.0)
+        _actuals(store, 25, "other", tenant_id="acme")
+
+        total, = datasource.query("default", _query("actual_cost"))
+        split = datasource.query("default", _query("actual_cost{service=compute,by=project}"))
+
+        assert total["target"] == "actual_cost"
+        assert total["datapoints"][0] == [14.0, ms(date(2026, 7, 1))]
+        assert len(total["datapoints"]) == 14
+        assert [s["target"] for s in split] == ["actual_cost web"]
+        assert split[0]["datapoints"][-1] == [10.0, ms(date(2026, 7, 14))]
+
+    def test_month_to_date(self, store, datasource):
+        """Test the month-to-date sum restarts each calendar month"""
+        _actuals(store, 25, "web")
+
+        series, = datasource.query("default", _query("actual_cost_month_to_date"))
+
+        assert series["datapoints"][0] == [10.0, ms(date(2026, 7, 1))]
+        assert series["datapoints"][-1] == [140.0, ms(date(2026, 7, 14))]
+
+    def test_filters_from_data_and_adhoc(self, store, datasource):
+        """Test target data and ad hoc filters narrow the series, braces taking precedence"""
+        _actuals(store, 25, "web")
+        _actuals(store, 25, "batch")
+
+        request = GrafanaQueryRequest(
+            range=RANGE,
+            targets=[{"target": "actual_cost", "data": {"project": "web"}}, {"target": "budget"}],
+            adhocFilters=[{"key": "label.team", "operator": "=", "value": "other"}],
+        )
+        series = datasource.query("default", request)
+
+        assert series[0]["datapoints"] == []
+        assert datasource.query("default", _query("actual_cost{project=web}"))[0]["datapoints"][0][0] == 10.0
+
+    def test_unknown_filter(self, datasource):
+        """Test filters a metric does not have are rejected"""
+        with pytest.raises(ValueError, match="Unknown filter 'kind'"):
+            datasource.query("default", _query("actual_cost{kind=terraform}"))
+
+    def test_estimates_and_annotations(self, store, datasource):
+        """Test recorded estimates as a series and as annotations"""
+        for day, cost in ((2, 100.0), (5, 120.0), (20, 999.0)):
+            store.save_estimate(EstimateRecord(
+                kind="terraform", project="web", monthly_cost=cost,
+                created_at=datetime(2026, 7, day, 9, tzinfo=timezone.utc),
+            ))
+
+        series, = datasource.query("default", _query("estimates{kind=terraform}"))
+        events = datasource.annotations("default", GrafanaAnnotationRequest(
+            range=RANGE, annotation={"name": "deploys", "query": "estimates{project=web}"},
+        ))
+
+        assert [p[0] for p in series["datapoints"]] == [100.0, 120.0]
+        assert len(events) == 2
+        assert events[0]["tags"] == ["terraform", "web"]
+        assert events[0]["annotation"]["name"] == "deploys"
+        with pytest.raises(ValueError, match="Annotations"):
+            datasource.annotations("default", GrafanaAnnotationRequest(range=RANGE, annotation={"query": "budget"}))
+
+    def test_budgets_and_table(self, store, datasource):
+        """Test budgets are flat series over the range and tables hold one row per point"""
+        store.save_budget(BudgetRecord(name="platform", amount=5000))
+        store.save_budget(BudgetRecord(name="data", amount=800, project="batch"))
+
+        series = datasource.query("default", _query("budget"))
+        table, = datasource.query("default", GrafanaQueryRequest(
+            range=RANGE, targets=[{"target": "budget{project=batch}", "type": "table"}]
+        ))
+
+        assert [s["target"] for s in series] == ["budget data", "budget platform"]
+        assert [p[0] for p in series[1]["datapoints"]] == [5000, 5000]
+        assert [c["text"] for c in table["columns"]] == ["Time", "Series", "Value"]
+        assert [r[1:] for r in table["rows"]] == [["budget data", 800], ["budget data", 800]]
+
+    def test_forecast(self, store, datasource):
+        """Test forecasts start today, run to the range's end and are empty without history"""
+        request = GrafanaQueryRequest(
+            range={"from": "2026-07-10T00:00:00Z", "to": "2026-07-20T00:00:00Z"}, targets=[{"target": "forecast"}]
+        )
+        assert datasource.query("default", request)[0]["datapoints"] == []
+
+        _actuals(store, 25, "web")
+        series, = datasource.query("default", request)
+
+        assert series["datapoints"][0] == [pytest.approx(10.0), ms(date(2026, 7, 15))]
+        assert series["datapoints"][-1][1] == ms(date(2026, 7, 20))
+        assert datasource.query("default", _query("forecast"))[0]["datapoints"] == []
@@ -0,0 +1 @@
+"""Tests for gRPC API module"""
@@ -0,0 +1,98 @@
+"""Unit tests for the gRPC EstimateService"""
+
+import asyncio
+
+import pytest
+
+grpc = pytest.importorskip("grpc")
+pb = pytest.importorskip("src.grpc_api.estimate_service_pb2", reason="run `make proto` first")
+
+from src.compare import Comparer  # noqa: E402
+from src.estimator import CostEstimator  # noqa: E402
+from src.grpc_api import EstimateServicer  # noqa: E402
+from src.pricing import ProviderRegistry, StaticProvider  # noqa: E402
+
+
+class AbortError(Exception):
+    """Raised by FakeContext.abort like grpc.aio does"""
+
+    def __init__(self, code, details):
+        super().__init__(details)
+        self.code = code
+
+
+class FakeContext:
+    """Servicer context that records the abort status"""
+
+    async def abort(self, code, details):
+        raise AbortError(code, details)
+
+
+def call(method, request):
+    return asyncio.run(method(request, FakeContext()))
+
+
+class TestEstimateServicer:
+    """Test cases for the EstimateService RPCs"""
+
+    @pytest.fixture
+    def servicer(self):
+        """Create servicer over the built-in rate card"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        return EstimateServicer(
+            CostEstimator(registry=registry),
+            Comparer(registry=registry),
+            registry,
+        )
+
+    def test_estimate(self, servicer):
+        """Test unset fields take the HTTP defaults"""
+        request = pb.EstimateRequest(resources=[
+            pb.ResourceSpec(instance_type="m5.large", region="us-east-1", count=2),
+        ])
+        response = call(servicer.Estimate, request)
+
+        item = response.estimate.line_items[0]
+        assert item.provider == "aws" and item.hours == 730.0 and item.pricing_model == "on_demand"
+        assert item.unit_price_hourly == pytest.approx(0.096)
+        assert response.estimate.monthly_cost == pytest.approx(0.096 * 2 * 730)
+        assert response.estimate.currency == "USD"
+        assert response.estimate_id == ""
+        assert not item.HasField("name")
+
+    def test_estimate_errors(self, servicer):
+        """Test validation and missing prices map to gRPC status codes"""
+        with pytest.raises(AbortError) as invalid:
+            call(servicer.Estimate, pb.EstimateRequest())
+        assert invalid.value.code == grpc.StatusCode.INVALID_ARGUMENT
+
+        missing = pb.EstimateRequest(resources=[pb.ResourceSpec(instance_type="m5.large", region="mars-1")])
+        with pytest.raises(AbortError) as not_found:
+            call(servicer.Estimate, missing)
+        assert not_found.value.code == grpc.StatusCode.NOT_FOUND
+
+    def test_compare(self, servicer):
+        """Test region lists and the cheapest option per provider"""
+        request = pb.CompareRequest(
+            vcpus=2, memory_gb=8, providers=["aws"],
+            regions={"aws": pb.RegionList(regions=["us-east-1"])},
+        )
+        comparison = call(servicer.Compare, request).comparison
+
+        assert comparison.options and all(o.region == "us-east-1" for o in comparison.options)
+        assert comparison.cheapest["aws"].monthly_cost == comparison.options[0].monthly_cost
+
+    def test_get_catalog(self, servicer):
+        """Test requested SKUs are priced or listed as missing"""
+        request = pb.GetCatalogRequest(provider="aws", region="us-east-1", skus=["m5.large", "x9.huge"])
+        response = call(servicer.GetCatalog, request)
+
+        assert [p.sku for p in response.prices] == ["m5.large"]
+        assert response.prices[0].price == pytest.approx(0.096)
+        assert response.prices[0].source == "static"
+        assert list(response.missing) == ["x9.huge"]
+
+        with pytest.raises(AbortError) as invalid:
+            call(servicer.GetCatalog, pb.GetCatalogRequest(provider="aws"))
+        assert invalid.value.code == grpc.StatusCode.INVALID_ARGUMENT
@@ -0,0 +1 @@
+"""Tests for Helm chart cost estimation module"""
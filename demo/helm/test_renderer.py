@@ -0,0 +1,93 @@
+"""Unit tests for Helm chart renderer"""
+
+import os
+import stat
+
+import pytest
+
+from src.helm import HelmRenderer, HelmRenderError, HelmUnavailableError
+from src.k8s import parse_manifests
+
+# Stand-in for helm: records its arguments and prints a rendered Deployment
+FAKE_HELM = """#!/bin/sh
+echo "$@" > "$ARGS_FILE"
+if [ -n "$FAIL" ]; then echo "Error: chart not found" >&2; exit 1; fi
+cat <<'YAML'
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: web
+        resources:
+          requests: {cpu: 500m, memory: 1Gi}
+YAML
+"""
+
+
+class TestHelmRenderer:
+    """Test cases for HelmRenderer class"""
+
+    @pytest.fixture
+    def args_file(self, tmp_path, monkeypatch):
+        """File the fake helm writes its arguments to"""
+        path = tmp_path / "args"
+        monkeypatch.setenv("ARGS_FILE", str(path))
+        return path
+
+    @pytest.fixture
+    def renderer(self, tmp_path):
+        """Renderer using the fake helm binary"""
+        helm = tmp_path / "helm"
+        helm.write_text(FAKE_HELM)
+        helm.chmod(helm.stat().st_mode | stat.S_IEXEC)
+        return HelmRenderer(helm_binary=str(helm), timeout_seconds=10)
+
+    def test_render_archive(self, renderer, args_file):
+        """Test uploaded archives are templated with values and namespace"""
+        manifests = renderer.render_archive(b"chart-bytes", values="replicaCount: 2\n",
+                                            release_name="shop", namespace="prod")
+
+        args = args_file.read_text().split()
+        assert args[:2] == ["template", "shop"]
+        assert args[2].endswith("chart.tgz")
+        assert args[args.index("--namespace") + 1] == "prod"
+        assert "--values" in args
+        assert parse_manifests(manifests).workloads[0].replicas == 2
+
+    def test_render_reference(self, renderer, args_file):
+        """Test repository references pass repo and version"""
+        renderer.render_reference("nginx", repo_url="https://charts.example.com", version="1.2.3")
+
+        args = args_file.read_text().split()
+        assert args[2] == "nginx"
+        assert args[args.index("--repo") + 1] == "https://charts.example.com"
+        assert args[args.index("--version") + 1] == "1.2.3"
+        assert "--values" not in args
+
+    def test_reference_validation(self, renderer):
+        """Test unsafe or incomplete references are rejected before running helm"""
+        with pytest.raises(HelmRenderError, match="repo_url is required"):
+            renderer.render_reference("nginx")
+        with pytest.raises(HelmRenderError, match="scheme 'file'"):
+            renderer.render_reference("nginx", repo_url="file:///etc")
+        with pytest.raises(HelmRenderError, match="Invalid chart name"):
+            renderer.render_reference("/etc/chart", repo_url="https://charts.example.com")
+
+    def test_helm_failure(self, renderer, args_file, monkeypatch):
+        """Test helm errors surface as HelmRenderError"""
+        monkeypatch.setenv("FAIL", "1")
+
+        with pytest.raises(HelmRenderError, match="chart not found"):
+            renderer.render_archive(b"chart-bytes")
+
+    def test_missing_binary(self):
+        """Test a missing helm binary is reported as unavailable"""
+        renderer = HelmRenderer(helm_binary=os.path.join("/nonexistent", "helm"))
+
+        with pytest.raises(HelmUnavailableError):
+            renderer.render_archive(b"chart-bytes")
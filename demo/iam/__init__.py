@@ -0,0 +1 @@
+"""Tests for IAM module"""
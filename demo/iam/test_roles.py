@@ -0,0 +1,104 @@
+"""Unit tests for tenant roles"""
+
+import pytest
+from pydantic import ValidationError
+
+from src.auth import Principal, SCOPE_ADMIN, SCOPE_ESTIMATE, SCOPE_READ
+from src.iam import RoleAssignmentSpec, RoleResolver, ROLE_ADMIN, ROLE_EDITOR, ROLE_VIEWER, scope_role
+from src.store import RoleAssignmentRecord, DEFAULT_TENANT
+from src.tenancy import is_operator
+
+
+class FakeClock:
+    def __init__(self):
+        self.now = 0.0
+
+    def __call__(self):
+        return self.now
+
+
+def _assign(store, tenant_id, subject, role):
+    return store.save_role_assignment(RoleAssignmentRecord(tenant_id=tenant_id, subject=subject, role=role))
+
+
+def _principal(**fields):
+    defaults = {"subject": "ci", "method": "api_key", "tenant_id": "acme", "scopes": [SCOPE_READ]}
+    return Principal(**dict(defaults, **fields))
+
+
+class TestRoleAssignments:
+    """Test cases for stored role assignments"""
+
+    def test_assign_replace_and_remove(self, store):
+        """Test a subject has one role per tenant"""
+        first = _assign(store, "acme", "alice", ROLE_VIEWER)
+        replaced = _assign(store, "acme", "alice", ROLE_EDITOR)
+        _assign(store, "globex", "alice", ROLE_ADMIN)
+
+        assert replaced.created_at == first.created_at
+        assert [(r.subject, r.role) for r in store.list_role_assignments("acme")] == [("alice", ROLE_EDITOR)]
+        assert store.get_role_assignment("globex", "alice").role == ROLE_ADMIN
+        assert store.delete_role_assignment("acme", "alice")
+        assert not store.delete_role_assignment("acme", "alice")
+        assert store.get_role_assignment("acme", "alice") is None
+
+    def test_roles(self):
+        """Test role names are normalized and scopes map to the equivalent role"""
+        assert RoleAssignmentSpec(role=" Editor ").role == ROLE_EDITOR
+        with pytest.raises(ValidationError):
+            RoleAssignmentSpec(role="owner")
+        assert scope_role([SCOPE_READ, SCOPE_ESTIMATE]) == ROLE_EDITOR
+        assert scope_role([SCOPE_ADMIN]) == ROLE_ADMIN
+        assert scope_role([]) is None
+
+
+class TestRoleResolver:
+    """Test cases for binding callers to their role in a tenant"""
+
+    def test_role_takes_place_of_scopes(self, store):
+        """Test an assigned role grants more, or less, than the credential's scopes"""
+        _assign(store, "acme", "k1", ROLE_ADMIN)
+        _assign(store, "acme", "ops", ROLE_VIEWER)
+        resolver = RoleResolver(store)
+
+        tenant_id, principal = resolver.bind(_principal(key_id="k1"))
+        assert tenant_id == "acme"
+        assert (principal.role, principal.has_scope(SCOPE_ADMIN)) == (ROLE_ADMIN, True)
+
+        _, principal = resolver.bind(_principal(subject="ops", method="oidc", scopes=[SCOPE_ADMIN]))
+        assert (principal.role, principal.has_scope(SCOPE_ESTIMATE)) == (ROLE_VIEWER, False)
+
+        _, principal = resolver.bind(_principal(subject="other"))
+        assert (principal.role, principal.scopes) == (None, [SCOPE_READ])
+
+    def test_role_in_another_tenant(self, store):
+        """Test callers act for tenants they have a role in, and only as members of them"""
+        _assign(store, "globex", "alice", ROLE_EDITOR)
+        _assign(store, "globex", "root", ROLE_ADMIN)
+        resolver = RoleResolver(store)
+
+        tenant_id, principal = resolver.bind(_principal(subject="alice", method="oidc"), "globex")
+        assert (tenant_id, principal.tenant_id, principal.role) == ("globex", "globex", ROLE_EDITOR)
+        with pytest.raises(PermissionError):
+            resolver.bind(_principal(subject="alice", method="oidc"), "initech")
+        with pytest.raises(ValueError):
+            resolver.bind(_principal(), "Not A Tenant")
+
+        # An admin role in a tenant does not make its holder an operator of every tenant
+        _, principal = resolver.bind(_principal(subject="root", tenant_id=DEFAULT_TENANT), "globex")
+        assert not is_operator(principal)
+
+    def test_assignments_cached(self, store):
+        """Test assignments are cached per tenant until they expire or are invalidated"""
+        clock = FakeClock()
+        resolver = RoleResolver(store, ttl=60, clock=clock)
+        assert resolver.role("acme", "alice") is None
+
+        _assign(store, "acme", "alice", ROLE_EDITOR)
+        assert resolver.role("acme", "alice") is None
+        clock.now = 61
+        assert resolver.role("acme", "alice") == ROLE_EDITOR
+
+        store.delete_role_assignment("acme", "alice")
+        resolver.invalidate("acme")
+        assert resolver.role("acme", "alice") is None
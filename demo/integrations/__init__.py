@@ -0,0 +1 @@
+"""Tests for integrations module"""
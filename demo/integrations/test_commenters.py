@@ -0,0 +1,144 @@
+"""Tests for pull request commenters"""
+
+import json
+
+import pytest
+import requests
+
+from src.integrations import (
+    ACTION_CREATED,
+    ACTION_UNCHANGED,
+    ACTION_UPDATED,
+    GitHubCommenter,
+    GitLabCommenter,
+    IntegrationError,
+    ci_defaults,
+    format_comment,
+    marker,
+    post_or_update,
+)
+
+DIFF = {"passed": True, "markdown": "### Cost diff: ✅ passed\n\n| | main | PR |\n"}
+
+
+class FakeSession(requests.Session):
+    """Session answering requests from a queue of (status, body, headers)"""
+
+    def __init__(self, *responses):
+        super().__init__()
+        self.responses = list(responses)
+        self.sent = []
+
+    def request(self, method, url, **kwargs):
+        self.sent.append((method, url, kwargs))
+        status_code, body, headers = self.responses.pop(0)
+        response = requests.Response()
+        response.status_code = status_code
+        response._content = json.dumps(body).encode()
+        response.headers.update(headers)
+        return response
+
+
+class TestPostOrUpdate:
+    """Test cases for post_or_update"""
+
+    def test_marker_and_body(self):
+        body = format_comment(DIFF, "infra/prod")
+        assert body.startswith("<!-- kcloud-cost-estimator:infra/prod -->\n### Cost diff")
+        with pytest.raises(ValueError):
+            marker("two words")
+
+    def test_github_create_update_unchanged(self):
+        body = format_comment(DIFF)
+        session = FakeSession(
+            (200, [{"id": 1, "body": "LGTM", "html_url": "https://github.com/o/r/pull/7#c1"}], {}),
+            (201, {"id": 2, "body": body, "html_url": "https://github.com/o/r/pull/7#c2"}, {}),
+        )
+        commenter = GitHubCommenter("o/r", "ghp_x", session=session)
+
+        result = post_or_update(commenter, 7, body)
+
+        assert result == (ACTION_CREATED, "2", "https://github.com/o/r/pull/7#c2")
+        method, url, kwargs = session.sent[1]
+        assert (method, url) == ("POST", "https://api.github.com/repos/o/r/issues/7/comments")
+        assert kwargs["json"] == {"body": body}
+        assert session.headers["Authorization"] == "Bearer ghp_x"
+
+        session.responses = [(200, [{"id": 2, "body": body, "html_url": "u"}], {})]
+        assert post_or_update(commenter, 7, body).action == ACTION_UNCHANGED
+
+        newer = format_comment({"markdown": "### Cost diff: ❌ failed\n"})
+        session.responses = [
+            (200, [{"id": 2, "body": body, "html_url": "u"}], {}),
+            (200, {"id": 2, "body": newer, "html_url": "u"}, {}),
+        ]
+        assert post_or_update(commenter, 7, newer).action == ACTION_UPDATED
+        assert session.sent[-1][:2] == ("PATCH", "https://api.github.com/repos/o/r/issues/comments/2")
+
+    def test_keys_keep_separate_comments(self):
+        prod = format_comment(DIFF, "prod")
+        session = FakeSession(
+            (200, [{"id": 5, "body": prod}], {}),
+            (201, {"id": 6, "body": "x"}, {}),
+        )
+        result = post_or_update(GitHubCommenter("o/r", "t", session=session), 7, format_comment(DIFF, "dev"), "dev")
+        assert result.action == ACTION_CREATED
+
+    def test_github_pagination_and_errors(self):
+        body = format_comment(DIFF)
+        next_page = "https://api.github.com/repositories/1/issues/7/comments?per_page=100&page=2"
+        session = FakeSession(
+            (200, [{"id": 1, "body": "first"}], {"Link": f'<{next_page}>; rel="next"'}),
+            (200, [{"id": 9, "body": body}], {}),
+        )
+        commenter = GitHubCommenter("o/r", "t", session=session)
+        assert post_or_update(commenter, 7, body) == (ACTION_UNCHANGED, "9", None)
+        assert session.sent[1][1] == next_page and session.sent[1][2]["params"] is None
+
+        session.responses = [(403, {"message": "Resource not accessible by integration"}, {})]
+        with pytest.raises(IntegrationError, match="HTTP 403: Resource not accessible"):
+            post_or_update(commenter, 7, body)
+        with pytest.raises(ValueError):
+            GitHubCommenter("repo", "t")
+
+    def test_gitlab_notes(self):
+        body = format_comment(DIFF)
+        session = FakeSession(
+            (200, [{"id": 10, "body": "added 1 commit", "system": True}], {"X-Next-Page": "2"}),
+            (200, [{"id": 11, "body": body.replace("passed", "old")}], {"X-Next-Page": ""}),
+            (200, {"id": 11, "body": body}, {}),
+        )
+        commenter = GitLabCommenter(
+            "group/app", "glpat-x", api_url="https://gitlab.example.com/api/v4/",
+            session=session, web_url="https://gitlab.example.com/group/app",
+        )
+
+        result = post_or_update(commenter, 3, body)
+
+        assert result == (ACTION_UPDATED, "11", "https://gitlab.example.com/group/app/-/merge_requests/3#note_11")
+        notes = "https://gitlab.example.com/api/v4/projects/group%2Fapp/merge_requests/3/notes"
+        assert [s[:2] for s in session.sent] == [("GET", notes), ("GET", notes), ("PUT", f"{notes}/11")]
+        assert session.sent[1][2]["params"]["page"] == "2"
+        assert session.headers["PRIVATE-TOKEN"] == "glpat-x"
+
+        session.responses = [(200, [], {}), (401, {"message": "401 Unauthorized"}, {})]
+        with pytest.raises(IntegrationError, match="GitLab API POST"):
+            post_or_update(commenter, 3, body)
+
+
+class TestCIDefaults:
+    """Test cases for ci_defaults"""
+
+    def test_environments(self):
+        github = ci_defaults("github", {
+            "GITHUB_REPOSITORY": "o/r", "GITHUB_REF": "refs/pull/42/merge", "GITHUB_TOKEN": "t",
+        })
+        assert (github["repository"], github["number"], github["token"]) == ("o/r", 42, "t")
+        assert ci_defaults("github", {"GITHUB_REF": "refs/heads/main"})["number"] is None
+
+        gitlab = ci_defaults("gitlab", {"CI_PROJECT_ID": "17", "CI_MERGE_REQUEST_IID": "3",
+                                        "CI_API_V4_URL": "https://gitlab.example.com/api/v4"})
+        assert (gitlab["repository"], gitlab["number"], gitlab["api_url"]) == (
+            "17", 3, "https://gitlab.example.com/api/v4")
+        with pytest.raises(ValueError):
+            ci_defaults("bitbucket", {})
@@ -0,0 +1 @@
+"""Tests for inventory module"""
@@ -0,0 +1,181 @@
+"""Unit tests for inventory collection from cloud APIs"""
+
+from datetime import datetime, timezone
+from types import SimpleNamespace
+
+import pytest
+
+from src.inventory import (
+    AzureResourceGraphCollector,
+    EC2Collector,
+    GCPComputeCollector,
+    IdleDetector,
+    InventoryCollection,
+    InventoryCollector,
+    InventoryResource,
+    REASON_UNATTACHED_VOLUME,
+    build_collection,
+)
+from src.inventory.azure_graph import row_resource
+from src.inventory.gcp_compute import zone_region
+from src.pricing import ProviderRegistry, StaticProvider
+
+NOW = datetime(2026, 10, 14, 9, tzinfo=timezone.utc)
+
+
+class _Collector(InventoryCollector):
+    source = "test-api"
+
+    def __init__(self, resources):
+        self.resources = resources
+
+    def collect(self):
+        if isinstance(self.resources, Exception):
+            raise self.resources
+        return self.resources
+
+
+class TestInventoryCollection:
+    """Test cases for InventoryCollection class"""
+
+    def test_snapshot_replaced(self, store):
+        """Test each collection replaces the source's previous resources"""
+        collector = _Collector([
+            InventoryResource(resource_id="i-1", kind="instance", provider="aws", sku="m5.large"),
+            InventoryResource(resource_id="vol-1", kind="volume", provider="aws", sku="gp3", size_gb=10),
+        ])
+        collection = InventoryCollection(store, "acme", [collector], clock=lambda: NOW)
+
+        result = collection.collect("test-api")
+        assert (result.resources, result.kinds, result.collected_at) == (2, {"instance": 1, "volume": 1}, NOW)
+
+        collector.resources = collector.resources[1:]
+        collection.collect("test-api")
+        [volume] = store.list_inventory("acme")
+        assert (volume.resource_id, volume.source, volume.observed_at) == ("vol-1", "test-api", NOW)
+
+    def test_unknown_source_and_failures(self, store):
+        """Test unconfigured sources are refused and failing collectors skipped"""
+        failing = _Collector(OSError("throttled"))
+        collection = InventoryCollection(store, "acme", [failing])
+
+        with pytest.raises(KeyError, match="configured: test-api"):
+            collection.collect("aws-ec2")
+        assert collection.collect_all() == []
+
+    def test_build_collection(self, store):
+        """Test a collector is configured per cloud with regions, projects or subscriptions"""
+        settings = SimpleNamespace(
+            inventory_tenant="default",
+            inventory_aws_regions=["us-east-1"],
+            inventory_gcp_projects=[],
+            inventory_azure_subscriptions=["sub-1"],
+        )
+        assert build_collection(settings, store).sources == ["aws-ec2", "azure-resource-graph"]
+        settings.inventory_aws_regions, settings.inventory_azure_subscriptions = [], []
+        assert build_collection(settings, store) is None
+
+
+class _Paginator:
+    def __init__(self, pages):
+        self.pages = pages
+
+    def paginate(self):
+        return iter(self.pages)
+
+
+class _EC2:
+    def __init__(self, region):
+        self.region = region
+
+    def get_paginator(self, operation):
+        if operation == "describe_instances":
+            return _Paginator([{"Reservations": [{"Instances": [
+                {"InstanceId": "i-1", "InstanceType": "m5.large", "State": {"Name": "stopped"},
+                 "Tags": [{"Key": "Name", "Value": "web-1"}, {"Key": "app", "Value": "web"}]},
+                {"InstanceId": "i-2", "InstanceType": "m5.large", "State": {"Name": "terminated"}},
+            ]}]}])
+        return _Paginator([
+            {"Volumes": [{"VolumeId": "vol-1", "VolumeType": "gp3", "Size": 100, "State": "in-use",
+                          "Attachments": [{"InstanceId": "i-1"}]}]},
+            {"Volumes": [{"VolumeId": "vol-2", "VolumeType": "gp3", "Size": 500, "State": "available"}]},
+        ])
+
+
+class TestCloudCollectors:
+    """Test cases for the EC2, Compute Engine and Resource Graph collectors"""
+
+    def test_ec2(self, store):
+        """Test EC2 instances and volumes are listed per region, without terminated instances"""
+        session = SimpleNamespace(client=lambda service, region_name: _EC2(region_name))
+        resources = EC2Collector(["us-east-1"], session=session).collect()
+
+        assert [(r.resource_id, r.kind, r.state) for r in resources] == [
+            ("i-1", "instance", "stopped"), ("vol-1", "volume", "in-use"), ("vol-2", "volume", "available"),
+        ]
+        instance, attached = resources[0], resources[1]
+        assert (instance.name, instance.region) == ("web-1", "us-east-1")
+        assert instance.labels == {"Name": "web-1", "app": "web"}
+        assert (attached.attached_to, attached.size_gb) == ("i-1", 100.0)
+
+        # Collected resources feed idle detection
+        store.replace_inventory("acme", "aws-ec2", [r.to_record("acme", "aws-ec2") for r in resources])
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        findings = IdleDetector(store, registry).detect("acme").findings
+        assert [(f.resource_id, f.reason) for f in findings] == [
+            ("vol-2", REASON_UNATTACHED_VOLUME), ("i-1", "stopped_instance"),
+        ]
+
+    def test_gcp_compute(self):
+        """Test instances and disks of every zone are listed, disks attached to their users"""
+        base = "https://www.googleapis.com/compute/v1/projects/shop/zones/us-central1-a"
+        instance = SimpleNamespace(
+            self_link=f"{base}/instances/web-1", name="web-1", zone=base, status="TERMINATED",
+            machine_type=f"{base}/machineTypes/n2-standard-4", labels={"app": "web"},
+        )
+        disk = SimpleNamespace(
+            self_link=f"{base}/disks/web-1", name="web-1", zone=base, region="", type_=f"{base}/diskTypes/pd-balanced",
+            size_gb=50, status="READY", users=[instance.self_link], labels={},
+        )
+        instances = SimpleNamespace(aggregated_list=lambda project: [
+            ("zones/us-central1-a", SimpleNamespace(instances=[instance])),
+            ("zones/us-east1-b", SimpleNamespace(instances=[])),
+        ])
+        disks = SimpleNamespace(aggregated_list=lambda project: [
+            ("zones/us-central1-a", SimpleNamespace(disks=[disk])),
+        ])
+
+        vm, volume = GCPComputeCollector(["shop"], instances=instances, disks=disks).collect()
+        assert (vm.sku, vm.region, vm.state) == ("n2-standard-4", "us-central1", "terminated")
+        assert vm.labels == {"app": "web"}
+        assert (volume.sku, volume.state, volume.attached_to, volume.size_gb) == (
+            "pd-balanced", "in-use", vm.resource_id, 50.0,
+        )
+        assert zone_region("asia-northeast3-c") == "asia-northeast3"
+
+    def test_azure_resource_graph(self):
+        """Test VMs take their power state and disks the VM managing them"""
+        vm_id = "/subscriptions/s/resourceGroups/RG/providers/Microsoft.Compute/virtualMachines/web-1"
+        rows = [
+            {"id": vm_id, "name": "web-1", "type": "Microsoft.Compute/virtualMachines", "location": "koreacentral",
+             "tags": {"app": "web"}, "properties": {
+                 "hardwareProfile": {"vmSize": "Standard_D4s_v5"},
+                 "extended": {"instanceView": {"powerState": {"code": "PowerState/deallocated"}}},
+             }},
+            {"id": vm_id + "_disk", "name": "web-1_disk", "type": "microsoft.compute/disks", "location": "koreacentral",
+             "sku": {"name": "Premium_LRS"}, "managedBy": vm_id,
+             "properties": {"diskSizeGB": 128, "diskState": "Attached"}},
+            {"id": "/subscriptions/s/.../ip", "type": "microsoft.network/publicipaddresses"},
+        ]
+
+        class _Graph(AzureResourceGraphCollector):
+            def rows(self):
+                return rows
+
+        vm, disk = _Graph(["s"]).collect()
+        assert (vm.resource_id, vm.sku, vm.state) == (vm_id.lower(), "Standard_D4s_v5", "deallocated")
+        assert vm.labels == {"app": "web"}
+        assert (disk.sku, disk.state, disk.attached_to) == ("Premium_LRS", "attached", vm.resource_id)
+        assert disk.size_gb == 128.0
+        assert row_resource(rows[2]) is None
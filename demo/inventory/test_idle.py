@@ -0,0 +1,128 @@
+"""Unit tests for idle resource detection"""
+
+import pytest
+
+from src.inventory import (
+    IdleDetector,
+    InventoryBatch,
+    InventoryResource,
+    KIND_VOLUME,
+    REASON_EMPTY_NODE_POOL,
+    REASON_STOPPED_INSTANCE,
+    REASON_UNATTACHED_VOLUME,
+    REASON_UNUSED_LOAD_BALANCER,
+)
+from src.pricing import ProviderRegistry, StaticProvider
+
+
+@pytest.fixture
+def detector(store):
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return IdleDetector(store, registry, load_balancer_hourly={"aws": 0.0225})
+
+
+def _ingest(store, *resources, source="aws-config"):
+    batch = InventoryBatch(source=source, resources=[
+        InventoryResource(provider="aws", region="us-east-1", **fields) for fields in resources
+    ])
+    store.replace_inventory("acme", batch.source, [r.to_record("acme", batch.source) for r in batch.resources])
+
+
+class TestIdleDetector:
+    """Test cases for IdleDetector class"""
+
+    def test_unattached_volume(self, store, detector):
+        """Test volumes attached to no instance waste their storage price"""
+        _ingest(
+            store,
+            dict(resource_id="vol-1", kind="volume", sku="gp3", size_gb=500, state="available"),
+            dict(resource_id="vol-2", kind="volume", sku="gp3", size_gb=100, state="in-use", attached_to="i-1"),
+            dict(resource_id="i-1", kind="instance", sku="m5.large", state="running"),
+        )
+
+        report = detector.detect("acme")
+        [finding] = report.findings
+        assert (finding.resource_id, finding.reason) == ("vol-1", REASON_UNATTACHED_VOLUME)
+        assert finding.waste_monthly_cost == pytest.approx(500 * 0.08)
+        assert finding.source == "aws-config"
+        assert report.resources == 3
+
+    def test_unused_load_balancer(self, store, detector):
+        """Test load balancers without targets or requests waste their hourly rate"""
+        _ingest(
+            store,
+            dict(resource_id="lb-1", kind="load_balancer", targets=0, requests=120),
+            dict(resource_id="lb-2", kind="load_balancer", targets=2, requests=0),
+            dict(resource_id="lb-3", kind="load_balancer", targets=2, requests=5000),
+            dict(resource_id="lb-4", kind="load_balancer"),
+        )
+
+        findings = detector.detect("acme").findings
+        assert [f.resource_id for f in findings] == ["lb-1", "lb-2"]
+        assert all(f.reason == REASON_UNUSED_LOAD_BALANCER for f in findings)
+        assert findings[0].waste_monthly_cost == pytest.approx(0.0225 * 730)
+
+    def test_stopped_instance(self, store, detector):
+        """Test stopped instances waste their volumes, and Azure VMs not deallocated their compute"""
+        _ingest(
+            store,
+            dict(resource_id="i-1", kind="instance", sku="m5.large", state="stopped"),
+            dict(resource_id="vol-1", kind="volume", sku="gp3", size_gb=50, attached_to="i-1"),
+            dict(resource_id="i-2", kind="instance", sku="m5.large", state="stopped"),
+        )
+
+        [finding] = detector.detect("acme").findings
+        assert (finding.resource_id, finding.reason) == ("i-1", REASON_STOPPED_INSTANCE)
+        assert finding.waste_monthly_cost == pytest.approx(50 * 0.08)
+
+        store.replace_inventory("acme", "azure", [
+            InventoryResource(
+                resource_id="vm-1", kind="instance", provider="azure", region="eastus",
+                sku="Standard_D2s_v3", state="Stopped",
+            ).to_record("acme", "azure"),
+        ])
+        vm = next(f for f in detector.detect("acme").findings if f.resource_id == "vm-1")
+        assert vm.waste_monthly_cost == pytest.approx(0.096 * 730)
+        assert "not deallocated" in vm.detail
+
+    def test_empty_node_pool(self, store, detector):
+        """Test node pools without pods waste their nodes"""
+        _ingest(
+            store,
+            dict(resource_id="pool-1", kind="node_pool", sku="m5.xlarge", nodes=3, pods=0),
+            dict(resource_id="pool-2", kind="node_pool", sku="m5.xlarge", nodes=3, pods=12),
+            dict(resource_id="pool-3", kind="node_pool", sku="m5.xlarge", nodes=0, pods=0),
+        )
+
+        [finding] = detector.detect("acme").findings
+        assert (finding.resource_id, finding.reason) == ("pool-1", REASON_EMPTY_NODE_POOL)
+        assert finding.waste_monthly_cost == pytest.approx(3 * 0.192 * 730)
+
+    def test_unpriced_and_kind_filter(self, store, detector):
+        """Test findings that cannot be priced are listed without waste, and kinds filter findings"""
+        _ingest(
+            store,
+            dict(resource_id="vol-1", kind="volume", sku="sc9", size_gb=10),
+            dict(resource_id="lb-1", kind="load_balancer", targets=0),
+        )
+
+        report = detector.detect("acme", kind=KIND_VOLUME)
+        assert [f.resource_id for f in report.findings] == ["vol-1"]
+        assert report.unpriced == ["vol-1"]
+        assert report.waste_monthly_cost == 0.0
+        with pytest.raises(ValueError):
+            detector.detect("acme", kind="bucket")
+
+    def test_snapshot_replaces_source(self, store, detector):
+        """Test a source's new snapshot removes resources it no longer reports"""
+        _ingest(store, dict(resource_id="vol-1", kind="volume", sku="gp3", size_gb=10))
+        _ingest(store, dict(resource_id="vol-2", kind="volume", sku="gp3", size_gb=10), source="ebs-scan")
+        _ingest(store)
+
+        assert [r.resource_id for r in store.list_inventory("acme")] == ["vol-2"]
+        assert store.list_inventory("other") == []
+
+    def test_invalid_kind(self):
+        with pytest.raises(ValueError):
+            InventoryResource(resource_id="b-1", kind="bucket", provider="aws")
@@ -0,0 +1,99 @@
+"""Unit tests for region migration analysis"""
+
+import pytest
+
+from src.estimator import CostEstimator, ResourceSpec
+from src.inventory import (
+    InventoryBatch,
+    InventoryResource,
+    RegionMigrationAnalyzer,
+    RegionMigrationRequest,
+    matches_selector,
+)
+from src.pricing import ProviderRegistry, StaticProvider
+
+
+@pytest.fixture
+def analyzer(store):
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return RegionMigrationAnalyzer(CostEstimator(registry=registry), store)
+
+
+def _ingest(store, *resources):
+    batch = InventoryBatch(source="aws-config", resources=[
+        InventoryResource(provider="aws", region="us-east-1", **fields) for fields in resources
+    ])
+    store.replace_inventory("acme", batch.source, [r.to_record("acme", batch.source) for r in batch.resources])
+
+
+class TestRegionMigrationAnalyzer:
+    """Test cases for RegionMigrationAnalyzer class"""
+
+    def test_listed_resources(self, analyzer):
+        """Test listed resources are priced in both regions and SKUs the target lacks flagged"""
+        request = RegionMigrationRequest(target_region="ap-northeast-2", resources=[
+            ResourceSpec(name="web", instance_type="m5.large", region="us-east-1", count=2, hours=730),
+            ResourceSpec(name="gpu", instance_type="g5.xlarge", region="us-east-1", hours=730),
+        ])
+
+        result = analyzer.analyze("acme", request)
+        assert [(i.name, i.available) for i in result.items] == [("web", True), ("gpu", False)]
+        web, gpu = result.items
+        assert (web.current_monthly_cost, web.target_monthly_cost, web.monthly_delta) == (140.16, 172.28, 32.12)
+        assert gpu.current_monthly_cost == pytest.approx(734.38)
+        assert gpu.target_monthly_cost is None and gpu.monthly_delta is None
+        assert "g4dn.xlarge" in gpu.suggestions and len(gpu.suggestions) <= 3
+
+        # Totals leave out the unavailable GPU instance
+        assert (result.current_monthly_cost, result.target_monthly_cost) == (140.16, 172.28)
+        assert (result.monthly_delta, result.delta_percent) == (32.12, 22.9)
+        assert result.unavailable == ["gpu"]
+        assert result.summary == "1 resources to ap-northeast-2: +$32.12/month (+22.9%), 1 unavailable"
+
+    def test_inventory_selector(self, store, analyzer):
+        """Test inventory instances, node pools and volumes matching the selector are priced"""
+        _ingest(
+            store,
+            dict(resource_id="i-1", kind="instance", sku="m5.large", labels={"app": "web"}),
+            dict(resource_id="np-1", kind="node_pool", sku="c5.large", nodes=2, labels={"app": "web", "env": "prod"}),
+            dict(resource_id="vol-1", kind="volume", sku="gp3", size_gb=100, labels={"app": "web"}),
+            dict(resource_id="lb-1", kind="load_balancer", sku="alb", labels={"app": "web"}),
+            dict(resource_id="i-2", kind="instance", sku="m5.large", labels={"app": "db"}),
+        )
+        request = RegionMigrationRequest(target_region="ap-northeast-2", selector={"app": "web"}, hours=730)
+
+        result = analyzer.analyze("acme", request)
+        assert [(i.name, i.kind, i.count) for i in result.items] == [
+            ("i-1", "instance", 1), ("np-1", "node_pool", 2), ("vol-1", "volume", 1),
+        ]
+        assert [i.monthly_delta for i in result.items] == [16.06, 16.06, 1.12]
+        assert result.monthly_delta == 33.24
+        assert result.provider == "aws" and result.unavailable == [] and result.unpriced == []
+
+    def test_validation(self, store, analyzer):
+        """Test requests name resources of one cloud, and selectors need a store"""
+        with pytest.raises(ValueError, match="label selector"):
+            RegionMigrationRequest(target_region="eu-central-1")
+
+        mixed = RegionMigrationRequest(target_region="eu-central-1", resources=[
+            ResourceSpec(instance_type="m5.large", region="us-east-1"),
+            ResourceSpec(provider="gcp", instance_type="n2-standard-4", region="us-central1"),
+        ])
+        with pytest.raises(ValueError, match="name the provider"):
+            analyzer.analyze("acme", mixed)
+        with pytest.raises(ValueError, match="not aws"):
+            analyzer.analyze("acme", mixed.copy(update={"provider": "aws"}))
+        with pytest.raises(ValueError, match="No inventory resources"):
+            analyzer.analyze("acme", RegionMigrationRequest(target_region="eu-central-1", selector={"app": "web"}))
+
+        without_store = RegionMigrationAnalyzer(analyzer.estimator)
+        with pytest.raises(ValueError, match="STORE_URL"):
+            without_store.analyze("acme", RegionMigrationRequest(target_region="eu-central-1", selector={}))
+
+    def test_matches_selector(self):
+        """Test every key and value of a selector must be among the labels"""
+        assert matches_selector({"app": "web", "env": "prod"}, {"app": "web"})
+        assert matches_selector({"app": "web"}, {})
+        assert not matches_selector({"app": "web"}, {"app": "web", "env": "prod"})
+        assert not matches_selector({"app": "db"}, {"app": "web"})
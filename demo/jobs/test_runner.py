@@ -0,0 +1,224 @@
+"""Unit tests for background estimation jobs"""
+
+import threading
+import time
+from datetime import datetime, timedelta, timezone
+
+import pytest
+from pydantic import BaseModel
+
+from src.jobs import JobKind, JobOutcome, JobRunner
+from src.store import JOB_DEAD_LETTER, JOB_FAILED, JOB_QUEUED, JOB_RUNNING, JOB_SUCCEEDED, JobRecord, SQLiteStore
+from src.tenancy import current_tenant
+
+
+class Plan(BaseModel):
+    resources: int
+    secret: str = ""
+
+
+def count_resources(request: Plan, progress) -> JobOutcome:
+    for done in range(request.resources):
+        progress(done, request.resources, f"{done} of {request.resources}")
+    return JobOutcome({"resources": request.resources, "tenant": current_tenant()}, estimate_id="e1")
+
+
+def fail(request: Plan, progress) -> JobOutcome:
+    raise ValueError("Not a Terraform plan")
+
+
+def wait_idle(runner: JobRunner, timeout: float = 5) -> None:
+    deadline = time.monotonic() + timeout
+    while runner.idle_workers() < runner.workers:
+        assert time.monotonic() < deadline, "jobs still running"
+        time.sleep(0.01)
+
+
+class TestJobRunner:
+    """Test cases for JobRunner class"""
+
+    @pytest.fixture
+    def store(self):
+        store = SQLiteStore(":memory:")
+        store.migrate()
+        return store
+
+    def runner(self, store, **kwargs) -> JobRunner:
+        kinds = {
+            "count": JobKind(Plan, count_resources, lambda request: {"resources": request.resources}),
+            "fail": JobKind(Plan, fail),
+        }
+        return JobRunner(store, kinds, **kwargs)
+
+    def test_submit_runs_job(self, store):
+        runner = self.runner(store)
+
+        job = runner.submit("acme", "count", {"resources": 3, "secret": "s3cr3t"})
+        wait_idle(runner)
+
+        done = runner.get(job.id, tenant_id="acme")
+        assert done.state == JOB_SUCCEEDED
+        assert done.result == {"resources": 3, "tenant": "acme"}
+        assert (done.estimate_id, done.progress, done.attempts) == ("e1", 1.0, 1)
+        assert done.finished_at is not None
+        # The finished job keeps only the request summary
+        assert done.request == {"resources": 3}
+        assert runner.get(job.id, tenant_id="other") is None
+        assert [j.id for j in runner.list("acme")] == [job.id]
+
+    def test_failed_job_keeps_error(self, store):
+        runner = self.runner(store)
+
+        job = runner.submit("acme", "fail", {"resources": 1})
+        wait_idle(runner)
+
+        failed = runner.get(job.id)
+        assert (failed.state, failed.error, failed.attempts) == (JOB_FAILED, "Not a Terraform plan", 1)
+        assert runner.poll() == []
+
+    def test_invalid_requests_are_rejected(self, store):
+        runner = self.runner(store)
+
+        with pytest.raises(ValueError, match="Unknown job kind 'helm'"):
+            runner.submit("acme", "helm", {})
+        with pytest.raises(ValueError):
+            runner.submit("acme", "count", {"resources": "many"})
+        assert store.list_jobs() == []
+
+    def test_queued_jobs_wait_for_a_worker(self, store):
+        release = threading.Event()
+
+        def blocked(request: Plan, progress) -> JobOutcome:
+            release.wait(5)
+            return JobOutcome({})
+
+        runner = JobRunner(store, {"blocked": JobKind(Plan, blocked)}, workers=1)
+        first = runner.submit("acme", "blocked", {"resources": 1})
+        second = runner.submit("acme", "blocked", {"resources": 1})
+
+        assert (first.state, second.state) == (JOB_RUNNING, JOB_QUEUED)
+        assert runner.poll() == []
+        release.set()
+        wait_idle(runner)
+        assert runner.poll() == [second.id]
+        wait_idle(runner)
+        assert store.get_job(second.id).state == JOB_SUCCEEDED
+
+    def test_abandoned_job_is_resumed(self, store):
+        # A replica claimed the job, then stopped without finishing it
+        job = store.create_job(JobRecord(tenant_id="acme", kind="count", request={"resources": 2}))
+        store.claim_job(job.id, "stopped-replica", stale_before=datetime.now(timezone.utc))
+
+        fresh = self.runner(store, lease_seconds=60)
+        assert fresh.poll() == []
+
+        later = datetime.now(timezone.utc) + timedelta(seconds=61)
+        runner = self.runner(store, lease_seconds=60, worker_id="new-replica", clock=lambda: later)
+        assert runner.poll() == [job.id]
+        wait_idle(runner)
+
+        resumed = store.get_job(job.id)
+        assert (resumed.state, resumed.worker, resumed.attempts) == (JOB_SUCCEEDED, "new-replica", 2)
+
+    def test_job_abandoned_too_often_fails(self, store):
+        job = store.create_job(JobRecord(kind="count", request={"resources": 1}))
+        store.claim_job(job.id, "crashed", stale_before=datetime.now(timezone.utc))
+
+        later = datetime.now(timezone.utc) + timedelta(seconds=61)
+        runner = self.runner(store, lease_seconds=60, max_attempts=1, clock=lambda: later)
+        assert runner.poll() == [job.id]
+        wait_idle(runner)
+
+        failed = store.get_job(job.id)
+        assert failed.state == JOB_FAILED
+        assert "giving up" in failed.error
+
+    def test_closed_runner_starts_nothing(self, store):
+        runner = self.runner(store)
+        runner.close()
+
+        job = runner.submit("acme", "count", {"resources": 1})
+
+        assert job.state == JOB_QUEUED
+        assert runner.poll() == []
+        assert not runner.running
+
+    def test_failed_run_is_retried_after_backoff(self, store):
+        outages = [ConnectionError("export bucket unreachable")]
+
+        def ingest(request: Plan, progress) -> JobOutcome:
+            if outages:
+                raise outages.pop()
+            return JobOutcome({"ingested": True})
+
+        runner = JobRunner(store, {"ingest": JobKind(Plan, ingest, retries=2)}, retry_backoff=0)
+        job = runner.submit("acme", "ingest", {"resources": 1})
+        wait_idle(runner)
+
+        waiting = store.get_job(job.id)
+        assert (waiting.state, waiting.error, waiting.attempts) == (JOB_QUEUED, "export bucket unreachable", 1)
+        assert waiting.run_after is not None
+        assert runner.poll() == [job.id]
+        wait_idle(runner)
+
+        done = store.get_job(job.id)
+        assert (done.state, done.attempts, done.error, done.run_after) == (JOB_SUCCEEDED, 2, None, None)
+
+    def test_backoff_delays_retry(self, store):
+        def down(request: Plan, progress) -> JobOutcome:
+            raise ConnectionError("store down")
+
+        runner = JobRunner(store, {"down": JobKind(Plan, down, retries=1)}, retry_backoff=3600)
+        job = runner.submit("acme", "down", {"resources": 1})
+        wait_idle(runner)
+
+        assert runner.poll() == []
+        assert store.claim_job(job.id, "other", stale_before=datetime.now(timezone.utc)) is None
+
+    def test_retries_used_up_dead_letter(self, store):
+        def down(request: Plan, progress) -> JobOutcome:
+            raise ConnectionError("store down")
+
+        kinds = {"down": JobKind(Plan, down, retries=1), "invalid": JobKind(Plan, fail, retries=1)}
+        runner = JobRunner(store, kinds, retry_backoff=0)
+        job = runner.submit("acme", "down", {"resources": 1})
+        wait_idle(runner)
+        assert runner.poll() == [job.id]
+        wait_idle(runner)
+
+        dead = store.get_job(job.id)
+        assert (dead.state, dead.attempts, dead.error) == (JOB_DEAD_LETTER, 2, "store down")
+        assert [j.id for j in runner.list("acme", states=[JOB_DEAD_LETTER])] == [job.id]
+        assert runner.poll() == []
+
+        # Invalid input fails at once
+        invalid = runner.submit("acme", "invalid", {"resources": 1})
+        wait_idle(runner)
+        assert store.get_job(invalid.id).state == JOB_FAILED
+
+    def test_requeue(self, store):
+        runner = self.runner(store)
+        job = runner.submit("acme", "fail", {"resources": 1})
+        wait_idle(runner)
+
+        assert runner.requeue(job.id, tenant_id="other") is None
+        requeued = runner.requeue(job.id, tenant_id="acme")
+        wait_idle(runner)
+
+        assert requeued.id == job.id
+        again = store.get_job(job.id)
+        assert (again.state, again.attempts) == (JOB_FAILED, 1)
+        succeeded = runner.submit("acme", "count", {"resources": 1})
+        wait_idle(runner)
+        assert runner.requeue(succeeded.id) is None
+
+    def test_runner_without_workers_only_queues(self, store):
+        api = self.runner(store, workers=0)
+        job = api.submit("acme", "count", {"resources": 1})
+
+        assert job.state == JOB_QUEUED
+        assert api.poll() == []
+        worker = self.runner(store, worker_id="worker-replica")
+        assert worker.poll() == [job.id]
+        wait_idle(worker)
+        assert store.get_job(job.id).worker == "worker-replica"
@@ -0,0 +1 @@
+"""Tests for Kubernetes cost estimation module"""
@@ -0,0 +1,149 @@
+"""Unit tests for ARM migration recommendations"""
+
+import pytest
+
+from src.k8s import (
+    ARM_COMPATIBLE_ANNOTATION,
+    ArmMigrationAnalyzer,
+    ArmMigrationRequest,
+    ClusterEstimator,
+    ClusterSnapshot,
+    ClusterSource,
+    KubernetesEstimator,
+)
+from src.pricing import PriceCatalog, ProviderRegistry, StaticProvider
+
+PRICES = {"aws": {"us-east-1": {
+    "m5.xlarge": 0.192,
+    "m6g.xlarge": 0.154,
+    "m7g.xlarge": 0.1632,
+    "t3.medium": 0.0416,
+    "t4g.medium": 0.0336,
+    "c6g.xlarge": 0.136,
+}}}
+
+
+def _node(name, instance_type):
+    return {
+        "metadata": {"name": name, "labels": {
+            "node.kubernetes.io/instance-type": instance_type,
+            "topology.kubernetes.io/region": "us-east-1",
+        }},
+        "spec": {"providerID": f"aws:///us-east-1a/i-{name}"},
+    }
+
+
+def _workload(name, annotations=None, **pod_spec):
+    return {
+        "kind": "Deployment",
+        "metadata": {"name": name, "namespace": "web", "annotations": annotations or {}},
+        "spec": {"replicas": 2, "template": {"spec": {
+            "containers": [{"name": "app", "resources": {"requests": {"cpu": "1", "memory": "2Gi"}}}],
+            **pod_spec,
+        }}},
+    }
+
+
+class FakeSource(ClusterSource):
+    def __init__(self, snapshot):
+        self._snapshot = snapshot
+
+    def snapshot(self):
+        return self._snapshot
+
+
+def _analyzer(workloads, nodes=None):
+    registry = ProviderRegistry()
+    registry.register(StaticProvider(PriceCatalog(PRICES, storage_prices={})))
+    snapshot = ClusterSnapshot(
+        nodes=nodes or [_node("a", "m5.xlarge"), _node("b", "m5.xlarge")],
+        workloads=workloads,
+        volume_claims=[],
+        services=[],
+    )
+    return ArmMigrationAnalyzer(ClusterEstimator(KubernetesEstimator(registry=registry), FakeSource(snapshot)))
+
+
+class TestArmMigrationAnalyzer:
+    """Test cases for ArmMigrationAnalyzer class"""
+
+    def test_cheapest_equivalent(self):
+        """Test x86 nodes are matched with the cheapest ARM type of the same shape"""
+        result = _analyzer([]).recommend(ArmMigrationRequest())
+
+        node = result.nodes[0]
+        assert (node.instance_type, node.arm_instance_type, node.nodes) == ("m5.xlarge", "m6g.xlarge", 2)
+        assert node.savings_monthly_cost == pytest.approx(2 * (0.192 - 0.154) * 730)
+        assert result.node_savings_monthly_cost == node.savings_monthly_cost
+        assert node.summary.startswith("2 x m5.xlarge → m6g.xlarge, save $55/mo")
+
+    def test_family_filter_and_burstable(self):
+        """Test the allowed families narrow the equivalents and burstable nodes move to burstable types"""
+        analyzer = _analyzer([], nodes=[_node("a", "m5.xlarge"), _node("b", "t3.medium")])
+
+        result = analyzer.recommend(ArmMigrationRequest(instance_families=["m7g.*", "t4g.*"]))
+
+        assert {n.instance_type: n.arm_instance_type for n in result.nodes} == {
+            "m5.xlarge": "m7g.xlarge",
+            "t3.medium": "t4g.medium",
+        }
+
+    def test_compatibility_sources(self):
+        """Test flags, annotations and the pod spec decide compatibility, in that order"""
+        analyzer = _analyzer([
+            _workload("api"),
+            _workload("worker", annotations={ARM_COMPATIBLE_ANNOTATION: "true"}),
+            _workload("legacy", annotations={ARM_COMPATIBLE_ANNOTATION: "false"}),
+            _workload("pinned", nodeSelector={"kubernetes.io/arch": "amd64"}),
+            _workload("tolerant", tolerations=[
+                {"key": "kubernetes.io/arch", "operator": "Equal", "value": "arm64", "effect": "NoSchedule"},
+            ]),
+            _workload("flagged", annotations={ARM_COMPATIBLE_ANNOTATION: "false"}),
+        ])
+
+        result = analyzer.recommend(ArmMigrationRequest(compatible=["web/flag*"]))
+
+        compatibility = {w.name: w.compatibility for w in result.workloads}
+        assert compatibility == {
+            "api": "unknown",
+            "worker": "compatible",
+            "legacy": "incompatible",
+            "pinned": "incompatible",
+            "tolerant": "compatible",
+            "flagged": "compatible",
+        }
+        assert (result.compatible_workloads, result.incompatible_workloads, result.unknown_workloads) == (3, 2, 1)
+
+    def test_savings_of_compatible_workloads(self):
+        """Test only compatible workloads count, unknown ones too when assumed compatible"""
+        analyzer = _analyzer([_workload("api"), _workload("worker", annotations={ARM_COMPATIBLE_ANNOTATION: "true"})])
+
+        result = analyzer.recommend(ArmMigrationRequest())
+        assumed = analyzer.recommend(ArmMigrationRequest(assume_compatible=True))
+
+        worker = next(w for w in result.workloads if w.name == "worker")
+        assert worker.savings_monthly_cost > 0
+        assert worker.current_monthly_cost - worker.arm_monthly_cost == pytest.approx(worker.savings_monthly_cost)
+        assert result.savings_monthly_cost == pytest.approx(worker.savings_monthly_cost)
+        assert assumed.savings_monthly_cost == pytest.approx(2 * worker.savings_monthly_cost)
+        assert "1 of 2 workloads compatible" in result.summary
+
+    def test_arm_nodes_and_arm_workloads_skipped(self):
+        """Test nodes already on ARM and workloads that only run on ARM are left out"""
+        analyzer = _analyzer(
+            [_workload("graviton", nodeSelector={"kubernetes.io/arch": "arm64"})],
+            nodes=[_node("a", "m5.xlarge"), _node("b", "m6g.xlarge")],
+        )
+
+        result = analyzer.recommend(ArmMigrationRequest())
+
+        assert [n.instance_type for n in result.nodes] == ["m5.xlarge"]
+        assert result.workloads == []
+
+    def test_no_equivalent(self):
+        """Test nodes without a priced ARM type of their shape save nothing"""
+        result = _analyzer([]).recommend(ArmMigrationRequest(instance_families=["r7g.*"]))
+
+        assert result.nodes[0].arm_instance_type is None
+        assert result.node_savings_monthly_cost == 0
+        assert result.summary == "No x86 node has a priced ARM equivalent"
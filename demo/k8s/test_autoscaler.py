@@ -0,0 +1,163 @@
+"""Unit tests for the cluster autoscaler simulation"""
+
+import pytest
+from pydantic import ValidationError
+
+from src.k8s import (
+    AutoscalerSimulator,
+    KubernetesEstimateRequest,
+    KubernetesEstimator,
+    NodeGroup,
+    WorkloadResources,
+    parse_manifests,
+)
+from src.pricing import ProviderRegistry, StaticProvider
+
+MANIFESTS = """
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web}
+spec:
+  replicas: 5
+  template:
+    spec:
+      containers: [{name: app, resources: {requests: {cpu: 1500m, memory: 3Gi}}}]
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata: {name: agent}
+spec:
+  template:
+    spec:
+      containers: [{name: agent, resources: {requests: {cpu: 200m, memory: 256Mi}}}]
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: batch}
+spec:
+  replicas: 2
+  template:
+    spec:
+      nodeSelector: {pool: batch}
+      tolerations: [{key: dedicated, operator: Equal, value: batch, effect: NoSchedule}]
+      containers: [{name: worker, resources: {requests: {cpu: "3", memory: 6Gi}}}]
+"""
+
+
+def _group(name, cpu=4.0, memory=16.0, **fields):
+    return NodeGroup(name=name, labels=fields.pop("labels", {}), taints=fields.pop("taints", []),
+                     cpu_cores=cpu, memory_gb=memory, **fields)
+
+
+def _pods(name, replicas, cpu, memory, **fields):
+    return WorkloadResources(kind="Deployment", name=name, replicas=replicas, cpu_cores=cpu, memory_gb=memory,
+                             **fields)
+
+
+class TestAutoscalerSimulator:
+    """Test cases for AutoscalerSimulator class"""
+
+    def test_bin_packing(self):
+        """Test pods are packed onto as few nodes as their requests allow"""
+        result = AutoscalerSimulator([_group("m5")]).simulate([_pods("web", 5, 1.5, 2), _pods("api", 3, 1, 6)])
+
+        # Two web pods per node, each api pod filling a node's last core
+        assert result.nodes == {"m5": 3}
+        assert result.placements == {(0, "m5"): 5, (1, "m5"): 3}
+
+    def test_max_pods_and_daemon_sets(self):
+        """Test DaemonSet pods take their requests and a pod slot out of every node"""
+        daemon = WorkloadResources(kind="DaemonSet", name="agent", cpu_cores=1, memory_gb=1)
+        result = AutoscalerSimulator([_group("m5", max_pods=3)]).simulate([_pods("web", 4, 0.5, 1), daemon])
+
+        assert result.nodes == {"m5": 2}
+        assert result.placements[(1, "m5")] == 2
+
+    def test_taints_and_selectors(self):
+        """Test pods run only on nodes whose taints they tolerate and labels they select"""
+        groups = [
+            _group("general"),
+            _group("gpu", gpus=1, labels={"accelerator": "t4"},
+                   taints=[{"key": "nvidia.com/gpu", "effect": "NoSchedule"}]),
+        ]
+        tolerating = [{"key": "nvidia.com/gpu", "operator": "Exists"}]
+        workloads = [
+            _pods("web", 1, 1, 1),
+            _pods("train", 1, 1, 1, gpus=1, tolerations=tolerating),
+            _pods("pinned", 1, 1, 1, node_selector={"accelerator": "a100"}, tolerations=tolerating),
+            _pods("affine", 1, 1, 1, tolerations=tolerating, node_affinity=[
+                {"matchExpressions": [{"key": "accelerator", "operator": "In", "values": ["t4"]}]},
+            ]),
+        ]
+        result = AutoscalerSimulator(groups).simulate(workloads)
+
+        assert result.placements == {(0, "general"): 1, (1, "gpu"): 1, (3, "gpu"): 1}
+        assert result.unschedulable == {2: 1}
+
+    def test_least_waste_group(self):
+        """Test scale-ups pick the group leaving the least capacity unused"""
+        groups = [_group("small", 2, 8, hourly_price=0.1), _group("large", 6, 24, hourly_price=0.4)]
+
+        # Both fill their nodes: the cheaper node wins
+        assert AutoscalerSimulator(groups).simulate([_pods("web", 4, 2, 8)]).nodes == {"small": 4, "large": 0}
+        # A small node would leave a quarter unused, a large one takes four pods exactly
+        assert AutoscalerSimulator(groups).simulate([_pods("web", 4, 1.5, 6)]).nodes == {"small": 0, "large": 1}
+
+    def test_node_counts(self):
+        """Test groups keep their minimum nodes and stop growing at their maximum"""
+        groups = [_group("m5", min_nodes=2, max_nodes=3)]
+
+        assert AutoscalerSimulator(groups).simulate([]).nodes == {"m5": 2}
+        result = AutoscalerSimulator(groups).simulate([_pods("web", 4, 4, 4)])
+        assert result.nodes == {"m5": 3}
+        assert result.unschedulable == {0: 1}
+
+
+class TestNodePoolEstimate:
+    """Test cases for estimates simulating node pools"""
+
+    @pytest.fixture
+    def estimator(self):
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        return KubernetesEstimator(registry=registry)
+
+    def test_parse_scheduling_constraints(self):
+        """Test nodeSelector and tolerations are read from pod templates"""
+        batch = parse_manifests(MANIFESTS).workloads[2]
+
+        assert batch.node_selector == {"pool": "batch"}
+        assert batch.tolerations[0]["key"] == "dedicated"
+
+    def test_compute_cost_of_simulated_nodes(self, estimator):
+        """Test the compute cost is that of the nodes the pods are packed onto"""
+        request = KubernetesEstimateRequest(manifests=MANIFESTS, region="us-east-1", node_pools=[
+            {"instance_type": "m5.xlarge"},
+            {"instance_type": "m5.2xlarge"},
+            {"name": "batch", "instance_type": "c5.xlarge", "labels": {"pool": "batch"},
+             "taints": [{"key": "dedicated", "value": "batch"}]},
+        ])
+        result = estimator.estimate(request)
+        pools = {pool.name: pool for pool in result.node_pools}
+
+        # Five web pods fit one m5.2xlarge next to the agent; batch pods get a c5.xlarge each
+        assert (pools["m5.xlarge"].nodes, pools["m5.2xlarge"].nodes, pools["batch"].nodes) == (0, 1, 2)
+        assert pools["m5.2xlarge"].pods == 6
+        assert result.compute_monthly_cost == pytest.approx(730 * (0.384 + 2 * 0.17))
+        requested = sum(w.monthly_cost for w in result.workloads)
+        assert result.idle_monthly_cost == pytest.approx(result.compute_monthly_cost - requested, abs=1e-3)
+        assert result.workloads[1].replicas == 1
+        assert result.unschedulable == []
+
+    def test_node_pool_validation(self):
+        """Test node pools replace node_instance_type and must be named uniquely"""
+        with pytest.raises(ValidationError):
+            KubernetesEstimateRequest(manifests="", region="us-east-1")
+        with pytest.raises(ValidationError):
+            KubernetesEstimateRequest(manifests="", region="us-east-1", node_pools=[
+                {"instance_type": "m5.xlarge"}, {"instance_type": "m5.xlarge"},
+            ])
+        with pytest.raises(ValidationError):
+            KubernetesEstimateRequest(manifests="", region="us-east-1", node_pools=[
+                {"instance_type": "m5.xlarge", "taints": [{"key": "a", "effect": "Never"}]},
+            ])
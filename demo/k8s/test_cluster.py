@@ -0,0 +1,147 @@
+"""Unit tests for live cluster cost estimation"""
+
+import pytest
+
+from src.k8s import (
+    ClusterEstimateRequest,
+    ClusterEstimator,
+    ClusterSnapshot,
+    ClusterSource,
+    KubernetesEstimator,
+    parse_hourly_rates,
+)
+from src.k8s.cluster import running
+from src.pricing import ProviderRegistry, StaticProvider
+
+
+def _node(name, instance_type, **labels):
+    return {
+        "metadata": {"name": name, "labels": {
+            "node.kubernetes.io/instance-type": instance_type,
+            "topology.kubernetes.io/region": "us-east-1",
+            **labels,
+        }},
+        "spec": {"providerID": f"aws:///us-east-1a/i-{name}"},
+    }
+
+
+def _pod_spec(cpu, memory):
+    return {"containers": [{"name": "app", "resources": {"requests": {"cpu": cpu, "memory": memory}}}]}
+
+
+def _workload(kind, name, namespace, replicas=1, status=None, owners=None):
+    return {
+        "kind": kind,
+        "metadata": {"name": name, "namespace": namespace, "ownerReferences": owners},
+        "spec": {"replicas": replicas, "template": {"spec": _pod_spec("500m", "1Gi")}},
+        "status": status or {},
+    }
+
+
+SNAPSHOT = ClusterSnapshot(
+    nodes=[_node("a", "m5.xlarge"), _node("b", "m5.xlarge"), _node("fargate", "")],
+    workloads=[
+        _workload("Deployment", "api", "web", replicas=3),
+        _workload("DaemonSet", "agent", "monitoring", status={"desiredNumberScheduled": 2}),
+    ],
+    volume_claims=[{
+        "metadata": {"name": "data-db-0", "namespace": "web"},
+        "spec": {"storageClassName": "gp3", "resources": {"requests": {"storage": "50Gi"}}},
+        "status": {"capacity": {"storage": "100Gi"}},
+    }],
+    services=[{"metadata": {"name": "ingress", "namespace": "web"}, "spec": {"type": "LoadBalancer"}}],
+)
+
+
+class FakeSource(ClusterSource):
+    def __init__(self, snapshot):
+        self._snapshot = snapshot
+
+    def snapshot(self):
+        return self._snapshot
+
+
+class TestClusterEstimator:
+    """Test cases for ClusterEstimator class"""
+
+    @pytest.fixture
+    def estimator(self):
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        return KubernetesEstimator(registry=registry)
+
+    def test_cluster_cost(self, estimator):
+        """Test nodes, claims and load balancers make up the cluster cost, split by namespace"""
+        cluster = ClusterEstimator(estimator, FakeSource(SNAPSHOT), cluster="prod")
+
+        result = cluster.estimate(ClusterEstimateRequest())
+
+        assert [n.name for n in result.nodes] == ["a", "b"]
+        assert result.node_monthly_cost == pytest.approx(2 * 0.192 * 730)
+        assert result.unpriced == ["Node/fargate"]
+        # Bound claims are priced at the capacity they were given
+        [volume] = result.volumes
+        assert volume.size_gb == pytest.approx(100)
+        assert result.load_balancer_monthly_cost == pytest.approx(0.0225 * 730)
+        assert result.monthly_cost == pytest.approx(
+            result.node_monthly_cost + result.storage_monthly_cost + result.load_balancer_monthly_cost
+        )
+
+        agent = next(w for w in result.workloads if w.name == "agent")
+        assert agent.replicas == 2
+        assert result.idle_monthly_cost == pytest.approx(result.node_monthly_cost - result.allocated_monthly_cost)
+        web = next(n for n in result.namespaces if n.namespace == "web")
+        assert web.monthly_cost == pytest.approx(
+            web.compute_monthly_cost + volume.monthly_cost + result.load_balancer_monthly_cost
+        )
+
+    def test_spot_nodes(self, estimator):
+        """Test nodes labelled as spot capacity are priced at spot rates"""
+        snapshot = ClusterSnapshot([_node("s", "m5.xlarge", **{"karpenter.sh/capacity-type": "spot"})], [], [], [])
+
+        [node] = ClusterEstimator(estimator, FakeSource(snapshot)).estimate(ClusterEstimateRequest()).nodes
+
+        assert node.pricing_model == "spot"
+        assert node.hourly_price == pytest.approx(0.0768)
+
+    def test_control_plane_tier(self, estimator):
+        """Test managed node pool labels add the provider's default control plane fee"""
+        self_managed = ClusterEstimator(estimator, FakeSource(SNAPSHOT)).estimate(ClusterEstimateRequest())
+        eks = ClusterSnapshot([_node("a", "m5.xlarge", **{"eks.amazonaws.com/nodegroup": "main"})], [], [], [])
+        managed = ClusterEstimator(estimator, FakeSource(eks)).estimate(ClusterEstimateRequest())
+        extended = ClusterEstimator(estimator, FakeSource(eks), cluster_tier="extended").estimate(
+            ClusterEstimateRequest())
+
+        assert self_managed.control_plane is None
+        assert managed.control_plane.tier == "standard"
+        assert managed.monthly_cost == pytest.approx(managed.node_monthly_cost + 0.10 * 730)
+        assert extended.control_plane_monthly_cost == pytest.approx(0.60 * 730)
+
+    def test_autopilot(self, estimator):
+        """Test Autopilot clusters bill pod requests instead of nodes"""
+        node = _node("gk3-a", "e2-standard-4", **{"topology.kubernetes.io/region": "us-central1"})
+        node["spec"]["providerID"] = "gce://project/us-central1-a/gk3-a"
+        snapshot = ClusterSnapshot([node], [_workload("Deployment", "api", "web", replicas=2)], [], [])
+        cluster = ClusterEstimator(estimator, FakeSource(snapshot), cluster_tier="autopilot")
+
+        result = cluster.estimate(ClusterEstimateRequest())
+
+        assert result.nodes == []
+        [api] = result.workloads
+        assert api.monthly_cost == pytest.approx(2 * 730 * (0.5 * 0.0445 + 1.0 * 0.0049225), rel=1e-4)
+        assert result.idle_monthly_cost == 0
+        assert result.monthly_cost == pytest.approx(api.monthly_cost + 0.10 * 730, abs=1e-3)
+
+    def test_running_workloads(self):
+        """Test owned ReplicaSets and pods and finished Jobs are not counted twice"""
+        assert running(_workload("Deployment", "api", "web"))
+        assert not running(_workload("ReplicaSet", "api-7d9f", "web", owners=[{"kind": "Deployment"}]))
+        assert not running(_workload("Job", "backup", "ops", status={"succeeded": 1}))
+        assert running(_workload("Job", "backup", "ops", status={"active": 1}))
+        assert not running({"kind": "Pod", "metadata": {}, "status": {"phase": "Succeeded"}})
+
+    def test_hourly_rates(self):
+        """Test load balancer rates are parsed per provider"""
+        assert parse_hourly_rates("AWS=0.0225, gcp=0.025") == {"aws": 0.0225, "gcp": 0.025}
+        with pytest.raises(ValueError):
+            parse_hourly_rates("aws:0.02")
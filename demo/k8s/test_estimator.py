@@ -0,0 +1,173 @@
+"""Unit tests for Kubernetes cost estimator"""
+
+import pytest
+from pydantic import ValidationError
+
+from src.k8s import KubernetesEstimateRequest, KubernetesEstimator
+from src.pricing import ProviderRegistry, StaticProvider
+
+from .test_parser import MANIFESTS
+
+
+class TestKubernetesEstimator:
+    """Test cases for KubernetesEstimator class"""
+
+    @pytest.fixture
+    def estimator(self):
+        """Create estimator over the built-in rate card"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        return KubernetesEstimator(registry=registry)
+
+    def test_node_rates_reconstruct_node_price(self, estimator):
+        """Test a full node's requests cost exactly the node price"""
+        rates = estimator.node_rates("aws", "us-east-1", "m5.xlarge")
+
+        node_cost = rates.vcpus * rates.cpu_hourly + rates.memory_gb * rates.memory_hourly
+        assert node_cost == pytest.approx(0.192)
+
+    def test_spot_node_rates(self, estimator):
+        """Test spot nodes derive rates from the spot price"""
+        rates = estimator.node_rates("aws", "us-east-1", "m5.xlarge", pricing_model="spot")
+
+        assert rates.pricing_model == "spot"
+        assert rates.hourly_price == pytest.approx(0.0768)
+
+    def test_estimate_manifests(self, estimator):
+        """Test compute and storage costs of the sample manifests"""
+        request = KubernetesEstimateRequest(
+            manifests=MANIFESTS, region="us-east-1", node_instance_type="m5.xlarge")
+
+        result = estimator.estimate(request)
+        rates = result.node_rates
+
+        web = result.workloads[0]
+        expected_web = 3 * 730 * (2.0 * rates.cpu_hourly + 1.5 * rates.memory_hourly)
+        assert web.monthly_cost == pytest.approx(expected_web, rel=1e-4)
+
+        data, uploads = result.volumes
+        assert data.monthly_cost == pytest.approx(0.08 * 100 * 2)
+        # Default storage class maps to gp3 on AWS
+        assert uploads.volume_type == "gp3"
+        assert result.monthly_cost == pytest.approx(
+            result.compute_monthly_cost + result.storage_monthly_cost, abs=1e-3)
+        assert result.skipped == ["Service/web"]
+
+    def test_azure_disk_tiers(self, estimator):
+        """Test Azure claims are priced by disk size tier"""
+        manifest = (
+            "kind: PersistentVolumeClaim\nmetadata: {name: d}\n"
+            "spec: {storageClassName: managed-csi-premium, resources: {requests: {storage: 100Gi}}}\n"
+        )
+        request = KubernetesEstimateRequest(
+            manifests=manifest, provider="azure", region="eastus", node_instance_type="Standard_D4s_v3")
+
+        volume = estimator.estimate(request).volumes[0]
+
+        assert volume.volume_type == "P10 LRS"
+        assert volume.monthly_cost == pytest.approx(19.71)
+
+    def test_storage_class_override(self, estimator):
+        """Test request overrides of storage class mappings"""
+        manifest = (
+            "kind: PersistentVolumeClaim\nmetadata: {name: d}\n"
+            "spec: {storageClassName: fast, resources: {requests: {storage: 10Gi}}}\n"
+        )
+        request = KubernetesEstimateRequest(
+            manifests=manifest, region="us-east-1", node_instance_type="m5.large",
+            storage_class_map={"fast": "io1"})
+
+        assert estimator.estimate(request).volumes[0].volume_type == "io1"
+
+    def test_unmapped_storage_class(self, estimator):
+        """Test unknown storage classes are rejected"""
+        manifest = (
+            "kind: PersistentVolumeClaim\nmetadata: {name: d}\n"
+            "spec: {storageClassName: mystery, resources: {requests: {storage: 10Gi}}}\n"
+        )
+        request = KubernetesEstimateRequest(
+            manifests=manifest, region="us-east-1", node_instance_type="m5.large")
+
+        with pytest.raises(ValueError, match="mystery"):
+            estimator.estimate(request)
+
+    def test_unknown_node_type(self, estimator):
+        """Test node types without a known shape are rejected"""
+        request = KubernetesEstimateRequest(
+            manifests=MANIFESTS, region="us-east-1", node_instance_type="x9.huge")
+
+        with pytest.raises(ValueError, match="x9.huge"):
+            estimator.estimate(request)
+
+    def test_control_plane_fee(self, estimator):
+        """Test a managed cluster tier adds its control plane fee to the estimate"""
+        request = KubernetesEstimateRequest(
+            manifests=MANIFESTS, region="us-east-1", node_instance_type="m5.xlarge", cluster_tier="standard")
+
+        result = estimator.estimate(request)
+
+        assert result.control_plane.tier == "standard"
+        assert result.control_plane_monthly_cost == pytest.approx(0.10 * 730)
+        assert result.monthly_cost == pytest.approx(
+            result.compute_monthly_cost + result.storage_monthly_cost + 73.0, abs=1e-3)
+        assert estimator.control_plane_cost("azure", "eastus", "free", 730).monthly_cost == 0
+        assert estimator.control_plane_cost("aws", "us-east-1", "self_managed", 730) is None
+
+    def test_autopilot_pod_rates(self, estimator):
+        """Test Autopilot workloads are priced at pod request rates without a node type"""
+        request = KubernetesEstimateRequest(
+            manifests=MANIFESTS, provider="gcp", region="us-central1", cluster_tier="autopilot",
+            storage_class_map={"gp3": "pd-balanced"})
+
+        result = estimator.estimate(request)
+
+        web = result.workloads[0]
+        assert result.node_rates.instance_type == "autopilot"
+        assert web.monthly_cost == pytest.approx(3 * 730 * (2.0 * 0.0445 + 1.5 * 0.0049225), rel=1e-4)
+        assert result.control_plane_monthly_cost == pytest.approx(73.0)
+
+    def test_gpu_node_rates(self, estimator):
+        """Test a GPU node's price is split over vCPUs, memory and GPUs and adds back up"""
+        rates = estimator.node_rates("aws", "us-east-1", "g4dn.xlarge")
+
+        assert rates.gpus == 1
+        assert rates.accelerator == "nvidia-tesla-t4"
+        assert rates.gpu_hourly > rates.cpu_hourly
+        assert (rates.cpu_hourly * 4 + rates.memory_hourly * 16 + rates.gpu_hourly) == pytest.approx(0.526)
+
+    def test_gpu_workloads(self, estimator):
+        """Test GPU workloads run on the GPU node type, a shared GPU splitting its cost"""
+        manifest = (
+            "kind: Deployment\n"
+            "metadata: {name: infer}\n"
+            "spec:\n"
+            "  replicas: 2\n"
+            "  template:\n"
+            "    spec:\n"
+            "      containers:\n"
+            "      - resources: {requests: {cpu: '1', memory: 4Gi}, limits: {nvidia.com/gpu: 1}}\n"
+        )
+        request = KubernetesEstimateRequest(
+            manifests=[manifest, MANIFESTS], region="us-east-1", node_instance_type="m5.xlarge",
+            gpu_node_instance_type="g4dn.xlarge", gpu_sharing=2)
+
+        result = estimator.estimate(request)
+
+        infer = result.workloads[0]
+        gpu_hourly = result.gpu_node_rates.gpu_hourly
+        assert infer.gpu_monthly_cost == pytest.approx(2 * 0.5 * gpu_hourly * 730, abs=1e-3)
+        assert result.gpu_monthly_cost == infer.gpu_monthly_cost
+        assert all(w.gpu_monthly_cost == 0 for w in result.workloads[1:])
+        with pytest.raises(ValueError, match="requests GPUs"):
+            estimator.estimate(KubernetesEstimateRequest(
+                manifests=manifest, region="us-east-1", node_instance_type="m5.xlarge"))
+
+    def test_cluster_tier_validation(self):
+        """Test unknown tiers, Autopilot outside GKE and missing node types are rejected"""
+        with pytest.raises(ValidationError):
+            KubernetesEstimateRequest(manifests=MANIFESTS, region="us-east-1", node_instance_type="m5.xlarge",
+                                      cluster_tier="enterprise")
+        with pytest.raises(ValidationError):
+            KubernetesEstimateRequest(manifests=MANIFESTS, region="us-east-1", cluster_tier="autopilot")
+        with pytest.raises(ValidationError):
+            KubernetesEstimateRequest(manifests=MANIFESTS, region="us-east-1")
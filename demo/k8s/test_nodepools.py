@@ -0,0 +1,149 @@
+"""Unit tests for node pool recommendations"""
+
+import pytest
+
+from src.k8s import (
+    ClusterEstimator,
+    ClusterSnapshot,
+    ClusterSource,
+    KubernetesEstimator,
+    NodePoolOptimizer,
+    NodePoolRequest,
+)
+from src.pricing import ProviderRegistry, StaticProvider
+
+
+def _node(name, instance_type):
+    return {
+        "metadata": {"name": name, "labels": {
+            "node.kubernetes.io/instance-type": instance_type,
+            "topology.kubernetes.io/region": "us-east-1",
+        }},
+        "spec": {"providerID": f"aws:///us-east-1a/i-{name}"},
+    }
+
+
+def _workload(kind, name, replicas, cpu, memory, gpus=0, **pod_spec):
+    requests = {"cpu": cpu, "memory": memory}
+    if gpus:
+        requests["nvidia.com/gpu"] = str(gpus)
+    return {
+        "kind": kind,
+        "metadata": {"name": name, "namespace": "web"},
+        "spec": {"replicas": replicas, "template": {"spec": {
+            "containers": [{"name": "app", "resources": {"requests": requests}}],
+            **pod_spec,
+        }}},
+    }
+
+
+class FakeSource(ClusterSource):
+    def __init__(self, snapshot):
+        self._snapshot = snapshot
+
+    def snapshot(self):
+        return self._snapshot
+
+
+def _optimizer(workloads, nodes=4):
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    snapshot = ClusterSnapshot(
+        nodes=[_node(f"n{i}", "m5.2xlarge") for i in range(nodes)],
+        workloads=workloads,
+        volume_claims=[],
+        services=[],
+    )
+    return NodePoolOptimizer(ClusterEstimator(KubernetesEstimator(registry=registry), FakeSource(snapshot)))
+
+
+class TestNodePoolOptimizer:
+    """Test cases for NodePoolOptimizer class"""
+
+    def test_cheapest_single_pool(self):
+        """Test the workloads are packed onto the cheapest type, with savings over the current nodes"""
+        optimizer = _optimizer([
+            _workload("Deployment", "api", 6, "1", "2Gi"),
+            _workload("DaemonSet", "agent", 1, "100m", "128Mi"),
+        ])
+
+        result = optimizer.recommend(NodePoolRequest())
+
+        assert [(p.instance_type, p.nodes) for p in result.pools] == [("c5.xlarge", 2)]
+        assert result.current_nodes == 4
+        assert result.current_monthly_cost == pytest.approx(4 * 0.384 * 730)
+        assert result.recommended_monthly_cost == pytest.approx(2 * 0.17 * 730)
+        assert result.savings_monthly_cost == pytest.approx(result.current_monthly_cost - 2 * 0.17 * 730)
+        assert result.pools[0].pods == 8
+        assert result.summary.startswith("2 x c5.xlarge: $248/mo")
+
+    def test_burstable_types_excluded(self):
+        """Test burstable types are never candidates"""
+        result = _optimizer([_workload("Deployment", "api", 2, "250m", "512Mi")]).recommend(NodePoolRequest())
+
+        assert not result.pools[0].instance_type.startswith("t")
+
+    def test_zones_and_spot_split(self):
+        """Test node counts are rounded up to the zones and spot_percent of them priced at spot"""
+        optimizer = _optimizer([_workload("Deployment", "api", 6, "1", "2Gi")])
+
+        result = optimizer.recommend(NodePoolRequest(zones=3, spot_percent=50, instance_families=["c5.xlarge"]))
+
+        pool = result.pools[0]
+        assert pool.nodes == 3
+        assert (pool.on_demand_nodes, pool.spot_nodes) == (2, 1)
+        assert pool.monthly_cost == pytest.approx((2 * 0.17 + pool.spot_hourly_price) * 730)
+        assert "50% spot across 3 zones" in result.summary
+
+    def test_gpu_workloads_get_gpu_pool(self):
+        """Test GPU pods run on a GPU pool of their own and other pods stay off it"""
+        optimizer = _optimizer([
+            _workload("Deployment", "api", 4, "1", "2Gi"),
+            _workload("Deployment", "trainer", 2, "2", "8Gi", gpus=1),
+        ])
+
+        result = optimizer.recommend(NodePoolRequest())
+
+        gpu_pools = [p for p in result.pools if p.gpus]
+        assert [(p.instance_type, p.nodes, p.pods) for p in gpu_pools] == [("g4dn.xlarge", 2, 2)]
+        assert sum(p.pods for p in result.pools if not p.gpus) == 4
+        assert not result.unschedulable
+
+    def test_mixed_pools(self):
+        """Test a second type is added when it lowers the cost"""
+        optimizer = _optimizer([
+            _workload("Deployment", "api", 4, "1800m", "3Gi"),
+            _workload("Deployment", "cache", 4, "500m", "14Gi"),
+        ])
+
+        result = optimizer.recommend(NodePoolRequest())
+        single = optimizer.recommend(NodePoolRequest(max_pools=1))
+
+        assert {p.instance_type for p in result.pools} == {"c5.large", "r5.large"}
+        assert len(single.pools) == 1
+        assert result.recommended_monthly_cost < single.recommended_monthly_cost
+
+    def test_unschedulable_workloads(self):
+        """Test workloads no candidate type can run are listed"""
+        optimizer = _optimizer([
+            _workload("Deployment", "api", 1, "1", "2Gi"),
+            _workload("Deployment", "arm", 1, "1", "2Gi", nodeSelector={"kubernetes.io/arch": "arm64"}),
+        ])
+
+        result = optimizer.recommend(NodePoolRequest())
+
+        assert result.unschedulable == ["Deployment/web/arm"]
+
+    def test_no_candidates(self):
+        """Test constraints no priced type meets raise ValueError"""
+        optimizer = _optimizer([_workload("Deployment", "api", 1, "1", "2Gi")])
+
+        with pytest.raises(ValueError, match="No instance type"):
+            optimizer.recommend(NodePoolRequest(architectures=["arm64"]))
+
+    def test_request_validation(self):
+        """Test unknown architectures and out of range spot shares are rejected"""
+        with pytest.raises(ValueError):
+            NodePoolRequest(architectures=["sparc"])
+        with pytest.raises(ValueError):
+            NodePoolRequest(spot_percent=120)
@@ -0,0 +1,162 @@
+"""Unit tests for Kubernetes manifest parser"""
+
+import pytest
+
+from src.k8s import parse_bytes_gb, parse_cpu, parse_manifests, parse_quantity
+
+MANIFESTS = """
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  replicas: 3
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        resources:
+          requests: {cpu: "2", memory: 256Mi}
+      containers:
+      - name: app
+        resources:
+          requests: {cpu: 500m, memory: 1Gi}
+      - name: sidecar
+        resources:
+          limits: {cpu: 250m, memory: 512Mi}
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: postgres
+        resources:
+          requests: {cpu: "1", memory: 4Gi}
+  volumeClaimTemplates:
+  - metadata:
+      name: data
+    spec:
+      storageClassName: gp3
+      resources:
+        requests: {storage: 100Gi}
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: uploads
+  namespace: shop
+spec:
+  resources:
+    requests: {storage: 50Gi}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+"""
+
+
+class TestQuantity:
+    """Test cases for quantity parsing"""
+
+    def test_cpu(self):
+        """Test millicore and whole core quantities"""
+        assert parse_cpu("250m") == pytest.approx(0.25)
+        assert parse_cpu("2") == 2.0
+        assert parse_cpu(1.5) == 1.5
+
+    def test_memory(self):
+        """Test binary and decimal suffixes"""
+        assert parse_bytes_gb("1Gi") == pytest.approx(1.0)
+        assert parse_bytes_gb("512Mi") == pytest.approx(0.5)
+        assert parse_quantity("1G") == 1e9
+        assert parse_quantity("1e3") == 1000.0
+
+    def test_invalid(self):
+        """Test malformed quantities"""
+        with pytest.raises(ValueError):
+            parse_quantity("lots")
+        with pytest.raises(ValueError):
+            parse_quantity("10Q")
+
+
+class TestParseManifests:
+    """Test cases for parse_manifests"""
+
+    @pytest.fixture
+    def parsed(self):
+        """Parse the sample manifests"""
+        return parse_manifests(MANIFESTS)
+
+    def test_workloads(self, parsed):
+        """Test replicas and effective requests"""
+        web, db = parsed.workloads
+
+        assert (web.kind, web.namespace, web.replicas) == ("Deployment", "shop", 3)
+        # Init container (2 cores) dominates the summed app containers (0.75)
+        assert web.cpu_cores == pytest.approx(2.0)
+        assert web.memory_gb == pytest.approx(1.5)
+        assert db.replicas == 2
+
+    def test_volume_claims(self, parsed):
+        """Test template claims are multiplied by replicas"""
+        data, uploads = parsed.volume_claims
+
+        assert (data.storage_class, data.size_gb, data.count) == ("gp3", 100.0, 2)
+        assert data.owner == "StatefulSet/db"
+        assert uploads.storage_class is None
+        assert uploads.namespace == "shop"
+
+    def test_skipped_kinds(self, parsed):
+        """Test unpriced kinds are reported"""
+        assert parsed.skipped == ["Service/web"]
+
+    def test_list_and_multiple_texts(self):
+        """Test List documents and several manifest texts"""
+        parsed = parse_manifests([
+            "kind: List\nitems:\n- kind: Pod\n  metadata: {name: a}\n  spec: {containers: []}\n",
+            "kind: Pod\nmetadata: {name: b}\nspec:\n  containers:\n  - resources: {requests: {cpu: 100m}}\n",
+        ])
+
+        assert [w.name for w in parsed.workloads] == ["a", "b"]
+        assert parsed.workloads[1].cpu_cores == pytest.approx(0.1)
+
+    def test_labels_and_annotations(self):
+        """Test labels and annotations are kept, without last-applied configuration"""
+        parsed = parse_manifests(
+            "kind: Pod\n"
+            "metadata:\n"
+            "  name: a\n"
+            "  labels: {team: web}\n"
+            "  annotations:\n"
+            "    cost-center: cc-42\n"
+            "    kubectl.kubernetes.io/last-applied-configuration: '{}'\n"
+            "spec: {containers: []}\n"
+        )
+
+        assert parsed.workloads[0].labels == {"team": "web"}
+        assert parsed.workloads[0].annotations == {"cost-center": "cc-42"}
+
+    def test_gpu_requests(self):
+        """Test GPU limits and MIG slices are counted per replica, as a share of a GPU for slices"""
+        parsed = parse_manifests(
+            "kind: Pod\n"
+            "metadata: {name: train}\n"
+            "spec:\n"
+            "  containers:\n"
+            "  - resources: {limits: {nvidia.com/gpu: 2}}\n"
+            "  - resources: {limits: {nvidia.com/mig-1g.5gb: 1}}\n"
+        )
+
+        assert parsed.workloads[0].gpus == pytest.approx(2 + 1 / 7, abs=1e-4)
+
+    def test_invalid_yaml(self):
+        """Test YAML errors become ValueError"""
+        with pytest.raises(ValueError, match="Invalid YAML"):
+            parse_manifests("kind: [unclosed")
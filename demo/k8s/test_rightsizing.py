@@ -0,0 +1,136 @@
+"""Unit tests for right-sizing recommendations"""
+
+import pytest
+
+from src.k8s import (
+    KubernetesEstimator,
+    RightsizingRecommender,
+    RightsizingRequest,
+    UsageEstimator,
+    node_presence_query,
+    usage_queries,
+)
+from src.k8s.prometheus import Sample
+from src.pricing import ProviderRegistry, StaticProvider
+
+DAY = 86400
+STEPS = DAY // 300
+GIB = 1024 ** 3
+
+
+def _pod(pod, value, namespace="web", **labels):
+    return Sample(dict(namespace=namespace, pod=pod, **labels), value)
+
+
+class FakePrometheus:
+    """Prometheus answering each query by the series it selects"""
+
+    def __init__(self, series, window="1d"):
+        self.series = series
+        self.names = {
+            promql: name
+            for namespace in (None, "web")
+            for name, promql in usage_queries(window, namespace).items()
+        }
+        self.names[node_presence_query(window)] = "node_presence"
+
+    def query(self, promql):
+        return self.series.get(self.names[promql], [])
+
+
+def _series(presence=STEPS):
+    """Two api pods using 0.5 of 2 cores and 1 of 4 GiB each, and coredns, on an m5.2xlarge"""
+    api = ("api-7d9f8c6b5-a1b2c", "api-7d9f8c6b5-d3e4f")
+    return {
+        "cpu_usage": [_pod(p, 0.5 * DAY) for p in api] + [_pod("coredns", 0.05 * DAY, "kube-system")],
+        "memory_usage": [_pod(p, 1 * GIB * STEPS) for p in api] + [_pod("coredns", 0.05 * GIB * STEPS, "kube-system")],
+        "cpu_request": [_pod(p, 2.0 * STEPS) for p in api] + [_pod("coredns", 0.1 * STEPS, "kube-system")],
+        "memory_request": [_pod(p, 4 * GIB * STEPS) for p in api] + [_pod("coredns", 0.1 * GIB * STEPS, "kube-system")],
+        "pod_node": [_pod(p, 1, node="ip-10-0-1-1") for p in api] + [_pod("coredns", 1, "kube-system", node="ip-10-0-1-1")],
+        "pod_owner": [_pod(p, 1, owner_kind="ReplicaSet", owner_name="api-7d9f8c6b5") for p in api],
+        "replicaset_owner": [Sample({"namespace": "web", "replicaset": "api-7d9f8c6b5",
+                                     "owner_kind": "Deployment", "owner_name": "api"}, 1)],
+        "node_labels": [Sample({"node": "ip-10-0-1-1", "label_node_kubernetes_io_instance_type": "m5.2xlarge",
+                                "label_topology_kubernetes_io_region": "us-east-1"}, 1)],
+        "node_info": [Sample({"node": "ip-10-0-1-1", "provider_id": "aws:///us-east-1a/i-0abc"}, 1)],
+        "node_presence": [Sample({"node": "ip-10-0-1-1"}, presence)],
+    }
+
+
+class TestRightsizingRecommender:
+    """Test cases for RightsizingRecommender class"""
+
+    @pytest.fixture
+    def estimator(self):
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        return KubernetesEstimator(registry=registry)
+
+    def _recommender(self, estimator, series=None, **kwargs):
+        usage = UsageEstimator(estimator, FakePrometheus(series or _series()))
+        return RightsizingRecommender(usage, **kwargs)
+
+    def test_workload_and_node(self, estimator):
+        """Test over-requested workloads get lower requests and their node a cheaper type"""
+        result = self._recommender(estimator).recommend(RightsizingRequest(window="1d"))
+        rates = estimator.node_rates("aws", "us-east-1", "m5.2xlarge")
+
+        [api] = result.workloads
+        assert (api.kind, api.name) == ("Deployment", "api")
+        assert api.recommended_cpu_request_cores == pytest.approx(1.2)
+        assert api.recommended_memory_request_gb == pytest.approx(2.4)
+        assert api.savings_monthly_cost == pytest.approx(
+            (2.8 * rates.cpu_hourly + 5.6 * rates.memory_hourly) * 730, rel=1e-3
+        )
+        assert result.excluded == ["kube-system/Pod/coredns"]
+
+        # api at usage plus 20%, coredns at its requests: 1.3 cores, 2.5 GiB
+        [node] = result.nodes
+        assert node.cpu_needed_cores == pytest.approx(1.3)
+        assert node.memory_needed_gb == pytest.approx(2.5)
+        assert (node.recommended_instance_type, node.action) == ("c5.large", "downsize")
+        assert node.savings_monthly_cost == pytest.approx((0.384 - 0.085) * 730)
+        assert node.summary == "downsize m5.2xlarge → c5.large, save $218/mo"
+        assert result.savings_monthly_cost == node.savings_monthly_cost
+
+    def test_headroom(self, estimator):
+        """Test headroom raises what the new node type must fit"""
+        result = self._recommender(estimator).recommend(RightsizingRequest(window="1d", headroom_percent=100))
+
+        [node] = result.nodes
+        assert node.cpu_needed_cores == pytest.approx(2.1)
+        assert node.recommended_instance_type == "c5.xlarge"
+
+    def test_excluded_workload_keeps_requests(self, estimator):
+        """Test an excluded workload is not right-sized and its node must still fit its requests"""
+        recommender = self._recommender(estimator, exclude_workloads=["web/api*"])
+        result = recommender.recommend(RightsizingRequest(window="1d"))
+
+        assert result.workloads == []
+        assert result.nodes == []
+        assert "web/Deployment/api" in result.excluded
+
+    def test_excluded_node(self, estimator):
+        """Test nodes are excluded by name or instance type"""
+        result = self._recommender(estimator).recommend(RightsizingRequest(window="1d", exclude_nodes=["m5.*"]))
+
+        assert result.nodes == []
+        assert "ip-10-0-1-1" in result.excluded
+        assert len(result.workloads) == 1
+
+    def test_partial_node_lifetime(self, estimator):
+        """Test the load of a node that ran part of the window is averaged over its lifetime"""
+        result = self._recommender(estimator, series=_series(presence=STEPS / 4)).recommend(
+            RightsizingRequest(window="1d")
+        )
+
+        # 5.2 cores and 10 GiB: no priced type cheaper than the m5.2xlarge fits
+        assert result.nodes == []
+        assert len(result.workloads) == 1
+
+    def test_namespace_skips_nodes(self, estimator):
+        """Test a namespace's pods are not a node's whole load"""
+        result = self._recommender(estimator).recommend(RightsizingRequest(window="1d", namespace="web"))
+
+        assert result.nodes == []
+        assert len(result.workloads) == 1
@@ -0,0 +1,118 @@
+"""Unit tests for usage-based Kubernetes cost estimation"""
+
+import pytest
+
+from src.k8s import KubernetesEstimator, UsageEstimateRequest, UsageEstimator, usage_queries
+from src.k8s.prometheus import Sample
+from src.k8s.usage import window_seconds
+from src.pricing import ProviderRegistry, StaticProvider
+
+DAY = 86400
+STEPS = DAY // 300
+GIB = 1024 ** 3
+
+
+def _pod(pod, value, namespace="web", **labels):
+    return Sample(dict(namespace=namespace, pod=pod, **labels), value)
+
+
+class FakePrometheus:
+    """Prometheus answering each query by the series it selects"""
+
+    def __init__(self, series, window="1d"):
+        self.series = series
+        self.names = {promql: name for name, promql in usage_queries(window).items()}
+
+    def query(self, promql):
+        return self.series.get(self.names[promql], [])
+
+
+# Two api pods requesting 1 core and 2 GiB each over the whole day, a
+# replaced api pod that ran half of it, and a pod on a Fargate node.
+SERIES = {
+    "cpu_usage": [_pod("api-7d9f8c6b5-a1b2c", 0.25 * DAY), _pod("api-7d9f8c6b5-d3e4f", 0.25 * DAY),
+                  _pod("api-6c5b4a398-x9y8z", 0.5 * DAY / 2), _pod("batch", 1.0 * DAY)],
+    "memory_usage": [_pod("api-7d9f8c6b5-a1b2c", 1 * GIB * STEPS), _pod("api-7d9f8c6b5-d3e4f", 1 * GIB * STEPS),
+                     _pod("api-6c5b4a398-x9y8z", 1 * GIB * STEPS / 2)],
+    "cpu_request": [_pod("api-7d9f8c6b5-a1b2c", 1.0 * STEPS), _pod("api-7d9f8c6b5-d3e4f", 1.0 * STEPS),
+                    _pod("api-6c5b4a398-x9y8z", 1.0 * STEPS / 2), _pod("batch", 1.0 * STEPS)],
+    "memory_request": [_pod("api-7d9f8c6b5-a1b2c", 2 * GIB * STEPS), _pod("api-7d9f8c6b5-d3e4f", 2 * GIB * STEPS),
+                       _pod("api-6c5b4a398-x9y8z", 2 * GIB * STEPS / 2)],
+    "pod_node": [_pod("api-7d9f8c6b5-a1b2c", 1, node="ip-10-0-1-1"), _pod("api-7d9f8c6b5-d3e4f", 1, node="ip-10-0-1-1"),
+                 _pod("api-6c5b4a398-x9y8z", 1, node="ip-10-0-1-1"), _pod("batch", 1, node="fargate-ip-1")],
+    "pod_owner": [_pod(p, 1, owner_kind="ReplicaSet", owner_name=p.rsplit("-", 1)[0])
+                  for p in ("api-7d9f8c6b5-a1b2c", "api-7d9f8c6b5-d3e4f", "api-6c5b4a398-x9y8z")],
+    "replicaset_owner": [Sample({"namespace": "web", "replicaset": "api-7d9f8c6b5",
+                                 "owner_kind": "Deployment", "owner_name": "api"}, 1)],
+    "node_labels": [
+        Sample({"node": "ip-10-0-1-1", "label_node_kubernetes_io_instance_type": "m5.xlarge",
+                "label_topology_kubernetes_io_region": "us-east-1"}, 1),
+        Sample({"node": "fargate-ip-1", "label_node_kubernetes_io_instance_type": "fargate"}, 1),
+    ],
+    "node_info": [Sample({"node": "ip-10-0-1-1", "provider_id": "aws:///us-east-1a/i-0abc"}, 1)],
+}
+
+
+class TestUsageEstimator:
+    """Test cases for UsageEstimator class"""
+
+    @pytest.fixture
+    def estimator(self):
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        return KubernetesEstimator(registry=registry)
+
+    def test_queries(self):
+        """Test namespace filters apply to pod series and gauges are averaged with subqueries"""
+        queries = usage_queries("7d", "web")
+
+        assert 'namespace="web"' in queries["cpu_usage"]
+        assert "[7d:5m]" in queries["memory_request"]
+        assert "namespace" not in queries["node_labels"].split("(max_over_time")[1]
+        assert window_seconds("7d") == 7 * DAY
+
+    def test_usage_and_waste(self, estimator):
+        """Test pods are priced at their node's rates, grouped by Deployment, with unused requests as waste"""
+        usage = UsageEstimator(estimator, FakePrometheus(SERIES), default_region="us-east-1")
+
+        result = usage.estimate(UsageEstimateRequest(window="1d"))
+        rates = estimator.node_rates("aws", "us-east-1", "m5.xlarge")
+        cpu, memory = rates.cpu_hourly * 730, rates.memory_hourly * 730
+
+        # The replaced ReplicaSet has no owner series and is named after its pods' template hash
+        api = next(w for w in result.workloads if w.name == "api")
+        assert (api.kind, api.pods) == ("Deployment", 3)
+        assert api.cpu_usage_cores == pytest.approx(0.75)
+        assert api.cpu_request_cores == pytest.approx(2.5)
+        assert api.memory_request_gb == pytest.approx(5.0)
+        assert api.cpu_utilization == pytest.approx(0.3)
+        assert api.usage_monthly_cost == pytest.approx(0.75 * cpu + 2.5 * memory, rel=1e-3)
+        assert api.waste_monthly_cost == pytest.approx(1.75 * cpu + 2.5 * memory, rel=1e-3)
+
+        assert result.unpriced == ["web/batch"]
+        [namespace] = result.namespaces
+        assert namespace.workloads == 1
+        assert result.waste_monthly_cost == pytest.approx(api.waste_monthly_cost)
+
+    def test_usage_above_requests_is_not_waste(self, estimator):
+        """Test CPU used beyond a pod's request does not offset its unused memory"""
+        series = {
+            "cpu_usage": [_pod("job", 2.0 * DAY)],
+            "cpu_request": [_pod("job", 1.0 * STEPS)],
+            "memory_request": [_pod("job", 4 * GIB * STEPS)],
+            "memory_usage": [_pod("job", 1 * GIB * STEPS)],
+            "pod_node": [_pod("job", 1, node="n1")],
+            "node_labels": [Sample({"node": "n1", "label_beta_kubernetes_io_instance_type": "m5.xlarge"}, 1)],
+        }
+        usage = UsageEstimator(estimator, FakePrometheus(series), default_region="us-east-1")
+
+        [job] = usage.estimate(UsageEstimateRequest(window="1d")).workloads
+        rates = estimator.node_rates("aws", "us-east-1", "m5.xlarge")
+
+        assert (job.kind, job.name) == ("Pod", "job")
+        assert job.waste_monthly_cost == pytest.approx(3 * rates.memory_hourly * 730, rel=1e-3)
+
+    def test_invalid_window(self):
+        """Test windows must be Prometheus durations"""
+        with pytest.raises(ValueError):
+            UsageEstimateRequest(window="7 days")
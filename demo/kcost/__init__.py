@@ -0,0 +1 @@
+"""Tests for kcost module"""
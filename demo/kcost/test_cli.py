@@ -0,0 +1,252 @@
+"""Unit tests for the kcost command line"""
+
+import json
+
+import pytest
+import requests
+
+from src.integrations import Comment
+from src.kcost import APIError, EXIT_COST_CHECK, EstimatorClient, check_costs, cli, detect_input, main
+
+PLAN = {
+    "format_version": "1.2",
+    "resource_changes": [{"address": "aws_instance.web", "type": "aws_instance", "change": {"actions": ["create"]}}],
+}
+
+
+def _estimate_response(monthly):
+    return {
+        "estimate_id": "est-1",
+        "estimate": {
+            "line_items": [{"name": "web", "provider": "aws", "region": "us-east-1", "instance_type": "m5.large",
+                            "count": 2, "hourly_cost": 0.192, "monthly_cost": monthly}],
+            "hourly_cost": 0.192, "monthly_cost": monthly, "yearly_cost": monthly * 12, "currency": "USD",
+        },
+        "budget_warnings": [],
+    }
+
+
+def _terraform_response(before, after):
+    return {
+        "estimate": {
+            "added": [{"address": "aws_instance.web", "type": "aws_instance", "action": "create",
+                       "before_monthly_cost": before, "after_monthly_cost": after, "monthly_delta": after - before}],
+            "changed": [], "destroyed": [], "unpriced": [],
+            "before_monthly_cost": before, "after_monthly_cost": after, "monthly_delta": after - before,
+            "currency": "USD",
+        },
+    }
+
+
+class FakeClient:
+    """Client answering with canned responses and recording its calls"""
+
+    def __init__(self, **responses):
+        self.responses = responses
+        self.calls = []
+
+    def __getattr__(self, name):
+        def call(*args, **kwargs):
+            self.calls.append((name, args, kwargs))
+            response = self.responses[name]
+            if isinstance(response, Exception):
+                raise response
+            return response
+        return call
+
+
+class TestDetectInput:
+    """Test cases for estimate input detection"""
+
+    def test_input_types(self):
+        assert detect_input(json.dumps(PLAN))[0] == "terraform"
+        assert detect_input('{"steps": [], "changeSummary": {}}')[0] == "pulumi"
+        assert detect_input('{"resources": [{"instance_type": "m5.large", "region": "us-east-1"}]}')[0] == "estimate"
+        assert detect_input('{"manifests": "kind: Deployment", "region": "us-east-1"}')[0] == "kubernetes"
+        assert detect_input("apiVersion: apps/v1\nkind: Deployment\n") == (
+            "kubernetes", "apiVersion: apps/v1\nkind: Deployment\n"
+        )
+
+        with pytest.raises(ValueError):
+            detect_input('{"name": "x"}')
+
+
+class TestCostChecks:
+    """Test cases for cost regression checks"""
+
+    def test_checks(self):
+        assert check_costs(120.0, 100.0, max_monthly_cost=150, max_increase=25, max_increase_percent=20) == []
+        assert len(check_costs(120.0, 100.0, max_monthly_cost=100, max_increase=10, max_increase_percent=10)) == 3
+        assert check_costs(50.0, 0.0, max_increase_percent=10) == ["monthly cost grows by inf%, more than 10%"]
+        assert check_costs(80.0, 100.0, max_increase_percent=0) == []
+
+    def test_increase_needs_a_previous_cost(self):
+        assert check_costs(120.0, None, max_increase=10)
+
+
+class TestCLI:
+    """Test cases for kcost commands"""
+
+    def test_terraform_plan_fails_on_increase(self, tmp_path, capsys):
+        """Test a plan growing the monthly cost past --max-increase exits with the cost check status"""
+        plan = tmp_path / "plan.json"
+        plan.write_text(json.dumps(PLAN))
+        client = FakeClient(estimate_terraform=_terraform_response(100.0, 700.0))
+
+        status = main(["estimate", "-f", str(plan), "--max-increase", "500", "--label", "ci=1"], client=client)
+
+        assert status == EXIT_COST_CHECK
+        name, (sent,), kwargs = client.calls[0]
+        assert name == "estimate_terraform" and sent == PLAN and kwargs["labels"] == {"ci": "1"}
+        captured = capsys.readouterr()
+        assert "aws_instance.web" in captured.out and "100.00 -> 700.00 USD (+600.00)" in captured.out
+        assert "grows by 600.00" in captured.err
+
+        assert main(["estimate", "-f", str(plan), "--max-increase", "1000"], client=client) == 0
+
+    def test_manifests_and_baseline(self, tmp_path, capsys):
+        """Test manifests need a region and node type, and JSON output serves as a baseline"""
+        manifests = tmp_path / "deploy.yaml"
+        manifests.write_text("apiVersion: apps/v1\nkind: Deployment\n")
+        response = {"estimate": {"workloads": [], "volumes": [], "compute_monthly_cost": 90.0,
+                                 "storage_monthly_cost": 10.0, "monthly_cost": 100.0, "yearly_cost": 1200.0,
+                                 "currency": "USD"}}
+        client = FakeClient(estimate_kubernetes=response)
+
+        assert main(["estimate", "-f", str(manifests)], client=client) == 1
+        assert "--region and --node-type" in capsys.readouterr().err
+
+        assert main(["-o", "json", "estimate", "-f", str(manifests), "--region", "us-east-1",
+                     "--node-type", "m5.xlarge"], client=client) == 0
+        baseline = tmp_path / "baseline.json"
+        baseline.write_text(capsys.readouterr().out)
+        assert client.calls[-1][1][0]["node_instance_type"] == "m5.xlarge"
+
+        request = tmp_path / "request.json"
+        request.write_text(json.dumps({"resources": [{"instance_type": "m5.large", "region": "us-east-1"}]}))
+        client = FakeClient(estimate=_estimate_response(130.0))
+        assert main(["estimate", "-f", str(request), "--baseline", str(baseline),
+                     "--max-increase-percent", "25"], client=client) == EXIT_COST_CHECK
+        assert "grows by 30.0%" in capsys.readouterr().err
+        assert main(["estimate", "-f", str(request), "--baseline", str(baseline),
+                     "--max-increase-percent", "50"], client=client) == 0
+        assert "Total: 130.00 USD/month" in capsys.readouterr().out
+
+    def test_diff(self, tmp_path, capsys):
+        """Test diff sides from files and recorded estimates, and the failed status"""
+        head = tmp_path / "pr.json"
+        head.write_text(json.dumps(PLAN))
+        markdown = tmp_path / "comment.md"
+        client = FakeClient(diff={"diff": {"passed": False, "markdown": "### Cost diff: ❌ failed\n"}})
+
+        status = main(["diff", "--base-id", "est-1", "--head", str(head), "--head-label", "PR #42",
+                       "--max-increase-percent", "10", "--markdown", str(markdown)], client=client)
+
+        assert status == EXIT_COST_CHECK
+        request = client.calls[0][1][0]
+        assert request["base"] == {"label": "main", "estimate_id": "est-1"}
+        assert request["head"] == {"label": "PR #42", "terraform": PLAN}
+        assert request["thresholds"]["max_increase_percent"] == 10
+        assert markdown.read_text() == "### Cost diff: ❌ failed\n"
+        assert "Cost diff" in capsys.readouterr().out
+
+        assert main(["diff", "--head", str(head)], client=client) == 1
+
+    def test_diff_comment(self, monkeypatch, capsys):
+        """Test posting the summary to the CI build's pull request"""
+        comments = []
+
+        class FakeCommenter:
+            def comments(self, number):
+                return list(comments)
+
+            def create_comment(self, number, body):
+                comments.append(Comment("1", body, f"https://github.com/o/r/pull/{number}#c1"))
+                return comments[-1]
+
+        def build_commenter(platform, repository, token, **kwargs):
+            assert (platform, repository, token) == ("github", "o/r", "ghs_ci")
+            return FakeCommenter()
+
+        monkeypatch.setattr(cli, "build_commenter", build_commenter)
+        monkeypatch.setenv("GITHUB_REPOSITORY", "o/r")
+        monkeypatch.setenv("GITHUB_REF", "refs/pull/42/merge")
+        monkeypatch.setenv("GITHUB_TOKEN", "ghs_ci")
+        client = FakeClient(diff={"diff": {"passed": True, "markdown": "### Cost diff: ✅ passed\n"}})
+
+        assert main(["diff", "--base-id", "a", "--head-id", "b", "--comment", "github"], client=client) == 0
+        assert "Comment created on o/r#42" in capsys.readouterr().err
+        assert comments[0].body.startswith("<!-- kcloud-cost-estimator:cost-diff -->\n### Cost diff")
+
+        assert main(["diff", "--base-id", "a", "--head-id", "b", "--comment", "github"], client=client) == 0
+        assert "Comment unchanged" in capsys.readouterr().err
+
+    def test_compare_and_catalog_refresh(self, capsys):
+        client = FakeClient(
+            compare={"comparison": {"options": [
+                {"provider": "aws", "region": "us-east-1", "instance_type": "m5.2xlarge", "vcpus": 8,
+                 "memory_gb": 32, "pricing_model": "on_demand", "monthly_cost": 280.32},
+            ], "unavailable": {"gcp": "No priced option"}}},
+            refresh_catalogs={"message": "Catalog refresh started", "providers": ["aws", "static"]},
+        )
+
+        assert main(["compare", "--cpu", "8", "--mem", "32", "--region", "aws:us-east-1"], client=client) == 0
+        request = client.calls[0][1][0]
+        assert (request["vcpus"], request["memory_gb"], request["regions"]) == (8, 32, {"aws": ["us-east-1"]})
+        out = capsys.readouterr().out
+        assert "m5.2xlarge" in out and "280.32" in out and "gcp: No priced option" in out
+
+        assert main(["catalog", "refresh"], client=client) == 0
+        assert capsys.readouterr().out.strip() == "Catalog refresh started: aws, static"
+
+    def test_api_errors(self, capsys):
+        client = FakeClient(refresh_catalogs=APIError(409, "Catalog refresh is disabled in offline mode"))
+        assert main(["catalog", "refresh"], client=client) == 1
+        assert "HTTP 409: Catalog refresh is disabled" in capsys.readouterr().err
+
+
+class FakeSession(requests.Session):
+    """Session answering every request with one status and body"""
+
+    def __init__(self, status_code, body):
+        super().__init__()
+        self.status_code = status_code
+        self.body = body
+        self.sent = []
+
+    def request(self, method, url, **kwargs):
+        self.sent.append((method, url, kwargs))
+        response = requests.Response()
+        response.status_code = self.status_code
+        response._content = json.dumps(self.body).encode()
+        return response
+
+
+class TestEstimatorClient:
+    """Test cases for EstimatorClient class"""
+
+    def test_requests_and_errors(self):
+        session = FakeSession(200, {"estimate": {}})
+        client = EstimatorClient("http://estimator:8001/", api_key="kc_123", tenant="acme", session=session)
+        client.estimate_terraform(PLAN, region="us-east-1", labels={"ci": "1"}, currency="EUR")
+
+        method, url, kwargs = session.sent[0]
+        assert (method, url) == ("POST", "http://estimator:8001/estimate/terraform")
+        assert kwargs["params"] == {"region": "us-east-1", "currency": "EUR", "label": ["ci=1"]}
+        assert session.headers["X-API-Key"] == "kc_123" and session.headers["X-Tenant-ID"] == "acme"
+
+        session.status_code, session.body = 401, {"detail": "Invalid or revoked API key"}
+        with pytest.raises(APIError) as error:
+            client.refresh_catalogs()
+        assert error.value.status_code == 401 and error.value.detail == "Invalid or revoked API key"
+
+    def test_problem_details(self):
+        """Test every violation of a problem response is in the error"""
+        session = FakeSession(422, {"title": "Invalid request", "detail": "resources[0].count: too small (and 1 more)",
+                                    "violations": [
+                                        {"field": "resources[0].count", "code": "out_of_range", "message": "too small"},
+                                        {"field": "resources[1].region", "code": "missing", "message": "required"},
+                                    ]})
+        with pytest.raises(APIError) as error:
+            EstimatorClient(session=session).estimate({"resources": []})
+        assert error.value.detail == "resources[0].count: too small; resources[1].region: required"
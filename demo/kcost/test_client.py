@@ -0,0 +1,185 @@
+"""Unit tests for the estimator SDK client"""
+
+import io
+import json
+
+import pytest
+import requests
+
+from src.kcost import NO_RETRY, APIError, DeadlineExceeded, EstimatorClient, RetryPolicy
+
+
+class ScriptedSession(requests.Session):
+    """Session answering requests in turn with (status, body, headers) or raising exceptions"""
+
+    def __init__(self, *answers):
+        super().__init__()
+        self.answers = list(answers)
+        self.sent = []
+
+    def request(self, method, url, **kwargs):
+        self.sent.append((method, url, kwargs))
+        answer = self.answers.pop(0) if len(self.answers) > 1 else self.answers[0]
+        if isinstance(answer, Exception):
+            raise answer
+        status, body, headers = answer
+        response = requests.Response()
+        response.status_code = status
+        response.headers.update(headers)
+        response.raw = io.BytesIO(body if isinstance(body, bytes) else json.dumps(body).encode())
+        return response
+
+
+class FakeClock:
+    def __init__(self):
+        self.now = 100.0
+        self.slept = []
+
+    def __call__(self):
+        return self.now
+
+    def sleep(self, seconds):
+        self.slept.append(seconds)
+        self.now += seconds
+
+
+def _client(session, clock=None, **kwargs):
+    clock = clock or FakeClock()
+    return EstimatorClient("http://estimator:8001", session=session, sleep=clock.sleep, clock=clock, **kwargs)
+
+
+class TestRetries:
+    """Test cases for retries of failed requests"""
+
+    def test_unprocessed_requests_retried(self):
+        """Test 503 answers and refused connections are retried for POST requests"""
+        session = ScriptedSession(
+            (503, {"detail": "Service is shedding load"}, {"Retry-After": "4"}),
+            requests.ConnectionError("refused"),
+            (200, {"estimate": {"monthly_cost": 70.08}}, {}),
+        )
+        clock = FakeClock()
+        response = _client(session, clock).estimate({"resources": []})
+
+        assert response["estimate"]["monthly_cost"] == 70.08
+        assert len(session.sent) == 3
+        # Retry-After is honored over a shorter backoff
+        assert clock.slept[0] == 4.0 and 0.5 <= clock.slept[1] <= 1.0
+
+    def test_ambiguous_failures_retried_only_for_idempotent_methods(self):
+        """Test 502 answers and read timeouts repeat GET requests but not POST requests"""
+        session = ScriptedSession((502, {"detail": "Bad gateway"}, {}), (200, {"jobs": []}, {}))
+        assert _client(session).list_jobs(state="running") == {"jobs": []}
+
+        session = ScriptedSession((502, {"detail": "Bad gateway"}, {}))
+        with pytest.raises(APIError) as error:
+            _client(session).submit_job("terraform", {})
+        assert error.value.status_code == 502 and len(session.sent) == 1
+
+        session = ScriptedSession(requests.ReadTimeout("slow"))
+        with pytest.raises(requests.ReadTimeout):
+            _client(session).diff({})
+        assert len(session.sent) == 1
+
+    def test_attempts_and_client_errors(self):
+        """Test retries stop after the policy's attempts and client errors are not retried"""
+        session = ScriptedSession((429, {"detail": "Rate limit exceeded"}, {}))
+        with pytest.raises(APIError) as error:
+            _client(session, retry=RetryPolicy(attempts=2)).info()
+        assert error.value.status_code == 429 and len(session.sent) == 2
+
+        session = ScriptedSession((404, {"detail": "Job not found"}, {}))
+        with pytest.raises(APIError):
+            _client(session).get_job("job-1")
+        assert len(session.sent) == 1
+
+        session = ScriptedSession((503, {}, {}))
+        with pytest.raises(APIError):
+            _client(session, retry=NO_RETRY).info()
+        assert len(session.sent) == 1
+
+
+class TestCallOptions:
+    """Test cases for EstimatorClient.with_options()"""
+
+    def test_headers_and_timeout(self):
+        """Test a scoped client sends its request ID and tenant without changing the original"""
+        session = ScriptedSession((200, {}, {}))
+        client = _client(session, tenant="acme")
+        scoped = client.with_options(timeout=5, request_id="req-1", tenant="globex")
+        scoped.whoami()
+        client.whoami()
+
+        (_, _, scoped_kwargs), (_, _, kwargs) = session.sent
+        assert scoped_kwargs["headers"] == {"X-Request-ID": "req-1", "X-Tenant-ID": "globex"}
+        assert scoped_kwargs["timeout"] == 5
+        assert kwargs["headers"] is None and kwargs["timeout"] == 120
+
+    def test_deadline(self):
+        """Test attempts time out at the deadline and retries stop before passing it"""
+        session = ScriptedSession((503, {}, {"Retry-After": "30"}), (200, {}, {}))
+        clock = FakeClock()
+        scoped = _client(session, clock).with_options(deadline=10)
+        with pytest.raises(DeadlineExceeded):
+            scoped.estimate({"resources": []})
+        assert session.sent[0][2]["timeout"] == 10 and clock.slept == []
+
+        clock.now += 20
+        with pytest.raises(DeadlineExceeded):
+            scoped.info()
+
+    def test_wait_job(self):
+        """Test a job is polled until it finished"""
+        session = ScriptedSession(
+            (200, {"job": {"id": "job-1", "state": "queued"}}, {}),
+            (200, {"job": {"id": "job-1", "state": "running", "progress": 0.5}}, {}),
+            (200, {"job": {"id": "job-1", "state": "succeeded", "estimate_id": "est-1"}}, {}),
+        )
+        clock = FakeClock()
+        response = _client(session, clock).wait_job("job-1", interval=3)
+        assert response["job"]["estimate_id"] == "est-1" and clock.slept == [3, 3]
+
+    def test_wait_approval(self):
+        """Test an estimate's review is polled until it was decided"""
+        session = ScriptedSession(
+            (200, {"review": {"estimate_id": "est-1", "status": "pending"}}, {}),
+            (200, {"review": {"estimate_id": "est-1", "status": "rejected", "decided_by": "lead"}}, {}),
+        )
+        clock = FakeClock()
+        response = _client(session, clock).wait_approval("est-1", interval=5)
+        assert response["review"]["status"] == "rejected" and clock.slept == [5]
+        assert session.sent[0][1].endswith("/estimates/est-1/review")
+
+
+class TestEndpoints:
+    """Test cases for the endpoint methods"""
+
+    def test_paths_and_params(self):
+        """Test path segments are escaped and from_ is sent as from"""
+        session = ScriptedSession((200, {}, {}), (204, b"", {}), (200, b"BilledCost\n1.0\n", {}))
+        client = _client(session)
+
+        client.accuracy_report(from_="2026-01", to="2026-06", project=None)
+        assert client.delete_webhook("hook/1") == {}
+        assert client.focus_export(format="csv") == b"BilledCost\n1.0\n"
+
+        (_, url, kwargs), (method, delete_url, _), (_, focus_url, _) = session.sent
+        assert url == "http://estimator:8001/reports/accuracy"
+        assert kwargs["params"] == {"from": "2026-01", "to": "2026-06"}
+        assert (method, delete_url) == ("DELETE", "http://estimator:8001/webhooks/hook%2F1")
+        assert focus_url == "http://estimator:8001/reports/focus"
+
+    def test_estimate_stream(self):
+        """Test Server-Sent Events are decoded as they arrive"""
+        body = (
+            b'event: progress\ndata: {"priced": 1, "total": 2}\n\n'
+            b'event: result\ndata: {"monthly_cost": 140.16}\n\n'
+        )
+        session = ScriptedSession((200, body, {"Content-Type": "text/event-stream"}))
+        events = list(_client(session).estimate_stream({"items": []}))
+
+        assert events == [
+            {"event": "progress", "data": {"priced": 1, "total": 2}},
+            {"event": "result", "data": {"monthly_cost": 140.16}},
+        ]
+        assert session.sent[0][2]["stream"] is True
@@ -0,0 +1 @@
+"""Tests for logging module"""
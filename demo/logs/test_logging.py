@@ -0,0 +1,78 @@
+"""Unit tests for structured logging"""
+
+import json
+import logging
+
+import pytest
+
+from src.logs import (
+    JsonFormatter,
+    RequestContextFilter,
+    configure_logging,
+    get_request_id,
+    request_context,
+)
+
+
+def make_record(message, **extra):
+    record = logging.LogRecord("src.test", logging.WARNING, __file__, 1, message, None, None)
+    for key, value in extra.items():
+        setattr(record, key, value)
+    return record
+
+
+class TestRequestContext:
+    """Test cases for request ID binding"""
+
+    def test_bind_and_reset(self):
+        """Test the ID is bound inside the block only"""
+        assert get_request_id() is None
+        with request_context("req-1") as request_id:
+            assert request_id == "req-1"
+            assert get_request_id() == "req-1"
+            with request_context() as generated:
+                assert generated != "req-1" and get_request_id() == generated
+            assert get_request_id() == "req-1"
+        assert get_request_id() is None
+
+
+class TestFormatting:
+    """Test cases for log record formatting"""
+
+    def test_json_record(self):
+        """Test JSON output carries service, request ID and extra fields"""
+        record = make_record("estimate done", path="/estimate", status=200)
+        with request_context("req-2"):
+            RequestContextFilter("kcloud-cost-estimator").filter(record)
+
+        entry = json.loads(JsonFormatter().format(record))
+        assert entry["message"] == "estimate done"
+        assert entry["level"] == "WARNING" and entry["logger"] == "src.test"
+        assert entry["service"] == "kcloud-cost-estimator"
+        assert entry["request_id"] == "req-2"
+        assert entry["path"] == "/estimate" and entry["status"] == 200
+        assert "args" not in entry and "msg" not in entry
+
+    def test_json_without_request(self):
+        """Test records outside a request have no request ID"""
+        record = make_record("startup")
+        RequestContextFilter("svc").filter(record)
+
+        assert "request_id" not in json.loads(JsonFormatter().format(record))
+        assert record.request_id == "-"
+
+    def test_configure_logging(self):
+        """Test the root logger gets a single handler with the chosen format"""
+        root = logging.getLogger()
+        saved_handlers, saved_level = list(root.handlers), root.level
+        try:
+            configure_logging("debug", "json", "svc")
+            assert root.level == logging.DEBUG
+            assert len(root.handlers) == 1
+            assert isinstance(root.handlers[0].formatter, JsonFormatter)
+
+            with pytest.raises(ValueError, match="text, json"):
+                configure_logging("info", "xml")
+        finally:
+            root.handlers[:] = saved_handlers
+            root.setLevel(saved_level)
@@ -0,0 +1,139 @@
+"""Unit tests for estimator metrics"""
+
+import pytest
+from prometheus_client import REGISTRY
+
+from src.k8s.models import NamespaceCost
+from src.metrics import observe_estimate, observe_pricing_api, record_namespace_costs
+from src.providers.cache import CatalogCache
+from src.providers.gcp.client import CloudBillingClient
+
+
+def sample(name, **labels):
+    return REGISTRY.get_sample_value(name, labels) or 0.0
+
+
+class FakeResponse:
+    def __init__(self, data):
+        self.data = data
+
+    def raise_for_status(self):
+        pass
+
+    def json(self):
+        return self.data
+
+
+class FakeSession:
+    def get(self, url, params=None, timeout=None):
+        return FakeResponse({"skus": [{"skuId": "1"}]})
+
+
+class TestEstimateMetrics:
+    """Test cases for estimate request metrics"""
+
+    def test_counts_each_provider_once(self):
+        """Test an estimate is counted per distinct provider and timed once"""
+        before_aws = sample("kcloud_estimate_requests_total", endpoint="test", provider="aws", status="success")
+        before_count = sample("kcloud_estimate_duration_seconds_count", endpoint="test")
+
+        with observe_estimate("test", ["aws", "aws", "gcp"]):
+            pass
+
+        assert sample("kcloud_estimate_requests_total", endpoint="test", provider="aws", status="success") == before_aws + 1
+        assert sample("kcloud_estimate_requests_total", endpoint="test", provider="gcp", status="success") >= 1
+        assert sample("kcloud_estimate_duration_seconds_count", endpoint="test") == before_count + 1
+
+    def test_errors(self):
+        """Test failed estimates are counted as errors and re-raised"""
+        before = sample("kcloud_estimate_requests_total", endpoint="test", provider="azure", status="error")
+
+        with pytest.raises(ValueError):
+            with observe_estimate("test", ["azure"]):
+                raise ValueError("bad request")
+
+        assert sample("kcloud_estimate_requests_total", endpoint="test", provider="azure", status="error") == before + 1
+
+
+class TestCatalogMetrics:
+    """Test cases for cache and upstream API metrics"""
+
+    def test_cache_lookups(self, tmp_path):
+        """Test hits, misses and stale reads per provider"""
+        cache = CatalogCache(cache_dir=str(tmp_path / "metrics-test"), ttl_seconds=60)
+        assert cache.provider == "metrics-test"
+
+        def count(result):
+            return sample("kcloud_catalog_cache_lookups_total", provider="metrics-test", result=result)
+
+        assert cache.load("AmazonEC2") is None
+        assert count("miss") == 1
+
+        cache.save("AmazonEC2", {"entries": {}})
+        assert cache.load("AmazonEC2") is not None
+        assert count("hit") == 1
+
+        cache.ttl_seconds = -1
+        assert cache.load("AmazonEC2") is None
+        assert cache.load("AmazonEC2", allow_stale=True) is not None
+        assert count("miss") == 2 and count("stale") == 1
+
+    def test_pricing_api_latency(self):
+        """Test upstream calls are timed by provider, operation and outcome"""
+        before = sample("kcloud_pricing_api_request_duration_seconds_count",
+                        provider="gcp", operation="list_skus", status="success")
+        client = CloudBillingClient(api_key="key")
+        client.session = FakeSession()
+
+        assert len(client.list_skus("service")) == 1
+        assert sample("kcloud_pricing_api_request_duration_seconds_count",
+                      provider="gcp", operation="list_skus", status="success") == before + 1
+
+        with pytest.raises(ConnectionError):
+            with observe_pricing_api("gcp", "list_skus"):
+                raise ConnectionError("unreachable")
+        assert sample("kcloud_pricing_api_request_duration_seconds_count",
+                      provider="gcp", operation="list_skus", status="error") >= 1
+
+
+def _namespace(name, compute, storage=0.0, load_balancer=0.0):
+    return NamespaceCost(
+        namespace=name,
+        workloads=1,
+        compute_monthly_cost=compute,
+        storage_monthly_cost=storage,
+        load_balancer_monthly_cost=load_balancer,
+        monthly_cost=compute + storage + load_balancer,
+    )
+
+
+class TestNamespaceCostMetrics:
+    """Test cases for live namespace cost gauges"""
+
+    def test_namespace_costs(self):
+        """Test each namespace's total and components are exported with the idle cost"""
+        record_namespace_costs("test-a", [_namespace("web", 100.0, 20.0, 16.4)], 42.0, collected_at=1700000000)
+
+        assert sample("kcost_namespace_monthly_cost", cluster="test-a", namespace="web") == pytest.approx(136.4)
+        assert sample(
+            "kcost_namespace_component_monthly_cost", cluster="test-a", namespace="web", component="storage"
+        ) == 20.0
+        assert sample("kcost_cluster_idle_monthly_cost", cluster="test-a") == 42.0
+        assert sample("kcost_namespace_costs_last_collected_timestamp_seconds", cluster="test-a") == 1700000000
+
+    def test_removed_namespaces(self):
+        """Test namespaces missing from a collection stop being reported, other clusters' stay"""
+        record_namespace_costs("test-b", [_namespace("web", 10.0), _namespace("batch", 5.0)], 0.0)
+        record_namespace_costs("test-c", [_namespace("batch", 7.0)], 0.0)
+
+        record_namespace_costs("test-b", [_namespace("web", 12.0)], 0.0)
+
+        assert sample("kcost_namespace_monthly_cost", cluster="test-b", namespace="web") == 12.0
+        assert REGISTRY.get_sample_value(
+            "kcost_namespace_monthly_cost", {"cluster": "test-b", "namespace": "batch"}
+        ) is None
+        assert REGISTRY.get_sample_value(
+            "kcost_namespace_component_monthly_cost",
+            {"cluster": "test-b", "namespace": "batch", "component": "compute"},
+        ) is None
+        assert sample("kcost_namespace_monthly_cost", cluster="test-c", namespace="batch") == 7.0
@@ -0,0 +1,121 @@
+"""Unit tests for decimal amounts and currency rounding"""
+
+from decimal import Decimal
+
+import pytest
+
+from src.estimator import CostEstimator, EstimateRequest, ResourceSpec
+from src.money import (
+    ROUNDING_DOWN,
+    ROUNDING_HALF_EVEN,
+    CurrencyRounding,
+    add_amounts,
+    allocate,
+    configure_rounding,
+    currency_rounding,
+    multiply_money,
+    parse_currency_rounding,
+    round_currency,
+    round_money,
+    sum_money,
+)
+from src.pricing import Price, PriceCatalog, ProviderRegistry, StaticProvider, usage_amount
+
+
+@pytest.fixture(autouse=True)
+def default_rounding():
+    """Run each test with the default rounding, restored afterwards"""
+    configure_rounding()
+    yield
+    configure_rounding()
+
+
+class TestAmounts:
+    """Test cases for decimal sums and rounding"""
+
+    def test_round_money(self):
+        """Test halves round up by default and to even with half_even"""
+        assert round_money(0.12345) == 0.1235
+        assert round_money(2.675, 2) == 2.68
+
+        configure_rounding(ROUNDING_HALF_EVEN)
+        assert round_money(0.12345) == 0.1234
+        assert round_money(0.12355) == 0.1236
+
+    def test_exact_sums(self):
+        """Test sums are exact before rounding"""
+        assert sum_money([0.1, 0.1, 0.1]) == 0.3
+        assert sum_money([0.00004] * 5) == 0.0002
+        assert sum_money([]) == 0.0
+        assert multiply_money(19.99, 1380.5) == 27596.195
+        assert add_amounts([0.1, 0.2, Decimal("0.3")]) == Decimal("0.6")
+
+    def test_line_items_in_decimal(self):
+        """Test line item costs are computed in decimal, not rounded from a float product"""
+        price = Price(provider="aws", region="us-east-1", sku="gp3", service="storage", unit="GB-month",
+                      price=0.000205, source="test")
+        # 0.000205 * 10 is 0.0020499999999999997 in float
+        assert usage_amount(price, 10) == Decimal("0.00205")
+
+        registry = ProviderRegistry()
+        catalog = PriceCatalog({"aws": {"us-east-1": {"t4g.nano": 0.000405}}}, storage_prices={})
+        registry.register(StaticProvider(catalog))
+        item, = CostEstimator(registry=registry).estimate(EstimateRequest(resources=[
+            ResourceSpec(instance_type="t4g.nano", region="us-east-1", hours=730),
+        ])).line_items
+        assert item.monthly_cost == 0.2957
+
+    def test_currency_minor_units(self):
+        """Test zero- and three-decimal currencies round to their minor unit"""
+        assert round_currency(1234.5, "JPY") == Decimal("1235")
+        assert round_currency(1234.4999, "krw") == Decimal("1234")
+        assert round_currency(1.23456, "KWD") == Decimal("1.235")
+        assert round_currency(1.005, "USD") == Decimal("1.01")
+
+
+class TestRoundingRules:
+    """Test cases for per-currency rounding rules"""
+
+    def test_overrides(self):
+        """Test a currency's places and mode override the defaults"""
+        configure_rounding(ROUNDING_HALF_EVEN, "krw=0:down, CHF=1")
+
+        assert currency_rounding("KRW") == CurrencyRounding(0, ROUNDING_DOWN)
+        assert currency_rounding("CHF") == CurrencyRounding(1, ROUNDING_HALF_EVEN)
+        assert currency_rounding("EUR") == CurrencyRounding(2, ROUNDING_HALF_EVEN)
+        assert round_currency(1999.9, "KRW") == Decimal("1999")
+        assert round_currency(1.25, "CHF") == Decimal("1.2")
+
+    def test_invalid_rules(self):
+        """Test unknown modes and unreadable rules are rejected"""
+        with pytest.raises(ValueError, match="half_up, half_even, down"):
+            configure_rounding("ceiling")
+        with pytest.raises(ValueError, match="CUR=places"):
+            parse_currency_rounding("KRW")
+        with pytest.raises(ValueError, match="CUR=places"):
+            parse_currency_rounding("KRW=two")
+        with pytest.raises(ValueError):
+            parse_currency_rounding("KRW=0:up")
+
+
+class TestAllocate:
+    """Test cases for allocating a total to shares"""
+
+    def test_shares_add_up(self):
+        """Test leftover minor units go to the largest remainders, ties to the first share"""
+        thirds = {"a": 100 / 3, "b": 100 / 3, "c": 100 / 3}
+        shares = allocate(100, thirds, "USD")
+
+        assert shares == {"a": Decimal("33.34"), "b": Decimal("33.33"), "c": Decimal("33.33")}
+        assert sum(shares.values()) == Decimal("100.00")
+
+        shares = allocate(1000, {"a": 200.4, "b": 499.8, "c": 299.8}, "KRW")
+        assert shares == {"a": Decimal("200"), "b": Decimal("500"), "c": Decimal("300")}
+        shares = allocate(10, {"a": 6.6, "b": 3.4}, "JPY")
+        assert shares == {"a": Decimal("7"), "b": Decimal("3")}
+
+    def test_negative_and_empty(self):
+        """Test credits are allocated like charges and no shares allocate nothing"""
+        shares = allocate(-0.1, {"a": -0.05, "b": -0.05, "c": 0.0}, "USD")
+        assert shares == {"a": Decimal("-0.05"), "b": Decimal("-0.05"), "c": Decimal("0.00")}
+        assert allocate(100, {}, "USD") == {}
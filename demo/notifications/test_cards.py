@@ -0,0 +1,93 @@
+"""Unit tests for Slack and Teams cards"""
+
+import json
+from datetime import datetime, timezone
+
+from src.notifications import Event, payload, EVENT_BUDGET_THRESHOLD, EVENT_COST_DIFF, EVENT_TEST
+
+OCCURRED = datetime(2026, 10, 14, 9, 30, tzinfo=timezone.utc)
+
+
+def _budget_event():
+    return Event(
+        type=EVENT_BUDGET_THRESHOLD,
+        summary="Budget 'web' is projected at 85% of $1,000.00/month, reaching the 80% threshold",
+        occurred_at=OCCURRED,
+        data={
+            "budget": {"id": "b1", "name": "web", "amount": 1000.0, "project": "web", "labels": {}},
+            "threshold": 0.8,
+            "status": {"actual_to_date": 390.0, "projected_actual": 850.0, "utilization": 0.85},
+        },
+    )
+
+
+def _diff_event(changes=7):
+    return Event(
+        type=EVENT_COST_DIFF,
+        tenant_id="acme",
+        summary="PR #42 changes the monthly cost of main by +$140.16 (failed)",
+        occurred_at=OCCURRED,
+        data={"diff": {
+            "base_label": "main",
+            "head_label": "PR #42",
+            "base_monthly_cost": 280.32,
+            "head_monthly_cost": 420.48,
+            "monthly_delta": 140.16,
+            "delta_percent": 50.0,
+            "passed": False,
+            "failures": ["monthly increase $140.16 exceeds $100.00"],
+            "changes": [
+                {"key": f"aws_instance.web[{i}]", "change": "added", "monthly_delta": 20.02} for i in range(changes)
+            ],
+            "unpriced": [],
+        }},
+    )
+
+
+class TestCards:
+    """Test cases for Block Kit and Adaptive Card payloads"""
+
+    def test_slack_budget_card(self):
+        """Test budget alerts list their figures as fields"""
+        message = json.loads(payload(_budget_event(), "slack_blocks"))
+
+        assert message["text"].startswith("Budget threshold reached: Budget 'web'")
+        blocks = message["blocks"]
+        assert blocks[0] == {"type": "header", "text": {"type": "plain_text", "text": "Budget threshold reached"}}
+        assert blocks[1]["text"]["text"].startswith(":warning: ")
+        fields = [field["text"] for field in blocks[2]["fields"]]
+        assert fields[:3] == ["*Budget*\nweb", "*Amount*\n$1,000.00/month", "*Projected*\n$850.00"]
+        assert "*Threshold*\n80%" in fields
+        assert blocks[-1]["elements"][0]["text"] == "budget.threshold_reached | tenant default | 2026-10-14 09:30 UTC"
+
+    def test_teams_diff_card(self):
+        """Test cost diffs show both sides, the result and the largest changes"""
+        message = json.loads(payload(_diff_event(), "teams"))
+
+        assert message["type"] == "message"
+        card = message["attachments"][0]
+        assert card["contentType"] == "application/vnd.microsoft.card.adaptive"
+        body = card["content"]["body"]
+        assert body[0]["text"] == "Cost diff: main -> PR #42"
+        assert body[0]["color"] == "Attention"
+        assert body[1]["text"] == "PR #42 changes the monthly cost of main by +$140.16 (failed)"
+        facts = {fact["title"]: fact["value"] for fact in body[2]["facts"]}
+        assert facts == {
+            "main": "$280.32/month",
+            "PR #42": "$420.48/month",
+            "Change": "+$140.16/month (+50.0%)",
+            "Result": "failed: monthly increase $140.16 exceeds $100.00",
+        }
+        lines = body[3]["text"].split("\n")
+        assert lines[0] == "- aws_instance.web[0] (added): +$20.02/month"
+        assert lines[-1] == "- ... and 2 more"
+        assert "tenant acme" in body[4]["text"]
+
+    def test_other_events(self):
+        """Test events without figures are summarized; plain Slack payloads stay a text line"""
+        event = Event(type=EVENT_TEST, summary="Test notification for webhook 'chat'", occurred_at=OCCURRED)
+
+        message = json.loads(payload(event, "slack_blocks"))
+        assert [block["type"] for block in message["blocks"]] == ["header", "section", "context"]
+        assert message["blocks"][1]["text"]["text"] == "Test notification for webhook 'chat'"
+        assert json.loads(payload(event, "slack")) == {"text": "[webhook.test] Test notification for webhook 'chat'"}
@@ -0,0 +1,273 @@
+"""Unit tests for webhooks, signing and notification delivery"""
+
+import json
+import time
+from datetime import date, datetime, timezone
+
+import pytest
+
+from src.anomalies import AnomalyDetector
+from src.cache import MemoryLocks
+from src.budgets import ActualCostEntry, BudgetEvaluator, BudgetSpec
+from src.notifications import (
+    AnomalyAlerts,
+    BudgetAlerts,
+    Event,
+    NotificationDispatcher,
+    Webhook,
+    WebhookSpec,
+    catalog_failure_notifier,
+    payload,
+    sign,
+    verify,
+    EVENT_ANOMALY,
+    EVENT_BUDGET_THRESHOLD,
+    EVENT_CATALOG_REFRESH_FAILED,
+    SIGNATURE_HEADER,
+    TIMESTAMP_HEADER,
+)
+from src.pricing import add_refresh_failure_listener, remove_refresh_failure_listener, report_refresh_failure
+from src.store import DEFAULT_TENANT
+
+SECRET = "whsec_0123456789abcdef"
+
+
+class FakeClock:
+    def __init__(self):
+        self.now = 0.0
+
+    def __call__(self):
+        return self.now
+
+
+class FakeReceiver:
+    """Sender answering with queued status codes (or raising queued exceptions)"""
+
+    def __init__(self, *responses):
+        self.responses = list(responses)
+        self.requests = []
+
+    def __call__(self, url, body, headers, timeout):
+        self.requests.append((url, body, headers))
+        response = self.responses.pop(0) if self.responses else 200
+        if isinstance(response, Exception):
+            raise response
+        return response
+
+
+def _webhook(store, tenant_id="acme", **fields):
+    spec = WebhookSpec(**dict({"name": "ops", "url": "https://hooks.example.com/a", "secret": SECRET}, **fields))
+    return store.save_webhook(spec.to_record(tenant_id))
+
+
+def _event(tenant_id="acme", type=EVENT_BUDGET_THRESHOLD):
+    return Event(type=type, tenant_id=tenant_id, summary="Budget 'web' reached 80%", data={"threshold": 0.8})
+
+
+class TestWebhookSpec:
+    """Test cases for webhook definitions"""
+
+    def test_validation(self):
+        """Test URLs, event types and formats are validated"""
+        assert WebhookSpec(name="a", url="https://x", events=["catalog.refresh_failed"]).format == "json"
+        with pytest.raises(ValueError):
+            WebhookSpec(name="a", url="ftp://x")
+        with pytest.raises(ValueError, match="Unknown event"):
+            WebhookSpec(name="a", url="https://x", events=["budget.exceeded"])
+        with pytest.raises(ValueError):
+            WebhookSpec(name="a", url="https://x", format="xml")
+        assert WebhookSpec(name="a", url="https://x", format=" Teams").format == "teams"
+
+    def test_secret_not_exposed(self, store):
+        """Test stored webhooks report whether they have a secret, never the secret"""
+        record = _webhook(store)
+        webhook = Webhook.from_record(store.get_webhook(record.id, tenant_id="acme"))
+
+        assert webhook.has_secret
+        assert "secret" not in webhook.dict()
+        assert store.get_webhook(record.id, tenant_id="other") is None
+
+
+class TestSigning:
+    """Test cases for payload signatures"""
+
+    def test_sign_and_verify(self):
+        """Test the signature covers the timestamp and the body"""
+        signature = sign(SECRET, 1700000000, b'{"a": 1}')
+
+        assert signature.startswith("sha256=")
+        assert verify(SECRET, 1700000000, b'{"a": 1}', signature)
+        assert not verify(SECRET, 1700000001, b'{"a": 1}', signature)
+        assert not verify("whsec_other-secret-value", 1700000000, b'{"a": 1}', signature)
+
+    def test_payload_formats(self):
+        """Test JSON payloads carry the event and Slack payloads a text line"""
+        event = _event()
+
+        document = json.loads(payload(event, "json"))
+        assert document["type"] == EVENT_BUDGET_THRESHOLD
+        assert document["data"] == {"threshold": 0.8}
+        assert json.loads(payload(event, "slack")) == {"text": "[budget.threshold_reached] Budget 'web' reached 80%"}
+
+
+class TestNotificationDispatcher:
+    """Test cases for webhook delivery"""
+
+    def test_signed_delivery_to_subscribers(self, store):
+        """Test events reach the tenant's enabled, subscribed webhooks only"""
+        _webhook(store, name="all")
+        _webhook(store, name="catalog", events=[EVENT_CATALOG_REFRESH_FAILED])
+        _webhook(store, name="off", enabled=False)
+        _webhook(store, tenant_id="other", name="other")
+        receiver = FakeReceiver()
+        dispatcher = NotificationDispatcher(store, send=receiver, clock=FakeClock())
+
+        assert dispatcher.publish(_event()) == 1
+        results = dispatcher.run_pending()
+
+        assert [r.delivered for r in results] == [True]
+        _, body, headers = receiver.requests[0]
+        assert verify(SECRET, int(headers[TIMESTAMP_HEADER]), body, headers[SIGNATURE_HEADER])
+
+    def test_retry_with_backoff(self, store):
+        """Test 5xx and connection errors are retried after growing delays"""
+        _webhook(store)
+        clock = FakeClock()
+        receiver = FakeReceiver(503, ConnectionError("refused"), 200)
+        dispatcher = NotificationDispatcher(store, backoff=2, send=receiver, clock=clock)
+
+        dispatcher.publish(_event())
+        assert dispatcher.run_pending()[0].retry
+
+        clock.now = 1.9
+        assert dispatcher.run_pending() == []
+        clock.now = 2.0
+        assert dispatcher.run_pending()[0].error == "refused"
+
+        clock.now = 5.9
+        assert dispatcher.run_pending() == []
+        clock.now = 6.0
+        result = dispatcher.run_pending()[0]
+        assert result.delivered and result.attempt == 3
+        assert dispatcher.pending == 0
+
+    def test_gives_up(self, store):
+        """Test client errors are final and retries stop at max_attempts"""
+        _webhook(store)
+        clock = FakeClock()
+        dispatcher = NotificationDispatcher(
+            store, max_attempts=2, backoff=1, send=FakeReceiver(410, 500, 500), clock=clock
+        )
+
+        dispatcher.publish(_event())
+        result = dispatcher.run_pending()[0]
+        assert not result.delivered and not result.retry
+
+        dispatcher.publish(_event())
+        assert dispatcher.run_pending()[0].retry
+        clock.now = 1
+        assert not dispatcher.run_pending()[0].retry
+        assert dispatcher.pending == 0
+
+    def test_deliver_now(self, store):
+        """Test a test delivery is attempted once without retries"""
+        record = _webhook(store)
+        dispatcher = NotificationDispatcher(store, send=FakeReceiver(500), clock=FakeClock())
+
+        result = dispatcher.deliver_now(record, _event(type="webhook.test"))
+
+        assert result.status_code == 500 and not result.retry
+        assert dispatcher.pending == 0
+
+    def test_worker(self, store):
+        """Test the background worker delivers published events"""
+        _webhook(store)
+        receiver = FakeReceiver()
+        dispatcher = NotificationDispatcher(store, send=receiver)
+        dispatcher.start()
+        try:
+            dispatcher.publish(_event())
+            for _ in range(200):
+                if receiver.requests:
+                    break
+                time.sleep(0.01)
+        finally:
+            dispatcher.stop(timeout=1)
+
+        assert len(receiver.requests) == 1
+
+
+class TestAlerts:
+    """Test cases for budget, anomaly and catalog events"""
+
+    def test_budget_threshold_once_per_month(self, store):
+        """Test a budget notifies each threshold its actual spend reaches once"""
+        _webhook(store)
+        now = datetime(2026, 6, 11, tzinfo=timezone.utc)
+        budget = store.save_budget(BudgetSpec(name="team", amount=300).to_record("acme"))
+        receiver = FakeReceiver()
+        dispatcher = NotificationDispatcher(store, send=receiver, clock=FakeClock())
+        alerts = BudgetAlerts(BudgetEvaluator(store, clock=lambda: now), dispatcher)
+
+        # $60 in 10 days projects to $180/month: 60% of the budget
+        store.add_actual_costs([ActualCostEntry(usage_date=date(2026, 6, 5), amount=60).to_record("acme")])
+        events = alerts.check("acme")
+        assert [e.data["threshold"] for e in events] == [0.5]
+        assert events[0].data["budget"]["id"] == budget.id
+        assert alerts.check("acme") == []
+
+        store.add_actual_costs([ActualCostEntry(usage_date=date(2026, 6, 6), amount=40).to_record("acme")])
+        assert [e.data["threshold"] for e in alerts.check("acme")] == [1.0]
+        assert len(dispatcher.run_pending()) == 2
+
+    def test_anomaly_once(self, store):
+        """Test a spend spike notifies the tenant's webhooks once"""
+        _webhook(store, events=[EVENT_ANOMALY])
+        store.add_actual_costs([
+            ActualCostEntry(usage_date=date(2026, 6, day), amount=500 if day == 10 else 100, service="compute", project="web").to_record("acme")
+            for day in range(1, 11)
+        ])
+        receiver = FakeReceiver()
+        dispatcher = NotificationDispatcher(store, send=receiver, clock=FakeClock())
+        detector = AnomalyDetector(store, clock=lambda: datetime(2026, 6, 11, tzinfo=timezone.utc))
+        alerts = AnomalyAlerts(detector, dispatcher)
+
+        events = alerts.check("acme")
+        assert sorted(e.data["anomaly"]["key"] for e in events) == ["compute", "web"]
+        assert "$500.00" in events[0].summary
+        assert alerts.check("acme") == []
+
+        dispatcher.run_pending()
+        assert json.loads(receiver.requests[0][1])["type"] == EVENT_ANOMALY
+
+    def test_anomaly_once_across_replicas(self, store):
+        """Test replicas sharing locks notify an anomaly once between them"""
+        store.add_actual_costs([
+            ActualCostEntry(usage_date=date(2026, 6, day), amount=500 if day == 10 else 100).to_record("acme")
+            for day in range(1, 11)
+        ])
+        dispatcher = NotificationDispatcher(store, send=FakeReceiver(), clock=FakeClock())
+        detector = AnomalyDetector(store, clock=lambda: datetime(2026, 6, 11, tzinfo=timezone.utc))
+        locks = MemoryLocks()
+        first = AnomalyAlerts(detector, dispatcher, locks=locks)
+        second = AnomalyAlerts(detector, dispatcher, locks=locks)
+
+        assert len(first.check("acme")) == 1
+        assert second.check("acme") == []
+
+    def test_catalog_refresh_failure(self, store):
+        """Test reported refresh failures reach the default tenant's webhooks"""
+        _webhook(store, tenant_id=DEFAULT_TENANT, events=[EVENT_CATALOG_REFRESH_FAILED])
+        receiver = FakeReceiver()
+        dispatcher = NotificationDispatcher(store, send=receiver, clock=FakeClock())
+        listener = catalog_failure_notifier(dispatcher)
+        add_refresh_failure_listener(listener)
+        try:
+            report_refresh_failure("aws", "AmazonEC2/us-east-1", TimeoutError("read timed out"))
+        finally:
+            remove_refresh_failure_listener(listener)
+
+        dispatcher.run_pending()
+        document = json.loads(receiver.requests[0][1])
+        assert document["type"] == EVENT_CATALOG_REFRESH_FAILED
+        assert document["data"]["provider"] == "aws"
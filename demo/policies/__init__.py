@@ -0,0 +1 @@
+"""Tests for cost policy module"""
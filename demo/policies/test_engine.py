@@ -0,0 +1,197 @@
+"""Unit tests for the cost policy engine"""
+
+import shutil
+import sys
+from types import SimpleNamespace
+
+import pytest
+
+from src.policies import (
+    EmbeddedEvaluator,
+    OpaEvaluator,
+    PolicyEngine,
+    PolicyError,
+    PolicyUnavailableError,
+    build_policy_engine,
+    diff_input,
+    estimate_input,
+    load_policies,
+    query_value,
+)
+
+POLICY = """
+package kcloud.cost
+
+import rego.v1
+
+deny contains msg if {
+    some item in input.estimate.line_items
+    item.monthly_cost > 500
+    msg := sprintf("%s costs more than $500/month", [item.name])
+}
+
+deny contains msg if {
+    input.labels.environment == "dev"
+    some item in input.estimate.line_items
+    item.pricing_model != "spot"
+    msg := sprintf("%s must use spot pricing in dev", [item.name])
+}
+
+warn contains msg if {
+    input.estimate.monthly_cost > 100
+    msg := "Estimate is over $100/month"
+}
+"""
+
+
+class FakeEvaluator:
+    """Evaluator returning a fixed value and recording its calls"""
+
+    def __init__(self, value=None, error=None):
+        self.value = value
+        self.error = error
+        self.calls = []
+
+    def evaluate(self, modules, document, query):
+        self.calls.append((modules, document, query))
+        if self.error is not None:
+            raise self.error
+        return self.value
+
+
+def _estimate(*items):
+    line_items = [{"name": name, "monthly_cost": cost, "pricing_model": model} for name, cost, model in items]
+    return {"line_items": line_items, "monthly_cost": sum(item["monthly_cost"] for item in line_items)}
+
+
+class TestPolicyEngine:
+    """Test cases for verdicts of the deny and warn rules"""
+
+    def test_verdict(self):
+        """Test deny messages deny and warn messages are only reported"""
+        evaluator = FakeEvaluator({"deny": ["db costs more than $500/month", {"msg": "web must use spot"}],
+                                   "warn": ["Estimate is over $100/month"]})
+        engine = PolicyEngine({"cost.rego": POLICY}, evaluator)
+        document = estimate_input("resources", _estimate(("db", 620.5, "on_demand")), "shop", {"team": "a"})
+
+        verdict = engine.evaluate(document)
+
+        assert not verdict.allowed
+        assert verdict.denials == ["db costs more than $500/month", "web must use spot"]
+        assert verdict.warnings == ["Estimate is over $100/month"]
+        assert verdict.policies == ["cost.rego"]
+        modules, sent, query = evaluator.calls[0]
+        assert query == "data.kcloud.cost"
+        assert sent == {"kind": "resources", "project": "shop", "labels": {"team": "a"},
+                        "estimate": document["estimate"]}
+
+    def test_allowed(self):
+        """Test no deny messages, or an undefined package, allow"""
+        assert PolicyEngine({"a.rego": ""}, FakeEvaluator({"warn": []})).evaluate({}).allowed
+        assert PolicyEngine({"a.rego": ""}, FakeEvaluator(None)).evaluate({}).allowed
+
+        verdict = PolicyEngine({"a.rego": ""}, FakeEvaluator({"deny": [{"limit": 500}]})).evaluate({})
+        assert verdict.denials == ['{"limit": 500}']
+
+    def test_failures_deny(self):
+        """Test policies that cannot be evaluated deny with the error"""
+        verdict = PolicyEngine({"a.rego": ""}, FakeEvaluator(error=PolicyError("rego_parse_error"))).evaluate({})
+        assert not verdict.allowed
+        assert verdict.error == "rego_parse_error"
+
+        verdict = PolicyEngine({"a.rego": ""}, FakeEvaluator(error=PolicyUnavailableError("no opa"))).evaluate({})
+        assert verdict.error == "no opa"
+
+        verdict = PolicyEngine({"a.rego": ""}, FakeEvaluator(True)).evaluate({})
+        assert not verdict.allowed
+        assert "not a package" in verdict.error
+
+    def test_diff_input(self):
+        """Test diffs are sent with the head side's labels"""
+        document = diff_input({"monthly_delta": 12.5}, {"environment": "dev"})
+        assert document == {"kind": "diff", "labels": {"environment": "dev"}, "diff": {"monthly_delta": 12.5}}
+
+
+class TestLoading:
+    """Test cases for loading policies and reading query results"""
+
+    def test_load_directory(self, tmp_path):
+        """Test .rego files are loaded recursively without tests"""
+        (tmp_path / "limits.rego").write_text(POLICY)
+        (tmp_path / "limits_test.rego").write_text("package kcloud.cost_test")
+        (tmp_path / "teams").mkdir()
+        (tmp_path / "teams" / "dev.rego").write_text("package kcloud.cost")
+        (tmp_path / "README.md").write_text("policies")
+
+        assert list(load_policies(str(tmp_path))) == ["limits.rego", "teams/dev.rego"]
+        assert list(load_policies(str(tmp_path / "limits.rego"))) == ["limits.rego"]
+
+        with pytest.raises(PolicyError, match="No .rego"):
+            load_policies(str(tmp_path / "teams" / "missing"))
+
+    def test_query_value(self):
+        """Test values are read from opa eval and rego-cpp results"""
+        assert query_value({"result": [{"expressions": [{"value": {"deny": []}, "text": "data.kcloud.cost"}]}]}) == {
+            "deny": []
+        }
+        assert query_value({}) is None
+        assert query_value({"result": []}) is None
+        assert query_value({"expressions": [{"value": 1}]}) == 1
+        assert query_value({"bindings": {"x": 2}}) == 2
+
+    def test_opa_not_installed(self):
+        """Test a missing opa binary is reported as unavailable"""
+        with pytest.raises(PolicyUnavailableError, match="opa binary not found"):
+            OpaEvaluator("/nonexistent/opa").evaluate({"a.rego": POLICY}, {}, "data.kcloud.cost")
+
+
+class TestBuildPolicyEngine:
+    """Test cases for build_policy_engine"""
+
+    def _settings(self, path, engine, opa_binary="opa"):
+        return SimpleNamespace(
+            policy_path=str(path), policy_engine=engine, policy_package="kcloud.cost",
+            policy_opa_binary=opa_binary, policy_timeout_seconds=5.0,
+        )
+
+    def test_unavailable_evaluator_fails_fast(self, tmp_path, monkeypatch):
+        """Test a missing opa binary or regopy fails the build instead of denying estimates later"""
+        (tmp_path / "cost.rego").write_text(POLICY)
+        with pytest.raises(PolicyUnavailableError, match="opa binary not found"):
+            build_policy_engine(self._settings(tmp_path, "opa", opa_binary="/nonexistent/opa"))
+
+        monkeypatch.setitem(sys.modules, "regopy", None)
+        with pytest.raises(PolicyUnavailableError, match="needs regopy"):
+            build_policy_engine(self._settings(tmp_path, "embedded"))
+
+    def test_without_path(self):
+        """Test no engine is built without POLICY_PATH"""
+        assert build_policy_engine(self._settings("", "embedded")) is None
+
+
+class TestRego:
+    """Test cases for Rego policies evaluated by OPA"""
+
+    def test_opa(self):
+        """Test a resource limit and spot pricing for dev environments with the opa CLI"""
+        if shutil.which("opa") is None:
+            pytest.skip("opa is not installed")
+        self._check(OpaEvaluator())
+
+    def test_embedded(self):
+        """Test the same policies with the embedded interpreter"""
+        pytest.importorskip("regopy")
+        self._check(EmbeddedEvaluator())
+
+    def _check(self, evaluator):
+        engine = PolicyEngine({"cost.rego": POLICY}, evaluator)
+
+        verdict = engine.evaluate(estimate_input("resources", _estimate(("web", 70.08, "spot"))))
+        assert verdict.allowed
+        assert verdict.warnings == []
+
+        estimate = _estimate(("db", 620.5, "spot"), ("web", 70.08, "on_demand"))
+        verdict = engine.evaluate(estimate_input("resources", estimate, labels={"environment": "dev"}))
+        assert not verdict.allowed
+        assert verdict.denials == ["db costs more than $500/month", "web must use spot pricing in dev"]
+        assert verdict.warnings == ["Estimate is over $100/month"]
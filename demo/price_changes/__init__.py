@@ -0,0 +1 @@
+"""Tests for price changes module"""
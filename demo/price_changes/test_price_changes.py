@@ -0,0 +1,137 @@
+"""Unit tests for catalog price change tracking"""
+
+from datetime import datetime, timezone
+
+import pytest
+
+from src.notifications import EVENT_PRICE_CHANGED, NotificationDispatcher, PriceChangeAlerts
+from src.price_changes import PriceChangeTracker, catalog_prices
+from src.providers.aws.parser import entry_key
+from src.store import KIND_RESOURCES, SQLiteStore, record_estimate
+from src.tenancy import OVERRIDE_SOURCE
+
+KEY = "AmazonEC2-us-east-1"
+NOW = datetime(2026, 6, 1, 12, tzinfo=timezone.utc)
+
+
+def _catalog(**prices):
+    return {"entries": {
+        entry_key("compute", "us-east-1", sku.replace("_", ".")): {"price": price, "unit": "hour"}
+        for sku, price in prices.items()
+    }}
+
+
+def _line_item(instance_type, price, monthly_cost, **fields):
+    return dict({
+        "provider": "aws", "region": "us-east-1", "instance_type": instance_type,
+        "unit_price_hourly": price, "monthly_cost": monthly_cost,
+    }, **fields)
+
+
+@pytest.fixture
+def store():
+    """Store whose EC2 catalog changed m5.large, dropped t3.micro and added c5.large since May 1"""
+    store = SQLiteStore(":memory:")
+    store.migrate()
+    store.save_catalog("aws", KEY, _catalog(m5_large=0.096, t3_micro=0.0104, r5_large=0.126),
+                       datetime(2026, 5, 1, tzinfo=timezone.utc))
+    store.save_catalog("aws", KEY, _catalog(m5_large=0.1056, r5_large=0.126, c5_large=0.085), NOW)
+    return store
+
+
+def _tracker(store, now=NOW):
+    return PriceChangeTracker(store, clock=lambda: now)
+
+
+class TestCatalogPrices:
+    """Test cases for reading prices out of stored catalog documents"""
+
+    def test_entries_quotes_and_rate_cards(self):
+        """Test entry keys are split, quotes take the region of their catalog and rate cards have no prices"""
+        entries = {"entries": {
+            "compute|eastus|Standard_D2s_v5": {"price": 0.096},
+            "compute|eastus|Standard_D2s_v5|reserved/1yr": {"price": 0.06},
+            "compute|eastus|Standard_B1s": {"price": None},
+        }}
+        assert catalog_prices("vm-eastus", entries) == {
+            ("compute", "eastus", "Standard_D2s_v5", ""): {"price": 0.096},
+            ("compute", "eastus", "Standard_D2s_v5", "reserved/1yr"): {"price": 0.06},
+        }
+
+        quotes = {"quotes": {"compute|ecs.g7.large": {"price": 0.12, "unit": "hour"}}}
+        assert list(catalog_prices("quotes-cn-hangzhou", quotes)) == [("compute", "cn-hangzhou", "ecs.g7.large", "")]
+        assert catalog_prices("rates", {"rates": {"flavors": {}}}) == {}
+
+
+class TestPriceChangeTracker:
+    """Test cases for price changes and the estimates they affect"""
+
+    def test_changes_since_a_day(self, store):
+        """Test prices are compared with the snapshot of the window's first day"""
+        report = _tracker(store).report("acme", days=30)
+
+        assert (report.since.isoformat(), report.until.isoformat()) == ("2026-05-02", "2026-06-01")
+        assert (report.changed, report.added, report.removed) == (1, 1, 1)
+        change = report.changes[0]
+        assert (change.catalog, change.sku, change.old_price, change.new_price) == (KEY, "m5.large", 0.096, 0.1056)
+        assert change.change == pytest.approx(0.0096)
+        assert change.change_percent == 10.0
+        assert report.affected_estimates == []
+
+    def test_window_before_first_snapshot(self, store):
+        """Test catalogs first saved within the window count as added"""
+        report = _tracker(store, datetime(2026, 5, 20, tzinfo=timezone.utc)).report("acme", days=30)
+
+        assert (report.changed, report.added, report.removed) == (0, 3, 0)
+
+    def test_affected_estimates(self, store):
+        """Test estimates priced at an old price are affected, spot and price list items are not"""
+        result = {"line_items": [
+            _line_item("m5.large", 0.096, 140.16, count=2),
+            _line_item("m5.large", 0.0384, 56.06, pricing_model="spot"),
+            _line_item("m5.large", 0.08, 58.4, price_source=OVERRIDE_SOURCE),
+            _line_item("r5.large", 0.126, 91.98),
+        ]}
+        affected = record_estimate(store, KIND_RESOURCES, {}, result, 346.6, project="shop", tenant_id="acme")
+        record_estimate(store, KIND_RESOURCES, {}, {"line_items": [_line_item("m5.large", 0.096, 70.08)]}, 70.08,
+                        tenant_id="globex")
+        # Priced after the change
+        record_estimate(store, KIND_RESOURCES, {}, {"line_items": [_line_item("m5.large", 0.1056, 77.09)]}, 77.09,
+                        tenant_id="acme")
+
+        report = _tracker(store).report("acme", provider="aws")
+
+        assert [e.estimate_id for e in report.affected_estimates] == [affected.id]
+        estimate = report.affected_estimates[0]
+        assert estimate.project == "shop"
+        assert [(i.sku, i.monthly_cost) for i in estimate.items] == [("m5.large", 140.16)]
+        assert estimate.monthly_delta == pytest.approx(14.016)
+        assert report.monthly_delta == pytest.approx(14.016)
+
+    def test_nested_items_inherit_provider_and_region(self, store):
+        """Test components of database and cluster items are matched in the region of their item"""
+        result = {"database_items": [{
+            "provider": "aws", "region": "us-east-1", "instance_class": "m5.large",
+            "components": [{"component": "instance", "sku": "m5.large", "unit_price": 0.096, "monthly_cost": 70.08}],
+        }]}
+        record_estimate(store, KIND_RESOURCES, {}, result, 70.08, tenant_id="acme")
+
+        items = _tracker(store).report("acme").affected_estimates[0].items
+        assert [(i.region, i.monthly_delta) for i in items] == [("us-east-1", pytest.approx(7.008))]
+
+
+class TestPriceChangeAlerts:
+    """Test cases for price change webhook events"""
+
+    def test_affected_estimates_notified_once(self, store):
+        """Test a tenant is notified of an estimate moved by a price change once"""
+        record_estimate(store, KIND_RESOURCES, {}, {"line_items": [_line_item("m5.large", 0.096, 70.08)]}, 70.08,
+                        tenant_id="acme")
+        alerts = PriceChangeAlerts(_tracker(store), NotificationDispatcher(store))
+
+        events = alerts.check("acme")
+        assert [e.type for e in events] == [EVENT_PRICE_CHANGED]
+        assert "+$7.01/month" in events[0].summary
+        assert [c["sku"] for c in events[0].data["changes"]] == ["m5.large"]
+        assert alerts.check("acme") == []
+        assert alerts.check("globex") == []
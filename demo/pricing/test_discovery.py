@@ -0,0 +1,93 @@
+"""Unit tests for SKU discovery"""
+
+import pytest
+
+from src.pricing import (
+    PriceCatalog,
+    ProviderNotFoundError,
+    ProviderRegistry,
+    StaticProvider,
+    cloud_capabilities,
+    list_instance_types,
+    list_regions,
+    paginate,
+)
+from src.providers.openstack import Flavor, OpenStackPricingProvider, PrivateCloudRates
+
+
+@pytest.fixture
+def registry():
+    """Registry with a small AWS and Azure catalog and a rate card pricing every region"""
+    registry = ProviderRegistry()
+    registry.register(StaticProvider(PriceCatalog({
+        "aws": {
+            "us-east-1": {"m5.2xlarge": 0.384, "m5.large": 0.096, "m5.xlarge": 0.192, "g5.xlarge": 1.006},
+            "ap-northeast-2": {"m5.large": 0.118, "m6g.large": 0.094},
+        },
+        "azure": {"koreacentral": {"Standard_D2s_v5": 0.115}},
+    }, storage_prices={})))
+    registry.register(OpenStackPricingProvider(PrivateCloudRates(
+        vcpu_hourly=0.02,
+        memory_gb_hourly=0.005,
+        storage_gb_monthly=0.073,
+        flavors={"m1.medium": Flavor(vcpus=2, ram_mb=4096), "m1.custom": Flavor(vcpus=4, ram_mb=8192)},
+    )))
+    return registry
+
+
+class TestCloudCapabilities:
+    """Test cases for the listing of clouds"""
+
+    def test_clouds(self, registry):
+        """Test each cloud lists its providers and what they price"""
+        clouds = {cloud.provider: cloud for cloud in cloud_capabilities(registry)}
+
+        assert clouds["aws"].pricing_providers == ["static"]
+        assert clouds["aws"].listed
+        assert (clouds["aws"].regions, clouds["aws"].instance_types) == (2, 5)
+        assert "lambda" in clouds["aws"].serverless_platforms
+        assert (clouds["openstack"].regions, clouds["openstack"].instance_types) == (1, 2)
+
+
+class TestListings:
+    """Test cases for region and instance type listings"""
+
+    def test_regions(self, registry):
+        """Test regions are listed in name order with their instance type count"""
+        regions = list_regions(registry, "aws")
+
+        assert [(r.region, r.instance_types) for r in regions] == [("ap-northeast-2", 2), ("us-east-1", 4)]
+        assert [r.region for r in list_regions(registry, "aws", search="AP-")] == ["ap-northeast-2"]
+        with pytest.raises(ProviderNotFoundError):
+            list_regions(registry, "oracle")
+
+    def test_instance_types(self, registry):
+        """Test instance types are listed by family and size with their regions"""
+        instance_types = list_instance_types(registry, "aws")
+
+        assert [t.instance_type for t in instance_types] == [
+            "g5.xlarge", "m5.large", "m5.xlarge", "m5.2xlarge", "m6g.large",
+        ]
+        assert instance_types[1].regions == ["ap-northeast-2", "us-east-1"]
+        assert (instance_types[1].vcpus, instance_types[1].memory_gb) == (2, 8)
+
+    def test_filters(self, registry):
+        """Test region, search and shape filters"""
+        def names(**filters):
+            return [t.instance_type for t in list_instance_types(registry, "aws", **filters)]
+
+        assert names(region="ap-northeast-2") == ["m5.large", "m6g.large"]
+        assert names(search="M5.") == ["m5.large", "m5.xlarge", "m5.2xlarge"]
+        assert names(min_vcpus=4, max_memory_gb=16) == ["g5.xlarge", "m5.xlarge"]
+        assert names(arch="arm64") == ["m6g.large"]
+        assert names(gpu=True) == ["g5.xlarge"]
+        assert [t.instance_type for t in list_instance_types(registry, "openstack", region="RegionTwo")] == [
+            "m1.medium", "m1.custom",
+        ]
+
+    def test_paginate(self):
+        """Test pages end with no next offset"""
+        assert paginate(list(range(5)), 2) == ([0, 1], 2)
+        assert paginate(list(range(5)), 2, 4) == ([4], None)
+        assert paginate(list(range(5)), 5) == (list(range(5)), None)
+        assert paginate([], 10, 20) == ([], None)
@@ -0,0 +1,84 @@
+"""Unit tests for pricing formulas"""
+
+import pytest
+
+from src.pricing import (
+    Price,
+    PriceTier,
+    TIER_MODE_VOLUME,
+    effective_unit_price,
+    normalize_tier_mode,
+    parse_tiers,
+    usage_cost,
+    validate_tiers,
+)
+
+EGRESS_TIERS = [
+    PriceTier(begin=0, end=10240, price=0.09),
+    PriceTier(begin=10240, end=51200, price=0.085),
+    PriceTier(begin=51200, price=0.07),
+]
+
+
+def _price(**formula):
+    return Price(provider="aws", region="us-east-1", sku="egress", unit="GB", price=0.09, source="test", **formula)
+
+
+class TestPricingFormula:
+    """Test cases for usage priced under a formula"""
+
+    def test_flat(self):
+        """Test prices without a formula bill every unit"""
+        assert usage_cost(_price(), 100) == pytest.approx(9.0)
+
+    def test_graduated_tiers(self):
+        """Test each band is billed at its own price"""
+        price = _price(tiers=EGRESS_TIERS)
+
+        assert usage_cost(price, 10240) == pytest.approx(10240 * 0.09)
+        assert usage_cost(price, 60000) == pytest.approx(10240 * 0.09 + 40960 * 0.085 + 8800 * 0.07)
+
+    def test_volume_tiers(self):
+        """Test every unit is billed at the price of the band the quantity ends in"""
+        price = _price(tiers=EGRESS_TIERS, tier_mode=TIER_MODE_VOLUME)
+
+        assert usage_cost(price, 10240) == pytest.approx(10240 * 0.09)
+        assert usage_cost(price, 20000) == pytest.approx(20000 * 0.085)
+        assert usage_cost(price, 60000) == pytest.approx(60000 * 0.07)
+
+    def test_free_allowance(self):
+        """Test the allowance is free and tiers count the quantity beyond it"""
+        price = _price(tiers=EGRESS_TIERS, free_quantity=100)
+
+        assert usage_cost(price, 100) == 0.0
+        assert usage_cost(price, 10340) == pytest.approx(10240 * 0.09)
+        assert effective_unit_price(price, 200) == pytest.approx(0.045)
+
+    def test_minimums(self):
+        """Test billed usage is raised to the minimum quantity and charge, usage within the allowance is not"""
+        assert usage_cost(_price(minimum_quantity=10), 2) == pytest.approx(0.9)
+        assert usage_cost(_price(minimum_quantity=10), 20) == pytest.approx(1.8)
+        assert usage_cost(_price(minimum_charge=5.0), 10) == 5.0
+        assert usage_cost(_price(minimum_charge=5.0, free_quantity=50), 50) == 0.0
+        assert usage_cost(_price(minimum_charge=5.0), 0) == 0.0
+
+    def test_parse_tiers(self):
+        """Test end:price bands and lists of tiers"""
+        assert parse_tiers("10240:0.09; 51200:0.085; :0.07") == EGRESS_TIERS
+        assert parse_tiers([{"up_to": 10240, "price": 0.09}, {"up_to": 51200, "price": 0.085},
+                            {"price": 0.07}]) == EGRESS_TIERS
+        assert parse_tiers("") == []
+        with pytest.raises(ValueError, match="end:price"):
+            parse_tiers("10240=0.09")
+        with pytest.raises(ValueError, match="numbers"):
+            parse_tiers("ten:0.09")
+
+    def test_validate_tiers(self):
+        """Test tiers must start at 0 without gaps, only the last open-ended"""
+        assert validate_tiers(EGRESS_TIERS) == EGRESS_TIERS
+        with pytest.raises(ValueError, match="begins at 10, expected 0"):
+            validate_tiers([PriceTier(begin=10, price=0.1)])
+        with pytest.raises(ValueError, match="last tier"):
+            validate_tiers(parse_tiers(":0.09;100:0.08"))
+        with pytest.raises(ValueError, match="tier_mode"):
+            normalize_tier_mode("stepped")
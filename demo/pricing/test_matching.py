@@ -0,0 +1,113 @@
+"""Unit tests for fuzzy SKU matching"""
+
+import pytest
+
+from src.estimator import BatchEstimateRequest, BatchEstimator, CostEstimator, EstimateRequest
+from src.pricing import (
+    PriceCatalog,
+    PriceNotFoundError,
+    PriceQuery,
+    ProviderRegistry,
+    StaticProvider,
+    closest_skus,
+    did_you_mean,
+    fuzzy_matching,
+    resolve_sku,
+    similarity,
+)
+
+CANDIDATES = ["m5.large", "m5.xlarge", "m5.4xlarge", "m4.large", "c5.large", "Standard_D2s_v5"]
+
+
+@pytest.fixture
+def registry():
+    """Registry pricing a few AWS instance types in one region"""
+    registry = ProviderRegistry()
+    registry.register(StaticProvider(PriceCatalog({
+        "aws": {"us-east-1": {"m5.large": 0.096, "m5.xlarge": 0.192, "c5.large": 0.085}},
+    }, storage_prices={})))
+    return registry
+
+
+class TestSimilarity:
+    """Test cases for the confidence of matches"""
+
+    def test_similarity(self):
+        """Test case and separators barely count, typos count more and other families or sizes most"""
+        assert similarity("m5.large", "m5.large") == 1.0
+        assert similarity("M5-Large", "m5.large") == 0.98
+        assert similarity("D2s_v5", "Standard_D2s_v5") == 0.9
+        assert 0.8 < similarity("m5.lrage", "m5.large") < 0.9
+        assert similarity("m5.lrage", "m5.large") > similarity("m5.lrage", "m5.xlarge")
+
+    def test_known_parts_are_meant(self):
+        """Test a family or size another candidate has is not taken as a typo"""
+        matches = closest_skus("m3.large", CANDIDATES)
+
+        assert [m.sku for m in matches][:2] == ["m4.large", "m5.large"]
+        assert matches[0].confidence < 0.8
+        assert closest_skus("m5.4xlarge", ["m5.xlarge", "m5.4xlarge"])[0].sku == "m5.4xlarge"
+        assert similarity("m5.xlarge", "m5.4xlarge", sizes={"xlarge", "4xlarge"}) < 0.8
+
+    def test_resolve(self):
+        """Test only a confident match clearly ahead of the next is resolved"""
+        assert resolve_sku("m5.lrage", CANDIDATES, 0.8).sku == "m5.large"
+        assert resolve_sku("m3.large", CANDIDATES, 0.8) is None
+        assert resolve_sku("m5.2xlarge", ["m5.xlarge", "m5.4xlarge"], 0.8) is None
+        assert resolve_sku("t3.micro", CANDIDATES, 0.8) is None
+
+    def test_did_you_mean(self):
+        """Test suggestions read as a question"""
+        assert did_you_mean(closest_skus("m5.lrage", ["m5.large"])) == "did you mean m5.large?"
+        assert did_you_mean(closest_skus("m5.lrage", ["m5.large", "m5.xlarge"])) == (
+            "did you mean m5.large or m5.xlarge?"
+        )
+        assert did_you_mean([]) == ""
+
+
+class TestFuzzyLookups:
+    """Test cases for fuzzy matching of price lookups"""
+
+    def test_off_by_default(self, registry):
+        """Test unknown instance types are not found unless fuzzy matching is on"""
+        with pytest.raises(PriceNotFoundError):
+            registry.get_price(PriceQuery(provider="aws", region="us-east-1", sku="m5.lrage"))
+
+        with fuzzy_matching(0.8):
+            price = registry.get_price(PriceQuery(provider="aws", region="us-east-1", sku="m5.lrage"))
+            with pytest.raises(PriceNotFoundError):
+                registry.get_price(PriceQuery(provider="aws", region="us-east-1", sku="m3.large"))
+
+        assert (price.sku, price.price, price.requested_sku) == ("m5.large", 0.096, "m5.lrage")
+        assert price.match_confidence == similarity("m5.lrage", "m5.large")
+
+    def test_line_items(self, registry):
+        """Test line items of fuzzy matches name the requested instance type and the confidence"""
+        estimator = CostEstimator(registry)
+        request = EstimateRequest(resources=[
+            {"instance_type": "M5.XLarge", "region": "us-east-1"},
+            {"instance_type": "c5.large", "region": "us-east-1"},
+        ])
+
+        with fuzzy_matching():
+            matched, exact = estimator.estimate(request).line_items
+
+        assert (matched.instance_type, matched.requested_instance_type, matched.match_confidence) == (
+            "m5.xlarge", "M5.XLarge", 0.98,
+        )
+        assert matched.unit_price_hourly == 0.192
+        assert (exact.requested_instance_type, exact.match_confidence) == (None, None)
+
+    def test_batch_suggestions(self, registry):
+        """Test batch items that are not found list the closest instance types"""
+        batch = BatchEstimator(CostEstimator(registry), workers=2)
+        try:
+            result = batch.estimate(BatchEstimateRequest(items=[
+                {"instance_type": "m5.lrage", "region": "us-east-1"},
+                {"provider": "oracle", "instance_type": "VM.Standard3", "region": "us-ashburn-1"},
+            ]))
+        finally:
+            batch.close()
+
+        assert result.items[0].suggestions == ["m5.large", "m5.xlarge", "c5.large"]
+        assert result.items[1].suggestions == []
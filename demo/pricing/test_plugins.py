@@ -0,0 +1,131 @@
+"""Unit tests for out-of-tree pricing plugins"""
+
+import os
+import sys
+import textwrap
+from types import SimpleNamespace
+
+import pytest
+
+from src.pricing import (
+    ExecPricingProvider,
+    PluginError,
+    PriceNotFoundError,
+    PriceQuery,
+    ProviderRegistry,
+    StaticProvider,
+    available_providers,
+    build_registry,
+    load_plugins,
+    plugin_executables,
+)
+
+EXAMPLE_PLUGIN = os.path.join(os.path.dirname(__file__), "..", "..", "config", "pricing-plugin.example.py")
+
+
+def _script(tmp_path, name, body):
+    path = tmp_path / name
+    path.write_text(f"#!{sys.executable}\n" + textwrap.dedent(body))
+    path.chmod(0o755)
+    return str(path)
+
+
+@pytest.fixture
+def acme():
+    provider = ExecPricingProvider("acme-rates", [sys.executable, EXAMPLE_PLUGIN], timeout=5)
+    yield provider
+    provider.close()
+
+
+class TestExecPricingProvider:
+    """Test cases for ExecPricingProvider class"""
+
+    def test_prices_from_plugin(self, acme):
+        """Test prices, instance types and missing prices are answered by the plugin process"""
+        assert acme.clouds == ["acme"]
+        price = acme.get_price(PriceQuery(provider="acme", region="dc1", sku="gold"))
+        assert (price.price, price.unit, price.sku, price.region) == (0.21, "hour", "gold", "dc1")
+        assert price.source == "acme-rates"
+
+        with pytest.raises(PriceNotFoundError, match="No acme price for gold in dc3"):
+            acme.get_price(PriceQuery(provider="acme", region="dc3", sku="gold"))
+        assert acme.instance_types("acme") == {"dc1": {"gold", "silver"}, "dc2": {"gold"}}
+        acme.refresh()
+
+    def test_registry_falls_through(self, acme):
+        """Test lookups the plugin cannot answer go to the next provider of the cloud"""
+        registry = ProviderRegistry()
+        registry.register(acme)
+        registry.register(StaticProvider())
+        assert registry.get_price(PriceQuery(provider="acme", region="dc2", sku="gold")).price == 0.24
+        with pytest.raises(PriceNotFoundError):
+            registry.get_price(PriceQuery(provider="acme", region="dc2", sku="silver"))
+
+    def test_protocol_mismatch(self, tmp_path):
+        """Test plugins of another protocol version are refused at start"""
+        path = _script(tmp_path, "old", """
+            import json, sys
+            for line in sys.stdin:
+                request = json.loads(line)
+                print(json.dumps({"id": request["id"], "result": {"protocol": 0, "clouds": ["acme"]}}), flush=True)
+        """)
+        with pytest.raises(PluginError, match="protocol 0"):
+            ExecPricingProvider("old", [path])
+
+    def test_crash_and_timeout_restart(self, tmp_path):
+        """Test a plugin that exits or hangs leaves prices not found and is restarted"""
+        path = _script(tmp_path, "flaky", """
+            import json, sys, time
+            for line in sys.stdin:
+                request = json.loads(line)
+                if request["method"] == "describe":
+                    result = {"protocol": 1, "clouds": ["acme"]}
+                elif request["params"]["sku"] == "crash":
+                    sys.exit(3)
+                elif request["params"]["sku"] == "hang":
+                    time.sleep(5)
+                else:
+                    result = {"price": 1.5}
+                print(json.dumps({"id": request["id"], "result": result}), flush=True)
+        """)
+        provider = ExecPricingProvider("flaky", [path], timeout=0.5)
+        try:
+            with pytest.raises(PriceNotFoundError, match="exited with status 3"):
+                provider.get_price(PriceQuery(provider="acme", region="dc1", sku="crash"))
+            assert provider.get_price(PriceQuery(provider="acme", region="dc1", sku="gold")).price == 1.5
+
+            with pytest.raises(PriceNotFoundError, match="did not answer within 0.5s"):
+                provider.get_price(PriceQuery(provider="acme", region="dc1", sku="hang"))
+            assert provider.get_price(PriceQuery(provider="acme", region="dc1", sku="gold")).price == 1.5
+        finally:
+            provider.close()
+
+
+class TestLoadPlugins:
+    """Test cases for plugin discovery"""
+
+    def test_executables_registered(self, tmp_path):
+        """Test executables of the plugin directory become providers named after their files"""
+        plugins = tmp_path / "plugins"
+        plugins.mkdir()
+        with open(EXAMPLE_PLUGIN) as f:
+            (plugins / "acme-rates.py").write_text(f"#!{sys.executable}\n" + f.read())
+        (plugins / "acme-rates.py").chmod(0o755)
+        (plugins / "README").write_text("not a plugin")
+        (plugins / "static").write_text("")
+        (plugins / "static").chmod(0o755)
+
+        assert list(plugin_executables(str(plugins))) == ["acme-rates", "static"]
+        settings = SimpleNamespace(pricing_plugin_dir=str(plugins), pricing_plugin_modules=[], pricing_plugin_timeout=5)
+        # The built-in static provider is not shadowed
+        assert load_plugins(settings) == ["acme-rates"]
+        assert load_plugins(settings) == ["acme-rates"]
+        assert "acme-rates" in available_providers()
+
+        registry = build_registry(["acme-rates", "static"], settings)
+        provider = registry.get("acme-rates")
+        try:
+            assert registry.get_price(PriceQuery(provider="acme", region="dc1", sku="silver")).price == 0.12
+            assert isinstance(registry.get("static"), StaticProvider)
+        finally:
+            provider.close()
@@ -0,0 +1,201 @@
+"""Unit tests for pricing provider registry"""
+
+import json
+import pytest
+
+from src.pricing import (
+    Price,
+    PriceCatalog,
+    PriceNotFoundError,
+    PriceQuery,
+    PricingProvider,
+    ProviderNotFoundError,
+    ProviderRegistry,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_DATA_TRANSFER,
+    SERVICE_OBJECT_REQUESTS,
+    SERVICE_OBJECT_STORAGE,
+    StaticProvider,
+    available_providers,
+    build_registry,
+    register_factory,
+)
+
+
+class FixedProvider(PricingProvider):
+    """Provider returning one fixed price for a single SKU"""
+
+    def __init__(self, name, cloud, sku, price, currency="USD"):
+        self.name = name
+        self.currency = currency
+        self._cloud = cloud
+        self.sku = sku
+        self.price = price
+        self.refreshed = False
+
+    @property
+    def clouds(self):
+        return [self._cloud]
+
+    def get_price(self, query):
+        if query.sku != self.sku:
+            raise PriceNotFoundError(query.sku)
+        return Price(
+            provider=query.provider, region=query.region, sku=query.sku,
+            price=self.price, currency=self.currency, source=self.name,
+        )
+
+    def refresh(self):
+        self.refreshed = True
+
+
+class TestProviderRegistry:
+    """Test cases for ProviderRegistry class"""
+
+    @pytest.fixture
+    def query(self):
+        """Create a compute price query"""
+        return PriceQuery(provider="aws", region="us-east-1", sku="m5.large")
+
+    def test_routes_by_cloud(self, query):
+        """Test lookups go to the provider serving the cloud"""
+        registry = ProviderRegistry()
+        registry.register(FixedProvider("aws-fixed", "aws", "m5.large", 0.2))
+        registry.register(FixedProvider("gcp-fixed", "gcp", "m5.large", 0.9))
+
+        price = registry.get_price(query)
+
+        assert price.price == 0.2
+        assert price.source == "aws-fixed"
+        assert registry.clouds() == ["aws", "gcp"]
+
+    def test_falls_through_provider_chain(self, query):
+        """Test later providers answer when earlier ones have no price"""
+        registry = ProviderRegistry()
+        registry.register(FixedProvider("custom", "aws", "c5.large", 0.05))
+        registry.register(StaticProvider())
+
+        price = registry.get_price(query)
+
+        assert price.source == "static"
+
+    def test_first_provider_wins(self, query):
+        """Test registration order sets precedence"""
+        registry = ProviderRegistry()
+        registry.register(FixedProvider("custom", "aws", "m5.large", 0.05))
+        registry.register(StaticProvider())
+
+        assert registry.get_price(query).price == 0.05
+
+    def test_unknown_cloud(self):
+        """Test error when no provider serves a cloud"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+
+        with pytest.raises(ProviderNotFoundError, match="openstack"):
+            registry.get_price(PriceQuery(provider="openstack", region="r1", sku="m1.small"))
+
+    def test_price_not_found(self, query):
+        """Test error when no provider knows the SKU"""
+        registry = ProviderRegistry()
+        registry.register(FixedProvider("custom", "aws", "c5.large", 0.05))
+
+        with pytest.raises(PriceNotFoundError):
+            registry.get_price(query)
+
+    def test_prices_in_other_currencies(self, query):
+        """Test prices listed in another currency are converted to USD or passed over without a rate"""
+        registry = ProviderRegistry()
+        registry.register(FixedProvider("krw", "aws", "m5.large", 138.0, currency="KRW"))
+        registry.register(FixedProvider("usd", "aws", "m5.large", 0.2))
+
+        assert registry.get_price(query).source == "usd"
+
+        registry.set_exchange_rates(lambda currency: 1380.0)
+        price = registry.get_price(query)
+        assert (price.source, price.currency) == ("krw", "USD")
+        assert price.price == pytest.approx(0.1)
+
+    def test_refresh_all(self):
+        """Test refresh reaches every provider"""
+        provider = FixedProvider("custom", "aws", "c5.large", 0.05)
+        registry = ProviderRegistry()
+        registry.register(provider)
+
+        registry.refresh()
+
+        assert provider.refreshed
+
+    def test_build_registry_from_names(self):
+        """Test building a registry from configured names"""
+        register_factory("fixed-test", lambda settings: FixedProvider("fixed-test", "aws", "x", 1.0))
+
+        registry = build_registry(["fixed-test", "static"])
+
+        assert "static" in available_providers()
+        assert [p.name for p in registry.providers()] == ["fixed-test", "static"]
+
+    def test_build_registry_unknown_name(self):
+        """Test error for providers not compiled in"""
+        with pytest.raises(ProviderNotFoundError, match="nope"):
+            build_registry(["nope"])
+
+
+class TestStaticProvider:
+    """Test cases for StaticProvider class"""
+
+    def test_compute_price(self):
+        """Test hourly compute price from the rate card"""
+        provider = StaticProvider(PriceCatalog({"aws": {"us-east-1": {"m5.large": 0.1}}}, storage_prices={}))
+
+        price = provider.get_price(PriceQuery(provider="aws", region="us-east-1", sku="m5.large"))
+
+        assert price.price == 0.1
+        assert price.unit == "hour"
+        assert provider.clouds == ["aws"]
+
+    def test_block_storage_price(self):
+        """Test block storage prices keep their billing unit"""
+        provider = StaticProvider()
+
+        gp3 = provider.get_price(PriceQuery(
+            provider="aws", region="us-east-1", sku="gp3", service=SERVICE_BLOCK_STORAGE))
+        p10 = provider.get_price(PriceQuery(
+            provider="azure", region="eastus", sku="P10 LRS", service=SERVICE_BLOCK_STORAGE))
+
+        assert gp3.unit == "GB-month"
+        assert p10.unit == "month"
+
+    def test_unsupported_service(self):
+        """Test static catalog has no data transfer prices"""
+        provider = StaticProvider()
+
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(
+                provider="aws", region="us-east-1", sku="internet_egress", service=SERVICE_DATA_TRANSFER))
+
+    def test_object_storage_prices(self):
+        """Test object storage keeps its volume tiers and requests are priced per 1000"""
+        provider = StaticProvider()
+
+        standard = provider.get_price(PriceQuery(
+            provider="aws", region="us-east-1", sku="Standard", service=SERVICE_OBJECT_STORAGE))
+        writes = provider.get_price(PriceQuery(
+            provider="gcp", region="us-central1", sku="NEARLINE", service=SERVICE_OBJECT_REQUESTS,
+            attributes={"operation": "write"}))
+
+        assert standard.price == 0.023
+        assert [(t.begin, t.end) for t in standard.tiers] == [(0, 51200), (51200, 512000), (512000, None)]
+        assert writes.unit == "1K requests"
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(
+                provider="gcp", region="us-central1", sku="NEARLINE", service=SERVICE_OBJECT_REQUESTS))
+
+    def test_catalog_from_file(self, tmp_path):
+        """Test loading a catalog from JSON"""
+        path = tmp_path / "catalog.json"
+        path.write_text(json.dumps({"azure": {"eastus": {"Standard_B2s": 0.05}}}))
+
+        catalog = PriceCatalog.from_file(str(path))
+
+        assert catalog.get_hourly_price("azure", "eastus", "Standard_B2s") == 0.05
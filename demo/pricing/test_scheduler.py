@@ -0,0 +1,142 @@
+"""Unit tests for price catalog refresh scheduling"""
+
+import random
+from datetime import datetime, timedelta, timezone
+
+import pytest
+
+from src.cache import MemoryLocks
+from src.pricing import (
+    CATALOG_OK,
+    CATALOG_PENDING,
+    PricingProvider,
+    ProviderRegistry,
+    RefreshScheduler,
+    add_refresh_failure_listener,
+    remove_refresh_failure_listener,
+)
+
+START = datetime(2025, 6, 1, tzinfo=timezone.utc)
+
+
+class FlakyProvider(PricingProvider):
+    """Provider whose refresh fails a number of times before succeeding"""
+
+    def __init__(self, name, failures=0):
+        self.name = name
+        self.failures = failures
+        self.refreshes = 0
+
+    @property
+    def clouds(self):
+        return [self.name]
+
+    def get_price(self, query):
+        raise NotImplementedError
+
+    def refresh(self):
+        self.refreshes += 1
+        if self.failures:
+            self.failures -= 1
+            raise RuntimeError("catalog download failed")
+
+
+class Clock:
+    def __init__(self):
+        self.now = START
+
+    def __call__(self):
+        return self.now
+
+    def advance(self, seconds):
+        self.now += timedelta(seconds=seconds)
+
+
+def scheduler(*providers, **kwargs):
+    registry = ProviderRegistry()
+    for provider in providers:
+        registry.register(provider)
+    clock = Clock()
+    kwargs.setdefault("jitter", 0)
+    return RefreshScheduler(registry, clock=clock, **kwargs), clock
+
+
+class TestRefreshScheduler:
+    """Test cases for RefreshScheduler class"""
+
+    def test_interval_after_success(self):
+        aws = FlakyProvider("aws")
+        schedule, clock = scheduler(aws, interval=3600)
+
+        assert [s.state for s in schedule.status()] == [CATALOG_PENDING]
+        assert schedule.run_due() == ["aws"]
+        status = schedule.status()[0]
+        assert (status.state, status.last_success, status.next_refresh) == (
+            CATALOG_OK, START, START + timedelta(hours=1))
+        assert schedule.seconds_until_due() == 3600
+
+        clock.advance(3599)
+        assert schedule.run_due() == []
+        clock.advance(1)
+        assert schedule.run_due() == ["aws"] and aws.refreshes == 2
+
+    def test_backoff_on_failure(self):
+        failures = []
+        listener = lambda provider, catalog, error: failures.append((provider, error))
+        add_refresh_failure_listener(listener)
+        try:
+            gcp = FlakyProvider("gcp", failures=3)
+            schedule, clock = scheduler(gcp, interval=86400, backoff=60, max_backoff=100)
+
+            delays = []
+            for _ in range(4):
+                schedule.run_due()
+                delays.append(schedule.seconds_until_due())
+                clock.advance(delays[-1])
+        finally:
+            remove_refresh_failure_listener(listener)
+
+        # 60s, 120s capped at 100s, then the interval once a refresh succeeds
+        assert delays == [60, 100, 100, 86400]
+        assert failures == [("gcp", "catalog download failed")] * 3
+        status = schedule.status()[0]
+        assert (status.consecutive_failures, status.last_error) == (0, None)
+
+    def test_jitter_spreads_delays(self):
+        schedule, _ = scheduler(FlakyProvider("aws"), interval=1000, jitter=0.2, rng=random.Random(7))
+        schedule.run_due()
+        assert 800 <= schedule.seconds_until_due() <= 1200
+        with pytest.raises(ValueError):
+            scheduler(FlakyProvider("aws"), jitter=1)
+
+    def test_stale_catalogs_are_degraded(self):
+        ok, failing = FlakyProvider("aws"), FlakyProvider("azure", failures=100)
+        schedule, clock = scheduler(ok, failing, interval=0, max_age=7200)
+
+        schedule.run_due()
+        assert schedule.seconds_until_due() == 60
+        assert schedule.degraded() == []
+        clock.advance(7201)
+        # Refreshed once (interval 0), now older than the max age; azure never refreshed
+        assert schedule.degraded() == ["aws", "azure"]
+
+        schedule.refresh()
+        assert schedule.degraded() == ["azure"]
+        assert schedule.status()[1].consecutive_failures == 2
+
+    def test_refresh_locked_by_another_replica(self):
+        locks = MemoryLocks()
+        aws = FlakyProvider("aws")
+        schedule, clock = scheduler(aws, interval=3600, backoff=60, locks=locks, lock_ttl=300)
+
+        token = locks.acquire("catalog:aws", 300)
+        assert schedule.run_due() == []
+        status = schedule.status()[0]
+        assert (aws.refreshes, status.refreshing, status.consecutive_failures) == (0, False, 0)
+        assert schedule.seconds_until_due() == 60
+
+        locks.release("catalog:aws", token)
+        clock.advance(60)
+        assert schedule.run_due() == ["aws"] and aws.refreshes == 1
+        # Released once refreshed
+        assert locks.acquire("catalog:aws", 300) is not None
@@ -0,0 +1,95 @@
+"""Unit tests for spot pricing"""
+
+import pytest
+
+from src.pricing import (
+    PriceQuery,
+    PRICING_SPOT,
+    PriceNotFoundError,
+    ProviderRegistry,
+    StaticProvider,
+    SERVICE_BLOCK_STORAGE,
+    time_weighted_average,
+    update_history,
+    window_average,
+)
+from src.pricing.spot import append_observation
+
+from .test_registry import FixedProvider
+
+DAY = 86400.0
+
+
+class TestSpotAveraging:
+    """Test cases for spot price history averaging"""
+
+    def test_time_weighted_average(self):
+        """Test each price is weighted by how long it held"""
+        points = [(0.0, 1.0), (30.0, 4.0)]
+
+        assert time_weighted_average(points, 0.0, 40.0) == pytest.approx((30 * 1 + 10 * 4) / 40)
+
+    def test_price_before_window_carries_in(self):
+        """Test the price in effect at the window start counts for the whole gap"""
+        points = [(0.0, 2.0), (5.0, 3.0), (60.0, 1.0)]
+
+        assert time_weighted_average(points, 50.0, 70.0) == pytest.approx((10 * 3 + 10 * 1) / 20)
+
+    def test_no_history(self):
+        """Test no average without points before the window end"""
+        assert time_weighted_average([], 0.0, 10.0) is None
+        assert time_weighted_average([(20.0, 1.0)], 0.0, 10.0) is None
+
+    def test_window_average_reports_coverage(self):
+        """Test partial history is averaged over the observed days only"""
+        now = 100 * DAY
+        price, days = window_average([[now - 10 * DAY, 0.5], [now - 5 * DAY, 1.5]], 30, now=now)
+
+        assert price == pytest.approx(1.0)
+        assert days == pytest.approx(10)
+
+    def test_append_observation_prunes(self):
+        """Test old points are dropped but the one in effect at the window start is kept"""
+        history = [[0.0, 1.0], [10.0, 2.0], [20.0, 3.0]]
+
+        updated = append_observation(history, 40.0, 3.0, window_seconds=25.0)
+
+        assert updated == [[10.0, 2.0], [20.0, 3.0]]
+
+    def test_update_history(self):
+        """Test current prices are appended and delisted entries dropped"""
+        history = {"a": [[0.0, 1.0]], "gone": [[0.0, 1.0]]}
+
+        updated = update_history(history, {"a": {"price": 2.0}, "b": {"price": 3.0}}, 30, now=DAY)
+
+        assert updated == {"a": [[0.0, 1.0], [DAY, 2.0]], "b": [[DAY, 3.0]]}
+
+
+class TestStaticSpotPrices:
+    """Test cases for spot lookups through the registry"""
+
+    def test_static_spot_price(self):
+        """Test the built-in rate card serves spot prices"""
+        price = StaticProvider().get_price(PriceQuery(
+            provider="aws", region="us-east-1", sku="m5.large", pricing_model=PRICING_SPOT))
+
+        assert price.price == pytest.approx(0.0384)
+        assert price.pricing_model == PRICING_SPOT
+
+    def test_static_spot_storage(self):
+        """Test storage has no spot price"""
+        with pytest.raises(PriceNotFoundError):
+            StaticProvider().get_price(PriceQuery(
+                provider="aws", region="us-east-1", sku="gp3",
+                service=SERVICE_BLOCK_STORAGE, pricing_model=PRICING_SPOT))
+
+    def test_on_demand_only_provider_skipped(self):
+        """Test providers ignoring the pricing model do not answer spot queries"""
+        registry = ProviderRegistry()
+        registry.register(FixedProvider("custom", "aws", "m5.large", 0.05))
+        registry.register(StaticProvider())
+
+        price = registry.get_price(PriceQuery(
+            provider="aws", region="us-east-1", sku="m5.large", pricing_model=PRICING_SPOT))
+
+        assert price.source == "static"
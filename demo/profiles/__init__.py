@@ -0,0 +1 @@
+"""Tests for profiles module"""
@@ -0,0 +1,138 @@
+"""Unit tests for usage profiles"""
+
+import pytest
+
+from src.estimator import CostEstimator, DatabaseSpec, EstimateRequest, ResourceSpec
+from src.pricing import ProviderRegistry, StaticProvider
+from src.profiles import BUILTIN_PROFILES, UnknownProfileError, UsageProfiles, UsageProfileSpec, validate_profile_name
+from src.tenancy import tenant_context
+from src.validation import CODE_UNKNOWN_USAGE_PROFILE, validate_estimate
+
+
+@pytest.fixture
+def registry():
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return registry
+
+
+class TestUsageProfileSpec:
+    """Test cases for UsageProfileSpec class"""
+
+    def test_fraction_and_runs(self):
+        """Test weekly schedules run their share of the week, once per running day"""
+        business = BUILTIN_PROFILES["business-hours"]
+        assert business.fraction_of_month == pytest.approx(40 / 168, abs=1e-6)
+        assert business.monthly_runs == 22
+        assert business.scale(730) == pytest.approx(730 * 40 / 168, abs=1e-4)
+
+        assert BUILTIN_PROFILES["bursty-batch"].fraction() == 0.2
+        assert BUILTIN_PROFILES["bursty-batch"].runs() is None
+        assert UsageProfileSpec(hours_per_day=24, days_per_week=5).runs() is None
+        assert UsageProfileSpec(hours_per_day=6, days_per_week=5, runs_per_month=10).runs() == 10
+
+    def test_schedule_or_duty_cycle(self):
+        """Test a profile has either a complete weekly schedule or a duty cycle"""
+        for spec in ({}, {"hours_per_day": 8}, {"duty_cycle": 0.5, "days_per_week": 5}, {"duty_cycle": 1.5}):
+            with pytest.raises(ValueError):
+                UsageProfileSpec(**spec)
+
+    def test_names(self):
+        """Test names are normalized and malformed ones refused"""
+        assert validate_profile_name(" Nightly-Batch ") == "nightly-batch"
+        for name in ("", "-batch", "night batch", "x" * 64):
+            with pytest.raises(ValueError):
+                validate_profile_name(name)
+
+
+class TestUsageProfiles:
+    """Test cases for UsageProfiles class"""
+
+    def test_tenant_profiles(self, store):
+        """Test tenants see the built-in profiles and their own"""
+        store.save_usage_profile(UsageProfileSpec(hours_per_day=6, days_per_week=5).to_record("acme", "nightly"))
+        profiles = UsageProfiles(store)
+
+        with tenant_context("acme"):
+            assert [p.name for p in profiles.list()][-1] == "nightly"
+            assert profiles.get("Nightly").fraction_of_month == pytest.approx(30 / 168, abs=1e-6)
+            assert profiles.get("business-hours").builtin
+        with tenant_context("globex"):
+            with pytest.raises(UnknownProfileError):
+                profiles.get("nightly")
+        assert len(UsageProfiles().list()) == len(BUILTIN_PROFILES)
+
+    def test_cache_invalidation(self, store):
+        """Test changed profiles are read again once invalidated"""
+        now = [0.0]
+        profiles = UsageProfiles(store, ttl=60, clock=lambda: now[0])
+        store.save_usage_profile(UsageProfileSpec(duty_cycle=0.5).to_record("acme", "half"))
+        assert profiles.get("half", "acme").duty_cycle == 0.5
+
+        store.save_usage_profile(UsageProfileSpec(duty_cycle=0.25).to_record("acme", "half"))
+        assert profiles.get("half", "acme").duty_cycle == 0.5
+        profiles.invalidate("acme")
+        assert profiles.get("half", "acme").duty_cycle == 0.25
+
+        assert store.delete_usage_profile("acme", "half")
+        now[0] = 61
+        with pytest.raises(UnknownProfileError):
+            profiles.get("half", "acme")
+
+
+class TestProfileEstimates:
+    """Test cases for estimates with usage profiles"""
+
+    def test_scaled_hours(self, registry):
+        """Test profiles scale the hours of resources and database instances"""
+        estimator = CostEstimator(registry)
+        full = estimator.estimate(EstimateRequest(
+            resources=[ResourceSpec(provider="aws", instance_type="m5.large", region="us-east-1")],
+            databases=[DatabaseSpec(region="us-east-1", instance_class="db.m5.large", storage_gb=100)],
+        ))
+        scaled = estimator.estimate(EstimateRequest(
+            resources=[ResourceSpec(
+                provider="aws", instance_type="m5.large", region="us-east-1", usage_profile="Bursty-Batch",
+            )],
+            databases=[DatabaseSpec(
+                region="us-east-1", instance_class="db.m5.large", storage_gb=100, usage_profile="bursty-batch",
+            )],
+        ))
+
+        item = scaled.line_items[0]
+        assert item.usage_profile == "bursty-batch" and item.billed_hours is None
+        assert item.hours == pytest.approx(full.line_items[0].hours * 0.2, abs=1e-4)
+        assert item.monthly_cost == pytest.approx(full.line_items[0].monthly_cost * 0.2, abs=1e-3)
+
+        database = scaled.database_items[0]
+        instance = next(c for c in database.components if c.component == "instance")
+        storage = next(c for c in database.components if c.component == "storage")
+        assert database.usage_profile == "bursty-batch"
+        assert instance.quantity == pytest.approx(full.database_items[0].components[0].quantity * 0.2, abs=1e-4)
+        assert storage.monthly_cost == full.database_items[0].components[1].monthly_cost
+
+    def test_runs_of_schedules(self, registry):
+        """Test scheduled profiles are billed per running day, unless the resource sets its runs"""
+        estimator = CostEstimator(registry)
+        spec = dict(provider="aws", instance_type="m5.large", region="us-east-1", usage_profile="business-hours")
+        item = estimator.price_resource(ResourceSpec(**spec))
+        assert item.billed_hours is not None and item.billed_hours >= item.hours
+
+        own = estimator.price_resource(ResourceSpec(**spec, runs=1))
+        assert own.billed_hours == pytest.approx(own.hours, abs=1e-2)
+
+    def test_unknown_profile(self, registry):
+        """Test unknown profiles are violations of the field referencing them"""
+        estimator = CostEstimator(registry)
+        request = EstimateRequest(
+            resources=[ResourceSpec(provider="aws", instance_type="m5.large", region="us-east-1", usage_profile="x")],
+            databases=[DatabaseSpec(region="us-east-1", instance_class="db.m5.large", usage_profile="x")],
+        )
+        with pytest.raises(UnknownProfileError):
+            estimator.estimate(request)
+
+        violations = validate_estimate(estimator, request)
+        assert [(v.field, v.code) for v in violations] == [
+            ("resources[0].usage_profile", CODE_UNKNOWN_USAGE_PROFILE),
+            ("databases[0].usage_profile", CODE_UNKNOWN_USAGE_PROFILE),
+        ]
@@ -0,0 +1 @@
+"""Tests for cloud pricing providers"""
@@ -0,0 +1,156 @@
+"""Unit tests for Alibaba Cloud pricing provider"""
+
+import pytest
+
+from src.compare import Comparer, CompareRequest
+from src.pricing import (
+    PriceNotFoundError,
+    PriceQuery,
+    ProviderRegistry,
+    PRICING_SPOT,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_COMPUTE,
+    SERVICE_OBJECT_STORAGE,
+)
+from src.pricing.instance_types import get_instance_shape
+from src.providers.alibaba import AlibabaApiError, AlibabaPricingProvider, quote_for
+from src.providers.alibaba.client import rpc_signature
+from src.providers.cache import CatalogCache
+
+
+class FakeBss:
+    """BssClient stand-in pricing configs from a table"""
+
+    def __init__(self, costs, currency="USD"):
+        self.costs = costs
+        self.currency = currency
+        self.calls = []
+        self.error = None
+
+    def pay_as_you_go_price(self, region, product_code, module_code, config, price_type="Hour", product_type=None):
+        self.calls.append((region, module_code, config))
+        if self.error is not None:
+            raise self.error
+        cost = self.costs.get(config)
+        if cost is None:
+            raise AlibabaApiError("InvalidParameter", "instance type not found")
+        return {"cost": cost, "currency": self.currency}
+
+
+@pytest.fixture
+def client():
+    return FakeBss({
+        "InstanceType:ecs.g7.large,IoOptimized:IoOptimized,ImageOs:linux": 0.098,
+        "DataDisk.Category:cloud_essd,DataDisk.Size:100,DataDisk.PerformanceLevel:PL0": 0.0082,
+        "Storage:100": 0.0027,
+    })
+
+
+class TestQuotes:
+    """Test cases for quote configurations"""
+
+    def test_quote_for(self):
+        """Test instance, disk and OSS configurations and unpriced SKUs"""
+        assert quote_for(SERVICE_COMPUTE, "ecs.c7.xlarge").config.startswith("InstanceType:ecs.c7.xlarge,")
+        essd = quote_for(SERVICE_BLOCK_STORAGE, "cloud_essd")
+        assert essd.config.endswith("DataDisk.PerformanceLevel:PL1")
+        assert essd.scale == pytest.approx(7.3)
+        assert "PerformanceLevel" not in quote_for(SERVICE_BLOCK_STORAGE, "cloud_efficiency").config
+        assert quote_for(SERVICE_OBJECT_STORAGE, "standard").product_code == "oss"
+        assert quote_for(SERVICE_COMPUTE, "m5.large") is None
+        assert quote_for(SERVICE_BLOCK_STORAGE, "gp3") is None
+
+    def test_signature(self):
+        """Test the RPC signature of the documented example"""
+        params = {
+            "AccessKeyId": "testid", "Action": "DescribeRegions", "Format": "XML",
+            "SignatureMethod": "HMAC-SHA1", "SignatureNonce": "3ee8c1b8-83d3-44af-a94f-4e0ad82fd6cf",
+            "SignatureVersion": "1.0", "Timestamp": "2016-02-23T12:46:24Z", "Version": "2014-05-26",
+        }
+
+        assert rpc_signature("testsecret", "GET", params) == "OLeaidS1JvxuMvnyHOwuJ+uX5qY="
+
+    def test_ecs_shapes(self):
+        """Test ECS families resolve to shapes"""
+        assert get_instance_shape("alibaba", "ecs.r7.2xlarge")[:2] == (8, 64)
+        assert get_instance_shape("alibaba", "ecs.g8y.large").arch == "arm64"
+        with pytest.raises(KeyError):
+            get_instance_shape("alibaba", "ecs.x9.large")
+
+
+class TestAlibabaProvider:
+    """Test cases for the Alibaba Cloud provider"""
+
+    def test_quotes_on_lookup(self, client, tmp_path):
+        """Test SKUs are quoted once and kept across restarts"""
+        provider = AlibabaPricingProvider(["ap-southeast-1"], client, CatalogCache(str(tmp_path), ttl_seconds=3600))
+
+        price = provider.get_price(PriceQuery(provider="alibaba", region="ap-southeast-1", sku="ecs.g7.large"))
+        provider.get_price(PriceQuery(provider="alibaba", region="ap-southeast-1", sku="ecs.g7.large"))
+        assert (price.price, price.unit, price.currency) == (0.098, "hour", "USD")
+        assert len(client.calls) == 1
+
+        disk = provider.get_price(PriceQuery(
+            provider="alibaba", region="ap-southeast-1", sku="cloud_essd_pl0", service=SERVICE_BLOCK_STORAGE,
+        ))
+        assert disk.price == pytest.approx(0.0082 * 7.3)
+        assert disk.unit == "GB-month"
+
+        restarted = AlibabaPricingProvider(["ap-southeast-1"], cache=CatalogCache(str(tmp_path), ttl_seconds=3600))
+        assert restarted.get_price(PriceQuery(
+            provider="alibaba", region="ap-southeast-1", sku="ecs.g7.large",
+        )).price == 0.098
+
+    def test_rejected_configurations(self, client):
+        """Test rejected SKUs are remembered while failed calls are not"""
+        provider = AlibabaPricingProvider(["ap-southeast-1"], client)
+        query = PriceQuery(provider="alibaba", region="ap-southeast-1", sku="ecs.x9.large")
+
+        for _ in range(2):
+            with pytest.raises(PriceNotFoundError):
+                provider.get_price(query)
+        assert len(client.calls) == 1
+
+        client.error = AlibabaApiError("Throttling.User", "Request was denied due to user flow control")
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(provider="alibaba", region="ap-southeast-1", sku="ecs.c7.large"))
+        client.error = None
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(provider="alibaba", region="ap-southeast-1", sku="ecs.c7.large"))
+        assert len(client.calls) == 3
+
+    def test_stale_quotes(self, client, tmp_path):
+        """Test expired quotes are re-quoted on refresh and served while the API fails"""
+        provider = AlibabaPricingProvider(["ap-southeast-1"], client, quote_ttl=-1)
+        query = PriceQuery(provider="alibaba", region="ap-southeast-1", sku="ecs.g7.large")
+        provider.get_price(query)
+
+        provider.refresh()
+        assert len(client.calls) == 2
+
+        client.error = RuntimeError("connection reset")
+        assert provider.get_price(query).price == 0.098
+
+    def test_rejections(self, client):
+        """Test regions outside the configured ones and spot queries are not priced"""
+        provider = AlibabaPricingProvider(["ap-southeast-1"], client)
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(provider="alibaba", region="cn-hangzhou", sku="ecs.g7.large"))
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(
+                provider="alibaba", region="ap-southeast-1", sku="ecs.g7.large", pricing_model=PRICING_SPOT,
+            ))
+        assert client.calls == []
+
+    def test_comparison(self, client):
+        """Test Alibaba Cloud takes part in comparisons"""
+        registry = ProviderRegistry()
+        registry.register(AlibabaPricingProvider(["ap-southeast-1"], client))
+
+        result = Comparer(registry=registry).compare(CompareRequest(
+            vcpus=2, memory_gb=8, storage_gb=100, geography="sg", providers=["alibaba"],
+        ))
+
+        cheapest = result.cheapest["alibaba"]
+        assert (cheapest.instance_type, cheapest.storage_type) == ("ecs.g7.large", "cloud_essd_pl0")
+        assert cheapest.monthly_cost == pytest.approx(0.098 * 730 + 0.0082 * 730, abs=0.01)
@@ -0,0 +1,589 @@
+"""Unit tests for AWS pricing provider"""
+
+import json
+import os
+import time
+from datetime import datetime, timezone
+
+import pytest
+
+from src.pricing import (
+    PriceNotFoundError,
+    PriceQuery,
+    PRICING_RESERVED,
+    PRICING_SAVINGS_PLAN,
+    PRICING_SPOT,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_COMPUTE,
+    SERVICE_DATABASE,
+    SERVICE_DATABASE_BACKUP,
+    SERVICE_DATABASE_IOPS,
+    SERVICE_DATABASE_STORAGE,
+    SERVICE_OBJECT_STORAGE,
+)
+from src.providers.aws import (
+    AWSPricingProvider,
+    PriceListClient,
+    SpotPriceClient,
+    instance_family,
+    parse_offer,
+    parse_savings_plan_offer,
+)
+from src.providers.cache import CatalogCache
+
+
+def _on_demand(sku, unit, *tiers):
+    """Build an OnDemand term with one price dimension per (begin, end, usd) tier"""
+    return {
+        f"{sku}.JRTCKXETXF": {
+            "priceDimensions": {
+                f"{sku}.JRTCKXETXF.{i}": {
+                    "unit": unit,
+                    "beginRange": str(begin),
+                    "endRange": end,
+                    "pricePerUnit": {"USD": usd},
+                }
+                for i, (begin, end, usd) in enumerate(tiers)
+            }
+        }
+    }
+
+
+@pytest.fixture
+def ec2_offer():
+    """Offer file with two Linux instances, one Windows instance and one EBS volume"""
+    linux = {
+        "operatingSystem": "Linux", "tenancy": "Shared",
+        "preInstalledSw": "NA", "capacitystatus": "Used", "regionCode": "us-east-1",
+    }
+    return {
+        "version": "20240501000000",
+        "products": {
+            "SKU1": {"productFamily": "Compute Instance", "attributes": dict(linux, instanceType="m5.large")},
+            "SKU2": {"productFamily": "Compute Instance", "attributes": dict(linux, instanceType="c5.large")},
+            "SKU3": {"productFamily": "Compute Instance",
+                     "attributes": dict(linux, instanceType="m5.large", operatingSystem="Windows")},
+            "SKU4": {"productFamily": "Storage", "attributes": {"volumeApiName": "gp3", "regionCode": "us-east-1"}},
+        },
+        "terms": {"OnDemand": {
+            **{"SKU1": _on_demand("SKU1", "Hrs", (0, "Inf", "0.0960000000"))},
+            **{"SKU2": _on_demand("SKU2", "Hrs", (0, "Inf", "0.0850000000"))},
+            **{"SKU3": _on_demand("SKU3", "Hrs", (0, "Inf", "0.1880000000"))},
+            **{"SKU4": _on_demand("SKU4", "GB-Mo", (0, "Inf", "0.0800000000"))},
+        }},
+    }
+
+
+@pytest.fixture
+def s3_rds_offer():
+    """Offer file with tiered S3 storage and an RDS instance"""
+    return {
+        "version": "20240502000000",
+        "products": {
+            "S3STD": {"productFamily": "Storage", "attributes": {
+                "servicecode": "AmazonS3", "volumeType": "Standard", "regionCode": "us-east-1"}},
+            "RDS1": {"productFamily": "Database Instance", "attributes": {
+                "instanceType": "db.m5.large", "databaseEngine": "PostgreSQL",
+                "deploymentOption": "Multi-AZ", "licenseModel": "No license required",
+                "regionCode": "us-east-1"}},
+        },
+        "terms": {"OnDemand": {
+            "S3STD": _on_demand("S3STD", "GB-Mo", (51200, "512000", "0.022"), (0, "51200", "0.023")),
+            "RDS1": _on_demand("RDS1", "Hrs", (0, "Inf", "0.356")),
+        }},
+    }
+
+
+@pytest.fixture
+def rds_storage_offer(s3_rds_offer):
+    """RDS offer with gp3 and io1 storage, provisioned IOPS and backup storage"""
+    def product(family, **attrs):
+        return {"productFamily": family, "attributes": dict(attrs, regionCode="us-east-1")}
+
+    offer = {"version": "20240503000000", "products": dict(s3_rds_offer["products"]), "terms": {"OnDemand": dict(
+        s3_rds_offer["terms"]["OnDemand"],
+        GP3=_on_demand("GP3", "GB-Mo", (0, "Inf", "0.23")),
+        IO1=_on_demand("IO1", "GB-Mo", (0, "Inf", "0.125")),
+        PIOPS=_on_demand("PIOPS", "IOPS-Mo", (0, "Inf", "0.10")),
+        BACKUP=_on_demand("BACKUP", "GB-Mo", (0, "Inf", "0.095")),
+    )}}
+    offer["products"].update(
+        GP3=product("Database Storage", volumeType="General Purpose-GP3", deploymentOption="Multi-AZ",
+                    databaseEngine="PostgreSQL"),
+        IO1=product("Database Storage", volumeType="Provisioned IOPS", deploymentOption="Single-AZ",
+                    databaseEngine="MySQL"),
+        PIOPS=product("Provisioned IOPS", usagetype="RDS:PIOPS", deploymentOption="Single-AZ"),
+        BACKUP=product("Storage Snapshot", usagetype="RDS:ChargedBackupUsage"),
+    )
+    return offer
+
+
+class FakeClient:
+    """Price List client serving offers from memory"""
+
+    def __init__(self, offers):
+        self.offers = offers
+        self.downloads = []
+
+    def get_region_offer(self, service_code, region, instance_families=None):
+        self.downloads.append((service_code, region))
+        if (service_code, region) not in self.offers:
+            raise LookupError(f"{service_code} not in {region}")
+        return self.offers[(service_code, region)]
+
+    get_savings_plan_offer = get_region_offer
+
+
+class TestParseOffer:
+    """Test cases for offer file parsing"""
+
+    def test_linux_shared_instances_only(self, ec2_offer):
+        """Test only Linux shared-tenancy instances are kept"""
+        entries = parse_offer(ec2_offer, "us-east-1")
+
+        assert entries["compute|us-east-1|m5.large|"]["price"] == pytest.approx(0.096)
+        assert entries["compute|us-east-1|m5.large|"]["unit"] == "hour"
+        assert entries["block_storage|us-east-1|gp3|"]["unit"] == "GB-month"
+        assert len(entries) == 3
+
+    def test_instance_family_filter(self, ec2_offer):
+        """Test family filtering drops other instance families"""
+        entries = parse_offer(ec2_offer, "us-east-1", instance_families=["c5"])
+
+        assert "compute|us-east-1|c5.large|" in entries
+        assert "compute|us-east-1|m5.large|" not in entries
+        # Storage is not subject to instance family filtering
+        assert "block_storage|us-east-1|gp3|" in entries
+
+    def test_tiered_prices_sorted(self, s3_rds_offer):
+        """Test multi-tier prices keep the first tier as headline price"""
+        entries = parse_offer(s3_rds_offer, "us-east-1")
+
+        s3 = entries["object_storage|us-east-1|Standard|"]
+        assert s3["price"] == pytest.approx(0.023)
+        assert [tier["begin"] for tier in s3["tiers"]] == [0, 51200]
+
+    def test_database_storage(self, rds_storage_offer):
+        """Test RDS storage and IOPS are keyed by type and deployment, backups by region"""
+        entries = parse_offer(rds_storage_offer, "us-east-1")
+
+        assert entries["database_storage|us-east-1|gp3|Multi-AZ"]["price"] == pytest.approx(0.23)
+        assert entries["database_storage|us-east-1|io1|Single-AZ"]["price"] == pytest.approx(0.125)
+        assert entries["database_iops|us-east-1|io1|Single-AZ"]["unit"] == "IOPS-month"
+        assert entries["database_backup|us-east-1|backup|"]["price"] == pytest.approx(0.095)
+
+    def test_database_editions(self, s3_rds_offer):
+        """Test RDS offers differing only in license model or edition keep their own prices"""
+        sql_server = {"instanceType": "db.m5.large", "databaseEngine": "SQL Server", "deploymentOption": "Single-AZ",
+                      "licenseModel": "License included", "regionCode": "us-east-1"}
+        s3_rds_offer["products"].update(
+            RDSSTD={"productFamily": "Database Instance", "attributes": dict(sql_server, databaseEdition="Standard")},
+            RDSENT={"productFamily": "Database Instance", "attributes": dict(sql_server, databaseEdition="Enterprise")},
+            RDSBYOL={"productFamily": "Database Instance", "attributes": dict(
+                sql_server, databaseEdition="Enterprise", licenseModel="Bring your own license")},
+        )
+        s3_rds_offer["terms"]["OnDemand"].update(
+            RDSSTD=_on_demand("RDSSTD", "Hrs", (0, "Inf", "0.977")),
+            RDSENT=_on_demand("RDSENT", "Hrs", (0, "Inf", "1.92")),
+            RDSBYOL=_on_demand("RDSBYOL", "Hrs", (0, "Inf", "0.205")),
+        )
+        entries = parse_offer(s3_rds_offer, "us-east-1")
+
+        prefix = "database|us-east-1|db.m5.large|SQL Server/Single-AZ/license-included"
+        assert entries[f"{prefix}/Standard"]["price"] == pytest.approx(0.977)
+        assert entries[f"{prefix}/Enterprise"]["price"] == pytest.approx(1.92)
+        assert entries["database|us-east-1|db.m5.large|PostgreSQL/Multi-AZ"]["price"] == pytest.approx(0.356)
+        assert len([key for key in entries if key.startswith("database|")]) == 3
+
+        provider = AWSPricingProvider(regions=["us-east-1"], client=FakeClient({
+            ("AmazonRDS", "us-east-1"): s3_rds_offer,
+        }))
+        provider.refresh()
+        price = provider.get_price(PriceQuery(
+            provider="aws", region="us-east-1", sku="db.m5.large", service=SERVICE_DATABASE,
+            attributes={"engine": "sqlserver", "deployment": "single_az"}))
+        assert price.price == pytest.approx(0.977)
+
+    def test_instance_family(self):
+        """Test instance family extraction"""
+        assert instance_family("m5.2xlarge") == "m5"
+        assert instance_family("db.r6g.large") == "r6g"
+
+
+class TestAWSPricingProvider:
+    """Test cases for AWSPricingProvider class"""
+
+    @pytest.fixture
+    def client(self, ec2_offer, s3_rds_offer):
+        """Create fake client with EC2, S3 and RDS offers"""
+        return FakeClient({
+            ("AmazonEC2", "us-east-1"): ec2_offer,
+            ("AmazonS3", "us-east-1"): s3_rds_offer,
+            ("AmazonRDS", "us-east-1"): s3_rds_offer,
+            ("AWSComputeSavingsPlan", "us-east-1"): {"products": [], "terms": {}},
+        })
+
+    def test_not_loaded(self, client):
+        """Test lookups fail until a catalog is loaded"""
+        provider = AWSPricingProvider(regions=["us-east-1"], client=client)
+
+        with pytest.raises(PriceNotFoundError, match="not loaded"):
+            provider.get_price(PriceQuery(provider="aws", region="us-east-1", sku="m5.large"))
+
+    def test_prices_by_service(self, client):
+        """Test compute, storage and database lookups"""
+        provider = AWSPricingProvider(regions=["us-east-1"], client=client)
+        provider.refresh()
+
+        compute = provider.get_price(PriceQuery(provider="aws", region="us-east-1", sku="m5.large"))
+        ebs = provider.get_price(PriceQuery(
+            provider="aws", region="us-east-1", sku="gp3", service=SERVICE_BLOCK_STORAGE))
+        s3 = provider.get_price(PriceQuery(
+            provider="aws", region="us-east-1", sku="Standard", service=SERVICE_OBJECT_STORAGE))
+        rds = provider.get_price(PriceQuery(
+            provider="aws", region="us-east-1", sku="db.m5.large", service=SERVICE_DATABASE,
+            attributes={"engine": "PostgreSQL", "deployment": "Multi-AZ"}))
+
+        assert compute.price == pytest.approx(0.096)
+        assert compute.service == SERVICE_COMPUTE
+        assert ebs.unit == "GB-month"
+        assert s3.price == pytest.approx(0.023)
+        assert rds.price == pytest.approx(0.356)
+
+    def test_database_components(self, client, rds_storage_offer):
+        """Test RDS lookups accept provider-neutral engine and deployment names"""
+        client.offers[("AmazonRDS", "us-east-1")] = rds_storage_offer
+        provider = AWSPricingProvider(regions=["us-east-1"], client=client)
+        provider.refresh()
+        multi_az = {"engine": "postgres", "deployment": "multi_az"}
+
+        instance = provider.get_price(PriceQuery(
+            provider="aws", region="us-east-1", sku="db.m5.large", service=SERVICE_DATABASE, attributes=multi_az))
+        storage = provider.get_price(PriceQuery(
+            provider="aws", region="us-east-1", sku="gp3", service=SERVICE_DATABASE_STORAGE, attributes=multi_az))
+        iops = provider.get_price(PriceQuery(
+            provider="aws", region="us-east-1", sku="io1", service=SERVICE_DATABASE_IOPS,
+            attributes={"deployment": "single_az"}))
+        backup = provider.get_price(PriceQuery(
+            provider="aws", region="us-east-1", sku="backup", service=SERVICE_DATABASE_BACKUP))
+
+        assert instance.price == pytest.approx(0.356)
+        assert storage.price == pytest.approx(0.23)
+        assert iops.unit == "IOPS-month"
+        assert backup.price == pytest.approx(0.095)
+
+    def test_missing_region_offer_skipped(self, client):
+        """Test regions without an offer file do not fail the refresh"""
+        provider = AWSPricingProvider(regions=["us-east-1", "mars-1"], client=client)
+
+        provider.refresh()
+
+        assert provider.loaded
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(provider="aws", region="mars-1", sku="m5.large"))
+
+    def test_cache_reused(self, client, tmp_path):
+        """Test fresh cache entries skip downloads and survive restarts"""
+        cache = CatalogCache(str(tmp_path), ttl_seconds=3600)
+        AWSPricingProvider(regions=["us-east-1"], client=client, cache=cache).refresh()
+        downloads = len(client.downloads)
+
+        restarted = AWSPricingProvider(regions=["us-east-1"], client=client, cache=cache)
+        restarted.refresh()
+
+        assert len(client.downloads) == downloads
+        price = restarted.get_price(PriceQuery(provider="aws", region="us-east-1", sku="c5.large"))
+        assert price.price == pytest.approx(0.085)
+
+
+def _reserved(sku, code, lease, purchase, hourly, upfront=None, offering_class="standard"):
+    """Build a Reserved term with an hourly and an optional upfront dimension"""
+    dimensions = {f"{sku}.{code}.1": {"unit": "Hrs", "pricePerUnit": {"USD": hourly}}}
+    if upfront is not None:
+        dimensions[f"{sku}.{code}.2"] = {"unit": "Quantity", "pricePerUnit": {"USD": upfront}}
+    return {f"{sku}.{code}": {
+        "termAttributes": {
+            "LeaseContractLength": lease, "PurchaseOption": purchase, "OfferingClass": offering_class,
+        },
+        "priceDimensions": dimensions,
+    }}
+
+
+@pytest.fixture
+def reserved_offer(ec2_offer):
+    """EC2 offer with reserved terms for m5.large"""
+    ec2_offer["terms"]["Reserved"] = {"SKU1": {
+        **_reserved("SKU1", "A", "1yr", "No Upfront", "0.0600000000"),
+        **_reserved("SKU1", "B", "1yr", "Partial Upfront", "0.0290000000", "254"),
+        **_reserved("SKU1", "C", "3yr", "All Upfront", "0.0000000000", "964"),
+        **_reserved("SKU1", "D", "1yr", "No Upfront", "0.0700000000", offering_class="convertible"),
+    }}
+    return ec2_offer
+
+
+@pytest.fixture
+def savings_plan_offer():
+    """Compute Savings Plans rate file with one no-upfront and one all-upfront plan"""
+    def rate(usage_type, price, operation="RunInstances"):
+        return {
+            "discountedServiceCode": "AmazonEC2", "discountedUsageType": usage_type,
+            "discountedOperation": operation, "discountedRate": {"price": price, "currency": "USD"},
+        }
+
+    return {
+        "version": "20240503000000",
+        "products": [
+            {"sku": "SP1", "productFamily": "ComputeSavingsPlans",
+             "attributes": {"purchaseTerm": "1yr", "purchaseOption": "No Upfront"}},
+            {"sku": "SP3", "productFamily": "ComputeSavingsPlans",
+             "attributes": {"purchaseTerm": "3yr", "purchaseOption": "All Upfront"}},
+        ],
+        "terms": {"savingsPlan": [
+            {"sku": "SP1", "rates": [
+                rate("USE1-BoxUsage:m5.large", "0.068"),
+                rate("USE1-BoxUsage:m5.large", "0.12", operation="RunInstances:0002"),
+                rate("USE1-DedicatedUsage:m5.large", "0.08"),
+            ]},
+            {"sku": "SP3", "rates": [rate("USE1-BoxUsage:m5.large", "0.043")]},
+        ]},
+    }
+
+
+class TestAWSCommitmentPrices:
+    """Test cases for reserved instance and Savings Plans prices"""
+
+    def _query(self, model, term, payment_option):
+        return PriceQuery(
+            provider="aws", region="us-east-1", sku="m5.large", pricing_model=model,
+            attributes={"term": term, "payment_option": payment_option},
+        )
+
+    def test_parse_reserved_terms(self, reserved_offer):
+        """Test standard reserved terms are split into hourly and upfront parts"""
+        entries = parse_offer(reserved_offer, "us-east-1")
+
+        partial = entries["compute|us-east-1|m5.large|reserved/1yr/partial_upfront"]
+        assert partial == {"price": pytest.approx(0.029), "unit": "hour", "upfront": pytest.approx(254)}
+        assert entries["compute|us-east-1|m5.large|reserved/1yr/no_upfront"]["price"] == pytest.approx(0.06)
+        assert entries["compute|us-east-1|m5.large|reserved/3yr/all_upfront"]["upfront"] == pytest.approx(964)
+
+    def test_parse_savings_plans(self, savings_plan_offer):
+        """Test only Linux shared-tenancy EC2 rates are kept"""
+        entries = parse_savings_plan_offer(savings_plan_offer, "us-east-1")
+
+        no_upfront = entries["compute|us-east-1|m5.large|savings_plan/1yr/no_upfront"]
+        all_upfront = entries["compute|us-east-1|m5.large|savings_plan/3yr/all_upfront"]
+        assert no_upfront["price"] == pytest.approx(0.068)
+        assert no_upfront["upfront"] == 0
+        assert all_upfront["price"] == 0
+        assert all_upfront["upfront"] == pytest.approx(0.043 * 730 * 36)
+        assert len(entries) == 2
+
+    def test_commitment_lookups(self, reserved_offer, savings_plan_offer):
+        """Test reserved and Savings Plans prices after a refresh"""
+        client = FakeClient({
+            ("AmazonEC2", "us-east-1"): reserved_offer,
+            ("AWSComputeSavingsPlan", "us-east-1"): savings_plan_offer,
+        })
+        provider = AWSPricingProvider(regions=["us-east-1"], client=client)
+        provider.refresh()
+
+        reserved = provider.get_price(self._query(PRICING_RESERVED, "1yr", "partial_upfront"))
+        plan = provider.get_price(self._query(PRICING_SAVINGS_PLAN, "1yr", "no_upfront"))
+
+        assert reserved.price == pytest.approx(0.029)
+        assert reserved.upfront == pytest.approx(254)
+        assert reserved.pricing_model == PRICING_RESERVED
+        assert plan.price == pytest.approx(0.068)
+        with pytest.raises(PriceNotFoundError, match="reserved/3yr/no_upfront"):
+            provider.get_price(self._query(PRICING_RESERVED, "3yr", "no_upfront"))
+
+
+class FakeSession:
+    """requests.Session stand-in serving JSON documents by URL"""
+
+    def __init__(self, documents):
+        self.documents = documents
+
+    def get(self, url, timeout=None, stream=False):
+        body = json.dumps(self.documents[url]).encode()
+        response = type("Response", (), {
+            "raise_for_status": lambda self: None,
+            "json": lambda self: json.loads(body),
+            "iter_content": lambda self, chunk_size: (body[i:i + chunk_size] for i in range(0, len(body), chunk_size)),
+            "__enter__": lambda self: self,
+            "__exit__": lambda self, *exc_info: None,
+        })
+        return response()
+
+
+class TestPriceListClient:
+    """Test cases for offer file downloads"""
+
+    BASE = "https://pricing.example.com"
+
+    def _client(self, monkeypatch, tmp_path, documents):
+        monkeypatch.setattr("tempfile.tempdir", str(tmp_path))
+        client = PriceListClient(base_url=self.BASE)
+        client.session = FakeSession(documents)
+        return client
+
+    def test_region_offer_keeps_priced_products(self, monkeypatch, tmp_path, reserved_offer):
+        """Test products the parser drops and their terms are not kept, and the download is removed"""
+        index = f"{self.BASE}/offers/v1.0/aws/AmazonEC2/current/region_index.json"
+        client = self._client(monkeypatch, tmp_path, {
+            index: {"regions": {"us-east-1": {"currentVersionUrl": "/offers/ec2.json"}}},
+            f"{self.BASE}/offers/ec2.json": reserved_offer,
+        })
+
+        offer = client.get_region_offer("AmazonEC2", "us-east-1", ["m5"])
+
+        assert offer["version"] == reserved_offer["version"]
+        assert sorted(offer["products"]) == ["SKU1", "SKU4"]
+        assert sorted(offer["terms"]["OnDemand"]) == ["SKU1", "SKU4"]
+        assert sorted(offer["terms"]["Reserved"]) == ["SKU1"]
+        assert parse_offer(offer, "us-east-1", ["m5"]) == parse_offer(reserved_offer, "us-east-1", ["m5"])
+        assert os.listdir(tmp_path) == []
+        with pytest.raises(LookupError):
+            client.get_region_offer("AmazonEC2", "eu-west-1")
+
+    def test_savings_plan_offer_keeps_priced_rates(self, monkeypatch, tmp_path, savings_plan_offer):
+        """Test Savings Plans rates the parser drops are not kept"""
+        index = f"{self.BASE}/savingsPlan/v1.0/aws/AWSComputeSavingsPlan/current/region_index.json"
+        savings_plan_offer["products"].append(
+            {"sku": "SPX", "attributes": {"purchaseTerm": "5yr", "purchaseOption": "No Upfront"}}
+        )
+        savings_plan_offer["terms"]["savingsPlan"].append({"sku": "SPX", "rates": []})
+        client = self._client(monkeypatch, tmp_path, {
+            index: {"regions": [{"regionCode": "us-east-1", "versionUrl": "/savingsPlan/use1.json"}]},
+            f"{self.BASE}/savingsPlan/use1.json": savings_plan_offer,
+        })
+
+        offer = client.get_savings_plan_offer("AWSComputeSavingsPlan", "us-east-1")
+
+        assert [product["sku"] for product in offer["products"]] == ["SP1", "SP3"]
+        assert [len(plan["rates"]) for plan in offer["terms"]["savingsPlan"]] == [1, 1]
+        assert parse_savings_plan_offer(offer, "us-east-1") == parse_savings_plan_offer(savings_plan_offer, "us-east-1")
+
+
+class FakeSpotClient:
+    """SpotPriceClient stand-in returning fixed per-type, per-zone histories"""
+
+    def __init__(self, history=None, error=None):
+        self.history = history or {}
+        self.error = error
+        self.calls = []
+
+    def region_history(self, region, window_days, instance_families=None):
+        self.calls.append((region, instance_families))
+        if self.error is not None:
+            raise self.error
+        return self.history
+
+
+class TestAWSSpotPrices:
+    """Test cases for AWS spot price averaging"""
+
+    NOW = 1_700_000_000.0
+    DAY = 86400.0
+
+    @pytest.fixture(autouse=True)
+    def clock(self, monkeypatch):
+        self.now = [self.NOW]
+        monkeypatch.setattr(time, "time", lambda: self.now[0])
+
+    def _query(self, sku="m5.large"):
+        return PriceQuery(provider="aws", region="us-east-1", sku=sku, pricing_model=PRICING_SPOT)
+
+    def _provider(self, spot, **kwargs):
+        return AWSPricingProvider(regions=["us-east-1"], client=FakeClient({}), spot_client=spot, **kwargs)
+
+    def test_average_across_zones(self):
+        """Test each zone is time-weighted over the window, then zones averaged"""
+        spot = FakeSpotClient({"m5.large": {
+            "us-east-1a": [[self.NOW - 40 * self.DAY, 0.03], [self.NOW - 15 * self.DAY, 0.05]],
+            "us-east-1b": [[self.NOW - 30 * self.DAY, 0.02]],
+        }})
+        provider = self._provider(spot)
+        provider.refresh()
+
+        price = provider.get_price(self._query())
+
+        assert price.price == pytest.approx((0.04 + 0.02) / 2)
+        assert price.pricing_model == PRICING_SPOT
+        assert price.averaged_over_days == pytest.approx(30)
+        with pytest.raises(PriceNotFoundError, match="No AWS spot price"):
+            provider.get_price(self._query("c5.large"))
+
+    def test_history_read_on_refresh(self):
+        """Test lookups read the averages of the last refresh, which reads a region at most once per TTL"""
+        spot = FakeSpotClient({"m5.large": {"us-east-1a": [[self.NOW - self.DAY, 0.04]]}})
+        provider = self._provider(spot, instance_families=["m5"], spot_cache_ttl=3600)
+
+        with pytest.raises(PriceNotFoundError, match="not loaded yet"):
+            provider.get_price(self._query())
+        provider.refresh()
+        provider.get_price(self._query())
+        provider.get_price(self._query())
+        provider.refresh()
+        assert spot.calls == [("us-east-1", ["m5"])]
+
+        self.now[0] += 3600
+        spot.history = {"m5.large": {"us-east-1a": [[self.NOW - self.DAY, 0.05]]}}
+        provider.refresh()
+        assert len(spot.calls) == 2
+        assert provider.get_price(self._query()).price == pytest.approx(0.05)
+
+    def test_unavailable_history(self):
+        """Test API errors keep earlier averages and disabled spot lookups report no price"""
+        spot = FakeSpotClient({"m5.large": {"us-east-1a": [[self.NOW - self.DAY, 0.04]]}})
+        provider = self._provider(spot, spot_cache_ttl=0)
+        provider.refresh()
+        spot.error = RuntimeError("no credentials")
+        provider.refresh()
+        assert provider.get_price(self._query()).price == pytest.approx(0.04)
+
+        failing = self._provider(FakeSpotClient(error=RuntimeError("no credentials")))
+        failing.refresh()
+        disabled = AWSPricingProvider(regions=["us-east-1"], client=FakeClient({}))
+
+        with pytest.raises(PriceNotFoundError):
+            failing.get_price(self._query())
+        with pytest.raises(PriceNotFoundError, match="not enabled"):
+            disabled.get_price(self._query())
+
+    def test_client_pages_history(self):
+        """Test SpotPriceClient groups paginated records by instance type and zone"""
+        pages = [
+            {"SpotPriceHistory": [
+                {"InstanceType": "m5.large", "AvailabilityZone": "us-east-1a", "SpotPrice": "0.0400",
+                 "Timestamp": datetime(2024, 5, 1, tzinfo=timezone.utc)},
+            ]},
+            {"SpotPriceHistory": [
+                {"InstanceType": "m5.large", "AvailabilityZone": "us-east-1b", "SpotPrice": "0.0380",
+                 "Timestamp": datetime(2024, 5, 2, tzinfo=timezone.utc)},
+                {"InstanceType": "m5.xlarge", "AvailabilityZone": "us-east-1b", "SpotPrice": "0.0760",
+                 "Timestamp": datetime(2024, 5, 2, tzinfo=timezone.utc)},
+            ]},
+        ]
+
+        class Paginator:
+            def paginate(self, **kwargs):
+                assert kwargs["Filters"] == [{"Name": "instance-type", "Values": ["m5.*"]}]
+                assert kwargs["ProductDescriptions"] == ["Linux/UNIX"]
+                return iter(pages)
+
+        class EC2:
+            def get_paginator(self, name):
+                assert name == "describe_spot_price_history"
+                return Paginator()
+
+        class Session:
+            def client(self, service, region_name=None):
+                return EC2()
+
+        history = SpotPriceClient(session=Session()).region_history("us-east-1", 30, ["m5"])
+
+        assert history["m5.large"]["us-east-1a"] == [[datetime(2024, 5, 1, tzinfo=timezone.utc).timestamp(), 0.04]]
+        assert sorted(history["m5.large"]) == ["us-east-1a", "us-east-1b"]
+        assert sorted(history) == ["m5.large", "m5.xlarge"]
@@ -0,0 +1,200 @@
+"""Unit tests for Azure pricing provider"""
+
+import time
+
+import pytest
+
+from src.pricing import (
+    PriceNotFoundError,
+    PriceQuery,
+    PRICING_RESERVED,
+    PRICING_SPOT,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_OBJECT_STORAGE,
+)
+from src.providers.azure import AzurePricingProvider, RetailPricesClient, parse_items
+from src.providers.cache import CatalogCache
+
+
+def _item(service, product, sku_name, meter, unit, price, arm_sku=None, region="eastus", tier=0):
+    """Build a Retail Prices item"""
+    return {
+        "serviceName": service, "productName": product, "skuName": sku_name,
+        "meterName": meter, "unitOfMeasure": unit, "retailPrice": price,
+        "armSkuName": arm_sku, "armRegionName": region, "type": "Consumption",
+        "tierMinimumUnits": tier,
+    }
+
+
+@pytest.fixture
+def items():
+    """VM, disk and blob items including ones that must be skipped"""
+    return [
+        _item("Virtual Machines", "Virtual Machines DSv3 Series", "D2s v3", "D2s v3", "1 Hour", 0.096, "Standard_D2s_v3"),
+        _item("Virtual Machines", "Virtual Machines DSv3 Series Windows", "D2s v3", "D2s v3", "1 Hour", 0.188, "Standard_D2s_v3"),
+        _item("Virtual Machines", "Virtual Machines DSv3 Series", "D2s v3 Spot", "D2s v3 Spot", "1 Hour", 0.019, "Standard_D2s_v3"),
+        _item("Storage", "Premium SSD Managed Disks", "P10 LRS", "P10 LRS Disk", "1/Month", 19.71),
+        _item("Storage", "General Block Blob v2", "Hot LRS", "Hot LRS Data Stored", "1 GB/Month", 0.0184),
+        _item("Storage", "General Block Blob v2", "Hot LRS", "Hot LRS Data Stored", "1 GB/Month", 0.0177, tier=51200),
+        dict(_item("Virtual Machines", "Virtual Machines DSv3 Series", "D2s v3", "D2s v3", "1 Hour", 504.0,
+                   "Standard_D2s_v3"), type="Reservation", reservationTerm="1 Year"),
+    ]
+
+
+class FakeSession:
+    """requests.Session stand-in serving paged responses"""
+
+    def __init__(self, pages):
+        self.pages = pages
+        self.calls = []
+
+    def get(self, url, params=None, timeout=None):
+        self.calls.append((url, params))
+        page = self.pages[len(self.calls) - 1]
+
+        class Response:
+            def raise_for_status(self):
+                pass
+
+            def json(self):
+                return page
+
+        return Response()
+
+
+class TestRetailPricesClient:
+    """Test cases for RetailPricesClient paging"""
+
+    def test_follows_next_page_link(self, items):
+        """Test all pages are fetched and the filter is sent once"""
+        client = RetailPricesClient(base_url="https://prices.example")
+        client.session = FakeSession([
+            {"Items": items[:3], "NextPageLink": "https://prices.example?$skip=100"},
+            {"Items": items[3:], "NextPageLink": None},
+        ])
+
+        result = client.region_prices("eastus", "Virtual Machines")
+
+        assert len(result) == len(items)
+        assert "armRegionName eq 'eastus'" in client.session.calls[0][1]["$filter"]
+        assert client.session.calls[1] == ("https://prices.example?$skip=100", None)
+
+
+class TestAzurePricingProvider:
+    """Test cases for AzurePricingProvider class"""
+
+    class FakeClient:
+        """Client returning the fixture items for every query"""
+
+        def __init__(self, items):
+            self.items = items
+            self.queries = 0
+
+        def region_prices(self, region, service_name, price_type="Consumption"):
+            self.queries += 1
+            return [
+                i for i in self.items
+                if i["serviceName"] == service_name and i["armRegionName"] == region and i["type"] == price_type
+            ]
+
+    def test_parse_items(self, items):
+        """Test Windows and volume-tier items are dropped and spot and reservation prices kept apart"""
+        entries = parse_items(items)
+
+        assert entries["compute|eastus|Standard_D2s_v3"]["price"] == pytest.approx(0.096)
+        assert entries["compute|eastus|Standard_D2s_v3|spot"]["price"] == pytest.approx(0.019)
+        assert entries["block_storage|eastus|P10 LRS"]["unit"] == "month"
+        assert entries["object_storage|eastus|Hot LRS"]["price"] == pytest.approx(0.0184)
+        assert entries["compute|eastus|Standard_D2s_v3|reserved/1yr"] == {"price": 504.0, "unit": "term"}
+        assert len(entries) == 5
+
+    def test_prices_after_refresh(self, items):
+        """Test VM, disk and blob lookups"""
+        provider = AzurePricingProvider(regions=["eastus"], client=self.FakeClient(items))
+        provider.refresh()
+
+        vm = provider.get_price(PriceQuery(provider="azure", region="eastus", sku="Standard_D2s_v3"))
+        disk = provider.get_price(PriceQuery(
+            provider="azure", region="eastus", sku="P10 LRS", service=SERVICE_BLOCK_STORAGE))
+        blob = provider.get_price(PriceQuery(
+            provider="azure", region="eastus", sku="Hot LRS", service=SERVICE_OBJECT_STORAGE))
+
+        assert vm.price == pytest.approx(0.096)
+        assert disk.price == pytest.approx(19.71)
+        assert blob.unit == "GB-month"
+
+    def test_unknown_sku(self, items):
+        """Test lookups for unknown VM sizes"""
+        provider = AzurePricingProvider(regions=["eastus"], client=self.FakeClient(items))
+        provider.refresh()
+
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(provider="azure", region="eastus", sku="Standard_Z99"))
+
+    def test_cache_ttl(self, items, tmp_path):
+        """Test fresh cache skips queries while an expired cache re-fetches"""
+        client = self.FakeClient(items)
+        fresh = CatalogCache(str(tmp_path), ttl_seconds=3600)
+        AzurePricingProvider(regions=["eastus"], client=client, cache=fresh).refresh()
+        queries = client.queries
+
+        AzurePricingProvider(regions=["eastus"], client=client, cache=fresh).refresh()
+        assert client.queries == queries
+
+        expired = CatalogCache(str(tmp_path), ttl_seconds=-1)
+        provider = AzurePricingProvider(regions=["eastus"], client=client, cache=expired)
+        assert provider.loaded
+        provider.refresh()
+        assert client.queries == 2 * queries
+
+    def test_spot_price_averaged_across_refreshes(self, items, tmp_path, monkeypatch):
+        """Test spot prices observed at each refresh are time-weighted"""
+        day = 86400.0
+        clock = {"now": 1_700_000_000.0}
+        monkeypatch.setattr(time, "time", lambda: clock["now"])
+        client = self.FakeClient(items)
+        cache = CatalogCache(str(tmp_path), ttl_seconds=-1)
+        query = PriceQuery(provider="azure", region="eastus", sku="Standard_D2s_v3", pricing_model=PRICING_SPOT)
+
+        AzurePricingProvider(regions=["eastus"], client=client, cache=cache).refresh()
+        clock["now"] += 10 * day
+        items[2]["retailPrice"] = 0.029
+        AzurePricingProvider(regions=["eastus"], client=client, cache=cache).refresh()
+        clock["now"] += 10 * day
+
+        price = AzurePricingProvider(regions=["eastus"], cache=cache, spot_window_days=30).get_price(query)
+
+        assert price.price == pytest.approx(0.024)
+        assert price.pricing_model == PRICING_SPOT
+        assert price.averaged_over_days == pytest.approx(20)
+
+    def test_spot_storage_not_priced(self, items):
+        """Test spot lookups for non-compute services"""
+        provider = AzurePricingProvider(regions=["eastus"], client=self.FakeClient(items))
+        provider.refresh()
+
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(
+                provider="azure", region="eastus", sku="P10 LRS",
+                service=SERVICE_BLOCK_STORAGE, pricing_model=PRICING_SPOT))
+
+    def test_reservation_prices(self, items):
+        """Test reservations are paid upfront or spread over the term"""
+        provider = AzurePricingProvider(regions=["eastus"], client=self.FakeClient(items))
+        provider.refresh()
+
+        def query(payment_option):
+            return PriceQuery(
+                provider="azure", region="eastus", sku="Standard_D2s_v3", pricing_model=PRICING_RESERVED,
+                attributes={"term": "1yr", "payment_option": payment_option},
+            )
+
+        monthly = provider.get_price(query("no_upfront"))
+        upfront = provider.get_price(query("all_upfront"))
+
+        assert monthly.price == pytest.approx(504.0 / 8760)
+        assert monthly.upfront == 0
+        assert upfront.price == 0
+        assert upfront.upfront == pytest.approx(504.0)
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(query("partial_upfront"))
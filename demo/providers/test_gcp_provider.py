@@ -0,0 +1,230 @@
+"""Unit tests for GCP pricing provider"""
+
+import time
+
+import pytest
+
+from src.estimator import CostEstimator, EstimateRequest, ResourceSpec
+from src.pricing import (
+    PriceNotFoundError,
+    PriceQuery,
+    ProviderRegistry,
+    PRICING_RESERVED,
+    PRICING_SPOT,
+    SERVICE_ACCELERATOR,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_OBJECT_STORAGE,
+)
+from src.providers.gcp import (
+    GCPPricingProvider,
+    machine_shape,
+    parse_skus,
+    sustained_use_discount,
+)
+
+
+def _sku(description, family, group, unit, nanos, regions=("us-central1",), usage="OnDemand"):
+    """Build a Cloud Billing Catalog SKU"""
+    return {
+        "description": description,
+        "category": {"resourceFamily": family, "resourceGroup": group, "usageType": usage},
+        "serviceRegions": list(regions),
+        "pricingInfo": [{"pricingExpression": {
+            "usageUnit": unit,
+            "tieredRates": [{"startUsageAmount": 0, "unitPrice": {"units": "0", "nanos": nanos}}],
+        }}],
+    }
+
+
+@pytest.fixture
+def skus():
+    """Compute, disk and storage SKUs"""
+    return [
+        _sku("N2 Instance Core running in Americas", "Compute", "N2Standard", "h", 31611000),
+        _sku("N2 Instance Ram running in Americas", "Compute", "N2Standard", "GiBy.h", 4237000),
+        _sku("N2 Instance Core running in Americas", "Compute", "N2Standard", "h", 7650000, usage="Preemptible"),
+        _sku("Spot Preemptible N2 Instance Ram running in Americas", "Compute", "N2Standard", "GiBy.h", 1025000, usage="Preemptible"),
+        _sku("Commitment v1: N2 Cpu in Americas for 1 Year", "Compute", "CPU", "h", 19915000, usage="Commit1Yr"),
+        _sku("Commitment v1: N2 Ram in Americas for 1 Year", "Compute", "RAM", "GiBy.h", 2669000, usage="Commit1Yr"),
+        _sku("Commitment v1: GPU in Americas for 1 Year", "Compute", "GPU", "h", 1000000000, usage="Commit1Yr"),
+        _sku("E2 Instance Core running in Americas", "Compute", "CPU", "h", 21811590),
+        _sku("E2 Instance Ram running in Americas", "Compute", "RAM", "GiBy.h", 2923240),
+        _sku("Custom Instance Core running in Americas", "Compute", "N1Standard", "h", 33174000),
+        _sku("Nvidia Tesla T4 GPU running in Americas", "Compute", "GPU", "h", 350000000),
+        _sku("Spot Preemptible Nvidia Tesla T4 GPU running in Americas", "Compute", "GPU", "h", 140000000,
+             usage="Preemptible"),
+        _sku("SSD backed PD Capacity", "Storage", "SSD", "GiBy.mo", 170000000),
+        _sku("Regional SSD backed PD Capacity", "Storage", "SSD", "GiBy.mo", 340000000),
+        _sku("Standard Storage US Regional", "Storage", "RegionalStorage", "GiBy.mo", 20000000),
+        _sku("N2 Instance Core running in Tokyo", "Compute", "N2Standard", "h", 40690000, regions=("asia-northeast1",)),
+    ]
+
+
+class TestMachineTypes:
+    """Test cases for machine type shapes and sustained use"""
+
+    def test_predefined_shapes(self):
+        """Test vCPU and memory of predefined types"""
+        assert machine_shape("n2-standard-4") == ("n2", 4.0, 16.0)
+        assert machine_shape("n1-highmem-2") == ("n1", 2.0, 13.0)
+        assert machine_shape("e2-medium") == ("e2", 1.0, 4.0)
+
+    def test_unknown_shape(self):
+        """Test error for unknown machine types"""
+        with pytest.raises(ValueError):
+            machine_shape("z9-standard-4")
+
+    def test_sustained_use_full_month(self):
+        """Test maximum discounts for a full month"""
+        assert sustained_use_discount("n1", 1.0) == pytest.approx(0.30)
+        assert sustained_use_discount("n2", 1.0) == pytest.approx(0.20, abs=0.005)
+
+    def test_sustained_use_partial_month(self):
+        """Test no discount below 25% usage and none for E2"""
+        assert sustained_use_discount("n1", 0.25) == pytest.approx(0.0)
+        assert sustained_use_discount("n1", 0.5) == pytest.approx(0.1)
+        assert sustained_use_discount("e2", 1.0) == 0.0
+
+
+class TestGCPPricingProvider:
+    """Test cases for GCPPricingProvider class"""
+
+    @pytest.fixture
+    def provider(self, skus):
+        """Create provider preloaded from parsed SKUs"""
+        provider = GCPPricingProvider(regions=["us-central1"])
+        provider._merge({"entries": parse_skus(skus, ["us-central1"])})
+        return provider
+
+    def test_parse_filters(self, skus):
+        """Test custom, regional-PD and other-region SKUs are dropped and spot rates kept apart"""
+        entries = parse_skus(skus, ["us-central1"])
+
+        assert entries["compute|us-central1|n2|core"]["price"] == pytest.approx(0.031611)
+        assert entries["compute|us-central1|n2|spot/core"]["price"] == pytest.approx(0.00765)
+        assert "compute|us-central1|n1|core" not in entries
+        assert entries["block_storage|us-central1|pd-ssd|"]["price"] == pytest.approx(0.17)
+        assert "compute|asia-northeast1|n2|core" not in entries
+
+    def test_machine_price(self, provider):
+        """Test machine price from vCPU and RAM rates"""
+        price = provider.get_price(PriceQuery(provider="gcp", region="us-central1", sku="n2-standard-4"))
+
+        assert price.price == pytest.approx(4 * 0.031611 + 16 * 0.004237)
+        assert price.unit == "hour"
+
+    def test_instance_types(self, provider):
+        """Test the predefined machine types of families with vCPU and RAM rates are listed"""
+        instance_types = provider.instance_types("gcp")["us-central1"]
+
+        assert {"n2-standard-4", "n2-highmem-8", "n2-highcpu-96"} <= instance_types
+        assert not any(name.startswith("n1-") for name in instance_types)
+
+    def test_storage_prices(self, provider):
+        """Test disk and object storage prices"""
+        disk = provider.get_price(PriceQuery(
+            provider="gcp", region="us-central1", sku="pd-ssd", service=SERVICE_BLOCK_STORAGE))
+        gcs = provider.get_price(PriceQuery(
+            provider="gcp", region="us-central1", sku="STANDARD", service=SERVICE_OBJECT_STORAGE))
+
+        assert disk.unit == "GB-month"
+        assert gcs.price == pytest.approx(0.02)
+
+    def test_tiered_rates(self):
+        """Test SKUs priced in several bands keep their tiers, the price being the first priced band's"""
+        egress = _sku("Network Internet Egress from Americas to Americas", "Storage", "RegionalStorage",
+                      "GiBy.mo", 20000000)
+        egress["pricingInfo"][0]["pricingExpression"]["tieredRates"] = [
+            {"startUsageAmount": 0, "unitPrice": {"units": "0", "nanos": 0}},
+            {"startUsageAmount": 5, "unitPrice": {"units": "0", "nanos": 20000000}},
+            {"startUsageAmount": 1024, "unitPrice": {"units": "0", "nanos": 10000000}},
+        ]
+        provider = GCPPricingProvider(regions=["us-central1"])
+        provider._merge({"entries": parse_skus([egress], ["us-central1"])})
+
+        price = provider.get_price(PriceQuery(
+            provider="gcp", region="us-central1", sku="STANDARD", service=SERVICE_OBJECT_STORAGE))
+
+        assert price.price == pytest.approx(0.02)
+        assert [(t.begin, t.end, t.price) for t in price.tiers] == [
+            (0, 5, 0.0), (5, 1024, pytest.approx(0.02)), (1024, None, pytest.approx(0.01))
+        ]
+
+    def test_accelerator_prices(self, provider):
+        """Test GPU SKUs are priced per attached GPU, on-demand and spot"""
+        query = PriceQuery(provider="gcp", region="us-central1", sku="nvidia-tesla-t4", service=SERVICE_ACCELERATOR)
+
+        assert provider.get_price(query).price == pytest.approx(0.35)
+        assert provider.get_price(query.copy(update={"pricing_model": PRICING_SPOT})).price == pytest.approx(0.14)
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(query.copy(update={"sku": "nvidia-tesla-v100"}))
+
+    def test_unknown_machine_type(self, provider):
+        """Test lookups for unsupported machine types"""
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(provider="gcp", region="us-central1", sku="n1-standard-2"))
+
+    def test_sustained_use_in_estimate(self, provider):
+        """Test sustained use discounts appear in estimate output"""
+        registry = ProviderRegistry()
+        registry.register(provider)
+        estimator = CostEstimator(registry=registry)
+
+        result = estimator.estimate(EstimateRequest(resources=[
+            ResourceSpec(provider="gcp", instance_type="n2-standard-4", region="us-central1"),
+            ResourceSpec(provider="gcp", instance_type="e2-standard-2", region="us-central1"),
+        ]))
+
+        n2, e2 = result.line_items
+        gross = n2.hourly_cost * 730
+        assert n2.discounts[0].name == "gcp-sustained-use"
+        assert n2.monthly_cost == pytest.approx(gross * (1 - n2.discounts[0].rate), rel=1e-3)
+        assert e2.discounts == []
+
+    def test_spot_machine_price(self, provider):
+        """Test spot prices use spot rates and get no sustained use discount"""
+        price = provider.get_price(PriceQuery(
+            provider="gcp", region="us-central1", sku="n2-standard-4", pricing_model=PRICING_SPOT))
+
+        assert price.price == pytest.approx(4 * 0.00765 + 16 * 0.001025)
+        assert price.averaged_over_days is None
+        assert provider.usage_discount(price, 730) is None
+
+    def test_committed_use_price(self, provider):
+        """Test 1 year commitments are priced from commitment rates without sustained use"""
+        query = PriceQuery(
+            provider="gcp", region="us-central1", sku="n2-standard-4", pricing_model=PRICING_RESERVED,
+            attributes={"term": "1yr", "payment_option": "no_upfront"},
+        )
+
+        price = provider.get_price(query)
+
+        assert price.price == pytest.approx(4 * 0.019915 + 16 * 0.002669)
+        assert price.upfront == 0
+        assert provider.usage_discount(price, 730) is None
+        with pytest.raises(PriceNotFoundError, match="reserved/3yr"):
+            provider.get_price(query.copy(update={"attributes": {"term": "3yr", "payment_option": "no_upfront"}}))
+
+    def test_spot_price_from_history(self, provider, monkeypatch):
+        """Test recorded spot history is averaged over the window"""
+        now = 1_700_000_000.0
+        monkeypatch.setattr(time, "time", lambda: now)
+        provider.spot_window_days = 10
+        provider._merge({"entries": {}, "spot_history": {
+            "compute|us-central1|n2|spot/core": [[now - 20 * 86400, 0.01], [now - 5 * 86400, 0.02]],
+        }})
+
+        price = provider.get_price(PriceQuery(
+            provider="gcp", region="us-central1", sku="n2-standard-2", pricing_model=PRICING_SPOT))
+
+        # Core averages 0.015 over the 10 day window; RAM has no history yet
+        assert price.price == pytest.approx(2 * 0.015 + 8 * 0.001025)
+        assert price.averaged_over_days is None
+
+    def test_refresh_without_client(self):
+        """Test refresh is skipped without an API key"""
+        provider = GCPPricingProvider(regions=["us-central1"])
+
+        provider.refresh()
+
+        assert not provider.loaded
@@ -0,0 +1,145 @@
+"""Unit tests for NCP pricing provider"""
+
+import base64
+import hashlib
+import hmac
+
+import pytest
+
+from src.pricing import (
+    PriceNotFoundError,
+    PriceQuery,
+    ProviderRegistry,
+    PRICING_SPOT,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_OBJECT_STORAGE,
+)
+from src.pricing.instance_types import get_instance_shape
+from src.providers.cache import CatalogCache
+from src.providers.ncp import NcpPricingProvider, parse_products
+from src.providers.ncp.client import signature
+
+
+def _product(kind, price, unit, region="KR", **fields):
+    """Build a Billing API product with one price"""
+    return {
+        "productItemKind": {"code": kind},
+        "priceList": [{
+            "region": {"regionCode": region}, "unit": {"code": unit},
+            "price": price, "payCurrency": {"code": "KRW"},
+        }],
+        **fields,
+    }
+
+
+@pytest.fixture
+def products():
+    """Server, disk and object storage products including ones that must be skipped"""
+    return [
+        _product("VSVR", 97.0, "HOUR", serverSpecCode="s2-g3", productCode="SVR.VSVR.STAND.C002.M008.G003"),
+        _product("VSVR", 84.0, "HOUR", productCode="SVR.VSVR.STAND.C002.M008.NET.SSD.B050.G002"),
+        _product("BST", 100.0, "GB", diskDetailType={"code": "SSD"}),
+        _product("BST", 80.0, "GB", region="JPN", diskDetailType={"code": "HDD"}),
+        _product("OBSTR", 28.0, "GB"),
+        _product("LB", 22.0, "HOUR"),
+        _product("VSVR", 70000.0, "MONTH", serverSpecCode="c2-g3"),
+    ]
+
+
+class FakeClient:
+    """BillingApiClient stand-in returning products by category"""
+
+    def __init__(self, products):
+        self.products = products
+        self.calls = []
+
+    def product_prices(self, region, category, currency="KRW"):
+        self.calls.append((region, category))
+        return self.products if category == "COMPUTE" else []
+
+
+class TestNcpParser:
+    """Test cases for product price parsing"""
+
+    def test_parse_products(self, products):
+        """Test servers, disks and object storage are kept by SKU in their listed currency"""
+        entries = parse_products(products)
+
+        assert set(entries) == {
+            "compute|KR|s2-g3",
+            "compute|KR|SVR.VSVR.STAND.C002.M008.NET.SSD.B050.G002",
+            "block_storage|KR|SSD",
+            "block_storage|JPN|HDD",
+            "object_storage|KR|STANDARD",
+        }
+        assert entries["compute|KR|s2-g3"] == {"price": 97.0, "unit": "hour", "currency": "KRW"}
+        assert entries["block_storage|KR|SSD"]["unit"] == "GB-month"
+
+    def test_signature(self):
+        """Test requests are signed over method, URI, timestamp and access key"""
+        expected = base64.b64encode(hmac.new(
+            b"secret", b"GET /billing/v1/x?a=1\n1700000000000\nak", hashlib.sha256,
+        ).digest()).decode()
+
+        assert signature("secret", "GET", "/billing/v1/x?a=1", "1700000000000", "ak") == expected
+
+    def test_server_shapes(self):
+        """Test vCPUs and memory are read from spec and product codes"""
+        assert get_instance_shape("ncp", "s2-g3")[:2] == (2, 8)
+        assert get_instance_shape("ncp", "m4-g3")[:2] == (4, 32)
+        assert get_instance_shape("ncp", "SVR.VSVR.HICPU.C004.M008.NET.SSD.B050.G002")[:2] == (4, 8)
+        with pytest.raises(KeyError):
+            get_instance_shape("ncp", "x2-large")
+
+
+class TestNcpProvider:
+    """Test cases for the NCP provider"""
+
+    def test_prices_in_krw(self, products, tmp_path):
+        """Test refreshed prices are served in KRW and cached"""
+        provider = NcpPricingProvider(
+            regions=["KR"], client=FakeClient(products), cache=CatalogCache(str(tmp_path), ttl_seconds=3600),
+        )
+        provider.refresh()
+
+        price = provider.get_price(PriceQuery(provider="ncp", region="KR", sku="s2-g3"))
+        assert (price.price, price.currency, price.unit) == (97.0, "KRW", "hour")
+        disk = provider.get_price(PriceQuery(provider="ncp", region="KR", sku="SSD", service=SERVICE_BLOCK_STORAGE))
+        assert disk.price == 100.0
+
+        cached = NcpPricingProvider(regions=["KR"], cache=CatalogCache(str(tmp_path), ttl_seconds=3600))
+        assert cached.get_price(PriceQuery(
+            provider="ncp", region="KR", sku="STANDARD", service=SERVICE_OBJECT_STORAGE,
+        )).price == 28.0
+
+    def test_rejections(self, products):
+        """Test spot queries, unknown SKUs and an unloaded catalog are not priced"""
+        with pytest.raises(PriceNotFoundError):
+            NcpPricingProvider(regions=["KR"]).get_price(PriceQuery(provider="ncp", region="KR", sku="s2-g3"))
+
+        provider = NcpPricingProvider(regions=["KR"], client=FakeClient(products))
+        provider.refresh()
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(provider="ncp", region="KR", sku="s2-g3", pricing_model=PRICING_SPOT))
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(provider="ncp", region="KR", sku="c2-g3"))
+
+    def test_refresh_without_keys(self):
+        """Test refresh is skipped without an API access key"""
+        provider = NcpPricingProvider(regions=["KR"])
+        provider.refresh()
+
+        assert not provider.loaded
+
+    def test_converted_by_registry(self, products):
+        """Test KRW prices are priced in USD through the registry"""
+        provider = NcpPricingProvider(regions=["KR"], client=FakeClient(products))
+        provider.refresh()
+        registry = ProviderRegistry()
+        registry.register(provider)
+        registry.set_exchange_rates(lambda currency: 1380.0)
+
+        price = registry.get_price(PriceQuery(provider="ncp", region="KR", sku="s2-g3"))
+
+        assert price.currency == "USD"
+        assert price.price == pytest.approx(97.0 / 1380.0)
@@ -0,0 +1,120 @@
+"""Unit tests for OpenStack pricing provider"""
+
+import pytest
+
+from src.estimator import CostEstimator, EstimateRequest
+from src.k8s.storage import volume_type_for
+from src.pricing import (
+    PriceNotFoundError,
+    PriceQuery,
+    ProviderRegistry,
+    PRICING_SPOT,
+    SERVICE_BLOCK_STORAGE,
+)
+from src.pricing.instance_types import get_instance_shape
+from src.providers.cache import CatalogCache
+from src.providers.openstack import Flavor, OpenStackPricingProvider, PrivateCloudRates
+from src.terraform.mappers import get_mapper
+
+
+@pytest.fixture
+def rates():
+    """Rate card with a resource-priced and a fixed-price flavor"""
+    return PrivateCloudRates(
+        vcpu_hourly=0.02,
+        memory_gb_hourly=0.005,
+        storage_gb_monthly=0.073,
+        regions=["RegionOne"],
+        flavors={
+            "m1.medium": Flavor(vcpus=2, ram_mb=4096, disk_gb=40, ephemeral_gb=10),
+            "g1.v100": Flavor(vcpus=8, ram_mb=65536, hourly=1.85),
+        },
+        volume_types={"ssd": 0.09},
+    )
+
+
+class TestOpenStackProvider:
+    """Test cases for rate card prices"""
+
+    def test_flavor_prices(self, rates):
+        """Test flavors are priced by their resources unless they set an hourly price"""
+        provider = OpenStackPricingProvider(rates)
+
+        medium = provider.get_price(PriceQuery(provider="openstack", region="RegionOne", sku="m1.medium"))
+        assert medium.unit == "hour"
+        assert medium.price == pytest.approx(2 * 0.02 + 4 * 0.005 + 50 * 0.073 / 730)
+        assert provider.get_price(PriceQuery(provider="openstack", region="RegionOne", sku="g1.v100")).price == 1.85
+
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(provider="openstack", region="RegionOne", sku="m1.tiny"))
+
+    def test_volume_types(self, rates):
+        """Test volume types use their own rate or the storage rate"""
+        provider = OpenStackPricingProvider(rates)
+
+        def price(sku):
+            return provider.get_price(PriceQuery(
+                provider="openstack", region="RegionOne", sku=sku, service=SERVICE_BLOCK_STORAGE,
+            ))
+
+        assert price("ssd").price == 0.09
+        assert price("__DEFAULT__").price == 0.073
+        assert price("ssd").unit == "GB-month"
+
+    def test_rejections(self, rates):
+        """Test regions outside the card, spot queries and a missing card are not priced"""
+        provider = OpenStackPricingProvider(rates)
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(provider="openstack", region="RegionTwo", sku="m1.medium"))
+        with pytest.raises(PriceNotFoundError):
+            provider.get_price(PriceQuery(
+                provider="openstack", region="RegionOne", sku="m1.medium", pricing_model=PRICING_SPOT,
+            ))
+        with pytest.raises(PriceNotFoundError):
+            OpenStackPricingProvider().get_price(PriceQuery(provider="openstack", region="RegionOne", sku="m1.medium"))
+
+    def test_rates_set_through_api_persist(self, rates, tmp_path):
+        """Test replaced rates survive a restart until they are reset"""
+        configured = PrivateCloudRates(vcpu_hourly=0.01, memory_gb_hourly=0.001, storage_gb_monthly=0.05)
+        provider = OpenStackPricingProvider(configured, CatalogCache(str(tmp_path), ttl_seconds=3600))
+        assert provider.rates_source == "config"
+
+        provider.set_rates(rates)
+        restarted = OpenStackPricingProvider(configured, CatalogCache(str(tmp_path), ttl_seconds=-1))
+        assert restarted.rates_source == "api"
+        assert restarted.rates.flavors.keys() == {"m1.medium", "g1.v100"}
+
+        restarted.reset_rates()
+        assert restarted.rates == configured
+        assert OpenStackPricingProvider(configured, CatalogCache(str(tmp_path), ttl_seconds=3600)).rates == configured
+
+    def test_flavor_shapes(self, rates):
+        """Test flavors of the card resolve to instance shapes"""
+        OpenStackPricingProvider(rates)
+
+        shape = get_instance_shape("openstack", "m1.medium")
+        assert (shape.vcpus, shape.memory_gb) == (2, 4.0)
+        with pytest.raises(KeyError):
+            get_instance_shape("openstack", "m1.tiny")
+
+    def test_estimate(self, rates):
+        """Test flavors are priced in estimates and Cinder classes and resources map to volume types"""
+        registry = ProviderRegistry()
+        registry.register(OpenStackPricingProvider(rates))
+
+        result = CostEstimator(registry).estimate(EstimateRequest(resources=[
+            {"provider": "openstack", "instance_type": "g1.v100", "region": "RegionOne", "count": 2},
+        ]))
+        assert result.monthly_cost == pytest.approx(2 * 1.85 * 730)
+
+        assert volume_type_for("openstack", "csi-cinder-sc-delete") == "__DEFAULT__"
+        instance = get_mapper("openstack_compute_instance_v2")({
+            "flavor_name": "m1.medium",
+            "block_device": [
+                {"source_type": "image", "destination_type": "volume", "volume_size": 50, "volume_type": "ssd"},
+                {"source_type": "volume", "destination_type": "volume", "volume_size": 100},
+            ],
+        }, "RegionOne")
+        assert [(c.sku, c.size_gb) for c in instance] == [("m1.medium", None), ("ssd", 50.0)]
+        volume = get_mapper("openstack_blockstorage_volume_v3")({"size": 20}, "RegionOne")
+        assert volume[0].sku == "__DEFAULT__"
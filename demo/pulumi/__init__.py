@@ -0,0 +1 @@
+"""Tests for pulumi module"""
@@ -0,0 +1,119 @@
+"""Unit tests for Pulumi preview cost estimation"""
+
+import pytest
+
+from src.pricing import ProviderRegistry, StaticProvider
+from src.pulumi import PulumiEstimateRequest, PulumiEstimator, load_preview, resource_changes, terraform_type
+from src.pulumi.preview import UNKNOWN_VALUE
+from src.terraform import TerraformEstimator
+
+STACK = "urn:pulumi:dev::shop::"
+PROVIDER = f"{STACK}pulumi:providers:aws::west"
+
+
+def step(op, pulumi_type, name, old=None, new=None, provider=None):
+    """A preview step of a custom resource"""
+    urn = f"{STACK}{pulumi_type}::{name}"
+    result = {"op": op, "urn": urn}
+    if old is not None:
+        result["oldState"] = {"urn": urn, "type": pulumi_type, "custom": True, "inputs": old, "outputs": old}
+    if new is not None:
+        result["newState"] = {"urn": urn, "type": pulumi_type, "custom": True, "inputs": new,
+                              "provider": f"{provider}::04da" if provider else ""}
+    return result
+
+
+def make_preview(*steps, config=None):
+    return {"config": config or {}, "steps": list(steps), "changeSummary": {}}
+
+
+class TestPreview:
+    """Test cases for reading previews"""
+
+    def test_types(self):
+        """Test Pulumi types map to the Terraform resources they wrap"""
+        assert terraform_type("aws:ec2/instance:Instance") == "aws_instance"
+        assert terraform_type("aws:rds/instance:Instance") == "aws_db_instance"
+        assert terraform_type("aws:eks/nodeGroup:NodeGroup") == "aws_eks_node_group"
+        assert terraform_type("gcp:compute/disk:Disk") == "google_compute_disk"
+        vm = "azure:compute/linuxVirtualMachine:LinuxVirtualMachine"
+        assert terraform_type(vm) == "azurerm_linux_virtual_machine"
+        assert terraform_type("aws:s3/bucket:Bucket") is None
+        assert terraform_type("my:component:Shop") is None
+
+    def test_changes(self):
+        """Test steps become Terraform-shaped changes with their provider's region"""
+        preview = make_preview(
+            {"op": "create", "urn": PROVIDER, "newState": {
+                "urn": PROVIDER, "type": "pulumi:providers:aws", "custom": True, "inputs": {"region": "us-west-2"},
+            }},
+            step("create", "aws:ec2/instance:Instance", "web", new={
+                "instanceType": "m5.large",
+                "rootBlockDevice": {"volumeSize": 50, "volumeType": "gp3"},
+                "ebsBlockDevices": [{"volumeSize": 10}],
+                "ami": UNKNOWN_VALUE,
+            }, provider=PROVIDER),
+            step("delete", "aws:ebs/volume:Volume", "old", old={"availabilityZone": "us-east-1a", "size": 100}),
+            step("create", "pulumi:pulumi:Stack", "shop-dev", new={}),
+            config={"aws:region": "us-east-1"},
+        )
+
+        web, old = resource_changes(load_preview(preview))
+        assert (web.address, web.type, web.action, web.region) == (
+            "aws:ec2/instance:Instance::web", "aws_instance", "create", "us-west-2")
+        assert web.after == {
+            "instance_type": "m5.large",
+            "root_block_device": [{"volume_size": 50, "volume_type": "gp3"}],
+            "ebs_block_device": [{"volume_size": 10}],
+        }
+        assert (old.type, old.action, old.region, old.before["availability_zone"]) == (
+            "aws_ebs_volume", "delete", "us-east-1", "us-east-1a")
+
+    def test_replacement_merged(self):
+        """Test the steps replacing a resource are one replace change"""
+        instance = "aws:ec2/instance:Instance"
+        preview = make_preview(
+            step("create-replacement", instance, "api", old={"instanceType": "m5.large"},
+                 new={"instanceType": "m5.xlarge"}),
+            step("replace", instance, "api", old={"instanceType": "m5.large"}, new={"instanceType": "m5.xlarge"}),
+            step("delete-replaced", instance, "api", old={"instanceType": "m5.large"}),
+            step("same", instance, "db", old={"instanceType": "m5.large"}, new={"instanceType": "m5.large"}),
+        )
+
+        (api,) = resource_changes(preview)
+        assert (api.action, api.before, api.after) == (
+            "replace", {"instance_type": "m5.large"}, {"instance_type": "m5.xlarge"})
+
+    def test_not_a_preview(self):
+        """Test other documents are rejected"""
+        with pytest.raises(ValueError):
+            load_preview({"resource_changes": []})
+        with pytest.raises(ValueError):
+            load_preview("{not json")
+
+
+class TestPulumiEstimator:
+    """Test cases for PulumiEstimator class"""
+
+    def test_cost_delta(self):
+        """Test previews are priced by the Terraform mappers"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        estimator = PulumiEstimator(TerraformEstimator(registry=registry))
+        preview = make_preview(
+            step("create", "aws:ec2/instance:Instance", "web", new={"instanceType": "m5.large"}),
+            step("update", "aws:ec2/instance:Instance", "api",
+                 old={"instanceType": "m5.large"}, new={"instanceType": "m5.xlarge"}),
+            step("create", "aws:s3/bucket:Bucket", "logs", new={"bucket": "logs"}),
+            config={"aws:region": "us-east-1"},
+        )
+
+        result = estimator.estimate(PulumiEstimateRequest(preview=preview))
+
+        (web,) = result.added
+        assert web.after_monthly_cost == pytest.approx(0.096 * 730)
+        (api,) = result.changed
+        assert api.monthly_delta == pytest.approx((0.192 - 0.096) * 730)
+        (logs,) = result.unpriced
+        assert logs.type == "aws:s3/bucket:Bucket"
+        assert result.monthly_delta == pytest.approx(web.monthly_delta + api.monthly_delta, abs=1e-3)
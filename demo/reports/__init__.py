@@ -0,0 +1 @@
+"""Tests for reports module"""
@@ -0,0 +1,105 @@
+"""Unit tests for estimate accuracy reports"""
+
+from datetime import date, datetime, timezone
+
+import pytest
+
+from src.reports import AccuracyReporter
+from src.store import ActualCostRecord, EstimateRecord
+
+
+def _at(month, day):
+    return datetime(2026, month, day, tzinfo=timezone.utc)
+
+
+def _estimate(store, created_at, monthly_cost, project="web", kind="kubernetes", labels=None, result=None):
+    return store.save_estimate(EstimateRecord(
+        kind=kind,
+        project=project,
+        monthly_cost=monthly_cost,
+        labels=labels or {},
+        result=result or {},
+        created_at=created_at,
+    ))
+
+
+def _spend(store, month, amount, project="web", labels=None, days=2):
+    store.add_actual_costs([
+        ActualCostRecord(
+            usage_date=date(2026, month, day + 1),
+            amount=amount / days,
+            project=project,
+            labels=labels or {},
+            source="aws-cur",
+        )
+        for day in range(days)
+    ])
+
+
+class TestAccuracyReporter:
+    """Test cases for correlating estimates with actual spend"""
+
+    def _reporter(self, store):
+        return AccuracyReporter(store, clock=lambda: _at(7, 10))
+
+    def test_latest_estimate_in_effect(self, store):
+        """Test each month compares the latest estimate made before it ended"""
+        _estimate(store, _at(3, 20), 100.0)
+        newer = _estimate(store, _at(5, 15), 200.0)
+        _spend(store, 4, 125.0)
+        _spend(store, 5, 160.0)
+        _spend(store, 6, 250.0)
+
+        report = self._reporter(store).report("default")
+
+        assert (report.period_start, report.period_end) == (date(2026, 4, 1), date(2026, 7, 1))
+        assert [(e.month, e.estimated, e.actual) for e in report.entries] == [
+            ("2026-04", 100.0, 125.0), ("2026-05", 200.0, 160.0), ("2026-06", 200.0, 250.0),
+        ]
+        assert report.entries[1].estimate_id == newer.id
+        assert [e.error_percent for e in report.entries] == [-20.0, 25.0, -20.0]
+        [web] = report.groups
+        assert web.mean_absolute_percent_error == pytest.approx(21.67)
+        assert web.bias_percent == pytest.approx((500 - 535) / 535 * 100, abs=0.01)
+
+    def test_unestimated_spend(self, store):
+        """Test spend of groups without an estimate is totalled, not reported as entries"""
+        _estimate(store, _at(6, 1), 50.0)
+        _spend(store, 6, 40.0)
+        _spend(store, 6, 30.0, project="batch")
+        _spend(store, 6, 5.0, project=None)
+
+        report = self._reporter(store).report("default", since="2026-06", until="2026-06")
+
+        assert [e.group for e in report.entries] == ["web"]
+        assert report.unestimated_actual == pytest.approx(30.0)
+
+    def test_terraform_after_cost(self, store):
+        """Test Terraform plans count with their cost after apply, not the delta"""
+        _estimate(store, _at(6, 1), 20.0, kind="terraform", result={"after_monthly_cost": 90.0})
+        _spend(store, 6, 100.0)
+
+        [entry] = self._reporter(store).report("default", since="2026-06", until="2026-06").entries
+
+        assert (entry.estimated, entry.error_percent) == (90.0, -10.0)
+
+    def test_group_by_label(self, store):
+        """Test estimates and spend can be grouped by a label's value"""
+        _estimate(store, _at(6, 1), 80.0, project=None, labels={"team": "data"})
+        _spend(store, 6, 100.0, project="etl", labels={"team": "data"})
+        _spend(store, 6, 60.0, project="etl", labels={"team": "ops"})
+
+        report = self._reporter(store).report("default", since="2026-06", until="2026-06", group_by="label:team")
+
+        assert [(e.group, e.actual) for e in report.entries] == [("data", 100.0)]
+        assert report.unestimated_actual == pytest.approx(60.0)
+
+    def test_invalid_periods(self, store):
+        """Test months that are not over and malformed groupings are rejected"""
+        reporter = self._reporter(store)
+        with pytest.raises(ValueError):
+            reporter.report("default", until="2026-07")
+        with pytest.raises(ValueError):
+            reporter.report("default", since="2026-06", until="2026-05")
+        with pytest.raises(ValueError):
+            reporter.report("default", group_by="team")
@@ -0,0 +1,215 @@
+"""Unit tests for chargeback statements"""
+
+import csv
+import io
+from datetime import date, datetime, timezone
+
+import pytest
+
+from src.allocation import AllocationEngine, SPLIT_EVEN
+from src.discounts import DiscountEngine, DiscountRuleSpec
+from src.reports import ChargebackReporter, ChargebackSchedule, Mailer, render_csv, render_pdf
+from src.store import ActualCostRecord
+
+NOW = datetime(2026, 8, 3, 9, tzinfo=timezone.utc)
+
+
+def _reporter(store, discounts=True):
+    return ChargebackReporter(
+        store,
+        AllocationEngine(store),
+        discounts=DiscountEngine(store) if discounts else None,
+        clock=lambda: NOW,
+    )
+
+
+def _spend(store, amount, team=None, sku="m5.large", quantity=0.0, usage_date=date(2026, 7, 10)):
+    store.add_actual_costs([ActualCostRecord(
+        usage_date=usage_date,
+        provider="aws",
+        service="compute",
+        region="us-east-1",
+        sku=sku,
+        amount=amount,
+        usage_quantity=quantity,
+        usage_unit="hour",
+        labels={"team": team} if team else {},
+        source="aws-cur",
+    )])
+
+
+class TestChargebackReporter:
+    """Test cases for monthly chargeback statements"""
+
+    def test_line_items_and_shared_spend(self, store):
+        """Test spend rolls up per SKU and untagged spend is split between teams"""
+        _spend(store, 30.0, "web")
+        _spend(store, 30.0, "web", usage_date=date(2026, 7, 11))
+        _spend(store, 20.0, "data", sku="r5.large")
+        _spend(store, 40.0)
+        _spend(store, 999.0, "web", usage_date=date(2026, 8, 1))
+
+        report = _reporter(store, discounts=False).report("default", "2026-07", "label:team")
+
+        assert report.complete
+        assert report.total == pytest.approx(120.0)
+        groups = {g.group: g for g in report.groups}
+        assert len(groups["web"].line_items) == 1
+        assert groups["web"].line_items[0].cost == pytest.approx(60.0)
+        assert groups["web"].shared_cost == pytest.approx(30.0)
+        assert groups["data"].total == pytest.approx(30.0)
+        assert [item.cost for item in report.shared_line_items] == [40.0]
+
+    def test_discount_rules(self, store):
+        """Test the tenant's discount rules apply to line items"""
+        store.save_discount_rule(DiscountRuleSpec(
+            name="edp", match={"provider": "aws"}, rate=0.1,
+        ).to_record("default"))
+        _spend(store, 100.0, "web", quantity=1000)
+
+        report = _reporter(store).report("default", "2026-07", "label:team")
+
+        item = report.groups[0].line_items[0]
+        assert item.discount == pytest.approx(10.0)
+        assert item.net_cost == pytest.approx(90.0)
+        assert item.discounts == ["edp"]
+        assert report.discount == pytest.approx(10.0)
+        assert report.total == pytest.approx(90.0)
+
+    def test_running_month_and_split(self, store):
+        """Test the running month is reported to date and the split is configurable"""
+        _spend(store, 10.0, "web", usage_date=date(2026, 8, 1))
+        _spend(store, 90.0, "data", usage_date=date(2026, 8, 1))
+        _spend(store, 20.0, usage_date=date(2026, 8, 2))
+
+        report = _reporter(store).report("default", "2026-08", "label:team", split=SPLIT_EVEN)
+
+        assert not report.complete
+        assert {g.group: g.shared_cost for g in report.groups} == {"data": 10.0, "web": 10.0}
+
+    def test_statement_currency(self, store):
+        """Test line items round to the currency's minor unit and shares add up to the shared spend"""
+        _spend(store, 10.004, "web")
+        _spend(store, 10.0, "data")
+        _spend(store, 10.0, "ops")
+        _spend(store, 10.0)
+
+        report = _reporter(store, discounts=False).report(
+            "default", "2026-07", "label:team", split=SPLIT_EVEN, currency="KRW", rate=1380.5
+        )
+
+        assert report.currency == "KRW"
+        groups = {g.group: g for g in report.groups}
+        assert groups["web"].line_items[0].cost == 13811.0
+        assert sorted(g.shared_cost for g in report.groups) == [4601.0, 4602.0, 4602.0]
+        assert report.total == sum(g.total for g in report.groups) == 55226.0
+
+    def test_invalid_period(self, store):
+        """Test malformed and future months are rejected"""
+        with pytest.raises(ValueError):
+            _reporter(store).report("default", "2026-13", "label:team")
+        with pytest.raises(ValueError):
+            _reporter(store).report("default", "2026-09", "label:team")
+
+    def test_render_csv(self, store):
+        """Test the CSV statement lists line items and shared costs"""
+        _spend(store, 60.0, "web")
+        _spend(store, 40.0)
+
+        rows = list(csv.DictReader(io.StringIO(render_csv(_reporter(store).report("default", "2026-07", "label:team")))))
+
+        assert [(r["group"], r["type"], r["net_cost"]) for r in rows] == [
+            ("web", "direct", "60.0"),
+            ("web", "shared", "40.0"),
+            ("", "shared_source", "40.0"),
+        ]
+
+    def test_render_pdf(self, store):
+        """Test the PDF statement renders"""
+        pytest.importorskip("reportlab")
+        _spend(store, 60.0, "web")
+
+        pdf = render_pdf(_reporter(store).report("default", "2026-07", "label:team"))
+
+        assert pdf.startswith(b"%PDF")
+
+
+class _Mailer:
+    def __init__(self):
+        self.sent = []
+
+    def send(self, recipients, subject, body, attachments=None):
+        self.sent.append((recipients, subject, [name for name, _, _ in attachments or []]))
+
+
+class TestChargebackSchedule:
+    """Test cases for mailing statements"""
+
+    def test_sends_previous_month_once(self, store, monkeypatch):
+        """Test the previous month's statement is mailed once on the mail day"""
+        monkeypatch.setattr("src.reports.mail.render_pdf", lambda report: b"%PDF")
+        mailer = _Mailer()
+        now = [NOW]
+        schedule = ChargebackSchedule(
+            _reporter(store), mailer, "default", ["finops@example.com"], "label:team", day=3, clock=lambda: now[0]
+        )
+
+        assert schedule.send_due() == "2026-07"
+        assert schedule.send_due() is None
+        now[0] = datetime(2026, 8, 4, tzinfo=timezone.utc)
+        assert schedule.send_due() is None
+
+        assert mailer.sent == [(
+            ["finops@example.com"],
+            "Chargeback 2026-07 - default",
+            ["chargeback-default-2026-07.csv", "chargeback-default-2026-07.pdf"],
+        )]
+
+    def test_failed_send_is_retried(self, store, monkeypatch):
+        """Test a failed send is not recorded as sent"""
+        monkeypatch.setattr("src.reports.mail.render_pdf", lambda report: b"%PDF")
+
+        class Failing(_Mailer):
+            def send(self, *args, **kwargs):
+                raise OSError("relay down")
+
+        schedule = ChargebackSchedule(
+            _reporter(store), Failing(), "default", ["finops@example.com"], "label:team", day=3, clock=lambda: NOW
+        )
+
+        assert schedule.send_due() is None
+        assert schedule._sent == set()
+
+    def test_mailer_sends_attachments(self):
+        """Test the mailer logs in over STARTTLS and attaches the files"""
+        calls = []
+
+        class SMTP:
+            def __init__(self, host, port, timeout):
+                calls.append(("connect", host, port))
+
+            def __enter__(self):
+                return self
+
+            def __exit__(self, *exc):
+                return False
+
+            def starttls(self):
+                calls.append(("starttls",))
+
+            def login(self, username, password):
+                calls.append(("login", username))
+
+            def send_message(self, message):
+                calls.append(("send", message["To"], [p.get_filename() for p in message.iter_attachments()]))
+
+        Mailer("smtp.example.com", 587, sender="cost@example.com", username="cost", password="pw", smtp=SMTP).send(
+            ["a@example.com", "b@example.com"], "Chargeback", "body", [("r.csv", b"a,b", "text/csv")]
+        )
+
+        assert calls == [
+            ("connect", "smtp.example.com", 587),
+            ("starttls",),
+            ("login", "cost"),
+            ("send", "a@example.com, b@example.com", ["r.csv"]),
+        ]
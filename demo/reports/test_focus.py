@@ -0,0 +1,139 @@
+"""Unit tests for FOCUS exports"""
+
+import csv
+import io
+import json
+from datetime import date, datetime, timezone
+
+import pytest
+
+from src.reports import FOCUS_COLUMNS, FocusExporter, focus_csv, render_focus
+from src.store import ActualCostRecord, EstimateRecord
+
+NOW = datetime(2026, 8, 3, 9, tzinfo=timezone.utc)
+
+
+@pytest.fixture
+def exporter(store):
+    return FocusExporter(store, clock=lambda: NOW)
+
+
+def _spend(store, amount, usage_date=date(2026, 7, 10), labels=None):
+    store.add_actual_costs([ActualCostRecord(
+        usage_date=usage_date,
+        provider="aws",
+        service="compute",
+        region="us-east-1",
+        account_id="123456789012",
+        sku="m5.large",
+        project="web",
+        amount=amount,
+        usage_quantity=24.0,
+        usage_unit="Hrs",
+        labels=labels or {},
+        source="aws-cur",
+    )])
+
+
+def _estimate(store, result=None, monthly_cost=140.16, created_at=datetime(2026, 7, 20, tzinfo=timezone.utc)):
+    return store.save_estimate(EstimateRecord(
+        kind="resources",
+        project="web",
+        monthly_cost=monthly_cost,
+        result=result or {},
+        labels={"ci": "run-1"},
+        created_at=created_at,
+    ))
+
+
+class TestFocusExporter:
+    """Test cases for FocusExporter class"""
+
+    def test_actual_cost_rows(self, store, exporter):
+        """Test a daily actual cost is charged over its usage day in its billing month"""
+        _spend(store, 2.304, labels={"team": "web"})
+        _spend(store, -1.0)
+
+        usage, credit = exporter.rows("default", "2026-07", dataset="actuals")
+
+        assert usage["ChargeCategory"] == "Usage"
+        assert credit["ChargeCategory"] == "Credit"
+        assert usage["BilledCost"] == usage["EffectiveCost"] == pytest.approx(2.304)
+        assert usage["ListUnitPrice"] == pytest.approx(0.096)
+        assert (usage["ConsumedQuantity"], usage["ConsumedUnit"]) == (24.0, "Hrs")
+        assert usage["ChargePeriodStart"] == datetime(2026, 7, 10, tzinfo=timezone.utc)
+        assert usage["ChargePeriodEnd"] == datetime(2026, 7, 11, tzinfo=timezone.utc)
+        assert usage["BillingPeriodStart"] == datetime(2026, 7, 1, tzinfo=timezone.utc)
+        assert usage["BillingPeriodEnd"] == datetime(2026, 8, 1, tzinfo=timezone.utc)
+        assert (usage["ProviderName"], usage["ServiceCategory"]) == ("AWS", "Compute")
+        assert usage["ServiceName"] == "Amazon Elastic Compute Cloud"
+        assert (usage["SubAccountId"], usage["SkuId"]) == ("123456789012", "m5.large")
+        assert json.loads(usage["Tags"]) == {"team": "web"}
+        assert usage["x_Estimated"] is False
+
+    def test_estimate_rows(self, store, exporter):
+        """Test estimates export a row per line item, or one for the total"""
+        record = _estimate(store, result={
+            "line_items": [{
+                "name": "web", "provider": "aws", "region": "us-east-1", "instance_type": "m5.large",
+                "count": 2, "hours": 730, "pricing_model": "spot", "unit_price_hourly": 0.096, "monthly_cost": 140.16,
+            }],
+            "transfer_items": [{
+                "provider": "aws", "region": "us-east-1", "direction": "internet_egress",
+                "gb_per_month": 100, "monthly_cost": 9.0,
+            }],
+        })
+        _estimate(store, monthly_cost=50.0, created_at=datetime(2026, 7, 21, tzinfo=timezone.utc))
+
+        compute, transfer, total = exporter.rows("default", "2026-07", dataset="estimates")
+
+        assert compute["x_EstimateId"] == record.id
+        assert compute["x_Estimated"] is True
+        assert (compute["SkuId"], compute["PricingCategory"]) == ("m5.large", "Dynamic")
+        assert (compute["ConsumedQuantity"], compute["ConsumedUnit"]) == (1460, "Hours")
+        assert compute["ListUnitPrice"] == 0.096
+        assert compute["ChargePeriodStart"] == datetime(2026, 7, 1, tzinfo=timezone.utc)
+        assert (transfer["ServiceCategory"], transfer["BilledCost"]) == ("Networking", 9.0)
+        assert (total["ServiceCategory"], total["BilledCost"]) == ("Other", 50.0)
+
+    def test_period_and_dataset(self, store, exporter):
+        """Test only the requested months and datasets are exported"""
+        _spend(store, 1.0)
+        _spend(store, 1.0, usage_date=date(2026, 8, 2))
+        _estimate(store)
+
+        assert len(exporter.rows("default")) == 1
+        assert len(exporter.rows("default", "2026-07", "2026-08")) == 3
+        assert exporter.rows("other", "2026-07") == []
+        with pytest.raises(ValueError):
+            exporter.rows("default", dataset="invoices")
+        with pytest.raises(ValueError):
+            exporter.rows("default", "2026-08", "2026-07")
+        with pytest.raises(ValueError):
+            exporter.rows("default", "2024-01", "2026-07")
+
+    def test_csv(self, store, exporter):
+        """Test the CSV has every FOCUS column and UTC timestamps"""
+        _spend(store, 2.304)
+
+        rows = list(csv.DictReader(io.StringIO(focus_csv(exporter.rows("default", "2026-07")))))
+
+        assert set(FOCUS_COLUMNS) <= set(rows[0])
+        assert rows[0]["ChargePeriodStart"] == "2026-07-10T00:00:00Z"
+        assert rows[0]["x_Estimated"] == "false"
+        assert rows[0]["CommitmentDiscountId"] == ""
+
+    def test_parquet(self, store, exporter):
+        """Test the Parquet file reads back with typed columns"""
+        pa = pytest.importorskip("pyarrow")
+        import pyarrow.parquet as pq
+
+        _spend(store, 2.304)
+
+        table = pq.read_table(io.BytesIO(render_focus(exporter.rows("default", "2026-07"), "parquet")))
+
+        assert table.schema.field("BilledCost").type == pa.float64()
+        assert table.schema.field("ChargePeriodStart").type == pa.timestamp("s", tz="UTC")
+        assert table.to_pylist()[0]["SkuId"] == "m5.large"
+        with pytest.raises(ValueError):
+            render_focus([], "xlsx")
@@ -0,0 +1,254 @@
+"""Unit tests for scheduled cost reports"""
+
+import csv
+import io
+from datetime import date, datetime, timezone
+from types import SimpleNamespace
+
+import pytest
+
+from src.budgets import BudgetEvaluator, BudgetSpec
+from src.reports import (
+    CostReportBuilder,
+    Mailer,
+    ReportScheduler,
+    ReportScheduleSpec,
+    is_due,
+    render_report_csv,
+    render_report_html,
+    report_period,
+)
+from src.store import ActualCostRecord
+
+# A Wednesday; the last complete week is 2026-W41 (October 5 to 11)
+NOW = datetime(2026, 10, 14, 9, tzinfo=timezone.utc)
+
+
+def _spend(store, amount, project, service, usage_date):
+    store.add_actual_costs([ActualCostRecord(
+        usage_date=usage_date,
+        provider="aws",
+        service=service,
+        project=project,
+        amount=amount,
+        source="aws-cur",
+    )])
+
+
+@pytest.fixture
+def spend(store):
+    _spend(store, 100.0, "web", "compute", date(2026, 10, 6))
+    _spend(store, 70.0, "web", "compute", date(2026, 9, 29))
+    _spend(store, 20.0, "data", "storage", date(2026, 9, 30))
+    _spend(store, 5.0, None, "network", date(2026, 9, 29))
+    _spend(store, 5.0, None, "network", date(2026, 10, 6))
+    return store
+
+
+class _Commitments:
+    def recommend(self, tenant_id, request):
+        return SimpleNamespace(recommendations=[
+            SimpleNamespace(summary="buy 2 x m5.large reserved 1yr no_upfront, save $40/mo", monthly_savings_cost=40.0),
+        ])
+
+
+class _Idle:
+    def __init__(self, error=None):
+        self.error = error
+
+    def detect(self, tenant_id):
+        if self.error is not None:
+            raise self.error
+        return SimpleNamespace(findings=[
+            SimpleNamespace(name="", resource_id="vol-1", detail="gp3 volume of 500 GiB attached to no instance",
+                            waste_monthly_cost=55.0),
+            SimpleNamespace(name="old-lb", resource_id="lb-1", detail="load balancer without targets",
+                            waste_monthly_cost=None),
+        ])
+
+
+def _builder(store, **kwargs):
+    return CostReportBuilder(store, budgets=BudgetEvaluator(store, clock=lambda: NOW), clock=lambda: NOW, **kwargs)
+
+
+class TestReportPeriods:
+    """Test cases for report periods and when reports are due"""
+
+    def test_periods(self):
+        """Test weekly reports cover the last Monday to Sunday and monthly reports the last month"""
+        assert report_period("weekly", date(2026, 10, 14)) == ("2026-W41", date(2026, 10, 5), date(2026, 10, 12))
+        assert report_period("weekly", date(2026, 10, 12)) == ("2026-W41", date(2026, 10, 5), date(2026, 10, 12))
+        assert report_period("monthly", date(2026, 10, 14)) == ("2026-09", date(2026, 9, 1), date(2026, 10, 1))
+        assert report_period("monthly", date(2027, 1, 3)) == ("2026-12", date(2026, 12, 1), date(2027, 1, 1))
+
+    def test_due(self):
+        """Test a report is due from its send day on until its period is sent"""
+        schedule = ReportScheduleSpec(
+            name="w", frequency="weekly", day=3, recipients=["a@example.com"]
+        ).to_record("default", schedule_id="s1")
+
+        assert not is_due(schedule, date(2026, 10, 13))
+        assert is_due(schedule, date(2026, 10, 14))
+        assert is_due(schedule, date(2026, 10, 18))
+        schedule.last_period = "2026-W41"
+        assert not is_due(schedule, date(2026, 10, 18))
+        schedule.last_period, schedule.enabled = None, False
+        assert not is_due(schedule, date(2026, 10, 14))
+
+    def test_schedule_validation(self):
+        """Test days, recipients and sections are checked"""
+        with pytest.raises(ValueError, match="7 \\(Sunday\\)"):
+            ReportScheduleSpec(name="w", frequency="weekly", day=8, recipients=["a@example.com"])
+        with pytest.raises(ValueError, match="between 1 and 28"):
+            ReportScheduleSpec(name="m", frequency="monthly", day=31, recipients=["a@example.com"])
+        with pytest.raises(ValueError, match="Invalid email"):
+            ReportScheduleSpec(name="m", frequency="monthly", recipients=["finops"])
+        with pytest.raises(ValueError, match="Unknown sections"):
+            ReportScheduleSpec(name="m", frequency="monthly", recipients=["a@example.com"], sections=["forecast"])
+
+        spec = ReportScheduleSpec(
+            name="m", frequency=" Monthly", recipients=["a@example.com", "a@example.com"],
+            sections=["recommendations", "budgets"],
+        )
+        assert spec.frequency == "monthly"
+        assert spec.recipients == ["a@example.com"]
+        assert spec.sections == ["budgets", "recommendations"]
+
+
+class TestCostReportBuilder:
+    """Test cases for the content of cost reports"""
+
+    def test_weekly_report(self, spend):
+        """Test spend, budgets, largest cost changes and recommendations of the last week"""
+        spend.save_budget(BudgetSpec(name="all", amount=1000).to_record("default"))
+        report = _builder(spend, commitments=_Commitments(), idle=_Idle()).build("default", "weekly", name="finops")
+
+        assert (report.period, report.cost, report.previous_cost) == ("2026-W41", 105.0, 95.0)
+        assert [(b.name, b.actual_to_date) for b in report.budgets] == [("all", 105.0)]
+        assert [(c.project, c.service, c.delta, c.delta_percent) for c in report.cost_changes] == [
+            ("web", "compute", 30.0, 42.9),
+            ("data", "storage", -20.0, -100.0),
+        ]
+        assert [(r.kind, r.monthly_savings) for r in report.recommendations] == [
+            ("idle_resource", 55.0), ("commitment", 40.0), ("idle_resource", None),
+        ]
+        assert report.recommendations[0].summary == "Remove vol-1: gp3 volume of 500 GiB attached to no instance"
+
+    def test_sections_and_failing_sources(self, spend):
+        """Test only the requested sections are built and a failing source is left out"""
+        builder = _builder(spend, commitments=_Commitments(), idle=_Idle(OSError("inventory unavailable")), top_items=1)
+        report = builder.build("default", "monthly", ["cost_changes", "recommendations"])
+
+        assert report.period == "2026-09"
+        assert report.sections == ["cost_changes", "recommendations"]
+        assert report.budgets == []
+        assert [(c.project, c.delta_percent) for c in report.cost_changes] == [("web", None)]
+        assert [r.kind for r in report.recommendations] == ["commitment"]
+
+    def test_render(self, spend):
+        """Test the HTML body escapes names and the CSV lists every row"""
+        spend.save_budget(BudgetSpec(name="<all>", amount=1000).to_record("default"))
+        report = _builder(spend, commitments=_Commitments()).build("default", "weekly")
+
+        page = render_report_html(report)
+        assert "Weekly cost report 2026-W41 - default" in page
+        assert "&lt;all&gt;" in page and "<all>" not in page
+        assert "$910" not in page and "$100.00" in page
+
+        rows = list(csv.reader(io.StringIO(render_report_csv(report))))
+        assert rows[0][:3] == ["section", "item", "cost"]
+        assert [row[0] for row in rows[1:]] == ["total", "budgets", "cost_changes", "cost_changes", "recommendations"]
+        assert rows[3][:6] == ["cost_changes", "web/compute", "100.0", "70.0", "30.0", "42.9"]
+
+
+class _Mailer:
+    def __init__(self, error=None):
+        self.error = error
+        self.sent = []
+
+    def send(self, recipients, subject, body, attachments=None, html=None):
+        if self.error is not None:
+            raise self.error
+        self.sent.append((recipients, subject, [name for name, _, _ in attachments or []], html))
+
+
+class TestReportScheduler:
+    """Test cases for mailing scheduled reports"""
+
+    def _schedule(self, store, **fields):
+        fields = {"name": "finops", "frequency": "weekly", "recipients": ["a@example.com"], **fields}
+        spec = ReportScheduleSpec(**fields)
+        return store.save_report_schedule(spec.to_record("default"))
+
+    def test_sends_due_reports_once(self, spend):
+        """Test due reports are mailed once and their period recorded"""
+        weekly = self._schedule(spend)
+        self._schedule(spend, name="monthly", frequency="monthly", day=20)
+        mailer = _Mailer()
+        scheduler = ReportScheduler(spend, _builder(spend), mailer, clock=lambda: NOW)
+
+        assert scheduler.send_due() == [f"{weekly.id}:2026-W41"]
+        assert scheduler.send_due() == []
+        assert spend.get_report_schedule(weekly.id).last_period == "2026-W41"
+
+        recipients, subject, attachments, html = mailer.sent[0]
+        assert (recipients, subject) == (["a@example.com"], "Weekly cost report 2026-W41 - default (finops)")
+        assert attachments == ["cost-report-default-2026-W41.csv"]
+        assert "<h3>Top cost changes</h3>" in html
+
+    def test_failed_send_is_retried(self, spend):
+        """Test a report that could not be sent stays due"""
+        schedule = self._schedule(spend)
+        scheduler = ReportScheduler(spend, _builder(spend), _Mailer(OSError("relay down")), clock=lambda: NOW)
+
+        assert scheduler.send_due() == []
+        assert spend.get_report_schedule(schedule.id).last_period is None
+
+    def test_schedule_store(self, store):
+        """Test schedules are kept per tenant and replacing one keeps its last period"""
+        schedule = self._schedule(store)
+        other = store.save_report_schedule(
+            ReportScheduleSpec(name="other", frequency="monthly", recipients=["b@example.com"]).to_record("acme")
+        )
+        assert store.mark_report_sent(schedule.id, "2026-W41")
+        assert not store.mark_report_sent("missing", "2026-W41")
+
+        replaced = ReportScheduleSpec(name="finops", frequency="monthly", day=2, recipients=["c@example.com"])
+        store.save_report_schedule(replaced.to_record("default", schedule_id=schedule.id))
+
+        loaded = store.get_report_schedule(schedule.id, tenant_id="default")
+        assert (loaded.frequency, loaded.recipients, loaded.last_period) == ("monthly", ["c@example.com"], "2026-W41")
+        assert store.get_report_schedule(other.id, tenant_id="default") is None
+        assert [s.name for s in store.list_report_schedules()] == ["finops", "other"]
+        assert [s.name for s in store.list_report_schedules("acme")] == ["other"]
+        assert not store.delete_report_schedule(other.id, tenant_id="default")
+        assert store.delete_report_schedule(other.id, tenant_id="acme")
+
+    def test_mailer_html_alternative(self):
+        """Test the HTML body is sent as the plain text body's alternative"""
+        messages = []
+
+        class SMTP:
+            def __init__(self, host, port, timeout):
+                pass
+
+            def __enter__(self):
+                return self
+
+            def __exit__(self, *exc):
+                return False
+
+            def starttls(self):
+                pass
+
+            def send_message(self, message):
+                messages.append(message)
+
+        Mailer("smtp.example.com", smtp=SMTP).send(
+            ["a@example.com"], "Report", "plain", [("r.csv", b"a,b", "text/csv")], html="<p>rich</p>"
+        )
+
+        message = messages[0]
+        assert message.get_body(("html",)).get_content().strip() == "<p>rich</p>"
+        assert message.get_body(("plain",)).get_content().strip() == "plain"
+        assert [p.get_filename() for p in message.iter_attachments()] == ["r.csv"]
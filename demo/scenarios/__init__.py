@@ -0,0 +1 @@
+"""Tests for scenarios module"""
@@ -0,0 +1,138 @@
+"""Unit tests for scenarios and scenario comparisons"""
+
+import pytest
+from pydantic import ValidationError
+
+from src.estimator import CostEstimator, EstimateRequest, HOURS_PER_MONTH
+from src.pricing import ProviderRegistry, StaticProvider
+from src.scenarios import BASELINE, Scenario, ScenarioComparer, ScenarioSpec, Variation, apply_variation
+from src.store import SQLiteStore
+
+BASELINE_REQUEST = {
+    "resources": [
+        {"name": "web", "instance_type": "m5.large", "region": "ap-northeast-2", "count": 3},
+        {"instance_type": "r5.large", "region": "ap-northeast-2"},
+    ],
+    "object_storage": [{"region": "ap-northeast-2", "storage_gb": 100, "read_requests": 1_000_000}],
+    "traffic": [{"region": "ap-northeast-2", "inter_az_gb": 100, "internet_egress_gb": 500}],
+}
+
+
+@pytest.fixture
+def estimator():
+    registry = ProviderRegistry()
+    registry.register(StaticProvider())
+    return CostEstimator(registry)
+
+
+def _scenario(*variations):
+    spec = ScenarioSpec(name="web-prod", baseline=EstimateRequest(**BASELINE_REQUEST), variations=list(variations))
+    return Scenario(id="s1", **spec.dict())
+
+
+def _costs(comparison, key):
+    row = next(row for row in comparison.rows if row.key == key)
+    return {cost.scenario: cost.monthly_cost for cost in row.costs}
+
+
+class TestVariations:
+    """Test cases for applying variations to a baseline request"""
+
+    def test_scales_capacity_and_usage(self):
+        """Test counts are rounded with at least one left and usage is scaled"""
+        request = apply_variation(
+            EstimateRequest(**BASELINE_REQUEST),
+            Variation(name="half", capacity_scale=0.5, usage_scale=2, storage_scale=3),
+        )
+
+        assert [r.count for r in request.resources] == [2, 1]
+        assert request.object_storage[0].storage_gb == 300
+        assert request.object_storage[0].read_requests == 2_000_000
+        assert (request.traffic[0].inter_az_gb, request.traffic[0].internet_egress_gb) == (200, 1000)
+
+    def test_pricing_model_and_regions(self):
+        """Test pricing models are set and only mapped regions move"""
+        request = apply_variation(
+            EstimateRequest(**BASELINE_REQUEST),
+            Variation(name="move", pricing_model="spot", regions={"ap-northeast-2": "us-east-1"}, hours=200),
+        )
+
+        assert {(r.pricing_model, r.region, r.hours) for r in request.resources} == {("spot", "us-east-1", 200)}
+        assert request.object_storage[0].region == "us-east-1"
+        assert request.traffic[0].region == "us-east-1"
+
+    def test_validation(self):
+        """Test the baseline name and duplicate variation names are rejected"""
+        with pytest.raises(ValidationError):
+            Variation(name="Baseline")
+        with pytest.raises(ValidationError):
+            _scenario(Variation(name="a"), Variation(name="a"))
+        with pytest.raises(ValidationError):
+            ScenarioSpec(name="empty", baseline=EstimateRequest(**BASELINE_REQUEST), variations=[])
+
+
+class TestScenarioComparer:
+    """Test cases for scenario cost matrices"""
+
+    def test_matrix_of_totals_and_items(self, estimator):
+        """Test every scenario is priced and rows line up with the baseline's items"""
+        comparison = ScenarioComparer(estimator).compare(_scenario(
+            Variation(name="2x traffic", capacity_scale=2, usage_scale=2),
+            Variation(name="us-east-1", regions={"ap-northeast-2": "us-east-1"}),
+        ))
+
+        assert comparison.scenarios == [BASELINE, "2x traffic", "us-east-1"]
+        assert [row.key for row in comparison.rows] == [
+            "resources[0]", "resources[1]", "object_storage[0]", "traffic[0].inter_az", "traffic[0].internet_egress",
+        ]
+        assert [row.label for row in comparison.rows[:2]] == ["web", "aws/ap-northeast-2/r5.large"]
+        assert _costs(comparison, "resources[0]") == {
+            BASELINE: pytest.approx(3 * 0.118 * HOURS_PER_MONTH),
+            "2x traffic": pytest.approx(6 * 0.118 * HOURS_PER_MONTH),
+            "us-east-1": pytest.approx(3 * 0.096 * HOURS_PER_MONTH),
+        }
+
+        base, doubled, moved = comparison.totals
+        assert doubled.monthly_delta == pytest.approx(doubled.monthly_cost - base.monthly_cost, abs=1e-3)
+        assert doubled.delta_percent > 0 > moved.delta_percent
+        assert base.monthly_delta == 0
+        assert comparison.cheapest == "us-east-1"
+        for total in comparison.totals:
+            row_sum = sum(_costs(comparison, row.key)[total.scenario] for row in comparison.rows)
+            assert row_sum == pytest.approx(total.monthly_cost, abs=1e-2)
+
+    def test_unpriced_scenario_is_reported(self, estimator):
+        """Test a scenario without prices reports an error and leaves its cells empty"""
+        comparison = ScenarioComparer(estimator).compare(_scenario(Variation(name="spot", pricing_model="spot")))
+
+        spot = comparison.totals[1]
+        assert spot.monthly_cost is None
+        assert "r5.large" in spot.error
+        assert _costs(comparison, "resources[0]")["spot"] is None
+        assert comparison.cheapest == BASELINE
+
+
+class TestScenarioStore:
+    """Test cases for stored scenarios"""
+
+    def test_round_trip_per_tenant(self, tmp_path):
+        """Test scenarios are saved, replaced and deleted by id within their tenant only"""
+        store = SQLiteStore(str(tmp_path / "scenarios.db"))
+        store.migrate()
+        spec = ScenarioSpec(
+            name="v1", baseline=EstimateRequest(**BASELINE_REQUEST),
+            variations=[Variation(name="spot", pricing_model="spot")],
+        )
+
+        saved = store.save_scenario(spec.to_record("acme"))
+        store.save_scenario(spec.copy(update={"name": "v2"}).to_record("acme", scenario_id=saved.id))
+        store.save_scenario(spec.copy(update={"name": "hijack"}).to_record("globex", scenario_id=saved.id))
+
+        scenario = Scenario.from_record(store.get_scenario(saved.id, tenant_id="acme"))
+        assert scenario.name == "v2"
+        assert scenario.baseline == spec.baseline
+        assert scenario.variations[0].pricing_model == "spot"
+        assert store.get_scenario(saved.id, tenant_id="globex") is None
+        assert [s.name for s in store.list_scenarios("acme")] == ["v2"]
+        assert store.delete_scenario(saved.id, tenant_id="acme") is True
+        assert store.list_scenarios("acme") == []
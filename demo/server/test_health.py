@@ -0,0 +1,108 @@
+"""Unit tests for dependency health checks"""
+
+import asyncio
+
+from src.pricing import PricingProvider, ProviderRegistry, RefreshScheduler
+from src.server import (
+    CheckOutcome,
+    HealthChecker,
+    PROBE_LIVENESS,
+    PROBE_READINESS,
+    STATUS_DEGRADED,
+    STATUS_FAILING,
+    STATUS_OK,
+    ServerLifecycle,
+    catalog_check,
+    jobs_check,
+    store_check,
+)
+from src.store import SQLiteStore
+
+
+class BrokenStore:
+    dialect = "postgresql"
+
+    def schema_version(self):
+        raise ConnectionError("connection refused")
+
+
+class StaticCatalog(PricingProvider):
+    name = "static"
+
+    @property
+    def clouds(self):
+        return ["aws"]
+
+    def get_price(self, query):
+        raise NotImplementedError
+
+
+class TestHealthChecker:
+    """Test cases for HealthChecker class"""
+
+    def test_critical_checks_decide_the_probe(self):
+        checker = HealthChecker()
+        checker.register("store", store_check(BrokenStore()), critical_for=(PROBE_READINESS,))
+        checker.register("power", lambda: CheckOutcome(STATUS_DEGRADED, "Prometheus unavailable"))
+
+        liveness = asyncio.run(checker.run(PROBE_LIVENESS))
+        assert liveness.status == STATUS_DEGRADED
+        assert liveness.checks["store"].status == STATUS_FAILING
+        assert not liveness.checks["store"].critical
+        assert liveness.checks["store"].detail == "connection refused"
+
+        readiness = asyncio.run(checker.run(PROBE_READINESS))
+        assert readiness.status == STATUS_FAILING and readiness.checks["store"].critical
+
+    def test_store_and_timeouts(self):
+        store = SQLiteStore(":memory:")
+        store.migrate()
+
+        async def slow():
+            await asyncio.sleep(1)
+            return CheckOutcome(STATUS_OK)
+
+        checker = HealthChecker(timeout=0.05)
+        checker.register("store", store_check(store), critical_for=(PROBE_READINESS,))
+        checker.register("slow", slow)
+        report = asyncio.run(checker.run(PROBE_READINESS))
+
+        assert report.checks["store"].status == STATUS_OK
+        assert report.checks["store"].info["dialect"] == "sqlite"
+        assert report.checks["slow"].status == STATUS_FAILING
+        assert "timed out" in report.checks["slow"].detail
+        assert report.status == STATUS_DEGRADED
+
+    def test_catalogs(self):
+        registry = ProviderRegistry()
+        registry.register(StaticCatalog())
+        scheduler = RefreshScheduler(registry, max_age=0)
+        scheduler.run_due()
+        outcome = catalog_check(scheduler)()
+        assert outcome.status == STATUS_OK and outcome.info == {"static": "ok"}
+
+    def test_background_jobs(self):
+        class Worker:
+            running = True
+
+        async def scenario():
+            async def crash():
+                raise RuntimeError("store closed")
+
+            running = asyncio.create_task(asyncio.sleep(10))
+            crashed = asyncio.create_task(crash())
+            await asyncio.sleep(0)
+            worker = Worker()
+            jobs = {"scan": running, "billing": None, "webhooks": worker}
+
+            healthy = await jobs_check(lambda: jobs, ServerLifecycle())()
+            jobs["anomalies"] = crashed
+            failing = await jobs_check(lambda: jobs)()
+            running.cancel()
+            return healthy, failing
+
+        healthy, failing = asyncio.run(scenario())
+        assert healthy.status == STATUS_OK
+        assert healthy.info == {"scan": "running", "webhooks": "running", "pending_jobs": 0}
+        assert failing.status == STATUS_FAILING
+        assert failing.info["anomalies"] == "failed: store closed"
@@ -0,0 +1,72 @@
+"""Unit tests for server lifecycle tracking"""
+
+import asyncio
+import threading
+
+from src.server import ServerLifecycle
+
+
+class TestServerLifecycle:
+    """Test cases for request tracking and shutdown draining"""
+
+    def test_track_requests(self):
+        """Test in-flight requests are counted and drained"""
+        lifecycle = ServerLifecycle()
+
+        async def scenario():
+            async def request():
+                async with lifecycle.track():
+                    await asyncio.sleep(0.1)
+
+            task = asyncio.create_task(request())
+            await asyncio.sleep(0.01)
+            assert lifecycle.in_flight == 1
+
+            assert await lifecycle.drain(timeout=2)
+            assert lifecycle.draining and lifecycle.in_flight == 0
+            await task
+
+        asyncio.run(scenario())
+
+    def test_drain_waits_for_background_jobs(self):
+        """Test shutdown waits for a running catalog refresh"""
+        lifecycle = ServerLifecycle()
+        finished = threading.Event()
+
+        def refresh():
+            threading.Event().wait(0.1)
+            finished.set()
+
+        async def scenario():
+            lifecycle.run_in_background(refresh, "refresh")
+            assert lifecycle.pending_jobs == 1
+            assert await lifecycle.drain(timeout=2)
+
+        asyncio.run(scenario())
+        assert finished.is_set()
+
+    def test_drain_timeout(self):
+        """Test draining gives up when a job outlives the timeout"""
+        lifecycle = ServerLifecycle()
+        release = threading.Event()
+
+        async def scenario():
+            lifecycle.run_in_background(lambda: release.wait(5), "slow refresh")
+            drained = await lifecycle.drain(timeout=0.1)
+            release.set()
+            return drained
+
+        assert asyncio.run(scenario()) is False
+
+    def test_failed_job_is_logged(self):
+        """Test a failing job does not block draining"""
+        lifecycle = ServerLifecycle()
+
+        def broken():
+            raise RuntimeError("catalog download failed")
+
+        async def scenario():
+            lifecycle.run_in_background(broken, "refresh")
+            return await lifecycle.drain(timeout=2)
+
+        assert asyncio.run(scenario())
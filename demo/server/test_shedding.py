@@ -0,0 +1,87 @@
+"""Unit tests for load shedding of expensive routes"""
+
+import asyncio
+
+import pytest
+
+from src.server import ConcurrencyLimit, LoadShedder, Overloaded, parse_concurrency_limits
+
+
+class TestConcurrencyLimit:
+    """Test cases for ConcurrencyLimit class"""
+
+    def test_queue_then_shed(self):
+        """Test requests over the limit queue, and are refused once the queue is full"""
+        limit = ConcurrencyLimit("/estimate/cluster", limit=1, max_queue=1, queue_timeout=2)
+        release = asyncio.Event()
+
+        async def request(log, name):
+            async with limit.slot():
+                log.append(name)
+                await release.wait()
+
+        async def scenario():
+            log = []
+            first = asyncio.create_task(request(log, "first"))
+            await asyncio.sleep(0.01)
+            second = asyncio.create_task(request(log, "second"))
+            await asyncio.sleep(0.01)
+            assert (limit.active, limit.queued) == (1, 1)
+
+            with pytest.raises(Overloaded) as shed:
+                async with limit.slot():
+                    pass
+            assert shed.value.route == "/estimate/cluster" and shed.value.retry_after == 4
+
+            release.set()
+            await asyncio.gather(first, second)
+            assert log == ["first", "second"]
+            assert limit.status() == {"limit": 1, "active": 0, "queued": 0}
+
+        asyncio.run(scenario())
+
+    def test_queue_timeout(self):
+        """Test a queued request is refused when no slot frees up in time"""
+        limit = ConcurrencyLimit("/estimate/terraform", limit=1, max_queue=5, queue_timeout=0.05)
+
+        async def scenario():
+            blocker = asyncio.Event()
+
+            async def hold():
+                async with limit.slot():
+                    await blocker.wait()
+
+            task = asyncio.create_task(hold())
+            await asyncio.sleep(0.01)
+            with pytest.raises(Overloaded, match="Too many concurrent /estimate/terraform requests"):
+                async with limit.slot():
+                    pass
+            assert limit.queued == 0
+            blocker.set()
+            await task
+
+            # The freed slot is taken without waiting
+            async with limit.slot():
+                assert limit.active == 1
+
+        asyncio.run(scenario())
+
+
+class TestLoadShedder:
+    """Test cases for route limits"""
+
+    def test_parse_and_match(self):
+        """Test limits are parsed and matched by exact path"""
+        limits = parse_concurrency_limits(["/estimate/cluster=2", " /estimate/terraform/ = 4"])
+        assert limits == {"/estimate/cluster": 2, "/estimate/terraform": 4}
+
+        shedder = LoadShedder(limits, max_queue=10, queue_timeout=5)
+        assert shedder.limit_for("/estimate/terraform/").limit == 4
+        assert shedder.limit_for("/estimate/terraform/resource-types") is None
+        assert shedder.status()["/estimate/cluster"] == {"limit": 2, "active": 0, "queued": 0}
+
+    def test_parse_errors(self):
+        """Test malformed entries and limits below one are refused"""
+        for entries in (["estimate=2"], ["/estimate"], ["/estimate=two"], ["/estimate=0"]):
+            with pytest.raises(ValueError):
+                parse_concurrency_limits(entries)
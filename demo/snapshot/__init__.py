@@ -0,0 +1 @@
+"""Tests for pricing snapshot module"""
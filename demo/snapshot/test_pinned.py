@@ -0,0 +1,101 @@
+"""Unit tests for estimates pinned to catalog snapshots"""
+
+from datetime import date, datetime, timezone
+from types import SimpleNamespace
+
+import pytest
+
+from src.pricing import PriceQuery
+from src.providers.aws.parser import entry_key
+from src.providers.cache import set_catalog_store
+from src.snapshot import PinnedEstimators, build_pinned_registry, check_catalog_version
+from src.store import KIND_RESOURCES, SQLiteStore, record_estimate
+
+KEY = "AmazonEC2-us-east-1"
+SETTINGS = SimpleNamespace(
+    aws_pricing_regions=["us-east-1"],
+    aws_price_list_url="https://pricing.us-east-1.amazonaws.com",
+    aws_pricing_services=["AmazonEC2"],
+    aws_instance_families=[],
+    pricing_cache_ttl=60,
+    spot_price_window_days=30,
+    spot_price_cache_ttl=3600,
+    offline=False,
+)
+
+
+def _catalog(price):
+    return {"entries": {entry_key("compute", "us-east-1", "m5.large"): {"price": price, "unit": "hour"}}}
+
+
+@pytest.fixture
+def store():
+    """Store with an EC2 catalog saved twice on May 1 and again on May 3"""
+    store = SQLiteStore(":memory:")
+    store.migrate()
+    store.save_catalog("aws", KEY, _catalog(0.090), datetime(2026, 5, 1, 6, tzinfo=timezone.utc))
+    store.save_catalog("aws", KEY, _catalog(0.096), datetime(2026, 5, 1, 18, tzinfo=timezone.utc))
+    store.save_catalog("aws", KEY, _catalog(0.100), datetime(2026, 5, 3, 6, tzinfo=timezone.utc))
+    set_catalog_store(store)
+    yield store
+    set_catalog_store(None)
+
+
+def _price(registry):
+    return registry.get_price(PriceQuery(provider="aws", region="us-east-1", sku="m5.large")).price
+
+
+class TestCatalogSnapshots:
+    """Test cases for catalog snapshots kept by the store"""
+
+    def test_last_catalog_of_a_day_is_its_snapshot(self, store):
+        """Test snapshots are looked up as of a day"""
+        assert store.list_catalog_snapshots() == [date(2026, 5, 3), date(2026, 5, 1)]
+        assert store.load_catalog_snapshot("aws", KEY, date(2026, 5, 2)).document == _catalog(0.096)
+        assert store.load_catalog_snapshot("aws", KEY, date(2026, 6, 1)).document == _catalog(0.100)
+        assert store.load_catalog_snapshot("aws", KEY, date(2026, 4, 30)) is None
+        assert store.load_catalog("aws", KEY).document == _catalog(0.100)
+
+    def test_pinned_registry_prices_from_snapshot(self, store):
+        """Test pinned providers read the snapshot and never write catalogs or fetch spot prices"""
+        registry = build_pinned_registry(["aws"], SETTINGS, date(2026, 5, 2))
+
+        assert _price(registry) == 0.096
+        assert registry.get("aws").spot_client is None
+        registry.get("aws").cache.save(KEY, _catalog(1.0))
+        assert store.load_catalog("aws", KEY).document == _catalog(0.100)
+        assert _price(build_pinned_registry(["aws"], SETTINGS, date(2026, 5, 3))) == 0.100
+
+    def test_check_catalog_version(self, store):
+        """Test future days and days before the first snapshot are rejected"""
+        check_catalog_version(store, date(2026, 5, 1), today=date(2026, 5, 10))
+        with pytest.raises(ValueError, match="future"):
+            check_catalog_version(store, date(2026, 5, 11), today=date(2026, 5, 10))
+        with pytest.raises(LookupError, match="2026-04-30"):
+            check_catalog_version(store, date(2026, 4, 30), today=date(2026, 5, 10))
+
+    def test_pinned_estimators_keep_recent_snapshots(self):
+        """Test estimators are built once per day and the least recently used is unloaded"""
+        built = []
+        estimators = PinnedEstimators(lambda day: built.append(day) or object(), max_snapshots=2)
+
+        first = estimators.get(date(2026, 5, 1))
+        estimators.get(date(2026, 5, 2))
+        assert estimators.get(date(2026, 5, 1)) is first
+        estimators.get(date(2026, 5, 3))
+        estimators.get(date(2026, 5, 1))
+        estimators.get(date(2026, 5, 2))
+
+        assert built == [date(2026, 5, 1), date(2026, 5, 2), date(2026, 5, 3), date(2026, 5, 2)]
+
+    def test_estimates_record_versions(self, store):
+        """Test estimates keep the pinned day, or the day they were made, and the pricing rules version"""
+        pinned = record_estimate(
+            store, KIND_RESOURCES, request={}, result={}, monthly_cost=70.08,
+            catalog_version=date(2026, 5, 2), pricing_model_version=1,
+        )
+        current = record_estimate(store, KIND_RESOURCES, request={}, result={}, monthly_cost=73.0)
+
+        loaded = store.get_estimate(pinned.id)
+        assert (loaded.catalog_version, loaded.pricing_model_version) == (date(2026, 5, 2), 1)
+        assert store.get_estimate(current.id).catalog_version == datetime.now(timezone.utc).date()
@@ -0,0 +1,112 @@
+"""Unit tests for pricing snapshot export/import"""
+
+import json
+from datetime import datetime, timezone
+from types import SimpleNamespace
+
+import pytest
+
+from src.pricing import PriceQuery
+from src.providers.aws import AWSPricingProvider
+from src.providers.aws.parser import entry_key
+from src.providers.cache import StoreCatalogCache
+from src.snapshot import SNAPSHOT_FORMAT_VERSION, export_snapshot, import_snapshot, read_snapshot
+from src.snapshot.cli import main
+from src.store import SQLiteStore
+
+FETCHED_AT = datetime(2026, 1, 15, 12, 0, tzinfo=timezone.utc)
+
+
+@pytest.fixture
+def source_store():
+    """Store with downloaded AWS and Azure catalogs"""
+    store = SQLiteStore(":memory:")
+    store.migrate()
+    store.save_catalog("aws", "AmazonEC2-us-east-1", {
+        "version": "20260115",
+        "entries": {entry_key("compute", "us-east-1", "m5.large"): {"price": 0.096, "unit": "hour"}},
+    }, FETCHED_AT)
+    store.save_catalog("azure", "Virtual Machines-eastus", {"entries": {}}, FETCHED_AT)
+    return store
+
+
+def fresh_store():
+    store = SQLiteStore(":memory:")
+    store.migrate()
+    return store
+
+
+class TestSnapshot:
+    """Test cases for snapshot files"""
+
+    @pytest.mark.parametrize("filename", ["snapshot.json", "snapshot.db"])
+    def test_round_trip(self, source_store, tmp_path, filename):
+        """Test JSON and SQLite snapshots keep documents and fetch times"""
+        path = str(tmp_path / filename)
+
+        assert export_snapshot(source_store, path) == 2
+        target = fresh_store()
+        assert import_snapshot(target, path) == 2
+
+        record = target.load_catalog("aws", "AmazonEC2-us-east-1")
+        assert record.version == "20260115"
+        assert record.fetched_at == FETCHED_AT
+        assert record.document == source_store.load_catalog("aws", "AmazonEC2-us-east-1").document
+
+    def test_provider_filter(self, source_store, tmp_path):
+        """Test exporting a subset of providers"""
+        path = str(tmp_path / "aws.json")
+
+        export_snapshot(source_store, path, providers=["aws"])
+
+        assert [r.provider for r in read_snapshot(path)] == ["aws"]
+
+    def test_invalid_snapshot(self, tmp_path):
+        """Test unknown formats are rejected"""
+        path = tmp_path / "bad.json"
+        path.write_text(json.dumps({"format_version": SNAPSHOT_FORMAT_VERSION + 1}))
+
+        with pytest.raises(ValueError, match="format version"):
+            read_snapshot(str(path))
+
+    def test_offline_provider_prices_from_snapshot(self, source_store, tmp_path):
+        """Test a provider serves imported prices without downloading"""
+        path = str(tmp_path / "snapshot.json")
+        export_snapshot(source_store, path)
+        target = fresh_store()
+        import_snapshot(target, path)
+
+        # Snapshot catalogs are older than the TTL, but still used
+        provider = AWSPricingProvider(
+            regions=["us-east-1"],
+            cache=StoreCatalogCache(target, "aws", ttl_seconds=60),
+            service_codes=["AmazonEC2"],
+        )
+
+        price = provider.get_price(PriceQuery(provider="aws", region="us-east-1", sku="m5.large"))
+        assert price.price == 0.096
+
+
+class TestSnapshotCLI:
+    """Test cases for the snapshot command line"""
+
+    def test_export_import_info(self, source_store, tmp_path, capsys):
+        """Test the export, import and info commands"""
+        source_db = str(tmp_path / "source.db")
+        export_snapshot(source_store, source_db)
+        snapshot = str(tmp_path / "snapshot.json")
+
+        assert main(["export", snapshot], settings=SimpleNamespace(store_url=f"sqlite:///{source_db}")) == 0
+
+        target_db = tmp_path / "target.db"
+        assert main(["import", snapshot], settings=SimpleNamespace(store_url=f"sqlite:///{target_db}")) == 0
+        assert len(SQLiteStore(str(target_db)).list_catalogs()) == 2
+
+        assert main(["info", snapshot], settings=SimpleNamespace()) == 0
+        assert "AmazonEC2-us-east-1" in capsys.readouterr().out
+
+    def test_missing_file(self, tmp_path):
+        """Test errors are reported with a non-zero exit code"""
+        settings = SimpleNamespace(store_url=f"sqlite:///{tmp_path / 'store.db'}")
+
+        assert main(["import", str(tmp_path / "missing.json")], settings=settings) == 1
@@ -0,0 +1,43 @@
+"""Unit tests for estimate history recording"""
+
+from src.estimator import EstimateRequest
+from src.store import KIND_RESOURCES, SQLiteStore, record_estimate
+
+
+class TestRecordEstimate:
+    """Test cases for record_estimate"""
+
+    def test_records_with_project_and_labels(self):
+        """Test estimates are saved and retrievable by id"""
+        store = SQLiteStore(":memory:")
+        store.migrate()
+        request = EstimateRequest(
+            resources=[{"instance_type": "m5.large", "region": "us-east-1"}],
+            project="shop",
+            labels={"ci_url": "https://ci.example.com/runs/42"},
+        )
+
+        record = record_estimate(
+            store, KIND_RESOURCES,
+            request=request.dict(exclude={"project", "labels"}),
+            result={"monthly_cost": 70.08},
+            monthly_cost=70.08,
+            project=request.project,
+            labels=request.labels,
+        )
+
+        loaded = store.get_estimate(record.id)
+        assert loaded.project == "shop"
+        assert loaded.labels["ci_url"].endswith("/42")
+        assert "project" not in loaded.request
+        assert [e.id for e in store.list_estimates(project="shop")] == [record.id]
+
+    def test_without_store(self):
+        """Test estimates are not recorded when no store is configured"""
+        assert record_estimate(None, KIND_RESOURCES, {}, {}, 0.0) is None
+
+    def test_store_failure_is_not_raised(self):
+        """Test database errors do not fail the estimate"""
+        store = SQLiteStore(":memory:")  # not migrated: estimates table missing
+
+        assert record_estimate(store, KIND_RESOURCES, {}, {}, 0.0) is None
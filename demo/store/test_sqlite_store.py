@@ -0,0 +1,184 @@
+"""Unit tests for the SQLite store"""
+
+import time
+from datetime import datetime, timedelta, timezone
+
+import pytest
+
+from src.providers.cache import StoreCatalogCache
+from src.store import (
+    EstimateRecord,
+    JobRecord,
+    SQLiteStore,
+    StoreError,
+    JOB_QUEUED,
+    JOB_RUNNING,
+    JOB_SUCCEEDED,
+    latest_version,
+    open_store,
+)
+
+
+class TestMigrations:
+    """Test cases for schema migrations"""
+
+    def test_migrate_is_idempotent(self, tmp_path):
+        """Test migrations apply once and survive reopening"""
+        path = str(tmp_path / "store.db")
+        store = SQLiteStore(path)
+
+        assert store.schema_version() == 0
+        assert store.migrate() == [m for m in range(1, latest_version() + 1)]
+        assert store.migrate() == []
+        store.close()
+
+        reopened = SQLiteStore(path)
+        assert reopened.schema_version() == latest_version()
+        reopened.close()
+
+    def test_unmigrated_store_errors(self):
+        """Test driver errors are wrapped"""
+        store = SQLiteStore(":memory:")
+
+        with pytest.raises(StoreError):
+            store.get_estimate("missing")
+
+
+class TestSQLiteStore:
+    """Test cases for catalog and estimate persistence"""
+
+    @pytest.fixture
+    def store(self):
+        """Migrated in-memory store"""
+        store = SQLiteStore(":memory:")
+        store.migrate()
+        return store
+
+    def test_catalog_upsert(self, store):
+        """Test catalogs are replaced per provider and key"""
+        store.save_catalog("aws", "AmazonEC2-us-east-1", {"version": "v1", "entries": {"a": 1}})
+        store.save_catalog("aws", "AmazonEC2-us-east-1", {"version": "v2", "entries": {"a": 2}})
+        store.save_catalog("azure", "eastus", {"entries": {}})
+
+        record = store.load_catalog("aws", "AmazonEC2-us-east-1")
+        assert record.version == "v2"
+        assert record.document["entries"] == {"a": 2}
+        assert record.fetched_at.tzinfo is not None
+        assert [c.provider for c in store.list_catalogs()] == ["aws", "azure"]
+        assert len(store.list_catalogs("azure")) == 1
+        assert store.load_catalog("gcp", "x") is None
+
+    def test_save_and_get_estimate(self, store):
+        """Test ids and timestamps are assigned on save"""
+        saved = store.save_estimate(EstimateRecord(
+            kind="resources",
+            project="shop",
+            request={"resources": [{"instance_type": "m5.large"}]},
+            result={"monthly_cost": 70.08},
+            monthly_cost=70.08,
+            labels={"ci_run": "https://ci.example.com/runs/1"},
+        ))
+
+        assert saved.id and saved.created_at
+        loaded = store.get_estimate(saved.id)
+        assert loaded == saved
+        assert store.get_estimate("missing") is None
+
+    def test_list_estimates_filters(self, store):
+        """Test project and time range filters, newest first"""
+        base = datetime(2026, 1, 1, tzinfo=timezone.utc)
+        for day, project in enumerate(["shop", "shop", "blog", "shop"]):
+            store.save_estimate(EstimateRecord(
+                id=f"e{day}", kind="resources", project=project, created_at=base + timedelta(days=day),
+            ))
+
+        assert [e.id for e in store.list_estimates(project="shop")] == ["e3", "e1", "e0"]
+        assert [e.id for e in store.list_estimates(
+            since=base + timedelta(days=1), until=base + timedelta(days=3))] == ["e2", "e1"]
+        # Naive datetimes are treated as UTC
+        assert [e.id for e in store.list_estimates(since=datetime(2026, 1, 3))] == ["e3", "e2"]
+        assert len(store.list_estimates(limit=2)) == 2
+
+    def test_claim_and_update_job(self, store):
+        """Test a job is claimed once and only its worker writes it while it runs"""
+        job = store.create_job(JobRecord(tenant_id="acme", kind="terraform", request={"plan": {}}))
+        assert job.id and job.state == JOB_QUEUED
+        assert store.get_job(job.id, tenant_id="other") is None
+
+        now = datetime.now(timezone.utc)
+        claimed = store.claim_job(job.id, "replica-a", stale_before=now - timedelta(minutes=5))
+        assert (claimed.state, claimed.worker, claimed.attempts) == (JOB_RUNNING, "replica-a", 1)
+        assert claimed.started_at is not None
+        # Running with a fresh heartbeat: not claimable
+        assert store.claim_job(job.id, "replica-b", stale_before=now - timedelta(minutes=5)) is None
+
+        assert store.update_job(claimed.copy(update={"progress": 0.5}))
+        assert not store.update_job(claimed.copy(update={"worker": "replica-b", "progress": 0.9}))
+        done = claimed.copy(update={"state": JOB_SUCCEEDED, "result": {"monthly_delta": 12.5}, "progress": 1.0})
+        assert store.update_job(done)
+        # Finished jobs are no longer written
+        assert not store.update_job(claimed)
+
+        loaded = store.get_job(job.id, tenant_id="acme")
+        assert (loaded.state, loaded.progress, loaded.result) == (JOB_SUCCEEDED, 1.0, {"monthly_delta": 12.5})
+
+    def test_stale_job_is_reclaimed(self, store):
+        """Test a running job whose heartbeat is older than the lease is claimed again"""
+        job = store.create_job(JobRecord(kind="cluster"))
+        store.claim_job(job.id, "replica-a", stale_before=datetime.now(timezone.utc))
+
+        future = datetime.now(timezone.utc) + timedelta(seconds=1)
+        claimed = store.claim_job(job.id, "replica-b", stale_before=future)
+        assert (claimed.worker, claimed.attempts) == ("replica-b", 2)
+        assert not store.update_job(claimed.copy(update={"worker": "replica-a"}))
+
+    def test_list_jobs(self, store):
+        """Test jobs are listed newest first, by tenant and state"""
+        first = store.create_job(JobRecord(tenant_id="acme", kind="batch"))
+        time.sleep(0.001)
+        second = store.create_job(JobRecord(tenant_id="acme", kind="batch"))
+        store.create_job(JobRecord(tenant_id="other", kind="batch"))
+        store.claim_job(first.id, "replica-a", stale_before=datetime.now(timezone.utc))
+
+        assert [j.id for j in store.list_jobs(tenant_id="acme")] == [second.id, first.id]
+        assert [j.id for j in store.list_jobs(tenant_id="acme", states=[JOB_QUEUED])] == [second.id]
+        assert len(store.list_jobs(states=[JOB_QUEUED, JOB_RUNNING])) == 3
+
+
+class TestOpenStore:
+    """Test cases for store URLs"""
+
+    def test_sqlite_urls(self, tmp_path):
+        """Test absolute and in-memory SQLite URLs"""
+        path = tmp_path / "sub" / "kcloud.db"
+        store = open_store(f"sqlite:///{path}")
+        store.migrate()
+        assert path.exists()
+        store.close()
+
+        assert isinstance(open_store("sqlite://:memory:"), SQLiteStore)
+
+    def test_unsupported_scheme(self):
+        """Test unknown schemes are rejected"""
+        with pytest.raises(ValueError, match="mysql"):
+            open_store("mysql://localhost/db")
+
+
+class TestStoreCatalogCache:
+    """Test cases for store-backed provider catalog cache"""
+
+    def test_ttl(self):
+        """Test freshness follows the stored fetch time"""
+        store = SQLiteStore(":memory:")
+        store.migrate()
+        cache = StoreCatalogCache(store, "aws", ttl_seconds=60)
+
+        cache.save("AmazonEC2-us-east-1", {"version": "v1", "entries": {}})
+        document = cache.load("AmazonEC2-us-east-1")
+        assert document["version"] == "v1"
+        assert document["fetched_at"] == pytest.approx(time.time(), abs=5)
+
+        cache.ttl_seconds = -1
+        assert cache.load("AmazonEC2-us-east-1") is None
+        assert cache.load("AmazonEC2-us-east-1", allow_stale=True) is not None
+        assert cache.load("missing") is None
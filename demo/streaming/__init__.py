@@ -0,0 +1 @@
+"""Tests for streaming module"""
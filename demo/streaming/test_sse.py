@@ -0,0 +1,82 @@
+"""Unit tests for Server-Sent Events"""
+
+import asyncio
+import json
+import time
+
+import pytest
+
+from src.streaming import (
+    EVENT_ERROR,
+    EVENT_ITEM,
+    EVENT_RESULT,
+    StreamEstimateRequest,
+    format_event,
+    stream_events,
+)
+
+
+def _collect(work, heartbeat=15.0):
+    async def run():
+        return [message async for message in stream_events(work, heartbeat=heartbeat)]
+    return asyncio.run(run())
+
+
+def _parse(message):
+    fields = dict(line.split(": ", 1) for line in message.strip().split("\n"))
+    return fields.get("id"), fields["event"], json.loads(fields["data"])
+
+
+class TestStreamEvents:
+    """Test cases for the event stream"""
+
+    def test_format_event(self):
+        """Test a message carries its id, event name and JSON data on one line"""
+        message = format_event(EVENT_ITEM, {"index": 0, "name": "web\nui"}, 3)
+
+        assert message == 'id: 3\nevent: item\ndata: {"index":0,"name":"web\\nui"}\n\n'
+        assert format_event(EVENT_RESULT, 1).startswith("event: result\n")
+
+    def test_items_then_result(self):
+        """Test emitted events arrive in order, numbered, followed by the result"""
+        def work(emit):
+            for index in range(3):
+                emit(EVENT_ITEM, {"index": index})
+            return {"monthly_cost": 10.0}
+
+        events = [_parse(message) for message in _collect(work)]
+
+        assert [e[0] for e in events] == ["1", "2", "3", "4"]
+        assert [e[2]["index"] for e in events[:3]] == [0, 1, 2]
+        assert events[-1][1:] == (EVENT_RESULT, {"monthly_cost": 10.0})
+
+    def test_errors_end_the_stream(self):
+        """Test a ValueError ends the stream with status 400, other failures with 500"""
+        def invalid(emit):
+            emit(EVENT_ITEM, {"index": 0})
+            raise ValueError("No price for aws/us-east-1/m1.small")
+
+        def broken(emit):
+            raise RuntimeError("catalog gone")
+
+        assert _parse(_collect(invalid)[-1])[1:] == (
+            EVENT_ERROR, {"status": 400, "detail": "No price for aws/us-east-1/m1.small"}
+        )
+        assert _parse(_collect(broken)[-1])[2]["status"] == 500
+
+    def test_heartbeat(self):
+        """Test a comment line is sent while the estimate is quiet"""
+        def slow(emit):
+            time.sleep(0.2)
+            return {}
+
+        messages = _collect(slow, heartbeat=0.05)
+
+        assert messages[0] == ": keep-alive\n\n"
+        assert _parse(messages[-1])[1] == EVENT_RESULT
+
+    def test_request_kind(self):
+        """Test only batch and cluster estimates can be streamed"""
+        assert StreamEstimateRequest(kind="Batch", request={}).kind == "batch"
+        with pytest.raises(ValueError):
+            StreamEstimateRequest(kind="terraform", request={})
@@ -0,0 +1 @@
+"""Tests for tenancy module"""
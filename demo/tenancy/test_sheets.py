@@ -0,0 +1,93 @@
+"""Unit tests for CSV and JSON price sheet files"""
+
+import json
+
+import pytest
+
+from src.tenancy import FORMAT_CSV, FORMAT_JSON, PriceSheet, detect_format, merge_sheets, parse_price_sheet
+
+
+class TestParsePriceSheet:
+    """Test cases for price sheet parsing"""
+
+    def test_csv(self):
+        """Test CSV rows with a file-wide provider and service from the unit"""
+        content = (
+            "\ufeffSKU,Region,Unit,Price,Description\n"
+            "m5.large,us-east-1,hour,0.081,EA 2026\n"
+            "gp3,*,GB-month,0.065,\n"
+        ).encode()
+
+        sheet = parse_price_sheet(content, FORMAT_CSV, provider="aws")
+
+        compute, storage = sheet.prices
+        assert (compute.provider, compute.service, compute.price) == ("aws", "compute", 0.081)
+        assert compute.description == "EA 2026"
+        assert (storage.service, storage.region, storage.unit) == ("block_storage", "*", "GB-month")
+
+    def test_formula_columns(self):
+        """Test tiers, allowances and minimums of usage-based prices"""
+        content = (
+            "provider,region,sku,service,unit,price,tiers,tier_mode,free_quantity,minimum_charge\n"
+            "aws,us-east-1,internet_egress,data_transfer,GB,0.09,10240:0.09;51200:0.085;:0.07,,100,\n"
+            "aws,*,gp3,block_storage,GB-month,0.065,,,,10\n"
+        ).encode()
+
+        egress, storage = parse_price_sheet(content, FORMAT_CSV).prices
+
+        assert [(t.begin, t.end, t.price) for t in egress.tiers] == [
+            (0, 10240, 0.09), (10240, 51200, 0.085), (51200, None, 0.07)
+        ]
+        assert (egress.tier_mode, egress.free_quantity) == ("graduated", 100)
+        assert (storage.tiers, storage.minimum_charge) == ([], 10)
+
+    def test_invalid_tiers(self):
+        """Test rows with gaps in their tiers or an unknown mode name the row"""
+        header = "provider,region,sku,unit,price,tiers,tier_mode\n"
+        with pytest.raises(ValueError, match="Row 2: tiers"):
+            parse_price_sheet((header + "aws,us-east-1,egress,GB,0.09,:0.09;100:0.08,\n").encode(), FORMAT_CSV)
+        with pytest.raises(ValueError, match="Row 2: tier_mode"):
+            parse_price_sheet((header + "aws,us-east-1,egress,GB,0.09,,stepped\n").encode(), FORMAT_CSV)
+
+    def test_json(self):
+        """Test a list or a {"prices": [...]} document"""
+        rows = [{"provider": "gcp", "region": "us-central1", "sku": "n2-standard-4", "unit": "hour",
+                 "price": 0.15, "pricing_model": "reserved"}]
+
+        for document in (rows, {"prices": rows}):
+            sheet = parse_price_sheet(json.dumps(document).encode(), FORMAT_JSON)
+            assert sheet.prices[0].pricing_model == "reserved"
+
+    def test_errors_name_the_row(self):
+        """Test malformed rows are reported with their line"""
+        header = b"provider,sku,region,unit,price\n"
+        row = b"aws,m5.large,us-east-1,hour,0.1\n"
+
+        with pytest.raises(ValueError, match="Row 3: missing price"):
+            parse_price_sheet(header + row + b"aws,m5.xlarge,us-east-1,hour,\n", FORMAT_CSV)
+        with pytest.raises(ValueError, match="Row 2: missing provider"):
+            parse_price_sheet(b"sku,region,unit,price\nm5.large,us-east-1,hour,0.1\n", FORMAT_CSV)
+        with pytest.raises(ValueError, match="Row 2: price"):
+            parse_price_sheet(header + b"aws,m5.large,us-east-1,hour,cheap\n", FORMAT_CSV)
+        with pytest.raises(ValueError, match="Duplicate"):
+            parse_price_sheet(header + row + row, FORMAT_CSV)
+        with pytest.raises(ValueError, match="JSON"):
+            parse_price_sheet(b'{"sku": "m5.large"}', FORMAT_JSON)
+
+    def test_detect_format(self):
+        """Test formats from file names and content types"""
+        assert detect_format("prices.CSV") == FORMAT_CSV
+        assert detect_format("upload", "application/json") == FORMAT_JSON
+        with pytest.raises(ValueError):
+            detect_format("prices.xlsx")
+
+    def test_merge(self):
+        """Test merged prices replace matching entries and keep the rest"""
+        current = PriceSheet(prices=[
+            {"provider": "aws", "region": "us-east-1", "sku": "m5.large", "price": 0.09},
+            {"provider": "aws", "region": "us-east-1", "sku": "m5.xlarge", "price": 0.18},
+        ])
+        update = PriceSheet(prices=[{"provider": "aws", "region": "us-east-1", "sku": "m5.large", "price": 0.08}])
+
+        merged = merge_sheets(current, update)
+        assert sorted((e.sku, e.price) for e in merged.prices) == [("m5.large", 0.08), ("m5.xlarge", 0.18)]
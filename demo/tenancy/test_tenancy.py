@@ -0,0 +1,154 @@
+"""Unit tests for tenants and tenant price overrides"""
+
+import pytest
+
+from src.auth import Principal, SCOPE_ADMIN, SCOPE_ESTIMATE
+from src.estimator import CostEstimator, EstimateRequest
+from src.pricing import PriceQuery, ProviderRegistry, StaticProvider, usage_cost
+from src.store import DEFAULT_TENANT, EstimateRecord, SQLiteStore, StoreError, TenantRecord
+from src.tenancy import (
+    OVERRIDE_SOURCE,
+    PriceSheet,
+    TenantPriceOverrides,
+    current_tenant,
+    resolve_tenant,
+    tenant_context,
+)
+
+
+@pytest.fixture
+def store():
+    store = SQLiteStore(":memory:")
+    store.migrate()
+    store.save_tenant(TenantRecord(id="acme", name="ACME Corp."))
+    return store
+
+
+def _principal(tenant_id, *scopes):
+    return Principal(subject="someone", method="api_key", tenant_id=tenant_id, scopes=list(scopes))
+
+
+class TestResolveTenant:
+    """Test cases for request tenant binding"""
+
+    def test_without_authentication(self):
+        """Test the header names the tenant when authentication is disabled"""
+        assert resolve_tenant(None) == DEFAULT_TENANT
+        assert resolve_tenant("acme") == "acme"
+        with pytest.raises(ValueError):
+            resolve_tenant("ACME Corp")
+
+    def test_callers_act_for_their_tenant(self):
+        """Test tenant-bound callers cannot switch, operators can"""
+        assert resolve_tenant(None, _principal("acme", SCOPE_ESTIMATE)) == "acme"
+        assert resolve_tenant("acme", _principal("acme", SCOPE_ADMIN)) == "acme"
+        with pytest.raises(PermissionError):
+            resolve_tenant("globex", _principal("acme", SCOPE_ADMIN))
+        with pytest.raises(PermissionError):
+            resolve_tenant("acme", _principal(DEFAULT_TENANT, SCOPE_ESTIMATE))
+        assert resolve_tenant("acme", _principal(DEFAULT_TENANT, SCOPE_ADMIN)) == "acme"
+
+    def test_context(self):
+        """Test the bound tenant is reset after the block"""
+        with tenant_context("acme"):
+            assert current_tenant() == "acme"
+        assert current_tenant() == DEFAULT_TENANT
+
+
+class TestPriceOverrides:
+    """Test cases for negotiated price sheets"""
+
+    @pytest.fixture
+    def registry(self, store):
+        store.replace_price_overrides("acme", [
+            entry.to_record("acme") for entry in PriceSheet(prices=[
+                {"provider": "AWS", "region": "us-east-1", "sku": "m5.large", "price": 0.05},
+                {"provider": "aws", "region": "*", "sku": "gp3", "service": "block_storage",
+                 "unit": "GB-month", "price": 0.06},
+            ]).prices
+        ])
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        self.overrides = TenantPriceOverrides(store, ttl=60, clock=lambda: 0.0)
+        registry.set_overrides(self.overrides.lookup)
+        return registry
+
+    def test_override_applies_to_tenant_only(self, registry):
+        """Test the tenant gets its price and other tenants the list price"""
+        query = PriceQuery(provider="aws", region="us-east-1", sku="m5.large")
+
+        with tenant_context("acme"):
+            price = registry.get_price(query)
+        assert (price.price, price.source) == (0.05, OVERRIDE_SOURCE)
+        assert registry.get_price(query).source == "static"
+
+    def test_any_region(self, registry):
+        """Test * entries apply in every region"""
+        query = PriceQuery(provider="aws", region="eu-west-1", sku="gp3", service="block_storage")
+        with tenant_context("acme"):
+            assert registry.get_price(query).price == 0.06
+
+    def test_estimate_uses_override(self, registry):
+        """Test estimates made for the tenant use negotiated prices"""
+        request = EstimateRequest(resources=[{"instance_type": "m5.large", "region": "us-east-1"}])
+        estimator = CostEstimator(registry=registry)
+
+        with tenant_context("acme"):
+            discounted = estimator.estimate(request)
+        listed = estimator.estimate(request)
+
+        assert discounted.monthly_cost < listed.monthly_cost
+        assert discounted.line_items[0].price_source == OVERRIDE_SOURCE
+
+    def test_formula_is_stored(self, registry, store):
+        """Test a tiered price keeps its formula through the store and applies to estimates"""
+        store.replace_price_overrides("acme", [PriceSheet(prices=[{
+            "provider": "aws", "region": "us-east-1", "sku": "STANDARD", "service": "object_storage",
+            "unit": "GB-month", "price": 0.02, "tiers": "1000:0.02;:0.01", "free_quantity": 100,
+        }]).prices[0].to_record("acme")])
+        self.overrides.invalidate("acme")
+        query = PriceQuery(provider="aws", region="us-east-1", sku="STANDARD", service="object_storage")
+
+        with tenant_context("acme"):
+            price = registry.get_price(query)
+
+        assert [tier.end for tier in price.tiers] == [1000, None]
+        assert price.free_quantity == 100
+        assert usage_cost(price, 2100) == pytest.approx(1000 * 0.02 + 1000 * 0.01)
+        assert store.list_price_overrides("acme")[0].tiers[1] == {"begin": 1000, "end": None, "price": 0.01}
+
+    def test_replaced_sheet_after_invalidate(self, registry, store):
+        """Test an empty sheet restores list prices"""
+        store.replace_price_overrides("acme", [])
+        self.overrides.invalidate("acme")
+
+        with tenant_context("acme"):
+            assert registry.get_price(PriceQuery(provider="aws", region="us-east-1", sku="m5.large")).source == "static"
+
+    def test_duplicate_entries(self):
+        """Test a sheet cannot price the same SKU twice"""
+        entry = {"provider": "aws", "region": "us-east-1", "sku": "m5.large", "price": 0.05}
+        with pytest.raises(ValueError, match="Duplicate"):
+            PriceSheet(prices=[entry, dict(entry, price=0.04)])
+
+
+class TestTenantStore:
+    """Test cases for tenant-scoped records"""
+
+    def test_estimates_are_scoped(self, store):
+        """Test tenants only see their own estimates"""
+        saved = store.save_estimate(EstimateRecord(kind="resources", tenant_id="acme"))
+        store.save_estimate(EstimateRecord(kind="resources"))
+
+        assert store.get_estimate(saved.id, tenant_id="acme") == saved
+        assert store.get_estimate(saved.id, tenant_id=DEFAULT_TENANT) is None
+        assert [e.id for e in store.list_estimates(tenant_id="acme")] == [saved.id]
+        assert len(store.list_estimates()) == 2
+
+    def test_tenants(self, store):
+        """Test tenants are listed and duplicates rejected"""
+        assert [t.id for t in store.list_tenants()] == ["acme"]
+        assert store.get_tenant("acme").name == "ACME Corp."
+        assert store.get_tenant("globex") is None
+        with pytest.raises(StoreError):
+            store.save_tenant(TenantRecord(id="acme"))
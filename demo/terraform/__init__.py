@@ -0,0 +1 @@
+"""Tests for Terraform plan cost estimation module"""
@@ -0,0 +1,154 @@
+"""Unit tests for Terraform plan cost estimator"""
+
+import pytest
+
+from src.pricing import ProviderRegistry, StaticProvider
+from src.terraform import TerraformEstimateRequest, TerraformEstimator
+
+from .test_plan import change, make_plan
+
+
+class TestTerraformEstimator:
+    """Test cases for TerraformEstimator class"""
+
+    @pytest.fixture
+    def estimator(self):
+        """Create estimator over the built-in rate card"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider())
+        return TerraformEstimator(registry=registry)
+
+    @pytest.fixture
+    def plan(self):
+        """Plan that adds, resizes and destroys resources"""
+        return make_plan(
+            change("aws_instance.web", "aws_instance", ["create"], after={
+                "instance_type": "m5.large",
+                "root_block_device": [{"volume_size": 50, "volume_type": "gp3"}],
+            }),
+            change("aws_instance.api", "aws_instance", ["update"],
+                   before={"instance_type": "m5.large"}, after={"instance_type": "m5.xlarge"}),
+            change("aws_ebs_volume.old", "aws_ebs_volume", ["delete"], before={
+                "availability_zone": "us-east-1a", "size": 100, "type": "gp2",
+            }),
+            change("aws_s3_bucket.logs", "aws_s3_bucket", ["create"], after={"bucket": "logs"}),
+            regions={"aws": "us-east-1"},
+        )
+
+    def test_cost_delta(self, estimator, plan):
+        """Test added, changed and destroyed costs"""
+        result = estimator.estimate(TerraformEstimateRequest(plan=plan))
+
+        (web,) = result.added
+        assert web.after_monthly_cost == pytest.approx(0.096 * 730 + 0.08 * 50)
+        assert [c.name for c in web.components] == ["instance", "root_volume"]
+
+        (api,) = result.changed
+        assert api.monthly_delta == pytest.approx((0.192 - 0.096) * 730)
+
+        (old,) = result.destroyed
+        assert old.before_monthly_cost == pytest.approx(10.0)
+        assert old.monthly_delta == pytest.approx(-10.0)
+
+        assert result.monthly_delta == pytest.approx(
+            web.monthly_delta + api.monthly_delta + old.monthly_delta, abs=1e-3)
+
+    def test_unsupported_types_reported(self, estimator, plan):
+        """Test resources without a mapper are listed as unpriced"""
+        result = estimator.estimate(TerraformEstimateRequest(plan=plan))
+
+        (logs,) = result.unpriced
+        assert logs.address == "aws_s3_bucket.logs"
+        assert "No cost mapper" in logs.reason
+        assert result.coverage.unsupported == 1
+        assert result.coverage.percent == pytest.approx(result.coverage.priced / result.coverage.items * 100, abs=0.01)
+
+    def test_unknown_price_and_attribute(self, estimator):
+        """Test missing prices and unknown attributes do not fail the estimate"""
+        plan = make_plan(
+            change("aws_instance.big", "aws_instance", ["create"], after={"instance_type": "x9.huge"}),
+            change("aws_instance.tbd", "aws_instance", ["create"], after={}),
+            regions={"aws": "us-east-1"},
+        )
+
+        result = estimator.estimate(TerraformEstimateRequest(plan=plan))
+
+        assert [u.address for u in result.unpriced] == ["aws_instance.big", "aws_instance.tbd"]
+        assert "instance_type" in result.unpriced[1].reason
+        assert result.monthly_delta == 0
+
+    def test_fallback_region_and_other_clouds(self, estimator):
+        """Test the request region and google/azurerm resources"""
+        plan = make_plan(
+            change("google_compute_instance.vm", "google_compute_instance", ["create"], after={
+                "machine_type": "e2-standard-2", "zone": "us-central1-a",
+            }, provider="google"),
+            change("azurerm_linux_virtual_machine.vm", "azurerm_linux_virtual_machine", ["create"], after={
+                "size": "Standard_D2s_v3", "location": "eastus",
+                "os_disk": [{"storage_account_type": "StandardSSD_LRS", "disk_size_gb": 100}],
+            }, provider="azurerm"),
+            change("aws_instance.web", "aws_instance", ["create"], after={"instance_type": "m5.large"}),
+        )
+
+        result = estimator.estimate(TerraformEstimateRequest(plan=plan, region="us-east-1"))
+
+        gcp, azure, aws = result.added
+        assert gcp.after_monthly_cost == pytest.approx(0.067006 * 730, rel=1e-4)
+        assert azure.after_monthly_cost == pytest.approx(0.096 * 730 + 9.60)
+        assert aws.after_monthly_cost == pytest.approx(0.096 * 730)
+
+    def test_missing_region(self, estimator):
+        """Test resources without any region are unpriced"""
+        plan = make_plan(change("aws_db_instance.db", "aws_db_instance", ["create"],
+                                after={"instance_class": "db.m5.large", "engine": "postgres"}))
+
+        result = estimator.estimate(TerraformEstimateRequest(plan=plan))
+
+        assert "Cannot determine region" in result.unpriced[0].reason
+
+    def test_spot_instances(self, estimator):
+        """Test spot market options, Spot VMs and Spot priority use spot prices"""
+        plan = make_plan(
+            change("aws_instance.worker", "aws_instance", ["create"], after={
+                "instance_type": "m5.large",
+                "instance_market_options": [{"market_type": "spot"}],
+            }),
+            change("google_compute_instance.batch", "google_compute_instance", ["create"], after={
+                "machine_type": "e2-standard-2", "zone": "us-central1-a",
+                "scheduling": [{"provisioning_model": "SPOT"}],
+            }, provider="google"),
+            change("azurerm_linux_virtual_machine.vm", "azurerm_linux_virtual_machine", ["create"], after={
+                "size": "Standard_D2s_v3", "location": "eastus", "priority": "Spot",
+                "os_disk": [{"storage_account_type": "StandardSSD_LRS", "disk_size_gb": 100}],
+            }, provider="azurerm"),
+        )
+
+        result = estimator.estimate(TerraformEstimateRequest(plan=plan, region="us-east-1"))
+
+        aws, gcp, azure = result.added
+        assert aws.components[0].pricing_model == "spot"
+        assert aws.after_monthly_cost == pytest.approx(0.0384 * 730)
+        assert gcp.after_monthly_cost == pytest.approx(0.020102 * 730, rel=1e-4)
+        assert azure.after_monthly_cost == pytest.approx(0.0168 * 730 + 9.60)
+
+    def test_guest_accelerators(self, estimator):
+        """Test GPUs attached to instances and node pools are priced per GPU"""
+        plan = make_plan(
+            change("google_compute_instance.train", "google_compute_instance", ["create"], after={
+                "machine_type": "n1-standard-4", "zone": "us-central1-a",
+                "guest_accelerator": [{"type": "projects/p/zones/us-central1-a/acceleratorTypes/nvidia-tesla-t4",
+                                       "count": 2}],
+            }, provider="google"),
+            change("google_container_node_pool.gpu", "google_container_node_pool", ["create"], after={
+                "location": "us-central1-a", "node_count": 3,
+                "node_config": [{"machine_type": "n1-standard-4",
+                                 "guest_accelerator": [{"type": "nvidia-tesla-v100", "count": 1}]}],
+            }, provider="google"),
+        )
+
+        result = estimator.estimate(TerraformEstimateRequest(plan=plan))
+
+        instance, pool = result.added
+        assert [c.name for c in instance.components] == ["instance", "accelerator"]
+        assert instance.after_monthly_cost == pytest.approx((0.189999 + 2 * 0.35) * 730, rel=1e-4)
+        assert pool.after_monthly_cost == pytest.approx(3 * (0.189999 + 2.48) * 730, rel=1e-4)
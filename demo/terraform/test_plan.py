@@ -0,0 +1,136 @@
+"""Unit tests for Terraform plan reading and resource mappers"""
+
+import pytest
+
+from src.terraform import get_mapper, load_plan, register_mapper, resource_changes, supported_resource_types
+from src.terraform.mappers import registry
+
+
+def make_plan(*changes, regions=None, config_resources=None):
+    """Build a minimal `terraform show -json` document"""
+    provider_config = {
+        key: {"name": key.split(".")[0], "expressions": {"region": {"constant_value": region}}}
+        for key, region in (regions or {}).items()
+    }
+    return {
+        "format_version": "1.2",
+        "resource_changes": list(changes),
+        "configuration": {
+            "provider_config": provider_config,
+            "root_module": {"resources": config_resources or []},
+        },
+    }
+
+
+def change(address, rtype, actions, before=None, after=None, provider="aws", mode="managed"):
+    """Build one resource_changes entry"""
+    return {
+        "address": address,
+        "mode": mode,
+        "type": rtype,
+        "name": address.split(".")[-1],
+        "provider_name": f"registry.terraform.io/hashicorp/{provider}",
+        "change": {"actions": actions, "before": before, "after": after},
+    }
+
+
+class TestLoadPlan:
+    """Test cases for plan decoding"""
+
+    def test_json_text(self):
+        """Test plans given as JSON text"""
+        assert load_plan('{"resource_changes": []}') == {"resource_changes": []}
+
+    def test_invalid(self):
+        """Test non-plan documents are rejected"""
+        with pytest.raises(ValueError, match="Invalid plan JSON"):
+            load_plan("{not json")
+        with pytest.raises(ValueError, match="Not a Terraform plan"):
+            load_plan({"planned_values": {}})
+
+
+class TestResourceChanges:
+    """Test cases for resource change extraction"""
+
+    def test_actions_and_filtering(self):
+        """Test action collapsing and skipping of no-op and data resources"""
+        plan = make_plan(
+            change("aws_instance.a", "aws_instance", ["create"], after={}),
+            change("aws_instance.b", "aws_instance", ["delete", "create"], before={}, after={}),
+            change("aws_instance.c", "aws_instance", ["no-op"], before={}, after={}),
+            change("data.aws_ami.x", "aws_ami", ["read"], mode="data"),
+            change("aws_instance.d", "aws_instance", ["delete"], before={}),
+        )
+
+        changes = resource_changes(plan)
+
+        assert [(c.address, c.action) for c in changes] == [
+            ("aws_instance.a", "create"),
+            ("aws_instance.b", "replace"),
+            ("aws_instance.d", "delete"),
+        ]
+        assert changes[0].before is None
+        assert changes[2].after is None
+
+    def test_provider_alias_region(self):
+        """Test regions follow the resource's provider alias"""
+        plan = make_plan(
+            change("aws_instance.east[0]", "aws_instance", ["create"], after={}),
+            change("aws_instance.seoul", "aws_instance", ["create"], after={}),
+            regions={"aws": "us-east-1", "aws.seoul": "ap-northeast-2"},
+            config_resources=[
+                {"address": "aws_instance.east", "provider_config_key": "aws"},
+                {"address": "aws_instance.seoul", "provider_config_key": "aws.seoul"},
+            ],
+        )
+
+        east, seoul = resource_changes(plan)
+
+        assert east.region == "us-east-1"
+        assert seoul.region == "ap-northeast-2"
+
+
+class TestMappers:
+    """Test cases for built-in resource mappers"""
+
+    def test_builtin_types(self):
+        """Test common types have mappers"""
+        types = supported_resource_types()
+        for rtype in ("aws_instance", "aws_ebs_volume", "google_compute_instance", "azurerm_managed_disk"):
+            assert rtype in types
+
+    def test_aws_instance_volumes(self):
+        """Test root and EBS block devices become storage components"""
+        components = get_mapper("aws_instance")({
+            "instance_type": "m5.large",
+            "root_block_device": [{"volume_size": 20, "volume_type": "gp3"}],
+            "ebs_block_device": [{"device_name": "/dev/sdf", "volume_size": 100}],
+        }, "us-east-1")
+
+        assert [(c.sku, c.size_gb) for c in components] == [("m5.large", None), ("gp3", 20.0), ("gp2", 100.0)]
+
+    def test_gke_regional_node_pool(self):
+        """Test regional node pools count nodes in every zone"""
+        components = get_mapper("google_container_node_pool")({
+            "location": "us-central1",
+            "node_count": 2,
+            "node_config": [{"machine_type": "n2-standard-2"}],
+        }, None)
+
+        assert components[0].count == 6
+        assert components[0].region == "us-central1"
+
+    def test_azure_disk_tier(self):
+        """Test managed disks are priced by size tier"""
+        (disk,) = get_mapper("azurerm_managed_disk")({
+            "location": "East US", "storage_account_type": "Premium_LRS", "disk_size_gb": 100,
+        }, None)
+
+        assert (disk.region, disk.sku) == ("eastus", "P10 LRS")
+
+    def test_register_custom_mapper(self, monkeypatch):
+        """Test third-party mappers can be registered"""
+        monkeypatch.setattr(registry, "_mappers", dict(registry._mappers))
+        register_mapper("custom_thing", lambda values, region: [])
+
+        assert get_mapper("custom_thing")({}, None) == []
@@ -0,0 +1 @@
+"""Tests for tracing module"""
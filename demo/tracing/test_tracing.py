@@ -0,0 +1,86 @@
+"""Unit tests for OpenTelemetry tracing"""
+
+import sqlite3
+
+import pytest
+
+from src.store.sql import _TracedCursor
+from src.tracing import (
+    configure_tracing,
+    current_trace_id,
+    server_span,
+    set_response_status,
+    shutdown_tracing,
+    span,
+    tracing_enabled,
+)
+
+
+class TestDisabledTracing:
+    """Test cases for tracing without a configured exporter"""
+
+    def test_spans_are_noops(self):
+        """Test spans yield None and exceptions pass through"""
+        assert not tracing_enabled()
+        with span("work", {"key": "value"}) as current:
+            assert current is None
+        with server_span("GET", {"traceparent": "00-" + "1" * 32 + "-" + "2" * 16 + "-01"}) as current:
+            set_response_status(current, "GET", 200, "/estimate")
+        assert current_trace_id() is None
+
+        with pytest.raises(KeyError):
+            with span("failing"):
+                raise KeyError("boom")
+
+    def test_invalid_settings(self):
+        """Test unknown protocols and ratios are rejected"""
+        with pytest.raises(ValueError, match="protocol"):
+            configure_tracing("svc", protocol="thrift")
+        with pytest.raises(ValueError, match="ratio"):
+            configure_tracing("svc", sample_ratio=1.5)
+
+
+class TestTracing:
+    """Test cases for recorded spans"""
+
+    @pytest.fixture
+    def exporter(self):
+        pytest.importorskip("opentelemetry.sdk")
+        from opentelemetry.sdk.trace.export.in_memory_span_exporter import InMemorySpanExporter
+
+        exporter = InMemorySpanExporter()
+        assert configure_tracing("kcloud-cost-estimator", exporter=exporter)
+        yield exporter
+        shutdown_tracing()
+
+    def test_nested_spans(self, exporter):
+        """Test spans nest under the request's span and continue the caller's trace"""
+        trace_id = "4bf92f3577b34da6a3ce929d0e0e4736"
+        headers = {"traceparent": f"00-{trace_id}-00f067aa0ba902b7-01"}
+        with server_span("POST", headers, {"http.request.method": "POST"}) as request_span:
+            assert current_trace_id() == trace_id
+            with span("azure retail_prices", {"pricing.page": 1, "skipped": None}):
+                pass
+            set_response_status(request_span, "POST", 503, "/estimate")
+        shutdown_tracing()
+
+        child, parent = exporter.get_finished_spans()
+        assert parent.name == "POST /estimate"
+        assert parent.attributes["http.response.status_code"] == 503
+        assert not parent.status.is_ok
+        assert child.parent.span_id == parent.context.span_id
+        assert dict(child.attributes) == {"pricing.page": 1}
+
+    def test_store_queries(self, exporter):
+        """Test each statement gets a span without its parameters"""
+        conn = sqlite3.connect(":memory:")
+        cursor = _TracedCursor(conn.cursor(), "sqlite")
+        cursor.execute("CREATE TABLE t (v TEXT)")
+        cursor.executemany("INSERT INTO t VALUES (?)", [("secret",)])
+        cursor.execute("SELECT v FROM t")
+        assert cursor.fetchall() == [("secret",)]
+        shutdown_tracing()
+
+        spans = exporter.get_finished_spans()
+        assert [s.name for s in spans] == ["sqlite CREATE", "sqlite INSERT", "sqlite SELECT"]
+        assert spans[1].attributes["db.statement"] == "INSERT INTO t VALUES (?)"
@@ -0,0 +1 @@
+"""Tests for Units module"""
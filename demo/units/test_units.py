@@ -0,0 +1,134 @@
+"""Unit tests for time bases and data sizes"""
+
+from datetime import date
+
+import pytest
+
+from src.compare import CompareRequest
+from src.estimator import CostEstimator, DatabaseSpec, EstimateRequest, ResourceSpec, ServerlessSpec
+from src.pricing import PriceCatalog, ProviderRegistry, StaticProvider, term_hours
+from src.units import (
+    BASIS_720,
+    BASIS_730,
+    BASIS_CALENDAR,
+    INTERVAL_DAY,
+    INTERVAL_HOUR,
+    INTERVAL_MONTH,
+    INTERVAL_YEAR,
+    configure_time_basis,
+    convert_rate,
+    convert_size,
+    current_time_basis,
+    hours_per_month,
+    hours_per_year,
+    months_per_year,
+    parse_size,
+    recorded_hours_per_month,
+)
+
+
+@pytest.fixture(autouse=True)
+def default_basis():
+    """Run each test with the default basis, restored afterwards"""
+    configure_time_basis(BASIS_730)
+    yield
+    configure_time_basis(BASIS_730)
+
+
+class TestTimeBasis:
+    """Test cases for hours per month and interval conversions"""
+
+    def test_fixed_bases(self):
+        """Test 730 and 720 hour months and their years"""
+        assert (hours_per_month(), hours_per_year()) == (730.0, 8760.0)
+        assert convert_rate(73.0, INTERVAL_MONTH, INTERVAL_HOUR) == pytest.approx(0.1)
+
+        assert configure_time_basis("720") == BASIS_720
+        assert (hours_per_month(), hours_per_year()) == (720.0, 8640.0)
+        assert convert_rate(0.1, INTERVAL_HOUR, INTERVAL_MONTH) == pytest.approx(72.0)
+        assert convert_rate(2.4, INTERVAL_DAY, INTERVAL_HOUR) == pytest.approx(0.1)
+        assert current_time_basis().dict() == {"basis": "720", "hours_per_month": 720.0, "month": None}
+
+    def test_calendar_basis(self):
+        """Test the calendar basis counts the days of the clock's month and year"""
+        configure_time_basis("Calendar", clock=lambda: date(2028, 2, 10))
+
+        assert hours_per_month() == 29 * 24
+        assert hours_per_month(date(2028, 3, 1)) == 31 * 24
+        assert hours_per_year() == 366 * 24
+        assert convert_rate(100.0, INTERVAL_YEAR, INTERVAL_DAY) == pytest.approx(100.0 / 366)
+        assert current_time_basis().dict() == {"basis": BASIS_CALENDAR, "hours_per_month": 696.0, "month": "2028-02"}
+
+    def test_unknown_basis_and_interval(self):
+        """Test unknown bases and intervals are rejected"""
+        with pytest.raises(ValueError, match="730, 720, calendar"):
+            configure_time_basis("744")
+        with pytest.raises(ValueError):
+            convert_rate(1.0, "week", INTERVAL_HOUR)
+
+    def test_recorded_hours(self):
+        """Test a recorded result keeps its basis, older results take the current one"""
+        configure_time_basis(BASIS_720)
+        assert recorded_hours_per_month({"time_basis": {"basis": "730", "hours_per_month": 730.0}}) == 730.0
+        assert recorded_hours_per_month({"monthly_cost": 10.0}) == 720.0
+
+    def test_estimates_follow_the_basis(self):
+        """Test default hours, hourly costs and commitment terms use the basis, reported with the result"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider(PriceCatalog({"aws": {"us-east-1": {"m5.large": 0.1}}}, storage_prices={})))
+        configure_time_basis(BASIS_720)
+
+        spec = ResourceSpec(instance_type="m5.large", region="us-east-1")
+        result = CostEstimator(registry=registry).estimate(EstimateRequest(resources=[spec]))
+
+        assert spec.hours == 720.0
+        assert result.line_items[0].monthly_cost == pytest.approx(72.0)
+        assert result.time_basis.hours_per_month == 720.0
+        assert term_hours("1yr") == 8640.0
+
+    def test_yearly_costs_follow_the_calendar_year(self):
+        """Test a February estimate's yearly cost is the hourly cost over the year's hours, as commitment terms are"""
+        registry = ProviderRegistry()
+        registry.register(StaticProvider(PriceCatalog({"aws": {"us-east-1": {"m5.large": 0.1}}}, storage_prices={})))
+        configure_time_basis(BASIS_CALENDAR, clock=lambda: date(2026, 2, 10))
+
+        result = CostEstimator(registry=registry).estimate(EstimateRequest(resources=[
+            ResourceSpec(instance_type="m5.large", region="us-east-1"),
+        ]))
+
+        item = result.line_items[0]
+        assert item.monthly_cost == pytest.approx(0.1 * 672)
+        assert item.yearly_cost == pytest.approx(0.1 * 8760)
+        assert item.yearly_cost == pytest.approx(0.1 * term_hours("1yr"))
+        assert months_per_year() == pytest.approx(8760 / 672)
+
+
+class TestSizes:
+    """Test cases for size conversions"""
+
+    def test_convert_size(self):
+        """Test decimal and binary names are both binary units"""
+        assert convert_size(2, "TB") == 2048.0
+        assert convert_size(2, "TiB") == 2048.0
+        assert convert_size(512, "MB") == 0.5
+        assert convert_size(3072, "GB", "TB") == 3.0
+        with pytest.raises(ValueError, match="Unknown size unit"):
+            convert_size(1, "EB")
+
+    def test_parse_size(self):
+        """Test sizes with and without a unit"""
+        assert parse_size("500GiB") == 500.0
+        assert parse_size("1.5 tb") == 1536.0
+        assert parse_size("100") == 100.0
+        with pytest.raises(ValueError, match="Invalid size"):
+            parse_size("lots")
+
+    def test_size_fields(self):
+        """Test request sizes given with a unit are converted to the field's unit"""
+        database = DatabaseSpec(region="us-east-1", instance_class="db.m5.large", storage_gb="2TB",
+                                backup_storage_gb=50)
+        assert (database.storage_gb, database.backup_storage_gb) == (2048.0, 50.0)
+        assert ServerlessSpec(region="us-east-1", memory_mb="1GiB").memory_mb == 1024
+        assert CompareRequest(vcpus=2, memory_gb="8192MB", storage_gb="0.5TB").memory_gb == 8.0
+        with pytest.raises(ValueError):
+            DatabaseSpec(region="us-east-1", instance_class="db.m5.large", storage_gb="lots")
@@ -0,0 +1 @@
+"""Tests for Validation module"""
@@ -0,0 +1,111 @@
+"""Unit tests for request validation and problem details"""
+
+import pytest
+from pydantic import ValidationError
+
+from src.estimator import CostEstimator, EstimateRequest
+from src.pricing import PriceCatalog, ProviderRegistry, StaticProvider
+from src.validation import (
+    CODE_INVALID_REGION,
+    CODE_MISSING,
+    CODE_NEGATIVE_QUANTITY,
+    CODE_OUT_OF_RANGE,
+    CODE_UNKNOWN_INSTANCE_TYPE,
+    CODE_UNPRICED,
+    CODE_UNSUPPORTED_KIND,
+    CODE_UNSUPPORTED_PROVIDER,
+    PROBLEM_TYPE_VALIDATION,
+    Violation,
+    field_path,
+    problem_of,
+    validate_estimate,
+    violations_of,
+)
+
+
+@pytest.fixture
+def estimator():
+    registry = ProviderRegistry()
+    registry.register(StaticProvider(PriceCatalog({"aws": {"us-east-1": {"m5.large": 0.1}}}, storage_prices={})))
+    return CostEstimator(registry)
+
+
+class TestProblems:
+    """Test cases for violations and problem details"""
+
+    def test_schema_violations(self):
+        """Test every schema error of a request is a violation with its field path"""
+        with pytest.raises(ValidationError) as error:
+            EstimateRequest.parse_obj({
+                "resources": [
+                    {"instance_type": "m5.large", "region": "us-east-1", "count": 0},
+                    {"region": "us-east-1"},
+                ],
+                "object_storage": [{"region": "us-east-1", "storage_gb": -5}],
+                "load_balancers": [{"count": 1}],
+            })
+
+        violations = {(v.field, v.code) for v in violations_of(error.value.errors())}
+        assert violations == {
+            ("resources[0].count", CODE_OUT_OF_RANGE),
+            ("resources[1].instance_type", CODE_MISSING),
+            ("object_storage[0].storage_gb", CODE_NEGATIVE_QUANTITY),
+            ("load_balancers", CODE_UNSUPPORTED_KIND),
+        }
+
+    def test_field_paths(self):
+        """Test the body is the root and other locations keep their name"""
+        assert field_path(("body", "items", 3, "hours")) == "items[3].hours"
+        assert field_path(("query", "currency")) == "query.currency"
+        assert field_path(("items", 0, "__root__"), prefix="request") == "request.items[0]"
+
+    def test_problem(self):
+        """Test problem details summarize the first violation"""
+        problem = problem_of([
+            Violation(field="resources[0].count", code=CODE_OUT_OF_RANGE, message="must be at least 1"),
+            Violation(field="resources[1].region", code=CODE_MISSING, message="field required"),
+        ], instance="/estimate")
+
+        assert (problem.type, problem.status, problem.instance) == (PROBLEM_TYPE_VALIDATION, 422, "/estimate")
+        assert problem.detail == "resources[0].count: must be at least 1 (and 1 more)"
+
+
+class TestEstimateValidation:
+    """Test cases for items the estimator cannot price"""
+
+    def test_every_unpriced_item(self, estimator):
+        """Test unsupported clouds, unknown instance types and regions are all reported"""
+        request = EstimateRequest(
+            resources=[
+                {"instance_type": "m5.large", "region": "us-east-1"},
+                {"instance_type": "m5.large", "region": "mars-north-1"},
+                {"instance_type": "m99.huge", "region": "us-east-1"},
+                {"provider": "oracle", "instance_type": "VM.Standard3", "region": "us-ashburn-1"},
+            ],
+            object_storage=[{"region": "mars-north-1", "storage_gb": 100}],
+        )
+
+        violations = validate_estimate(estimator, request)
+
+        assert [(v.field, v.code) for v in violations] == [
+            ("resources[1].region", CODE_INVALID_REGION),
+            ("resources[2].instance_type", CODE_UNKNOWN_INSTANCE_TYPE),
+            ("resources[3].provider", CODE_UNSUPPORTED_PROVIDER),
+            ("object_storage[0]", CODE_UNPRICED),
+        ]
+        assert violations[0].message == "aws m5.large is not offered in region mars-north-1"
+
+    def test_suggestions(self, estimator):
+        """Test unknown instance types are reported with the closest ones of their region"""
+        request = EstimateRequest(resources=[{"instance_type": "m5.lrage", "region": "us-east-1"}])
+
+        violation, = validate_estimate(estimator, request)
+
+        assert violation.suggestions == ["m5.large"]
+        assert violation.message == "Unknown aws instance type m5.lrage, did you mean m5.large?"
+
+    def test_valid_request(self, estimator):
+        """Test a request the estimator can price has no violations"""
+        request = EstimateRequest(resources=[{"instance_type": "m5.large", "region": "us-east-1", "count": 3}])
+
+        assert validate_estimate(estimator, request, prefix="items[0]") == []
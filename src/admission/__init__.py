@@ -0,0 +1,30 @@
+"""
+Admission Webhook Module
+
+Optional ValidatingAdmissionWebhook server estimating the cost impact of
+incoming Deployments and StatefulSets, warning or rejecting when a
+namespace's projected monthly spend would exceed its budget.
+"""
+
+from .models import AdmissionDecision
+from .reviewer import (
+    AdmissionReviewer,
+    ADMISSION_MODES,
+    MODE_WARN,
+    MODE_DENY,
+    REVIEWED_KINDS,
+    NAMESPACE_LABEL,
+)
+from .server import AdmissionServer, start_admission_server
+
+__all__ = [
+    "AdmissionDecision",
+    "AdmissionReviewer",
+    "ADMISSION_MODES",
+    "MODE_WARN",
+    "MODE_DENY",
+    "REVIEWED_KINDS",
+    "NAMESPACE_LABEL",
+    "AdmissionServer",
+    "start_admission_server",
+]
@@ -0,0 +1,27 @@
+"""
+Admission review data models
+"""
+
+from typing import List
+
+from pydantic import BaseModel, Field
+
+from ..budgets import BudgetWarning
+
+
+class AdmissionDecision(BaseModel):
+    """Cost impact of one admission request and whether it is admitted"""
+
+    uid: str
+    operation: str = Field(..., description="CREATE, UPDATE, DELETE or CONNECT")
+    kind: str
+    namespace: str
+    name: str
+    reviewed: bool = Field(default=True, description="False for kinds, operations and namespaces not reviewed")
+    allowed: bool = True
+    result: str = Field(default="allowed", description="allowed, warned, denied or error")
+    monthly_cost: float = Field(default=0.0, description="Monthly cost of the object as admitted")
+    previous_monthly_cost: float = Field(default=0.0, description="Monthly cost of the object it replaces")
+    monthly_delta: float = 0.0
+    budget_warnings: List[BudgetWarning] = Field(default_factory=list)
+    messages: List[str] = Field(default_factory=list, description="Warnings returned to the client")
@@ -0,0 +1,195 @@
+"""
+Cost-aware admission review
+
+Reviews the AdmissionReview requests of a ValidatingAdmissionWebhook.
+Each created or updated Deployment or StatefulSet is priced by the
+Kubernetes estimator, the monthly cost of its old version subtracted on
+update, and the change is checked against the budgets of its namespace:
+budgets labeled namespace=<namespace>, whose projected spend is the
+namespace's actual spend this month extrapolated to the month's end.
+
+In warn mode the budget warnings are returned as admission warnings
+(kubectl prints them); in deny mode a change that would take projected
+spend past a budget's amount is rejected. Changes lowering the cost are
+always admitted. Objects that cannot be priced are admitted with a
+warning, so an unpriceable manifest never blocks a deployment.
+"""
+
+import logging
+from typing import Any, Dict, List, Optional
+
+from ..budgets import BudgetEvaluator
+from ..k8s import KubernetesEstimateRequest, KubernetesEstimator
+from ..k8s.parser import parse_documents
+from ..metrics import record_admission_review
+from ..money import round_money
+from ..pricing import PRICING_ON_DEMAND
+from .models import AdmissionDecision
+
+logger = logging.getLogger(__name__)
+
+ADMISSION_API_VERSION = "admission.k8s.io/v1"
+
+MODE_WARN = "warn"
+MODE_DENY = "deny"
+ADMISSION_MODES = (MODE_WARN, MODE_DENY)
+
+# Kinds whose cost impact is reviewed; other objects are admitted as they are
+REVIEWED_KINDS = ("Deployment", "StatefulSet")
+
+# Budget label selecting a namespace's spend
+NAMESPACE_LABEL = "namespace"
+
+RESULT_ALLOWED = "allowed"
+RESULT_WARNED = "warned"
+RESULT_DENIED = "denied"
+RESULT_ERROR = "error"
+
+
+class AdmissionReviewer:
+    """Decides AdmissionReview requests from their cost impact on namespace budgets"""
+
+    def __init__(
+        self,
+        estimator: KubernetesEstimator,
+        budgets: Optional[BudgetEvaluator],
+        provider: str,
+        region: str,
+        node_instance_type: str,
+        mode: str = MODE_WARN,
+        tenant_id: Optional[str] = None,
+        project: Optional[str] = None,
+        exempt_namespaces: Optional[List[str]] = None,
+        pricing_model: str = PRICING_ON_DEMAND,
+    ):
+        """
+        Initialize reviewer
+
+        Args:
+            estimator: Estimator pricing the reviewed objects
+            budgets: Evaluator of the namespace budgets. Every change is admitted if not provided.
+            provider: Provider of the cluster's nodes
+            region: Region of the cluster's nodes
+            node_instance_type: Node type the per-vCPU and per-GiB rates are derived from
+            mode: MODE_WARN or MODE_DENY
+            tenant_id: Tenant whose budgets apply, the default tenant if not provided
+            project: Project of the cluster's spend (K8S_CLUSTER_NAME)
+            exempt_namespaces: Namespaces whose objects are admitted without review
+            pricing_model: Node pricing model
+        """
+        if mode not in ADMISSION_MODES:
+            raise ValueError(f"Admission mode must be one of: {', '.join(ADMISSION_MODES)}")
+        if not node_instance_type:
+            raise ValueError("Admission reviews need a node instance type to price requests")
+        self.estimator = estimator
+        self.budgets = budgets
+        self.provider = provider
+        self.region = region
+        self.node_instance_type = node_instance_type
+        self.mode = mode
+        self.tenant_id = tenant_id
+        self.project = project
+        self.exempt_namespaces = set(exempt_namespaces or [])
+        self.pricing_model = pricing_model
+
+    def monthly_cost(self, manifest: Optional[Dict[str, Any]]) -> float:
+        """
+        Monthly cost of an object, 0 for none
+
+        Raises:
+            ValueError: If the object has invalid quantities or an unmapped storage class
+            PriceNotFoundError: If the node type or a volume type cannot be priced
+        """
+        if not manifest:
+            return 0.0
+        request = KubernetesEstimateRequest(
+            manifests=[],
+            provider=self.provider,
+            region=self.region,
+            node_instance_type=self.node_instance_type,
+            pricing_model=self.pricing_model,
+        )
+        return self.estimator.estimate_parsed(parse_documents([manifest]), request).monthly_cost
+
+    def decide(self, request: Dict[str, Any]) -> AdmissionDecision:
+        """Decision on the request of an AdmissionReview"""
+        kind = (request.get("kind") or {}).get("kind", "")
+        manifest = request.get("object") or request.get("oldObject") or {}
+        decision = AdmissionDecision(
+            uid=str(request.get("uid", "")),
+            operation=str(request.get("operation", "")),
+            kind=kind,
+            namespace=request.get("namespace") or (manifest.get("metadata") or {}).get("namespace") or "default",
+            name=request.get("name") or (manifest.get("metadata") or {}).get("name") or "",
+        )
+        if (
+            kind not in REVIEWED_KINDS
+            or decision.operation not in ("CREATE", "UPDATE")
+            or decision.namespace in self.exempt_namespaces
+        ):
+            decision.reviewed = False
+            return decision
+
+        try:
+            decision.monthly_cost = round_money(self.monthly_cost(request.get("object")))
+            decision.previous_monthly_cost = round_money(self.monthly_cost(request.get("oldObject")))
+        except Exception as e:
+            logger.warning(f"Admission review of {kind} {decision.namespace}/{decision.name} not priced: {e}")
+            decision.result = RESULT_ERROR
+            decision.messages.append(f"Cost of {kind} {decision.name} not estimated: {e}")
+            return decision
+        decision.monthly_delta = round_money(decision.monthly_cost - decision.previous_monthly_cost)
+
+        if self.budgets is None or decision.monthly_delta <= 0:
+            return decision
+        decision.budget_warnings = self.budgets.warnings(
+            decision.monthly_delta,
+            self.project,
+            {NAMESPACE_LABEL: decision.namespace},
+            tenant_id=self.tenant_id,
+            label_key=NAMESPACE_LABEL,
+        )
+        if not decision.budget_warnings:
+            return decision
+
+        decision.messages = [
+            f"{kind} {decision.name} adds ${decision.monthly_delta:,.2f}/month: {warning.message}"
+            for warning in decision.budget_warnings
+        ]
+        exceeded = [w for w in decision.budget_warnings if w.utilization > 1.0]
+        if self.mode == MODE_DENY and exceeded:
+            decision.allowed = False
+            decision.result = RESULT_DENIED
+        else:
+            decision.result = RESULT_WARNED
+        return decision
+
+    def review(self, review: Dict[str, Any]) -> Dict[str, Any]:
+        """
+        AdmissionReview response to an AdmissionReview request
+
+        Raises:
+            ValueError: If the document is not an AdmissionReview request
+        """
+        if not isinstance(review, dict) or review.get("kind") != "AdmissionReview" or \
+                not isinstance(review.get("request"), dict):
+            raise ValueError("Not an AdmissionReview request")
+
+        decision = self.decide(review["request"])
+        if decision.reviewed:
+            record_admission_review(decision.kind, decision.result)
+            logger.info(
+                f"Admission {decision.operation} {decision.kind} {decision.namespace}/{decision.name}: "
+                f"{decision.result}, ${decision.monthly_delta:+.2f}/month"
+            )
+
+        response: Dict[str, Any] = {"uid": decision.uid, "allowed": decision.allowed}
+        if decision.messages:
+            response["warnings"] = decision.messages
+        if not decision.allowed:
+            response["status"] = {"code": 403, "reason": "Forbidden", "message": "; ".join(decision.messages)}
+        return {
+            "apiVersion": review.get("apiVersion") or ADMISSION_API_VERSION,
+            "kind": "AdmissionReview",
+            "response": response,
+        }
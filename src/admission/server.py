@@ -0,0 +1,85 @@
+"""
+Admission webhook server
+
+Serves the AdmissionReviewer over HTTPS on its own port, next to the HTTP
+API: the API server only calls webhooks over TLS, with the certificate
+its ValidatingWebhookConfiguration's caBundle signs.
+"""
+
+import json
+import logging
+import ssl
+from typing import Optional
+
+from aiohttp import web
+
+from .reviewer import AdmissionReviewer
+
+logger = logging.getLogger(__name__)
+
+VALIDATE_PATH = "/validate"
+
+
+class AdmissionServer:
+    """Running webhook server"""
+
+    def __init__(self, runner: web.AppRunner):
+        self._runner = runner
+
+    async def stop(self) -> None:
+        """Stop accepting reviews and close open connections"""
+        await self._runner.cleanup()
+
+
+def admission_app(reviewer: AdmissionReviewer) -> web.Application:
+    """aiohttp application answering AdmissionReview requests on /validate"""
+
+    async def validate(request: web.Request) -> web.Response:
+        try:
+            review = await request.json()
+            return web.json_response(reviewer.review(review))
+        except (json.JSONDecodeError, ValueError) as e:
+            return web.json_response({"error": str(e)}, status=400)
+        except Exception as e:
+            logger.error(f"Admission review failed: {e}")
+            return web.json_response({"error": f"Admission review failed: {e}"}, status=500)
+
+    async def healthz(request: web.Request) -> web.Response:
+        return web.json_response({"status": "ok"})
+
+    app = web.Application()
+    app.router.add_post(VALIDATE_PATH, validate)
+    app.router.add_get("/healthz", healthz)
+    return app
+
+
+async def start_admission_server(
+    reviewer: AdmissionReviewer,
+    host: str,
+    port: int,
+    cert_file: Optional[str] = None,
+    key_file: Optional[str] = None,
+) -> AdmissionServer:
+    """
+    Start serving admission reviews
+
+    Args:
+        reviewer: Reviewer deciding the requests
+        host: Listen address
+        port: Listen port
+        cert_file: TLS certificate (PEM). Served over plain HTTP if not provided, e.g. behind a TLS proxy.
+        key_file: TLS private key (PEM)
+
+    Returns:
+        Running server; stop it with `await server.stop()`
+    """
+    ssl_context = None
+    if cert_file:
+        ssl_context = ssl.create_default_context(ssl.Purpose.CLIENT_AUTH)
+        ssl_context.load_cert_chain(cert_file, key_file or None)
+
+    runner = web.AppRunner(admission_app(reviewer))
+    await runner.setup()
+    await web.TCPSite(runner, host, port, ssl_context=ssl_context).start()
+    logger.info(f"Admission webhook listening on {host}:{port}{VALIDATE_PATH} ({reviewer.mode} mode)")
+    return AdmissionServer(runner)
@@ -0,0 +1,49 @@
+"""
+Allocation Module
+
+This module attributes actual and estimated costs to teams, projects and
+namespaces by their labels and annotations, splitting shared and idle
+costs between them proportionally, evenly or by weight.
+"""
+
+from .models import (
+    AllocationReport,
+    GroupAllocation,
+    GROUP_BY_PROJECT,
+    GROUP_BY_NAMESPACE,
+    GROUP_BY_LABEL_PREFIX,
+    SPLIT_PROPORTIONAL,
+    SPLIT_EVEN,
+    SPLIT_WEIGHTED,
+    SPLIT_STRATEGIES,
+)
+from .engine import (
+    AllocationEngine,
+    CostItem,
+    parse_label_keys,
+    parse_weights,
+    split_shared,
+    window_days,
+    DEFAULT_LABEL_KEYS,
+    DEFAULT_SHARED_NAMESPACES,
+)
+
+__all__ = [
+    "AllocationReport",
+    "GroupAllocation",
+    "GROUP_BY_PROJECT",
+    "GROUP_BY_NAMESPACE",
+    "GROUP_BY_LABEL_PREFIX",
+    "SPLIT_PROPORTIONAL",
+    "SPLIT_EVEN",
+    "SPLIT_WEIGHTED",
+    "SPLIT_STRATEGIES",
+    "AllocationEngine",
+    "CostItem",
+    "parse_label_keys",
+    "parse_weights",
+    "split_shared",
+    "window_days",
+    "DEFAULT_LABEL_KEYS",
+    "DEFAULT_SHARED_NAMESPACES",
+]
@@ -0,0 +1,368 @@
+"""
+Cost allocation
+
+Attributes the actual spend and the estimated cost of a window to
+groups: projects, namespaces, or the value of a label such as team or
+cost-center. A label key resolves through its configured aliases, first
+in an object's labels and then in its annotations, so workloads tagged
+"owner" and billing rows tagged "team" land in the same group.
+
+Actual costs come from ingested billing exports; estimated costs from
+the latest cluster estimate recorded in the window, prorated to its
+length. Costs no group can be found for, idle node capacity and
+workloads in shared namespaces (kube-system, monitoring) are shared and
+split between the groups proportionally to their direct cost, evenly,
+or by explicit weights.
+"""
+
+import logging
+import re
+from datetime import date, datetime, time, timedelta, timezone
+from typing import Any, Callable, Dict, Iterable, List, NamedTuple, Optional, Tuple
+
+from ..money import round_money, sum_money
+from ..store import ActualCostRecord, EstimateRecord, KIND_CLUSTER, Store
+from ..units import HOURS_PER_DAY, recorded_hours_per_month
+from .models import (
+    AllocationReport,
+    GroupAllocation,
+    GROUP_BY_LABEL_PREFIX,
+    GROUP_BY_NAMESPACE,
+    GROUP_BY_PROJECT,
+    SPLIT_EVEN,
+    SPLIT_PROPORTIONAL,
+    SPLIT_STRATEGIES,
+    SPLIT_WEIGHTED,
+)
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_LABEL_KEYS = "team=team|owner,cost-center=cost-center|costcenter|cost_center,namespace=namespace|k8s-namespace"
+DEFAULT_SHARED_NAMESPACES = ("kube-system", "kube-public", "kube-node-lease", "monitoring")
+# Estimates searched for the window's latest cluster estimate
+MAX_ESTIMATES = 10000
+# Longest allocation window, in days
+MAX_WINDOW_DAYS = 366
+
+_WINDOW = re.compile(r"^([1-9][0-9]*)([dw])$")
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+class CostItem(NamedTuple):
+    """An amount attributed to a group, or shared when group is None"""
+
+    amount: float
+    group: Optional[str]
+
+
+def parse_label_keys(value: str) -> Dict[str, List[str]]:
+    """
+    Parse label key aliases such as "team=team|owner,cost-center=cost-center|costcenter"
+
+    Raises:
+        ValueError: If an entry is malformed
+    """
+    keys: Dict[str, List[str]] = {}
+    for item in value.split(","):
+        item = item.strip()
+        if not item:
+            continue
+        key, sep, aliases = item.partition("=")
+        names = [name.strip() for name in aliases.split("|") if name.strip()]
+        if not sep or not key.strip() or not names:
+            raise ValueError(f"Invalid label key '{item}', expected key=alias|alias")
+        keys[key.strip()] = names
+    return keys
+
+
+def parse_weights(value: str) -> Dict[str, float]:
+    """
+    Parse group weights such as "platform=2,data=1"
+
+    Raises:
+        ValueError: If an entry is malformed or negative
+    """
+    weights: Dict[str, float] = {}
+    for item in value.split(","):
+        item = item.strip()
+        if not item:
+            continue
+        group, sep, weight = item.partition("=")
+        try:
+            if not sep or not group.strip():
+                raise ValueError
+            weights[group.strip()] = float(weight)
+        except ValueError:
+            raise ValueError(f"Invalid weight '{item}', expected group=weight") from None
+        if weights[group.strip()] < 0:
+            raise ValueError(f"Weight of {group.strip()} must not be negative")
+    return weights
+
+
+def window_days(window: str) -> int:
+    """
+    Days in a window such as "30d" or "4w"
+
+    Raises:
+        ValueError: If the window is malformed or too long
+    """
+    match = _WINDOW.match(window)
+    if not match:
+        raise ValueError(f"Invalid window {window!r}, expected days or weeks such as 30d or 4w")
+    days = int(match.group(1)) * (7 if match.group(2) == "w" else 1)
+    if days > MAX_WINDOW_DAYS:
+        raise ValueError(f"A window covers at most {MAX_WINDOW_DAYS} days")
+    return days
+
+
+def split_shared(
+    amount: float,
+    direct: Dict[str, float],
+    strategy: str,
+    weights: Optional[Dict[str, float]] = None,
+) -> Dict[str, float]:
+    """
+    Split a shared amount between groups
+
+    Args:
+        amount: Shared amount
+        direct: Direct cost of every group taking part in the split
+        strategy: proportional to direct cost (even when no group has any),
+            even, or weighted by the given weights (unweighted groups get nothing)
+        weights: Group weights for the weighted split
+
+    Returns:
+        Share of each group; empty when there is no group to split between
+
+    Raises:
+        ValueError: Unknown strategy, or a weighted split without a positive weight
+    """
+    if strategy == SPLIT_WEIGHTED:
+        shares = {group: (weights or {}).get(group, 0.0) for group in set(direct) | set(weights or {})}
+        if not any(shares.values()):
+            raise ValueError("The weighted split needs a positive weight for at least one group")
+    elif strategy == SPLIT_PROPORTIONAL:
+        shares = {group: max(0.0, cost) for group, cost in direct.items()}
+        if not any(shares.values()):
+            shares = {group: 1.0 for group in direct}
+    elif strategy == SPLIT_EVEN:
+        shares = {group: 1.0 for group in direct}
+    else:
+        raise ValueError(f"Unknown split {strategy!r}, expected one of {', '.join(SPLIT_STRATEGIES)}")
+
+    total = sum(shares.values())
+    if not total:
+        return {}
+    return {group: amount * share / total for group, share in shares.items()}
+
+
+class AllocationEngine:
+    """Attributes a tenant's actual and estimated costs to teams, projects or namespaces"""
+
+    def __init__(
+        self,
+        store: Store,
+        label_keys: Optional[Dict[str, List[str]]] = None,
+        shared_namespaces: Iterable[str] = DEFAULT_SHARED_NAMESPACES,
+        default_split: str = SPLIT_PROPORTIONAL,
+        clock: Callable[[], datetime] = utcnow,
+    ):
+        """
+        Initialize allocation engine
+
+        Args:
+            store: Store holding estimates and actual costs
+            label_keys: Aliases looked up for each label key
+            shared_namespaces: Namespaces whose workloads serve every group
+            default_split: Split used when a request names none
+            clock: Current time (aware UTC)
+        """
+        if default_split not in SPLIT_STRATEGIES:
+            raise ValueError(f"Unknown split {default_split!r}, expected one of {', '.join(SPLIT_STRATEGIES)}")
+        self.store = store
+        self.label_keys = label_keys if label_keys is not None else parse_label_keys(DEFAULT_LABEL_KEYS)
+        self.shared_namespaces = set(shared_namespaces)
+        self.default_split = default_split
+        self.clock = clock
+
+    def allocate(
+        self,
+        tenant_id: str,
+        group_by: str,
+        window: str = "30d",
+        split: Optional[str] = None,
+        weights: Optional[Dict[str, float]] = None,
+        cluster: Optional[str] = None,
+    ) -> AllocationReport:
+        """
+        Allocate the costs of the days before today
+
+        Args:
+            tenant_id: Tenant the estimates and costs belong to
+            group_by: "project", "namespace" or "label:<key>"
+            window: Days or weeks before today, e.g. 30d
+            split: How shared costs are split, default the engine's
+            weights: Group weights for the weighted split
+            cluster: Only cluster estimates of this cluster (project)
+
+        Raises:
+            ValueError: Malformed grouping, window, split or weights
+        """
+        split = split or self.default_split
+        if split not in SPLIT_STRATEGIES:
+            raise ValueError(f"Unknown split {split!r}, expected one of {', '.join(SPLIT_STRATEGIES)}")
+        if weights and split != SPLIT_WEIGHTED:
+            raise ValueError("Weights only apply to the weighted split")
+        aliases = self._aliases(group_by)
+        days = window_days(window)
+        end = self.clock().date()
+        start = end - timedelta(days=days)
+
+        actual = self._actual_items(tenant_id, start, end, group_by)
+        record = self._latest_cluster_estimate(tenant_id, start, cluster)
+        estimated, idle = [], 0.0
+        if record is not None:
+            factor = days * HOURS_PER_DAY / recorded_hours_per_month(record.result)
+            estimated, idle = self._estimated_items(record, group_by, aliases, factor)
+
+        actual_direct, actual_shared = _direct(actual)
+        estimated_direct, estimated_shared = _direct(estimated)
+        groups = set(actual_direct) | set(estimated_direct)
+        actual_split = split_shared(
+            actual_shared, {group: actual_direct.get(group, 0.0) for group in groups}, split, weights
+        )
+        estimated_split = split_shared(
+            estimated_shared, {group: estimated_direct.get(group, 0.0) for group in groups}, split, weights
+        )
+
+        allocations = []
+        for group in sorted(groups | set(actual_split) | set(estimated_split)):
+            a_direct, a_shared = actual_direct.get(group, 0.0), actual_split.get(group, 0.0)
+            e_direct, e_shared = estimated_direct.get(group, 0.0), estimated_split.get(group, 0.0)
+            allocations.append(GroupAllocation(
+                group=group,
+                actual_direct=round_money(a_direct),
+                actual_shared=round_money(a_shared),
+                actual_total=round_money(a_direct + a_shared),
+                estimated_direct=round_money(e_direct),
+                estimated_shared=round_money(e_shared),
+                estimated_total=round_money(e_direct + e_shared),
+            ))
+
+        return AllocationReport(
+            window=window,
+            period_start=start,
+            period_end=end,
+            group_by=group_by,
+            split=split,
+            groups=allocations,
+            actual_total=sum_money(item.amount for item in actual),
+            actual_shared=round_money(actual_shared),
+            estimated_total=sum_money(item.amount for item in estimated),
+            estimated_shared=round_money(estimated_shared),
+            estimated_idle=round_money(idle),
+            estimate_id=record.id if record is not None else None,
+            unallocated_actual=round_money(actual_shared if not actual_split else 0.0),
+            unallocated_estimated=round_money(estimated_shared if not estimated_split else 0.0),
+        )
+
+    def _aliases(self, group_by: str) -> List[str]:
+        """Label names a grouping is read from"""
+        if group_by == GROUP_BY_PROJECT:
+            return []
+        if group_by == GROUP_BY_NAMESPACE:
+            return self.label_keys.get(GROUP_BY_NAMESPACE, [GROUP_BY_NAMESPACE])
+        if group_by.startswith(GROUP_BY_LABEL_PREFIX) and len(group_by) > len(GROUP_BY_LABEL_PREFIX):
+            key = group_by[len(GROUP_BY_LABEL_PREFIX):]
+            return self.label_keys.get(key, [key])
+        raise ValueError(f"Invalid groupBy {group_by!r}, expected project, namespace or label:<key>")
+
+    def actual_group(self, group_by: str) -> Callable[[ActualCostRecord], Optional[str]]:
+        """
+        Group of an actual cost by its project or billing tags, None for shared costs
+
+        Raises:
+            ValueError: Malformed grouping
+        """
+        aliases = self._aliases(group_by)
+        if group_by == GROUP_BY_PROJECT:
+            return lambda cost: cost.project
+        return lambda cost: _lookup(aliases, cost.labels)
+
+    def _actual_items(self, tenant_id: str, start: date, end: date, group_by: str) -> List[CostItem]:
+        group_of = self.actual_group(group_by)
+        return [CostItem(cost.amount, group_of(cost)) for cost in self.store.list_actual_costs(tenant_id, start, end)]
+
+    def _latest_cluster_estimate(self, tenant_id: str, start: date, cluster: Optional[str]) -> Optional[EstimateRecord]:
+        records = self.store.list_estimates(
+            project=cluster,
+            since=datetime.combine(start, time.min, tzinfo=timezone.utc),
+            until=self.clock(),
+            limit=MAX_ESTIMATES,
+            tenant_id=tenant_id,
+        )
+        # Newest first
+        return next((record for record in records if record.kind == KIND_CLUSTER), None)
+
+    def _estimated_items(
+        self, record: EstimateRecord, group_by: str, aliases: List[str], factor: float
+    ) -> Tuple[List[CostItem], float]:
+        """
+        Cost items of a cluster estimate over the window, and its idle cost
+
+        Volumes and load balancers without a group of their own belong to
+        the group of their namespace's workloads when they all share one.
+        """
+        result = record.result
+
+        def group_of(item: Dict[str, Any]) -> Optional[str]:
+            namespace = item.get("namespace", "default")
+            if namespace in self.shared_namespaces:
+                return None
+            if group_by == GROUP_BY_PROJECT:
+                return record.project
+            if group_by == GROUP_BY_NAMESPACE:
+                return namespace
+            return _lookup(aliases, item.get("labels") or {}) or _lookup(aliases, item.get("annotations") or {})
+
+        items = []
+        namespace_groups: Dict[str, set] = {}
+        for workload in result.get("workloads", []):
+            group = group_of(workload)
+            namespace_groups.setdefault(workload.get("namespace", "default"), set()).add(group)
+            items.append(CostItem(workload.get("monthly_cost", 0.0) * factor, group))
+
+        for item in result.get("volumes", []) + result.get("load_balancers", []):
+            group = group_of(item)
+            namespace = item.get("namespace", "default")
+            if group is None and namespace not in self.shared_namespaces:
+                owners = namespace_groups.get(namespace, set())
+                group = next(iter(owners)) if len(owners) == 1 else None
+            items.append(CostItem(item.get("monthly_cost", 0.0) * factor, group))
+
+        idle = result.get("idle_monthly_cost", 0.0) * factor
+        items.append(CostItem(idle, None))
+        return items, idle
+
+
+def _lookup(aliases: List[str], labels: Dict[str, str]) -> Optional[str]:
+    """Value of the first alias present"""
+    for name in aliases:
+        if labels.get(name):
+            return labels[name]
+    return None
+
+
+def _direct(items: List[CostItem]) -> Tuple[Dict[str, float], float]:
+    """Direct cost of each group and the shared total"""
+    direct: Dict[str, float] = {}
+    shared = 0.0
+    for item in items:
+        if item.group is None:
+            shared += item.amount
+        else:
+            direct[item.group] = direct.get(item.group, 0.0) + item.amount
+    return direct, shared
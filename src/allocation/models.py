@@ -0,0 +1,50 @@
+"""
+Data models for cost allocation
+"""
+
+from datetime import date
+from typing import List, Optional
+
+from pydantic import BaseModel, Field
+
+# Groupings: by project, namespace or the value of a label key ("label:team")
+GROUP_BY_PROJECT = "project"
+GROUP_BY_NAMESPACE = "namespace"
+GROUP_BY_LABEL_PREFIX = "label:"
+
+# Ways shared and idle costs are split between groups
+SPLIT_PROPORTIONAL = "proportional"
+SPLIT_EVEN = "even"
+SPLIT_WEIGHTED = "weighted"
+SPLIT_STRATEGIES = (SPLIT_PROPORTIONAL, SPLIT_EVEN, SPLIT_WEIGHTED)
+
+
+class GroupAllocation(BaseModel):
+    """Costs attributed to one team, project or namespace over the window"""
+
+    group: str = Field(..., description="Project, namespace or label value")
+    actual_direct: float = Field(0.0, description="Actual spend carrying the group (USD)")
+    actual_shared: float = Field(0.0, description="Share of actual spend without a group")
+    actual_total: float = 0.0
+    estimated_direct: float = Field(0.0, description="Estimated cost of the group's workloads, volumes and load balancers")
+    estimated_shared: float = Field(0.0, description="Share of estimated idle and shared cost")
+    estimated_total: float = 0.0
+
+
+class AllocationReport(BaseModel):
+    """Actual and estimated costs of a window attributed to groups"""
+
+    window: str = Field(..., description="e.g. 30d")
+    period_start: date
+    period_end: date = Field(..., description="First day after the period")
+    group_by: str
+    split: str = Field(..., description="How shared costs were split: proportional, even or weighted")
+    groups: List[GroupAllocation]
+    actual_total: float = 0.0
+    actual_shared: float = Field(0.0, description="Actual spend without a group, before splitting")
+    estimated_total: float = 0.0
+    estimated_shared: float = Field(0.0, description="Estimated idle and shared cost, before splitting")
+    estimated_idle: float = Field(0.0, description="Node cost not requested by any workload, part of estimated_shared")
+    estimate_id: Optional[str] = Field(None, description="Cluster estimate the estimated costs come from")
+    unallocated_actual: float = Field(0.0, description="Shared spend left unsplit because no group has costs")
+    unallocated_estimated: float = Field(0.0, description="Shared estimated cost left unsplit because no group has costs")
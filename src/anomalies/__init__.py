@@ -0,0 +1,37 @@
+"""
+Anomalies Module
+
+This module flags days whose actual spend stands out from the days
+before them, per provider service and per project, with rolling z-score
+or MAD detectors, and reports SKUs that start spending heavily.
+"""
+
+from .models import (
+    Anomaly,
+    AnomalyReport,
+    DETECTOR_ZSCORE,
+    DETECTOR_MAD,
+    DETECTORS,
+    KIND_SPIKE,
+    KIND_NEW_SKU,
+    DIMENSION_SERVICE,
+    DIMENSION_PROJECT,
+    DIMENSIONS,
+)
+from .detector import AnomalyDetector, score, MIN_BASELINE_DAYS
+
+__all__ = [
+    "Anomaly",
+    "AnomalyReport",
+    "DETECTOR_ZSCORE",
+    "DETECTOR_MAD",
+    "DETECTORS",
+    "KIND_SPIKE",
+    "KIND_NEW_SKU",
+    "DIMENSION_SERVICE",
+    "DIMENSION_PROJECT",
+    "DIMENSIONS",
+    "AnomalyDetector",
+    "score",
+    "MIN_BASELINE_DAYS",
+]
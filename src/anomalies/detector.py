@@ -0,0 +1,244 @@
+"""
+Daily spend anomaly detection
+
+Every day of the period is scored against a baseline of the days before
+it, per provider service and per project. The z-score detector measures
+the deviation from the baseline's mean in standard deviations; the MAD
+detector from its median in median absolute deviations scaled to a
+standard deviation, so a few past spikes do not hide the next one. The
+spread is at least 1% of the baseline so flat spend does not turn cents
+into anomalies, and a spike must also exceed the baseline by a minimum
+amount.
+
+A SKU spending more than a minimum on a day it had no spend in the whole
+baseline is reported as new, once the tenant has enough history for the
+SKU to be new rather than merely not ingested yet.
+"""
+
+import statistics
+from datetime import date, datetime, timedelta, timezone
+from typing import Callable, Dict, List, Optional, Tuple
+
+from ..money import round_money
+from ..store import ActualCostRecord, Store
+from .models import (
+    Anomaly,
+    AnomalyReport,
+    DETECTORS,
+    DETECTOR_MAD,
+    DETECTOR_ZSCORE,
+    DIMENSIONS,
+    DIMENSION_PROJECT,
+    DIMENSION_SERVICE,
+    KIND_NEW_SKU,
+    KIND_SPIKE,
+)
+
+# Days with spend a baseline needs before a day is scored
+MIN_BASELINE_DAYS = 7
+# Scales the median absolute deviation of normal data to its standard deviation
+_MAD_SCALE = 1.4826
+# Smallest spread, relative to the baseline level
+_MIN_SPREAD = 0.01
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+def score(value: float, baseline: List[float], detector: str) -> Tuple[float, float]:
+    """
+    (expected value, deviation in standard deviations) of a value against a baseline
+
+    Raises:
+        ValueError: Unknown detector
+    """
+    if detector == DETECTOR_MAD:
+        expected = statistics.median(baseline)
+        spread = _MAD_SCALE * statistics.median(abs(v - expected) for v in baseline)
+    elif detector == DETECTOR_ZSCORE:
+        expected = statistics.fmean(baseline)
+        spread = statistics.pstdev(baseline)
+    else:
+        raise ValueError(f"Unknown detector {detector!r}, expected one of {', '.join(DETECTORS)}")
+    spread = max(spread, abs(expected) * _MIN_SPREAD, 0.01)
+    return expected, (value - expected) / spread
+
+
+class AnomalyDetector:
+    """Flags days whose spend stands out from their baseline"""
+
+    def __init__(
+        self,
+        store: Store,
+        detector: str = DETECTOR_MAD,
+        window_days: int = 28,
+        threshold: float = 3.5,
+        min_increase: float = 10.0,
+        new_sku_min_cost: float = 25.0,
+        clock: Callable[[], datetime] = utcnow,
+    ):
+        """
+        Initialize detector
+
+        Args:
+            store: Store holding actual costs
+            detector: zscore or mad
+            window_days: Days before each day its baseline is taken from
+            threshold: Score a day reaches to be an anomaly
+            min_increase: Spend above the baseline a spike needs (USD/day)
+            new_sku_min_cost: Spend a new SKU needs on its first day (USD)
+            clock: Current time (aware UTC)
+        """
+        if detector not in DETECTORS:
+            raise ValueError(f"Unknown detector {detector!r}, expected one of {', '.join(DETECTORS)}")
+        self.store = store
+        self.detector = detector
+        self.window_days = window_days
+        self.threshold = threshold
+        self.min_increase = min_increase
+        self.new_sku_min_cost = new_sku_min_cost
+        self.clock = clock
+
+    def detect(
+        self,
+        tenant_id: str,
+        days: int = 7,
+        dimensions: Optional[List[str]] = None,
+        detector: Optional[str] = None,
+        threshold: Optional[float] = None,
+    ) -> AnomalyReport:
+        """
+        Anomalies of the days before today, newest first
+
+        Args:
+            tenant_id: Tenant the costs belong to
+            days: Days analyzed, ending yesterday
+            dimensions: Series scored for spikes, default service and project
+            detector: Detector, default the detector's
+            threshold: Score threshold, default the detector's
+
+        Raises:
+            ValueError: Invalid parameters
+        """
+        detector = detector or self.detector
+        threshold = self.threshold if threshold is None else threshold
+        dimensions = dimensions or list(DIMENSIONS)
+        if detector not in DETECTORS:
+            raise ValueError(f"Unknown detector {detector!r}, expected one of {', '.join(DETECTORS)}")
+        unknown = [d for d in dimensions if d not in DIMENSIONS]
+        if unknown:
+            raise ValueError(f"Unknown dimension {unknown[0]!r}, expected one of {', '.join(DIMENSIONS)}")
+        if not 1 <= days <= 90:
+            raise ValueError("Anomalies are detected over 1 to 90 days")
+        if threshold <= 0:
+            raise ValueError("The threshold must be positive")
+
+        end = self.clock().date()
+        start = end - timedelta(days=days)
+        costs = self.store.list_actual_costs(tenant_id, start - timedelta(days=self.window_days), end)
+
+        anomalies: List[Anomaly] = []
+        for dimension in dimensions:
+            for key, (daily, sample) in _series(costs, dimension).items():
+                anomalies += self._spikes(dimension, key, daily, sample, start, end, detector, threshold)
+        anomalies += self._new_skus(costs, start, end)
+        anomalies.sort(key=lambda a: (a.date, a.cost - a.expected), reverse=True)
+
+        return AnomalyReport(
+            period_start=start,
+            period_end=end,
+            detector=detector,
+            window_days=self.window_days,
+            threshold=threshold,
+            anomalies=anomalies,
+        )
+
+    def _spikes(
+        self,
+        dimension: str,
+        key: str,
+        daily: Dict[date, float],
+        sample: ActualCostRecord,
+        start: date,
+        end: date,
+        detector: str,
+        threshold: float,
+    ) -> List[Anomaly]:
+        first = min(daily)
+        found = []
+        day = start
+        while day < end:
+            # Days before the series' first spend are not part of its baseline
+            baseline_start = max(first, day - timedelta(days=self.window_days))
+            baseline = [daily.get(baseline_start + timedelta(days=i), 0.0) for i in range((day - baseline_start).days)]
+            cost = daily.get(day, 0.0)
+            if len(baseline) >= MIN_BASELINE_DAYS:
+                expected, deviation = score(cost, baseline, detector)
+                if deviation >= threshold and cost - expected >= self.min_increase:
+                    found.append(Anomaly(
+                        id=f"{KIND_SPIKE}:{dimension}:{key}:{day}",
+                        kind=KIND_SPIKE,
+                        dimension=dimension,
+                        key=key,
+                        date=day,
+                        cost=round_money(cost),
+                        expected=round_money(expected),
+                        score=round(deviation, 2),
+                        provider=sample.provider if dimension == DIMENSION_SERVICE else "",
+                        service=sample.service if dimension == DIMENSION_SERVICE else "",
+                        project=sample.project if dimension == DIMENSION_PROJECT else None,
+                    ))
+            day += timedelta(days=1)
+        return found
+
+    def _new_skus(self, costs: List[ActualCostRecord], start: date, end: date) -> List[Anomaly]:
+        spend_days = sorted({cost.usage_date for cost in costs})
+        by_sku: Dict[Tuple[str, str, str], Dict[date, float]] = {}
+        for cost in costs:
+            if cost.sku:
+                daily = by_sku.setdefault((cost.provider, cost.service, cost.sku), {})
+                daily[cost.usage_date] = daily.get(cost.usage_date, 0.0) + cost.amount
+
+        found = []
+        for (provider, service, sku), daily in by_sku.items():
+            spent = [day for day, amount in daily.items() if amount > 0]
+            if not spent:
+                continue
+            first = min(spent)
+            if not start <= first < end or daily[first] < self.new_sku_min_cost:
+                continue
+            # Enough of the tenant's spend was ingested before the SKU's first day
+            if sum(1 for day in spend_days if first - timedelta(days=self.window_days) <= day < first) < MIN_BASELINE_DAYS:
+                continue
+            key = "/".join(part for part in (provider, service, sku) if part)
+            found.append(Anomaly(
+                id=f"{KIND_NEW_SKU}:{key}:{first}",
+                kind=KIND_NEW_SKU,
+                dimension="sku",
+                key=key,
+                date=first,
+                cost=round_money(daily[first]),
+                expected=0.0,
+                provider=provider,
+                service=service,
+                sku=sku,
+            ))
+        return found
+
+
+def _series(
+    costs: List[ActualCostRecord], dimension: str
+) -> Dict[str, Tuple[Dict[date, float], ActualCostRecord]]:
+    """Daily spend of each provider service or project, with one of its costs"""
+    series: Dict[str, Tuple[Dict[date, float], ActualCostRecord]] = {}
+    for cost in costs:
+        if dimension == DIMENSION_PROJECT:
+            if not cost.project:
+                continue
+            key = cost.project
+        else:
+            key = "/".join(part for part in (cost.provider, cost.service) if part) or "unknown"
+        daily, _ = series.setdefault(key, ({}, cost))
+        daily[cost.usage_date] = daily.get(cost.usage_date, 0.0) + cost.amount
+    return series
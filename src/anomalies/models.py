@@ -0,0 +1,50 @@
+"""
+Data models for spend anomalies
+"""
+
+from datetime import date
+from typing import List, Optional
+
+from pydantic import BaseModel, Field
+
+# Detectors scoring a day against its baseline
+DETECTOR_ZSCORE = "zscore"
+DETECTOR_MAD = "mad"
+DETECTORS = (DETECTOR_ZSCORE, DETECTOR_MAD)
+
+# Anomaly kinds
+KIND_SPIKE = "spike"
+KIND_NEW_SKU = "new_sku"
+
+# Series analyzed for spikes: spend per provider service, or per project
+DIMENSION_SERVICE = "service"
+DIMENSION_PROJECT = "project"
+DIMENSIONS = (DIMENSION_SERVICE, DIMENSION_PROJECT)
+
+
+class Anomaly(BaseModel):
+    """A day whose spend stands out from the days before it"""
+
+    id: str = Field(..., description="Stable across detections of the same anomaly")
+    kind: str = Field(..., description="spike or new_sku")
+    dimension: str = Field(..., description="service, project, or sku for new SKUs")
+    key: str = Field(..., description="e.g. aws/compute, a project, or aws/compute/p4d.24xlarge")
+    date: date
+    cost: float = Field(..., description="Spend of the day (USD)")
+    expected: float = Field(..., description="Baseline daily spend: mean or median of the window")
+    score: Optional[float] = Field(None, description="Deviation from the baseline in (robust) standard deviations")
+    provider: str = ""
+    service: str = ""
+    project: Optional[str] = None
+    sku: str = ""
+
+
+class AnomalyReport(BaseModel):
+    """Anomalies of a tenant's recent days"""
+
+    period_start: date
+    period_end: date = Field(..., description="First day after the period")
+    detector: str = Field(..., description="zscore or mad")
+    window_days: int = Field(..., description="Days before each day its baseline is taken from")
+    threshold: float
+    anomalies: List[Anomaly]
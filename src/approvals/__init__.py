@@ -0,0 +1,32 @@
+"""
+Approvals Module
+
+This module keeps comments on recorded estimates and the approvals or
+rejections of tenant admins, so a platform lead signs off on projected
+costs before deployment pipelines proceed.
+"""
+
+from .models import (
+    DECISION_APPROVED,
+    DECISION_REJECTED,
+    DECISIONS,
+    STATUS_PENDING,
+    Annotation,
+    CommentSpec,
+    DecisionSpec,
+    EstimateReview,
+)
+from .reviews import EstimateReviews, review_of
+
+__all__ = [
+    "DECISION_APPROVED",
+    "DECISION_REJECTED",
+    "DECISIONS",
+    "STATUS_PENDING",
+    "Annotation",
+    "CommentSpec",
+    "DecisionSpec",
+    "EstimateReview",
+    "EstimateReviews",
+    "review_of",
+]
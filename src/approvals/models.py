@@ -0,0 +1,56 @@
+"""
+Data models for estimate comments and approvals
+"""
+
+from datetime import datetime
+from typing import List, Optional
+
+from pydantic import BaseModel, Field
+
+from ..store import EstimateAnnotationRecord
+
+# Review decisions, and the status of estimates without one
+DECISION_APPROVED = "approved"
+DECISION_REJECTED = "rejected"
+DECISIONS = (DECISION_APPROVED, DECISION_REJECTED)
+STATUS_PENDING = "pending"
+
+MAX_COMMENT_LENGTH = 4000
+
+
+class CommentSpec(BaseModel):
+    """Body of POST /estimates/{estimate_id}/comments"""
+
+    comment: str = Field(..., min_length=1, max_length=MAX_COMMENT_LENGTH)
+
+
+class DecisionSpec(BaseModel):
+    """Body of POST /estimates/{estimate_id}/approve and /reject"""
+
+    comment: str = Field("", max_length=MAX_COMMENT_LENGTH, description="Reason for the decision")
+
+
+class Annotation(BaseModel):
+    """A comment or review decision on an estimate"""
+
+    id: str
+    author: str
+    decision: Optional[str] = Field(None, description="approved or rejected, None for comments")
+    comment: str = ""
+    created_at: datetime
+
+    @classmethod
+    def from_record(cls, record: EstimateAnnotationRecord) -> "Annotation":
+        return cls(**record.dict(exclude={"tenant_id", "estimate_id"}))
+
+
+class EstimateReview(BaseModel):
+    """Review status of a recorded estimate with its comments and decisions"""
+
+    estimate_id: str
+    project: Optional[str] = None
+    monthly_cost: float = Field(..., description="Recorded monthly cost (USD)")
+    status: str = Field(..., description="pending, approved or rejected: the latest decision")
+    decided_by: Optional[str] = Field(None, description="Approver or rejecter of the latest decision")
+    decided_at: Optional[datetime] = None
+    annotations: List[Annotation] = Field(default_factory=list, description="Oldest first")
@@ -0,0 +1,99 @@
+"""
+Estimate reviews
+
+Callers comment on recorded estimates, and tenant admins approve or
+reject them, as a sign-off deployment pipelines wait for before they
+proceed. Comments and decisions are appended to the estimate's
+annotations and never changed; the estimate's status is its latest
+decision, so a rejected estimate can be approved after discussion (and
+the other way round). Estimates are immutable: a changed plan is a new
+estimate that needs a new approval.
+"""
+
+import logging
+from typing import List, Optional
+
+from ..store import EstimateAnnotationRecord, EstimateRecord, Store
+from .models import Annotation, DECISIONS, EstimateReview, STATUS_PENDING
+
+logger = logging.getLogger(__name__)
+
+
+def review_of(record: EstimateRecord, annotations: List[EstimateAnnotationRecord]) -> EstimateReview:
+    """Review of an estimate from its annotations, oldest first"""
+    decisions = [annotation for annotation in annotations if annotation.decision]
+    latest = decisions[-1] if decisions else None
+    return EstimateReview(
+        estimate_id=record.id,
+        project=record.project,
+        monthly_cost=record.monthly_cost,
+        status=latest.decision if latest else STATUS_PENDING,
+        decided_by=latest.author if latest else None,
+        decided_at=latest.created_at if latest else None,
+        annotations=[Annotation.from_record(annotation) for annotation in annotations],
+    )
+
+
+class EstimateReviews:
+    """Comments on and review decisions about the estimates of the store"""
+
+    def __init__(self, store: Store):
+        """
+        Initialize reviews
+
+        Args:
+            store: Store holding the estimates and their annotations
+        """
+        self.store = store
+
+    def review(self, tenant_id: str, estimate_id: str) -> EstimateReview:
+        """
+        Review status of an estimate of a tenant
+
+        Raises:
+            LookupError: If the tenant has no such estimate
+        """
+        record = self._estimate(tenant_id, estimate_id)
+        return review_of(record, self.store.list_estimate_annotations(estimate_id, tenant_id=tenant_id))
+
+    def comment(self, tenant_id: str, estimate_id: str, author: str, comment: str) -> EstimateReview:
+        """
+        Add a comment to an estimate
+
+        Raises:
+            LookupError: If the tenant has no such estimate
+        """
+        return self._append(tenant_id, estimate_id, author, None, comment)
+
+    def decide(self, tenant_id: str, estimate_id: str, author: str, decision: str, comment: str = "") -> EstimateReview:
+        """
+        Approve or reject an estimate
+
+        Raises:
+            ValueError: If the decision is neither approved nor rejected
+            LookupError: If the tenant has no such estimate
+        """
+        if decision not in DECISIONS:
+            raise ValueError(f"decision must be one of: {', '.join(DECISIONS)}")
+        review = self._append(tenant_id, estimate_id, author, decision, comment)
+        logger.info(f"Estimate {estimate_id} of tenant {tenant_id} {decision} by {author}")
+        return review
+
+    def _append(
+        self, tenant_id: str, estimate_id: str, author: str, decision: Optional[str], comment: str
+    ) -> EstimateReview:
+        self._estimate(tenant_id, estimate_id)
+        self.store.append_estimate_annotation(EstimateAnnotationRecord(
+            tenant_id=tenant_id,
+            estimate_id=estimate_id,
+            author=author,
+            decision=decision,
+            comment=comment,
+        ))
+        return self.review(tenant_id, estimate_id)
+
+    def _estimate(self, tenant_id: str, estimate_id: str) -> EstimateRecord:
+        record = self.store.get_estimate(estimate_id, tenant_id=tenant_id)
+        if record is None:
+            raise LookupError(f"Estimate {estimate_id} not found")
+        return record
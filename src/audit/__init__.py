@@ -0,0 +1,30 @@
+"""
+Audit Module
+
+This module records who changed what and when: every successful request
+changing a tenant's catalogs, discount rules, budgets, webhooks, roles,
+API keys or other configuration is appended to an audit log in the
+store, queryable through GET /audit.
+"""
+
+from .models import AuditEvent
+from .log import (
+    AuditLog,
+    AuditTarget,
+    audit_target,
+    created_resource_id,
+    ACTION_CREATE,
+    ACTION_UPDATE,
+    ACTION_DELETE,
+)
+
+__all__ = [
+    "AuditEvent",
+    "AuditLog",
+    "AuditTarget",
+    "audit_target",
+    "created_resource_id",
+    "ACTION_CREATE",
+    "ACTION_UPDATE",
+    "ACTION_DELETE",
+]
@@ -0,0 +1,162 @@
+"""
+Audit log of changes made through the API
+
+Every successful request changing a tenant's configuration (catalogs and
+price sheets, discount rules, budgets, scenarios, actual costs, inventory,
+webhooks, report schedules, role assignments, API keys and tenants) or
+reviewing an estimate is appended to the audit log with the caller, the
+resource changed and the time. Requests computing estimates change nothing but the estimate
+history, which records them already, and are not audited. Rejected
+requests change nothing and are left to the access log.
+"""
+
+import json
+import logging
+import re
+from typing import List, NamedTuple, Optional, Pattern, Tuple
+
+from ..auth import Principal
+from ..iam import subject_of
+from ..store import AuditEventRecord, Store
+from .models import AuditEvent
+
+logger = logging.getLogger(__name__)
+
+ACTION_CREATE = "create"
+ACTION_UPDATE = "update"
+ACTION_DELETE = "delete"
+
+_METHOD_ACTIONS = {"POST": ACTION_CREATE, "PUT": ACTION_UPDATE, "PATCH": ACTION_UPDATE, "DELETE": ACTION_DELETE}
+
+_ID = r"(?P<id>[^/]+)"
+
+# Audited routes: method (None for every changing method), path, resource
+# type and action (None for the method's). The path's id group names the
+# resource; resources created by POST are named by the response.
+_ROUTES: List[Tuple[Optional[str], Pattern, str, Optional[str]]] = [
+    (method, re.compile(f"^{path}$"), resource_type, action)
+    for method, path, resource_type, action in (
+        ("POST", "/catalog/refresh", "catalog", "refresh"),
+        ("POST", "/catalog/custom", "price_sheet", "upload"),
+        ("DELETE", "/catalog/custom", "price_sheet", None),
+        (None, "/pricing/openstack/rates", "rate_card", None),
+        (None, f"/discounts(?:/{_ID})?", "discount_rule", None),
+        (None, f"/profiles/{_ID}", "usage_profile", None),
+        (None, f"/budgets(?:/{_ID})?", "budget", None),
+        (None, f"/scenarios(?:/{_ID})?", "scenario", None),
+        ("POST", "/actuals", "actual_costs", "record"),
+        ("POST", "/admin/billing/ingest", "actual_costs", "ingest"),
+        ("POST", "/admin/actuals/compact", "actual_costs", "compact"),
+        ("POST", "/inventory", "inventory", "replace"),
+        (None, f"/webhooks(?:/{_ID})?", "webhook", None),
+        (None, f"/reports/schedules(?:/{_ID})?", "report_schedule", None),
+        (None, f"/iam/roles/{_ID}", "role_assignment", None),
+        ("DELETE", f"/admin/api-keys/{_ID}", "api_key", "revoke"),
+        (None, "/admin/api-keys", "api_key", None),
+        (None, "/admin/tenants", "tenant", None),
+        (None, f"/admin/tenants/{_ID}/prices", "price_overrides", None),
+        ("POST", f"/estimates/{_ID}/comments", "estimate", "comment"),
+        ("POST", f"/estimates/{_ID}/approve", "estimate", "approve"),
+        ("POST", f"/estimates/{_ID}/reject", "estimate", "reject"),
+        ("POST", "/calibrate", "calibration", ACTION_UPDATE),
+        ("POST", "/collect/start", "collector", "start"),
+        ("POST", "/collect/stop", "collector", "stop"),
+    )
+]
+
+
+class AuditTarget(NamedTuple):
+    """What an audited request changes"""
+
+    resource_type: str
+    action: str
+    resource_id: Optional[str]
+
+
+def audit_target(method: str, path: str) -> Optional[AuditTarget]:
+    """Resource and action of a request that changes configuration, None for requests not audited"""
+    if method not in _METHOD_ACTIONS:
+        return None
+    for route_method, pattern, resource_type, action in _ROUTES:
+        if route_method is not None and route_method != method:
+            continue
+        match = pattern.match(path)
+        if match is not None:
+            return AuditTarget(resource_type, action or _METHOD_ACTIONS[method], match.groupdict().get("id"))
+    return None
+
+
+def created_resource_id(body: bytes) -> Optional[str]:
+    """ID of the resource a JSON response returns, e.g. {"budget": {"id": ...}}"""
+    try:
+        document = json.loads(body)
+    except ValueError:
+        return None
+    if not isinstance(document, dict):
+        return None
+    for value in document.values():
+        if isinstance(value, dict) and isinstance(value.get("id"), str):
+            return value["id"]
+    return None
+
+
+class AuditLog:
+    """Appends audited requests to the store's audit log and queries it"""
+
+    def __init__(self, store: Store):
+        """
+        Initialize audit log
+
+        Args:
+            store: Store keeping the append-only audit log
+        """
+        self.store = store
+
+    def record(
+        self,
+        method: str,
+        path: str,
+        status: int,
+        tenant_id: str,
+        principal: Optional[Principal] = None,
+        client: Optional[str] = None,
+        request_id: Optional[str] = None,
+        resource_id: Optional[str] = None,
+    ) -> Optional[AuditEventRecord]:
+        """
+        Append a handled request if it changed configuration
+
+        Args:
+            method: Request method
+            path: Request path
+            status: Response status code; requests rejected (4xx/5xx) are not recorded
+            tenant_id: Tenant the request acted for
+            principal: Authenticated caller, None without authentication
+            client: Client address
+            request_id: X-Request-ID of the request
+            resource_id: ID of the resource created, for routes not naming it
+
+        Returns:
+            The appended event, None if the request is not audited
+        """
+        target = audit_target(method, path)
+        if target is None or status >= 400:
+            return None
+        return self.store.append_audit_event(AuditEventRecord(
+            tenant_id=tenant_id,
+            actor=subject_of(principal) if principal is not None else None,
+            actor_method=principal.method if principal is not None else None,
+            role=principal.role if principal is not None else None,
+            client=client,
+            action=target.action,
+            resource_type=target.resource_type,
+            resource_id=target.resource_id or resource_id,
+            method=method,
+            path=path,
+            status=status,
+            request_id=request_id,
+        ))
+
+    def events(self, tenant_id: str, **filters) -> List[AuditEvent]:
+        """A tenant's audit events, newest first, filtered as Store.list_audit_events"""
+        return [AuditEvent.from_record(r) for r in self.store.list_audit_events(tenant_id=tenant_id, **filters)]
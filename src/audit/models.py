@@ -0,0 +1,33 @@
+"""
+Data models for the audit log
+"""
+
+from datetime import datetime
+from typing import Optional
+
+from pydantic import BaseModel, Field
+
+from ..store import AuditEventRecord
+
+
+class AuditEvent(BaseModel):
+    """A change made through the API: who made it, to what and when"""
+
+    id: str
+    tenant_id: str
+    actor: Optional[str] = Field(None, description="Issued API key ID, static key name or OIDC token subject")
+    actor_method: Optional[str] = Field(None, description="api_key, static_key or oidc")
+    role: Optional[str] = Field(None, description="Caller's role in the tenant, if assigned one")
+    client: Optional[str] = Field(None, description="Client address")
+    action: str = Field(..., description="create, update, delete or the operation done, e.g. refresh")
+    resource_type: str = Field(..., description="e.g. budget, discount_rule or api_key")
+    resource_id: Optional[str] = None
+    method: str
+    path: str
+    status: int
+    request_id: Optional[str] = None
+    created_at: datetime
+
+    @classmethod
+    def from_record(cls, record: AuditEventRecord) -> "AuditEvent":
+        return cls(**record.dict())
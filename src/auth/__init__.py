@@ -0,0 +1,64 @@
+"""
+Auth Module
+
+API key and OIDC bearer token authentication with per-caller scopes
+(read, estimate, admin) and rate limits, rate limits of anonymous clients
+by address, and issuing of API keys kept in the store.
+"""
+
+from .models import (
+    Principal,
+    ApiKeyCreateRequest,
+    grants,
+    SCOPES,
+    SCOPE_READ,
+    SCOPE_ESTIMATE,
+    SCOPE_ADMIN,
+    METHOD_STATIC_KEY,
+    METHOD_API_KEY,
+    METHOD_OIDC,
+)
+from .keys import KeyResolver, generate_api_key, hash_api_key, issue_api_key, parse_static_keys, KEY_PREFIX
+from .oidc import OIDCVerifier, InvalidTokenError, token_scopes
+from .ratelimit import RateLimiter, client_address, FORWARDED_FOR_HEADER
+from .authenticator import (
+    Authenticator,
+    AuthError,
+    build_authenticator,
+    rate_limit_error,
+    required_scope,
+    API_KEY_HEADER,
+    PUBLIC_PATHS,
+)
+
+__all__ = [
+    "Principal",
+    "ApiKeyCreateRequest",
+    "grants",
+    "SCOPES",
+    "SCOPE_READ",
+    "SCOPE_ESTIMATE",
+    "SCOPE_ADMIN",
+    "METHOD_STATIC_KEY",
+    "METHOD_API_KEY",
+    "METHOD_OIDC",
+    "KeyResolver",
+    "generate_api_key",
+    "hash_api_key",
+    "issue_api_key",
+    "parse_static_keys",
+    "KEY_PREFIX",
+    "OIDCVerifier",
+    "InvalidTokenError",
+    "token_scopes",
+    "RateLimiter",
+    "client_address",
+    "FORWARDED_FOR_HEADER",
+    "Authenticator",
+    "AuthError",
+    "build_authenticator",
+    "rate_limit_error",
+    "required_scope",
+    "API_KEY_HEADER",
+    "PUBLIC_PATHS",
+]
@@ -0,0 +1,239 @@
+"""
+Request authentication and authorization
+
+Callers authenticate with an API key (X-API-Key header, or as a bearer
+token) or an OIDC access token (Authorization: Bearer <JWT>). Each route
+requires a scope; probes, metrics and the API docs stay public, as do chat
+platform requests, which are verified by their signatures instead.
+"""
+
+import logging
+import math
+from typing import Dict, Optional
+
+from ..store import Store
+from .keys import KeyResolver, parse_static_keys
+from .models import Principal, SCOPE_READ, SCOPE_ESTIMATE, SCOPE_ADMIN
+from .oidc import OIDCVerifier, InvalidTokenError
+from .ratelimit import RateLimiter
+
+logger = logging.getLogger(__name__)
+
+API_KEY_HEADER = "X-API-Key"
+
+# Routes served without credentials: probes, metrics scraping, API docs and the
+# dashboard page, whose API calls carry the caller's key
+PUBLIC_PATHS = frozenset({
+    "/",
+    "/health",
+    "/healthz",
+    "/ready",
+    "/readyz",
+    "/live",
+    "/metrics",
+    "/dashboard",
+    "/docs",
+    "/docs/oauth2-redirect",
+    "/redoc",
+    "/openapi.json",
+    # Signed by Slack and Teams (see src.chatops)
+    "/chatops/slack/commands",
+    "/chatops/teams/messages",
+})
+
+# Routes that manage the service rather than use it
+_ADMIN_PATH_PREFIX = "/admin/"
+_ADMIN_ROUTES = frozenset({
+    ("POST", "/catalog/refresh"),
+    ("POST", "/catalog/custom"),
+    ("DELETE", "/catalog/custom"),
+    ("POST", "/actuals"),
+})
+# Resources anyone may read but only admins may change
+_ADMIN_MANAGED_PATHS = ("/discounts", "/profiles", "/pricing/openstack/rates")
+# Resources only admins may read or change
+_ADMIN_ONLY_PATHS = ("/webhooks", "/iam/roles", "/audit")
+# Sign-offs only admins may give: POST /estimates/{estimate_id}/approve or /reject
+_ESTIMATES_PATH = "/estimates/"
+_ADMIN_DECISIONS = frozenset({"approve", "reject"})
+
+_READ_METHODS = frozenset({"GET", "HEAD"})
+# Read-only queries sent as POSTs, e.g. by Grafana's JSON datasource
+_READ_POST_PATHS = ("/grafana",)
+
+
+def required_scope(method: str, path: str) -> Optional[str]:
+    """
+    Scope a request needs
+
+    Returns:
+        None for public routes and CORS preflight requests
+    """
+    method = method.upper()
+    if method == "OPTIONS" or path in PUBLIC_PATHS:
+        return None
+    if path.startswith(_ADMIN_PATH_PREFIX) or (method, path) in _ADMIN_ROUTES:
+        return SCOPE_ADMIN
+    if any(path == p or path.startswith(p + "/") for p in _ADMIN_ONLY_PATHS):
+        return SCOPE_ADMIN
+    if method in _READ_METHODS:
+        return SCOPE_READ
+    if any(path == p or path.startswith(p + "/") for p in _READ_POST_PATHS):
+        return SCOPE_READ
+    if any(path == p or path.startswith(p + "/") for p in _ADMIN_MANAGED_PATHS):
+        return SCOPE_ADMIN
+    if path.startswith(_ESTIMATES_PATH) and path.count("/") == 3 and path.rsplit("/", 1)[1] in _ADMIN_DECISIONS:
+        return SCOPE_ADMIN
+    return SCOPE_ESTIMATE
+
+
+class AuthError(Exception):
+    """Raised when a request is not authenticated, not allowed or over its rate limit"""
+
+    def __init__(self, status_code: int, detail: str, headers: Optional[Dict[str, str]] = None):
+        super().__init__(detail)
+        self.status_code = status_code
+        self.detail = detail
+        self.headers = headers or {}
+
+
+def rate_limit_error(limit: int, retry_after: float) -> AuthError:
+    """429 of a caller over its limit, telling it when to retry"""
+    return AuthError(
+        429,
+        f"Rate limit of {limit} requests per minute exceeded",
+        {"Retry-After": str(max(1, math.ceil(retry_after)))},
+    )
+
+
+def _is_jwt(token: str) -> bool:
+    return token.count(".") == 2
+
+
+class Authenticator:
+    """Authenticates callers, checks their scope and applies rate limits"""
+
+    def __init__(
+        self,
+        keys: KeyResolver,
+        oidc: Optional[OIDCVerifier] = None,
+        limiter: Optional[RateLimiter] = None,
+        default_rate_limit: int = 60,
+        rate_limit_burst: int = 0,
+    ):
+        """
+        Initialize authenticator
+
+        Args:
+            keys: Static and issued API keys
+            oidc: Bearer token verifier. JWT bearer tokens are rejected if not provided.
+            limiter: Rate limiter, shared by every caller
+            default_rate_limit: Requests per minute of callers without their own limit (0 = unlimited)
+            rate_limit_burst: Requests a caller may send at once, its per-minute limit if 0
+        """
+        self.keys = keys
+        self.oidc = oidc
+        self.limiter = limiter or RateLimiter()
+        self.default_rate_limit = default_rate_limit
+        self.rate_limit_burst = rate_limit_burst
+
+    def authenticate(self, api_key: Optional[str] = None, authorization: Optional[str] = None) -> Principal:
+        """
+        Identify the caller from its credentials
+
+        Raises:
+            AuthError: 401 if credentials are missing or invalid
+        """
+        if not api_key and authorization:
+            scheme, _, token = authorization.partition(" ")
+            token = token.strip()
+            if scheme.lower() != "bearer" or not token:
+                raise AuthError(401, "Unsupported Authorization scheme, expected Bearer",
+                                {"WWW-Authenticate": "Bearer"})
+            if _is_jwt(token):
+                if self.oidc is None:
+                    raise AuthError(401, "Bearer tokens are not accepted (OIDC is not configured)",
+                                    {"WWW-Authenticate": "Bearer"})
+                try:
+                    return self.oidc.verify(token)
+                except InvalidTokenError as e:
+                    raise AuthError(401, str(e), {"WWW-Authenticate": 'Bearer error="invalid_token"'})
+            api_key = token
+
+        if not api_key:
+            raise AuthError(401, f"Missing credentials: send {API_KEY_HEADER} or Authorization: Bearer",
+                            {"WWW-Authenticate": "Bearer"})
+
+        principal = self.keys.resolve(api_key)
+        if principal is None:
+            raise AuthError(401, "Invalid or revoked API key", {"WWW-Authenticate": "Bearer"})
+        return principal
+
+    def authorize(self, principal: Principal, scope: str) -> None:
+        """
+        Check a caller's scope and take one request from its rate limit
+
+        Raises:
+            AuthError: 403 without the scope, 429 over the rate limit
+        """
+        if not principal.has_scope(scope):
+            raise AuthError(403, f"'{principal.subject}' lacks the '{scope}' scope")
+
+        limit = principal.rate_limit if principal.rate_limit is not None else self.default_rate_limit
+        caller = principal.key_id or f"{principal.method}:{principal.subject}"
+        retry_after = self.limiter.acquire(caller, limit, self.rate_limit_burst)
+        if retry_after > 0:
+            raise rate_limit_error(limit, retry_after)
+
+    def check(self, scope: str, api_key: Optional[str] = None, authorization: Optional[str] = None) -> Principal:
+        """Authenticate and authorize one request"""
+        principal = self.authenticate(api_key, authorization)
+        self.authorize(principal, scope)
+        return principal
+
+
+def build_authenticator(
+    settings, store: Optional[Store] = None, limiter: Optional[RateLimiter] = None
+) -> Optional[Authenticator]:
+    """
+    Create the authenticator from application settings
+
+    Args:
+        settings: Application settings
+        store: Store of issued API keys
+        limiter: Rate limiter, shared with the limits of anonymous clients
+
+    Returns:
+        None if authentication is disabled (AUTH_ENABLED=false)
+
+    Raises:
+        ValueError: If AUTH_STATIC_KEYS is malformed
+    """
+    if not settings.auth_enabled:
+        return None
+
+    static_keys = parse_static_keys(settings.auth_static_keys)
+    oidc = None
+    if settings.oidc_issuer:
+        oidc = OIDCVerifier(
+            issuer=settings.oidc_issuer,
+            audience=settings.oidc_audience,
+            jwks_url=settings.oidc_jwks_url,
+            scope_claim=settings.oidc_scope_claim,
+            scope_prefix=settings.oidc_scope_prefix,
+            tenant_claim=settings.oidc_tenant_claim,
+        )
+    if not static_keys and store is None and oidc is None:
+        logger.warning("Authentication enabled without static keys, store or OIDC: every request will be rejected")
+
+    logger.info(
+        f"Authentication enabled ({len(static_keys)} static keys, issued keys "
+        f"{'on' if store is not None else 'off'}, OIDC {'on' if oidc is not None else 'off'})"
+    )
+    return Authenticator(
+        keys=KeyResolver(static_keys, store),
+        oidc=oidc,
+        limiter=limiter,
+        default_rate_limit=settings.auth_rate_limit,
+        rate_limit_burst=settings.rate_limit_burst,
+    )
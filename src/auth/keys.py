@@ -0,0 +1,120 @@
+"""
+API keys
+
+Static keys come from settings and are meant for bootstrapping (an admin
+key) and fixed integrations of the default tenant; further keys are
+issued per tenant and revoked through the admin API and stored as
+SHA-256 hashes.
+"""
+
+import hashlib
+import hmac
+import secrets
+from typing import Dict, List, Optional, Tuple
+
+from ..store import ApiKeyRecord, Store, DEFAULT_TENANT
+from .models import Principal, SCOPES, METHOD_API_KEY, METHOD_STATIC_KEY
+
+# Prefix of generated keys, so leaked keys are easy to recognize
+KEY_PREFIX = "kce_"
+
+# Characters of a key kept in the clear to tell keys apart in listings
+_DISPLAY_PREFIX_LENGTH = len(KEY_PREFIX) + 6
+
+
+def generate_api_key() -> str:
+    """New random API key"""
+    return KEY_PREFIX + secrets.token_urlsafe(32)
+
+
+def hash_api_key(key: str) -> str:
+    """Hash under which a key is stored and looked up"""
+    return hashlib.sha256(key.encode()).hexdigest()
+
+
+def parse_static_keys(entries: List[str]) -> Dict[str, Principal]:
+    """
+    Parse static key settings
+
+    Each entry is "name:key:scopes" with scopes joined by "+"
+    (e.g. "ci:s3cret:read+estimate"), optionally followed by ":limit" in
+    requests per minute.
+
+    Returns:
+        Key -> principal
+
+    Raises:
+        ValueError: If an entry is malformed or names an unknown scope
+    """
+    keys: Dict[str, Principal] = {}
+    for entry in entries:
+        parts = entry.split(":")
+        if len(parts) not in (3, 4) or not parts[0] or not parts[1]:
+            raise ValueError(f"Invalid static API key '{parts[0]}', expected name:key:scopes[:limit]")
+        name, key, scopes = parts[0], parts[1], [s for s in parts[2].split("+") if s]
+        unknown = sorted(set(scopes) - set(SCOPES))
+        if unknown or not scopes:
+            raise ValueError(f"Static API key '{name}' has invalid scopes: {parts[2]}")
+        rate_limit = int(parts[3]) if len(parts) == 4 else None
+        keys[key] = Principal(subject=name, method=METHOD_STATIC_KEY, scopes=scopes, rate_limit=rate_limit)
+    return keys
+
+
+def issue_api_key(
+    store: Store,
+    name: str,
+    scopes: List[str],
+    rate_limit: Optional[int] = None,
+    tenant_id: str = DEFAULT_TENANT,
+) -> Tuple[ApiKeyRecord, str]:
+    """
+    Create and persist an API key
+
+    Returns:
+        (saved record, the key). The key is not stored and cannot be shown again.
+    """
+    key = generate_api_key()
+    record = store.save_api_key(ApiKeyRecord(
+        tenant_id=tenant_id,
+        name=name,
+        key_hash=hash_api_key(key),
+        prefix=key[:_DISPLAY_PREFIX_LENGTH],
+        scopes=scopes,
+        rate_limit=rate_limit,
+    ))
+    return record, key
+
+
+class KeyResolver:
+    """Resolves an API key to a principal from static keys and the store"""
+
+    def __init__(self, static_keys: Optional[Dict[str, Principal]] = None, store: Optional[Store] = None):
+        """
+        Initialize resolver
+
+        Args:
+            static_keys: Key -> principal, from parse_static_keys
+            store: Store of issued keys. Only static keys are accepted if not provided.
+        """
+        self.static_keys = static_keys or {}
+        self.store = store
+
+    def resolve(self, key: str) -> Optional[Principal]:
+        """Principal of a key, or None if the key is unknown or revoked"""
+        for static_key, principal in self.static_keys.items():
+            if hmac.compare_digest(static_key.encode(), key.encode()):
+                return principal
+
+        if self.store is None:
+            return None
+        record = self.store.find_api_key(hash_api_key(key))
+        if record is None or record.revoked:
+            return None
+        return Principal(
+            subject=record.name,
+            method=METHOD_API_KEY,
+            tenant_id=record.tenant_id,
+            scopes=record.scopes,
+            key_id=record.id,
+            rate_limit=record.rate_limit,
+        )
@@ -0,0 +1,60 @@
+"""
+Data models for authentication
+"""
+
+from typing import List, Optional
+
+from pydantic import BaseModel, Field, validator
+
+from ..store import DEFAULT_TENANT
+
+# Scopes, each granting the ones before it: read-only access, creating
+# estimates (and other writes), managing API keys and catalogs
+SCOPE_READ = "read"
+SCOPE_ESTIMATE = "estimate"
+SCOPE_ADMIN = "admin"
+SCOPES = (SCOPE_READ, SCOPE_ESTIMATE, SCOPE_ADMIN)
+
+# How a caller authenticated
+METHOD_STATIC_KEY = "static_key"
+METHOD_API_KEY = "api_key"
+METHOD_OIDC = "oidc"
+
+
+def grants(scopes: List[str], required: str) -> bool:
+    """Whether any of scopes grants the required scope"""
+    rank = SCOPES.index(required)
+    return any(scope in SCOPES and SCOPES.index(scope) >= rank for scope in scopes)
+
+
+class Principal(BaseModel):
+    """An authenticated caller"""
+
+    subject: str = Field(..., description="Key name or token subject")
+    method: str = Field(..., description="static_key, api_key or oidc")
+    tenant_id: str = Field(DEFAULT_TENANT, description="Tenant the caller belongs to")
+    scopes: List[str] = Field(default_factory=list)
+    key_id: Optional[str] = Field(None, description="Id of an issued API key")
+    rate_limit: Optional[int] = Field(None, description="Requests per minute, None for the default limit")
+    role: Optional[str] = Field(None, description="Role in the tenant acted for, None if the credential's scopes apply")
+
+    def has_scope(self, scope: str) -> bool:
+        return grants(self.scopes, scope)
+
+
+class ApiKeyCreateRequest(BaseModel):
+    """Request to issue an API key"""
+
+    name: str = Field(..., min_length=1, description="Who or what the key is issued to")
+    tenant_id: Optional[str] = Field(None, description="Tenant the key acts for, default the caller's")
+    scopes: List[str] = Field(default_factory=lambda: [SCOPE_READ])
+    rate_limit: Optional[int] = Field(None, ge=0, description="Requests per minute (0 = unlimited)")
+
+    @validator("scopes")
+    def validate_scopes(cls, v):
+        unknown = sorted(set(v) - set(SCOPES))
+        if unknown:
+            raise ValueError(f"Unknown scopes: {', '.join(unknown)} (expected {', '.join(SCOPES)})")
+        if not v:
+            raise ValueError("At least one scope is required")
+        return sorted(set(v), key=SCOPES.index)
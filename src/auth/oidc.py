@@ -0,0 +1,122 @@
+"""
+OIDC bearer tokens
+
+Verifies JWT access tokens against the issuer's published signing keys
+(JWKS) and maps the token's scope claim to service scopes.
+"""
+
+import logging
+from typing import Any, Dict, List
+
+import requests
+
+from ..store import DEFAULT_TENANT
+from .models import Principal, SCOPES, METHOD_OIDC
+
+logger = logging.getLogger(__name__)
+
+# Signature algorithms accepted for access tokens
+_ALGORITHMS = ["RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "PS256"]
+
+
+class InvalidTokenError(Exception):
+    """Raised when a bearer token cannot be verified"""
+
+
+def token_scopes(claims: Dict[str, Any], claim: str = "scope", prefix: str = "") -> List[str]:
+    """
+    Service scopes granted by a token
+
+    The claim may be a space separated string (OAuth 2 "scope") or a list
+    (e.g. "roles", "groups"). With a prefix, only values starting with it
+    are considered and the prefix is stripped: "cost:estimate" -> "estimate".
+    """
+    value = claims.get(claim, [])
+    values = value.split() if isinstance(value, str) else list(value)
+    scopes = []
+    for item in values:
+        if not isinstance(item, str) or not item.startswith(prefix):
+            continue
+        scope = item[len(prefix):]
+        if scope in SCOPES and scope not in scopes:
+            scopes.append(scope)
+    return scopes
+
+
+class OIDCVerifier:
+    """Verifies access tokens issued by one OIDC provider"""
+
+    def __init__(
+        self,
+        issuer: str,
+        audience: str,
+        jwks_url: str = "",
+        scope_claim: str = "scope",
+        scope_prefix: str = "",
+        tenant_claim: str = "tenant",
+        timeout: float = 10.0,
+    ):
+        """
+        Initialize verifier
+
+        Args:
+            issuer: Expected "iss", e.g. https://keycloak.example.com/realms/kcloud
+            audience: Expected "aud"
+            jwks_url: Signing keys URL. Discovered from the issuer's openid-configuration if empty.
+            scope_claim: Claim holding the caller's scopes
+            scope_prefix: Prefix of service scopes in that claim
+            tenant_claim: Claim holding the caller's tenant ID (default tenant if absent)
+            timeout: Seconds to wait for discovery and JWKS requests
+        """
+        self.issuer = issuer
+        self.audience = audience
+        self.jwks_url = jwks_url
+        self.scope_claim = scope_claim
+        self.scope_prefix = scope_prefix
+        self.tenant_claim = tenant_claim
+        self.timeout = timeout
+        self._jwks_client = None
+
+    def _signing_key(self, token: str):
+        import jwt
+
+        if self._jwks_client is None:
+            jwks_url = self.jwks_url or self._discover_jwks_url()
+            # PyJWKClient caches keys and refetches on unknown key ids
+            self._jwks_client = jwt.PyJWKClient(jwks_url, cache_keys=True, timeout=self.timeout)
+        return self._jwks_client.get_signing_key_from_jwt(token).key
+
+    def _discover_jwks_url(self) -> str:
+        url = f"{self.issuer.rstrip('/')}/.well-known/openid-configuration"
+        response = requests.get(url, timeout=self.timeout)
+        response.raise_for_status()
+        return response.json()["jwks_uri"]
+
+    def verify(self, token: str) -> Principal:
+        """
+        Verify a bearer token
+
+        Raises:
+            InvalidTokenError: If the signature, issuer, audience or expiry does not check out
+        """
+        import jwt
+
+        try:
+            claims = jwt.decode(
+                token,
+                self._signing_key(token),
+                algorithms=_ALGORITHMS,
+                audience=self.audience,
+                issuer=self.issuer,
+                options={"require": ["exp", "iss", "sub"]},
+            )
+        except (jwt.PyJWTError, requests.RequestException, KeyError) as e:
+            logger.debug(f"Bearer token rejected: {e}")
+            raise InvalidTokenError(f"Invalid bearer token: {e}") from e
+
+        return Principal(
+            subject=claims["sub"],
+            method=METHOD_OIDC,
+            tenant_id=str(claims.get(self.tenant_claim) or DEFAULT_TENANT),
+            scopes=token_scopes(claims, self.scope_claim, self.scope_prefix),
+        )
@@ -0,0 +1,96 @@
+"""
+Per-caller rate limiting
+
+Token buckets refilled continuously, so a caller may burst up to its
+bucket size (by default its per-minute limit) and then proceeds at the
+limit's average rate.
+
+Callers are API keys and token subjects, or the client address of
+anonymous requests. Behind a load balancer or ingress the peer address is
+the proxy's; with trusted_proxies set, the client is read from the
+X-Forwarded-For entries those proxies appended.
+"""
+
+import threading
+import time
+from collections import OrderedDict
+from typing import Callable, Optional, Tuple
+
+# Header proxies append the address of the client they received a request from
+FORWARDED_FOR_HEADER = "X-Forwarded-For"
+
+
+class RateLimiter:
+    """Token bucket per caller"""
+
+    def __init__(self, clock: Callable[[], float] = time.monotonic, max_callers: int = 10000):
+        """
+        Initialize rate limiter
+
+        Args:
+            clock: Monotonic time source in seconds
+            max_callers: Buckets kept at most; the least recently used are dropped beyond it
+        """
+        if max_callers < 1:
+            raise ValueError("A rate limiter tracks at least 1 caller")
+        self.clock = clock
+        self.max_callers = max_callers
+        # Caller -> (tokens left, last refill time), least recently used first
+        self._buckets: "OrderedDict[str, Tuple[float, float]]" = OrderedDict()
+        self._lock = threading.Lock()
+
+    def acquire(self, caller: str, per_minute: int, burst: Optional[int] = None) -> float:
+        """
+        Take one request from a caller's budget
+
+        Args:
+            caller: Key id, token subject or client address
+            per_minute: Requests per minute, 0 for unlimited
+            burst: Bucket size, the per-minute limit if None or 0
+
+        Returns:
+            0 if the request is allowed, otherwise seconds until it would be
+        """
+        if per_minute <= 0:
+            return 0.0
+
+        rate = per_minute / 60.0
+        capacity = float(burst or per_minute)
+        now = self.clock()
+        with self._lock:
+            tokens, last = self._buckets.get(caller, (capacity, now))
+            tokens = min(capacity, tokens + (now - last) * rate)
+            if tokens >= 1:
+                self._put(caller, tokens - 1, now)
+                return 0.0
+            self._put(caller, tokens, now)
+            return (1 - tokens) / rate
+
+    def __len__(self) -> int:
+        return len(self._buckets)
+
+    def _put(self, caller: str, tokens: float, now: float) -> None:
+        self._buckets[caller] = (tokens, now)
+        self._buckets.move_to_end(caller)
+        # A dropped caller starts over with a full bucket
+        while len(self._buckets) > self.max_callers:
+            self._buckets.popitem(last=False)
+
+
+def client_address(peer: Optional[str], forwarded_for: Optional[str] = None, trusted_proxies: int = 0) -> str:
+    """
+    Address of the client that sent a request
+
+    Args:
+        peer: Address of the connection's peer
+        forwarded_for: X-Forwarded-For header of the request
+        trusted_proxies: Proxies in front of the service; each appended one entry to the header
+
+    The entries left of those the trusted proxies appended were sent by the
+    client itself and could be anything, so they are never used.
+    """
+    if trusted_proxies > 0 and forwarded_for:
+        hops = [hop.strip() for hop in forwarded_for.split(",") if hop.strip()]
+        if hops:
+            return hops[-min(trusted_proxies, len(hops))]
+    return peer or "unknown"
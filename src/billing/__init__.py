@@ -0,0 +1,87 @@
+"""
+Billing Module
+
+This module ingests actual usage from cloud billing exports (AWS Cost
+and Usage Reports, the GCP BigQuery billing export, Azure Cost
+Management exports), normalizes it into daily actual costs and stores
+them next to the estimates they can be compared with. Aged actual costs
+are rolled up to daily and monthly costs under retention periods.
+"""
+
+from .models import (
+    BillingLine,
+    CompactionResult,
+    IngestRequest,
+    IngestResult,
+    month_bounds,
+    next_month,
+    previous_month,
+)
+from .normalize import aggregate, csv_rows, parquet_rows, snake_case
+from .ingest import BillingExport, BillingIngester, BillingIngestion
+from .retention import ActualsRetention, roll_up
+from .aws_cur import (
+    AWSCURIngester,
+    S3ReportSource,
+    classify,
+    parse_cur_rows,
+    select_report_keys,
+    COST_COLUMNS,
+    SOURCE as AWS_CUR_SOURCE,
+)
+from .gcp_bigquery import (
+    BigQueryExportSource,
+    GCPBillingExportIngester,
+    export_query,
+    parse_export_rows,
+    classify as classify_gcp,
+    SOURCE as GCP_BIGQUERY_SOURCE,
+)
+from .azure_cost import (
+    AzureCostExportIngester,
+    BlobExportSource,
+    select_export_blobs,
+    parse_export_rows as parse_azure_export_rows,
+    classify as classify_azure,
+    SOURCE as AZURE_COST_EXPORT_SOURCE,
+)
+from .factory import build_ingestion
+
+__all__ = [
+    "BillingLine",
+    "CompactionResult",
+    "IngestRequest",
+    "IngestResult",
+    "month_bounds",
+    "next_month",
+    "previous_month",
+    "aggregate",
+    "csv_rows",
+    "parquet_rows",
+    "snake_case",
+    "BillingExport",
+    "BillingIngester",
+    "BillingIngestion",
+    "ActualsRetention",
+    "roll_up",
+    "AWSCURIngester",
+    "S3ReportSource",
+    "classify",
+    "parse_cur_rows",
+    "select_report_keys",
+    "COST_COLUMNS",
+    "AWS_CUR_SOURCE",
+    "BigQueryExportSource",
+    "GCPBillingExportIngester",
+    "export_query",
+    "parse_export_rows",
+    "classify_gcp",
+    "GCP_BIGQUERY_SOURCE",
+    "AzureCostExportIngester",
+    "BlobExportSource",
+    "select_export_blobs",
+    "parse_azure_export_rows",
+    "classify_azure",
+    "AZURE_COST_EXPORT_SOURCE",
+    "build_ingestion",
+]
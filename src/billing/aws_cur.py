@@ -0,0 +1,238 @@
+"""
+AWS Cost and Usage Report ingestion
+
+Reads CUR files of a billing period from S3: legacy CUR CSV (gzipped)
+assemblies, selected through the period's manifest, and Parquet reports
+(legacy year=/month= partitions and CUR 2.0 BILLING_PERIOD= exports).
+Column names are matched in snake case, so lineItem/UnblendedCost and
+line_item_unblended_cost are the same column.
+"""
+
+import json
+import logging
+from datetime import date
+from typing import Any, Dict, Iterable, Iterator, List, Optional, Tuple
+
+from ..pricing import SERVICE_COMPUTE, SERVICE_BLOCK_STORAGE, SERVICE_OBJECT_STORAGE, SERVICE_DATABASE
+from .ingest import BillingExport, BillingIngester
+from .models import BillingLine
+from .normalize import csv_rows, parquet_rows, project_of, snake_case, to_date, to_float
+
+logger = logging.getLogger(__name__)
+
+SOURCE = "aws-cur"
+
+# Cost column by cost type
+COST_COLUMNS = {
+    "unblended": "line_item_unblended_cost",
+    "net_unblended": "line_item_net_unblended_cost",
+    "blended": "line_item_blended_cost",
+}
+
+_DATA_SUFFIXES = (".csv", ".csv.gz", ".csv.zip", ".parquet")
+_TAG_PREFIX = "resource_tags_"
+_USER_TAG = "user_"
+
+_SERVICES = {
+    "AmazonS3": SERVICE_OBJECT_STORAGE,
+    "AmazonRDS": SERVICE_DATABASE,
+}
+# EC2 usage types billing instance hours, e.g. APN2-BoxUsage:m5.large
+_INSTANCE_USAGE = frozenset({"BoxUsage", "SpotUsage", "DedicatedUsage", "HostUsage"})
+_VOLUME_USAGE = "VolumeUsage."
+
+
+def classify(product_code: str, usage_type: str) -> Tuple[str, str]:
+    """
+    Estimator service and SKU of a CUR line
+
+    EC2 instance hours are billed by instance type and EBS volumes by
+    volume type, the SKUs estimates use; other lines keep the product code
+    as service and the usage type as SKU.
+    """
+    kind, _, detail = usage_type.partition(":")
+    # Usage types outside us-east-1 start with a region code, e.g. USE2-
+    kind = kind.rsplit("-", 1)[-1]
+    if product_code == "AmazonEC2":
+        if kind in _INSTANCE_USAGE and detail:
+            return SERVICE_COMPUTE, detail
+        if kind == "EBS":
+            return SERVICE_BLOCK_STORAGE, detail[len(_VOLUME_USAGE):] if detail.startswith(_VOLUME_USAGE) else usage_type
+    return _SERVICES.get(product_code, product_code), usage_type
+
+
+def row_labels(row: Dict[str, Any]) -> Dict[str, str]:
+    """User-defined cost allocation tags of a row (snake case columns), lower case without their user: prefix"""
+    labels: Dict[str, str] = {}
+    tags = row.get("resource_tags")
+    if isinstance(tags, str) and tags.startswith("{"):
+        tags = json.loads(tags)
+    if isinstance(tags, dict):
+        # CUR 2.0: one map column keyed user_<tag>
+        tags = {_TAG_PREFIX + _column_key(key): value for key, value in tags.items()}
+    else:
+        tags = row
+    for column, value in tags.items():
+        if not column.startswith(_TAG_PREFIX) or value in (None, ""):
+            continue
+        key = column[len(_TAG_PREFIX):]
+        if key.startswith(_USER_TAG):
+            labels[key[len(_USER_TAG):]] = str(value)
+    return labels
+
+
+def parse_cur_rows(
+    rows: Iterable[Dict[str, Any]],
+    cost: str = "unblended",
+    project_label: str = "project",
+) -> Iterator[BillingLine]:
+    """
+    Cost lines of CUR rows
+
+    Credits, refunds, taxes and fees are kept (credits are negative), so
+    the daily totals add up to the invoice.
+
+    Args:
+        rows: Rows keyed by CUR column names in any case style
+        cost: Cost type (unblended, net_unblended, blended)
+        project_label: Tag naming the project of a line
+    """
+    cost_column = COST_COLUMNS[cost]
+    for raw in rows:
+        row = {_column_key(name): value for name, value in raw.items()}
+        usage_date = to_date(row.get("line_item_usage_start_date"))
+        if usage_date is None:
+            continue
+        labels = row_labels(row)
+        service, sku = classify(
+            str(row.get("line_item_product_code") or ""),
+            str(row.get("line_item_usage_type") or ""),
+        )
+        yield BillingLine(
+            usage_date=usage_date,
+            provider="aws",
+            service=service,
+            region=str(row.get("product_region_code") or row.get("product_region") or ""),
+            account_id=str(row.get("line_item_usage_account_id") or ""),
+            sku=sku,
+            project=project_of(labels, project_label),
+            labels=labels,
+            usage_quantity=to_float(row.get("line_item_usage_amount")),
+            usage_unit=str(row.get("pricing_unit") or ""),
+            amount=to_float(row.get(cost_column)),
+            currency=str(row.get("line_item_currency_code") or "USD"),
+        )
+
+
+def _column_key(name: str) -> str:
+    # Tag columns keep user: apart from the tag name: resourceTags/user:Project -> resource_tags_user_project
+    return snake_case(name.replace(":", "_"))
+
+
+def period_markers(start: date, end: date) -> List[str]:
+    """Key fragments of the report folders of a billing period"""
+    return [
+        f"/{start:%Y%m%d}-{end:%Y%m%d}/",
+        f"/year={start.year}/month={start.month}/",
+        f"/BILLING_PERIOD={start:%Y-%m}/",
+    ]
+
+
+def select_report_keys(keys: List[str], start: date, end: date, manifests: Dict[str, List[str]]) -> List[str]:
+    """
+    Data files of a billing period
+
+    A legacy CUR folder keeps every assembly of the period next to a
+    manifest naming the current one; only the manifest's files are read.
+
+    Args:
+        keys: Object keys under the report prefix
+        start: First day of the period
+        end: First day after the period
+        manifests: Manifest key -> reportKeys, for the period's manifests
+    """
+    if manifests:
+        return sorted({key for report_keys in manifests.values() for key in report_keys})
+    markers = period_markers(start, end)
+    return sorted(
+        key for key in keys
+        if key.endswith(_DATA_SUFFIXES) and any(marker in "/" + key for marker in markers)
+    )
+
+
+class S3ReportSource:
+    """CUR files in an S3 bucket (uses the default boto3 credential chain)"""
+
+    def __init__(self, bucket: str, prefix: str = "", client: Optional[Any] = None):
+        """
+        Initialize report source
+
+        Args:
+            bucket: Bucket the report is delivered to
+            prefix: Key prefix of the report, up to and including the report name
+            client: boto3 S3 client. A default client is created on first use if not provided.
+        """
+        self.bucket = bucket
+        self.prefix = prefix.strip("/")
+        self._client = client
+
+    @property
+    def client(self):
+        if self._client is None:
+            import boto3
+            self._client = boto3.client("s3")
+        return self._client
+
+    def period_keys(self, start: date, end: date) -> List[str]:
+        """Data files of a billing period"""
+        keys = []
+        paginator = self.client.get_paginator("list_objects_v2")
+        for page in paginator.paginate(Bucket=self.bucket, Prefix=self.prefix + "/" if self.prefix else ""):
+            keys.extend(item["Key"] for item in page.get("Contents", []))
+
+        folder = period_markers(start, end)[0]
+        manifests = {
+            key: self._manifest_keys(key)
+            for key in keys
+            if key.endswith("-Manifest.json") and ("/" + key).rsplit("/", 1)[0].endswith(folder.rstrip("/"))
+        }
+        return select_report_keys(keys, start, end, manifests)
+
+    def rows(self, key: str) -> Iterator[Dict[str, Any]]:
+        """Rows of a data file"""
+        body = self.client.get_object(Bucket=self.bucket, Key=key)["Body"].read()
+        if key.endswith(".parquet"):
+            yield from parquet_rows(body)
+        else:
+            yield from csv_rows(body, key)
+
+    def _manifest_keys(self, key: str) -> List[str]:
+        document = json.loads(self.client.get_object(Bucket=self.bucket, Key=key)["Body"].read())
+        return list(document.get("reportKeys", []))
+
+
+class AWSCURIngester(BillingIngester):
+    """Billing export reader of AWS Cost and Usage Reports"""
+
+    source = SOURCE
+
+    def __init__(self, reports: S3ReportSource, cost: str = "unblended", project_label: str = "project"):
+        """
+        Initialize CUR ingester
+
+        Args:
+            reports: Where the report files are
+            cost: Cost type (unblended, net_unblended, blended)
+            project_label: Tag naming the project of a line
+        """
+        if cost not in COST_COLUMNS:
+            raise ValueError(f"Unknown CUR cost type {cost!r}; expected {', '.join(COST_COLUMNS)}")
+        self.reports = reports
+        self.cost = cost
+        self.project_label = project_label
+
+    def read(self, start: date, end: date) -> BillingExport:
+        keys = self.reports.period_keys(start, end)
+        logger.info(f"AWS CUR {start:%Y-%m}: {len(keys)} report files in s3://{self.reports.bucket}")
+        rows = (row for key in keys for row in self.reports.rows(key))
+        return BillingExport(files=keys, lines=parse_cur_rows(rows, self.cost, self.project_label))
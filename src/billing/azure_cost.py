@@ -0,0 +1,270 @@
+"""
+Azure Cost Management export ingestion
+
+Reads the cost details exports Cost Management writes to blob storage,
+one folder per billing period (e.g. 20260601-20260630). Every export run
+rewrites the month to date, so only the latest run of a period is read:
+the blobs of its manifest.json (partitioned exports), the files of its
+run folder, or the latest file of a legacy export. Both the EA
+(CostInBillingCurrency, MeterCategory) and MCA (costInBillingCurrency,
+meterCategory) column styles are accepted.
+"""
+
+import json
+import logging
+from datetime import date, datetime, timedelta
+from typing import Any, Dict, Iterable, Iterator, List, NamedTuple, Optional, Tuple
+
+from ..pricing import SERVICE_COMPUTE, SERVICE_BLOCK_STORAGE, SERVICE_OBJECT_STORAGE, SERVICE_DATABASE
+from .ingest import BillingExport, BillingIngester
+from .models import BillingLine
+from .normalize import csv_rows, parquet_rows, project_of, snake_case, to_float
+
+logger = logging.getLogger(__name__)
+
+SOURCE = "azure-cost-export"
+
+_DATA_SUFFIXES = (".csv", ".csv.gz", ".parquet")
+_MANIFEST = "manifest.json"
+
+# Cost columns by preference: USD when the export has it, else the billing currency
+_COST_COLUMNS = ("cost_in_usd", "cost_in_billing_currency", "cost", "pre_tax_cost")
+_CURRENCY_COLUMNS = ("billing_currency_code", "billing_currency", "currency")
+
+_DATABASE_CATEGORIES = ("SQL Database", "Azure Database for PostgreSQL", "Azure Database for MySQL", "Azure Cosmos DB")
+
+
+class ExportBlob(NamedTuple):
+    """A blob of the export's container"""
+
+    name: str
+    last_modified: datetime
+
+
+def classify(meter_category: str, meter_subcategory: str, meter_name: str, vm_size: str = "") -> Tuple[str, str]:
+    """Estimator service and SKU of a cost line, the meter category and name when it has none"""
+    if meter_category == "Virtual Machines":
+        return SERVICE_COMPUTE, vm_size or meter_subcategory or meter_name
+    if meter_category == "Storage":
+        if "Disk" in meter_subcategory or meter_name.endswith("Disk") or meter_name.endswith("Disks"):
+            return SERVICE_BLOCK_STORAGE, meter_name
+        if "Blob" in meter_subcategory or "Data Stored" in meter_name:
+            return SERVICE_OBJECT_STORAGE, meter_name
+    if meter_category in _DATABASE_CATEGORIES:
+        return SERVICE_DATABASE, meter_name
+    return meter_category, meter_name
+
+
+def parse_tags(value: Any) -> Dict[str, str]:
+    """Resource tags of a row; EA exports write them without the enclosing braces"""
+    if isinstance(value, dict):
+        tags = value
+    else:
+        value = str(value or "").strip()
+        if not value:
+            return {}
+        if not value.startswith("{"):
+            value = "{" + value + "}"
+        try:
+            tags = json.loads(value)
+        except ValueError:
+            return {}
+    return {str(key).lower(): str(v) for key, v in tags.items() if v not in (None, "")}
+
+
+def parse_date(value: Any) -> Optional[date]:
+    """Usage date, written MM/DD/YYYY or YYYY-MM-DD depending on the export"""
+    if isinstance(value, datetime):
+        return value.date()
+    if isinstance(value, date):
+        return value
+    value = str(value or "").strip()
+    if not value:
+        return None
+    if "/" in value:
+        return datetime.strptime(value.split(" ", 1)[0], "%m/%d/%Y").date()
+    return date.fromisoformat(value[:10])
+
+
+def _vm_size(additional_info: Any) -> str:
+    if isinstance(additional_info, str) and additional_info.startswith("{"):
+        try:
+            additional_info = json.loads(additional_info)
+        except ValueError:
+            return ""
+    if isinstance(additional_info, dict):
+        return str(additional_info.get("ServiceType") or "")
+    return ""
+
+
+def _first(row: Dict[str, Any], columns: Iterable[str]) -> Tuple[Optional[str], Any]:
+    for column in columns:
+        if row.get(column) not in (None, ""):
+            return column, row[column]
+    return None, None
+
+
+def parse_export_rows(rows: Iterable[Dict[str, Any]], project_label: str = "project") -> Iterator[BillingLine]:
+    """
+    Cost lines of cost details rows
+
+    Args:
+        rows: Rows keyed by export column names in any case style
+        project_label: Tag naming the project of a line
+    """
+    for raw in rows:
+        row = {snake_case(name): value for name, value in raw.items()}
+        usage_date = parse_date(row.get("date") or row.get("usage_date_time"))
+        if usage_date is None:
+            continue
+        tags = parse_tags(row.get("tags"))
+        service, sku = classify(
+            str(row.get("meter_category") or ""),
+            str(row.get("meter_sub_category") or row.get("meter_subcategory") or ""),
+            str(row.get("meter_name") or ""),
+            _vm_size(row.get("additional_info")),
+        )
+        cost_column, amount = _first(row, _COST_COLUMNS)
+        currency = "USD" if cost_column == "cost_in_usd" else str(_first(row, _CURRENCY_COLUMNS)[1] or "USD")
+        yield BillingLine(
+            usage_date=usage_date,
+            provider="azure",
+            service=service,
+            region=str(row.get("resource_location") or ""),
+            account_id=str(row.get("subscription_id") or row.get("subscription_guid") or ""),
+            sku=sku,
+            project=project_of(tags, project_label),
+            labels=tags,
+            usage_quantity=to_float(row.get("quantity")),
+            usage_unit=str(row.get("unit_of_measure") or ""),
+            amount=to_float(amount),
+            currency=currency,
+        )
+
+
+def period_folder(start: date, end: date) -> str:
+    """Folder of a billing period's export runs; the end day is inclusive"""
+    return f"{start:%Y%m%d}-{end - timedelta(days=1):%Y%m%d}"
+
+
+def select_export_blobs(
+    blobs: List[ExportBlob], start: date, end: date, manifests: Dict[str, List[str]]
+) -> List[str]:
+    """
+    Data files of the latest export run of a billing period
+
+    Args:
+        blobs: Blobs under the export's prefix
+        start: First day of the period
+        end: First day after the period
+        manifests: Blob names of each manifest of the period, by manifest name
+    """
+    folder = "/" + period_folder(start, end) + "/"
+    in_period = [b for b in blobs if folder in "/" + b.name]
+
+    manifest_blobs = sorted(
+        (b for b in in_period if b.name.rsplit("/", 1)[-1] == _MANIFEST and b.name in manifests),
+        key=lambda b: b.last_modified,
+    )
+    if manifest_blobs:
+        return sorted(manifests[manifest_blobs[-1].name])
+
+    data = [b for b in in_period if b.name.endswith(_DATA_SUFFIXES)]
+    if not data:
+        return []
+    latest = max(data, key=lambda b: b.last_modified)
+    run = latest.name.rsplit("/", 1)[0]
+    if ("/" + run + "/").endswith(folder):
+        # Legacy exports write each run as one file straight into the period folder
+        return [latest.name]
+    return sorted(b.name for b in data if b.name.rsplit("/", 1)[0] == run)
+
+
+class BlobExportSource:
+    """Cost Management export in a blob container"""
+
+    def __init__(
+        self,
+        container: str,
+        prefix: str = "",
+        account_url: str = "",
+        connection_string: str = "",
+        client: Optional[Any] = None,
+    ):
+        """
+        Initialize export source
+
+        Args:
+            container: Container the export is written to
+            prefix: Export directory and name, e.g. exports/kcloud-costs
+            account_url: Storage account URL, authenticated with DefaultAzureCredential
+            connection_string: Storage account connection string, used instead of account_url
+            client: azure.storage.blob ContainerClient. Created on first use if not provided.
+        """
+        self.container = container
+        self.prefix = prefix.strip("/")
+        self.account_url = account_url
+        self.connection_string = connection_string
+        self._client = client
+
+    @property
+    def client(self):
+        if self._client is None:
+            from azure.storage.blob import ContainerClient
+
+            if self.connection_string:
+                self._client = ContainerClient.from_connection_string(self.connection_string, self.container)
+            else:
+                from azure.identity import DefaultAzureCredential
+
+                self._client = ContainerClient(self.account_url, self.container, credential=DefaultAzureCredential())
+        return self._client
+
+    def period_blobs(self, start: date, end: date) -> List[str]:
+        """Data files of the latest export run of a billing period"""
+        blobs = [
+            ExportBlob(b.name, b.last_modified)
+            for b in self.client.list_blobs(name_starts_with=self.prefix + "/" if self.prefix else None)
+        ]
+        folder = "/" + period_folder(start, end) + "/"
+        manifests = {
+            b.name: self._manifest_blobs(b.name)
+            for b in blobs
+            if b.name.rsplit("/", 1)[-1] == _MANIFEST and folder in "/" + b.name
+        }
+        return select_export_blobs(blobs, start, end, manifests)
+
+    def rows(self, name: str) -> Iterator[Dict[str, Any]]:
+        """Rows of a data file"""
+        body = self.client.download_blob(name).readall()
+        if name.endswith(".parquet"):
+            yield from parquet_rows(body)
+        else:
+            yield from csv_rows(body, name)
+
+    def _manifest_blobs(self, name: str) -> List[str]:
+        document = json.loads(self.client.download_blob(name).readall())
+        return [blob["blobName"] for blob in document.get("blobs", []) if blob.get("blobName")]
+
+
+class AzureCostExportIngester(BillingIngester):
+    """Billing export reader of Azure Cost Management exports"""
+
+    source = SOURCE
+
+    def __init__(self, export: BlobExportSource, project_label: str = "project"):
+        """
+        Initialize Cost Management export ingester
+
+        Args:
+            export: Where the export is written
+            project_label: Tag naming the project of a line
+        """
+        self.export = export
+        self.project_label = project_label
+
+    def read(self, start: date, end: date) -> BillingExport:
+        names = self.export.period_blobs(start, end)
+        logger.info(f"Azure cost export {start:%Y-%m}: {len(names)} files in container {self.export.container}")
+        rows = (row for name in names for row in self.export.rows(name))
+        return BillingExport(files=names, lines=parse_export_rows(rows, self.project_label))
@@ -0,0 +1,48 @@
+"""
+Billing ingestion from application settings
+"""
+
+from typing import Optional
+
+from ..store import Store
+from .aws_cur import AWSCURIngester, S3ReportSource
+from .azure_cost import AzureCostExportIngester, BlobExportSource
+from .gcp_bigquery import BigQueryExportSource, GCPBillingExportIngester
+from .ingest import BillingIngestion
+
+
+def build_ingestion(settings, store: Store) -> Optional[BillingIngestion]:
+    """Ingestion of the configured billing exports, None when none is configured"""
+    ingesters = []
+    if settings.aws_cur_bucket:
+        ingesters.append(AWSCURIngester(
+            S3ReportSource(settings.aws_cur_bucket, settings.aws_cur_prefix),
+            cost=settings.aws_cur_cost,
+            project_label=settings.billing_project_label,
+        ))
+    if settings.gcp_billing_export_table:
+        ingesters.append(GCPBillingExportIngester(
+            BigQueryExportSource(
+                settings.gcp_billing_export_table,
+                cost=settings.gcp_billing_export_cost,
+                project=settings.gcp_billing_export_project,
+            ),
+            project_label=settings.billing_project_label,
+        ))
+
+    if settings.azure_cost_export_container and (
+        settings.azure_cost_export_account_url or settings.azure_cost_export_connection_string
+    ):
+        ingesters.append(AzureCostExportIngester(
+            BlobExportSource(
+                settings.azure_cost_export_container,
+                prefix=settings.azure_cost_export_prefix,
+                account_url=settings.azure_cost_export_account_url,
+                connection_string=settings.azure_cost_export_connection_string,
+            ),
+            project_label=settings.billing_project_label,
+        ))
+
+    if not ingesters:
+        return None
+    return BillingIngestion(store, settings.billing_tenant, ingesters)
@@ -0,0 +1,181 @@
+"""
+GCP Cloud Billing BigQuery export ingestion
+
+Queries the standard (or detailed) usage cost export table for a billing
+period, rolled up to days in BigQuery so only daily totals are
+transferred. Credits (sustained and committed use discounts, promotions)
+are subtracted from the cost unless gross costs are configured.
+"""
+
+import json
+import logging
+import re
+from datetime import date
+from typing import Any, Dict, Iterable, Iterator, Optional, Tuple
+
+from ..pricing import SERVICE_COMPUTE, SERVICE_BLOCK_STORAGE, SERVICE_OBJECT_STORAGE, SERVICE_DATABASE
+from .ingest import BillingExport, BillingIngester
+from .models import BillingLine
+from .normalize import project_of, to_date, to_float
+
+logger = logging.getLogger(__name__)
+
+SOURCE = "gcp-bigquery"
+
+COST_NET = "net"
+COST_GROSS = "gross"
+COST_TYPES = (COST_NET, COST_GROSS)
+
+# project.dataset.table
+_TABLE = re.compile(r"^[A-Za-z0-9_.:-]+\.[A-Za-z0-9_]+\.[A-Za-z0-9_]+$")
+
+# Costs of a usage day keep arriving in later export partitions for a few days
+_LATE_DATA_DAYS = 5
+
+_SERVICES = {
+    "Cloud Storage": SERVICE_OBJECT_STORAGE,
+    "Cloud SQL": SERVICE_DATABASE,
+}
+_INSTANCE_SKUS = ("Instance Core", "Instance Ram")
+_DISK_SKUS = "PD Capacity"
+
+
+def export_query(table: str, cost: str = COST_NET) -> str:
+    """Daily cost query of an export table, with @start and @end DATE parameters"""
+    if not _TABLE.match(table):
+        raise ValueError(f"Invalid BigQuery table {table!r}, expected project.dataset.table")
+    if cost not in COST_TYPES:
+        raise ValueError(f"Unknown GCP cost type {cost!r}; expected {', '.join(COST_TYPES)}")
+
+    amount = "cost"
+    if cost == COST_NET:
+        amount = "cost + IFNULL((SELECT SUM(c.amount) FROM UNNEST(credits) AS c), 0)"
+    return f"""
+        SELECT
+            DATE(usage_start_time) AS usage_date,
+            service.description AS service,
+            sku.description AS sku,
+            IFNULL(location.region, '') AS region,
+            IFNULL(project.id, '') AS project_id,
+            TO_JSON_STRING(labels) AS labels,
+            usage.pricing_unit AS usage_unit,
+            currency,
+            SUM(usage.amount_in_pricing_units) AS usage_quantity,
+            SUM({amount}) AS amount
+        FROM `{table}`
+        WHERE DATE(_PARTITIONTIME) BETWEEN DATE_SUB(@start, INTERVAL 1 DAY) AND DATE_ADD(@end, INTERVAL {_LATE_DATA_DAYS} DAY)
+            AND DATE(usage_start_time) >= @start AND DATE(usage_start_time) < @end
+        GROUP BY usage_date, service, sku, region, project_id, labels, usage_unit, currency
+    """
+
+
+def classify(service: str, sku: str) -> Tuple[str, str]:
+    """Estimator service of an export line, the service description when it has none"""
+    if service == "Compute Engine":
+        if any(kind in sku for kind in _INSTANCE_SKUS):
+            return SERVICE_COMPUTE, sku
+        if _DISK_SKUS in sku:
+            return SERVICE_BLOCK_STORAGE, sku
+    return _SERVICES.get(service, service), sku
+
+
+def row_labels(value: Any) -> Dict[str, str]:
+    """Labels of a row, from the export's [{"key": ..., "value": ...}] array"""
+    if isinstance(value, str):
+        value = json.loads(value) if value else []
+    return {
+        str(item["key"]).lower(): str(item.get("value") or "")
+        for item in value or []
+        if item.get("key")
+    }
+
+
+def parse_export_rows(rows: Iterable[Dict[str, Any]], project_label: str = "project") -> Iterator[BillingLine]:
+    """
+    Cost lines of daily export query rows
+
+    Args:
+        rows: Rows of export_query
+        project_label: Label naming the project of a line
+    """
+    for row in rows:
+        usage_date = to_date(row.get("usage_date"))
+        if usage_date is None:
+            continue
+        labels = row_labels(row.get("labels"))
+        service, sku = classify(str(row.get("service") or ""), str(row.get("sku") or ""))
+        yield BillingLine(
+            usage_date=usage_date,
+            provider="gcp",
+            service=service,
+            region=str(row.get("region") or ""),
+            account_id=str(row.get("project_id") or ""),
+            sku=sku,
+            project=project_of(labels, project_label),
+            labels=labels,
+            usage_quantity=to_float(row.get("usage_quantity")),
+            usage_unit=str(row.get("usage_unit") or ""),
+            amount=to_float(row.get("amount")),
+            currency=str(row.get("currency") or "USD"),
+        )
+
+
+class BigQueryExportSource:
+    """Billing export table in BigQuery (uses Application Default Credentials)"""
+
+    def __init__(self, table: str, cost: str = COST_NET, project: str = "", client: Optional[Any] = None):
+        """
+        Initialize export source
+
+        Args:
+            table: Export table, project.dataset.table
+            cost: Cost type (net of credits, or gross)
+            project: Project query jobs run and are billed in, default the table's project
+            client: google.cloud.bigquery Client. Created on first use if not provided.
+        """
+        self.query = export_query(table, cost)
+        self.table = table
+        self.project = project or table.split(".", 1)[0]
+        self._client = client
+
+    @property
+    def client(self):
+        if self._client is None:
+            from google.cloud import bigquery
+            self._client = bigquery.Client(project=self.project)
+        return self._client
+
+    def rows(self, start: date, end: date) -> Iterator[Dict[str, Any]]:
+        """Daily cost rows of a billing period"""
+        from google.cloud import bigquery
+
+        config = bigquery.QueryJobConfig(query_parameters=[
+            bigquery.ScalarQueryParameter("start", "DATE", start),
+            bigquery.ScalarQueryParameter("end", "DATE", end),
+        ])
+        for row in self.client.query(self.query, job_config=config).result():
+            yield dict(row.items())
+
+
+class GCPBillingExportIngester(BillingIngester):
+    """Billing export reader of the GCP Cloud Billing BigQuery export"""
+
+    source = SOURCE
+
+    def __init__(self, export: BigQueryExportSource, project_label: str = "project"):
+        """
+        Initialize BigQuery export ingester
+
+        Args:
+            export: Where the export table is
+            project_label: Label naming the project of a line
+        """
+        self.export = export
+        self.project_label = project_label
+
+    def read(self, start: date, end: date) -> BillingExport:
+        logger.info(f"GCP billing export {start:%Y-%m}: querying {self.export.table}")
+        return BillingExport(
+            files=[self.export.table],
+            lines=parse_export_rows(self.export.rows(start, end), self.project_label),
+        )
@@ -0,0 +1,144 @@
+"""
+Billing export ingestion
+
+An ingester reads one billing period of an export as cost lines in the
+common schema. Ingestion rolls them up to daily actual costs and replaces
+the period's previously ingested costs of that export, so exports that
+are restated during the month can be ingested again and again.
+"""
+
+import logging
+from abc import ABC, abstractmethod
+from datetime import date, datetime, timezone
+from typing import Callable, Dict, Iterable, List, NamedTuple, Optional, Tuple
+
+from ..store import Store
+from .models import BillingLine, IngestResult, next_month, previous_month
+from .normalize import aggregate
+
+logger = logging.getLogger(__name__)
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+class BillingExport(NamedTuple):
+    """Cost lines of a billing period and the files they were read from"""
+
+    files: List[str]
+    lines: Iterable[BillingLine]
+
+
+class BillingIngester(ABC):
+    """Reader of one kind of billing export"""
+
+    # Source name the costs are stored under, e.g. aws-cur
+    source: str = ""
+
+    @abstractmethod
+    def read(self, start: date, end: date) -> BillingExport:
+        """
+        Cost lines of a billing period
+
+        Args:
+            start: First day of the period
+            end: First day after the period
+        """
+
+
+class BillingIngestion:
+    """Stores billing exports as a tenant's actual costs"""
+
+    def __init__(
+        self,
+        store: Store,
+        tenant_id: str,
+        ingesters: List[BillingIngester],
+        clock: Callable[[], datetime] = utcnow,
+    ):
+        """
+        Initialize ingestion
+
+        Args:
+            store: Store the actual costs are kept in
+            tenant_id: Tenant the exports' costs belong to
+            ingesters: Readers of the configured exports
+            clock: Current time (aware UTC)
+        """
+        self.store = store
+        self.tenant_id = tenant_id
+        self.clock = clock
+        self._ingesters: Dict[str, BillingIngester] = {i.source: i for i in ingesters}
+
+    @property
+    def sources(self) -> List[str]:
+        """Configured export sources"""
+        return sorted(self._ingesters)
+
+    def ingest(self, source: str, start: Optional[date] = None) -> IngestResult:
+        """
+        Ingest one billing month of an export
+
+        Args:
+            source: Export source, e.g. aws-cur
+            start: First day of the month, default the current month
+
+        Raises:
+            KeyError: If the source is not configured
+        """
+        ingester = self._ingesters.get(source)
+        if ingester is None:
+            raise KeyError(f"Billing source {source} is not configured; configured: {', '.join(self.sources) or 'none'}")
+
+        start = start or self.clock().date().replace(day=1)
+        end = next_month(start)
+        export = ingester.read(start, end)
+
+        rows = 0
+
+        def counted(lines: Iterable[BillingLine]) -> Iterable[BillingLine]:
+            nonlocal rows
+            for line in lines:
+                rows += 1
+                if start <= line.usage_date < end:
+                    yield line
+
+        records = aggregate(counted(export.lines), self.tenant_id, source)
+        records = self.store.replace_actual_costs(self.tenant_id, source, start, end, records)
+        result = IngestResult(
+            source=source,
+            tenant_id=self.tenant_id,
+            period_start=start,
+            period_end=end,
+            files=export.files,
+            rows=rows,
+            records=len(records),
+            total_amount=round(sum(r.amount for r in records), 4),
+            ingested_at=self.clock(),
+        )
+        logger.info(
+            f"Ingested {source} {start:%Y-%m}: {rows} lines from {len(export.files)} files, "
+            f"{len(records)} daily costs, ${result.total_amount:,.2f}"
+        )
+        return result
+
+    def recent(self) -> List[Tuple[str, date]]:
+        """
+        Source and first day of the month of every export month ingested periodically
+
+        The previous month is included because exports are restated until
+        the invoice is final.
+        """
+        current = self.clock().date().replace(day=1)
+        return [(source, start) for source in self.sources for start in (previous_month(current), current)]
+
+    def ingest_recent(self) -> List[IngestResult]:
+        """Ingest the previous and the current month of every export; failures are logged and the export skipped"""
+        results = []
+        for source, start in self.recent():
+            try:
+                results.append(self.ingest(source, start))
+            except Exception as e:
+                logger.error(f"Billing ingestion of {source} {start:%Y-%m} failed: {e}")
+        return results
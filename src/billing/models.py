@@ -0,0 +1,88 @@
+"""
+Data models for billing export ingestion
+"""
+
+import re
+from datetime import date, datetime
+from typing import Dict, List, NamedTuple, Optional, Tuple
+
+from pydantic import BaseModel, Field, validator
+
+_MONTH = re.compile(r"^(\d{4})-(\d{2})$")
+
+
+class BillingLine(NamedTuple):
+    """One cost line of a billing export in the common schema"""
+
+    usage_date: date
+    provider: str
+    service: str
+    region: str
+    account_id: str
+    sku: str
+    project: Optional[str]
+    labels: Dict[str, str]
+    usage_quantity: float
+    usage_unit: str
+    amount: float
+    currency: str
+
+
+def month_bounds(month: str) -> Tuple[date, date]:
+    """First day of a YYYY-MM month and of the month after it"""
+    match = _MONTH.match(month)
+    if not match or not 1 <= int(match.group(2)) <= 12:
+        raise ValueError(f"Invalid month {month!r}, expected YYYY-MM")
+    start = date(int(match.group(1)), int(match.group(2)), 1)
+    return start, next_month(start)
+
+
+def next_month(start: date) -> date:
+    """First day of the month after a date's month"""
+    return date(start.year + start.month // 12, start.month % 12 + 1, 1)
+
+
+def previous_month(start: date) -> date:
+    """First day of the month before a date's month"""
+    return date(start.year - (start.month == 1), (start.month - 2) % 12 + 1, 1)
+
+
+class IngestRequest(BaseModel):
+    """Billing export period to ingest through POST /admin/billing/ingest"""
+
+    source: str = Field(..., description="Billing export: aws-cur, gcp-bigquery or azure-cost-export")
+    month: Optional[str] = Field(None, description="Billing month YYYY-MM; default the current month")
+
+    @validator("month")
+    def validate_month(cls, v):
+        if v is not None:
+            month_bounds(v)
+        return v
+
+
+class IngestResult(BaseModel):
+    """Outcome of ingesting one billing period of an export"""
+
+    source: str
+    tenant_id: str
+    period_start: date
+    period_end: date = Field(..., description="First day after the period")
+    files: List[str] = Field(default_factory=list, description="Export files or tables read")
+    rows: int = Field(0, description="Cost lines read")
+    records: int = Field(0, description="Daily actual costs stored, replacing the period's previous ones")
+    total_amount: float = 0.0
+    ingested_at: Optional[datetime] = None
+
+
+class CompactionResult(BaseModel):
+    """Outcome of rolling up and expiring aged actual costs"""
+
+    raw_before: Optional[date] = Field(None, description="Raw costs before this day were rolled up to daily costs")
+    daily_before: Optional[date] = Field(None, description="Costs before this month were rolled up to monthly costs")
+    expire_before: Optional[date] = Field(None, description="Costs before this month were deleted")
+    raw_compacted: int = Field(0, description="Raw costs rolled up to daily costs")
+    daily_written: int = Field(0, description="Daily costs written")
+    daily_compacted: int = Field(0, description="Raw and daily costs rolled up to monthly costs")
+    monthly_written: int = Field(0, description="Monthly costs written")
+    expired: int = Field(0, description="Costs deleted")
+    compacted_at: Optional[datetime] = None
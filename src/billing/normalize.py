@@ -0,0 +1,114 @@
+"""
+Normalization shared by billing export readers
+
+Export files are read as rows of column name -> value; cost lines are
+rolled up to one actual cost per day, account, region, service, SKU,
+project and label set before they are stored.
+"""
+
+import csv
+import gzip
+import io
+import re
+import zipfile
+from datetime import date, datetime
+from typing import Any, Dict, Iterable, Iterator, List, Optional, Tuple
+
+from ..store import ActualCostRecord
+from .models import BillingLine
+
+_CAMEL = re.compile(r"(?<=[a-z0-9])(?=[A-Z])")
+_NON_WORD = re.compile(r"[^a-z0-9]+")
+
+
+def snake_case(name: str) -> str:
+    """Column name in snake case, e.g. lineItem/UsageStartDate -> line_item_usage_start_date"""
+    return _NON_WORD.sub("_", _CAMEL.sub("_", name).lower()).strip("_")
+
+
+def csv_rows(body: bytes, name: str = "") -> Iterator[Dict[str, str]]:
+    """Rows of a CSV export file, gzipped or zipped when its name says so"""
+    if name.endswith(".gz"):
+        body = gzip.decompress(body)
+    elif name.endswith(".zip"):
+        with zipfile.ZipFile(io.BytesIO(body)) as archive:
+            body = archive.read(archive.namelist()[0])
+    yield from csv.DictReader(io.StringIO(body.decode("utf-8-sig")))
+
+
+def parquet_rows(body: bytes, batch_size: int = 10000) -> Iterator[Dict[str, Any]]:
+    """Rows of a Parquet export file"""
+    import pyarrow.parquet as pq
+
+    report = pq.ParquetFile(io.BytesIO(body))
+    for batch in report.iter_batches(batch_size=batch_size):
+        for row in batch.to_pylist():
+            for column, value in row.items():
+                # Parquet maps are read as (key, value) pairs
+                if isinstance(value, list) and value and all(isinstance(v, tuple) for v in value):
+                    row[column] = dict(value)
+            yield row
+
+
+def to_float(value: Any) -> float:
+    """Numeric export value; empty cells count as zero"""
+    if value is None or value == "":
+        return 0.0
+    return float(value)
+
+
+def to_date(value: Any) -> Optional[date]:
+    """Date of an export timestamp (ISO 8601 text, datetime, or date)"""
+    if isinstance(value, datetime):
+        return value.date()
+    if isinstance(value, date):
+        return value
+    if not value:
+        return None
+    return date.fromisoformat(str(value)[:10])
+
+
+def project_of(labels: Dict[str, str], project_label: str) -> Optional[str]:
+    """Project named by a label, matched case-insensitively"""
+    wanted = project_label.lower()
+    for key, value in labels.items():
+        if key.lower() == wanted and value:
+            return value
+    return None
+
+
+def aggregate(lines: Iterable[BillingLine], tenant_id: str, source: str) -> List[ActualCostRecord]:
+    """Roll cost lines up to daily actual costs, ordered by day"""
+    totals: Dict[Tuple, ActualCostRecord] = {}
+    for line in lines:
+        key = (
+            line.usage_date, line.provider, line.service, line.region, line.account_id,
+            line.sku, line.project or "", tuple(sorted(line.labels.items())), line.currency,
+        )
+        record = totals.get(key)
+        if record is None:
+            totals[key] = ActualCostRecord(
+                tenant_id=tenant_id,
+                source=source,
+                usage_date=line.usage_date,
+                provider=line.provider,
+                service=line.service,
+                region=line.region,
+                account_id=line.account_id,
+                sku=line.sku,
+                project=line.project,
+                labels=dict(line.labels),
+                amount=line.amount,
+                currency=line.currency,
+                usage_quantity=line.usage_quantity,
+                usage_unit=line.usage_unit,
+            )
+        else:
+            record.amount += line.amount
+            record.usage_quantity += line.usage_quantity
+
+    records = [totals[key] for key in sorted(totals, key=lambda k: (k[0], str(k[1:])))]
+    for record in records:
+        record.amount = round(record.amount, 6)
+        record.usage_quantity = round(record.usage_quantity, 6)
+    return records
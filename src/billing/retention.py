@@ -0,0 +1,160 @@
+"""
+Retention of actual costs
+
+Raw actual costs, one per day, SKU and label set as ingested or recorded,
+are kept for the raw retention period. Older ones are rolled up to daily
+costs per service, region, account, project and labels, dropping the SKU;
+daily costs older than the daily retention period are rolled up to
+monthly costs dated the first of their month, and monthly costs older
+than the monthly retention period are deleted. A period of 0 keeps its
+costs forever.
+
+Stored costs stay at one resolution per day, so queries read whichever
+is kept for a day. The store lists monthly costs prorated by the days of
+a range that covers their month only partly.
+"""
+
+import logging
+from datetime import date, datetime, timedelta, timezone
+from typing import Callable, Dict, Iterable, Iterator, List, Optional, Sequence, Tuple
+
+from ..store import ActualCostRecord, RESOLUTION_DAILY, RESOLUTION_MONTHLY, RESOLUTION_RAW, RESOLUTIONS, Store
+from .models import CompactionResult, next_month
+
+logger = logging.getLogger(__name__)
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+def roll_up(records: Iterable[ActualCostRecord], resolution: str) -> List[ActualCostRecord]:
+    """
+    Roll actual costs up to daily or monthly costs, ordered by tenant and day
+
+    Costs of different SKUs add up; usage quantities add up per usage unit.
+    """
+    totals: Dict[Tuple, ActualCostRecord] = {}
+    for record in records:
+        day = record.usage_date.replace(day=1) if resolution == RESOLUTION_MONTHLY else record.usage_date
+        key = (
+            record.tenant_id, day, record.source, record.provider, record.service, record.region,
+            record.account_id, record.project or "", tuple(sorted(record.labels.items())), record.currency,
+            record.usage_unit,
+        )
+        total = totals.get(key)
+        if total is None:
+            totals[key] = record.copy(update={
+                "usage_date": day,
+                "labels": dict(record.labels),
+                "sku": "",
+                "resolution": resolution,
+                "recorded_at": None,
+            })
+        else:
+            total.amount += record.amount
+            total.usage_quantity += record.usage_quantity
+
+    rollups = [totals[key] for key in sorted(totals, key=lambda k: (k[0], k[1], str(k[2:])))]
+    for record in rollups:
+        record.amount = round(record.amount, 6)
+        record.usage_quantity = round(record.usage_quantity, 6)
+    return rollups
+
+
+class ActualsRetention:
+    """Rolls up and expires aged actual costs of every tenant"""
+
+    def __init__(
+        self,
+        store: Store,
+        raw_days: int = 90,
+        daily_days: int = 730,
+        monthly_days: int = 0,
+        clock: Callable[[], datetime] = utcnow,
+    ):
+        """
+        Initialize retention
+
+        Args:
+            store: Store the actual costs are kept in
+            raw_days: Days raw costs are kept before they are rolled up to daily costs, 0 forever
+            daily_days: Days costs are kept before they are rolled up to monthly costs, 0 forever
+            monthly_days: Days costs are kept before they are deleted, 0 forever
+            clock: Current time (aware UTC)
+
+        Raises:
+            ValueError: If a period is negative or not longer than a shorter one
+        """
+        periods = [("raw", raw_days), ("daily", daily_days), ("monthly", monthly_days)]
+        for name, days in periods:
+            if days < 0:
+                raise ValueError(f"The {name} retention period must not be negative, got {days} days")
+        kept = [(name, days) for name, days in periods if days > 0]
+        for (shorter, short_days), (longer, long_days) in zip(kept, kept[1:]):
+            if long_days <= short_days:
+                raise ValueError(
+                    f"The {longer} retention period ({long_days} days) must be longer than "
+                    f"the {shorter} one ({short_days} days)"
+                )
+        self.store = store
+        self.raw_days = raw_days
+        self.daily_days = daily_days
+        self.monthly_days = monthly_days
+        self.clock = clock
+
+    def cutoffs(self, today: Optional[date] = None) -> Tuple[Optional[date], Optional[date], Optional[date]]:
+        """
+        First usage dates kept raw, kept daily and kept at all, None for periods kept forever
+
+        Only whole months are rolled up to monthly costs or deleted.
+        """
+        today = today or self.clock().date()
+        raw_before = today - timedelta(days=self.raw_days) if self.raw_days else None
+        daily_before = (today - timedelta(days=self.daily_days)).replace(day=1) if self.daily_days else None
+        expire_before = (today - timedelta(days=self.monthly_days)).replace(day=1) if self.monthly_days else None
+        return raw_before, daily_before, expire_before
+
+    def compact(self, today: Optional[date] = None) -> CompactionResult:
+        """Roll up and delete aged costs; each month is rolled up in one transaction"""
+        raw_before, daily_before, expire_before = self.cutoffs(today)
+        result = CompactionResult(raw_before=raw_before, daily_before=daily_before, expire_before=expire_before)
+
+        if raw_before is not None:
+            for since, until in self._months((RESOLUTION_RAW,), raw_before):
+                records = self.store.scan_actual_costs((RESOLUTION_RAW, RESOLUTION_DAILY), since, until)
+                rollups = roll_up(records, RESOLUTION_DAILY)
+                self.store.replace_actual_cost_rollups((RESOLUTION_RAW, RESOLUTION_DAILY), since, until, rollups)
+                result.raw_compacted += sum(r.resolution == RESOLUTION_RAW for r in records)
+                result.daily_written += len(rollups)
+
+        if daily_before is not None:
+            for since, until in self._months((RESOLUTION_RAW, RESOLUTION_DAILY), daily_before):
+                records = self.store.scan_actual_costs(RESOLUTIONS, since, until)
+                rollups = roll_up(records, RESOLUTION_MONTHLY)
+                self.store.replace_actual_cost_rollups(RESOLUTIONS, since, until, rollups)
+                result.daily_compacted += sum(r.resolution != RESOLUTION_MONTHLY for r in records)
+                result.monthly_written += len(rollups)
+
+        if expire_before is not None:
+            result.expired = sum(self.store.delete_actual_costs(r, expire_before) for r in RESOLUTIONS)
+
+        result.compacted_at = self.clock()
+        if result.raw_compacted or result.daily_compacted or result.expired:
+            logger.info(
+                f"Compacted actual costs: {result.raw_compacted} raw into {result.daily_written} daily, "
+                f"{result.daily_compacted} into {result.monthly_written} monthly, {result.expired} expired"
+            )
+        return result
+
+    def _months(self, resolutions: Sequence[str], until: date) -> Iterator[Tuple[date, date]]:
+        """Usage date ranges, a month at most, from the earliest cost of the resolutions to until"""
+        earliest = [self.store.earliest_actual_cost(r, until) for r in resolutions]
+        earliest = [day for day in earliest if day is not None]
+        if not earliest:
+            return
+        since = min(earliest).replace(day=1)
+        while since < until:
+            end = min(next_month(since), until)
+            yield since, end
+            since = end
@@ -0,0 +1,31 @@
+"""
+Budgets Module
+
+This module keeps monthly budgets of a tenant, a project or a set of
+labels, and evaluates actual spend and incoming estimates against their
+warning thresholds (50/80/100% by default).
+"""
+
+from .models import (
+    ActualCostBatch,
+    ActualCostEntry,
+    Budget,
+    BudgetSpec,
+    BudgetStatus,
+    BudgetWarning,
+    DEFAULT_THRESHOLDS,
+)
+from .evaluator import BudgetEvaluator, highest_threshold, month_period
+
+__all__ = [
+    "ActualCostBatch",
+    "ActualCostEntry",
+    "Budget",
+    "BudgetSpec",
+    "BudgetStatus",
+    "BudgetWarning",
+    "DEFAULT_THRESHOLDS",
+    "BudgetEvaluator",
+    "highest_threshold",
+    "month_period",
+]
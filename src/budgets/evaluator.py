@@ -0,0 +1,156 @@
+"""
+Budget evaluation
+
+Actual spend recorded for the current month is extrapolated to the end
+of the month at its daily run rate. An estimate's projected spend is that
+projection plus the estimate's monthly cost; budgets whose thresholds the
+projected spend reaches are reported as warnings with the estimate.
+"""
+
+import calendar
+import logging
+from datetime import date, datetime, timedelta, timezone
+from typing import Callable, Dict, List, Optional, Tuple
+
+from ..money import round_money
+from ..store import Store, StoreError
+from ..tenancy import current_tenant
+from .models import Budget, BudgetStatus, BudgetWarning
+
+logger = logging.getLogger(__name__)
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+def month_period(now: datetime) -> Tuple[date, date, float]:
+    """
+    Calendar month containing a time
+
+    Returns:
+        (first day, first day of the next month, fraction of the month elapsed)
+    """
+    start = now.date().replace(day=1)
+    days = calendar.monthrange(start.year, start.month)[1]
+    end = start + timedelta(days=days)
+    elapsed = (now - datetime(start.year, start.month, 1, tzinfo=now.tzinfo)).total_seconds() / 86400
+    # At least one day, so the first hours of a month do not extrapolate wildly
+    return start, end, max(1.0, elapsed) / days
+
+
+def highest_threshold(thresholds: List[float], utilization: float) -> Optional[float]:
+    """Highest threshold a utilization reaches, None below all of them"""
+    reached = [t for t in thresholds if utilization >= t]
+    return max(reached) if reached else None
+
+
+class BudgetEvaluator:
+    """Evaluates a tenant's budgets against actual spend and estimates"""
+
+    def __init__(self, store: Store, clock: Callable[[], datetime] = utcnow):
+        """
+        Initialize evaluator
+
+        Args:
+            store: Store holding budgets and actual costs
+            clock: Current time (aware UTC)
+        """
+        self.store = store
+        self.clock = clock
+
+    def status(self, budget: Budget) -> BudgetStatus:
+        """Actual and projected spend against a budget in the current month"""
+        start, end, elapsed = month_period(self.clock())
+        actual = self._actual(budget, start, end)
+        return self._status(budget, start, end, actual, actual / elapsed)
+
+    def warnings(
+        self,
+        monthly_cost: float,
+        project: Optional[str] = None,
+        labels: Optional[Dict[str, str]] = None,
+        tenant_id: Optional[str] = None,
+        label_key: Optional[str] = None,
+    ) -> List[BudgetWarning]:
+        """
+        Budgets an estimate would take past a threshold
+
+        Failures are logged rather than raised so an unavailable database
+        never fails the estimate itself.
+
+        Args:
+            monthly_cost: Monthly cost (or cost delta) of the estimate
+            project: Project of the estimate
+            labels: Labels of the estimate
+            tenant_id: Tenant of the estimate, default the current one
+            label_key: Only budgets selecting spend by this label, e.g. a namespace's budgets
+        """
+        labels = labels or {}
+        tenant_id = tenant_id or current_tenant()
+        try:
+            budgets = [
+                Budget.from_record(record)
+                for record in self.store.list_budgets(tenant_id)
+            ]
+            budgets = [
+                b for b in budgets
+                if b.applies_to(project, labels) and (label_key is None or label_key in b.labels)
+            ]
+            if not budgets:
+                return []
+
+            start, end, elapsed = month_period(self.clock())
+            warnings = []
+            for budget in budgets:
+                actual = self._actual(budget, start, end)
+                warning = self._warning(budget, actual, actual / elapsed, monthly_cost)
+                if warning is not None:
+                    warnings.append(warning)
+            return warnings
+        except StoreError as e:
+            logger.error(f"Budget evaluation failed: {e}")
+            return []
+
+    def _actual(self, budget: Budget, start: date, end: date) -> float:
+        costs = self.store.list_actual_costs(budget.tenant_id, start, end, project=budget.project)
+        return sum(
+            cost.amount for cost in costs
+            if all(cost.labels.get(key) == value for key, value in budget.labels.items())
+        )
+
+    def _status(self, budget: Budget, start: date, end: date, actual: float, projected: float) -> BudgetStatus:
+        utilization = projected / budget.amount
+        return BudgetStatus(
+            period_start=start,
+            period_end=end,
+            actual_to_date=round_money(actual),
+            projected_actual=round_money(projected),
+            utilization=round(utilization, 4),
+            threshold_reached=highest_threshold(budget.thresholds, utilization),
+        )
+
+    def _warning(
+        self, budget: Budget, actual: float, projected: float, monthly_cost: float
+    ) -> Optional[BudgetWarning]:
+        spend = projected + monthly_cost
+        utilization = spend / budget.amount
+        threshold = highest_threshold(budget.thresholds, utilization)
+        if threshold is None:
+            return None
+
+        return BudgetWarning(
+            budget_id=budget.id,
+            name=budget.name,
+            amount=budget.amount,
+            threshold=threshold,
+            actual_to_date=round_money(actual),
+            projected_actual=round_money(projected),
+            estimate_monthly_cost=round_money(monthly_cost),
+            projected_spend=round_money(spend),
+            utilization=round(utilization, 4),
+            message=(
+                f"Projected spend ${spend:,.2f} is {utilization:.0%} of budget "
+                f"'{budget.name}' (${budget.amount:,.2f}/month), reaching the {threshold:.0%} threshold"
+            ),
+        )
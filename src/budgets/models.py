@@ -0,0 +1,104 @@
+"""
+Data models for budgets and budget evaluation
+"""
+
+from datetime import date, datetime
+from typing import Dict, List, Optional
+
+from pydantic import BaseModel, Field, validator
+
+from ..store import ActualCostRecord, BudgetRecord, DEFAULT_TENANT
+
+# Fractions of a budget at which warnings are raised
+DEFAULT_THRESHOLDS = [0.5, 0.8, 1.0]
+
+
+class BudgetSpec(BaseModel):
+    """Budget as created or replaced through /budgets"""
+
+    name: str = Field(..., min_length=1, max_length=100)
+    amount: float = Field(..., gt=0, description="Monthly amount (USD)")
+    project: Optional[str] = Field(None, description="Only spend of this project; None for the whole tenant")
+    labels: Dict[str, str] = Field(default_factory=dict, description="Only spend carrying all these labels")
+    thresholds: List[float] = Field(
+        default_factory=lambda: list(DEFAULT_THRESHOLDS),
+        description="Fractions of the amount that raise warnings, e.g. 0.8 for 80%"
+    )
+
+    @validator("thresholds")
+    def validate_thresholds(cls, v):
+        if not v:
+            raise ValueError("At least one threshold is required")
+        for threshold in v:
+            if not 0 < threshold <= 10:
+                raise ValueError("Thresholds must be greater than 0 and at most 10 (1000%)")
+        return sorted(set(v))
+
+    def to_record(self, tenant_id: str, budget_id: Optional[str] = None) -> BudgetRecord:
+        return BudgetRecord(id=budget_id, tenant_id=tenant_id, **self.dict())
+
+
+class Budget(BudgetSpec):
+    """Stored budget"""
+
+    id: str
+    tenant_id: str = DEFAULT_TENANT
+    created_at: Optional[datetime] = None
+    updated_at: Optional[datetime] = None
+
+    @classmethod
+    def from_record(cls, record: BudgetRecord) -> "Budget":
+        return cls(**record.dict())
+
+    def applies_to(self, project: Optional[str], labels: Dict[str, str]) -> bool:
+        """Whether spend of a project with labels counts against the budget"""
+        if self.project is not None and project != self.project:
+            return False
+        return all(labels.get(key) == value for key, value in self.labels.items())
+
+
+class BudgetStatus(BaseModel):
+    """Spend against a budget in the current month"""
+
+    period_start: date
+    period_end: date = Field(..., description="First day after the period")
+    actual_to_date: float = Field(..., description="Actual spend recorded this month")
+    projected_actual: float = Field(..., description="Actual spend extrapolated to the end of the month")
+    utilization: float = Field(..., description="Projected actual spend as a fraction of the amount")
+    threshold_reached: Optional[float] = Field(None, description="Highest threshold the projection reaches")
+
+
+class BudgetWarning(BaseModel):
+    """Budget an estimate's projected spend reaches a threshold of"""
+
+    budget_id: str
+    name: str
+    amount: float
+    threshold: float = Field(..., description="Highest threshold reached")
+    actual_to_date: float
+    projected_actual: float
+    estimate_monthly_cost: float
+    projected_spend: float = Field(..., description="Projected actual spend plus the estimate's monthly cost")
+    utilization: float = Field(..., description="Projected spend as a fraction of the amount")
+    message: str
+
+
+class ActualCostEntry(BaseModel):
+    """Actual spend of one day, as recorded through POST /actuals"""
+
+    usage_date: date
+    amount: float = Field(..., description="Cost (USD); credits are negative")
+    provider: str = ""
+    service: str = ""
+    project: Optional[str] = None
+    labels: Dict[str, str] = Field(default_factory=dict)
+    source: str = Field("manual", description="e.g. manual or the billing export it came from")
+
+    def to_record(self, tenant_id: str) -> ActualCostRecord:
+        return ActualCostRecord(tenant_id=tenant_id, **self.dict())
+
+
+class ActualCostBatch(BaseModel):
+    """Actual costs to record"""
+
+    costs: List[ActualCostEntry] = Field(..., min_items=1)
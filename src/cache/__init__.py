@@ -0,0 +1,30 @@
+"""
+Cache Module
+
+This module caches estimate results under normalized request hashes, in
+memory and optionally in Redis shared by all replicas, and computes the
+entity tags that let GET endpoints answer If-None-Match with 304. Its
+locks let one replica refresh a catalog or run a periodic job at a time.
+"""
+
+from .backends import CacheBackend, MemoryCache, RedisCache, TieredCache
+from .results import ResultCache, request_hash
+from .etag import etag_matches, json_etag
+from .factory import build_result_cache
+from .locks import Locks, MemoryLocks, RedisLocks, build_locks
+
+__all__ = [
+    "CacheBackend",
+    "MemoryCache",
+    "RedisCache",
+    "TieredCache",
+    "ResultCache",
+    "request_hash",
+    "etag_matches",
+    "json_etag",
+    "build_result_cache",
+    "Locks",
+    "MemoryLocks",
+    "RedisLocks",
+    "build_locks",
+]
@@ -0,0 +1,189 @@
+"""
+Cache backends
+
+Values are opaque bytes with a time to live. Counters, used as cache
+generations, never expire. The in-memory backend is private to a replica;
+Redis is shared by all replicas, and a tiered backend keeps recently used
+values of a shared backend in memory.
+
+A cache is an optimization: backend errors are logged and turn into
+misses, never into failed requests.
+"""
+
+import logging
+import threading
+import time
+from abc import ABC, abstractmethod
+from collections import OrderedDict
+from typing import Callable, Dict, Optional, Tuple
+
+logger = logging.getLogger(__name__)
+
+
+class CacheBackend(ABC):
+    """Key-value store of cached values and counters"""
+
+    name: str = ""
+
+    @abstractmethod
+    def get(self, key: str) -> Optional[bytes]:
+        """A cached value, or None if it is missing or expired"""
+
+    @abstractmethod
+    def set(self, key: str, value: bytes, ttl: float) -> None:
+        """Cache a value for ttl seconds"""
+
+    @abstractmethod
+    def counter(self, key: str) -> int:
+        """Current value of a counter, 0 if it was never incremented"""
+
+    @abstractmethod
+    def incr(self, key: str) -> int:
+        """Increment a counter, returning its new value"""
+
+
+class MemoryCache(CacheBackend):
+    """Cache in this process, evicting the least recently used values beyond max_entries"""
+
+    name = "memory"
+
+    def __init__(self, max_entries: int = 1000, clock: Callable[[], float] = time.monotonic):
+        """
+        Initialize memory cache
+
+        Args:
+            max_entries: Values kept at most
+            clock: Monotonic time in seconds
+        """
+        if max_entries < 1:
+            raise ValueError("A memory cache holds at least 1 entry")
+        self.max_entries = max_entries
+        self.clock = clock
+        self._values: "OrderedDict[str, Tuple[float, bytes]]" = OrderedDict()
+        self._counters: Dict[str, int] = {}
+        self._lock = threading.Lock()
+
+    def get(self, key: str) -> Optional[bytes]:
+        with self._lock:
+            entry = self._values.get(key)
+            if entry is None:
+                return None
+            expires, value = entry
+            if expires <= self.clock():
+                del self._values[key]
+                return None
+            self._values.move_to_end(key)
+            return value
+
+    def set(self, key: str, value: bytes, ttl: float) -> None:
+        with self._lock:
+            self._values[key] = (self.clock() + ttl, value)
+            self._values.move_to_end(key)
+            while len(self._values) > self.max_entries:
+                self._values.popitem(last=False)
+
+    def counter(self, key: str) -> int:
+        with self._lock:
+            return self._counters.get(key, 0)
+
+    def incr(self, key: str) -> int:
+        with self._lock:
+            self._counters[key] = self._counters.get(key, 0) + 1
+            return self._counters[key]
+
+    def __len__(self) -> int:
+        return len(self._values)
+
+
+class RedisCache(CacheBackend):
+    """Cache shared by all replicas through Redis"""
+
+    name = "redis"
+
+    def __init__(self, url: str, prefix: str = "kcloud-cost:", timeout: float = 0.5, client=None):
+        """
+        Initialize Redis cache
+
+        Args:
+            url: Redis URL, e.g. redis://redis:6379/1
+            prefix: Prepended to every key
+            timeout: Socket timeout of each command (seconds)
+            client: redis.Redis client, created from the URL when omitted
+        """
+        self.url = url
+        self.prefix = prefix
+        self.timeout = timeout
+        self._client = client
+
+    @property
+    def client(self):
+        if self._client is None:
+            import redis
+            self._client = redis.Redis.from_url(
+                self.url, socket_timeout=self.timeout, socket_connect_timeout=self.timeout
+            )
+        return self._client
+
+    def get(self, key: str) -> Optional[bytes]:
+        try:
+            return self.client.get(self.prefix + key)
+        except Exception as e:
+            logger.warning(f"Redis cache read failed: {e}")
+            return None
+
+    def set(self, key: str, value: bytes, ttl: float) -> None:
+        try:
+            self.client.set(self.prefix + key, value, px=max(int(ttl * 1000), 1))
+        except Exception as e:
+            logger.warning(f"Redis cache write failed: {e}")
+
+    def counter(self, key: str) -> int:
+        try:
+            value = self.client.get(self.prefix + key)
+        except Exception as e:
+            logger.warning(f"Redis cache read failed: {e}")
+            return 0
+        return int(value) if value else 0
+
+    def incr(self, key: str) -> int:
+        try:
+            return int(self.client.incr(self.prefix + key))
+        except Exception as e:
+            logger.error(f"Redis cache invalidation failed: {e}")
+            return 0
+
+
+class TieredCache(CacheBackend):
+    """Shared cache with recently used values also kept in memory"""
+
+    def __init__(self, local: CacheBackend, shared: CacheBackend, local_ttl: float = 30):
+        """
+        Initialize tiered cache
+
+        Args:
+            local: Cache in this process, looked up first
+            shared: Cache of all replicas; counters live here only
+            local_ttl: Seconds a value read from the shared cache is kept locally
+        """
+        self.local = local
+        self.shared = shared
+        self.local_ttl = local_ttl
+        self.name = f"{local.name}+{shared.name}"
+
+    def get(self, key: str) -> Optional[bytes]:
+        value = self.local.get(key)
+        if value is None:
+            value = self.shared.get(key)
+            if value is not None:
+                self.local.set(key, value, self.local_ttl)
+        return value
+
+    def set(self, key: str, value: bytes, ttl: float) -> None:
+        self.local.set(key, value, min(ttl, self.local_ttl))
+        self.shared.set(key, value, ttl)
+
+    def counter(self, key: str) -> int:
+        return self.shared.counter(key)
+
+    def incr(self, key: str) -> int:
+        return self.shared.incr(key)
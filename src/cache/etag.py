@@ -0,0 +1,43 @@
+"""
+Entity tags of JSON responses
+
+The tag of a response is a hash of its JSON body without the fields that
+change on every call (the top-level timestamp), so a client repeating a
+GET receives 304 Not Modified while the data it would get is unchanged.
+"""
+
+import hashlib
+import json
+from typing import Iterable, Optional
+
+# Top-level fields left out of the hash
+VOLATILE_FIELDS = ("timestamp",)
+
+
+def json_etag(body: bytes, volatile: Iterable[str] = VOLATILE_FIELDS) -> Optional[str]:
+    """Strong entity tag of a JSON body, None if the body is not JSON"""
+    try:
+        document = json.loads(body)
+    except ValueError:
+        return None
+    if isinstance(document, dict):
+        document = {key: value for key, value in document.items() if key not in volatile}
+    canonical = json.dumps(document, sort_keys=True, separators=(",", ":"))
+    return f'"{hashlib.sha256(canonical.encode()).hexdigest()[:32]}"'
+
+
+def etag_matches(if_none_match: Optional[str], etag: str) -> bool:
+    """
+    Whether an If-None-Match header matches a tag
+
+    Uses the weak comparison of RFC 9110: W/ prefixes are ignored and
+    "*" matches any tag.
+    """
+    if not if_none_match:
+        return False
+    candidates = [tag.strip() for tag in if_none_match.split(",")]
+    return "*" in candidates or _opaque(etag) in {_opaque(tag) for tag in candidates}
+
+
+def _opaque(tag: str) -> str:
+    return tag[2:] if tag.startswith("W/") else tag
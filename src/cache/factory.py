@@ -0,0 +1,22 @@
+"""
+Result cache from application settings
+"""
+
+from typing import Optional
+
+from .backends import MemoryCache, RedisCache, TieredCache
+from .results import ResultCache
+
+
+def build_result_cache(settings) -> Optional[ResultCache]:
+    """Result cache in memory, in front of Redis when configured; None when disabled"""
+    if settings.result_cache_ttl <= 0:
+        return None
+    backend = MemoryCache(max_entries=settings.result_cache_max_entries)
+    if settings.result_cache_redis_url:
+        backend = TieredCache(
+            backend,
+            RedisCache(settings.result_cache_redis_url, prefix=settings.result_cache_redis_prefix),
+            local_ttl=min(settings.result_cache_ttl, settings.result_cache_local_ttl),
+        )
+    return ResultCache(backend, ttl=settings.result_cache_ttl)
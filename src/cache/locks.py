@@ -0,0 +1,196 @@
+"""
+Distributed locks
+
+Work one replica should do for all of them (downloading a price catalog,
+ingesting billing exports, sending an alert) is guarded by a named lock
+with a time to live. A lock is either held while the work runs, renewed
+every third of its TTL so long downloads keep it, or claimed for a
+period and left to expire, so replicas trying within the period skip
+the work. A replica that dies holding a lock blocks the others for at
+most its TTL.
+
+Memory locks coordinate the threads of one replica; Redis locks are
+shared by all replicas. Like the cache, locks never fail the work they
+guard: when Redis cannot be reached a lock counts as acquired and the
+work runs, possibly on several replicas at once.
+"""
+
+import logging
+import secrets
+import threading
+import time
+from abc import ABC, abstractmethod
+from contextlib import contextmanager
+from typing import Callable, Dict, Iterator, Optional, Tuple
+
+logger = logging.getLogger(__name__)
+
+# Token of a lock acquired without the backend, which cannot be released or lost
+UNGUARDED = ""
+
+# Expired memory locks are purged once this many are kept
+_PURGE_THRESHOLD = 1000
+
+_RELEASE = "if redis.call('get', KEYS[1]) == ARGV[1] then return redis.call('del', KEYS[1]) else return 0 end"
+_EXTEND = (
+    "if redis.call('get', KEYS[1]) == ARGV[1] then return redis.call('pexpire', KEYS[1], ARGV[2]) else return 0 end"
+)
+
+
+class Locks(ABC):
+    """Named locks with a time to live"""
+
+    name: str = ""
+
+    @abstractmethod
+    def acquire(self, key: str, ttl: float) -> Optional[str]:
+        """Token of the lock taken for ttl seconds, or None if another holder has it"""
+
+    @abstractmethod
+    def release(self, key: str, token: str) -> None:
+        """Release a lock if the token still holds it"""
+
+    @abstractmethod
+    def extend(self, key: str, token: str, ttl: float) -> bool:
+        """Renew a held lock for ttl seconds; False if it expired and was lost"""
+
+    def claim(self, key: str, ttl: float) -> bool:
+        """Take a lock for ttl seconds, leaving it to expire; False if it is held"""
+        return self.acquire(key, ttl) is not None
+
+    @contextmanager
+    def hold(self, key: str, ttl: float) -> Iterator[bool]:
+        """
+        Hold a lock while the block runs, yielding whether it was acquired
+
+        The lock is renewed every third of its TTL and released when the
+        block exits.
+        """
+        token = self.acquire(key, ttl)
+        if token is None:
+            yield False
+            return
+        stop = threading.Event()
+        renewer = threading.Thread(
+            target=self._renew, args=(key, token, ttl, stop), name=f"lock-{key}", daemon=True
+        )
+        renewer.start()
+        try:
+            yield True
+        finally:
+            stop.set()
+            renewer.join()
+            self.release(key, token)
+
+    def _renew(self, key: str, token: str, ttl: float, stop: threading.Event) -> None:
+        while not stop.wait(ttl / 3):
+            if not self.extend(key, token, ttl):
+                logger.warning(f"Lock {key} expired while held; another replica may run the same work")
+                return
+
+
+class MemoryLocks(Locks):
+    """Locks of this process"""
+
+    name = "memory"
+
+    def __init__(self, clock: Callable[[], float] = time.monotonic):
+        """
+        Initialize memory locks
+
+        Args:
+            clock: Monotonic time in seconds
+        """
+        self.clock = clock
+        self._locks: Dict[str, Tuple[str, float]] = {}
+        self._lock = threading.Lock()
+
+    def acquire(self, key: str, ttl: float) -> Optional[str]:
+        now = self.clock()
+        with self._lock:
+            held = self._locks.get(key)
+            if held is not None and held[1] > now:
+                return None
+            if len(self._locks) >= _PURGE_THRESHOLD:
+                self._locks = {k: v for k, v in self._locks.items() if v[1] > now}
+            token = secrets.token_hex(8)
+            self._locks[key] = (token, now + ttl)
+            return token
+
+    def release(self, key: str, token: str) -> None:
+        with self._lock:
+            held = self._locks.get(key)
+            if held is not None and held[0] == token:
+                del self._locks[key]
+
+    def extend(self, key: str, token: str, ttl: float) -> bool:
+        now = self.clock()
+        with self._lock:
+            held = self._locks.get(key)
+            if held is None or held[0] != token or held[1] <= now:
+                return False
+            self._locks[key] = (token, now + ttl)
+            return True
+
+
+class RedisLocks(Locks):
+    """Locks shared by all replicas through Redis"""
+
+    name = "redis"
+
+    def __init__(self, url: str, prefix: str = "kcloud-cost:lock:", timeout: float = 0.5, client=None):
+        """
+        Initialize Redis locks
+
+        Args:
+            url: Redis URL, e.g. redis://redis:6379/1
+            prefix: Prepended to every lock name
+            timeout: Socket timeout of each command (seconds)
+            client: redis.Redis client, created from the URL when omitted
+        """
+        self.url = url
+        self.prefix = prefix
+        self.timeout = timeout
+        self._client = client
+
+    @property
+    def client(self):
+        if self._client is None:
+            import redis
+            self._client = redis.Redis.from_url(
+                self.url, socket_timeout=self.timeout, socket_connect_timeout=self.timeout
+            )
+        return self._client
+
+    def acquire(self, key: str, ttl: float) -> Optional[str]:
+        token = secrets.token_hex(8)
+        try:
+            acquired = self.client.set(self.prefix + key, token, nx=True, px=max(int(ttl * 1000), 1))
+        except Exception as e:
+            logger.warning(f"Redis lock {key} unavailable, running unguarded: {e}")
+            return UNGUARDED
+        return token if acquired else None
+
+    def release(self, key: str, token: str) -> None:
+        if token == UNGUARDED:
+            return
+        try:
+            self.client.eval(_RELEASE, 1, self.prefix + key, token)
+        except Exception as e:
+            logger.warning(f"Redis lock {key} not released, it expires on its own: {e}")
+
+    def extend(self, key: str, token: str, ttl: float) -> bool:
+        if token == UNGUARDED:
+            return True
+        try:
+            return bool(self.client.eval(_EXTEND, 1, self.prefix + key, token, max(int(ttl * 1000), 1)))
+        except Exception as e:
+            logger.warning(f"Redis lock {key} not renewed: {e}")
+            return True
+
+
+def build_locks(settings) -> Locks:
+    """Locks shared through Redis when LOCK_REDIS_URL is set, else locks of this replica"""
+    if settings.lock_redis_url:
+        return RedisLocks(settings.lock_redis_url, prefix=settings.lock_redis_prefix)
+    return MemoryLocks()
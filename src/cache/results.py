@@ -0,0 +1,95 @@
+"""
+Estimate result cache
+
+CI pipelines estimate the same manifests and plans on every run. Results
+are cached under a hash of the normalized request: the request model's
+fields with defaults filled in, serialized with sorted keys, so requests
+differing only in field order or omitted defaults share an entry. Fields
+that do not change the result, such as the project and labels recorded
+with the history, are left out by the caller.
+
+Keys also hold the tenant, whose price sheet and discount rules shape its
+results, and two generations: a global one bumped when price catalogs are
+refreshed and one per tenant bumped when its prices or discounts change.
+Bumping a generation orphans every entry keyed on the old value, which
+then expires with its TTL.
+"""
+
+import hashlib
+import json
+import logging
+from typing import Any, Callable, Dict, Optional, Type, TypeVar
+
+from pydantic import BaseModel
+
+from ..metrics import CACHE_HIT, CACHE_MISS, record_response_cache_lookup
+from .backends import CacheBackend
+
+logger = logging.getLogger(__name__)
+
+T = TypeVar("T", bound=BaseModel)
+
+_GLOBAL_GENERATION = "generation"
+
+
+def request_hash(request: Dict[str, Any]) -> str:
+    """SHA-256 of a request's canonical JSON"""
+    canonical = json.dumps(request, sort_keys=True, separators=(",", ":"), default=str)
+    return hashlib.sha256(canonical.encode()).hexdigest()
+
+
+class ResultCache:
+    """Caches estimate results by tenant and normalized request"""
+
+    def __init__(self, backend: CacheBackend, ttl: float = 300):
+        """
+        Initialize result cache
+
+        Args:
+            backend: Where results are kept
+            ttl: Seconds a result is served from the cache
+        """
+        self.backend = backend
+        self.ttl = ttl
+
+    def key(self, endpoint: str, tenant_id: str, request: Dict[str, Any]) -> str:
+        """Cache key of a request under the current generations"""
+        generation = self.backend.counter(_GLOBAL_GENERATION)
+        tenant_generation = self.backend.counter(f"{_GLOBAL_GENERATION}:{tenant_id}")
+        return f"result:{endpoint}:{tenant_id}:{generation}.{tenant_generation}:{request_hash(request)}"
+
+    def get_or_compute(
+        self,
+        endpoint: str,
+        tenant_id: str,
+        request: Dict[str, Any],
+        compute: Callable[[], T],
+        model: Type[T],
+    ) -> T:
+        """
+        The cached result of a request, computing and caching it on a miss
+
+        Exceptions of compute propagate and nothing is cached.
+        """
+        key = self.key(endpoint, tenant_id, request)
+        cached = self.backend.get(key)
+        if cached is not None:
+            try:
+                result = model.parse_obj(json.loads(cached))
+            except ValueError as e:
+                logger.warning(f"Dropping unreadable cached {endpoint} result: {e}")
+            else:
+                record_response_cache_lookup(endpoint, CACHE_HIT)
+                return result
+
+        record_response_cache_lookup(endpoint, CACHE_MISS)
+        result = compute()
+        self.backend.set(key, json.dumps(result.dict(), default=str).encode(), self.ttl)
+        return result
+
+    def invalidate(self, tenant_id: Optional[str] = None) -> None:
+        """Stop serving cached results of a tenant, or of every tenant when None"""
+        if tenant_id is None:
+            self.backend.incr(_GLOBAL_GENERATION)
+        else:
+            self.backend.incr(f"{_GLOBAL_GENERATION}:{tenant_id}")
@@ -0,0 +1,39 @@
+"""
+Carbon Module
+
+This module estimates the operational carbon footprint (kgCO2e) of
+priced resources from instance power models and the carbon intensity
+of each region's grid, with intensities from a built-in table of annual
+averages or Electricity Maps.
+"""
+
+from .models import CarbonIntensity, CarbonLineItem, CarbonEstimate
+from .power import PowerModel, PUE, register_power_model, shape_power_model, get_power_model
+from .provider import (
+    CarbonIntensityProvider,
+    StaticIntensityProvider,
+    ElectricityMapsProvider,
+    IntensityNotFoundError,
+    DEFAULT_ELECTRICITYMAPS_URL,
+    parse_intensity_overrides,
+)
+from .estimator import CarbonEstimator, build_carbon_estimator
+
+__all__ = [
+    "CarbonIntensity",
+    "CarbonLineItem",
+    "CarbonEstimate",
+    "PowerModel",
+    "PUE",
+    "register_power_model",
+    "shape_power_model",
+    "get_power_model",
+    "CarbonIntensityProvider",
+    "StaticIntensityProvider",
+    "ElectricityMapsProvider",
+    "IntensityNotFoundError",
+    "DEFAULT_ELECTRICITYMAPS_URL",
+    "parse_intensity_overrides",
+    "CarbonEstimator",
+    "build_carbon_estimator",
+]
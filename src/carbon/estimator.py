@@ -0,0 +1,170 @@
+"""
+Carbon footprint of estimated resources
+
+Operational emissions of a resource are its instances' average power
+draw at the assumed CPU utilization, times the provider's PUE for data
+center overhead, times the hours it runs, times the carbon intensity of
+the region's grid. Embodied emissions of the hardware are not included.
+"""
+
+import logging
+import threading
+import time
+from typing import Dict, List, Optional, Protocol, Tuple
+
+from ..units import months_per_year
+from .models import CarbonEstimate, CarbonIntensity, CarbonLineItem
+from .power import PUE, get_power_model
+from .provider import (
+    CarbonIntensityProvider,
+    ElectricityMapsProvider,
+    IntensityNotFoundError,
+    StaticIntensityProvider,
+    parse_intensity_overrides,
+)
+
+logger = logging.getLogger(__name__)
+
+
+class Resource(Protocol):
+    """Priced resource the footprint is estimated for, e.g. an estimate line item"""
+
+    name: Optional[str]
+    provider: str
+    region: str
+    instance_type: str
+    count: int
+    hours: float
+
+
+class CarbonEstimator:
+    """Estimates the emissions of resources with intensities from providers tried in order"""
+
+    def __init__(
+        self,
+        providers: List[CarbonIntensityProvider],
+        cpu_utilization: float = 0.5,
+        ttl: int = 3600,
+    ):
+        """
+        Initialize carbon estimator
+
+        Args:
+            providers: Intensity providers, the first that knows a region answers it
+            cpu_utilization: Average CPU utilization of instances, between 0 and 1
+            ttl: Seconds an intensity is reused before asking the providers again
+        """
+        if not 0 <= cpu_utilization <= 1:
+            raise ValueError("The CPU utilization must be between 0 and 1")
+        self.providers = providers
+        self.cpu_utilization = cpu_utilization
+        self.ttl = ttl
+
+        self._intensities: Dict[Tuple[str, str], Tuple[float, CarbonIntensity]] = {}
+        self._lock = threading.Lock()
+
+    def intensity(self, provider: str, region: str) -> CarbonIntensity:
+        """
+        Carbon intensity of a region from the first provider that knows it
+
+        Providers that fail are skipped; the last intensity fetched is
+        served while all of them fail.
+
+        Raises:
+            IntensityNotFoundError: If no provider has an intensity for the region
+        """
+        key = (provider, region)
+        with self._lock:
+            cached = self._intensities.get(key)
+        if cached is not None and time.time() - cached[0] < self.ttl:
+            return cached[1]
+
+        for source in self.providers:
+            try:
+                intensity = source.get_intensity(provider, region)
+            except IntensityNotFoundError:
+                continue
+            except Exception as e:
+                logger.error(f"Carbon intensity of {provider}/{region} from {source.name} failed: {e}")
+                continue
+            with self._lock:
+                self._intensities[key] = (time.time(), intensity)
+            return intensity
+
+        if cached is not None:
+            return cached[1]
+        raise IntensityNotFoundError(f"No carbon intensity for {provider}/{region}")
+
+    def estimate(self, resources: List[Resource]) -> CarbonEstimate:
+        """Emissions of resources; those without a power model or intensity are listed as unestimated"""
+        line_items: List[CarbonLineItem] = []
+        unestimated: List[str] = []
+        for resource in resources:
+            item = self.estimate_resource(resource)
+            if item is None:
+                unestimated.append(resource.name or f"{resource.provider}/{resource.region}/{resource.instance_type}")
+            else:
+                line_items.append(item)
+
+        monthly = sum(item.monthly_kg_co2e for item in line_items)
+        return CarbonEstimate(
+            line_items=line_items,
+            monthly_kg_co2e=_round(monthly),
+            yearly_kg_co2e=_round(monthly * months_per_year()),
+            cpu_utilization=self.cpu_utilization,
+            unestimated=unestimated,
+        )
+
+    def estimate_resource(self, resource: Resource) -> Optional[CarbonLineItem]:
+        """Emissions of one resource, None without a power model or intensity"""
+        try:
+            model = get_power_model(resource.provider, resource.instance_type)
+            intensity = self.intensity(resource.provider, resource.region)
+        except (KeyError, IntensityNotFoundError) as e:
+            logger.warning(f"Carbon footprint of {resource.provider}/{resource.instance_type} not estimated: {e}")
+            return None
+
+        watts = model.watts(self.cpu_utilization)
+        pue = PUE.get(resource.provider, 1.0)
+        kwh = watts * pue * resource.count * resource.hours / 1000
+        kg = kwh * intensity.grams_per_kwh / 1000
+        return CarbonLineItem(
+            name=resource.name,
+            provider=resource.provider,
+            region=resource.region,
+            instance_type=resource.instance_type,
+            count=resource.count,
+            hours=resource.hours,
+            power_watts=_round(watts),
+            pue=pue,
+            monthly_kwh=_round(kwh),
+            grams_per_kwh=intensity.grams_per_kwh,
+            intensity_source=intensity.source,
+            monthly_kg_co2e=_round(kg),
+            yearly_kg_co2e=_round(kg * months_per_year()),
+        )
+
+
+def build_carbon_estimator(settings) -> Optional[CarbonEstimator]:
+    """
+    Create the carbon estimator from application settings, None when disabled
+
+    Electricity Maps falls back to the static table for regions it cannot
+    answer; offline mode uses the static table only.
+    """
+    if not settings.carbon_estimates:
+        return None
+    static = StaticIntensityProvider(parse_intensity_overrides(settings.carbon_intensity_overrides))
+    providers: List[CarbonIntensityProvider] = [static]
+    if settings.carbon_intensity_source == "electricitymaps" and not settings.offline:
+        if not settings.electricitymaps_api_token:
+            raise ValueError("CARBON_INTENSITY_SOURCE=electricitymaps needs ELECTRICITYMAPS_API_TOKEN")
+        providers.insert(0, ElectricityMapsProvider(settings.electricitymaps_api_token, url=settings.electricitymaps_url))
+    elif settings.carbon_intensity_source not in ("static", "electricitymaps"):
+        raise ValueError(f"Unknown carbon intensity source: {settings.carbon_intensity_source}")
+    return CarbonEstimator(providers, cpu_utilization=settings.carbon_cpu_utilization, ttl=settings.carbon_intensity_ttl)
+
+
+def _round(value: float) -> float:
+    """Round an amount for output"""
+    return round(value, 4)
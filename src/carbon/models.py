@@ -0,0 +1,50 @@
+"""
+Data models for carbon footprint estimation
+"""
+
+from datetime import datetime
+from typing import List, Optional
+
+from pydantic import BaseModel, Field
+
+
+class CarbonIntensity(BaseModel):
+    """Carbon intensity of the grid powering a provider region"""
+
+    provider: str
+    region: str
+    grams_per_kwh: float = Field(..., ge=0, description="gCO2e emitted per kWh drawn from the grid")
+    source: str = Field(..., description="Intensity provider that supplied the value")
+    zone: Optional[str] = Field(None, description="Grid zone of the region, e.g. US-MIDA-PJM")
+    as_of: Optional[datetime] = Field(None, description="When a live value was measured, None for annual averages")
+
+
+class CarbonLineItem(BaseModel):
+    """Estimated emissions of one resource specification"""
+
+    name: Optional[str] = None
+    provider: str
+    region: str
+    instance_type: str
+    count: int
+    hours: float
+    power_watts: float = Field(..., description="Average power draw of one instance at the assumed utilization")
+    pue: float = Field(..., description="Power usage effectiveness of the provider's data centers")
+    monthly_kwh: float = Field(..., description="Energy of all instances per month, data center overhead included")
+    grams_per_kwh: float
+    intensity_source: str
+    monthly_kg_co2e: float
+    yearly_kg_co2e: float
+
+
+class CarbonEstimate(BaseModel):
+    """Operational emissions of an estimate's resources"""
+
+    line_items: List[CarbonLineItem]
+    monthly_kg_co2e: float
+    yearly_kg_co2e: float
+    cpu_utilization: float = Field(..., description="Average CPU utilization the power draw assumes")
+    unestimated: List[str] = Field(
+        default_factory=list,
+        description="Resources without a power model or grid intensity, left out of the totals"
+    )
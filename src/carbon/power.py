@@ -0,0 +1,84 @@
+"""
+Instance power models
+
+The average power draw of an instance follows the Cloud Carbon Footprint
+methodology: each vCPU draws between an idle and a peak wattage depending
+on utilization, memory draws a fixed wattage per GB and each GPU between
+its own idle and peak. Coefficients are the published averages over each
+provider's processor microarchitectures; Arm instances use the Graviton
+coefficients. Measured models of single instance types can be registered
+and take precedence.
+"""
+
+from typing import Dict, NamedTuple, Tuple
+
+from ..pricing.instance_types import InstanceShape, get_instance_shape
+
+
+class PowerModel(NamedTuple):
+    """Power draw of one instance at idle and at full load (watts)"""
+
+    idle_watts: float
+    max_watts: float
+
+    def watts(self, utilization: float) -> float:
+        """Average power draw at a CPU utilization between 0 and 1"""
+        return self.idle_watts + utilization * (self.max_watts - self.idle_watts)
+
+
+# Provider -> (idle, peak) watts per x86 vCPU
+_VCPU_WATTS = {
+    "aws": (0.74, 3.5),
+    "gcp": (0.71, 4.26),
+    "azure": (0.78, 3.76),
+}
+# (idle, peak) watts per Arm vCPU
+_ARM_VCPU_WATTS = (0.47, 1.69)
+# Watts per GB of memory
+_MEMORY_WATTS_PER_GB = 0.392
+# (idle, peak) watts per GPU
+_GPU_WATTS = (68.39, 218.97)
+
+# Power usage effectiveness of each provider's data centers
+PUE = {
+    "aws": 1.135,
+    "gcp": 1.1,
+    "azure": 1.185,
+}
+
+_MODELS: Dict[Tuple[str, str], PowerModel] = {}
+
+
+def register_power_model(provider: str, instance_type: str, model: PowerModel) -> None:
+    """Add or override the measured power model of one instance type"""
+    _MODELS[(provider, instance_type)] = model
+
+
+def shape_power_model(provider: str, shape: InstanceShape) -> PowerModel:
+    """
+    Power model of an instance shape from per-component coefficients
+
+    Raises:
+        KeyError: If the provider has no coefficients
+    """
+    idle, peak = _ARM_VCPU_WATTS if shape.arch == "arm64" else _VCPU_WATTS[provider]
+    memory = shape.memory_gb * _MEMORY_WATTS_PER_GB
+    return PowerModel(
+        idle_watts=shape.vcpus * idle + memory + shape.gpus * _GPU_WATTS[0],
+        max_watts=shape.vcpus * peak + memory + shape.gpus * _GPU_WATTS[1],
+    )
+
+
+def get_power_model(provider: str, instance_type: str) -> PowerModel:
+    """
+    Power model of an instance type
+
+    Raises:
+        KeyError: If the instance type or the provider is unknown
+    """
+    model = _MODELS.get((provider, instance_type))
+    if model is not None:
+        return model
+    if provider not in _VCPU_WATTS:
+        raise KeyError(f"No power coefficients for provider {provider}")
+    return shape_power_model(provider, get_instance_shape(provider, instance_type))
@@ -0,0 +1,195 @@
+"""
+Carbon intensity providers
+
+A provider answers the carbon intensity of the grid powering a cloud
+region. The static provider serves annual averages published for each
+region; the Electricity Maps provider fetches the latest measured
+intensity of the region's grid zone.
+"""
+
+import logging
+from abc import ABC, abstractmethod
+from datetime import datetime
+from typing import Dict, Optional, Tuple
+
+import requests
+
+from .models import CarbonIntensity
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_ELECTRICITYMAPS_URL = "https://api.electricitymap.org/v3"
+
+# Annual average grid intensity of provider regions (gCO2e/kWh), as
+# published by the Cloud Carbon Footprint project and the providers
+_REGION_INTENSITY: Dict[str, Dict[str, float]] = {
+    "aws": {
+        "us-east-1": 379.1, "us-east-2": 410.6, "us-west-1": 322.2, "us-west-2": 322.2,
+        "ca-central-1": 120.0, "sa-east-1": 61.7,
+        "eu-west-1": 278.6, "eu-west-2": 225.0, "eu-west-3": 51.1, "eu-central-1": 338.0,
+        "eu-north-1": 8.8, "eu-south-1": 233.0,
+        "ap-northeast-1": 465.8, "ap-northeast-2": 415.6, "ap-northeast-3": 465.8,
+        "ap-southeast-1": 408.0, "ap-southeast-2": 790.1, "ap-south-1": 708.2,
+    },
+    "gcp": {
+        "us-central1": 454.0, "us-east1": 480.0, "us-east4": 361.0, "us-west1": 117.0,
+        "us-west2": 248.0, "northamerica-northeast1": 27.0, "southamerica-east1": 103.0,
+        "europe-west1": 167.0, "europe-west2": 228.0, "europe-west3": 293.0, "europe-west4": 390.0,
+        "europe-north1": 112.0,
+        "asia-northeast1": 506.0, "asia-northeast3": 500.0, "asia-southeast1": 419.0,
+        "asia-south1": 670.0, "australia-southeast1": 727.0,
+    },
+    "azure": {
+        "eastus": 379.1, "eastus2": 379.1, "centralus": 454.0, "westus": 322.2, "westus2": 322.2,
+        "canadacentral": 120.0, "brazilsouth": 61.7,
+        "northeurope": 278.6, "westeurope": 328.4, "uksouth": 225.0, "francecentral": 51.1,
+        "germanywestcentral": 338.0, "swedencentral": 8.8,
+        "japaneast": 465.8, "koreacentral": 415.6, "southeastasia": 408.0, "australiaeast": 790.1,
+        "centralindia": 708.2,
+    },
+}
+
+# Electricity Maps grid zone of provider regions
+_REGION_ZONES: Dict[str, Dict[str, str]] = {
+    "aws": {
+        "us-east-1": "US-MIDA-PJM", "us-east-2": "US-MIDA-PJM", "us-west-1": "US-CAL-CISO",
+        "us-west-2": "US-NW-BPAT", "ca-central-1": "CA-QC", "sa-east-1": "BR-CS",
+        "eu-west-1": "IE", "eu-west-2": "GB", "eu-west-3": "FR", "eu-central-1": "DE", "eu-north-1": "SE-SE3",
+        "eu-south-1": "IT-NO", "ap-northeast-1": "JP-TK", "ap-northeast-2": "KR", "ap-northeast-3": "JP-KN",
+        "ap-southeast-1": "SG", "ap-southeast-2": "AU-NSW", "ap-south-1": "IN-WE",
+    },
+    "gcp": {
+        "us-central1": "US-MIDW-MISO", "us-east1": "US-SE-SOCO", "us-east4": "US-MIDA-PJM",
+        "us-west1": "US-NW-BPAT", "us-west2": "US-CAL-LDWP", "northamerica-northeast1": "CA-QC",
+        "southamerica-east1": "BR-CS", "europe-west1": "BE", "europe-west2": "GB", "europe-west3": "DE",
+        "europe-west4": "NL", "europe-north1": "FI", "asia-northeast1": "JP-TK", "asia-northeast3": "KR",
+        "asia-southeast1": "SG", "asia-south1": "IN-WE", "australia-southeast1": "AU-NSW",
+    },
+    "azure": {
+        "eastus": "US-MIDA-PJM", "eastus2": "US-MIDA-PJM", "centralus": "US-MIDW-MISO",
+        "westus": "US-CAL-CISO", "westus2": "US-NW-BPAT", "canadacentral": "CA-ON", "brazilsouth": "BR-CS",
+        "northeurope": "IE", "westeurope": "NL", "uksouth": "GB", "francecentral": "FR",
+        "germanywestcentral": "DE", "swedencentral": "SE-SE3", "japaneast": "JP-TK",
+        "koreacentral": "KR", "southeastasia": "SG", "australiaeast": "AU-NSW", "centralindia": "IN-WE",
+    },
+}
+
+
+class IntensityNotFoundError(LookupError):
+    """Raised when no carbon intensity is known for a region"""
+
+
+class CarbonIntensityProvider(ABC):
+    """Source of the grid carbon intensity of cloud regions"""
+
+    #: Unique provider name used in configuration (e.g. "static", "electricitymaps")
+    name: str = ""
+
+    @abstractmethod
+    def get_intensity(self, provider: str, region: str) -> CarbonIntensity:
+        """
+        Carbon intensity of a provider region
+
+        Raises:
+            IntensityNotFoundError: If the provider has no intensity for the region
+        """
+
+
+def parse_intensity_overrides(value: str) -> Dict[Tuple[str, str], float]:
+    """
+    Parse an intensity list such as "aws:us-east-1=350,gcp:europe-north1=40"
+
+    Raises:
+        ValueError: If an entry is malformed
+    """
+    overrides: Dict[Tuple[str, str], float] = {}
+    for item in value.split(","):
+        item = item.strip()
+        if not item:
+            continue
+        key, sep, grams = item.partition("=")
+        provider, colon, region = key.partition(":")
+        try:
+            if not sep or not colon or not provider.strip() or not region.strip():
+                raise ValueError
+            overrides[(provider.strip().lower(), region.strip())] = float(grams)
+        except ValueError:
+            raise ValueError(f"Invalid carbon intensity '{item}', expected provider:region=gCO2e/kWh") from None
+    return overrides
+
+
+class StaticIntensityProvider(CarbonIntensityProvider):
+    """Annual average intensities of the built-in region table"""
+
+    name = "static"
+
+    def __init__(self, overrides: Optional[Dict[Tuple[str, str], float]] = None):
+        """
+        Initialize static provider
+
+        Args:
+            overrides: gCO2e/kWh of (provider, region), replacing or adding to the built-in table
+        """
+        self.overrides = overrides or {}
+
+    def get_intensity(self, provider: str, region: str) -> CarbonIntensity:
+        grams = self.overrides.get((provider, region))
+        if grams is None:
+            grams = _REGION_INTENSITY.get(provider, {}).get(region)
+        if grams is None:
+            raise IntensityNotFoundError(f"No carbon intensity for {provider}/{region}")
+        return CarbonIntensity(
+            provider=provider,
+            region=region,
+            grams_per_kwh=grams,
+            source=self.name,
+            zone=_REGION_ZONES.get(provider, {}).get(region),
+        )
+
+
+class ElectricityMapsProvider(CarbonIntensityProvider):
+    """Latest measured intensity of a region's grid zone from Electricity Maps"""
+
+    name = "electricitymaps"
+
+    def __init__(self, token: str, url: str = DEFAULT_ELECTRICITYMAPS_URL, timeout: int = 30):
+        """
+        Initialize Electricity Maps provider
+
+        Args:
+            token: API auth token
+            url: API base URL
+            timeout: Request timeout in seconds
+        """
+        self.token = token
+        self.url = url.rstrip("/")
+        self.timeout = timeout
+        self.session = requests.Session()
+
+    def get_intensity(self, provider: str, region: str) -> CarbonIntensity:
+        zone = _REGION_ZONES.get(provider, {}).get(region)
+        if zone is None:
+            raise IntensityNotFoundError(f"No grid zone known for {provider}/{region}")
+
+        response = self.session.get(
+            f"{self.url}/carbon-intensity/latest",
+            params={"zone": zone},
+            headers={"auth-token": self.token},
+            timeout=self.timeout,
+        )
+        if response.status_code == 404:
+            raise IntensityNotFoundError(f"Electricity Maps has no intensity for zone {zone}")
+        response.raise_for_status()
+        data = response.json()
+        if data.get("carbonIntensity") is None:
+            raise IntensityNotFoundError(f"Electricity Maps has no intensity for zone {zone}")
+
+        as_of = data.get("datetime")
+        return CarbonIntensity(
+            provider=provider,
+            region=region,
+            grams_per_kwh=float(data["carbonIntensity"]),
+            source=self.name,
+            zone=zone,
+            as_of=datetime.fromisoformat(as_of.replace("Z", "+00:00")) if as_of else None,
+        )
@@ -0,0 +1,43 @@
+"""
+ChatOps Module
+
+This module answers /kcost slash commands from Slack and @kcost messages
+from Teams outgoing webhooks with cost estimate cards, verifying that the
+requests were signed by the chat platform.
+"""
+
+from .commands import (
+    CommandError,
+    CommandHandler,
+    command_text,
+    guess_provider,
+    COMMAND_ESTIMATE,
+    COMMAND_HELP,
+    USAGE,
+)
+from .signing import (
+    slack_signature,
+    teams_authorization,
+    verify_slack,
+    verify_teams,
+    SLACK_MAX_AGE_SECONDS,
+    SLACK_SIGNATURE_HEADER,
+    SLACK_TIMESTAMP_HEADER,
+)
+
+__all__ = [
+    "CommandError",
+    "CommandHandler",
+    "command_text",
+    "guess_provider",
+    "COMMAND_ESTIMATE",
+    "COMMAND_HELP",
+    "USAGE",
+    "slack_signature",
+    "teams_authorization",
+    "verify_slack",
+    "verify_teams",
+    "SLACK_MAX_AGE_SECONDS",
+    "SLACK_SIGNATURE_HEADER",
+    "SLACK_TIMESTAMP_HEADER",
+]
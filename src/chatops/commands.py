@@ -0,0 +1,185 @@
+"""
+Chat commands
+
+/kcost in Slack (a slash command) and @kcost in Teams (an outgoing
+webhook) price instances from the catalogs:
+
+    /kcost estimate m5.xlarge x3
+    /kcost estimate n2-standard-4 x2 asia-northeast3 spot
+    /kcost estimate Standard_D4s_v5 azure koreacentral 200h
+
+The instance type comes first, followed, in any order, by the count (x3
+or 3x), the provider, the region, the pricing model and the running hours
+per month. Without a provider it is told from the instance type; without
+a region the provider's first catalog region is priced.
+"""
+
+import html
+import re
+from typing import Dict, List, Optional
+
+from ..estimator import EstimateRequest, EstimateResult, ResourceSpec
+from ..notifications import Card, usd
+from ..pricing import PriceNotFoundError, ProviderNotFoundError, PRICING_MODELS
+
+COMMAND_ESTIMATE = "estimate"
+COMMAND_HELP = "help"
+
+USAGE = "estimate <instance type> [x<count>] [provider] [region] [on_demand|spot] [<hours>h]"
+
+_COUNT = re.compile(r"^(?:x(\d+)|(\d+)x)$", re.IGNORECASE)
+_HOURS = re.compile(r"^(\d+(?:\.\d+)?)h$", re.IGNORECASE)
+# GCP machine types: family-series[-vcpus], e.g. n2-standard-4, e2-medium
+_GCP_MACHINE_TYPE = re.compile(r"^[a-z]\d[a-z]?-[a-z]+(?:-\d+)?", re.IGNORECASE)
+# Bots are addressed as <at>name</at> in Teams messages
+_MENTION = re.compile(r"<at>.*?</at>", re.IGNORECASE)
+
+
+class CommandError(ValueError):
+    """Raised when a command cannot be understood"""
+
+
+def command_text(text: str) -> str:
+    """Words of a chat message addressed to the bot, without mentions and markup"""
+    text = _MENTION.sub(" ", text or "")
+    text = re.sub(r"<[^>]+>", " ", text)
+    return " ".join(html.unescape(text).split())
+
+
+def guess_provider(instance_type: str) -> str:
+    """Cloud an instance type name belongs to: azure for Standard_*, gcp for machine types, else aws"""
+    if instance_type.lower().startswith(("standard_", "basic_")):
+        return "azure"
+    if "." not in instance_type and _GCP_MACHINE_TYPE.match(instance_type):
+        return "gcp"
+    return "aws"
+
+
+class CommandHandler:
+    """Answers chat commands with cards"""
+
+    def __init__(self, estimator, default_regions: Dict[str, str], command: str = "/kcost"):
+        """
+        Initialize handler
+
+        Args:
+            estimator: CostEstimator pricing the instances
+            default_regions: Region priced for each provider when a command names none;
+                also the providers commands may name
+            command: Name the commands are invoked with, shown in the help
+        """
+        self.estimator = estimator
+        self.default_regions = {provider.lower(): region for provider, region in default_regions.items()}
+        self.command = command
+
+    def handle(self, text: str) -> Card:
+        """
+        Card answering a command; errors are answered with an alert card
+
+        Args:
+            text: Command without the bot's name, e.g. "estimate m5.xlarge x3"
+        """
+        words = command_text(text).split()
+        if not words or words[0].lower() == COMMAND_HELP:
+            return self.help()
+        if words[0].lower() != COMMAND_ESTIMATE:
+            return self._error(f"Unknown command '{words[0]}'", title="Unknown command")
+
+        try:
+            resource = self.parse_estimate(words[1:])
+        except CommandError as e:
+            return self._error(str(e))
+
+        try:
+            result = self.estimator.estimate(EstimateRequest(resources=[resource]))
+        except PriceNotFoundError as e:
+            suggestions = [match.sku for match in self.estimator.suggest_instance_types(resource)]
+            lines = [f"Did you mean {', '.join(suggestions)}?"] if suggestions else []
+            return self._error(str(e), lines)
+        except (ProviderNotFoundError, ValueError) as e:
+            return self._error(str(e))
+        return self._estimate_card(result)
+
+    def parse_estimate(self, words: List[str]) -> ResourceSpec:
+        """
+        Resource of the arguments of an estimate command
+
+        Raises:
+            CommandError: If the arguments do not make a resource
+        """
+        if not words:
+            raise CommandError("Name an instance type to estimate")
+        instance_type, fields = words[0], {}
+        for word in words[1:]:
+            lower = word.lower()
+            count, hours = _COUNT.match(word), _HOURS.match(word)
+            if count:
+                key, value = "count", int(count.group(1) or count.group(2))
+            elif hours:
+                key, value = "hours", float(hours.group(1))
+            elif lower in self.default_regions:
+                key, value = "provider", lower
+            elif lower in PRICING_MODELS:
+                key, value = "pricing_model", lower
+            else:
+                key, value = "region", word
+            if key in fields:
+                raise CommandError(f"More than one {key.replace('_', ' ')} given: {fields[key]} and {value}")
+            fields[key] = value
+
+        provider = fields.setdefault("provider", guess_provider(instance_type))
+        if "region" not in fields:
+            if provider not in self.default_regions:
+                raise CommandError(f"Name a region of {provider}")
+            fields["region"] = self.default_regions[provider]
+        try:
+            return ResourceSpec(instance_type=instance_type, **fields)
+        except ValueError as e:
+            raise CommandError(_first_error(e)) from None
+
+    def help(self) -> Card:
+        """Card listing the commands"""
+        return Card(
+            title="kcost commands",
+            summary=f"`{self.command} {USAGE}` prices instances from the catalogs",
+            lines=[
+                f"{self.command} estimate m5.xlarge x3",
+                f"{self.command} estimate n2-standard-4 x2 asia-northeast3 spot",
+                f"{self.command} estimate Standard_D4s_v5 azure koreacentral 200h",
+            ],
+        )
+
+    def _estimate_card(self, result: EstimateResult) -> Card:
+        item = result.line_items[0]
+        facts = [
+            ("Monthly", usd(result.monthly_cost)),
+            ("Yearly", usd(result.yearly_cost)),
+            ("Unit price", f"${item.unit_price_hourly:,.4f}/hour"),
+            ("Hours", f"{item.hours:g}/month per instance"),
+            ("Pricing", item.pricing_model),
+        ]
+        if result.applied_rules:
+            facts.append(("Discounts", ", ".join(rule.name for rule in result.applied_rules)))
+        return Card(
+            title=f"{item.instance_type} x{item.count} in {item.provider} {item.region}",
+            summary=f"{usd(result.monthly_cost, per_month=True)} ({usd(result.hourly_cost)}/hour)",
+            facts=facts,
+            context=f"Priced by {item.price_source}" if item.price_source else None,
+        )
+
+    def _error(self, message: str, lines: Optional[List[str]] = None, title: str = "Cannot estimate") -> Card:
+        return Card(
+            title=title,
+            summary=message,
+            lines=(lines or []) + [f"Usage: {self.command} {USAGE}"],
+            alert=True,
+        )
+
+
+def _first_error(error: ValueError) -> str:
+    """Message of the first field of a validation error"""
+    errors = getattr(error, "errors", None)
+    if callable(errors):
+        first = errors()[0]
+        return f"{'.'.join(str(part) for part in first.get('loc', ()))}: {first.get('msg', 'invalid value')}"
+    return str(error)
@@ -0,0 +1,67 @@
+"""
+Chat platform request verification
+
+Slack signs slash command requests with the app's signing secret:
+X-Slack-Signature is v0=<hex> of the HMAC-SHA256 of
+"v0:<X-Slack-Request-Timestamp>:<body>". Teams outgoing webhooks send
+Authorization: HMAC <base64> of the HMAC-SHA256 of the body under the
+base64-decoded security token shown when the webhook is created.
+"""
+
+import base64
+import binascii
+import hashlib
+import hmac
+import time
+from typing import Optional
+
+SLACK_SIGNATURE_HEADER = "X-Slack-Signature"
+SLACK_TIMESTAMP_HEADER = "X-Slack-Request-Timestamp"
+
+# Slack requests older than this are rejected as replays
+SLACK_MAX_AGE_SECONDS = 300
+
+_SLACK_VERSION = "v0"
+_TEAMS_SCHEME = "HMAC "
+
+
+def slack_signature(signing_secret: str, timestamp: str, body: bytes) -> str:
+    """X-Slack-Signature value of a request"""
+    message = f"{_SLACK_VERSION}:{timestamp}:".encode() + body
+    return f"{_SLACK_VERSION}=" + hmac.new(signing_secret.encode(), message, hashlib.sha256).hexdigest()
+
+
+def verify_slack(
+    signing_secret: str,
+    timestamp: Optional[str],
+    body: bytes,
+    signature: Optional[str],
+    now: Optional[float] = None,
+) -> bool:
+    """Whether a request was signed by Slack within the last SLACK_MAX_AGE_SECONDS"""
+    if not timestamp or not signature:
+        return False
+    try:
+        age = abs((time.time() if now is None else now) - int(timestamp))
+    except ValueError:
+        return False
+    if age > SLACK_MAX_AGE_SECONDS:
+        return False
+    return hmac.compare_digest(slack_signature(signing_secret, timestamp, body), signature)
+
+
+def teams_authorization(security_token: str, body: bytes) -> str:
+    """Authorization value of a Teams outgoing webhook request"""
+    key = base64.b64decode(security_token)
+    return _TEAMS_SCHEME + base64.b64encode(hmac.new(key, body, hashlib.sha256).digest()).decode()
+
+
+def verify_teams(security_token: str, body: bytes, authorization: Optional[str]) -> bool:
+    """Whether a request was sent by the Teams outgoing webhook of a security token"""
+    if not authorization or not authorization.startswith(_TEAMS_SCHEME):
+        return False
+    try:
+        expected = teams_authorization(security_token, body)
+    except (binascii.Error, ValueError):
+        return False
+    return hmac.compare_digest(expected, authorization)
@@ -0,0 +1,25 @@
+"""
+CloudFormation Template Cost Estimation Module
+
+Estimates the monthly cost of the stack a CloudFormation template creates,
+per logical resource, by resolving the template's intrinsic functions and
+pricing the resources with the Terraform resource mappers.
+"""
+
+from .models import CloudFormationEstimateRequest
+from .template import UNRESOLVED, TemplateResolver, load_template
+from .resources import ResourceType, get_resource_type, register_resource_type, supported_resource_types
+from .estimator import CloudFormationEstimator, resource_changes
+
+__all__ = [
+    "CloudFormationEstimateRequest",
+    "UNRESOLVED",
+    "TemplateResolver",
+    "load_template",
+    "ResourceType",
+    "get_resource_type",
+    "register_resource_type",
+    "supported_resource_types",
+    "CloudFormationEstimator",
+    "resource_changes",
+]
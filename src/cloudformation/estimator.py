@@ -0,0 +1,97 @@
+"""
+CloudFormation template cost estimator
+
+Resolves the template against the request's parameters and region, then
+prices every logical resource the stack creates with the Terraform
+estimator, as the resource it is converted to. Resources of types without
+a converter, or whose properties cannot be converted, are reported as
+unpriced.
+"""
+
+import logging
+from typing import List, Tuple
+
+from ..terraform import ResourceChange, TerraformEstimateResult, TerraformEstimator, UnpricedResource
+from ..terraform.models import ACTION_CREATE
+from .models import CloudFormationEstimateRequest
+from .resources import get_resource_type
+from .template import TemplateResolver, load_template
+
+logger = logging.getLogger(__name__)
+
+
+def resource_changes(request: CloudFormationEstimateRequest) -> Tuple[List[ResourceChange], List[UnpricedResource]]:
+    """
+    Creation of each logical resource of the request's template, and the resources that cannot be converted
+
+    Changes are addressed by logical ID and typed as the Terraform resource
+    the CloudFormation type converts to; types without a converter keep
+    their CloudFormation type and are left to the estimator to report.
+
+    Raises:
+        ValueError: If the template or its parameters are invalid
+    """
+    template = load_template(request.template)
+    resolver = TemplateResolver(template, request.parameters, request.region)
+
+    changes, unconverted = [], []
+    for logical_id, resource in template["Resources"].items():
+        if not isinstance(resource, dict) or not isinstance(resource.get("Type"), str):
+            raise ValueError(f"Resource {logical_id} has no Type")
+        if not resolver.resource_created(resource):
+            continue
+
+        cfn_type = resource["Type"]
+        resource_type = get_resource_type(cfn_type)
+        if resource_type is None:
+            changes.append(ResourceChange(
+                address=logical_id, type=cfn_type, action=ACTION_CREATE, provider="aws", after={},
+            ))
+            continue
+
+        try:
+            properties = resolver.resolve(resource.get("Properties") or {})
+            values = resource_type.convert(properties)
+        except (ValueError, TypeError, AttributeError) as e:
+            unconverted.append(UnpricedResource(
+                address=logical_id, type=resource_type.terraform_type, action=ACTION_CREATE, reason=str(e),
+            ))
+            continue
+        changes.append(ResourceChange(
+            address=logical_id, type=resource_type.terraform_type, action=ACTION_CREATE, provider="aws",
+            region=request.region, after=values,
+        ))
+    return changes, unconverted
+
+
+class CloudFormationEstimator:
+    """Estimates the monthly cost of the stack a CloudFormation template creates"""
+
+    def __init__(self, terraform: TerraformEstimator):
+        """
+        Initialize CloudFormation estimator
+
+        Args:
+            terraform: Estimator pricing the resources by the Terraform resource type they convert to
+        """
+        self.terraform = terraform
+
+    def estimate(self, request: CloudFormationEstimateRequest) -> TerraformEstimateResult:
+        """
+        Estimate the monthly cost of the request's template
+
+        Every priced resource is an addition, so the monthly delta is the
+        stack's monthly cost.
+
+        Raises:
+            ValueError: If the template or its parameters are invalid
+        """
+        changes, unconverted = resource_changes(request)
+        result = self.terraform.estimate_changes(changes, request.region, request.hours)
+        result.unpriced.extend(unconverted)
+
+        logger.info(
+            f"CloudFormation estimate: {len(result.added)} resources ({len(result.unpriced)} unpriced), "
+            f"${result.monthly_delta:.2f}/month"
+        )
+        return result
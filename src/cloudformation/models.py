@@ -0,0 +1,22 @@
+"""
+Data models for CloudFormation template cost estimation
+"""
+
+from typing import Any, Dict, Optional, Union
+
+from pydantic import BaseModel, Field
+
+from ..units import hours_per_month
+
+
+class CloudFormationEstimateRequest(BaseModel):
+    """Request model for the CloudFormation estimate"""
+
+    template: Union[Dict[str, Any], str] = Field(..., description="CloudFormation template, as JSON or YAML text")
+    parameters: Dict[str, Any] = Field(
+        default_factory=dict, description="Parameter values, parameters not given take their Default"
+    )
+    region: str = Field(..., description="Region the stack is deployed in, the value of AWS::Region")
+    hours: float = Field(default_factory=hours_per_month, gt=0, le=744, description="Running hours per month")
+    project: Optional[str] = Field(None, description="Project the estimate is recorded under")
+    labels: Dict[str, str] = Field(default_factory=dict, description="Metadata kept with the estimate, e.g. CI run URL")
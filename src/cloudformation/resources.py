@@ -0,0 +1,135 @@
+"""
+CloudFormation resource types
+
+Each supported resource type is converted to the Terraform resource it
+creates, its properties renamed to the Terraform attributes the resource
+mappers read. CloudFormation passes numbers and booleans as strings when
+they come from parameters, so they are converted back here.
+"""
+
+from typing import Any, Callable, Dict, List, NamedTuple, Optional
+
+# Properties of a resolved resource -> Terraform attribute values
+PropertyConverter = Callable[[Dict[str, Any]], Dict[str, Any]]
+
+# Device names the root volume of an AMI is mapped to
+ROOT_DEVICE_NAMES = ("/dev/xvda", "/dev/sda1")
+
+# RunInstances default when neither InstanceType nor a launch template is set
+DEFAULT_INSTANCE_TYPE = "m1.small"
+
+
+class ResourceType(NamedTuple):
+    """How a CloudFormation resource type is priced"""
+
+    terraform_type: str
+    convert: PropertyConverter
+
+
+_RESOURCE_TYPES: Dict[str, ResourceType] = {}
+
+
+def register_resource_type(cfn_type: str, terraform_type: str, convert: PropertyConverter) -> None:
+    """Price a CloudFormation resource type with the mapper of a Terraform resource type"""
+    _RESOURCE_TYPES[cfn_type] = ResourceType(terraform_type, convert)
+
+
+def get_resource_type(cfn_type: str) -> Optional[ResourceType]:
+    """Pricing registered for a CloudFormation resource type, if any"""
+    return _RESOURCE_TYPES.get(cfn_type)
+
+
+def supported_resource_types() -> List[str]:
+    """CloudFormation resource types that are priced"""
+    return sorted(_RESOURCE_TYPES)
+
+
+def _number(value: Any) -> Optional[float]:
+    if value is None or value == "":
+        return None
+    try:
+        return float(value)
+    except (TypeError, ValueError):
+        raise ValueError(f"Not a number: {value}") from None
+
+
+def _bool(value: Any) -> bool:
+    if isinstance(value, str):
+        return value.strip().lower() == "true"
+    return bool(value)
+
+
+def _volume(ebs: Dict[str, Any]) -> Dict[str, Any]:
+    return {"volume_size": _number(ebs.get("VolumeSize")), "volume_type": ebs.get("VolumeType")}
+
+
+def convert_instance(properties: Dict[str, Any]) -> Dict[str, Any]:
+    """AWS::EC2::Instance -> aws_instance"""
+    instance_type = properties.get("InstanceType")
+    if instance_type is None and "LaunchTemplate" in properties:
+        raise ValueError("Instance type set by a launch template is not resolved")
+
+    values: Dict[str, Any] = {
+        "instance_type": instance_type or DEFAULT_INSTANCE_TYPE,
+        "availability_zone": properties.get("AvailabilityZone"),
+        "ebs_block_device": [],
+    }
+    for mapping in properties.get("BlockDeviceMappings") or []:
+        ebs = mapping.get("Ebs")
+        if not isinstance(ebs, dict):
+            continue
+        if mapping.get("DeviceName") in ROOT_DEVICE_NAMES:
+            values["root_block_device"] = [_volume(ebs)]
+        else:
+            values["ebs_block_device"].append({"device_name": mapping.get("DeviceName"), **_volume(ebs)})
+
+    market = (properties.get("InstanceMarketOptions") or {}).get("MarketType")
+    if market:
+        values["instance_market_options"] = [{"market_type": market}]
+    return values
+
+
+def convert_volume(properties: Dict[str, Any]) -> Dict[str, Any]:
+    """AWS::EC2::Volume -> aws_ebs_volume"""
+    return {
+        "size": _number(properties.get("Size")),
+        "type": properties.get("VolumeType"),
+        "availability_zone": properties.get("AvailabilityZone"),
+    }
+
+
+def convert_db_instance(properties: Dict[str, Any]) -> Dict[str, Any]:
+    """AWS::RDS::DBInstance -> aws_db_instance"""
+    if "DBInstanceClass" not in properties:
+        raise ValueError("DBInstanceClass is not set")
+    return {
+        "instance_class": properties["DBInstanceClass"],
+        "engine": properties.get("Engine"),
+        "multi_az": _bool(properties.get("MultiAZ", False)),
+        "storage_type": properties.get("StorageType"),
+        "allocated_storage": _number(properties.get("AllocatedStorage")),
+        "iops": _number(properties.get("Iops")),
+    }
+
+
+def convert_eks_cluster(properties: Dict[str, Any]) -> Dict[str, Any]:
+    """AWS::EKS::Cluster -> aws_eks_cluster"""
+    return {"name": properties.get("Name"), "version": properties.get("Version")}
+
+
+def convert_eks_nodegroup(properties: Dict[str, Any]) -> Dict[str, Any]:
+    """AWS::EKS::Nodegroup -> aws_eks_node_group"""
+    scaling = properties.get("ScalingConfig") or {}
+    desired = scaling.get("DesiredSize", scaling.get("MinSize"))
+    return {
+        "instance_types": properties.get("InstanceTypes"),
+        "capacity_type": properties.get("CapacityType"),
+        "scaling_config": [{"desired_size": _number(desired)}],
+    }
+
+
+register_resource_type("AWS::EC2::Instance", "aws_instance", convert_instance)
+register_resource_type("AWS::EC2::Volume", "aws_ebs_volume", convert_volume)
+register_resource_type("AWS::RDS::DBInstance", "aws_db_instance", convert_db_instance)
+register_resource_type("AWS::EKS::Cluster", "aws_eks_cluster", convert_eks_cluster)
+register_resource_type("AWS::EKS::Nodegroup", "aws_eks_node_group", convert_eks_nodegroup)
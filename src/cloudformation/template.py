@@ -0,0 +1,328 @@
+"""
+CloudFormation template reader
+
+Loads JSON or YAML templates, short-form intrinsic function tags (!Ref,
+!Sub, !If, ...) included, and resolves resource properties against the
+parameter values and the stack's pseudo parameters:
+
+- Ref of parameters and pseudo parameters, AWS::NoValue leaving the
+  property out
+- Fn::FindInMap, Fn::If and the condition functions (Fn::Equals, Fn::And,
+  Fn::Or, Fn::Not, Condition)
+- Fn::Join, Fn::Select, Fn::Split, Fn::Sub, Fn::GetAZs and Fn::Base64
+
+Values known only once the stack is created (Ref of resources,
+Fn::GetAtt, Fn::ImportValue, Fn::Cidr) cannot be resolved and are left
+out, so the resource mappers fall back to their defaults. Resources whose
+Condition is false are not created and have no properties.
+"""
+
+import base64
+import json
+import re
+from typing import Any, Dict, List, Optional, Union
+
+import yaml
+
+PSEUDO_PARAMETERS = ("AWS::Region", "AWS::AccountId", "AWS::StackName", "AWS::StackId", "AWS::Partition",
+                     "AWS::URLSuffix", "AWS::NoValue", "AWS::NotificationARNs")
+
+# Pseudo parameter values standing in for the stack being estimated
+DEFAULT_ACCOUNT_ID = "123456789012"
+DEFAULT_STACK_NAME = "estimate"
+
+# Availability zones Fn::GetAZs returns for a region
+_GET_AZS_ZONES = ("a", "b", "c")
+
+_SUB_VARIABLE = re.compile(r"\$\{([^}]*)\}")
+
+
+class _Unresolved:
+    """Value known only once the stack is created"""
+
+    def __repr__(self) -> str:
+        return "UNRESOLVED"
+
+
+UNRESOLVED = _Unresolved()
+# Value of Ref AWS::NoValue, removing the property it is set to
+_NO_VALUE = object()
+
+
+class _TemplateLoader(yaml.SafeLoader):
+    """YAML loader reading short-form intrinsic function tags"""
+
+
+def _construct_tag(loader: yaml.SafeLoader, suffix: str, node: yaml.Node) -> Dict[str, Any]:
+    if isinstance(node, yaml.ScalarNode):
+        value: Any = loader.construct_scalar(node)
+    elif isinstance(node, yaml.SequenceNode):
+        value = loader.construct_sequence(node, deep=True)
+    else:
+        value = loader.construct_mapping(node, deep=True)
+
+    if suffix in ("Ref", "Condition"):
+        return {suffix: value}
+    if suffix == "GetAtt" and isinstance(value, str):
+        value = value.split(".", 1)
+    return {f"Fn::{suffix}": value}
+
+
+_TemplateLoader.add_multi_constructor("!", _construct_tag)
+
+
+def load_template(template: Union[Dict[str, Any], str]) -> Dict[str, Any]:
+    """
+    Load a CloudFormation template given as a document or as JSON or YAML text
+
+    Raises:
+        ValueError: If the input is not a CloudFormation template
+    """
+    if isinstance(template, str):
+        try:
+            document = json.loads(template)
+        except json.JSONDecodeError:
+            try:
+                document = yaml.load(template, Loader=_TemplateLoader)
+            except yaml.YAMLError as e:
+                raise ValueError(f"Not a CloudFormation template: {e}")
+    else:
+        document = template
+
+    if not isinstance(document, dict) or not isinstance(document.get("Resources"), dict):
+        raise ValueError("Not a CloudFormation template: Resources section missing")
+    return document
+
+
+def _text(value: Any) -> str:
+    """String form CloudFormation compares and joins values in"""
+    if isinstance(value, bool):
+        return "true" if value else "false"
+    return str(value)
+
+
+class TemplateResolver:
+    """Resolves the intrinsic functions of one template for one stack"""
+
+    def __init__(
+        self,
+        template: Dict[str, Any],
+        parameters: Optional[Dict[str, Any]] = None,
+        region: str = "us-east-1",
+        account_id: str = DEFAULT_ACCOUNT_ID,
+        stack_name: str = DEFAULT_STACK_NAME,
+    ):
+        """
+        Initialize resolver
+
+        Args:
+            template: Loaded template
+            parameters: Parameter values; parameters not given take their Default
+            region: Region the stack is deployed in
+            account_id: Value of AWS::AccountId
+            stack_name: Value of AWS::StackName
+
+        Raises:
+            ValueError: If a parameter is not declared, has no value or is not an allowed value
+        """
+        self.template = template
+        self.region = region
+        self.mappings = template.get("Mappings") or {}
+        self.conditions = template.get("Conditions") or {}
+        self._condition_values: Dict[str, bool] = {}
+
+        partition = "aws-cn" if region.startswith("cn-") else "aws"
+        self.values: Dict[str, Any] = {
+            "AWS::Region": region,
+            "AWS::AccountId": account_id,
+            "AWS::StackName": stack_name,
+            "AWS::StackId": UNRESOLVED,
+            "AWS::Partition": partition,
+            "AWS::URLSuffix": "amazonaws.com.cn" if partition == "aws-cn" else "amazonaws.com",
+            "AWS::NoValue": _NO_VALUE,
+            "AWS::NotificationARNs": [],
+        }
+        self.values.update(self._parameter_values(template.get("Parameters") or {}, parameters or {}))
+
+    def _parameter_values(self, declared: Dict[str, Any], given: Dict[str, Any]) -> Dict[str, Any]:
+        undeclared = sorted(set(given) - set(declared))
+        if undeclared:
+            raise ValueError(f"Parameters not declared in the template: {', '.join(undeclared)}")
+
+        values = {}
+        for name, declaration in declared.items():
+            declaration = declaration if isinstance(declaration, dict) else {}
+            if name in given:
+                value = given[name]
+            elif "Default" in declaration:
+                value = declaration["Default"]
+            else:
+                raise ValueError(f"Parameter {name} has no value and no Default")
+
+            allowed = declaration.get("AllowedValues")
+            if isinstance(allowed, list) and not isinstance(value, list):
+                if _text(value) not in [_text(v) for v in allowed]:
+                    raise ValueError(f"Parameter {name} must be one of: {', '.join(_text(v) for v in allowed)}")
+
+            parameter_type = str(declaration.get("Type") or "String")
+            is_list = parameter_type.startswith("List<") or parameter_type == "CommaDelimitedList"
+            if isinstance(value, str) and is_list:
+                value = [item.strip() for item in value.split(",")] if value else []
+            values[name] = value
+        return values
+
+    def condition(self, name: str) -> bool:
+        """
+        Value of a condition of the Conditions section
+
+        Raises:
+            ValueError: If the condition is not declared or cannot be evaluated
+        """
+        if name not in self._condition_values:
+            if name not in self.conditions:
+                raise ValueError(f"Condition {name} is not declared")
+            self._condition_values[name] = self._evaluate(self.conditions[name])
+        return self._condition_values[name]
+
+    def _evaluate(self, expression: Any) -> bool:
+        if isinstance(expression, dict) and len(expression) == 1:
+            function, argument = next(iter(expression.items()))
+            if function == "Condition":
+                return self.condition(argument)
+            if function == "Fn::Not":
+                return not self._evaluate(argument[0] if isinstance(argument, list) else argument)
+            if function == "Fn::And":
+                return all(self._evaluate(item) for item in argument)
+            if function == "Fn::Or":
+                return any(self._evaluate(item) for item in argument)
+            if function == "Fn::Equals":
+                left, right = (self.resolve(item) for item in argument)
+                if UNRESOLVED in (left, right):
+                    raise ValueError("Fn::Equals compares a value known only once the stack is created")
+                return _text(left) == _text(right)
+
+        value = self.resolve(expression)
+        if isinstance(value, bool):
+            return value
+        if isinstance(value, str) and value.lower() in ("true", "false"):
+            return value.lower() == "true"
+        raise ValueError(f"Not a condition: {expression}")
+
+    def resource_created(self, resource: Dict[str, Any]) -> bool:
+        """Whether a resource is created, its Condition (if any) being true"""
+        name = resource.get("Condition")
+        return name is None or self.condition(name)
+
+    def resolve(self, value: Any) -> Any:
+        """
+        Value with its intrinsic functions resolved
+
+        Properties and list items that cannot be resolved are left out;
+        UNRESOLVED is returned when the value itself cannot be.
+
+        Raises:
+            ValueError: If a function is malformed or refers to a missing mapping or condition
+        """
+        if isinstance(value, list):
+            items = [self.resolve(item) for item in value]
+            return [item for item in items if item is not _NO_VALUE and item is not UNRESOLVED]
+        if not isinstance(value, dict):
+            return value
+
+        if len(value) == 1:
+            function, argument = next(iter(value.items()))
+            if function == "Ref":
+                return self._ref(argument)
+            if function.startswith("Fn::"):
+                return self._function(function, argument)
+
+        resolved = {}
+        for key, item in value.items():
+            item = self.resolve(item)
+            if item is not _NO_VALUE and item is not UNRESOLVED:
+                resolved[key] = item
+        return resolved
+
+    def _ref(self, name: Any) -> Any:
+        if name in self.values:
+            return self.values[name]
+        if name in self.template["Resources"]:
+            return UNRESOLVED
+        raise ValueError(f"Ref to undeclared parameter or resource {name}")
+
+    def _function(self, function: str, argument: Any) -> Any:
+        if function == "Fn::If":
+            name, if_true, if_false = argument
+            return self.resolve(if_true if self.condition(name) else if_false)
+        if function == "Fn::FindInMap":
+            return self._find_in_map(argument)
+        if function == "Fn::Sub":
+            return self._sub(argument)
+        if function == "Fn::GetAZs":
+            region = self.resolve(argument) or self.region
+            return [f"{region}{zone}" for zone in _GET_AZS_ZONES] if isinstance(region, str) else UNRESOLVED
+        if function == "Fn::Join":
+            return self._join(argument)
+
+        argument = self.resolve(argument)
+        if argument is UNRESOLVED:
+            return UNRESOLVED
+        if function == "Fn::Select":
+            index, items = argument
+            index = int(index)
+            if not isinstance(items, list) or not 0 <= index < len(items):
+                return UNRESOLVED
+            return items[index]
+        if function == "Fn::Split":
+            delimiter, text = argument
+            return text.split(delimiter) if isinstance(text, str) else UNRESOLVED
+        if function == "Fn::Base64":
+            return base64.b64encode(_text(argument).encode()).decode() if isinstance(argument, str) else UNRESOLVED
+        # Fn::GetAtt, Fn::ImportValue, Fn::Cidr, Fn::Length, Fn::Transform, ...
+        return UNRESOLVED
+
+    def _join(self, argument: List[Any]) -> Any:
+        delimiter, items = argument
+        # Items are resolved one by one: a list joined without an unresolved item would be a wrong value
+        items = self.resolve(items) if not isinstance(items, list) else [self.resolve(item) for item in items]
+        if not isinstance(items, list) or UNRESOLVED in items:
+            return UNRESOLVED
+        return _text(delimiter).join(_text(item) for item in items if item is not _NO_VALUE)
+
+    def _find_in_map(self, argument: List[Any]) -> Any:
+        name, top, second = (self.resolve(item) for item in argument)
+        if UNRESOLVED in (name, top, second):
+            return UNRESOLVED
+        try:
+            return self.resolve(self.mappings[name][_text(top)][_text(second)])
+        except (KeyError, TypeError):
+            raise ValueError(f"Fn::FindInMap: no {name}.{top}.{second} in Mappings") from None
+
+    def _sub(self, argument: Any) -> Any:
+        if isinstance(argument, list):
+            text, variables = argument[0], self.resolve(argument[1] if len(argument) > 1 else {})
+        else:
+            text, variables = argument, {}
+        if not isinstance(text, str):
+            raise ValueError("Fn::Sub: template string expected")
+
+        unresolved = False
+
+        def substitute(match: "re.Match[str]") -> str:
+            nonlocal unresolved
+            name = match.group(1)
+            if name.startswith("!"):
+                return "${" + name[1:] + "}"
+            if name in variables:
+                value = variables[name]
+            elif "." in name and name.split(".", 1)[0] in self.template["Resources"]:
+                value = UNRESOLVED
+            else:
+                value = self._ref(name)
+            if value is UNRESOLVED:
+                unresolved = True
+                return ""
+            return ",".join(_text(v) for v in value) if isinstance(value, list) else _text(value)
+
+        result = _SUB_VARIABLE.sub(substitute, text)
+        return UNRESOLVED if unresolved else result
@@ -0,0 +1,28 @@
+"""
+Commitments Module
+
+This module recommends reserved instance, savings plan and committed use
+purchases from the instance hours of ingested billing exports: the
+number of instances to commit to per instance type and region for a
+coverage target, the term and payment option saving the most, and the
+cumulative costs by month showing when each purchase breaks even.
+"""
+
+from .models import (
+    BreakEvenPoint,
+    CommitmentOptionSavings,
+    CommitmentRecommendation,
+    CommitmentReport,
+    CommitmentRequest,
+)
+from .recommender import CommitmentRecommender, unit_hours
+
+__all__ = [
+    "BreakEvenPoint",
+    "CommitmentOptionSavings",
+    "CommitmentRecommendation",
+    "CommitmentReport",
+    "CommitmentRequest",
+    "CommitmentRecommender",
+    "unit_hours",
+]
@@ -0,0 +1,122 @@
+"""
+Data models for commitment purchase recommendations
+"""
+
+from datetime import date
+from typing import List, Optional
+
+from pydantic import BaseModel, Field, validator
+
+from ..pricing import COMMITMENT_MODELS, PAYMENT_OPTIONS, TERMS
+
+
+class CommitmentRequest(BaseModel):
+    """Request model for commitment purchase recommendations"""
+
+    lookback_days: Optional[int] = Field(
+        None, ge=7, le=365, description="Days of usage analyzed, ending yesterday, default the recommender's"
+    )
+    coverage_percent: Optional[float] = Field(
+        None, gt=0, le=100, description="Share of usage hours commitments should cover, default the recommender's"
+    )
+    provider: Optional[str] = Field(None, description="Only usage of this provider")
+    types: List[str] = Field(default_factory=list, description="reserved, savings_plan; default both")
+    terms: List[str] = Field(default_factory=list, description="1yr, 3yr; default both")
+    payment_options: List[str] = Field(
+        default_factory=list, description="no_upfront, partial_upfront, all_upfront; default all"
+    )
+    min_monthly_savings: float = Field(default=1.0, ge=0, description="Savings a recommendation needs (USD/month)")
+
+    @validator("provider")
+    def normalize_provider(cls, v):
+        return v.strip().lower() if v else v
+
+    @validator("types", each_item=True)
+    def validate_type(cls, v):
+        if v not in COMMITMENT_MODELS:
+            raise ValueError(f"type must be one of: {', '.join(COMMITMENT_MODELS)}")
+        return v
+
+    @validator("terms", each_item=True)
+    def validate_term(cls, v):
+        if v not in TERMS:
+            raise ValueError(f"term must be one of: {', '.join(TERMS)}")
+        return v
+
+    @validator("payment_options", each_item=True)
+    def validate_payment_option(cls, v):
+        if v not in PAYMENT_OPTIONS:
+            raise ValueError(f"payment_option must be one of: {', '.join(PAYMENT_OPTIONS)}")
+        return v
+
+
+class BreakEvenPoint(BaseModel):
+    """Cumulative cost of the covered usage after a month of the term"""
+
+    month: int
+    on_demand_cost: float = Field(..., description="Covered usage paid on-demand")
+    commitment_cost: float = Field(..., description="Upfront fee plus recurring charges paid so far")
+    savings_cost: float = Field(..., description="On-demand minus commitment, negative until break-even")
+
+
+class CommitmentOptionSavings(BaseModel):
+    """Savings of the best purchase of one commitment option"""
+
+    type: str = Field(..., description="reserved or savings_plan")
+    term: str
+    payment_option: str
+    count: int = Field(..., description="Instances committed to")
+    effective_hourly_price: float = Field(..., description="Recurring plus amortized upfront, per instance")
+    upfront_cost: float
+    monthly_savings_cost: float
+    break_even_month: Optional[int] = Field(None, description="First month cumulative savings are not negative")
+
+
+class CommitmentRecommendation(BaseModel):
+    """Commitment purchase for one instance type of a region"""
+
+    provider: str
+    region: str
+    instance_type: str
+    usage_hours: float = Field(..., description="Instance hours in the lookback period")
+    average_instances: float
+    min_instances: float = Field(..., description="Lowest daily average of running instances")
+    max_instances: float = Field(..., description="Highest daily average of running instances")
+    on_demand_hourly_price: float
+    type: str
+    term: str
+    payment_option: str
+    count: int
+    coverage: float = Field(..., description="Share of the usage hours the commitment covers")
+    utilization: float = Field(..., description="Share of the committed hours the usage would use")
+    effective_hourly_price: float
+    upfront_cost: float
+    on_demand_monthly_cost: float = Field(..., description="Covered usage at the on-demand price")
+    commitment_monthly_cost: float = Field(..., description="Commitment, its upfront fee amortized over the term")
+    monthly_savings_cost: float
+    term_savings_cost: float
+    break_even_month: Optional[int] = None
+    break_even: List[BreakEvenPoint] = Field(..., description="Cumulative costs by month over the term")
+    options: List[CommitmentOptionSavings] = Field(
+        ..., description="Every priced option's best purchase, largest savings first"
+    )
+    summary: str = Field(..., description="e.g. buy 6 x m5.xlarge reserved 1yr no_upfront, save $312/mo")
+
+
+class CommitmentReport(BaseModel):
+    """Commitment purchases recommended from a tenant's ingested usage, largest savings first"""
+
+    period_start: date
+    period_end: date = Field(..., description="First day after the period")
+    lookback_days: int
+    coverage_percent: float
+    recommendations: List[CommitmentRecommendation]
+    on_demand_monthly_cost: float = Field(..., description="Monthly cost of the analyzed usage at on-demand prices")
+    commitment_monthly_cost: float = Field(..., description="Recommended commitments, upfront fees amortized")
+    monthly_savings_cost: float
+    upfront_cost: float
+    coverage: float = Field(..., description="Share of the analyzed usage hours the recommendations cover")
+    currency: str = "USD"
+    unmatched: List[str] = Field(
+        default_factory=list, description="Compute usage (provider/region/sku) without an on-demand price"
+    )
@@ -0,0 +1,312 @@
+"""
+Commitment purchase recommendations
+
+Instance hours are read from the compute usage of a tenant's ingested
+billing exports (the instance type SKUs of EC2 and Azure VM lines) over
+the lookback period. Each instance type of a region is counted in daily
+average running instances, days without usage counting as none.
+
+For every commitment option (reserved or savings plan, term, payment
+option) priced for the type, committing to N instances covers
+min(N, average) instances each day. N grows while each extra instance
+saves more on-demand spend than it costs; of those counts, the one
+covering the share of usage hours closest to the coverage target is
+bought, so spiky usage is left on-demand. The option saving the most is
+recommended, with its cumulative on-demand and commitment costs by month
+of the term (the break-even chart).
+
+Savings are against on-demand list prices; usage already covered by
+commitments is billed on other lines and stays out of the analysis.
+"""
+
+import logging
+import math
+import re
+from datetime import date, datetime, timedelta, timezone
+from typing import Callable, Dict, List, Optional, Tuple
+
+from ..money import round_money, sum_money
+from ..pricing import (
+    ATTR_PAYMENT_OPTION,
+    ATTR_TERM,
+    COMMITMENT_MODELS,
+    PAYMENT_OPTIONS,
+    PRICING_ON_DEMAND,
+    Price,
+    PriceNotFoundError,
+    PriceQuery,
+    ProviderNotFoundError,
+    ProviderRegistry,
+    SERVICE_COMPUTE,
+    TERMS,
+    term_hours,
+)
+from ..store import Store
+from ..units import HOURS_PER_DAY, MONTHS_PER_YEAR, hours_per_month
+from .models import (
+    BreakEvenPoint,
+    CommitmentOptionSavings,
+    CommitmentRecommendation,
+    CommitmentReport,
+    CommitmentRequest,
+)
+
+logger = logging.getLogger(__name__)
+
+# Usage units of instance hours: Hrs (AWS), 1 Hour / 10 Hours (Azure)
+_HOUR_UNIT = re.compile(r"^\s*(\d+)?\s*(hrs?|hours?)\s*$", re.IGNORECASE)
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+def unit_hours(unit: str) -> Optional[float]:
+    """Hours one unit of usage counts, None if the unit is not hours"""
+    match = _HOUR_UNIT.match(unit or "")
+    if not match:
+        return None
+    return float(match.group(1) or 1)
+
+
+class CommitmentRecommender:
+    """Recommends reserved instance, savings plan and committed use purchases from actual usage"""
+
+    def __init__(
+        self,
+        store: Store,
+        registry: ProviderRegistry,
+        lookback_days: int = 30,
+        coverage_percent: float = 80.0,
+        clock: Callable[[], datetime] = utcnow,
+    ):
+        """
+        Initialize recommender
+
+        Args:
+            store: Store holding ingested actual costs
+            registry: Registry pricing on-demand and commitment rates
+            lookback_days: Days of usage analyzed, ending yesterday
+            coverage_percent: Share of usage hours commitments should cover
+            clock: Current time (aware UTC)
+        """
+        self.store = store
+        self.registry = registry
+        self.lookback_days = lookback_days
+        self.coverage_percent = coverage_percent
+        self.clock = clock
+
+    def recommend(self, tenant_id: str, request: CommitmentRequest) -> CommitmentReport:
+        """Commitment purchases for a tenant's recent compute usage, largest savings first"""
+        days = request.lookback_days or self.lookback_days
+        coverage_target = (request.coverage_percent or self.coverage_percent) / 100
+        end = self.clock().date()
+        start = end - timedelta(days=days)
+
+        usage: Dict[Tuple[str, str, str], Dict[date, float]] = {}
+        for record in self.store.list_actual_costs(tenant_id, start, end):
+            if record.service != SERVICE_COMPUTE or not record.sku or record.usage_quantity <= 0:
+                continue
+            if request.provider and record.provider != request.provider:
+                continue
+            hours = unit_hours(record.usage_unit)
+            if hours is None:
+                continue
+            daily = usage.setdefault((record.provider, record.region, record.sku), {})
+            daily[record.usage_date] = daily.get(record.usage_date, 0.0) + record.usage_quantity * hours
+
+        recommendations: List[CommitmentRecommendation] = []
+        unmatched: List[str] = []
+        on_demand_total = covered_total = hours_total = 0.0
+        for (provider, region, sku), daily in sorted(usage.items()):
+            try:
+                on_demand = self._price(provider, region, sku, PRICING_ON_DEMAND)
+            except (PriceNotFoundError, ProviderNotFoundError, ValueError):
+                unmatched.append(f"{provider}/{region}/{sku}")
+                continue
+            instances = [daily.get(start + timedelta(days=i), 0.0) / HOURS_PER_DAY for i in range(days)]
+            hours = sum(instances) * HOURS_PER_DAY
+            on_demand_total += on_demand.price * hours
+            hours_total += hours
+
+            recommendation = self._recommend(
+                provider, region, sku, on_demand.price, instances, coverage_target, request
+            )
+            if recommendation is not None and recommendation.monthly_savings_cost >= request.min_monthly_savings:
+                recommendations.append(recommendation)
+                covered_total += recommendation.coverage * hours
+
+        recommendations.sort(key=lambda r: (-r.monthly_savings_cost, r.provider, r.region, r.instance_type))
+        monthly = hours_per_month() / (days * HOURS_PER_DAY)
+        report = CommitmentReport(
+            period_start=start,
+            period_end=end,
+            lookback_days=days,
+            coverage_percent=coverage_target * 100,
+            recommendations=recommendations,
+            on_demand_monthly_cost=round_money(on_demand_total * monthly),
+            commitment_monthly_cost=sum_money(r.commitment_monthly_cost for r in recommendations),
+            monthly_savings_cost=sum_money(r.monthly_savings_cost for r in recommendations),
+            upfront_cost=sum_money(r.upfront_cost for r in recommendations),
+            coverage=round(covered_total / hours_total, 4) if hours_total else 0.0,
+            unmatched=unmatched,
+        )
+        logger.info(
+            f"Commitment recommendations for tenant {tenant_id}: {len(recommendations)} purchases, "
+            f"${report.monthly_savings_cost:.2f}/month saved"
+        )
+        return report
+
+    def _price(
+        self, provider: str, region: str, sku: str, pricing_model: str, term: str = "", payment: str = ""
+    ) -> Price:
+        attributes = {ATTR_TERM: term, ATTR_PAYMENT_OPTION: payment} if term else {}
+        return self.registry.get_price(PriceQuery(
+            provider=provider,
+            region=region,
+            sku=sku,
+            service=SERVICE_COMPUTE,
+            pricing_model=pricing_model,
+            attributes=attributes,
+        ))
+
+    def _recommend(
+        self,
+        provider: str,
+        region: str,
+        sku: str,
+        on_demand_hourly: float,
+        instances: List[float],
+        coverage_target: float,
+        request: CommitmentRequest,
+    ) -> Optional[CommitmentRecommendation]:
+        """Best purchase over the allowed options, None if no option saves anything"""
+        hours = sum(instances) * HOURS_PER_DAY
+        if not hours:
+            return None
+        period_hours = len(instances) * HOURS_PER_DAY
+        month_hours = hours_per_month()
+        monthly = month_hours / period_hours
+
+        options: List[CommitmentOptionSavings] = []
+        best: Optional[Tuple[CommitmentOptionSavings, float, float, float, List[BreakEvenPoint]]] = None
+        for model in request.types or COMMITMENT_MODELS:
+            for term in request.terms or TERMS:
+                for payment in request.payment_options or PAYMENT_OPTIONS:
+                    try:
+                        price = self._price(provider, region, sku, model, term, payment)
+                    except (PriceNotFoundError, ProviderNotFoundError, ValueError):
+                        continue
+                    upfront_hourly = price.upfront / term_hours(term)
+                    effective = price.price + upfront_hourly
+                    count, covered = _commitment(instances, on_demand_hourly, effective, coverage_target)
+                    if not count:
+                        continue
+                    on_demand_monthly = on_demand_hourly * covered * monthly
+                    commitment_monthly = effective * count * month_hours
+                    savings = on_demand_monthly - commitment_monthly
+                    chart = _break_even(
+                        TERMS[term] * MONTHS_PER_YEAR, on_demand_monthly,
+                        price.upfront * count, price.price * count * month_hours,
+                    )
+                    option = CommitmentOptionSavings(
+                        type=model,
+                        term=term,
+                        payment_option=payment,
+                        count=count,
+                        effective_hourly_price=round_money(effective),
+                        upfront_cost=round_money(price.upfront * count),
+                        monthly_savings_cost=round_money(savings),
+                        break_even_month=_break_even_month(chart),
+                    )
+                    options.append(option)
+                    if savings > 0 and (best is None or savings > best[0].monthly_savings_cost):
+                        best = (option, covered, on_demand_monthly, commitment_monthly, chart)
+        if best is None:
+            return None
+
+        option, covered, on_demand_monthly, commitment_monthly, chart = best
+        term_months = TERMS[option.term] * MONTHS_PER_YEAR
+        savings = on_demand_monthly - commitment_monthly
+        options.sort(key=lambda o: (-o.monthly_savings_cost, o.type, o.term, o.payment_option))
+        return CommitmentRecommendation(
+            provider=provider,
+            region=region,
+            instance_type=sku,
+            usage_hours=round(hours, 2),
+            average_instances=round(hours / period_hours, 2),
+            min_instances=round(min(instances), 2),
+            max_instances=round(max(instances), 2),
+            on_demand_hourly_price=on_demand_hourly,
+            type=option.type,
+            term=option.term,
+            payment_option=option.payment_option,
+            count=option.count,
+            coverage=round(covered / hours, 4),
+            utilization=round(covered / (option.count * period_hours), 4),
+            effective_hourly_price=option.effective_hourly_price,
+            upfront_cost=option.upfront_cost,
+            on_demand_monthly_cost=round_money(on_demand_monthly),
+            commitment_monthly_cost=round_money(commitment_monthly),
+            monthly_savings_cost=round_money(savings),
+            term_savings_cost=round_money(savings * term_months),
+            break_even_month=option.break_even_month,
+            break_even=chart,
+            options=options,
+            summary=(
+                f"buy {option.count} x {sku} {option.type} {option.term} {option.payment_option} in {region}, "
+                f"save ${savings:,.0f}/mo"
+            ),
+        )
+
+
+def _covered_hours(instances: List[float], count: int) -> float:
+    """Usage hours a commitment to count instances covers"""
+    return sum(min(count, level) for level in instances) * HOURS_PER_DAY
+
+
+def _commitment(
+    instances: List[float], on_demand_hourly: float, effective_hourly: float, coverage_target: float
+) -> Tuple[int, float]:
+    """
+    (instances committed to, usage hours covered)
+
+    Counts are tried while one more instance saves more than it costs;
+    the count whose coverage is closest to the target wins, the smaller
+    one on a tie.
+    """
+    hours = sum(instances) * HOURS_PER_DAY
+    period_hours = len(instances) * HOURS_PER_DAY
+    best = (coverage_target, 0, 0.0)
+    covered = 0.0
+    for count in range(1, math.ceil(max(instances)) + 1):
+        more = _covered_hours(instances, count)
+        # Usage the extra instance covers must be worth its committed hours
+        if (more - covered) * on_demand_hourly <= effective_hourly * period_hours:
+            break
+        covered = more
+        distance = abs(covered / hours - coverage_target)
+        if distance < best[0] - 1e-9:
+            best = (distance, count, covered)
+    return best[1], best[2]
+
+
+def _break_even(
+    months: int, on_demand_monthly: float, upfront: float, recurring_monthly: float
+) -> List[BreakEvenPoint]:
+    """Cumulative costs of the covered usage by month, month 0 holding the upfront fee"""
+    points = []
+    for month in range(months + 1):
+        on_demand = on_demand_monthly * month
+        commitment = upfront + recurring_monthly * month
+        points.append(BreakEvenPoint(
+            month=month,
+            on_demand_cost=round_money(on_demand),
+            commitment_cost=round_money(commitment),
+            savings_cost=round_money(on_demand - commitment),
+        ))
+    return points
+
+
+def _break_even_month(chart: List[BreakEvenPoint]) -> Optional[int]:
+    return next((point.month for point in chart if point.month and point.savings_cost >= 0), None)
@@ -0,0 +1,29 @@
+"""
+Cost Comparison Module
+
+Normalizes abstract workload requirements to concrete instance types per
+provider and compares their prices side by side.
+"""
+
+from .models import CompareRequest, CompareOption, CompareResult, STORAGE_TIERS
+from .normalize import (
+    GEOGRAPHY_REGIONS,
+    STORAGE_TYPES,
+    regions_for,
+    matching_instance_types,
+    storage_type_for,
+)
+from .comparer import Comparer
+
+__all__ = [
+    "CompareRequest",
+    "CompareOption",
+    "CompareResult",
+    "STORAGE_TIERS",
+    "GEOGRAPHY_REGIONS",
+    "STORAGE_TYPES",
+    "regions_for",
+    "matching_instance_types",
+    "storage_type_for",
+    "Comparer",
+]
@@ -0,0 +1,151 @@
+"""
+Cross-provider cost comparison
+
+Matches an abstract workload to the instance types of each provider,
+prices every candidate in the allowed regions and returns the options
+cheapest first.
+"""
+
+import logging
+from typing import Dict, Optional
+
+from ..discounts import DiscountEngine
+from ..estimator import CostEstimator, ResourceSpec
+from ..k8s.storage import volume_sku
+from ..money import round_money
+from ..pricing import (
+    InstanceShape,
+    PriceQuery,
+    PriceNotFoundError,
+    ProviderNotFoundError,
+    ProviderRegistry,
+    SERVICE_BLOCK_STORAGE,
+)
+from ..units import months_per_year
+from .models import CompareOption, CompareRequest, CompareResult
+from .normalize import matching_instance_types, regions_for, storage_type_for
+
+logger = logging.getLogger(__name__)
+
+
+class Comparer:
+    """Compares equivalent instance options across providers"""
+
+    def __init__(self, registry: ProviderRegistry, discounts: Optional[DiscountEngine] = None):
+        """
+        Initialize comparer
+
+        Args:
+            registry: Registry of enabled pricing providers
+            discounts: Discount rules applied to each option's compute cost
+        """
+        self.registry = registry
+        self.estimator = CostEstimator(registry=registry, discounts=discounts)
+
+    def compare(self, request: CompareRequest) -> CompareResult:
+        """
+        Price the cheapest matching options of every requested provider
+
+        Raises:
+            ValueError: If the request's geography is unknown
+        """
+        result = CompareResult()
+
+        for provider in request.providers:
+            regions = regions_for(provider, request)
+            if not regions:
+                result.unavailable[provider] = "No regions allowed"
+                continue
+
+            candidates = matching_instance_types(provider, request)
+            if not candidates:
+                result.unavailable[provider] = "No known instance type satisfies the requirements"
+                continue
+
+            options = []
+            for region in regions:
+                storage = self._storage_cost(provider, region, request)
+                for instance_type, shape in candidates:
+                    option = self._option(provider, region, instance_type, shape, request, storage)
+                    if option is not None:
+                        options.append(option)
+
+            if not options:
+                result.unavailable[provider] = f"No prices available in {', '.join(regions)}"
+                continue
+
+            options.sort(key=lambda o: (o.monthly_cost, o.region, o.instance_type))
+            result.options.extend(options[:request.max_options_per_provider])
+            result.cheapest[provider] = options[0]
+
+        result.options.sort(key=lambda o: (o.monthly_cost, o.provider, o.region, o.instance_type))
+
+        logger.info(
+            f"Compared {request.vcpus} vCPU / {request.memory_gb} GiB across "
+            f"{len(request.providers)} providers: {len(result.options)} options"
+        )
+        return result
+
+    def _option(
+        self,
+        provider: str,
+        region: str,
+        instance_type: str,
+        shape: InstanceShape,
+        request: CompareRequest,
+        storage: Optional[Dict],
+    ) -> Optional[CompareOption]:
+        if storage is None and request.storage_gb > 0:
+            return None
+
+        try:
+            item = self.estimator.price_resource(ResourceSpec(
+                provider=provider,
+                instance_type=instance_type,
+                region=region,
+                count=request.count,
+                hours=request.hours,
+                pricing_model=request.pricing_model,
+            ))
+        except (PriceNotFoundError, ProviderNotFoundError):
+            return None
+
+        storage_cost = storage["monthly_cost"] if storage else 0.0
+        monthly = item.monthly_cost + storage_cost
+
+        return CompareOption(
+            provider=provider,
+            region=region,
+            instance_type=instance_type,
+            vcpus=shape.vcpus,
+            memory_gb=shape.memory_gb,
+            gpus=shape.gpus,
+            accelerator=shape.accelerator or None,
+            arch=shape.arch,
+            pricing_model=item.pricing_model,
+            unit_price_hourly=item.unit_price_hourly,
+            price_source=item.price_source,
+            compute_monthly_cost=item.monthly_cost,
+            storage_type=storage["type"] if storage else None,
+            storage_monthly_cost=round_money(storage_cost),
+            discounts=item.discounts,
+            monthly_cost=round_money(monthly),
+            yearly_cost=round_money(monthly * months_per_year()),
+        )
+
+    def _storage_cost(self, provider: str, region: str, request: CompareRequest) -> Optional[Dict]:
+        """Monthly block storage cost of all instances, or None if it cannot be priced"""
+        if request.storage_gb <= 0:
+            return None
+
+        try:
+            volume_type = storage_type_for(provider, request.storage_tier)
+            sku = volume_sku(provider, volume_type, request.storage_gb)
+            price = self.registry.get_price(PriceQuery(
+                provider=provider, region=region, sku=sku, service=SERVICE_BLOCK_STORAGE,
+            ))
+        except (KeyError, ValueError, PriceNotFoundError, ProviderNotFoundError):
+            return None
+
+        per_volume = price.price * request.storage_gb if price.unit == "GB-month" else price.price
+        return {"type": sku, "monthly_cost": per_volume * request.count}
@@ -0,0 +1,95 @@
+"""
+Data models for cross-provider cost comparison
+"""
+
+from typing import Dict, List, Optional
+from pydantic import BaseModel, Field, validator
+
+from ..estimator import AppliedDiscount
+from ..pricing import PRICING_ON_DEMAND, normalize_accelerator, normalize_pricing_model
+from ..units import TimeBasis, current_time_basis, hours_per_month, size_field
+
+STORAGE_TIERS = ("hdd", "standard", "premium")
+
+
+class CompareRequest(BaseModel):
+    """Abstract workload to be matched to instances of each provider"""
+
+    vcpus: float = Field(..., gt=0, description="Minimum vCPUs per instance")
+    memory_gb: float = Field(..., gt=0, description="Minimum memory per instance (GiB)")
+    gpus: int = Field(default=0, ge=0, description="Minimum GPUs per instance; GPU instances only match if set")
+    accelerator: Optional[str] = Field(None, description="Required GPU type, e.g. nvidia-tesla-t4")
+    arch: Optional[str] = Field(None, description="Required CPU architecture: x86_64 or arm64")
+    storage_gb: float = Field(default=0.0, ge=0, description="Block storage per instance (GiB)")
+    storage_tier: str = Field(default="standard", description="hdd, standard (SSD) or premium (SSD)")
+    count: int = Field(default=1, ge=1, description="Number of instances")
+    hours: float = Field(default_factory=hours_per_month, gt=0, le=744, description="Running hours per month")
+    pricing_model: str = Field(default=PRICING_ON_DEMAND, description="on_demand or spot")
+    providers: List[str] = Field(default_factory=lambda: ["aws", "gcp", "azure"])
+    regions: Dict[str, List[str]] = Field(
+        default_factory=dict,
+        description="Allowed regions per provider; overrides geography"
+    )
+    geography: Optional[str] = Field(None, description="Region group used when regions are not given, e.g. us, eu, kr")
+    include_burstable: bool = Field(default=False, description="Consider burstable/shared-core types")
+    max_options_per_provider: int = Field(default=3, ge=1, le=20)
+
+    @validator("memory_gb", "storage_gb", pre=True)
+    def parse_sizes(cls, v):
+        return size_field(v)
+
+    @validator("providers", each_item=True)
+    def normalize_provider(cls, v):
+        return v.strip().lower()
+
+    @validator("pricing_model")
+    def validate_pricing_model(cls, v):
+        return normalize_pricing_model(v)
+
+    @validator("accelerator")
+    def validate_accelerator(cls, v):
+        return normalize_accelerator(v) if v is not None else None
+
+    @validator("arch")
+    def validate_arch(cls, v):
+        if v is not None and v not in ("x86_64", "arm64"):
+            raise ValueError("arch must be x86_64 or arm64")
+        return v
+
+    @validator("storage_tier")
+    def validate_storage_tier(cls, v):
+        if v not in STORAGE_TIERS:
+            raise ValueError(f"storage_tier must be one of {', '.join(STORAGE_TIERS)}")
+        return v
+
+
+class CompareOption(BaseModel):
+    """One concrete instance option and its cost"""
+
+    provider: str
+    region: str
+    instance_type: str
+    vcpus: float
+    memory_gb: float
+    gpus: int = 0
+    accelerator: Optional[str] = None
+    arch: str
+    pricing_model: str = PRICING_ON_DEMAND
+    unit_price_hourly: float
+    price_source: Optional[str] = None
+    compute_monthly_cost: float
+    storage_type: Optional[str] = None
+    storage_monthly_cost: float = 0.0
+    discounts: List[AppliedDiscount] = Field(default_factory=list)
+    monthly_cost: float
+    yearly_cost: float
+
+
+class CompareResult(BaseModel):
+    """Options across providers, cheapest first"""
+
+    options: List[CompareOption] = Field(default_factory=list)
+    cheapest: Dict[str, CompareOption] = Field(default_factory=dict, description="Cheapest option per provider")
+    unavailable: Dict[str, str] = Field(default_factory=dict, description="Providers without a priced option")
+    currency: str = "USD"
+    time_basis: TimeBasis = Field(default_factory=current_time_basis, description="Hours per month of the costs")
@@ -0,0 +1,119 @@
+"""
+Resource requirement normalization
+
+Maps abstract requirements (vCPU, memory, storage tier, region group)
+onto the concrete instance types, volume types and regions of each provider.
+"""
+
+from typing import Dict, List, Tuple
+
+from ..pricing import InstanceShape, known_instance_types
+from .models import CompareRequest
+
+# Region groups -> regions per provider
+GEOGRAPHY_REGIONS: Dict[str, Dict[str, List[str]]] = {
+    "us": {
+        "aws": ["us-east-1", "us-east-2", "us-west-2"],
+        "gcp": ["us-central1", "us-east1", "us-west1"],
+        "azure": ["eastus", "eastus2", "westus2"],
+        "alibaba": ["us-east-1", "us-west-1"],
+    },
+    "eu": {
+        "aws": ["eu-west-1", "eu-central-1"],
+        "gcp": ["europe-west1", "europe-west4"],
+        "azure": ["westeurope", "northeurope"],
+        "alibaba": ["eu-central-1", "eu-west-1"],
+    },
+    "kr": {
+        "aws": ["ap-northeast-2"],
+        "gcp": ["asia-northeast3"],
+        "azure": ["koreacentral"],
+        "alibaba": ["ap-northeast-2"],
+    },
+    "jp": {
+        "aws": ["ap-northeast-1"],
+        "gcp": ["asia-northeast1"],
+        "azure": ["japaneast"],
+        "alibaba": ["ap-northeast-1"],
+    },
+    "sg": {
+        "aws": ["ap-southeast-1"],
+        "gcp": ["asia-southeast1"],
+        "azure": ["southeastasia"],
+        "alibaba": ["ap-southeast-1"],
+    },
+}
+
+DEFAULT_GEOGRAPHY = "us"
+
+# Storage tier -> block volume type per provider
+STORAGE_TYPES: Dict[str, Dict[str, str]] = {
+    "hdd": {"aws": "st1", "gcp": "pd-standard", "azure": "Standard_LRS", "alibaba": "cloud_efficiency"},
+    "standard": {"aws": "gp3", "gcp": "pd-balanced", "azure": "StandardSSD_LRS", "alibaba": "cloud_essd_pl0"},
+    "premium": {"aws": "io1", "gcp": "pd-ssd", "azure": "Premium_LRS", "alibaba": "cloud_essd_pl2"},
+}
+
+
+def regions_for(provider: str, request: CompareRequest) -> List[str]:
+    """
+    Regions a provider may be priced in
+
+    Raises:
+        ValueError: If the geography is unknown
+    """
+    if provider in request.regions:
+        return request.regions[provider]
+    if request.regions:
+        # Explicit regions were given for other providers only
+        return []
+
+    geography = request.geography or DEFAULT_GEOGRAPHY
+    if geography not in GEOGRAPHY_REGIONS:
+        raise ValueError(
+            f"Unknown geography '{geography}' (available: {', '.join(sorted(GEOGRAPHY_REGIONS))})"
+        )
+    return GEOGRAPHY_REGIONS[geography].get(provider, [])
+
+
+def is_burstable(provider: str, instance_type: str) -> bool:
+    """Whether an instance type is burstable or shared-core"""
+    if provider == "aws":
+        return instance_type.startswith("t")
+    if provider == "azure":
+        return instance_type.startswith("Standard_B")
+    if provider == "alibaba":
+        return instance_type.startswith("ecs.t")
+    if provider == "gcp":
+        return instance_type in ("e2-micro", "e2-small", "e2-medium", "f1-micro", "g1-small")
+    return False
+
+
+def matching_instance_types(provider: str, request: CompareRequest) -> List[Tuple[str, InstanceShape]]:
+    """Known instance types of a provider that satisfy the requirements, smallest first"""
+    matches = []
+    for instance_type, shape in known_instance_types(provider).items():
+        if shape.vcpus < request.vcpus or shape.memory_gb < request.memory_gb or shape.gpus < request.gpus:
+            continue
+        # GPU instances are only candidates of GPU requests
+        if shape.gpus and not request.gpus:
+            continue
+        if request.accelerator and shape.accelerator != request.accelerator:
+            continue
+        if request.arch and shape.arch != request.arch:
+            continue
+        if not request.include_burstable and is_burstable(provider, instance_type):
+            continue
+        matches.append((instance_type, shape))
+
+    matches.sort(key=lambda m: (m[1].vcpus, m[1].memory_gb, m[0]))
+    return matches
+
+
+def storage_type_for(provider: str, tier: str) -> str:
+    """
+    Block volume type of a storage tier
+
+    Raises:
+        KeyError: If the provider has no volume type for the tier
+    """
+    return STORAGE_TYPES[tier][provider]
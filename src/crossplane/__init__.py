@@ -0,0 +1,27 @@
+"""
+Crossplane Cost Estimation Module
+
+Estimates the monthly cost of Crossplane claims, composite resources and
+managed resources by rendering compositions and pricing the managed
+resources of the Upbound providers with the Terraform resource mappers
+they are generated from.
+"""
+
+from .models import CrossplaneEstimateRequest
+from .resources import CROSSPLANE_RESOURCE_TYPES, PROVIDER_GROUPS, terraform_type
+from .composition import Composition, CompositionCatalog, apply_patches, apply_transforms
+from .estimator import CrossplaneEstimator, load_compositions, load_documents
+
+__all__ = [
+    "CrossplaneEstimateRequest",
+    "CROSSPLANE_RESOURCE_TYPES",
+    "PROVIDER_GROUPS",
+    "terraform_type",
+    "Composition",
+    "CompositionCatalog",
+    "apply_patches",
+    "apply_transforms",
+    "CrossplaneEstimator",
+    "load_compositions",
+    "load_documents",
+]
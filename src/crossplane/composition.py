@@ -0,0 +1,464 @@
+"""
+Crossplane compositions
+
+Renders the resources a composite resource (XR) composes, the way
+Crossplane's patch-and-transform composition does: the base of each
+composed resource is copied and patched from the composite's fields.
+Both the legacy resources mode (spec.resources) and the pipeline mode
+with function-patch-and-transform are read; other composition functions
+cannot be run here and compose nothing.
+
+- patches: FromCompositeFieldPath, CombineFromComposite (string
+  strategy) and PatchSet; patches writing back to the composite are
+  left out
+- transforms: map, match (literal and regexp patterns), math (Multiply,
+  ClampMin, ClampMax), string (Format, Convert, TrimPrefix, TrimSuffix)
+  and convert
+
+A claim's spec is the spec of the composite it claims. Compositions are
+selected by compositionRef, then compositionSelector, then the
+definition's defaultCompositionRef, then the only composition of the
+composite type.
+"""
+
+import copy
+import re
+from typing import Any, Dict, List, NamedTuple, Optional, Tuple
+
+from .resources import api_group
+
+XRD_KIND = "CompositeResourceDefinition"
+COMPOSITION_KIND = "Composition"
+# apiextensions.crossplane.io/v1
+CROSSPLANE_GROUP = "apiextensions.crossplane.io"
+
+# Input kind of function-patch-and-transform pipeline steps
+PATCH_AND_TRANSFORM_INPUT = "Resources"
+
+_PATH_PART = re.compile(r"([^.\[\]]+)|\[([^\]]+)\]")
+_FORMAT_VERB = re.compile(r"%(?:[-+# 0]*\d*(?:\.\d+)?)([svdfqt%])")
+
+
+class _Missing:
+    """A field path with no value"""
+
+
+_MISSING = _Missing()
+
+
+def _path_parts(path: str) -> List[Any]:
+    """spec.forProvider.tags[Name] -> ["spec", "forProvider", "tags", "Name"]; [0] indexes lists"""
+    parts: List[Any] = []
+    for field, bracket in _PATH_PART.findall(path):
+        if field:
+            parts.append(field)
+        elif bracket.isdigit():
+            parts.append(int(bracket))
+        else:
+            parts.append(bracket.strip("'\""))
+    return parts
+
+
+def get_field(document: Any, path: str) -> Any:
+    """Value at a field path, _MISSING if there is none"""
+    value = document
+    for part in _path_parts(path):
+        if isinstance(part, int) and isinstance(value, list) and part < len(value):
+            value = value[part]
+        elif isinstance(part, str) and isinstance(value, dict) and part in value:
+            value = value[part]
+        else:
+            return _MISSING
+    return value
+
+
+def set_field(document: Dict[str, Any], path: str, value: Any) -> None:
+    """
+    Set the value at a field path, creating the objects and lists on the way
+
+    Raises:
+        ValueError: If the path runs through a value of another type
+    """
+    parts = _path_parts(path)
+    if not parts:
+        raise ValueError("Empty field path")
+    node: Any = document
+    for part, following in zip(parts, parts[1:]):
+        child = node[part] if _has(node, part) else None
+        if not isinstance(child, list if isinstance(following, int) else dict):
+            child = [] if isinstance(following, int) else {}
+            _put(node, part, child, path)
+        node = child
+    _put(node, parts[-1], value, path)
+
+
+def _has(node: Any, part: Any) -> bool:
+    if isinstance(part, int):
+        return isinstance(node, list) and part < len(node)
+    return isinstance(node, dict) and part in node
+
+
+def _put(node: Any, part: Any, value: Any, path: str) -> None:
+    if isinstance(part, int):
+        if not isinstance(node, list):
+            raise ValueError(f"Field path {path} indexes a value that is not a list")
+        node.extend([None] * (part + 1 - len(node)))
+    elif not isinstance(node, dict):
+        raise ValueError(f"Field path {path} names a field of a value that is not an object")
+    node[part] = value
+
+
+def _format(fmt: str, values: List[Any]) -> str:
+    """Go fmt.Sprintf of the verbs Crossplane string formats use"""
+    remaining = list(values)
+
+    def verb(match: "re.Match[str]") -> str:
+        if match.group(1) == "%":
+            return "%"
+        value = remaining.pop(0) if remaining else None
+        if match.group(1) == "d" and isinstance(value, (int, float)):
+            return str(int(value))
+        if match.group(1) == "q":
+            return f'"{value}"'
+        if isinstance(value, bool):
+            return "true" if value else "false"
+        return str(value)
+
+    return _FORMAT_VERB.sub(verb, fmt)
+
+
+def _convert(value: Any, to_type: str) -> Any:
+    if to_type == "string":
+        return "true" if value is True else "false" if value is False else str(value)
+    if to_type in ("int", "int64"):
+        return int(float(value))
+    if to_type == "float64":
+        return float(value)
+    if to_type == "bool":
+        return value if isinstance(value, bool) else str(value).lower() == "true"
+    raise ValueError(f"convert transform to {to_type} is not supported")
+
+
+def _match(transform: Dict[str, Any], value: Any) -> Any:
+    for pattern in transform.get("patterns") or []:
+        if pattern.get("type", "literal") == "literal" and pattern.get("literal") == value:
+            return pattern.get("result")
+        if pattern.get("type") == "regexp" and re.search(pattern.get("regexp", ""), str(value)):
+            return pattern.get("result")
+    if "fallbackValue" in transform:
+        return transform["fallbackValue"]
+    if transform.get("fallbackTo") == "Input":
+        return value
+    raise ValueError(f"match transform: no pattern matches {value}")
+
+
+def _math(transform: Dict[str, Any], value: Any) -> Any:
+    if not isinstance(value, (int, float)) or isinstance(value, bool):
+        raise ValueError(f"math transform of a value that is not a number: {value}")
+    kind = transform.get("type") or ("Multiply" if "multiply" in transform else None)
+    if kind == "Multiply":
+        return value * transform["multiply"]
+    if kind == "ClampMin":
+        return max(value, transform["clampMin"])
+    if kind == "ClampMax":
+        return min(value, transform["clampMax"])
+    raise ValueError(f"math transform {kind} is not supported")
+
+
+def _string(transform: Dict[str, Any], value: Any) -> Any:
+    kind = transform.get("type") or ("Format" if "fmt" in transform else None)
+    if kind == "Format":
+        return _format(transform["fmt"], [value])
+    if kind == "Convert":
+        conversion = transform.get("convert")
+        if conversion == "ToUpper":
+            return str(value).upper()
+        if conversion == "ToLower":
+            return str(value).lower()
+        raise ValueError(f"string transform Convert {conversion} is not supported")
+    if kind == "TrimPrefix":
+        text, prefix = str(value), transform.get("trim", "")
+        return text[len(prefix):] if prefix and text.startswith(prefix) else text
+    if kind == "TrimSuffix":
+        text, suffix = str(value), transform.get("trim", "")
+        return text[:-len(suffix)] if suffix and text.endswith(suffix) else text
+    raise ValueError(f"string transform {kind} is not supported")
+
+
+def apply_transforms(transforms: List[Dict[str, Any]], value: Any) -> Any:
+    """
+    A patched value after its transforms
+
+    Raises:
+        ValueError: If a transform is not supported or does not apply to the value
+    """
+    for transform in transforms or []:
+        kind = transform.get("type")
+        if kind == "map":
+            mapping = transform.get("map") or {}
+            if str(value) not in mapping:
+                raise ValueError(f"map transform: no value for {value}")
+            value = mapping[str(value)]
+        elif kind == "match":
+            value = _match(transform.get("match") or {}, value)
+        elif kind == "math":
+            value = _math(transform.get("math") or {}, value)
+        elif kind == "string":
+            value = _string(transform.get("string") or {}, value)
+        elif kind == "convert":
+            value = _convert(value, (transform.get("convert") or {}).get("toType", "string"))
+        else:
+            raise ValueError(f"{kind} transform is not supported")
+    return value
+
+
+def _expand_patch_sets(patches: List[Dict[str, Any]], patch_sets: Dict[str, List[Dict[str, Any]]]) -> List[Any]:
+    expanded = []
+    for patch in patches or []:
+        if patch.get("type") == "PatchSet":
+            name = patch.get("patchSetName")
+            if name not in patch_sets:
+                raise ValueError(f"PatchSet {name} is not declared")
+            expanded.extend(_expand_patch_sets(patch_sets[name], patch_sets))
+        else:
+            expanded.append(patch)
+    return expanded
+
+
+def apply_patches(
+    composite: Dict[str, Any],
+    resource: Dict[str, Any],
+    patches: List[Dict[str, Any]],
+    patch_sets: Optional[Dict[str, List[Dict[str, Any]]]] = None,
+) -> None:
+    """
+    Patch a composed resource from its composite's fields
+
+    Optional patches whose source field is not set are skipped, as
+    Crossplane skips them.
+
+    Raises:
+        ValueError: If a required source field is not set or a transform fails
+    """
+    for patch in _expand_patch_sets(patches, patch_sets or {}):
+        kind = patch.get("type", "FromCompositeFieldPath")
+        required = (patch.get("policy") or {}).get("fromFieldPath") == "Required"
+        if kind == "FromCompositeFieldPath":
+            value = get_field(composite, patch.get("fromFieldPath", ""))
+            if value is _MISSING:
+                if required:
+                    raise ValueError(f"Required field {patch.get('fromFieldPath')} is not set")
+                continue
+        elif kind == "CombineFromComposite":
+            combine = patch.get("combine") or {}
+            values = [get_field(composite, v.get("fromFieldPath", "")) for v in combine.get("variables") or []]
+            if _MISSING in values:
+                if required:
+                    raise ValueError("A required field of a CombineFromComposite patch is not set")
+                continue
+            if combine.get("strategy") != "string":
+                raise ValueError(f"Combine strategy {combine.get('strategy')} is not supported")
+            value = _format((combine.get("string") or {}).get("fmt", ""), values)
+        else:
+            # ToCompositeFieldPath and other patches write to the composite, not to composed resources
+            continue
+
+        value = apply_transforms(patch.get("transforms") or [], copy.deepcopy(value))
+        set_field(resource, patch.get("toFieldPath") or patch.get("fromFieldPath", ""), value)
+
+
+class ComposedTemplate(NamedTuple):
+    """A resource a composition composes"""
+
+    name: str
+    base: Dict[str, Any]
+    patches: List[Dict[str, Any]]
+
+
+class Composition:
+    """A composition and the resources it composes"""
+
+    def __init__(self, manifest: Dict[str, Any]):
+        """
+        Initialize composition from its manifest
+
+        Raises:
+            ValueError: If the manifest has no compositeTypeRef
+        """
+        self.manifest = manifest
+        metadata = manifest.get("metadata") or {}
+        spec = manifest.get("spec") or {}
+        self.name = str(metadata.get("name", ""))
+        self.labels: Dict[str, str] = metadata.get("labels") or {}
+
+        type_ref = spec.get("compositeTypeRef") or {}
+        if not type_ref.get("kind"):
+            raise ValueError(f"Composition {self.name} has no compositeTypeRef")
+        self.composite_api_version = str(type_ref.get("apiVersion", ""))
+        self.composite_kind = str(type_ref["kind"])
+
+        self.patch_sets: Dict[str, List[Dict[str, Any]]] = {}
+        resources = []
+        if spec.get("mode") == "Pipeline":
+            for step in spec.get("pipeline") or []:
+                step_input = step.get("input") or {}
+                if step_input.get("kind") == PATCH_AND_TRANSFORM_INPUT:
+                    resources.extend(step_input.get("resources") or [])
+                    self.patch_sets.update(_patch_sets(step_input.get("patchSets")))
+        else:
+            resources = spec.get("resources") or []
+            self.patch_sets = _patch_sets(spec.get("patchSets"))
+
+        self.templates = [
+            ComposedTemplate(str(r.get("name") or f"resource-{i}"), r.get("base") or {}, r.get("patches") or [])
+            for i, r in enumerate(resources) if isinstance(r, dict)
+        ]
+
+    def composes(self, api_version: str, kind: str) -> bool:
+        """Whether the composition is for composites of an API version and kind"""
+        return self.composite_kind == kind and api_group(self.composite_api_version) == api_group(api_version)
+
+    def render(self, composite: Dict[str, Any]) -> List[Tuple[str, Dict[str, Any]]]:
+        """
+        (name, manifest) of the resources composed for a composite
+
+        Raises:
+            ValueError: If a resource cannot be patched
+        """
+        rendered = []
+        for template in self.templates:
+            resource = copy.deepcopy(template.base)
+            try:
+                apply_patches(composite, resource, template.patches, self.patch_sets)
+            except ValueError as e:
+                raise ValueError(f"Composition {self.name}, resource {template.name}: {e}") from None
+            rendered.append((template.name, resource))
+        return rendered
+
+
+def _patch_sets(items: Any) -> Dict[str, List[Dict[str, Any]]]:
+    return {
+        item["name"]: item.get("patches") or []
+        for item in items or [] if isinstance(item, dict) and "name" in item
+    }
+
+
+class CompositeDefinition(NamedTuple):
+    """The composite and claim kinds of a CompositeResourceDefinition"""
+
+    group: str
+    kind: str
+    claim_kind: Optional[str]
+    default_composition: Optional[str]
+
+
+def composite_definition(manifest: Dict[str, Any]) -> CompositeDefinition:
+    """Kinds a CompositeResourceDefinition defines"""
+    spec = manifest.get("spec") or {}
+    return CompositeDefinition(
+        group=str(spec.get("group", "")),
+        kind=str((spec.get("names") or {}).get("kind", "")),
+        claim_kind=(spec.get("claimNames") or {}).get("kind"),
+        default_composition=(spec.get("defaultCompositionRef") or {}).get("name"),
+    )
+
+
+def is_crossplane_kind(manifest: Dict[str, Any], kind: str) -> bool:
+    return manifest.get("kind") == kind and api_group(str(manifest.get("apiVersion", ""))) == CROSSPLANE_GROUP
+
+
+class CompositionCatalog:
+    """Compositions and composite definitions composites are rendered with"""
+
+    def __init__(self):
+        self.compositions: Dict[str, Composition] = {}
+        self.definitions: List[CompositeDefinition] = []
+
+    def add(self, manifest: Dict[str, Any]) -> bool:
+        """
+        Add a Composition or CompositeResourceDefinition manifest, replacing one of the same name
+
+        Returns:
+            Whether the manifest is one of them
+
+        Raises:
+            ValueError: If a composition is invalid
+        """
+        if is_crossplane_kind(manifest, COMPOSITION_KIND):
+            composition = Composition(manifest)
+            self.compositions[composition.name] = composition
+            return True
+        if is_crossplane_kind(manifest, XRD_KIND):
+            definition = composite_definition(manifest)
+            self.definitions = [d for d in self.definitions if (d.group, d.kind) != (definition.group, definition.kind)]
+            self.definitions.append(definition)
+            return True
+        return False
+
+    def merged(self, other: "CompositionCatalog") -> "CompositionCatalog":
+        """Catalog of these and another catalog's compositions, the other's replacing these of the same name"""
+        catalog = CompositionCatalog()
+        catalog.compositions = {**self.compositions, **other.compositions}
+        keys = {(d.group, d.kind) for d in other.definitions}
+        catalog.definitions = [d for d in self.definitions if (d.group, d.kind) not in keys] + other.definitions
+        return catalog
+
+    def claimed_kind(self, manifest: Dict[str, Any]) -> Optional[str]:
+        """
+        Composite kind a manifest claims, None if it is not a claim
+
+        Without a definition, a claim of kind Foo is taken to claim the
+        composite XFoo of its group, following Crossplane's naming.
+        """
+        api_version, kind = str(manifest.get("apiVersion", "")), str(manifest.get("kind", ""))
+        group = api_group(api_version)
+        for definition in self.definitions:
+            if definition.group == group and definition.claim_kind == kind:
+                return definition.kind
+        if any(d.group == group and d.kind == kind for d in self.definitions):
+            return None
+        if not any(c.composes(api_version, kind) for c in self.compositions.values()) and \
+                any(c.composes(api_version, f"X{kind}") for c in self.compositions.values()):
+            return f"X{kind}"
+        return None
+
+    def is_composite(self, manifest: Dict[str, Any]) -> bool:
+        """Whether a manifest is a composite resource of a known composition or definition"""
+        api_version, kind = str(manifest.get("apiVersion", "")), str(manifest.get("kind", ""))
+        return any(c.composes(api_version, kind) for c in self.compositions.values()) or \
+            any(d.group == api_group(api_version) and d.kind == kind for d in self.definitions)
+
+    def select(self, api_version: str, kind: str, spec: Dict[str, Any]) -> Composition:
+        """
+        Composition a composite of a kind is rendered with
+
+        Raises:
+            LookupError: If no composition, or more than one, can be selected
+        """
+        reference = (spec.get("compositionRef") or {}).get("name")
+        if reference:
+            if reference not in self.compositions:
+                raise LookupError(f"Composition {reference} not found")
+            return self.compositions[reference]
+
+        candidates = sorted(
+            (c for c in self.compositions.values() if c.composes(api_version, kind)), key=lambda c: c.name
+        )
+        selector = (spec.get("compositionSelector") or {}).get("matchLabels")
+        if isinstance(selector, dict):
+            candidates = [c for c in candidates if all(c.labels.get(k) == v for k, v in selector.items())]
+            if candidates:
+                return candidates[0]
+            raise LookupError(f"No composition of {kind} matches the labels {selector}")
+
+        for definition in self.definitions:
+            if (definition.group, definition.kind) == (api_group(api_version), kind) and \
+                    definition.default_composition in self.compositions:
+                return self.compositions[definition.default_composition]
+        if len(candidates) == 1:
+            return candidates[0]
+        if not candidates:
+            raise LookupError(f"No composition for {kind}")
+        raise LookupError(
+            f"{len(candidates)} compositions for {kind}, set compositionRef or compositionSelector"
+        )
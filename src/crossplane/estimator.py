@@ -0,0 +1,222 @@
+"""
+Crossplane cost estimator
+
+Renders the claims and composite resources of the manifests with their
+compositions, and prices every managed resource, submitted or composed,
+with the Terraform estimator as the Terraform resource it is generated
+from. Compositions come from the manifests and from the catalog the
+service is configured with (CROSSPLANE_COMPOSITIONS_PATH), those of the
+manifests replacing configured ones of the same name.
+"""
+
+import copy
+import logging
+import os
+from typing import Any, Dict, Iterable, List, Optional, Tuple, Union
+
+import yaml
+
+from ..terraform import ResourceChange, TerraformEstimateResult, TerraformEstimator, UnpricedResource
+from ..terraform.models import ACTION_CREATE
+from .composition import CompositionCatalog
+from .models import CrossplaneEstimateRequest
+from .resources import api_group, creates_resource, is_managed_resource, managed_values, terraform_type
+
+logger = logging.getLogger(__name__)
+
+# Composites composing composites deeper than this are taken to compose themselves
+MAX_COMPOSITION_DEPTH = 8
+
+# Labels Crossplane sets on the composite of a claim
+CLAIM_NAME_LABEL = "crossplane.io/claim-name"
+CLAIM_NAMESPACE_LABEL = "crossplane.io/claim-namespace"
+COMPOSITE_LABEL = "crossplane.io/composite"
+
+
+def load_documents(manifests: Union[str, Iterable[str]]) -> List[Dict[str, Any]]:
+    """
+    Objects of one or more YAML manifest texts, List items included
+
+    Raises:
+        ValueError: If a text is not valid YAML
+    """
+    if isinstance(manifests, str):
+        manifests = [manifests]
+    documents: List[Dict[str, Any]] = []
+    for text in manifests:
+        try:
+            loaded = list(yaml.safe_load_all(text))
+        except yaml.YAMLError as e:
+            raise ValueError(f"Invalid YAML manifest: {e}") from None
+        for document in loaded:
+            if isinstance(document, dict) and str(document.get("kind", "")).endswith("List"):
+                documents.extend(item for item in document.get("items") or [] if isinstance(item, dict))
+            elif isinstance(document, dict):
+                documents.append(document)
+    return documents
+
+
+def load_compositions(path: str) -> CompositionCatalog:
+    """
+    Compositions and definitions of a YAML file, or of the .yaml and .yml files of a directory
+
+    Raises:
+        ValueError: If a file cannot be read or holds an invalid composition
+    """
+    paths = [path]
+    if os.path.isdir(path):
+        paths = sorted(
+            os.path.join(path, name) for name in os.listdir(path) if name.endswith((".yaml", ".yml"))
+        )
+    catalog = CompositionCatalog()
+    for file_path in paths:
+        try:
+            with open(file_path, encoding="utf-8") as f:
+                text = f.read()
+        except OSError as e:
+            raise ValueError(f"Cannot read compositions {file_path}: {e}") from None
+        for document in load_documents(text):
+            catalog.add(document)
+    logger.info(f"Loaded {len(catalog.compositions)} Crossplane compositions from {path}")
+    return catalog
+
+
+def _address(manifest: Dict[str, Any]) -> str:
+    metadata = manifest.get("metadata") or {}
+    parts = [str(manifest.get("kind", "")), metadata.get("namespace"), metadata.get("name")]
+    return "/".join(str(part) for part in parts if part)
+
+
+def _claimed_composite(claim: Dict[str, Any], kind: str) -> Dict[str, Any]:
+    """The composite a claim is bound to, with the claim's spec and labels"""
+    metadata = claim.get("metadata") or {}
+    name = str(metadata.get("name", ""))
+    labels = {
+        **(metadata.get("labels") or {}),
+        CLAIM_NAME_LABEL: name,
+        CLAIM_NAMESPACE_LABEL: str(metadata.get("namespace", "default")),
+        COMPOSITE_LABEL: name,
+    }
+    return {
+        "apiVersion": claim.get("apiVersion"),
+        "kind": kind,
+        "metadata": {"name": name, "labels": labels},
+        "spec": copy.deepcopy(claim.get("spec") or {}),
+    }
+
+
+class CrossplaneEstimator:
+    """Estimates the monthly cost of the cloud resources Crossplane manifests create"""
+
+    def __init__(self, terraform: TerraformEstimator, compositions: Optional[CompositionCatalog] = None):
+        """
+        Initialize Crossplane estimator
+
+        Args:
+            terraform: Estimator pricing managed resources by their Terraform resource type
+            compositions: Configured compositions claims and composites are rendered with
+        """
+        self.terraform = terraform
+        self.compositions = compositions or CompositionCatalog()
+
+    def resource_changes(
+        self, request: CrossplaneEstimateRequest
+    ) -> Tuple[List[ResourceChange], List[UnpricedResource]]:
+        """
+        Creation of each managed resource of the manifests, and the composites that cannot be rendered
+
+        Managed resources are addressed by kind, namespace and name, composed
+        ones under the claim or composite composing them. Kubernetes objects
+        that are none of these are left out.
+
+        Raises:
+            ValueError: If the manifests are not valid YAML or hold an invalid composition
+        """
+        documents = load_documents(request.manifests)
+        submitted = CompositionCatalog()
+        resources = [document for document in documents if not submitted.add(document)]
+        catalog = self.compositions.merged(submitted)
+
+        changes: List[ResourceChange] = []
+        unrendered: List[UnpricedResource] = []
+        for manifest in resources:
+            claimed = catalog.claimed_kind(manifest)
+            if claimed is not None:
+                self._compose(catalog, _claimed_composite(manifest, claimed), _address(manifest), 0,
+                              changes, unrendered)
+            elif catalog.is_composite(manifest):
+                self._compose(catalog, manifest, _address(manifest), 0, changes, unrendered)
+            elif is_managed_resource(manifest):
+                self._add_resource(manifest, _address(manifest), changes)
+        return changes, unrendered
+
+    def _compose(
+        self,
+        catalog: CompositionCatalog,
+        composite: Dict[str, Any],
+        address: str,
+        depth: int,
+        changes: List[ResourceChange],
+        unrendered: List[UnpricedResource],
+    ) -> None:
+        api_version, kind = str(composite.get("apiVersion", "")), str(composite.get("kind", ""))
+        try:
+            if depth >= MAX_COMPOSITION_DEPTH:
+                raise ValueError(f"Compositions nest deeper than {MAX_COMPOSITION_DEPTH} levels")
+            composition = catalog.select(api_version, kind, composite.get("spec") or {})
+            rendered = composition.render(composite)
+        except (LookupError, ValueError) as e:
+            unrendered.append(UnpricedResource(address=address, type=kind, action=ACTION_CREATE, reason=str(e)))
+            return
+
+        for name, resource in rendered:
+            resource_address = f"{address}/{name}"
+            if catalog.is_composite(resource):
+                self._compose(catalog, resource, resource_address, depth + 1, changes, unrendered)
+            else:
+                self._add_resource(resource, resource_address, changes)
+
+    @staticmethod
+    def _add_resource(manifest: Dict[str, Any], address: str, changes: List[ResourceChange]) -> None:
+        """
+        Add the creation of a managed resource
+
+        Resources that are not managed resources of a known provider, such
+        as provider-kubernetes Objects, and managed resources without a
+        mapper keep their kind and group as type, and are reported unpriced.
+        """
+        if not creates_resource(manifest):
+            return
+        api_version, kind = str(manifest.get("apiVersion", "")), str(manifest.get("kind", ""))
+        resource_type = terraform_type(api_version, kind)
+        if resource_type is None:
+            changes.append(ResourceChange(
+                address=address, type=f"{kind}.{api_group(api_version)}", action=ACTION_CREATE,
+                provider="crossplane", after={},
+            ))
+            return
+        values, region = managed_values(manifest)
+        changes.append(ResourceChange(
+            address=address, type=resource_type, action=ACTION_CREATE, provider=resource_type.split("_", 1)[0],
+            region=region, after=values,
+        ))
+
+    def estimate(self, request: CrossplaneEstimateRequest) -> TerraformEstimateResult:
+        """
+        Estimate the monthly cost of the request's manifests
+
+        Every priced resource is an addition, so the monthly delta is the
+        cost of the resources the manifests create.
+
+        Raises:
+            ValueError: If the manifests are not valid YAML or hold an invalid composition
+        """
+        changes, unrendered = self.resource_changes(request)
+        result = self.terraform.estimate_changes(changes, request.region, request.hours)
+        result.unpriced.extend(unrendered)
+
+        logger.info(
+            f"Crossplane estimate: {len(result.added)} resources ({len(result.unpriced)} unpriced), "
+            f"${result.monthly_delta:.2f}/month"
+        )
+        return result
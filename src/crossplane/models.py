@@ -0,0 +1,21 @@
+"""
+Data models for Crossplane cost estimation
+"""
+
+from typing import Dict, List, Optional, Union
+
+from pydantic import BaseModel, Field
+
+from ..units import hours_per_month
+
+
+class CrossplaneEstimateRequest(BaseModel):
+    """Request model for the Crossplane estimate"""
+
+    manifests: Union[str, List[str]] = Field(
+        ..., description="YAML of claims, composite and managed resources, and optionally Compositions and XRDs"
+    )
+    region: Optional[str] = Field(None, description="Region of managed resources whose forProvider sets none")
+    hours: float = Field(default_factory=hours_per_month, gt=0, le=744, description="Running hours per month")
+    project: Optional[str] = Field(None, description="Project the estimate is recorded under")
+    labels: Dict[str, str] = Field(default_factory=dict, description="Metadata kept with the estimate, e.g. CI run URL")
@@ -0,0 +1,104 @@
+"""
+Crossplane managed resources
+
+The Upbound providers (provider-aws, provider-gcp, provider-azure) are
+generated from the Terraform providers, so each managed resource is the
+Terraform resource its kind names and spec.forProvider holds its
+arguments in camelCase. Managed resources are typed as that Terraform
+resource and priced by the Terraform resource mappers.
+"""
+
+import re
+from typing import Any, Dict, Optional, Tuple
+
+from ..pulumi import terraform_values
+from ..terraform import get_mapper
+
+# API group suffix of each provider family -> Terraform provider
+PROVIDER_GROUPS = {
+    "aws.upbound.io": "aws",
+    "gcp.upbound.io": "google",
+    "azure.upbound.io": "azurerm",
+}
+
+# (API group, kind) whose Terraform name does not follow from the group and kind
+CROSSPLANE_RESOURCE_TYPES = {
+    ("rds.aws.upbound.io", "Instance"): "aws_db_instance",
+    ("dbforpostgresql.azure.upbound.io", "FlexibleServer"): "azurerm_postgresql_flexible_server",
+    ("dbformysql.azure.upbound.io", "FlexibleServer"): "azurerm_mysql_flexible_server",
+}
+
+# Management policies under which Crossplane creates the external resource
+_CREATING_POLICIES = ("*", "Create")
+
+_WORD = re.compile(r"(?<=[a-z0-9])(?=[A-Z])|(?<=[A-Z])(?=[A-Z][a-z])")
+
+
+def api_group(api_version: str) -> str:
+    """ec2.aws.upbound.io/v1beta1 -> ec2.aws.upbound.io"""
+    return api_version.rsplit("/", 1)[0] if "/" in api_version else ""
+
+
+def _provider(group: str) -> Optional[Tuple[str, str]]:
+    """(Terraform provider, service) of a managed resource group, None if not a known provider"""
+    for suffix, provider in PROVIDER_GROUPS.items():
+        # Namespaced managed resources (Crossplane v2) are served under <service>.<provider>.m.upbound.io
+        for family in (suffix, suffix.replace(".upbound.io", ".m.upbound.io")):
+            if group.endswith(f".{family}"):
+                return provider, group[:-len(family) - 1]
+    return None
+
+
+def _snake(name: str) -> str:
+    """EBSVolume -> ebs_volume, NodeGroup -> node_group"""
+    return _WORD.sub("_", name).lower()
+
+
+def is_managed_resource(manifest: Dict[str, Any]) -> bool:
+    """Whether a manifest is a managed resource of a known provider"""
+    return _provider(api_group(str(manifest.get("apiVersion", "")))) is not None
+
+
+def terraform_type(api_version: str, kind: str) -> Optional[str]:
+    """
+    Terraform resource type of a managed resource, None if it has no mapper
+
+    Kinds not listed in CROSSPLANE_RESOURCE_TYPES are named as Upjet names
+    them, e.g. compute.gcp.upbound.io Instance -> google_compute_instance
+    or ec2.aws.upbound.io EBSVolume -> aws_ebs_volume.
+    """
+    group = api_group(api_version)
+    known = CROSSPLANE_RESOURCE_TYPES.get((group.replace(".m.upbound.io", ".upbound.io"), kind))
+    if known is not None:
+        return known
+    provider = _provider(group)
+    if provider is None:
+        return None
+    prefix, service = provider
+    name = _snake(kind)
+    for candidate in (f"{prefix}_{service}_{name}", f"{prefix}_{name}"):
+        if get_mapper(candidate) is not None:
+            return candidate
+    return None
+
+
+def creates_resource(manifest: Dict[str, Any]) -> bool:
+    """Whether Crossplane creates the external resource, rather than only observing an existing one"""
+    policies = (manifest.get("spec") or {}).get("managementPolicies")
+    if not isinstance(policies, list):
+        return True
+    return any(policy in _CREATING_POLICIES for policy in policies)
+
+
+def managed_values(manifest: Dict[str, Any]) -> Tuple[Dict[str, Any], Optional[str]]:
+    """
+    (Terraform attribute values, region) of a managed resource's spec.forProvider
+
+    The region is forProvider.region (AWS, GCP regional resources); GCP
+    zones and Azure locations are attributes the mappers read themselves.
+    """
+    for_provider = (manifest.get("spec") or {}).get("forProvider") or {}
+    if not isinstance(for_provider, dict):
+        raise ValueError("spec.forProvider is not an object")
+    region = for_provider.get("region")
+    return terraform_values(for_provider), region if isinstance(region, str) else None
@@ -0,0 +1,41 @@
+"""
+Currency Module
+
+Converts USD estimates to other currencies using exchange rates from the
+ECB reference feed or a static rate table.
+"""
+
+from .models import RateTable, BASE_CURRENCY
+from .sources import (
+    RateSource,
+    StaticRateSource,
+    ECBRateSource,
+    DEFAULT_ECB_RATES_URL,
+    parse_static_rates,
+    parse_ecb_rates,
+)
+from .converter import (
+    CurrencyConverter,
+    UnsupportedCurrencyError,
+    convert_amounts,
+    MONEY_FIELDS,
+    PRICE_FIELDS,
+    build_converter,
+)
+
+__all__ = [
+    "RateTable",
+    "BASE_CURRENCY",
+    "RateSource",
+    "StaticRateSource",
+    "ECBRateSource",
+    "DEFAULT_ECB_RATES_URL",
+    "parse_static_rates",
+    "parse_ecb_rates",
+    "CurrencyConverter",
+    "UnsupportedCurrencyError",
+    "convert_amounts",
+    "MONEY_FIELDS",
+    "PRICE_FIELDS",
+    "build_converter",
+]
@@ -0,0 +1,194 @@
+"""
+Currency conversion of estimate results
+
+Estimates are computed in USD. Conversion walks a result and converts
+every monetary field by name, so each estimate type converts the same way.
+Costs and amounts are rounded to the currency's minor unit (whole won and
+yen, cents of EUR); unit prices keep the decimal places of estimates.
+"""
+
+import logging
+import threading
+import time
+from datetime import datetime, timezone
+from typing import Any, Dict, Optional, Tuple
+
+from ..money import multiply_money, round_currency, to_decimal
+from .models import RateTable, BASE_CURRENCY
+from .sources import RateSource, StaticRateSource, ECBRateSource, parse_static_rates
+
+logger = logging.getLogger(__name__)
+
+# Unit prices besides the fields ending in _price
+PRICE_FIELDS = frozenset({
+    "unit_price",
+    "unit_price_hourly",
+    "cpu_hourly",
+    "memory_hourly",
+    "price",
+})
+
+# Monetary fields besides those ending in _cost or _price
+MONEY_FIELDS = PRICE_FIELDS | {
+    "monthly_amount",
+    "monthly_delta",
+    "savings",
+    "upfront",
+}
+
+_MONEY_SUFFIXES = ("_cost", "_price")
+
+
+class UnsupportedCurrencyError(ValueError):
+    """Raised when no exchange rate is available for a currency"""
+
+
+def _is_money_field(name: str) -> bool:
+    return name in MONEY_FIELDS or name.endswith(_MONEY_SUFFIXES)
+
+
+def _is_price_field(name: str) -> bool:
+    return name in PRICE_FIELDS or name.endswith("_price")
+
+
+def convert_amounts(value: Any, rate: float, currency: str) -> Any:
+    """
+    Convert every monetary field of a result structure
+
+    Dicts and lists are walked recursively; numeric values under monetary
+    field names are multiplied by rate in decimal and "currency" fields are set.
+    Amounts are rounded to the currency's minor unit, unit prices to MONEY_PLACES.
+    """
+    if isinstance(value, list):
+        return [convert_amounts(item, rate, currency) for item in value]
+    if not isinstance(value, dict):
+        return value
+
+    converted = {}
+    for key, item in value.items():
+        if key == "currency":
+            converted[key] = currency
+        elif _is_money_field(key) and isinstance(item, (int, float)) and not isinstance(item, bool):
+            if _is_price_field(key):
+                converted[key] = multiply_money(item, rate)
+            else:
+                converted[key] = float(round_currency(to_decimal(item) * to_decimal(rate), currency))
+        else:
+            converted[key] = convert_amounts(item, rate, currency)
+    return converted
+
+
+class CurrencyConverter:
+    """Converts USD results with rates cached from a rate source"""
+
+    def __init__(self, source: RateSource, ttl: int = 3600, fallback: Optional[RateSource] = None):
+        """
+        Initialize converter
+
+        Args:
+            source: Primary rate source
+            ttl: Seconds fetched rates are reused before fetching again
+            fallback: Source used if the primary has never loaded
+        """
+        self.source = source
+        self.ttl = ttl
+        self.fallback = fallback
+
+        self._table: Optional[RateTable] = None
+        self._fetched_at = 0.0
+        self._lock = threading.Lock()
+
+    def rates(self) -> RateTable:
+        """
+        Current rate table
+
+        Keeps serving the last fetched rates if the source fails.
+
+        Raises:
+            UnsupportedCurrencyError: If no rates could be loaded at all
+        """
+        with self._lock:
+            if self._table is not None and time.time() - self._fetched_at < self.ttl:
+                return self._table
+
+            try:
+                self._table = self.source.fetch()
+                self._fetched_at = time.time()
+                return self._table
+            except Exception as e:
+                logger.error(f"Exchange rate fetch from {self.source.name} failed: {e}")
+
+            if self._table is not None:
+                return self._table
+            if self.fallback is not None:
+                return self.fallback.fetch()
+            raise UnsupportedCurrencyError("Exchange rates are not available")
+
+    def rate(self, currency: str) -> Tuple[float, RateTable]:
+        """
+        Rate from USD to a currency with the table it came from
+
+        Raises:
+            UnsupportedCurrencyError: If the currency has no rate
+        """
+        currency = currency.upper()
+        if currency == BASE_CURRENCY:
+            return 1.0, RateTable(rates={}, source="identity", as_of=datetime.now(timezone.utc))
+
+        table = self.rates()
+        try:
+            return table.rate(currency), table
+        except KeyError:
+            raise UnsupportedCurrencyError(
+                f"Unsupported currency '{currency}', available: {', '.join(table.currencies)}"
+            ) from None
+
+    def convert(self, result: Dict[str, Any], currency: str) -> Tuple[Dict[str, Any], Dict[str, Any]]:
+        """
+        Convert a USD result
+
+        Returns:
+            (converted result, exchange rate details for the response)
+
+        Raises:
+            UnsupportedCurrencyError: If the currency has no rate
+        """
+        currency = currency.upper()
+        exchange_rate = self.exchange_rate(currency)
+        return convert_amounts(result, exchange_rate["rate"], currency), exchange_rate
+
+    def exchange_rate(self, currency: str) -> Dict[str, Any]:
+        """
+        Exchange rate details of a currency, as returned with converted results
+
+        Raises:
+            UnsupportedCurrencyError: If the currency has no rate
+        """
+        currency = currency.upper()
+        rate, table = self.rate(currency)
+        return {
+            "base": BASE_CURRENCY,
+            "currency": currency,
+            "rate": rate,
+            "source": table.source,
+            "as_of": table.as_of.isoformat(),
+        }
+
+
+def build_converter(settings) -> CurrencyConverter:
+    """
+    Create the converter from application settings
+
+    The ECB feed falls back to the static rates when it cannot be reached;
+    offline mode uses the static rates only.
+    """
+    static = StaticRateSource(parse_static_rates(settings.currency_static_rates))
+    if settings.currency_rate_source == "static" or settings.offline:
+        return CurrencyConverter(static, ttl=settings.currency_rate_ttl)
+    if settings.currency_rate_source == "ecb":
+        return CurrencyConverter(
+            ECBRateSource(url=settings.ecb_rates_url),
+            ttl=settings.currency_rate_ttl,
+            fallback=static,
+        )
+    raise ValueError(f"Unknown exchange rate source: {settings.currency_rate_source}")
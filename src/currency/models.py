@@ -0,0 +1,46 @@
+"""
+Currency data models
+"""
+
+from datetime import datetime
+from typing import Dict
+
+from pydantic import BaseModel, Field, validator
+
+# Currency every price and estimate is computed in
+BASE_CURRENCY = "USD"
+
+
+class RateTable(BaseModel):
+    """Exchange rates from the base currency"""
+
+    base: str = BASE_CURRENCY
+    rates: Dict[str, float] = Field(..., description="Units of each currency per one base unit")
+    source: str
+    as_of: datetime = Field(..., description="When the rates were published (or configured)")
+
+    @validator("rates")
+    def validate_rates(cls, v):
+        normalized = {}
+        for code, rate in v.items():
+            if rate <= 0:
+                raise ValueError(f"Exchange rate for {code} must be positive")
+            normalized[code.upper()] = float(rate)
+        return normalized
+
+    def rate(self, currency: str) -> float:
+        """
+        Rate from the base currency
+
+        Raises:
+            KeyError: If the table has no rate for the currency
+        """
+        currency = currency.upper()
+        if currency == self.base:
+            return 1.0
+        return self.rates[currency]
+
+    @property
+    def currencies(self):
+        """Currencies the table converts to, base included"""
+        return sorted(set(self.rates) | {self.base})
@@ -0,0 +1,132 @@
+"""
+Exchange rate sources
+"""
+
+import logging
+import xml.etree.ElementTree as ET
+from abc import ABC, abstractmethod
+from datetime import datetime, timezone
+from typing import Dict, Optional
+
+import requests
+
+from .models import RateTable, BASE_CURRENCY
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_ECB_RATES_URL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+_ECB_NAMESPACE = "{http://www.ecb.int/vocabulary/2002-08-01/eurofxref}"
+
+
+class RateSource(ABC):
+    """Source of exchange rates from the base currency"""
+
+    name: str = "base"
+
+    @abstractmethod
+    def fetch(self) -> RateTable:
+        """
+        Load the current rate table
+
+        Raises:
+            Exception: If the rates cannot be loaded
+        """
+
+
+def parse_static_rates(value: str) -> Dict[str, float]:
+    """
+    Parse a rate list such as "EUR=0.92,KRW=1380,JPY=150"
+
+    Raises:
+        ValueError: If an entry is malformed
+    """
+    rates: Dict[str, float] = {}
+    for item in value.split(","):
+        item = item.strip()
+        if not item:
+            continue
+        code, sep, rate = item.partition("=")
+        try:
+            if not sep or not code.strip():
+                raise ValueError
+            rates[code.strip().upper()] = float(rate)
+        except ValueError:
+            raise ValueError(f"Invalid exchange rate '{item}', expected CODE=rate") from None
+    return rates
+
+
+class StaticRateSource(RateSource):
+    """Fixed rates from configuration"""
+
+    name = "static"
+
+    def __init__(self, rates: Dict[str, float], as_of: Optional[datetime] = None):
+        """
+        Initialize static source
+
+        Args:
+            rates: Units of each currency per USD
+            as_of: When the rates were set. Defaults to now.
+        """
+        self.table = RateTable(
+            rates=rates,
+            source=self.name,
+            as_of=as_of or datetime.now(timezone.utc),
+        )
+
+    def fetch(self) -> RateTable:
+        return self.table
+
+
+def parse_ecb_rates(document: str) -> RateTable:
+    """
+    Parse the ECB euro reference rates and rebase them on USD
+
+    Raises:
+        ValueError: If the document has no rates or no USD rate
+    """
+    root = ET.fromstring(document)
+    day = root.find(f".//{_ECB_NAMESPACE}Cube[@time]")
+    if day is None:
+        raise ValueError("ECB rates document has no reference day")
+
+    per_eur = {"EUR": 1.0}
+    for cube in day.findall(f"{_ECB_NAMESPACE}Cube"):
+        per_eur[cube.get("currency", "").upper()] = float(cube.get("rate"))
+
+    usd = per_eur.pop(BASE_CURRENCY, None)
+    if usd is None:
+        raise ValueError("ECB rates document has no USD rate")
+
+    as_of = datetime.strptime(day.get("time"), "%Y-%m-%d").replace(tzinfo=timezone.utc)
+    return RateTable(
+        rates={code: rate / usd for code, rate in per_eur.items()},
+        source="ecb",
+        as_of=as_of,
+    )
+
+
+class ECBRateSource(RateSource):
+    """European Central Bank daily euro reference rates"""
+
+    name = "ecb"
+
+    def __init__(self, url: str = DEFAULT_ECB_RATES_URL, timeout: int = 30):
+        """
+        Initialize ECB source
+
+        Args:
+            url: eurofxref-daily.xml location
+            timeout: Request timeout in seconds
+        """
+        self.url = url
+        self.timeout = timeout
+        self.session = requests.Session()
+
+    def fetch(self) -> RateTable:
+        response = self.session.get(self.url, timeout=self.timeout)
+        response.raise_for_status()
+        table = parse_ecb_rates(response.text)
+        logger.info(f"Fetched ECB exchange rates for {table.as_of.date()} ({len(table.rates)} currencies)")
+        return table
@@ -0,0 +1,16 @@
+"""
+Dashboard Module
+
+This module serves a minimal web dashboard bundled with the service:
+a single page showing the tenant's spend this month by project, budget
+status, recent estimates and recommendations, read from the JSON API,
+for teams without Grafana.
+"""
+
+from .page import DASHBOARD_PATH, dashboard_html, wants_dashboard
+
+__all__ = [
+    "DASHBOARD_PATH",
+    "dashboard_html",
+    "wants_dashboard",
+]
@@ -0,0 +1,28 @@
+"""
+Dashboard page
+
+The dashboard is one HTML file with its script and styles inline,
+shipped in the package so it needs no build step or asset server. The
+page calls the API from the browser with an API key kept in the
+browser's local storage, so it sees exactly what the key may read.
+"""
+
+from functools import lru_cache
+from importlib import resources
+from typing import Optional
+
+from ..formats import FORMAT_HTML, FORMAT_JSON, negotiate_format
+
+# Served regardless of the Accept header; / serves it to browsers
+DASHBOARD_PATH = "/dashboard"
+
+
+@lru_cache(maxsize=1)
+def dashboard_html() -> str:
+    """The dashboard page"""
+    return resources.files(__package__).joinpath("static", "index.html").read_text(encoding="utf-8")
+
+
+def wants_dashboard(accept: Optional[str]) -> bool:
+    """Whether a request to / prefers the dashboard to the service's JSON description, e.g. from a browser"""
+    return negotiate_format(None, accept, allowed=(FORMAT_JSON, FORMAT_HTML)) == FORMAT_HTML
@@ -0,0 +1,35 @@
+"""
+Diff Module
+
+This module compares the monthly cost of two estimates, e.g. the
+Terraform plans of the main branch and of a pull request, resource by
+resource, decides pass or fail against absolute and percentage
+thresholds and renders a markdown summary for pull request comments.
+"""
+
+from .models import (
+    DiffSide,
+    DiffThresholds,
+    DiffRequest,
+    CostChange,
+    DiffResult,
+    CHANGE_ADDED,
+    CHANGE_REMOVED,
+    CHANGE_CHANGED,
+)
+from .differ import EstimateDiffer, parse_threshold
+from .summary import render_markdown
+
+__all__ = [
+    "DiffSide",
+    "DiffThresholds",
+    "DiffRequest",
+    "CostChange",
+    "DiffResult",
+    "CHANGE_ADDED",
+    "CHANGE_REMOVED",
+    "CHANGE_CHANGED",
+    "EstimateDiffer",
+    "parse_threshold",
+    "render_markdown",
+]
@@ -0,0 +1,248 @@
+"""
+Estimate diffs
+
+Compares the monthly cost of two estimates, e.g. of the main branch and
+of a pull request, resource by resource, and checks the head side
+against absolute and percentage thresholds.
+
+Terraform plans and Pulumi previews only list the resources they change.
+A resource one plan changes and the other does not is taken at its
+current cost (its cost before the plan that changes it) on the other
+side, so both totals cover the same resources: those either plan changes.
+"""
+
+import logging
+from typing import Dict, List, Optional, Tuple
+
+from ..estimator import CostEstimator
+from ..k8s import KubernetesEstimator
+from ..money import round_money
+from ..store import (
+    DEFAULT_TENANT,
+    KIND_CLOUDFORMATION,
+    KIND_CROSSPLANE,
+    KIND_KUBERNETES,
+    KIND_PULUMI,
+    KIND_RESOURCES,
+    KIND_TERRAFORM,
+    Store,
+)
+from ..pulumi import PulumiEstimateRequest, PulumiEstimator
+from ..terraform import TerraformEstimateRequest, TerraformEstimator
+from .models import (
+    CHANGE_ADDED,
+    CHANGE_CHANGED,
+    CHANGE_REMOVED,
+    CostChange,
+    DiffRequest,
+    DiffResult,
+    DiffSide,
+    DiffThresholds,
+)
+from .summary import render_markdown
+
+logger = logging.getLogger(__name__)
+
+# Kinds whose results price resource changes before and after, keyed by address
+PLAN_KINDS = (KIND_TERRAFORM, KIND_PULUMI, KIND_CLOUDFORMATION, KIND_CROSSPLANE)
+
+
+def parse_threshold(value: str) -> Optional[float]:
+    """
+    Threshold from a setting, None when empty
+
+    Raises:
+        ValueError: If the value is not a non-negative number
+    """
+    if not value.strip():
+        return None
+    threshold = float(value)
+    if threshold < 0:
+        raise ValueError(f"Thresholds must not be negative: {value}")
+    return threshold
+
+
+class _SideCost:
+    """Costs of one side: per resource (before, after) and unpriced addresses"""
+
+    def __init__(self, kind: str, items: Dict[str, Tuple[float, float]], unpriced: List[str]):
+        self.kind = kind
+        self.items = items
+        self.unpriced = unpriced
+
+
+class EstimateDiffer:
+    """Diffs two estimates against thresholds"""
+
+    def __init__(
+        self,
+        cost_estimator: CostEstimator,
+        k8s_estimator: KubernetesEstimator,
+        terraform_estimator: TerraformEstimator,
+        store: Optional[Store] = None,
+        thresholds: Optional[DiffThresholds] = None,
+    ):
+        """
+        Initialize differ
+
+        Args:
+            cost_estimator: Estimates resource lists
+            k8s_estimator: Estimates Kubernetes manifests
+            terraform_estimator: Estimates Terraform plans
+            store: Holds recorded estimates. Sides cannot name an estimate_id if not provided.
+            thresholds: Default thresholds, used for limits a request does not set
+        """
+        self.cost_estimator = cost_estimator
+        self.k8s_estimator = k8s_estimator
+        self.terraform_estimator = terraform_estimator
+        self.store = store
+        self.thresholds = thresholds or DiffThresholds()
+
+    def diff(self, request: DiffRequest, tenant_id: str = DEFAULT_TENANT) -> DiffResult:
+        """
+        Diff the head side of a request against its base side
+
+        Raises:
+            ValueError: If a side cannot be estimated, or the sides are of different kinds
+            LookupError: If a recorded estimate does not exist
+        """
+        base = self._side_cost(request.base, request, tenant_id)
+        head = self._side_cost(request.head, request, tenant_id)
+        if base.kind != head.kind:
+            raise ValueError(f"Cannot diff a {base.kind} estimate against a {head.kind} estimate")
+
+        changes: List[CostChange] = []
+        base_total = head_total = 0.0
+        for key in list(base.items) + [k for k in head.items if k not in base.items]:
+            base_cost, head_cost = self._costs(key, base, head)
+            base_total += base_cost
+            head_total += head_cost
+            if round(head_cost - base_cost, 4) == 0:
+                continue
+            changes.append(CostChange(
+                key=key,
+                change=_change(base_cost, head_cost),
+                base_monthly_cost=round_money(base_cost),
+                head_monthly_cost=round_money(head_cost),
+                monthly_delta=round_money(head_cost - base_cost),
+            ))
+        changes.sort(key=lambda c: (-abs(c.monthly_delta), c.key))
+
+        thresholds = DiffThresholds(**{
+            name: value if value is not None else getattr(self.thresholds, name)
+            for name, value in request.thresholds.dict().items()
+        })
+        delta = head_total - base_total
+        percent = delta / base_total * 100 if base_total > 0 else None
+        failures = _failures(head_total, delta, percent, thresholds)
+
+        result = DiffResult(
+            base_label=request.base.label or "base",
+            head_label=request.head.label or "head",
+            kind=base.kind,
+            base_monthly_cost=round_money(base_total),
+            head_monthly_cost=round_money(head_total),
+            monthly_delta=round_money(delta),
+            delta_percent=None if percent is None else round(percent, 2),
+            thresholds=thresholds,
+            passed=not failures,
+            failures=failures,
+            changes=changes,
+            unpriced=sorted(set(base.unpriced) | set(head.unpriced)),
+        )
+        result.markdown = render_markdown(result)
+        logger.info(
+            f"Estimate diff {result.base_label} -> {result.head_label}: "
+            f"${result.monthly_delta:+.2f}/month, {'passed' if result.passed else 'failed'}"
+        )
+        return result
+
+    @staticmethod
+    def _costs(key: str, base: _SideCost, head: _SideCost) -> Tuple[float, float]:
+        """(base, head) monthly cost of a resource"""
+        if base.kind in PLAN_KINDS:
+            # A resource one plan leaves alone keeps its current cost on that side
+            base_cost = base.items[key][1] if key in base.items else head.items[key][0]
+            head_cost = head.items[key][1] if key in head.items else base.items[key][0]
+            return base_cost, head_cost
+        return base.items.get(key, (0.0, 0.0))[1], head.items.get(key, (0.0, 0.0))[1]
+
+    def _side_cost(self, side: DiffSide, request: DiffRequest, tenant_id: str) -> _SideCost:
+        if side.estimate_id is not None:
+            if self.store is None:
+                raise ValueError("Recorded estimates need a store (STORE_URL)")
+            record = self.store.get_estimate(side.estimate_id, tenant_id=tenant_id)
+            if record is None:
+                raise LookupError(f"Estimate {side.estimate_id} not found")
+            return _result_cost(record.kind, record.result)
+        if side.resources is not None:
+            return _result_cost(KIND_RESOURCES, self.cost_estimator.estimate(side.resources).dict())
+        if side.kubernetes is not None:
+            return _result_cost(KIND_KUBERNETES, self.k8s_estimator.estimate(side.kubernetes).dict())
+        if side.pulumi is not None:
+            result = PulumiEstimator(self.terraform_estimator).estimate(PulumiEstimateRequest(
+                preview=side.pulumi, region=request.region, hours=request.hours,
+            ))
+            return _result_cost(KIND_PULUMI, result.dict())
+        result = self.terraform_estimator.estimate(TerraformEstimateRequest(
+            plan=side.terraform, region=request.region, hours=request.hours,
+        ))
+        return _result_cost(KIND_TERRAFORM, result.dict())
+
+
+def _result_cost(kind: str, result: Dict) -> _SideCost:
+    """
+    Costs of an estimate result of a kind
+
+    Raises:
+        ValueError: If estimates of the kind cannot be diffed
+    """
+    items: Dict[str, Tuple[float, float]] = {}
+
+    def add(key: str, before: float, after: float) -> None:
+        previous = items.get(key, (0.0, 0.0))
+        items[key] = (previous[0] + before, previous[1] + after)
+
+    if kind in PLAN_KINDS:
+        for group in ("added", "changed", "destroyed"):
+            for change in result.get(group) or []:
+                add(change["address"], change["before_monthly_cost"], change["after_monthly_cost"])
+        return _SideCost(kind, items, [u["address"] for u in result.get("unpriced") or []])
+    if kind == KIND_RESOURCES:
+        for item in result.get("line_items") or []:
+            key = item.get("name") or f"{item['provider']}/{item['region']}/{item['instance_type']}"
+            add(key, item["monthly_cost"], item["monthly_cost"])
+        return _SideCost(kind, items, [])
+    if kind == KIND_KUBERNETES:
+        for workload in result.get("workloads") or []:
+            key = f"{workload['namespace']}/{workload['kind']}/{workload['name']}"
+            add(key, workload["monthly_cost"], workload["monthly_cost"])
+        for volume in result.get("volumes") or []:
+            key = f"{volume['namespace']}/PersistentVolumeClaim/{volume['name']}"
+            add(key, volume["monthly_cost"], volume["monthly_cost"])
+        return _SideCost(kind, items, [])
+    raise ValueError(f"Estimates of kind {kind} cannot be diffed")
+
+
+def _change(base_cost: float, head_cost: float) -> str:
+    """Whether a resource costing something on one side only was added or removed"""
+    if base_cost == 0:
+        return CHANGE_ADDED
+    if head_cost == 0:
+        return CHANGE_REMOVED
+    return CHANGE_CHANGED
+
+
+def _failures(head: float, delta: float, percent: Optional[float], thresholds: DiffThresholds) -> List[str]:
+    """Thresholds the head side exceeds"""
+    failures = []
+    if thresholds.max_monthly_cost is not None and head > thresholds.max_monthly_cost:
+        failures.append(f"Monthly cost {head:,.2f} USD exceeds {thresholds.max_monthly_cost:,.2f} USD")
+    if thresholds.max_increase_cost is not None and delta > thresholds.max_increase_cost:
+        failures.append(f"Monthly cost grows by {delta:,.2f} USD, more than {thresholds.max_increase_cost:,.2f} USD")
+    if thresholds.max_increase_percent is not None and delta > 0:
+        if percent is None:
+            failures.append("Monthly cost grows from 0")
+        elif percent > thresholds.max_increase_percent:
+            failures.append(f"Monthly cost grows by {percent:.1f}%, more than {thresholds.max_increase_percent:g}%")
+    return failures
@@ -0,0 +1,89 @@
+"""
+Data models for estimate diffs
+"""
+
+from typing import Any, Dict, List, Optional
+
+from pydantic import BaseModel, Field, root_validator
+
+from ..estimator import EstimateRequest
+from ..k8s import KubernetesEstimateRequest
+from ..units import hours_per_month
+
+CHANGE_ADDED = "added"
+CHANGE_REMOVED = "removed"
+CHANGE_CHANGED = "changed"
+
+
+class DiffSide(BaseModel):
+    """One side of a diff: a recorded estimate, or an input estimated for the diff"""
+
+    label: Optional[str] = Field(None, description="Name shown in the summary, e.g. main or PR #42")
+    estimate_id: Optional[str] = Field(None, description="Recorded estimate")
+    resources: Optional[EstimateRequest] = Field(None, description="Resources, as for POST /estimate")
+    terraform: Optional[Dict[str, Any]] = Field(None, description="Output of `terraform show -json`")
+    pulumi: Optional[Dict[str, Any]] = Field(None, description="Output of `pulumi preview --json`")
+    kubernetes: Optional[KubernetesEstimateRequest] = Field(None, description="As for POST /estimate/kubernetes")
+
+    @root_validator(skip_on_failure=True)
+    def one_input(cls, values):
+        inputs = ("estimate_id", "resources", "terraform", "pulumi", "kubernetes")
+        given = [name for name in inputs if values.get(name) is not None]
+        if len(given) != 1:
+            raise ValueError("Set exactly one of estimate_id, resources, terraform, pulumi or kubernetes")
+        return values
+
+
+class DiffThresholds(BaseModel):
+    """Limits the head side must stay within to pass"""
+
+    max_increase_cost: Optional[float] = Field(None, ge=0, description="Largest monthly cost increase")
+    max_increase_percent: Optional[float] = Field(None, ge=0, description="Largest increase, percent of the base")
+    max_monthly_cost: Optional[float] = Field(None, ge=0, description="Largest monthly cost of the head")
+
+
+class DiffRequest(BaseModel):
+    """Request model for the estimate diff endpoint"""
+
+    base: DiffSide = Field(..., description="Estimate compared against, e.g. the main branch")
+    head: DiffSide = Field(..., description="Estimate checked, e.g. the pull request branch")
+    thresholds: DiffThresholds = Field(
+        default_factory=DiffThresholds,
+        description="Limits; unset limits fall back to the configured DIFF_MAX_*",
+    )
+    region: Optional[str] = Field(None, description="Fallback region of Terraform plans and Pulumi previews")
+    hours: float = Field(
+        default_factory=hours_per_month,
+        gt=0,
+        le=744,
+        description="Running hours per month of Terraform plans and Pulumi previews",
+    )
+
+
+class CostChange(BaseModel):
+    """Monthly cost of one resource on both sides"""
+
+    key: str = Field(..., description="Terraform address, namespace/kind/name or line item name")
+    change: str = Field(..., description="added, removed or changed")
+    base_monthly_cost: float
+    head_monthly_cost: float
+    monthly_delta: float
+
+
+class DiffResult(BaseModel):
+    """Monthly cost of two estimates and whether the head passes the thresholds"""
+
+    base_label: str
+    head_label: str
+    kind: str = Field(..., description="Estimate type compared: resources, kubernetes, terraform or pulumi")
+    base_monthly_cost: float
+    head_monthly_cost: float
+    monthly_delta: float
+    delta_percent: Optional[float] = Field(None, description="Change in percent of the base, None if the base is 0")
+    thresholds: DiffThresholds
+    passed: bool
+    failures: List[str] = Field(default_factory=list, description="Thresholds the head exceeds")
+    changes: List[CostChange] = Field(default_factory=list, description="Resources whose cost differs, largest first")
+    unpriced: List[str] = Field(default_factory=list, description="Plan resources that could not be priced")
+    currency: str = "USD"
+    markdown: str = Field("", description="Summary for a pull request comment")
@@ -0,0 +1,57 @@
+"""
+Markdown summary of an estimate diff, for pull request comments
+"""
+
+from typing import List
+
+from .models import DiffResult
+
+# Resources listed in the summary; the rest are counted
+MAX_ROWS = 20
+
+
+def _money(value: float, currency: str) -> str:
+    return f"{value:,.2f} {currency}"
+
+
+def _signed(value: float, currency: str) -> str:
+    return f"{'+' if value >= 0 else '-'}{abs(value):,.2f} {currency}"
+
+
+def render_markdown(result: DiffResult) -> str:
+    """Summary table of the totals, the changed resources and the failed thresholds"""
+    currency = result.currency
+    status = "✅ passed" if result.passed else "❌ failed"
+    change = _signed(result.monthly_delta, currency)
+    if result.delta_percent is not None:
+        change += f" ({result.delta_percent:+.1f}%)"
+
+    lines: List[str] = [
+        f"### Cost diff: {status}",
+        "",
+        "| | Monthly cost |",
+        "|---|---:|",
+        f"| {result.base_label} | {_money(result.base_monthly_cost, currency)} |",
+        f"| {result.head_label} | {_money(result.head_monthly_cost, currency)} |",
+        f"| **Change** | **{change}** |",
+    ]
+
+    if result.changes:
+        lines += [
+            "",
+            f"| Resource | {result.base_label} | {result.head_label} | Change |",
+            "|---|---:|---:|---:|",
+        ]
+        for c in result.changes[:MAX_ROWS]:
+            lines.append(
+                f"| `{c.key}` ({c.change}) | {_money(c.base_monthly_cost, currency)} | "
+                f"{_money(c.head_monthly_cost, currency)} | {_signed(c.monthly_delta, currency)} |"
+            )
+        if len(result.changes) > MAX_ROWS:
+            lines.append(f"| … {len(result.changes) - MAX_ROWS} more | | | |")
+
+    if result.failures:
+        lines += ["", "**Thresholds exceeded:**"] + [f"- {failure}" for failure in result.failures]
+    if result.unpriced:
+        lines += ["", f"Not priced: {', '.join(f'`{address}`' for address in result.unpriced)}"]
+    return "\n".join(lines) + "\n"
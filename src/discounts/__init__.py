@@ -0,0 +1,33 @@
+"""
+Discount Rules Module
+
+This module applies admin-defined discount rules, such as a percentage
+off all EC2 in a region or a free tier of instance-hours, to estimates.
+Rules belong to a tenant and are evaluated in a fixed order.
+"""
+
+from .models import (
+    RuleMatch,
+    DiscountTier,
+    DiscountRuleSpec,
+    DiscountRule,
+    RuleDiscount,
+    KIND_PERCENTAGE,
+    KIND_TIERED,
+    RULE_KINDS,
+)
+from .engine import DiscountEngine, DiscountSession, tiered_rate
+
+__all__ = [
+    "RuleMatch",
+    "DiscountTier",
+    "DiscountRuleSpec",
+    "DiscountRule",
+    "RuleDiscount",
+    "KIND_PERCENTAGE",
+    "KIND_TIERED",
+    "RULE_KINDS",
+    "DiscountEngine",
+    "DiscountSession",
+    "tiered_rate",
+]
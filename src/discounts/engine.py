@@ -0,0 +1,144 @@
+"""
+Discount rule evaluation
+
+A tenant's rules are read from the store and cached for a short TTL.
+They are evaluated in a fixed order, by priority and then id, so the
+same resources always receive the same discounts. Each matching rule
+discounts the cost left after provider usage discounts and the rules
+before it. Tier usage accumulates over the costs of one estimate in
+request order: a 750 hour free tier is shared by every matching resource.
+"""
+
+import math
+import threading
+import time
+from decimal import Decimal
+from typing import Callable, Dict, List, Optional, Tuple
+
+from ..money import Amount, to_decimal
+from ..pricing import Price
+from ..store import Store
+from ..tenancy import current_tenant
+from .models import DiscountRule, DiscountTier, RuleDiscount, KIND_TIERED
+
+
+def tiered_rate(tiers: List[DiscountTier], used: float, quantity: float) -> float:
+    """
+    Discounted fraction of usage from `used` to `used + quantity`
+
+    Args:
+        tiers: Usage bands in order
+        used: Usage already counted against the bands this month
+        quantity: Usage being priced
+    """
+    if quantity <= 0:
+        return 0.0
+
+    start, end = used, used + quantity
+    lower = 0.0
+    discounted = 0.0
+    for tier in tiers:
+        upper = math.inf if tier.up_to is None else tier.up_to
+        overlap = min(upper, end) - max(lower, start)
+        if overlap > 0:
+            discounted += overlap * tier.rate
+        lower = upper
+    return discounted / quantity
+
+
+class DiscountSession:
+    """Applies rules to the costs of one estimate, tracking tier usage"""
+
+    def __init__(self, rules: List[DiscountRule]):
+        """
+        Initialize session
+
+        Args:
+            rules: Enabled rules in evaluation order
+        """
+        self.rules = rules
+        # Rule id -> usage counted against its tiers
+        self._used: Dict[str, float] = {}
+
+    def apply(self, price: Price, quantity: float, cost: Amount) -> Tuple[List[RuleDiscount], Decimal]:
+        """
+        Discount a monthly cost with the matching rules
+
+        Args:
+            price: Unit price the cost was computed from
+            quantity: Monthly usage in the price's unit (instance-hours, GB-months, units)
+            cost: Monthly cost after provider usage discounts
+
+        Returns:
+            (discounts granted, discounted cost in decimal)
+        """
+        discounts = []
+        cost = to_decimal(cost)
+        for rule in self.rules:
+            if cost <= 0:
+                break
+            if not rule.match.matches(price):
+                continue
+
+            if rule.kind == KIND_TIERED:
+                used = self._used.get(rule.id, 0.0)
+                self._used[rule.id] = used + quantity
+                rate = tiered_rate(rule.tiers, used, quantity)
+            else:
+                rate = rule.rate
+            if rate <= 0:
+                continue
+
+            amount = cost * to_decimal(rate)
+            cost -= amount
+            discounts.append(RuleDiscount(rule=rule, amount=amount))
+            if rule.exclusive:
+                break
+        return discounts, cost
+
+
+class DiscountEngine:
+    """Discount rules of the current tenant"""
+
+    def __init__(self, store: Store, ttl: float = 60.0, clock: Callable[[], float] = time.monotonic):
+        """
+        Initialize engine
+
+        Args:
+            store: Store holding the rules
+            ttl: Seconds a tenant's rules are cached
+            clock: Monotonic time source in seconds
+        """
+        self.store = store
+        self.ttl = ttl
+        self.clock = clock
+        # Tenant -> (loaded at, enabled rules in evaluation order)
+        self._rules: Dict[str, Tuple[float, List[DiscountRule]]] = {}
+        self._lock = threading.Lock()
+
+    def rules(self, tenant_id: str) -> List[DiscountRule]:
+        """Enabled rules of a tenant in evaluation order"""
+        now = self.clock()
+        with self._lock:
+            cached = self._rules.get(tenant_id)
+        if cached is not None and now - cached[0] < self.ttl:
+            return cached[1]
+
+        rules = [
+            DiscountRule.from_record(record)
+            for record in self.store.list_discount_rules(tenant_id)
+            if record.enabled
+        ]
+        rules.sort(key=lambda r: (r.priority, r.id))
+        with self._lock:
+            self._rules[tenant_id] = (now, rules)
+        return rules
+
+    def invalidate(self, tenant_id: str) -> None:
+        """Drop a tenant's cached rules after they changed"""
+        with self._lock:
+            self._rules.pop(tenant_id, None)
+
+    def session(self, tenant_id: Optional[str] = None) -> DiscountSession:
+        """Start evaluating the rules of a tenant (default: the current one) for one estimate"""
+        return DiscountSession(self.rules(tenant_id or current_tenant()))
@@ -0,0 +1,147 @@
+"""
+Data models for discount rules
+"""
+
+from datetime import datetime
+from decimal import Decimal
+from fnmatch import fnmatchcase
+from typing import List, NamedTuple, Optional
+
+from pydantic import BaseModel, Field, validator
+
+from ..pricing import Price
+from ..store import DiscountRuleRecord, DEFAULT_TENANT
+
+# Percentage off every matching cost, e.g. 15% off all EC2 in ap-northeast-2
+KIND_PERCENTAGE = "percentage"
+# Rates by monthly usage band, e.g. the first 750 hours of t3.micro free
+KIND_TIERED = "tiered"
+RULE_KINDS = (KIND_PERCENTAGE, KIND_TIERED)
+
+# Pattern matching any value
+ANY = "*"
+
+
+class RuleMatch(BaseModel):
+    """Prices a rule applies to, as glob patterns (e.g. t3.*) matched against the price"""
+
+    provider: str = Field(ANY, description="Cloud provider, e.g. aws")
+    service: str = Field(
+        ANY,
+        description="compute, block_storage, object_storage, object_requests, object_retrieval, database, "
+                    "database_storage, database_iops, database_backup, data_transfer, kubernetes, accelerator, "
+                    "serverless_requests, serverless_memory or serverless_cpu"
+    )
+    region: str = Field(ANY, description="Provider region, e.g. ap-northeast-2")
+    sku: str = Field(ANY, description="Instance type, volume type or provider SKU")
+    pricing_model: str = Field(ANY, description="on_demand, spot, reserved or savings_plan")
+
+    @validator("provider", "service", "pricing_model")
+    def normalize(cls, v):
+        return v.strip().lower() or ANY
+
+    def matches(self, price: Price) -> bool:
+        return (
+            fnmatchcase(price.provider, self.provider)
+            and fnmatchcase(price.service, self.service)
+            and fnmatchcase(price.region, self.region)
+            and fnmatchcase(price.sku, self.sku)
+            and fnmatchcase(price.pricing_model, self.pricing_model)
+        )
+
+
+class DiscountTier(BaseModel):
+    """Discount rate of one band of monthly usage"""
+
+    up_to: Optional[float] = Field(
+        None,
+        gt=0,
+        description="Monthly usage the band ends at, in the price's unit (instance-hours, GB-months); None for no end"
+    )
+    rate: float = Field(..., ge=0, le=1, description="Fraction of the cost of usage in the band discounted (1 = free)")
+
+
+class DiscountRuleSpec(BaseModel):
+    """Discount rule as created or replaced through /discounts"""
+
+    name: str = Field(..., min_length=1, max_length=100)
+    description: str = ""
+    priority: int = Field(100, description="Rules are evaluated by ascending priority, then by id")
+    enabled: bool = True
+    exclusive: bool = Field(False, description="Later rules do not apply to costs this rule discounted")
+    match: RuleMatch = Field(default_factory=RuleMatch)
+    kind: str = Field(KIND_PERCENTAGE, description="percentage or tiered")
+    rate: Optional[float] = Field(None, gt=0, le=1, description="Fraction discounted by a percentage rule")
+    tiers: List[DiscountTier] = Field(default_factory=list, description="Usage bands of a tiered rule, in order")
+
+    @validator("kind")
+    def validate_kind(cls, v):
+        v = v.strip().lower()
+        if v not in RULE_KINDS:
+            raise ValueError(f"kind must be one of: {', '.join(RULE_KINDS)}")
+        return v
+
+    @validator("tiers", always=True)
+    def validate_tiers(cls, v, values):
+        kind = values.get("kind")
+        if kind == KIND_PERCENTAGE:
+            if values.get("rate") is None:
+                raise ValueError("A percentage rule needs a rate")
+            if v:
+                raise ValueError("A percentage rule has no tiers")
+        elif kind == KIND_TIERED:
+            if not v:
+                raise ValueError("A tiered rule needs at least one tier")
+            if values.get("rate") is not None:
+                raise ValueError("A tiered rule sets rates per tier")
+            previous = 0.0
+            for i, tier in enumerate(v):
+                if tier.up_to is None:
+                    if i != len(v) - 1:
+                        raise ValueError("Only the last tier may be unbounded")
+                elif tier.up_to <= previous:
+                    raise ValueError("Tier up_to values must increase")
+                else:
+                    previous = tier.up_to
+        return v
+
+    def to_record(self, tenant_id: str, rule_id: Optional[str] = None) -> DiscountRuleRecord:
+        return DiscountRuleRecord(
+            id=rule_id,
+            tenant_id=tenant_id,
+            name=self.name,
+            description=self.description,
+            priority=self.priority,
+            enabled=self.enabled,
+            definition=self.dict(include={"exclusive", "match", "kind", "rate", "tiers"}),
+        )
+
+
+class DiscountRule(DiscountRuleSpec):
+    """Stored discount rule"""
+
+    id: str
+    tenant_id: str = DEFAULT_TENANT
+    created_at: Optional[datetime] = None
+    updated_at: Optional[datetime] = None
+
+    @classmethod
+    def from_record(cls, record: DiscountRuleRecord) -> "DiscountRule":
+        return cls(
+            id=record.id,
+            tenant_id=record.tenant_id,
+            name=record.name,
+            description=record.description,
+            priority=record.priority,
+            enabled=record.enabled,
+            created_at=record.created_at,
+            updated_at=record.updated_at,
+            **record.definition,
+        )
+
+
+class RuleDiscount(NamedTuple):
+    """Discount a rule granted on one cost"""
+
+    rule: DiscountRule
+    amount: Decimal
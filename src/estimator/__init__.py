@@ -0,0 +1,211 @@
+"""
+Cost Estimation Module
+
+This module computes hourly, monthly and yearly cost breakdowns
+for cloud resource specifications (instance type, region, count, hours),
+applies discount rules, prices data transfer (inter-AZ, inter-region,
+internet egress), managed databases, object storage, serverless functions and
+software licenses billed with instances (OS, marketplace images, per-node
+agents), prices what a disaster recovery strategy adds in a recovery
+region, compares on-demand cost with reserved and savings plan
+commitments, and prices large batches of resources concurrently,
+reporting items that cannot be priced instead of failing on request.
+Short instance runs are billed by each cloud's granularity, and
+estimates can cover explicit date ranges, prorating partial months.
+Estimates are explained line by line, from the SKU and unit price matched
+to the discounted monthly cost.
+"""
+
+from .estimator import (
+    Estimator,
+    CostEstimator,
+    apply_discount_rules,
+    summarize_applied_rules,
+    coverage_of,
+    HOURS_PER_MONTH,
+    MONTHS_PER_YEAR,
+    PRICING_MODEL_VERSION,
+)
+from .batch import BatchEstimator
+from .explain import explain_estimate
+from .network import (
+    TransferRates,
+    traffic_volumes,
+    DEFAULT_TRANSFER_RATES,
+    DIRECTIONS,
+    DIRECTION_INTER_AZ,
+    DIRECTION_INTER_REGION,
+    DIRECTION_INTERNET_EGRESS,
+)
+from .database import default_storage_type, billable_iops, billable_backup_gb
+from .disaster_recovery import (
+    DR_STRATEGIES,
+    STRATEGY_BACKUP_ONLY,
+    STRATEGY_PILOT_LIGHT,
+    STRATEGY_WARM_STANDBY,
+    STRATEGY_ACTIVE_ACTIVE,
+    normalize_strategy,
+    replication_gb,
+    snapshot_gb,
+)
+from .licenses import (
+    LicenseRate,
+    LicenseRates,
+    DEFAULT_LICENSE_RATES,
+    LICENSE_UNITS,
+    UNIT_VCPU_HOUR,
+    UNIT_INSTANCE_HOUR,
+    UNIT_INSTANCE_MONTH,
+)
+from .object_storage import billable_storage_gb, object_count
+from .serverless import FreeTier, FREE_TIERS, SERVERLESS_BILLING, billed_seconds, billed_memory_gb, default_vcpus
+from .commitment import compare_commitment
+from .billing import (
+    BillingGranularity,
+    billed_hours,
+    billing_granularity,
+    month_segments,
+    period_cost,
+    BILLING_GRANULARITY,
+    PER_SECOND,
+    PER_MINUTE,
+    PER_HOUR,
+)
+from .models import (
+    ResourceSpec,
+    CommitmentOption,
+    TrafficSpec,
+    DatabaseSpec,
+    ObjectSizeBucket,
+    ObjectStorageSpec,
+    ServerlessSpec,
+    BillingPeriod,
+    DisasterRecoverySpec,
+    DisasterRecoveryComponent,
+    DisasterRecoveryCost,
+    MAX_PERIOD_DAYS,
+    EstimateRequest,
+    AppliedDiscount,
+    AppliedRule,
+    LicenseCost,
+    LineItem,
+    TransferTier,
+    TransferLineItem,
+    DatabaseComponent,
+    DatabaseLineItem,
+    ObjectStorageComponent,
+    ObjectStorageLineItem,
+    ServerlessComponent,
+    ServerlessLineItem,
+    CommitmentLineItem,
+    CommitmentComparison,
+    UnsupportedItem,
+    Coverage,
+    STATUS_PRICED,
+    STATUS_UNSUPPORTED,
+    PeriodMonth,
+    PeriodCost,
+    EstimateResult,
+    ExplainStep,
+    ItemExplanation,
+    BatchItem,
+    BatchEstimateRequest,
+    BatchItemResult,
+    BatchEstimateResult,
+)
+
+__all__ = [
+    "Estimator",
+    "CostEstimator",
+    "apply_discount_rules",
+    "summarize_applied_rules",
+    "coverage_of",
+    "BatchEstimator",
+    "explain_estimate",
+    "TransferRates",
+    "DR_STRATEGIES",
+    "STRATEGY_BACKUP_ONLY",
+    "STRATEGY_PILOT_LIGHT",
+    "STRATEGY_WARM_STANDBY",
+    "STRATEGY_ACTIVE_ACTIVE",
+    "normalize_strategy",
+    "replication_gb",
+    "snapshot_gb",
+    "LicenseRate",
+    "LicenseRates",
+    "DEFAULT_LICENSE_RATES",
+    "LICENSE_UNITS",
+    "UNIT_VCPU_HOUR",
+    "UNIT_INSTANCE_HOUR",
+    "UNIT_INSTANCE_MONTH",
+    "traffic_volumes",
+    "DEFAULT_TRANSFER_RATES",
+    "DIRECTIONS",
+    "DIRECTION_INTER_AZ",
+    "DIRECTION_INTER_REGION",
+    "DIRECTION_INTERNET_EGRESS",
+    "HOURS_PER_MONTH",
+    "PRICING_MODEL_VERSION",
+    "MONTHS_PER_YEAR",
+    "default_storage_type",
+    "billable_iops",
+    "billable_backup_gb",
+    "billable_storage_gb",
+    "object_count",
+    "FreeTier",
+    "FREE_TIERS",
+    "SERVERLESS_BILLING",
+    "billed_seconds",
+    "billed_memory_gb",
+    "default_vcpus",
+    "compare_commitment",
+    "BillingGranularity",
+    "billed_hours",
+    "billing_granularity",
+    "month_segments",
+    "period_cost",
+    "BILLING_GRANULARITY",
+    "PER_SECOND",
+    "PER_MINUTE",
+    "PER_HOUR",
+    "ResourceSpec",
+    "CommitmentOption",
+    "TrafficSpec",
+    "DatabaseSpec",
+    "ObjectSizeBucket",
+    "ObjectStorageSpec",
+    "ServerlessSpec",
+    "BillingPeriod",
+    "DisasterRecoverySpec",
+    "DisasterRecoveryComponent",
+    "DisasterRecoveryCost",
+    "MAX_PERIOD_DAYS",
+    "EstimateRequest",
+    "AppliedDiscount",
+    "AppliedRule",
+    "LicenseCost",
+    "LineItem",
+    "TransferTier",
+    "TransferLineItem",
+    "DatabaseComponent",
+    "DatabaseLineItem",
+    "ObjectStorageComponent",
+    "ObjectStorageLineItem",
+    "ServerlessComponent",
+    "ServerlessLineItem",
+    "CommitmentLineItem",
+    "CommitmentComparison",
+    "UnsupportedItem",
+    "Coverage",
+    "STATUS_PRICED",
+    "STATUS_UNSUPPORTED",
+    "PeriodMonth",
+    "PeriodCost",
+    "EstimateResult",
+    "ExplainStep",
+    "ItemExplanation",
+    "BatchItem",
+    "BatchEstimateRequest",
+    "BatchItemResult",
+    "BatchEstimateResult",
+]
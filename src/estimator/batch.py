@@ -0,0 +1,121 @@
+"""
+Batch estimation
+
+Prices many independent resources in one request, e.g. every resource of
+a Terraform monorepo. Price lookups run concurrently on a bounded pool of
+worker threads shared by all batch requests; discount rules are then
+applied in item order, so tiered rules give the same result as a
+sequential estimate. An item that cannot be priced is unsupported: it
+gets an error, and the closest instance types of its region, instead of
+failing the batch, and the result's coverage counts the priced items.
+"""
+
+import contextvars
+import logging
+from concurrent.futures import ThreadPoolExecutor
+from typing import Callable, List, Optional, Tuple
+
+from ..money import sum_money
+from ..pricing import Price, PriceNotFoundError, ProviderNotFoundError
+from .estimator import CostEstimator, coverage_of, summarize_applied_rules
+from .models import BatchEstimateRequest, BatchEstimateResult, BatchItem, BatchItemResult, STATUS_UNSUPPORTED
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_MAX_ITEMS = 5000
+DEFAULT_WORKERS = 16
+
+
+class BatchEstimator:
+    """Estimates batches of resources on a bounded worker pool"""
+
+    def __init__(self, estimator: CostEstimator, workers: int = DEFAULT_WORKERS, max_items: int = DEFAULT_MAX_ITEMS):
+        """
+        Initialize batch estimator
+
+        Args:
+            estimator: Estimator pricing each item
+            workers: Price lookups running at once, across all batches
+            max_items: Largest batch accepted
+        """
+        if workers < 1 or max_items < 1:
+            raise ValueError("Batch workers and max items must be at least 1")
+        self.estimator = estimator
+        self.max_items = max_items
+        self._pool = ThreadPoolExecutor(max_workers=workers, thread_name_prefix="batch-estimate")
+
+    def estimate(
+        self,
+        request: BatchEstimateRequest,
+        progress: Optional[Callable[[int, int], None]] = None,
+        on_item: Optional[Callable[[BatchItemResult], None]] = None,
+    ) -> BatchEstimateResult:
+        """
+        Price every item of a batch
+
+        Args:
+            request: Items to price
+            progress: Called with (items priced, items) as items are priced
+            on_item: Called with each item's result, in request order, as it is priced
+
+        Raises:
+            ValueError: If the batch has more than max_items items
+        """
+        if len(request.items) > self.max_items:
+            raise ValueError(f"A batch holds at most {self.max_items} items, got {len(request.items)}")
+
+        # Workers see the request's tenant, whose price sheet may override list prices
+        lookups = [
+            self._pool.submit(contextvars.copy_context().run, self._lookup, item)
+            for item in request.items
+        ]
+
+        session = self.estimator.discount_session()
+        results = []
+        for index, (item, lookup) in enumerate(zip(request.items, lookups)):
+            price, error, suggestions = lookup.result()
+            if price is not None:
+                try:
+                    line_item = self.estimator.price_resource(item, session, price=price)
+                    results.append(BatchItemResult(index=index, id=item.id, line_item=line_item))
+                except (PriceNotFoundError, ProviderNotFoundError) as e:
+                    # An attached GPU without a price
+                    price, error = None, str(e)
+            if price is None:
+                results.append(BatchItemResult(
+                    index=index, id=item.id, status=STATUS_UNSUPPORTED, error=error, suggestions=suggestions,
+                ))
+            if on_item is not None:
+                on_item(results[-1])
+            if progress is not None:
+                progress(index + 1, len(request.items))
+
+        line_items = [r.line_item for r in results if r.line_item is not None]
+        result = BatchEstimateResult(
+            items=results,
+            succeeded=len(line_items),
+            failed=len(results) - len(line_items),
+            hourly_cost=sum_money(item.hourly_cost for item in line_items),
+            monthly_cost=sum_money(item.monthly_cost for item in line_items),
+            yearly_cost=sum_money(item.yearly_cost for item in line_items),
+            applied_rules=summarize_applied_rules(line_items),
+            coverage=coverage_of(len(line_items), len(results) - len(line_items)),
+        )
+        logger.info(
+            f"Estimated batch of {len(results)} resources ({result.failed} failed): "
+            f"${result.monthly_cost:.2f}/month"
+        )
+        return result
+
+    def close(self) -> None:
+        """Stop the worker pool once running lookups finish"""
+        self._pool.shutdown(wait=True)
+
+    def _lookup(self, item: BatchItem) -> Tuple[Optional[Price], Optional[str], List[str]]:
+        try:
+            return self.estimator.lookup_price(item), None, []
+        except PriceNotFoundError as e:
+            suggestions = [match.sku for match in self.estimator.suggest_instance_types(item)]
+            return None, str(e), suggestions
+        except ProviderNotFoundError as e:
+            return None, str(e), []
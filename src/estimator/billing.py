@@ -0,0 +1,110 @@
+"""
+Billing granularity and billing periods
+
+Instances are billed for each run rounded up to the provider's increment,
+and at least its minimum:
+
+- aws, gcp, alibaba: per second, at least a minute
+- azure: per minute
+- ncp, openstack and other clouds: per hour, at least an hour
+
+Rounding only matters for instances started and stopped within the
+month, e.g. CI runners: 300 runs of 45 seconds bill 5 hours on AWS but
+300 hours on a per-hour cloud. Resources with runs set are priced by the
+hours billed for them.
+
+An estimate for a date range (period) prices each calendar month the
+range touches: instances and their GPUs by the hours of the month's days
+in the range, at the share of the hours of the time basis they run, and
+databases, object storage, serverless and data transfer by the share of
+the month's days in the range. A 31-day month thus costs more than a
+30-day one for always-on instances, and a range of half a month half its
+monthly storage.
+"""
+
+import calendar
+import math
+from datetime import date, timedelta
+from typing import Dict, Iterator, NamedTuple, Tuple
+
+from ..money import round_money, sum_money
+from ..units import HOURS_PER_DAY, hours_per_month
+from .models import BillingPeriod, EstimateResult, PeriodCost, PeriodMonth
+
+_SECONDS_PER_HOUR = 3600.0
+
+
+class BillingGranularity(NamedTuple):
+    """How a cloud meters instance runs"""
+
+    increment_seconds: float
+    minimum_seconds: float
+
+
+PER_SECOND = BillingGranularity(1, 60)
+PER_MINUTE = BillingGranularity(60, 60)
+PER_HOUR = BillingGranularity(3600, 3600)
+
+BILLING_GRANULARITY: Dict[str, BillingGranularity] = {
+    "aws": PER_SECOND,
+    "gcp": PER_SECOND,
+    "alibaba": PER_SECOND,
+    "azure": PER_MINUTE,
+}
+
+
+def billing_granularity(provider: str) -> BillingGranularity:
+    """Granularity of a cloud's instance billing, per hour for clouds not listed"""
+    return BILLING_GRANULARITY.get(provider, PER_HOUR)
+
+
+def billed_hours(provider: str, hours: float, runs: int) -> float:
+    """Hours billed for instance hours split into runs of equal length"""
+    granularity = billing_granularity(provider)
+    run_seconds = hours * _SECONDS_PER_HOUR / runs
+    # Rounded first so float noise (e.g. 45.000000001 s) does not bill another increment
+    increments = math.ceil(round(run_seconds / granularity.increment_seconds, 6))
+    billed = max(granularity.minimum_seconds, increments * granularity.increment_seconds)
+    return runs * billed / _SECONDS_PER_HOUR
+
+
+def month_segments(period: BillingPeriod) -> Iterator[Tuple[date, date]]:
+    """(first, last) day of the period in each calendar month it touches"""
+    start = period.start
+    while start <= period.end:
+        last_of_month = date(start.year, start.month, calendar.monthrange(start.year, start.month)[1])
+        end = min(last_of_month, period.end)
+        yield start, end
+        start = end + timedelta(days=1)
+
+
+def period_cost(result: EstimateResult, period: BillingPeriod) -> PeriodCost:
+    """Cost of an estimate's resources over the days of a period"""
+    # Instance costs follow the hours of each month, the other items its days
+    hourly_monthly = sum_money(item.monthly_cost for item in result.line_items)
+    daily_monthly = result.monthly_cost - hourly_monthly
+    basis_hours = hours_per_month()
+
+    months = []
+    for start, end in month_segments(period):
+        days = (end - start).days + 1
+        days_in_month = calendar.monthrange(start.year, start.month)[1]
+        hours = days * HOURS_PER_DAY
+        months.append(PeriodMonth(
+            month=f"{start.year:04d}-{start.month:02d}",
+            start=start,
+            end=end,
+            days=days,
+            hours=hours,
+            fraction=round(days / days_in_month, 6),
+            prorated_cost=round_money(hourly_monthly * hours / basis_hours + daily_monthly * days / days_in_month),
+        ))
+
+    return PeriodCost(
+        start=period.start,
+        end=period.end,
+        days=sum(month.days for month in months),
+        hours=sum(month.hours for month in months),
+        total_cost=sum_money(month.prorated_cost for month in months),
+        months=months,
+    )
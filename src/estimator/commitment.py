@@ -0,0 +1,122 @@
+"""
+On-demand versus commitment comparison
+
+Prices each resource under a commitment option and compares the cost over
+the whole term with paying on-demand for the requested hours. Commitments
+are billed for every hour of the term, so the break-even utilization is
+the share of term hours the resources must run for them to pay off.
+"""
+
+from decimal import Decimal
+from typing import List
+
+from ..money import add_amounts, round_money, sum_money, to_decimal
+from ..pricing import (
+    PriceQuery,
+    ProviderRegistry,
+    PriceNotFoundError,
+    ATTR_TERM,
+    ATTR_PAYMENT_OPTION,
+    SERVICE_COMPUTE,
+    TERMS,
+    term_hours,
+)
+from .models import CommitmentComparison, CommitmentLineItem, CommitmentOption, LineItem, ResourceSpec
+
+MONTHS_PER_TERM_YEAR = 12
+
+
+def compare_commitment(
+    registry: ProviderRegistry,
+    resources: List[ResourceSpec],
+    on_demand_items: List[LineItem],
+    option: CommitmentOption,
+) -> CommitmentComparison:
+    """
+    Compare on-demand line items with the same resources under a commitment
+
+    Args:
+        registry: Registry used to look up commitment prices
+        resources: Requested resources
+        on_demand_items: On-demand line items of the resources, in the same order
+        option: Commitment option to price
+
+    Returns:
+        Comparison over the term. Resources without a commitment price are
+        listed with a reason and stay on-demand in the commitment total.
+    """
+    term_months = TERMS[option.term] * MONTHS_PER_TERM_YEAR
+    hours = term_hours(option.term)
+
+    line_items = []
+    committed_cost = committed_full_time = Decimal(0)
+    for resource, item in zip(resources, on_demand_items):
+        # Licenses cost the same either way and are left out of the comparison
+        licenses = add_amounts(license.monthly_cost for license in item.licenses)
+        on_demand_cost = (to_decimal(item.monthly_cost) - licenses) * term_months
+        line = CommitmentLineItem(
+            name=resource.name,
+            provider=resource.provider,
+            region=resource.region,
+            instance_type=resource.instance_type,
+            count=resource.count,
+            on_demand_term_cost=round_money(on_demand_cost),
+        )
+
+        if resource.accelerator_count:
+            line.unavailable_reason = "Commitments of attached GPUs are not priced"
+            line_items.append(line)
+            continue
+
+        try:
+            price = registry.get_price(PriceQuery(
+                provider=resource.provider,
+                region=resource.region,
+                sku=resource.instance_type,
+                service=SERVICE_COMPUTE,
+                pricing_model=option.type,
+                attributes={ATTR_TERM: option.term, ATTR_PAYMENT_OPTION: option.payment_option},
+            ))
+        except (PriceNotFoundError, ValueError) as e:
+            line.unavailable_reason = str(e)
+            line_items.append(line)
+            continue
+
+        upfront, hourly = to_decimal(price.upfront), to_decimal(price.price)
+        commitment_cost = resource.count * (upfront + hourly * to_decimal(hours))
+        full_time_on_demand = to_decimal(item.unit_price_hourly) * resource.count * to_decimal(hours)
+        line.commitment_term_cost = round_money(commitment_cost)
+        line.upfront_cost = round_money(upfront * resource.count)
+        line.recurring_hourly_cost = round_money(hourly * resource.count)
+        line.effective_hourly_cost = round_money(commitment_cost / to_decimal(hours))
+        if full_time_on_demand > 0:
+            line.break_even_utilization = round(float(commitment_cost / full_time_on_demand), 4)
+        line_items.append(line)
+
+        committed_cost += commitment_cost
+        committed_full_time += full_time_on_demand
+
+    on_demand_total = add_amounts(line.on_demand_term_cost for line in line_items)
+    commitment_total = add_amounts(
+        line.commitment_term_cost if line.commitment_term_cost is not None else line.on_demand_term_cost
+        for line in line_items
+    )
+
+    break_even = None
+    if committed_full_time > 0:
+        break_even = round(float(committed_cost / committed_full_time), 4)
+
+    savings = on_demand_total - commitment_total
+    return CommitmentComparison(
+        type=option.type,
+        term=option.term,
+        payment_option=option.payment_option,
+        term_months=term_months,
+        on_demand_total_cost=round_money(on_demand_total),
+        commitment_total_cost=round_money(commitment_total),
+        upfront_cost=sum_money(line.upfront_cost for line in line_items),
+        savings=round_money(savings),
+        savings_rate=round(float(savings / on_demand_total), 4) if on_demand_total > 0 else 0.0,
+        break_even_utilization=break_even,
+        line_items=line_items,
+    )
@@ -0,0 +1,68 @@
+"""
+Managed database usage rules
+
+Turns a database specification into the billed quantity of each component.
+Providers differ in what a database includes for free:
+
+- aws: gp3 storage includes 3000 IOPS; io1/io2 IOPS are all billed.
+  Backup storage up to the provisioned storage is free.
+- gcp: Cloud SQL IOPS scale with the disk size and are not billed
+  separately; all backup storage is billed.
+- azure: flexible servers include 3 IOPS per GB plus 300; backup storage
+  up to the provisioned storage is free.
+
+The baselines are simplified: RDS raises the gp3 baseline above 400 GB
+and Azure caps it by disk tier.
+"""
+
+from typing import Dict, Optional, Tuple
+
+# Storage type of databases without one
+DEFAULT_STORAGE_TYPES: Dict[str, str] = {
+    "aws": "gp3",
+    "gcp": "ssd",
+    "azure": "premium_ssd",
+}
+
+# IOPS a storage type includes as (base, per provisioned GB)
+INCLUDED_IOPS: Dict[str, Dict[str, Tuple[float, float]]] = {
+    "aws": {"gp3": (3000, 0), "io1": (0, 0), "io2": (0, 0)},
+    "azure": {"premium_ssd": (300, 3)},
+}
+
+# Free backup storage per GB of provisioned storage
+FREE_BACKUP_RATIO: Dict[str, float] = {
+    "aws": 1.0,
+    "gcp": 0.0,
+    "azure": 1.0,
+}
+
+
+def default_storage_type(provider: str) -> str:
+    """
+    Storage type of a database whose specification sets none
+
+    Raises:
+        ValueError: If the provider has no default storage type
+    """
+    try:
+        return DEFAULT_STORAGE_TYPES[provider]
+    except KeyError:
+        raise ValueError(f"storage_type is required for {provider} databases") from None
+
+
+def billable_iops(provider: str, storage_type: str, storage_gb: float, iops: Optional[int]) -> float:
+    """
+    Provisioned IOPS of one database beyond the storage's baseline
+
+    Storage types without a baseline entry bill every provisioned IOPS.
+    """
+    if not iops:
+        return 0.0
+    base, per_gb = INCLUDED_IOPS.get(provider, {}).get(storage_type, (0, 0))
+    return max(iops - (base + per_gb * storage_gb), 0.0)
+
+
+def billable_backup_gb(provider: str, storage_gb: float, backup_storage_gb: float) -> float:
+    """Backup storage of one database beyond the provider's free allowance"""
+    return max(backup_storage_gb - FREE_BACKUP_RATIO.get(provider, 0.0) * storage_gb, 0.0)
@@ -0,0 +1,88 @@
+"""
+Disaster recovery cost model
+
+Prices what a DR strategy adds to an estimate in its recovery region:
+
+- backup_only: database snapshots copied to the recovery region and
+  object storage backed up there in the infrequent access class; nothing
+  runs until a disaster
+- pilot_light: as backup_only, with database replicas running and object
+  storage replicated in its own class; instances are started on failover
+- warm_standby: as pilot_light, with a scaled-down share of the instances
+  running (a quarter by default)
+- active_active: every instance and database runs in both regions
+
+Snapshots keep a full copy of each database plus its daily changes over
+the retention period. Changed data is replicated across regions as it is
+written and billed as inter-region transfer from the primary region.
+Instance disks are not modeled.
+"""
+
+import math
+from typing import NamedTuple, Optional
+
+from ..pricing import STORAGE_CLASS_INFREQUENT
+
+STRATEGY_BACKUP_ONLY = "backup_only"
+STRATEGY_PILOT_LIGHT = "pilot_light"
+STRATEGY_WARM_STANDBY = "warm_standby"
+STRATEGY_ACTIVE_ACTIVE = "active_active"
+
+# Average days in a month, for the data changed per month
+DAYS_PER_MONTH = 365.25 / 12
+
+
+class Strategy(NamedTuple):
+    """What a DR strategy keeps in the recovery region"""
+
+    description: str
+    recovery_time: str
+    # Share of the instances running, rounded up per resource
+    compute_fraction: float
+    database_replicas: bool
+    # Storage class of object storage copies, None for the primary's class
+    object_storage_class: Optional[str]
+
+
+DR_STRATEGIES = {
+    STRATEGY_BACKUP_ONLY: Strategy(
+        "Snapshots and backups copied to the recovery region", "hours", 0.0, False, STORAGE_CLASS_INFREQUENT,
+    ),
+    STRATEGY_PILOT_LIGHT: Strategy(
+        "Replicated data, instances started on failover", "tens of minutes", 0.0, True, None,
+    ),
+    STRATEGY_WARM_STANDBY: Strategy(
+        "Replicated data and a scaled-down copy of the instances", "minutes", 0.25, True, None,
+    ),
+    STRATEGY_ACTIVE_ACTIVE: Strategy(
+        "Every instance and database running in both regions", "near zero", 1.0, True, None,
+    ),
+}
+
+
+def normalize_strategy(strategy: str) -> str:
+    """
+    Canonical name of a DR strategy, e.g. warm_standby for "Warm-Standby"
+
+    Raises:
+        ValueError: If the strategy is unknown
+    """
+    normalized = strategy.strip().lower().replace("-", "_").replace(" ", "_")
+    if normalized not in DR_STRATEGIES:
+        raise ValueError(f"Unknown DR strategy '{strategy}', expected one of {', '.join(DR_STRATEGIES)}")
+    return normalized
+
+
+def snapshot_gb(protected_gb: float, retention_days: int, daily_change_rate: float) -> float:
+    """Snapshot storage of data kept as a full copy and its daily changes over the retention period"""
+    return protected_gb * (1 + daily_change_rate * retention_days)
+
+
+def replication_gb(protected_gb: float, daily_change_rate: float) -> float:
+    """GB/month of changed data replicated to the recovery region"""
+    return protected_gb * daily_change_rate * DAYS_PER_MONTH
+
+
+def standby_count(count: int, fraction: float) -> int:
+    """Instances of a resource running in the recovery region"""
+    return math.ceil(count * fraction - 1e-9) if fraction > 0 else 0
@@ -0,0 +1,835 @@
+"""
+Cost estimators
+
+An Estimator turns a set of resource specifications into a cost breakdown.
+CostEstimator prices resources through a pricing ProviderRegistry,
+applies the tenant's discount rules, prices data transfer assumptions,
+managed databases, object storage and serverless functions, optionally
+compares on-demand cost with commitment options and estimates the
+resources' carbon footprint.
+"""
+
+import logging
+from abc import ABC, abstractmethod
+from decimal import Decimal
+from typing import Dict, List, Optional, Tuple, Union
+
+from ..carbon import CarbonEstimator
+from ..discounts import DiscountEngine, DiscountSession
+from ..money import add_amounts, round_money, sum_money, to_decimal
+from ..profiles import UsageProfile, UsageProfiles
+from ..pricing import (
+    Price,
+    PriceNotFoundError,
+    PriceQuery,
+    ProviderNotFoundError,
+    ProviderRegistry,
+    SkuMatch,
+    SERVICE_COMPUTE,
+    SERVICE_ACCELERATOR,
+    SERVICE_DATABASE,
+    SERVICE_DATABASE_STORAGE,
+    SERVICE_DATABASE_IOPS,
+    SERVICE_DATABASE_BACKUP,
+    SERVICE_OBJECT_STORAGE,
+    SERVICE_OBJECT_REQUESTS,
+    SERVICE_OBJECT_RETRIEVAL,
+    SERVICE_SERVERLESS_REQUESTS,
+    SERVICE_SERVERLESS_MEMORY,
+    SERVICE_SERVERLESS_CPU,
+    ATTR_ARCHITECTURE,
+    ATTR_ENGINE,
+    ATTR_DEPLOYMENT,
+    ATTR_OPERATION,
+    BACKUP_SKU,
+    OPERATION_READ,
+    OPERATION_TRANSITION,
+    OPERATION_WRITE,
+    PRICING_ON_DEMAND,
+    deployment_of,
+    usage_amount,
+    storage_class_sku,
+)
+from ..units import HOURS_PER_MONTH, MONTHS_PER_YEAR, hours_per_month, months_per_year
+from .billing import billed_hours, period_cost
+from .commitment import compare_commitment
+from .database import billable_backup_gb, billable_iops, default_storage_type
+from .disaster_recovery import (
+    DR_STRATEGIES,
+    STRATEGY_ACTIVE_ACTIVE,
+    STRATEGY_WARM_STANDBY,
+    replication_gb,
+    snapshot_gb,
+    standby_count,
+)
+from .licenses import LicenseRates
+from .object_storage import billable_storage_gb, object_count
+from .serverless import FreeTier, billed_memory_gb, billed_seconds, default_vcpus
+from .models import (
+    AppliedDiscount,
+    AppliedRule,
+    Coverage,
+    DatabaseComponent,
+    DatabaseLineItem,
+    DatabaseSpec,
+    DisasterRecoveryComponent,
+    DisasterRecoveryCost,
+    EstimateRequest,
+    EstimateResult,
+    LineItem,
+    ObjectStorageComponent,
+    ObjectStorageLineItem,
+    ObjectStorageSpec,
+    ResourceSpec,
+    ServerlessComponent,
+    ServerlessLineItem,
+    ServerlessSpec,
+    TrafficSpec,
+    TransferLineItem,
+    UnsupportedItem,
+)
+from .network import TransferRates, traffic_volumes
+
+logger = logging.getLogger(__name__)
+
+# Version of the rules turning prices into estimates, recorded with every
+# estimate; bumped when the same prices and request would be priced differently
+PRICING_MODEL_VERSION = 1
+
+
+class Estimator(ABC):
+    """Interface implemented by all cost estimators"""
+
+    @abstractmethod
+    def estimate(self, request: EstimateRequest) -> EstimateResult:
+        """
+        Compute the cost breakdown for a request
+
+        Raises:
+            PriceNotFoundError: If a resource cannot be priced
+            ProviderNotFoundError: If no pricing provider serves a resource's cloud
+        """
+
+
+class CostEstimator(Estimator):
+    """Estimator that resolves unit prices through a provider registry"""
+
+    def __init__(
+        self,
+        registry: ProviderRegistry,
+        discounts: Optional[DiscountEngine] = None,
+        carbon: Optional[CarbonEstimator] = None,
+        transfer_rates: Optional[TransferRates] = None,
+        profiles: Optional[UsageProfiles] = None,
+        licenses: Optional[LicenseRates] = None,
+    ):
+        """
+        Initialize cost estimator
+
+        Args:
+            registry: Registry of enabled pricing providers
+            discounts: Discount rules applied to line items. No rules apply if not provided.
+            carbon: Estimates the line items' emissions. Results have no carbon section if not provided.
+            transfer_rates: Data transfer rates. Uses the built-in rates if not provided.
+            profiles: Usage profiles resources reference by name. Only the built-in ones if not provided.
+            licenses: Software license rates. Uses the built-in rates if not provided.
+        """
+        self.registry = registry
+        self.discounts = discounts
+        self.carbon = carbon
+        self.transfer_rates = transfer_rates or TransferRates()
+        self.profiles = profiles or UsageProfiles()
+        self.licenses = licenses or LicenseRates()
+
+    def with_registry(self, registry: ProviderRegistry) -> "CostEstimator":
+        """Estimator pricing through another registry with the same discounts, carbon, rates and profiles"""
+        return CostEstimator(registry, self.discounts, self.carbon, self.transfer_rates, self.profiles, self.licenses)
+
+    def estimate(self, request: EstimateRequest) -> EstimateResult:
+        session = self.discount_session()
+        unsupported: List[UnsupportedItem] = []
+
+        def priced(kind: str, specs: list, price) -> list:
+            """(spec, item) of each spec priced; with continue_on_error, specs that cannot be priced are left out"""
+            pairs = []
+            for i, spec in enumerate(specs):
+                try:
+                    pairs.append((spec, price(spec)))
+                except (PriceNotFoundError, ProviderNotFoundError, ValueError) as e:
+                    if not request.continue_on_error:
+                        raise
+                    unsupported.append(UnsupportedItem(
+                        field=f"{kind}[{i}]", name=spec.name, provider=spec.provider, region=spec.region, reason=str(e),
+                    ))
+            return pairs
+
+        resources = priced("resources", request.resources, lambda resource: self.price_resource(resource, session))
+        line_items = [item for _, item in resources]
+        transfer_items = [
+            item
+            for _, items in priced("traffic", request.traffic, lambda traffic: self.price_traffic(traffic, session))
+            for item in items
+        ]
+        database_items = [
+            item for _, item in priced("databases", request.databases, lambda db: self.price_database(db, session))
+        ]
+        object_storage_items = [
+            item for _, item in priced(
+                "object_storage", request.object_storage, lambda spec: self.price_object_storage(spec, session)
+            )
+        ]
+        free_tier = FreeTier()
+        serverless_items = [
+            item for _, item in priced(
+                "serverless", request.serverless, lambda spec: self.price_serverless(spec, session, free_tier)
+            )
+        ]
+        items = line_items + transfer_items + database_items + object_storage_items + serverless_items
+        specs = len(request.resources) + len(request.traffic) + len(request.databases)
+        specs += len(request.object_storage) + len(request.serverless)
+
+        result = EstimateResult(
+            line_items=line_items,
+            transfer_items=transfer_items,
+            database_items=database_items,
+            object_storage_items=object_storage_items,
+            serverless_items=serverless_items,
+            hourly_cost=sum_money(item.hourly_cost for item in items),
+            monthly_cost=sum_money(item.monthly_cost for item in items),
+            yearly_cost=sum_money(item.yearly_cost for item in items),
+            applied_rules=summarize_applied_rules(items),
+            unsupported=unsupported,
+            coverage=coverage_of(specs - len(unsupported), len(unsupported)),
+        )
+
+        if request.commitments:
+            priced_resources = [resource for resource, _ in resources]
+            on_demand_items = [
+                item if item.pricing_model == PRICING_ON_DEMAND
+                else self.price_resource(resource.copy(update={"pricing_model": PRICING_ON_DEMAND}))
+                for resource, item in resources
+            ]
+            result.commitment_comparison = [
+                compare_commitment(self.registry, priced_resources, on_demand_items, option)
+                for option in request.commitments
+            ]
+
+        if request.disaster_recovery is not None:
+            result.disaster_recovery = self.price_disaster_recovery(request, result, session)
+        if self.carbon is not None:
+            result.carbon = self.carbon.estimate(line_items)
+        if request.period is not None:
+            result.period = period_cost(result, request.period)
+
+        logger.info(
+            f"Estimated {len(line_items)} resources, {len(database_items)} databases, "
+            f"{len(object_storage_items)} object storage classes, {len(serverless_items)} serverless items "
+            f"and {len(transfer_items)} transfer items ({len(unsupported)} unsupported): "
+            f"${result.monthly_cost:.2f}/month"
+        )
+        return result
+
+    def discount_session(self) -> Optional[DiscountSession]:
+        """Discount rules of the current tenant for one estimate, None without rules"""
+        return self.discounts.session() if self.discounts is not None else None
+
+    def usage_profile(self, name: Optional[str]) -> Optional[UsageProfile]:
+        """
+        Usage profile of the current tenant by name, None without a name
+
+        Raises:
+            UnknownProfileError: If the tenant has no profile of that name
+        """
+        return self.profiles.get(name) if name else None
+
+    def lookup_price(self, resource: ResourceSpec) -> Price:
+        """
+        Unit price of a resource specification
+
+        Raises:
+            PriceNotFoundError: If the resource cannot be priced
+            ProviderNotFoundError: If no pricing provider serves the resource's cloud
+        """
+        return self.registry.get_price(PriceQuery(
+            provider=resource.provider,
+            region=resource.region,
+            sku=resource.instance_type,
+            service=SERVICE_COMPUTE,
+            pricing_model=resource.pricing_model,
+        ))
+
+    def suggest_instance_types(self, resource: ResourceSpec) -> List[SkuMatch]:
+        """Instance types of the resource's region most like its instance type, none for unsupported clouds"""
+        try:
+            return self.registry.suggest_instance_types(resource.provider, resource.region, resource.instance_type)
+        except ProviderNotFoundError:
+            return []
+
+    def price_resource(
+        self,
+        resource: ResourceSpec,
+        session: Optional[DiscountSession] = None,
+        price: Optional[Price] = None,
+    ) -> LineItem:
+        """
+        Price a single resource specification, with the GPUs attached to it and the licenses it runs
+
+        Args:
+            resource: Resource to price
+            session: Discount rules shared with the other resources of an estimate.
+                Defaults to a new session of the current tenant's rules.
+            price: Unit price already looked up with lookup_price()
+        """
+        if price is None:
+            price = self.lookup_price(resource)
+        if session is None:
+            session = self.discount_session()
+
+        # (unit price, units running) of the instances and their attached GPUs
+        units = [(price, resource.count)]
+        accelerator = None
+        if resource.accelerator_type:
+            accelerator = self.registry.get_price(PriceQuery(
+                provider=resource.provider,
+                region=resource.region,
+                sku=resource.accelerator_type,
+                service=SERVICE_ACCELERATOR,
+                pricing_model=resource.pricing_model,
+            ))
+            units.append((accelerator, resource.count * resource.accelerator_count))
+
+        # A usage profile scales the hours, and splits them into its runs unless the resource sets its own
+        running_hours, runs = resource.hours, resource.runs
+        profile = self.usage_profile(resource.usage_profile)
+        if profile is not None:
+            running_hours = profile.scale(resource.hours)
+            if runs is None:
+                runs = profile.monthly_runs
+
+        # Instances started and stopped within the month are billed per run
+        hours = running_hours
+        if runs is not None:
+            hours = billed_hours(resource.provider, running_hours, runs)
+
+        hourly_cost = monthly_cost = Decimal(0)
+        discounts = []
+        for unit_price, count in units:
+            unit_hourly = to_decimal(unit_price.price) * count
+            unit_gross = unit_monthly = unit_hourly * to_decimal(hours)
+
+            usage_discount = self.registry.usage_discount(unit_price, hours)
+            if usage_discount is not None:
+                amount = unit_monthly * to_decimal(usage_discount.rate)
+                discounts.append(AppliedDiscount(
+                    name=usage_discount.name,
+                    description=usage_discount.description,
+                    rate=usage_discount.rate,
+                    monthly_amount=round_money(amount),
+                ))
+                unit_monthly -= amount
+
+            rule_discounts, unit_monthly = apply_discount_rules(
+                session, unit_price, count * hours, unit_gross, unit_monthly
+            )
+            discounts.extend(rule_discounts)
+            hourly_cost += unit_hourly
+            monthly_cost += unit_monthly
+
+        # Licenses are billed for the hours the instances are billed, without cloud discounts
+        instance_type = resource.instance_type if price.requested_sku is None else price.sku
+        licenses, license_hourly = self.licenses.price(
+            resource.provider, instance_type, resource.licenses, resource.count, hours
+        )
+        hourly_cost += to_decimal(license_hourly)
+        monthly_cost += add_amounts(license.monthly_cost for license in licenses)
+
+        return LineItem(
+            name=resource.name,
+            provider=resource.provider,
+            region=resource.region,
+            instance_type=instance_type,
+            requested_instance_type=price.requested_sku,
+            match_confidence=price.match_confidence,
+            count=resource.count,
+            hours=running_hours,
+            billed_hours=None if runs is None else round(hours, 6),
+            usage_profile=profile.name if profile else None,
+            pricing_model=resource.pricing_model,
+            unit_price_hourly=price.price,
+            accelerator_type=resource.accelerator_type,
+            accelerator_count=resource.accelerator_count,
+            accelerator_unit_price_hourly=accelerator.price if accelerator else None,
+            price_source=price.source,
+            price_averaged_over_days=price.averaged_over_days,
+            licenses=licenses,
+            hourly_cost=round_money(hourly_cost),
+            monthly_cost=round_money(monthly_cost),
+            yearly_cost=round_money(monthly_cost * to_decimal(months_per_year())),
+            discounts=discounts,
+        )
+
+    def price_traffic(
+        self,
+        traffic: TrafficSpec,
+        session: Optional[DiscountSession] = None,
+    ) -> List[TransferLineItem]:
+        """
+        Price a traffic assumption, one line item per direction with traffic
+
+        Discount rules match its prices as the data_transfer service with the
+        direction (inter_az, inter_region, internet_egress) as SKU.
+
+        Raises:
+            PriceNotFoundError: If the provider has no rates for a direction
+        """
+        if session is None:
+            session = self.discount_session()
+        items = []
+        for direction, gb in traffic_volumes(traffic).items():
+            price, tiers, card = self.transfer_rates.quote(traffic, direction, gb)
+            gross_cost = to_decimal(price.price) * to_decimal(gb)
+            discounts, monthly_cost = apply_discount_rules(session, price, gb, gross_cost, gross_cost)
+            items.append(TransferLineItem(
+                name=traffic.name,
+                provider=traffic.provider,
+                region=traffic.region,
+                direction=direction,
+                gb_per_month=gb,
+                effective_unit_price=round(price.price, 6),
+                price_source=card,
+                tiers=[tier.copy(update={"monthly_cost": round_money(tier.monthly_cost)}) for tier in tiers],
+                hourly_cost=round_money(monthly_cost / to_decimal(hours_per_month())),
+                monthly_cost=round_money(monthly_cost),
+                yearly_cost=round_money(monthly_cost * to_decimal(months_per_year())),
+                discounts=discounts,
+            ))
+        return items
+
+    def price_database(
+        self,
+        database: DatabaseSpec,
+        session: Optional[DiscountSession] = None,
+    ) -> DatabaseLineItem:
+        """
+        Price a managed database: instance hours, storage, IOPS beyond the
+        storage's baseline and backup storage beyond the free allowance
+
+        Raises:
+            ValueError: If no storage type is set and the provider has no default
+            UnknownProfileError: If the database references a usage profile its tenant does not have
+            PriceNotFoundError: If a component cannot be priced
+            ProviderNotFoundError: If no pricing provider serves the database's cloud
+        """
+        if session is None:
+            session = self.discount_session()
+        profile = self.usage_profile(database.usage_profile)
+        hours = profile.scale(database.hours) if profile else database.hours
+        storage_type = database.storage_type or default_storage_type(database.provider)
+        deployment = deployment_of(database.high_availability)
+        attributes = {ATTR_ENGINE: database.engine, ATTR_DEPLOYMENT: deployment}
+
+        usage = [
+            ("instance", SERVICE_DATABASE, database.instance_class, database.count * hours),
+            ("storage", SERVICE_DATABASE_STORAGE, storage_type, database.count * database.storage_gb),
+            ("iops", SERVICE_DATABASE_IOPS, storage_type, database.count * billable_iops(
+                database.provider, storage_type, database.storage_gb, database.iops)),
+            ("backup", SERVICE_DATABASE_BACKUP, BACKUP_SKU, database.count * billable_backup_gb(
+                database.provider, database.storage_gb, database.backup_storage_gb)),
+        ]
+
+        components, discounts = [], []
+        monthly_cost = Decimal(0)
+        for component, service, sku, quantity in usage:
+            if quantity <= 0:
+                continue
+            price = self.registry.get_price(PriceQuery(
+                provider=database.provider,
+                region=database.region,
+                sku=sku,
+                service=service,
+                attributes=attributes if service != SERVICE_DATABASE_BACKUP else {},
+            ))
+            gross_cost = usage_amount(price, quantity)
+            applied, cost = apply_discount_rules(session, price, quantity, gross_cost, gross_cost)
+            discounts.extend(applied)
+            monthly_cost += cost
+            components.append(DatabaseComponent(
+                component=component,
+                sku=sku,
+                quantity=quantity,
+                unit=price.unit,
+                unit_price=price.price,
+                price_source=price.source,
+                monthly_cost=round_money(cost),
+            ))
+
+        return DatabaseLineItem(
+            name=database.name,
+            provider=database.provider,
+            region=database.region,
+            engine=database.engine,
+            instance_class=database.instance_class,
+            deployment=deployment,
+            count=database.count,
+            hours=hours,
+            usage_profile=profile.name if profile else None,
+            storage_type=storage_type,
+            storage_gb=database.storage_gb,
+            components=components,
+            hourly_cost=round_money(monthly_cost / to_decimal(hours_per_month())),
+            monthly_cost=round_money(monthly_cost),
+            yearly_cost=round_money(monthly_cost * to_decimal(months_per_year())),
+            discounts=discounts,
+        )
+
+    def price_object_storage(
+        self,
+        spec: ObjectStorageSpec,
+        session: Optional[DiscountSession] = None,
+    ) -> ObjectStorageLineItem:
+        """
+        Price the data and monthly operations of one object storage class:
+        storage over its volume tiers, write and read requests, lifecycle
+        transitions into the class (one request per object) and retrieval
+
+        Raises:
+            ValueError: If the provider has no such storage class
+            PriceNotFoundError: If a component with usage cannot be priced
+            ProviderNotFoundError: If no pricing provider serves the provider's cloud
+        """
+        if session is None:
+            session = self.discount_session()
+        sku = storage_class_sku(spec.provider, spec.storage_class)
+        distribution = [(bucket.size_kb, bucket.share) for bucket in spec.object_size_distribution]
+        billable_gb = billable_storage_gb(spec.provider, spec.storage_class, spec.storage_gb, distribution)
+
+        usage = [
+            ("storage", SERVICE_OBJECT_STORAGE, {}, billable_gb),
+            ("write_requests", SERVICE_OBJECT_REQUESTS, {ATTR_OPERATION: OPERATION_WRITE}, spec.write_requests / 1000),
+            ("read_requests", SERVICE_OBJECT_REQUESTS, {ATTR_OPERATION: OPERATION_READ}, spec.read_requests / 1000),
+            ("transitions", SERVICE_OBJECT_REQUESTS, {ATTR_OPERATION: OPERATION_TRANSITION},
+             object_count(spec.transition_in_gb, distribution) / 1000),
+            ("retrieval", SERVICE_OBJECT_RETRIEVAL, {}, spec.retrieval_gb),
+        ]
+
+        components, discounts = [], []
+        monthly_cost = Decimal(0)
+        for component, service, attributes, quantity in usage:
+            if quantity <= 0:
+                continue
+            price = self.registry.get_price(PriceQuery(
+                provider=spec.provider, region=spec.region, sku=sku, service=service, attributes=attributes,
+            ))
+            gross_cost = usage_amount(price, quantity)
+            applied, cost = apply_discount_rules(session, price, quantity, gross_cost, gross_cost)
+            discounts.extend(applied)
+            monthly_cost += cost
+            components.append(ObjectStorageComponent(
+                component=component,
+                quantity=round(quantity, 6),
+                unit=price.unit,
+                effective_unit_price=round(float(gross_cost) / quantity, 6),
+                price_source=price.source,
+                monthly_cost=round_money(cost),
+            ))
+
+        return ObjectStorageLineItem(
+            name=spec.name,
+            provider=spec.provider,
+            region=spec.region,
+            storage_class=spec.storage_class,
+            sku=sku,
+            storage_gb=spec.storage_gb,
+            billable_storage_gb=round(billable_gb, 6),
+            objects=round(object_count(spec.storage_gb, distribution)),
+            components=components,
+            hourly_cost=round_money(monthly_cost / to_decimal(hours_per_month())),
+            monthly_cost=round_money(monthly_cost),
+            yearly_cost=round_money(monthly_cost * to_decimal(months_per_year())),
+            discounts=discounts,
+        )
+
+    def price_serverless(
+        self,
+        spec: ServerlessSpec,
+        session: Optional[DiscountSession] = None,
+        free_tier: Optional[FreeTier] = None,
+    ) -> ServerlessLineItem:
+        """
+        Price a month of invocations: requests, memory and vCPU time, less
+        the part covered by the provider's free grant
+
+        Args:
+            spec: Serverless item to price
+            session: Discount rules shared with the other items of an estimate
+            free_tier: Free grants shared with the other serverless items of an estimate.
+                Defaults to a full month's grant.
+
+        Raises:
+            PriceNotFoundError: If a component with usage cannot be priced
+            ProviderNotFoundError: If no pricing provider serves the provider's cloud
+        """
+        if session is None:
+            session = self.discount_session()
+        if free_tier is None:
+            free_tier = FreeTier()
+        seconds = billed_seconds(spec.platform, spec.invocations, spec.duration_ms, spec.concurrency)
+        memory_gb = billed_memory_gb(spec.platform, spec.memory_mb)
+        vcpus = spec.vcpus or default_vcpus(spec.platform, spec.memory_mb)
+
+        usage = [
+            ("requests", SERVICE_SERVERLESS_REQUESTS, {}, spec.invocations / 1_000_000),
+            ("memory", SERVICE_SERVERLESS_MEMORY, {ATTR_ARCHITECTURE: spec.architecture}, seconds * memory_gb),
+        ]
+        if vcpus:
+            usage.append(("cpu", SERVICE_SERVERLESS_CPU, {}, seconds * vcpus))
+
+        components, discounts = [], []
+        monthly_cost = Decimal(0)
+        for component, service, attributes, quantity in usage:
+            if quantity <= 0:
+                continue
+            price = self.registry.get_price(PriceQuery(
+                provider=spec.provider, region=spec.region, sku=spec.platform, service=service, attributes=attributes,
+            ))
+            free = free_tier.take(spec.provider, component, quantity) if spec.free_tier else 0.0
+            gross_cost = usage_amount(price, quantity - free)
+            applied, cost = apply_discount_rules(session, price, quantity - free, gross_cost, gross_cost)
+            discounts.extend(applied)
+            monthly_cost += cost
+            components.append(ServerlessComponent(
+                component=component,
+                quantity=round(quantity, 6),
+                free_quantity=round(free, 6),
+                unit=price.unit,
+                unit_price=price.price,
+                price_source=price.source,
+                monthly_cost=round_money(cost),
+            ))
+
+        return ServerlessLineItem(
+            name=spec.name,
+            provider=spec.provider,
+            region=spec.region,
+            platform=spec.platform,
+            invocations=spec.invocations,
+            billed_seconds=round(seconds, 6),
+            memory_gb=round(memory_gb, 6),
+            vcpus=vcpus,
+            components=components,
+            hourly_cost=round_money(monthly_cost / to_decimal(hours_per_month())),
+            monthly_cost=round_money(monthly_cost),
+            yearly_cost=round_money(monthly_cost * to_decimal(months_per_year())),
+            discounts=discounts,
+        )
+
+    def price_disaster_recovery(
+        self,
+        request: EstimateRequest,
+        result: Optional[EstimateResult] = None,
+        session: Optional[DiscountSession] = None,
+    ) -> DisasterRecoveryCost:
+        """
+        Price what the request's DR strategy adds in its recovery regions:
+        snapshots, object storage copies, cross-region replication and the
+        instances and databases kept running there
+
+        Args:
+            request: Request with a disaster_recovery strategy
+            result: Estimate of the request; items it left unsupported are not
+                protected. None to check the strategy can be priced.
+            session: Discount rules shared with the other items of the estimate
+
+        Raises:
+            PriceNotFoundError: If a component cannot be priced in its recovery region
+            ProviderNotFoundError: If no pricing provider serves an item's cloud
+            ValueError: If an item's region has no recovery region
+        """
+        if session is None:
+            session = self.discount_session()
+        spec = request.disaster_recovery
+        strategy = DR_STRATEGIES[spec.strategy]
+        fraction = strategy.compute_fraction
+        if spec.standby_fraction is not None and spec.strategy == STRATEGY_WARM_STANDBY:
+            fraction = spec.standby_fraction
+        skipped = {item.field for item in result.unsupported} if result is not None else set()
+        components: List[DisasterRecoveryComponent] = []
+        unsupported: List[UnsupportedItem] = []
+        # (provider, primary region) -> GB/month replicated to another region
+        replicated: Dict[Tuple[str, str], float] = {}
+
+        def protect(field: str, item, price) -> None:
+            """Components of one item; with continue_on_error, items that cannot be priced are listed instead"""
+            try:
+                components.extend(price())
+            except (PriceNotFoundError, ProviderNotFoundError, ValueError) as e:
+                if not request.continue_on_error:
+                    raise
+                unsupported.append(UnsupportedItem(
+                    field=f"disaster_recovery.{field}",
+                    name=item.name,
+                    provider=item.provider,
+                    region=item.region,
+                    reason=str(e),
+                ))
+
+        def replicate(item, gb: float) -> None:
+            if spec.recovery_region(item.region) != item.region:
+                key = (item.provider, item.region)
+                replicated[key] = replicated.get(key, 0.0) + gb
+
+        def standby_compute(field: str, resource: ResourceSpec) -> List[DisasterRecoveryComponent]:
+            count = standby_count(resource.count, fraction)
+            if count == 0:
+                return []
+            region = spec.recovery_region(resource.region)
+            item = self.price_resource(resource.copy(update={"region": region, "count": count}), session)
+            return [DisasterRecoveryComponent(
+                component="standby_compute", item=field, name=resource.name, provider=resource.provider,
+                region=region, sku=item.instance_type, quantity=count, unit="instance", monthly_cost=item.monthly_cost,
+            )]
+
+        def database_copies(field: str, database: DatabaseSpec) -> List[DisasterRecoveryComponent]:
+            region = spec.recovery_region(database.region)
+            stored_gb = database.count * database.storage_gb
+            gb = snapshot_gb(stored_gb, spec.retention_days, spec.daily_change_rate)
+            copies = []
+            if gb > 0:
+                price = self.registry.get_price(PriceQuery(
+                    provider=database.provider, region=region, sku=BACKUP_SKU, service=SERVICE_DATABASE_BACKUP,
+                ))
+                gross_cost = usage_amount(price, gb)
+                _, monthly_cost = apply_discount_rules(session, price, gb, gross_cost, gross_cost)
+                copies.append(DisasterRecoveryComponent(
+                    component="snapshots", item=field, name=database.name, provider=database.provider,
+                    region=region, sku=BACKUP_SKU, quantity=round(gb, 6), unit=price.unit,
+                    monthly_cost=round_money(monthly_cost),
+                ))
+            if strategy.database_replicas:
+                # Replicas run single-zone unless the strategy serves traffic from both regions
+                item = self.price_database(database.copy(update={
+                    "region": region,
+                    "high_availability": database.high_availability and spec.strategy == STRATEGY_ACTIVE_ACTIVE,
+                    "backup_storage_gb": 0.0,
+                }), session)
+                copies.append(DisasterRecoveryComponent(
+                    component="standby_database", item=field, name=database.name, provider=database.provider,
+                    region=region, sku=database.instance_class, quantity=database.count, unit="database",
+                    monthly_cost=item.monthly_cost,
+                ))
+            replicate(database, replication_gb(stored_gb, spec.daily_change_rate))
+            return copies
+
+        def object_storage_copy(field: str, storage: ObjectStorageSpec) -> List[DisasterRecoveryComponent]:
+            region = spec.recovery_region(storage.region)
+            item = self.price_object_storage(storage.copy(update={
+                "region": region,
+                "storage_class": strategy.object_storage_class or storage.storage_class,
+                "write_requests": 0,
+                "read_requests": 0,
+                "retrieval_gb": 0.0,
+                "transition_in_gb": 0.0,
+            }), session)
+            replicate(storage, replication_gb(storage.storage_gb, spec.daily_change_rate))
+            return [DisasterRecoveryComponent(
+                component="object_storage", item=field, name=storage.name, provider=storage.provider,
+                region=region, sku=item.sku, quantity=storage.storage_gb, unit="GB", monthly_cost=item.monthly_cost,
+            )]
+
+        for i, resource in enumerate(request.resources):
+            if f"resources[{i}]" not in skipped:
+                protect(f"resources[{i}]", resource, lambda: standby_compute(f"resources[{i}]", resource))
+        for i, database in enumerate(request.databases):
+            if f"databases[{i}]" not in skipped:
+                protect(f"databases[{i}]", database, lambda: database_copies(f"databases[{i}]", database))
+        for i, storage in enumerate(request.object_storage):
+            if f"object_storage[{i}]" not in skipped:
+                protect(f"object_storage[{i}]", storage, lambda: object_storage_copy(f"object_storage[{i}]", storage))
+
+        for (provider, region), gb in replicated.items():
+            traffic = TrafficSpec(name="DR replication", provider=provider, region=region, inter_region_gb=gb)
+            protect("replication", traffic, lambda: [
+                DisasterRecoveryComponent(
+                    component="replication", item="replication", name=traffic.name, provider=provider,
+                    region=region, sku=item.direction, quantity=round(gb, 6), unit="GB",
+                    monthly_cost=item.monthly_cost,
+                )
+                for item in self.price_traffic(traffic, session)
+            ])
+
+        monthly_cost = add_amounts(component.monthly_cost for component in components)
+        return DisasterRecoveryCost(
+            strategy=spec.strategy,
+            description=strategy.description,
+            recovery_time=strategy.recovery_time,
+            standby_fraction=fraction,
+            components=components,
+            snapshot_storage_gb=round(sum(c.quantity for c in components if c.component == "snapshots"), 6),
+            replication_gb=round(sum(replicated.values()), 6),
+            monthly_cost=round_money(monthly_cost),
+            yearly_cost=round_money(monthly_cost * to_decimal(months_per_year())),
+            total_monthly_cost=round_money(
+                monthly_cost + to_decimal(result.monthly_cost if result is not None else 0.0)
+            ),
+            unsupported=unsupported,
+        )
+
+
+def apply_discount_rules(
+    session: Optional[DiscountSession],
+    price: Price,
+    quantity: float,
+    gross_cost: Decimal,
+    cost: Decimal,
+) -> Tuple[List[AppliedDiscount], Decimal]:
+    """
+    Apply discount rules to a monthly cost
+
+    Args:
+        session: Discount rules of the estimate, None for no rules
+        price: Unit price the cost was computed from
+        quantity: Monthly usage in the price's unit
+        gross_cost: Monthly cost before any discount
+        cost: Monthly cost after provider usage discounts
+
+    Returns:
+        (applied discounts, discounted cost)
+    """
+    if session is None:
+        return [], to_decimal(cost)
+
+    granted, cost = session.apply(price, quantity, cost)
+    discounts = [
+        AppliedDiscount(
+            name=d.rule.name,
+            description=d.rule.description or None,
+            rate=round(float(d.amount / gross_cost), 6) if gross_cost > 0 else 0.0,
+            monthly_amount=round_money(d.amount),
+            rule_id=d.rule.id,
+        )
+        for d in granted
+    ]
+    return discounts, cost
+
+
+def coverage_of(priced: int, unsupported: int) -> Coverage:
+    """Coverage of an estimate that priced some items and could not price others"""
+    items = priced + unsupported
+    percent = round(priced / items * 100, 2) if items else 100.0
+    return Coverage(items=items, priced=priced, unsupported=unsupported, percent=percent)
+
+
+def summarize_applied_rules(
+    line_items: List[Union[LineItem, TransferLineItem, DatabaseLineItem, ObjectStorageLineItem, ServerlessLineItem]],
+) -> List[AppliedRule]:
+    """Total discount of each rule over the line items"""
+    rules: Dict[str, AppliedRule] = {}
+    for item in line_items:
+        for discount in item.discounts:
+            if discount.rule_id is None:
+                continue
+            applied = rules.setdefault(
+                discount.rule_id, AppliedRule(rule_id=discount.rule_id, name=discount.name, monthly_amount=0.0)
+            )
+            applied.monthly_amount = round_money(applied.monthly_amount + discount.monthly_amount)
+    return list(rules.values())
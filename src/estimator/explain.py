@@ -0,0 +1,288 @@
+"""
+Estimate explanations
+
+Derives, for every line item of an estimate, the SKU and unit price it
+was priced from and the arithmetic from the unit prices to the monthly
+cost: units and hours billed, gross cost, each discount in the order it
+was applied, and the discounted cost. Explanations are computed from the
+estimate itself, so they describe exactly the amounts it holds.
+"""
+
+from datetime import date
+from typing import List, Optional, Sequence, Tuple
+
+from ..money import round_money
+from ..units import months_per_year
+from .licenses import UNIT_INSTANCE_HOUR, UNIT_INSTANCE_MONTH, UNIT_VCPU_HOUR
+from .models import (
+    AppliedDiscount,
+    DatabaseLineItem,
+    EstimateResult,
+    ExplainStep,
+    ItemExplanation,
+    LineItem,
+    ObjectStorageLineItem,
+    ServerlessLineItem,
+    TransferLineItem,
+)
+
+
+def _usd(amount: float) -> str:
+    return f"${amount:,.4f}"
+
+
+def _price(price: float) -> str:
+    """Unit price with the precision providers publish, e.g. $0.0000166667"""
+    return f"${price:.10g}"
+
+
+def _quantity(quantity: float) -> str:
+    return f"{quantity:,.6g}"
+
+
+def _discount_steps(gross: float, discounts: List[AppliedDiscount]) -> Tuple[List[ExplainStep], float]:
+    """Steps of discounts taken off a gross monthly cost in turn, and the cost left"""
+    steps = []
+    cost = gross
+    for discount in discounts:
+        cost -= discount.monthly_amount
+        steps.append(ExplainStep(
+            description=f"Discount {discount.name} ({discount.rate:.2%})",
+            expression=f"{_usd(cost + discount.monthly_amount)} - {_usd(discount.monthly_amount)}",
+            amount=round_money(cost),
+        ))
+    return steps, cost
+
+
+def _total_steps(item, gross: float, added: Sequence[ExplainStep] = ()) -> List[ExplainStep]:
+    """Discount, monthly and yearly steps of a line item with the gross monthly cost and costs added undiscounted"""
+    steps, _ = _discount_steps(gross, item.discounts)
+    steps.extend(added)
+    expression = "after discounts"
+    if added:
+        expression += ", with " + " + ".join(_usd(step.amount) for step in added)
+    steps.append(ExplainStep(description="Monthly cost", expression=expression, amount=item.monthly_cost))
+    steps.append(ExplainStep(
+        description="Yearly cost",
+        expression=f"{_usd(item.monthly_cost)} × {round(months_per_year(), 4):g} months",
+        amount=item.yearly_cost,
+    ))
+    return steps
+
+
+def explain_line_item(item: LineItem, index: int, catalog_version: Optional[date] = None) -> ItemExplanation:
+    """Derivation of the cost of instances and their attached GPUs"""
+    source = f"{item.provider}/{item.region} price from {item.price_source or 'unknown source'}"
+    if item.requested_instance_type:
+        source += f", closest match of {item.requested_instance_type} (confidence {item.match_confidence:.2f})"
+    if item.price_averaged_over_days:
+        source += f", averaged over {item.price_averaged_over_days:g} days"
+    steps = [ExplainStep(
+        description=f"Unit price of {item.instance_type} ({item.pricing_model})",
+        expression=source,
+        amount=item.unit_price_hourly,
+    )]
+
+    hourly = item.unit_price_hourly * item.count
+    terms = [f"{item.count} × {_price(item.unit_price_hourly)}"]
+    if item.accelerator_unit_price_hourly is not None:
+        gpus = item.count * item.accelerator_count
+        steps.append(ExplainStep(
+            description=f"Unit price of {item.accelerator_type} GPUs",
+            expression=f"{item.accelerator_count} per instance",
+            amount=item.accelerator_unit_price_hourly,
+        ))
+        hourly += item.accelerator_unit_price_hourly * gpus
+        terms.append(f"{gpus} × {_price(item.accelerator_unit_price_hourly)}")
+    steps.append(ExplainStep(description="Hourly cost", expression=" + ".join(terms), amount=round_money(hourly)))
+
+    hours = item.hours
+    if item.billed_hours is not None:
+        hours = item.billed_hours
+        steps.append(ExplainStep(
+            description="Billed hours",
+            expression=f"{_quantity(item.hours)} hours, each run rounded up to {item.provider}'s billing granularity",
+            amount=hours,
+        ))
+    gross = hourly * hours
+    steps.append(ExplainStep(
+        description="Gross monthly cost",
+        expression=f"{_usd(hourly)}/hour × {_quantity(hours)} hours",
+        amount=round_money(gross),
+    ))
+    return ItemExplanation(
+        item=f"line_items[{index}]",
+        name=item.name,
+        provider=item.provider,
+        region=item.region,
+        sku=item.instance_type,
+        requested_sku=item.requested_instance_type,
+        unit_price=item.unit_price_hourly,
+        unit="hour",
+        price_source=item.price_source,
+        catalog_version=catalog_version,
+        discounts=item.discounts,
+        steps=steps + _total_steps(item, gross, _license_steps(item)),
+        monthly_cost=item.monthly_cost,
+    )
+
+
+def _license_steps(item: LineItem) -> List[ExplainStep]:
+    """Steps of the licenses billed with instances, quantity × unit price each"""
+    units = {UNIT_VCPU_HOUR: "vCPU-hours", UNIT_INSTANCE_HOUR: "instance-hours", UNIT_INSTANCE_MONTH: "instance-months"}
+    return [
+        ExplainStep(
+            description=f"License {license.name} ({license.rate_card})",
+            expression=f"{_quantity(license.quantity)} {units[license.unit]} × {_price(license.unit_price)}",
+            amount=license.monthly_cost,
+        )
+        for license in item.licenses
+    ]
+
+
+def explain_transfer_item(
+    item: TransferLineItem, index: int, catalog_version: Optional[date] = None
+) -> ItemExplanation:
+    """Derivation of the cost of a traffic direction from its volume tiers"""
+    steps = [
+        ExplainStep(
+            description=f"Tier from {_quantity(tier.from_gb)} GB"
+            + (f" to {_quantity(tier.to_gb)} GB" if tier.to_gb is not None else ""),
+            expression=f"{_quantity(tier.gb)} GB × {_price(tier.unit_price)}",
+            amount=tier.monthly_cost,
+        )
+        for tier in item.tiers
+    ]
+    gross = sum(tier.monthly_cost for tier in item.tiers)
+    steps.append(ExplainStep(
+        description="Gross monthly cost",
+        expression=" + ".join(_usd(tier.monthly_cost) for tier in item.tiers) or _usd(0),
+        amount=round_money(gross),
+    ))
+    return ItemExplanation(
+        item=f"transfer_items[{index}]",
+        name=item.name,
+        provider=item.provider,
+        region=item.region,
+        sku=item.direction,
+        unit_price=item.effective_unit_price,
+        unit="GB",
+        price_source=item.price_source,
+        catalog_version=catalog_version,
+        discounts=item.discounts,
+        steps=steps + _total_steps(item, gross),
+        monthly_cost=item.monthly_cost,
+    )
+
+
+def _component_steps(components) -> Tuple[List[ExplainStep], float]:
+    """Gross cost steps of billed components, (quantity - free quantity) × unit price each"""
+    steps = []
+    gross = 0.0
+    for component in components:
+        price = getattr(component, "unit_price", None)
+        if price is None:
+            price = component.effective_unit_price
+        free = getattr(component, "free_quantity", 0.0)
+        billed = max(component.quantity - free, 0.0)
+        quantity = _quantity(component.quantity)
+        if free:
+            quantity = f"({quantity} - {_quantity(free)} free)"
+        cost = billed * price
+        gross += cost
+        label = getattr(component, "sku", None)
+        steps.append(ExplainStep(
+            description=f"{component.component}" + (f" ({label})" if label else ""),
+            expression=f"{quantity} {component.unit} × {_price(price)}",
+            amount=round_money(cost),
+        ))
+    steps.append(ExplainStep(
+        description="Gross monthly cost",
+        expression=" + ".join(_usd(step.amount) for step in steps) or _usd(0),
+        amount=round_money(gross),
+    ))
+    return steps, gross
+
+
+def explain_database_item(
+    item: DatabaseLineItem, index: int, catalog_version: Optional[date] = None
+) -> ItemExplanation:
+    """Derivation of the cost of managed databases from their instance, storage and backup prices"""
+    steps, gross = _component_steps(item.components)
+    instance = next((c for c in item.components if c.component == "instance"), None)
+    return ItemExplanation(
+        item=f"database_items[{index}]",
+        name=item.name,
+        provider=item.provider,
+        region=item.region,
+        sku=item.instance_class,
+        unit_price=instance.unit_price if instance else None,
+        unit=instance.unit if instance else None,
+        price_source=instance.price_source if instance else None,
+        catalog_version=catalog_version,
+        discounts=item.discounts,
+        steps=steps + _total_steps(item, gross),
+        monthly_cost=item.monthly_cost,
+    )
+
+
+def explain_object_storage_item(
+    item: ObjectStorageLineItem, index: int, catalog_version: Optional[date] = None
+) -> ItemExplanation:
+    """Derivation of the cost of an object storage class from its storage and request prices"""
+    steps, gross = _component_steps(item.components)
+    storage = next((c for c in item.components if c.component == "storage"), None)
+    return ItemExplanation(
+        item=f"object_storage_items[{index}]",
+        name=item.name,
+        provider=item.provider,
+        region=item.region,
+        sku=item.sku,
+        unit_price=storage.effective_unit_price if storage else None,
+        unit=storage.unit if storage else None,
+        price_source=storage.price_source if storage else None,
+        catalog_version=catalog_version,
+        discounts=item.discounts,
+        steps=steps + _total_steps(item, gross),
+        monthly_cost=item.monthly_cost,
+    )
+
+
+def explain_serverless_item(
+    item: ServerlessLineItem, index: int, catalog_version: Optional[date] = None
+) -> ItemExplanation:
+    """Derivation of the cost of serverless invocations from their request, memory and CPU prices"""
+    steps, gross = _component_steps(item.components)
+    source = next((c.price_source for c in item.components if c.price_source), None)
+    return ItemExplanation(
+        item=f"serverless_items[{index}]",
+        name=item.name,
+        provider=item.provider,
+        region=item.region,
+        sku=item.platform,
+        price_source=source,
+        catalog_version=catalog_version,
+        discounts=item.discounts,
+        steps=steps + _total_steps(item, gross),
+        monthly_cost=item.monthly_cost,
+    )
+
+
+def explain_estimate(result: EstimateResult, catalog_version: Optional[date] = None) -> List[ItemExplanation]:
+    """
+    Explanations of every line item of an estimate, in USD
+
+    Args:
+        result: Estimate priced in USD
+        catalog_version: Catalog snapshot the estimate was priced from
+    """
+    explanations = [explain_line_item(item, i, catalog_version) for i, item in enumerate(result.line_items)]
+    explanations += [explain_transfer_item(item, i, catalog_version) for i, item in enumerate(result.transfer_items)]
+    explanations += [explain_database_item(item, i, catalog_version) for i, item in enumerate(result.database_items)]
+    explanations += [
+        explain_object_storage_item(item, i, catalog_version) for i, item in enumerate(result.object_storage_items)
+    ]
+    explanations += [
+        explain_serverless_item(item, i, catalog_version) for i, item in enumerate(result.serverless_items)
+    ]
+    return explanations
@@ -0,0 +1,152 @@
+"""
+Software license cost model
+
+Licenses billed on top of the instances they run on, often as much as the
+infrastructure itself:
+
+- vcpu_hour: per vCPU-hour of each instance, e.g. Windows Server or RHEL
+  images; SQL Server bills at least min_vcpus (4 cores) per instance
+- instance_hour: per instance-hour, e.g. the hourly fee of a marketplace
+  image
+- instance_month: per instance and month, e.g. a monitoring or security
+  agent licensed per node
+
+Rates are list prices in USD by license name; a "<provider>/<name>" entry
+takes the place of the shared one on that provider. Resources name the
+licenses they run in their licenses field. Discount rules match cloud
+prices and do not apply to licenses.
+"""
+
+import json
+import logging
+from typing import Any, Dict, List, Optional, Tuple
+
+from pydantic import BaseModel, Field, validator
+
+from ..money import round_money
+from ..pricing import PriceNotFoundError, get_instance_shape
+from ..units import hours_per_month
+from .models import LicenseCost
+
+logger = logging.getLogger(__name__)
+
+UNIT_VCPU_HOUR = "vcpu_hour"
+UNIT_INSTANCE_HOUR = "instance_hour"
+UNIT_INSTANCE_MONTH = "instance_month"
+LICENSE_UNITS = (UNIT_VCPU_HOUR, UNIT_INSTANCE_HOUR, UNIT_INSTANCE_MONTH)
+
+# License name or <provider>/<name> -> rate
+DEFAULT_LICENSE_RATES: Dict[str, Dict[str, Any]] = {
+    "rhel": {"unit": UNIT_VCPU_HOUR, "price": 0.0144, "description": "Red Hat Enterprise Linux"},
+    "sles": {"unit": UNIT_VCPU_HOUR, "price": 0.0125, "description": "SUSE Linux Enterprise Server"},
+    "windows-server": {"unit": UNIT_VCPU_HOUR, "price": 0.046, "description": "Windows Server"},
+    "sql-server-standard": {
+        "unit": UNIT_VCPU_HOUR, "price": 0.1645, "min_vcpus": 4, "description": "SQL Server Standard",
+    },
+    "sql-server-enterprise": {
+        "unit": UNIT_VCPU_HOUR, "price": 0.399, "min_vcpus": 4, "description": "SQL Server Enterprise",
+    },
+    "aws/rhel": {"unit": UNIT_VCPU_HOUR, "price": 0.012, "description": "Red Hat Enterprise Linux"},
+    "datadog-infra": {"unit": UNIT_INSTANCE_MONTH, "price": 15.0, "description": "Datadog infrastructure agent"},
+}
+
+
+class LicenseRate(BaseModel):
+    """Price of a software license in the rate card"""
+
+    unit: str = Field(..., description="vcpu_hour, instance_hour or instance_month")
+    price: float = Field(..., ge=0, description="USD per unit")
+    min_vcpus: float = Field(0, ge=0, description="Fewest vCPUs billed per instance, for vcpu_hour licenses")
+    description: str = ""
+
+    @validator("unit")
+    def validate_unit(cls, v):
+        if v not in LICENSE_UNITS:
+            raise ValueError(f"Unknown license unit '{v}', expected one of {', '.join(LICENSE_UNITS)}")
+        return v
+
+
+class LicenseRates:
+    """Software license rate card"""
+
+    def __init__(self, rates: Optional[Dict[str, Dict[str, Any]]] = None):
+        """
+        Initialize license rates
+
+        Args:
+            rates: Mapping license name or <provider>/<name> -> rate, as
+                DEFAULT_LICENSE_RATES. Uses the built-in rates if not provided.
+
+        Raises:
+            ValueError: If a rate has an unknown unit or a negative price
+        """
+        rates = rates if rates is not None else DEFAULT_LICENSE_RATES
+        self.rates = {name.strip().lower(): LicenseRate(**rate) for name, rate in rates.items()}
+
+    @classmethod
+    def from_file(cls, path: str) -> "LicenseRates":
+        """Load rates from a JSON file with the same layout as DEFAULT_LICENSE_RATES"""
+        with open(path, "r", encoding="utf-8") as f:
+            rates = json.load(f)
+
+        if not isinstance(rates, dict):
+            raise ValueError(f"License rates {path} must be a JSON object")
+
+        logger.info(f"License rates loaded from {path}")
+        return cls(rates)
+
+    def rate(self, provider: str, name: str) -> Tuple[LicenseRate, str]:
+        """
+        Rate of a license as (rate, rate card entry), the provider's own before the shared one
+
+        Raises:
+            PriceNotFoundError: If the rate card has no such license
+        """
+        for key in (f"{provider}/{name}", name):
+            rate = self.rates.get(key)
+            if rate is not None:
+                return rate, key
+        raise PriceNotFoundError(f"No license rate for '{name}' on {provider}")
+
+    def price(
+        self, provider: str, instance_type: str, names: List[str], count: int, hours: float
+    ) -> Tuple[List[LicenseCost], float]:
+        """
+        Licenses of instances running the given hours per month
+
+        Returns:
+            (cost of each license, hourly cost of all of them)
+
+        Raises:
+            PriceNotFoundError: If the rate card has no such license
+            ValueError: If a license is billed per vCPU and the instance type is unknown
+        """
+        costs, hourly = [], 0.0
+        for name in names:
+            rate, key = self.rate(provider, name)
+            if rate.unit == UNIT_INSTANCE_MONTH:
+                quantity = float(count)
+                unit_hourly = rate.price * count / hours_per_month()
+                monthly = rate.price * quantity
+            else:
+                per_instance = 1.0
+                if rate.unit == UNIT_VCPU_HOUR:
+                    try:
+                        vcpus = get_instance_shape(provider, instance_type).vcpus
+                    except KeyError:
+                        raise ValueError(f"{name} is billed per vCPU and {provider} {instance_type} is unknown")
+                    per_instance = max(vcpus, rate.min_vcpus)
+                quantity = per_instance * count * hours
+                unit_hourly = rate.price * per_instance * count
+                monthly = unit_hourly * hours
+            hourly += unit_hourly
+            costs.append(LicenseCost(
+                name=name,
+                description=rate.description,
+                unit=rate.unit,
+                quantity=round(quantity, 6),
+                unit_price=rate.price,
+                rate_card=key,
+                monthly_cost=round_money(monthly),
+            ))
+        return costs, hourly
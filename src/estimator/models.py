@@ -0,0 +1,801 @@
+"""
+Data models for cost estimation
+"""
+
+from datetime import date
+from typing import Dict, List, Optional
+from pydantic import BaseModel, Field, root_validator, validator
+
+from ..carbon.models import CarbonEstimate
+from ..pricing import (
+    PRICING_ON_DEMAND,
+    COMMITMENT_MODELS,
+    TERMS,
+    PAYMENT_OPTIONS,
+    NO_UPFRONT,
+    ARCH_X86_64,
+    ARCH_ARM64,
+    PLATFORM_LAMBDA,
+    SERVERLESS_PLATFORMS,
+    default_platform,
+    normalize_accelerator,
+    normalize_engine,
+    normalize_platform,
+    normalize_pricing_model,
+    normalize_storage_class,
+)
+from ..units import TimeBasis, current_time_basis, hours_per_month, size_field
+from .disaster_recovery import normalize_strategy
+from .serverless import SERVERLESS_BILLING
+
+# Status of the items of batch estimates and estimates with continue_on_error
+STATUS_PRICED = "priced"
+STATUS_UNSUPPORTED = "unsupported"
+
+
+class ResourceSpec(BaseModel):
+    """Single resource to be priced"""
+
+    name: Optional[str] = Field(None, description="Optional label for the line item")
+    provider: str = Field(default="aws", min_length=1, description="Cloud provider (aws, gcp, azure)")
+    instance_type: str = Field(..., min_length=1, description="Instance/machine type, e.g. m5.large")
+    region: str = Field(..., min_length=1, description="Provider region, e.g. us-east-1")
+    count: int = Field(default=1, ge=1, description="Number of identical instances")
+    hours: float = Field(
+        default_factory=hours_per_month,
+        gt=0,
+        le=744,
+        description="Running hours per month for each instance"
+    )
+    pricing_model: str = Field(
+        default=PRICING_ON_DEMAND,
+        description="on_demand or spot (spot prices are averaged over recent price history)"
+    )
+    accelerator_type: Optional[str] = Field(
+        None, description="GPU attached to each instance (GCP N1), e.g. nvidia-tesla-t4"
+    )
+    accelerator_count: int = Field(default=0, ge=0, description="GPUs attached to each instance, default 1 with a type")
+    runs: Optional[int] = Field(
+        None,
+        ge=1,
+        description="Separate runs the hours are split into, e.g. CI jobs; each is billed by the cloud's granularity",
+    )
+    usage_profile: Optional[str] = Field(
+        None, description="Usage profile the hours are scaled by, e.g. business-hours; its runs apply unless set"
+    )
+    licenses: List[str] = Field(
+        default_factory=list,
+        description="Software licenses of the license rate card billed with each instance, e.g. rhel or windows-server",
+    )
+
+    @validator("provider")
+    def normalize_provider(cls, v):
+        return v.strip().lower()
+
+    @validator("usage_profile")
+    def normalize_usage_profile(cls, v):
+        return (v.strip().lower() or None) if v is not None else None
+
+    @validator("licenses")
+    def normalize_licenses(cls, v):
+        return list(dict.fromkeys(name.strip().lower() for name in v if name.strip()))
+
+    @validator("pricing_model")
+    def validate_pricing_model(cls, v):
+        return normalize_pricing_model(v)
+
+    @validator("accelerator_type")
+    def validate_accelerator_type(cls, v):
+        return normalize_accelerator(v) if v is not None else None
+
+    @root_validator(skip_on_failure=True)
+    def default_accelerator_count(cls, values):
+        if values.get("accelerator_type") is None:
+            if values.get("accelerator_count"):
+                raise ValueError("accelerator_count requires an accelerator_type")
+        elif not values.get("accelerator_count"):
+            values["accelerator_count"] = 1
+        return values
+
+
+# Longest period an estimate may cover
+MAX_PERIOD_DAYS = 3 * 366
+
+
+class BillingPeriod(BaseModel):
+    """Dates an estimate is priced for, e.g. 2026-11-15 to 2026-12-20"""
+
+    start: date = Field(..., description="First day covered")
+    end: date = Field(..., description="Last day covered, included")
+
+    @root_validator(skip_on_failure=True)
+    def validate_range(cls, values):
+        days = (values["end"] - values["start"]).days + 1
+        if days < 1:
+            raise ValueError("end must not be before start")
+        if days > MAX_PERIOD_DAYS:
+            raise ValueError(f"A period covers at most {MAX_PERIOD_DAYS} days")
+        return values
+
+
+class CommitmentOption(BaseModel):
+    """Commitment purchase option to compare against on-demand"""
+
+    type: str = Field(..., description="reserved or savings_plan")
+    term: str = Field(default="1yr", description="1yr or 3yr")
+    payment_option: str = Field(default=NO_UPFRONT, description="no_upfront, partial_upfront or all_upfront")
+
+    @validator("type")
+    def validate_type(cls, v):
+        if v not in COMMITMENT_MODELS:
+            raise ValueError(f"type must be one of: {', '.join(COMMITMENT_MODELS)}")
+        return v
+
+    @validator("term")
+    def validate_term(cls, v):
+        if v not in TERMS:
+            raise ValueError(f"term must be one of: {', '.join(TERMS)}")
+        return v
+
+    @validator("payment_option")
+    def validate_payment_option(cls, v):
+        if v not in PAYMENT_OPTIONS:
+            raise ValueError(f"payment_option must be one of: {', '.join(PAYMENT_OPTIONS)}")
+        return v
+
+
+class TrafficSpec(BaseModel):
+    """Monthly data transfer of a workload in one region"""
+
+    name: Optional[str] = Field(None, description="Optional label for the line items")
+    provider: str = Field(default="aws", min_length=1, description="Cloud provider (aws, gcp, azure)")
+    region: str = Field(..., min_length=1, description="Region the data is sent from")
+    inter_az_gb: float = Field(0.0, ge=0, description="GB/month exchanged between availability zones of the region")
+    inter_region_gb: float = Field(0.0, ge=0, description="GB/month sent to other regions of the provider")
+    internet_egress_gb: float = Field(0.0, ge=0, description="GB/month sent to the internet")
+
+    @validator("inter_az_gb", "inter_region_gb", "internet_egress_gb", pre=True)
+    def parse_sizes(cls, v):
+        return size_field(v)
+
+
+class DatabaseSpec(BaseModel):
+    """Managed relational database (RDS, Cloud SQL, Azure Database) to be priced"""
+
+    name: Optional[str] = Field(None, description="Optional label for the line item")
+    provider: str = Field(default="aws", min_length=1, description="Cloud provider (aws, gcp, azure)")
+    region: str = Field(..., min_length=1, description="Provider region")
+    engine: str = Field(default="mysql", description="mysql, postgres, mariadb or sqlserver")
+    instance_class: str = Field(
+        ..., min_length=1, description="Instance class, e.g. db.m5.large, db-custom-2-7680 or Standard_D2ds_v4"
+    )
+    count: int = Field(default=1, ge=1, description="Number of identical databases")
+    hours: float = Field(
+        default_factory=hours_per_month, gt=0, le=744, description="Running hours per month of each database"
+    )
+    storage_gb: float = Field(default=20.0, ge=0, description="Provisioned storage per database")
+    storage_type: Optional[str] = Field(
+        None, description="Storage type, e.g. gp3, io1, ssd or premium_ssd; the provider's default if not set"
+    )
+    iops: Optional[int] = Field(
+        None, ge=0, description="Provisioned IOPS; only IOPS beyond the storage's baseline are billed"
+    )
+    high_availability: bool = Field(
+        False, description="Standby in another zone (multi-AZ, regional Cloud SQL, zone-redundant HA)"
+    )
+    backup_storage_gb: float = Field(
+        default=0.0, ge=0, description="Backup storage per database; the provider's free allowance is deducted"
+    )
+    usage_profile: Optional[str] = Field(None, description="Usage profile the instance hours are scaled by")
+
+    @validator("provider")
+    def normalize_provider(cls, v):
+        return v.strip().lower()
+
+    @validator("storage_gb", "backup_storage_gb", pre=True)
+    def parse_sizes(cls, v):
+        return size_field(v)
+
+    @validator("usage_profile")
+    def normalize_usage_profile(cls, v):
+        return (v.strip().lower() or None) if v is not None else None
+
+    @validator("engine")
+    def validate_engine(cls, v):
+        return normalize_engine(v)
+
+
+class ObjectSizeBucket(BaseModel):
+    """Share of a bucket's objects of one size"""
+
+    size_kb: float = Field(..., gt=0, description="Object size (KB)")
+    share: float = Field(..., gt=0, le=1, description="Fraction of the objects of this size")
+
+
+class ObjectStorageSpec(BaseModel):
+    """Data kept in one object storage class (S3, Cloud Storage, Azure Blob) and its monthly operations"""
+
+    name: Optional[str] = Field(None, description="Optional label for the line item")
+    provider: str = Field(default="aws", min_length=1, description="Cloud provider (aws, gcp, azure)")
+    region: str = Field(..., min_length=1, description="Provider region")
+    storage_class: str = Field(default="standard", description="standard, infrequent or archive")
+    storage_gb: float = Field(default=0.0, ge=0, description="Data stored in the class")
+    write_requests: int = Field(default=0, ge=0, description="PUT, COPY, POST and LIST requests per month")
+    read_requests: int = Field(default=0, ge=0, description="GET and other requests per month")
+    retrieval_gb: float = Field(default=0.0, ge=0, description="GB/month read back from the class")
+    transition_in_gb: float = Field(
+        default=0.0, ge=0, description="GB/month moved into the class by lifecycle rules"
+    )
+    object_size_distribution: List[ObjectSizeBucket] = Field(
+        default_factory=list,
+        description="Object sizes by share of objects; objects are assumed to be 1 MB if not set"
+    )
+
+    @validator("provider")
+    def normalize_provider(cls, v):
+        return v.strip().lower()
+
+    @validator("storage_gb", "retrieval_gb", "transition_in_gb", pre=True)
+    def parse_sizes(cls, v):
+        return size_field(v)
+
+    @validator("storage_class")
+    def validate_storage_class(cls, v):
+        return normalize_storage_class(v)
+
+    @validator("object_size_distribution")
+    def validate_distribution(cls, v):
+        if v and abs(sum(bucket.share for bucket in v) - 1.0) > 1e-3:
+            raise ValueError("object_size_distribution shares must add up to 1")
+        return v
+
+
+class ServerlessSpec(BaseModel):
+    """Functions or a serverless container service (Lambda, Cloud Run, Azure Functions) and its monthly load"""
+
+    name: Optional[str] = Field(None, description="Optional label for the line item")
+    provider: str = Field(default="aws", min_length=1, description="Cloud provider (aws, gcp, azure)")
+    region: str = Field(..., min_length=1, description="Provider region")
+    platform: Optional[str] = Field(
+        None,
+        description="lambda, cloud_run, cloud_functions or azure_functions, default the provider's functions"
+    )
+    invocations: int = Field(default=0, ge=0, description="Invocations (requests) per month")
+    duration_ms: float = Field(default=100.0, gt=0, description="Average duration of an invocation (ms)")
+    memory_mb: int = Field(default=128, ge=128, description="Memory allocated to an instance (MB)")
+    vcpus: Optional[float] = Field(None, gt=0, description="vCPUs of an instance, Cloud Run platforms only")
+    concurrency: int = Field(
+        default=1, ge=1, description="Requests an instance serves at once, Cloud Run platforms only"
+    )
+    architecture: str = Field(default=ARCH_X86_64, description="x86_64 or arm64 (Lambda only)")
+    free_tier: bool = Field(
+        default=True, description="Deduct the provider's monthly free grant, shared by the estimate's items"
+    )
+
+    @validator("provider")
+    def normalize_provider(cls, v):
+        return v.strip().lower()
+
+    @validator("memory_mb", pre=True)
+    def parse_memory(cls, v):
+        return size_field(v, "MB")
+
+    @validator("platform")
+    def validate_platform(cls, v):
+        return normalize_platform(v) if v is not None else None
+
+    @validator("architecture")
+    def validate_architecture(cls, v):
+        v = v.strip().lower()
+        if v not in (ARCH_X86_64, ARCH_ARM64):
+            raise ValueError(f"architecture must be {ARCH_X86_64} or {ARCH_ARM64}")
+        return v
+
+    @root_validator(skip_on_failure=True)
+    def validate_platform_options(cls, values):
+        provider, platform = values["provider"], values.get("platform")
+        if platform is None:
+            values["platform"] = platform = default_platform(provider)
+        elif platform not in SERVERLESS_PLATFORMS.get(provider, ()):
+            raise ValueError(f"platform {platform} is not offered by {provider}")
+
+        billing = SERVERLESS_BILLING[platform]
+        if values["memory_mb"] > billing.max_memory_mb:
+            raise ValueError(f"memory_mb must be at most {billing.max_memory_mb} on {platform}")
+        if values.get("vcpus") is not None and not billing.bills_cpu:
+            raise ValueError(f"vcpus cannot be set on {platform}")
+        if values["concurrency"] > 1 and not billing.concurrent:
+            raise ValueError(f"{platform} instances serve one request at a time")
+        if values["architecture"] == ARCH_ARM64 and platform != PLATFORM_LAMBDA:
+            raise ValueError(f"architecture {ARCH_ARM64} is only priced for {PLATFORM_LAMBDA}")
+        return values
+
+
+class DisasterRecoverySpec(BaseModel):
+    """DR strategy priced on top of an estimate, in a recovery region"""
+
+    strategy: str = Field(..., description="backup_only, pilot_light, warm_standby or active_active")
+    region: Optional[str] = Field(None, description="Recovery region of items whose region is not in regions")
+    regions: Dict[str, str] = Field(
+        default_factory=dict, description="Recovery region by primary region, e.g. us-east-1: us-west-2"
+    )
+    retention_days: int = Field(7, ge=1, le=3650, description="Days of daily snapshots kept")
+    daily_change_rate: float = Field(
+        0.05, ge=0, le=1, description="Share of the stored data changed per day, snapshotted and replicated"
+    )
+    standby_fraction: Optional[float] = Field(
+        None, gt=0, le=1, description="Share of the instances warm_standby keeps running, default 0.25"
+    )
+
+    @validator("strategy")
+    def validate_strategy(cls, v):
+        return normalize_strategy(v)
+
+    @root_validator(skip_on_failure=True)
+    def require_region(cls, values):
+        if not values.get("region") and not values.get("regions"):
+            raise ValueError("disaster_recovery needs a recovery region or regions by primary region")
+        return values
+
+    def recovery_region(self, region: str) -> str:
+        """
+        Recovery region of items in a primary region
+
+        Raises:
+            ValueError: If the primary region has no recovery region
+        """
+        recovery = self.regions.get(region, self.region)
+        if not recovery:
+            raise ValueError(f"No recovery region for {region}")
+        return recovery
+
+
+class EstimateRequest(BaseModel):
+    """Request model for the estimate endpoint"""
+
+    resources: List[ResourceSpec] = Field(default_factory=list)
+    databases: List[DatabaseSpec] = Field(
+        default_factory=list,
+        description="Managed databases, priced as database line items"
+    )
+    object_storage: List[ObjectStorageSpec] = Field(
+        default_factory=list,
+        description="Object storage classes, priced as object storage line items"
+    )
+    serverless: List[ServerlessSpec] = Field(
+        default_factory=list,
+        description="Functions and serverless services, priced as serverless line items"
+    )
+    commitments: List[CommitmentOption] = Field(
+        default_factory=list,
+        description="Commitment options to compare against on-demand cost"
+    )
+    traffic: List[TrafficSpec] = Field(
+        default_factory=list,
+        description="Data transfer assumptions, priced as transfer line items"
+    )
+    continue_on_error: bool = Field(
+        False,
+        description="Report items that cannot be priced as unsupported, at zero cost, instead of failing the estimate"
+    )
+    period: Optional[BillingPeriod] = Field(
+        None, description="Dates to price, prorating the calendar months they touch, as well as a month"
+    )
+    disaster_recovery: Optional[DisasterRecoverySpec] = Field(
+        None, description="DR strategy priced as a separate section, on top of the totals"
+    )
+    project: Optional[str] = Field(None, description="Project the estimate is recorded under")
+    labels: Dict[str, str] = Field(default_factory=dict, description="Metadata kept with the estimate, e.g. CI run URL")
+
+    class Config:
+        # Kinds of resources the estimator does not price are rejected, not ignored
+        extra = "forbid"
+
+    @root_validator(skip_on_failure=True)
+    def require_resources(cls, values):
+        if not any(values.get(name) for name in ("resources", "databases", "object_storage", "serverless")):
+            raise ValueError("At least one resource, database, object storage class or serverless item is required")
+        return values
+
+
+class AppliedDiscount(BaseModel):
+    """Discount applied to a line item"""
+
+    name: str
+    description: Optional[str] = None
+    rate: float = Field(..., description="Fraction of the gross cost discounted")
+    monthly_amount: float = Field(..., description="Monthly discount amount")
+    rule_id: Optional[str] = Field(None, description="Discount rule that granted it, None for provider discounts")
+
+
+class AppliedRule(BaseModel):
+    """Discount rule applied in an estimate, with its total over all line items"""
+
+    rule_id: str
+    name: str
+    monthly_amount: float
+
+
+class LicenseCost(BaseModel):
+    """Cost of a software license billed with the instances of a line item"""
+
+    name: str
+    description: str = ""
+    unit: str = Field(..., description="vcpu_hour, instance_hour or instance_month")
+    quantity: float = Field(..., description="vCPU-hours, instance-hours or instance-months billed")
+    unit_price: float
+    rate_card: str = Field(..., description="Rate card entry the price comes from, e.g. aws/windows-server")
+    monthly_cost: float
+
+
+class LineItem(BaseModel):
+    """Cost breakdown for one resource specification"""
+
+    name: Optional[str] = None
+    provider: str
+    region: str
+    instance_type: str
+    requested_instance_type: Optional[str] = Field(
+        None, description="Instance type of the request, if it was priced as its fuzzy match instance_type"
+    )
+    match_confidence: Optional[float] = Field(None, description="Confidence of the fuzzy match")
+    count: int
+    hours: float
+    billed_hours: Optional[float] = Field(
+        None, description="Hours billed per instance, rounded up per run by the cloud's granularity, with runs"
+    )
+    usage_profile: Optional[str] = Field(None, description="Usage profile the hours were scaled by")
+    pricing_model: str = PRICING_ON_DEMAND
+    unit_price_hourly: float = Field(..., description="Price per instance-hour")
+    accelerator_type: Optional[str] = None
+    accelerator_count: int = Field(default=0, description="GPUs attached to each instance")
+    accelerator_unit_price_hourly: Optional[float] = Field(None, description="Price per attached GPU-hour")
+    price_source: Optional[str] = Field(None, description="Pricing provider that supplied the unit price")
+    price_averaged_over_days: Optional[float] = Field(
+        None,
+        description="Days of spot price history averaged into the unit price"
+    )
+    licenses: List[LicenseCost] = Field(
+        default_factory=list, description="Software licenses, included in the costs and not discounted"
+    )
+    hourly_cost: float
+    monthly_cost: float
+    yearly_cost: float
+    discounts: List[AppliedDiscount] = Field(default_factory=list)
+
+
+class TransferTier(BaseModel):
+    """Share of a transfer line item billed at one tier's rate"""
+
+    from_gb: float = Field(..., description="Monthly volume the tier starts at")
+    to_gb: Optional[float] = Field(None, description="Monthly volume the tier ends at, None for no end")
+    gb: float = Field(..., description="GB/month billed in the tier")
+    unit_price: float = Field(..., description="Price per GB")
+    monthly_cost: float
+
+
+class TransferLineItem(BaseModel):
+    """Cost breakdown of one direction of a traffic assumption"""
+
+    name: Optional[str] = None
+    provider: str
+    region: str
+    direction: str = Field(..., description="inter_az, inter_region or internet_egress")
+    gb_per_month: float
+    effective_unit_price: float = Field(..., description="Average price per GB over the tiers")
+    price_source: str = Field(..., description="Rate card the rates come from, e.g. aws/us-east-1 or aws/default")
+    tiers: List[TransferTier] = Field(default_factory=list)
+    hourly_cost: float
+    monthly_cost: float
+    yearly_cost: float
+    discounts: List[AppliedDiscount] = Field(default_factory=list)
+
+
+class DatabaseComponent(BaseModel):
+    """Billed component of a database line item"""
+
+    component: str = Field(..., description="instance, storage, iops or backup")
+    sku: str = Field(..., description="Instance class, storage type or backup")
+    quantity: float = Field(..., description="Monthly usage in the unit, over all databases")
+    unit: str
+    unit_price: float
+    price_source: Optional[str] = None
+    monthly_cost: float = Field(..., description="Monthly cost after discount rules")
+
+
+class DatabaseLineItem(BaseModel):
+    """Cost breakdown of one managed database specification"""
+
+    name: Optional[str] = None
+    provider: str
+    region: str
+    engine: str
+    instance_class: str
+    deployment: str = Field(..., description="single_az or multi_az")
+    count: int
+    hours: float
+    usage_profile: Optional[str] = Field(None, description="Usage profile the hours were scaled by")
+    storage_type: str
+    storage_gb: float
+    components: List[DatabaseComponent]
+    hourly_cost: float = Field(..., description="Monthly cost spread over an average month")
+    monthly_cost: float
+    yearly_cost: float
+    discounts: List[AppliedDiscount] = Field(default_factory=list)
+
+
+class ObjectStorageComponent(BaseModel):
+    """Billed component of an object storage line item"""
+
+    component: str = Field(..., description="storage, write_requests, read_requests, transitions or retrieval")
+    quantity: float = Field(..., description="Monthly usage in the unit")
+    unit: str
+    effective_unit_price: float = Field(..., description="Average price per unit over the volume tiers")
+    price_source: Optional[str] = None
+    monthly_cost: float = Field(..., description="Monthly cost after discount rules")
+
+
+class ObjectStorageLineItem(BaseModel):
+    """Cost breakdown of the data and operations of one object storage class"""
+
+    name: Optional[str] = None
+    provider: str
+    region: str
+    storage_class: str
+    sku: str = Field(..., description="Provider SKU of the storage class")
+    storage_gb: float
+    billable_storage_gb: float = Field(..., description="Stored data plus minimum object size and per-object overhead")
+    objects: float = Field(..., description="Objects stored, from the size distribution")
+    components: List[ObjectStorageComponent]
+    hourly_cost: float = Field(..., description="Monthly cost spread over an average month")
+    monthly_cost: float
+    yearly_cost: float
+    discounts: List[AppliedDiscount] = Field(default_factory=list)
+
+
+class ServerlessComponent(BaseModel):
+    """Billed component of a serverless line item"""
+
+    component: str = Field(..., description="requests, memory or cpu")
+    quantity: float = Field(..., description="Monthly usage in the unit")
+    free_quantity: float = Field(default=0.0, description="Usage covered by the provider's free grant")
+    unit: str
+    unit_price: float
+    price_source: Optional[str] = None
+    monthly_cost: float = Field(..., description="Monthly cost after the free grant and discount rules")
+
+
+class ServerlessLineItem(BaseModel):
+    """Cost breakdown of the invocations of one serverless item"""
+
+    name: Optional[str] = None
+    provider: str
+    region: str
+    platform: str
+    invocations: int
+    billed_seconds: float = Field(..., description="Billable instance-seconds per month")
+    memory_gb: float = Field(..., description="Billed memory of an instance")
+    vcpus: Optional[float] = Field(None, description="Billed vCPUs of an instance, None for platforms not billing CPU")
+    components: List[ServerlessComponent]
+    hourly_cost: float = Field(..., description="Monthly cost spread over an average month")
+    monthly_cost: float
+    yearly_cost: float
+    discounts: List[AppliedDiscount] = Field(default_factory=list)
+
+
+class CommitmentLineItem(BaseModel):
+    """Cost of one resource over a commitment term"""
+
+    name: Optional[str] = None
+    provider: str
+    region: str
+    instance_type: str
+    count: int
+    on_demand_term_cost: float = Field(..., description="On-demand cost over the term at the requested hours")
+    commitment_term_cost: Optional[float] = Field(None, description="Upfront plus recurring cost over the term")
+    upfront_cost: float = 0.0
+    recurring_hourly_cost: Optional[float] = None
+    effective_hourly_cost: Optional[float] = Field(None, description="Commitment cost per committed hour")
+    break_even_utilization: Optional[float] = Field(
+        None,
+        description="Share of term hours the resources must run for the commitment to pay off"
+    )
+    unavailable_reason: Optional[str] = None
+
+
+class CommitmentComparison(BaseModel):
+    """On-demand versus commitment cost over the commitment term"""
+
+    type: str
+    term: str
+    payment_option: str
+    term_months: int
+    on_demand_total_cost: float
+    commitment_total_cost: float = Field(
+        ...,
+        description="Commitment cost, with resources lacking a commitment price kept on-demand"
+    )
+    upfront_cost: float
+    savings: float
+    savings_rate: float = Field(..., description="Savings as a fraction of the on-demand total")
+    break_even_utilization: Optional[float] = Field(
+        None,
+        description="Share of term hours the committed resources must run to break even"
+    )
+    line_items: List[CommitmentLineItem]
+
+
+class UnsupportedItem(BaseModel):
+    """Item of a request that could not be priced and is left out of the totals"""
+
+    field: str = Field(..., description="Path of the item in the request, e.g. resources[2]")
+    name: Optional[str] = None
+    provider: str
+    region: str
+    status: str = STATUS_UNSUPPORTED
+    monthly_cost: float = 0.0
+    reason: str
+
+
+class DisasterRecoveryComponent(BaseModel):
+    """Cost a DR strategy adds in a recovery region"""
+
+    component: str = Field(
+        ..., description="snapshots, object_storage, replication, standby_compute or standby_database"
+    )
+    item: str = Field(..., description="Item of the request it protects, e.g. databases[0]")
+    name: Optional[str] = None
+    provider: str
+    region: str = Field(..., description="Recovery region, or the primary region replication is sent from")
+    sku: str
+    quantity: float = Field(..., description="Snapshot GB-months, GB stored or replicated, or instances running")
+    unit: str
+    monthly_cost: float
+
+
+class DisasterRecoveryCost(BaseModel):
+    """Costs of a DR strategy, on top of the estimate's totals"""
+
+    strategy: str
+    description: str
+    recovery_time: str = Field(..., description="Typical recovery time of the strategy")
+    standby_fraction: float = Field(..., description="Share of the instances running in the recovery region")
+    components: List[DisasterRecoveryComponent]
+    snapshot_storage_gb: float = Field(..., description="Snapshot storage kept in the recovery regions")
+    replication_gb: float = Field(..., description="GB/month replicated across regions")
+    monthly_cost: float
+    yearly_cost: float
+    total_monthly_cost: float = Field(..., description="Estimate and DR costs together")
+    unsupported: List[UnsupportedItem] = Field(
+        default_factory=list, description="Components that could not be priced, with continue_on_error"
+    )
+
+
+class Coverage(BaseModel):
+    """Share of the items of an estimate that could be priced"""
+
+    items: int
+    priced: int
+    unsupported: int
+    percent: float = Field(..., description="Priced items in percent of all items, 100 without items")
+
+
+class PeriodMonth(BaseModel):
+    """Part of a period in one calendar month"""
+
+    month: str = Field(..., description="YYYY-MM")
+    start: date
+    end: date = Field(..., description="Last day, included")
+    days: int
+    hours: float
+    fraction: float = Field(..., description="Share of the month's days in the period")
+    prorated_cost: float
+
+
+class PeriodCost(BaseModel):
+    """Cost of an estimate over the days of a period"""
+
+    start: date
+    end: date = Field(..., description="Last day, included")
+    days: int
+    hours: float
+    total_cost: float
+    months: List[PeriodMonth]
+
+
+class EstimateResult(BaseModel):
+    """Aggregated estimate for a request"""
+
+    line_items: List[LineItem]
+    transfer_items: List[TransferLineItem] = Field(
+        default_factory=list, description="Data transfer costs of the request's traffic assumptions"
+    )
+    database_items: List[DatabaseLineItem] = Field(
+        default_factory=list, description="Costs of the request's managed databases"
+    )
+    object_storage_items: List[ObjectStorageLineItem] = Field(
+        default_factory=list, description="Costs of the request's object storage classes"
+    )
+    serverless_items: List[ServerlessLineItem] = Field(
+        default_factory=list, description="Costs of the request's functions and serverless services"
+    )
+    hourly_cost: float
+    monthly_cost: float
+    yearly_cost: float
+    currency: str = "USD"
+    time_basis: TimeBasis = Field(default_factory=current_time_basis, description="Hours per month of the costs")
+    applied_rules: List[AppliedRule] = Field(default_factory=list, description="Discount rules applied, in order of first use")
+    commitment_comparison: List[CommitmentComparison] = Field(default_factory=list)
+    carbon: Optional[CarbonEstimate] = Field(None, description="Estimated emissions, None if carbon estimates are disabled")
+    unsupported: List[UnsupportedItem] = Field(
+        default_factory=list, description="Items that could not be priced, with continue_on_error"
+    )
+    coverage: Optional[Coverage] = None
+    period: Optional[PeriodCost] = Field(None, description="Cost over the request's period, if it has one")
+    disaster_recovery: Optional[DisasterRecoveryCost] = Field(
+        None, description="Costs of the request's DR strategy, not included in the totals"
+    )
+
+
+class ExplainStep(BaseModel):
+    """One step of the arithmetic of a line item's cost"""
+
+    description: str
+    expression: str = Field(..., description="Arithmetic of the step, with the amounts it uses")
+    amount: float = Field(..., description="Result of the step (USD, or hours for billed hours)")
+
+
+class ItemExplanation(BaseModel):
+    """How a line item's cost was derived from its prices"""
+
+    item: str = Field(..., description="Line item explained, e.g. line_items[0]")
+    name: Optional[str] = None
+    provider: str
+    region: str
+    sku: str = Field(..., description="SKU the price was found for")
+    requested_sku: Optional[str] = Field(None, description="SKU of the request, if it was priced as a fuzzy match")
+    unit_price: Optional[float] = Field(None, description="Price of the SKU per unit (USD)")
+    unit: Optional[str] = None
+    price_source: Optional[str] = Field(None, description="Pricing provider that supplied the price")
+    catalog_version: Optional[date] = Field(None, description="Catalog snapshot the price was read from")
+    discounts: List[AppliedDiscount] = Field(default_factory=list)
+    steps: List[ExplainStep]
+    monthly_cost: float
+
+
+class BatchItem(ResourceSpec):
+    """Resource of a batch estimate"""
+
+    id: Optional[str] = Field(None, description="Caller's identifier, e.g. a Terraform address, echoed in the result")
+
+
+class BatchEstimateRequest(BaseModel):
+    """Estimate request for many independent resources"""
+
+    items: List[BatchItem] = Field(..., min_items=1)
+
+
+class BatchItemResult(BaseModel):
+    """Result of one batch item: a line item, or why it could not be priced"""
+
+    index: int = Field(..., description="Position of the item in the request")
+    id: Optional[str] = None
+    status: str = Field(STATUS_PRICED, description="priced, or unsupported for items that could not be priced")
+    line_item: Optional[LineItem] = None
+    error: Optional[str] = None
+    suggestions: List[str] = Field(default_factory=list, description="Closest instance types to an unpriced one")
+
+
+class BatchEstimateResult(BaseModel):
+    """Per-item results of a batch estimate and the total of the priced items"""
+
+    items: List[BatchItemResult]
+    succeeded: int
+    failed: int
+    hourly_cost: float
+    monthly_cost: float
+    yearly_cost: float
+    currency: str = "USD"
+    time_basis: TimeBasis = Field(default_factory=current_time_basis, description="Hours per month of the costs")
+    applied_rules: List[AppliedRule] = Field(default_factory=list, description="Discount rules applied, in order of first use")
+    coverage: Optional[Coverage] = None
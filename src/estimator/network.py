@@ -0,0 +1,195 @@
+"""
+Data transfer cost model
+
+Prices a request's traffic assumptions per direction:
+
+- inter_az: traffic between availability zones of a region, billed per GB
+  crossing (AWS bills both the sending and the receiving side, included
+  in its rate)
+- inter_region: traffic sent to another region of the same provider
+- internet_egress: traffic sent to the internet, billed in volume tiers
+  with a free allowance on AWS and Azure
+
+Rates are list prices in USD per GB by provider and source region; regions
+without their own rates use the provider's "default" rate card. Ingress
+is free on all supported providers and not modeled. Free allowances apply
+per traffic assumption, though providers grant them once per account.
+"""
+
+import json
+import logging
+from typing import Any, Dict, List, Optional, Tuple
+
+from ..pricing import Price, PriceNotFoundError, SERVICE_DATA_TRANSFER
+from .models import TrafficSpec, TransferTier
+
+logger = logging.getLogger(__name__)
+
+DIRECTION_INTER_AZ = "inter_az"
+DIRECTION_INTER_REGION = "inter_region"
+DIRECTION_INTERNET_EGRESS = "internet_egress"
+DIRECTIONS = (DIRECTION_INTER_AZ, DIRECTION_INTER_REGION, DIRECTION_INTERNET_EGRESS)
+
+# Rate card of regions without their own
+DEFAULT_REGION = "default"
+
+SOURCE = "transfer_rates"
+
+# Tiers per direction as [monthly GB the tier ends at (None for no end), USD/GB]
+DEFAULT_TRANSFER_RATES: Dict[str, Dict[str, Dict[str, List[List[Any]]]]] = {
+    "aws": {
+        "default": {
+            "inter_az": [[None, 0.02]],
+            "inter_region": [[None, 0.02]],
+            "internet_egress": [[100, 0.0], [10240, 0.09], [51200, 0.085], [153600, 0.07], [None, 0.05]],
+        },
+        "ap-northeast-2": {
+            "inter_az": [[None, 0.02]],
+            "inter_region": [[None, 0.08]],
+            "internet_egress": [[100, 0.0], [10240, 0.126], [51200, 0.122], [153600, 0.117], [None, 0.108]],
+        },
+    },
+    "gcp": {
+        "default": {
+            "inter_az": [[None, 0.01]],
+            "inter_region": [[None, 0.02]],
+            "internet_egress": [[1024, 0.12], [10240, 0.11], [None, 0.08]],
+        },
+        "asia-northeast3": {
+            "inter_az": [[None, 0.01]],
+            "inter_region": [[None, 0.05]],
+            "internet_egress": [[1024, 0.12], [10240, 0.11], [None, 0.08]],
+        },
+    },
+    "azure": {
+        "default": {
+            "inter_az": [[None, 0.0]],
+            "inter_region": [[None, 0.02]],
+            "internet_egress": [[100, 0.0], [10240, 0.087], [51200, 0.083], [153600, 0.07], [None, 0.05]],
+        },
+        "koreacentral": {
+            "inter_az": [[None, 0.0]],
+            "inter_region": [[None, 0.08]],
+            "internet_egress": [[100, 0.0], [10240, 0.12], [51200, 0.085], [153600, 0.082], [None, 0.08]],
+        },
+    },
+}
+
+
+def traffic_volumes(traffic: TrafficSpec) -> Dict[str, float]:
+    """GB/month of a traffic assumption by direction, leaving out directions without traffic"""
+    volumes = {
+        DIRECTION_INTER_AZ: traffic.inter_az_gb,
+        DIRECTION_INTER_REGION: traffic.inter_region_gb,
+        DIRECTION_INTERNET_EGRESS: traffic.internet_egress_gb,
+    }
+    return {direction: gb for direction, gb in volumes.items() if gb > 0}
+
+
+class TransferRates:
+    """Tiered data transfer rates by provider, region and direction"""
+
+    def __init__(self, rates: Optional[Dict[str, Dict[str, Dict[str, List[List[Any]]]]]] = None):
+        """
+        Initialize transfer rates
+
+        Args:
+            rates: Nested mapping provider -> region -> direction -> tiers, as
+                DEFAULT_TRANSFER_RATES. Uses the built-in rates if not provided.
+
+        Raises:
+            ValueError: If a direction is unknown or tiers are not in ascending order
+        """
+        self.rates = rates if rates is not None else DEFAULT_TRANSFER_RATES
+        for provider, regions in self.rates.items():
+            for region, directions in regions.items():
+                for direction, tiers in directions.items():
+                    _validate_tiers(f"{provider}/{region}/{direction}", direction, tiers)
+
+    @classmethod
+    def from_file(cls, path: str) -> "TransferRates":
+        """Load rates from a JSON file with the same nesting as DEFAULT_TRANSFER_RATES"""
+        with open(path, "r", encoding="utf-8") as f:
+            rates = json.load(f)
+
+        if not isinstance(rates, dict):
+            raise ValueError(f"Transfer rates {path} must be a JSON object")
+
+        logger.info(f"Transfer rates loaded from {path}")
+        return cls(rates)
+
+    def quote(self, traffic: TrafficSpec, direction: str, gb: float) -> Tuple[Price, List[TransferTier], str]:
+        """
+        Price of a monthly volume in one direction
+
+        Returns:
+            (price per GB averaged over the tiers, tiers billed, rate card)
+
+        Raises:
+            PriceNotFoundError: If the provider has no rates for the direction
+        """
+        rates, card = self.tiers(traffic.provider, traffic.region, direction)
+        tiers = _bill_tiers(rates, gb)
+        cost = sum(tier.monthly_cost for tier in tiers)
+        price = Price(
+            provider=traffic.provider,
+            region=traffic.region,
+            sku=direction,
+            service=SERVICE_DATA_TRANSFER,
+            unit="GB",
+            price=cost / gb if gb > 0 else 0.0,
+            source=SOURCE,
+        )
+        return price, tiers, card
+
+    def tiers(self, provider: str, region: str, direction: str) -> Tuple[List[List[Any]], str]:
+        """
+        Tiers of a direction as (tiers, rate card), falling back to the provider's default rates
+
+        Raises:
+            PriceNotFoundError: If the provider has no rates for the direction
+        """
+        regions = self.rates.get(provider, {})
+        for card in (region, DEFAULT_REGION):
+            tiers = regions.get(card, {}).get(direction)
+            if tiers is not None:
+                return tiers, f"{provider}/{card}"
+        raise PriceNotFoundError(f"No {direction} transfer rates for {provider}/{region}")
+
+
+def _bill_tiers(rates: List[List[Any]], gb: float) -> List[TransferTier]:
+    """Split a monthly volume over the tiers it reaches"""
+    billed = []
+    start = 0.0
+    for end, rate in rates:
+        if start >= gb:
+            break
+        volume = (gb if end is None else min(gb, float(end))) - start
+        billed.append(TransferTier(
+            from_gb=start,
+            to_gb=None if end is None else float(end),
+            gb=volume,
+            unit_price=float(rate),
+            monthly_cost=volume * float(rate),
+        ))
+        if end is None:
+            break
+        start = float(end)
+    return billed
+
+
+def _validate_tiers(label: str, direction: str, tiers: List[List[Any]]) -> None:
+    if direction not in DIRECTIONS:
+        raise ValueError(
+            f"Unknown transfer direction '{direction}' in {label}, expected one of {', '.join(DIRECTIONS)}"
+        )
+    if not tiers or tiers[-1][0] is not None:
+        raise ValueError(f"Transfer tiers of {label} must end with an open tier [null, price]")
+    previous = 0.0
+    for end, rate in tiers:
+        if float(rate) < 0:
+            raise ValueError(f"Transfer tiers of {label} have a negative rate")
+        if end is not None:
+            if float(end) <= previous:
+                raise ValueError(f"Transfer tiers of {label} must end at increasing volumes")
+            previous = float(end)
@@ -0,0 +1,56 @@
+"""
+Object storage usage rules
+
+Turns an object storage specification into billed quantities. The object
+size distribution gives the number of objects stored and transitioned,
+and the storage billed for small objects:
+
+- aws: infrequent access bills objects smaller than 128 KB as 128 KB;
+  archive (Glacier) adds 40 KB of index and metadata per object
+- gcp and azure bill stored bytes only
+
+Minimum storage durations (early deletion fees) are not modeled.
+"""
+
+from typing import Dict, List, Tuple
+
+from ..pricing import STORAGE_CLASS_ARCHIVE, STORAGE_CLASS_INFREQUENT
+from ..units import KB_PER_GB
+
+# Average object size of specifications without a size distribution
+DEFAULT_OBJECT_SIZE_KB = 1024.0
+
+# (provider, storage class) -> (minimum billable object KB, overhead KB per object)
+OBJECT_BILLING_RULES: Dict[Tuple[str, str], Tuple[float, float]] = {
+    ("aws", STORAGE_CLASS_INFREQUENT): (128.0, 0.0),
+    ("aws", STORAGE_CLASS_ARCHIVE): (0.0, 40.0),
+}
+
+
+def average_object_kb(distribution: List[Tuple[float, float]]) -> float:
+    """Average object size of (size KB, share of objects) buckets"""
+    if not distribution:
+        return DEFAULT_OBJECT_SIZE_KB
+    return sum(size_kb * share for size_kb, share in distribution)
+
+
+def object_count(gb: float, distribution: List[Tuple[float, float]]) -> float:
+    """Objects making up a volume of data"""
+    return gb * KB_PER_GB / average_object_kb(distribution)
+
+
+def billable_storage_gb(
+    provider: str,
+    storage_class: str,
+    storage_gb: float,
+    distribution: List[Tuple[float, float]],
+) -> float:
+    """Stored data plus the padding of small objects and per-object overhead"""
+    minimum_kb, overhead_kb = OBJECT_BILLING_RULES.get((provider, storage_class), (0.0, 0.0))
+    if not minimum_kb and not overhead_kb:
+        return storage_gb
+    objects = object_count(storage_gb, distribution)
+    buckets = distribution or [(DEFAULT_OBJECT_SIZE_KB, 1.0)]
+    billed_kb = sum(max(size_kb, minimum_kb) * share for size_kb, share in buckets) + overhead_kb
+    return objects * billed_kb / KB_PER_GB
+
@@ -0,0 +1,98 @@
+"""
+Serverless usage rules
+
+Turns invocations, duration, memory and concurrency into billed
+quantities per platform:
+
+- lambda: duration rounded up to 1 ms, memory as configured
+- cloud_run, cloud_functions: billable instance time rounded up to 100 ms,
+  shared by the requests an instance serves concurrently, plus vCPU time
+- azure_functions: at least 100 ms per execution, memory rounded up to
+  128 MB
+
+Each provider's monthly free grant is shared by the serverless items of an
+estimate, applied in request order. Lambda provisioned concurrency, Cloud
+Run minimum instances and always-allocated CPU are not modeled.
+"""
+
+import copy
+import math
+from typing import Dict, NamedTuple, Optional
+
+from ..pricing import PLATFORM_AZURE_FUNCTIONS, PLATFORM_CLOUD_FUNCTIONS, PLATFORM_CLOUD_RUN, PLATFORM_LAMBDA
+from ..units import MB_PER_GB
+
+
+class ServerlessBilling(NamedTuple):
+    """How a platform meters an invocation"""
+
+    granularity_ms: float
+    minimum_ms: float
+    memory_step_mb: int
+    max_memory_mb: int
+    bills_cpu: bool
+    concurrent: bool
+
+
+SERVERLESS_BILLING: Dict[str, ServerlessBilling] = {
+    PLATFORM_LAMBDA: ServerlessBilling(1, 1, 1, 10240, False, False),
+    PLATFORM_CLOUD_RUN: ServerlessBilling(100, 100, 1, 32768, True, True),
+    PLATFORM_CLOUD_FUNCTIONS: ServerlessBilling(100, 100, 1, 32768, True, True),
+    PLATFORM_AZURE_FUNCTIONS: ServerlessBilling(1, 100, 128, 1536, False, False),
+}
+
+# Monthly free grant per billing account: million requests, GB-seconds and vCPU-seconds
+FREE_TIERS: Dict[str, Dict[str, float]] = {
+    "aws": {"requests": 1.0, "memory": 400000.0},
+    "gcp": {"requests": 2.0, "memory": 360000.0, "cpu": 180000.0},
+    "azure": {"requests": 1.0, "memory": 400000.0},
+}
+
+# Cloud Run functions vCPUs by memory (MB) when none are set
+_FUNCTION_VCPUS = [(128, 0.083), (256, 0.167), (512, 0.333), (1024, 0.583), (2048, 1.0), (8192, 2.0), (16384, 4.0)]
+MAX_FUNCTION_VCPUS = 8.0
+# Cloud Run instance vCPUs when none are set
+DEFAULT_CLOUD_RUN_VCPUS = 1.0
+
+
+def billed_seconds(platform: str, invocations: int, duration_ms: float, concurrency: int = 1) -> float:
+    """Billed instance-seconds of a month of invocations"""
+    billing = SERVERLESS_BILLING[platform]
+    per_invocation = max(billing.minimum_ms, math.ceil(duration_ms / billing.granularity_ms) * billing.granularity_ms)
+    return invocations * per_invocation / 1000.0 / (concurrency if billing.concurrent else 1)
+
+
+def billed_memory_gb(platform: str, memory_mb: int) -> float:
+    """Memory an instance is billed for"""
+    step = SERVERLESS_BILLING[platform].memory_step_mb
+    return math.ceil(memory_mb / step) * step / MB_PER_GB
+
+
+def default_vcpus(platform: str, memory_mb: int) -> Optional[float]:
+    """vCPUs billed per instance when none are set, None for platforms not billing CPU"""
+    if not SERVERLESS_BILLING[platform].bills_cpu:
+        return None
+    if platform == PLATFORM_CLOUD_FUNCTIONS:
+        return next((vcpus for limit, vcpus in _FUNCTION_VCPUS if memory_mb <= limit), MAX_FUNCTION_VCPUS)
+    return DEFAULT_CLOUD_RUN_VCPUS
+
+
+class FreeTier:
+    """Free grants left to the serverless items of one estimate"""
+
+    def __init__(self, grants: Optional[Dict[str, Dict[str, float]]] = None):
+        """
+        Initialize free tier
+
+        Args:
+            grants: Monthly grant by provider and component. Uses FREE_TIERS if not provided.
+        """
+        self.remaining = copy.deepcopy(grants if grants is not None else FREE_TIERS)
+
+    def take(self, provider: str, component: str, quantity: float) -> float:
+        """Use up to a quantity of the grant, returning the part covered"""
+        remaining = self.remaining.get(provider, {})
+        covered = min(quantity, remaining.get(component, 0.0))
+        if covered > 0:
+            remaining[component] -= covered
+        return covered
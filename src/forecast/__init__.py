@@ -0,0 +1,34 @@
+"""
+Forecast Module
+
+This module projects a tenant's spend over the coming days from the
+actual costs ingested from billing exports, with linear, exponential
+smoothing and seasonal models and confidence intervals.
+"""
+
+from .models import (
+    Forecast,
+    ForecastPoint,
+    MODEL_LINEAR,
+    MODEL_EXPONENTIAL_SMOOTHING,
+    MODEL_SEASONAL,
+    MODELS,
+)
+from .methods import Projection, linear, exponential_smoothing, seasonal
+from .engine import Forecaster, HISTORY_DAYS, MAX_HORIZON_DAYS
+
+__all__ = [
+    "Forecast",
+    "ForecastPoint",
+    "MODEL_LINEAR",
+    "MODEL_EXPONENTIAL_SMOOTHING",
+    "MODEL_SEASONAL",
+    "MODELS",
+    "Projection",
+    "linear",
+    "exponential_smoothing",
+    "seasonal",
+    "Forecaster",
+    "HISTORY_DAYS",
+    "MAX_HORIZON_DAYS",
+]
@@ -0,0 +1,153 @@
+"""
+Spend forecasting
+
+Projects a tenant's daily spend from the actual costs ingested from
+billing exports. The history runs from the first day with spend in the
+HISTORY_DAYS before today up to yesterday; days without spend in between
+count as zero. Forecasts never go below zero, and the interval of the
+total assumes the errors of different days are independent.
+"""
+
+import math
+from datetime import date, datetime, timedelta, timezone
+from statistics import NormalDist
+from typing import Callable, Dict, List, Optional, Tuple
+
+from ..money import round_money, sum_money
+from ..store import Store
+from .methods import Projection, exponential_smoothing, linear, seasonal
+from .models import (
+    Forecast,
+    ForecastPoint,
+    MODELS,
+    MODEL_EXPONENTIAL_SMOOTHING,
+    MODEL_LINEAR,
+    MODEL_SEASONAL,
+)
+
+# Longest forecast, in days
+MAX_HORIZON_DAYS = 365
+# Days of actual spend the models are fitted to by default
+HISTORY_DAYS = 180
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+class Forecaster:
+    """Forecasts a tenant's spend from its actual costs"""
+
+    def __init__(
+        self,
+        store: Store,
+        default_model: str = MODEL_LINEAR,
+        history_days: int = HISTORY_DAYS,
+        season_days: int = 7,
+        clock: Callable[[], datetime] = utcnow,
+    ):
+        """
+        Initialize forecaster
+
+        Args:
+            store: Store holding actual costs
+            default_model: Model used when a request names none
+            history_days: Days of actual spend before today the models are fitted to
+            season_days: Period of the seasonal model's pattern
+            clock: Current time (aware UTC)
+        """
+        if default_model not in MODELS:
+            raise ValueError(f"Unknown forecast model {default_model!r}, expected one of {', '.join(MODELS)}")
+        self.store = store
+        self.default_model = default_model
+        self.history_days = history_days
+        self.season_days = season_days
+        self.clock = clock
+
+    def forecast(
+        self,
+        tenant_id: str,
+        horizon_days: int = 30,
+        model: Optional[str] = None,
+        confidence: float = 0.95,
+        project: Optional[str] = None,
+        labels: Optional[Dict[str, str]] = None,
+    ) -> Forecast:
+        """
+        Forecast the spend of the days from today
+
+        Args:
+            tenant_id: Tenant the costs belong to
+            horizon_days: Days forecast, e.g. 30, 90 or 365
+            model: linear, exponential_smoothing or seasonal, default the forecaster's
+            confidence: Confidence level of the intervals
+            project: Only costs of this project
+            labels: Only costs carrying all these labels
+
+        Raises:
+            ValueError: Invalid parameters, or too little history for the model
+        """
+        model = model or self.default_model
+        if model not in MODELS:
+            raise ValueError(f"Unknown forecast model {model!r}, expected one of {', '.join(MODELS)}")
+        if not 1 <= horizon_days <= MAX_HORIZON_DAYS:
+            raise ValueError(f"The horizon must be between 1 and {MAX_HORIZON_DAYS} days")
+        if not 0 < confidence < 1:
+            raise ValueError("The confidence level must be between 0 and 1, e.g. 0.95")
+        labels = labels or {}
+
+        end = self.clock().date()
+        start, series = self._history(tenant_id, end, project, labels)
+        projection = self._project(model, series, horizon_days)
+
+        z = NormalDist().inv_cdf((1 + confidence) / 2)
+        points = []
+        for step, (value, error) in enumerate(zip(projection.values, projection.errors)):
+            points.append(ForecastPoint(
+                date=end + timedelta(days=step),
+                cost=round_money(max(0.0, value)),
+                lower=round_money(max(0.0, value - z * error)),
+                upper=round_money(max(0.0, value + z * error)),
+            ))
+
+        total = sum(max(0.0, v) for v in projection.values)
+        spread = z * math.sqrt(sum(e * e for e in projection.errors))
+        return Forecast(
+            model=model,
+            horizon_days=horizon_days,
+            confidence=confidence,
+            project=project,
+            labels=labels,
+            history_start=start,
+            history_end=end,
+            history_days=len(series),
+            history_cost=sum_money(series),
+            residual_std=round_money(projection.residual_std),
+            points=points,
+            total_cost=round_money(total),
+            total_lower=round_money(max(0.0, total - spread)),
+            total_upper=round_money(total + spread),
+        )
+
+    def _history(
+        self, tenant_id: str, end: date, project: Optional[str], labels: Dict[str, str]
+    ) -> Tuple[date, List[float]]:
+        """(first day, daily spend) of the history before a day"""
+        since = end - timedelta(days=self.history_days)
+        daily: Dict[date, float] = {}
+        for cost in self.store.list_actual_costs(tenant_id, since, end, project=project):
+            if all(cost.labels.get(key) == value for key, value in labels.items()):
+                daily[cost.usage_date] = daily.get(cost.usage_date, 0.0) + cost.amount
+        if not daily:
+            raise ValueError(f"No actual costs in the {self.history_days} days before {end}")
+
+        start = min(daily)
+        series = [daily.get(start + timedelta(days=i), 0.0) for i in range((end - start).days)]
+        return start, series
+
+    def _project(self, model: str, series: List[float], horizon_days: int) -> Projection:
+        if model == MODEL_SEASONAL:
+            return seasonal(series, horizon_days, self.season_days)
+        if model == MODEL_EXPONENTIAL_SMOOTHING:
+            return exponential_smoothing(series, horizon_days)
+        return linear(series, horizon_days)
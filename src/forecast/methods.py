@@ -0,0 +1,139 @@
+"""
+Forecasting methods
+
+Each method fits a daily series and projects it forward, returning the
+expected value of every future day with the standard error of that
+estimate, from which the engine builds confidence intervals.
+
+- linear: least-squares trend line; the error grows with the distance
+  from the fitted days
+- exponential smoothing: Holt's linear trend, with smoothing constants
+  chosen by grid search on one-step-ahead errors
+- seasonal: classical additive decomposition into a weekly pattern and a
+  linear trend of the deseasonalized series
+"""
+
+import math
+from typing import List, NamedTuple, Tuple
+
+# Smoothing constants searched for Holt's method
+_SMOOTHING_GRID = [i / 10 for i in range(1, 10)]
+
+
+class Projection(NamedTuple):
+    """Expected future values and their standard errors"""
+
+    values: List[float]
+    errors: List[float]
+    residual_std: float
+
+
+def _line(values: List[float]) -> Tuple[float, float, float, float]:
+    """(intercept, slope, mean t, sum of squared t deviations) of a least-squares line"""
+    n = len(values)
+    t_mean = (n - 1) / 2
+    y_mean = sum(values) / n
+    sxx = sum((t - t_mean) ** 2 for t in range(n))
+    slope = sum((t - t_mean) * (y - y_mean) for t, y in enumerate(values)) / sxx if sxx else 0.0
+    return y_mean - slope * t_mean, slope, t_mean, sxx
+
+
+def linear(values: List[float], horizon: int) -> Projection:
+    """
+    Project a least-squares trend line
+
+    Raises:
+        ValueError: With fewer than 3 values
+    """
+    n = len(values)
+    if n < 3:
+        raise ValueError("A linear forecast needs at least 3 days of history")
+
+    intercept, slope, t_mean, sxx = _line(values)
+    residuals = [y - (intercept + slope * t) for t, y in enumerate(values)]
+    std = math.sqrt(sum(r * r for r in residuals) / (n - 2))
+
+    projected, errors = [], []
+    for step in range(1, horizon + 1):
+        t = n - 1 + step
+        projected.append(intercept + slope * t)
+        errors.append(std * math.sqrt(1 + 1 / n + (t - t_mean) ** 2 / sxx))
+    return Projection(projected, errors, std)
+
+
+def _holt(values: List[float], alpha: float, beta: float) -> Tuple[float, float, float]:
+    """(level, trend, sum of squared one-step errors) after smoothing a series"""
+    level, trend = values[0], values[1] - values[0]
+    sse = 0.0
+    for y in values[1:]:
+        error = y - (level + trend)
+        sse += error * error
+        previous = level
+        level = alpha * y + (1 - alpha) * (level + trend)
+        trend = beta * (level - previous) + (1 - beta) * trend
+    return level, trend, sse
+
+
+def exponential_smoothing(values: List[float], horizon: int) -> Projection:
+    """
+    Project Holt's linear trend
+
+    Raises:
+        ValueError: With fewer than 3 values
+    """
+    n = len(values)
+    if n < 3:
+        raise ValueError("An exponential smoothing forecast needs at least 3 days of history")
+
+    alpha, beta = min(
+        ((a, b) for a in _SMOOTHING_GRID for b in _SMOOTHING_GRID),
+        key=lambda ab: _holt(values, *ab)[2],
+    )
+    level, trend, sse = _holt(values, alpha, beta)
+    std = math.sqrt(sse / (n - 1))
+
+    projected, errors = [], []
+    variance = 0.0
+    for step in range(1, horizon + 1):
+        projected.append(level + step * trend)
+        # Var of an h-step forecast: sigma^2 (1 + sum_{j<h} alpha^2 (1 + j beta)^2)
+        errors.append(std * math.sqrt(1 + variance))
+        variance += (alpha * (1 + step * beta)) ** 2
+    return Projection(projected, errors, std)
+
+
+def seasonal(values: List[float], horizon: int, period: int = 7) -> Projection:
+    """
+    Project a weekly (or other period) pattern on top of a linear trend
+
+    Raises:
+        ValueError: With fewer than two full periods of values
+    """
+    n = len(values)
+    if period < 2 or n < 2 * period:
+        raise ValueError(f"A seasonal forecast needs at least {2 * period} days of history")
+
+    # Centered moving average; even periods average two offset windows
+    half = period // 2
+    trend = {}
+    for t in range(half, n - half):
+        if period % 2:
+            trend[t] = sum(values[t - half:t + half + 1]) / period
+        else:
+            window = values[t - half:t + half + 1]
+            trend[t] = (sum(window) - window[0] / 2 - window[-1] / 2) / period
+
+    by_position: List[List[float]] = [[] for _ in range(period)]
+    for t, level in trend.items():
+        by_position[t % period].append(values[t] - level)
+    indices = [sum(d) / len(d) if d else 0.0 for d in by_position]
+    mean = sum(indices) / period
+    indices = [i - mean for i in indices]
+
+    adjusted = [y - indices[t % period] for t, y in enumerate(values)]
+    projection = linear(adjusted, horizon)
+    return Projection(
+        [value + indices[(n + step) % period] for step, value in enumerate(projection.values)],
+        projection.errors,
+        projection.residual_std,
+    )
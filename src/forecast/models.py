@@ -0,0 +1,42 @@
+"""
+Data models for spend forecasts
+"""
+
+from datetime import date
+from typing import Dict, List, Optional
+
+from pydantic import BaseModel, Field
+
+# Forecasting models
+MODEL_LINEAR = "linear"
+MODEL_EXPONENTIAL_SMOOTHING = "exponential_smoothing"
+MODEL_SEASONAL = "seasonal"
+MODELS = (MODEL_LINEAR, MODEL_EXPONENTIAL_SMOOTHING, MODEL_SEASONAL)
+
+
+class ForecastPoint(BaseModel):
+    """Forecast spend of one day"""
+
+    date: date
+    cost: float = Field(..., description="Expected spend (USD)")
+    lower: float = Field(..., description="Lower bound of the confidence interval")
+    upper: float = Field(..., description="Upper bound of the confidence interval")
+
+
+class Forecast(BaseModel):
+    """Daily spend projected from a tenant's actual costs"""
+
+    model: str = Field(..., description="linear, exponential_smoothing or seasonal")
+    horizon_days: int
+    confidence: float = Field(..., description="Confidence level of the intervals, e.g. 0.95")
+    project: Optional[str] = None
+    labels: Dict[str, str] = Field(default_factory=dict)
+    history_start: date = Field(..., description="First day of actual spend the model was fitted to")
+    history_end: date = Field(..., description="First day after the history, and of the forecast")
+    history_days: int
+    history_cost: float = Field(..., description="Actual spend over the history")
+    residual_std: float = Field(..., description="Standard deviation of the model's errors on the history (USD/day)")
+    points: List[ForecastPoint]
+    total_cost: float = Field(..., description="Expected spend over the horizon")
+    total_lower: float
+    total_upper: float
@@ -0,0 +1,47 @@
+"""
+Formats Module
+
+This module renders estimate and report responses as CSV for
+spreadsheets, Markdown tables for pull request comments and chat, and
+standalone HTML reports, choosing the format from a format parameter or
+the request's Accept header. Estimates can also be laid out in
+Infracost's JSON schema for tooling built on Infracost output.
+"""
+
+from .render import (
+    FORMAT_JSON,
+    FORMAT_CSV,
+    FORMAT_MARKDOWN,
+    FORMAT_HTML,
+    FORMATS,
+    MEDIA_TYPES,
+    EXTENSIONS,
+    Table,
+    negotiate_format,
+    tabulate,
+    render_csv,
+    render_markdown,
+    render_html,
+    render_document,
+)
+from .infracost import FORMAT_INFRACOST, INFRACOST_VERSION, infracost_document
+
+__all__ = [
+    "FORMAT_JSON",
+    "FORMAT_CSV",
+    "FORMAT_MARKDOWN",
+    "FORMAT_HTML",
+    "FORMATS",
+    "MEDIA_TYPES",
+    "EXTENSIONS",
+    "Table",
+    "negotiate_format",
+    "tabulate",
+    "render_csv",
+    "render_markdown",
+    "render_html",
+    "render_document",
+    "FORMAT_INFRACOST",
+    "INFRACOST_VERSION",
+    "infracost_document",
+]
@@ -0,0 +1,315 @@
+"""
+Infracost-compatible output
+
+Estimates are laid out in the JSON schema of `infracost breakdown
+--format json` (version 0.2), so CI integrations, policies and
+dashboards built on Infracost output read our estimates unchanged: one
+project per estimate, its resources with their cost components, totals
+as decimal strings and a summary of the resources that could not be
+priced.
+
+Plan estimates (Terraform, Pulumi, CloudFormation, Crossplane) fill the
+past breakdown with the cost of the changed and destroyed resources
+before the change, the breakdown with the changed and added resources
+after it and the diff with each resource's delta; resources the plan
+leaves alone are not priced and stay out of all three. Resource and
+Kubernetes estimates have a breakdown only.
+"""
+
+from collections import Counter
+from datetime import datetime, timezone
+from typing import Any, Dict, Iterable, List, Optional
+
+from ..units import hours_per_month, recorded_hours_per_month
+
+FORMAT_INFRACOST = "infracost"
+
+INFRACOST_VERSION = "0.2"
+
+# Units of our prices as Infracost names them
+_UNITS = {"hour": "hours", "GB-month": "GB", "GB": "GB"}
+
+
+def _cost(value: Optional[float]) -> Optional[str]:
+    """Amount as an Infracost decimal string, None for unknown amounts"""
+    if value is None:
+        return None
+    text = f"{value:.10f}".rstrip("0").rstrip(".")
+    return "0" if text in ("", "-0") else text
+
+
+def _hourly(monthly: Optional[float]) -> Optional[float]:
+    return None if monthly is None else monthly / hours_per_month()
+
+
+def _component(
+    name: str,
+    unit: str,
+    price: Optional[float],
+    monthly_cost: float,
+    monthly_quantity: Optional[float] = None,
+    usage_based: bool = False,
+) -> Dict[str, Any]:
+    unit = _UNITS.get(unit, unit)
+    if monthly_quantity is None and price:
+        monthly_quantity = monthly_cost / price
+    hourly_quantity = monthly_quantity / hours_per_month() if unit == "hours" and monthly_quantity is not None else None
+    return {
+        "name": name,
+        "unit": unit,
+        "hourlyQuantity": _cost(hourly_quantity),
+        "monthlyQuantity": _cost(monthly_quantity),
+        "price": _cost(price),
+        "hourlyCost": _cost(_hourly(monthly_cost)),
+        "monthlyCost": _cost(monthly_cost),
+        "usageBased": usage_based,
+    }
+
+
+def _resource(
+    name: str,
+    resource_type: str,
+    monthly_cost: Optional[float],
+    components: List[Dict[str, Any]],
+    tags: Optional[Dict[str, str]] = None,
+    metadata: Optional[Dict[str, Any]] = None,
+) -> Dict[str, Any]:
+    usage = sum(float(c["monthlyCost"] or 0) for c in components if c.get("usageBased"))
+    return {
+        "name": name,
+        "resourceType": resource_type,
+        "tags": tags or {},
+        "metadata": metadata or {},
+        "hourlyCost": _cost(_hourly(monthly_cost)),
+        "monthlyCost": _cost(monthly_cost),
+        "monthlyUsageCost": _cost(usage) if any(c.get("usageBased") for c in components) else None,
+        "costComponents": components,
+        "subresources": [],
+    }
+
+
+def _breakdown(resources: List[Dict[str, Any]], monthly_cost: float) -> Dict[str, Any]:
+    usage = [float(r["monthlyUsageCost"]) for r in resources if r["monthlyUsageCost"] is not None]
+    return {
+        "resources": resources,
+        "totalHourlyCost": _cost(_hourly(monthly_cost)),
+        "totalMonthlyCost": _cost(monthly_cost),
+        "totalMonthlyUsageCost": _cost(sum(usage)) if usage else None,
+    }
+
+
+def _summary(resources: Iterable[Dict[str, Any]], unsupported_types: List[str]) -> Dict[str, Any]:
+    resources = list(resources)
+    no_price = Counter(r["resourceType"] for r in resources if not r["costComponents"] and not r["monthlyCost"])
+    return {
+        "totalDetectedResources": len(resources) + len(unsupported_types),
+        "totalSupportedResources": len(resources),
+        "totalUnsupportedResources": len(unsupported_types),
+        "totalUsageBasedResources": sum(1 for r in resources if r["monthlyUsageCost"] is not None),
+        "totalNoPriceResources": sum(no_price.values()),
+        "unsupportedResourceCounts": dict(sorted(Counter(unsupported_types).items())),
+        "noPriceResourceCounts": dict(sorted(no_price.items())),
+    }
+
+
+def _plan_components(resource: Dict[str, Any], hours: float) -> List[Dict[str, Any]]:
+    components = []
+    for c in resource.get("components") or []:
+        name = f"{c['name']} ({c.get('pricing_model', 'on_demand')}, {c['sku']})"
+        if c["unit"] == "hour":
+            quantity = c["count"] * hours
+        elif c["unit"] == "GB-month":
+            quantity = (c.get("size_gb") or 0.0) * c["count"]
+        else:
+            quantity = c["count"]
+        components.append(_component(name, c["unit"], c["unit_price"], c["monthly_cost"], quantity))
+    return components
+
+
+def _plan_projects(estimate: Dict[str, Any], hours: float) -> Dict[str, Any]:
+    added = estimate.get("added") or []
+    changed = estimate.get("changed") or []
+    destroyed = estimate.get("destroyed") or []
+
+    def resource(r: Dict[str, Any], monthly_cost: float, components: List[Dict[str, Any]]) -> Dict[str, Any]:
+        return _resource(r["address"], r["type"], monthly_cost, components, metadata={"action": r["action"]})
+
+    past = [resource(r, r["before_monthly_cost"], []) for r in changed]
+    past += [resource(r, r["before_monthly_cost"], _plan_components(r, hours)) for r in destroyed]
+    current = [resource(r, r["after_monthly_cost"], _plan_components(r, hours)) for r in [*added, *changed]]
+    diff = [resource(r, r["monthly_delta"], []) for r in [*added, *changed, *destroyed]]
+    unsupported = [r["type"] for r in estimate.get("unpriced") or []]
+
+    return {
+        "pastBreakdown": _breakdown(past, estimate.get("before_monthly_cost", 0.0)),
+        "breakdown": _breakdown(current, estimate.get("after_monthly_cost", 0.0)),
+        "diff": _breakdown(diff, estimate.get("monthly_delta", 0.0)),
+        "summary": _summary(current, unsupported),
+    }
+
+
+def _line_item_resources(estimate: Dict[str, Any]) -> List[Dict[str, Any]]:
+    resources = []
+    for item in estimate.get("line_items") or []:
+        component = _component(
+            f"Instance usage ({item.get('pricing_model', 'on_demand')}, {item['instance_type']})",
+            "hour",
+            item["unit_price_hourly"],
+            item["monthly_cost"],
+            item["count"] * item["hours"],
+        )
+        resources.append(_resource(
+            item.get("name") or item["instance_type"], f"{item['provider']}_compute", item["monthly_cost"], [component],
+            metadata={"region": item["region"]},
+        ))
+    for item in estimate.get("transfer_items") or []:
+        component = _component(
+            f"Data transfer ({item['direction']})", "GB", item["effective_unit_price"], item["monthly_cost"],
+            item["gb_per_month"], usage_based=True,
+        )
+        resources.append(_resource(
+            item.get("name") or item["direction"], f"{item['provider']}_data_transfer", item["monthly_cost"],
+            [component], metadata={"region": item["region"]},
+        ))
+    for field, resource_type, label in (
+        ("database_items", "database", "instance_class"),
+        ("object_storage_items", "object_storage", "storage_class"),
+        ("serverless_items", "serverless", "platform"),
+    ):
+        for item in estimate.get(field) or []:
+            components = [
+                _component(
+                    f"{c['component'].replace('_', ' ').capitalize()} ({c.get('sku') or item[label]})",
+                    c["unit"],
+                    c.get("unit_price", c.get("effective_unit_price")),
+                    c["monthly_cost"],
+                    c["quantity"],
+                    usage_based=resource_type != "database",
+                )
+                for c in item.get("components") or []
+            ]
+            resources.append(_resource(
+                item.get("name") or item[label], f"{item['provider']}_{resource_type}", item["monthly_cost"],
+                components, metadata={"region": item["region"]},
+            ))
+    return resources
+
+
+def _kubernetes_resources(estimate: Dict[str, Any]) -> List[Dict[str, Any]]:
+    rates = estimate.get("node_rates") or {}
+    resources = []
+    if estimate.get("node_pools"):
+        for pool in estimate["node_pools"]:
+            component = _component(
+                f"Instance usage ({pool.get('pricing_model', 'on_demand')}, {pool['instance_type']})",
+                "hour",
+                pool["hourly_price"],
+                pool["monthly_cost"],
+            )
+            resources.append(_resource(pool["name"], "kubernetes_node_pool", pool["monthly_cost"], [component]))
+    else:
+        for workload in estimate.get("workloads") or []:
+            components = [
+                _component("CPU requests", "vCPU-hours", rates.get("cpu_hourly"), workload["cpu_monthly_cost"]),
+                _component("Memory requests", "GiB-hours", rates.get("memory_hourly"), workload["memory_monthly_cost"]),
+            ]
+            if workload.get("gpu_monthly_cost"):
+                components.append(
+                    _component("GPU requests", "GPU-hours", rates.get("gpu_hourly"), workload["gpu_monthly_cost"])
+                )
+            resources.append(_resource(
+                f"{workload['namespace']}/{workload['kind']}/{workload['name']}",
+                f"kubernetes_{workload['kind'].lower()}",
+                workload["monthly_cost"],
+                components,
+                tags=workload.get("labels"),
+                metadata={"replicas": workload["replicas"]},
+            ))
+    for volume in estimate.get("volumes") or []:
+        component = _component(
+            f"Storage ({volume['volume_type']})", volume["unit"], volume["unit_price"], volume["monthly_cost"],
+            volume["size_gb"] * volume["count"],
+        )
+        resources.append(_resource(
+            f"{volume['namespace']}/{volume['name']}", "kubernetes_persistent_volume_claim", volume["monthly_cost"],
+            [component], tags=volume.get("labels"),
+        ))
+    plane = estimate.get("control_plane")
+    if plane:
+        component = _component(
+            f"Cluster management ({plane['tier']})", "hour", plane["hourly_price"], plane["monthly_cost"]
+        )
+        resources.append(_resource(
+            "control_plane", f"{plane['provider']}_kubernetes_cluster", plane["monthly_cost"], [component]
+        ))
+    return resources
+
+
+def infracost_document(
+    estimate: Dict[str, Any],
+    kind: str,
+    project: Optional[str] = None,
+    estimate_id: Optional[str] = None,
+    hours: Optional[float] = None,
+    generated_at: Optional[datetime] = None,
+) -> Dict[str, Any]:
+    """
+    An estimate result in Infracost's JSON output schema
+
+    Args:
+        estimate: Estimate result (line items, a Kubernetes estimate or a plan estimate), as JSON
+        kind: Estimate type, e.g. resources, kubernetes or terraform
+        project: Project the estimate belongs to, the Infracost project name
+        estimate_id: ID of the recorded estimate
+        hours: Running hours per month of the estimate's hourly components, the estimate's time basis by default
+        generated_at: Time the estimate was made
+
+    Raises:
+        ValueError: If the estimate has none of the supported layouts
+    """
+    generated_at = generated_at or datetime.now(timezone.utc)
+    hours = hours or recorded_hours_per_month(estimate)
+    metadata: Dict[str, Any] = {"type": kind}
+    if estimate_id:
+        metadata["estimateId"] = estimate_id
+
+    if "added" in estimate or "monthly_delta" in estimate:
+        sections = _plan_projects(estimate, hours)
+        command = "diff"
+    elif "line_items" in estimate or "workloads" in estimate:
+        if "workloads" in estimate:
+            resources = _kubernetes_resources(estimate)
+            skipped = [*estimate.get("skipped", []), *estimate.get("unschedulable", [])]
+            unsupported = [name.split("/")[0] for name in skipped]
+        else:
+            resources = _line_item_resources(estimate)
+            unsupported = []
+        sections = {
+            "pastBreakdown": None,
+            "breakdown": _breakdown(resources, estimate.get("monthly_cost", 0.0)),
+            "diff": None,
+            "summary": _summary(resources, unsupported),
+        }
+        command = "breakdown"
+    else:
+        raise ValueError(f"{kind} estimates have no Infracost layout")
+
+    name = project or kind
+    past, current, diff = sections["pastBreakdown"], sections["breakdown"], sections["diff"]
+    return {
+        "version": INFRACOST_VERSION,
+        "metadata": {"infracostCommand": command, "generator": "kcloud-cost-estimator"},
+        "currency": estimate.get("currency", "USD"),
+        "projects": [{"name": name, "displayName": name, "metadata": metadata, **sections}],
+        "totalHourlyCost": current["totalHourlyCost"],
+        "totalMonthlyCost": current["totalMonthlyCost"],
+        "totalMonthlyUsageCost": current["totalMonthlyUsageCost"],
+        "pastTotalHourlyCost": past["totalHourlyCost"] if past else None,
+        "pastTotalMonthlyCost": past["totalMonthlyCost"] if past else None,
+        "pastTotalMonthlyUsageCost": past["totalMonthlyUsageCost"] if past else None,
+        "diffTotalHourlyCost": diff["totalHourlyCost"] if diff else None,
+        "diffTotalMonthlyCost": diff["totalMonthlyCost"] if diff else None,
+        "diffTotalMonthlyUsageCost": diff["totalMonthlyUsageCost"] if diff else None,
+        "timeGenerated": generated_at.astimezone(timezone.utc).strftime("%Y-%m-%dT%H:%M:%SZ"),
+        "summary": sections["summary"],
+    }
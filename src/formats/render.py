@@ -0,0 +1,244 @@
+"""
+Estimate and report output formats
+
+A response document is laid out as a summary and tables: its scalar
+fields (nested objects flattened to dotted names, lists of scalars
+joined) make up the summary, and every list of objects becomes a table
+whose columns are the scalar fields of its items. Lists nested in the
+items are left out; the JSON response keeps everything.
+
+CSV holds the summary and each table as sections, each headed by a row
+with its name and separated by an empty row, so a spreadsheet imports
+the whole document from one file.
+"""
+
+import csv
+import html
+import io
+from typing import Any, Dict, List, NamedTuple, Optional, Sequence, Tuple
+
+FORMAT_JSON = "json"
+FORMAT_CSV = "csv"
+FORMAT_MARKDOWN = "markdown"
+FORMAT_HTML = "html"
+
+FORMATS = (FORMAT_JSON, FORMAT_CSV, FORMAT_MARKDOWN, FORMAT_HTML)
+
+MEDIA_TYPES = {
+    FORMAT_JSON: "application/json",
+    FORMAT_CSV: "text/csv; charset=utf-8",
+    FORMAT_MARKDOWN: "text/markdown; charset=utf-8",
+    FORMAT_HTML: "text/html; charset=utf-8",
+}
+
+EXTENSIONS = {FORMAT_JSON: "json", FORMAT_CSV: "csv", FORMAT_MARKDOWN: "md", FORMAT_HTML: "html"}
+
+_ALIASES = {"md": FORMAT_MARKDOWN, "htm": FORMAT_HTML}
+
+_ACCEPTED = {
+    "application/json": FORMAT_JSON,
+    "text/csv": FORMAT_CSV,
+    "text/markdown": FORMAT_MARKDOWN,
+    "text/x-markdown": FORMAT_MARKDOWN,
+    "text/html": FORMAT_HTML,
+}
+
+# Rows a Markdown table shows before summarizing the rest, so comments stay readable
+MAX_MARKDOWN_ROWS = 50
+
+SUMMARY = "summary"
+
+
+class Table(NamedTuple):
+    """Rows of one list of objects in a document"""
+
+    name: str
+    columns: List[str]
+    rows: List[List[Any]]
+
+
+def negotiate_format(format: Optional[str], accept: Optional[str], allowed: Sequence[str] = FORMATS) -> str:
+    """
+    Output format of a request: the format parameter, else the most preferred type of its Accept header
+
+    Accept headers naming none of the formats (e.g. */*) get JSON.
+
+    Raises:
+        ValueError: If the format parameter names an unknown or disallowed format
+    """
+    if format:
+        name = format.strip().lower()
+        name = _ALIASES.get(name, name)
+        if name not in allowed:
+            raise ValueError(f"Unknown format {format!r}, expected one of: {', '.join(allowed)}")
+        return name
+
+    preferences: List[Tuple[float, int, str]] = []
+    for position, item in enumerate((accept or "").split(",")):
+        media, _, params = item.strip().partition(";")
+        quality = 1.0
+        for param in params.split(";"):
+            key, _, value = param.strip().partition("=")
+            if key == "q":
+                try:
+                    quality = float(value)
+                except ValueError:
+                    quality = 0.0
+        name = _ACCEPTED.get(media.strip().lower())
+        if name in allowed and quality > 0:
+            preferences.append((-quality, position, name))
+    return min(preferences)[2] if preferences else FORMAT_JSON
+
+
+def tabulate(document: Dict[str, Any]) -> Tuple[List[Tuple[str, Any]], List[Table]]:
+    """(summary fields, tables) of a document"""
+    summary: List[Tuple[str, Any]] = []
+    tables: List[Table] = []
+    _walk(document, "", summary, tables)
+    return summary, tables
+
+
+def _walk(document: Dict[str, Any], prefix: str, summary: List[Tuple[str, Any]], tables: List[Table]) -> None:
+    for key, value in document.items():
+        name = f"{prefix}{key}"
+        if isinstance(value, dict):
+            _walk(value, f"{name}.", summary, tables)
+        elif isinstance(value, list):
+            if value and all(isinstance(item, dict) for item in value):
+                tables.append(_table(name, value))
+            elif value and not any(isinstance(item, (dict, list)) for item in value):
+                summary.append((name, ", ".join(_text(item) for item in value)))
+        elif value is not None:
+            summary.append((name, value))
+
+
+def _table(name: str, items: List[Dict[str, Any]]) -> Table:
+    flattened = [dict(_scalars(item, "")) for item in items]
+    columns: List[str] = []
+    for row in flattened:
+        columns.extend(column for column in row if column not in columns)
+    return Table(name, columns, [[row.get(column) for column in columns] for row in flattened])
+
+
+def _scalars(item: Dict[str, Any], prefix: str) -> List[Tuple[str, Any]]:
+    fields: List[Tuple[str, Any]] = []
+    for key, value in item.items():
+        if isinstance(value, dict):
+            fields.extend(_scalars(value, f"{prefix}{key}."))
+        elif isinstance(value, list):
+            if not any(isinstance(v, (dict, list)) for v in value):
+                fields.append((f"{prefix}{key}", ", ".join(_text(v) for v in value)))
+        else:
+            fields.append((f"{prefix}{key}", value))
+    return fields
+
+
+def _text(value: Any) -> str:
+    """Cell text of a value: empty for None, true/false for booleans"""
+    if value is None:
+        return ""
+    if isinstance(value, bool):
+        return "true" if value else "false"
+    return str(value)
+
+
+def _numeric(value: Any) -> bool:
+    return isinstance(value, (int, float)) and not isinstance(value, bool)
+
+
+def render_csv(document: Dict[str, Any]) -> str:
+    """Document as CSV sections: the summary, then every table"""
+    summary, tables = tabulate(document)
+    out = io.StringIO()
+    writer = csv.writer(out)
+    writer.writerow([SUMMARY])
+    writer.writerow(["field", "value"])
+    for key, value in summary:
+        writer.writerow([key, _text(value)])
+    for table in tables:
+        writer.writerow([])
+        writer.writerow([table.name])
+        writer.writerow(table.columns)
+        for row in table.rows:
+            writer.writerow([_text(value) for value in row])
+    return out.getvalue()
+
+
+def render_markdown(document: Dict[str, Any], title: str) -> str:
+    """Document as Markdown: a field/value table of the summary, then every table"""
+    summary, tables = tabulate(document)
+    lines = [f"### {_cell(title)}", "", "| Field | Value |", "|---|---:|"]
+    lines += [f"| {_cell(key)} | {_cell(_text(value))} |" for key, value in summary]
+    for table in tables:
+        align = ["---:" if any(_numeric(row[i]) for row in table.rows) else "---" for i in range(len(table.columns))]
+        lines += [
+            "",
+            f"#### {_cell(table.name)}",
+            "",
+            "| " + " | ".join(_cell(column) for column in table.columns) + " |",
+            "|" + "|".join(align) + "|",
+        ]
+        for row in table.rows[:MAX_MARKDOWN_ROWS]:
+            lines.append("| " + " | ".join(_cell(_text(value)) for value in row) + " |")
+        if len(table.rows) > MAX_MARKDOWN_ROWS:
+            lines.append(f"| … {len(table.rows) - MAX_MARKDOWN_ROWS} more |" + " |" * (len(table.columns) - 1))
+    return "\n".join(lines) + "\n"
+
+
+def _cell(text: str) -> str:
+    """Markdown table cell text; pipes and line breaks would end the cell"""
+    return text.replace("|", "\\|").replace("\n", " ")
+
+
+_STYLE = """
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; font-size: 13px; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f3f3f3; }
+td.number { text-align: right; font-variant-numeric: tabular-nums; }
+"""
+
+
+def render_html(document: Dict[str, Any], title: str) -> str:
+    """Document as a standalone HTML page, without scripts or external assets"""
+    summary, tables = tabulate(document)
+    escaped = html.escape(title)
+    parts = [
+        "<!DOCTYPE html>",
+        '<html lang="en">',
+        f'<head><meta charset="utf-8"><title>{escaped}</title><style>{_STYLE}</style></head>',
+        "<body>",
+        f"<h1>{escaped}</h1>",
+        "<table>",
+    ]
+    parts += [f"<tr><th>{html.escape(key)}</th>{_html_cell(value)}</tr>" for key, value in summary]
+    parts.append("</table>")
+    for table in tables:
+        parts.append(f"<h2>{html.escape(table.name)}</h2>")
+        parts.append("<table>")
+        parts.append("<tr>" + "".join(f"<th>{html.escape(column)}</th>" for column in table.columns) + "</tr>")
+        parts += ["<tr>" + "".join(_html_cell(value) for value in row) + "</tr>" for row in table.rows]
+        parts.append("</table>")
+    parts += ["</body>", "</html>"]
+    return "\n".join(parts) + "\n"
+
+
+def _html_cell(value: Any) -> str:
+    css = ' class="number"' if _numeric(value) else ""
+    return f"<td{css}>{html.escape(_text(value))}</td>"
+
+
+def render_document(document: Dict[str, Any], format: str, title: str) -> str:
+    """
+    Document in a format other than JSON
+
+    Raises:
+        ValueError: If the format is not csv, markdown or html
+    """
+    if format == FORMAT_CSV:
+        return render_csv(document)
+    if format == FORMAT_MARKDOWN:
+        return render_markdown(document, title)
+    if format == FORMAT_HTML:
+        return render_html(document, title)
+    raise ValueError(f"Cannot render {format!r}, expected csv, markdown or html")
@@ -0,0 +1,49 @@
+"""
+Grafana Module
+
+This module serves estimate history, actual spend, budgets and forecasts
+through the JSON datasource protocol of Grafana's Simple JSON and JSON
+API plugins.
+"""
+
+from .models import (
+    TimeRange,
+    Target,
+    AdhocFilter,
+    GrafanaSearchRequest,
+    GrafanaQueryRequest,
+    Annotation,
+    GrafanaAnnotationRequest,
+)
+from .datasource import (
+    GrafanaDatasource,
+    METRIC_ESTIMATES,
+    METRIC_ACTUAL,
+    METRIC_ACTUAL_MONTH_TO_DATE,
+    METRIC_BUDGET,
+    METRIC_FORECAST,
+    METRIC_FORECAST_LOWER,
+    METRIC_FORECAST_UPPER,
+    METRICS,
+    parse_target,
+)
+
+__all__ = [
+    "TimeRange",
+    "Target",
+    "AdhocFilter",
+    "GrafanaSearchRequest",
+    "GrafanaQueryRequest",
+    "Annotation",
+    "GrafanaAnnotationRequest",
+    "GrafanaDatasource",
+    "METRIC_ESTIMATES",
+    "METRIC_ACTUAL",
+    "METRIC_ACTUAL_MONTH_TO_DATE",
+    "METRIC_BUDGET",
+    "METRIC_FORECAST",
+    "METRIC_FORECAST_LOWER",
+    "METRIC_FORECAST_UPPER",
+    "METRICS",
+    "parse_target",
+]
@@ -0,0 +1,372 @@
+"""
+Grafana JSON datasource
+
+Serves estimate history, actual spend, budgets and forecasts in the JSON
+datasource protocol (/search, /query, /annotations) of the Simple JSON
+and JSON API Grafana plugins, so panels chart them without an exporter.
+
+A target names a metric, optionally followed by filters in braces:
+
+    actual_cost{service=compute,label.team=core,by=project}
+
+Filters also come from a target's data or payload object and from the
+dashboard's ad hoc filters; by splits a metric into one series per value
+of a field. Timestamps are Unix milliseconds, days starting at 00:00 UTC.
+"""
+
+import logging
+import re
+from datetime import date, datetime, timedelta, timezone
+from typing import Any, Callable, Dict, Iterable, List, Optional, Tuple
+
+from ..forecast import Forecaster, MAX_HORIZON_DAYS
+from ..money import round_money
+from ..store import EstimateRecord, Store
+from .models import GrafanaAnnotationRequest, GrafanaQueryRequest, Target
+
+logger = logging.getLogger(__name__)
+
+# Monthly cost of each recorded estimate, at the time it was made
+METRIC_ESTIMATES = "estimates"
+# Actual spend by usage day, and accumulated over each calendar month
+METRIC_ACTUAL = "actual_cost"
+METRIC_ACTUAL_MONTH_TO_DATE = "actual_cost_month_to_date"
+# Monthly amount of each budget
+METRIC_BUDGET = "budget"
+# Forecast daily spend and its confidence interval, from today
+METRIC_FORECAST = "forecast"
+METRIC_FORECAST_LOWER = "forecast_lower"
+METRIC_FORECAST_UPPER = "forecast_upper"
+
+METRICS = (
+    METRIC_ESTIMATES,
+    METRIC_ACTUAL,
+    METRIC_ACTUAL_MONTH_TO_DATE,
+    METRIC_BUDGET,
+    METRIC_FORECAST,
+    METRIC_FORECAST_LOWER,
+    METRIC_FORECAST_UPPER,
+)
+
+TYPE_TABLE = "table"
+
+# Filters each metric accepts besides label.<key>; by takes the same fields
+_ESTIMATE_FIELDS = ("project", "kind")
+_ACTUAL_FIELDS = ("project", "provider", "service", "region", "account_id", "source")
+_FILTERS = {
+    METRIC_ESTIMATES: _ESTIMATE_FIELDS + ("by",),
+    METRIC_ACTUAL: _ACTUAL_FIELDS + ("by",),
+    METRIC_ACTUAL_MONTH_TO_DATE: _ACTUAL_FIELDS + ("by",),
+    METRIC_BUDGET: ("name", "project"),
+    METRIC_FORECAST: ("project", "model", "confidence"),
+    METRIC_FORECAST_LOWER: ("project", "model", "confidence"),
+    METRIC_FORECAST_UPPER: ("project", "model", "confidence"),
+}
+_LABEL_PREFIX = "label."
+# Metrics without label.<key> filters
+_UNLABELED = (METRIC_BUDGET,)
+
+# Most estimates a series holds when the panel sets no maxDataPoints
+MAX_ESTIMATES = 10000
+
+_TARGET = re.compile(r"^\s*([a-z_]+)\s*(?:\{(.*)\})?\s*$")
+
+Series = Dict[str, Any]
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+def parse_target(target: str) -> Tuple[str, Dict[str, str]]:
+    """
+    (metric, filters) of a target
+
+    Raises:
+        ValueError: If the target is malformed or names an unknown metric
+    """
+    match = _TARGET.match(target or "")
+    if not match or match.group(1) not in METRICS:
+        raise ValueError(f"Unknown target {target!r}, expected one of {', '.join(METRICS)} with optional {{filters}}")
+    filters: Dict[str, str] = {}
+    for item in (match.group(2) or "").split(","):
+        if not item.strip():
+            continue
+        key, sep, value = item.partition("=")
+        if not sep or not key.strip():
+            raise ValueError(f"Malformed filter {item.strip()!r} in target {target!r}, expected key=value")
+        filters[key.strip()] = value.strip().strip('"')
+    return match.group(1), filters
+
+
+def timestamp_ms(value: datetime) -> int:
+    """Unix milliseconds of a time, naive times taken as UTC"""
+    if value.tzinfo is None:
+        value = value.replace(tzinfo=timezone.utc)
+    return int(value.timestamp() * 1000)
+
+
+def day_ms(day: date) -> int:
+    """Unix milliseconds of a day's start (UTC)"""
+    return timestamp_ms(datetime(day.year, day.month, day.day, tzinfo=timezone.utc))
+
+
+class GrafanaDatasource:
+    """Answers Grafana JSON datasource queries from a tenant's stored costs"""
+
+    def __init__(
+        self,
+        store: Store,
+        forecaster: Optional[Forecaster] = None,
+        clock: Callable[[], datetime] = utcnow,
+    ):
+        """
+        Initialize datasource
+
+        Args:
+            store: Store holding estimates, actual costs and budgets
+            forecaster: Forecaster of the forecast metrics, None leaves them empty
+            clock: Current time (aware UTC)
+        """
+        self.store = store
+        self.forecaster = forecaster
+        self.clock = clock
+
+    def search(self, target: str = "") -> List[str]:
+        """Metric names containing a search string"""
+        needle = (target or "").strip().lower()
+        return [metric for metric in METRICS if needle in metric]
+
+    def query(self, tenant_id: str, request: GrafanaQueryRequest) -> List[Series]:
+        """
+        Series (or tables) of a panel's targets
+
+        Raises:
+            ValueError: If a target is malformed or uses an unknown filter
+        """
+        start, end = request.range.from_, request.range.to
+        adhoc = {f.key: f.value for f in request.adhoc_filters if f.operator == "="}
+        results: List[Series] = []
+        for target in request.targets:
+            if not target.target:
+                continue
+            metric, filters = self._filters(target, adhoc)
+            series = self._series(tenant_id, metric, filters, start, end, request.max_data_points)
+            if target.type == TYPE_TABLE:
+                results.append(_table(series))
+            else:
+                results.extend(series)
+        return results
+
+    def annotations(self, tenant_id: str, request: GrafanaAnnotationRequest) -> List[Dict[str, Any]]:
+        """
+        Recorded estimates in a time range as annotations
+
+        The annotation query takes the estimates metric's filters, e.g.
+        estimates{project=web,kind=terraform}.
+
+        Raises:
+            ValueError: If the query is malformed or names another metric
+        """
+        query = request.annotation.query.strip() or METRIC_ESTIMATES
+        metric, filters = parse_target(query)
+        if metric != METRIC_ESTIMATES:
+            raise ValueError(f"Annotations are made of {METRIC_ESTIMATES}, not {metric}")
+        _check_filters(metric, filters)
+        annotation = request.annotation.dict(by_alias=True)
+        events = []
+        for record in self._estimates(tenant_id, filters, request.range.from_, request.range.to, None):
+            project = record.project or "no project"
+            events.append({
+                "annotation": annotation,
+                "time": timestamp_ms(record.created_at),
+                "title": f"{record.kind} estimate",
+                "text": f"{project}: ${record.monthly_cost:,.2f}/mo",
+                "tags": [record.kind, project],
+            })
+        return events
+
+    def _filters(self, target: Target, adhoc: Dict[str, str]) -> Tuple[str, Dict[str, str]]:
+        metric, filters = parse_target(target.target)
+        extra = {**(target.data or {}), **(target.payload or {})}
+        allowed = _FILTERS[metric]
+        # Dashboard-wide filters apply where the metric has the field
+        merged = {
+            k: v for k, v in adhoc.items()
+            if k in allowed or (metric not in _UNLABELED and k.startswith(_LABEL_PREFIX))
+        }
+        merged.update({k: str(v) for k, v in extra.items() if v is not None})
+        merged.update(filters)
+        _check_filters(metric, merged)
+        return metric, merged
+
+    def _series(
+        self,
+        tenant_id: str,
+        metric: str,
+        filters: Dict[str, str],
+        start: datetime,
+        end: datetime,
+        max_points: Optional[int],
+    ) -> List[Series]:
+        by = filters.pop("by", None)
+        if metric == METRIC_ESTIMATES:
+            records = self._estimates(tenant_id, filters, start, end, max_points)
+            points = [(_field(r, by), r.monthly_cost, timestamp_ms(r.created_at)) for r in reversed(records)]
+            return _group(metric, by, points)
+        if metric in (METRIC_ACTUAL, METRIC_ACTUAL_MONTH_TO_DATE):
+            return self._actuals(tenant_id, metric, filters, by, start, end)
+        if metric == METRIC_BUDGET:
+            return self._budgets(tenant_id, filters, start, end)
+        return self._forecast(tenant_id, metric, filters, start, end)
+
+    def _estimates(
+        self, tenant_id: str, filters: Dict[str, str], start: datetime, end: datetime, max_points: Optional[int]
+    ) -> List[EstimateRecord]:
+        """Estimates made in the range, newest first"""
+        records = self.store.list_estimates(
+            project=filters.get("project"),
+            since=start,
+            until=end,
+            limit=min(max_points or MAX_ESTIMATES, MAX_ESTIMATES),
+            tenant_id=tenant_id,
+        )
+        return [r for r in records if _matches(r, filters)]
+
+    def _actuals(
+        self,
+        tenant_id: str,
+        metric: str,
+        filters: Dict[str, str],
+        by: Optional[str],
+        start: datetime,
+        end: datetime,
+    ) -> List[Series]:
+        first, last = start.date(), end.date() + timedelta(days=1)
+        if metric == METRIC_ACTUAL_MONTH_TO_DATE:
+            # The month-to-date sum of the first day includes the spend before it
+            first = first.replace(day=1)
+        daily: Dict[Optional[str], Dict[date, float]] = {}
+        for record in self.store.list_actual_costs(tenant_id, first, last, project=filters.get("project")):
+            if _matches(record, filters):
+                days = daily.setdefault(_field(record, by), {})
+                days[record.usage_date] = days.get(record.usage_date, 0.0) + record.amount
+
+        shown = start.date()
+        points: List[Tuple[Optional[str], float, int]] = []
+        for key, days in daily.items():
+            total = 0.0
+            month = None
+            for day in sorted(days):
+                if metric == METRIC_ACTUAL_MONTH_TO_DATE:
+                    if (day.year, day.month) != month:
+                        month, total = (day.year, day.month), 0.0
+                    total += days[day]
+                    value = total
+                else:
+                    value = days[day]
+                if day >= shown:
+                    points.append((key, round_money(value), day_ms(day)))
+        return _group(metric, by, points)
+
+    def _budgets(self, tenant_id: str, filters: Dict[str, str], start: datetime, end: datetime) -> List[Series]:
+        """One flat series per budget at its monthly amount"""
+        series = []
+        for record in sorted(self.store.list_budgets(tenant_id), key=lambda r: r.name):
+            if "name" in filters and record.name != filters["name"]:
+                continue
+            if "project" in filters and record.project != filters["project"]:
+                continue
+            series.append({
+                "target": f"{METRIC_BUDGET} {record.name}",
+                "datapoints": [[record.amount, timestamp_ms(start)], [record.amount, timestamp_ms(end)]],
+            })
+        return series
+
+    def _forecast(
+        self, tenant_id: str, metric: str, filters: Dict[str, str], start: datetime, end: datetime
+    ) -> List[Series]:
+        """Forecast from today up to the range's end; empty if nothing can be forecast"""
+        series: Series = {"target": metric, "datapoints": []}
+        today = self.clock().date()
+        # Through the day the range ends on
+        horizon = (end.astimezone(timezone.utc).date() - today).days + 1
+        if self.forecaster is None or horizon < 1:
+            return [series]
+        try:
+            confidence = float(filters.get("confidence", 0.95))
+        except ValueError:
+            raise ValueError(f"Malformed confidence {filters['confidence']!r}, expected e.g. 0.95") from None
+        labels = {k[len(_LABEL_PREFIX):]: v for k, v in filters.items() if k.startswith(_LABEL_PREFIX)}
+        try:
+            forecast = self.forecaster.forecast(
+                tenant_id,
+                horizon_days=min(horizon, MAX_HORIZON_DAYS),
+                model=filters.get("model"),
+                confidence=confidence,
+                project=filters.get("project"),
+                labels=labels,
+            )
+        except ValueError as e:
+            # Too little history leaves the panel empty rather than failing the dashboard
+            logger.info(f"No {metric} for tenant {tenant_id}: {e}")
+            return [series]
+
+        field = {METRIC_FORECAST: "cost", METRIC_FORECAST_LOWER: "lower", METRIC_FORECAST_UPPER: "upper"}[metric]
+        shown = start.date()
+        series["datapoints"] = [
+            [getattr(point, field), day_ms(point.date)] for point in forecast.points if point.date >= shown
+        ]
+        return [series]
+
+
+def _check_filters(metric: str, filters: Dict[str, str]) -> None:
+    allowed = _FILTERS[metric]
+    labeled = metric not in _UNLABELED
+    for key in filters:
+        if key in allowed or (labeled and key.startswith(_LABEL_PREFIX) and len(key) > len(_LABEL_PREFIX)):
+            continue
+        raise ValueError(f"Unknown filter {key!r} of {metric}, expected one of: {', '.join(allowed)}, label.<key>")
+    by = filters.get("by")
+    if by is not None and by not in allowed and not by.startswith(_LABEL_PREFIX):
+        raise ValueError(f"Cannot split {metric} by {by!r}")
+
+
+def _field(record: Any, field: Optional[str]) -> Optional[str]:
+    """Value of a record's field or label.<key>, None without a field"""
+    if field is None:
+        return None
+    if field.startswith(_LABEL_PREFIX):
+        return record.labels.get(field[len(_LABEL_PREFIX):])
+    return getattr(record, field, None)
+
+
+def _matches(record: Any, filters: Dict[str, str]) -> bool:
+    return all((_field(record, key) or "") == value for key, value in filters.items())
+
+
+def _group(metric: str, by: Optional[str], points: Iterable[Tuple[Optional[str], float, int]]) -> List[Series]:
+    """Series of (group, value, time) points, one per group when split"""
+    grouped: Dict[Optional[str], List[List[float]]] = {}
+    for key, value, time in points:
+        grouped.setdefault(key, []).append([value, time])
+    if by is None:
+        return [{"target": metric, "datapoints": grouped.get(None, [])}]
+    return [
+        {"target": f"{metric} {key or f'no {by}'}", "datapoints": sorted(grouped[key], key=lambda p: p[1])}
+        for key in sorted(grouped, key=lambda k: k or "")
+    ]
+
+
+def _table(series: List[Series]) -> Dict[str, Any]:
+    """Table of the points of series, one row per point"""
+    rows = [[time, s["target"], value] for s in series for value, time in s["datapoints"]]
+    return {
+        "type": TYPE_TABLE,
+        "columns": [
+            {"text": "Time", "type": "time"},
+            {"text": "Series", "type": "string"},
+            {"text": "Value", "type": "number"},
+        ],
+        "rows": sorted(rows, key=lambda r: (r[0], r[1])),
+    }
@@ -0,0 +1,64 @@
+"""
+Data models of the Grafana JSON datasource protocol
+"""
+
+from datetime import datetime
+from typing import Any, Dict, List, Optional
+
+from pydantic import BaseModel, Field
+
+
+class TimeRange(BaseModel):
+    """Dashboard time range"""
+
+    from_: datetime = Field(..., alias="from")
+    to: datetime
+
+
+class Target(BaseModel):
+    """One query of a panel"""
+
+    target: str = Field("", description="Metric with optional filters, e.g. actual_cost{service=compute,by=project}")
+    ref_id: Optional[str] = Field(None, alias="refId")
+    type: str = Field("timeserie", description="timeserie or table")
+    data: Optional[Dict[str, Any]] = Field(None, description="Extra filters (Simple JSON datasource)")
+    payload: Optional[Dict[str, Any]] = Field(None, description="Extra filters (JSON API datasource)")
+
+
+class AdhocFilter(BaseModel):
+    """Dashboard-wide key=value filter"""
+
+    key: str
+    operator: str = "="
+    value: str
+
+
+class GrafanaSearchRequest(BaseModel):
+    """POST /search"""
+
+    target: str = ""
+
+
+class GrafanaQueryRequest(BaseModel):
+    """POST /query"""
+
+    range: TimeRange
+    targets: List[Target] = Field(default_factory=list)
+    max_data_points: Optional[int] = Field(None, alias="maxDataPoints")
+    adhoc_filters: List[AdhocFilter] = Field(default_factory=list, alias="adhocFilters")
+
+
+class Annotation(BaseModel):
+    """Annotation query of a dashboard"""
+
+    name: str = ""
+    query: str = Field("", description="Annotated events with optional filters, default estimates")
+    enable: bool = True
+    icon_color: Optional[str] = Field(None, alias="iconColor")
+
+
+class GrafanaAnnotationRequest(BaseModel):
+    """POST /annotations"""
+
+    range: TimeRange
+    annotation: Annotation = Field(default_factory=Annotation)
@@ -0,0 +1,15 @@
+"""
+gRPC API Module
+
+EstimateService (Estimate, Compare, GetCatalog) for internal services that
+integrate over gRPC instead of the JSON HTTP API. The protobuf modules are
+generated from estimate_service.proto with `make proto`.
+"""
+
+from .server import EstimateServicer, start_grpc_server, DEFAULT_SHUTDOWN_GRACE
+
+__all__ = [
+    "EstimateServicer",
+    "start_grpc_server",
+    "DEFAULT_SHUTDOWN_GRACE",
+]
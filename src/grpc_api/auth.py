@@ -0,0 +1,83 @@
+"""
+Authentication of gRPC calls
+
+Applies the HTTP API's credentials, scopes, rate limits and tenant
+binding to RPCs: credentials are read from the x-api-key or authorization
+metadata, and operators may name a tenant in x-tenant-id.
+"""
+
+import asyncio
+from typing import Optional
+
+import grpc
+
+from ..auth import Authenticator, AuthError, SCOPE_READ, SCOPE_ESTIMATE
+from ..tenancy import resolve_tenant, tenant_context
+
+# RPCs that only read; every other RPC needs the estimate scope
+_READ_METHODS = frozenset({"GetCatalog"})
+
+_STATUS = {
+    401: grpc.StatusCode.UNAUTHENTICATED,
+    403: grpc.StatusCode.PERMISSION_DENIED,
+    429: grpc.StatusCode.RESOURCE_EXHAUSTED,
+}
+
+
+def _deny(code: grpc.StatusCode, detail: str) -> grpc.RpcMethodHandler:
+    async def abort(request, context):
+        await context.abort(code, detail)
+
+    return grpc.unary_unary_rpc_method_handler(abort)
+
+
+def _with_tenant(handler: grpc.RpcMethodHandler, tenant_id: str) -> grpc.RpcMethodHandler:
+    """Handler running a unary RPC bound to a tenant"""
+    if handler is None or handler.unary_unary is None:
+        return handler
+    behavior = handler.unary_unary
+
+    async def bound(request, context):
+        with tenant_context(tenant_id):
+            return await behavior(request, context)
+
+    return handler._replace(unary_unary=bound)
+
+
+class AuthInterceptor(grpc.aio.ServerInterceptor):
+    """Rejects RPCs without valid credentials for the method's scope and binds the caller's tenant"""
+
+    def __init__(self, authenticator: Optional[Authenticator] = None):
+        """
+        Initialize interceptor
+
+        Args:
+            authenticator: Credentials check. RPCs are not authenticated if not provided.
+        """
+        self.authenticator = authenticator
+
+    async def intercept_service(self, continuation, handler_call_details):
+        metadata = dict(handler_call_details.invocation_metadata or ())
+        method = handler_call_details.method.rsplit("/", 1)[-1]
+        scope = SCOPE_READ if method in _READ_METHODS else SCOPE_ESTIMATE
+
+        principal = None
+        if self.authenticator is not None:
+            try:
+                principal = await asyncio.to_thread(
+                    self.authenticator.check,
+                    scope,
+                    metadata.get("x-api-key"),
+                    metadata.get("authorization"),
+                )
+            except AuthError as e:
+                return _deny(_STATUS.get(e.status_code, grpc.StatusCode.UNAUTHENTICATED), e.detail)
+
+        try:
+            tenant_id = resolve_tenant(metadata.get("x-tenant-id"), principal)
+        except PermissionError as e:
+            return _deny(grpc.StatusCode.PERMISSION_DENIED, str(e))
+        except ValueError as e:
+            return _deny(grpc.StatusCode.INVALID_ARGUMENT, str(e))
+
+        return _with_tenant(await continuation(handler_call_details), tenant_id)
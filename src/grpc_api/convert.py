@@ -0,0 +1,47 @@
+"""
+Conversion between protobuf messages and the API models
+
+Message fields carry the same names as the pydantic models, so messages
+convert through their dict form. Unset fields are left out and take the
+model defaults, as omitted JSON fields do over HTTP.
+"""
+
+from typing import Any, Dict, Type, TypeVar
+
+from google.protobuf import json_format
+from google.protobuf.message import Message
+from pydantic import BaseModel
+
+ModelT = TypeVar("ModelT", bound=BaseModel)
+MessageT = TypeVar("MessageT", bound=Message)
+
+
+def message_to_dict(message: Message) -> Dict[str, Any]:
+    """Set fields of a message, keyed by proto field name"""
+    return json_format.MessageToDict(message, preserving_proto_field_name=True)
+
+
+def to_model(message: Message, model: Type[ModelT]) -> ModelT:
+    """
+    Build an API model from a request message
+
+    Raises:
+        ValueError: If the message fails model validation
+    """
+    return model(**message_to_dict(message))
+
+
+def to_message(data: Dict[str, Any], message: Type[MessageT]) -> MessageT:
+    """Build a response message from a result dict, ignoring fields the message lacks"""
+    return json_format.ParseDict(data, message(), ignore_unknown_fields=True)
+
+
+def compare_request_dict(message: Message) -> Dict[str, Any]:
+    """CompareRequest fields with RegionList wrappers flattened to lists"""
+    data = message_to_dict(message)
+    if "regions" in data:
+        data["regions"] = {
+            provider: wrapper.get("regions", [])
+            for provider, wrapper in data["regions"].items()
+        }
+    return data
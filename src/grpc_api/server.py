@@ -0,0 +1,170 @@
+"""
+gRPC EstimateService
+
+Serves the estimator, comparer and price catalog over gRPC on its own
+port, next to the HTTP API. Requests are validated by the same models and
+estimates are recorded in the same history.
+"""
+
+import logging
+from typing import Optional
+
+import grpc
+
+from ..auth import Authenticator
+from ..budgets import BudgetEvaluator
+from ..compare import Comparer, CompareRequest
+from ..estimator import CostEstimator, EstimateRequest, PRICING_MODEL_VERSION
+from ..metrics import observe_estimate
+from ..pricing import (
+    PriceQuery,
+    ProviderRegistry,
+    PriceNotFoundError,
+    ProviderNotFoundError,
+    SERVICE_COMPUTE,
+    PRICING_ON_DEMAND,
+    known_instance_types,
+)
+from ..store import Store, record_estimate, KIND_RESOURCES
+from ..tenancy import current_tenant
+from . import estimate_service_pb2 as pb
+from . import estimate_service_pb2_grpc as pb_grpc
+from .auth import AuthInterceptor
+from .convert import compare_request_dict, to_model, to_message
+
+logger = logging.getLogger(__name__)
+
+# Seconds in-flight RPCs may take to finish on shutdown
+DEFAULT_SHUTDOWN_GRACE = 5.0
+
+
+class EstimateServicer(pb_grpc.EstimateServiceServicer):
+    """EstimateService backed by the HTTP API's components"""
+
+    def __init__(
+        self,
+        estimator: CostEstimator,
+        comparer: Comparer,
+        registry: ProviderRegistry,
+        store: Optional[Store] = None,
+        budgets: Optional[BudgetEvaluator] = None,
+    ):
+        """
+        Initialize servicer
+
+        Args:
+            estimator: Resource cost estimator
+            comparer: Cross-provider comparer
+            registry: Pricing providers queried by GetCatalog
+            store: Estimate history. Estimates are not recorded if not provided.
+            budgets: Budgets estimates are checked against
+        """
+        self.estimator = estimator
+        self.comparer = comparer
+        self.registry = registry
+        self.store = store
+        self.budgets = budgets
+
+    async def Estimate(self, request, context):
+        try:
+            estimate_request = to_model(request, EstimateRequest)
+            with observe_estimate("grpc_estimate", [r.provider for r in estimate_request.resources]):
+                result = self.estimator.estimate(estimate_request)
+        except Exception as e:
+            await _abort(context, "Cost estimation", e)
+
+        record = record_estimate(
+            self.store, KIND_RESOURCES,
+            tenant_id=current_tenant(),
+            request=estimate_request.dict(exclude={"project", "labels"}),
+            result=result.dict(),
+            monthly_cost=result.monthly_cost,
+            pricing_model_version=PRICING_MODEL_VERSION,
+            project=estimate_request.project,
+            labels=estimate_request.labels,
+        )
+        warnings = []
+        if self.budgets is not None:
+            warnings = self.budgets.warnings(
+                result.monthly_cost, estimate_request.project, estimate_request.labels
+            )
+        return pb.EstimateResponse(
+            estimate_id=record.id if record else "",
+            estimate=to_message(result.dict(), pb.EstimateResult),
+            budget_warnings=[to_message(w.dict(), pb.BudgetWarning) for w in warnings],
+        )
+
+    async def Compare(self, request, context):
+        try:
+            compare_request = CompareRequest(**compare_request_dict(request))
+            with observe_estimate("grpc_compare", compare_request.providers):
+                result = self.comparer.compare(compare_request)
+        except Exception as e:
+            await _abort(context, "Cost comparison", e)
+
+        return pb.CompareResponse(comparison=to_message(result.dict(), pb.CompareResult))
+
+    async def GetCatalog(self, request, context):
+        if not request.provider or not request.region:
+            await context.abort(grpc.StatusCode.INVALID_ARGUMENT, "provider and region are required")
+
+        service = request.service or SERVICE_COMPUTE
+        skus = list(request.skus)
+        if not skus and service == SERVICE_COMPUTE:
+            skus = sorted(known_instance_types(request.provider))
+
+        response = pb.GetCatalogResponse()
+        for sku in skus:
+            query = PriceQuery(
+                provider=request.provider,
+                region=request.region,
+                sku=sku,
+                service=service,
+                pricing_model=request.pricing_model or PRICING_ON_DEMAND,
+            )
+            try:
+                price = self.registry.get_price(query)
+            except PriceNotFoundError:
+                response.missing.append(sku)
+                continue
+            except Exception as e:
+                await _abort(context, "Catalog lookup", e)
+            response.prices.append(to_message(price.dict(exclude={"effective_date"}), pb.CatalogPrice))
+
+        return response
+
+
+async def _abort(context, operation: str, error: Exception) -> None:
+    """Fail an RPC with the status matching the HTTP error mapping"""
+    if isinstance(error, PriceNotFoundError):
+        await context.abort(grpc.StatusCode.NOT_FOUND, str(error))
+    if isinstance(error, (ValueError, ProviderNotFoundError)):
+        await context.abort(grpc.StatusCode.INVALID_ARGUMENT, str(error))
+    logger.error(f"{operation} failed: {error}")
+    await context.abort(grpc.StatusCode.INTERNAL, f"{operation} failed: {error}")
+
+
+async def start_grpc_server(
+    servicer: EstimateServicer,
+    host: str,
+    port: int,
+    authenticator: Optional[Authenticator] = None,
+) -> grpc.aio.Server:
+    """
+    Start serving EstimateService
+
+    Args:
+        servicer: Service implementation
+        host: Listen address
+        port: Listen port
+        authenticator: Credentials check of every RPC. Calls are not authenticated if not provided.
+
+    Returns:
+        Running server; stop it with `await server.stop(grace)`
+    """
+    server = grpc.aio.server(interceptors=[AuthInterceptor(authenticator)])
+    pb_grpc.add_EstimateServiceServicer_to_server(servicer, server)
+    server.add_insecure_port(f"{host}:{port}")
+    await server.start()
+    logger.info(f"gRPC EstimateService listening on {host}:{port}")
+    return server
@@ -0,0 +1,14 @@
+"""
+Helm Chart Cost Estimation Module
+
+Renders Helm charts server-side so their manifests can be priced by the
+Kubernetes estimator.
+"""
+
+from .renderer import HelmRenderer, HelmRenderError, HelmUnavailableError
+
+__all__ = [
+    "HelmRenderer",
+    "HelmRenderError",
+    "HelmUnavailableError",
+]
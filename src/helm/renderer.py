@@ -0,0 +1,158 @@
+"""
+Helm chart renderer
+
+Renders charts server-side with `helm template` so the resulting
+manifests can be priced by the Kubernetes estimator. Charts are either
+an uploaded chart archive or a chart reference resolved from a repository.
+"""
+
+import logging
+import os
+import subprocess
+import tempfile
+from typing import List, Optional
+from urllib.parse import urlparse
+
+logger = logging.getLogger(__name__)
+
+# Repository URL schemes helm is allowed to fetch from
+ALLOWED_REPO_SCHEMES = ("https", "http", "oci")
+
+
+class HelmRenderError(ValueError):
+    """Raised when a chart cannot be rendered (invalid chart, values or reference)"""
+
+
+class HelmUnavailableError(RuntimeError):
+    """Raised when the helm binary cannot be executed"""
+
+
+class HelmRenderer:
+    """Renders Helm charts with the helm CLI"""
+
+    def __init__(self, helm_binary: str = "helm", timeout_seconds: int = 60):
+        """
+        Initialize Helm renderer
+
+        Args:
+            helm_binary: Path or name of the helm executable
+            timeout_seconds: Maximum time for one render, including chart download
+        """
+        self.helm_binary = helm_binary
+        self.timeout_seconds = timeout_seconds
+
+    def render_archive(
+        self,
+        chart_archive: bytes,
+        values: Optional[str] = None,
+        release_name: str = "estimate",
+        namespace: str = "default",
+    ) -> str:
+        """
+        Render an uploaded chart archive (.tgz)
+
+        Returns:
+            Rendered multi-document manifest YAML
+
+        Raises:
+            HelmRenderError: If helm rejects the chart or values
+            HelmUnavailableError: If helm cannot be run
+        """
+        with tempfile.TemporaryDirectory(prefix="kcloud-helm-") as workdir:
+            chart_path = os.path.join(workdir, "chart.tgz")
+            with open(chart_path, "wb") as f:
+                f.write(chart_archive)
+            return self._template(workdir, chart_path, values, release_name, namespace)
+
+    def render_reference(
+        self,
+        chart: str,
+        repo_url: Optional[str] = None,
+        version: Optional[str] = None,
+        values: Optional[str] = None,
+        release_name: str = "estimate",
+        namespace: str = "default",
+    ) -> str:
+        """
+        Render a chart from a repository (chart name plus repo URL, or an oci:// reference)
+
+        Raises:
+            HelmRenderError: If the reference is invalid or helm rejects it
+            HelmUnavailableError: If helm cannot be run
+        """
+        if chart.startswith("oci://"):
+            _check_repo_url(chart)
+        elif not repo_url:
+            raise HelmRenderError("repo_url is required unless chart is an oci:// reference")
+        elif chart.startswith(("-", "/", ".")) or "://" in chart:
+            raise HelmRenderError(f"Invalid chart name '{chart}'")
+
+        extra = []
+        if repo_url:
+            _check_repo_url(repo_url)
+            extra += ["--repo", repo_url]
+        if version:
+            extra += ["--version", version]
+
+        with tempfile.TemporaryDirectory(prefix="kcloud-helm-") as workdir:
+            return self._template(workdir, chart, values, release_name, namespace, extra)
+
+    def _template(
+        self,
+        workdir: str,
+        chart: str,
+        values: Optional[str],
+        release_name: str,
+        namespace: str,
+        extra_args: Optional[List[str]] = None,
+    ) -> str:
+        args = [
+            self.helm_binary, "template", release_name, chart,
+            "--namespace", namespace,
+            "--include-crds",
+            "--skip-tests",
+        ]
+        if values:
+            values_path = os.path.join(workdir, "values.yaml")
+            with open(values_path, "w", encoding="utf-8") as f:
+                f.write(values)
+            args += ["--values", values_path]
+        args += extra_args or []
+
+        # Keep helm's repository cache and config inside the scratch directory
+        env = dict(os.environ)
+        env.update({
+            "HELM_CACHE_HOME": os.path.join(workdir, "cache"),
+            "HELM_CONFIG_HOME": os.path.join(workdir, "config"),
+            "HELM_DATA_HOME": os.path.join(workdir, "data"),
+        })
+
+        try:
+            completed = subprocess.run(
+                args,
+                capture_output=True,
+                text=True,
+                timeout=self.timeout_seconds,
+                cwd=workdir,
+                env=env,
+            )
+        except FileNotFoundError:
+            raise HelmUnavailableError(f"helm binary not found: {self.helm_binary}") from None
+        except subprocess.TimeoutExpired:
+            raise HelmRenderError(f"helm template timed out after {self.timeout_seconds}s") from None
+
+        if completed.returncode != 0:
+            message = (completed.stderr or completed.stdout).strip()
+            raise HelmRenderError(f"helm template failed: {message}")
+
+        logger.info(f"Rendered Helm chart {chart} as release {release_name}")
+        return completed.stdout
+
+
+def _check_repo_url(url: str) -> None:
+    """Reject repository URLs helm should not be asked to fetch"""
+    scheme = urlparse(url).scheme
+    if scheme not in ALLOWED_REPO_SCHEMES:
+        raise HelmRenderError(
+            f"Unsupported chart repository scheme '{scheme}' (allowed: {', '.join(ALLOWED_REPO_SCHEMES)})"
+        )
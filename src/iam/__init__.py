@@ -0,0 +1,37 @@
+"""
+IAM Module
+
+This module assigns callers roles (viewer, editor, admin) per tenant,
+taking the place of their credentials' scopes in that tenant, and binds
+each request to the caller's role in the tenant it acts for.
+"""
+
+from .models import (
+    RoleAssignment,
+    RoleAssignmentSpec,
+    normalize_role,
+    scope_role,
+    subject_of,
+    with_role,
+    ROLES,
+    ROLE_VIEWER,
+    ROLE_EDITOR,
+    ROLE_ADMIN,
+    ROLE_SCOPES,
+)
+from .roles import RoleResolver
+
+__all__ = [
+    "RoleAssignment",
+    "RoleAssignmentSpec",
+    "normalize_role",
+    "scope_role",
+    "subject_of",
+    "with_role",
+    "ROLES",
+    "ROLE_VIEWER",
+    "ROLE_EDITOR",
+    "ROLE_ADMIN",
+    "ROLE_SCOPES",
+    "RoleResolver",
+]
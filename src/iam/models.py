@@ -0,0 +1,77 @@
+"""
+Data models for roles and role assignments
+"""
+
+from datetime import datetime
+from typing import List, Optional
+
+from pydantic import BaseModel, Field, validator
+
+from ..auth import Principal, SCOPES, SCOPE_ADMIN, SCOPE_ESTIMATE, SCOPE_READ
+from ..store import RoleAssignmentRecord
+
+# Roles a caller can be assigned in a tenant, each granting the ones before it:
+# reading, making estimates and changing budgets and scenarios, managing
+# discounts, catalogs, webhooks, API keys and role assignments
+ROLE_VIEWER = "viewer"
+ROLE_EDITOR = "editor"
+ROLE_ADMIN = "admin"
+ROLES = (ROLE_VIEWER, ROLE_EDITOR, ROLE_ADMIN)
+
+# Scope each role grants in its tenant
+ROLE_SCOPES = {
+    ROLE_VIEWER: SCOPE_READ,
+    ROLE_EDITOR: SCOPE_ESTIMATE,
+    ROLE_ADMIN: SCOPE_ADMIN,
+}
+
+
+def normalize_role(value: str) -> str:
+    """Normalize a role name for request validators"""
+    role = value.strip().lower()
+    if role not in ROLES:
+        raise ValueError(f"role must be one of: {', '.join(ROLES)}")
+    return role
+
+
+def scope_role(scopes: List[str]) -> Optional[str]:
+    """Role equivalent to the highest of a credential's scopes, None without a known scope"""
+    known = [scope for scope in scopes if scope in SCOPES]
+    if not known:
+        return None
+    scope = max(known, key=SCOPES.index)
+    return next(role for role, granted in ROLE_SCOPES.items() if granted == scope)
+
+
+def subject_of(principal: Principal) -> str:
+    """Subject a caller's roles are assigned to: its issued key ID, else its key name or token subject"""
+    return principal.key_id or principal.subject
+
+
+def with_role(principal: Principal, tenant_id: str, role: str) -> Principal:
+    """A caller acting for a tenant with the scope of its role there"""
+    return principal.copy(update={"tenant_id": tenant_id, "scopes": [ROLE_SCOPES[role]], "role": role})
+
+
+class RoleAssignmentSpec(BaseModel):
+    """Role as assigned through PUT /iam/roles/{subject}"""
+
+    role: str = Field(..., description="viewer, editor or admin")
+
+    @validator("role")
+    def validate_role(cls, v):
+        return normalize_role(v)
+
+
+class RoleAssignment(BaseModel):
+    """A subject's role in a tenant"""
+
+    tenant_id: str
+    subject: str = Field(..., description="Issued API key ID, static key name or OIDC token subject")
+    role: str
+    created_at: Optional[datetime] = None
+    updated_at: Optional[datetime] = None
+
+    @classmethod
+    def from_record(cls, record: RoleAssignmentRecord) -> "RoleAssignment":
+        return cls(**record.dict())
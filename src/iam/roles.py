@@ -0,0 +1,79 @@
+"""
+Tenant roles of callers
+
+A caller assigned a role in a tenant acts there with the role's scope
+instead of its credential's scopes, whether that grants more or less, and
+may act for that tenant (X-Tenant-ID) even if its credential belongs to
+another one. Callers without an assignment keep their credential's scopes
+and tenant. Assignments are read from the store and cached per tenant for
+a short TTL, so a change made on one replica applies on the others within
+the TTL.
+"""
+
+import threading
+import time
+from typing import Callable, Dict, Optional, Tuple
+
+from ..auth import Principal
+from ..store import Store
+from ..tenancy import resolve_tenant, validate_tenant_id
+from .models import subject_of, with_role
+
+
+class RoleResolver:
+    """Role lookup of callers, binding each request to a tenant and the caller's role there"""
+
+    def __init__(self, store: Store, ttl: float = 60.0, clock: Callable[[], float] = time.monotonic):
+        """
+        Initialize resolver
+
+        Args:
+            store: Store holding the role assignments
+            ttl: Seconds a tenant's assignments are cached
+            clock: Monotonic time source in seconds
+        """
+        self.store = store
+        self.ttl = ttl
+        self.clock = clock
+        # Tenant -> (loaded at, role by subject)
+        self._roles: Dict[str, Tuple[float, Dict[str, str]]] = {}
+        self._lock = threading.Lock()
+
+    def _assignments(self, tenant_id: str) -> Dict[str, str]:
+        now = self.clock()
+        with self._lock:
+            cached = self._roles.get(tenant_id)
+        if cached is not None and now - cached[0] < self.ttl:
+            return cached[1]
+
+        roles = {record.subject: record.role for record in self.store.list_role_assignments(tenant_id)}
+        with self._lock:
+            self._roles[tenant_id] = (now, roles)
+        return roles
+
+    def role(self, tenant_id: str, subject: str) -> Optional[str]:
+        """A subject's role in a tenant, None if it has none"""
+        return self._assignments(tenant_id).get(subject)
+
+    def invalidate(self, tenant_id: str) -> None:
+        """Drop a tenant's cached assignments after they changed"""
+        with self._lock:
+            self._roles.pop(tenant_id, None)
+
+    def bind(self, principal: Principal, requested: Optional[str] = None) -> Tuple[str, Principal]:
+        """
+        Tenant a request acts for and the caller as it acts there
+
+        Args:
+            principal: Authenticated caller
+            requested: Tenant named in the X-Tenant-ID header
+
+        Raises:
+            ValueError: If the requested tenant ID is malformed
+            PermissionError: If the caller names a tenant it has no role in and does not belong to
+        """
+        tenant_id = validate_tenant_id(requested) if requested else principal.tenant_id
+        role = self.role(tenant_id, subject_of(principal))
+        if role is None:
+            return resolve_tenant(requested, principal), principal
+        return tenant_id, with_role(principal, tenant_id, role)
@@ -0,0 +1,47 @@
+"""
+Integrations Module
+
+This module posts estimate diffs as pull request comments on GitHub
+and merge request comments on GitLab, updating the comment an earlier
+run posted instead of adding another one.
+"""
+
+from .base import (
+    Comment,
+    CommentResult,
+    IntegrationError,
+    PullRequestCommenter,
+    format_comment,
+    marker,
+    post_or_update,
+    DEFAULT_COMMENT_KEY,
+    ACTION_CREATED,
+    ACTION_UPDATED,
+    ACTION_UNCHANGED,
+)
+from .github import GitHubCommenter, DEFAULT_GITHUB_API_URL
+from .gitlab import GitLabCommenter, DEFAULT_GITLAB_API_URL
+from .factory import build_commenter, ci_defaults, PLATFORMS, PLATFORM_GITHUB, PLATFORM_GITLAB
+
+__all__ = [
+    "Comment",
+    "CommentResult",
+    "IntegrationError",
+    "PullRequestCommenter",
+    "format_comment",
+    "marker",
+    "post_or_update",
+    "DEFAULT_COMMENT_KEY",
+    "ACTION_CREATED",
+    "ACTION_UPDATED",
+    "ACTION_UNCHANGED",
+    "GitHubCommenter",
+    "DEFAULT_GITHUB_API_URL",
+    "GitLabCommenter",
+    "DEFAULT_GITLAB_API_URL",
+    "build_commenter",
+    "ci_defaults",
+    "PLATFORMS",
+    "PLATFORM_GITHUB",
+    "PLATFORM_GITLAB",
+]
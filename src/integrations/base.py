@@ -0,0 +1,125 @@
+"""
+Pull request comment interface
+
+Cost-diff comments carry a hidden marker so a later run finds and
+updates its own comment instead of adding another one. The marker names
+a key, letting several estimates (e.g. one per Terraform root module)
+keep their own comment on the same pull request.
+"""
+
+import re
+from abc import ABC, abstractmethod
+from typing import Any, Dict, Iterable, NamedTuple, Optional
+
+DEFAULT_COMMENT_KEY = "cost-diff"
+
+ACTION_CREATED = "created"
+ACTION_UPDATED = "updated"
+ACTION_UNCHANGED = "unchanged"
+
+_KEY_PATTERN = re.compile(r"^[A-Za-z0-9._/-]+$")
+
+
+class Comment(NamedTuple):
+    """A comment on a pull request"""
+
+    id: str
+    body: str
+    url: Optional[str] = None
+
+
+class CommentResult(NamedTuple):
+    """What posting a comment did"""
+
+    action: str
+    comment_id: str
+    url: Optional[str] = None
+
+
+class IntegrationError(Exception):
+    """Raised when the code hosting API answers with an error"""
+
+
+class PullRequestCommenter(ABC):
+    """Comments on the pull (or merge) requests of one repository"""
+
+    #: Platform name used on the command line (e.g. "github", "gitlab")
+    name: str = ""
+
+    @abstractmethod
+    def comments(self, number: int) -> Iterable[Comment]:
+        """
+        Comments of a pull request, oldest first
+
+        Raises:
+            IntegrationError: If the comments cannot be listed
+        """
+
+    @abstractmethod
+    def create_comment(self, number: int, body: str) -> Comment:
+        """
+        Add a comment to a pull request
+
+        Raises:
+            IntegrationError: If the comment cannot be created
+        """
+
+    @abstractmethod
+    def update_comment(self, number: int, comment_id: str, body: str) -> Comment:
+        """
+        Replace the body of a comment
+
+        Raises:
+            IntegrationError: If the comment cannot be updated
+        """
+
+
+def marker(key: str = DEFAULT_COMMENT_KEY) -> str:
+    """
+    Hidden marker identifying the comment of a key
+
+    Raises:
+        ValueError: If the key contains characters other than letters, digits and ._/-
+    """
+    if not _KEY_PATTERN.match(key):
+        raise ValueError(f"Invalid comment key '{key}': use letters, digits and ._/-")
+    return f"<!-- kcloud-cost-estimator:{key} -->"
+
+
+def format_comment(diff: Dict[str, Any], key: str = DEFAULT_COMMENT_KEY) -> str:
+    """Comment body of an estimate diff (the diff object of POST /estimate/diff)"""
+    return f"{marker(key)}\n{diff['markdown'].rstrip()}\n\n<sub>Posted by kcloud-cost-estimator</sub>\n"
+
+
+def post_or_update(
+    commenter: PullRequestCommenter, number: int, body: str, key: str = DEFAULT_COMMENT_KEY
+) -> CommentResult:
+    """
+    Create the key's comment, or update it when the pull request has one
+
+    A comment whose body is already up to date is left alone, so
+    repeated runs of the same pipeline do not notify reviewers.
+
+    Raises:
+        IntegrationError: If the API answers with an error
+    """
+    tag = marker(key)
+    if tag not in body:
+        body = f"{tag}\n{body}"
+    existing = next((c for c in commenter.comments(number) if tag in c.body), None)
+    if existing is None:
+        created = commenter.create_comment(number, body)
+        return CommentResult(ACTION_CREATED, created.id, created.url)
+    if existing.body.strip() == body.strip():
+        return CommentResult(ACTION_UNCHANGED, existing.id, existing.url)
+    updated = commenter.update_comment(number, existing.id, body)
+    return CommentResult(ACTION_UPDATED, updated.id, updated.url or existing.url)
+
+
+def api_error(platform: str, method: str, url: str, response) -> IntegrationError:
+    """Error of a failed API response"""
+    try:
+        detail = response.json().get("message", response.text)
+    except ValueError:
+        detail = response.text
+    return IntegrationError(f"{platform} API {method} {url}: HTTP {response.status_code}: {detail}")
@@ -0,0 +1,75 @@
+"""
+Commenters from the command line and CI environment
+
+GitHub Actions and GitLab CI both describe the pull request of a build
+in environment variables, so a pipeline step only names the platform.
+"""
+
+import os
+import re
+from typing import Dict, Mapping, Optional, Union
+
+from .base import PullRequestCommenter
+from .github import DEFAULT_GITHUB_API_URL, GitHubCommenter
+from .gitlab import DEFAULT_GITLAB_API_URL, GitLabCommenter
+
+PLATFORM_GITHUB = "github"
+PLATFORM_GITLAB = "gitlab"
+PLATFORMS = [PLATFORM_GITHUB, PLATFORM_GITLAB]
+
+# GITHUB_REF of pull_request workflow runs
+_GITHUB_PR_REF = re.compile(r"^refs/pull/(\d+)/")
+
+
+def ci_defaults(platform: str, environ: Optional[Mapping[str, str]] = None) -> Dict[str, Union[str, int, None]]:
+    """
+    Repository, pull request number, token and API URL of the CI build
+
+    Values the environment does not define are None.
+
+    Raises:
+        ValueError: Unknown platform
+    """
+    env = os.environ if environ is None else environ
+    if platform == PLATFORM_GITHUB:
+        match = _GITHUB_PR_REF.match(env.get("GITHUB_REF", ""))
+        return {
+            "repository": env.get("GITHUB_REPOSITORY"),
+            "number": int(match.group(1)) if match else None,
+            "token": env.get("GITHUB_TOKEN"),
+            "api_url": env.get("GITHUB_API_URL"),
+            "web_url": None,
+        }
+    if platform == PLATFORM_GITLAB:
+        iid = env.get("CI_MERGE_REQUEST_IID")
+        return {
+            "repository": env.get("CI_PROJECT_ID") or env.get("CI_PROJECT_PATH"),
+            "number": int(iid) if iid and iid.isdigit() else None,
+            "token": env.get("GITLAB_TOKEN"),
+            "api_url": env.get("CI_API_V4_URL"),
+            "web_url": env.get("CI_PROJECT_URL"),
+        }
+    raise ValueError(f"Unknown platform '{platform}', expected one of {', '.join(PLATFORMS)}")
+
+
+def build_commenter(
+    platform: str,
+    repository: str,
+    token: str,
+    api_url: Optional[str] = None,
+    web_url: Optional[str] = None,
+    timeout: float = 30,
+) -> PullRequestCommenter:
+    """
+    Commenter of a GitHub repository or GitLab project
+
+    Raises:
+        ValueError: Unknown platform or invalid repository
+    """
+    if platform == PLATFORM_GITHUB:
+        return GitHubCommenter(repository, token, api_url=api_url or DEFAULT_GITHUB_API_URL, timeout=timeout)
+    if platform == PLATFORM_GITLAB:
+        return GitLabCommenter(
+            repository, token, api_url=api_url or DEFAULT_GITLAB_API_URL, timeout=timeout, web_url=web_url
+        )
+    raise ValueError(f"Unknown platform '{platform}', expected one of {', '.join(PLATFORMS)}")
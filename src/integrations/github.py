@@ -0,0 +1,80 @@
+"""
+GitHub pull request comments
+
+Comments are issue comments of the pull request, listed through the
+REST API's issue comment endpoints.
+"""
+
+from typing import Any, Dict, Iterable, Optional
+
+import requests
+
+from .base import Comment, PullRequestCommenter, api_error
+
+DEFAULT_GITHUB_API_URL = "https://api.github.com"
+
+
+class GitHubCommenter(PullRequestCommenter):
+    """Comments on the pull requests of a GitHub repository"""
+
+    name = "github"
+
+    def __init__(
+        self,
+        repository: str,
+        token: str,
+        api_url: str = DEFAULT_GITHUB_API_URL,
+        timeout: float = 30,
+        session: Optional[requests.Session] = None,
+    ):
+        """
+        Initialize GitHub commenter
+
+        Args:
+            repository: owner/name
+            token: Token allowed to write pull request comments (GITHUB_TOKEN in Actions)
+            api_url: REST API URL, e.g. https://github.example.com/api/v3 for GitHub Enterprise
+            timeout: Request timeout in seconds
+            session: HTTP session, a new one if not provided
+        """
+        if repository.count("/") != 1:
+            raise ValueError(f"Invalid GitHub repository '{repository}', expected owner/name")
+        self.repository = repository
+        self.api_url = api_url.rstrip("/")
+        self.timeout = timeout
+        self.session = session or requests.Session()
+        self.session.headers.update({
+            "Authorization": f"Bearer {token}",
+            "Accept": "application/vnd.github+json",
+            "X-GitHub-Api-Version": "2022-11-28",
+        })
+
+    def comments(self, number: int) -> Iterable[Comment]:
+        url: Optional[str] = f"{self.api_url}/repos/{self.repository}/issues/{number}/comments"
+        params: Optional[Dict[str, Any]] = {"per_page": 100}
+        while url:
+            response = self.session.get(url, params=params, timeout=self.timeout)
+            if response.status_code >= 400:
+                raise api_error("GitHub", "GET", url, response)
+            for item in response.json():
+                yield _comment(item)
+            # Following pages are linked with their query string
+            url, params = response.links.get("next", {}).get("url"), None
+
+    def create_comment(self, number: int, body: str) -> Comment:
+        url = f"{self.api_url}/repos/{self.repository}/issues/{number}/comments"
+        return self._send("POST", url, body)
+
+    def update_comment(self, number: int, comment_id: str, body: str) -> Comment:
+        url = f"{self.api_url}/repos/{self.repository}/issues/comments/{comment_id}"
+        return self._send("PATCH", url, body)
+
+    def _send(self, method: str, url: str, body: str) -> Comment:
+        response = self.session.request(method, url, json={"body": body}, timeout=self.timeout)
+        if response.status_code >= 400:
+            raise api_error("GitHub", method, url, response)
+        return _comment(response.json())
+
+
+def _comment(item: Dict[str, Any]) -> Comment:
+    return Comment(id=str(item["id"]), body=item.get("body") or "", url=item.get("html_url"))
@@ -0,0 +1,82 @@
+"""
+GitLab merge request comments
+
+Comments are notes of the merge request; system notes (pushes, label
+changes) are skipped.
+"""
+
+from typing import Any, Dict, Iterable, Optional
+from urllib.parse import quote
+
+import requests
+
+from .base import Comment, PullRequestCommenter, api_error
+
+DEFAULT_GITLAB_API_URL = "https://gitlab.com/api/v4"
+
+
+class GitLabCommenter(PullRequestCommenter):
+    """Comments on the merge requests of a GitLab project"""
+
+    name = "gitlab"
+
+    def __init__(
+        self,
+        project: str,
+        token: str,
+        api_url: str = DEFAULT_GITLAB_API_URL,
+        timeout: float = 30,
+        session: Optional[requests.Session] = None,
+        web_url: Optional[str] = None,
+    ):
+        """
+        Initialize GitLab commenter
+
+        Args:
+            project: Project id or group/name path
+            token: Token with the api scope (a project access token in CI)
+            api_url: REST API URL, e.g. https://gitlab.example.com/api/v4 (CI_API_V4_URL)
+            timeout: Request timeout in seconds
+            session: HTTP session, a new one if not provided
+            web_url: Project URL notes are linked from, no links if not provided
+        """
+        self.project = project
+        self.api_url = api_url.rstrip("/")
+        self.timeout = timeout
+        self.web_url = web_url.rstrip("/") if web_url else None
+        self.session = session or requests.Session()
+        self.session.headers["PRIVATE-TOKEN"] = token
+
+    def _notes_url(self, number: int) -> str:
+        return f"{self.api_url}/projects/{quote(self.project, safe='')}/merge_requests/{number}/notes"
+
+    def comments(self, number: int) -> Iterable[Comment]:
+        url = self._notes_url(number)
+        page: Optional[str] = "1"
+        while page:
+            response = self.session.get(
+                url, params={"per_page": 100, "page": page, "sort": "asc", "order_by": "created_at"},
+                timeout=self.timeout,
+            )
+            if response.status_code >= 400:
+                raise api_error("GitLab", "GET", url, response)
+            for item in response.json():
+                if not item.get("system"):
+                    yield self._comment(number, item)
+            page = response.headers.get("X-Next-Page") or None
+
+    def create_comment(self, number: int, body: str) -> Comment:
+        return self._send("POST", self._notes_url(number), number, body)
+
+    def update_comment(self, number: int, comment_id: str, body: str) -> Comment:
+        return self._send("PUT", f"{self._notes_url(number)}/{comment_id}", number, body)
+
+    def _send(self, method: str, url: str, number: int, body: str) -> Comment:
+        response = self.session.request(method, url, json={"body": body}, timeout=self.timeout)
+        if response.status_code >= 400:
+            raise api_error("GitLab", method, url, response)
+        return self._comment(number, response.json())
+
+    def _comment(self, number: int, item: Dict[str, Any]) -> Comment:
+        url = f"{self.web_url}/-/merge_requests/{number}#note_{item['id']}" if self.web_url else None
+        return Comment(id=str(item["id"]), body=item.get("body") or "", url=url)
@@ -0,0 +1,69 @@
+"""
+Inventory Module
+
+This module keeps the resource inventory collectors report for each
+tenant, or lists it from cloud APIs (EC2, Compute Engine, Azure Resource
+Graph), and finds idle resources in it: unattached volumes, unused load
+balancers, stopped instances still billed and empty node pools, with
+the estimated monthly waste of each, and prices resources in another
+region to plan disaster recovery sites and data residency moves.
+"""
+
+from .models import (
+    InventoryResource,
+    InventoryBatch,
+    CollectRequest,
+    CollectResult,
+    IdleFinding,
+    IdleReport,
+    KINDS,
+    KIND_INSTANCE,
+    KIND_VOLUME,
+    KIND_LOAD_BALANCER,
+    KIND_NODE_POOL,
+    REASON_UNATTACHED_VOLUME,
+    REASON_UNUSED_LOAD_BALANCER,
+    REASON_STOPPED_INSTANCE,
+    REASON_EMPTY_NODE_POOL,
+    RegionMigrationRequest,
+    RegionMigrationItem,
+    RegionMigrationResult,
+)
+from .idle import IdleDetector, STOPPED_STATES
+from .migration import RegionMigrationAnalyzer, matches_selector
+from .collect import InventoryCollector, InventoryCollection
+from .aws_ec2 import EC2Collector
+from .gcp_compute import GCPComputeCollector
+from .azure_graph import AzureResourceGraphCollector
+from .factory import build_collection
+
+__all__ = [
+    "InventoryResource",
+    "InventoryBatch",
+    "CollectRequest",
+    "CollectResult",
+    "IdleFinding",
+    "IdleReport",
+    "KINDS",
+    "KIND_INSTANCE",
+    "KIND_VOLUME",
+    "KIND_LOAD_BALANCER",
+    "KIND_NODE_POOL",
+    "REASON_UNATTACHED_VOLUME",
+    "REASON_UNUSED_LOAD_BALANCER",
+    "REASON_STOPPED_INSTANCE",
+    "REASON_EMPTY_NODE_POOL",
+    "IdleDetector",
+    "STOPPED_STATES",
+    "RegionMigrationRequest",
+    "RegionMigrationItem",
+    "RegionMigrationResult",
+    "RegionMigrationAnalyzer",
+    "matches_selector",
+    "InventoryCollector",
+    "InventoryCollection",
+    "EC2Collector",
+    "GCPComputeCollector",
+    "AzureResourceGraphCollector",
+    "build_collection",
+]
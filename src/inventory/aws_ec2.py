@@ -0,0 +1,100 @@
+"""
+AWS EC2 inventory
+
+Lists the instances (DescribeInstances) and EBS volumes (DescribeVolumes)
+of each configured region with the default boto3 credential chain.
+Terminated instances, which AWS keeps listing for about an hour, are
+left out. Tags become labels; the Name tag is the resource name.
+"""
+
+import logging
+from typing import Any, Dict, Iterator, List, Optional
+
+from .collect import InventoryCollector
+from .models import InventoryResource, KIND_INSTANCE, KIND_VOLUME
+
+logger = logging.getLogger(__name__)
+
+SOURCE = "aws-ec2"
+
+# Instance states of instances that no longer exist
+_GONE_STATES = {"shutting-down", "terminated"}
+
+
+def _tags(item: Dict[str, Any]) -> Dict[str, str]:
+    return {tag["Key"]: tag.get("Value", "") for tag in item.get("Tags") or []}
+
+
+def instance_resource(region: str, instance: Dict[str, Any]) -> InventoryResource:
+    """Inventory resource of a DescribeInstances instance"""
+    labels = _tags(instance)
+    return InventoryResource(
+        resource_id=instance["InstanceId"],
+        kind=KIND_INSTANCE,
+        provider="aws",
+        region=region,
+        sku=instance.get("InstanceType", ""),
+        name=labels.get("Name", ""),
+        state=(instance.get("State") or {}).get("Name", ""),
+        labels=labels,
+    )
+
+
+def volume_resource(region: str, volume: Dict[str, Any]) -> InventoryResource:
+    """Inventory resource of a DescribeVolumes volume"""
+    labels = _tags(volume)
+    attachments = volume.get("Attachments") or []
+    return InventoryResource(
+        resource_id=volume["VolumeId"],
+        kind=KIND_VOLUME,
+        provider="aws",
+        region=region,
+        sku=volume.get("VolumeType", ""),
+        name=labels.get("Name", ""),
+        state=volume.get("State", ""),
+        attached_to=attachments[0].get("InstanceId", "") if attachments else "",
+        size_gb=float(volume.get("Size") or 0),
+        labels=labels,
+    )
+
+
+class EC2Collector(InventoryCollector):
+    """Inventory collector of EC2 instances and EBS volumes"""
+
+    source = SOURCE
+
+    def __init__(self, regions: List[str], session: Optional[Any] = None):
+        """
+        Initialize collector
+
+        Args:
+            regions: Regions listed
+            session: boto3 Session creating the regional EC2 clients. A default session is used if not provided.
+        """
+        self.regions = regions
+        self._session = session
+
+    def client(self, region: str):
+        """EC2 client of a region"""
+        if self._session is None:
+            import boto3
+            self._session = boto3.session.Session()
+        return self._session.client("ec2", region_name=region)
+
+    def collect(self) -> List[InventoryResource]:
+        resources = []
+        for region in self.regions:
+            client = self.client(region)
+            for reservation in _paginate(client, "describe_instances", "Reservations"):
+                for instance in reservation.get("Instances", []):
+                    if (instance.get("State") or {}).get("Name") not in _GONE_STATES:
+                        resources.append(instance_resource(region, instance))
+            for volume in _paginate(client, "describe_volumes", "Volumes"):
+                resources.append(volume_resource(region, volume))
+        logger.debug(f"EC2 listed {len(resources)} resources in {', '.join(self.regions)}")
+        return resources
+
+
+def _paginate(client, operation: str, key: str) -> Iterator[Dict[str, Any]]:
+    for page in client.get_paginator(operation).paginate():
+        yield from page.get(key, [])
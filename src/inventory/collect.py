@@ -0,0 +1,107 @@
+"""
+Inventory collection from cloud APIs
+
+A collector lists the live resources of one cloud API. Collection records
+them as a full snapshot of the collector's source, replacing the
+resources it recorded before, the same way POST /inventory records the
+batches of external collectors. Current-state reports (idle resources,
+region migrations) thus do not wait for billing exports, which lag a day.
+"""
+
+import logging
+from abc import ABC, abstractmethod
+from datetime import datetime, timezone
+from typing import Callable, Dict, List
+
+from ..store import Store
+from .models import CollectResult, InventoryResource
+
+logger = logging.getLogger(__name__)
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+class InventoryCollector(ABC):
+    """Lister of the resources of one cloud API"""
+
+    # Source name the resources are recorded under, e.g. aws-ec2
+    source: str = ""
+
+    @abstractmethod
+    def collect(self) -> List[InventoryResource]:
+        """Resources the API lists now"""
+
+
+class InventoryCollection:
+    """Records the resources of cloud APIs as a tenant's inventory"""
+
+    def __init__(
+        self,
+        store: Store,
+        tenant_id: str,
+        collectors: List[InventoryCollector],
+        clock: Callable[[], datetime] = utcnow,
+    ):
+        """
+        Initialize collection
+
+        Args:
+            store: Store the inventory is kept in
+            tenant_id: Tenant the resources belong to
+            collectors: Listers of the configured cloud APIs
+            clock: Current time (aware UTC)
+        """
+        self.store = store
+        self.tenant_id = tenant_id
+        self.clock = clock
+        self._collectors: Dict[str, InventoryCollector] = {c.source: c for c in collectors}
+
+    @property
+    def sources(self) -> List[str]:
+        """Configured collector sources"""
+        return sorted(self._collectors)
+
+    def collect(self, source: str) -> CollectResult:
+        """
+        Record the resources one collector lists now
+
+        Raises:
+            KeyError: If the source is not configured
+        """
+        collector = self._collectors.get(source)
+        if collector is None:
+            raise KeyError(
+                f"Inventory source {source} is not configured; configured: {', '.join(self.sources) or 'none'}"
+            )
+
+        now = self.clock()
+        resources = collector.collect()
+        records = self.store.replace_inventory(self.tenant_id, source, [
+            r.to_record(self.tenant_id, source).copy(update={"observed_at": r.observed_at or now}) for r in resources
+        ])
+        kinds: Dict[str, int] = {}
+        for record in records:
+            kinds[record.kind] = kinds.get(record.kind, 0) + 1
+        logger.info(
+            f"Collected {len(records)} resources of tenant {self.tenant_id} from {source}: "
+            + (", ".join(f"{count} {kind}" for kind, count in sorted(kinds.items())) or "none")
+        )
+        return CollectResult(
+            source=source,
+            tenant_id=self.tenant_id,
+            resources=len(records),
+            kinds=kinds,
+            collected_at=now,
+        )
+
+    def collect_all(self) -> List[CollectResult]:
+        """Record the resources of every collector; failures are logged and the collector skipped"""
+        results = []
+        for source in self.sources:
+            try:
+                results.append(self.collect(source))
+            except Exception as e:
+                logger.error(f"Inventory collection from {source} failed: {e}")
+        return results
@@ -0,0 +1,26 @@
+"""
+Inventory collection from application settings
+"""
+
+from typing import Optional
+
+from ..store import Store
+from .aws_ec2 import EC2Collector
+from .azure_graph import AzureResourceGraphCollector
+from .collect import InventoryCollection
+from .gcp_compute import GCPComputeCollector
+
+
+def build_collection(settings, store: Store) -> Optional[InventoryCollection]:
+    """Collection of the configured cloud APIs, None when none is configured"""
+    collectors = []
+    if settings.inventory_aws_regions:
+        collectors.append(EC2Collector(settings.inventory_aws_regions))
+    if settings.inventory_gcp_projects:
+        collectors.append(GCPComputeCollector(settings.inventory_gcp_projects))
+    if settings.inventory_azure_subscriptions:
+        collectors.append(AzureResourceGraphCollector(settings.inventory_azure_subscriptions))
+
+    if not collectors:
+        return None
+    return InventoryCollection(store, settings.inventory_tenant, collectors)
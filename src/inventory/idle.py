@@ -0,0 +1,219 @@
+"""
+Idle resource detection
+
+Finds resources in a tenant's ingested inventory that are billed without
+doing work, and estimates the monthly waste of each at list prices:
+
+- volumes attached to no instance, at their storage price
+- load balancers with no healthy targets or no requests, at their hourly rate
+- stopped instances, at the storage price of their attached volumes;
+  stopped Azure VMs that were not deallocated also bill their compute
+- node pools with nodes but no pods besides DaemonSets, at the price of
+  their nodes
+
+Resources are judged on what their collector reported: a load balancer
+without target or request counts, or a node pool without a pod count,
+is never idle.
+"""
+
+import logging
+from typing import Dict, List, Optional
+
+from ..k8s.storage import volume_sku
+from ..money import round_money
+from ..pricing import (
+    PriceNotFoundError,
+    PriceQuery,
+    ProviderNotFoundError,
+    ProviderRegistry,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_COMPUTE,
+)
+from ..store import InventoryRecord, Store
+from ..units import hours_per_month
+from .models import (
+    IdleFinding,
+    IdleReport,
+    KINDS,
+    KIND_INSTANCE,
+    KIND_LOAD_BALANCER,
+    KIND_NODE_POOL,
+    KIND_VOLUME,
+    REASON_EMPTY_NODE_POOL,
+    REASON_STOPPED_INSTANCE,
+    REASON_UNATTACHED_VOLUME,
+    REASON_UNUSED_LOAD_BALANCER,
+)
+
+logger = logging.getLogger(__name__)
+
+# Instance states that stop compute billing (GCP reports stopped VMs as terminated)
+STOPPED_STATES = {"stopped", "stopping", "terminated", "suspended", "deallocated"}
+# (provider, state) of stopped instances still billed for compute
+_COMPUTE_BILLED_STATES = {("azure", "stopped"), ("azure", "stopping")}
+# Volume states of attached volumes
+_ATTACHED_STATES = {"in-use", "attached"}
+
+
+class IdleDetector:
+    """Finds idle resources in a tenant's inventory"""
+
+    def __init__(
+        self,
+        store: Store,
+        registry: ProviderRegistry,
+        load_balancer_hourly: Optional[Dict[str, float]] = None,
+        hours: Optional[float] = None,
+    ):
+        """
+        Initialize detector
+
+        Args:
+            store: Store holding the inventory
+            registry: Registry pricing instances and volumes
+            load_balancer_hourly: Hourly rate of a load balancer per provider
+            hours: Billed hours per month, the time basis' when omitted
+        """
+        self.store = store
+        self.registry = registry
+        self.load_balancer_hourly = load_balancer_hourly or {}
+        self._hours = hours
+
+    @property
+    def hours(self) -> float:
+        """Billed hours per month"""
+        return self._hours if self._hours is not None else hours_per_month()
+
+    def detect(self, tenant_id: str, kind: Optional[str] = None) -> IdleReport:
+        """
+        Idle resources of a tenant, optionally of one resource kind
+
+        Raises:
+            ValueError: Unknown resource kind
+            StoreError: If the inventory cannot be read
+        """
+        if kind is not None and kind not in KINDS:
+            raise ValueError(f"Unknown resource kind '{kind}', expected one of {', '.join(KINDS)}")
+        resources = self.store.list_inventory(tenant_id)
+        volumes_of: Dict[str, List[InventoryRecord]] = {}
+        for resource in resources:
+            if resource.kind == KIND_VOLUME and resource.attached_to:
+                volumes_of.setdefault(resource.attached_to, []).append(resource)
+
+        findings: List[IdleFinding] = []
+        for resource in resources:
+            if kind is not None and resource.kind != kind:
+                continue
+            finding = None
+            if resource.kind == KIND_VOLUME:
+                finding = self._volume(resource)
+            elif resource.kind == KIND_LOAD_BALANCER:
+                finding = self._load_balancer(resource)
+            elif resource.kind == KIND_INSTANCE:
+                finding = self._instance(resource, volumes_of.get(resource.resource_id, []))
+            elif resource.kind == KIND_NODE_POOL:
+                finding = self._node_pool(resource)
+            if finding is not None:
+                findings.append(finding)
+
+        findings.sort(key=lambda f: (f.waste_monthly_cost is None, -(f.waste_monthly_cost or 0.0), f.resource_id))
+        waste = sum(f.waste_monthly_cost or 0.0 for f in findings)
+        logger.info(f"Idle resources of tenant {tenant_id}: {len(findings)} of {len(resources)}, ${waste:.2f}/month")
+        return IdleReport(
+            resources=len(resources),
+            findings=findings,
+            waste_monthly_cost=round_money(waste),
+            unpriced=[f.resource_id for f in findings if f.waste_monthly_cost is None],
+        )
+
+    def _volume(self, volume: InventoryRecord) -> Optional[IdleFinding]:
+        if volume.attached_to or volume.state in _ATTACHED_STATES:
+            return None
+        return _finding(
+            volume, REASON_UNATTACHED_VOLUME,
+            f"{volume.sku or 'Volume'} of {volume.size_gb:g} GiB attached to no instance",
+            self._storage_monthly(volume),
+        )
+
+    def _load_balancer(self, balancer: InventoryRecord) -> Optional[IdleFinding]:
+        targets, requests = balancer.attributes.get("targets"), balancer.attributes.get("requests")
+        if targets == 0:
+            detail = "No healthy targets"
+        elif requests == 0:
+            detail = "No requests served"
+        else:
+            return None
+        rate = self.load_balancer_hourly.get(balancer.provider)
+        return _finding(
+            balancer, REASON_UNUSED_LOAD_BALANCER, detail, None if rate is None else rate * self.hours
+        )
+
+    def _instance(self, instance: InventoryRecord, volumes: List[InventoryRecord]) -> Optional[IdleFinding]:
+        if instance.state not in STOPPED_STATES:
+            return None
+        billed = [f"{len(volumes)} attached volumes"] if volumes else []
+        waste: Optional[float] = 0.0
+        for volume in volumes:
+            cost = self._storage_monthly(volume)
+            waste = None if waste is None or cost is None else waste + cost
+        if (instance.provider, instance.state) in _COMPUTE_BILLED_STATES:
+            billed.append("compute (not deallocated)")
+            hourly = self._hourly(instance)
+            waste = None if waste is None or hourly is None else waste + hourly * self.hours
+        if not billed:
+            return None
+        return _finding(
+            instance, REASON_STOPPED_INSTANCE,
+            f"{instance.sku or 'Instance'} {instance.state}, still billed for {' and '.join(billed)}",
+            waste,
+        )
+
+    def _node_pool(self, pool: InventoryRecord) -> Optional[IdleFinding]:
+        nodes = pool.attributes.get("nodes") or 0
+        if nodes == 0 or pool.attributes.get("pods") != 0:
+            return None
+        hourly = self._hourly(pool)
+        return _finding(
+            pool, REASON_EMPTY_NODE_POOL,
+            f"{nodes} {pool.sku or 'node'} nodes running no pods besides DaemonSets",
+            None if hourly is None else hourly * nodes * self.hours,
+        )
+
+    def _hourly(self, resource: InventoryRecord) -> Optional[float]:
+        """List price per hour of an instance type, None when it cannot be priced"""
+        try:
+            return self.registry.get_price(PriceQuery(
+                provider=resource.provider, region=resource.region, sku=resource.sku, service=SERVICE_COMPUTE,
+            )).price
+        except (PriceNotFoundError, ProviderNotFoundError) as e:
+            logger.warning(f"Idle {resource.kind} {resource.resource_id} not priced: {e}")
+            return None
+
+    def _storage_monthly(self, volume: InventoryRecord) -> Optional[float]:
+        """List price per month of a volume, None when it cannot be priced"""
+        try:
+            price = self.registry.get_price(PriceQuery(
+                provider=volume.provider, region=volume.region,
+                sku=volume_sku(volume.provider, volume.sku, volume.size_gb), service=SERVICE_BLOCK_STORAGE,
+            ))
+        except (ValueError, PriceNotFoundError, ProviderNotFoundError) as e:
+            logger.warning(f"Volume {volume.resource_id} not priced: {e}")
+            return None
+        return price.price * volume.size_gb if price.unit == "GB-month" else price.price
+
+
+def _finding(resource: InventoryRecord, reason: str, detail: str, waste: Optional[float]) -> IdleFinding:
+    return IdleFinding(
+        resource_id=resource.resource_id,
+        kind=resource.kind,
+        reason=reason,
+        provider=resource.provider,
+        region=resource.region,
+        sku=resource.sku,
+        name=resource.name,
+        source=resource.source,
+        detail=detail,
+        labels=resource.labels,
+        observed_at=resource.observed_at,
+        waste_monthly_cost=None if waste is None else round_money(waste),
+    )
@@ -0,0 +1,213 @@
+"""
+Region migration analysis
+
+Prices resources in the region they run in and in a target region, e.g.
+to plan a disaster recovery site or a move for data residency. The
+resources are listed in the request, selected from the tenant's
+inventory by their labels, or both:
+
+- listed resources are priced like estimate resources, discounts included
+- inventory instances and node pools (one instance per node) are priced
+  on demand for the request's hours, volumes at their storage price;
+  load balancers are priced at one rate per cloud and leave the delta
+  unchanged, so they are not listed
+
+A resource whose price is not found in the target region is flagged
+unavailable there, with the target region's instance types most like
+it. Totals cover the resources priced in both regions.
+"""
+
+import logging
+from typing import List, Optional, Tuple
+
+from ..estimator import CostEstimator, ResourceSpec
+from ..k8s.storage import volume_sku
+from ..money import round_money, sum_money
+from ..pricing import PriceNotFoundError, PriceQuery, ProviderNotFoundError, SERVICE_BLOCK_STORAGE
+from ..store import InventoryRecord, Store
+from .models import (
+    KIND_INSTANCE,
+    KIND_NODE_POOL,
+    KIND_VOLUME,
+    RegionMigrationItem,
+    RegionMigrationRequest,
+    RegionMigrationResult,
+)
+
+logger = logging.getLogger(__name__)
+
+# Inventory kinds whose cost depends on the region
+_MIGRATED_KINDS = (KIND_INSTANCE, KIND_NODE_POOL, KIND_VOLUME)
+
+
+def matches_selector(labels: dict, selector: dict) -> bool:
+    """Whether labels include every key and value of a selector"""
+    return all(labels.get(key) == value for key, value in selector.items())
+
+
+class RegionMigrationAnalyzer:
+    """Reports the cost delta of moving resources to another region"""
+
+    def __init__(self, estimator: CostEstimator, store: Optional[Store] = None):
+        """
+        Initialize analyzer
+
+        Args:
+            estimator: Estimator pricing instances; its registry prices volumes
+            store: Store holding the inventory, required for label selectors
+        """
+        self.estimator = estimator
+        self.store = store
+
+    def analyze(self, tenant_id: str, request: RegionMigrationRequest) -> RegionMigrationResult:
+        """
+        Cost of a tenant's resources in their regions and in the target region
+
+        Raises:
+            ValueError: If the resources span clouds without a provider, or a
+                selector is given without a store
+            StoreError: If the inventory cannot be read
+        """
+        inventory = self._select(tenant_id, request)
+        provider = request.provider or _only_provider(request.resources, inventory)
+
+        items: List[RegionMigrationItem] = []
+        sessions = (self.estimator.discount_session(), self.estimator.discount_session())
+        for resource in request.resources:
+            if resource.provider != provider:
+                raise ValueError(
+                    f"Resource {resource.name or resource.instance_type} runs on {resource.provider}, "
+                    f"not {provider}; analyze each cloud separately"
+                )
+            items.append(self._listed(resource, request, sessions))
+        for record in inventory:
+            if record.provider != provider:
+                continue
+            if record.kind == KIND_VOLUME:
+                items.append(self._volume(record, request.target_region))
+            else:
+                spec = ResourceSpec(
+                    name=record.name or record.resource_id,
+                    provider=record.provider,
+                    instance_type=record.sku,
+                    region=record.region,
+                    count=max(1, int(record.attributes.get("nodes") or 1)),
+                    hours=request.hours,
+                )
+                items.append(self._listed(spec, request, sessions, kind=record.kind))
+
+        compared = [item for item in items if item.monthly_delta is not None]
+        current = sum_money(item.current_monthly_cost for item in compared)
+        target = sum_money(item.target_monthly_cost for item in compared)
+        delta = round_money(target - current)
+        percent = round(delta / current * 100, 1) if current else None
+
+        sign = "+" if delta >= 0 else "-"
+        summary = f"{len(compared)} resources to {request.target_region}: {sign}${abs(delta):,.2f}/month"
+        if percent is not None:
+            summary += f" ({percent:+.1f}%)"
+        unavailable = [item.name for item in items if not item.available]
+        if unavailable:
+            summary += f", {len(unavailable)} unavailable"
+        items.sort(key=lambda item: (item.monthly_delta is None, -(item.monthly_delta or 0.0), item.name))
+        logger.info(f"Region migration of tenant {tenant_id} to {provider} {request.target_region}: {summary}")
+        return RegionMigrationResult(
+            provider=provider,
+            target_region=request.target_region,
+            items=items,
+            current_monthly_cost=current,
+            target_monthly_cost=target,
+            monthly_delta=delta,
+            delta_percent=percent,
+            summary=summary,
+            unavailable=unavailable,
+            unpriced=[item.name for item in items if item.current_monthly_cost is None],
+        )
+
+    def _select(self, tenant_id: str, request: RegionMigrationRequest) -> List[InventoryRecord]:
+        if request.selector is None:
+            return []
+        if self.store is None:
+            raise ValueError("Inventory label selectors need a store (STORE_URL)")
+        return [
+            record for record in self.store.list_inventory(tenant_id)
+            if record.kind in _MIGRATED_KINDS and record.sku and matches_selector(record.labels, request.selector)
+        ]
+
+    def _listed(
+        self,
+        resource: ResourceSpec,
+        request: RegionMigrationRequest,
+        sessions: Tuple,
+        kind: str = KIND_INSTANCE,
+    ) -> RegionMigrationItem:
+        item = RegionMigrationItem(
+            name=resource.name or resource.instance_type,
+            kind=kind,
+            provider=resource.provider,
+            region=resource.region,
+            sku=resource.instance_type,
+            count=resource.count,
+            available=True,
+        )
+        try:
+            item.current_monthly_cost = self.estimator.price_resource(resource, sessions[0]).monthly_cost
+        except (PriceNotFoundError, ProviderNotFoundError) as e:
+            item.detail = str(e)
+
+        moved = resource.copy(update={"region": request.target_region})
+        try:
+            item.target_monthly_cost = self.estimator.price_resource(moved, sessions[1]).monthly_cost
+        except PriceNotFoundError as e:
+            item.available = False
+            item.detail = item.detail or str(e)
+            if request.suggestions:
+                matches = self.estimator.suggest_instance_types(moved)[:request.suggestions]
+                item.suggestions = [match.sku for match in matches]
+        except ProviderNotFoundError as e:
+            item.detail = item.detail or str(e)
+        return _with_delta(item)
+
+    def _volume(self, volume: InventoryRecord, target_region: str) -> RegionMigrationItem:
+        item = RegionMigrationItem(
+            name=volume.name or volume.resource_id,
+            kind=KIND_VOLUME,
+            provider=volume.provider,
+            region=volume.region,
+            sku=volume.sku,
+            available=True,
+        )
+        try:
+            sku = volume_sku(volume.provider, volume.sku, volume.size_gb)
+            item.current_monthly_cost = self._storage_monthly(volume, volume.region, sku)
+        except (ValueError, PriceNotFoundError, ProviderNotFoundError) as e:
+            item.detail = str(e)
+            return item
+        try:
+            item.target_monthly_cost = self._storage_monthly(volume, target_region, sku)
+        except PriceNotFoundError as e:
+            item.available, item.detail = False, str(e)
+        except ProviderNotFoundError as e:
+            item.detail = str(e)
+        return _with_delta(item)
+
+    def _storage_monthly(self, volume: InventoryRecord, region: str, sku: str) -> float:
+        price = self.estimator.registry.get_price(PriceQuery(
+            provider=volume.provider, region=region, sku=sku, service=SERVICE_BLOCK_STORAGE,
+        ))
+        return round_money(price.price * volume.size_gb if price.unit == "GB-month" else price.price)
+
+
+def _only_provider(resources: List[ResourceSpec], inventory: List[InventoryRecord]) -> str:
+    providers = sorted({r.provider for r in resources} | {r.provider for r in inventory})
+    if not providers:
+        raise ValueError("No inventory resources match the selector")
+    if len(providers) > 1:
+        raise ValueError(f"Resources run on {', '.join(providers)}; name the provider of the target region")
+    return providers[0]
+
+
+def _with_delta(item: RegionMigrationItem) -> RegionMigrationItem:
+    if item.current_monthly_cost is not None and item.target_monthly_cost is not None:
+        item.monthly_delta = round_money(item.target_monthly_cost - item.current_monthly_cost)
+    return item
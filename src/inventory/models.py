@@ -0,0 +1,189 @@
+"""
+Data models for resource inventory, idle resource findings and region migrations
+"""
+
+from datetime import datetime
+from typing import Dict, List, Optional
+
+from pydantic import BaseModel, Field, root_validator, validator
+
+from ..estimator import ResourceSpec
+from ..store import InventoryRecord
+from ..units import hours_per_month
+
+# Resource kinds
+KIND_INSTANCE = "instance"
+KIND_VOLUME = "volume"
+KIND_LOAD_BALANCER = "load_balancer"
+KIND_NODE_POOL = "node_pool"
+KINDS = (KIND_INSTANCE, KIND_VOLUME, KIND_LOAD_BALANCER, KIND_NODE_POOL)
+
+# Why a resource is idle
+REASON_UNATTACHED_VOLUME = "unattached_volume"
+REASON_UNUSED_LOAD_BALANCER = "unused_load_balancer"
+REASON_STOPPED_INSTANCE = "stopped_instance"
+REASON_EMPTY_NODE_POOL = "empty_node_pool"
+
+# Kind-specific counts kept with a resource
+_ATTRIBUTES = ("targets", "requests", "nodes", "pods")
+
+
+class InventoryResource(BaseModel):
+    """A resource reported by an inventory collector"""
+
+    resource_id: str = Field(..., min_length=1, description="Provider ID, e.g. vol-0abc or an ARN")
+    kind: str = Field(..., description="instance, volume, load_balancer or node_pool")
+    provider: str = Field(..., min_length=1, description="aws, gcp or azure")
+    region: str = ""
+    sku: str = Field("", description="Instance type of instances and node pools, volume or load balancer type")
+    name: str = ""
+    state: str = Field("", description="Provider state, e.g. running, stopped, deallocated, available, in-use")
+    attached_to: str = Field("", description="Instance a volume is attached to")
+    size_gb: float = Field(0.0, ge=0, description="Volume size")
+    targets: Optional[int] = Field(None, ge=0, description="Healthy targets of a load balancer")
+    requests: Optional[int] = Field(None, ge=0, description="Requests a load balancer served over the collector's lookback")
+    nodes: Optional[int] = Field(None, ge=0, description="Nodes of a node pool")
+    pods: Optional[int] = Field(None, ge=0, description="Pods on a node pool's nodes, not counting DaemonSets")
+    labels: Dict[str, str] = Field(default_factory=dict, description="Tags or labels")
+    observed_at: Optional[datetime] = Field(None, description="When the collector saw the resource, default now")
+
+    @validator("kind")
+    def validate_kind(cls, v):
+        v = v.strip().lower()
+        if v not in KINDS:
+            raise ValueError(f"Unknown resource kind '{v}', expected one of {', '.join(KINDS)}")
+        return v
+
+    @validator("provider")
+    def normalize_provider(cls, v):
+        return v.strip().lower()
+
+    @validator("state")
+    def normalize_state(cls, v):
+        return v.strip().lower()
+
+    def to_record(self, tenant_id: str, source: str) -> InventoryRecord:
+        data = self.dict()
+        attributes = {key: data.pop(key) for key in _ATTRIBUTES}
+        return InventoryRecord(
+            tenant_id=tenant_id,
+            source=source,
+            attributes={key: value for key, value in attributes.items() if value is not None},
+            **data,
+        )
+
+
+class InventoryBatch(BaseModel):
+    """A collector's full snapshot of a tenant's resources"""
+
+    source: str = Field(..., min_length=1, description="Collector name; its previous snapshot is replaced")
+    resources: List[InventoryResource] = Field(default_factory=list)
+
+
+class CollectRequest(BaseModel):
+    """Request model for collecting a tenant's inventory from a cloud API now"""
+
+    source: str = Field(..., min_length=1, description="Collector, e.g. aws-ec2, gcp-compute or azure-resource-graph")
+
+
+class CollectResult(BaseModel):
+    """Outcome of collecting the resources a cloud API lists"""
+
+    source: str
+    tenant_id: str
+    resources: int = Field(..., description="Resources recorded, replacing the source's previous snapshot")
+    kinds: Dict[str, int] = Field(default_factory=dict, description="Resources recorded per kind")
+    collected_at: datetime
+
+
+class IdleFinding(BaseModel):
+    """A resource that is billed without doing work"""
+
+    resource_id: str
+    kind: str
+    reason: str = Field(..., description="unattached_volume, unused_load_balancer, stopped_instance or empty_node_pool")
+    provider: str
+    region: str
+    sku: str
+    name: str = ""
+    source: str
+    detail: str = Field(..., description="e.g. gp3 volume of 500 GiB attached to no instance")
+    labels: Dict[str, str] = Field(default_factory=dict)
+    observed_at: datetime
+    waste_monthly_cost: Optional[float] = Field(None, description="Estimated monthly waste, None if it could not be priced")
+
+
+class IdleReport(BaseModel):
+    """Idle resources of a tenant's inventory, largest waste first"""
+
+    resources: int = Field(..., description="Resources inventoried")
+    findings: List[IdleFinding]
+    waste_monthly_cost: float = Field(..., description="Estimated waste of all priced findings")
+    currency: str = "USD"
+    unpriced: List[str] = Field(default_factory=list, description="Resource IDs of findings that could not be priced")
+
+
+class RegionMigrationRequest(BaseModel):
+    """Resources to move to another region, listed or selected from the inventory by their labels"""
+
+    target_region: str = Field(..., min_length=1, description="Region the resources move to, e.g. eu-central-1")
+    provider: Optional[str] = Field(
+        None, description="Cloud of the target region, default the resources' only cloud"
+    )
+    resources: List[ResourceSpec] = Field(default_factory=list, description="Deployed resources")
+    selector: Optional[Dict[str, str]] = Field(
+        None, description="Inventory instances, node pools and volumes whose labels include all of these, e.g. app=web"
+    )
+    hours: float = Field(
+        default_factory=hours_per_month, gt=0, le=744, description="Running hours per month of inventory resources"
+    )
+    suggestions: int = Field(3, ge=0, le=10, description="Instance types suggested for each one unavailable")
+
+    @validator("target_region")
+    def strip_target_region(cls, v):
+        return v.strip()
+
+    @validator("provider")
+    def normalize_provider(cls, v):
+        return v.strip().lower() if v is not None else None
+
+    @root_validator(skip_on_failure=True)
+    def require_resources(cls, values):
+        if not values.get("resources") and values.get("selector") is None:
+            raise ValueError("List resources or give an inventory label selector")
+        return values
+
+
+class RegionMigrationItem(BaseModel):
+    """Monthly cost of one resource in its current region and in the target region"""
+
+    name: str = Field(..., description="Resource name, inventory ID or instance type")
+    kind: str = Field(..., description="instance, node_pool or volume")
+    provider: str
+    region: str
+    sku: str
+    count: int = 1
+    available: bool = Field(..., description="Whether the target region offers the SKU")
+    current_monthly_cost: Optional[float] = Field(None, description="None if it could not be priced")
+    target_monthly_cost: Optional[float] = Field(None, description="None if unavailable or not priced")
+    monthly_delta: Optional[float] = Field(None, description="Target minus current, if both are priced")
+    suggestions: List[str] = Field(
+        default_factory=list, description="Target region instance types most like an unavailable one"
+    )
+    detail: str = Field("", description="Why the resource could not be priced")
+
+
+class RegionMigrationResult(BaseModel):
+    """What moving resources to another region changes in their monthly cost"""
+
+    provider: str
+    target_region: str
+    items: List[RegionMigrationItem] = Field(..., description="Largest increase first")
+    current_monthly_cost: float = Field(..., description="Cost of the resources priced in both regions")
+    target_monthly_cost: float = Field(..., description="Their cost in the target region")
+    monthly_delta: float
+    delta_percent: Optional[float] = Field(None, description="None when the current cost is zero")
+    summary: str = Field(..., description="e.g. 4 resources to eu-central-1: +$52.10/month (+8.3%), always in USD")
+    currency: str = "USD"
+    unavailable: List[str] = Field(default_factory=list, description="Resources whose SKU the target region lacks")
+    unpriced: List[str] = Field(default_factory=list, description="Resources that could not be priced currently")
@@ -0,0 +1,31 @@
+"""
+Jobs Module
+
+This module runs estimates of large inputs (cluster scans, big Terraform
+plans, large batches) and heavy periodic work (billing ingestion, reports)
+in the background, persisting each job with its progress and result so it
+survives restarts, retrying it and dead-lettering it when it keeps failing.
+"""
+
+from .models import (
+    JobKind,
+    JobOutcome,
+    JobSubmitRequest,
+    Progress,
+    KIND_BILLING_INGESTION,
+    KIND_CHARGEBACK_REPORT,
+    KIND_CLUSTER_SCAN,
+)
+from .runner import JobRunner, LeaseLostError
+
+__all__ = [
+    "JobKind",
+    "JobOutcome",
+    "JobSubmitRequest",
+    "Progress",
+    "KIND_BILLING_INGESTION",
+    "KIND_CHARGEBACK_REPORT",
+    "KIND_CLUSTER_SCAN",
+    "JobRunner",
+    "LeaseLostError",
+]
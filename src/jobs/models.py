@@ -0,0 +1,45 @@
+"""
+Data models for estimation jobs
+"""
+
+from typing import Any, Callable, Dict, NamedTuple, Optional, Type
+
+from pydantic import BaseModel, Field
+
+# Reports progress as (units done, units in total, detail)
+Progress = Callable[..., None]
+
+# Kinds of heavy work other than estimates, queued by the periodic schedules
+# (or by operators) and run by any replica with workers
+KIND_BILLING_INGESTION = "billing_ingestion"
+KIND_CLUSTER_SCAN = "cluster_scan"
+KIND_CHARGEBACK_REPORT = "chargeback_report"
+
+
+class JobSubmitRequest(BaseModel):
+    """An estimate to compute in the background"""
+
+    kind: str = Field(..., description=(
+        "terraform, pulumi, cloudformation, crossplane, cluster, kubernetes, batch, "
+        "billing_ingestion, cluster_scan or chargeback_report"
+    ))
+    request: Dict[str, Any] = Field(..., description="Request body of the kind's synchronous endpoint")
+
+
+class JobOutcome(NamedTuple):
+    """Result of a finished job, with the estimate it was recorded as"""
+
+    result: Dict[str, Any]
+    estimate_id: Optional[str] = None
+
+
+class JobKind(NamedTuple):
+    """How jobs of one kind are validated, run and kept"""
+
+    model: Type[BaseModel]
+    run: Callable[[BaseModel, Progress], JobOutcome]
+    # Request kept once the job finishes, e.g. without a Terraform plan's values
+    summarize: Optional[Callable[[BaseModel], Dict[str, Any]]] = None
+    # Runs retried after a failure other than a ValueError, for work failing
+    # on outages rather than on its input (billing exports, cluster scans)
+    retries: int = 0
@@ -0,0 +1,311 @@
+"""
+Background estimation jobs
+
+Large inputs, such as full cluster scans and big Terraform plans, are
+estimated in the background. A submitted job is persisted as queued and
+returned at once; a worker thread claims it, reports progress into the
+store while it runs and keeps its result or error with it.
+
+Every poll refreshes the heartbeat of the replica's running jobs. A job
+whose heartbeat is older than the lease lost its replica to a restart or
+crash, and the next poll of any replica claims it again, up to
+max_attempts starts. A job whose estimate fails is not retried: the same
+input fails the same way.
+
+Kinds of work failing on outages rather than on their input, such as
+billing ingestion and cluster scans, retry failed runs after a backoff
+doubling from retry_backoff. A job of such a kind that fails every retry,
+or is abandoned max_attempts times, is dead-lettered and kept until it
+is requeued. A runner without workers only queues jobs, leaving them to
+worker replicas, so API replicas stay responsive.
+"""
+
+import logging
+import os
+import socket
+import threading
+import time
+import uuid
+from datetime import datetime, timedelta, timezone
+from typing import Any, Callable, Dict, List, Optional
+
+from ..store import JOB_DEAD_LETTER, JOB_FAILED, JOB_QUEUED, JOB_RUNNING, JOB_SUCCEEDED, JobRecord, Store
+from ..tenancy import tenant_context
+from .models import JobKind
+
+logger = logging.getLogger(__name__)
+
+# Shortest time between two progress writes of a job
+PROGRESS_WRITE_SECONDS = 1.0
+# Queued and abandoned jobs a poll looks at
+_POLL_LIMIT = 100
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+class LeaseLostError(Exception):
+    """Raised into a running job whose claim another replica took over"""
+
+
+class JobRunner:
+    """Runs estimation jobs on a bounded number of worker threads"""
+
+    def __init__(
+        self,
+        store: Store,
+        kinds: Dict[str, JobKind],
+        workers: int = 2,
+        lease_seconds: float = 600,
+        max_attempts: int = 3,
+        retry_backoff: float = 30,
+        worker_id: Optional[str] = None,
+        clock: Callable[[], datetime] = utcnow,
+    ):
+        """
+        Initialize runner
+
+        Args:
+            store: Store the jobs are persisted in
+            kinds: How each job kind is run
+            workers: Jobs this replica runs at once (0: only queue them)
+            lease_seconds: Age of a running job's heartbeat after which it is claimed again
+            max_attempts: Starts of a job before it fails
+            retry_backoff: Seconds before the first retry of a failed run
+            worker_id: Name of this replica in the jobs it holds, default host, pid and a random suffix
+            clock: Current time (aware UTC)
+        """
+        if workers < 0 or max_attempts < 1 or retry_backoff < 0:
+            raise ValueError("Job workers and retry backoff must not be negative, max attempts at least 1")
+        self.store = store
+        self.kinds = kinds
+        self.workers = workers
+        self.lease_seconds = lease_seconds
+        self.max_attempts = max_attempts
+        self.retry_backoff = retry_backoff
+        self.worker_id = worker_id or f"{socket.gethostname()}-{os.getpid()}-{uuid.uuid4().hex[:6]}"
+        self.clock = clock
+
+        self._lock = threading.Lock()
+        self._running: Dict[str, JobRecord] = {}
+        self._threads: Dict[str, threading.Thread] = {}
+        self._closed = False
+
+    @property
+    def running(self) -> bool:
+        """Whether the runner accepts jobs"""
+        return not self._closed
+
+    def submit(self, tenant_id: str, kind: str, request: Dict[str, Any]) -> JobRecord:
+        """
+        Queue a job and start it if a worker is free
+
+        Raises:
+            ValueError: Unknown kind, or a request the kind does not accept
+            StoreError: If the job cannot be persisted
+        """
+        if kind not in self.kinds:
+            raise ValueError(f"Unknown job kind '{kind}', expected one of {', '.join(self.kinds)}")
+        parsed = self.kinds[kind].model.parse_obj(request)
+        record = self.store.create_job(JobRecord(tenant_id=tenant_id, kind=kind, request=parsed.dict()))
+        logger.info(f"Queued {kind} job {record.id} of tenant {tenant_id}")
+        return self._start(record.id) or record
+
+    def get(self, job_id: str, tenant_id: Optional[str] = None) -> Optional[JobRecord]:
+        """A job, or None if it does not exist (or belongs to another tenant)"""
+        return self.store.get_job(job_id, tenant_id=tenant_id)
+
+    def list(self, tenant_id: str, limit: int = 100, states: Optional[List[str]] = None) -> List[JobRecord]:
+        """A tenant's jobs, newest first, optionally in some states"""
+        return self.store.list_jobs(tenant_id=tenant_id, states=states, limit=limit)
+
+    def requeue(self, job_id: str, tenant_id: Optional[str] = None) -> Optional[JobRecord]:
+        """
+        Queue a failed or dead-lettered job again with its attempts reset, starting it if a worker is free
+
+        Returns:
+            The job, or None if it does not exist (or belongs to another tenant) or has not failed
+        """
+        record = self.store.requeue_job(job_id, tenant_id=tenant_id)
+        if record is None:
+            return None
+        logger.info(f"Requeued {record.kind} job {record.id} of tenant {record.tenant_id}")
+        return self._start(record.id) or record
+
+    def poll(self) -> List[str]:
+        """
+        Refresh the heartbeat of running jobs, then start queued and abandoned ones, oldest first
+
+        Returns:
+            IDs of the jobs started
+        """
+        with self._lock:
+            running = list(self._running.values())
+        for record in running:
+            self._write(record)
+
+        now = self.clock()
+        stale_before = now - timedelta(seconds=self.lease_seconds)
+        started = []
+        for record in reversed(self.store.list_jobs(states=[JOB_QUEUED, JOB_RUNNING], limit=_POLL_LIMIT)):
+            if self._closed or self.idle_workers() == 0:
+                break
+            if record.id in self._running:
+                continue
+            if record.state == JOB_QUEUED and record.run_after is not None and record.run_after > now:
+                continue
+            if record.state == JOB_RUNNING and record.updated_at >= stale_before:
+                continue
+            if record.state == JOB_RUNNING:
+                logger.warning(f"Job {record.id} of worker {record.worker} abandoned, resuming")
+            if self._start(record.id, stale_before) is not None:
+                started.append(record.id)
+        return started
+
+    def idle_workers(self) -> int:
+        """Jobs this replica could start now"""
+        with self._lock:
+            return max(self.workers - len(self._running), 0)
+
+    def close(self, timeout: float = 0) -> None:
+        """
+        Stop starting jobs, waiting up to timeout seconds for running ones
+
+        Jobs still running are resumed by the next replica once their lease
+        expires.
+        """
+        with self._lock:
+            self._closed = True
+            threads = list(self._threads.values())
+        deadline = time.monotonic() + timeout
+        for thread in threads:
+            thread.join(max(deadline - time.monotonic(), 0))
+
+    def _start(self, job_id: str, stale_before: Optional[datetime] = None) -> Optional[JobRecord]:
+        """Claim a job and run it on a new worker thread, None if no worker is free or it is taken"""
+        with self._lock:
+            if self._closed or len(self._running) >= self.workers:
+                return None
+            record = self.store.claim_job(job_id, self.worker_id, stale_before or self.clock())
+            if record is None:
+                return None
+            self._running[record.id] = record
+            thread = threading.Thread(
+                target=self._run, args=(record,), name=f"estimate-job-{record.id[:8]}", daemon=True
+            )
+            self._threads[record.id] = thread
+        thread.start()
+        return record
+
+    def _run(self, record: JobRecord) -> None:
+        try:
+            kind = self.kinds.get(record.kind)
+            if kind is None:
+                self._finish(record, JOB_FAILED, error=f"Unknown job kind '{record.kind}'")
+            elif record.attempts > max(self.max_attempts, kind.retries + 1):
+                self._finish(
+                    record, JOB_DEAD_LETTER if kind.retries else JOB_FAILED,
+                    error=f"Abandoned {record.attempts - 1} times, giving up",
+                )
+            else:
+                self._execute(record, kind)
+        except LeaseLostError:
+            logger.warning(f"Job {record.id} was taken over by another worker")
+        except Exception as e:
+            logger.error(f"Job {record.id} could not be written: {e}")
+        finally:
+            with self._lock:
+                self._running.pop(record.id, None)
+                self._threads.pop(record.id, None)
+
+    def _execute(self, record: JobRecord, kind: JobKind) -> None:
+        last_write = [time.monotonic()]
+
+        def progress(done: int, total: int, detail: Optional[str] = None) -> None:
+            with self._lock:
+                current = self._running.get(record.id, record).copy(update={
+                    "progress": min(max(done / total, 0.0), 1.0) if total > 0 else 0.0,
+                    "progress_detail": detail,
+                })
+                self._running[record.id] = current
+            if time.monotonic() - last_write[0] >= PROGRESS_WRITE_SECONDS:
+                last_write[0] = time.monotonic()
+                if not self._write(current):
+                    raise LeaseLostError(record.id)
+
+        request = None
+        started = time.monotonic()
+        try:
+            request = kind.model.parse_obj(record.request)
+            with tenant_context(record.tenant_id):
+                outcome = kind.run(request, progress)
+        except LeaseLostError:
+            raise
+        except Exception as e:
+            if kind.retries and not isinstance(e, ValueError):
+                self._retry(record, kind, request, e)
+            else:
+                logger.error(f"{record.kind} job {record.id} failed: {e}")
+                self._finish(record, JOB_FAILED, kind, request, error=str(e))
+            return
+        logger.info(f"{record.kind} job {record.id} succeeded in {time.monotonic() - started:.1f}s")
+        self._finish(record, JOB_SUCCEEDED, kind, request, result=outcome.result, estimate_id=outcome.estimate_id)
+
+    def _retry(self, record: JobRecord, kind: JobKind, request: Any, error: Exception) -> None:
+        """Queue a failed run again after the backoff, or dead-letter the job once its retries are used up"""
+        if record.attempts > kind.retries:
+            logger.error(f"{record.kind} job {record.id} failed {record.attempts} times, dead-lettered: {error}")
+            self._finish(record, JOB_DEAD_LETTER, kind, request, error=str(error))
+            return
+        delay = self.retry_backoff * 2 ** (record.attempts - 1)
+        logger.warning(
+            f"{record.kind} job {record.id} failed (attempt {record.attempts}/{kind.retries + 1}), "
+            f"retrying in {delay:.0f}s: {error}"
+        )
+        with self._lock:
+            queued = self._running.get(record.id, record).copy(update={
+                "state": JOB_QUEUED,
+                "error": str(error),
+                "run_after": self.clock() + timedelta(seconds=delay),
+            })
+        if not self.store.update_job(queued):
+            raise LeaseLostError(record.id)
+
+    def _finish(
+        self,
+        record: JobRecord,
+        state: str,
+        kind: Optional[JobKind] = None,
+        request: Any = None,
+        result: Optional[Dict[str, Any]] = None,
+        estimate_id: Optional[str] = None,
+        error: Optional[str] = None,
+    ) -> None:
+        update = {
+            "state": state,
+            "result": result,
+            "estimate_id": estimate_id,
+            "error": error,
+            "finished_at": self.clock(),
+            "run_after": None,
+        }
+        if state == JOB_SUCCEEDED:
+            update.update(progress=1.0, progress_detail=None)
+        if kind is not None and kind.summarize is not None and request is not None:
+            update["request"] = kind.summarize(request)
+        with self._lock:
+            final = self._running.get(record.id, record).copy(update=update)
+        if not self.store.update_job(final):
+            raise LeaseLostError(record.id)
+
+    def _write(self, record: JobRecord) -> bool:
+        """Write a running job's progress, refreshing its heartbeat; False if the job is no longer held"""
+        try:
+            held = self.store.update_job(record)
+        except Exception as e:
+            logger.error(f"Heartbeat of job {record.id} failed: {e}")
+            return True
+        if not held:
+            logger.warning(f"Job {record.id} is no longer held by {self.worker_id}")
+        return held
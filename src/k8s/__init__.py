@@ -0,0 +1,98 @@
+"""
+Kubernetes Cost Estimation Module
+
+Parses Kubernetes manifests and estimates their monthly cost from
+resource requests, replica counts and storage classes, and prices the
+usage Prometheus measured in a running cluster against its requests,
+recommending lower requests and cheaper node types from that usage,
+and the current state of a cluster read from its API server. Given node
+pools, pods are bin-packed onto them to project the nodes the cluster
+autoscaler would run, and the cheapest node pool mix for a running
+cluster's workloads is searched the same way. The savings of moving a
+cluster's ARM-compatible workloads to ARM nodes are estimated too.
+"""
+
+from .models import (
+    WorkloadResources,
+    VolumeClaim,
+    ParsedManifests,
+    KubernetesEstimateRequest,
+    KubernetesEstimateResult,
+    UsageEstimateRequest,
+    UsageEstimateResult,
+    RightsizingRequest,
+    RightsizingResult,
+    ClusterEstimateRequest,
+    ClusterEstimateResult,
+    NodePool,
+    NodeTaint,
+    NodePoolProjection,
+    NodePoolRequest,
+    RecommendedNodePool,
+    NodePoolRecommendation,
+    ArmMigrationRequest,
+    ArmMigrationResult,
+)
+from .parser import parse_manifests, parse_documents, effective_pod_requests
+from .quantity import parse_quantity, parse_cpu, parse_bytes_gb
+from .estimator import KubernetesEstimator
+from .autoscaler import AutoscalerSimulator, NodeGroup, SimulationResult, MAX_SIMULATED_PODS
+from .prometheus import PrometheusClient, PrometheusError
+from .usage import UsageEstimator, usage_queries
+from .rightsizing import RightsizingRecommender, node_presence_query
+from .nodepools import NodePoolOptimizer
+from .arm import ArmMigrationAnalyzer, ARM_COMPATIBLE_ANNOTATION
+from .cluster import (
+    ClusterEstimator,
+    ClusterSnapshot,
+    ClusterSource,
+    KubernetesAPISource,
+    parse_hourly_rates,
+)
+
+__all__ = [
+    "WorkloadResources",
+    "VolumeClaim",
+    "ParsedManifests",
+    "KubernetesEstimateRequest",
+    "KubernetesEstimateResult",
+    "UsageEstimateRequest",
+    "UsageEstimateResult",
+    "RightsizingRequest",
+    "RightsizingResult",
+    "ClusterEstimateRequest",
+    "ClusterEstimateResult",
+    "NodePool",
+    "NodeTaint",
+    "NodePoolProjection",
+    "NodePoolRequest",
+    "RecommendedNodePool",
+    "NodePoolRecommendation",
+    "ArmMigrationRequest",
+    "ArmMigrationResult",
+    "parse_manifests",
+    "parse_documents",
+    "effective_pod_requests",
+    "parse_quantity",
+    "parse_cpu",
+    "parse_bytes_gb",
+    "KubernetesEstimator",
+    "AutoscalerSimulator",
+    "NodeGroup",
+    "SimulationResult",
+    "MAX_SIMULATED_PODS",
+    "PrometheusClient",
+    "PrometheusError",
+    "UsageEstimator",
+    "usage_queries",
+    "RightsizingRecommender",
+    "node_presence_query",
+    "NodePoolOptimizer",
+    "ArmMigrationAnalyzer",
+    "ARM_COMPATIBLE_ANNOTATION",
+    "ClusterEstimator",
+    "ClusterSnapshot",
+    "ClusterSource",
+    "KubernetesAPISource",
+    "parse_hourly_rates",
+]
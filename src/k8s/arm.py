@@ -0,0 +1,278 @@
+"""
+ARM migration recommendations
+
+Estimates the savings of moving a running cluster from x86 to ARM
+instance families (e.g. m5 → m6g, Standard_D4s_v5 → Standard_D4ps_v5).
+Each x86 node is matched with the cheapest ARM type of the same vCPUs
+and memory priced in its region, burstable types with burstable ones.
+
+Whether a workload runs on ARM depends on its images, which the cluster
+does not tell, so each workload is classified from, in order:
+
+- The compatible / incompatible namespace/name patterns of the request
+- Its kcloud.io/arm-compatible annotation ("true" or "false")
+- Its pod spec: GPU requests, or a nodeSelector or required node
+  affinity excluding arm64 nodes, make it incompatible; tolerating the
+  kubernetes.io/arch=arm64 taint ARM node pools carry makes it compatible
+
+Workloads none of these decide are unknown. A workload's savings are
+its requests priced at the x86 nodes' rates minus the same requests at
+the ARM equivalents' rates; the total counts compatible workloads, and
+unknown ones too when assume_compatible is set.
+"""
+
+import fnmatch
+import logging
+from typing import Dict, List, Optional, Tuple
+
+from ..compare.normalize import is_burstable
+from ..money import round_money
+from ..pricing import (
+    PriceNotFoundError,
+    ProviderNotFoundError,
+    get_instance_shape,
+    known_instance_types,
+)
+from .autoscaler import NodeGroup, can_schedule, tolerates
+from .cluster import ClusterEstimator, cluster_rates, daemon_pods
+from .models import (
+    ArmMigrationRequest,
+    ArmMigrationResult,
+    ArmNodeMigration,
+    ArmWorkload,
+    NodeCost,
+    NodeRates,
+    WorkloadResources,
+)
+from .parser import parse_documents
+
+logger = logging.getLogger(__name__)
+
+ARM_COMPATIBLE_ANNOTATION = "kcloud.io/arm-compatible"
+ARCH_LABEL = "kubernetes.io/arch"
+
+# Taint GKE sets on arm64 nodes, and ARM node pools commonly carry elsewhere
+ARM_TAINT = {"key": "kubernetes.io/arch", "value": "arm64", "effect": "NoSchedule"}
+
+COMPATIBLE = "compatible"
+INCOMPATIBLE = "incompatible"
+UNKNOWN = "unknown"
+
+_ARM = "arm64"
+
+# (provider, region, instance type, pricing model)
+_NodeKey = Tuple[str, str, str, str]
+
+
+def _matches(value: str, patterns: List[str]) -> bool:
+    return any(fnmatch.fnmatchcase(value, pattern) for pattern in patterns)
+
+
+class ArmMigrationAnalyzer:
+    """Estimates the savings of moving a running cluster's x86 workloads to ARM nodes"""
+
+    def __init__(self, cluster: ClusterEstimator):
+        """
+        Initialize analyzer
+
+        Args:
+            cluster: Cluster estimator reading and pricing the cluster's nodes
+        """
+        self.cluster = cluster
+        self.estimator = cluster.estimator
+
+    def recommend(self, request: ArmMigrationRequest) -> ArmMigrationResult:
+        """
+        ARM equivalents of the cluster's x86 nodes and the savings of its compatible workloads
+
+        Raises:
+            ValueError: If an object has invalid quantities
+        """
+        snapshot = self.cluster.source.snapshot()
+        unpriced: List[str] = []
+        priced = self.cluster.price_nodes(snapshot.nodes, request.hours, unpriced)
+
+        groups: Dict[_NodeKey, List[Tuple[NodeCost, NodeRates]]] = {}
+        for node, rates in priced:
+            if _architecture(node.provider, node.instance_type) == _ARM:
+                continue
+            key = (node.provider, node.region, node.instance_type, node.pricing_model)
+            groups.setdefault(key, []).append((node, rates))
+
+        migrations: List[ArmNodeMigration] = []
+        x86_nodes: List[Tuple[NodeCost, NodeRates]] = []
+        arm_nodes: List[Tuple[NodeCost, NodeRates]] = []
+        for key, nodes in sorted(groups.items()):
+            equivalent = self._equivalent(key, request.instance_families)
+            migrations.append(_migration(key, nodes, equivalent, request.hours))
+            x86_nodes.extend(nodes)
+            arm_nodes.extend((node, equivalent or rates) for node, rates in nodes)
+
+        x86_rates = cluster_rates(x86_nodes)
+        arm_rates = cluster_rates(arm_nodes)
+        workloads: List[ArmWorkload] = []
+        if x86_rates is not None and arm_rates is not None:
+            daemons = daemon_pods(snapshot.workloads)
+            for workload in parse_documents(snapshot.workloads).workloads:
+                if workload.kind == "DaemonSet":
+                    workload.replicas = daemons.get((workload.namespace, workload.name), 0)
+                compatibility, reason = _compatibility(workload, request)
+                if compatibility is None:
+                    continue
+                try:
+                    current = self.estimator.workload_cost(workload, x86_rates, request.hours).monthly_cost
+                    arm = self.estimator.workload_cost(workload, arm_rates, request.hours).monthly_cost
+                except ValueError as e:
+                    logger.warning(f"Workload {workload.namespace}/{workload.name} not priced: {e}")
+                    unpriced.append(f"{workload.kind}/{workload.namespace}/{workload.name}")
+                    continue
+                workloads.append(ArmWorkload(
+                    kind=workload.kind,
+                    name=workload.name,
+                    namespace=workload.namespace,
+                    compatibility=compatibility,
+                    reason=reason,
+                    current_monthly_cost=round_money(current),
+                    arm_monthly_cost=round_money(arm),
+                    savings_monthly_cost=round_money(current - arm),
+                ))
+        workloads.sort(key=lambda w: (-w.savings_monthly_cost, w.namespace, w.name))
+
+        counted = (COMPATIBLE, UNKNOWN) if request.assume_compatible else (COMPATIBLE,)
+        savings = sum(w.savings_monthly_cost for w in workloads if w.compatibility in counted)
+        current_cost = sum(m.current_monthly_cost for m in migrations)
+        node_savings = sum(m.savings_monthly_cost for m in migrations)
+        counts = {c: sum(1 for w in workloads if w.compatibility == c) for c in (COMPATIBLE, INCOMPATIBLE, UNKNOWN)}
+
+        result = ArmMigrationResult(
+            nodes=migrations,
+            workloads=workloads,
+            compatible_workloads=counts[COMPATIBLE],
+            incompatible_workloads=counts[INCOMPATIBLE],
+            unknown_workloads=counts[UNKNOWN],
+            current_monthly_cost=round_money(current_cost),
+            arm_monthly_cost=round_money(current_cost - node_savings),
+            node_savings_monthly_cost=round_money(node_savings),
+            savings_monthly_cost=round_money(savings),
+            summary=_summary(migrations, counts, len(workloads), savings, node_savings),
+            unpriced=unpriced,
+        )
+        logger.info(f"ARM migration of cluster {self.cluster.cluster}: {result.summary}")
+        return result
+
+    def _equivalent(self, key: _NodeKey, families: List[str]) -> Optional[NodeRates]:
+        """Rates of the cheapest ARM type of a node type's shape, None if none is priced"""
+        provider, region, instance_type, pricing_model = key
+        try:
+            shape = get_instance_shape(provider, instance_type)
+        except KeyError:
+            return None
+        if shape.gpus:
+            return None
+
+        burstable = is_burstable(provider, instance_type)
+        best: Optional[NodeRates] = None
+        for candidate, candidate_shape in sorted(known_instance_types(provider).items()):
+            if (
+                candidate_shape.arch != _ARM
+                or candidate_shape.gpus
+                or candidate_shape.vcpus != shape.vcpus
+                or candidate_shape.memory_gb != shape.memory_gb
+                or is_burstable(provider, candidate) != burstable
+                or (families and not _matches(candidate, families))
+            ):
+                continue
+            try:
+                rates = self.estimator.node_rates(provider, region, candidate, pricing_model)
+            except (ValueError, PriceNotFoundError, ProviderNotFoundError):
+                continue
+            if best is None or rates.hourly_price < best.hourly_price:
+                best = rates
+        return best
+
+
+def _architecture(provider: str, instance_type: str) -> Optional[str]:
+    try:
+        return get_instance_shape(provider, instance_type).arch
+    except KeyError:
+        return None
+
+
+def _migration(
+    key: _NodeKey, nodes: List[Tuple[NodeCost, NodeRates]], equivalent: Optional[NodeRates], hours: float
+) -> ArmNodeMigration:
+    provider, region, instance_type, pricing_model = key
+    rates = nodes[0][1]
+    current = sum(node.monthly_cost for node, _ in nodes)
+    arm = equivalent.hourly_price * hours * len(nodes) if equivalent else None
+    savings = current - arm if arm is not None else 0.0
+    if equivalent is None:
+        summary = f"{len(nodes)} x {instance_type}: no ARM type of the same shape priced"
+    else:
+        summary = f"{len(nodes)} x {instance_type} → {equivalent.instance_type}, save ${savings:,.0f}/mo"
+    return ArmNodeMigration(
+        provider=provider,
+        region=region,
+        instance_type=instance_type,
+        pricing_model=pricing_model,
+        arm_instance_type=equivalent.instance_type if equivalent else None,
+        nodes=len(nodes),
+        vcpus=rates.vcpus,
+        memory_gb=rates.memory_gb,
+        hourly_price=rates.hourly_price,
+        arm_hourly_price=equivalent.hourly_price if equivalent else None,
+        current_monthly_cost=round_money(current),
+        arm_monthly_cost=round_money(arm) if arm is not None else None,
+        savings_monthly_cost=round_money(savings),
+        summary=summary,
+    )
+
+
+def _compatibility(workload: WorkloadResources, request: ArmMigrationRequest) -> Tuple[Optional[str], str]:
+    """(compatibility, reason) of a workload, None for workloads that only run on ARM nodes already"""
+    name = f"{workload.namespace}/{workload.name}"
+    if _matches(name, request.incompatible):
+        return INCOMPATIBLE, "listed incompatible"
+    if _matches(name, request.compatible):
+        return COMPATIBLE, "listed compatible"
+    annotation = workload.annotations.get(ARM_COMPATIBLE_ANNOTATION, "").strip().lower()
+    if annotation in ("true", "false"):
+        if annotation == "true":
+            return COMPATIBLE, "annotated compatible"
+        return INCOMPATIBLE, "annotated incompatible"
+    if workload.gpus:
+        return INCOMPATIBLE, "requests GPUs, which no ARM type offers"
+
+    if not _allows(workload, _ARM):
+        return INCOMPATIBLE, "node selector or affinity excludes arm64 nodes"
+    if not _allows(workload, "amd64"):
+        return None, ""
+    if tolerates(workload.tolerations, ARM_TAINT):
+        return COMPATIBLE, "tolerates the arm64 node taint"
+    return UNKNOWN, f"image architectures not known, list it or set {ARM_COMPATIBLE_ANNOTATION}"
+
+
+def _allows(workload: WorkloadResources, arch: str) -> bool:
+    """Whether a workload's nodeSelector and required node affinity allow nodes of an architecture"""
+    pinned = workload.copy(update={
+        "node_selector": {k: v for k, v in workload.node_selector.items() if k == ARCH_LABEL},
+        "node_affinity": [
+            {"matchExpressions": [e for e in term.get("matchExpressions") or [] if e.get("key") == ARCH_LABEL]}
+            for term in workload.node_affinity
+        ],
+    })
+    return can_schedule(pinned, NodeGroup(name=arch, labels={ARCH_LABEL: arch}, taints=[]))
+
+
+def _summary(
+    migrations: List[ArmNodeMigration], counts: Dict[str, int], total: int, savings: float, node_savings: float
+) -> str:
+    """e.g. 3 x m5.xlarge → m6g.xlarge: save $84/mo if all move; 4 of 6 workloads compatible, save $52/mo"""
+    moves = [m for m in migrations if m.arm_instance_type]
+    if not moves:
+        return "No x86 node has a priced ARM equivalent"
+    nodes = " + ".join(f"{m.nodes} x {m.instance_type} → {m.arm_instance_type}" for m in moves)
+    return (
+        f"{nodes}: save ${node_savings:,.0f}/mo if all move; "
+        f"{counts[COMPATIBLE]} of {total} workloads compatible, save ${savings:,.0f}/mo"
+    )
@@ -0,0 +1,286 @@
+"""
+Cluster autoscaler simulation
+
+Projects the nodes a cluster autoscaler would run for a set of workloads
+by bin-packing their pods onto node groups, the way the scheduler and
+the autoscaler's scale-up do:
+
+- A pod runs on a node whose taints (NoSchedule, NoExecute) it tolerates,
+  whose labels match its nodeSelector and required node affinity, and
+  whose free allocatable CPU, memory, GPUs and pod slots fit its requests
+- DaemonSet pods run on every node they can run on, taking their requests
+  out of each node's allocatable resources
+- Pods are placed largest first on the first existing node they fit
+  (first-fit decreasing); a pod fitting none scales up the group leaving
+  the least capacity unused once the pending pods it fits are placed
+  (the autoscaler's least-waste expander), the cheaper group on a tie
+
+Groups start at their minimum node count and never grow past their
+maximum; pods no group can run are reported as unschedulable.
+"""
+
+from typing import Any, Dict, List, NamedTuple, Optional, Tuple
+
+from .models import WorkloadResources
+
+# Pods a single simulation places, bounding the time it takes
+MAX_SIMULATED_PODS = 20000
+
+# Pending pods the least-waste expander tries on a new node
+_LOOKAHEAD_PODS = 1000
+
+_DAEMON_KINDS = ("DaemonSet",)
+# Taint effects keeping pods that do not tolerate them off a node
+_BLOCKING_EFFECTS = ("NoSchedule", "NoExecute")
+_EPSILON = 1e-9
+
+
+# Instance architecture -> kubernetes.io/arch node label
+ARCH_LABELS = {"x86_64": "amd64", "arm64": "arm64"}
+
+
+def node_labels(instance_type: str, arch: str, region: str) -> Dict[str, str]:
+    """Well-known labels the kubelet and cloud provider set on a node"""
+    return {
+        "node.kubernetes.io/instance-type": instance_type,
+        "kubernetes.io/arch": ARCH_LABELS.get(arch, arch),
+        "kubernetes.io/os": "linux",
+        "topology.kubernetes.io/region": region,
+    }
+
+
+class NodeGroup(NamedTuple):
+    """Node group nodes can be added to"""
+
+    name: str
+    labels: Dict[str, str]
+    taints: List[Dict[str, Any]]
+    cpu_cores: float = 0.0
+    memory_gb: float = 0.0
+    gpus: float = 0.0
+    max_pods: int = 110
+    min_nodes: int = 0
+    max_nodes: Optional[int] = None
+    hourly_price: float = 0.0
+
+
+class SimulatedNode:
+    """A node of the simulation with the resources left on it"""
+
+    def __init__(self, group: NodeGroup, cpu_cores: float, memory_gb: float, gpus: float, pods: int):
+        self.group = group
+        self.cpu_cores = cpu_cores
+        self.memory_gb = memory_gb
+        self.gpus = gpus
+        self.pods = pods
+
+    def fits(self, pod: "_Pod") -> bool:
+        return (
+            self.pods >= 1
+            and pod.cpu_cores <= self.cpu_cores + _EPSILON
+            and pod.memory_gb <= self.memory_gb + _EPSILON
+            and pod.gpus <= self.gpus + _EPSILON
+        )
+
+    def place(self, pod: "_Pod") -> None:
+        self.cpu_cores -= pod.cpu_cores
+        self.memory_gb -= pod.memory_gb
+        self.gpus -= pod.gpus
+        self.pods -= 1
+
+
+class SimulationResult(NamedTuple):
+    """Nodes per group and where each workload's pods run"""
+
+    nodes: Dict[str, int]
+    # (workload index, group name) -> pods of the workload on the group's nodes
+    placements: Dict[Tuple[int, str], int]
+    # Workload index -> pods not placed
+    unschedulable: Dict[int, int]
+
+
+class _Pod(NamedTuple):
+    workload: int
+    cpu_cores: float
+    memory_gb: float
+    gpus: float
+
+
+def tolerates(tolerations: List[Dict[str, Any]], taint: Dict[str, Any]) -> bool:
+    """Whether one of a pod's tolerations tolerates a taint"""
+    for toleration in tolerations:
+        effect = toleration.get("effect")
+        if effect and effect != taint.get("effect"):
+            continue
+        key = toleration.get("key")
+        if toleration.get("operator", "Equal") == "Exists":
+            if not key or key == taint.get("key"):
+                return True
+        elif key == taint.get("key") and (toleration.get("value") or None) == (taint.get("value") or None):
+            return True
+    return False
+
+
+def _expression_matches(expression: Dict[str, Any], labels: Dict[str, str]) -> bool:
+    key = expression.get("key")
+    operator = expression.get("operator")
+    values = [str(v) for v in expression.get("values") or []]
+    if operator == "In":
+        return key in labels and labels[key] in values
+    if operator == "NotIn":
+        return key not in labels or labels[key] not in values
+    if operator == "Exists":
+        return key in labels
+    if operator == "DoesNotExist":
+        return key not in labels
+    if operator in ("Gt", "Lt") and key in labels and values:
+        try:
+            label, bound = int(labels[key]), int(values[0])
+        except ValueError:
+            return False
+        return label > bound if operator == "Gt" else label < bound
+    return False
+
+
+def can_schedule(workload: WorkloadResources, group: NodeGroup) -> bool:
+    """Whether a workload's pods may run on a group's nodes, resources aside"""
+    for taint in group.taints:
+        if taint.get("effect") in _BLOCKING_EFFECTS and not tolerates(workload.tolerations, taint):
+            return False
+    if any(group.labels.get(key) != value for key, value in workload.node_selector.items()):
+        return False
+    if workload.node_affinity:
+        return any(
+            all(_expression_matches(e, group.labels) for e in term.get("matchExpressions") or [])
+            for term in workload.node_affinity
+        )
+    return True
+
+
+class AutoscalerSimulator:
+    """Bin-packs workloads' pods onto node groups"""
+
+    def __init__(self, groups: List[NodeGroup], gpu_sharing: int = 1):
+        """
+        Initialize simulator
+
+        Args:
+            groups: Node groups in the order they are preferred on a tie
+            gpu_sharing: Pods sharing one GPU
+        """
+        self.groups = groups
+        self.gpu_sharing = gpu_sharing
+
+    def simulate(self, workloads: List[WorkloadResources]) -> SimulationResult:
+        """
+        Nodes each group runs for the workloads
+
+        Raises:
+            ValueError: If the workloads have more pods than a simulation places
+        """
+        daemons = [i for i, w in enumerate(workloads) if w.kind in _DAEMON_KINDS]
+        pods = [
+            _Pod(i, w.cpu_cores, w.memory_gb, w.gpus / self.gpu_sharing)
+            for i, w in enumerate(workloads) if w.kind not in _DAEMON_KINDS
+            for _ in range(w.replicas)
+        ]
+        if len(pods) > MAX_SIMULATED_PODS:
+            raise ValueError(f"Autoscaler simulation is limited to {MAX_SIMULATED_PODS} pods, got {len(pods)}")
+
+        # Each group's nodes are what is left of a node once its DaemonSet pods run
+        daemon_sets = {
+            group.name: [
+                i for i in daemons
+                if can_schedule(workloads[i], group) and workloads[i].gpus / self.gpu_sharing <= group.gpus
+            ]
+            for group in self.groups
+        }
+        allowed = {
+            i: [g for g in self.groups if can_schedule(w, g)]
+            for i, w in enumerate(workloads) if w.kind not in _DAEMON_KINDS
+        }
+        largest_cpu = max((g.cpu_cores for g in self.groups), default=0.0) or 1.0
+        largest_memory = max((g.memory_gb for g in self.groups), default=0.0) or 1.0
+        pods.sort(key=lambda p: (-p.gpus, -max(p.cpu_cores / largest_cpu, p.memory_gb / largest_memory), p.workload))
+
+        nodes: List[SimulatedNode] = []
+        for group in self.groups:
+            nodes.extend(self._new_node(group, workloads, daemon_sets) for _ in range(group.min_nodes))
+
+        placements: Dict[Tuple[int, str], int] = {}
+        unschedulable: Dict[int, int] = {}
+        for position, pod in enumerate(pods):
+            groups = allowed[pod.workload]
+            node = next((n for n in nodes if n.group in groups and n.fits(pod)), None)
+            if node is None:
+                pending = pods[position + 1:position + 1 + _LOOKAHEAD_PODS]
+                node = self._scale_up(pod, pending, groups, nodes, workloads, daemon_sets, allowed)
+                if node is None:
+                    unschedulable[pod.workload] = unschedulable.get(pod.workload, 0) + 1
+                    continue
+                nodes.append(node)
+            node.place(pod)
+            key = (pod.workload, node.group.name)
+            placements[key] = placements.get(key, 0) + 1
+
+        counts = {group.name: 0 for group in self.groups}
+        for node in nodes:
+            counts[node.group.name] += 1
+        for group in self.groups:
+            for i in daemon_sets[group.name]:
+                if counts[group.name] and workloads[i].replicas:
+                    placements[(i, group.name)] = counts[group.name]
+        return SimulationResult(nodes=counts, placements=placements, unschedulable=unschedulable)
+
+    def _new_node(
+        self, group: NodeGroup, workloads: List[WorkloadResources], daemon_sets: Dict[str, List[int]]
+    ) -> SimulatedNode:
+        daemons = [workloads[i] for i in daemon_sets[group.name] if workloads[i].replicas]
+        return SimulatedNode(
+            group,
+            group.cpu_cores - sum(w.cpu_cores for w in daemons),
+            group.memory_gb - sum(w.memory_gb for w in daemons),
+            group.gpus - sum(w.gpus / self.gpu_sharing for w in daemons),
+            group.max_pods - len(daemons),
+        )
+
+    def _scale_up(
+        self,
+        pod: _Pod,
+        pending: List[_Pod],
+        groups: List[NodeGroup],
+        nodes: List[SimulatedNode],
+        workloads: List[WorkloadResources],
+        daemon_sets: Dict[str, List[int]],
+        allowed: Dict[int, List[NodeGroup]],
+    ) -> Optional[SimulatedNode]:
+        """New node of the group wasting the least capacity, None if no group can take the pod"""
+        best: Optional[Tuple[Tuple[float, float], SimulatedNode]] = None
+        for group in groups:
+            if group.max_nodes is not None and sum(1 for n in nodes if n.group is group) >= group.max_nodes:
+                continue
+            node = self._new_node(group, workloads, daemon_sets)
+            if not node.fits(pod):
+                continue
+            score = (self._waste(node, pod, pending, allowed), group.hourly_price)
+            if best is None or score < best[0]:
+                best = (score, node)
+        return best[1] if best is not None else None
+
+    @staticmethod
+    def _waste(node: SimulatedNode, pod: _Pod, pending: List[_Pod], allowed: Dict[int, List[NodeGroup]]) -> float:
+        """Share of a new node's capacity left unused once the pod and the pending pods it fits are placed"""
+        trial = SimulatedNode(node.group, node.cpu_cores, node.memory_gb, node.gpus, node.pods)
+        trial.place(pod)
+        for other in pending:
+            if trial.pods < 1:
+                break
+            if node.group in allowed[other.workload] and trial.fits(other):
+                trial.place(other)
+
+        group = node.group
+        shares = [trial.cpu_cores / group.cpu_cores if group.cpu_cores else 0.0,
+                  trial.memory_gb / group.memory_gb if group.memory_gb else 0.0]
+        if group.gpus:
+            shares.append(trial.gpus / group.gpus)
+        return sum(shares) / len(shares)
@@ -0,0 +1,489 @@
+"""
+Live cluster cost estimation
+
+Reads the current state of a cluster from its API server (nodes,
+workloads, persistent volume claims and LoadBalancer Services) and
+prices it: nodes at their instance price, workloads at the per-vCPU and
+per-GiB rates averaged over the priced nodes, claims at the volume type
+of their storage class and load balancers at a flat hourly rate. Node
+cost not requested by any workload is reported as idle.
+
+Managed clusters add the control plane fee of their tier, configured or
+detected: clusters whose nodes carry a managed node pool label are on the
+provider's default tier, others are self-managed. On GKE Autopilot nodes
+are not billed and workloads are priced at the per-pod request rates.
+"""
+
+import copy
+import logging
+from abc import ABC, abstractmethod
+from collections import Counter
+from decimal import Decimal
+from typing import Any, Callable, Dict, List, NamedTuple, Optional, Tuple
+
+from ..money import add_amounts, round_money, to_decimal
+from ..pricing import (
+    PriceNotFoundError,
+    ProviderNotFoundError,
+    PRICING_ON_DEMAND,
+    PRICING_SPOT,
+    TIER_AUTOPILOT,
+    TIER_SELF_MANAGED,
+    default_cluster_tier,
+    normalize_cluster_tier,
+)
+from ..units import months_per_year
+from .estimator import KubernetesEstimator
+from .models import (
+    ClusterEstimateRequest,
+    ClusterEstimateResult,
+    ControlPlaneCost,
+    LoadBalancerCost,
+    NamespaceCost,
+    NodeCost,
+    NodeRates,
+    VolumeCost,
+    WorkloadCost,
+)
+from .parser import annotations_of, parse_documents
+from .usage import provider_of
+
+logger = logging.getLogger(__name__)
+
+# Hourly charge of one cloud load balancer, without data processing
+DEFAULT_LOAD_BALANCER_HOURLY = {"aws": 0.0225, "gcp": 0.025, "azure": 0.025}
+
+INSTANCE_TYPE_LABELS = ("node.kubernetes.io/instance-type", "beta.kubernetes.io/instance-type")
+REGION_LABELS = ("topology.kubernetes.io/region", "failure-domain.beta.kubernetes.io/region")
+# Node labels marking spot / preemptible capacity, with the value that marks it
+SPOT_NODE_LABELS = {
+    "eks.amazonaws.com/capacityType": "SPOT",
+    "karpenter.sh/capacity-type": "spot",
+    "cloud.google.com/gke-spot": "true",
+    "cloud.google.com/gke-preemptible": "true",
+    "kubernetes.azure.com/scalesetpriority": "spot",
+}
+# Node labels set by managed node pools (EKS, GKE, AKS)
+MANAGED_NODE_LABELS = (
+    "eks.amazonaws.com/nodegroup",
+    "eks.amazonaws.com/compute-type",
+    "cloud.google.com/gke-nodepool",
+    "kubernetes.azure.com/cluster",
+)
+
+
+def parse_hourly_rates(value: str) -> Dict[str, float]:
+    """
+    Parse per-provider hourly rates such as "aws=0.0225,gcp=0.025"
+
+    Raises:
+        ValueError: If an entry is malformed
+    """
+    rates: Dict[str, float] = {}
+    for item in value.split(","):
+        item = item.strip()
+        if not item:
+            continue
+        provider, sep, rate = item.partition("=")
+        try:
+            if not sep or not provider.strip():
+                raise ValueError
+            rates[provider.strip().lower()] = float(rate)
+        except ValueError:
+            raise ValueError(f"Invalid hourly rate '{item}', expected provider=rate") from None
+    return rates
+
+
+class ClusterSnapshot(NamedTuple):
+    """Objects of a cluster, as API server JSON"""
+
+    nodes: List[Dict[str, Any]]
+    workloads: List[Dict[str, Any]]
+    volume_claims: List[Dict[str, Any]]
+    services: List[Dict[str, Any]]
+
+
+class ClusterSource(ABC):
+    """Where cluster state is read from"""
+
+    @abstractmethod
+    def snapshot(self) -> ClusterSnapshot:
+        """Current nodes, running workloads, claims and LoadBalancer Services"""
+
+
+class KubernetesAPISource(ClusterSource):
+    """Cluster state read through the Kubernetes API"""
+
+    def __init__(self, kubeconfig: str = "", context: str = ""):
+        """
+        Initialize API source
+
+        Args:
+            kubeconfig: Path of a kubeconfig; in-cluster credentials (then ~/.kube/config) if empty
+            context: kubeconfig context, default the current one
+        """
+        self.kubeconfig = kubeconfig
+        self.context = context
+        self._api_client = None
+
+    @property
+    def api_client(self):
+        if self._api_client is None:
+            from kubernetes import client, config
+
+            if self.kubeconfig:
+                config.load_kube_config(config_file=self.kubeconfig, context=self.context or None)
+            else:
+                try:
+                    config.load_incluster_config()
+                except config.ConfigException:
+                    config.load_kube_config(context=self.context or None)
+            self._api_client = client.ApiClient()
+        return self._api_client
+
+    def snapshot(self) -> ClusterSnapshot:
+        from kubernetes import client
+
+        core = client.CoreV1Api(self.api_client)
+        apps = client.AppsV1Api(self.api_client)
+        batch = client.BatchV1Api(self.api_client)
+
+        workloads = []
+        for kind, items in (
+            ("Deployment", apps.list_deployment_for_all_namespaces().items),
+            ("StatefulSet", apps.list_stateful_set_for_all_namespaces().items),
+            ("DaemonSet", apps.list_daemon_set_for_all_namespaces().items),
+            ("ReplicaSet", apps.list_replica_set_for_all_namespaces().items),
+            ("Job", batch.list_job_for_all_namespaces().items),
+            ("Pod", core.list_pod_for_all_namespaces().items),
+        ):
+            workloads.extend(dict(self._serialize(item), kind=kind) for item in items)
+
+        return ClusterSnapshot(
+            nodes=[self._serialize(n) for n in core.list_node().items],
+            workloads=[w for w in workloads if running(w)],
+            volume_claims=[self._serialize(c) for c in core.list_persistent_volume_claim_for_all_namespaces().items],
+            services=[
+                self._serialize(s) for s in core.list_service_for_all_namespaces().items
+                if s.spec and s.spec.type == "LoadBalancer"
+            ],
+        )
+
+    def _serialize(self, obj) -> Dict[str, Any]:
+        return self.api_client.sanitize_for_serialization(obj)
+
+
+def running(workload: Dict[str, Any]) -> bool:
+    """
+    Whether a workload object runs pods of its own
+
+    ReplicaSets of Deployments and pods of controllers are counted through
+    their owner; finished Jobs and pods cost nothing.
+    """
+    kind = workload.get("kind")
+    metadata = workload.get("metadata") or {}
+    status = workload.get("status") or {}
+    if kind in ("ReplicaSet", "Pod") and metadata.get("ownerReferences"):
+        return False
+    if kind == "Job":
+        return bool(status.get("active"))
+    if kind == "Pod":
+        return status.get("phase") in ("Pending", "Running")
+    return True
+
+
+def _label(labels: Dict[str, str], names) -> str:
+    return next((labels[name] for name in names if labels.get(name)), "")
+
+
+def node_identity(node: Dict[str, Any], default_provider: str, default_region: str) -> Tuple[str, str, str, str]:
+    """(provider, region, instance type, pricing model) of a node"""
+    labels = (node.get("metadata") or {}).get("labels") or {}
+    provider = provider_of((node.get("spec") or {}).get("providerID", "")) or default_provider
+    spot = any(labels.get(name) == value for name, value in SPOT_NODE_LABELS.items())
+    return (
+        provider,
+        _label(labels, REGION_LABELS) or default_region,
+        _label(labels, INSTANCE_TYPE_LABELS),
+        PRICING_SPOT if spot else PRICING_ON_DEMAND,
+    )
+
+
+def detect_cluster_tier(nodes: List[Dict[str, Any]], provider: str) -> str:
+    """Default tier of the provider if a node belongs to a managed node pool, else self-managed"""
+    for node in nodes:
+        labels = (node.get("metadata") or {}).get("labels") or {}
+        if any(name in labels for name in MANAGED_NODE_LABELS):
+            return default_cluster_tier(provider)
+    return TIER_SELF_MANAGED
+
+
+def cluster_rates(nodes: List[Tuple[NodeCost, NodeRates]]) -> Optional[NodeRates]:
+    """Per-vCPU, per-GiB and per-GPU rates averaged over nodes, weighted by their capacity"""
+    vcpus = sum(rates.vcpus for _, rates in nodes)
+    memory = sum(rates.memory_gb for _, rates in nodes)
+    gpus = sum(rates.gpus for _, rates in nodes)
+    if not vcpus or not memory:
+        return None
+    accelerators = {rates.accelerator for _, rates in nodes if rates.gpus}
+    return NodeRates(
+        instance_type="cluster",
+        hourly_price=float(add_amounts(rates.hourly_price for _, rates in nodes)),
+        vcpus=vcpus,
+        memory_gb=memory,
+        gpus=gpus,
+        accelerator=accelerators.pop() if len(accelerators) == 1 else None,
+        cpu_hourly=sum(rates.cpu_hourly * rates.vcpus for _, rates in nodes) / vcpus,
+        memory_hourly=sum(rates.memory_hourly * rates.memory_gb for _, rates in nodes) / memory,
+        gpu_hourly=sum(rates.gpu_hourly * rates.gpus for _, rates in nodes) / gpus if gpus else 0.0,
+    )
+
+
+def _with_capacity(claim: Dict[str, Any]) -> Dict[str, Any]:
+    """A bound claim sized by the capacity it was given rather than the request"""
+    capacity = ((claim.get("status") or {}).get("capacity") or {}).get("storage")
+    if not capacity:
+        return dict(claim, kind="PersistentVolumeClaim")
+    claim = copy.deepcopy(claim)
+    claim["kind"] = "PersistentVolumeClaim"
+    claim.setdefault("spec", {}).setdefault("resources", {}).setdefault("requests", {})["storage"] = capacity
+    return claim
+
+
+class ClusterEstimator:
+    """Estimates the current monthly cost of a running cluster"""
+
+    def __init__(
+        self,
+        estimator: KubernetesEstimator,
+        source: ClusterSource,
+        cluster: str = "default",
+        default_provider: str = "aws",
+        default_region: str = "",
+        load_balancer_hourly: Optional[Dict[str, float]] = None,
+        cluster_tier: Optional[str] = None,
+    ):
+        """
+        Initialize cluster estimator
+
+        Args:
+            estimator: Kubernetes estimator deriving node rates and volume costs
+            source: Where the cluster's state is read from
+            cluster: Cluster name reported with the estimate
+            default_provider: Provider of nodes whose providerID names none
+            default_region: Region of nodes without a region label
+            load_balancer_hourly: Hourly load balancer charge by provider
+            cluster_tier: Control plane tier, detected from node labels if not set
+
+        Raises:
+            ValueError: If the cluster tier is unknown
+        """
+        self.estimator = estimator
+        self.source = source
+        self.cluster = cluster
+        self.default_provider = default_provider
+        self.default_region = default_region
+        self.load_balancer_hourly = load_balancer_hourly or dict(DEFAULT_LOAD_BALANCER_HOURLY)
+        self.cluster_tier = normalize_cluster_tier(cluster_tier) if cluster_tier else None
+
+    def estimate(
+        self, request: ClusterEstimateRequest, on_item: Optional[Callable[[str, Any], None]] = None
+    ) -> ClusterEstimateResult:
+        """
+        Estimate the cluster's current monthly cost
+
+        Args:
+            request: Hours and overrides of the estimate
+            on_item: Called with ("node", NodeCost), ("workload", WorkloadCost), ("volume", VolumeCost),
+                ("load_balancer", LoadBalancerCost) and ("control_plane", ControlPlaneCost) as each is priced
+
+        Raises:
+            ValueError: If an object has invalid quantities
+        """
+        emit = on_item or (lambda kind, item: None)
+        snapshot = self.source.snapshot()
+        unpriced: List[str] = []
+
+        tier = request.cluster_tier or self.cluster_tier
+        if tier == TIER_AUTOPILOT:
+            # Autopilot bills pod requests, not the nodes Google runs them on
+            nodes = []
+            provider, region = self.location([
+                node_identity(node, self.default_provider, self.default_region)[:2] for node in snapshot.nodes
+            ])
+            rates = self.estimator.pod_rates(provider, region)
+        else:
+            nodes = self.price_nodes(snapshot.nodes, request.hours, unpriced)
+            for node, _ in nodes:
+                emit("node", node)
+            rates = cluster_rates(nodes)
+            provider, region = self.location([(node.provider, node.region) for node, _ in nodes])
+        tier = tier or detect_cluster_tier(snapshot.nodes, provider)
+        control_plane = self._control_plane(provider, region, tier, request.hours, unpriced)
+        if control_plane is not None:
+            emit("control_plane", control_plane)
+
+        parsed = parse_documents(snapshot.workloads + [_with_capacity(c) for c in snapshot.volume_claims])
+        daemons = daemon_pods(snapshot.workloads)
+        workloads: List[WorkloadCost] = []
+        if rates is not None:
+            for workload in parsed.workloads:
+                if workload.kind == "DaemonSet":
+                    workload.replicas = daemons.get((workload.namespace, workload.name), 0)
+                try:
+                    workloads.append(
+                        self.estimator.workload_cost(workload, rates, request.hours, request.gpu_sharing)
+                    )
+                    emit("workload", workloads[-1])
+                except ValueError as e:
+                    logger.warning(f"Workload {workload.namespace}/{workload.name} not priced: {e}")
+                    unpriced.append(f"{workload.kind}/{workload.namespace}/{workload.name}")
+        elif parsed.workloads:
+            unpriced.append("workloads: no priced nodes")
+
+        volumes = []
+        # StatefulSet claim templates exist as claims in a running cluster
+        for claim in (c for c in parsed.volume_claims if c.owner is None):
+            try:
+                volumes.append(self.estimator.volume_cost(claim, provider, region, request.storage_class_map))
+                emit("volume", volumes[-1])
+            except (ValueError, PriceNotFoundError, ProviderNotFoundError) as e:
+                logger.warning(f"Volume claim {claim.namespace}/{claim.name} not priced: {e}")
+                unpriced.append(f"PersistentVolumeClaim/{claim.namespace}/{claim.name}")
+
+        hourly = self.load_balancer_hourly.get(provider, 0.0)
+        load_balancers = [
+            LoadBalancerCost(
+                name=metadata.get("name", "unnamed"),
+                namespace=metadata.get("namespace", "default"),
+                hourly_price=hourly,
+                monthly_cost=round_money(to_decimal(hourly) * to_decimal(request.hours)),
+                labels=metadata.get("labels") or {},
+                annotations=annotations_of(metadata),
+            )
+            for metadata in ((s.get("metadata") or {}) for s in snapshot.services)
+        ]
+        for load_balancer in load_balancers:
+            emit("load_balancer", load_balancer)
+
+        node_cost = add_amounts(node.monthly_cost for node, _ in nodes)
+        allocated = add_amounts(w.monthly_cost for w in workloads)
+        storage = add_amounts(v.monthly_cost for v in volumes)
+        balancers = add_amounts(lb.monthly_cost for lb in load_balancers)
+        fee = to_decimal(control_plane.monthly_cost) if control_plane else Decimal(0)
+        billed = allocated if tier == TIER_AUTOPILOT else node_cost
+        monthly = billed + storage + balancers + fee
+
+        logger.info(
+            f"Cluster {self.cluster} estimate: {len(nodes)} nodes, {len(workloads)} workloads, "
+            f"{len(volumes)} volumes, {len(load_balancers)} load balancers, ${monthly:.2f}/month"
+        )
+
+        return ClusterEstimateResult(
+            cluster=self.cluster,
+            nodes=[node for node, _ in nodes],
+            cluster_rates=rates,
+            workloads=workloads,
+            volumes=volumes,
+            load_balancers=load_balancers,
+            namespaces=_namespaces(workloads, volumes, load_balancers),
+            control_plane=control_plane,
+            node_monthly_cost=round_money(node_cost),
+            allocated_monthly_cost=round_money(allocated),
+            idle_monthly_cost=round_money(max(Decimal(0), billed - allocated)),
+            storage_monthly_cost=round_money(storage),
+            load_balancer_monthly_cost=round_money(balancers),
+            control_plane_monthly_cost=round_money(fee),
+            monthly_cost=round_money(monthly),
+            yearly_cost=round_money(monthly * to_decimal(months_per_year())),
+            unpriced=unpriced,
+            skipped=parsed.skipped,
+        )
+
+    def price_nodes(
+        self, nodes: List[Dict[str, Any]], hours: float, unpriced: List[str]
+    ) -> List[Tuple[NodeCost, NodeRates]]:
+        """Cost and rates of each node that can be priced, the others added to unpriced"""
+        priced = []
+        for node in nodes:
+            name = (node.get("metadata") or {}).get("name", "unnamed")
+            provider, region, instance_type, pricing_model = node_identity(
+                node, self.default_provider, self.default_region
+            )
+            try:
+                if not instance_type:
+                    raise ValueError("no instance type label")
+                rates = self.estimator.node_rates(provider, region, instance_type, pricing_model)
+            except (ValueError, PriceNotFoundError, ProviderNotFoundError) as e:
+                logger.warning(f"Node {name} ({provider}/{region}/{instance_type}) not priced: {e}")
+                unpriced.append(f"Node/{name}")
+                continue
+
+            priced.append((NodeCost(
+                name=name,
+                provider=provider,
+                region=region,
+                instance_type=instance_type,
+                pricing_model=pricing_model,
+                vcpus=rates.vcpus,
+                memory_gb=rates.memory_gb,
+                hourly_price=rates.hourly_price,
+                monthly_cost=round_money(to_decimal(rates.hourly_price) * to_decimal(hours)),
+            ), rates))
+        return priced
+
+    def _control_plane(
+        self, provider: str, region: str, tier: str, hours: float, unpriced: List[str]
+    ) -> Optional[ControlPlaneCost]:
+        try:
+            return self.estimator.control_plane_cost(provider, region, tier, hours)
+        except (PriceNotFoundError, ProviderNotFoundError) as e:
+            logger.warning(f"Control plane of cluster {self.cluster} ({provider}/{region}/{tier}) not priced: {e}")
+            unpriced.append(f"ControlPlane/{tier}")
+            return None
+
+    def location(self, locations: List[Tuple[str, str]]) -> Tuple[str, str]:
+        """Provider and region most nodes run in, where volumes, load balancers and the control plane are priced"""
+        if not locations:
+            return self.default_provider, self.default_region
+        return Counter(locations).most_common(1)[0][0]
+
+
+def daemon_pods(workloads: List[Dict[str, Any]]) -> Dict[Tuple[str, str], int]:
+    """Pods each DaemonSet is scheduled to run"""
+    return {
+        ((w.get("metadata") or {}).get("namespace", "default"), (w.get("metadata") or {}).get("name", "unnamed")):
+            int((w.get("status") or {}).get("desiredNumberScheduled") or 0)
+        for w in workloads if w.get("kind") == "DaemonSet"
+    }
+
+
+def _namespaces(
+    workloads: List[WorkloadCost], volumes: List[VolumeCost], load_balancers: List[LoadBalancerCost]
+) -> List[NamespaceCost]:
+    totals: Dict[str, Dict[str, Decimal]] = {}
+
+    def total(namespace: str) -> Dict[str, Decimal]:
+        return totals.setdefault(
+            namespace, {key: Decimal(0) for key in ("workloads", "compute", "storage", "load_balancer")}
+        )
+
+    for workload in workloads:
+        total(workload.namespace)["workloads"] += 1
+        total(workload.namespace)["compute"] += to_decimal(workload.monthly_cost)
+    for volume in volumes:
+        total(volume.namespace)["storage"] += to_decimal(volume.monthly_cost)
+    for balancer in load_balancers:
+        total(balancer.namespace)["load_balancer"] += to_decimal(balancer.monthly_cost)
+
+    return [
+        NamespaceCost(
+            namespace=namespace,
+            workloads=int(t["workloads"]),
+            compute_monthly_cost=round_money(t["compute"]),
+            storage_monthly_cost=round_money(t["storage"]),
+            load_balancer_monthly_cost=round_money(t["load_balancer"]),
+            monthly_cost=round_money(t["compute"] + t["storage"] + t["load_balancer"]),
+        )
+        for namespace, t in sorted(totals.items())
+    ]
@@ -0,0 +1,426 @@
+"""
+Kubernetes cost estimator
+
+Prices workloads by their resource requests using per-vCPU and per-GiB
+rates derived from a reference node instance, and persistent volumes by
+the cloud volume type backing their storage class. Estimates of a managed
+cluster tier include its control plane fee; on GKE Autopilot workloads
+are priced at the per-pod request rates instead of node rates.
+
+GPU workloads are priced per GPU-hour of a GPU node type, a GPU shared
+by several pods (time-slicing, MPS) split between them.
+
+Given node pools, the pods are bin-packed onto them instead and the
+compute cost is that of the nodes the cluster autoscaler would run, the
+capacity no pod requests reported as idle.
+"""
+
+import logging
+from decimal import Decimal
+from typing import Dict, List, Optional, Tuple
+
+from ..money import add_amounts, round_money, sum_money, to_decimal
+from ..pricing import (
+    ACCELERATOR_TYPES,
+    PriceQuery,
+    ProviderRegistry,
+    get_instance_shape,
+    SERVICE_COMPUTE,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_KUBERNETES,
+    SERVICE_KUBERNETES_PODS,
+    PRICING_ON_DEMAND,
+    POD_SKU_MEMORY,
+    POD_SKU_VCPU,
+    TIER_AUTOPILOT,
+    TIER_SELF_MANAGED,
+)
+from ..units import months_per_year
+from .autoscaler import AutoscalerSimulator, NodeGroup, node_labels
+from .models import (
+    ControlPlaneCost,
+    KubernetesEstimateRequest,
+    KubernetesEstimateResult,
+    NodePoolProjection,
+    NodeRates,
+    ParsedManifests,
+    VolumeClaim,
+    VolumeCost,
+    WorkloadCost,
+    WorkloadResources,
+)
+from .parser import parse_manifests
+from .storage import volume_type_for, volume_sku
+
+logger = logging.getLogger(__name__)
+
+# Relative cost of one vCPU expressed in GiB of memory, used to split a
+# node's price between CPU and memory (0.031611 $/vCPU-h vs 0.004237 $/GiB-h)
+DEFAULT_CPU_TO_MEMORY_COST_RATIO = 7.46
+# GiB-hour price GPUs are weighed against, by the reference price of their type
+MEMORY_REFERENCE_HOURLY = 0.004237
+
+
+class KubernetesEstimator:
+    """Estimates monthly cost of Kubernetes manifests"""
+
+    def __init__(self, registry: ProviderRegistry, cpu_to_memory_cost_ratio: float = DEFAULT_CPU_TO_MEMORY_COST_RATIO):
+        """
+        Initialize Kubernetes estimator
+
+        Args:
+            registry: Registry of enabled pricing providers
+            cpu_to_memory_cost_ratio: Cost of one vCPU relative to one GiB of memory
+        """
+        self.registry = registry
+        self.cpu_to_memory_cost_ratio = cpu_to_memory_cost_ratio
+
+    def estimate(self, request: KubernetesEstimateRequest) -> KubernetesEstimateResult:
+        """
+        Estimate the monthly cost of the request's manifests
+
+        Raises:
+            ValueError: If manifests are invalid or a storage class is unmapped
+            PriceNotFoundError: If the node type or a volume type cannot be priced
+        """
+        parsed = parse_manifests(request.manifests)
+        return self.estimate_parsed(parsed, request)
+
+    def estimate_parsed(self, parsed: ParsedManifests, request: KubernetesEstimateRequest) -> KubernetesEstimateResult:
+        """Estimate already parsed manifests"""
+        gpu_rates = None
+        pools: List[NodePoolProjection] = []
+        unschedulable: List[str] = []
+        if request.node_pools:
+            rates, workloads, pools, unschedulable = self.simulate_node_pools(parsed.workloads, request)
+        else:
+            if request.cluster_tier == TIER_AUTOPILOT:
+                rates = self.pod_rates(request.provider, request.region, request.pricing_model)
+            else:
+                rates = self.node_rates(
+                    request.provider, request.region, request.node_instance_type, request.pricing_model
+                )
+            if request.gpu_node_instance_type:
+                gpu_rates = self.node_rates(
+                    request.provider, request.region, request.gpu_node_instance_type, request.pricing_model
+                )
+            workloads = [
+                self.workload_cost(
+                    w, gpu_rates if w.gpus and gpu_rates else rates, request.hours, request.gpu_sharing
+                )
+                for w in parsed.workloads
+            ]
+        volumes = [
+            self.volume_cost(claim, request.provider, request.region, request.storage_class_map)
+            for claim in parsed.volume_claims
+        ]
+
+        control_plane = None
+        if request.cluster_tier is not None:
+            control_plane = self.control_plane_cost(
+                request.provider, request.region, request.cluster_tier, request.hours
+            )
+
+        requested = add_amounts(w.monthly_cost for w in workloads)
+        compute = add_amounts(p.monthly_cost for p in pools) if pools else requested
+        gpu = add_amounts(w.gpu_monthly_cost for w in workloads)
+        storage = add_amounts(v.monthly_cost for v in volumes)
+        fee = to_decimal(control_plane.monthly_cost) if control_plane else Decimal(0)
+        monthly = compute + storage + fee
+
+        logger.info(
+            f"Kubernetes estimate: {len(workloads)} workloads, {len(volumes)} volumes, "
+            f"${monthly:.2f}/month"
+        )
+
+        return KubernetesEstimateResult(
+            node_rates=rates,
+            gpu_node_rates=gpu_rates,
+            workloads=workloads,
+            volumes=volumes,
+            control_plane=control_plane,
+            node_pools=pools,
+            compute_monthly_cost=round_money(compute),
+            gpu_monthly_cost=round_money(gpu),
+            idle_monthly_cost=round_money(max(compute - requested, Decimal(0))),
+            storage_monthly_cost=round_money(storage),
+            control_plane_monthly_cost=round_money(fee),
+            monthly_cost=round_money(monthly),
+            yearly_cost=round_money(monthly * to_decimal(months_per_year())),
+            skipped=parsed.skipped,
+            unschedulable=unschedulable,
+        )
+
+    def simulate_node_pools(
+        self, workloads: List[WorkloadResources], request: KubernetesEstimateRequest
+    ) -> Tuple[NodeRates, List[WorkloadCost], List[NodePoolProjection], List[str]]:
+        """
+        Bin-pack workloads onto the request's node pools
+
+        Each workload is priced at the rates of the pools its pods run on,
+        DaemonSets once per node they run on.
+
+        Returns:
+            (rates of the first pool, workload costs, nodes of each pool, unschedulable workloads)
+
+        Raises:
+            ValueError: If a pool's instance type has no known shape or there are too many pods
+            PriceNotFoundError: If a pool's instance type cannot be priced
+        """
+        groups: List[NodeGroup] = []
+        pool_rates: Dict[str, NodeRates] = {}
+        for pool in request.node_pools:
+            name = pool.name or pool.instance_type
+            rates = self.node_rates(
+                request.provider, request.region, pool.instance_type, pool.pricing_model or request.pricing_model
+            )
+            arch = get_instance_shape(request.provider, pool.instance_type).arch
+            labels = {**node_labels(pool.instance_type, arch, request.region), **pool.labels}
+            pool_rates[name] = rates
+            groups.append(NodeGroup(
+                name=name,
+                labels=labels,
+                taints=[taint.dict() for taint in pool.taints],
+                cpu_cores=rates.vcpus - pool.reserved_cpu_cores,
+                memory_gb=rates.memory_gb - pool.reserved_memory_gb,
+                gpus=rates.gpus,
+                max_pods=pool.max_pods,
+                min_nodes=pool.min_nodes,
+                max_nodes=pool.max_nodes,
+                hourly_price=rates.hourly_price,
+            ))
+
+        simulation = AutoscalerSimulator(groups, request.gpu_sharing).simulate(workloads)
+
+        costs = []
+        for index, workload in enumerate(workloads):
+            parts = [
+                self.workload_cost(workload.copy(update={"replicas": count}), pool_rates[name], request.hours,
+                                   request.gpu_sharing)
+                for (placed, name), count in simulation.placements.items() if placed == index
+            ]
+            costs.append(_merge_costs(workload, parts))
+
+        projections = []
+        for group in groups:
+            nodes = simulation.nodes[group.name]
+            placed = [(workloads[i], count) for (i, name), count in simulation.placements.items() if name == group.name]
+            rates = pool_rates[group.name]
+            projections.append(NodePoolProjection(
+                name=group.name,
+                instance_type=rates.instance_type,
+                pricing_model=rates.pricing_model,
+                nodes=nodes,
+                pods=sum(count for _, count in placed),
+                hourly_price=rates.hourly_price,
+                monthly_cost=round_money(nodes * to_decimal(rates.hourly_price) * to_decimal(request.hours)),
+                cpu_utilization=_utilization(sum(w.cpu_cores * count for w, count in placed), nodes * rates.vcpus),
+                memory_utilization=_utilization(
+                    sum(w.memory_gb * count for w, count in placed), nodes * rates.memory_gb
+                ),
+            ))
+
+        unschedulable = [
+            f"{workloads[i].kind}/{workloads[i].name}" for i in sorted(simulation.unschedulable)
+        ]
+        logger.info(
+            "Autoscaler simulation: "
+            + ", ".join(f"{p.nodes} x {p.name}" for p in projections)
+            + (f", {len(unschedulable)} workloads unschedulable" if unschedulable else "")
+        )
+        return pool_rates[groups[0].name], costs, projections, unschedulable
+
+    def node_rates(
+        self,
+        provider: str,
+        region: str,
+        instance_type: str,
+        pricing_model: str = PRICING_ON_DEMAND,
+    ) -> NodeRates:
+        """
+        Split a node's hourly price into per-vCPU, per-GiB and per-GPU rates
+
+        The GPUs of a node are weighed by the reference price of their type.
+
+        Raises:
+            ValueError: If the node instance type has no known shape
+        """
+        try:
+            shape = get_instance_shape(provider, instance_type)
+        except KeyError as e:
+            raise ValueError(str(e)) from None
+
+        price = self.registry.get_price(PriceQuery(
+            provider=provider, region=region, sku=instance_type, service=SERVICE_COMPUTE,
+            pricing_model=pricing_model,
+        ))
+
+        cpu_weight = shape.vcpus * self.cpu_to_memory_cost_ratio
+        gpu_weight = 0.0
+        if shape.gpus:
+            accelerator = ACCELERATOR_TYPES.get(shape.accelerator)
+            if accelerator is None:
+                raise ValueError(f"No accelerator type known for {instance_type}")
+            gpu_weight = shape.gpus * accelerator.reference_hourly / MEMORY_REFERENCE_HOURLY
+        total = cpu_weight + shape.memory_gb + gpu_weight
+
+        return NodeRates(
+            instance_type=instance_type,
+            pricing_model=pricing_model,
+            hourly_price=price.price,
+            vcpus=shape.vcpus,
+            memory_gb=shape.memory_gb,
+            gpus=shape.gpus,
+            accelerator=shape.accelerator or None,
+            cpu_hourly=price.price * cpu_weight / total / shape.vcpus,
+            memory_hourly=price.price * shape.memory_gb / total / shape.memory_gb,
+            gpu_hourly=price.price * gpu_weight / total / shape.gpus if shape.gpus else 0.0,
+        )
+
+    def pod_rates(self, provider: str, region: str, pricing_model: str = PRICING_ON_DEMAND) -> NodeRates:
+        """
+        Per-vCPU and per-GiB rates of pod requests on a cluster billed per pod (GKE Autopilot)
+
+        The rates are reported as an "autopilot" node of one vCPU and one GiB.
+
+        Raises:
+            PriceNotFoundError: If the provider or region bills no pods
+        """
+        cpu, memory = (
+            self.registry.get_price(PriceQuery(
+                provider=provider, region=region, sku=sku, service=SERVICE_KUBERNETES_PODS,
+                pricing_model=pricing_model,
+            )).price
+            for sku in (POD_SKU_VCPU, POD_SKU_MEMORY)
+        )
+        return NodeRates(
+            instance_type=TIER_AUTOPILOT,
+            pricing_model=pricing_model,
+            hourly_price=cpu + memory,
+            vcpus=1,
+            memory_gb=1,
+            cpu_hourly=cpu,
+            memory_hourly=memory,
+        )
+
+    def control_plane_cost(self, provider: str, region: str, tier: str, hours: float) -> Optional[ControlPlaneCost]:
+        """
+        Monthly control plane fee of a cluster tier, None for self-managed clusters
+
+        Raises:
+            PriceNotFoundError: If the provider does not offer the tier
+        """
+        if tier == TIER_SELF_MANAGED:
+            return None
+        price = self.registry.get_price(PriceQuery(
+            provider=provider, region=region, sku=tier, service=SERVICE_KUBERNETES,
+        ))
+        return ControlPlaneCost(
+            provider=provider,
+            tier=tier,
+            hourly_price=price.price,
+            monthly_cost=round_money(to_decimal(price.price) * to_decimal(hours)),
+        )
+
+    def workload_cost(
+        self, workload: WorkloadResources, rates: NodeRates, hours: float, gpu_sharing: int = 1
+    ) -> WorkloadCost:
+        """
+        Monthly cost of a workload's requests at per-vCPU, per-GiB and per-GPU rates
+
+        Args:
+            gpu_sharing: Pods sharing one GPU, each paying its share
+
+        Raises:
+            ValueError: If the workload requests GPUs and the rates have none
+        """
+        if workload.gpus and not rates.gpus:
+            raise ValueError(
+                f"{workload.kind}/{workload.name} requests GPUs but {rates.instance_type} nodes have none"
+            )
+        replica_hours = workload.replicas * to_decimal(hours)
+        cpu_cost = to_decimal(workload.cpu_cores) * to_decimal(rates.cpu_hourly) * replica_hours
+        memory_cost = to_decimal(workload.memory_gb) * to_decimal(rates.memory_hourly) * replica_hours
+        gpu_cost = to_decimal(workload.gpus) / gpu_sharing * to_decimal(rates.gpu_hourly) * replica_hours
+
+        return WorkloadCost(
+            kind=workload.kind,
+            name=workload.name,
+            namespace=workload.namespace,
+            replicas=workload.replicas,
+            cpu_cores=workload.cpu_cores,
+            memory_gb=round(workload.memory_gb, 4),
+            gpus=workload.gpus,
+            cpu_monthly_cost=round_money(cpu_cost),
+            memory_monthly_cost=round_money(memory_cost),
+            gpu_monthly_cost=round_money(gpu_cost),
+            monthly_cost=round_money(cpu_cost + memory_cost + gpu_cost),
+            labels=workload.labels,
+            annotations=workload.annotations,
+        )
+
+    def volume_cost(
+        self,
+        claim: VolumeClaim,
+        provider: str,
+        region: str,
+        storage_class_map: Optional[Dict[str, str]],
+    ) -> VolumeCost:
+        """
+        Monthly cost of a volume claim on the volume type of its storage class
+
+        Raises:
+            ValueError: If the storage class is not mapped
+            PriceNotFoundError: If the volume type cannot be priced
+        """
+        try:
+            volume_type = volume_type_for(provider, claim.storage_class, storage_class_map)
+        except KeyError as e:
+            raise ValueError(str(e)) from None
+        sku = volume_sku(provider, volume_type, claim.size_gb)
+
+        price = self.registry.get_price(PriceQuery(
+            provider=provider, region=region, sku=sku, service=SERVICE_BLOCK_STORAGE,
+        ))
+
+        per_volume = to_decimal(price.price)
+        if price.unit == "GB-month":
+            per_volume *= to_decimal(claim.size_gb)
+        return VolumeCost(
+            name=claim.name,
+            namespace=claim.namespace,
+            storage_class=claim.storage_class,
+            volume_type=sku,
+            size_gb=round(claim.size_gb, 4),
+            count=claim.count,
+            unit_price=price.price,
+            unit=price.unit,
+            monthly_cost=round_money(per_volume * claim.count),
+            labels=claim.labels,
+            annotations=claim.annotations,
+        )
+
+
+def _merge_costs(workload: WorkloadResources, parts: List[WorkloadCost]) -> WorkloadCost:
+    """One cost of a workload whose pods run on several pools"""
+    if len(parts) == 1:
+        return parts[0]
+    return WorkloadCost(
+        kind=workload.kind,
+        name=workload.name,
+        namespace=workload.namespace,
+        replicas=sum(p.replicas for p in parts),
+        cpu_cores=workload.cpu_cores,
+        memory_gb=round(workload.memory_gb, 4),
+        gpus=workload.gpus,
+        cpu_monthly_cost=sum_money(p.cpu_monthly_cost for p in parts),
+        memory_monthly_cost=sum_money(p.memory_monthly_cost for p in parts),
+        gpu_monthly_cost=sum_money(p.gpu_monthly_cost for p in parts),
+        monthly_cost=sum_money(p.monthly_cost for p in parts),
+        labels=workload.labels,
+        annotations=workload.annotations,
+    )
+
+
+def _utilization(requested: float, capacity: float) -> float:
+    return round(requested / capacity, 4) if capacity else 0.0
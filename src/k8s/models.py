@@ -0,0 +1,635 @@
+"""
+Data models for Kubernetes cost estimation
+"""
+
+import re
+from typing import Any, Dict, List, Optional, Union
+from pydantic import BaseModel, Field, root_validator, validator
+
+from ..pricing import PRICING_ON_DEMAND, TIER_AUTOPILOT, normalize_cluster_tier, normalize_pricing_model
+from ..units import TimeBasis, current_time_basis, hours_per_month
+
+TAINT_EFFECTS = ("NoSchedule", "PreferNoSchedule", "NoExecute")
+
+
+class WorkloadResources(BaseModel):
+    """Pod-template resources of a workload, per replica"""
+
+    kind: str
+    name: str
+    namespace: str = "default"
+    replicas: int = Field(default=1, ge=0)
+    cpu_cores: float = Field(default=0.0, ge=0, description="Effective CPU request per replica")
+    memory_gb: float = Field(default=0.0, ge=0, description="Effective memory request per replica (GiB)")
+    gpus: float = Field(default=0.0, ge=0, description="GPUs per replica, MIG slices as a share of a GPU")
+    labels: Dict[str, str] = Field(default_factory=dict)
+    annotations: Dict[str, str] = Field(default_factory=dict)
+    node_selector: Dict[str, str] = Field(default_factory=dict, description="Pod template's nodeSelector")
+    node_affinity: List[Dict[str, Any]] = Field(
+        default_factory=list,
+        description="nodeSelectorTerms required by the pod template's node affinity, any of which must match",
+    )
+    tolerations: List[Dict[str, Any]] = Field(default_factory=list)
+
+
+class VolumeClaim(BaseModel):
+    """Persistent storage requested by a PVC or StatefulSet volumeClaimTemplate"""
+
+    name: str
+    namespace: str = "default"
+    storage_class: Optional[str] = None
+    size_gb: float = Field(..., ge=0)
+    count: int = Field(default=1, ge=0, description="Number of volumes (StatefulSet replicas)")
+    owner: Optional[str] = Field(None, description="Owning workload for template claims")
+    labels: Dict[str, str] = Field(default_factory=dict)
+    annotations: Dict[str, str] = Field(default_factory=dict)
+
+
+class ParsedManifests(BaseModel):
+    """Resources extracted from a set of manifests"""
+
+    workloads: List[WorkloadResources] = Field(default_factory=list)
+    volume_claims: List[VolumeClaim] = Field(default_factory=list)
+    skipped: List[str] = Field(default_factory=list, description="Documents that were not priced (kind/name)")
+
+
+class NodeTaint(BaseModel):
+    """Taint of a node pool's nodes"""
+
+    key: str = Field(..., min_length=1)
+    value: Optional[str] = None
+    effect: str = Field(default="NoSchedule", description="NoSchedule, PreferNoSchedule or NoExecute")
+
+    @validator("effect")
+    def validate_effect(cls, v):
+        if v not in TAINT_EFFECTS:
+            raise ValueError(f"Taint effect must be one of: {', '.join(TAINT_EFFECTS)}")
+        return v
+
+
+class NodePool(BaseModel):
+    """Node group the autoscaler simulation may add nodes to"""
+
+    name: Optional[str] = Field(None, description="Pool name, default the instance type")
+    instance_type: str = Field(..., min_length=1)
+    pricing_model: Optional[str] = Field(None, description="Node pricing, default the request's")
+    labels: Dict[str, str] = Field(
+        default_factory=dict,
+        description="Node labels besides the well-known instance type, architecture, OS and region labels",
+    )
+    taints: List[NodeTaint] = Field(default_factory=list)
+    max_pods: int = Field(default=110, ge=1, description="Pods per node, incl. DaemonSet pods (kubelet maxPods)")
+    min_nodes: int = Field(default=0, ge=0)
+    max_nodes: Optional[int] = Field(None, ge=1)
+    reserved_cpu_cores: float = Field(default=0.0, ge=0, description="CPU per node not allocatable to pods")
+    reserved_memory_gb: float = Field(default=0.0, ge=0, description="Memory per node not allocatable to pods (GiB)")
+
+    @validator("pricing_model")
+    def validate_pricing_model(cls, v):
+        return normalize_pricing_model(v) if v is not None else None
+
+    @root_validator(skip_on_failure=True)
+    def validate_node_counts(cls, values):
+        if values.get("max_nodes") is not None and values["max_nodes"] < values["min_nodes"]:
+            raise ValueError("max_nodes must not be less than min_nodes")
+        return values
+
+
+class KubernetesEstimateRequest(BaseModel):
+    """Request model for the Kubernetes estimate endpoint"""
+
+    manifests: Union[str, List[str]] = Field(..., description="YAML manifest text, multi-document allowed")
+    provider: str = Field(default="aws", min_length=1)
+    region: str = Field(..., min_length=1)
+    node_instance_type: Optional[str] = Field(
+        None, min_length=1, description="Node type used to derive vCPU/GB rates, not used on Autopilot"
+    )
+    storage_class_map: Dict[str, str] = Field(
+        default_factory=dict,
+        description="Overrides of storage class -> cloud volume type"
+    )
+    hours: float = Field(default_factory=hours_per_month, gt=0, le=744, description="Running hours per month")
+    pricing_model: str = Field(default=PRICING_ON_DEMAND, description="Node pricing: on_demand or spot")
+    gpu_node_instance_type: Optional[str] = Field(
+        None, min_length=1, description="Node type GPU workloads run on, default node_instance_type"
+    )
+    gpu_sharing: int = Field(
+        default=1, ge=1, description="Pods sharing one GPU through time-slicing or MPS; each shares its cost"
+    )
+    cluster_tier: Optional[str] = Field(
+        None,
+        description="Tier of the managed cluster whose control plane fee is included, e.g. standard or "
+                    "autopilot (pods priced per request); none prices the workloads only",
+    )
+    node_pools: List[NodePool] = Field(
+        default_factory=list,
+        description="Node pools pods are bin-packed onto to project the nodes the cluster autoscaler runs; "
+                    "pods are priced per request when empty",
+    )
+    project: Optional[str] = Field(None, description="Project the estimate is recorded under")
+    labels: Dict[str, str] = Field(default_factory=dict, description="Metadata kept with the estimate, e.g. CI run URL")
+
+    @validator("provider")
+    def normalize_provider(cls, v):
+        return v.strip().lower()
+
+    @validator("pricing_model")
+    def validate_pricing_model(cls, v):
+        return normalize_pricing_model(v)
+
+    @validator("cluster_tier")
+    def validate_cluster_tier(cls, v):
+        return normalize_cluster_tier(v) if v is not None else None
+
+    @root_validator(skip_on_failure=True)
+    def require_node_instance_type(cls, values):
+        if values.get("cluster_tier") == TIER_AUTOPILOT:
+            if values.get("provider") != "gcp":
+                raise ValueError("The autopilot cluster tier is only offered by gcp")
+            if values.get("node_pools"):
+                raise ValueError("Autopilot clusters have no node pools")
+        elif not values.get("node_instance_type") and not values.get("node_pools"):
+            raise ValueError("node_instance_type or node_pools is required unless cluster_tier is autopilot")
+        names = [pool.name or pool.instance_type for pool in values.get("node_pools") or []]
+        if len(set(names)) != len(names):
+            raise ValueError("Node pool names must be unique")
+        return values
+
+
+class NodeRates(BaseModel):
+    """Per-resource rates derived from the reference node price"""
+
+    instance_type: str
+    pricing_model: str = PRICING_ON_DEMAND
+    hourly_price: float
+    vcpus: float
+    memory_gb: float
+    gpus: float = 0
+    accelerator: Optional[str] = None
+    cpu_hourly: float = Field(..., description="Price per vCPU-hour")
+    memory_hourly: float = Field(..., description="Price per GiB-hour")
+    gpu_hourly: float = Field(default=0.0, description="Price per GPU-hour")
+
+
+class WorkloadCost(BaseModel):
+    """Monthly cost of one workload"""
+
+    kind: str
+    name: str
+    namespace: str
+    replicas: int
+    cpu_cores: float
+    memory_gb: float
+    gpus: float = 0.0
+    cpu_monthly_cost: float
+    memory_monthly_cost: float
+    gpu_monthly_cost: float = 0.0
+    monthly_cost: float
+    labels: Dict[str, str] = Field(default_factory=dict)
+    annotations: Dict[str, str] = Field(default_factory=dict)
+
+
+class VolumeCost(BaseModel):
+    """Monthly cost of one persistent volume claim"""
+
+    name: str
+    namespace: str
+    storage_class: Optional[str]
+    volume_type: str
+    size_gb: float
+    count: int
+    unit_price: float
+    unit: str
+    monthly_cost: float
+    labels: Dict[str, str] = Field(default_factory=dict)
+    annotations: Dict[str, str] = Field(default_factory=dict)
+
+
+class ControlPlaneCost(BaseModel):
+    """Monthly fee of a managed cluster's control plane"""
+
+    provider: str
+    tier: str
+    hourly_price: float
+    monthly_cost: float
+
+
+class NodePoolProjection(BaseModel):
+    """Nodes of a pool the autoscaler simulation runs"""
+
+    name: str
+    instance_type: str
+    pricing_model: str = PRICING_ON_DEMAND
+    nodes: int
+    pods: int = Field(..., description="Pods scheduled on the pool's nodes, DaemonSet pods included")
+    hourly_price: float = Field(..., description="Price of one node")
+    monthly_cost: float
+    cpu_utilization: float = Field(..., description="CPU requested as a fraction of the nodes' vCPUs")
+    memory_utilization: float = Field(..., description="Memory requested as a fraction of the nodes' memory")
+
+
+class KubernetesEstimateResult(BaseModel):
+    """Aggregated Kubernetes estimate"""
+
+    node_rates: NodeRates = Field(..., description="Per-vCPU and per-GiB rates, Autopilot pod rates on Autopilot")
+    gpu_node_rates: Optional[NodeRates] = Field(None, description="Rates of the node type GPU workloads run on")
+    workloads: List[WorkloadCost]
+    volumes: List[VolumeCost]
+    control_plane: Optional[ControlPlaneCost] = None
+    node_pools: List[NodePoolProjection] = Field(
+        default_factory=list, description="Nodes the autoscaler simulation runs, when node pools are given"
+    )
+    compute_monthly_cost: float = Field(..., description="Workload requests, or the simulated nodes")
+    gpu_monthly_cost: float = Field(default=0.0, description="GPU share of the compute cost")
+    idle_monthly_cost: float = Field(default=0.0, description="Simulated node cost not requested by any pod")
+    storage_monthly_cost: float
+    control_plane_monthly_cost: float = 0.0
+    monthly_cost: float
+    yearly_cost: float
+    currency: str = "USD"
+    time_basis: TimeBasis = Field(default_factory=current_time_basis, description="Hours per month of the costs")
+    skipped: List[str] = Field(default_factory=list)
+    unschedulable: List[str] = Field(
+        default_factory=list, description="Workloads (kind/name) with pods no node pool can run, not priced"
+    )
+
+
+class UsageEstimateRequest(BaseModel):
+    """Request model for the usage-based Kubernetes estimate"""
+
+    namespace: Optional[str] = Field(None, description="Only workloads of this namespace")
+    window: str = Field(default="7d", description="Usage window, e.g. 24h or 7d")
+    hours: float = Field(default_factory=hours_per_month, gt=0, le=744, description="Running hours per month")
+    pricing_model: str = Field(default=PRICING_ON_DEMAND, description="Node pricing: on_demand or spot")
+    provider: Optional[str] = Field(None, description="Provider of nodes whose provider ID does not name one")
+    region: Optional[str] = Field(None, description="Region of nodes without a region label")
+
+    @validator("window")
+    def validate_window(cls, v):
+        if not re.match(r"^[1-9][0-9]*[mhdw]$", v):
+            raise ValueError("Window must be a Prometheus duration in minutes, hours, days or weeks, e.g. 7d")
+        return v
+
+    @validator("provider")
+    def normalize_provider(cls, v):
+        return v.strip().lower() if v else v
+
+    @validator("pricing_model")
+    def validate_pricing_model(cls, v):
+        return normalize_pricing_model(v)
+
+
+class WorkloadUsageCost(BaseModel):
+    """Monthly cost of one workload at its measured usage and at its requests"""
+
+    kind: str
+    name: str
+    namespace: str
+    pods: int = Field(..., description="Pods of the workload seen in the window")
+    cpu_usage_cores: float = Field(..., description="Average CPU used over the window")
+    cpu_request_cores: float = Field(..., description="Average CPU requested over the window")
+    memory_usage_gb: float = Field(..., description="Average working set over the window (GiB)")
+    memory_request_gb: float = Field(..., description="Average memory requested over the window (GiB)")
+    cpu_utilization: Optional[float] = Field(None, description="CPU used as a fraction of requested")
+    memory_utilization: Optional[float] = Field(None, description="Memory used as a fraction of requested")
+    usage_monthly_cost: float = Field(..., description="Cost of the resources actually used")
+    request_monthly_cost: float = Field(..., description="Cost of the resources requested")
+    waste_monthly_cost: float = Field(..., description="Cost of requested resources left unused")
+
+
+class NamespaceUsageCost(BaseModel):
+    """Usage and request costs of a namespace's workloads"""
+
+    namespace: str
+    workloads: int
+    cpu_usage_cores: float
+    cpu_request_cores: float
+    memory_usage_gb: float
+    memory_request_gb: float
+    usage_monthly_cost: float
+    request_monthly_cost: float
+    waste_monthly_cost: float
+
+
+class UsageEstimateResult(BaseModel):
+    """Cluster cost from measured usage, with the waste of over-provisioned requests"""
+
+    window: str
+    node_rates: List[NodeRates] = Field(default_factory=list, description="Rates of each node type priced")
+    workloads: List[WorkloadUsageCost]
+    namespaces: List[NamespaceUsageCost]
+    usage_monthly_cost: float
+    request_monthly_cost: float
+    waste_monthly_cost: float
+    currency: str = "USD"
+    time_basis: TimeBasis = Field(default_factory=current_time_basis, description="Hours per month of the costs")
+    unpriced: List[str] = Field(
+        default_factory=list, description="Pods (namespace/pod) on nodes whose type could not be priced"
+    )
+
+
+class ClusterEstimateRequest(BaseModel):
+    """Request model for the live cluster estimate"""
+
+    hours: float = Field(default_factory=hours_per_month, gt=0, le=744, description="Running hours per month")
+    storage_class_map: Dict[str, str] = Field(
+        default_factory=dict,
+        description="Overrides of storage class -> cloud volume type"
+    )
+    gpu_sharing: int = Field(
+        default=1, ge=1, description="Pods sharing one GPU through time-slicing or MPS; each shares its cost"
+    )
+    cluster_tier: Optional[str] = Field(
+        None, description="Control plane tier, default the configured or detected tier"
+    )
+    project: Optional[str] = Field(None, description="Project the estimate is recorded under, default the cluster name")
+    labels: Dict[str, str] = Field(default_factory=dict, description="Metadata kept with the estimate")
+
+    @validator("cluster_tier")
+    def validate_cluster_tier(cls, v):
+        return normalize_cluster_tier(v) if v is not None else None
+
+
+class NodeCost(BaseModel):
+    """Monthly cost of one cluster node"""
+
+    name: str
+    provider: str
+    region: str
+    instance_type: str
+    pricing_model: str = PRICING_ON_DEMAND
+    vcpus: float
+    memory_gb: float
+    hourly_price: float
+    monthly_cost: float
+
+
+class LoadBalancerCost(BaseModel):
+    """Monthly cost of a Service of type LoadBalancer (hourly charge, without data processing)"""
+
+    name: str
+    namespace: str
+    hourly_price: float
+    monthly_cost: float
+    labels: Dict[str, str] = Field(default_factory=dict)
+    annotations: Dict[str, str] = Field(default_factory=dict)
+
+
+class NamespaceCost(BaseModel):
+    """Monthly cost of a namespace's workloads, volumes and load balancers"""
+
+    namespace: str
+    workloads: int
+    compute_monthly_cost: float
+    storage_monthly_cost: float
+    load_balancer_monthly_cost: float
+    monthly_cost: float
+
+
+class ClusterEstimateResult(BaseModel):
+    """Current-state monthly cost of a running cluster"""
+
+    cluster: str
+    nodes: List[NodeCost]
+    cluster_rates: Optional[NodeRates] = Field(
+        None, description="Per-vCPU and per-GiB rates averaged over the priced nodes"
+    )
+    workloads: List[WorkloadCost]
+    volumes: List[VolumeCost]
+    load_balancers: List[LoadBalancerCost]
+    namespaces: List[NamespaceCost]
+    control_plane: Optional[ControlPlaneCost] = Field(None, description="Control plane fee of a managed cluster")
+    node_monthly_cost: float = Field(..., description="Cost of all priced nodes (none on Autopilot)")
+    allocated_monthly_cost: float = Field(..., description="Node cost requested by workloads")
+    idle_monthly_cost: float = Field(..., description="Node cost not requested by any workload")
+    storage_monthly_cost: float
+    load_balancer_monthly_cost: float
+    control_plane_monthly_cost: float = 0.0
+    monthly_cost: float = Field(
+        ..., description="Nodes (Autopilot pods), volumes, load balancers and the control plane"
+    )
+    yearly_cost: float
+    currency: str = "USD"
+    time_basis: TimeBasis = Field(default_factory=current_time_basis, description="Hours per month of the costs")
+    unpriced: List[str] = Field(default_factory=list, description="Nodes and volumes that could not be priced")
+    skipped: List[str] = Field(default_factory=list)
+
+
+class RightsizingRequest(UsageEstimateRequest):
+    """Request model for right-sizing recommendations"""
+
+    headroom_percent: Optional[float] = Field(
+        None, ge=0, le=500, description="Capacity kept above measured usage, default the recommender's"
+    )
+    exclude_namespaces: List[str] = Field(default_factory=list, description="Namespaces never right-sized")
+    exclude_workloads: List[str] = Field(
+        default_factory=list, description="Workloads never right-sized, namespace/name patterns, e.g. db/*"
+    )
+    exclude_nodes: List[str] = Field(
+        default_factory=list, description="Nodes never right-sized, node name or instance type patterns"
+    )
+
+
+class NodeRecommendation(BaseModel):
+    """A cheaper instance type for a node, fitting its measured load plus headroom"""
+
+    node: str
+    provider: str
+    region: str
+    instance_type: str
+    recommended_instance_type: str
+    action: str = Field(..., description="downsize, or switch when the new type is larger in one resource")
+    cpu_usage_cores: float = Field(..., description="Average CPU used by the node's pods while it ran")
+    memory_usage_gb: float = Field(..., description="Average working set of the node's pods while it ran (GiB)")
+    cpu_needed_cores: float = Field(..., description="CPU the new type must offer, with headroom")
+    memory_needed_gb: float = Field(..., description="Memory the new type must offer, with headroom (GiB)")
+    vcpus: float
+    memory_gb: float
+    recommended_vcpus: float
+    recommended_memory_gb: float
+    current_monthly_cost: float
+    recommended_monthly_cost: float
+    savings_monthly_cost: float
+    summary: str = Field(..., description="e.g. downsize m5.2xlarge → m5.xlarge, save $83/mo, always in USD")
+
+
+class WorkloadRecommendation(BaseModel):
+    """Lower requests for a workload, fitting its measured usage plus headroom"""
+
+    kind: str
+    name: str
+    namespace: str
+    pods: int = Field(..., description="Pods of the workload seen in the window")
+    cpu_usage_cores: float = Field(..., description="Average CPU used over the window")
+    cpu_request_cores: float = Field(..., description="Average CPU requested over the window")
+    recommended_cpu_request_cores: float
+    memory_usage_gb: float = Field(..., description="Average working set over the window (GiB)")
+    memory_request_gb: float = Field(..., description="Average memory requested over the window (GiB)")
+    recommended_memory_request_gb: float
+    current_monthly_cost: float = Field(..., description="Cost of the current requests")
+    recommended_monthly_cost: float = Field(..., description="Cost of the recommended requests")
+    savings_monthly_cost: float
+    summary: str
+
+
+class RightsizingResult(BaseModel):
+    """Right-sizing recommendations of a running cluster, largest savings first"""
+
+    window: str
+    headroom_percent: float
+    nodes: List[NodeRecommendation]
+    workloads: List[WorkloadRecommendation]
+    savings_monthly_cost: float = Field(
+        ..., description="Savings of the node recommendations, which assume the workload recommendations applied"
+    )
+    freed_request_monthly_cost: float = Field(
+        ..., description="Cost of the requests the workload recommendations free, saved once nodes shrink"
+    )
+    currency: str = "USD"
+    excluded: List[str] = Field(
+        default_factory=list, description="Nodes (node) and workloads (namespace/kind/name) excluded"
+    )
+    unpriced: List[str] = Field(
+        default_factory=list, description="Pods (namespace/pod) on nodes whose type could not be priced"
+    )
+
+
+class NodePoolRequest(BaseModel):
+    """Request model for node pool recommendations"""
+
+    hours: float = Field(default_factory=hours_per_month, gt=0, le=744, description="Running hours per month")
+    provider: Optional[str] = Field(None, description="Provider pools are priced in, default that of most nodes")
+    region: Optional[str] = Field(None, description="Region pools are priced in, default that of most nodes")
+    architectures: List[str] = Field(
+        default_factory=list, description="Architectures pools may use (amd64, arm64), default any"
+    )
+    instance_families: List[str] = Field(
+        default_factory=list, description="Instance type patterns pools may use, e.g. m6* or c7g.*, default any"
+    )
+    spot_percent: float = Field(default=0.0, ge=0, le=100, description="Share of each pool's nodes run as spot")
+    zones: int = Field(default=1, ge=1, le=6, description="Zones each pool's nodes are spread evenly across")
+    max_pools: int = Field(default=3, ge=1, le=5, description="Node pools of general-purpose instance types")
+    max_pods: int = Field(default=110, ge=1, description="Pods per node (kubelet maxPods)")
+
+    @validator("provider")
+    def normalize_provider(cls, v):
+        return v.strip().lower() if v else v
+
+    @validator("architectures", each_item=True)
+    def validate_architecture(cls, v):
+        if v not in ("amd64", "arm64"):
+            raise ValueError("Architecture must be amd64 or arm64")
+        return v
+
+
+class RecommendedNodePool(BaseModel):
+    """A node pool of the recommended composition"""
+
+    instance_type: str
+    architecture: str
+    vcpus: float
+    memory_gb: float
+    gpus: float = 0
+    nodes: int
+    on_demand_nodes: int
+    spot_nodes: int
+    pods: int = Field(..., description="Pods the simulation places on the pool, DaemonSet pods included")
+    cpu_utilization: float
+    memory_utilization: float
+    hourly_price: float = Field(..., description="On-demand price of one node")
+    spot_hourly_price: Optional[float] = Field(None, description="Spot price of one node, if offered")
+    monthly_cost: float
+
+
+class NodePoolRecommendation(BaseModel):
+    """Cheapest node pool composition found for a running cluster's workloads"""
+
+    provider: str
+    region: str
+    current_nodes: int
+    current_instance_types: Dict[str, int] = Field(..., description="Instance type -> nodes of that type")
+    current_monthly_cost: float = Field(..., description="Cost of the priced nodes")
+    pools: List[RecommendedNodePool]
+    recommended_nodes: int
+    recommended_monthly_cost: float
+    savings_monthly_cost: float = Field(..., description="Current minus recommended cost, negative if it costs more")
+    spot_percent: float
+    zones: int
+    candidates: int = Field(..., description="Instance types evaluated")
+    summary: str
+    currency: str = "USD"
+    unschedulable: List[str] = Field(
+        default_factory=list, description="Workloads (kind/namespace/name) no candidate type can run"
+    )
+    unpriced: List[str] = Field(default_factory=list, description="Current nodes that could not be priced")
+
+
+class ArmMigrationRequest(BaseModel):
+    """Request model for ARM migration recommendations"""
+
+    hours: float = Field(default_factory=hours_per_month, gt=0, le=744, description="Running hours per month")
+    compatible: List[str] = Field(
+        default_factory=list, description="Workloads known to run on arm64, namespace/name patterns, e.g. web/*"
+    )
+    incompatible: List[str] = Field(
+        default_factory=list, description="Workloads known not to run on arm64, namespace/name patterns"
+    )
+    assume_compatible: bool = Field(
+        default=False, description="Count workloads of unknown compatibility as compatible in the savings"
+    )
+    instance_families: List[str] = Field(
+        default_factory=list, description="ARM instance type patterns nodes may move to, e.g. m7g.*, default any"
+    )
+
+
+class ArmNodeMigration(BaseModel):
+    """ARM equivalent of the nodes of one x86 instance type"""
+
+    provider: str
+    region: str
+    instance_type: str
+    pricing_model: str = PRICING_ON_DEMAND
+    arm_instance_type: Optional[str] = Field(None, description="Cheapest ARM type of the same shape, if priced")
+    nodes: int
+    vcpus: float
+    memory_gb: float
+    hourly_price: float
+    arm_hourly_price: Optional[float] = None
+    current_monthly_cost: float
+    arm_monthly_cost: Optional[float] = None
+    savings_monthly_cost: float
+    summary: str = Field(..., description="e.g. 3 x m5.xlarge → m6g.xlarge, save $84/mo, always in USD")
+
+
+class ArmWorkload(BaseModel):
+    """A workload's ARM compatibility and the cost of its requests on x86 and ARM nodes"""
+
+    kind: str
+    name: str
+    namespace: str
+    compatibility: str = Field(..., description="compatible, incompatible or unknown")
+    reason: str
+    current_monthly_cost: float = Field(..., description="Cost of its requests at the x86 nodes' rates")
+    arm_monthly_cost: float = Field(..., description="Cost of its requests at the ARM equivalents' rates")
+    savings_monthly_cost: float
+
+
+class ArmMigrationResult(BaseModel):
+    """Savings of moving a running cluster's x86 workloads to ARM instance families"""
+
+    nodes: List[ArmNodeMigration]
+    workloads: List[ArmWorkload] = Field(..., description="Largest savings first")
+    compatible_workloads: int
+    incompatible_workloads: int
+    unknown_workloads: int
+    current_monthly_cost: float = Field(..., description="Cost of the x86 nodes")
+    arm_monthly_cost: float = Field(..., description="Cost of their ARM equivalents")
+    node_savings_monthly_cost: float = Field(..., description="Savings if every x86 node moves to ARM")
+    savings_monthly_cost: float = Field(
+        ..., description="Savings of the requests of the workloads counted as compatible"
+    )
+    summary: str
+    currency: str = "USD"
+    unpriced: List[str] = Field(
+        default_factory=list, description="Nodes and workloads that could not be priced"
+    )
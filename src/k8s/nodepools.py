@@ -0,0 +1,334 @@
+"""
+Node pool recommendations
+
+Proposes the cheapest node pool composition for the workloads running in
+a cluster. Candidate instance types are the provider's known types,
+burstable ones excluded, narrowed to the allowed architectures and
+instance families; the cheapest of them at their lower bound (the
+cluster's total requests divided over the type's shape) are evaluated by
+bin-packing the workloads onto them with the autoscaler simulation.
+
+The composition starts from the best single general-purpose type and
+adds the type lowering cost the most while that helps, up to max_pools
+types. Workloads needing GPUs get GPU types of their own, tainted so
+only GPU pods run on them (as the ExtendedResourceToleration admission
+plugin does). A composition leaving fewer workloads unschedulable always
+wins, so workloads pinned to an architecture or needing large nodes are
+placed first.
+
+Node counts are rounded up to a multiple of the zones they are spread
+across, and spot_percent of each pool's nodes (rounded down) priced at
+the spot price of types offering one.
+"""
+
+import fnmatch
+import logging
+import math
+from typing import Dict, List, NamedTuple, Optional, Tuple
+
+from ..compare.normalize import is_burstable
+from ..money import round_money
+from ..pricing import (
+    InstanceShape,
+    PriceNotFoundError,
+    ProviderNotFoundError,
+    PRICING_SPOT,
+    known_instance_types,
+)
+from .autoscaler import ARCH_LABELS, AutoscalerSimulator, NodeGroup, SimulationResult, node_labels
+from .cluster import ClusterEstimator
+from .models import (
+    NodePoolRecommendation,
+    NodePoolRequest,
+    NodeRates,
+    RecommendedNodePool,
+    WorkloadResources,
+)
+from .parser import parse_documents
+
+logger = logging.getLogger(__name__)
+
+# General-purpose types evaluated, the cheapest at their lower bound
+DEFAULT_MAX_CANDIDATES = 12
+# GPU types evaluated when workloads need GPUs
+DEFAULT_MAX_GPU_CANDIDATES = 3
+
+GPU_TAINT = {"key": "nvidia.com/gpu", "value": None, "effect": "NoSchedule"}
+_GPU_TOLERATION = {"key": "nvidia.com/gpu", "operator": "Exists", "effect": "NoSchedule"}
+
+
+class _Candidate(NamedTuple):
+    instance_type: str
+    shape: InstanceShape
+    rates: NodeRates
+    spot_hourly: Optional[float]
+
+
+class _Evaluation(NamedTuple):
+    unschedulable: int
+    hourly_cost: float
+    pools: List[_Candidate]
+    simulation: SimulationResult
+
+
+class NodePoolOptimizer:
+    """Recommends the cheapest node pool mix for a running cluster's workloads"""
+
+    def __init__(
+        self,
+        cluster: ClusterEstimator,
+        max_candidates: int = DEFAULT_MAX_CANDIDATES,
+        max_gpu_candidates: int = DEFAULT_MAX_GPU_CANDIDATES,
+    ):
+        """
+        Initialize optimizer
+
+        Args:
+            cluster: Cluster estimator reading and pricing the cluster's nodes
+            max_candidates: General-purpose instance types evaluated
+            max_gpu_candidates: GPU instance types evaluated
+        """
+        self.cluster = cluster
+        self.estimator = cluster.estimator
+        self.max_candidates = max_candidates
+        self.max_gpu_candidates = max_gpu_candidates
+
+    def recommend(self, request: NodePoolRequest) -> NodePoolRecommendation:
+        """
+        Cheapest node pool composition for the cluster's current workloads
+
+        Raises:
+            ValueError: If an object has invalid quantities, no candidate type
+                can be priced or there are too many pods to simulate
+        """
+        snapshot = self.cluster.source.snapshot()
+        unpriced: List[str] = []
+        current = self.cluster.price_nodes(snapshot.nodes, request.hours, unpriced)
+        provider, region = self.cluster.location([(node.provider, node.region) for node, _ in current])
+        provider = request.provider or provider
+        region = request.region or region
+
+        workloads = [_with_gpu_toleration(w) for w in parse_documents(snapshot.workloads).workloads]
+        general, gpu = self._candidates(provider, region, request, workloads)
+        if not general:
+            raise ValueError(f"No instance type of {provider}/{region} matches the constraints and can be priced")
+
+        best = self._search(general, gpu, workloads, request)
+        simulation = best.simulation
+        pools = []
+        for candidate in best.pools:
+            nodes = simulation.nodes[candidate.instance_type]
+            if nodes:
+                pools.append(self._pool(candidate, nodes, workloads, simulation, request))
+
+        current_cost = sum(node.monthly_cost for node, _ in current)
+        recommended_cost = sum(pool.monthly_cost for pool in pools)
+        unschedulable = [
+            f"{workloads[i].kind}/{workloads[i].namespace}/{workloads[i].name}"
+            for i in sorted(simulation.unschedulable)
+        ]
+        types: Dict[str, int] = {}
+        for node, _ in current:
+            types[node.instance_type] = types.get(node.instance_type, 0) + 1
+
+        result = NodePoolRecommendation(
+            provider=provider,
+            region=region,
+            current_nodes=len(current),
+            current_instance_types=types,
+            current_monthly_cost=round_money(current_cost),
+            pools=pools,
+            recommended_nodes=sum(pool.nodes for pool in pools),
+            recommended_monthly_cost=round_money(recommended_cost),
+            savings_monthly_cost=round_money(current_cost - recommended_cost),
+            spot_percent=request.spot_percent,
+            zones=request.zones,
+            candidates=len(general) + len(gpu),
+            summary=_summary(pools, request, current_cost, recommended_cost),
+            unschedulable=unschedulable,
+            unpriced=unpriced,
+        )
+        logger.info(f"Node pool recommendation for cluster {self.cluster.cluster}: {result.summary}")
+        return result
+
+    def _candidates(
+        self, provider: str, region: str, request: NodePoolRequest, workloads: List[WorkloadResources]
+    ) -> Tuple[List[_Candidate], List[_Candidate]]:
+        """(general-purpose, GPU) candidate types, cheapest lower bound first"""
+        pods = [w for w in workloads if w.kind != "DaemonSet"]
+        cpu = sum(w.cpu_cores * w.replicas for w in pods)
+        memory = sum(w.memory_gb * w.replicas for w in pods)
+        gpu_pods = [w for w in pods if w.gpus]
+        gpu_cpu = sum(w.cpu_cores * w.replicas for w in gpu_pods)
+        gpu_memory = sum(w.memory_gb * w.replicas for w in gpu_pods)
+        gpus = sum(w.gpus * w.replicas for w in gpu_pods)
+
+        general: List[Tuple[float, _Candidate]] = []
+        gpu: List[Tuple[float, _Candidate]] = []
+        for instance_type, shape in sorted(known_instance_types(provider).items()):
+            if is_burstable(provider, instance_type):
+                continue
+            if request.architectures and ARCH_LABELS.get(shape.arch, shape.arch) not in request.architectures:
+                continue
+            if request.instance_families and not any(
+                fnmatch.fnmatch(instance_type, pattern) for pattern in request.instance_families
+            ):
+                continue
+            if shape.gpus and not gpu_pods:
+                continue
+            try:
+                rates = self.estimator.node_rates(provider, region, instance_type)
+            except (ValueError, PriceNotFoundError, ProviderNotFoundError):
+                continue
+            try:
+                spot: Optional[float] = self.estimator.node_rates(
+                    provider, region, instance_type, PRICING_SPOT
+                ).hourly_price
+            except (ValueError, PriceNotFoundError, ProviderNotFoundError):
+                spot = None
+            candidate = _Candidate(instance_type, shape, rates, spot)
+            price = _node_hourly(candidate, request.spot_percent)
+            if shape.gpus:
+                bound = max(gpu_cpu / shape.vcpus, gpu_memory / shape.memory_gb, gpus / shape.gpus)
+                gpu.append((bound * price, candidate))
+            else:
+                general.append((max(cpu / shape.vcpus, memory / shape.memory_gb) * price, candidate))
+
+        general.sort(key=lambda item: (item[0], item[1].instance_type))
+        gpu.sort(key=lambda item: (item[0], item[1].instance_type))
+        return (
+            [candidate for _, candidate in general[:self.max_candidates]],
+            [candidate for _, candidate in gpu[:self.max_gpu_candidates]],
+        )
+
+    def _search(
+        self,
+        general: List[_Candidate],
+        gpu: List[_Candidate],
+        workloads: List[WorkloadResources],
+        request: NodePoolRequest,
+    ) -> _Evaluation:
+        """Greedy composition: the best single type, then the type lowering cost the most"""
+        best = min(
+            (self._evaluate([candidate] + gpu, workloads, request) for candidate in general),
+            key=_score,
+        )
+        chosen = [candidate for candidate in best.pools if not candidate.shape.gpus]
+        while len(chosen) < request.max_pools:
+            trials = [
+                self._evaluate(chosen + [candidate] + gpu, workloads, request)
+                for candidate in general if candidate not in chosen
+            ]
+            improved = min(trials, key=_score, default=None)
+            if improved is None or _score(improved) >= _score(best):
+                break
+            best = improved
+            chosen = [candidate for candidate in best.pools if not candidate.shape.gpus]
+        return best
+
+    def _evaluate(
+        self, pools: List[_Candidate], workloads: List[WorkloadResources], request: NodePoolRequest
+    ) -> _Evaluation:
+        groups = [
+            NodeGroup(
+                name=candidate.instance_type,
+                labels=node_labels(candidate.instance_type, candidate.shape.arch, ""),
+                taints=[GPU_TAINT] if candidate.shape.gpus else [],
+                cpu_cores=candidate.rates.vcpus,
+                memory_gb=candidate.rates.memory_gb,
+                gpus=candidate.rates.gpus,
+                max_pods=request.max_pods,
+                hourly_price=_node_hourly(candidate, request.spot_percent),
+            )
+            for candidate in pools
+        ]
+        simulation = AutoscalerSimulator(groups).simulate(workloads)
+        hourly = sum(
+            _pool_hourly(candidate, _zone_nodes(simulation.nodes[candidate.instance_type], request.zones),
+                         request.spot_percent)
+            for candidate in pools
+        )
+        return _Evaluation(sum(simulation.unschedulable.values()), hourly, pools, simulation)
+
+    def _pool(
+        self,
+        candidate: _Candidate,
+        nodes: int,
+        workloads: List[WorkloadResources],
+        simulation: SimulationResult,
+        request: NodePoolRequest,
+    ) -> RecommendedNodePool:
+        nodes = _zone_nodes(nodes, request.zones)
+        spot = _spot_nodes(candidate, nodes, request.spot_percent)
+        placed = [
+            (workloads[i], count) for (i, name), count in simulation.placements.items()
+            if name == candidate.instance_type
+        ]
+        rates = candidate.rates
+        return RecommendedNodePool(
+            instance_type=candidate.instance_type,
+            architecture=ARCH_LABELS.get(candidate.shape.arch, candidate.shape.arch),
+            vcpus=rates.vcpus,
+            memory_gb=rates.memory_gb,
+            gpus=rates.gpus,
+            nodes=nodes,
+            on_demand_nodes=nodes - spot,
+            spot_nodes=spot,
+            pods=sum(count for _, count in placed),
+            cpu_utilization=round(sum(w.cpu_cores * count for w, count in placed) / (nodes * rates.vcpus), 4),
+            memory_utilization=round(sum(w.memory_gb * count for w, count in placed) / (nodes * rates.memory_gb), 4),
+            hourly_price=rates.hourly_price,
+            spot_hourly_price=candidate.spot_hourly,
+            monthly_cost=round_money(_pool_hourly(candidate, nodes, request.spot_percent) * request.hours),
+        )
+
+
+def _with_gpu_toleration(workload: WorkloadResources) -> WorkloadResources:
+    """GPU workloads tolerate the taint of GPU nodes, as ExtendedResourceToleration adds it"""
+    if not workload.gpus:
+        return workload
+    return workload.copy(update={"tolerations": workload.tolerations + [_GPU_TOLERATION]})
+
+
+def _score(evaluation: _Evaluation) -> Tuple[int, float]:
+    """Fewest unschedulable pods, then lowest cost"""
+    return evaluation.unschedulable, round(evaluation.hourly_cost, 6)
+
+
+def _zone_nodes(nodes: int, zones: int) -> int:
+    """Nodes once spread evenly across zones"""
+    return math.ceil(nodes / zones) * zones
+
+
+def _spot_nodes(candidate: _Candidate, nodes: int, spot_percent: float) -> int:
+    if candidate.spot_hourly is None:
+        return 0
+    return math.floor(nodes * spot_percent / 100)
+
+
+def _pool_hourly(candidate: _Candidate, nodes: int, spot_percent: float) -> float:
+    spot = _spot_nodes(candidate, nodes, spot_percent)
+    return (nodes - spot) * candidate.rates.hourly_price + spot * (candidate.spot_hourly or 0.0)
+
+
+def _node_hourly(candidate: _Candidate, spot_percent: float) -> float:
+    """Average price of a pool's node at the spot share"""
+    if candidate.spot_hourly is None:
+        return candidate.rates.hourly_price
+    share = spot_percent / 100
+    return (1 - share) * candidate.rates.hourly_price + share * candidate.spot_hourly
+
+
+def _summary(pools: List[RecommendedNodePool], request: NodePoolRequest, current: float, recommended: float) -> str:
+    """e.g. 3 x m6g.xlarge + 1 x c6g.2xlarge (25% spot across 3 zones): $412/mo vs $733/mo now, save $321/mo"""
+    mix = " + ".join(f"{pool.nodes} x {pool.instance_type}" for pool in pools) or "no nodes"
+    details = []
+    if request.spot_percent:
+        details.append(f"{request.spot_percent:g}% spot")
+    if request.zones > 1:
+        details.append(f"across {request.zones} zones")
+    if details:
+        mix += f" ({' '.join(details)})"
+    savings = current - recommended
+    change = f"save ${savings:,.0f}/mo" if savings >= 0 else f"${-savings:,.0f}/mo more"
+    return f"{mix}: ${recommended:,.0f}/mo vs ${current:,.0f}/mo now, {change}"
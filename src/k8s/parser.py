@@ -0,0 +1,230 @@
+"""
+Kubernetes manifest parser
+
+Extracts per-replica resource requests and persistent volume claims
+from Deployments, StatefulSets, DaemonSets, ReplicaSets, Jobs, Pods and PVCs.
+GPUs are read from the nvidia.com/gpu and amd.com/gpu extended resources
+and NVIDIA MIG slices (nvidia.com/mig-<slices>g.<memory>gb).
+"""
+
+import logging
+import re
+from typing import Any, Dict, Iterable, List, Tuple, Union
+
+import yaml
+
+from .models import ParsedManifests, VolumeClaim, WorkloadResources
+from .quantity import parse_bytes_gb, parse_cpu, parse_quantity
+
+logger = logging.getLogger(__name__)
+
+# Kinds whose spec.template is a pod template
+_TEMPLATE_KINDS = {"Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job"}
+# Annotations holding whole objects rather than metadata, not kept with costs
+_OBJECT_ANNOTATIONS = {"kubectl.kubernetes.io/last-applied-configuration"}
+
+# Extended resources allocating whole GPUs
+GPU_RESOURCES = ("nvidia.com/gpu", "amd.com/gpu")
+# A MIG slice allocates its compute slices out of the seven of a GPU
+_MIG_RESOURCE = re.compile(r"^nvidia\.com/mig-(?P<slices>\d+)g\.\d+gb$")
+MIG_SLICES_PER_GPU = 7
+
+
+def parse_manifests(manifests: Union[str, Iterable[str]]) -> ParsedManifests:
+    """
+    Parse one or more YAML manifest texts
+
+    Raises:
+        ValueError: If a document is not valid YAML or has invalid quantities
+    """
+    if isinstance(manifests, str):
+        manifests = [manifests]
+
+    documents = []
+    for text in manifests:
+        try:
+            documents.extend(yaml.safe_load_all(text))
+        except yaml.YAMLError as e:
+            raise ValueError(f"Invalid YAML manifest: {e}") from None
+
+    return parse_documents(documents)
+
+
+def parse_documents(documents: Iterable[Any]) -> ParsedManifests:
+    """
+    Parse already loaded manifest documents, e.g. objects read from the API server
+
+    Raises:
+        ValueError: If a document has invalid quantities
+    """
+    parsed = ParsedManifests()
+    for document in documents:
+        _parse_document(document, parsed)
+
+    logger.info(
+        f"Parsed manifests: {len(parsed.workloads)} workloads, "
+        f"{len(parsed.volume_claims)} volume claims, {len(parsed.skipped)} skipped"
+    )
+    return parsed
+
+
+def _parse_document(document: Any, parsed: ParsedManifests) -> None:
+    if not isinstance(document, dict):
+        return
+
+    kind = document.get("kind", "")
+    if kind == "List" or kind.endswith("List"):
+        for item in document.get("items", []):
+            _parse_document(item, parsed)
+        return
+
+    metadata = document.get("metadata") or {}
+    name = metadata.get("name", "unnamed")
+    namespace = metadata.get("namespace", "default")
+    spec = document.get("spec") or {}
+
+    if kind in _TEMPLATE_KINDS:
+        pod_spec = (spec.get("template") or {}).get("spec") or {}
+        replicas = _replicas(kind, spec)
+        parsed.workloads.append(_workload(kind, name, namespace, replicas, pod_spec, metadata))
+
+        if kind == "StatefulSet":
+            for template in spec.get("volumeClaimTemplates", []):
+                claim = _volume_claim(template, namespace, count=replicas)
+                claim.owner = f"{kind}/{name}"
+                parsed.volume_claims.append(claim)
+        return
+
+    if kind == "Pod":
+        parsed.workloads.append(_workload(kind, name, namespace, 1, spec, metadata))
+        return
+
+    if kind == "PersistentVolumeClaim":
+        parsed.volume_claims.append(_volume_claim(document, namespace))
+        return
+
+    parsed.skipped.append(f"{kind or 'Unknown'}/{name}")
+
+
+def _replicas(kind: str, spec: Dict[str, Any]) -> int:
+    """Replica count of a workload spec"""
+    if kind == "Job":
+        return int(spec.get("parallelism", 1))
+    if kind == "DaemonSet":
+        # One pod per node; node count is not known from the manifest alone
+        return 1
+    return int(spec.get("replicas", 1))
+
+
+def _workload(
+    kind: str,
+    name: str,
+    namespace: str,
+    replicas: int,
+    pod_spec: Dict[str, Any],
+    metadata: Dict[str, Any],
+) -> WorkloadResources:
+    cpu, memory = effective_pod_requests(pod_spec)
+    return WorkloadResources(
+        kind=kind,
+        name=name,
+        namespace=namespace,
+        replicas=replicas,
+        cpu_cores=cpu,
+        memory_gb=memory,
+        gpus=round(pod_gpus(pod_spec), 4),
+        labels=metadata.get("labels") or {},
+        annotations=annotations_of(metadata),
+        node_selector={str(k): str(v) for k, v in (pod_spec.get("nodeSelector") or {}).items()},
+        node_affinity=_required_node_terms(pod_spec),
+        tolerations=[t for t in pod_spec.get("tolerations") or [] if isinstance(t, dict)],
+    )
+
+
+def _required_node_terms(pod_spec: Dict[str, Any]) -> List[Dict[str, Any]]:
+    """nodeSelectorTerms of a pod's requiredDuringSchedulingIgnoredDuringExecution node affinity"""
+    node_affinity = (pod_spec.get("affinity") or {}).get("nodeAffinity") or {}
+    required = node_affinity.get("requiredDuringSchedulingIgnoredDuringExecution") or {}
+    return [term for term in required.get("nodeSelectorTerms") or [] if isinstance(term, dict)]
+
+
+def annotations_of(metadata: Dict[str, Any]) -> Dict[str, str]:
+    """An object's annotations, without those holding whole objects"""
+    return {
+        key: value for key, value in (metadata.get("annotations") or {}).items()
+        if key not in _OBJECT_ANNOTATIONS
+    }
+
+
+def effective_pod_requests(pod_spec: Dict[str, Any]) -> Tuple[float, float]:
+    """
+    Effective (cpu cores, memory GiB) request of a pod, as the scheduler sees it
+
+    The larger of the summed app containers and the biggest init container,
+    plus pod overhead. Containers without requests fall back to their limits.
+    """
+    containers = _container_requests(pod_spec.get("containers", []))
+    init_containers = _container_requests(pod_spec.get("initContainers", []))
+
+    cpu = max(sum(c for c, _ in containers), max((c for c, _ in init_containers), default=0.0))
+    memory = max(sum(m for _, m in containers), max((m for _, m in init_containers), default=0.0))
+
+    overhead = pod_spec.get("overhead") or {}
+    cpu += parse_cpu(overhead.get("cpu", 0))
+    memory += parse_bytes_gb(overhead.get("memory", 0))
+
+    return cpu, memory
+
+
+def _container_requests(containers: List[Dict[str, Any]]) -> List[Tuple[float, float]]:
+    result = []
+    for container in containers or []:
+        resources = container.get("resources") or {}
+        requests = resources.get("requests") or {}
+        limits = resources.get("limits") or {}
+        cpu = requests.get("cpu", limits.get("cpu", 0))
+        memory = requests.get("memory", limits.get("memory", 0))
+        result.append((parse_cpu(cpu), parse_bytes_gb(memory)))
+    return result
+
+
+def pod_gpus(pod_spec: Dict[str, Any]) -> float:
+    """
+    GPUs allocated to a pod, MIG slices as their share of a GPU
+
+    The larger of the summed app containers and the biggest init container.
+    Extended resources are set as limits; requests must equal them.
+    """
+    containers = [_container_gpus(c) for c in pod_spec.get("containers") or []]
+    init_containers = [_container_gpus(c) for c in pod_spec.get("initContainers") or []]
+    return max(sum(containers), max(init_containers, default=0.0))
+
+
+def _container_gpus(container: Dict[str, Any]) -> float:
+    resources = container.get("resources") or {}
+    amounts = {**(resources.get("requests") or {}), **(resources.get("limits") or {})}
+    gpus = 0.0
+    for name, value in amounts.items():
+        if name in GPU_RESOURCES:
+            gpus += parse_quantity(value)
+            continue
+        match = _MIG_RESOURCE.match(name)
+        if match is not None:
+            gpus += parse_quantity(value) * int(match.group("slices")) / MIG_SLICES_PER_GPU
+    return gpus
+
+
+def _volume_claim(document: Dict[str, Any], namespace: str, count: int = 1) -> VolumeClaim:
+    metadata = document.get("metadata") or {}
+    spec = document.get("spec") or {}
+    storage = ((spec.get("resources") or {}).get("requests") or {}).get("storage", 0)
+
+    return VolumeClaim(
+        name=metadata.get("name", "unnamed"),
+        namespace=metadata.get("namespace", namespace),
+        storage_class=spec.get("storageClassName"),
+        size_gb=parse_bytes_gb(storage),
+        count=count,
+        labels=metadata.get("labels") or {},
+        annotations=annotations_of(metadata),
+    )
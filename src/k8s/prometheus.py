@@ -0,0 +1,68 @@
+"""
+Prometheus HTTP API client for cluster usage queries
+"""
+
+from typing import Dict, List, NamedTuple, Optional
+
+import requests
+
+
+class PrometheusError(Exception):
+    """A query failed or Prometheus answered with an error"""
+
+
+class Sample(NamedTuple):
+    """One series of an instant vector"""
+
+    labels: Dict[str, str]
+    value: float
+
+
+class PrometheusClient:
+    """Client for the Prometheus instant query API"""
+
+    def __init__(
+        self,
+        base_url: str,
+        timeout: int = 30,
+        session: Optional[requests.Session] = None,
+    ):
+        """
+        Initialize Prometheus client
+
+        Args:
+            base_url: Prometheus (or Thanos/Mimir query frontend) URL
+            timeout: Per-query timeout in seconds
+            session: HTTP session. Created if not provided.
+        """
+        self.base_url = base_url.rstrip("/")
+        self.timeout = timeout
+        self.session = session or requests.Session()
+
+    def query(self, promql: str) -> List[Sample]:
+        """
+        Evaluate an instant query
+
+        Raises:
+            PrometheusError: If Prometheus cannot be reached or rejects the query
+        """
+        try:
+            response = self.session.get(
+                f"{self.base_url}/api/v1/query",
+                params={"query": promql},
+                timeout=self.timeout,
+            )
+            data = response.json()
+        except (requests.RequestException, ValueError) as e:
+            raise PrometheusError(f"Prometheus query failed: {e}") from None
+
+        if data.get("status") != "success":
+            raise PrometheusError(f"Prometheus query failed: {data.get('error') or response.status_code}")
+        result = data.get("data", {})
+        if result.get("resultType") != "vector":
+            raise PrometheusError(f"Expected an instant vector, got {result.get('resultType')}")
+
+        return [
+            Sample(series.get("metric", {}), float(series["value"][1]))
+            for series in result.get("result", [])
+        ]
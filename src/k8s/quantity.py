@@ -0,0 +1,46 @@
+"""
+Kubernetes resource quantity parsing
+"""
+
+import re
+
+from ..units import BYTES_PER_GB
+
+_BINARY = {"Ki": 2 ** 10, "Mi": 2 ** 20, "Gi": 2 ** 30, "Ti": 2 ** 40, "Pi": 2 ** 50, "Ei": 2 ** 60}
+_DECIMAL = {"n": 1e-9, "u": 1e-6, "m": 1e-3, "k": 1e3, "M": 1e6, "G": 1e9, "T": 1e12, "P": 1e15, "E": 1e18}
+
+_QUANTITY = re.compile(r"^([+-]?[0-9.]+(?:[eE][+-]?[0-9]+)?)([a-zA-Z]*)$")
+
+
+def parse_quantity(value) -> float:
+    """
+    Parse a Kubernetes quantity ("500m", "1.5Gi", "2") into a plain number
+
+    Raises:
+        ValueError: If the quantity is malformed
+    """
+    if isinstance(value, (int, float)):
+        return float(value)
+
+    match = _QUANTITY.match(str(value).strip())
+    if match is None:
+        raise ValueError(f"Invalid quantity: {value!r}")
+
+    number, suffix = float(match.group(1)), match.group(2)
+    if not suffix:
+        return number
+    if suffix in _BINARY:
+        return number * _BINARY[suffix]
+    if suffix in _DECIMAL:
+        return number * _DECIMAL[suffix]
+    raise ValueError(f"Invalid quantity suffix: {value!r}")
+
+
+def parse_cpu(value) -> float:
+    """CPU quantity in cores"""
+    return parse_quantity(value)
+
+
+def parse_bytes_gb(value) -> float:
+    """Memory or storage quantity in GiB (the unit cloud prices are quoted in)"""
+    return parse_quantity(value) / BYTES_PER_GB
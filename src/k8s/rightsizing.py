@@ -0,0 +1,310 @@
+"""
+Right-sizing recommendations
+
+Builds on the usage Prometheus measured (see usage.py). A workload whose
+requests exceed its average usage plus headroom is recommended lower
+requests; the cost of the requests it frees is saved once the nodes
+shrink. A node is recommended the cheapest known instance type of its
+provider, architecture and GPU count that fits the load of its pods plus
+headroom, assuming the workload recommendations are applied: pods of
+right-sized workloads need their usage plus headroom, all others the
+larger of that and their requests. A node's load is averaged over the
+time it ran, so nodes added by an autoscaler during the window are not
+undersized. Burstable types are only recommended for burstable nodes.
+"""
+
+import fnmatch
+import logging
+from typing import Dict, List, Optional, Set, Tuple
+
+from ..compare.normalize import is_burstable
+from ..money import round_money
+from ..pricing import (
+    InstanceShape,
+    PriceNotFoundError,
+    PriceQuery,
+    ProviderNotFoundError,
+    get_instance_shape,
+    known_instance_types,
+    SERVICE_COMPUTE,
+    PRICING_ON_DEMAND,
+)
+from .models import (
+    NodeRates,
+    NodeRecommendation,
+    RightsizingRequest,
+    RightsizingResult,
+    WorkloadRecommendation,
+)
+from .usage import STEP_SECONDS, SUBQUERY_STEP, Node, PodUsage, UsageEstimator, window_seconds
+
+logger = logging.getLogger(__name__)
+
+# Namespaces never right-sized by default
+DEFAULT_EXCLUDE_NAMESPACES = ["kube-system"]
+
+
+def node_presence_query(window: str) -> str:
+    """PromQL of the number of steps each node existed in the window"""
+    return f"count by (node) (count_over_time(kube_node_info[{window}:{SUBQUERY_STEP}]))"
+
+
+def _matches(value: str, patterns: List[str]) -> bool:
+    return any(fnmatch.fnmatchcase(value, pattern) for pattern in patterns)
+
+
+class RightsizingRecommender:
+    """Recommends lower workload requests and cheaper node types from measured usage"""
+
+    def __init__(
+        self,
+        usage: UsageEstimator,
+        headroom_percent: float = 20.0,
+        exclude_namespaces: Optional[List[str]] = None,
+        exclude_workloads: Optional[List[str]] = None,
+        exclude_nodes: Optional[List[str]] = None,
+        min_monthly_savings: float = 1.0,
+    ):
+        """
+        Initialize recommender
+
+        Args:
+            usage: Usage estimator measuring pods through Prometheus
+            headroom_percent: Capacity kept above measured usage, in percent
+            exclude_namespaces: Namespaces whose workloads are never right-sized
+            exclude_workloads: namespace/name patterns of workloads never right-sized
+            exclude_nodes: Node name or instance type patterns of nodes never right-sized
+            min_monthly_savings: Savings a recommendation needs (USD/month)
+        """
+        self.usage = usage
+        self.headroom_percent = headroom_percent
+        self.exclude_namespaces = DEFAULT_EXCLUDE_NAMESPACES if exclude_namespaces is None else exclude_namespaces
+        self.exclude_workloads = exclude_workloads or []
+        self.exclude_nodes = exclude_nodes or []
+        self.min_monthly_savings = min_monthly_savings
+
+    def recommend(self, request: RightsizingRequest) -> RightsizingResult:
+        """
+        Right-sizing recommendations of the cluster's workloads and nodes
+
+        Nodes are only recommended for the whole cluster, as a namespace's
+        pods are not all of a node's load.
+
+        Raises:
+            PrometheusError: If a query fails
+        """
+        headroom = self.headroom_percent if request.headroom_percent is None else request.headroom_percent
+        factor = 1 + headroom / 100
+        exclude_namespaces = self.exclude_namespaces + request.exclude_namespaces
+        exclude_workloads = self.exclude_workloads + request.exclude_workloads
+        exclude_nodes = self.exclude_nodes + request.exclude_nodes
+
+        usage = self.usage.observe(request)
+        rates: Dict[Node, Optional[NodeRates]] = {}
+        by_workload: Dict[Tuple[str, str, str], List[Tuple[PodUsage, NodeRates]]] = {}
+        by_node: Dict[str, List[Tuple[PodUsage, Tuple[str, str, str]]]] = {}
+        unpriced = []
+        for pod in usage.pods:
+            node_name = usage.pod_nodes.get((pod.namespace, pod.pod), "")
+            node = usage.nodes.get(node_name)
+            node_rates = None if node is None else self.usage.rates(rates, node, request.pricing_model)
+            if node_rates is None:
+                unpriced.append(f"{pod.namespace}/{pod.pod}")
+                continue
+            kind, name = usage.owners.get((pod.namespace, pod.pod), ("Pod", pod.pod))
+            by_workload.setdefault((pod.namespace, kind, name), []).append((pod, node_rates))
+            by_node.setdefault(node_name, []).append((pod, (pod.namespace, kind, name)))
+
+        excluded: List[str] = []
+        workloads: List[WorkloadRecommendation] = []
+        for (namespace, kind, name), pods in sorted(by_workload.items()):
+            if namespace in exclude_namespaces or _matches(f"{namespace}/{name}", exclude_workloads):
+                excluded.append(f"{namespace}/{kind}/{name}")
+                continue
+            recommendation = self._workload(namespace, kind, name, pods, factor, request.hours)
+            if recommendation is not None:
+                workloads.append(recommendation)
+        right_sized: Set[Tuple[str, str, str]] = {(w.namespace, w.kind, w.name) for w in workloads}
+
+        nodes: List[NodeRecommendation] = []
+        if not request.namespace:
+            presence = self._presence(request.window)
+            prices: Dict[Tuple[Node, str], Optional[float]] = {}
+            for node_name, pods in sorted(by_node.items()):
+                node = usage.nodes[node_name]
+                if _matches(node_name, exclude_nodes) or _matches(node.instance_type, exclude_nodes):
+                    excluded.append(node_name)
+                    continue
+                recommendation = self._node(
+                    node_name, node, rates[node], pods, right_sized, factor, presence.get(node_name, 1.0),
+                    prices, request,
+                )
+                if recommendation is not None:
+                    nodes.append(recommendation)
+
+        workloads.sort(key=lambda w: -w.savings_monthly_cost)
+        nodes.sort(key=lambda n: -n.savings_monthly_cost)
+        savings = sum(n.savings_monthly_cost for n in nodes)
+        freed = sum(w.savings_monthly_cost for w in workloads)
+        logger.info(
+            f"Right-sizing over {request.window} with {headroom:g}% headroom: {len(nodes)} nodes "
+            f"saving ${savings:.2f}/month, {len(workloads)} workloads freeing ${freed:.2f}/month of requests"
+        )
+
+        return RightsizingResult(
+            window=request.window,
+            headroom_percent=headroom,
+            nodes=nodes,
+            workloads=workloads,
+            savings_monthly_cost=round_money(savings),
+            freed_request_monthly_cost=round_money(freed),
+            excluded=excluded,
+            unpriced=unpriced,
+        )
+
+    def _presence(self, window: str) -> Dict[str, float]:
+        """Fraction of the window each node existed"""
+        steps = window_seconds(window) / STEP_SECONDS
+        return {
+            s.labels["node"]: min(1.0, s.value / steps)
+            for s in self.usage.client.query(node_presence_query(window))
+            if s.labels.get("node") and s.value > 0
+        }
+
+    def _workload(
+        self,
+        namespace: str,
+        kind: str,
+        name: str,
+        pods: List[Tuple[PodUsage, NodeRates]],
+        factor: float,
+        hours: float,
+    ) -> Optional[WorkloadRecommendation]:
+        cpu_usage = sum(p.cpu_usage for p, _ in pods)
+        memory_usage = sum(p.memory_usage_gb for p, _ in pods)
+        cpu_request = sum(p.cpu_request for p, _ in pods)
+        memory_request = sum(p.memory_request_gb for p, _ in pods)
+
+        current = recommended = 0.0
+        for pod, rates in pods:
+            cpu, memory = rates.cpu_hourly * hours, rates.memory_hourly * hours
+            current += pod.cpu_request * cpu + pod.memory_request_gb * memory
+            recommended += (
+                min(pod.cpu_request, pod.cpu_usage * factor) * cpu
+                + min(pod.memory_request_gb, pod.memory_usage_gb * factor) * memory
+            )
+        savings = current - recommended
+        if savings < self.min_monthly_savings:
+            return None
+
+        cpu_recommended = min(cpu_request, cpu_usage * factor)
+        memory_recommended = min(memory_request, memory_usage * factor)
+        changes = []
+        if cpu_recommended < cpu_request:
+            changes.append(f"CPU {cpu_request:.2f} → {cpu_recommended:.2f} cores")
+        if memory_recommended < memory_request:
+            changes.append(f"memory {memory_request:.2f} → {memory_recommended:.2f} GiB")
+        return WorkloadRecommendation(
+            kind=kind,
+            name=name,
+            namespace=namespace,
+            pods=len(pods),
+            cpu_usage_cores=round(cpu_usage, 4),
+            cpu_request_cores=round(cpu_request, 4),
+            recommended_cpu_request_cores=round(cpu_recommended, 4),
+            memory_usage_gb=round(memory_usage, 4),
+            memory_request_gb=round(memory_request, 4),
+            recommended_memory_request_gb=round(memory_recommended, 4),
+            current_monthly_cost=round_money(current),
+            recommended_monthly_cost=round_money(recommended),
+            savings_monthly_cost=round_money(savings),
+            summary=f"lower requests of {kind} {namespace}/{name}: {', '.join(changes)}, free ${savings:,.0f}/mo",
+        )
+
+    def _node(
+        self,
+        node_name: str,
+        node: Node,
+        node_rates: NodeRates,
+        pods: List[Tuple[PodUsage, Tuple[str, str, str]]],
+        right_sized: Set[Tuple[str, str, str]],
+        factor: float,
+        presence: float,
+        prices: Dict[Tuple[Node, str], Optional[float]],
+        request: RightsizingRequest,
+    ) -> Optional[NodeRecommendation]:
+        cpu_usage = sum(p.cpu_usage for p, _ in pods) / presence
+        memory_usage = sum(p.memory_usage_gb for p, _ in pods) / presence
+        cpu_needed = memory_needed = 0.0
+        for pod, workload in pods:
+            if workload in right_sized:
+                cpu_needed += pod.cpu_usage * factor
+                memory_needed += pod.memory_usage_gb * factor
+            else:
+                cpu_needed += max(pod.cpu_request, pod.cpu_usage * factor)
+                memory_needed += max(pod.memory_request_gb, pod.memory_usage_gb * factor)
+        cpu_needed /= presence
+        memory_needed /= presence
+
+        current_shape = get_instance_shape(node.provider, node.instance_type)
+        current_price = node_rates.hourly_price
+
+        best: Optional[Tuple[float, str, InstanceShape]] = None
+        for instance_type, shape in known_instance_types(node.provider).items():
+            if instance_type == node.instance_type or not self._fits(node, current_shape, instance_type, shape):
+                continue
+            if shape.vcpus < cpu_needed or shape.memory_gb < memory_needed:
+                continue
+            price = self._hourly(prices, node, instance_type, request.pricing_model)
+            if price is not None and (best is None or (price, instance_type) < best[:2]):
+                best = (price, instance_type, shape)
+        if best is None:
+            return None
+
+        price, instance_type, shape = best
+        savings = (current_price - price) * request.hours
+        if savings < self.min_monthly_savings:
+            return None
+        action = "downsize" if shape.vcpus <= current_shape.vcpus and shape.memory_gb <= current_shape.memory_gb else "switch"
+        return NodeRecommendation(
+            node=node_name,
+            provider=node.provider,
+            region=node.region,
+            instance_type=node.instance_type,
+            recommended_instance_type=instance_type,
+            action=action,
+            cpu_usage_cores=round(cpu_usage, 4),
+            memory_usage_gb=round(memory_usage, 4),
+            cpu_needed_cores=round(cpu_needed, 4),
+            memory_needed_gb=round(memory_needed, 4),
+            vcpus=current_shape.vcpus,
+            memory_gb=current_shape.memory_gb,
+            recommended_vcpus=shape.vcpus,
+            recommended_memory_gb=shape.memory_gb,
+            current_monthly_cost=round_money(current_price * request.hours),
+            recommended_monthly_cost=round_money(price * request.hours),
+            savings_monthly_cost=round_money(savings),
+            summary=f"{action} {node.instance_type} → {instance_type}, save ${savings:,.0f}/mo",
+        )
+
+    @staticmethod
+    def _fits(node: Node, current: InstanceShape, instance_type: str, shape: InstanceShape) -> bool:
+        """Whether a type can replace a node's: same architecture and GPUs, burstable only for burstable nodes"""
+        if shape.arch != current.arch or shape.gpus != current.gpus or shape.accelerator != current.accelerator:
+            return False
+        return not is_burstable(node.provider, instance_type) or is_burstable(node.provider, node.instance_type)
+
+    def _hourly(
+        self, prices: Dict[Tuple[Node, str], Optional[float]], node: Node, instance_type: str, pricing_model: str
+    ) -> Optional[float]:
+        """Hourly price of an instance type in a node's region, None when it cannot be priced"""
+        key = (node, instance_type)
+        if key not in prices:
+            try:
+                prices[key] = self.usage.estimator.registry.get_price(PriceQuery(
+                    provider=node.provider, region=node.region, sku=instance_type, service=SERVICE_COMPUTE,
+                    pricing_model=pricing_model or PRICING_ON_DEMAND,
+                )).price
+            except (PriceNotFoundError, ProviderNotFoundError):
+                prices[key] = None
+        return prices[key]
@@ -0,0 +1,94 @@
+"""
+Storage class to cloud volume type mapping
+"""
+
+from typing import Dict, Optional
+
+# Storage class name -> volume type per provider ("" is the cluster default class)
+DEFAULT_STORAGE_CLASSES: Dict[str, Dict[str, str]] = {
+    "aws": {
+        "": "gp3",
+        "gp2": "gp2",
+        "gp3": "gp3",
+        "io1": "io1",
+        "io2": "io2",
+        "st1": "st1",
+        "sc1": "sc1",
+        "standard": "gp2",
+        "ebs-sc": "gp3",
+    },
+    "gcp": {
+        "": "pd-balanced",
+        "standard": "pd-standard",
+        "standard-rwo": "pd-balanced",
+        "premium-rwo": "pd-ssd",
+        "pd-standard": "pd-standard",
+        "pd-balanced": "pd-balanced",
+        "pd-ssd": "pd-ssd",
+    },
+    "azure": {
+        "": "StandardSSD_LRS",
+        "default": "StandardSSD_LRS",
+        "managed": "StandardSSD_LRS",
+        "managed-csi": "StandardSSD_LRS",
+        "managed-premium": "Premium_LRS",
+        "managed-csi-premium": "Premium_LRS",
+        "managed-standard": "Standard_LRS",
+    },
+    # NKS block storage CSI classes
+    "ncp": {
+        "": "SSD",
+        "nks-block-storage": "SSD",
+    },
+    # Cinder CSI classes backed by the cloud's default volume type
+    "openstack": {
+        "": "__DEFAULT__",
+        "standard": "__DEFAULT__",
+        "csi-cinder-sc-delete": "__DEFAULT__",
+        "csi-cinder-sc-retain": "__DEFAULT__",
+    },
+}
+
+# Azure managed disks are billed per provisioned size tier: (max GiB, tier number)
+_AZURE_TIERS = {
+    "Premium_LRS": ("P", [(4, 1), (8, 2), (16, 3), (32, 4), (64, 6), (128, 10), (256, 15),
+                          (512, 20), (1024, 30), (2048, 40), (4096, 50), (8192, 60)]),
+    "StandardSSD_LRS": ("E", [(4, 1), (8, 2), (16, 3), (32, 4), (64, 6), (128, 10), (256, 15),
+                              (512, 20), (1024, 30), (2048, 40), (4096, 50), (8192, 60)]),
+    "Standard_LRS": ("S", [(32, 4), (64, 6), (128, 10), (256, 15), (512, 20), (1024, 30),
+                           (2048, 40), (4096, 50), (8192, 60)]),
+}
+
+
+def volume_type_for(provider: str, storage_class: Optional[str], overrides: Optional[Dict[str, str]] = None) -> str:
+    """
+    Resolve the cloud volume type backing a storage class
+
+    Raises:
+        KeyError: If the storage class is not mapped for the provider
+    """
+    storage_class = storage_class or ""
+    if overrides and storage_class in overrides:
+        return overrides[storage_class]
+
+    mapping = DEFAULT_STORAGE_CLASSES.get(provider, {})
+    if storage_class not in mapping:
+        raise KeyError(f"No volume type mapping for storage class '{storage_class}' on {provider}")
+    return mapping[storage_class]
+
+
+def volume_sku(provider: str, volume_type: str, size_gb: float) -> str:
+    """
+    Priced SKU of a volume
+
+    For Azure managed disks this is the size tier (e.g. StandardSSD_LRS 100GiB -> "E10 LRS");
+    other providers price the volume type per GB.
+    """
+    if provider != "azure" or volume_type not in _AZURE_TIERS:
+        return volume_type
+
+    prefix, tiers = _AZURE_TIERS[volume_type]
+    for max_gb, tier in tiers:
+        if size_gb <= max_gb:
+            return f"{prefix}{tier} LRS"
+    raise ValueError(f"Azure disk of {size_gb:.0f}GiB exceeds the largest {volume_type} tier")
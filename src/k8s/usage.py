@@ -0,0 +1,364 @@
+"""
+Usage-based Kubernetes cost estimation
+
+Prices what workloads actually use, measured by Prometheus, next to what
+they request. Usage comes from cAdvisor (container_cpu_usage_seconds_total,
+container_memory_working_set_bytes); requests, pod placement, owners and
+node instance types come from kube-state-metrics. All figures are
+averages over the window weighted by pod lifetime, so pods replaced
+during a rollout add up instead of double counting. Each pod is priced
+at the per-vCPU and per-GiB rates of the node it ran on; the waste of a
+workload is the cost of requested CPU and memory it did not use.
+"""
+
+import logging
+import math
+import re
+from typing import Dict, List, NamedTuple, Optional, Tuple
+
+from ..money import round_money, sum_money
+from ..pricing import PriceNotFoundError, ProviderNotFoundError, PRICING_ON_DEMAND
+from ..units import BYTES_PER_GB
+from .estimator import KubernetesEstimator
+from .models import (
+    NamespaceUsageCost,
+    NodeRates,
+    UsageEstimateRequest,
+    UsageEstimateResult,
+    WorkloadUsageCost,
+)
+from .prometheus import PrometheusClient, Sample
+
+logger = logging.getLogger(__name__)
+
+# Resolution of the subqueries averaging gauges over the window
+SUBQUERY_STEP = "5m"
+STEP_SECONDS = 300
+
+_DURATION_SECONDS = {"m": 60, "h": 3600, "d": 86400, "w": 604800}
+
+# Node labels naming the instance type and region, newest first
+INSTANCE_TYPE_LABELS = ("label_node_kubernetes_io_instance_type", "label_beta_kubernetes_io_instance_type")
+REGION_LABELS = ("label_topology_kubernetes_io_region", "label_failure_domain_beta_kubernetes_io_region")
+
+# Cloud of a node by the scheme of its spec.providerID
+_PROVIDER_SCHEMES = {"aws": "aws", "gce": "gcp", "azure": "azure", "openstack": "openstack"}
+# Pod name suffix of a ReplicaSet's pod template hash
+_TEMPLATE_HASH = re.compile(r"-[a-z0-9]{6,10}$")
+
+
+class Node(NamedTuple):
+    """A node's pricing identity"""
+
+    provider: str
+    region: str
+    instance_type: str
+
+
+class ClusterUsage(NamedTuple):
+    """Pods seen in a window with where they ran and what owns them"""
+
+    pods: List["PodUsage"]
+    nodes: Dict[str, Node]
+    pod_nodes: Dict[Tuple[str, str], str]
+    owners: Dict[Tuple[str, str], Tuple[str, str]]
+
+
+class PodUsage(NamedTuple):
+    """A pod's average usage and requests over the window"""
+
+    namespace: str
+    pod: str
+    cpu_usage: float
+    cpu_request: float
+    memory_usage_gb: float
+    memory_request_gb: float
+
+
+def window_seconds(window: str) -> int:
+    """Seconds of a Prometheus duration such as 7d"""
+    return int(window[:-1]) * _DURATION_SECONDS[window[-1]]
+
+
+def _selector(namespace: Optional[str], *matchers: str) -> str:
+    if namespace:
+        escaped = namespace.replace("\\", "\\\\").replace('"', '\\"')
+        matchers = (f'namespace="{escaped}"',) + matchers
+    return "{" + ",".join(matchers) + "}" if matchers else ""
+
+
+def usage_queries(window: str, namespace: Optional[str] = None) -> Dict[str, str]:
+    """
+    PromQL of every series the estimate needs, by name
+
+    CPU and memory figures are totals over the window (core-seconds,
+    byte-seconds / step), divided by the window length afterwards.
+    """
+    containers = _selector(namespace, 'container!=""', 'container!="POD"')
+    cpu_requests = _selector(namespace, 'resource="cpu"')
+    memory_requests = _selector(namespace, 'resource="memory"')
+    scheduled = _selector(namespace, 'node!=""')
+    subquery = f"[{window}:{SUBQUERY_STEP}]"
+    return {
+        "cpu_usage": f"sum by (namespace, pod) (increase(container_cpu_usage_seconds_total{containers}[{window}]))",
+        "memory_usage": (
+            f"sum by (namespace, pod) (sum_over_time(container_memory_working_set_bytes{containers}{subquery}))"
+        ),
+        "cpu_request": (
+            f"sum by (namespace, pod) (sum_over_time(kube_pod_container_resource_requests{cpu_requests}{subquery}))"
+        ),
+        "memory_request": (
+            f"sum by (namespace, pod) (sum_over_time(kube_pod_container_resource_requests{memory_requests}{subquery}))"
+        ),
+        "pod_node": f"max by (namespace, pod, node) (max_over_time(kube_pod_info{scheduled}[{window}]))",
+        "pod_owner": (
+            "max by (namespace, pod, owner_kind, owner_name) "
+            f"(max_over_time(kube_pod_owner{_selector(namespace)}[{window}]))"
+        ),
+        "replicaset_owner": (
+            "max by (namespace, replicaset, owner_kind, owner_name) "
+            f"(max_over_time(kube_replicaset_owner{_selector(namespace)}[{window}]))"
+        ),
+        "node_labels": (
+            f"max by (node, {', '.join(INSTANCE_TYPE_LABELS + REGION_LABELS)}) "
+            f"(max_over_time(kube_node_labels[{window}]))"
+        ),
+        "node_info": f"max by (node, provider_id) (max_over_time(kube_node_info[{window}]))",
+    }
+
+
+def provider_of(provider_id: str) -> Optional[str]:
+    """Cloud of a node's providerID, e.g. aws:///us-east-1a/i-0abc -> aws"""
+    scheme = provider_id.split("://", 1)[0] if "://" in provider_id else ""
+    return _PROVIDER_SCHEMES.get(scheme)
+
+
+def parse_nodes(
+    labels: List[Sample], info: List[Sample], default_provider: str, default_region: str
+) -> Dict[str, Node]:
+    """Pricing identity of every node with a known instance type"""
+    providers = {
+        s.labels["node"]: provider_of(s.labels.get("provider_id", ""))
+        for s in info if s.labels.get("node")
+    }
+    nodes = {}
+    for sample in labels:
+        name = sample.labels.get("node")
+        instance_type = next((sample.labels[l] for l in INSTANCE_TYPE_LABELS if sample.labels.get(l)), "")
+        if not name or not instance_type:
+            continue
+        region = next((sample.labels[l] for l in REGION_LABELS if sample.labels.get(l)), default_region)
+        nodes[name] = Node(providers.get(name) or default_provider, region, instance_type)
+    return nodes
+
+
+def workload_owners(pod_owners: List[Sample], replicaset_owners: List[Sample]) -> Dict[Tuple[str, str], Tuple[str, str]]:
+    """Workload (kind, name) of each (namespace, pod); ReplicaSets resolve to their Deployment"""
+    replicasets = {
+        (s.labels.get("namespace", ""), s.labels.get("replicaset", "")): (s.labels["owner_kind"], s.labels["owner_name"])
+        for s in replicaset_owners
+        if s.labels.get("owner_kind") not in (None, "", "<none>")
+    }
+    owners = {}
+    for sample in pod_owners:
+        namespace, pod = sample.labels.get("namespace", ""), sample.labels.get("pod", "")
+        kind, name = sample.labels.get("owner_kind", ""), sample.labels.get("owner_name", "")
+        if kind in ("", "<none>"):
+            continue
+        if kind == "ReplicaSet":
+            kind, name = replicasets.get((namespace, name), ("Deployment", _TEMPLATE_HASH.sub("", name)))
+        owners[(namespace, pod)] = (kind, name)
+    return owners
+
+
+def _by_pod(samples: List[Sample], scale: float) -> Dict[Tuple[str, str], float]:
+    values = {}
+    for sample in samples:
+        if math.isnan(sample.value):
+            continue
+        key = (sample.labels.get("namespace", ""), sample.labels.get("pod", ""))
+        values[key] = values.get(key, 0.0) + sample.value * scale
+    return values
+
+
+def pod_usage(series: Dict[str, List[Sample]], window: str) -> List[PodUsage]:
+    """Average usage and requests of every pod seen in the window"""
+    seconds = window_seconds(window)
+    step = STEP_SECONDS / seconds
+    cpu_usage = _by_pod(series["cpu_usage"], 1 / seconds)
+    memory_usage = _by_pod(series["memory_usage"], step / BYTES_PER_GB)
+    cpu_request = _by_pod(series["cpu_request"], step)
+    memory_request = _by_pod(series["memory_request"], step / BYTES_PER_GB)
+
+    pods = set(cpu_usage) | set(memory_usage) | set(cpu_request) | set(memory_request)
+    return [
+        PodUsage(
+            namespace=namespace,
+            pod=pod,
+            cpu_usage=cpu_usage.get((namespace, pod), 0.0),
+            cpu_request=cpu_request.get((namespace, pod), 0.0),
+            memory_usage_gb=memory_usage.get((namespace, pod), 0.0),
+            memory_request_gb=memory_request.get((namespace, pod), 0.0),
+        )
+        for namespace, pod in sorted(pods)
+    ]
+
+
+class UsageEstimator:
+    """Estimates cluster cost from Prometheus usage metrics"""
+
+    def __init__(
+        self,
+        estimator: KubernetesEstimator,
+        client: PrometheusClient,
+        default_provider: str = "aws",
+        default_region: str = "",
+    ):
+        """
+        Initialize usage estimator
+
+        Args:
+            estimator: Kubernetes estimator deriving per-resource node rates
+            client: Prometheus with cAdvisor and kube-state-metrics series
+            default_provider: Provider of nodes whose providerID does not name one
+            default_region: Region of nodes without a region label
+        """
+        self.estimator = estimator
+        self.client = client
+        self.default_provider = default_provider
+        self.default_region = default_region
+
+    def observe(self, request: UsageEstimateRequest) -> ClusterUsage:
+        """
+        Usage of every pod in the request's window, with its node and owner
+
+        Raises:
+            PrometheusError: If a query fails
+        """
+        series = {
+            name: self.client.query(promql)
+            for name, promql in usage_queries(request.window, request.namespace).items()
+        }
+        nodes = parse_nodes(
+            series["node_labels"], series["node_info"],
+            request.provider or self.default_provider, request.region or self.default_region,
+        )
+        pod_nodes = {
+            (s.labels.get("namespace", ""), s.labels.get("pod", "")): s.labels.get("node", "")
+            for s in series["pod_node"]
+        }
+        owners = workload_owners(series["pod_owner"], series["replicaset_owner"])
+        return ClusterUsage(pod_usage(series, request.window), nodes, pod_nodes, owners)
+
+    def estimate(self, request: UsageEstimateRequest) -> UsageEstimateResult:
+        """
+        Estimate the monthly cost of measured usage and requests
+
+        Raises:
+            PrometheusError: If a query fails
+        """
+        usage = self.observe(request)
+
+        rates: Dict[Node, Optional[NodeRates]] = {}
+        totals: Dict[Tuple[str, str, str], Dict[str, float]] = {}
+        unpriced = []
+        for pod in usage.pods:
+            node = usage.nodes.get(usage.pod_nodes.get((pod.namespace, pod.pod), ""))
+            node_rates = None if node is None else self.rates(rates, node, request.pricing_model)
+            if node_rates is None:
+                unpriced.append(f"{pod.namespace}/{pod.pod}")
+                continue
+
+            kind, name = usage.owners.get((pod.namespace, pod.pod), ("Pod", pod.pod))
+            total = totals.setdefault((pod.namespace, kind, name), dict.fromkeys(
+                ("pods", "cpu_usage", "cpu_request", "memory_usage", "memory_request", "usage", "request", "waste"), 0.0
+            ))
+            cpu, memory = node_rates.cpu_hourly * request.hours, node_rates.memory_hourly * request.hours
+            total["pods"] += 1
+            total["cpu_usage"] += pod.cpu_usage
+            total["cpu_request"] += pod.cpu_request
+            total["memory_usage"] += pod.memory_usage_gb
+            total["memory_request"] += pod.memory_request_gb
+            total["usage"] += pod.cpu_usage * cpu + pod.memory_usage_gb * memory
+            total["request"] += pod.cpu_request * cpu + pod.memory_request_gb * memory
+            total["waste"] += (
+                max(0.0, pod.cpu_request - pod.cpu_usage) * cpu
+                + max(0.0, pod.memory_request_gb - pod.memory_usage_gb) * memory
+            )
+
+        workloads = [
+            WorkloadUsageCost(
+                kind=kind,
+                name=name,
+                namespace=namespace,
+                pods=int(t["pods"]),
+                cpu_usage_cores=round(t["cpu_usage"], 4),
+                cpu_request_cores=round(t["cpu_request"], 4),
+                memory_usage_gb=round(t["memory_usage"], 4),
+                memory_request_gb=round(t["memory_request"], 4),
+                cpu_utilization=_utilization(t["cpu_usage"], t["cpu_request"]),
+                memory_utilization=_utilization(t["memory_usage"], t["memory_request"]),
+                usage_monthly_cost=round_money(t["usage"]),
+                request_monthly_cost=round_money(t["request"]),
+                waste_monthly_cost=round_money(t["waste"]),
+            )
+            for (namespace, kind, name), t in sorted(totals.items())
+        ]
+        namespaces = _namespaces(workloads)
+
+        usage = sum(w.usage_monthly_cost for w in workloads)
+        waste = sum(w.waste_monthly_cost for w in workloads)
+        logger.info(
+            f"Kubernetes usage estimate over {request.window}: {len(workloads)} workloads, "
+            f"${usage:.2f}/month used, ${waste:.2f}/month unused requests, {len(unpriced)} pods unpriced"
+        )
+
+        return UsageEstimateResult(
+            window=request.window,
+            node_rates=[r for r in rates.values() if r is not None],
+            workloads=workloads,
+            namespaces=namespaces,
+            usage_monthly_cost=round_money(usage),
+            request_monthly_cost=sum_money(w.request_monthly_cost for w in workloads),
+            waste_monthly_cost=round_money(waste),
+            unpriced=unpriced,
+        )
+
+    def rates(self, rates: Dict[Node, Optional[NodeRates]], node: Node, pricing_model: str) -> Optional[NodeRates]:
+        """Rates of a node type, None when it cannot be priced; rates caches the types looked up"""
+        if node not in rates:
+            try:
+                rates[node] = self.estimator.node_rates(
+                    node.provider, node.region, node.instance_type, pricing_model or PRICING_ON_DEMAND
+                )
+            except (ValueError, PriceNotFoundError, ProviderNotFoundError) as e:
+                logger.warning(f"Node type {node.provider}/{node.region}/{node.instance_type} not priced: {e}")
+                rates[node] = None
+        return rates[node]
+
+
+def _namespaces(workloads: List[WorkloadUsageCost]) -> List[NamespaceUsageCost]:
+    by_namespace: Dict[str, List[WorkloadUsageCost]] = {}
+    for workload in workloads:
+        by_namespace.setdefault(workload.namespace, []).append(workload)
+
+    return [
+        NamespaceUsageCost(
+            namespace=namespace,
+            workloads=len(items),
+            cpu_usage_cores=round(sum(w.cpu_usage_cores for w in items), 4),
+            cpu_request_cores=round(sum(w.cpu_request_cores for w in items), 4),
+            memory_usage_gb=round(sum(w.memory_usage_gb for w in items), 4),
+            memory_request_gb=round(sum(w.memory_request_gb for w in items), 4),
+            usage_monthly_cost=sum_money(w.usage_monthly_cost for w in items),
+            request_monthly_cost=sum_money(w.request_monthly_cost for w in items),
+            waste_monthly_cost=sum_money(w.waste_monthly_cost for w in items),
+        )
+        for namespace, items in sorted(by_namespace.items())
+    ]
+
+
+def _utilization(used: float, requested: float) -> Optional[float]:
+    if requested <= 0:
+        return None
+    return round(used / requested, 4)
@@ -0,0 +1,36 @@
+"""
+Kcost Module
+
+This module is the SDK and kcost command line client of the estimator
+service. EstimatorClient calls every cost API with typed request and
+response bodies, retries and per-call deadlines; the command line
+estimates Terraform plans, Kubernetes manifests and resource lists,
+diffs estimates, compares instance options and refreshes price catalogs
+over the HTTP API, printing tables or JSON and failing CI builds on
+cost regressions.
+"""
+
+from .client import EstimatorClient, APIError, DeadlineExceeded, RetryPolicy, NO_RETRY, DEFAULT_URL
+from .types import EstimateRequest, EstimateResponse, DiffRequest, DiffResponse, Job, JobResponse, StreamEvent
+from .cli import main, build_parser, detect_input, check_costs, EXIT_COST_CHECK
+
+__all__ = [
+    "EstimatorClient",
+    "APIError",
+    "DeadlineExceeded",
+    "RetryPolicy",
+    "NO_RETRY",
+    "DEFAULT_URL",
+    "EstimateRequest",
+    "EstimateResponse",
+    "DiffRequest",
+    "DiffResponse",
+    "Job",
+    "JobResponse",
+    "StreamEvent",
+    "main",
+    "build_parser",
+    "detect_input",
+    "check_costs",
+    "EXIT_COST_CHECK",
+]
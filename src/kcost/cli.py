@@ -0,0 +1,381 @@
+"""
+kcost command line
+
+    kcost estimate -f plan.json --max-increase 500
+    kcost estimate -f deploy.yaml --region us-east-1 --node-type m5.xlarge -o json
+    kcost diff --base main.json --head pr.json --max-increase-percent 10 --markdown comment.md
+    kcost diff --base main.json --head pr.json --comment github
+    kcost compare --cpu 8 --mem 32 --geography us
+    kcost catalog refresh
+
+Run as `python -m src.kcost`. The service is reached at --url, KCOST_URL
+or http://localhost:8001, with the API key from --api-key or KCOST_API_KEY.
+
+`estimate -f` accepts a Terraform plan (`terraform show -json`), a
+Pulumi preview (`pulumi preview --json`), an estimate request (JSON with "resources"), a Kubernetes estimate request
+(JSON with "manifests") or Kubernetes manifests (YAML). Cost checks make
+CI builds fail on regressions: exit status 2 when the estimate exceeds
+--max-monthly-cost, or grows by more than --max-increase (USD/month) or
+--max-increase-percent over the plan's current cost or a --baseline
+estimate saved with `-o json`. `diff` has the service compare two
+estimates against its thresholds and exits with status 2 when the head
+fails them; with --comment it also posts the summary to the pull request,
+updating the comment of an earlier run. Errors exit with status 1.
+"""
+
+import argparse
+import json
+import os
+import sys
+from typing import Any, Dict, List, Optional, Tuple
+
+import requests
+
+from ..integrations import (
+    DEFAULT_COMMENT_KEY,
+    IntegrationError,
+    PLATFORMS,
+    build_commenter,
+    ci_defaults,
+    format_comment,
+    post_or_update,
+)
+from .client import APIError, DEFAULT_URL, EstimatorClient
+from .output import render_compare, render_estimate, render_kubernetes, render_refresh, render_terraform
+
+INPUT_AUTO = "auto"
+INPUT_ESTIMATE = "estimate"
+INPUT_TERRAFORM = "terraform"
+INPUT_PULUMI = "pulumi"
+INPUT_KUBERNETES = "kubernetes"
+INPUT_TYPES = [INPUT_AUTO, INPUT_ESTIMATE, INPUT_TERRAFORM, INPUT_PULUMI, INPUT_KUBERNETES]
+
+OUTPUT_TABLE = "table"
+OUTPUT_JSON = "json"
+
+# Exit status when a cost check fails
+EXIT_COST_CHECK = 2
+
+_RENDERERS = {
+    INPUT_ESTIMATE: render_estimate,
+    INPUT_TERRAFORM: render_terraform,
+    INPUT_PULUMI: render_terraform,
+    INPUT_KUBERNETES: render_kubernetes,
+}
+
+
+def _read_input(path: str) -> str:
+    if path == "-":
+        return sys.stdin.read()
+    with open(path, encoding="utf-8") as f:
+        return f.read()
+
+
+def detect_input(text: str) -> Tuple[str, Any]:
+    """
+    (input type, decoded content) of an estimate input
+
+    JSON documents are recognized by their keys; anything else is taken
+    as Kubernetes manifests.
+
+    Raises:
+        ValueError: If a JSON document is none of the known inputs
+    """
+    try:
+        document = json.loads(text)
+    except ValueError:
+        return INPUT_KUBERNETES, text
+    if isinstance(document, dict):
+        if "resource_changes" in document or "planned_values" in document:
+            return INPUT_TERRAFORM, document
+        if isinstance(document.get("steps"), list):
+            return INPUT_PULUMI, document
+        if "resources" in document:
+            return INPUT_ESTIMATE, document
+        if "manifests" in document:
+            return INPUT_KUBERNETES, document
+    raise ValueError("Input is not a Terraform plan, a Pulumi preview, an estimate request or Kubernetes manifests")
+
+
+def monthly_costs(response: Dict[str, Any]) -> Tuple[float, Optional[float]]:
+    """
+    (monthly cost, monthly cost before the change if known) of an estimate response
+
+    Raises:
+        ValueError: If the response is not an estimate
+    """
+    estimate = response.get("estimate") if isinstance(response, dict) else None
+    if not isinstance(estimate, dict):
+        raise ValueError("Not an estimate response")
+    if "after_monthly_cost" in estimate:
+        return estimate["after_monthly_cost"], estimate["before_monthly_cost"]
+    return estimate["monthly_cost"], None
+
+
+def check_costs(
+    monthly: float,
+    before: Optional[float],
+    max_monthly_cost: Optional[float] = None,
+    max_increase: Optional[float] = None,
+    max_increase_percent: Optional[float] = None,
+) -> List[str]:
+    """Failed cost checks, empty if all pass"""
+    failures = []
+    if max_monthly_cost is not None and monthly > max_monthly_cost:
+        failures.append(f"monthly cost {monthly:,.2f} exceeds {max_monthly_cost:,.2f}")
+    if before is not None:
+        increase = monthly - before
+        if max_increase is not None and increase > max_increase:
+            failures.append(f"monthly cost grows by {increase:,.2f}, more than {max_increase:,.2f}")
+        if max_increase_percent is not None and increase > 0:
+            percent = increase / before * 100 if before > 0 else float("inf")
+            if percent > max_increase_percent:
+                failures.append(f"monthly cost grows by {percent:.1f}%, more than {max_increase_percent:g}%")
+    elif max_increase is not None or max_increase_percent is not None:
+        failures.append("no cost to compare with: pass --baseline, a Terraform plan or a Pulumi preview")
+    return failures
+
+
+def _labels(values: Optional[List[str]]) -> Dict[str, str]:
+    labels = {}
+    for value in values or []:
+        key, sep, item = value.partition("=")
+        if not sep or not key:
+            raise ValueError(f"Invalid label '{value}', expected key=value")
+        labels[key] = item
+    return labels
+
+
+def _estimate(args, client: EstimatorClient) -> int:
+    text = _read_input(args.file)
+    if args.type == INPUT_AUTO:
+        input_type, content = detect_input(text)
+    else:
+        input_type, content = args.type, text if args.type == INPUT_KUBERNETES else json.loads(text)
+    labels = _labels(args.label)
+
+    if input_type == INPUT_TERRAFORM:
+        response = client.estimate_terraform(
+            content, region=args.region, hours=args.hours, project=args.project, labels=labels,
+            currency=args.currency,
+        )
+    elif input_type == INPUT_PULUMI:
+        response = client.estimate_pulumi(
+            content, region=args.region, hours=args.hours, project=args.project, labels=labels,
+            currency=args.currency,
+        )
+    else:
+        request = dict(content) if isinstance(content, dict) else {"manifests": content}
+        if input_type == INPUT_KUBERNETES:
+            for key, value in (("region", args.region), ("node_instance_type", args.node_type), ("hours", args.hours)):
+                if value is not None:
+                    request[key] = value
+            if "region" not in request or "node_instance_type" not in request:
+                raise ValueError("Kubernetes manifests need --region and --node-type")
+            response = client.estimate_kubernetes(_with_metadata(request, args.project, labels), currency=args.currency)
+        else:
+            response = client.estimate(_with_metadata(request, args.project, labels), currency=args.currency)
+
+    _print(args, response, _RENDERERS[input_type])
+
+    monthly, before = monthly_costs(response)
+    if args.baseline:
+        with open(args.baseline, encoding="utf-8") as f:
+            before, _ = monthly_costs(json.load(f))
+    failures = check_costs(monthly, before, args.max_monthly_cost, args.max_increase, args.max_increase_percent)
+    for failure in failures:
+        print(f"Cost check failed: {failure}", file=sys.stderr)
+    return EXIT_COST_CHECK if failures else 0
+
+
+def _with_metadata(request: Dict[str, Any], project: Optional[str], labels: Dict[str, str]) -> Dict[str, Any]:
+    if project is not None:
+        request["project"] = project
+    if labels:
+        request["labels"] = {**request.get("labels", {}), **labels}
+    return request
+
+
+def _diff_side(args, path: Optional[str], estimate_id: Optional[str], label: Optional[str]) -> Dict[str, Any]:
+    """Diff side of an input file or a recorded estimate"""
+    side: Dict[str, Any] = {"label": label}
+    if estimate_id:
+        side["estimate_id"] = estimate_id
+        return side
+    if not path:
+        raise ValueError("Each side needs a file or an estimate id")
+    input_type, content = detect_input(_read_input(path))
+    if input_type == INPUT_KUBERNETES and not isinstance(content, dict):
+        if not args.region or not args.node_type:
+            raise ValueError("Kubernetes manifests need --region and --node-type")
+        content = {"manifests": content, "region": args.region, "node_instance_type": args.node_type}
+    side[{INPUT_ESTIMATE: "resources"}.get(input_type, input_type)] = content
+    return side
+
+
+def _diff(args, client: EstimatorClient) -> int:
+    request: Dict[str, Any] = {
+        "base": _diff_side(args, args.base, args.base_id, args.base_label),
+        "head": _diff_side(args, args.head, args.head_id, args.head_label),
+        "thresholds": {
+            "max_increase_cost": args.max_increase,
+            "max_increase_percent": args.max_increase_percent,
+            "max_monthly_cost": args.max_monthly_cost,
+        },
+    }
+    if args.region:
+        request["region"] = args.region
+    if args.hours is not None:
+        request["hours"] = args.hours
+
+    response = client.diff(request, currency=args.currency)
+    diff = response["diff"]
+    if args.markdown:
+        with open(args.markdown, "w", encoding="utf-8") as f:
+            f.write(diff["markdown"])
+    _print(args, response, lambda r: r["diff"]["markdown"].rstrip())
+    if args.comment:
+        _post_comment(args, diff)
+    return 0 if diff["passed"] else EXIT_COST_CHECK
+
+
+def _post_comment(args, diff: Dict[str, Any]) -> None:
+    """Post or update the diff's comment on the CI build's pull request"""
+    defaults = ci_defaults(args.comment)
+    repository = args.repo or defaults["repository"]
+    number = args.pr or defaults["number"]
+    token = args.comment_token or defaults["token"]
+    if not repository or not number or not token:
+        raise ValueError(f"Commenting on {args.comment} needs --repo, --pr and --comment-token outside CI")
+    commenter = build_commenter(
+        args.comment, repository, token,
+        api_url=args.comment_api_url or defaults["api_url"], web_url=defaults["web_url"], timeout=args.timeout,
+    )
+    result = post_or_update(commenter, int(number), format_comment(diff, args.comment_key), args.comment_key)
+    link = f": {result.url}" if result.url else ""
+    print(f"Comment {result.action} on {repository}#{number}{link}", file=sys.stderr)
+
+
+def _compare(args, client: EstimatorClient) -> int:
+    request: Dict[str, Any] = {
+        "vcpus": args.cpu,
+        "memory_gb": args.mem,
+        "gpus": args.gpus,
+        "count": args.count,
+        "pricing_model": args.pricing_model,
+        "include_burstable": args.include_burstable,
+        "max_options_per_provider": args.limit,
+    }
+    if args.hours is not None:
+        request["hours"] = args.hours
+    if args.arch:
+        request["arch"] = args.arch
+    if args.provider:
+        request["providers"] = args.provider
+    if args.geography:
+        request["geography"] = args.geography
+    regions: Dict[str, List[str]] = {}
+    for value in args.region or []:
+        provider, sep, region = value.partition(":")
+        if not sep or not provider or not region:
+            raise ValueError(f"Invalid region '{value}', expected provider:region")
+        regions.setdefault(provider, []).append(region)
+    if regions:
+        request["regions"] = regions
+
+    _print(args, client.compare(request, currency=args.currency), render_compare)
+    return 0
+
+
+def _catalog_refresh(args, client: EstimatorClient) -> int:
+    _print(args, client.refresh_catalogs(), render_refresh)
+    return 0
+
+
+def _print(args, response: Dict[str, Any], render) -> None:
+    if args.output == OUTPUT_JSON:
+        print(json.dumps(response, indent=2))
+    else:
+        print(render(response))
+
+
+def build_parser() -> argparse.ArgumentParser:
+    parser = argparse.ArgumentParser(prog="kcost", description="Client of the kcloud cost estimator service")
+    parser.add_argument("--url", default=os.environ.get("KCOST_URL", DEFAULT_URL), help="Service URL (KCOST_URL)")
+    parser.add_argument("--api-key", default=os.environ.get("KCOST_API_KEY"), help="API key (KCOST_API_KEY)")
+    parser.add_argument("--tenant", default=os.environ.get("KCOST_TENANT"), help="Tenant acted for (KCOST_TENANT)")
+    parser.add_argument("--timeout", type=float, default=120, help="Request timeout in seconds")
+    parser.add_argument("-o", "--output", choices=[OUTPUT_TABLE, OUTPUT_JSON], default=OUTPUT_TABLE)
+    parser.add_argument("--currency", help="Output currency, e.g. EUR, KRW, JPY (default USD)")
+    commands = parser.add_subparsers(dest="command", required=True)
+
+    estimate = commands.add_parser("estimate", help="Estimate a Terraform plan, manifests or resources")
+    estimate.add_argument("-f", "--file", required=True, help="Input file, - for stdin")
+    estimate.add_argument("--type", choices=INPUT_TYPES, default=INPUT_AUTO, help="Input type (default: detected)")
+    estimate.add_argument("--region", help="Fallback region (Terraform) or cluster region (Kubernetes)")
+    estimate.add_argument("--node-type", help="Node instance type of Kubernetes manifests")
+    estimate.add_argument("--hours", type=float, help="Running hours per month")
+    estimate.add_argument("--project", help="Project the estimate is recorded under")
+    estimate.add_argument("--label", action="append", help="key=value kept with the estimate (repeatable)")
+    estimate.add_argument("--max-monthly-cost", type=float, help="Fail above this monthly cost")
+    estimate.add_argument("--max-increase", type=float, help="Fail if the monthly cost grows by more")
+    estimate.add_argument("--max-increase-percent", type=float, help="Fail if the monthly cost grows by more percent")
+    estimate.add_argument("--baseline", help="Estimate saved with -o json the increase is measured from")
+    estimate.set_defaults(handler=_estimate)
+
+    diff = commands.add_parser("diff", help="Diff two estimates, e.g. of the main and a PR branch")
+    diff.add_argument("--base", help="Base input file, e.g. the main branch's plan")
+    diff.add_argument("--base-id", help="Recorded estimate used as the base")
+    diff.add_argument("--base-label", default="main", help="Base name in the summary")
+    diff.add_argument("--head", help="Head input file, e.g. the pull request's plan")
+    diff.add_argument("--head-id", help="Recorded estimate used as the head")
+    diff.add_argument("--head-label", default="PR", help="Head name in the summary")
+    diff.add_argument("--region", help="Fallback region (Terraform) or cluster region (Kubernetes)")
+    diff.add_argument("--node-type", help="Node instance type of Kubernetes manifests")
+    diff.add_argument("--hours", type=float, help="Running hours per month of Terraform plans")
+    diff.add_argument("--max-monthly-cost", type=float, help="Fail above this monthly cost (default: service's)")
+    diff.add_argument("--max-increase", type=float, help="Fail if the monthly cost grows by more")
+    diff.add_argument("--max-increase-percent", type=float, help="Fail if the monthly cost grows by more percent")
+    diff.add_argument("--markdown", help="Write the markdown summary to this file, e.g. for a PR comment")
+    diff.add_argument("--comment", choices=PLATFORMS, help="Post the summary as a pull request comment")
+    diff.add_argument("--repo", help="owner/name or GitLab project (default: GITHUB_REPOSITORY or CI_PROJECT_ID)")
+    diff.add_argument("--pr", type=int, help="Pull or merge request (default: from GITHUB_REF or CI_MERGE_REQUEST_IID)")
+    diff.add_argument("--comment-token", help="API token (default: GITHUB_TOKEN or GITLAB_TOKEN)")
+    diff.add_argument("--comment-api-url", help="API URL (default: GITHUB_API_URL, CI_API_V4_URL or the public API)")
+    diff.add_argument("--comment-key", default=DEFAULT_COMMENT_KEY, help="Comment updated, one per key")
+    diff.set_defaults(handler=_diff)
+
+    compare = commands.add_parser("compare", help="Compare instance options of a workload shape across providers")
+    compare.add_argument("--cpu", type=float, required=True, help="Minimum vCPUs per instance")
+    compare.add_argument("--mem", type=float, required=True, help="Minimum memory per instance (GiB)")
+    compare.add_argument("--gpus", type=int, default=0)
+    compare.add_argument("--arch", choices=["x86_64", "arm64"])
+    compare.add_argument("--count", type=int, default=1)
+    compare.add_argument("--hours", type=float, help="Running hours per month")
+    compare.add_argument("--pricing-model", default="on_demand", help="on_demand or spot")
+    compare.add_argument("--provider", action="append", help="Provider compared (repeatable, default all)")
+    compare.add_argument("--region", action="append", help="provider:region allowed (repeatable)")
+    compare.add_argument("--geography", help="Region group, e.g. us, eu, kr")
+    compare.add_argument("--include-burstable", action="store_true")
+    compare.add_argument("--limit", type=int, default=3, help="Options per provider")
+    compare.set_defaults(handler=_compare)
+
+    catalog = commands.add_parser("catalog", help="Price catalogs")
+    catalog_commands = catalog.add_subparsers(dest="catalog_command", required=True)
+    refresh = catalog_commands.add_parser("refresh", help="Re-download the catalogs of all enabled providers")
+    refresh.set_defaults(handler=_catalog_refresh)
+
+    return parser
+
+
+def main(argv: Optional[List[str]] = None, client: Optional[EstimatorClient] = None) -> int:
+    """Run the kcost command line"""
+    args = build_parser().parse_args(argv)
+    if client is None:
+        client = EstimatorClient(args.url, api_key=args.api_key, tenant=args.tenant, timeout=args.timeout)
+
+    try:
+        return args.handler(args, client)
+    except (APIError, IntegrationError, OSError, ValueError, requests.RequestException) as e:
+        print(f"error: {e}", file=sys.stderr)
+        return 1
@@ -0,0 +1,761 @@
+"""
+HTTP client of the estimator API
+
+EstimatorClient is the SDK other services embed to call the estimator:
+one method per endpoint of the cost APIs, with the JSON bodies of
+src.kcost.types. Requests the service could not take (connection refused,
+429, 503) are retried with exponential backoff for every method, honoring
+Retry-After; read timeouts, 502 and 504 only for GET, PUT and DELETE,
+which are safe to repeat. with_options() binds a per-call timeout, a
+deadline bounding all attempts and the caller's request ID, so a caller's
+own deadline and trace carry over:
+
+    client = EstimatorClient("http://kcloud-cost-estimator:8001", api_key=key)
+    scoped = client.with_options(deadline=5, request_id=request_id)
+    cost = scoped.estimate({"resources": [{"instance_type": "m5.large", "region": "us-east-1"}]})
+"""
+
+import copy
+import json
+import random
+import time
+from typing import Any, Callable, Dict, FrozenSet, Iterator, NamedTuple, Optional
+from urllib.parse import quote
+
+import requests
+
+from .types import DiffRequest, DiffResponse, EstimateRequest, EstimateResponse, JobResponse, StreamEvent
+
+DEFAULT_URL = "http://localhost:8001"
+
+# Headers read by the service's authenticator, tenancy middleware and request
+# logging; the client does not import them so it runs without the server's dependencies
+API_KEY_HEADER = "X-API-Key"
+TENANT_HEADER = "X-Tenant-ID"
+REQUEST_ID_HEADER = "X-Request-ID"
+
+# Methods repeated after failures that may have reached the service
+IDEMPOTENT_METHODS = frozenset({"GET", "HEAD", "PUT", "DELETE"})
+# Statuses of requests the service did not process
+UNPROCESSED_STATUSES = frozenset({429, 503})
+
+# Job states after which a job does not change
+FINISHED_JOB_STATES = ("succeeded", "failed", "dead_letter")
+PENDING_REVIEW = "pending"
+
+
+class APIError(Exception):
+    """Raised when the API answers with an error status"""
+
+    def __init__(self, status_code: int, detail: str):
+        super().__init__(f"HTTP {status_code}: {detail}")
+        self.status_code = status_code
+        self.detail = detail
+
+
+class DeadlineExceeded(TimeoutError):
+    """Raised when a call's deadline passes before the service answers"""
+
+
+class RetryPolicy(NamedTuple):
+    """How failed requests are retried"""
+
+    attempts: int = 3
+    backoff: float = 0.5
+    max_backoff: float = 10.0
+    statuses: FrozenSet[int] = frozenset({429, 502, 503, 504})
+
+    def delay(self, attempt: int) -> float:
+        """Seconds before the retry after an attempt (1-based), with jitter"""
+        return min(self.max_backoff, self.backoff * 2 ** (attempt - 1)) * random.uniform(0.5, 1.0)
+
+
+NO_RETRY = RetryPolicy(attempts=1)
+
+
+class CallOptions(NamedTuple):
+    """Per-call settings bound with EstimatorClient.with_options()"""
+
+    timeout: Optional[float] = None
+    deadline: Optional[float] = None  # clock() value
+    headers: Dict[str, str] = {}
+
+
+class EstimatorClient:
+    """Calls the estimator service's HTTP API"""
+
+    def __init__(
+        self,
+        url: str = DEFAULT_URL,
+        api_key: Optional[str] = None,
+        tenant: Optional[str] = None,
+        timeout: float = 120,
+        session: Optional[requests.Session] = None,
+        retry: RetryPolicy = RetryPolicy(),
+        sleep: Callable[[float], None] = time.sleep,
+        clock: Callable[[], float] = time.monotonic,
+    ):
+        """
+        Initialize client
+
+        Args:
+            url: Base URL of the service
+            api_key: API key sent in the X-API-Key header
+            tenant: Tenant acted for (X-Tenant-ID), for keys allowed to choose one
+            timeout: Request timeout in seconds
+            session: HTTP session, a new one if not provided
+            retry: Retries of failed requests, NO_RETRY to fail at once
+            sleep: Waits between attempts
+            clock: Monotonic time deadlines are measured with
+        """
+        self.url = url.rstrip("/")
+        self.timeout = timeout
+        self.session = session or requests.Session()
+        self.retry = retry
+        self.sleep = sleep
+        self.clock = clock
+        self.options = CallOptions()
+        if api_key:
+            self.session.headers[API_KEY_HEADER] = api_key
+        if tenant:
+            self.session.headers[TENANT_HEADER] = tenant
+
+    def with_options(
+        self,
+        timeout: Optional[float] = None,
+        deadline: Optional[float] = None,
+        request_id: Optional[str] = None,
+        tenant: Optional[str] = None,
+        headers: Optional[Dict[str, str]] = None,
+    ) -> "EstimatorClient":
+        """
+        Client sharing this one's session whose calls use the options
+
+        Args:
+            timeout: Timeout of each attempt in seconds
+            deadline: Seconds from now all attempts of a call must finish in
+            request_id: Request ID (X-Request-ID) the service logs the calls under
+            tenant: Tenant acted for, instead of the client's
+            headers: Other headers sent with the calls
+        """
+        bound = dict(self.options.headers)
+        bound.update(headers or {})
+        if request_id:
+            bound[REQUEST_ID_HEADER] = request_id
+        if tenant:
+            bound[TENANT_HEADER] = tenant
+        scoped = copy.copy(self)
+        scoped.options = CallOptions(
+            timeout=timeout if timeout is not None else self.options.timeout,
+            deadline=self.clock() + deadline if deadline is not None else self.options.deadline,
+            headers=bound,
+        )
+        return scoped
+
+    # Service
+
+    def info(self) -> Dict[str, Any]:
+        """GET /info: service version and configuration"""
+        return self._request("GET", "/info")
+
+    def ready(self) -> Dict[str, Any]:
+        """GET /readyz: readiness checks"""
+        return self._request("GET", "/readyz")
+
+    # Estimation
+
+    def estimate(
+        self, request: EstimateRequest, currency: Optional[str] = None, explain: bool = False
+    ) -> EstimateResponse:
+        """POST /estimate with an estimate request; explain=True is a dry run explaining each line item"""
+        params = _params(currency=currency, explain="true" if explain else None)
+        return self._request("POST", "/estimate", json=request, params=params)
+
+    def estimate_batch(self, request: Dict[str, Any], currency: Optional[str] = None) -> Dict[str, Any]:
+        """POST /estimate/batch with independent estimate items"""
+        return self._request("POST", "/estimate/batch", json=request, params=_params(currency=currency))
+
+    def estimate_stream(self, request: Dict[str, Any], currency: Optional[str] = None) -> Iterator[StreamEvent]:
+        """
+        POST /estimate/stream: the events of a batch or cluster estimate as they are priced
+
+        The stream is not retried once it started; it ends with a result or an error event.
+        """
+        response = self._send(
+            "POST", "/estimate/stream", json=request, params=_params(currency=currency), stream=True
+        )
+        # Event streams are UTF-8 (text/event-stream carries no charset)
+        response.encoding = response.encoding or "utf-8"
+        with response:
+            event, data = "message", []
+            for line in response.iter_lines(decode_unicode=True):
+                if not line:
+                    if data:
+                        yield {"event": event, "data": json.loads("\n".join(data))}
+                    event, data = "message", []
+                elif line.startswith("event:"):
+                    event = line[len("event:"):].strip()
+                elif line.startswith("data:"):
+                    data.append(line[len("data:"):].strip())
+
+    def estimate_terraform(
+        self,
+        plan: Dict[str, Any],
+        region: Optional[str] = None,
+        hours: Optional[float] = None,
+        project: Optional[str] = None,
+        labels: Optional[Dict[str, str]] = None,
+        currency: Optional[str] = None,
+    ) -> Dict[str, Any]:
+        """POST /estimate/terraform with a `terraform show -json` plan"""
+        params = _params(region=region, hours=hours, project=project, currency=currency)
+        params["label"] = [f"{key}={value}" for key, value in (labels or {}).items()]
+        return self._request("POST", "/estimate/terraform", json=plan, params=params)
+
+    def estimate_pulumi(
+        self,
+        preview: Dict[str, Any],
+        region: Optional[str] = None,
+        hours: Optional[float] = None,
+        project: Optional[str] = None,
+        labels: Optional[Dict[str, str]] = None,
+        currency: Optional[str] = None,
+    ) -> Dict[str, Any]:
+        """POST /estimate/pulumi with a `pulumi preview --json` preview"""
+        params = _params(region=region, hours=hours, project=project, currency=currency)
+        params["label"] = [f"{key}={value}" for key, value in (labels or {}).items()]
+        return self._request("POST", "/estimate/pulumi", json=preview, params=params)
+
+    def estimate_cloudformation(self, request: Dict[str, Any], currency: Optional[str] = None) -> Dict[str, Any]:
+        """POST /estimate/cloudformation with a template or change set"""
+        return self._request("POST", "/estimate/cloudformation", json=request, params=_params(currency=currency))
+
+    def estimate_crossplane(self, request: Dict[str, Any], currency: Optional[str] = None) -> Dict[str, Any]:
+        """POST /estimate/crossplane with claim, composite or managed resource manifests"""
+        return self._request("POST", "/estimate/crossplane", json=request, params=_params(currency=currency))
+
+    def estimate_kubernetes(self, request: Dict[str, Any], currency: Optional[str] = None) -> Dict[str, Any]:
+        """POST /estimate/kubernetes with manifests"""
+        return self._request("POST", "/estimate/kubernetes", json=request, params=_params(currency=currency))
+
+    def estimate_helm(
+        self,
+        form: Dict[str, str],
+        chart: Optional[bytes] = None,
+        values: Optional[str] = None,
+        currency: Optional[str] = None,
+    ) -> Dict[str, Any]:
+        """
+        POST /estimate/helm with a chart archive or a chart reference
+
+        Args:
+            form: Form fields, e.g. region, node_instance_type, chart_ref, repo_url, version
+            chart: Packaged chart (.tgz), instead of chart_ref
+            values: values.yaml content
+        """
+        files = {"chart": ("chart.tgz", chart, "application/gzip")} if chart is not None else None
+        data = dict(form, **({"values": values} if values is not None else {}))
+        return self._request("POST", "/estimate/helm", data=data, files=files, params=_params(currency=currency))
+
+    def estimate_cluster(
+        self, request: Optional[Dict[str, Any]] = None, currency: Optional[str] = None
+    ) -> Dict[str, Any]:
+        """POST /estimate/cluster: the cluster's current monthly cost (operators only)"""
+        return self._request("POST", "/estimate/cluster", json=request or {}, params=_params(currency=currency))
+
+    def estimate_kubernetes_usage(self, **params: Any) -> Dict[str, Any]:
+        """GET /estimate/kubernetes/usage: the cluster priced at measured usage, e.g. namespace="web", window="7d\""""
+        return self._request("GET", "/estimate/kubernetes/usage", params=_params(**params))
+
+    def terraform_resource_types(self) -> Dict[str, Any]:
+        """GET /estimate/terraform/resource-types: Terraform resource types that are priced"""
+        return self._request("GET", "/estimate/terraform/resource-types")
+
+    def compare(self, request: Dict[str, Any], currency: Optional[str] = None) -> Dict[str, Any]:
+        """POST /compare with a workload shape"""
+        return self._request("POST", "/compare", json=request, params=_params(currency=currency))
+
+    def diff(self, request: DiffRequest, currency: Optional[str] = None) -> DiffResponse:
+        """POST /estimate/diff with a base and a head estimate"""
+        return self._request("POST", "/estimate/diff", json=request, params=_params(currency=currency))
+
+    def analyze_region_migration(self, request: Dict[str, Any], currency: Optional[str] = None) -> Dict[str, Any]:
+        """POST /analyze/region-migration: cost delta of moving resources to another region"""
+        return self._request("POST", "/analyze/region-migration", json=request, params=_params(currency=currency))
+
+    # History
+
+    def list_estimates(self, **params: Any) -> Dict[str, Any]:
+        """GET /estimates, filtered e.g. by project="shop", label=["ci=1"], limit=20"""
+        return self._request("GET", "/estimates", params=_params(**params))
+
+    def get_estimate(self, estimate_id: str, currency: Optional[str] = None) -> Dict[str, Any]:
+        """GET /estimates/{estimate_id}"""
+        return self._request("GET", f"/estimates/{_path(estimate_id)}", params=_params(currency=currency))
+
+    def rerun_estimate(self, estimate_id: str, **params: Any) -> Dict[str, Any]:
+        """POST /estimates/{estimate_id}/rerun: a recorded estimate priced again"""
+        return self._request("POST", f"/estimates/{_path(estimate_id)}/rerun", params=_params(**params))
+
+    def estimate_review(self, estimate_id: str) -> Dict[str, Any]:
+        """GET /estimates/{estimate_id}/review: approval status, comments and decisions"""
+        return self._request("GET", f"/estimates/{_path(estimate_id)}/review")
+
+    def comment_estimate(self, estimate_id: str, comment: str) -> Dict[str, Any]:
+        """POST /estimates/{estimate_id}/comments"""
+        return self._request("POST", f"/estimates/{_path(estimate_id)}/comments", json={"comment": comment})
+
+    def approve_estimate(self, estimate_id: str, comment: str = "") -> Dict[str, Any]:
+        """POST /estimates/{estimate_id}/approve (admin scope)"""
+        return self._request("POST", f"/estimates/{_path(estimate_id)}/approve", json={"comment": comment})
+
+    def reject_estimate(self, estimate_id: str, comment: str = "") -> Dict[str, Any]:
+        """POST /estimates/{estimate_id}/reject (admin scope)"""
+        return self._request("POST", f"/estimates/{_path(estimate_id)}/reject", json={"comment": comment})
+
+    def wait_approval(self, estimate_id: str, interval: float = 10.0) -> Dict[str, Any]:
+        """
+        Poll an estimate's review until it is approved or rejected
+
+        Raises:
+            DeadlineExceeded: If the client's deadline passes first
+        """
+        while True:
+            response = self.estimate_review(estimate_id)
+            if response["review"].get("status") != PENDING_REVIEW:
+                return response
+            self._wait(interval)
+
+    # Jobs
+
+    def submit_job(self, kind: str, request: Dict[str, Any]) -> JobResponse:
+        """POST /jobs: queue an estimate of a large input"""
+        return self._request("POST", "/jobs", json={"kind": kind, "request": request})
+
+    def get_job(self, job_id: str) -> JobResponse:
+        """GET /jobs/{job_id}"""
+        return self._request("GET", f"/jobs/{_path(job_id)}")
+
+    def list_jobs(self, **params: Any) -> Dict[str, Any]:
+        """GET /jobs, filtered e.g. by state="running\""""
+        return self._request("GET", "/jobs", params=_params(**params))
+
+    def retry_job(self, job_id: str) -> JobResponse:
+        """POST /jobs/{job_id}/retry: queue a failed or dead-lettered job again"""
+        return self._request("POST", f"/jobs/{_path(job_id)}/retry")
+
+    def wait_job(self, job_id: str, interval: float = 2.0) -> JobResponse:
+        """
+        Poll a job until it succeeded, failed or was dead-lettered
+
+        Raises:
+            DeadlineExceeded: If the client's deadline passes first
+        """
+        while True:
+            response = self.get_job(job_id)
+            if response["job"].get("state") in FINISHED_JOB_STATES:
+                return response
+            self._wait(interval)
+
+    # Pricing and catalogs
+
+    def pricing_providers(self) -> Dict[str, Any]:
+        """GET /pricing/providers"""
+        return self._request("GET", "/pricing/providers")
+
+    def accelerators(self, **params: Any) -> Dict[str, Any]:
+        """GET /pricing/accelerators"""
+        return self._request("GET", "/pricing/accelerators", params=_params(**params))
+
+    def license_rates(self) -> Dict[str, Any]:
+        """GET /pricing/licenses"""
+        return self._request("GET", "/pricing/licenses")
+
+    def openstack_rates(self) -> Dict[str, Any]:
+        """GET /pricing/openstack/rates"""
+        return self._request("GET", "/pricing/openstack/rates")
+
+    def set_openstack_rates(self, rates: Dict[str, Any]) -> Dict[str, Any]:
+        """PUT /pricing/openstack/rates"""
+        return self._request("PUT", "/pricing/openstack/rates", json=rates)
+
+    def reset_openstack_rates(self) -> Dict[str, Any]:
+        """DELETE /pricing/openstack/rates"""
+        return self._request("DELETE", "/pricing/openstack/rates")
+
+    def refresh_catalogs(self) -> Dict[str, Any]:
+        """POST /catalog/refresh"""
+        return self._request("POST", "/catalog/refresh")
+
+    def catalog_status(self) -> Dict[str, Any]:
+        """GET /catalog/status"""
+        return self._request("GET", "/catalog/status")
+
+    def catalog_providers(self) -> Dict[str, Any]:
+        """GET /catalog/providers"""
+        return self._request("GET", "/catalog/providers")
+
+    def regions(self, provider: str, **params: Any) -> Dict[str, Any]:
+        """GET /catalog/{provider}/regions"""
+        return self._request("GET", f"/catalog/{_path(provider)}/regions", params=_params(**params))
+
+    def instance_types(self, provider: str, **params: Any) -> Dict[str, Any]:
+        """GET /catalog/{provider}/instance-types, filtered e.g. by region="us-east-1", min_vcpus=4"""
+        return self._request("GET", f"/catalog/{_path(provider)}/instance-types", params=_params(**params))
+
+    def catalog_changes(self, **params: Any) -> Dict[str, Any]:
+        """GET /catalog/changes"""
+        return self._request("GET", "/catalog/changes", params=_params(**params))
+
+    def upload_price_sheet(
+        self, content: bytes, filename: str = "prices.csv", **form: str
+    ) -> Dict[str, Any]:
+        """POST /catalog/custom with a CSV or JSON price list, form e.g. mode="merge\""""
+        files = {"file": (filename, content)}
+        return self._request("POST", "/catalog/custom", data=form, files=files)
+
+    def price_sheet(self) -> Dict[str, Any]:
+        """GET /catalog/custom"""
+        return self._request("GET", "/catalog/custom")
+
+    def delete_price_sheet(self) -> Dict[str, Any]:
+        """DELETE /catalog/custom"""
+        return self._request("DELETE", "/catalog/custom")
+
+    # Discount rules, budgets, scenarios, report schedules and webhooks
+
+    def create_discount(self, rule: Dict[str, Any]) -> Dict[str, Any]:
+        """POST /discounts"""
+        return self._request("POST", "/discounts", json=rule)
+
+    def list_discounts(self) -> Dict[str, Any]:
+        """GET /discounts"""
+        return self._request("GET", "/discounts")
+
+    def get_discount(self, rule_id: str) -> Dict[str, Any]:
+        """GET /discounts/{rule_id}"""
+        return self._request("GET", f"/discounts/{_path(rule_id)}")
+
+    def update_discount(self, rule_id: str, rule: Dict[str, Any]) -> Dict[str, Any]:
+        """PUT /discounts/{rule_id}"""
+        return self._request("PUT", f"/discounts/{_path(rule_id)}", json=rule)
+
+    def delete_discount(self, rule_id: str) -> Dict[str, Any]:
+        """DELETE /discounts/{rule_id}"""
+        return self._request("DELETE", f"/discounts/{_path(rule_id)}")
+
+    def list_profiles(self) -> Dict[str, Any]:
+        """GET /profiles, built-in ones included"""
+        return self._request("GET", "/profiles")
+
+    def get_profile(self, name: str) -> Dict[str, Any]:
+        """GET /profiles/{name}"""
+        return self._request("GET", f"/profiles/{_path(name)}")
+
+    def save_profile(self, name: str, profile: Dict[str, Any]) -> Dict[str, Any]:
+        """PUT /profiles/{name}"""
+        return self._request("PUT", f"/profiles/{_path(name)}", json=profile)
+
+    def delete_profile(self, name: str) -> Dict[str, Any]:
+        """DELETE /profiles/{name}"""
+        return self._request("DELETE", f"/profiles/{_path(name)}")
+
+    def create_budget(self, budget: Dict[str, Any]) -> Dict[str, Any]:
+        """POST /budgets"""
+        return self._request("POST", "/budgets", json=budget)
+
+    def list_budgets(self, **params: Any) -> Dict[str, Any]:
+        """GET /budgets with each budget's status"""
+        return self._request("GET", "/budgets", params=_params(**params))
+
+    def get_budget(self, budget_id: str, **params: Any) -> Dict[str, Any]:
+        """GET /budgets/{budget_id}"""
+        return self._request("GET", f"/budgets/{_path(budget_id)}", params=_params(**params))
+
+    def update_budget(self, budget_id: str, budget: Dict[str, Any]) -> Dict[str, Any]:
+        """PUT /budgets/{budget_id}"""
+        return self._request("PUT", f"/budgets/{_path(budget_id)}", json=budget)
+
+    def delete_budget(self, budget_id: str) -> Dict[str, Any]:
+        """DELETE /budgets/{budget_id}"""
+        return self._request("DELETE", f"/budgets/{_path(budget_id)}")
+
+    def record_actuals(self, batch: Dict[str, Any]) -> Dict[str, Any]:
+        """POST /actuals with daily actual costs"""
+        return self._request("POST", "/actuals", json=batch)
+
+    def create_scenario(self, scenario: Dict[str, Any]) -> Dict[str, Any]:
+        """POST /scenarios"""
+        return self._request("POST", "/scenarios", json=scenario)
+
+    def list_scenarios(self) -> Dict[str, Any]:
+        """GET /scenarios"""
+        return self._request("GET", "/scenarios")
+
+    def get_scenario(self, scenario_id: str) -> Dict[str, Any]:
+        """GET /scenarios/{scenario_id}"""
+        return self._request("GET", f"/scenarios/{_path(scenario_id)}")
+
+    def update_scenario(self, scenario_id: str, scenario: Dict[str, Any]) -> Dict[str, Any]:
+        """PUT /scenarios/{scenario_id}"""
+        return self._request("PUT", f"/scenarios/{_path(scenario_id)}", json=scenario)
+
+    def delete_scenario(self, scenario_id: str) -> Dict[str, Any]:
+        """DELETE /scenarios/{scenario_id}"""
+        return self._request("DELETE", f"/scenarios/{_path(scenario_id)}")
+
+    def compare_scenario(self, scenario_id: str, currency: Optional[str] = None) -> Dict[str, Any]:
+        """GET /scenarios/{scenario_id}/compare: the cost matrix of a scenario's variations"""
+        return self._request("GET", f"/scenarios/{_path(scenario_id)}/compare", params=_params(currency=currency))
+
+    def create_report_schedule(self, schedule: Dict[str, Any]) -> Dict[str, Any]:
+        """POST /reports/schedules"""
+        return self._request("POST", "/reports/schedules", json=schedule)
+
+    def list_report_schedules(self) -> Dict[str, Any]:
+        """GET /reports/schedules"""
+        return self._request("GET", "/reports/schedules")
+
+    def get_report_schedule(self, schedule_id: str) -> Dict[str, Any]:
+        """GET /reports/schedules/{schedule_id}"""
+        return self._request("GET", f"/reports/schedules/{_path(schedule_id)}")
+
+    def update_report_schedule(self, schedule_id: str, schedule: Dict[str, Any]) -> Dict[str, Any]:
+        """PUT /reports/schedules/{schedule_id}"""
+        return self._request("PUT", f"/reports/schedules/{_path(schedule_id)}", json=schedule)
+
+    def delete_report_schedule(self, schedule_id: str) -> Dict[str, Any]:
+        """DELETE /reports/schedules/{schedule_id}"""
+        return self._request("DELETE", f"/reports/schedules/{_path(schedule_id)}")
+
+    def send_report(self, schedule_id: str) -> Dict[str, Any]:
+        """POST /reports/schedules/{schedule_id}/send: mail a scheduled report now"""
+        return self._request("POST", f"/reports/schedules/{_path(schedule_id)}/send")
+
+    def create_webhook(self, webhook: Dict[str, Any]) -> Dict[str, Any]:
+        """POST /webhooks"""
+        return self._request("POST", "/webhooks", json=webhook)
+
+    def list_webhooks(self) -> Dict[str, Any]:
+        """GET /webhooks"""
+        return self._request("GET", "/webhooks")
+
+    def get_webhook(self, webhook_id: str) -> Dict[str, Any]:
+        """GET /webhooks/{webhook_id}"""
+        return self._request("GET", f"/webhooks/{_path(webhook_id)}")
+
+    def update_webhook(self, webhook_id: str, webhook: Dict[str, Any]) -> Dict[str, Any]:
+        """PUT /webhooks/{webhook_id}"""
+        return self._request("PUT", f"/webhooks/{_path(webhook_id)}", json=webhook)
+
+    def delete_webhook(self, webhook_id: str) -> Dict[str, Any]:
+        """DELETE /webhooks/{webhook_id}"""
+        return self._request("DELETE", f"/webhooks/{_path(webhook_id)}")
+
+    def test_webhook(self, webhook_id: str) -> Dict[str, Any]:
+        """POST /webhooks/{webhook_id}/test: deliver a test event"""
+        return self._request("POST", f"/webhooks/{_path(webhook_id)}/test")
+
+    # Inventory and recommendations
+
+    def record_inventory(self, batch: Dict[str, Any]) -> Dict[str, Any]:
+        """POST /inventory with a collector's snapshot"""
+        return self._request("POST", "/inventory", json=batch)
+
+    def rightsizing(self, **params: Any) -> Dict[str, Any]:
+        """GET /recommendations/rightsizing"""
+        return self._request("GET", "/recommendations/rightsizing", params=_params(**params))
+
+    def node_pool_recommendations(self, **params: Any) -> Dict[str, Any]:
+        """GET /recommendations/nodepools"""
+        return self._request("GET", "/recommendations/nodepools", params=_params(**params))
+
+    def arm_recommendations(self, **params: Any) -> Dict[str, Any]:
+        """GET /recommendations/arm"""
+        return self._request("GET", "/recommendations/arm", params=_params(**params))
+
+    def commitment_recommendations(self, **params: Any) -> Dict[str, Any]:
+        """GET /recommendations/commitments"""
+        return self._request("GET", "/recommendations/commitments", params=_params(**params))
+
+    def idle_resources(self, **params: Any) -> Dict[str, Any]:
+        """GET /recommendations/idle, e.g. kind="volume\""""
+        return self._request("GET", "/recommendations/idle", params=_params(**params))
+
+    # Reports
+
+    def accuracy_report(self, **params: Any) -> Dict[str, Any]:
+        """GET /reports/accuracy, e.g. from_="2026-01", to="2026-06\""""
+        return self._request("GET", "/reports/accuracy", params=_params(**params))
+
+    def chargeback_report(self, period: str, **params: Any) -> Dict[str, Any]:
+        """GET /reports/chargeback/{period} as JSON"""
+        return self._request("GET", f"/reports/chargeback/{_path(period)}", params=_params(**params))
+
+    def focus_export(self, **params: Any) -> bytes:
+        """GET /reports/focus: the FOCUS dataset (CSV, or Parquet with format="parquet")"""
+        return self._send("GET", "/reports/focus", params=_params(**params)).content
+
+    def forecast(self, **params: Any) -> Dict[str, Any]:
+        """GET /forecast"""
+        return self._request("GET", "/forecast", params=_params(**params))
+
+    def anomalies(self, **params: Any) -> Dict[str, Any]:
+        """GET /anomalies"""
+        return self._request("GET", "/anomalies", params=_params(**params))
+
+    def allocation(self, **params: Any) -> Dict[str, Any]:
+        """GET /allocation"""
+        return self._request("GET", "/allocation", params=_params(**params))
+
+    # Access control and audit
+
+    def whoami(self) -> Dict[str, Any]:
+        """GET /iam/me: the caller's identity and role"""
+        return self._request("GET", "/iam/me")
+
+    def list_roles(self) -> Dict[str, Any]:
+        """GET /iam/roles"""
+        return self._request("GET", "/iam/roles")
+
+    def assign_role(self, subject: str, assignment: Dict[str, Any]) -> Dict[str, Any]:
+        """PUT /iam/roles/{subject}"""
+        return self._request("PUT", f"/iam/roles/{_path(subject)}", json=assignment)
+
+    def remove_role(self, subject: str) -> Dict[str, Any]:
+        """DELETE /iam/roles/{subject}"""
+        return self._request("DELETE", f"/iam/roles/{_path(subject)}")
+
+    def audit_log(self, **params: Any) -> Dict[str, Any]:
+        """GET /audit"""
+        return self._request("GET", "/audit", params=_params(**params))
+
+    # Administration (operators)
+
+    def create_api_key(self, request: Dict[str, Any]) -> Dict[str, Any]:
+        """POST /admin/api-keys"""
+        return self._request("POST", "/admin/api-keys", json=request)
+
+    def list_api_keys(self) -> Dict[str, Any]:
+        """GET /admin/api-keys"""
+        return self._request("GET", "/admin/api-keys")
+
+    def revoke_api_key(self, key_id: str) -> Dict[str, Any]:
+        """DELETE /admin/api-keys/{key_id}"""
+        return self._request("DELETE", f"/admin/api-keys/{_path(key_id)}")
+
+    def ingest_billing(self, source: str, month: Optional[str] = None) -> Dict[str, Any]:
+        """POST /admin/billing/ingest: one month of a billing export"""
+        return self._request("POST", "/admin/billing/ingest", json=_params(source=source, month=month))
+
+    def compact_actuals(self) -> Dict[str, Any]:
+        """POST /admin/actuals/compact: roll up and expire aged actual costs"""
+        return self._request("POST", "/admin/actuals/compact")
+
+    def collect_inventory(self, source: str) -> Dict[str, Any]:
+        """POST /admin/inventory/collect: the resources a cloud API lists"""
+        return self._request("POST", "/admin/inventory/collect", json={"source": source})
+
+    def create_tenant(self, tenant: Dict[str, Any]) -> Dict[str, Any]:
+        """POST /admin/tenants"""
+        return self._request("POST", "/admin/tenants", json=tenant)
+
+    def list_tenants(self) -> Dict[str, Any]:
+        """GET /admin/tenants"""
+        return self._request("GET", "/admin/tenants")
+
+    def set_tenant_prices(self, tenant_id: str, sheet: Dict[str, Any]) -> Dict[str, Any]:
+        """PUT /admin/tenants/{tenant_id}/prices"""
+        return self._request("PUT", f"/admin/tenants/{_path(tenant_id)}/prices", json=sheet)
+
+    def tenant_prices(self, tenant_id: str) -> Dict[str, Any]:
+        """GET /admin/tenants/{tenant_id}/prices"""
+        return self._request("GET", f"/admin/tenants/{_path(tenant_id)}/prices")
+
+    def _request(self, method: str, path: str, **kwargs) -> Any:
+        """
+        Send a request and decode its JSON response
+
+        Raises:
+            APIError: If the service answers with an error status
+            DeadlineExceeded: If the deadline passes before the service answers
+            requests.RequestException: If the service cannot be reached
+        """
+        response = self._send(method, path, **kwargs)
+        return response.json() if response.content else {}
+
+    def _send(self, method: str, path: str, **kwargs) -> requests.Response:
+        """Send a request, retrying failures the retry policy allows; errors raise APIError"""
+        headers = self.options.headers or None
+        attempt = 0
+        while True:
+            attempt += 1
+            retry_after = None
+            try:
+                response = self.session.request(
+                    method, f"{self.url}{path}", timeout=self._timeout(), headers=headers, **kwargs
+                )
+            except (requests.ConnectionError, requests.Timeout) as e:
+                # Refused and unconnected requests never reached the service
+                unsent = isinstance(e, requests.ConnectTimeout) or not isinstance(e, requests.Timeout)
+                if not self._retries(attempt, method, unsent):
+                    raise
+            else:
+                if response.status_code < 400:
+                    return response
+                status = response.status_code
+                if not (status in self.retry.statuses
+                        and self._retries(attempt, method, status in UNPROCESSED_STATUSES)):
+                    raise _api_error(response)
+                retry_after = _retry_after(response)
+            self._wait(max(self.retry.delay(attempt), retry_after or 0.0))
+
+    def _retries(self, attempt: int, method: str, unprocessed: bool) -> bool:
+        return attempt < self.retry.attempts and (unprocessed or method.upper() in IDEMPOTENT_METHODS)
+
+    def _timeout(self) -> float:
+        """Timeout of the next attempt, at most the time left to the deadline"""
+        timeout = self.options.timeout or self.timeout
+        if self.options.deadline is None:
+            return timeout
+        left = self.options.deadline - self.clock()
+        if left <= 0:
+            raise DeadlineExceeded("Deadline exceeded before the estimator answered")
+        return min(timeout, left)
+
+    def _wait(self, seconds: float) -> None:
+        """Sleep before the next attempt or poll, failing if it would pass the deadline"""
+        if self.options.deadline is not None and self.clock() + seconds >= self.options.deadline:
+            raise DeadlineExceeded("Deadline exceeded before the estimator answered")
+        self.sleep(seconds)
+
+
+def _api_error(response: requests.Response) -> APIError:
+    try:
+        body = response.json()
+        detail = body.get("detail", response.text)
+        # Problem details of invalid requests list every violation
+        violations = body.get("violations") or []
+        if violations:
+            detail = "; ".join(f"{v.get('field')}: {v.get('message')}" for v in violations)
+    except ValueError:
+        detail = response.text
+    return APIError(response.status_code, str(detail))
+
+
+def _retry_after(response: requests.Response) -> Optional[float]:
+    """Seconds of a Retry-After header, None without one or for an HTTP date"""
+    try:
+        return float(response.headers.get("Retry-After", ""))
+    except ValueError:
+        return None
+
+
+def _path(value: str) -> str:
+    """A path segment, escaped"""
+    return quote(str(value), safe="")
+
+
+def _params(**values: Any) -> Dict[str, Any]:
+    """Query parameters that are set; from_ is sent as from"""
+    return {key.rstrip("_"): value for key, value in values.items() if value is not None}
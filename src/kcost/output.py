@@ -0,0 +1,118 @@
+"""
+Table output of API responses
+"""
+
+from typing import Any, Dict, List, Sequence
+
+
+def table(headers: Sequence[str], rows: List[Sequence[Any]]) -> str:
+    """Align rows under headers; numbers are right-aligned"""
+    cells = [[_cell(value) for value in row] for row in rows]
+    widths = [max([len(h)] + [len(row[i]) for row in cells]) for i, h in enumerate(headers)]
+    numeric = [bool(rows) and all(isinstance(row[i], (int, float)) for row in rows) for i in range(len(headers))]
+
+    def line(values: Sequence[str]) -> str:
+        return "  ".join(
+            value.rjust(width) if right else value.ljust(width)
+            for value, width, right in zip(values, widths, numeric)
+        ).rstrip()
+
+    return "\n".join([line(headers)] + [line(row) for row in cells])
+
+
+def _cell(value: Any) -> str:
+    if value is None:
+        return "-"
+    if isinstance(value, float):
+        return f"{value:,.2f}"
+    return str(value)
+
+
+def render_estimate(response: Dict[str, Any]) -> str:
+    """POST /estimate response"""
+    estimate = response["estimate"]
+    rows = [
+        [item.get("name") or "-", item["provider"], item["region"], item["instance_type"], item["count"],
+         item["hourly_cost"], item["monthly_cost"]]
+        for item in estimate["line_items"]
+    ]
+    lines = [
+        table(["NAME", "PROVIDER", "REGION", "TYPE", "COUNT", "HOURLY", "MONTHLY"], rows),
+        "",
+        f"Total: {estimate['monthly_cost']:,.2f} {estimate['currency']}/month "
+        f"({estimate['yearly_cost']:,.2f}/year)",
+    ]
+    carbon = estimate.get("carbon")
+    if carbon:
+        lines.append(f"Carbon: {carbon['monthly_kg_co2e']:,.2f} kgCO2e/month")
+    return "\n".join(lines + _history(response))
+
+
+def render_kubernetes(response: Dict[str, Any]) -> str:
+    """POST /estimate/kubernetes response"""
+    estimate = response["estimate"]
+    rows = [
+        [f"{w['kind']}/{w['name']}", w["namespace"], w["replicas"], w["cpu_cores"], w["memory_gb"], w["monthly_cost"]]
+        for w in estimate["workloads"]
+    ] + [
+        [f"PVC/{v['name']}", v["namespace"], v["count"], None, None, v["monthly_cost"]]
+        for v in estimate["volumes"]
+    ]
+    lines = [
+        table(["WORKLOAD", "NAMESPACE", "REPLICAS", "CPU", "MEMORY_GB", "MONTHLY"], rows),
+        "",
+        f"Total: {estimate['monthly_cost']:,.2f} {estimate['currency']}/month "
+        f"(compute {estimate['compute_monthly_cost']:,.2f}, storage {estimate['storage_monthly_cost']:,.2f})",
+    ]
+    if estimate.get("skipped"):
+        lines.append(f"Skipped: {', '.join(estimate['skipped'])}")
+    return "\n".join(lines + _history(response))
+
+
+def render_terraform(response: Dict[str, Any]) -> str:
+    """POST /estimate/terraform and /estimate/pulumi response"""
+    estimate = response["estimate"]
+    rows = [
+        [change["action"], change["address"], change["before_monthly_cost"], change["after_monthly_cost"],
+         change["monthly_delta"]]
+        for group in ("added", "changed", "destroyed")
+        for change in estimate[group]
+    ]
+    lines = [
+        table(["ACTION", "ADDRESS", "BEFORE", "AFTER", "DELTA"], rows),
+        "",
+        f"Monthly cost: {estimate['before_monthly_cost']:,.2f} -> {estimate['after_monthly_cost']:,.2f} "
+        f"{estimate['currency']} ({estimate['monthly_delta']:+,.2f})",
+    ]
+    for unpriced in estimate.get("unpriced") or []:
+        lines.append(f"Unpriced: {unpriced['address']} ({unpriced['reason']})")
+    return "\n".join(lines + _history(response))
+
+
+def render_compare(response: Dict[str, Any]) -> str:
+    """POST /compare response"""
+    comparison = response["comparison"]
+    rows = [
+        [o["provider"], o["region"], o["instance_type"], o["vcpus"], o["memory_gb"], o["pricing_model"],
+         o["monthly_cost"]]
+        for o in comparison["options"]
+    ]
+    lines = [table(["PROVIDER", "REGION", "TYPE", "VCPUS", "MEMORY_GB", "PRICING", "MONTHLY"], rows)]
+    for provider, reason in (comparison.get("unavailable") or {}).items():
+        lines.append(f"{provider}: {reason}")
+    return "\n".join(lines)
+
+
+def render_refresh(response: Dict[str, Any]) -> str:
+    """POST /catalog/refresh response"""
+    return f"{response['message']}: {', '.join(response['providers'])}"
+
+
+def _history(response: Dict[str, Any]) -> List[str]:
+    """Estimate id and budget warnings of an estimate response"""
+    lines = []
+    if response.get("estimate_id"):
+        lines.append(f"Estimate id: {response['estimate_id']}")
+    for warning in response.get("budget_warnings") or []:
+        lines.append(f"Budget warning: {warning['message']}")
+    return lines
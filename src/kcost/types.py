@@ -0,0 +1,225 @@
+"""
+Typed requests and responses of the estimator API
+
+The JSON bodies EstimatorClient sends and returns, as TypedDicts: they are
+plain dicts at run time, so the client keeps working when the service
+adds fields, while type checkers and editors know the fields callers use.
+Fields the service may omit are not required (total=False). Shapes follow
+the server's models (src.estimator, src.diff, src.store), which the client
+does not import so it runs without the server's dependencies.
+"""
+
+from typing import Any, Dict, List, Optional, TypedDict
+
+
+class ResourceSpec(TypedDict, total=False):
+    """An instance type to price"""
+
+    name: str
+    provider: str
+    instance_type: str
+    region: str
+    count: int
+    hours: float
+    pricing_model: str
+    accelerator_type: str
+    accelerator_count: int
+    runs: int
+    usage_profile: str
+    licenses: List[str]
+
+
+class BillingPeriod(TypedDict):
+    """Dates an estimate is priced for, end included (YYYY-MM-DD)"""
+
+    start: str
+    end: str
+
+
+class EstimateRequest(TypedDict, total=False):
+    """Body of POST /estimate"""
+
+    resources: List[ResourceSpec]
+    databases: List[Dict[str, Any]]
+    object_storage: List[Dict[str, Any]]
+    serverless: List[Dict[str, Any]]
+    commitments: List[Dict[str, Any]]
+    traffic: List[Dict[str, Any]]
+    continue_on_error: bool
+    period: BillingPeriod
+    disaster_recovery: Dict[str, Any]
+    project: str
+    labels: Dict[str, str]
+
+
+class LineItem(TypedDict, total=False):
+    """Cost of one priced resource"""
+
+    name: Optional[str]
+    provider: str
+    region: str
+    instance_type: str
+    count: int
+    hours: float
+    billed_hours: Optional[float]
+    usage_profile: Optional[str]
+    pricing_model: str
+    unit_price_hourly: float
+    price_source: Optional[str]
+    licenses: List[Dict[str, Any]]
+    hourly_cost: float
+    monthly_cost: float
+    yearly_cost: float
+    discounts: List[Dict[str, Any]]
+
+
+class UnsupportedItem(TypedDict, total=False):
+    """An item of a request that could not be priced"""
+
+    field: str
+    name: Optional[str]
+    provider: str
+    region: str
+    status: str
+    reason: str
+
+
+class EstimateResult(TypedDict, total=False):
+    """Cost breakdown of an estimate"""
+
+    line_items: List[LineItem]
+    transfer_items: List[Dict[str, Any]]
+    database_items: List[Dict[str, Any]]
+    object_storage_items: List[Dict[str, Any]]
+    serverless_items: List[Dict[str, Any]]
+    hourly_cost: float
+    monthly_cost: float
+    yearly_cost: float
+    currency: str
+    applied_rules: List[Dict[str, Any]]
+    unsupported: List[UnsupportedItem]
+    coverage: Optional[Dict[str, Any]]
+    period: Optional[Dict[str, Any]]
+    disaster_recovery: Optional[Dict[str, Any]]
+
+
+class ExchangeRate(TypedDict, total=False):
+    """Rate the amounts of a response were converted from USD with"""
+
+    base: str
+    currency: str
+    rate: float
+    source: str
+    as_of: str
+
+
+class EstimateResponse(TypedDict, total=False):
+    """Response of POST /estimate"""
+
+    estimate_id: Optional[str]
+    estimate: EstimateResult
+    exchange_rate: Optional[ExchangeRate]
+    budget_warnings: List[Dict[str, Any]]
+    policy: Optional[Dict[str, Any]]
+    catalog_version: Optional[str]
+    explanation: Optional[List[Dict[str, Any]]]
+    timestamp: str
+
+
+class DiffSide(TypedDict, total=False):
+    """One side of a diff: exactly one of estimate_id, resources, terraform, pulumi or kubernetes"""
+
+    label: str
+    estimate_id: str
+    resources: EstimateRequest
+    terraform: Dict[str, Any]
+    pulumi: Dict[str, Any]
+    kubernetes: Dict[str, Any]
+
+
+class DiffThresholds(TypedDict, total=False):
+    """Limits the head side must stay within to pass"""
+
+    max_increase_cost: float
+    max_increase_percent: float
+    max_monthly_cost: float
+
+
+class DiffRequest(TypedDict, total=False):
+    """Body of POST /estimate/diff"""
+
+    base: DiffSide
+    head: DiffSide
+    thresholds: DiffThresholds
+    region: str
+    hours: float
+
+
+class CostChange(TypedDict, total=False):
+    """Cost change of one resource between two estimates"""
+
+    key: str
+    change: str
+    base_monthly_cost: float
+    head_monthly_cost: float
+    monthly_delta: float
+
+
+class DiffResult(TypedDict, total=False):
+    """Comparison of two estimates"""
+
+    base_label: str
+    head_label: str
+    base_monthly_cost: float
+    head_monthly_cost: float
+    monthly_delta: float
+    delta_percent: Optional[float]
+    kind: str
+    thresholds: DiffThresholds
+    changes: List[CostChange]
+    passed: bool
+    failures: List[str]
+    markdown: str
+    unpriced: List[str]
+
+
+class DiffResponse(TypedDict, total=False):
+    """Response of POST /estimate/diff"""
+
+    diff: DiffResult
+    exchange_rate: Optional[ExchangeRate]
+    policy: Optional[Dict[str, Any]]
+    timestamp: str
+
+
+class Job(TypedDict, total=False):
+    """A background job"""
+
+    id: str
+    tenant_id: str
+    kind: str
+    state: str
+    request: Dict[str, Any]
+    result: Optional[Dict[str, Any]]
+    error: Optional[str]
+    progress: float
+    progress_detail: Optional[str]
+    estimate_id: Optional[str]
+    attempts: int
+    created_at: str
+    updated_at: str
+    finished_at: Optional[str]
+
+
+class JobResponse(TypedDict, total=False):
+    """Response of POST /jobs and GET /jobs/{job_id}"""
+
+    job: Job
+    timestamp: str
+
+
+class StreamEvent(TypedDict):
+    """A Server-Sent Event of POST /estimate/stream"""
+
+    event: str
+    data: Any
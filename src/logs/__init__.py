@@ -0,0 +1,29 @@
+"""
+Logging Module
+
+Structured log output (text or JSON) with request IDs propagated from the
+X-Request-ID header to every record logged while handling a request.
+"""
+
+from .context import REQUEST_ID_HEADER, get_request_id, new_request_id, request_context
+from .setup import (
+    FORMAT_TEXT,
+    FORMAT_JSON,
+    LOG_FORMATS,
+    JsonFormatter,
+    RequestContextFilter,
+    configure_logging,
+)
+
+__all__ = [
+    "REQUEST_ID_HEADER",
+    "get_request_id",
+    "new_request_id",
+    "request_context",
+    "FORMAT_TEXT",
+    "FORMAT_JSON",
+    "LOG_FORMATS",
+    "JsonFormatter",
+    "RequestContextFilter",
+    "configure_logging",
+]
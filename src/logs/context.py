@@ -0,0 +1,41 @@
+"""
+Request correlation context
+
+The request ID of the request being handled is kept in a context variable
+so every log record emitted while handling it carries the same ID.
+"""
+
+import contextvars
+import uuid
+from contextlib import contextmanager
+from typing import Optional
+
+REQUEST_ID_HEADER = "X-Request-ID"
+
+_request_id: contextvars.ContextVar[Optional[str]] = contextvars.ContextVar("request_id", default=None)
+
+
+def get_request_id() -> Optional[str]:
+    """ID of the request being handled, None outside a request"""
+    return _request_id.get()
+
+
+def new_request_id() -> str:
+    """Generate an ID for a request that arrived without one"""
+    return str(uuid.uuid4())
+
+
+@contextmanager
+def request_context(request_id: Optional[str] = None):
+    """
+    Bind a request ID for the duration of the block
+
+    Yields:
+        The bound ID (generated if none was given)
+    """
+    request_id = request_id or new_request_id()
+    token = _request_id.set(request_id)
+    try:
+        yield request_id
+    finally:
+        _request_id.reset(token)
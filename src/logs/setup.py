@@ -0,0 +1,92 @@
+"""
+Log output configuration
+
+Records are written to stderr as text lines or JSON objects (one per
+line), each tagged with the service name and the current request ID, and
+in JSON with the current trace ID when tracing is enabled.
+"""
+
+import json
+import logging
+import sys
+from datetime import datetime, timezone
+
+from ..tracing import current_trace_id
+from .context import get_request_id
+
+FORMAT_TEXT = "text"
+FORMAT_JSON = "json"
+LOG_FORMATS = (FORMAT_TEXT, FORMAT_JSON)
+
+_TEXT_FORMAT = "%(asctime)s %(levelname)s %(name)s [%(request_id)s] %(message)s"
+
+# LogRecord attributes that are not user supplied `extra` fields
+_RECORD_ATTRIBUTES = frozenset(vars(logging.LogRecord("", 0, "", 0, "", None, None))) | {
+    "message", "asctime", "request_id", "trace_id", "service", "taskName",
+}
+
+
+class RequestContextFilter(logging.Filter):
+    """Adds service, request_id and trace_id attributes to every record"""
+
+    def __init__(self, service: str):
+        super().__init__()
+        self.service = service
+
+    def filter(self, record: logging.LogRecord) -> bool:
+        record.service = self.service
+        record.request_id = get_request_id() or "-"
+        record.trace_id = current_trace_id()
+        return True
+
+
+class JsonFormatter(logging.Formatter):
+    """One JSON object per record, including `extra` fields"""
+
+    def format(self, record: logging.LogRecord) -> str:
+        entry = {
+            "timestamp": datetime.fromtimestamp(record.created, timezone.utc).isoformat(),
+            "level": record.levelname,
+            "logger": record.name,
+            "message": record.getMessage(),
+            "service": getattr(record, "service", None),
+        }
+        request_id = getattr(record, "request_id", "-")
+        if request_id != "-":
+            entry["request_id"] = request_id
+        trace_id = getattr(record, "trace_id", None)
+        if trace_id:
+            entry["trace_id"] = trace_id
+        for key, value in vars(record).items():
+            if key not in _RECORD_ATTRIBUTES and not key.startswith("_"):
+                entry[key] = value
+        if record.exc_info:
+            entry["error"] = self.formatException(record.exc_info)
+        return json.dumps(entry, default=str)
+
+
+def configure_logging(level: str = "INFO", log_format: str = FORMAT_TEXT, service: str = "") -> None:
+    """
+    Route all loggers through one handler with the chosen format
+
+    Replaces handlers on the root logger so uvicorn and library loggers,
+    which propagate to it, share the format.
+
+    Raises:
+        ValueError: If the format is unknown
+    """
+    if log_format not in LOG_FORMATS:
+        raise ValueError(f"log format must be one of: {', '.join(LOG_FORMATS)}")
+
+    handler = logging.StreamHandler(sys.stderr)
+    handler.addFilter(RequestContextFilter(service))
+    if log_format == FORMAT_JSON:
+        handler.setFormatter(JsonFormatter())
+    else:
+        handler.setFormatter(logging.Formatter(_TEXT_FORMAT))
+
+    root = logging.getLogger()
+    for existing in list(root.handlers):
+        root.removeHandler(existing)
+    root.addHandler(handler)
+    root.setLevel(getattr(logging, level.upper(), logging.INFO))
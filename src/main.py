@@ -3,45 +3,405 @@ Collector Module Main Application
 power data collection and cost conversion API server
 """
 
-from fastapi import FastAPI, HTTPException, BackgroundTasks
+from fastapi import FastAPI, HTTPException, BackgroundTasks, Body, File, Form, Query, Request, UploadFile
+from fastapi.encoders import jsonable_encoder
+from fastapi.exceptions import RequestValidationError
 from fastapi.middleware.cors import CORSMiddleware
-import uvicorn
-from typing import Optional, Dict, Any
-import uuid
-from datetime import datetime, timedelta
+from fastapi.responses import HTMLResponse, JSONResponse, Response, StreamingResponse
+from prometheus_client import CONTENT_TYPE_LATEST, generate_latest
+from pydantic import ValidationError
+import asyncio
+import contextlib
+import functools
+import json
+import smtplib
+import urllib.parse
+from typing import Optional, Dict, Any, List, Tuple
+import time
+from datetime import date, datetime, timedelta, timezone
 import logging
 
 from .power_client import PowerClient
 from .power_metrics import PowerCalculator
 from .data_processor import DataProcessor
-from .config.settings import get_settings
+from .config.settings import get_settings, Settings
 from .predictor import (
     EnergyPredictor,
     CalibrationTool,
     HistoricalData,
 )
+from . import providers  # noqa: F401  (registers pricing provider factories)
+from .providers.cache import set_catalog_store
+from .providers.openstack import OpenStackPricingProvider, PrivateCloudRates
+from .snapshot import PinnedEstimators, build_pinned_registry, check_catalog_version, import_snapshot
+from .store import (
+    open_store,
+    RoleAssignmentRecord,
+    StoreError,
+    TenantRecord,
+    DEFAULT_TENANT,
+    record_estimate,
+    KIND_RESOURCES,
+    KIND_KUBERNETES,
+    KIND_TERRAFORM,
+    KIND_PULUMI,
+    KIND_CLOUDFORMATION,
+    KIND_CROSSPLANE,
+    KIND_HELM,
+    KIND_CLUSTER,
+    JOB_STATES,
+)
+from .estimator import (
+    BatchEstimateRequest,
+    BatchEstimator,
+    BatchItemResult,
+    CostEstimator,
+    EstimateRequest,
+    EstimateResult,
+    LicenseRates,
+    TransferRates,
+    PRICING_MODEL_VERSION,
+    explain_estimate,
+)
+from .compare import Comparer, CompareRequest, CompareResult
+from .diff import DiffRequest, DiffResult, DiffThresholds, EstimateDiffer, parse_threshold, render_markdown
+from .policies import build_policy_engine, diff_input, estimate_input
+from .chatops import CommandHandler, verify_slack, verify_teams, SLACK_SIGNATURE_HEADER, SLACK_TIMESTAMP_HEADER
+from .currency import build_converter, BASE_CURRENCY, UnsupportedCurrencyError
+from .cache import build_locks, build_result_cache, etag_matches, json_etag
+from .carbon import build_carbon_estimator
+from .formats import (
+    EXTENSIONS, FORMAT_CSV, FORMAT_HTML, FORMAT_INFRACOST, FORMAT_JSON, FORMAT_MARKDOWN, FORMATS, MEDIA_TYPES,
+    infracost_document, negotiate_format, render_document,
+)
+from .responses import (
+    AcceleratorTypesResponse,
+    AccuracyReportResponse,
+    ActualCostsResponse,
+    AllocationResponse,
+    AnomaliesResponse,
+    ApiKeyCreatedResponse,
+    ApiKeyListResponse,
+    ApiKeyRevokedResponse,
+    ArmMigrationResponse,
+    AuditEventListResponse,
+    BatchEstimateResponse,
+    ActualsCompactResponse,
+    BillingIngestResponse,
+    BudgetListResponse,
+    BudgetResponse,
+    CallerResponse,
+    CatalogRefreshResponse,
+    CatalogInstanceTypesResponse,
+    CatalogProvidersResponse,
+    CatalogRegionsResponse,
+    CatalogStatusResponse,
+    ChargebackReportResponse,
+    CloudFormationEstimateResponse,
+    CrossplaneEstimateResponse,
+    ClusterEstimateResponse,
+    CommitmentsResponse,
+    CompareResponse,
+    DiscountRuleListResponse,
+    DiscountRuleResponse,
+    EstimateDiffResponse,
+    EstimateListResponse,
+    EstimateRecordResponse,
+    EstimateRerunResponse,
+    EstimateReviewResponse,
+    EstimateResponse,
+    ForecastResponse,
+    HealthResponse,
+    HelmEstimateResponse,
+    IdleResourcesResponse,
+    InventoryCollectResponse,
+    InventoryResponse,
+    JobListResponse,
+    JobResponse,
+    KubernetesEstimateResponse,
+    KubernetesUsageEstimateResponse,
+    LicenseRatesResponse,
+    NodePoolRecommendationResponse,
+    OpenStackRatesResponse,
+    PriceChangesResponse,
+    PriceSheetResponse,
+    PulumiEstimateResponse,
+    PricingProvidersResponse,
+    RegionMigrationResponse,
+    RightsizingResponse,
+    RoleAssignmentListResponse,
+    RoleAssignmentResponse,
+    ScenarioComparisonResponse,
+    ScenarioListResponse,
+    ScenarioResponse,
+    TenantListResponse,
+    TenantResponse,
+    TerraformEstimateResponse,
+    TerraformResourceTypesResponse,
+    UsageProfileListResponse,
+    UsageProfileResponse,
+    WebhookCreatedResponse,
+    WebhookListResponse,
+    WebhookResponse,
+    WebhookTestResponse,
+    ReportScheduleListResponse,
+    ReportScheduleResponse,
+    ReportSentResponse,
+)
+from .server import (
+    HealthChecker,
+    PROBE_LIVENESS,
+    PROBE_READINESS,
+    STATUS_DEGRADED,
+    STATUS_FAILING,
+    STATUS_OK,
+    CheckOutcome,
+    LoadShedder,
+    Overloaded,
+    ServerLifecycle,
+    catalog_check,
+    jobs_check,
+    parse_concurrency_limits,
+    serve,
+    store_check,
+)
+from .auth import (
+    ApiKeyCreateRequest,
+    AuthError,
+    build_authenticator,
+    client_address,
+    issue_api_key,
+    rate_limit_error,
+    required_scope,
+    Principal,
+    RateLimiter,
+    API_KEY_HEADER,
+    FORWARDED_FOR_HEADER,
+    SCOPE_ADMIN,
+)
+from .iam import RoleAssignment, RoleAssignmentSpec, RoleResolver, scope_role, subject_of
+from .approvals import CommentSpec, DecisionSpec, EstimateReviews, DECISION_APPROVED, DECISION_REJECTED
+from .audit import AuditLog, audit_target, created_resource_id, ACTION_CREATE
+from .discounts import DiscountEngine, DiscountRule, DiscountRuleSpec
+from .profiles import (
+    BUILTIN_PROFILES,
+    UnknownProfileError,
+    UsageProfile,
+    UsageProfileSpec,
+    UsageProfiles,
+    validate_profile_name,
+)
+from .budgets import ActualCostBatch, Budget, BudgetEvaluator, BudgetSpec
+from .billing import ActualsRetention, IngestRequest, build_ingestion, month_bounds
+from .reports import (
+    AccuracyReporter,
+    ChargebackReporter,
+    ChargebackReportRequest,
+    FocusExporter,
+    render_focus,
+    DATASET_ALL,
+    FOCUS_FORMATS,
+    ChargebackSchedule,
+    CostReportBuilder,
+    ReportSchedule,
+    ReportScheduler,
+    ReportScheduleSpec,
+    Mailer,
+    render_csv,
+    render_pdf,
+    CHECK_INTERVAL_SECONDS,
+    GROUP_BY_PROJECT,
+)
+from .allocation import AllocationEngine, parse_label_keys, parse_weights, window_days
+from .forecast import Forecaster
+from .grafana import GrafanaAnnotationRequest, GrafanaDatasource, GrafanaQueryRequest, GrafanaSearchRequest
+from .anomalies import AnomalyDetector
+from .commitments import CommitmentRecommender, CommitmentRequest
+from .inventory import (
+    CollectRequest,
+    IdleDetector,
+    InventoryBatch,
+    RegionMigrationAnalyzer,
+    RegionMigrationRequest,
+    build_collection,
+)
+from .dashboard import DASHBOARD_PATH, dashboard_html, wants_dashboard
+from .units import configure_time_basis, hours_per_month
+from .money import configure_rounding
+from .validation import PROBLEM_MEDIA_TYPE, ValidationProblem, Violation, problem_of, validate_estimate, violations_of
+from .streaming import (
+    Emit,
+    StreamEstimateRequest,
+    stream_events,
+    EVENT_ITEM,
+    EVENT_START,
+    MEDIA_TYPE as SSE_MEDIA_TYPE,
+    STREAM_BATCH,
+    STREAM_CLUSTER,
+)
+from .jobs import (
+    JobKind,
+    JobOutcome,
+    JobRunner,
+    JobSubmitRequest,
+    Progress,
+    KIND_BILLING_INGESTION,
+    KIND_CHARGEBACK_REPORT,
+    KIND_CLUSTER_SCAN,
+)
+from .scenarios import Scenario, ScenarioComparer, ScenarioSpec
+from .price_changes import PriceChangeTracker
+from .notifications import (
+    AnomalyAlerts,
+    BudgetAlerts,
+    Event,
+    NotificationDispatcher,
+    PriceChangeAlerts,
+    Webhook,
+    WebhookSpec,
+    catalog_failure_notifier,
+    generate_secret,
+    slack_blocks,
+    teams_card,
+    EVENT_COST_DIFF,
+    EVENT_ESTIMATE_REVIEWED,
+    EVENT_TEST,
+)
+from .tenancy import (
+    PriceSheet,
+    TenantCreateRequest,
+    TenantPriceOverrides,
+    current_tenant,
+    detect_format,
+    is_operator,
+    merge_sheets,
+    parse_price_sheet,
+    resolve_tenant,
+    tenant_context,
+    TENANT_HEADER,
+)
+from .logs import REQUEST_ID_HEADER, configure_logging, get_request_id, request_context
+from .tracing import configure_tracing, server_span, set_response_status, shutdown_tracing, tracing_enabled
+from .grpc_api import EstimateServicer, start_grpc_server, DEFAULT_SHUTDOWN_GRACE
+from .admission import AdmissionReviewer, start_admission_server
+from .k8s import (
+    ArmMigrationAnalyzer,
+    ArmMigrationRequest,
+    ClusterEstimateRequest,
+    ClusterEstimator,
+    KubernetesAPISource,
+    KubernetesEstimator,
+    KubernetesEstimateRequest,
+    KubernetesEstimateResult,
+    NodePoolOptimizer,
+    NodePoolRequest,
+    PrometheusClient,
+    PrometheusError,
+    RightsizingRecommender,
+    RightsizingRequest,
+    UsageEstimateRequest,
+    UsageEstimator,
+    parse_hourly_rates,
+)
+from .helm import HelmRenderer, HelmUnavailableError
+from .terraform import (
+    TerraformEstimator,
+    TerraformEstimateRequest,
+    TerraformEstimateResult,
+    supported_resource_types,
+)
+from .terraform.plan import load_plan, provider_short_name
+from .pulumi import PulumiEstimateRequest, PulumiEstimator, load_preview, preview_providers
+from .cloudformation import CloudFormationEstimateRequest, CloudFormationEstimator, load_template
+from .crossplane import CrossplaneEstimateRequest, CrossplaneEstimator, load_compositions, load_documents
+from .metrics import (
+    observe_estimate, record_namespace_costs, record_rate_limited, record_shed, RATE_LIMIT_ADDRESS, RATE_LIMIT_KEY,
+)
+from .pricing import (
+    accelerator_types,
+    add_refresh_failure_listener,
+    available_providers,
+    build_registry,
+    CATALOG_DEGRADED,
+    cloud_capabilities,
+    fuzzy_matching,
+    list_instance_types,
+    list_regions,
+    load_plugins,
+    paginate,
+    PriceNotFoundError,
+    ProviderNotFoundError,
+    RefreshScheduler,
+)
+
+# Service identity in responses and log records
+SERVICE_NAME = "kcloud-cost-estimator"
+
+# Longest sleep between checks for due price catalogs, so retries and manual refreshes are picked up
+CATALOG_POLL_SECONDS = 60
 
-# Logging configuration (initial default, refined after loading settings)
-logging.basicConfig(level=logging.INFO)
 logger = logging.getLogger(__name__)
+access_logger = logging.getLogger("src.access")
 
 # FastAPI app initialization
 app = FastAPI(
-    title="kcloud-collector",
-    description="power data collection and cost conversion API",
+    title="kcloud-cost-estimator",
+    description="Cloud cost estimation, power data collection and cost conversion API",
     version="1.0.0",
+    openapi_url="/openapi.json",
     docs_url="/docs",
-    redoc_url="/redoc"
+    redoc_url="/redoc",
+    openapi_tags=[
+        {"name": "estimation", "description": "Cost estimates of resources, manifests, charts and plans"},
+        {"name": "history", "description": "Recorded estimates"},
+        {"name": "jobs", "description": "Estimates of large inputs and heavy periodic work run in the background"},
+        {"name": "pricing", "description": "Pricing providers and price catalogs"},
+        {"name": "discounts", "description": "Discount rules applied to estimates"},
+        {"name": "budgets", "description": "Monthly budgets, actual spend and budget warnings"},
+        {"name": "scenarios", "description": "What-if variations of a baseline estimate and their cost matrix"},
+        {
+            "name": "reports",
+            "description": "Estimate accuracy against actual spend, chargeback statements and FOCUS exports",
+        },
+        {"name": "allocation", "description": "Costs attributed to teams, projects and namespaces"},
+        {"name": "forecast", "description": "Spend forecasts from actual costs"},
+        {"name": "recommendations", "description": "Right-sizing recommendations from measured usage"},
+        {"name": "analysis", "description": "What-if analyses of deployed resources, e.g. a move to another region"},
+        {"name": "anomalies", "description": "Anomalies of daily actual spend"},
+        {"name": "webhooks", "description": "Webhook notifications of budget, anomaly, catalog and cost diff events"},
+        {"name": "chatops", "description": "Slack slash commands and Teams outgoing webhooks"},
+        {"name": "power", "description": "Power data collection and power cost"},
+        {"name": "prediction", "description": "Energy prediction and calibration"},
+        {"name": "iam", "description": "Callers' roles in tenants"},
+        {"name": "audit", "description": "Audit log of configuration changes"},
+        {
+            "name": "admin",
+            "description": "API keys, tenants, tenant price sheets, billing ingestion and inventory collection",
+        },
+        {"name": "service", "description": "Service status and probes"},
+    ],
 )
 
 # Load settings
 settings = get_settings()
 
-# Refine logging level from settings
-try:
-    logger.setLevel(getattr(logging, settings.log_level.upper(), logging.INFO))
-except Exception:
-    logger.setLevel(logging.INFO)
+# Logging: level and text/JSON format from settings, request IDs on every record
+configure_logging(settings.log_level, settings.log_format, SERVICE_NAME)
+
+# Units: hours per month of every hourly, monthly and yearly conversion
+configure_time_basis(settings.units_hours_per_month)
+
+# Money: decimal rounding of amounts and the per-currency rules of statements
+configure_rounding(settings.money_rounding, settings.money_currency_rounding)
+
+# Tracing: spans of requests, estimates, pricing API calls and store queries over OTLP
+if settings.tracing_enabled:
+    configure_tracing(
+        SERVICE_NAME,
+        settings.tracing_otlp_endpoint,
+        settings.tracing_otlp_protocol,
+        settings.tracing_sample_ratio,
+    )
 
 # CORS configuration (from settings)
 app.add_middleware(
@@ -52,25 +412,321 @@ app.add_middleware(
     allow_headers=settings.cors_allow_headers,
 )
 
-# Request ID middleware
+# Invalid requests are answered with RFC 7807 problem details listing
+# every violation with the path of its field in the request body
+def _problem_response(request: Request, violations: List[Violation]) -> JSONResponse:
+    problem = problem_of(violations, instance=request.url.path)
+    return JSONResponse(status_code=problem.status, content=problem.dict(), media_type=PROBLEM_MEDIA_TYPE)
+
+@app.exception_handler(RequestValidationError)
+async def request_validation_problem(request: Request, exc: RequestValidationError):
+    return _problem_response(request, violations_of(exc.errors()))
+
+@app.exception_handler(ValidationProblem)
+async def validation_problem(request: Request, exc: ValidationProblem):
+    return _problem_response(request, exc.violations)
+
+# Load shedding: requests of expensive routes over their concurrency limit
+# wait in a bounded queue, then are refused with 503 and a Retry-After.
+# Declared first, so only authenticated requests within their rate limit
+# take a slot.
 @app.middleware("http")
-async def add_request_id_header(request, call_next):
-    request_id = request.headers.get("X-Request-ID") or str(uuid.uuid4())
+async def shed_load(request, call_next):
+    limit = load_shedder.limit_for(request.url.path) if load_shedder is not None else None
+    if limit is None:
+        return await call_next(request)
+    try:
+        async with limit.slot():
+            return await call_next(request)
+    except Overloaded as e:
+        record_shed(e.route)
+        logger.warning(f"{request.method} {request.url.path} shed: {e}")
+        return JSONResponse(status_code=503, content={"detail": str(e)}, headers={"Retry-After": str(e.retry_after)})
+
+# Audit log: successful requests changing configuration are appended with
+# their caller and the resource changed. Declared before authentication,
+# so it runs inside it and knows the caller and tenant.
+@app.middleware("http")
+async def audit_request(request, call_next):
     response = await call_next(request)
-    response.headers["X-Request-ID"] = request_id
+    target = audit_target(request.method, request.url.path)
+    if audit_log is None or target is None or response.status_code >= 400:
+        return response
+
+    # Resources created by POST are named by the response
+    resource_id = None
+    json_body = response.headers.get("content-type", "").startswith("application/json")
+    if target.action == ACTION_CREATE and target.resource_id is None and json_body:
+        body = b"".join([chunk async for chunk in response.body_iterator])
+        resource_id = created_resource_id(body)
+        response = Response(content=body, status_code=response.status_code, headers=dict(response.headers))
+    client = client_address(
+        request.client.host if request.client else None,
+        request.headers.get(FORWARDED_FOR_HEADER),
+        settings.rate_limit_trusted_proxies,
+    )
+    try:
+        await asyncio.to_thread(
+            audit_log.record,
+            request.method,
+            request.url.path,
+            response.status_code,
+            current_tenant(),
+            principal=getattr(request.state, "principal", None),
+            client=client,
+            request_id=get_request_id(),
+            resource_id=resource_id,
+        )
+    except Exception as e:
+        # The change is made; failing the request now would only hide it from the caller
+        logger.error(f"Audit event of {request.method} {request.url.path} not recorded: {e}")
     return response
 
+# Authentication: every route but probes, metrics and docs needs an API key
+# or OIDC token with the route's scope, within the caller's rate limit.
+# Without authentication, clients are limited by address instead. The
+# request is then bound to the caller's tenant (or, for operators and
+# callers with a role in it, the one named in X-Tenant-ID); a caller's role
+# in that tenant takes the place of its credential's scopes.
+@app.middleware("http")
+async def authenticate_request(request, call_next):
+    scope = required_scope(request.method, request.url.path)
+    if scope is None:
+        return await call_next(request)
+
+    principal = None
+    tenant_id = None
+    if settings.auth_enabled:
+        if authenticator is None:
+            return JSONResponse(status_code=503, content={"detail": "Service starting: authentication not ready"})
+        try:
+            # Key lookups, JWKS fetches and role lookups block, keep them off the event loop
+            principal, tenant_id = await asyncio.to_thread(
+                _authorize_request,
+                scope,
+                request.headers.get(TENANT_HEADER),
+                request.headers.get(API_KEY_HEADER),
+                request.headers.get("Authorization"),
+            )
+        except AuthError as e:
+            if e.status_code == 429:
+                record_rate_limited(RATE_LIMIT_KEY)
+            return JSONResponse(status_code=e.status_code, content={"detail": e.detail}, headers=e.headers)
+        except PermissionError as e:
+            return JSONResponse(status_code=403, content={"detail": str(e)})
+        except ValueError as e:
+            return JSONResponse(status_code=400, content={"detail": str(e)})
+        except StoreError as e:
+            logger.error(f"Role lookup failed: {e}")
+            return JSONResponse(status_code=503, content={"detail": "Role lookup failed"})
+    elif rate_limiter is not None and settings.rate_limit_anonymous > 0:
+        client = client_address(
+            request.client.host if request.client else None,
+            request.headers.get(FORWARDED_FOR_HEADER),
+            settings.rate_limit_trusted_proxies,
+        )
+        limit = settings.rate_limit_anonymous
+        retry_after = rate_limiter.acquire(f"address:{client}", limit, settings.rate_limit_burst)
+        if retry_after > 0:
+            record_rate_limited(RATE_LIMIT_ADDRESS)
+            e = rate_limit_error(limit, retry_after)
+            return JSONResponse(status_code=e.status_code, content={"detail": e.detail}, headers=e.headers)
+    request.state.principal = principal
+
+    if tenant_id is None:
+        try:
+            tenant_id = resolve_tenant(request.headers.get(TENANT_HEADER), principal)
+        except PermissionError as e:
+            return JSONResponse(status_code=403, content={"detail": str(e)})
+        except ValueError as e:
+            return JSONResponse(status_code=400, content={"detail": str(e)})
+    with tenant_context(tenant_id):
+        return await call_next(request)
+
+def _authorize_request(
+    scope: str, requested_tenant: Optional[str], api_key: Optional[str], authorization: Optional[str]
+) -> Tuple[Principal, str]:
+    """Authenticate a caller, bind it to its tenant and its role there, and check the route's scope"""
+    principal = authenticator.authenticate(api_key, authorization)
+    if role_resolver is not None:
+        tenant_id, principal = role_resolver.bind(principal, requested_tenant)
+    else:
+        tenant_id = resolve_tenant(requested_tenant, principal)
+    authenticator.authorize(principal, scope)
+    return principal, tenant_id
+
+# Entity tags: successful GET responses carry an ETag of their JSON body
+# (without the timestamp), and a request whose If-None-Match matches it is
+# answered with 304 Not Modified
+@app.middleware("http")
+async def add_etag_header(request, call_next):
+    response = await call_next(request)
+    if request.method != "GET" or response.status_code != 200:
+        return response
+    if not response.headers.get("content-type", "").startswith("application/json"):
+        return response
+
+    body = b"".join([chunk async for chunk in response.body_iterator])
+    headers = dict(response.headers)
+    etag = json_etag(body)
+    if etag is not None:
+        headers["etag"] = etag
+        # Responses depend on the caller's tenant: private, revalidated on every use
+        headers.setdefault("cache-control", "private, no-cache")
+        if etag_matches(request.headers.get("if-none-match"), etag):
+            return Response(status_code=304, headers={"etag": etag, "cache-control": headers["cache-control"]})
+    return Response(content=body, status_code=response.status_code, headers=headers)
+
+# In-flight requests and background jobs, drained on shutdown
+lifecycle = ServerLifecycle()
+
+# Refuse new requests while shutting down and bound request handling time
+@app.middleware("http")
+async def track_request_lifecycle(request, call_next):
+    if lifecycle.draining:
+        return JSONResponse(
+            status_code=503,
+            content={"detail": "Server shutting down"},
+            headers={"Connection": "close"},
+        )
+    async with lifecycle.track():
+        if settings.server_request_timeout <= 0:
+            return await call_next(request)
+        try:
+            return await asyncio.wait_for(call_next(request), settings.server_request_timeout)
+        except asyncio.TimeoutError:
+            return JSONResponse(status_code=504, content={"detail": "Request timed out"})
+
+# Request ID middleware: binds the caller's X-Request-ID (or a new one) to
+# every log record of the request and returns it in the response
+@app.middleware("http")
+async def add_request_id_header(request, call_next):
+    with request_context(request.headers.get(REQUEST_ID_HEADER)) as request_id:
+        start = time.perf_counter()
+        response = await call_next(request)
+        response.headers[REQUEST_ID_HEADER] = request_id
+        access_logger.info(
+            f"{request.method} {request.url.path} {response.status_code}",
+            extra={
+                "method": request.method,
+                "path": request.url.path,
+                "status": response.status_code,
+                "duration_ms": round((time.perf_counter() - start) * 1000, 1),
+            },
+        )
+        return response
+
+# Tracing middleware: one server span per request, continuing the caller's
+# trace from its traceparent header. Outermost, so the spans of every
+# other middleware and the access log's trace ID fall within it.
+@app.middleware("http")
+async def trace_request(request, call_next):
+    if not tracing_enabled():
+        return await call_next(request)
+    with server_span(request.method, request.headers, {
+        "http.request.method": request.method,
+        "url.path": request.url.path,
+    }) as current:
+        response = await call_next(request)
+        route = request.scope.get("route")
+        set_response_status(current, request.method, response.status_code, getattr(route, "path", None))
+        return response
+
 # Global instances
 power_client = None
 power_calculator = None
 data_processor = None
 energy_predictor = None
 calibration_tool = None
+pricing_registry = None
+catalog_scheduler = None
+health_checker = None
+catalog_task = None
+cost_estimator = None
+pinned_estimators = None
+batch_estimator = None
+job_runner = None
+job_task = None
+k8s_estimator = None
+usage_estimator = None
+rightsizing_recommender = None
+cluster_estimator = None
+node_pool_optimizer = None
+arm_migration_analyzer = None
+region_migration_analyzer = None
+cluster_scan_task = None
+namespace_cost_task = None
+terraform_estimator = None
+pulumi_estimator = None
+cloudformation_estimator = None
+crossplane_estimator = None
+helm_renderer = None
+comparer = None
+scenario_comparer = None
+estimate_differ = None
+policy_engine = None
+command_handler = None
+currency_converter = None
+result_cache = None
+job_locks = None
+grpc_server = None
+admission_server = None
+rate_limiter = None
+load_shedder = None
+authenticator = None
+tenant_prices = None
+role_resolver = None
+audit_log = None
+estimate_reviews = None
+discount_engine = None
+usage_profiles = None
+budget_evaluator = None
+notification_dispatcher = None
+budget_alerts = None
+accuracy_reporter = None
+focus_exporter = None
+allocation_engine = None
+chargeback_reporter = None
+forecaster = None
+grafana_datasource = None
+anomaly_detector = None
+commitment_recommender = None
+anomaly_alerts = None
+anomaly_task = None
+price_change_tracker = None
+price_change_alerts = None
+idle_detector = None
+chargeback_schedule = None
+chargeback_task = None
+report_scheduler = None
+report_task = None
+billing_ingestion = None
+billing_task = None
+actuals_retention = None
+actuals_task = None
+inventory_collection = None
+inventory_task = None
+store = None
 
 @app.on_event("startup")
 async def startup_event():
     """Initialize on application startup"""
     global power_client, power_calculator, data_processor, energy_predictor, calibration_tool
+    global pricing_registry, cost_estimator, k8s_estimator, terraform_estimator, pulumi_estimator, helm_renderer, store
+    global catalog_scheduler, catalog_task, health_checker, batch_estimator, job_runner, job_task
+    global comparer, estimate_differ, currency_converter, result_cache, grpc_server, rate_limiter, authenticator
+    global admission_server, load_shedder
+    global scenario_comparer, pinned_estimators, cloudformation_estimator, crossplane_estimator
+    global tenant_prices, role_resolver, audit_log, discount_engine, estimate_reviews, usage_profiles
+    global budget_evaluator, notification_dispatcher, budget_alerts, billing_ingestion, billing_task
+    global accuracy_reporter, allocation_engine, usage_estimator, cluster_estimator, cluster_scan_task
+    global namespace_cost_task, focus_exporter, job_locks
+    global rightsizing_recommender, node_pool_optimizer, arm_migration_analyzer
+    global chargeback_reporter, chargeback_schedule, chargeback_task, forecaster, grafana_datasource
+    global anomaly_detector, anomaly_alerts, anomaly_task, idle_detector, commitment_recommender
+    global price_change_tracker, price_change_alerts, policy_engine, report_scheduler, report_task
+    global command_handler, region_migration_analyzer, inventory_collection, inventory_task
+    global actuals_retention, actuals_task
 
     logger.info("Starting Collector module...")
     
@@ -107,28 +763,817 @@ async def startup_event():
         calibration_tool = CalibrationTool()
         logger.info("Calibration tool initialized")
 
+        # Work done once for all replicas (catalog downloads, ingestion, alerts) is guarded by locks,
+        # shared through Redis when LOCK_REDIS_URL is set
+        job_locks = build_locks(settings)
+
+        # Open persistent store; provider catalogs are kept there across restarts.
+        # A snapshot without a configured store is loaded into memory.
+        store_url = settings.store_url or ("sqlite://:memory:" if settings.pricing_snapshot_path else "")
+        if store_url:
+            store = open_store(store_url)
+            if settings.store_migrate_on_startup:
+                applied = store.migrate()
+                if applied:
+                    logger.info(f"Store migrations applied: {applied}")
+            set_catalog_store(store)
+            logger.info(f"Store initialized ({store.dialect}, schema v{store.schema_version()})")
+
+            if settings.pricing_snapshot_path:
+                import_snapshot(store, settings.pricing_snapshot_path)
+
+        # API keys issued through /admin/api-keys are kept in the store
+        # One limiter for keys, tokens and anonymous client addresses
+        rate_limiter = RateLimiter(max_callers=settings.rate_limit_max_clients)
+        authenticator = build_authenticator(settings, store, rate_limiter)
+        # Expensive routes handle a bounded number of requests at once
+        load_shedder = LoadShedder(
+            parse_concurrency_limits(settings.concurrency_limits),
+            max_queue=settings.concurrency_max_queue,
+            queue_timeout=settings.concurrency_queue_timeout,
+        )
+
+        # Initialize pricing providers and cost estimator; plugins add out-of-tree providers
+        plugins = load_plugins(settings)
+        if plugins:
+            logger.info(f"Pricing plugins registered: {', '.join(plugins)}")
+        pricing_registry = build_registry(settings.pricing_providers, settings)
+        # Tenants' negotiated price sheets take precedence over list prices
+        if store is not None:
+            tenant_prices = TenantPriceOverrides(store, ttl=settings.tenant_price_sheet_ttl)
+            pricing_registry.set_overrides(tenant_prices.lookup)
+            # Callers' roles in tenants take the place of their credentials' scopes there
+            role_resolver = RoleResolver(store, ttl=settings.iam_roles_ttl)
+            # Changes made through the API are appended to the audit log
+            audit_log = AuditLog(store)
+            # Recorded estimates are commented on and approved or rejected by tenant admins
+            estimate_reviews = EstimateReviews(store)
+            # Tenants' discount rules apply to every line item
+            discount_engine = DiscountEngine(store, ttl=settings.discount_rules_ttl)
+            # Resources reference the tenant's usage profiles by name, as well as the built-in ones
+            usage_profiles = UsageProfiles(store, ttl=settings.usage_profiles_ttl)
+            budget_evaluator = BudgetEvaluator(store)
+            # Budget, anomaly and catalog refresh events are delivered to tenants' webhooks
+            notification_dispatcher = NotificationDispatcher(
+                store,
+                timeout=settings.webhook_timeout_seconds,
+                max_attempts=settings.webhook_max_attempts,
+                backoff=settings.webhook_backoff_seconds,
+            )
+            notification_dispatcher.start()
+            budget_alerts = BudgetAlerts(budget_evaluator, notification_dispatcher, locks=job_locks)
+            add_refresh_failure_listener(catalog_failure_notifier(notification_dispatcher))
+            # Billing exports (AWS CUR) are ingested as actual costs
+            billing_ingestion = build_ingestion(settings, store)
+            # Aged actual costs are rolled up to daily and monthly costs
+            actuals_retention = ActualsRetention(
+                store,
+                raw_days=settings.actuals_raw_retention_days,
+                daily_days=settings.actuals_daily_retention_days,
+                monthly_days=settings.actuals_monthly_retention_days,
+            )
+            # Live resources are listed from the cloud APIs into the inventory
+            inventory_collection = build_collection(settings, store)
+            accuracy_reporter = AccuracyReporter(store)
+            focus_exporter = FocusExporter(store)
+            allocation_engine = AllocationEngine(
+                store,
+                label_keys=parse_label_keys(settings.allocation_label_keys),
+                shared_namespaces=settings.allocation_shared_namespaces,
+                default_split=settings.allocation_split,
+            )
+            forecaster = Forecaster(
+                store,
+                default_model=settings.forecast_model,
+                history_days=settings.forecast_history_days,
+                season_days=settings.forecast_season_days,
+            )
+            grafana_datasource = GrafanaDatasource(store, forecaster)
+            anomaly_detector = AnomalyDetector(
+                store,
+                detector=settings.anomaly_detector,
+                window_days=settings.anomaly_window_days,
+                threshold=settings.anomaly_threshold,
+                min_increase=settings.anomaly_min_increase,
+                new_sku_min_cost=settings.anomaly_new_sku_min_cost,
+            )
+            anomaly_alerts = AnomalyAlerts(anomaly_detector, notification_dispatcher, locks=job_locks)
+            # Commitment purchases are recommended from the ingested instance hours
+            commitment_recommender = CommitmentRecommender(
+                store,
+                pricing_registry,
+                lookback_days=settings.commitment_lookback_days,
+                coverage_percent=settings.commitment_coverage_percent,
+            )
+            # Catalog refreshes changing prices notify the tenants whose estimates they move
+            price_change_tracker = PriceChangeTracker(store, estimate_limit=settings.price_change_estimate_limit)
+            price_change_alerts = PriceChangeAlerts(price_change_tracker, notification_dispatcher, locks=job_locks)
+            # Collectors report resource inventories in which idle resources are found
+            idle_detector = IdleDetector(
+                store,
+                pricing_registry,
+                load_balancer_hourly=parse_hourly_rates(settings.k8s_load_balancer_hourly),
+            )
+            chargeback_reporter = ChargebackReporter(
+                store,
+                allocation_engine,
+                discounts=discount_engine if settings.chargeback_apply_discounts else None,
+            )
+            mailer = Mailer(
+                settings.smtp_host,
+                settings.smtp_port,
+                sender=settings.smtp_from,
+                username=settings.smtp_username,
+                password=settings.smtp_password,
+                starttls=settings.smtp_starttls,
+            )
+            if settings.chargeback_email_to and settings.smtp_host:
+                chargeback_schedule = ChargebackSchedule(
+                    chargeback_reporter,
+                    mailer,
+                    tenant_id=settings.billing_tenant,
+                    recipients=settings.chargeback_email_to,
+                    group_by=settings.chargeback_group_by,
+                    day=settings.chargeback_email_day,
+                    locks=job_locks,
+                )
+            # Tenants schedule weekly or monthly cost reports to their recipients
+            if settings.smtp_host:
+                report_scheduler = ReportScheduler(
+                    store,
+                    CostReportBuilder(
+                        store,
+                        budgets=budget_evaluator,
+                        commitments=commitment_recommender,
+                        idle=idle_detector,
+                        top_items=settings.report_top_items,
+                    ),
+                    mailer,
+                    locks=job_locks,
+                )
+        cost_estimator = CostEstimator(
+            registry=pricing_registry,
+            discounts=discount_engine,
+            carbon=build_carbon_estimator(settings),
+            profiles=usage_profiles,
+            licenses=LicenseRates.from_file(settings.license_rates_path) if settings.license_rates_path else None,
+            transfer_rates=(
+                TransferRates.from_file(settings.transfer_rates_path) if settings.transfer_rates_path else None
+            ),
+        )
+        batch_estimator = BatchEstimator(
+            cost_estimator, workers=settings.batch_workers, max_items=settings.batch_max_items
+        )
+        # Listed resources, or inventoried ones selected by label, are priced in another region
+        region_migration_analyzer = RegionMigrationAnalyzer(cost_estimator, store)
+        k8s_estimator = KubernetesEstimator(
+            registry=pricing_registry,
+            cpu_to_memory_cost_ratio=settings.k8s_cpu_to_memory_cost_ratio,
+        )
+        # The cluster is scanned through its API server on request or on a schedule
+        cluster_estimator = ClusterEstimator(
+            k8s_estimator,
+            KubernetesAPISource(settings.k8s_kubeconfig, settings.k8s_context),
+            cluster=settings.k8s_cluster_name,
+            default_provider=settings.k8s_node_provider,
+            default_region=settings.k8s_node_region,
+            load_balancer_hourly=parse_hourly_rates(settings.k8s_load_balancer_hourly),
+            cluster_tier=settings.k8s_cluster_tier or None,
+        )
+        node_pool_optimizer = NodePoolOptimizer(cluster_estimator)
+        arm_migration_analyzer = ArmMigrationAnalyzer(cluster_estimator)
+        # Running clusters are priced from the usage their Prometheus measured
+        if settings.k8s_usage_prometheus_url:
+            usage_estimator = UsageEstimator(
+                k8s_estimator,
+                PrometheusClient(settings.k8s_usage_prometheus_url, timeout=settings.k8s_usage_query_timeout),
+                default_provider=settings.k8s_node_provider,
+                default_region=settings.k8s_node_region,
+            )
+            rightsizing_recommender = RightsizingRecommender(
+                usage_estimator,
+                headroom_percent=settings.rightsizing_headroom_percent,
+                exclude_namespaces=settings.rightsizing_exclude_namespaces,
+                exclude_workloads=settings.rightsizing_exclude_workloads,
+                exclude_nodes=settings.rightsizing_exclude_nodes,
+                min_monthly_savings=settings.rightsizing_min_savings,
+            )
+        terraform_estimator = TerraformEstimator(registry=pricing_registry, discounts=discount_engine)
+        pulumi_estimator = PulumiEstimator(terraform_estimator)
+        cloudformation_estimator = CloudFormationEstimator(terraform_estimator)
+        crossplane_estimator = CrossplaneEstimator(
+            terraform_estimator,
+            compositions=(
+                load_compositions(settings.crossplane_compositions_path)
+                if settings.crossplane_compositions_path else None
+            ),
+        )
+        comparer = Comparer(registry=pricing_registry, discounts=discount_engine)
+        scenario_comparer = ScenarioComparer(cost_estimator)
+        estimate_differ = EstimateDiffer(
+            cost_estimator,
+            k8s_estimator,
+            terraform_estimator,
+            store=store,
+            thresholds=DiffThresholds(
+                max_increase_cost=parse_threshold(settings.diff_max_increase),
+                max_increase_percent=parse_threshold(settings.diff_max_increase_percent),
+                max_monthly_cost=parse_threshold(settings.diff_max_monthly_cost),
+            ),
+        )
+        # Slack and Teams commands price from the first catalog region of each provider by default
+        command_handler = CommandHandler(cost_estimator, {
+            provider: regions[0]
+            for provider, regions in (
+                ("aws", settings.aws_pricing_regions),
+                ("gcp", settings.gcp_pricing_regions),
+                ("azure", settings.azure_pricing_regions),
+                ("ncp", settings.ncp_pricing_regions),
+                ("alibaba", settings.alibaba_pricing_regions),
+            )
+            if regions
+        })
+        # Estimates and diffs are answered with the verdict of the Rego cost policies of POLICY_PATH
+        policy_engine = build_policy_engine(settings)
+        if policy_engine is not None:
+            logger.info(f"Cost policies loaded ({settings.policy_engine}): {', '.join(policy_engine.modules)}")
+        currency_converter = build_converter(settings)
+        # Providers listing prices in another currency (NCP in KRW) are priced in USD
+        pricing_registry.set_exchange_rates(lambda currency: currency_converter.rate(currency)[0])
+        # Estimates pinned to a past day (?catalog_version=) are priced from the store's catalog snapshots
+        if store is not None:
+            pinned_estimators = PinnedEstimators(
+                _build_pinned_estimator, max_snapshots=settings.catalog_pinned_snapshots
+            )
+        # Identical estimate requests, e.g. from repeated CI runs, are answered from the cache
+        result_cache = build_result_cache(settings)
+        helm_renderer = HelmRenderer(
+            helm_binary=settings.helm_binary,
+            timeout_seconds=settings.helm_timeout_seconds,
+        )
+        logger.info("Cost estimator initialized")
+
+        # Large inputs are estimated in background jobs kept in the store
+        if store is not None:
+            job_runner = JobRunner(
+                store,
+                _job_kinds(),
+                workers=settings.job_workers,
+                lease_seconds=settings.job_lease_seconds,
+                max_attempts=settings.job_max_attempts,
+                retry_backoff=settings.job_retry_backoff_seconds,
+            )
+
+        if settings.grpc_enabled:
+            grpc_server = await start_grpc_server(
+                EstimateServicer(cost_estimator, comparer, pricing_registry, store, budget_evaluator),
+                settings.api_host,
+                settings.grpc_port,
+                authenticator,
+            )
+        if settings.admission_enabled:
+            admission_server = await start_admission_server(
+                AdmissionReviewer(
+                    k8s_estimator,
+                    budget_evaluator,
+                    provider=settings.k8s_node_provider,
+                    region=settings.k8s_node_region,
+                    node_instance_type=settings.admission_node_instance_type,
+                    mode=settings.admission_mode,
+                    tenant_id=settings.admission_tenant,
+                    project=settings.k8s_cluster_name,
+                    exempt_namespaces=settings.admission_exempt_namespaces,
+                ),
+                settings.api_host,
+                settings.admission_port,
+                settings.admission_tls_cert_file,
+                settings.admission_tls_key_file,
+            )
+
+        # Download price catalogs without blocking startup, then whenever each is due
+        if settings.offline:
+            logger.info("Offline mode: price catalog downloads disabled")
+        else:
+            catalog_scheduler = RefreshScheduler(
+                pricing_registry,
+                interval=settings.catalog_refresh_interval,
+                jitter=settings.catalog_refresh_jitter,
+                backoff=settings.catalog_refresh_backoff,
+                max_backoff=settings.catalog_refresh_max_backoff,
+                max_age=settings.catalog_max_age,
+                locks=job_locks,
+                lock_ttl=settings.lock_ttl_seconds,
+            )
+            catalog_task = asyncio.create_task(_refresh_catalogs_periodically())
+        if billing_ingestion is not None and not settings.offline and settings.billing_ingest_interval > 0:
+            billing_task = asyncio.create_task(_ingest_billing_periodically())
+            logger.info(f"Billing ingestion of {', '.join(billing_ingestion.sources)} scheduled")
+        if actuals_retention is not None and settings.actuals_compaction_interval > 0:
+            actuals_task = asyncio.create_task(_compact_actuals_periodically())
+            logger.info("Compaction of aged actual costs scheduled")
+        if inventory_collection is not None and not settings.offline and settings.inventory_collect_interval > 0:
+            inventory_task = asyncio.create_task(_collect_inventory_periodically())
+            logger.info(f"Inventory collection from {', '.join(inventory_collection.sources)} scheduled")
+        if settings.k8s_cluster_scan_interval > 0:
+            cluster_scan_task = asyncio.create_task(_scan_cluster_periodically())
+            logger.info(f"Scan of cluster {settings.k8s_cluster_name} scheduled")
+        if settings.k8s_namespace_cost_interval_minutes > 0:
+            namespace_cost_task = asyncio.create_task(_collect_namespace_costs_periodically())
+            logger.info(f"Namespace cost metrics of cluster {settings.k8s_cluster_name} scheduled")
+        if chargeback_schedule is not None:
+            chargeback_task = asyncio.create_task(_mail_chargeback_periodically())
+            logger.info(f"Chargeback statements mailed on day {settings.chargeback_email_day} of every month")
+        if report_scheduler is not None:
+            report_task = asyncio.create_task(_mail_reports_periodically())
+        if anomaly_alerts is not None and settings.anomaly_check_interval > 0:
+            anomaly_task = asyncio.create_task(_check_anomalies_periodically())
+            logger.info(f"Spend anomaly checks scheduled every {settings.anomaly_check_interval}s")
+        # Queued jobs, and jobs left running by a stopped replica, are picked up on the first poll.
+        # Replicas without workers (JOB_WORKERS=0) only queue jobs for the others.
+        if job_runner is not None and settings.job_workers > 0:
+            job_task = asyncio.create_task(_run_jobs_periodically())
+
+        health_checker = _build_health_checker()
         logger.info("Collector module initialization completed")
         
     except Exception as e:
         logger.error(f"Initialization failed: {e}")
         raise
 
-@app.get("/")
-async def root():
-    """Root endpoint"""
+@app.on_event("shutdown")
+async def shutdown_event():
+    """Drain in-flight work, then release resources on application shutdown"""
+    lifecycle.begin_shutdown()
+    if catalog_task is not None:
+        catalog_task.cancel()
+    if billing_task is not None:
+        billing_task.cancel()
+    if actuals_task is not None:
+        actuals_task.cancel()
+    if inventory_task is not None:
+        inventory_task.cancel()
+    if cluster_scan_task is not None:
+        cluster_scan_task.cancel()
+    if namespace_cost_task is not None:
+        namespace_cost_task.cancel()
+    if chargeback_task is not None:
+        chargeback_task.cancel()
+    if report_task is not None:
+        report_task.cancel()
+    if anomaly_task is not None:
+        anomaly_task.cancel()
+    if job_task is not None:
+        job_task.cancel()
+    # Jobs still running are resumed by another replica once their lease expires
+    if job_runner is not None:
+        job_runner.close()
+    if grpc_server is not None:
+        await grpc_server.stop(DEFAULT_SHUTDOWN_GRACE)
+        logger.info("gRPC server stopped")
+    if admission_server is not None:
+        await admission_server.stop()
+        logger.info("Admission webhook stopped")
+    if await lifecycle.drain(settings.server_shutdown_timeout):
+        logger.info("In-flight requests and catalog refreshes finished")
+    if batch_estimator is not None:
+        batch_estimator.close()
+    if notification_dispatcher is not None:
+        notification_dispatcher.stop(timeout=settings.webhook_timeout_seconds)
+        logger.info("Notification dispatcher stopped")
+    if store is not None:
+        store.close()
+        logger.info("Store closed")
+    shutdown_tracing()
+
+def _build_health_checker() -> HealthChecker:
+    """Dependency checks of /healthz and /readyz"""
+    checker = HealthChecker(timeout=settings.health_check_timeout)
+    if store is not None:
+        checker.register("store", store_check(store), critical_for=(PROBE_READINESS,))
+    if catalog_scheduler is not None:
+        checker.register("catalogs", catalog_check(catalog_scheduler))
+    checker.register("background_jobs", jobs_check(lambda: {
+        "catalog_refresh": catalog_task,
+        "billing_ingestion": billing_task,
+        "actuals_compaction": actuals_task,
+        "inventory_collection": inventory_task,
+        "cluster_scan": cluster_scan_task,
+        "namespace_costs": namespace_cost_task,
+        "chargeback_mail": chargeback_task,
+        "cost_report_mail": report_task,
+        "anomaly_checks": anomaly_task,
+        "estimation_jobs": job_task,
+        "webhook_dispatcher": notification_dispatcher,
+    }, lifecycle), critical_for=(PROBE_LIVENESS,))
+    # Power metrics only feed the power APIs; estimates work without them
+    checker.register("power", _power_check)
+    checker.register("database", _database_check)
+    return checker
+
+async def _power_check() -> CheckOutcome:
+    status = await power_client.health_check()
+    if status.get("status") == "healthy":
+        return CheckOutcome(STATUS_OK, info=status)
+    return CheckOutcome(STATUS_DEGRADED, status.get("error") or "Prometheus or power metrics unavailable", status)
+
+async def _database_check() -> CheckOutcome:
+    status = await data_processor.health_check()
+    info = status if isinstance(status, dict) else {}
+    if (info.get("status") == "healthy") if info else bool(status):
+        return CheckOutcome(STATUS_OK, info=info)
+    return CheckOutcome(STATUS_DEGRADED, "Redis or InfluxDB unavailable", info)
+
+async def _refresh_catalogs_periodically():
+    """Refresh each provider's price catalog when it is due, checking at least every CATALOG_POLL_SECONDS"""
+    while not lifecycle.draining:
+        await lifecycle.run_in_background(_refresh_due_catalogs, "catalog refresh")
+        delay = catalog_scheduler.seconds_until_due()
+        await asyncio.sleep(CATALOG_POLL_SECONDS if delay is None else min(max(delay, 1), CATALOG_POLL_SECONDS))
+
+def _refresh_due_catalogs() -> None:
+    if catalog_scheduler.run_due():
+        _invalidate_results()
+        _check_price_changes()
+
+def _refresh_all_catalogs() -> None:
+    catalog_scheduler.refresh()
+    _invalidate_results()
+    _check_price_changes()
+
+def _check_price_changes() -> None:
+    """Notify every tenant's webhooks of estimates moved by the prices the refresh changed"""
+    if price_change_alerts is None:
+        return
+    for tenant_id in _tenant_ids():
+        price_change_alerts.check(tenant_id)
+
+async def _ingest_billing_periodically():
+    """Ingest the recent months of every billing export every BILLING_INGEST_INTERVAL seconds"""
+    while not lifecycle.draining:
+        await lifecycle.run_in_background(_ingest_recent_billing, "billing ingestion")
+        await asyncio.sleep(settings.billing_ingest_interval)
+
+def _ingest_recent_billing() -> None:
+    if not _claim_job("billing-ingestion", settings.billing_ingest_interval):
+        return
+    # Each export month is ingested, and retried, by a job of whichever replica has a worker free
+    if job_runner is not None:
+        for source, start in billing_ingestion.recent():
+            request = {"source": source, "month": f"{start:%Y-%m}"}
+            _queue_job(billing_ingestion.tenant_id, KIND_BILLING_INGESTION, request)
+        return
+    billing_ingestion.ingest_recent()
+    if budget_alerts is not None:
+        budget_alerts.check(billing_ingestion.tenant_id)
+
+async def _compact_actuals_periodically():
+    """Roll up and expire aged actual costs every ACTUALS_COMPACTION_INTERVAL seconds"""
+    while not lifecycle.draining:
+        await lifecycle.run_in_background(_compact_actuals, "actual cost compaction")
+        await asyncio.sleep(settings.actuals_compaction_interval)
+
+def _compact_actuals() -> None:
+    if not _claim_job("actuals-compaction", settings.actuals_compaction_interval):
+        return
+    actuals_retention.compact()
+
+async def _collect_inventory_periodically():
+    """List every configured cloud API into the inventory every INVENTORY_COLLECT_INTERVAL seconds"""
+    while not lifecycle.draining:
+        await lifecycle.run_in_background(_collect_inventory, "inventory collection")
+        await asyncio.sleep(settings.inventory_collect_interval)
+
+def _collect_inventory() -> None:
+    if not _claim_job("inventory-collection", settings.inventory_collect_interval):
+        return
+    inventory_collection.collect_all()
+
+async def _scan_cluster_periodically():
+    """Record an estimate of the cluster's current state every K8S_CLUSTER_SCAN_INTERVAL seconds"""
+    while not lifecycle.draining:
+        await lifecycle.run_in_background(_record_cluster_scan, "cluster scan")
+        await asyncio.sleep(settings.k8s_cluster_scan_interval)
+
+def _record_cluster_scan() -> None:
+    if not _claim_job("cluster-scan", settings.k8s_cluster_scan_interval):
+        return
+    if job_runner is not None:
+        _queue_job(DEFAULT_TENANT, KIND_CLUSTER_SCAN, {})
+        return
+    try:
+        _cluster_scan_job(ClusterEstimateRequest(), lambda *args: None)
+    except Exception as e:
+        logger.error(f"Cluster scan failed: {e}")
+
+async def _collect_namespace_costs_periodically():
+    """Export the cluster's cost per namespace every K8S_NAMESPACE_COST_INTERVAL_MINUTES minutes"""
+    while not lifecycle.draining:
+        await lifecycle.run_in_background(_collect_namespace_costs, "namespace cost collection")
+        await asyncio.sleep(settings.k8s_namespace_cost_interval_minutes * 60)
+
+def _collect_namespace_costs() -> None:
+    try:
+        result = cluster_estimator.estimate(ClusterEstimateRequest())
+    except Exception as e:
+        logger.error(f"Namespace cost collection failed: {e}")
+        return
+    record_namespace_costs(settings.k8s_cluster_name, result.namespaces, result.idle_monthly_cost)
+
+async def _mail_chargeback_periodically():
+    """Mail the previous month's chargeback statement once CHARGEBACK_EMAIL_DAY comes"""
+    while not lifecycle.draining:
+        await lifecycle.run_in_background(chargeback_schedule.send_due, "chargeback mail")
+        await asyncio.sleep(CHECK_INTERVAL_SECONDS)
+
+async def _mail_reports_periodically():
+    """Mail the cost reports of the tenants' schedules once their send day comes"""
+    while not lifecycle.draining:
+        await lifecycle.run_in_background(report_scheduler.send_due, "cost report mail")
+        await asyncio.sleep(CHECK_INTERVAL_SECONDS)
+
+async def _check_anomalies_periodically():
+    """Notify every tenant's webhooks of new spend anomalies every ANOMALY_CHECK_INTERVAL seconds"""
+    while not lifecycle.draining:
+        await lifecycle.run_in_background(_check_anomalies, "anomaly check")
+        await asyncio.sleep(settings.anomaly_check_interval)
+
+def _check_anomalies() -> None:
+    if not _claim_job("anomaly-check", settings.anomaly_check_interval):
+        return
+    for tenant_id in _tenant_ids():
+        anomaly_alerts.check(tenant_id)
+
+def _queue_job(tenant_id: str, kind: str, request: Dict[str, Any]) -> None:
+    """Queue periodic work as a job; a failure is logged and the work done next period"""
+    try:
+        job_runner.submit(tenant_id, kind, request)
+    except Exception as e:
+        logger.error(f"{kind} job not queued: {e}")
+
+def _claim_job(name: str, interval: float) -> bool:
+    """Whether this replica runs a periodic job this interval; another replica that claimed it runs it otherwise"""
+    if job_locks.claim(f"job:{name}", interval * 0.9):
+        return True
+    logger.debug(f"Periodic {name} run by another replica")
+    return False
+
+def _tenant_ids() -> List[str]:
+    """The default tenant and every created tenant"""
+    return [DEFAULT_TENANT] + [t.id for t in store.list_tenants() if t.id != DEFAULT_TENANT]
+
+async def _run_jobs_periodically():
+    """Heartbeat running estimation jobs and start queued ones every JOB_POLL_INTERVAL seconds"""
+    while not lifecycle.draining:
+        await lifecycle.run_in_background(job_runner.poll, "job poll")
+        await asyncio.sleep(settings.job_poll_interval)
+
+def _job_kinds() -> Dict[str, JobKind]:
+    """Estimates that can run as background jobs, by kind"""
+    return {
+        KIND_TERRAFORM: JobKind(TerraformEstimateRequest, _terraform_job, _terraform_request_summary),
+        KIND_PULUMI: JobKind(PulumiEstimateRequest, _pulumi_job, _pulumi_request_summary),
+        KIND_CLOUDFORMATION: JobKind(
+            CloudFormationEstimateRequest, _cloudformation_job, _cloudformation_request_summary
+        ),
+        KIND_CROSSPLANE: JobKind(CrossplaneEstimateRequest, _crossplane_job, _crossplane_request_summary),
+        KIND_CLUSTER: JobKind(ClusterEstimateRequest, _cluster_job),
+        KIND_KUBERNETES: JobKind(KubernetesEstimateRequest, _kubernetes_job),
+        "batch": JobKind(BatchEstimateRequest, _batch_job),
+        KIND_BILLING_INGESTION: JobKind(IngestRequest, _billing_ingestion_job, retries=settings.job_retries),
+        KIND_CLUSTER_SCAN: JobKind(ClusterEstimateRequest, _cluster_scan_job, retries=settings.job_retries),
+        KIND_CHARGEBACK_REPORT: JobKind(
+            ChargebackReportRequest, _chargeback_report_job, retries=settings.job_retries
+        ),
+    }
+
+def _terraform_job(request: TerraformEstimateRequest, progress: Progress) -> JobOutcome:
+    progress(0, 1, "Pricing plan")
+    plan = load_plan(request.plan)
+    with observe_estimate("terraform", [
+        provider_short_name(rc.get("provider_name", ""))
+        for rc in plan.get("resource_changes") or [] if isinstance(rc, dict)
+    ]):
+        result = terraform_estimator.estimate(request)
+    record = record_estimate(
+        store, KIND_TERRAFORM,
+        tenant_id=current_tenant(),
+        request=_terraform_request_summary(request),
+        result=result.dict(),
+        monthly_cost=result.monthly_delta,
+        pricing_model_version=PRICING_MODEL_VERSION,
+        project=request.project,
+        labels=request.labels,
+    )
+    return JobOutcome(result.dict(), record.id if record else None)
+
+def _pulumi_job(request: PulumiEstimateRequest, progress: Progress) -> JobOutcome:
+    progress(0, 1, "Pricing preview")
+    with observe_estimate("pulumi", preview_providers(load_preview(request.preview))):
+        result = pulumi_estimator.estimate(request)
+    record = record_estimate(
+        store, KIND_PULUMI,
+        tenant_id=current_tenant(),
+        request=_pulumi_request_summary(request),
+        result=result.dict(),
+        monthly_cost=result.monthly_delta,
+        pricing_model_version=PRICING_MODEL_VERSION,
+        project=request.project,
+        labels=request.labels,
+    )
+    return JobOutcome(result.dict(), record.id if record else None)
+
+def _cloudformation_job(request: CloudFormationEstimateRequest, progress: Progress) -> JobOutcome:
+    progress(0, 1, "Pricing template")
+    with observe_estimate("cloudformation", ["aws"]):
+        result = cloudformation_estimator.estimate(request)
+    record = record_estimate(
+        store, KIND_CLOUDFORMATION,
+        tenant_id=current_tenant(),
+        request=_cloudformation_request_summary(request),
+        result=result.dict(),
+        monthly_cost=result.monthly_delta,
+        pricing_model_version=PRICING_MODEL_VERSION,
+        project=request.project,
+        labels=request.labels,
+    )
+    return JobOutcome(result.dict(), record.id if record else None)
+
+def _crossplane_job(request: CrossplaneEstimateRequest, progress: Progress) -> JobOutcome:
+    progress(0, 1, "Rendering compositions")
+    with observe_estimate("crossplane", ["crossplane"]):
+        result = crossplane_estimator.estimate(request)
+    record = record_estimate(
+        store, KIND_CROSSPLANE,
+        tenant_id=current_tenant(),
+        request=_crossplane_request_summary(request),
+        result=result.dict(),
+        monthly_cost=result.monthly_delta,
+        pricing_model_version=PRICING_MODEL_VERSION,
+        project=request.project,
+        labels=request.labels,
+    )
+    return JobOutcome(result.dict(), record.id if record else None)
+
+def _cluster_job(request: ClusterEstimateRequest, progress: Progress) -> JobOutcome:
+    progress(0, 1, "Scanning cluster")
+    with observe_estimate("cluster", [settings.k8s_node_provider]):
+        result = cluster_estimator.estimate(request)
+    record = record_estimate(
+        store, KIND_CLUSTER,
+        tenant_id=current_tenant(),
+        request=request.dict(exclude={"project", "labels"}),
+        result=result.dict(),
+        monthly_cost=result.monthly_cost,
+        pricing_model_version=PRICING_MODEL_VERSION,
+        project=request.project or settings.k8s_cluster_name,
+        labels=request.labels,
+    )
+    return JobOutcome(result.dict(), record.id if record else None)
+
+def _kubernetes_job(request: KubernetesEstimateRequest, progress: Progress) -> JobOutcome:
+    progress(0, 1, "Pricing manifests")
+    with observe_estimate("kubernetes", [request.provider]):
+        result = k8s_estimator.estimate(request)
+    record = record_estimate(
+        store, KIND_KUBERNETES,
+        tenant_id=current_tenant(),
+        request=request.dict(exclude={"project", "labels"}),
+        result=result.dict(),
+        monthly_cost=result.monthly_cost,
+        pricing_model_version=PRICING_MODEL_VERSION,
+        project=request.project,
+        labels=request.labels,
+    )
+    return JobOutcome(result.dict(), record.id if record else None)
+
+def _batch_job(request: BatchEstimateRequest, progress: Progress) -> JobOutcome:
+    # Batches are not recorded in the estimate history
+    with observe_estimate("batch", [item.provider for item in request.items]):
+        result = batch_estimator.estimate(request, progress=lambda done, total: progress(
+            done, total, f"{done} of {total} items priced"
+        ))
+    return JobOutcome(result.dict())
+
+def _billing_ingestion_job(request: IngestRequest, progress: Progress) -> JobOutcome:
+    if billing_ingestion is None or request.source not in billing_ingestion.sources:
+        raise ValueError(f"Billing source {request.source} is not configured")
+    progress(0, 1, f"Ingesting {request.source} {request.month or 'current month'}")
+    start = month_bounds(request.month)[0] if request.month else None
+    result = billing_ingestion.ingest(request.source, start)
+    if budget_alerts is not None:
+        budget_alerts.check(result.tenant_id)
+    return JobOutcome(result.dict())
+
+def _cluster_scan_job(request: ClusterEstimateRequest, progress: Progress) -> JobOutcome:
+    progress(0, 1, "Scanning cluster")
+    result = cluster_estimator.estimate(request)
+    record_namespace_costs(settings.k8s_cluster_name, result.namespaces, result.idle_monthly_cost)
+    # Recorded for the default tenant, under the cluster's name
+    record = record_estimate(
+        store, KIND_CLUSTER,
+        request={"scheduled": True},
+        result=result.dict(),
+        monthly_cost=result.monthly_cost,
+        pricing_model_version=PRICING_MODEL_VERSION,
+        project=settings.k8s_cluster_name,
+    )
+    return JobOutcome(result.dict(), record.id if record else None)
+
+def _chargeback_report_job(request: ChargebackReportRequest, progress: Progress) -> JobOutcome:
+    progress(0, 1, f"Reporting {request.period}")
+    report = chargeback_reporter.report(
+        current_tenant(),
+        request.period,
+        request.group_by or settings.chargeback_group_by,
+        split=request.split.lower() if request.split else None,
+        weights=request.weights,
+    )
+    return JobOutcome(report.dict())
+
+def _terraform_request_summary(request: TerraformEstimateRequest) -> Dict[str, Any]:
+    """Recorded request of a Terraform estimate; the plan itself is not kept, it can be large and hold secrets"""
+    try:
+        plan = load_plan(request.plan)
+    except ValueError:
+        plan = {}
+    return {
+        "region": request.region,
+        "hours": request.hours,
+        "terraform_version": plan.get("terraform_version"),
+        "resource_changes": len(plan.get("resource_changes") or []),
+    }
+
+def _pulumi_request_summary(request: PulumiEstimateRequest) -> Dict[str, Any]:
+    """Recorded request of a Pulumi estimate; like Terraform plans, the preview itself is not kept"""
+    try:
+        preview = load_preview(request.preview)
+    except ValueError:
+        preview = {}
+    return {
+        "region": request.region,
+        "hours": request.hours,
+        "steps": len(preview.get("steps") or []),
+        "change_summary": preview.get("changeSummary") or {},
+    }
+
+def _cloudformation_request_summary(request: CloudFormationEstimateRequest) -> Dict[str, Any]:
+    """Recorded request of a CloudFormation estimate; the template is not kept, and parameters only by name"""
+    try:
+        template = load_template(request.template)
+    except ValueError:
+        template = {}
+    return {
+        "region": request.region,
+        "hours": request.hours,
+        "description": template.get("Description"),
+        "resources": len(template.get("Resources") or {}),
+        "parameters": sorted(request.parameters),
+    }
+
+def _crossplane_request_summary(request: CrossplaneEstimateRequest) -> Dict[str, Any]:
+    """Recorded request of a Crossplane estimate: the kinds and names of the manifests rather than their YAML"""
+    try:
+        documents = load_documents(request.manifests)
+    except ValueError:
+        documents = []
+    return {
+        "region": request.region,
+        "hours": request.hours,
+        "objects": [
+            "/".join(str(part) for part in (
+                document.get("kind"), (document.get("metadata") or {}).get("namespace"),
+                (document.get("metadata") or {}).get("name"),
+            ) if part)
+            for document in documents
+        ],
+    }
+
+@app.get("/", tags=["service"])
+async def root(http_request: Request):
+    """Root endpoint; browsers are served the dashboard"""
+    if settings.dashboard_enabled and wants_dashboard(http_request.headers.get("accept")):
+        return await dashboard()
     return {
-        "service": "kcloud-collector",
+        "service": SERVICE_NAME,
         "version": "1.0.0",
         "description": "Power data collection and cost conversion",
         "status": "running"
     }
 
 
-@app.get("/info")
+@app.get(DASHBOARD_PATH, tags=["service"], response_class=HTMLResponse)
+async def dashboard():
+    """Web dashboard: spend this month by project, budgets, recent estimates and recommendations"""
+    if not settings.dashboard_enabled:
+        raise HTTPException(status_code=404, detail="Dashboard disabled (DASHBOARD_ENABLED)")
+    return HTMLResponse(content=dashboard_html(), headers={"Cache-Control": "no-cache"})
+
+
+@app.get("/info", tags=["service"])
 async def info():
     """Service info endpoint"""
     return {
-        "service": "kcloud-collector",
+        "service": SERVICE_NAME,
         "version": "1.0.0",
         "config": {
             "prometheus_url": settings.power_prometheus_url,
@@ -141,55 +1586,58 @@ async def info():
         }
     }
 
-@app.get("/health")
-async def health_check():
-    """Health check"""
-    try:
-        # Guard against uninitialized components
-        if power_client is None or data_processor is None:
-            return {
-                "status": "starting",
-                "timestamp": datetime.utcnow().isoformat(),
-                "services": {
-                    "power": False if power_client is None else "unknown",
-                    "database": False if data_processor is None else "unknown",
-                }
-            }
+async def _probe(probe: str, response: Response) -> Dict[str, Any]:
+    """Dependency report of a probe; 503 when a check critical for it fails"""
+    if health_checker is None:
+        response.status_code = 503
+        return {"status": "starting", "checks": {}, "timestamp": datetime.utcnow().isoformat()}
 
-        # Check power connection
-        power_status = await power_client.health_check()
+    report = await health_checker.run(probe)
+    if report.status == STATUS_FAILING:
+        failing = [name for name, c in report.checks.items() if c.critical and c.status == STATUS_FAILING]
+        logger.warning(f"{probe.capitalize()} probe failing: {', '.join(failing)}")
+        response.status_code = 503
+    return {"status": report.status, "checks": report.checks, "timestamp": datetime.utcnow().isoformat()}
 
-        # Check database connection
-        db_status = await data_processor.health_check()
-        
-        return {
-            "status": "healthy",
-            "timestamp": datetime.utcnow().isoformat(),
-            "services": {
-                "power": power_status,
-                "database": db_status
-            }
-        }
-    except Exception as e:
-        raise HTTPException(status_code=503, detail=f"Health check failed: {str(e)}")
+@app.get("/healthz", tags=["service"], response_model=HealthResponse)
+async def healthz(response: Response):
+    """Liveness: 503 when a background job stopped, so Kubernetes restarts the pod"""
+    return await _probe(PROBE_LIVENESS, response)
+
+@app.get("/readyz", tags=["service"], response_model=HealthResponse)
+async def readyz(response: Response):
+    """Readiness: 503 while starting, shutting down or without the store"""
+    if lifecycle.draining:
+        response.status_code = 503
+        return {"status": "shutting_down", "checks": {}, "timestamp": datetime.utcnow().isoformat()}
+    return await _probe(PROBE_READINESS, response)
 
+@app.get("/health", tags=["service"], response_model=HealthResponse, deprecated=True)
+async def health_check(response: Response):
+    """Deprecated alias of /healthz"""
+    return await healthz(response)
 
-@app.get("/ready")
-async def readiness():
-    if power_client is None or data_processor is None:
-        raise HTTPException(status_code=503, detail="Service starting: dependencies not ready")
-    return {"status": "ready", "timestamp": datetime.utcnow().isoformat()}
+@app.get("/ready", tags=["service"], response_model=HealthResponse, deprecated=True)
+async def readiness(response: Response):
+    """Deprecated alias of /readyz"""
+    return await readyz(response)
 
 
-@app.get("/live")
+@app.get("/live", tags=["service"])
 async def liveness():
     return {"status": "alive", "timestamp": datetime.utcnow().isoformat()}
 
+
+@app.get("/metrics", tags=["service"])
+async def metrics():
+    """Prometheus metrics: estimate requests, catalog cache lookups, pricing API latency"""
+    return Response(content=generate_latest(), media_type=CONTENT_TYPE_LATEST)
+
 # =============================================================================
 # Power data collection API
 # =============================================================================
 
-@app.get("/power/current")
+@app.get("/power/current", tags=["power"])
 async def get_current_power(
     namespace: Optional[str] = None,
     workload: Optional[str] = None,
@@ -214,7 +1662,7 @@ async def get_current_power(
     except Exception as e:
         raise HTTPException(status_code=500, detail=f"Power data query failed: {str(e)}")
 
-@app.get("/power/containers")
+@app.get("/power/containers", tags=["power"])
 async def get_container_power(
     namespace: Optional[str] = None,
     limit: int = 100
@@ -237,7 +1685,7 @@ async def get_container_power(
     except Exception as e:
         raise HTTPException(status_code=500, detail=f"Container power data query failed: {str(e)}")
 
-@app.get("/power/nodes")
+@app.get("/power/nodes", tags=["power"])
 async def get_node_power():
     """Query power data by node"""
     try:
@@ -258,7 +1706,7 @@ async def get_node_power():
 # Cost calculation API
 # =============================================================================
 
-@app.get("/cost/current")
+@app.get("/cost/current", tags=["power"])
 async def get_current_cost(
     namespace: Optional[str] = None,
     workload: Optional[str] = None
@@ -286,7 +1734,7 @@ async def get_current_cost(
     except Exception as e:
         raise HTTPException(status_code=500, detail=f"Cost calculation failed: {str(e)}")
 
-@app.get("/cost/hourly")
+@app.get("/cost/hourly", tags=["power"])
 async def get_hourly_cost(
     start: Optional[datetime] = None,
     end: Optional[datetime] = None,
@@ -316,7 +1764,7 @@ async def get_hourly_cost(
     except Exception as e:
         raise HTTPException(status_code=500, detail=f"Hourly cost analysis failed: {str(e)}")
 
-@app.get("/cost/workload/{workload_id}")
+@app.get("/cost/workload/{workload_id}", tags=["power"])
 async def get_workload_cost(workload_id: str):
     """Detailed cost analysis by workload"""
     try:
@@ -337,7 +1785,7 @@ async def get_workload_cost(workload_id: str):
 # Power profiling API
 # =============================================================================
 
-@app.get("/profile/workload-types")
+@app.get("/profile/workload-types", tags=["power"])
 async def get_workload_types():
     """Power profile by workload type"""
     try:
@@ -353,7 +1801,7 @@ async def get_workload_types():
     except Exception as e:
         raise HTTPException(status_code=500, detail=f"Profile query failed: {str(e)}")
 
-@app.post("/profile/classify")
+@app.post("/profile/classify", tags=["power"])
 async def classify_workload(power_data: Dict):
     """Classify workload power patterns"""
     try:
@@ -374,7 +1822,7 @@ async def classify_workload(power_data: Dict):
 # Background data collection tasks
 # =============================================================================
 
-@app.post("/collect/start")
+@app.post("/collect/start", tags=["power"])
 async def start_collection(background_tasks: BackgroundTasks):
     """Start background data collection"""
     try:
@@ -390,7 +1838,7 @@ async def start_collection(background_tasks: BackgroundTasks):
     except Exception as e:
         raise HTTPException(status_code=500, detail=f"Data collection start failed: {str(e)}")
 
-@app.post("/collect/stop")
+@app.post("/collect/stop", tags=["power"])
 async def stop_collection():
     """Stop background data collection"""
     try:
@@ -410,7 +1858,7 @@ async def stop_collection():
 # Metrics and statistics
 # =============================================================================
 
-@app.get("/metrics/summary")
+@app.get("/metrics/summary", tags=["power"])
 async def get_metrics_summary():
     """Collection metrics summary"""
     try:
@@ -430,7 +1878,7 @@ async def get_metrics_summary():
 # Energy prediction API
 # =============================================================================
 
-@app.post("/predict/energy")
+@app.post("/predict/energy", tags=["prediction"])
 async def predict_container_energy(request: Dict[str, Any]):
     """
     Predict future energy consumption for a container
@@ -502,7 +1950,7 @@ async def predict_container_energy(request: Dict[str, Any]):
         raise HTTPException(status_code=500, detail=f"Energy prediction failed: {str(e)}")
 
 
-@app.post("/calibrate")
+@app.post("/calibrate", tags=["prediction"])
 async def calibrate_models(request: Dict[str, Any]):
     """
     Calibrate prediction models using measurement data
@@ -555,7 +2003,7 @@ async def calibrate_models(request: Dict[str, Any]):
         raise HTTPException(status_code=500, detail=f"Calibration failed: {str(e)}")
 
 
-@app.get("/calibration/config")
+@app.get("/calibration/config", tags=["prediction"])
 async def get_calibration_config():
     """Get current calibration configuration"""
     try:
@@ -578,11 +2026,4207 @@ async def get_calibration_config():
     except Exception as e:
         raise HTTPException(status_code=500, detail=f"Failed to get config: {str(e)}")
 
-if __name__ == "__main__":
-    uvicorn.run(
-        "main:app",
-        host="0.0.0.0",
-        port=8001,
-        reload=True,
-        log_level="info"
+# =============================================================================
+# Cost estimation API
+# =============================================================================
+
+def _validated_estimate(estimator: CostEstimator, request: EstimateRequest) -> EstimateResult:
+    """
+    Estimate of a request; a request that cannot be priced is checked item by item
+
+    Raises:
+        ValidationProblem: Every item of the request the estimator cannot price
+    """
+    try:
+        return estimator.estimate(request)
+    except (PriceNotFoundError, ProviderNotFoundError, ValueError):
+        violations = validate_estimate(estimator, request)
+        if not violations:
+            raise
+        raise ValidationProblem(violations)
+
+def _in_currency(result: Dict[str, Any], currency: Optional[str]):
+    """
+    Convert a USD result to the requested currency
+
+    Returns:
+        (result, exchange rate details or None if no currency was requested)
+    """
+    if not currency:
+        return result, None
+    if currency_converter is None:
+        raise HTTPException(status_code=503, detail="Service starting: currency converter not ready")
+    return currency_converter.convert(result, currency)
+
+# Alternatives to JSON of the estimate and report endpoints, for the API docs
+FORMATTED_RESPONSES = {200: {"content": {MEDIA_TYPES[f]: {} for f in (FORMAT_CSV, FORMAT_MARKDOWN, FORMAT_HTML)}}}
+
+def _output_format(http_request: Request, format: Optional[str], infracost: bool = False) -> str:
+    """
+    Format of a response: the format query parameter, else the Accept header, else JSON
+
+    Estimate endpoints pass infracost=True to allow the Infracost JSON layout.
+    """
+    allowed = (*FORMATS, FORMAT_INFRACOST) if infracost else FORMATS
+    try:
+        return negotiate_format(format, http_request.headers.get("accept"), allowed=allowed)
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+
+def _infracost(
+    kind: str,
+    estimate: Dict[str, Any],
+    project: Optional[str],
+    estimate_id: Optional[str],
+    hours: Optional[float] = None,
+    generated_at: Optional[datetime] = None,
+) -> Response:
+    """An estimate result in the JSON schema of `infracost breakdown --format json`"""
+    document = infracost_document(
+        jsonable_encoder(estimate), kind, project=project, estimate_id=estimate_id, hours=hours,
+        generated_at=generated_at,
     )
+    return JSONResponse(content=document)
+
+def _formatted(fmt: str, title: str, filename: str, response: Dict[str, Any], main: Optional[str]) -> Response:
+    """
+    A response rendered as CSV (downloaded as an attachment), Markdown or HTML
+
+    The fields of the main result come first, then the response's other
+    fields (estimate ID, budget warnings, ...); the timestamp is left out.
+    """
+    body = jsonable_encoder(response)
+    document = dict(body.pop(main) or {}) if main else {}
+    document.update((k, v) for k, v in body.items() if k != "timestamp" and k not in document)
+    headers = {"Vary": "Accept"}
+    if fmt == FORMAT_CSV:
+        headers["Content-Disposition"] = f'attachment; filename="{filename}.{EXTENSIONS[fmt]}"'
+    return Response(content=render_document(document, fmt, title), media_type=MEDIA_TYPES[fmt], headers=headers)
+
+def _cached_result(endpoint: str, request: Dict[str, Any], compute, model):
+    """Result of an estimate, served from the result cache when it is enabled"""
+    if result_cache is None:
+        return compute()
+    return result_cache.get_or_compute(endpoint, current_tenant(), request, compute, model)
+
+def _fuzzy(fuzzy: bool):
+    """With ?fuzzy=true, price instance types that are not found as their closest match"""
+    return fuzzy_matching(settings.fuzzy_match_min_confidence) if fuzzy else contextlib.nullcontext()
+
+def _fuzzy_key(endpoint: str, fuzzy: bool) -> str:
+    """Result cache endpoint of an estimate, fuzzy estimates being cached apart"""
+    return f"{endpoint}+fuzzy" if fuzzy else endpoint
+
+def _invalidate_results(tenant_id: Optional[str] = None) -> None:
+    """Stop serving cached results priced before a change of prices or discounts"""
+    if result_cache is not None:
+        result_cache.invalidate(tenant_id)
+
+def _budget_warnings(
+    monthly_cost: float, project: Optional[str], labels: Optional[Dict[str, str]]
+) -> List[Dict[str, Any]]:
+    """Budgets of the current tenant an estimate's projected spend reaches a threshold of"""
+    if budget_evaluator is None:
+        return []
+    return [w.dict() for w in budget_evaluator.warnings(monthly_cost, project, labels)]
+
+
+async def _policy_verdict(document: Dict[str, Any]) -> Optional[Dict[str, Any]]:
+    """Verdict of the cost policies on a policy input (USD), None if no policies are configured"""
+    if policy_engine is None:
+        return None
+    verdict = await asyncio.to_thread(policy_engine.evaluate, document)
+    return verdict.dict()
+
+@app.post("/estimate", tags=["estimation"], response_model=EstimateResponse, responses=FORMATTED_RESPONSES)
+async def estimate_cost(
+    request: EstimateRequest,
+    http_request: Request,
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+    catalog_version: Optional[date] = Query(
+        None, description="Price from the catalogs stored on this day (YYYY-MM-DD) instead of the current ones"
+    ),
+    format: Optional[str] = Query(None, description="json, csv, markdown, html or infracost, default from Accept"),
+    fuzzy: bool = Query(False, description="Price instance types that are not found as their closest match"),
+    explain: bool = Query(False, description="Dry run: explain each line item's cost, without recording the estimate"),
+):
+    """
+    Estimate the cost of a set of cloud resources
+
+    Request body:
+    {
+        "resources": [
+            {
+                "name": str,            # Optional line item label
+                "provider": str,        # aws | gcp | azure, default aws
+                "instance_type": str,   # e.g. m5.large
+                "region": str,          # e.g. us-east-1
+                "count": int,           # Optional, default 1
+                "hours": float,         # Running hours per month, default UNITS_HOURS_PER_MONTH
+                "runs": int             # Optional, runs the hours are split into, billed per run
+            }
+        ],
+        "period": {"start": date, "end": date},  # Optional, also price these days (end included)
+        "continue_on_error": bool,      # Optional, list items that cannot be priced as unsupported
+        "project": str,                 # Optional, recorded with the estimate history
+        "labels": {str: str}            # Optional metadata, e.g. CI run URL
+    }
+
+    Query parameters:
+        currency: Output currency, amounts are converted from USD
+        format: json (default), csv, markdown, html or infracost; without it the Accept header decides
+        fuzzy: Price instance types that are not found as their closest match of
+            FUZZY_MATCH_MIN_CONFIDENCE, reported with the requested type and the confidence
+        catalog_version: Catalog snapshot day to price from, e.g. 2026-05-01
+        explain: Dry run returning, per line item, the SKU and unit price matched,
+            the catalog snapshot, the discounts applied and the arithmetic of its
+            cost; the estimate is not recorded
+
+    A request with items that cannot be priced is answered with 422 problem
+    details listing every such item, e.g. unknown instance types with the
+    closest ones of their region. With POLICY_PATH set, the response holds
+    the verdict of the cost policies on the estimate.
+    """
+    try:
+        fmt = _output_format(http_request, format, infracost=True)
+        if cost_estimator is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+        estimator = _pinned_estimator(catalog_version)
+
+        providers = [
+            spec.provider
+            for spec in [*request.resources, *request.databases, *request.object_storage, *request.serverless]
+        ]
+        with observe_estimate("estimate", providers), _fuzzy(fuzzy):
+            result = await asyncio.to_thread(
+                _cached_result,
+                _fuzzy_key(f"estimate@{catalog_version}" if catalog_version else "estimate", fuzzy),
+                request.dict(exclude={"project", "labels"}),
+                lambda: _validated_estimate(estimator, request), EstimateResult,
+            )
+        # Explained estimates are dry runs, priced from today's snapshot unless pinned
+        record = None if explain else record_estimate(
+            store, KIND_RESOURCES,
+            tenant_id=current_tenant(),
+            request=request.dict(exclude={"project", "labels"}),
+            result=result.dict(),
+            monthly_cost=result.monthly_cost,
+            catalog_version=catalog_version,
+            pricing_model_version=PRICING_MODEL_VERSION,
+            project=request.project,
+            labels=request.labels,
+        )
+        if explain and store is not None:
+            catalog_version = catalog_version or datetime.now(timezone.utc).date()
+
+        estimate, exchange_rate = _in_currency(result.dict(), currency)
+
+        response = {
+            "estimate_id": record.id if record else None,
+            "estimate": estimate,
+            "exchange_rate": exchange_rate,
+            "budget_warnings": await asyncio.to_thread(
+                _budget_warnings, result.monthly_cost, request.project, request.labels
+            ),
+            "policy": await _policy_verdict(
+                estimate_input(KIND_RESOURCES, result.dict(), request.project, request.labels)
+            ),
+            "catalog_version": record.catalog_version if record else catalog_version,
+            "pricing_model_version": PRICING_MODEL_VERSION,
+            "explanation": [e.dict() for e in explain_estimate(result, catalog_version)] if explain else None,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt == FORMAT_INFRACOST:
+            return _infracost(KIND_RESOURCES, estimate, request.project, response["estimate_id"])
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, "Cost estimate", "estimate", response, "estimate")
+        return response
+
+    except (HTTPException, ValidationProblem):
+        raise
+    except PriceNotFoundError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    except (ProviderNotFoundError, UnsupportedCurrencyError) as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Cost estimation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Cost estimation failed: {str(e)}")
+
+
+@app.post("/estimate/batch", tags=["estimation"], response_model=BatchEstimateResponse, responses=FORMATTED_RESPONSES)
+async def estimate_batch(
+    request: BatchEstimateRequest,
+    http_request: Request,
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+    format: Optional[str] = Query(None, description="json, csv, markdown or html, default from the Accept header"),
+    fuzzy: bool = Query(False, description="Price instance types that are not found as their closest match"),
+):
+    """
+    Estimate up to BATCH_MAX_ITEMS independent resources in one call
+
+    Request body:
+    {
+        "items": [
+            {
+                "id": str,              # Optional, echoed in the item's result, e.g. a Terraform address
+                "provider": str,        # Same fields as the resources of POST /estimate
+                "instance_type": str,
+                "region": str,
+                "count": int,
+                "hours": float
+            }
+        ]
+    }
+
+    Items are priced concurrently. An item that cannot be priced gets an
+    error, with the closest instance types of its region, in its result and
+    is left out of the totals. With ?fuzzy=true an instance type that is not
+    found is priced as its closest match of FUZZY_MATCH_MIN_CONFIDENCE.
+    Batches are not recorded in the estimate history.
+    """
+    try:
+        fmt = _output_format(http_request, format)
+        if batch_estimator is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+
+        with observe_estimate("batch", [item.provider for item in request.items]), _fuzzy(fuzzy):
+            result = await asyncio.to_thread(batch_estimator.estimate, request)
+
+        estimate, exchange_rate = _in_currency(result.dict(), currency)
+
+        response = {
+            "estimate": estimate,
+            "exchange_rate": exchange_rate,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, "Batch estimate", "batch-estimate", response, "estimate")
+        return response
+
+    except HTTPException:
+        raise
+    except (ValueError, UnsupportedCurrencyError) as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Batch estimation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Batch estimation failed: {str(e)}")
+
+@app.post("/compare", tags=["estimation"], response_model=CompareResponse, responses=FORMATTED_RESPONSES)
+async def compare_costs(
+    request: CompareRequest,
+    http_request: Request,
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+    format: Optional[str] = Query(None, description="json, csv, markdown or html, default from the Accept header"),
+):
+    """
+    Compare equivalent instance options across providers
+
+    Request body:
+    {
+        "vcpus": float,               # Minimum vCPUs per instance
+        "memory_gb": float,           # Minimum memory per instance (GiB)
+        "gpus": int,                  # Optional minimum GPUs
+        "arch": str,                  # Optional: x86_64 | arm64
+        "storage_gb": float,          # Optional block storage per instance
+        "storage_tier": str,          # hdd | standard | premium, default standard
+        "count": int,                 # Number of instances, default 1
+        "hours": float,               # Running hours per month, default UNITS_HOURS_PER_MONTH
+        "providers": [str],           # Default aws, gcp, azure
+        "regions": {str: [str]},      # Optional allowed regions per provider
+        "geography": str,             # Region group when regions are not set: us | eu | kr | jp
+        "max_options_per_provider": int
+    }
+
+    Query parameters:
+        currency: Output currency, amounts are converted from USD
+        format: json (default), csv, markdown or html; without it the Accept header decides
+    """
+    try:
+        fmt = _output_format(http_request, format)
+        if comparer is None:
+            raise HTTPException(status_code=503, detail="Service starting: comparer not ready")
+
+        with observe_estimate("compare", request.providers):
+            result = await asyncio.to_thread(
+                _cached_result, "compare", request.dict(), lambda: comparer.compare(request), CompareResult
+            )
+        comparison, exchange_rate = _in_currency(result.dict(), currency)
+
+        response = {
+            "comparison": comparison,
+            "exchange_rate": exchange_rate,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, "Cost comparison", "comparison", response, "comparison")
+        return response
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Cost comparison failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Cost comparison failed: {str(e)}")
+
+@app.post(
+    "/estimate/kubernetes",
+    tags=["estimation"],
+    response_model=KubernetesEstimateResponse,
+    responses=FORMATTED_RESPONSES,
+)
+async def estimate_kubernetes_cost(
+    request: KubernetesEstimateRequest,
+    http_request: Request,
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+    format: Optional[str] = Query(None, description="json, csv, markdown, html or infracost, default from Accept"),
+):
+    """
+    Estimate the monthly cost of Kubernetes manifests
+
+    Request body:
+    {
+        "manifests": str | [str],         # YAML (Deployments, StatefulSets, PVCs, ...)
+        "provider": str,                  # aws | gcp | azure, default aws
+        "region": str,
+        "node_instance_type": str,        # Node type used to derive vCPU/GiB rates (not on autopilot)
+        "gpu_node_instance_type": str,    # Optional node type of GPU workloads
+        "gpu_sharing": int,               # Pods sharing one GPU, default 1
+        "node_pools": [{                  # Optional, pods are bin-packed onto these to project node count
+            "instance_type": str, "name": str, "labels": {str: str},
+            "taints": [{"key": str, "value": str, "effect": str}],
+            "max_pods": int, "min_nodes": int, "max_nodes": int
+        }],
+        "storage_class_map": {str: str},  # Optional storage class -> volume type overrides
+        "hours": float,                   # Running hours per month, default UNITS_HOURS_PER_MONTH
+        "project": str,                   # Optional, recorded with the estimate history
+        "labels": {str: str}              # Optional metadata, e.g. CI run URL
+    }
+
+    Query parameters:
+        currency: Output currency, amounts are converted from USD
+        format: json (default), csv, markdown, html or infracost; without it the Accept header decides
+    """
+    try:
+        fmt = _output_format(http_request, format, infracost=True)
+        if k8s_estimator is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+
+        with observe_estimate("kubernetes", [request.provider]):
+            result = await asyncio.to_thread(
+                _cached_result, "kubernetes", request.dict(exclude={"project", "labels"}),
+                lambda: k8s_estimator.estimate(request), KubernetesEstimateResult,
+            )
+        record = await asyncio.to_thread(
+            record_estimate, store, KIND_KUBERNETES,
+            tenant_id=current_tenant(),
+            request=request.dict(exclude={"project", "labels"}),
+            result=result.dict(),
+            monthly_cost=result.monthly_cost,
+            pricing_model_version=PRICING_MODEL_VERSION,
+            project=request.project,
+            labels=request.labels,
+        )
+
+        estimate, exchange_rate = _in_currency(result.dict(), currency)
+
+        response = {
+            "estimate_id": record.id if record else None,
+            "estimate": estimate,
+            "exchange_rate": exchange_rate,
+            "budget_warnings": await asyncio.to_thread(
+                _budget_warnings, result.monthly_cost, request.project, request.labels
+            ),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt == FORMAT_INFRACOST:
+            return _infracost(KIND_KUBERNETES, estimate, request.project, response["estimate_id"], request.hours)
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, "Kubernetes estimate", "kubernetes-estimate", response, "estimate")
+        return response
+
+    except HTTPException:
+        raise
+    except PriceNotFoundError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    except (ValueError, ProviderNotFoundError) as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Kubernetes cost estimation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Kubernetes cost estimation failed: {str(e)}")
+
+@app.get("/estimate/kubernetes/usage", tags=["estimation"], response_model=KubernetesUsageEstimateResponse)
+async def estimate_kubernetes_usage_cost(
+    namespace: Optional[str] = None,
+    window: Optional[str] = Query(None, description="Usage window, e.g. 24h or 7d (default K8S_USAGE_WINDOW)"),
+    hours: Optional[float] = Query(None, description="Running hours per month (default UNITS_HOURS_PER_MONTH)"),
+    pricing_model: str = "on_demand",
+    provider: Optional[str] = Query(None, description="Provider of nodes whose providerID names none"),
+    region: Optional[str] = Query(None, description="Region of nodes without a region label"),
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)")
+):
+    """
+    Estimate the monthly cost of a running cluster from measured usage
+
+    Workloads are priced at the CPU and memory they used over the window,
+    as measured by Prometheus, and at what they requested; the difference
+    is reported as waste per workload and namespace.
+
+    Query parameters:
+        namespace: Only workloads of this namespace
+        window: Usage window, default K8S_USAGE_WINDOW
+        hours: Running hours per month, default UNITS_HOURS_PER_MONTH
+        pricing_model: Node pricing: on_demand or spot
+        provider: Provider of nodes whose providerID names none, default K8S_NODE_PROVIDER
+        region: Region of nodes without a region label, default K8S_NODE_REGION
+        currency: Output currency, amounts are converted from USD
+    """
+    try:
+        if usage_estimator is None:
+            raise HTTPException(
+                status_code=503, detail="Usage estimates need a Prometheus (K8S_USAGE_PROMETHEUS_URL)"
+            )
+
+        request = UsageEstimateRequest(
+            namespace=namespace,
+            window=window or settings.k8s_usage_window,
+            hours=hours or hours_per_month(),
+            pricing_model=pricing_model,
+            provider=provider,
+            region=region,
+        )
+        with observe_estimate("kubernetes_usage", [request.provider or settings.k8s_node_provider]):
+            result = await asyncio.to_thread(usage_estimator.estimate, request)
+
+        estimate, exchange_rate = _in_currency(result.dict(), currency)
+
+        return {
+            "estimate": estimate,
+            "exchange_rate": exchange_rate,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except PrometheusError as e:
+        raise HTTPException(status_code=502, detail=str(e))
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Kubernetes usage estimation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Kubernetes usage estimation failed: {str(e)}")
+
+@app.get("/recommendations/rightsizing", tags=["recommendations"], response_model=RightsizingResponse)
+async def get_rightsizing_recommendations(
+    namespace: Optional[str] = None,
+    window: Optional[str] = Query(None, description="Usage window, e.g. 24h or 7d (default K8S_USAGE_WINDOW)"),
+    headroom: Optional[float] = Query(None, description="Capacity kept above usage in percent (default RIGHTSIZING_HEADROOM_PERCENT)"),
+    exclude_namespace: Optional[List[str]] = Query(None, description="Namespace never right-sized, repeatable"),
+    exclude_workload: Optional[List[str]] = Query(None, description="namespace/name pattern, repeatable"),
+    exclude_node: Optional[List[str]] = Query(None, description="Node name or instance type pattern, repeatable"),
+    hours: Optional[float] = Query(None, description="Running hours per month (default UNITS_HOURS_PER_MONTH)"),
+    pricing_model: str = "on_demand",
+    provider: Optional[str] = Query(None, description="Provider of nodes whose providerID names none"),
+    region: Optional[str] = Query(None, description="Region of nodes without a region label"),
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)")
+):
+    """
+    Right-sizing recommendations of a running cluster from measured usage
+
+    Workloads requesting more than their usage plus headroom are
+    recommended lower requests, and nodes the cheapest instance type that
+    fits their pods afterwards, e.g. "downsize m5.2xlarge → m5.xlarge,
+    save $83/mo". Exclusions add to the configured RIGHTSIZING_EXCLUDE_*.
+
+    Query parameters:
+        namespace: Only workloads of this namespace; nodes are not recommended
+        window: Usage window, default K8S_USAGE_WINDOW
+        headroom: Capacity kept above usage in percent
+        exclude_namespace, exclude_workload, exclude_node: Exclusions, repeatable
+        hours: Running hours per month, default UNITS_HOURS_PER_MONTH
+        pricing_model: Node pricing: on_demand or spot
+        provider: Provider of nodes whose providerID names none, default K8S_NODE_PROVIDER
+        region: Region of nodes without a region label, default K8S_NODE_REGION
+        currency: Output currency, amounts are converted from USD
+    """
+    try:
+        if rightsizing_recommender is None:
+            raise HTTPException(
+                status_code=503, detail="Right-sizing needs a Prometheus (K8S_USAGE_PROMETHEUS_URL)"
+            )
+
+        request = RightsizingRequest(
+            namespace=namespace,
+            window=window or settings.k8s_usage_window,
+            hours=hours or hours_per_month(),
+            pricing_model=pricing_model,
+            provider=provider,
+            region=region,
+            headroom_percent=headroom,
+            exclude_namespaces=exclude_namespace or [],
+            exclude_workloads=exclude_workload or [],
+            exclude_nodes=exclude_node or [],
+        )
+        result = await asyncio.to_thread(rightsizing_recommender.recommend, request)
+
+        recommendations, exchange_rate = _in_currency(result.dict(), currency)
+
+        return {
+            "recommendations": recommendations,
+            "exchange_rate": exchange_rate,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except PrometheusError as e:
+        raise HTTPException(status_code=502, detail=str(e))
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Right-sizing failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Right-sizing failed: {str(e)}")
+
+@app.get("/recommendations/nodepools", tags=["recommendations"], response_model=NodePoolRecommendationResponse)
+async def get_node_pool_recommendations(
+    http_request: Request,
+    architecture: Optional[List[str]] = Query(None, description="Allowed architecture (amd64, arm64), repeatable"),
+    family: Optional[List[str]] = Query(None, description="Allowed instance type pattern, e.g. m6*, repeatable"),
+    spot_percent: float = Query(0.0, description="Share of each pool's nodes run as spot, in percent"),
+    zones: int = Query(1, description="Zones each pool's nodes are spread evenly across"),
+    max_pools: int = Query(3, description="Node pools of general-purpose instance types"),
+    max_pods: int = Query(110, description="Pods per node"),
+    hours: Optional[float] = Query(None, description="Running hours per month (default UNITS_HOURS_PER_MONTH)"),
+    provider: Optional[str] = Query(None, description="Provider pools are priced in (default that of most nodes)"),
+    region: Optional[str] = Query(None, description="Region pools are priced in (default that of most nodes)"),
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)")
+):
+    """
+    Cheapest node pool composition for the cluster's current workloads (operators only)
+
+    The workloads running in the cluster are bin-packed onto candidate
+    instance types, as the cluster autoscaler would scale them up, and
+    the cheapest mix of up to max_pools types is returned with its
+    node counts, spot/on-demand split and savings over the current
+    nodes, e.g. "4 x m6g.xlarge + 1 x r6g.2xlarge: $612/mo vs $980/mo
+    now, save $368/mo". Workloads requesting GPUs get GPU pools of
+    their own. nodeSelectors and required node affinity on instance
+    type, architecture or OS are honored.
+
+    Query parameters:
+        architecture: Allowed architectures, repeatable, default any
+        family: Allowed instance type patterns (m6*, c7g.*), repeatable, default any
+        spot_percent: Share of each pool's nodes run as spot where the type has a spot price
+        zones: Zones to spread across; node counts are rounded up to a multiple of it
+        max_pools: Node pools of general-purpose types, 1-5
+        max_pods: Pods per node (kubelet maxPods)
+        hours: Running hours per month, default UNITS_HOURS_PER_MONTH
+        provider, region: Where pools are priced, default where most current nodes run
+        currency: Output currency, amounts are converted from USD
+    """
+    try:
+        if node_pool_optimizer is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+        _require_operator(http_request)
+
+        request = NodePoolRequest(
+            hours=hours or hours_per_month(),
+            provider=provider,
+            region=region,
+            architectures=architecture or [],
+            instance_families=family or [],
+            spot_percent=spot_percent,
+            zones=zones,
+            max_pools=max_pools,
+            max_pods=max_pods,
+        )
+        result = await asyncio.to_thread(node_pool_optimizer.recommend, request)
+
+        recommendation, exchange_rate = _in_currency(result.dict(), currency)
+
+        return {
+            "recommendation": recommendation,
+            "exchange_rate": exchange_rate,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Node pool recommendation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Node pool recommendation failed: {str(e)}")
+
+@app.get("/recommendations/arm", tags=["recommendations"], response_model=ArmMigrationResponse)
+async def get_arm_recommendations(
+    http_request: Request,
+    compatible: Optional[List[str]] = Query(None, description="namespace/name pattern arm64-ready, repeatable"),
+    incompatible: Optional[List[str]] = Query(None, description="namespace/name pattern not arm64-ready, repeatable"),
+    assume_compatible: bool = Query(False, description="Count workloads of unknown compatibility as compatible"),
+    family: Optional[List[str]] = Query(None, description="ARM instance type pattern, e.g. m7g.*, repeatable"),
+    hours: Optional[float] = Query(None, description="Running hours per month (default UNITS_HOURS_PER_MONTH)"),
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)")
+):
+    """
+    Savings of moving the cluster's x86 workloads to ARM nodes (operators only)
+
+    Each x86 node type is matched with the cheapest ARM type of the same
+    shape, e.g. "3 x m5.xlarge → m6g.xlarge, save $84/mo", and each
+    workload's requests are priced on both. Workloads are compatible or
+    incompatible as listed, as their kcloud.io/arm-compatible annotation
+    says, or as their pod spec tells (GPUs or an amd64 nodeSelector make
+    them incompatible, tolerating the arm64 node taint compatible), and
+    unknown otherwise.
+
+    Query parameters:
+        compatible, incompatible: Workload compatibility flags, namespace/name patterns, repeatable
+        assume_compatible: Count unknown workloads in the savings
+        family: ARM instance type patterns nodes may move to, repeatable, default any
+        hours: Running hours per month, default UNITS_HOURS_PER_MONTH
+        currency: Output currency, amounts are converted from USD
+    """
+    try:
+        if arm_migration_analyzer is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+        _require_operator(http_request)
+
+        request = ArmMigrationRequest(
+            hours=hours or hours_per_month(),
+            compatible=compatible or [],
+            incompatible=incompatible or [],
+            assume_compatible=assume_compatible,
+            instance_families=family or [],
+        )
+        result = await asyncio.to_thread(arm_migration_analyzer.recommend, request)
+
+        recommendations, exchange_rate = _in_currency(result.dict(), currency)
+
+        return {
+            "recommendations": recommendations,
+            "exchange_rate": exchange_rate,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"ARM migration analysis failed: {e}")
+        raise HTTPException(status_code=500, detail=f"ARM migration analysis failed: {str(e)}")
+
+@app.get("/recommendations/commitments", tags=["recommendations"], response_model=CommitmentsResponse)
+async def get_commitment_recommendations(
+    lookback_days: Optional[int] = Query(None, description="Days of usage analyzed (default COMMITMENT_LOOKBACK_DAYS)"),
+    coverage: Optional[float] = Query(None, description="Percent of usage hours to cover (COMMITMENT_COVERAGE_PERCENT)"),
+    provider: Optional[str] = None,
+    type: Optional[List[str]] = Query(None, description="reserved or savings_plan, repeatable"),
+    term: Optional[List[str]] = Query(None, description="1yr or 3yr, repeatable"),
+    payment_option: Optional[List[str]] = Query(None, description="no_upfront, partial_upfront, all_upfront"),
+    min_savings: float = Query(1.0, description="Savings a recommendation needs (USD/month)"),
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)")
+):
+    """
+    Commitment purchases recommended from the caller's tenant's ingested usage
+
+    Instance hours of the ingested billing exports are averaged per day
+    and instance type; each is recommended the reserved instance,
+    savings plan or committed use purchase saving the most, sized to
+    cover the share of usage hours closest to the coverage target, e.g.
+    "buy 8 x m5.xlarge reserved 1yr no_upfront in us-east-1, save
+    $420/mo", with cumulative costs by month showing its break-even.
+
+    Query parameters:
+        lookback_days: Days of usage analyzed, ending yesterday (7-365)
+        coverage: Share of usage hours commitments should cover, in percent
+        provider: Only usage of this provider
+        type, term, payment_option: Commitment options considered, repeatable, default all
+        min_savings: Savings a recommendation needs (USD/month)
+        currency: Output currency, amounts are converted from USD
+    """
+    try:
+        if commitment_recommender is None:
+            raise HTTPException(status_code=503, detail="Commitment recommendations need a store (STORE_URL)")
+
+        request = CommitmentRequest(
+            lookback_days=lookback_days,
+            coverage_percent=coverage,
+            provider=provider,
+            types=type or [],
+            terms=term or [],
+            payment_options=payment_option or [],
+            min_monthly_savings=min_savings,
+        )
+        report = await asyncio.to_thread(commitment_recommender.recommend, current_tenant(), request)
+
+        recommendations, exchange_rate = _in_currency(report.dict(), currency)
+
+        return {
+            "recommendations": recommendations,
+            "exchange_rate": exchange_rate,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Commitment recommendation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Commitment recommendation failed: {str(e)}")
+
+@app.get("/recommendations/idle", tags=["recommendations"], response_model=IdleResourcesResponse)
+async def get_idle_resources(
+    kind: Optional[str] = Query(None, description="instance, volume, load_balancer or node_pool"),
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)")
+):
+    """
+    Idle resources of the caller's tenant with their monthly waste
+
+    Finds unattached volumes, load balancers without targets or
+    requests, stopped instances still billed for their volumes and node
+    pools running no pods in the inventory reported through POST
+    /inventory. Waste is estimated at list prices; findings that cannot
+    be priced are listed in unpriced.
+
+    Query parameters:
+        kind: Only resources of this kind
+        currency: Output currency, amounts are converted from USD
+    """
+    try:
+        if idle_detector is None:
+            raise HTTPException(status_code=503, detail="Idle resource detection needs a store (STORE_URL)")
+        tenant_id = current_tenant()
+        _require_tenant(tenant_id)
+
+        report = await asyncio.to_thread(idle_detector.detect, tenant_id, kind)
+        report, exchange_rate = _in_currency(report.dict(), currency)
+
+        return {
+            "report": report,
+            "exchange_rate": exchange_rate,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Idle resource detection failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Idle resource detection failed: {str(e)}")
+
+@app.post("/analyze/region-migration", tags=["analysis"], response_model=RegionMigrationResponse)
+async def analyze_region_migration(
+    request: RegionMigrationRequest,
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)")
+):
+    """
+    Cost delta of moving resources to another region
+
+    Prices the listed resources, and the instances, node pools and
+    volumes of the caller's inventory whose labels match the selector, in
+    their current region and in target_region, e.g. to plan a disaster
+    recovery site or a data residency move. SKUs the target region does
+    not offer are flagged unavailable, with the target region's most
+    similar instance types.
+
+    Request body:
+        {
+            "target_region": "eu-central-1",
+            "provider": "aws",                       # Optional, default the resources' cloud
+            "resources": [{"instance_type": "m5.large", "region": "us-east-1", "count": 3}],
+            "selector": {"app": "web"},              # Optional, inventory labels
+            "hours": 730                             # Optional, hours of inventory resources
+        }
+
+    Query parameters:
+        currency: Output currency, amounts are converted from USD
+    """
+    try:
+        if region_migration_analyzer is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+        if request.selector is not None and store is None:
+            raise HTTPException(status_code=503, detail="Inventory label selectors need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        _require_tenant(tenant_id)
+
+        result = await asyncio.to_thread(region_migration_analyzer.analyze, tenant_id, request)
+        analysis, exchange_rate = _in_currency(result.dict(), currency)
+
+        return {
+            "analysis": analysis,
+            "exchange_rate": exchange_rate,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Region migration analysis failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Region migration analysis failed: {str(e)}")
+
+@app.post(
+    "/estimate/cluster",
+    tags=["estimation"],
+    response_model=ClusterEstimateResponse,
+    responses=FORMATTED_RESPONSES,
+)
+async def estimate_cluster_cost(
+    request: ClusterEstimateRequest,
+    http_request: Request,
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+    format: Optional[str] = Query(None, description="json, csv, markdown or html, default from the Accept header"),
+):
+    """
+    Estimate the current monthly cost of the cluster (operators only)
+
+    Nodes, running workloads, persistent volume claims and LoadBalancer
+    Services are read from the API server (K8S_KUBECONFIG, or in-cluster
+    credentials) and priced, with a breakdown by namespace.
+
+    Request body:
+    {
+        "hours": float,                   # Running hours per month, default UNITS_HOURS_PER_MONTH
+        "storage_class_map": {str: str},  # Optional storage class -> volume type overrides
+        "project": str,                   # Recorded project, default K8S_CLUSTER_NAME
+        "labels": {str: str}              # Optional metadata
+    }
+    """
+    try:
+        fmt = _output_format(http_request, format)
+        if cluster_estimator is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+        _require_operator(http_request)
+
+        with observe_estimate("cluster", [settings.k8s_node_provider]):
+            result = await asyncio.to_thread(cluster_estimator.estimate, request)
+        project = request.project or settings.k8s_cluster_name
+        record = await asyncio.to_thread(
+            record_estimate, store, KIND_CLUSTER,
+            tenant_id=current_tenant(),
+            request=request.dict(exclude={"project", "labels"}),
+            result=result.dict(),
+            monthly_cost=result.monthly_cost,
+            pricing_model_version=PRICING_MODEL_VERSION,
+            project=project,
+            labels=request.labels,
+        )
+
+        estimate, exchange_rate = _in_currency(result.dict(), currency)
+
+        response = {
+            "estimate_id": record.id if record else None,
+            "estimate": estimate,
+            "exchange_rate": exchange_rate,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, "Cluster estimate", "cluster-estimate", response, "estimate")
+        return response
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Cluster cost estimation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Cluster cost estimation failed: {str(e)}")
+
+@app.post("/estimate/stream", tags=["estimation"], responses={200: {"content": {SSE_MEDIA_TYPE: {}}}})
+async def estimate_stream(
+    request: StreamEstimateRequest,
+    http_request: Request,
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+):
+    """
+    Stream a batch or cluster estimate as Server-Sent Events, one event per resource as it is priced
+
+    Request body:
+    {
+        "kind": str,        # batch | cluster (operators only)
+        "request": {...}    # Body of POST /estimate/batch or /estimate/cluster
+    }
+
+    Events (text/event-stream, data as JSON):
+        start   {"kind", "total"}           # total: items of a batch, null for a cluster
+        item    {"type", "index", "item"}   # type: resource (a batch item's result), node, workload,
+                                            # volume, load_balancer or control_plane
+        result  {...}                       # Body of the synchronous endpoint's response
+        error   {"status", "detail"}        # The estimate failed; the stream ends
+
+    Invalid requests are answered before the stream starts, invalid fields
+    of the request with 422 problem details. A comment line is sent after
+    15 seconds without events.
+    """
+    try:
+        if request.kind == STREAM_BATCH:
+            if batch_estimator is None:
+                raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+            batch = _stream_request(BatchEstimateRequest, request.request)
+            if len(batch.items) > batch_estimator.max_items:
+                raise ValueError(f"A batch holds at most {batch_estimator.max_items} items, got {len(batch.items)}")
+            work = functools.partial(_stream_batch, batch, currency)
+        else:
+            if cluster_estimator is None:
+                raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+            _require_operator(http_request)
+            cluster = _stream_request(ClusterEstimateRequest, request.request)
+            work = functools.partial(_stream_cluster, cluster, currency)
+        # An unsupported currency is refused before the stream starts
+        await asyncio.to_thread(_in_currency, {}, currency)
+
+        return StreamingResponse(
+            stream_events(work),
+            media_type=SSE_MEDIA_TYPE,
+            headers={"Cache-Control": "no-cache", "X-Accel-Buffering": "no"},
+        )
+
+    except (HTTPException, ValidationProblem):
+        raise
+    except (ValueError, UnsupportedCurrencyError) as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Estimate stream failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Estimate stream failed: {str(e)}")
+
+def _stream_request(model, request: Dict[str, Any]):
+    """
+    The request of a stream as its estimate's model
+
+    Raises:
+        ValidationProblem: Every violation of the request, with field paths under request
+    """
+    try:
+        return model.parse_obj(request)
+    except ValidationError as e:
+        raise ValidationProblem(violations_of(e.errors(), prefix="request"))
+
+def _stream_batch(request: BatchEstimateRequest, currency: Optional[str], emit: Emit) -> Dict[str, Any]:
+    """Price a batch, emitting each item's result, and return the body of /estimate/batch"""
+    emit(EVENT_START, {"kind": STREAM_BATCH, "total": len(request.items)})
+
+    def on_item(item: BatchItemResult) -> None:
+        emit(EVENT_ITEM, {"type": "resource", "index": item.index, "item": _in_currency(item.dict(), currency)[0]})
+
+    with observe_estimate("batch", [item.provider for item in request.items]):
+        result = batch_estimator.estimate(request, on_item=on_item)
+    estimate, exchange_rate = _in_currency(result.dict(), currency)
+    return {
+        "estimate": estimate,
+        "exchange_rate": exchange_rate,
+        "timestamp": datetime.utcnow().isoformat()
+    }
+
+def _stream_cluster(request: ClusterEstimateRequest, currency: Optional[str], emit: Emit) -> Dict[str, Any]:
+    """Estimate the cluster, emitting each priced node, workload and volume, and return the body of /estimate/cluster"""
+    emit(EVENT_START, {"kind": STREAM_CLUSTER, "total": None})
+    index = 0
+
+    def on_item(kind: str, item: Any) -> None:
+        nonlocal index
+        emit(EVENT_ITEM, {"type": kind, "index": index, "item": _in_currency(item.dict(), currency)[0]})
+        index += 1
+
+    with observe_estimate("cluster", [settings.k8s_node_provider]):
+        result = cluster_estimator.estimate(request, on_item=on_item)
+    record = record_estimate(
+        store, KIND_CLUSTER,
+        tenant_id=current_tenant(),
+        request=request.dict(exclude={"project", "labels"}),
+        result=result.dict(),
+        monthly_cost=result.monthly_cost,
+        pricing_model_version=PRICING_MODEL_VERSION,
+        project=request.project or settings.k8s_cluster_name,
+        labels=request.labels,
+    )
+    estimate, exchange_rate = _in_currency(result.dict(), currency)
+    return {
+        "estimate_id": record.id if record else None,
+        "estimate": estimate,
+        "exchange_rate": exchange_rate,
+        "timestamp": datetime.utcnow().isoformat()
+    }
+
+@app.post("/estimate/helm", tags=["estimation"], response_model=HelmEstimateResponse, responses=FORMATTED_RESPONSES)
+async def estimate_helm_cost(
+    http_request: Request,
+    region: str = Form(...),
+    node_instance_type: str = Form(...),
+    provider: str = Form("aws"),
+    chart: Optional[UploadFile] = File(None, description="Packaged chart archive (.tgz)"),
+    chart_ref: Optional[str] = Form(None, description="Chart name in repo_url, or an oci:// reference"),
+    repo_url: Optional[str] = Form(None),
+    version: Optional[str] = Form(None),
+    values: Optional[str] = Form(None, description="values.yaml content"),
+    values_file: Optional[UploadFile] = File(None),
+    release_name: str = Form("estimate"),
+    namespace: str = Form("default"),
+    storage_class_map: Optional[str] = Form(None, description="JSON object of storage class -> volume type"),
+    hours: Optional[float] = Form(None, description="Running hours per month (default UNITS_HOURS_PER_MONTH)"),
+    project: Optional[str] = Form(None),
+    labels: Optional[str] = Form(None, description="JSON object of metadata, e.g. CI run URL"),
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+    format: Optional[str] = Query(None, description="json, csv, markdown, html or infracost, default from Accept"),
+):
+    """
+    Estimate the monthly cost of a Helm chart
+
+    The chart is rendered with `helm template` and the manifests are priced
+    by the Kubernetes estimator. Send either a chart archive upload (`chart`)
+    or a repository reference (`chart_ref` + `repo_url`, or `oci://...`).
+    Amounts are converted from USD if the `currency` query parameter is set.
+    """
+    try:
+        fmt = _output_format(http_request, format, infracost=True)
+        if k8s_estimator is None or helm_renderer is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+        if (chart is None) == (chart_ref is None):
+            raise HTTPException(status_code=400, detail="Provide exactly one of chart upload or chart_ref")
+
+        if values_file is not None:
+            values = (await values_file.read()).decode("utf-8")
+        class_map = json.loads(storage_class_map) if storage_class_map else {}
+        label_map = json.loads(labels) if labels else {}
+
+        loop = asyncio.get_running_loop()
+        if chart is not None:
+            archive = await chart.read(settings.helm_max_chart_bytes + 1)
+            if len(archive) > settings.helm_max_chart_bytes:
+                raise HTTPException(status_code=413, detail="Chart archive too large")
+            manifests = await loop.run_in_executor(
+                None, lambda: helm_renderer.render_archive(archive, values, release_name, namespace)
+            )
+        else:
+            manifests = await loop.run_in_executor(
+                None, lambda: helm_renderer.render_reference(
+                    chart_ref, repo_url, version, values, release_name, namespace
+                )
+            )
+
+        request = KubernetesEstimateRequest(
+            manifests=manifests,
+            provider=provider,
+            region=region,
+            node_instance_type=node_instance_type,
+            storage_class_map=class_map,
+            hours=hours or hours_per_month(),
+        )
+        with observe_estimate("helm", [request.provider]):
+            result = await asyncio.to_thread(k8s_estimator.estimate, request)
+        chart_info = {
+            "source": chart.filename if chart is not None else chart_ref,
+            "repo_url": repo_url,
+            "version": version,
+            "release_name": release_name,
+            "namespace": namespace,
+        }
+        record = await asyncio.to_thread(
+            record_estimate, store, KIND_HELM,
+            tenant_id=current_tenant(),
+            request=dict(
+                request.dict(exclude={"manifests", "project", "labels"}),
+                chart=chart_info,
+                values=values,
+            ),
+            result=result.dict(),
+            monthly_cost=result.monthly_cost,
+            pricing_model_version=PRICING_MODEL_VERSION,
+            project=project,
+            labels=label_map,
+        )
+
+        estimate, exchange_rate = _in_currency(result.dict(), currency)
+
+        response = {
+            "estimate_id": record.id if record else None,
+            "estimate": estimate,
+            "exchange_rate": exchange_rate,
+            "budget_warnings": await asyncio.to_thread(_budget_warnings, result.monthly_cost, project, label_map),
+            "chart": chart_info,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt == FORMAT_INFRACOST:
+            return _infracost(KIND_HELM, estimate, project, response["estimate_id"], hours)
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, "Helm chart estimate", "helm-estimate", response, "estimate")
+        return response
+
+    except HTTPException:
+        raise
+    except HelmUnavailableError as e:
+        raise HTTPException(status_code=503, detail=str(e))
+    except PriceNotFoundError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    except (ValueError, ProviderNotFoundError) as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Helm cost estimation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Helm cost estimation failed: {str(e)}")
+
+@app.post(
+    "/estimate/terraform",
+    tags=["estimation"],
+    response_model=TerraformEstimateResponse,
+    responses=FORMATTED_RESPONSES,
+)
+async def estimate_terraform_cost(
+    http_request: Request,
+    plan: Dict[str, Any] = Body(..., description="Output of `terraform show -json`"),
+    region: Optional[str] = None,
+    hours: Optional[float] = Query(None, description="Running hours per month (default UNITS_HOURS_PER_MONTH)"),
+    project: Optional[str] = None,
+    label: Optional[List[str]] = Query(None, description="key=value metadata, repeatable"),
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+    format: Optional[str] = Query(None, description="json, csv, markdown, html or infracost, default from Accept"),
+    fuzzy: bool = Query(False, description="Price instance types that are not found as their closest match"),
+):
+    """
+    Estimate the monthly cost delta of a Terraform plan
+
+    Request body: the JSON plan (`terraform show -json plan.tfplan`)
+
+    Query parameters:
+        region: Fallback region for resources whose provider sets none
+        hours: Running hours per month, default UNITS_HOURS_PER_MONTH
+        project: Project the estimate is recorded under
+        label: key=value metadata kept with the estimate (e.g. ci_url=...), repeatable
+        currency: Output currency, amounts are converted from USD
+        format: json (default), csv, markdown, html or infracost; without it the Accept header decides
+        fuzzy: Price instance types that are not found as their closest match of
+            FUZZY_MATCH_MIN_CONFIDENCE, reported with the requested type and the confidence
+    """
+    try:
+        fmt = _output_format(http_request, format, infracost=True)
+        if terraform_estimator is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+
+        labels = _label_params(label)
+
+        request = TerraformEstimateRequest(
+            plan=plan, region=region, hours=hours or hours_per_month(), project=project, labels=labels
+        )
+        with observe_estimate("terraform", [
+            provider_short_name(rc.get("provider_name", ""))
+            for rc in plan.get("resource_changes") or [] if isinstance(rc, dict)
+        ]), _fuzzy(fuzzy):
+            result = await asyncio.to_thread(
+                _cached_result, _fuzzy_key("terraform", fuzzy), request.dict(exclude={"project", "labels"}),
+                lambda: terraform_estimator.estimate(request), TerraformEstimateResult,
+            )
+        record = await asyncio.to_thread(
+            record_estimate, store, KIND_TERRAFORM,
+            tenant_id=current_tenant(),
+            request=_terraform_request_summary(request),
+            result=result.dict(),
+            monthly_cost=result.monthly_delta,
+            pricing_model_version=PRICING_MODEL_VERSION,
+            project=project,
+            labels=labels,
+        )
+
+        estimate, exchange_rate = _in_currency(result.dict(), currency)
+
+        response = {
+            "estimate_id": record.id if record else None,
+            "estimate": estimate,
+            "exchange_rate": exchange_rate,
+            "budget_warnings": await asyncio.to_thread(_budget_warnings, result.monthly_delta, project, labels),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt == FORMAT_INFRACOST:
+            return _infracost(KIND_TERRAFORM, estimate, project, response["estimate_id"], hours)
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, "Terraform estimate", "terraform-estimate", response, "estimate")
+        return response
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Terraform cost estimation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Terraform cost estimation failed: {str(e)}")
+
+@app.get("/estimate/terraform/resource-types", tags=["estimation"], response_model=TerraformResourceTypesResponse)
+async def get_terraform_resource_types():
+    """List Terraform resource types with a cost mapper"""
+    return {
+        "resource_types": supported_resource_types(),
+        "timestamp": datetime.utcnow().isoformat()
+    }
+
+@app.post("/estimate/pulumi", tags=["estimation"], response_model=PulumiEstimateResponse, responses=FORMATTED_RESPONSES)
+async def estimate_pulumi_cost(
+    http_request: Request,
+    preview: Dict[str, Any] = Body(..., description="Output of `pulumi preview --json`"),
+    region: Optional[str] = None,
+    hours: Optional[float] = Query(None, description="Running hours per month (default UNITS_HOURS_PER_MONTH)"),
+    project: Optional[str] = None,
+    label: Optional[List[str]] = Query(None, description="key=value metadata, repeatable"),
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+    format: Optional[str] = Query(None, description="json, csv, markdown, html or infracost, default from Accept"),
+    fuzzy: bool = Query(False, description="Price instance types that are not found as their closest match"),
+):
+    """
+    Estimate the monthly cost delta of a Pulumi preview
+
+    Request body: the JSON preview (`pulumi preview --json`). Resources of
+    the aws, gcp, azure and openstack providers are priced by the mappers
+    of the Terraform resources they wrap.
+
+    Query parameters:
+        region: Fallback region for resources whose provider sets none
+        hours: Running hours per month, default UNITS_HOURS_PER_MONTH
+        project: Project the estimate is recorded under
+        label: key=value metadata kept with the estimate (e.g. ci_url=...), repeatable
+        currency: Output currency, amounts are converted from USD
+        format: json (default), csv, markdown, html or infracost; without it the Accept header decides
+        fuzzy: Price instance types that are not found as their closest match of
+            FUZZY_MATCH_MIN_CONFIDENCE, reported with the requested type and the confidence
+    """
+    try:
+        fmt = _output_format(http_request, format, infracost=True)
+        if pulumi_estimator is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+
+        labels = _label_params(label)
+
+        request = PulumiEstimateRequest(
+            preview=preview, region=region, hours=hours or hours_per_month(), project=project, labels=labels
+        )
+        providers = await asyncio.to_thread(lambda: preview_providers(load_preview(preview)))
+        with observe_estimate("pulumi", providers), _fuzzy(fuzzy):
+            result = await asyncio.to_thread(
+                _cached_result, _fuzzy_key("pulumi", fuzzy), request.dict(exclude={"project", "labels"}),
+                lambda: pulumi_estimator.estimate(request), TerraformEstimateResult,
+            )
+        record = await asyncio.to_thread(
+            record_estimate, store, KIND_PULUMI,
+            tenant_id=current_tenant(),
+            request=_pulumi_request_summary(request),
+            result=result.dict(),
+            monthly_cost=result.monthly_delta,
+            pricing_model_version=PRICING_MODEL_VERSION,
+            project=project,
+            labels=labels,
+        )
+
+        estimate, exchange_rate = _in_currency(result.dict(), currency)
+
+        response = {
+            "estimate_id": record.id if record else None,
+            "estimate": estimate,
+            "exchange_rate": exchange_rate,
+            "budget_warnings": await asyncio.to_thread(_budget_warnings, result.monthly_delta, project, labels),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt == FORMAT_INFRACOST:
+            return _infracost(KIND_PULUMI, estimate, project, response["estimate_id"], hours)
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, "Pulumi estimate", "pulumi-estimate", response, "estimate")
+        return response
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Pulumi cost estimation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Pulumi cost estimation failed: {str(e)}")
+
+@app.post(
+    "/estimate/cloudformation",
+    tags=["estimation"],
+    response_model=CloudFormationEstimateResponse,
+    responses=FORMATTED_RESPONSES,
+)
+async def estimate_cloudformation_cost(
+    request: CloudFormationEstimateRequest,
+    http_request: Request,
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+    format: Optional[str] = Query(None, description="json, csv, markdown, html or infracost, default from Accept"),
+    fuzzy: bool = Query(False, description="Price instance types that are not found as their closest match"),
+):
+    """
+    Estimate the monthly cost of the stack a CloudFormation template creates
+
+    Request body:
+    {
+        "template": {...} | str,   # Template document, or its JSON or YAML text (short-form tags allowed)
+        "parameters": {str: any},  # Optional parameter values, others take their Default
+        "region": str,             # Region the stack is deployed in (AWS::Region)
+        "hours": float,            # Running hours per month, default UNITS_HOURS_PER_MONTH
+        "project": str,            # Optional, recorded with the estimate history
+        "labels": {str: str}       # Optional metadata, e.g. CI run URL
+    }
+
+    Each logical resource is priced as the Terraform resource it converts
+    to; values known only once the stack is created are left to the
+    mappers' defaults.
+
+    Query parameters:
+        currency: Output currency, amounts are converted from USD
+        format: json (default), csv, markdown, html or infracost; without it the Accept header decides
+        fuzzy: Price instance types that are not found as their closest match of
+            FUZZY_MATCH_MIN_CONFIDENCE, reported with the requested type and the confidence
+    """
+    try:
+        fmt = _output_format(http_request, format, infracost=True)
+        if cloudformation_estimator is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+
+        with observe_estimate("cloudformation", ["aws"]), _fuzzy(fuzzy):
+            result = await asyncio.to_thread(
+                _cached_result, _fuzzy_key("cloudformation", fuzzy), request.dict(exclude={"project", "labels"}),
+                lambda: cloudformation_estimator.estimate(request), TerraformEstimateResult,
+            )
+        record = await asyncio.to_thread(
+            record_estimate, store, KIND_CLOUDFORMATION,
+            tenant_id=current_tenant(),
+            request=_cloudformation_request_summary(request),
+            result=result.dict(),
+            monthly_cost=result.monthly_delta,
+            pricing_model_version=PRICING_MODEL_VERSION,
+            project=request.project,
+            labels=request.labels,
+        )
+
+        estimate, exchange_rate = _in_currency(result.dict(), currency)
+
+        response = {
+            "estimate_id": record.id if record else None,
+            "estimate": estimate,
+            "exchange_rate": exchange_rate,
+            "budget_warnings": await asyncio.to_thread(
+                _budget_warnings, result.monthly_delta, request.project, request.labels
+            ),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt == FORMAT_INFRACOST:
+            return _infracost(KIND_CLOUDFORMATION, estimate, request.project, response["estimate_id"], request.hours)
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, "CloudFormation estimate", "cloudformation-estimate", response, "estimate")
+        return response
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"CloudFormation cost estimation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"CloudFormation cost estimation failed: {str(e)}")
+
+@app.post(
+    "/estimate/crossplane",
+    tags=["estimation"],
+    response_model=CrossplaneEstimateResponse,
+    responses=FORMATTED_RESPONSES,
+)
+async def estimate_crossplane_cost(
+    request: CrossplaneEstimateRequest,
+    http_request: Request,
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+    format: Optional[str] = Query(None, description="json, csv, markdown, html or infracost, default from Accept"),
+    fuzzy: bool = Query(False, description="Price instance types that are not found as their closest match"),
+):
+    """
+    Estimate the monthly cost of the cloud resources Crossplane manifests create
+
+    Request body:
+    {
+        "manifests": str | [str],  # YAML of claims, composites, managed resources, Compositions and XRDs
+        "region": str,             # Optional region of managed resources whose forProvider sets none
+        "hours": float,            # Running hours per month, default UNITS_HOURS_PER_MONTH
+        "project": str,            # Optional, recorded with the estimate history
+        "labels": {str: str}       # Optional metadata, e.g. CI run URL
+    }
+
+    Claims and composites are rendered with the compositions of the
+    manifests and those configured by CROSSPLANE_COMPOSITIONS_PATH; the
+    managed resources they compose are priced with the Terraform mappers.
+
+    Query parameters:
+        currency: Output currency, amounts are converted from USD
+        format: json (default), csv, markdown, html or infracost; without it the Accept header decides
+        fuzzy: Price instance types that are not found as their closest match of
+            FUZZY_MATCH_MIN_CONFIDENCE, reported with the requested type and the confidence
+    """
+    try:
+        fmt = _output_format(http_request, format, infracost=True)
+        if crossplane_estimator is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+
+        with observe_estimate("crossplane", ["crossplane"]), _fuzzy(fuzzy):
+            result = await asyncio.to_thread(
+                _cached_result, _fuzzy_key("crossplane", fuzzy), request.dict(exclude={"project", "labels"}),
+                lambda: crossplane_estimator.estimate(request), TerraformEstimateResult,
+            )
+        record = await asyncio.to_thread(
+            record_estimate, store, KIND_CROSSPLANE,
+            tenant_id=current_tenant(),
+            request=_crossplane_request_summary(request),
+            result=result.dict(),
+            monthly_cost=result.monthly_delta,
+            pricing_model_version=PRICING_MODEL_VERSION,
+            project=request.project,
+            labels=request.labels,
+        )
+
+        estimate, exchange_rate = _in_currency(result.dict(), currency)
+
+        response = {
+            "estimate_id": record.id if record else None,
+            "estimate": estimate,
+            "exchange_rate": exchange_rate,
+            "budget_warnings": await asyncio.to_thread(
+                _budget_warnings, result.monthly_delta, request.project, request.labels
+            ),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt == FORMAT_INFRACOST:
+            return _infracost(KIND_CROSSPLANE, estimate, request.project, response["estimate_id"], request.hours)
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, "Crossplane estimate", "crossplane-estimate", response, "estimate")
+        return response
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Crossplane cost estimation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Crossplane cost estimation failed: {str(e)}")
+
+@app.post("/estimate/diff", tags=["estimation"], response_model=EstimateDiffResponse, responses=FORMATTED_RESPONSES)
+async def diff_estimates(
+    request: DiffRequest,
+    http_request: Request,
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+    format: Optional[str] = Query(None, description="json, csv, markdown or html, default from the Accept header"),
+    notify: bool = Query(False, description="Publish the diff to the tenant's webhooks as an estimate.diff event"),
+):
+    """
+    Compare two estimates and check the head against cost thresholds
+
+    Request body:
+    {
+        "base": {"label": "main", "terraform": {...}},    # or estimate_id, resources, kubernetes
+        "head": {"label": "PR #42", "terraform": {...}},
+        "thresholds": {                                  # Optional, default DIFF_MAX_*
+            "max_increase_cost": float,                  # USD/month
+            "max_increase_percent": float,
+            "max_monthly_cost": float
+        },
+        "region": str,                                   # Fallback region of Terraform plans
+        "hours": float                                   # Running hours per month of Terraform plans
+    }
+
+    The response tells whether the head passed, with the changed
+    resources and a markdown summary to post as a pull request comment,
+    and the verdict of the cost policies of POLICY_PATH on the diff.
+
+    Query parameters:
+        currency: Output currency, amounts are converted from USD
+        format: json (default), csv, markdown or html; without it the Accept header decides
+        notify: Publish the diff (USD) to the webhooks subscribed to estimate.diff,
+            e.g. a Slack or Teams channel of the pull request's team
+    """
+    try:
+        fmt = _output_format(http_request, format)
+        if estimate_differ is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+
+        result = await asyncio.to_thread(estimate_differ.diff, request, current_tenant())
+
+        diff, exchange_rate = _in_currency(result.dict(), currency)
+        if exchange_rate is not None:
+            diff["markdown"] = render_markdown(DiffResult.parse_obj(diff))
+
+        head = request.head.resources or request.head.kubernetes
+        response = {
+            "diff": diff,
+            "exchange_rate": exchange_rate,
+            "policy": await _policy_verdict(diff_input(result.dict(), getattr(head, "labels", None))),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if notify and notification_dispatcher is not None:
+            delta = result.monthly_delta
+            notification_dispatcher.publish(Event(
+                type=EVENT_COST_DIFF,
+                tenant_id=current_tenant(),
+                summary=(
+                    f"{result.head_label} changes the monthly cost of {result.base_label} by "
+                    f"{'+' if delta >= 0 else '-'}${abs(delta):,.2f} ({'passed' if result.passed else 'failed'})"
+                ),
+                data={"diff": result.dict(exclude={"markdown"}), "labels": getattr(head, "labels", None) or {}},
+            ))
+        if fmt == FORMAT_MARKDOWN:
+            return Response(
+                content=diff["markdown"],
+                media_type=MEDIA_TYPES[fmt],
+                headers={"Vary": "Accept"},
+            )
+        if fmt != FORMAT_JSON:
+            document = dict(response, diff={k: v for k, v in diff.items() if k != "markdown"})
+            return _formatted(fmt, "Cost diff", "estimate-diff", document, "diff")
+        return response
+
+    except HTTPException:
+        raise
+    except LookupError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Estimate diff failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Estimate diff failed: {str(e)}")
+
+@app.get(
+    "/estimates/{estimate_id}",
+    tags=["history"],
+    response_model=EstimateRecordResponse,
+    responses=FORMATTED_RESPONSES,
+)
+async def get_estimate(
+    estimate_id: str,
+    http_request: Request,
+    currency: Optional[str] = Query(None, description="Convert the recorded USD result, e.g. EUR"),
+    format: Optional[str] = Query(None, description="json, csv, markdown, html or infracost, default from Accept"),
+):
+    """Get a recorded estimate with its request and result"""
+    try:
+        fmt = _output_format(http_request, format, infracost=True)
+        if store is None:
+            raise HTTPException(status_code=503, detail="Estimate history is not configured (STORE_URL)")
+
+        record = store.get_estimate(estimate_id, tenant_id=current_tenant())
+        if record is None:
+            raise HTTPException(status_code=404, detail=f"Estimate {estimate_id} not found")
+
+        estimate, exchange_rate = _in_currency(record.dict(), currency)
+
+        response = {
+            "estimate": estimate,
+            "exchange_rate": exchange_rate,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt == FORMAT_INFRACOST:
+            return _infracost(
+                record.kind, estimate["result"], record.project, record.id,
+                record.request.get("hours"), record.created_at,
+            )
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, f"Estimate {estimate_id}", f"estimate-{estimate_id}", response, "estimate")
+        return response
+
+    except HTTPException:
+        raise
+    except (UnsupportedCurrencyError, ValueError) as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Estimate lookup failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Estimate lookup failed: {str(e)}")
+
+@app.post("/estimates/{estimate_id}/rerun", tags=["history"], response_model=EstimateRerunResponse)
+async def rerun_estimate(
+    estimate_id: str,
+    catalog_version: Optional[date] = Query(
+        None, description="Catalog snapshot day to price from instead of the recorded one (YYYY-MM-DD)"
+    ),
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+):
+    """
+    Re-run a recorded resources estimate against the catalogs it was priced from
+
+    The recorded request is priced from the catalog snapshot recorded with
+    it (the day it was made for estimates recorded before versions were)
+    with the current pricing rules, tenant prices and discount rules. The
+    re-run is not recorded.
+    """
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Estimate history is not configured (STORE_URL)")
+        if cost_estimator is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+
+        record = store.get_estimate(estimate_id, tenant_id=current_tenant())
+        if record is None:
+            raise HTTPException(status_code=404, detail=f"Estimate {estimate_id} not found")
+        if record.kind != KIND_RESOURCES:
+            raise HTTPException(status_code=400, detail=f"Only {KIND_RESOURCES} estimates can be re-run")
+
+        version = catalog_version or record.catalog_version or record.created_at.date()
+        result = _pinned_estimator(version).estimate(EstimateRequest(**record.request))
+        logger.info(
+            f"Re-ran estimate {estimate_id} against catalog snapshot {version}: "
+            f"${record.monthly_cost:,.2f} -> ${result.monthly_cost:,.2f}/month"
+        )
+        rerun, exchange_rate = _in_currency({
+            "estimate": result.dict(),
+            "recorded_monthly_cost": record.monthly_cost,
+            "monthly_delta": round(result.monthly_cost - record.monthly_cost, 4),
+        }, currency)
+
+        return dict(
+            rerun,
+            estimate_id=estimate_id,
+            catalog_version=version,
+            pricing_model_version=PRICING_MODEL_VERSION,
+            recorded_pricing_model_version=record.pricing_model_version,
+            exchange_rate=exchange_rate,
+            timestamp=datetime.utcnow().isoformat(),
+        )
+
+    except HTTPException:
+        raise
+    except PriceNotFoundError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    except (ProviderNotFoundError, UnsupportedCurrencyError) as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Estimate re-run failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Estimate re-run failed: {str(e)}")
+
+@app.get("/estimates/{estimate_id}/review", tags=["history"], response_model=EstimateReviewResponse)
+async def get_estimate_review(estimate_id: str):
+    """
+    Review status of a recorded estimate
+
+    The status is pending until a tenant admin approves or rejects the
+    estimate, then the latest decision; comments and decisions are listed
+    oldest first.
+    """
+    try:
+        if estimate_reviews is None:
+            raise HTTPException(status_code=503, detail="Estimate history is not configured (STORE_URL)")
+
+        review = estimate_reviews.review(current_tenant(), estimate_id)
+        return {"review": review.dict(), "timestamp": datetime.utcnow().isoformat()}
+
+    except HTTPException:
+        raise
+    except LookupError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    except Exception as e:
+        logger.error(f"Estimate review lookup failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Estimate review lookup failed: {str(e)}")
+
+@app.post("/estimates/{estimate_id}/comments", tags=["history"], response_model=EstimateReviewResponse)
+async def comment_estimate(estimate_id: str, spec: CommentSpec, http_request: Request):
+    """Add a comment to a recorded estimate, without changing its review status"""
+    try:
+        if estimate_reviews is None:
+            raise HTTPException(status_code=503, detail="Estimate history is not configured (STORE_URL)")
+
+        principal = getattr(http_request.state, "principal", None)
+        review = estimate_reviews.comment(
+            current_tenant(), estimate_id, principal.subject if principal else "anonymous", spec.comment
+        )
+        return {"review": review.dict(), "timestamp": datetime.utcnow().isoformat()}
+
+    except HTTPException:
+        raise
+    except LookupError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    except Exception as e:
+        logger.error(f"Estimate comment failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Estimate comment failed: {str(e)}")
+
+@app.post("/estimates/{estimate_id}/approve", tags=["history"], response_model=EstimateReviewResponse)
+async def approve_estimate(estimate_id: str, http_request: Request, spec: Optional[DecisionSpec] = None):
+    """
+    Approve a recorded estimate (admin scope)
+
+    Deployment pipelines wait for the approval before they proceed; an
+    estimate.reviewed event is delivered to the tenant's webhooks.
+    """
+    return _decide_estimate(estimate_id, http_request, DECISION_APPROVED, spec)
+
+@app.post("/estimates/{estimate_id}/reject", tags=["history"], response_model=EstimateReviewResponse)
+async def reject_estimate(estimate_id: str, http_request: Request, spec: Optional[DecisionSpec] = None):
+    """Reject a recorded estimate (admin scope), with the reason as the comment"""
+    return _decide_estimate(estimate_id, http_request, DECISION_REJECTED, spec)
+
+def _decide_estimate(estimate_id: str, http_request: Request, decision: str, spec: Optional[DecisionSpec]) -> dict:
+    """Record an admin's decision on an estimate and notify the tenant's webhooks"""
+    try:
+        if estimate_reviews is None:
+            raise HTTPException(status_code=503, detail="Estimate history is not configured (STORE_URL)")
+        principal = getattr(http_request.state, "principal", None)
+        if principal is None:
+            # Approvals name their approver, so they need authentication
+            raise HTTPException(status_code=403, detail="Estimate approvals need authentication (AUTH_ENABLED)")
+
+        tenant_id = current_tenant()
+        comment = spec.comment if spec else ""
+        review = estimate_reviews.decide(tenant_id, estimate_id, principal.subject, decision, comment)
+        if notification_dispatcher is not None:
+            notification_dispatcher.publish(Event(
+                type=EVENT_ESTIMATE_REVIEWED,
+                tenant_id=tenant_id,
+                summary=(
+                    f"Estimate {estimate_id} (${review.monthly_cost:,.2f}/month) {decision} by {principal.subject}"
+                ),
+                data={"review": review.dict(exclude={"annotations"}), "comment": comment},
+            ))
+        return {"review": review.dict(), "timestamp": datetime.utcnow().isoformat()}
+
+    except HTTPException:
+        raise
+    except LookupError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Estimate review failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Estimate review failed: {str(e)}")
+
+def _pinned_estimator(catalog_version: Optional[date]) -> CostEstimator:
+    """Estimator pricing from the catalog snapshot of a day, the current one without a day"""
+    if catalog_version is None:
+        return cost_estimator
+    if pinned_estimators is None:
+        raise HTTPException(status_code=503, detail="Catalog snapshots need a store (STORE_URL)")
+    try:
+        check_catalog_version(store, catalog_version, datetime.now(timezone.utc).date())
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except LookupError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    return pinned_estimators.get(catalog_version)
+
+def _build_pinned_estimator(snapshot: date) -> CostEstimator:
+    """Estimator whose providers read a catalog snapshot, with the current tenant prices and exchange rates"""
+    registry = build_pinned_registry(settings.pricing_providers, settings, snapshot)
+    if tenant_prices is not None:
+        registry.set_overrides(tenant_prices.lookup)
+    registry.set_exchange_rates(lambda currency: currency_converter.rate(currency)[0])
+    return cost_estimator.with_registry(registry)
+
+@app.get("/estimates", tags=["history"], response_model=EstimateListResponse, responses=FORMATTED_RESPONSES)
+async def list_estimates(
+    http_request: Request,
+    project: Optional[str] = None,
+    from_: Optional[datetime] = Query(None, alias="from", description="Created at or after (ISO 8601)"),
+    to: Optional[datetime] = Query(None, description="Created before (ISO 8601)"),
+    limit: int = Query(100, ge=1, le=1000),
+    format: Optional[str] = Query(None, description="json, csv, markdown or html, default from the Accept header"),
+):
+    """
+    List recorded estimates, newest first
+
+    Query parameters:
+        project: Only estimates of this project
+        from: Only estimates created at or after this time
+        to: Only estimates created before this time
+        limit: Maximum number of estimates, default 100
+    """
+    try:
+        fmt = _output_format(http_request, format)
+        if store is None:
+            raise HTTPException(status_code=503, detail="Estimate history is not configured (STORE_URL)")
+        # Naive timestamps are UTC, as in the store
+        from_, to = (
+            t.replace(tzinfo=timezone.utc) if t is not None and t.tzinfo is None else t
+            for t in (from_, to)
+        )
+        if from_ is not None and to is not None and from_ >= to:
+            raise HTTPException(status_code=400, detail="'from' must be before 'to'")
+
+        records = store.list_estimates(
+            project=project, since=from_, until=to, limit=limit, tenant_id=current_tenant()
+        )
+
+        response = {
+            "estimates": [
+                record.dict(exclude={"request", "result"})
+                for record in records
+            ],
+            "count": len(records),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, "Estimate history", "estimates", response, None)
+        return response
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Estimate listing failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Estimate listing failed: {str(e)}")
+
+@app.post("/jobs", tags=["jobs"], response_model=JobResponse, status_code=202)
+async def submit_job(request: JobSubmitRequest, http_request: Request):
+    """
+    Queue an estimate of a large input, returning at once
+
+    Request body:
+    {
+        "kind": str,        # terraform | cluster | kubernetes | batch
+        "request": {...}    # Body of the kind's endpoint; a terraform job takes
+                            # {"plan": {...}, "region", "hours", "project", "labels"}
+    }
+
+    Poll GET /jobs/{job_id} for progress; a succeeded job holds the result
+    and the id of the recorded estimate. Cluster, cluster_scan and
+    billing_ingestion jobs are for operators only. Jobs are kept in the
+    store and resumed after a restart; billing_ingestion, cluster_scan and
+    chargeback_report jobs are retried JOB_RETRIES times, then dead-lettered.
+    """
+    try:
+        if job_runner is None:
+            raise HTTPException(status_code=503, detail="Estimation jobs are not configured (STORE_URL)")
+        if request.kind in (KIND_CLUSTER, KIND_CLUSTER_SCAN, KIND_BILLING_INGESTION):
+            _require_operator(http_request)
+
+        record = await asyncio.to_thread(job_runner.submit, current_tenant(), request.kind, request.request)
+
+        return {
+            "job": record.dict(),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Job submission failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Job submission failed: {str(e)}")
+
+@app.get("/jobs/{job_id}", tags=["jobs"], response_model=JobResponse)
+async def get_job(job_id: str):
+    """Get an estimation job with its progress and, once it has finished, its result or error"""
+    try:
+        if job_runner is None:
+            raise HTTPException(status_code=503, detail="Estimation jobs are not configured (STORE_URL)")
+
+        record = job_runner.get(job_id, tenant_id=current_tenant())
+        if record is None:
+            raise HTTPException(status_code=404, detail=f"Job {job_id} not found")
+
+        return {
+            "job": record.dict(),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Job lookup failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Job lookup failed: {str(e)}")
+
+@app.post("/jobs/{job_id}/retry", tags=["jobs"], response_model=JobResponse, status_code=202)
+async def retry_job(job_id: str):
+    """Queue a failed or dead-lettered job again, with its attempts reset"""
+    try:
+        if job_runner is None:
+            raise HTTPException(status_code=503, detail="Estimation jobs are not configured (STORE_URL)")
+
+        tenant_id = current_tenant()
+        record = await asyncio.to_thread(job_runner.requeue, job_id, tenant_id)
+        if record is None:
+            if job_runner.get(job_id, tenant_id=tenant_id) is None:
+                raise HTTPException(status_code=404, detail=f"Job {job_id} not found")
+            raise HTTPException(status_code=409, detail=f"Job {job_id} has not failed")
+
+        return {
+            "job": record.dict(),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Job retry failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Job retry failed: {str(e)}")
+
+@app.get("/jobs", tags=["jobs"], response_model=JobListResponse)
+async def list_jobs(
+    limit: int = Query(100, ge=1, le=1000),
+    state: Optional[str] = Query(None, description=f"Only jobs in this state: {', '.join(JOB_STATES)}")
+):
+    """List the tenant's estimation jobs without their requests and results, newest first"""
+    try:
+        if job_runner is None:
+            raise HTTPException(status_code=503, detail="Estimation jobs are not configured (STORE_URL)")
+        if state is not None and state not in JOB_STATES:
+            raise HTTPException(
+                status_code=400, detail=f"Unknown job state {state}, expected one of {', '.join(JOB_STATES)}"
+            )
+
+        records = job_runner.list(current_tenant(), limit=limit, states=[state] if state else None)
+
+        return {
+            "jobs": [record.dict(exclude={"request", "result", "worker"}) for record in records],
+            "count": len(records),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Job listing failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Job listing failed: {str(e)}")
+
+@app.get("/pricing/providers", tags=["pricing"], response_model=PricingProvidersResponse)
+async def get_pricing_providers():
+    """List compiled-in and enabled pricing providers"""
+    try:
+        if pricing_registry is None:
+            raise HTTPException(status_code=503, detail="Service starting: pricing registry not ready")
+
+        return {
+            "available": available_providers(),
+            "enabled": [
+                {"name": provider.name, "clouds": provider.clouds}
+                for provider in pricing_registry.providers()
+            ],
+            "offline": settings.offline,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        raise HTTPException(status_code=500, detail=f"Provider listing failed: {str(e)}")
+
+@app.get("/pricing/openstack/rates", tags=["pricing"], response_model=OpenStackRatesResponse)
+async def get_openstack_rates():
+    """Get the private cloud rate card flavors and volumes are priced with"""
+    return _openstack_rates_response(_openstack_provider())
+
+@app.put("/pricing/openstack/rates", tags=["pricing"], response_model=OpenStackRatesResponse)
+async def put_openstack_rates(rates: PrivateCloudRates):
+    """
+    Replace the private cloud rate card
+
+    The rates are kept across restarts, over OPENSTACK_RATES_PATH, until
+    they are reset with DELETE.
+    """
+    try:
+        provider = _openstack_provider()
+        provider.set_rates(rates)
+        _invalidate_results()
+        return _openstack_rates_response(provider)
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"OpenStack rate card update failed: {e}")
+        raise HTTPException(status_code=500, detail=f"OpenStack rate card update failed: {str(e)}")
+
+@app.delete("/pricing/openstack/rates", tags=["pricing"], response_model=OpenStackRatesResponse)
+async def delete_openstack_rates():
+    """Drop the rate card set through the API and use OPENSTACK_RATES_PATH again"""
+    try:
+        provider = _openstack_provider()
+        provider.reset_rates()
+        _invalidate_results()
+        return _openstack_rates_response(provider)
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"OpenStack rate card reset failed: {e}")
+        raise HTTPException(status_code=500, detail=f"OpenStack rate card reset failed: {str(e)}")
+
+@app.get("/pricing/accelerators", tags=["pricing"], response_model=AcceleratorTypesResponse)
+async def get_accelerator_types():
+    """List accelerator types GPU estimates know"""
+    return {
+        "accelerators": [accelerator._asdict() for accelerator in accelerator_types()],
+        "timestamp": datetime.utcnow().isoformat()
+    }
+
+@app.get("/pricing/licenses", tags=["pricing"], response_model=LicenseRatesResponse)
+async def get_license_rates():
+    """List the software license rate card resources name their licenses from"""
+    if cost_estimator is None:
+        raise HTTPException(status_code=503, detail="Estimator not initialized")
+
+    return {
+        "rates": cost_estimator.licenses.rates,
+        "timestamp": datetime.utcnow().isoformat()
+    }
+
+@app.post("/catalog/refresh", tags=["pricing"], response_model=CatalogRefreshResponse)
+async def refresh_catalogs():
+    """Re-download price catalogs of all enabled providers in the background"""
+    try:
+        if pricing_registry is None:
+            raise HTTPException(status_code=503, detail="Service starting: pricing registry not ready")
+        if settings.offline or catalog_scheduler is None:
+            raise HTTPException(status_code=409, detail="Catalog refresh is disabled in offline mode")
+        lifecycle.run_in_background(_refresh_all_catalogs, "catalog refresh")
+
+        return {
+            "message": "Catalog refresh started",
+            "providers": [provider.name for provider in pricing_registry.providers()],
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        raise HTTPException(status_code=500, detail=f"Catalog refresh start failed: {str(e)}")
+
+@app.get("/catalog/status", tags=["pricing"], response_model=CatalogStatusResponse)
+async def get_catalog_status():
+    """Last refresh, next refresh and freshness of each provider's price catalog"""
+    try:
+        if pricing_registry is None:
+            raise HTTPException(status_code=503, detail="Service starting: pricing registry not ready")
+        if catalog_scheduler is None:
+            raise HTTPException(status_code=409, detail="Catalog refresh is disabled in offline mode")
+
+        catalogs = catalog_scheduler.status()
+        return {
+            "catalogs": catalogs,
+            "degraded": [c.provider for c in catalogs if c.state == CATALOG_DEGRADED],
+            "refresh_interval_seconds": catalog_scheduler.interval,
+            "max_age_seconds": catalog_scheduler.max_age,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        raise HTTPException(status_code=500, detail=f"Catalog status failed: {str(e)}")
+
+@app.get("/catalog/providers", tags=["pricing"], response_model=CatalogProvidersResponse)
+async def get_catalog_providers():
+    """Clouds that can be priced, with their pricing providers and what they list"""
+    try:
+        if pricing_registry is None:
+            raise HTTPException(status_code=503, detail="Service starting: pricing registry not ready")
+
+        return {
+            "providers": cloud_capabilities(pricing_registry),
+            "offline": settings.offline,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        raise HTTPException(status_code=500, detail=f"Catalog provider listing failed: {str(e)}")
+
+@app.get("/catalog/{provider}/regions", tags=["pricing"], response_model=CatalogRegionsResponse)
+async def get_catalog_regions(
+    provider: str,
+    search: Optional[str] = Query(None, description="Only regions containing this text, e.g. ap-"),
+    limit: int = Query(100, ge=1, le=1000, description="Regions per page"),
+    offset: int = Query(0, ge=0, description="Regions skipped"),
+):
+    """
+    Regions of a cloud its pricing providers have prices for
+
+    Query parameters:
+        search: Only regions containing this text
+        limit: Regions per page (default 100)
+        offset: Regions skipped, next_offset of the previous page
+    """
+    try:
+        if pricing_registry is None:
+            raise HTTPException(status_code=503, detail="Service starting: pricing registry not ready")
+
+        regions = list_regions(pricing_registry, provider, search)
+        page, next_offset = paginate(regions, limit, offset)
+        return {
+            "provider": provider,
+            "regions": page,
+            "total": len(regions),
+            "limit": limit,
+            "offset": offset,
+            "next_offset": next_offset,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except ProviderNotFoundError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    except Exception as e:
+        raise HTTPException(status_code=500, detail=f"Catalog region listing failed: {str(e)}")
+
+@app.get("/catalog/{provider}/instance-types", tags=["pricing"], response_model=CatalogInstanceTypesResponse)
+async def get_catalog_instance_types(
+    provider: str,
+    region: Optional[str] = Query(None, description="Only instance types priced in this region"),
+    search: Optional[str] = Query(None, description="Only instance types containing this text, e.g. m5."),
+    min_vcpus: Optional[float] = Query(None, ge=0),
+    max_vcpus: Optional[float] = Query(None, ge=0),
+    min_memory_gb: Optional[float] = Query(None, ge=0),
+    max_memory_gb: Optional[float] = Query(None, ge=0),
+    arch: Optional[str] = Query(None, description="x86_64 or arm64"),
+    gpu: Optional[bool] = Query(None, description="Only instance types with (true) or without (false) GPUs"),
+    limit: int = Query(100, ge=1, le=1000, description="Instance types per page"),
+    offset: int = Query(0, ge=0, description="Instance types skipped"),
+):
+    """
+    Instance types of a cloud with their shapes and the regions pricing them
+
+    Instance types are listed by family and size. Shape filters leave out
+    instance types whose shape is not known.
+
+    Query parameters:
+        region: Only instance types priced in this region
+        search: Only instance types containing this text
+        min_vcpus, max_vcpus, min_memory_gb, max_memory_gb: Shape bounds
+        arch: x86_64 or arm64
+        gpu: With (true) or without (false) GPUs
+        limit: Instance types per page (default 100)
+        offset: Instance types skipped, next_offset of the previous page
+    """
+    try:
+        if pricing_registry is None:
+            raise HTTPException(status_code=503, detail="Service starting: pricing registry not ready")
+
+        instance_types = list_instance_types(
+            pricing_registry, provider, region=region, search=search,
+            min_vcpus=min_vcpus, max_vcpus=max_vcpus,
+            min_memory_gb=min_memory_gb, max_memory_gb=max_memory_gb,
+            arch=arch, gpu=gpu,
+        )
+        page, next_offset = paginate(instance_types, limit, offset)
+        return {
+            "provider": provider,
+            "instance_types": page,
+            "total": len(instance_types),
+            "limit": limit,
+            "offset": offset,
+            "next_offset": next_offset,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except ProviderNotFoundError as e:
+        raise HTTPException(status_code=404, detail=str(e))
+    except Exception as e:
+        raise HTTPException(status_code=500, detail=f"Catalog instance type listing failed: {str(e)}")
+
+@app.get("/catalog/changes", tags=["pricing"], response_model=PriceChangesResponse)
+async def get_catalog_changes(
+    provider: Optional[str] = Query(None, description="Pricing provider, e.g. aws; default every stored catalog"),
+    since: str = Query("30d", description="Days or weeks before today, e.g. 30d"),
+    limit: int = Query(100, ge=1, le=1000, description="Most changed prices listed"),
+):
+    """
+    Prices changed since a day and the caller's tenant's estimates they affect
+
+    Stored catalogs are compared SKU by SKU with their snapshots of the day
+    (the newest on or before it). Recent estimates priced at an old price
+    are listed with their monthly cost change at the new price.
+
+    Query parameters:
+        provider: Only catalogs of this provider
+        since: Days or weeks before today (default 30d)
+        limit: Most changed prices listed, largest relative change first (default 100)
+    """
+    try:
+        if price_change_tracker is None:
+            raise HTTPException(status_code=503, detail="Price change tracking needs a store (STORE_URL)")
+
+        report = price_change_tracker.report(
+            current_tenant(),
+            days=window_days(since),
+            provider=provider.lower() if provider else None,
+            limit=limit,
+        )
+
+        return {
+            "changes": report,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Price change report failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Price change report failed: {str(e)}")
+
+@app.post("/catalog/custom", tags=["pricing"], response_model=PriceSheetResponse)
+async def upload_custom_prices(
+    file: UploadFile = File(..., description="Price list as CSV (header row) or JSON"),
+    format: Optional[str] = Form(None, description="csv or json, default from the file name"),
+    provider: Optional[str] = Form(None, description="Provider of rows without a provider column"),
+    mode: str = Form("replace", description="replace the current price list, or merge into it"),
+):
+    """
+    Upload the caller's tenant's own price list
+
+    Rows give SKU, region, unit and price (plus optional provider, service,
+    pricing_model, description). Matching resources are priced from the
+    list in the tenant's estimates before falling back to public provider
+    prices. The list replaces the current one unless mode is merge.
+    """
+    try:
+        if store is None or tenant_prices is None:
+            raise HTTPException(status_code=503, detail="Custom price lists need a store (STORE_URL)")
+        if mode not in ("replace", "merge"):
+            raise HTTPException(status_code=400, detail="mode must be replace or merge")
+        tenant_id = current_tenant()
+        _require_tenant(tenant_id)
+
+        content = await file.read(settings.custom_price_sheet_max_bytes + 1)
+        if len(content) > settings.custom_price_sheet_max_bytes:
+            raise HTTPException(status_code=413, detail="Price list too large")
+        sheet_format = format.lower() if format else detect_format(file.filename, file.content_type)
+        sheet = parse_price_sheet(content, sheet_format, provider)
+
+        if mode == "merge":
+            current = PriceSheet(prices=[
+                record.dict(exclude={"tenant_id", "updated_at"})
+                for record in store.list_price_overrides(tenant_id)
+            ])
+            sheet = merge_sheets(current, sheet)
+
+        return _save_price_sheet(tenant_id, sheet)
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Custom price list upload failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Custom price list upload failed: {str(e)}")
+
+@app.get("/catalog/custom", tags=["pricing"], response_model=PriceSheetResponse)
+async def get_custom_prices():
+    """Get the caller's tenant's own price list"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Custom price lists need a store (STORE_URL)")
+
+        tenant_id = current_tenant()
+        return _price_sheet_response(tenant_id, store.list_price_overrides(tenant_id))
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Custom price list lookup failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Custom price list lookup failed: {str(e)}")
+
+@app.delete("/catalog/custom", tags=["pricing"], response_model=PriceSheetResponse)
+async def delete_custom_prices():
+    """Remove the caller's tenant's own price list; estimates use public prices again"""
+    try:
+        if store is None or tenant_prices is None:
+            raise HTTPException(status_code=503, detail="Custom price lists need a store (STORE_URL)")
+
+        return _save_price_sheet(current_tenant(), PriceSheet())
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Custom price list removal failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Custom price list removal failed: {str(e)}")
+
+@app.post("/discounts", tags=["discounts"], response_model=DiscountRuleResponse)
+async def create_discount_rule(rule: DiscountRuleSpec):
+    """
+    Define a discount rule for the caller's tenant
+
+    Percentage rules take a rate off every matching cost; tiered rules
+    discount bands of monthly usage, e.g. the first 750 hours free.
+    Rules apply to the tenant's next estimates.
+    """
+    try:
+        if store is None or discount_engine is None:
+            raise HTTPException(status_code=503, detail="Discount rules need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        _require_tenant(tenant_id)
+
+        return _save_discount_rule(tenant_id, rule)
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Discount rule creation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Discount rule creation failed: {str(e)}")
+
+@app.get("/discounts", tags=["discounts"], response_model=DiscountRuleListResponse)
+async def list_discount_rules():
+    """List the caller's tenant's discount rules in evaluation order"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Discount rules need a store (STORE_URL)")
+
+        rules = [DiscountRule.from_record(record) for record in store.list_discount_rules(current_tenant())]
+
+        return {
+            "rules": rules,
+            "count": len(rules),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Discount rule listing failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Discount rule listing failed: {str(e)}")
+
+@app.get("/discounts/{rule_id}", tags=["discounts"], response_model=DiscountRuleResponse)
+async def get_discount_rule(rule_id: str):
+    """Get a discount rule of the caller's tenant"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Discount rules need a store (STORE_URL)")
+
+        record = _discount_rule_of(current_tenant(), rule_id)
+
+        return {
+            "rule": DiscountRule.from_record(record),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Discount rule lookup failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Discount rule lookup failed: {str(e)}")
+
+@app.put("/discounts/{rule_id}", tags=["discounts"], response_model=DiscountRuleResponse)
+async def replace_discount_rule(rule_id: str, rule: DiscountRuleSpec):
+    """Replace a discount rule of the caller's tenant"""
+    try:
+        if store is None or discount_engine is None:
+            raise HTTPException(status_code=503, detail="Discount rules need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        current = _discount_rule_of(tenant_id, rule_id)
+
+        return _save_discount_rule(tenant_id, rule, current)
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Discount rule update failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Discount rule update failed: {str(e)}")
+
+@app.delete("/discounts/{rule_id}", tags=["discounts"], response_model=DiscountRuleResponse)
+async def delete_discount_rule(rule_id: str):
+    """Delete a discount rule of the caller's tenant; it no longer applies to estimates"""
+    try:
+        if store is None or discount_engine is None:
+            raise HTTPException(status_code=503, detail="Discount rules need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        record = _discount_rule_of(tenant_id, rule_id)
+
+        store.delete_discount_rule(rule_id, tenant_id=tenant_id)
+        discount_engine.invalidate(tenant_id)
+        _invalidate_results(tenant_id)
+        logger.info(f"Discount rule {rule_id} ({record.name}) of tenant {tenant_id} deleted")
+
+        return {
+            "rule": DiscountRule.from_record(record),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Discount rule deletion failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Discount rule deletion failed: {str(e)}")
+
+def _discount_rule_of(tenant_id: str, rule_id: str):
+    """A tenant's discount rule; 404 for unknown rules and rules of other tenants"""
+    record = store.get_discount_rule(rule_id, tenant_id=tenant_id)
+    if record is None:
+        raise HTTPException(status_code=404, detail=f"Discount rule {rule_id} not found")
+    return record
+
+def _save_discount_rule(tenant_id: str, rule: DiscountRuleSpec, current=None) -> Dict[str, Any]:
+    """Save a new or replaced rule and apply it to the tenant's next estimates"""
+    record = rule.to_record(tenant_id, rule_id=current.id if current else None)
+    if current is not None:
+        record.created_at = current.created_at
+    record = store.save_discount_rule(record)
+    discount_engine.invalidate(tenant_id)
+    _invalidate_results(tenant_id)
+    logger.info(f"Discount rule {record.id} ({record.name}) of tenant {tenant_id} saved")
+
+    return {
+        "rule": DiscountRule.from_record(record),
+        "timestamp": datetime.utcnow().isoformat()
+    }
+
+@app.get("/profiles", tags=["profiles"], response_model=UsageProfileListResponse)
+async def list_usage_profiles():
+    """List the usage profiles resources can reference: the built-in ones, then the tenant's own"""
+    try:
+        if cost_estimator is None:
+            raise HTTPException(status_code=503, detail="Estimator not initialized")
+
+        profiles = cost_estimator.profiles.list()
+
+        return {
+            "profiles": profiles,
+            "count": len(profiles),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Usage profile listing failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Usage profile listing failed: {str(e)}")
+
+@app.get("/profiles/{name}", tags=["profiles"], response_model=UsageProfileResponse)
+async def get_usage_profile(name: str):
+    """Get a built-in usage profile or one of the caller's tenant"""
+    try:
+        if cost_estimator is None:
+            raise HTTPException(status_code=503, detail="Estimator not initialized")
+
+        return {
+            "profile": _usage_profile_of(name),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Usage profile lookup failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Usage profile lookup failed: {str(e)}")
+
+@app.put("/profiles/{name}", tags=["profiles"], response_model=UsageProfileResponse)
+async def save_usage_profile(name: str, spec: UsageProfileSpec):
+    """
+    Create or replace a usage profile of the caller's tenant
+
+    Resources and databases referencing the profile by name run its
+    share of the month, e.g. 8 hours a day, 5 days a week. Built-in
+    profiles cannot be replaced.
+    """
+    try:
+        if store is None or usage_profiles is None:
+            raise HTTPException(status_code=503, detail="Usage profiles need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        _require_tenant(tenant_id)
+        name = validate_profile_name(name)
+        if name in BUILTIN_PROFILES:
+            raise HTTPException(status_code=400, detail=f"Built-in usage profile {name} cannot be changed")
+
+        record = spec.to_record(tenant_id, name)
+        current = store.get_usage_profile(tenant_id, name)
+        if current is not None:
+            record.created_at = current.created_at
+        record = store.save_usage_profile(record)
+        usage_profiles.invalidate(tenant_id)
+        _invalidate_results(tenant_id)
+        logger.info(f"Usage profile {name} of tenant {tenant_id} saved")
+
+        return {
+            "profile": UsageProfile.from_record(record),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Usage profile update failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Usage profile update failed: {str(e)}")
+
+@app.delete("/profiles/{name}", tags=["profiles"], response_model=UsageProfileResponse)
+async def delete_usage_profile(name: str):
+    """Delete a usage profile of the caller's tenant; estimates referencing it are refused"""
+    try:
+        if store is None or usage_profiles is None:
+            raise HTTPException(status_code=503, detail="Usage profiles need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        profile = _usage_profile_of(name)
+        if profile.builtin:
+            raise HTTPException(status_code=400, detail=f"Built-in usage profile {profile.name} cannot be changed")
+
+        store.delete_usage_profile(tenant_id, profile.name)
+        usage_profiles.invalidate(tenant_id)
+        _invalidate_results(tenant_id)
+        logger.info(f"Usage profile {profile.name} of tenant {tenant_id} deleted")
+
+        return {
+            "profile": profile,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Usage profile deletion failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Usage profile deletion failed: {str(e)}")
+
+def _usage_profile_of(name: str) -> UsageProfile:
+    """A built-in profile or one of the caller's tenant; 404 for unknown profiles"""
+    try:
+        return cost_estimator.profiles.get(name)
+    except UnknownProfileError:
+        raise HTTPException(status_code=404, detail=f"Usage profile {name} not found")
+
+@app.post("/budgets", tags=["budgets"], response_model=BudgetResponse)
+async def create_budget(budget: BudgetSpec):
+    """
+    Create a monthly budget for the caller's tenant
+
+    Without a project or labels the budget covers the tenant's whole spend.
+    Estimates whose projected spend reaches one of its thresholds return
+    a budget warning.
+    """
+    try:
+        if store is None or budget_evaluator is None:
+            raise HTTPException(status_code=503, detail="Budgets need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        _require_tenant(tenant_id)
+
+        return _save_budget(tenant_id, budget)
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Budget creation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Budget creation failed: {str(e)}")
+
+@app.get("/budgets", tags=["budgets"], response_model=BudgetListResponse)
+async def list_budgets():
+    """List the caller's tenant's budgets with their spend this month"""
+    try:
+        if store is None or budget_evaluator is None:
+            raise HTTPException(status_code=503, detail="Budgets need a store (STORE_URL)")
+
+        budgets = [_budget_info(record) for record in store.list_budgets(current_tenant())]
+
+        return {
+            "budgets": budgets,
+            "count": len(budgets),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Budget listing failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Budget listing failed: {str(e)}")
+
+@app.get("/budgets/{budget_id}", tags=["budgets"], response_model=BudgetResponse)
+async def get_budget(budget_id: str):
+    """Get a budget of the caller's tenant with its actual and projected spend this month"""
+    try:
+        if store is None or budget_evaluator is None:
+            raise HTTPException(status_code=503, detail="Budgets need a store (STORE_URL)")
+
+        return {
+            "budget": _budget_info(_budget_of(current_tenant(), budget_id)),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Budget lookup failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Budget lookup failed: {str(e)}")
+
+@app.put("/budgets/{budget_id}", tags=["budgets"], response_model=BudgetResponse)
+async def replace_budget(budget_id: str, budget: BudgetSpec):
+    """Replace a budget of the caller's tenant"""
+    try:
+        if store is None or budget_evaluator is None:
+            raise HTTPException(status_code=503, detail="Budgets need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        current = _budget_of(tenant_id, budget_id)
+
+        return _save_budget(tenant_id, budget, current)
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Budget update failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Budget update failed: {str(e)}")
+
+@app.delete("/budgets/{budget_id}", tags=["budgets"], response_model=BudgetResponse)
+async def delete_budget(budget_id: str):
+    """Delete a budget of the caller's tenant"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Budgets need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        record = _budget_of(tenant_id, budget_id)
+
+        store.delete_budget(budget_id, tenant_id=tenant_id)
+        logger.info(f"Budget {budget_id} ({record.name}) of tenant {tenant_id} deleted")
+
+        return {
+            "budget": Budget.from_record(record).dict(),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Budget deletion failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Budget deletion failed: {str(e)}")
+
+@app.post("/actuals", tags=["budgets"], response_model=ActualCostsResponse)
+async def record_actual_costs(batch: ActualCostBatch):
+    """
+    Record actual spend of the caller's tenant
+
+    Daily costs, e.g. from a billing export, count against the budgets
+    whose project and labels they match. Budgets whose projected spend
+    newly reaches a threshold notify the tenant's webhooks.
+    """
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Actual costs need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        _require_tenant(tenant_id)
+
+        records = store.add_actual_costs([entry.to_record(tenant_id) for entry in batch.costs])
+        logger.info(f"Recorded {len(records)} actual costs of tenant {tenant_id}")
+        if budget_alerts is not None:
+            budget_alerts.check(tenant_id)
+
+        return {
+            "recorded": len(records),
+            "total_amount": round(sum(r.amount for r in records), 4),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Actual cost recording failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Actual cost recording failed: {str(e)}")
+
+@app.post("/scenarios", tags=["scenarios"], response_model=ScenarioResponse)
+async def create_scenario(scenario: ScenarioSpec):
+    """
+    Create a scenario of the caller's tenant
+
+    A scenario is a baseline estimate request with named variations of it,
+    e.g. twice the traffic, spot instances or another region. Its costs are
+    compared through GET /scenarios/{scenario_id}/compare.
+    """
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Scenarios need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        _require_tenant(tenant_id)
+
+        return _save_scenario(tenant_id, scenario)
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Scenario creation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Scenario creation failed: {str(e)}")
+
+@app.get("/scenarios", tags=["scenarios"], response_model=ScenarioListResponse)
+async def list_scenarios():
+    """List the caller's tenant's scenarios"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Scenarios need a store (STORE_URL)")
+
+        scenarios = [Scenario.from_record(record).dict() for record in store.list_scenarios(current_tenant())]
+
+        return {
+            "scenarios": scenarios,
+            "count": len(scenarios),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Scenario listing failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Scenario listing failed: {str(e)}")
+
+@app.get("/scenarios/{scenario_id}", tags=["scenarios"], response_model=ScenarioResponse)
+async def get_scenario(scenario_id: str):
+    """Get a scenario of the caller's tenant"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Scenarios need a store (STORE_URL)")
+
+        return {
+            "scenario": Scenario.from_record(_scenario_of(current_tenant(), scenario_id)).dict(),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Scenario lookup failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Scenario lookup failed: {str(e)}")
+
+@app.put("/scenarios/{scenario_id}", tags=["scenarios"], response_model=ScenarioResponse)
+async def replace_scenario(scenario_id: str, scenario: ScenarioSpec):
+    """Replace a scenario of the caller's tenant"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Scenarios need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        current = _scenario_of(tenant_id, scenario_id)
+
+        return _save_scenario(tenant_id, scenario, current)
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Scenario update failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Scenario update failed: {str(e)}")
+
+@app.delete("/scenarios/{scenario_id}", tags=["scenarios"], response_model=ScenarioResponse)
+async def delete_scenario(scenario_id: str):
+    """Delete a scenario of the caller's tenant"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Scenarios need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        record = _scenario_of(tenant_id, scenario_id)
+
+        store.delete_scenario(scenario_id, tenant_id=tenant_id)
+        logger.info(f"Scenario {scenario_id} ({record.name}) of tenant {tenant_id} deleted")
+
+        return {
+            "scenario": Scenario.from_record(record).dict(),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Scenario deletion failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Scenario deletion failed: {str(e)}")
+
+@app.get("/scenarios/{scenario_id}/compare", tags=["scenarios"], response_model=ScenarioComparisonResponse)
+async def compare_scenario(
+    scenario_id: str,
+    currency: Optional[str] = Query(None, description="Output currency, e.g. EUR, KRW, JPY (default USD)"),
+):
+    """
+    Compare the costs of a scenario's baseline and variations
+
+    Each variation is priced as its own estimate with the caller's prices
+    and discounts. Rows hold the monthly cost of every item of the baseline
+    in each scenario; scenarios that cannot be priced (e.g. no spot price
+    in a target region) report an error in their total.
+
+    Query parameters:
+        currency: Output currency, amounts are converted from USD
+    """
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Scenarios need a store (STORE_URL)")
+        if scenario_comparer is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+
+        scenario = Scenario.from_record(_scenario_of(current_tenant(), scenario_id))
+        comparison, exchange_rate = _in_currency(scenario_comparer.compare(scenario).dict(), currency)
+
+        return {
+            "comparison": comparison,
+            "exchange_rate": exchange_rate,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except UnsupportedCurrencyError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Scenario comparison failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Scenario comparison failed: {str(e)}")
+
+def _scenario_of(tenant_id: str, scenario_id: str):
+    """A tenant's scenario; 404 for unknown scenarios and scenarios of other tenants"""
+    record = store.get_scenario(scenario_id, tenant_id=tenant_id)
+    if record is None:
+        raise HTTPException(status_code=404, detail=f"Scenario {scenario_id} not found")
+    return record
+
+def _save_scenario(tenant_id: str, scenario: ScenarioSpec, current=None) -> Dict[str, Any]:
+    record = scenario.to_record(tenant_id, scenario_id=current.id if current else None)
+    if current is not None:
+        record.created_at = current.created_at
+    record = store.save_scenario(record)
+    logger.info(
+        f"Scenario {record.id} ({record.name}, {len(record.variations)} variations) of tenant {tenant_id} saved"
+    )
+
+    return {
+        "scenario": Scenario.from_record(record).dict(),
+        "timestamp": datetime.utcnow().isoformat()
+    }
+
+@app.post("/inventory", tags=["recommendations"], response_model=InventoryResponse)
+async def record_inventory(batch: InventoryBatch):
+    """
+    Record a resource inventory of the caller's tenant
+
+    Each batch is a full snapshot of the resources one collector (source)
+    sees: resources of the source it no longer reports are removed.
+    Idle resources are found in the inventory at /recommendations/idle.
+    """
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Resource inventories need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        _require_tenant(tenant_id)
+
+        records = store.replace_inventory(
+            tenant_id, batch.source, [r.to_record(tenant_id, batch.source) for r in batch.resources]
+        )
+        logger.info(f"Recorded {len(records)} resources of tenant {tenant_id} from {batch.source}")
+
+        return {
+            "source": batch.source,
+            "recorded": len(records),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Inventory recording failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Inventory recording failed: {str(e)}")
+
+@app.get("/reports/accuracy", tags=["reports"], response_model=AccuracyReportResponse, responses=FORMATTED_RESPONSES)
+async def get_accuracy_report(
+    http_request: Request,
+    from_: Optional[str] = Query(None, alias="from", description="First month (YYYY-MM)"),
+    to: Optional[str] = Query(None, description="Last month (YYYY-MM), default the previous month"),
+    project: Optional[str] = None,
+    label: Optional[List[str]] = Query(None, description="key=value, repeatable"),
+    group_by: str = Query(GROUP_BY_PROJECT, description="project or label:<key>"),
+    format: Optional[str] = Query(None, description="json, csv, markdown or html, default from the Accept header"),
+):
+    """
+    Compare the caller's tenant's estimates with actual spend
+
+    For every completed month, the latest estimate of each project (or
+    label value) recorded before the month ended is compared with the
+    month's actual spend, with error percentages per month and group.
+
+    Query parameters:
+        from: First month, default two months before 'to'
+        to: Last month, default the previous month
+        project: Only estimates and spend of this project
+        label: Only estimates and spend with this key=value label, repeatable
+        group_by: Group by project (default) or by the value of a label, e.g. label:team
+    """
+    try:
+        fmt = _output_format(http_request, format)
+        if accuracy_reporter is None:
+            raise HTTPException(status_code=503, detail="Accuracy reports need a store (STORE_URL)")
+
+        report = accuracy_reporter.report(
+            current_tenant(),
+            since=from_,
+            until=to,
+            project=project,
+            labels=_label_params(label),
+            group_by=group_by,
+        )
+
+        response = {
+            "report": report,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, "Estimate accuracy", "accuracy-report", response, "report")
+        return response
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Accuracy report failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Accuracy report failed: {str(e)}")
+
+@app.get(
+    "/reports/chargeback/{period}",
+    tags=["reports"],
+    response_model=ChargebackReportResponse,
+    responses={200: {"content": {**FORMATTED_RESPONSES[200]["content"], "application/pdf": {}}}},
+)
+async def get_chargeback_report(
+    period: str,
+    http_request: Request,
+    format: Optional[str] = Query(None, description="json, csv, markdown, html or pdf, default from the Accept header"),
+    group_by: Optional[str] = Query(None, description="project, namespace or label:<key>, default CHARGEBACK_GROUP_BY"),
+    split: Optional[str] = Query(None, description="proportional, even or weighted"),
+    weights: Optional[str] = Query(None, description="group=weight,... for the weighted split"),
+    currency: Optional[str] = Query(None, description="Statement currency, e.g. EUR, KRW, JPY (default USD)")
+):
+    """
+    Showback/chargeback statement of the caller's tenant for a month
+
+    Actual spend of the month is itemized per provider SKU for every team
+    (or project, namespace), with the tenant's discount rules applied, and
+    spend without a group is split between the teams. The running month
+    is reported to date.
+
+    Path parameters:
+        period: Month, YYYY-MM
+
+    Query parameters:
+        format: json (default), csv, markdown, html or pdf; csv and pdf download as attachments
+        group_by: Group by project, namespace or the value of a label key
+        split: How shared spend is split, default ALLOCATION_SPLIT
+        weights: Group weights for the weighted split, e.g. platform=2,data=1
+        currency: Currency the line items are converted to and rounded in (MONEY_CURRENCY_ROUNDING)
+    """
+    try:
+        if chargeback_reporter is None:
+            raise HTTPException(status_code=503, detail="Chargeback reports need a store (STORE_URL)")
+        try:
+            fmt = negotiate_format(format, http_request.headers.get("accept"), allowed=(*FORMATS, "pdf"))
+        except ValueError as e:
+            raise HTTPException(status_code=400, detail=str(e))
+
+        rate, exchange_rate = 1.0, None
+        if currency:
+            if currency_converter is None:
+                raise HTTPException(status_code=503, detail="Service starting: currency converter not ready")
+            exchange_rate = currency_converter.exchange_rate(currency)
+            rate = exchange_rate["rate"]
+
+        tenant_id = current_tenant()
+        report = await asyncio.to_thread(
+            chargeback_reporter.report,
+            tenant_id,
+            period,
+            group_by or settings.chargeback_group_by,
+            split=split.lower() if split else None,
+            weights=parse_weights(weights) if weights else None,
+            currency=currency.upper() if currency else BASE_CURRENCY,
+            rate=rate,
+        )
+
+        filename = f"chargeback-{tenant_id}-{report.period}"
+        if fmt == FORMAT_CSV:
+            return Response(
+                content=render_csv(report),
+                media_type="text/csv",
+                headers={"Content-Disposition": f'attachment; filename="{filename}.{fmt}"'},
+            )
+        if fmt == "pdf":
+            return Response(
+                content=await asyncio.to_thread(render_pdf, report),
+                media_type="application/pdf",
+                headers={"Content-Disposition": f'attachment; filename="{filename}.{fmt}"'},
+            )
+
+        response = {
+            "report": report,
+            "exchange_rate": exchange_rate,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+        if fmt != FORMAT_JSON:
+            return _formatted(fmt, f"Chargeback {report.period}", filename, response, "report")
+        return response
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Chargeback report failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Chargeback report failed: {str(e)}")
+
+@app.get(
+    "/reports/focus",
+    tags=["reports"],
+    responses={200: {"content": {"text/csv": {}, "application/vnd.apache.parquet": {}}}},
+)
+async def get_focus_export(
+    from_: Optional[str] = Query(None, alias="from", description="First month (YYYY-MM), default 'to'"),
+    to: Optional[str] = Query(None, description="Last month (YYYY-MM), default the running month"),
+    dataset: str = Query(DATASET_ALL, description="actuals, estimates or all"),
+    project: Optional[str] = None,
+    format: str = Query("csv", description="csv or parquet"),
+):
+    """
+    Export the caller's tenant's actual costs and estimates as a FOCUS 1.0 dataset
+
+    Ingested actual costs are one row per day and SKU, estimates one row
+    per line item charged over the month they were made in, marked with
+    x_Estimated=true. Downloads as an attachment.
+
+    Query parameters:
+        from: First month, default 'to'
+        to: Last month, default the running month (to date)
+        dataset: actuals, estimates or all (default)
+        project: Only costs and estimates of this project
+        format: csv (default) or parquet
+    """
+    try:
+        if focus_exporter is None:
+            raise HTTPException(status_code=503, detail="FOCUS exports need a store (STORE_URL)")
+        fmt = format.strip().lower()
+        if fmt not in FOCUS_FORMATS:
+            raise HTTPException(
+                status_code=400, detail=f"Unknown format {format!r}, expected one of: {', '.join(FOCUS_FORMATS)}"
+            )
+
+        tenant_id = current_tenant()
+        rows = await asyncio.to_thread(
+            focus_exporter.rows, tenant_id, since=from_, until=to, dataset=dataset.lower(), project=project
+        )
+        content = await asyncio.to_thread(render_focus, rows, fmt)
+
+        start, end = focus_exporter.period(from_, to)
+        filename = f"focus-{tenant_id}-{start:%Y-%m}-{end - timedelta(days=1):%Y-%m}.{fmt}"
+        return Response(
+            content=content,
+            media_type="text/csv" if fmt == "csv" else "application/vnd.apache.parquet",
+            headers={"Content-Disposition": f'attachment; filename="{filename}"'},
+        )
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"FOCUS export failed: {e}")
+        raise HTTPException(status_code=500, detail=f"FOCUS export failed: {str(e)}")
+
+@app.post("/reports/schedules", tags=["reports"], response_model=ReportScheduleResponse)
+async def create_report_schedule(schedule: ReportScheduleSpec):
+    """
+    Schedule a weekly or monthly cost report of the caller's tenant
+
+    Request body:
+    {
+        "name": str,
+        "frequency": "weekly" | "monthly",
+        "day": int,                  # Weekday 1 (Monday) to 7, or day of the month 1-28, default 1
+        "recipients": [str],
+        "sections": [str],           # Optional: budgets, cost_changes, recommendations; default all
+        "enabled": bool
+    }
+
+    Reports are mailed through SMTP_HOST as HTML with the CSV report attached.
+    """
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Scheduled reports need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        _require_tenant(tenant_id)
+
+        record = store.save_report_schedule(schedule.to_record(tenant_id))
+        logger.info(f"Report schedule {record.id} ({record.name}) of tenant {tenant_id} created")
+
+        return {
+            "schedule": ReportSchedule.from_record(record),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Report schedule creation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Report schedule creation failed: {str(e)}")
+
+@app.get("/reports/schedules", tags=["reports"], response_model=ReportScheduleListResponse)
+async def list_report_schedules():
+    """List the caller's tenant's report schedules"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Scheduled reports need a store (STORE_URL)")
+
+        schedules = [ReportSchedule.from_record(record) for record in store.list_report_schedules(current_tenant())]
+
+        return {
+            "schedules": schedules,
+            "count": len(schedules),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Report schedule listing failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Report schedule listing failed: {str(e)}")
+
+@app.get("/reports/schedules/{schedule_id}", tags=["reports"], response_model=ReportScheduleResponse)
+async def get_report_schedule(schedule_id: str):
+    """Get a report schedule of the caller's tenant"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Scheduled reports need a store (STORE_URL)")
+
+        return {
+            "schedule": ReportSchedule.from_record(_report_schedule_of(current_tenant(), schedule_id)),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Report schedule lookup failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Report schedule lookup failed: {str(e)}")
+
+@app.put("/reports/schedules/{schedule_id}", tags=["reports"], response_model=ReportScheduleResponse)
+async def replace_report_schedule(schedule_id: str, schedule: ReportScheduleSpec):
+    """Replace a report schedule of the caller's tenant; the period last sent is kept"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Scheduled reports need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        current = _report_schedule_of(tenant_id, schedule_id)
+
+        record = schedule.to_record(tenant_id, schedule_id=current.id)
+        record.created_at = current.created_at
+        record.last_period = current.last_period
+        record = store.save_report_schedule(record)
+        logger.info(f"Report schedule {record.id} ({record.name}) of tenant {tenant_id} replaced")
+
+        return {
+            "schedule": ReportSchedule.from_record(record),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Report schedule update failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Report schedule update failed: {str(e)}")
+
+@app.delete("/reports/schedules/{schedule_id}", tags=["reports"], response_model=ReportScheduleResponse)
+async def delete_report_schedule(schedule_id: str):
+    """Delete a report schedule of the caller's tenant"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Scheduled reports need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        record = _report_schedule_of(tenant_id, schedule_id)
+
+        store.delete_report_schedule(schedule_id, tenant_id=tenant_id)
+        logger.info(f"Report schedule {schedule_id} ({record.name}) of tenant {tenant_id} deleted")
+
+        return {
+            "schedule": ReportSchedule.from_record(record),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Report schedule deletion failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Report schedule deletion failed: {str(e)}")
+
+@app.post("/reports/schedules/{schedule_id}/send", tags=["reports"], response_model=ReportSentResponse)
+async def send_report(schedule_id: str):
+    """
+    Mail a schedule's report of the last complete period now
+
+    The report is sent even if the schedule is disabled or was sent for
+    the period already, and the schedule's next delivery is unchanged.
+    """
+    try:
+        if store is None or report_scheduler is None:
+            raise HTTPException(status_code=503, detail="Scheduled reports need a store (STORE_URL) and SMTP_HOST")
+        record = _report_schedule_of(current_tenant(), schedule_id)
+
+        try:
+            report = await asyncio.to_thread(report_scheduler.send, record)
+        except (smtplib.SMTPException, OSError) as e:
+            raise HTTPException(status_code=502, detail=f"Report not sent: {e}")
+
+        return {
+            "report": report,
+            "recipients": record.recipients,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Report delivery failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Report delivery failed: {str(e)}")
+
+def _report_schedule_of(tenant_id: str, schedule_id: str):
+    """A tenant's report schedule; 404 for unknown schedules and schedules of other tenants"""
+    record = store.get_report_schedule(schedule_id, tenant_id=tenant_id)
+    if record is None:
+        raise HTTPException(status_code=404, detail=f"Report schedule {schedule_id} not found")
+    return record
+
+@app.get("/forecast", tags=["forecast"], response_model=ForecastResponse)
+async def get_forecast(
+    horizon: int = Query(30, description="Days forecast, e.g. 30, 90 or 365"),
+    model: Optional[str] = Query(None, description="linear, exponential_smoothing or seasonal"),
+    confidence: float = Query(0.95, description="Confidence level of the intervals"),
+    project: Optional[str] = None,
+    label: Optional[List[str]] = Query(None, description="key=value, repeatable")
+):
+    """
+    Forecast the caller's tenant's spend from its actual costs
+
+    Query parameters:
+        horizon: Days from today forecast (1-365, default 30)
+        model: Forecasting model, default FORECAST_MODEL
+        confidence: Confidence level of the intervals (default 0.95)
+        project: Only spend of this project
+        label: Only spend with this key=value label, repeatable
+    """
+    try:
+        if forecaster is None:
+            raise HTTPException(status_code=503, detail="Forecasts need a store (STORE_URL)")
+
+        forecast = await asyncio.to_thread(
+            forecaster.forecast,
+            current_tenant(),
+            horizon_days=horizon,
+            model=model.lower() if model else None,
+            confidence=confidence,
+            project=project,
+            labels=_label_params(label),
+        )
+
+        return {
+            "forecast": forecast,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Forecast failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Forecast failed: {str(e)}")
+
+def _require_grafana() -> GrafanaDatasource:
+    if grafana_datasource is None:
+        raise HTTPException(status_code=503, detail="The Grafana datasource needs a store (STORE_URL)")
+    return grafana_datasource
+
+@app.get("/grafana", tags=["grafana"])
+async def grafana_test():
+    """Connection test of the Grafana JSON datasource"""
+    _require_grafana()
+    return {"status": "ok"}
+
+@app.post("/grafana/search", tags=["grafana"])
+async def grafana_search(request: Optional[GrafanaSearchRequest] = None):
+    """
+    Metrics the Grafana JSON datasource serves
+
+    Returns a plain list of metric names, as the datasource protocol expects.
+    """
+    return _require_grafana().search(request.target if request else "")
+
+@app.post("/grafana/query", tags=["grafana"])
+async def grafana_query(request: GrafanaQueryRequest):
+    """
+    Series or tables of a Grafana panel's targets, from the caller's tenant
+
+    Targets name a metric with optional filters, e.g.
+    actual_cost{service=compute,by=project}; the response is the plain list
+    of the datasource protocol.
+    """
+    try:
+        datasource = _require_grafana()
+        return await asyncio.to_thread(datasource.query, current_tenant(), request)
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Grafana query failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Grafana query failed: {str(e)}")
+
+@app.post("/grafana/annotations", tags=["grafana"])
+async def grafana_annotations(request: GrafanaAnnotationRequest):
+    """Estimates recorded in a Grafana dashboard's time range, as annotations"""
+    try:
+        datasource = _require_grafana()
+        return await asyncio.to_thread(datasource.annotations, current_tenant(), request)
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Grafana annotations failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Grafana annotations failed: {str(e)}")
+
+@app.get("/anomalies", tags=["anomalies"], response_model=AnomaliesResponse)
+async def get_anomalies(
+    days: int = Query(7, description="Days analyzed, ending yesterday"),
+    detector: Optional[str] = Query(None, description="zscore or mad"),
+    threshold: Optional[float] = Query(None, description="Score a day reaches to be an anomaly"),
+    dimension: Optional[List[str]] = Query(None, description="service or project, repeatable")
+):
+    """
+    Anomalies of the caller's tenant's daily spend
+
+    Every day is scored against the ANOMALY_WINDOW_DAYS before it, per
+    provider service and per project; SKUs first spending in the period
+    are reported as new.
+
+    Query parameters:
+        days: Days before today analyzed (1-90, default 7)
+        detector: Detector, default ANOMALY_DETECTOR
+        threshold: Score threshold, default ANOMALY_THRESHOLD
+        dimension: Series scored for spikes, repeatable (default both)
+    """
+    try:
+        if anomaly_detector is None:
+            raise HTTPException(status_code=503, detail="Anomaly detection needs a store (STORE_URL)")
+
+        report = await asyncio.to_thread(
+            anomaly_detector.detect,
+            current_tenant(),
+            days=days,
+            dimensions=[d.lower() for d in dimension] if dimension else None,
+            detector=detector.lower() if detector else None,
+            threshold=threshold,
+        )
+
+        return {
+            "report": report,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Anomaly detection failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Anomaly detection failed: {str(e)}")
+
+@app.get("/allocation", tags=["allocation"], response_model=AllocationResponse)
+async def get_allocation(
+    group_by: str = Query(..., alias="groupBy", description="project, namespace or label:<key>"),
+    window: str = Query("30d", description="Days or weeks before today, e.g. 30d"),
+    split: Optional[str] = Query(None, description="proportional, even or weighted"),
+    weights: Optional[str] = Query(None, description="group=weight,... for the weighted split"),
+    cluster: Optional[str] = Query(None, description="Cluster whose estimates are allocated")
+):
+    """
+    Attribute the caller's tenant's costs to teams, projects or namespaces
+
+    Actual spend is grouped by its billing tags and estimated cost by the
+    labels and annotations of the latest cluster estimate's workloads.
+    Costs without a group, idle node capacity and shared namespaces are
+    split between the groups.
+
+    Query parameters:
+        groupBy: Group by project, namespace or the value of a label key, e.g. label:team
+        window: Days or weeks before today (default 30d)
+        split: How shared costs are split, default ALLOCATION_SPLIT
+        weights: Group weights for the weighted split, e.g. platform=2,data=1
+        cluster: Only estimates of this cluster, default any
+    """
+    try:
+        if allocation_engine is None:
+            raise HTTPException(status_code=503, detail="Cost allocation needs a store (STORE_URL)")
+
+        report = allocation_engine.allocate(
+            current_tenant(),
+            group_by=group_by,
+            window=window,
+            split=split.lower() if split else None,
+            weights=parse_weights(weights) if weights else None,
+            cluster=cluster,
+        )
+
+        return {
+            "allocation": report,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except ValueError as e:
+        raise HTTPException(status_code=400, detail=str(e))
+    except Exception as e:
+        logger.error(f"Cost allocation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Cost allocation failed: {str(e)}")
+
+def _budget_of(tenant_id: str, budget_id: str):
+    """A tenant's budget; 404 for unknown budgets and budgets of other tenants"""
+    record = store.get_budget(budget_id, tenant_id=tenant_id)
+    if record is None:
+        raise HTTPException(status_code=404, detail=f"Budget {budget_id} not found")
+    return record
+
+def _budget_info(record) -> Dict[str, Any]:
+    budget = Budget.from_record(record)
+    return dict(budget.dict(), status=budget_evaluator.status(budget).dict())
+
+def _save_budget(tenant_id: str, budget: BudgetSpec, current=None) -> Dict[str, Any]:
+    record = budget.to_record(tenant_id, budget_id=current.id if current else None)
+    if current is not None:
+        record.created_at = current.created_at
+    record = store.save_budget(record)
+    logger.info(f"Budget {record.id} ({record.name}, ${record.amount:,.2f}/month) of tenant {tenant_id} saved")
+
+    return {
+        "budget": _budget_info(record),
+        "timestamp": datetime.utcnow().isoformat()
+    }
+
+@app.post("/webhooks", tags=["webhooks"], response_model=WebhookCreatedResponse)
+async def create_webhook(webhook: WebhookSpec):
+    """
+    Register a webhook of the caller's tenant
+
+    Without a secret one is generated; it is returned only in this response.
+    """
+    try:
+        if store is None or notification_dispatcher is None:
+            raise HTTPException(status_code=503, detail="Webhooks need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        _require_tenant(tenant_id)
+
+        secret = None if webhook.secret else generate_secret()
+        record = store.save_webhook(webhook.to_record(tenant_id, secret=secret or ""))
+        logger.info(f"Webhook {record.id} ({record.name}) of tenant {tenant_id} created")
+
+        return {
+            "webhook": Webhook.from_record(record),
+            "secret": secret,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Webhook creation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Webhook creation failed: {str(e)}")
+
+@app.get("/webhooks", tags=["webhooks"], response_model=WebhookListResponse)
+async def list_webhooks():
+    """List the caller's tenant's webhooks"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Webhooks need a store (STORE_URL)")
+
+        webhooks = [Webhook.from_record(record) for record in store.list_webhooks(current_tenant())]
+
+        return {
+            "webhooks": webhooks,
+            "count": len(webhooks),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Webhook listing failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Webhook listing failed: {str(e)}")
+
+@app.get("/webhooks/{webhook_id}", tags=["webhooks"], response_model=WebhookResponse)
+async def get_webhook(webhook_id: str):
+    """Get a webhook of the caller's tenant"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Webhooks need a store (STORE_URL)")
+
+        return {
+            "webhook": Webhook.from_record(_webhook_of(current_tenant(), webhook_id)),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Webhook lookup failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Webhook lookup failed: {str(e)}")
+
+@app.put("/webhooks/{webhook_id}", tags=["webhooks"], response_model=WebhookResponse)
+async def replace_webhook(webhook_id: str, webhook: WebhookSpec):
+    """Replace a webhook of the caller's tenant; without a secret the current one is kept"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Webhooks need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        current = _webhook_of(tenant_id, webhook_id)
+
+        record = webhook.to_record(tenant_id, webhook_id=current.id, secret=current.secret)
+        record.created_at = current.created_at
+        record = store.save_webhook(record)
+        logger.info(f"Webhook {record.id} ({record.name}) of tenant {tenant_id} replaced")
+
+        return {
+            "webhook": Webhook.from_record(record),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Webhook update failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Webhook update failed: {str(e)}")
+
+@app.delete("/webhooks/{webhook_id}", tags=["webhooks"], response_model=WebhookResponse)
+async def delete_webhook(webhook_id: str):
+    """Delete a webhook of the caller's tenant"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Webhooks need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        record = _webhook_of(tenant_id, webhook_id)
+
+        store.delete_webhook(webhook_id, tenant_id=tenant_id)
+        logger.info(f"Webhook {webhook_id} ({record.name}) of tenant {tenant_id} deleted")
+
+        return {
+            "webhook": Webhook.from_record(record),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Webhook deletion failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Webhook deletion failed: {str(e)}")
+
+@app.post("/webhooks/{webhook_id}/test", tags=["webhooks"], response_model=WebhookTestResponse)
+async def test_webhook(webhook_id: str):
+    """
+    Send a webhook.test event to a webhook of the caller's tenant
+
+    The delivery is attempted once, right away, even if the webhook is
+    disabled, and its outcome returned.
+    """
+    try:
+        if store is None or notification_dispatcher is None:
+            raise HTTPException(status_code=503, detail="Webhooks need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        record = _webhook_of(tenant_id, webhook_id)
+
+        event = Event(
+            type=EVENT_TEST,
+            tenant_id=tenant_id,
+            summary=f"Test notification for webhook '{record.name}'",
+            data={"webhook_id": record.id},
+        )
+        delivery = await asyncio.to_thread(notification_dispatcher.deliver_now, record, event)
+
+        return {
+            "delivery": delivery,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Webhook test failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Webhook test failed: {str(e)}")
+
+def _webhook_of(tenant_id: str, webhook_id: str):
+    """A tenant's webhook; 404 for unknown webhooks and webhooks of other tenants"""
+    record = store.get_webhook(webhook_id, tenant_id=tenant_id)
+    if record is None:
+        raise HTTPException(status_code=404, detail=f"Webhook {webhook_id} not found")
+    return record
+
+@app.post("/chatops/slack/commands", tags=["chatops"])
+async def slack_command(http_request: Request):
+    """
+    Answer a Slack slash command, e.g. /kcost estimate m5.xlarge x3
+
+    Configure the app's slash command with this URL; requests are verified
+    with SLACK_SIGNING_SECRET. Estimates are posted to the channel, errors
+    and the help only to the caller.
+    """
+    try:
+        if not settings.slack_signing_secret:
+            raise HTTPException(status_code=404, detail="Slack commands are not configured (SLACK_SIGNING_SECRET)")
+        if command_handler is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+        body = await http_request.body()
+        if not verify_slack(
+            settings.slack_signing_secret,
+            http_request.headers.get(SLACK_TIMESTAMP_HEADER),
+            body,
+            http_request.headers.get(SLACK_SIGNATURE_HEADER),
+        ):
+            raise HTTPException(status_code=401, detail="Invalid Slack request signature")
+
+        form = urllib.parse.parse_qs(body.decode("utf-8", errors="replace"))
+        text = form.get("text", [""])[0]
+        card = await asyncio.to_thread(command_handler.handle, text)
+
+        estimated = not card.alert and card.facts
+        return dict(slack_blocks(card), response_type="in_channel" if estimated else "ephemeral")
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Slack command failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Slack command failed: {str(e)}")
+
+@app.post("/chatops/teams/messages", tags=["chatops"])
+async def teams_message(http_request: Request):
+    """
+    Answer a message mentioning the Teams outgoing webhook, e.g. @kcost estimate m5.xlarge x3
+
+    Requests are verified with the webhook's security token, TEAMS_WEBHOOK_TOKEN.
+    """
+    try:
+        if not settings.teams_webhook_token:
+            raise HTTPException(status_code=404, detail="Teams commands are not configured (TEAMS_WEBHOOK_TOKEN)")
+        if command_handler is None:
+            raise HTTPException(status_code=503, detail="Service starting: estimator not ready")
+        body = await http_request.body()
+        if not verify_teams(settings.teams_webhook_token, body, http_request.headers.get("Authorization")):
+            raise HTTPException(status_code=401, detail="Invalid Teams request signature")
+
+        try:
+            activity = json.loads(body)
+        except ValueError:
+            activity = None
+        if not isinstance(activity, dict):
+            raise HTTPException(status_code=400, detail="Teams message is not a JSON activity")
+        card = await asyncio.to_thread(command_handler.handle, str(activity.get("text") or ""))
+        return teams_card(card)
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Teams message failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Teams message failed: {str(e)}")
+
+@app.get("/iam/me", tags=["iam"], response_model=CallerResponse)
+async def get_caller(http_request: Request):
+    """The caller, the tenant it acts for and its role and scopes there"""
+    try:
+        principal = getattr(http_request.state, "principal", None)
+        tenant_id = current_tenant()
+        if principal is None:
+            # Without authentication every caller operates the service
+            return {
+                "subject": None,
+                "method": None,
+                "tenant_id": tenant_id,
+                "role": scope_role([SCOPE_ADMIN]),
+                "assigned": False,
+                "scopes": [SCOPE_ADMIN],
+                "timestamp": datetime.utcnow().isoformat()
+            }
+
+        return {
+            "subject": subject_of(principal),
+            "method": principal.method,
+            "tenant_id": tenant_id,
+            "role": principal.role or scope_role(principal.scopes),
+            "assigned": principal.role is not None,
+            "scopes": principal.scopes,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except Exception as e:
+        logger.error(f"Caller lookup failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Caller lookup failed: {str(e)}")
+
+@app.get("/iam/roles", tags=["iam"], response_model=RoleAssignmentListResponse)
+async def list_role_assignments():
+    """List the role assignments of the caller's tenant"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Role assignments need a store (STORE_URL)")
+
+        assignments = [RoleAssignment.from_record(record) for record in store.list_role_assignments(current_tenant())]
+
+        return {
+            "assignments": assignments,
+            "count": len(assignments),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Role assignment listing failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Role assignment listing failed: {str(e)}")
+
+@app.put("/iam/roles/{subject}", tags=["iam"], response_model=RoleAssignmentResponse)
+async def assign_role(subject: str, assignment: RoleAssignmentSpec, http_request: Request):
+    """
+    Assign a subject a role in the caller's tenant
+
+    The subject is an issued API key's ID, a static key's name or an OIDC
+    token's subject. Its role takes the place of its credential's scopes in
+    the tenant and lets it act for the tenant (X-Tenant-ID).
+    """
+    try:
+        if store is None or role_resolver is None:
+            raise HTTPException(status_code=503, detail="Role assignments need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        _require_tenant(tenant_id)
+        _not_own_role(http_request, subject)
+
+        record = store.save_role_assignment(
+            RoleAssignmentRecord(tenant_id=tenant_id, subject=subject, role=assignment.role)
+        )
+        role_resolver.invalidate(tenant_id)
+        logger.info(f"Role {record.role} of tenant {tenant_id} assigned to {subject}")
+
+        return {
+            "assignment": RoleAssignment.from_record(record),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Role assignment failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Role assignment failed: {str(e)}")
+
+@app.delete("/iam/roles/{subject}", tags=["iam"], response_model=RoleAssignmentResponse)
+async def remove_role(subject: str, http_request: Request):
+    """Remove a subject's role in the caller's tenant; its credential's scopes apply again"""
+    try:
+        if store is None or role_resolver is None:
+            raise HTTPException(status_code=503, detail="Role assignments need a store (STORE_URL)")
+        tenant_id = current_tenant()
+        record = store.get_role_assignment(tenant_id, subject)
+        if record is None:
+            raise HTTPException(status_code=404, detail=f"{subject} has no role in tenant {tenant_id}")
+        _not_own_role(http_request, subject)
+
+        store.delete_role_assignment(tenant_id, subject)
+        role_resolver.invalidate(tenant_id)
+        logger.info(f"Role {record.role} of tenant {tenant_id} removed from {subject}")
+
+        return {
+            "assignment": RoleAssignment.from_record(record),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Role removal failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Role removal failed: {str(e)}")
+
+def _not_own_role(http_request: Request, subject: str) -> None:
+    """Reject changes of the caller's own role, which could lock the tenant's admins out"""
+    principal = getattr(http_request.state, "principal", None)
+    if principal is not None and subject_of(principal) == subject:
+        raise HTTPException(status_code=409, detail="Callers cannot change their own role")
+
+@app.get("/audit", tags=["audit"], response_model=AuditEventListResponse)
+async def list_audit_events(
+    actor: Optional[str] = Query(None, description="Issued API key ID, static key name or OIDC token subject"),
+    resource_type: Optional[str] = Query(None, description="e.g. budget, discount_rule or api_key"),
+    resource_id: Optional[str] = None,
+    action: Optional[str] = Query(None, description="e.g. create, update or delete"),
+    from_: Optional[datetime] = Query(None, alias="from", description="At or after (ISO 8601)"),
+    to: Optional[datetime] = Query(None, description="Before (ISO 8601)"),
+    limit: int = Query(100, ge=1, le=1000),
+):
+    """
+    List the changes made to the caller's tenant, newest first
+
+    Query parameters:
+        actor: Only changes made by this caller
+        resource_type: Only changes of this kind of resource
+        resource_id: Only changes of this resource
+        action: Only changes of this action
+        from: Only changes made at or after this time
+        to: Only changes made before this time
+        limit: Maximum number of events, default 100
+    """
+    try:
+        if audit_log is None:
+            raise HTTPException(status_code=503, detail="The audit log needs a store (STORE_URL)")
+        # Naive timestamps are UTC, as in the store
+        from_, to = (
+            t.replace(tzinfo=timezone.utc) if t is not None and t.tzinfo is None else t
+            for t in (from_, to)
+        )
+        if from_ is not None and to is not None and from_ >= to:
+            raise HTTPException(status_code=400, detail="'from' must be before 'to'")
+
+        events = audit_log.events(
+            current_tenant(),
+            actor=actor,
+            resource_type=resource_type,
+            resource_id=resource_id,
+            action=action.lower() if action else None,
+            since=from_,
+            until=to,
+            limit=limit,
+        )
+
+        return {
+            "events": events,
+            "count": len(events),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Audit log listing failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Audit log listing failed: {str(e)}")
+
+def _api_key_info(record) -> Dict[str, Any]:
+    return record.dict(exclude={"key_hash"})
+
+def _tenant_admin_of(http_request: Request, tenant_id: str) -> None:
+    """Reject admins of other tenants; operators manage every tenant"""
+    principal = getattr(http_request.state, "principal", None)
+    if not is_operator(principal) and principal.tenant_id != tenant_id:
+        raise HTTPException(status_code=403, detail=f"'{principal.subject}' cannot manage tenant '{tenant_id}'")
+
+def _require_operator(http_request: Request) -> None:
+    principal = getattr(http_request.state, "principal", None)
+    if not is_operator(principal):
+        raise HTTPException(status_code=403, detail="Only admins of the default tenant manage tenants")
+
+def _label_params(label: Optional[List[str]]) -> Dict[str, str]:
+    """Labels of repeated key=value query parameters"""
+    labels = {}
+    for item in label or []:
+        key, sep, value = item.partition("=")
+        if not sep or not key:
+            raise ValueError(f"Invalid label '{item}', expected key=value")
+        labels[key] = value
+    return labels
+
+def _require_tenant(tenant_id: str) -> None:
+    """404 for tenants that were never created (the default tenant always exists)"""
+    if tenant_id != DEFAULT_TENANT and store.get_tenant(tenant_id) is None:
+        raise HTTPException(status_code=404, detail=f"Tenant {tenant_id} not found")
+
+def _openstack_provider() -> OpenStackPricingProvider:
+    """The enabled openstack provider, 404 if PRICING_PROVIDERS does not include it"""
+    if pricing_registry is None:
+        raise HTTPException(status_code=503, detail="Service starting: pricing registry not ready")
+    try:
+        return pricing_registry.get(OpenStackPricingProvider.name)
+    except ProviderNotFoundError:
+        raise HTTPException(status_code=404, detail="The openstack pricing provider is not enabled") from None
+
+def _openstack_rates_response(provider: OpenStackPricingProvider) -> Dict[str, Any]:
+    return {
+        "rates": provider.rates,
+        "source": provider.rates_source,
+        "timestamp": datetime.utcnow().isoformat()
+    }
+
+def _price_sheet_response(tenant_id: str, records) -> Dict[str, Any]:
+    return {
+        "tenant_id": tenant_id,
+        "prices": [record.dict(exclude={"tenant_id"}) for record in records],
+        "count": len(records),
+        "timestamp": datetime.utcnow().isoformat()
+    }
+
+def _save_price_sheet(tenant_id: str, sheet: PriceSheet) -> Dict[str, Any]:
+    """Replace a tenant's price sheet and apply it to the tenant's next estimates"""
+    records = store.replace_price_overrides(tenant_id, [entry.to_record(tenant_id) for entry in sheet.prices])
+    tenant_prices.invalidate(tenant_id)
+    _invalidate_results(tenant_id)
+    logger.info(f"Price sheet of tenant {tenant_id} replaced ({len(records)} prices)")
+    return _price_sheet_response(tenant_id, records)
+
+@app.post("/admin/api-keys", tags=["admin"], response_model=ApiKeyCreatedResponse)
+async def create_api_key(request: ApiKeyCreateRequest, http_request: Request):
+    """
+    Issue an API key for the caller's tenant (operators may name another)
+
+    The key is returned once; only its hash is stored.
+    """
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="API keys need a store (STORE_URL)")
+
+        tenant_id = request.tenant_id or current_tenant()
+        _tenant_admin_of(http_request, tenant_id)
+        _require_tenant(tenant_id)
+
+        record, key = issue_api_key(store, request.name, request.scopes, request.rate_limit, tenant_id)
+        logger.info(f"API key {record.id} issued to {record.name}@{tenant_id} ({'+'.join(record.scopes)})")
+
+        return {
+            "api_key": _api_key_info(record),
+            "key": key,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"API key creation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"API key creation failed: {str(e)}")
+
+@app.get("/admin/api-keys", tags=["admin"], response_model=ApiKeyListResponse)
+async def list_api_keys(http_request: Request, include_revoked: bool = False):
+    """List issued API keys of the caller's tenant, oldest first"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="API keys need a store (STORE_URL)")
+
+        records = store.list_api_keys(include_revoked=include_revoked, tenant_id=current_tenant())
+
+        return {
+            "api_keys": [_api_key_info(record) for record in records],
+            "count": len(records),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"API key listing failed: {e}")
+        raise HTTPException(status_code=500, detail=f"API key listing failed: {str(e)}")
+
+@app.delete("/admin/api-keys/{key_id}", tags=["admin"], response_model=ApiKeyRevokedResponse)
+async def revoke_api_key(key_id: str):
+    """Revoke an API key of the caller's tenant; requests with it are rejected from now on"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="API keys need a store (STORE_URL)")
+
+        record = store.revoke_api_key(key_id, tenant_id=current_tenant())
+        if record is None:
+            raise HTTPException(status_code=404, detail=f"API key {key_id} not found")
+        logger.info(f"API key {record.id} of {record.name}@{record.tenant_id} revoked")
+
+        return {
+            "api_key": _api_key_info(record),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"API key revocation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"API key revocation failed: {str(e)}")
+
+@app.post("/admin/billing/ingest", tags=["admin"], response_model=BillingIngestResponse)
+async def ingest_billing(request: IngestRequest, http_request: Request):
+    """
+    Ingest one month of a billing export now (operators only)
+
+    The month's previously ingested costs of the export are replaced.
+    Costs are recorded for BILLING_TENANT.
+    """
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Billing ingestion needs a store (STORE_URL)")
+        _require_operator(http_request)
+        if billing_ingestion is None or request.source not in billing_ingestion.sources:
+            raise HTTPException(status_code=400, detail=f"Billing source {request.source} is not configured")
+
+        start = month_bounds(request.month)[0] if request.month else None
+        result = await asyncio.to_thread(billing_ingestion.ingest, request.source, start)
+        if budget_alerts is not None:
+            budget_alerts.check(result.tenant_id)
+
+        return {
+            "ingestion": result,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Billing ingestion failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Billing ingestion failed: {str(e)}")
+
+@app.post("/admin/actuals/compact", tags=["admin"], response_model=ActualsCompactResponse)
+async def compact_actuals(http_request: Request):
+    """
+    Roll up and expire aged actual costs of every tenant now (operators only)
+
+    Raw costs older than ACTUALS_RAW_RETENTION_DAYS become daily costs, costs
+    older than ACTUALS_DAILY_RETENTION_DAYS monthly ones, and costs older than
+    ACTUALS_MONTHLY_RETENTION_DAYS are deleted.
+    """
+    try:
+        if actuals_retention is None:
+            raise HTTPException(status_code=503, detail="Actual cost retention needs a store (STORE_URL)")
+        _require_operator(http_request)
+
+        result = await asyncio.to_thread(actuals_retention.compact)
+
+        return {
+            "compaction": result,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Actual cost compaction failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Actual cost compaction failed: {str(e)}")
+
+@app.post("/admin/inventory/collect", tags=["admin"], response_model=InventoryCollectResponse)
+async def collect_inventory(request: CollectRequest, http_request: Request):
+    """
+    List the resources of a cloud API into the inventory now (operators only)
+
+    The source's previously collected resources are replaced. Resources
+    are recorded for INVENTORY_TENANT.
+    """
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Inventory collection needs a store (STORE_URL)")
+        _require_operator(http_request)
+        if inventory_collection is None or request.source not in inventory_collection.sources:
+            raise HTTPException(status_code=400, detail=f"Inventory source {request.source} is not configured")
+
+        result = await asyncio.to_thread(inventory_collection.collect, request.source)
+
+        return {
+            "collection": result,
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Inventory collection failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Inventory collection failed: {str(e)}")
+
+@app.post("/admin/tenants", tags=["admin"], response_model=TenantResponse)
+async def create_tenant(request: TenantCreateRequest, http_request: Request):
+    """Create a tenant (operators only)"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Tenants need a store (STORE_URL)")
+        _require_operator(http_request)
+        if request.id == DEFAULT_TENANT or store.get_tenant(request.id) is not None:
+            raise HTTPException(status_code=409, detail=f"Tenant {request.id} already exists")
+
+        record = store.save_tenant(TenantRecord(id=request.id, name=request.name))
+        logger.info(f"Tenant {record.id} created")
+
+        return {
+            "tenant": record.dict(),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Tenant creation failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Tenant creation failed: {str(e)}")
+
+@app.get("/admin/tenants", tags=["admin"], response_model=TenantListResponse)
+async def list_tenants(http_request: Request):
+    """List tenants (operators only)"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Tenants need a store (STORE_URL)")
+        _require_operator(http_request)
+
+        records = store.list_tenants()
+
+        return {
+            "tenants": [record.dict() for record in records],
+            "count": len(records),
+            "timestamp": datetime.utcnow().isoformat()
+        }
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Tenant listing failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Tenant listing failed: {str(e)}")
+
+@app.put("/admin/tenants/{tenant_id}/prices", tags=["admin"], response_model=PriceSheetResponse)
+async def put_tenant_prices(tenant_id: str, sheet: PriceSheet, http_request: Request):
+    """
+    Replace a tenant's negotiated price sheet
+
+    Matching prices (provider, service, region or *, SKU, pricing model)
+    are used instead of list prices in the tenant's estimates. An empty
+    sheet restores list prices.
+    """
+    try:
+        if store is None or tenant_prices is None:
+            raise HTTPException(status_code=503, detail="Price sheets need a store (STORE_URL)")
+        _tenant_admin_of(http_request, tenant_id)
+        _require_tenant(tenant_id)
+
+        return _save_price_sheet(tenant_id, sheet)
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Price sheet upload failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Price sheet upload failed: {str(e)}")
+
+@app.get("/admin/tenants/{tenant_id}/prices", tags=["admin"], response_model=PriceSheetResponse)
+async def get_tenant_prices(tenant_id: str, http_request: Request):
+    """Get a tenant's negotiated price sheet"""
+    try:
+        if store is None:
+            raise HTTPException(status_code=503, detail="Price sheets need a store (STORE_URL)")
+        _tenant_admin_of(http_request, tenant_id)
+        _require_tenant(tenant_id)
+
+        return _price_sheet_response(tenant_id, store.list_price_overrides(tenant_id))
+
+    except HTTPException:
+        raise
+    except Exception as e:
+        logger.error(f"Price sheet lookup failed: {e}")
+        raise HTTPException(status_code=500, detail=f"Price sheet lookup failed: {str(e)}")
+
+if __name__ == "__main__":
+    import argparse
+    import os
+
+    parser = argparse.ArgumentParser(description="kcloud cost estimator API server")
+    parser.add_argument("--config", help="YAML config file (overrides defaults, overridden by environment)")
+    parser.add_argument("--host", help="Listen address (API_HOST)")
+    parser.add_argument("--port", type=int, help="HTTP port (API_PORT)")
+    parser.add_argument("--store-url", help="Database DSN, e.g. postgresql://... (STORE_URL)")
+    parser.add_argument("--log-level", help="DEBUG, INFO, WARNING or ERROR (LOG_LEVEL)")
+    parser.add_argument("--set", action="append", default=[], metavar="NAME=VALUE",
+                        help="Override any setting, e.g. --set PRICING_CACHE_TTL=3600 (repeatable)")
+    parser.add_argument("--offline", action="store_true", help="Do not call cloud pricing APIs")
+    parser.add_argument("--snapshot", help="Pricing snapshot to load at startup (JSON or SQLite)")
+    parser.add_argument("--reload", action="store_true", help="Restart on code changes (development)")
+    args = parser.parse_args()
+
+    # Settings are read when the app module is loaded, so flags are passed
+    # on as environment variables, which take precedence over the config file
+    overrides = {
+        "CONFIG_FILE": args.config,
+        "API_HOST": args.host,
+        "API_PORT": args.port,
+        "STORE_URL": args.store_url,
+        "LOG_LEVEL": args.log_level,
+        "OFFLINE": "true" if args.offline else None,
+        "PRICING_SNAPSHOT_PATH": args.snapshot,
+    }
+    for item in args.set:
+        name, sep, value = item.partition("=")
+        if not sep or not name:
+            parser.error(f"Invalid --set '{item}', expected NAME=VALUE")
+        overrides[name.upper()] = value
+    for name, value in overrides.items():
+        if value is not None:
+            os.environ[name] = str(value)
+
+    server_settings = Settings()
+    unknown = sorted(set(overrides) - server_settings.names - {"CONFIG_FILE"})
+    if unknown:
+        parser.error(f"Unknown settings: {', '.join(unknown)}")
+
+    serve("src.main:app", server_settings, reload=args.reload)
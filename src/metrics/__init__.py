@@ -0,0 +1,70 @@
+"""
+Metrics Module
+
+Prometheus counters and histograms for estimate requests, price catalog
+caching, estimate result caching, upstream pricing API calls, rate limited
+requests, webhook deliveries and admission reviews, and gauges of the
+live cluster cost per namespace.
+"""
+
+from .metrics import (
+    ESTIMATE_REQUESTS,
+    ESTIMATE_DURATION,
+    CATALOG_CACHE_LOOKUPS,
+    PRICING_API_DURATION,
+    RESPONSE_CACHE_LOOKUPS,
+    RATE_LIMITED_REQUESTS,
+    WEBHOOK_DELIVERIES,
+    ADMISSION_REVIEWS,
+    NAMESPACE_MONTHLY_COST,
+    NAMESPACE_COMPONENT_MONTHLY_COST,
+    CLUSTER_IDLE_MONTHLY_COST,
+    NAMESPACE_COSTS_COLLECTED,
+    STATUS_SUCCESS,
+    STATUS_ERROR,
+    CACHE_HIT,
+    CACHE_MISS,
+    CACHE_STALE,
+    RATE_LIMIT_KEY,
+    RATE_LIMIT_ADDRESS,
+    observe_estimate,
+    observe_pricing_api,
+    record_cache_lookup,
+    record_response_cache_lookup,
+    record_rate_limited,
+    record_shed,
+    record_webhook_delivery,
+    record_admission_review,
+    record_namespace_costs,
+)
+
+__all__ = [
+    "ESTIMATE_REQUESTS",
+    "ESTIMATE_DURATION",
+    "CATALOG_CACHE_LOOKUPS",
+    "PRICING_API_DURATION",
+    "RESPONSE_CACHE_LOOKUPS",
+    "RATE_LIMITED_REQUESTS",
+    "WEBHOOK_DELIVERIES",
+    "ADMISSION_REVIEWS",
+    "NAMESPACE_MONTHLY_COST",
+    "NAMESPACE_COMPONENT_MONTHLY_COST",
+    "CLUSTER_IDLE_MONTHLY_COST",
+    "NAMESPACE_COSTS_COLLECTED",
+    "STATUS_SUCCESS",
+    "STATUS_ERROR",
+    "CACHE_HIT",
+    "CACHE_MISS",
+    "CACHE_STALE",
+    "RATE_LIMIT_KEY",
+    "RATE_LIMIT_ADDRESS",
+    "observe_estimate",
+    "observe_pricing_api",
+    "record_cache_lookup",
+    "record_response_cache_lookup",
+    "record_rate_limited",
+    "record_shed",
+    "record_webhook_delivery",
+    "record_admission_review",
+    "record_namespace_costs",
+]
@@ -0,0 +1,221 @@
+"""
+Prometheus metrics of the estimator
+
+Metrics live in the default prometheus_client registry and are served at
+/metrics together with the client's process metrics. Timed estimates and
+pricing API calls are also traced as spans when tracing is enabled.
+"""
+
+import threading
+import time
+from contextlib import contextmanager
+from typing import Any, Dict, Iterable, Optional, Set
+
+from prometheus_client import Counter, Gauge, Histogram
+
+from ..tracing import span, KIND_CLIENT
+
+# Outcome label values
+STATUS_SUCCESS = "success"
+STATUS_ERROR = "error"
+
+# Catalog cache lookup results
+CACHE_HIT = "hit"
+CACHE_MISS = "miss"
+CACHE_STALE = "stale"
+
+# Callers of rejected requests: keys and tokens, or anonymous client addresses
+RATE_LIMIT_KEY = "key"
+RATE_LIMIT_ADDRESS = "address"
+
+ESTIMATE_REQUESTS = Counter(
+    "kcloud_estimate_requests_total",
+    "Estimate requests by endpoint, cloud provider and outcome",
+    ["endpoint", "provider", "status"],
+)
+
+ESTIMATE_DURATION = Histogram(
+    "kcloud_estimate_duration_seconds",
+    "Time spent computing an estimate",
+    ["endpoint"],
+    buckets=(0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0, 30.0, 60.0),
+)
+
+CATALOG_CACHE_LOOKUPS = Counter(
+    "kcloud_catalog_cache_lookups_total",
+    "Price catalog cache lookups by provider and result (hit, miss, stale)",
+    ["provider", "result"],
+)
+
+PRICING_API_DURATION = Histogram(
+    "kcloud_pricing_api_request_duration_seconds",
+    "Latency of upstream pricing API calls",
+    ["provider", "operation", "status"],
+    # Offer files of large services take minutes to download
+    buckets=(0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5.0, 10.0, 30.0, 60.0, 120.0, 300.0, 600.0),
+)
+
+RESPONSE_CACHE_LOOKUPS = Counter(
+    "kcloud_response_cache_lookups_total",
+    "Estimate result cache lookups by endpoint and result (hit, miss)",
+    ["endpoint", "result"],
+)
+
+RATE_LIMITED_REQUESTS = Counter(
+    "kcloud_rate_limited_requests_total",
+    "Requests rejected with 429 by caller (key, address)",
+    ["caller"],
+)
+
+SHED_REQUESTS = Counter(
+    "kcloud_shed_requests_total",
+    "Requests refused with 503 over their route's concurrency limit",
+    ["route"],
+)
+
+WEBHOOK_DELIVERIES = Counter(
+    "kcloud_webhook_deliveries_total",
+    "Webhook delivery attempts by result (delivered, retried, failed, dropped)",
+    ["result"],
+)
+
+ADMISSION_REVIEWS = Counter(
+    "kcloud_admission_reviews_total",
+    "Admission reviews by kind and result (allowed, warned, denied, error)",
+    ["kind", "result"],
+)
+
+# Live cluster cost, named for the kcost dashboards and alert rules
+NAMESPACE_MONTHLY_COST = Gauge(
+    "kcost_namespace_monthly_cost",
+    "Current monthly cost of a namespace's workloads, volumes and load balancers (USD)",
+    ["cluster", "namespace"],
+)
+
+NAMESPACE_COMPONENT_MONTHLY_COST = Gauge(
+    "kcost_namespace_component_monthly_cost",
+    "Current monthly cost of a namespace by component (compute, storage, load_balancer) (USD)",
+    ["cluster", "namespace", "component"],
+)
+
+CLUSTER_IDLE_MONTHLY_COST = Gauge(
+    "kcost_cluster_idle_monthly_cost",
+    "Current monthly node cost no workload requests (USD)",
+    ["cluster"],
+)
+
+NAMESPACE_COSTS_COLLECTED = Gauge(
+    "kcost_namespace_costs_last_collected_timestamp_seconds",
+    "Unix time namespace costs were last collected",
+    ["cluster"],
+)
+
+NAMESPACE_COMPONENTS = ("compute", "storage", "load_balancer")
+
+# Namespaces last exported per cluster, so deleted ones stop being reported
+_exported_namespaces: Dict[str, Set[str]] = {}
+_exported_lock = threading.Lock()
+
+
+@contextmanager
+def observe_estimate(endpoint: str, providers: Iterable[str]):
+    """
+    Time an estimate and count it once per cloud provider it covers
+
+    Exceptions propagate and are counted as errors.
+    """
+    providers = sorted(set(providers))
+    status = STATUS_ERROR
+    start = time.perf_counter()
+    try:
+        with span(f"estimate {endpoint}", {"estimate.endpoint": endpoint, "estimate.providers": providers}):
+            yield
+        status = STATUS_SUCCESS
+    finally:
+        ESTIMATE_DURATION.labels(endpoint).observe(time.perf_counter() - start)
+        for provider in providers or ["unknown"]:
+            ESTIMATE_REQUESTS.labels(endpoint, provider, status).inc()
+
+
+@contextmanager
+def observe_pricing_api(provider: str, operation: str, attributes: Optional[Dict[str, Any]] = None):
+    """
+    Time and trace an upstream pricing API call; exceptions propagate and are labeled as errors
+
+    Yields:
+        The call's span, None when tracing is disabled
+    """
+    status = STATUS_ERROR
+    start = time.perf_counter()
+    try:
+        with span(
+            f"{provider} {operation}",
+            {"pricing.provider": provider, "pricing.operation": operation, **(attributes or {})},
+            kind=KIND_CLIENT,
+        ) as current:
+            yield current
+        status = STATUS_SUCCESS
+    finally:
+        PRICING_API_DURATION.labels(provider, operation, status).observe(time.perf_counter() - start)
+
+
+def record_cache_lookup(provider: str, result: str) -> None:
+    """Count a catalog cache lookup (CACHE_HIT, CACHE_MISS or CACHE_STALE)"""
+    CATALOG_CACHE_LOOKUPS.labels(provider, result).inc()
+
+
+def record_response_cache_lookup(endpoint: str, result: str) -> None:
+    """Count an estimate result cache lookup (CACHE_HIT or CACHE_MISS)"""
+    RESPONSE_CACHE_LOOKUPS.labels(endpoint, result).inc()
+
+
+def record_rate_limited(caller: str) -> None:
+    """Count a request rejected over its rate limit (RATE_LIMIT_KEY or RATE_LIMIT_ADDRESS)"""
+    RATE_LIMITED_REQUESTS.labels(caller).inc()
+
+
+def record_shed(route: str) -> None:
+    """Count a request refused over its route's concurrency limit"""
+    SHED_REQUESTS.labels(route).inc()
+
+
+def record_webhook_delivery(result: str) -> None:
+    """Count a webhook delivery attempt by result"""
+    WEBHOOK_DELIVERIES.labels(result).inc()
+
+
+def record_admission_review(kind: str, result: str) -> None:
+    """Count an admission review by the reviewed object's kind and its result"""
+    ADMISSION_REVIEWS.labels(kind, result).inc()
+
+
+def record_namespace_costs(
+    cluster: str, namespaces: Iterable[Any], idle_monthly_cost: float, collected_at: Optional[float] = None
+) -> None:
+    """
+    Export a cluster's current cost per namespace
+
+    Args:
+        cluster: Cluster name label
+        namespaces: NamespaceCost entries of a cluster estimate
+        idle_monthly_cost: Node cost not requested by any workload
+        collected_at: Unix time of the collection, default now
+    """
+    current: Set[str] = set()
+    with _exported_lock:
+        for namespace in namespaces:
+            current.add(namespace.namespace)
+            NAMESPACE_MONTHLY_COST.labels(cluster, namespace.namespace).set(namespace.monthly_cost)
+            for component, cost in zip(NAMESPACE_COMPONENTS, (
+                namespace.compute_monthly_cost,
+                namespace.storage_monthly_cost,
+                namespace.load_balancer_monthly_cost,
+            )):
+                NAMESPACE_COMPONENT_MONTHLY_COST.labels(cluster, namespace.namespace, component).set(cost)
+        for removed in _exported_namespaces.get(cluster, set()) - current:
+            NAMESPACE_MONTHLY_COST.remove(cluster, removed)
+            for component in NAMESPACE_COMPONENTS:
+                NAMESPACE_COMPONENT_MONTHLY_COST.remove(cluster, removed, component)
+        _exported_namespaces[cluster] = current
+        CLUSTER_IDLE_MONTHLY_COST.labels(cluster).set(idle_monthly_cost)
+        NAMESPACE_COSTS_COLLECTED.labels(cluster).set(time.time() if collected_at is None else collected_at)
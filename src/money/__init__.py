@@ -0,0 +1,59 @@
+"""
+Money Module
+
+This module adds and rounds amounts in decimal arithmetic, so estimate
+totals are exact sums of their items and the same inputs always round
+the same way. Estimates keep four decimal places; invoicing statements
+round to the minor unit of their currency with per-currency rules, and
+shares of a total are allocated so they add up to it exactly.
+"""
+
+from .amounts import (
+    MONEY_PLACES,
+    Amount,
+    to_decimal,
+    quantize,
+    round_money,
+    add_amounts,
+    sum_money,
+    multiply_money,
+    round_currency,
+    allocate,
+)
+from .rounding import (
+    ROUNDING_HALF_UP,
+    ROUNDING_HALF_EVEN,
+    ROUNDING_DOWN,
+    ROUNDING_MODES,
+    MINOR_UNITS,
+    CurrencyRounding,
+    normalize_rounding_mode,
+    parse_currency_rounding,
+    configure_rounding,
+    rounding_mode,
+    currency_rounding,
+)
+
+__all__ = [
+    "MONEY_PLACES",
+    "Amount",
+    "to_decimal",
+    "quantize",
+    "round_money",
+    "add_amounts",
+    "sum_money",
+    "multiply_money",
+    "round_currency",
+    "allocate",
+    "ROUNDING_HALF_UP",
+    "ROUNDING_HALF_EVEN",
+    "ROUNDING_DOWN",
+    "ROUNDING_MODES",
+    "MINOR_UNITS",
+    "CurrencyRounding",
+    "normalize_rounding_mode",
+    "parse_currency_rounding",
+    "configure_rounding",
+    "rounding_mode",
+    "currency_rounding",
+]
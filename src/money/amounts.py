@@ -0,0 +1,84 @@
+"""
+Decimal arithmetic of amounts
+
+Prices and quantities arrive as floats, but amounts are added and
+rounded in decimal so totals do not drift with binary fractions and the
+same inputs always round the same way. A float enters as the decimal of
+its shortest representation (0.1 is 0.1, not 0.1000000000000000055...);
+line items compute and add their costs in decimal, sums are exact and
+amounts are rounded once, when they are output. Estimates keep MONEY_PLACES decimal
+places, statements the minor unit of their currency.
+"""
+
+from decimal import Decimal, ROUND_FLOOR
+from typing import Dict, Hashable, Iterable, Optional, TypeVar, Union
+
+from .rounding import DECIMAL_ROUNDING, currency_rounding, rounding_mode
+
+# Decimal places of estimate and report amounts
+MONEY_PLACES = 4
+
+Amount = Union[Decimal, float, int, str]
+K = TypeVar("K", bound=Hashable)
+
+
+def to_decimal(value: Amount) -> Decimal:
+    """Decimal of an amount, floats by their shortest representation"""
+    if isinstance(value, Decimal):
+        return value
+    if isinstance(value, float):
+        return Decimal(repr(value))
+    return Decimal(value)
+
+
+def quantize(value: Amount, places: int, mode: Optional[str] = None) -> Decimal:
+    """An amount rounded to decimal places with a rounding mode, the configured one by default"""
+    exponent = Decimal(1).scaleb(-places)
+    return to_decimal(value).quantize(exponent, rounding=DECIMAL_ROUNDING[mode or rounding_mode()])
+
+
+def round_money(value: Amount, places: int = MONEY_PLACES) -> float:
+    """An amount rounded for output, MONEY_PLACES decimal places by default"""
+    return float(quantize(value, places))
+
+
+def add_amounts(values: Iterable[Amount]) -> Decimal:
+    """Exact sum of amounts, kept in decimal for further arithmetic"""
+    return sum((to_decimal(v) for v in values), Decimal(0))
+
+
+def sum_money(values: Iterable[Amount], places: int = MONEY_PLACES) -> float:
+    """Exact sum of amounts, rounded once for output"""
+    return float(quantize(add_amounts(values), places))
+
+
+def multiply_money(amount: Amount, factor: Amount, places: int = MONEY_PLACES) -> float:
+    """An amount times a factor, e.g. an exchange rate, rounded for output"""
+    return float(quantize(to_decimal(amount) * to_decimal(factor), places))
+
+
+def round_currency(value: Amount, currency: str) -> Decimal:
+    """An amount rounded to its currency's minor unit with the currency's mode"""
+    rule = currency_rounding(currency)
+    return quantize(value, rule.places, rule.mode)
+
+
+def allocate(total: Amount, shares: Dict[K, Amount], currency: str) -> Dict[K, Decimal]:
+    """
+    Shares of a total rounded to the currency's minor unit, adding up to the rounded total
+
+    Each share is rounded down to the minor unit and the units left over go
+    to the shares with the largest remainders, ties to the first share.
+    """
+    rounded_total = round_currency(total, currency)
+    if not shares:
+        return {}
+    step = Decimal(1).scaleb(-currency_rounding(currency).places)
+    sign = -1 if rounded_total < 0 else 1
+    exact = {key: to_decimal(share) * sign for key, share in shares.items()}
+    floors = {key: (value / step).to_integral_value(rounding=ROUND_FLOOR) for key, value in exact.items()}
+    left = int(rounded_total * sign / step - sum(floors.values()))
+    by_remainder = sorted(exact, key=lambda key: exact[key] / step - floors[key], reverse=True)
+    for key in by_remainder[:max(left, 0)]:
+        floors[key] += 1
+    return {key: floors[key] * step * sign for key in shares}
@@ -0,0 +1,113 @@
+"""
+Currency rounding rules
+
+Statements meant for invoicing are rounded to the minor unit of their
+currency: cents of USD and EUR, whole won and yen, thousandths of a
+Kuwaiti dinar. Every currency rounds with the process-wide mode,
+half-up unless MONEY_ROUNDING says otherwise, and a currency can
+override its minor units and mode (MONEY_CURRENCY_ROUNDING), e.g.
+"CHF=2:half_even,KRW=0:down".
+"""
+
+import threading
+from decimal import ROUND_DOWN, ROUND_HALF_EVEN, ROUND_HALF_UP
+from typing import Dict, NamedTuple
+
+ROUNDING_HALF_UP = "half_up"
+ROUNDING_HALF_EVEN = "half_even"
+ROUNDING_DOWN = "down"
+ROUNDING_MODES = (ROUNDING_HALF_UP, ROUNDING_HALF_EVEN, ROUNDING_DOWN)
+
+# decimal module rounding of each mode
+DECIMAL_ROUNDING = {
+    ROUNDING_HALF_UP: ROUND_HALF_UP,
+    ROUNDING_HALF_EVEN: ROUND_HALF_EVEN,
+    ROUNDING_DOWN: ROUND_DOWN,
+}
+
+# ISO 4217 minor units of currencies without two decimal places
+MINOR_UNITS = {
+    "BHD": 3, "CLP": 0, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "OMR": 3, "TND": 3, "VND": 0,
+}
+DEFAULT_MINOR_UNITS = 2
+
+
+class CurrencyRounding(NamedTuple):
+    """How amounts of a currency are rounded"""
+
+    places: int
+    mode: str
+
+
+_lock = threading.Lock()
+_mode = ROUNDING_HALF_UP
+_overrides: Dict[str, CurrencyRounding] = {}
+
+
+def normalize_rounding_mode(value: str) -> str:
+    """
+    Lower-cased rounding mode
+
+    Raises:
+        ValueError: If the mode is not half_up, half_even or down
+    """
+    mode = value.strip().lower()
+    if mode not in ROUNDING_MODES:
+        raise ValueError(f"rounding must be one of: {', '.join(ROUNDING_MODES)}")
+    return mode
+
+
+def parse_currency_rounding(value: str, mode: str = ROUNDING_HALF_UP) -> Dict[str, CurrencyRounding]:
+    """
+    Per-currency rules of a CUR=places[:mode],... setting
+
+    Currencies without a mode take the given one.
+
+    Raises:
+        ValueError: If a rule cannot be read
+    """
+    rules: Dict[str, CurrencyRounding] = {}
+    for item in (i.strip() for i in value.split(",")):
+        if not item:
+            continue
+        currency, sep, rule = item.partition("=")
+        places, _, rule_mode = rule.partition(":")
+        if not sep or not currency.strip() or not places.strip().isdigit():
+            raise ValueError(f"Currency rounding '{item}' must be CUR=places or CUR=places:mode")
+        rules[currency.strip().upper()] = CurrencyRounding(
+            int(places), normalize_rounding_mode(rule_mode) if rule_mode.strip() else mode
+        )
+    return rules
+
+
+def configure_rounding(mode: str = ROUNDING_HALF_UP, currencies: str = "") -> None:
+    """
+    Set the rounding of every amount in this process
+
+    Args:
+        mode: half_up, half_even or down (MONEY_ROUNDING)
+        currencies: Per-currency overrides, CUR=places[:mode],... (MONEY_CURRENCY_ROUNDING)
+
+    Raises:
+        ValueError: If the mode or an override is not valid
+    """
+    global _mode, _overrides
+    normalized = normalize_rounding_mode(mode)
+    overrides = parse_currency_rounding(currencies, normalized)
+    with _lock:
+        _mode = normalized
+        _overrides = overrides
+
+
+def rounding_mode() -> str:
+    """Rounding mode of amounts without a currency rule"""
+    return _mode
+
+
+def currency_rounding(currency: str) -> CurrencyRounding:
+    """Minor units and rounding mode of a currency"""
+    code = currency.upper()
+    override = _overrides.get(code)
+    if override is not None:
+        return override
+    return CurrencyRounding(MINOR_UNITS.get(code, DEFAULT_MINOR_UNITS), _mode)
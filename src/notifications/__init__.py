@@ -0,0 +1,86 @@
+"""
+Notifications Module
+
+This module delivers budget, anomaly, catalog refresh, price change and
+cost diff events to tenants' webhooks as signed JSON, Slack-compatible
+payloads or Slack and Teams cards, retrying failed deliveries with
+exponential backoff.
+"""
+
+from .models import (
+    DeliveryResult,
+    Event,
+    Webhook,
+    WebhookSpec,
+    generate_secret,
+    subscribed,
+    EVENT_BUDGET_THRESHOLD,
+    EVENT_ANOMALY,
+    EVENT_CATALOG_REFRESH_FAILED,
+    EVENT_PRICE_CHANGED,
+    EVENT_COST_DIFF,
+    EVENT_ESTIMATE_REVIEWED,
+    EVENT_TEST,
+    EVENT_TYPES,
+    FORMAT_JSON,
+    FORMAT_SLACK,
+    FORMAT_SLACK_BLOCKS,
+    FORMAT_TEAMS,
+    FORMATS,
+)
+from .signing import (
+    delivery_headers,
+    sign,
+    verify,
+    SIGNATURE_HEADER,
+    TIMESTAMP_HEADER,
+    EVENT_HEADER,
+    DELIVERY_HEADER,
+)
+from .cards import Card, diff_card, event_card, slack_blocks, teams_card, usd, MAX_CARD_CHANGES
+from .dispatcher import NotificationDispatcher, http_send, payload, retryable
+from .alerts import AnomalyAlerts, BudgetAlerts, PriceChangeAlerts, catalog_failure_notifier
+
+__all__ = [
+    "DeliveryResult",
+    "Event",
+    "Webhook",
+    "WebhookSpec",
+    "generate_secret",
+    "subscribed",
+    "EVENT_BUDGET_THRESHOLD",
+    "EVENT_ANOMALY",
+    "EVENT_CATALOG_REFRESH_FAILED",
+    "EVENT_PRICE_CHANGED",
+    "EVENT_COST_DIFF",
+    "EVENT_ESTIMATE_REVIEWED",
+    "EVENT_TEST",
+    "EVENT_TYPES",
+    "FORMAT_JSON",
+    "FORMAT_SLACK",
+    "FORMAT_SLACK_BLOCKS",
+    "FORMAT_TEAMS",
+    "FORMATS",
+    "delivery_headers",
+    "sign",
+    "verify",
+    "SIGNATURE_HEADER",
+    "TIMESTAMP_HEADER",
+    "EVENT_HEADER",
+    "DELIVERY_HEADER",
+    "Card",
+    "diff_card",
+    "event_card",
+    "slack_blocks",
+    "teams_card",
+    "usd",
+    "MAX_CARD_CHANGES",
+    "NotificationDispatcher",
+    "http_send",
+    "payload",
+    "retryable",
+    "AnomalyAlerts",
+    "BudgetAlerts",
+    "PriceChangeAlerts",
+    "catalog_failure_notifier",
+]
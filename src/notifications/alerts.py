@@ -0,0 +1,251 @@
+"""
+Events raised by budgets, spend anomalies and catalog refreshes
+
+A budget raises one event per threshold and month once actual spend,
+projected to the end of the month, reaches it. An anomaly raises one
+event when it is first detected among the recent days. A catalog price
+change raises one event per tenant listing the estimates it moves that
+were not notified of it yet. What was notified is kept per replica, so a
+restart may repeat an alert; with locks shared by the replicas, each alert
+is also claimed for as long as it could be raised again, so only one
+replica sends it.
+"""
+
+import hashlib
+import logging
+import threading
+from typing import List, Set, Tuple
+
+from ..anomalies import AnomalyDetector, KIND_NEW_SKU
+from ..budgets import Budget, BudgetEvaluator
+from ..price_changes import PriceChangeTracker
+from ..pricing import RefreshFailureListener
+from ..store import StoreError, DEFAULT_TENANT
+from .dispatcher import NotificationDispatcher
+from .models import Event, EVENT_ANOMALY, EVENT_BUDGET_THRESHOLD, EVENT_CATALOG_REFRESH_FAILED, EVENT_PRICE_CHANGED
+
+logger = logging.getLogger(__name__)
+
+_DAY = 86400
+
+# A budget threshold can be reached again in the same month only
+_BUDGET_CLAIM = 32 * _DAY
+
+
+class BudgetAlerts:
+    """Publishes budget threshold events as actual spend is recorded"""
+
+    def __init__(self, evaluator: BudgetEvaluator, dispatcher: NotificationDispatcher, locks=None):
+        """
+        Initialize alerts
+
+        Args:
+            evaluator: Evaluator of budgets against actual spend
+            dispatcher: Dispatcher delivering the events
+            locks: Locks (src.cache.Locks) claimed per alert across replicas
+        """
+        self.evaluator = evaluator
+        self.dispatcher = dispatcher
+        self.locks = locks
+        self._notified: Set[Tuple[str, str, float, str]] = set()
+        self._lock = threading.Lock()
+
+    def check(self, tenant_id: str) -> List[Event]:
+        """
+        Publish an event for every budget of a tenant past a threshold not notified this month
+
+        Failures are logged rather than raised.
+
+        Returns:
+            Events published
+        """
+        events = []
+        try:
+            for record in self.evaluator.store.list_budgets(tenant_id):
+                budget = Budget.from_record(record)
+                status = self.evaluator.status(budget)
+                threshold = status.threshold_reached
+                if threshold is None:
+                    continue
+
+                key = (tenant_id, budget.id, threshold, status.period_start.isoformat())
+                with self._lock:
+                    if key in self._notified:
+                        continue
+                    self._notified.add(key)
+                if self.locks is not None and not self.locks.claim(
+                    f"alert:budget:{tenant_id}:{budget.id}:{threshold}:{key[3]}", _BUDGET_CLAIM
+                ):
+                    continue
+
+                event = Event(
+                    type=EVENT_BUDGET_THRESHOLD,
+                    tenant_id=tenant_id,
+                    summary=(
+                        f"Budget '{budget.name}' is projected at {status.utilization:.0%} of "
+                        f"${budget.amount:,.2f}/month, reaching the {threshold:.0%} threshold"
+                    ),
+                    data={
+                        "budget": budget.dict(include={"id", "name", "amount", "project", "labels"}),
+                        "threshold": threshold,
+                        "status": status.dict(),
+                    },
+                )
+                self.dispatcher.publish(event)
+                events.append(event)
+        except StoreError as e:
+            logger.error(f"Budget alerts of tenant {tenant_id} not evaluated: {e}")
+        return events
+
+
+class AnomalyAlerts:
+    """Publishes anomaly events for spend anomalies of the recent days"""
+
+    def __init__(self, detector: AnomalyDetector, dispatcher: NotificationDispatcher, days: int = 3, locks=None):
+        """
+        Initialize alerts
+
+        Args:
+            detector: Detector of spend anomalies
+            dispatcher: Dispatcher delivering the events
+            days: Recent days checked, covering billing exports that arrive late
+            locks: Locks (src.cache.Locks) claimed per alert across replicas
+        """
+        self.detector = detector
+        self.dispatcher = dispatcher
+        self.days = days
+        self.locks = locks
+        self._notified: Set[Tuple[str, str]] = set()
+        self._lock = threading.Lock()
+
+    def check(self, tenant_id: str) -> List[Event]:
+        """
+        Publish an event for every anomaly of a tenant's recent days not notified yet
+
+        Failures are logged rather than raised.
+
+        Returns:
+            Events published
+        """
+        events = []
+        try:
+            report = self.detector.detect(tenant_id, days=self.days)
+        except StoreError as e:
+            logger.error(f"Anomalies of tenant {tenant_id} not detected: {e}")
+            return events
+
+        for anomaly in report.anomalies:
+            with self._lock:
+                if (tenant_id, anomaly.id) in self._notified:
+                    continue
+                self._notified.add((tenant_id, anomaly.id))
+            if self.locks is not None and not self.locks.claim(
+                f"alert:anomaly:{tenant_id}:{anomaly.id}", (self.days + 1) * _DAY
+            ):
+                continue
+
+            if anomaly.kind == KIND_NEW_SKU:
+                summary = f"New SKU {anomaly.key} spent ${anomaly.cost:,.2f} on {anomaly.date}"
+            else:
+                summary = (
+                    f"Spend of {anomaly.dimension} {anomaly.key} was ${anomaly.cost:,.2f} on {anomaly.date}, "
+                    f"against ${anomaly.expected:,.2f}/day expected ({anomaly.score:+.1f} sd)"
+                )
+            event = Event(
+                type=EVENT_ANOMALY,
+                tenant_id=tenant_id,
+                summary=summary,
+                data={"anomaly": anomaly.dict(), "detector": report.detector, "threshold": report.threshold},
+            )
+            self.dispatcher.publish(event)
+            events.append(event)
+        return events
+
+
+class PriceChangeAlerts:
+    """Publishes price change events for the stored estimates catalog refreshes move"""
+
+    def __init__(self, tracker: PriceChangeTracker, dispatcher: NotificationDispatcher, days: int = 1, locks=None):
+        """
+        Initialize alerts
+
+        Args:
+            tracker: Tracker of catalog price changes
+            dispatcher: Dispatcher delivering the events
+            days: Days back to the catalog snapshots compared against
+            locks: Locks (src.cache.Locks) claimed per alert across replicas
+        """
+        self.tracker = tracker
+        self.dispatcher = dispatcher
+        self.days = days
+        self.locks = locks
+        self._notified: Set[Tuple[str, str, str, str, str, float]] = set()
+        self._lock = threading.Lock()
+
+    def check(self, tenant_id: str) -> List[Event]:
+        """
+        Publish an event if price changes of the recent days move estimates of a tenant not notified of them
+
+        Failures are logged rather than raised.
+
+        Returns:
+            Events published
+        """
+        try:
+            report = self.tracker.report(tenant_id, days=self.days)
+        except StoreError as e:
+            logger.error(f"Price changes of tenant {tenant_id} not checked: {e}")
+            return []
+
+        estimates = []
+        for estimate in report.affected_estimates:
+            keys = {
+                (tenant_id, estimate.estimate_id, item.provider, item.region, item.sku, item.new_price)
+                for item in estimate.items
+            }
+            with self._lock:
+                if keys <= self._notified:
+                    continue
+                self._notified |= keys
+            if self.locks is not None:
+                digest = hashlib.sha1(repr(sorted(keys)).encode()).hexdigest()
+                if not self.locks.claim(f"alert:price-change:{tenant_id}:{digest}", (self.days + 1) * _DAY):
+                    continue
+            estimates.append(estimate)
+        if not estimates:
+            return []
+
+        skus = {(item.provider, item.region, item.sku) for estimate in estimates for item in estimate.items}
+        monthly_delta = sum(estimate.monthly_delta for estimate in estimates)
+        event = Event(
+            type=EVENT_PRICE_CHANGED,
+            tenant_id=tenant_id,
+            summary=(
+                f"Price changes since {report.since} move {len(estimates)} estimate(s) "
+                f"by {'+' if monthly_delta >= 0 else '-'}${abs(monthly_delta):,.2f}/month"
+            ),
+            data={
+                "since": report.since.isoformat(),
+                "changes": [
+                    change.dict() for change in report.changes if (change.provider, change.region, change.sku) in skus
+                ],
+                "affected_estimates": [estimate.dict() for estimate in estimates],
+                "monthly_delta": round(monthly_delta, 4),
+            },
+        )
+        self.dispatcher.publish(event)
+        return [event]
+
+
+def catalog_failure_notifier(dispatcher: NotificationDispatcher) -> RefreshFailureListener:
+    """Refresh failure listener publishing events to the default tenant's webhooks"""
+
+    def notify(provider: str, catalog: str, error: str) -> None:
+        dispatcher.publish(Event(
+            type=EVENT_CATALOG_REFRESH_FAILED,
+            tenant_id=DEFAULT_TENANT,
+            summary=f"{provider} price catalog {catalog} could not be refreshed: {error}",
+            data={"provider": provider, "catalog": catalog, "error": error},
+        ))
+
+    return notify
@@ -0,0 +1,191 @@
+"""
+Slack and Microsoft Teams cards
+
+Events delivered to slack_blocks webhooks are Block Kit messages and
+those delivered to teams webhooks are Adaptive Cards (the message format
+of Teams incoming webhooks and workflows). Budget threshold and cost diff
+events list their figures as fields; other events show their summary.
+The same cards answer chat commands (see src.chatops).
+"""
+
+from typing import Any, Dict, List, NamedTuple, Optional, Tuple
+
+from .models import (
+    Event,
+    EVENT_ANOMALY,
+    EVENT_BUDGET_THRESHOLD,
+    EVENT_COST_DIFF,
+    EVENT_ESTIMATE_REVIEWED,
+    EVENT_PRICE_CHANGED,
+)
+
+# Changed resources listed on a cost diff card
+MAX_CARD_CHANGES = 5
+# Slack renders at most 10 fields per section
+_MAX_SLACK_FIELDS = 10
+
+_TITLES = {
+    EVENT_BUDGET_THRESHOLD: "Budget threshold reached",
+    EVENT_ANOMALY: "Spend anomaly detected",
+    EVENT_PRICE_CHANGED: "Catalog prices changed",
+    EVENT_ESTIMATE_REVIEWED: "Estimate reviewed",
+}
+
+
+class Card(NamedTuple):
+    """Platform-neutral content of a chat message"""
+
+    title: str
+    summary: str
+    facts: List[Tuple[str, str]] = []
+    lines: List[str] = []
+    context: Optional[str] = None
+    alert: bool = False
+
+
+def usd(amount: Optional[float], per_month: bool = False) -> str:
+    """Amount as $1,234.56 or -$1,234.56, "-" if unknown"""
+    if amount is None:
+        return "-"
+    text = f"-${abs(amount):,.2f}" if amount < 0 else f"${amount:,.2f}"
+    return text + "/month" if per_month else text
+
+
+def _signed(amount: float) -> str:
+    return ("+" if amount >= 0 else "") + usd(amount, per_month=True)
+
+
+def _budget_card(event: Event) -> Card:
+    budget = event.data.get("budget") or {}
+    status = event.data.get("status") or {}
+    facts = [
+        ("Budget", str(budget.get("name", "-"))),
+        ("Amount", usd(budget.get("amount"), per_month=True)),
+        ("Projected", usd(status.get("projected_actual"))),
+        ("Actual to date", usd(status.get("actual_to_date"))),
+    ]
+    if status.get("utilization") is not None:
+        facts.append(("Utilization", f"{status['utilization']:.0%}"))
+    if event.data.get("threshold") is not None:
+        facts.append(("Threshold", f"{event.data['threshold']:.0%}"))
+    if budget.get("project"):
+        facts.append(("Project", str(budget["project"])))
+    return Card(_TITLES[EVENT_BUDGET_THRESHOLD], event.summary, facts, alert=True)
+
+
+def diff_card(diff: Dict[str, Any]) -> Card:
+    """Card of an estimate diff (DiffResult as a dict, USD)"""
+    delta = diff.get("monthly_delta", 0.0)
+    percent = diff.get("delta_percent")
+    change = _signed(delta) + (f" ({percent:+.1f}%)" if percent is not None else "")
+    passed = diff.get("passed", True)
+    failures = diff.get("failures") or []
+    facts = [
+        (diff.get("base_label", "base"), usd(diff.get("base_monthly_cost"), per_month=True)),
+        (diff.get("head_label", "head"), usd(diff.get("head_monthly_cost"), per_month=True)),
+        ("Change", change),
+        ("Result", "passed" if passed else "failed: " + "; ".join(failures)),
+    ]
+    changes = diff.get("changes") or []
+    lines = [
+        f"{c['key']} ({c['change']}): {_signed(c['monthly_delta'])}" for c in changes[:MAX_CARD_CHANGES]
+    ]
+    if len(changes) > MAX_CARD_CHANGES:
+        lines.append(f"... and {len(changes) - MAX_CARD_CHANGES} more")
+    if diff.get("unpriced"):
+        lines.append(f"{len(diff['unpriced'])} resource(s) could not be priced")
+    return Card(
+        title=f"Cost diff: {diff.get('base_label', 'base')} -> {diff.get('head_label', 'head')}",
+        summary=f"Monthly cost {'increases' if delta > 0 else 'decreases' if delta < 0 else 'is unchanged'}: {change}",
+        facts=facts,
+        lines=lines,
+        alert=not passed,
+    )
+
+
+def _review_card(event: Event) -> Card:
+    review = event.data.get("review") or {}
+    facts = [
+        ("Status", str(review.get("status", ""))),
+        ("Monthly cost", usd(review.get("monthly_cost"), per_month=True)),
+    ]
+    if review.get("project"):
+        facts.append(("Project", str(review["project"])))
+    if review.get("decided_by"):
+        facts.append(("By", str(review["decided_by"])))
+    comment = event.data.get("comment")
+    return Card(
+        _TITLES[EVENT_ESTIMATE_REVIEWED],
+        event.summary,
+        facts,
+        lines=[comment] if comment else [],
+        alert=review.get("status") == "rejected",
+    )
+
+
+def event_card(event: Event) -> Card:
+    """Card of an event"""
+    if event.type == EVENT_BUDGET_THRESHOLD:
+        card = _budget_card(event)
+    elif event.type == EVENT_COST_DIFF and isinstance(event.data.get("diff"), dict):
+        card = diff_card(event.data["diff"])._replace(summary=event.summary)
+    elif event.type == EVENT_ESTIMATE_REVIEWED:
+        card = _review_card(event)
+    else:
+        card = Card(_TITLES.get(event.type, event.type), event.summary, alert=event.type == EVENT_ANOMALY)
+    return card._replace(context=f"{event.type} | tenant {event.tenant_id} | {event.occurred_at:%Y-%m-%d %H:%M} UTC")
+
+
+def slack_blocks(card: Card) -> Dict[str, Any]:
+    """Block Kit message of a card, with its summary as the notification text"""
+    blocks: List[Dict[str, Any]] = [
+        {"type": "header", "text": {"type": "plain_text", "text": card.title[:150]}},
+        {"type": "section", "text": {"type": "mrkdwn", "text": (":warning: " if card.alert else "") + card.summary}},
+    ]
+    for i in range(0, len(card.facts), _MAX_SLACK_FIELDS):
+        blocks.append({
+            "type": "section",
+            "fields": [
+                {"type": "mrkdwn", "text": f"*{name}*\n{value}"} for name, value in card.facts[i:i + _MAX_SLACK_FIELDS]
+            ],
+        })
+    if card.lines:
+        text = "\n".join(f"• {line}" for line in card.lines)
+        blocks.append({"type": "section", "text": {"type": "mrkdwn", "text": text}})
+    if card.context:
+        blocks.append({"type": "context", "elements": [{"type": "mrkdwn", "text": card.context}]})
+    return {"text": f"{card.title}: {card.summary}", "blocks": blocks}
+
+
+def teams_card(card: Card) -> Dict[str, Any]:
+    """Message with the Adaptive Card of a card, as accepted by Teams webhooks and bots"""
+    body: List[Dict[str, Any]] = [
+        {
+            "type": "TextBlock",
+            "text": card.title,
+            "weight": "Bolder",
+            "size": "Medium",
+            "wrap": True,
+            **({"color": "Attention"} if card.alert else {}),
+        },
+        {"type": "TextBlock", "text": card.summary, "wrap": True},
+    ]
+    if card.facts:
+        body.append({"type": "FactSet", "facts": [{"title": name, "value": value} for name, value in card.facts]})
+    if card.lines:
+        body.append({"type": "TextBlock", "text": "\n".join(f"- {line}" for line in card.lines), "wrap": True})
+    if card.context:
+        body.append({"type": "TextBlock", "text": card.context, "isSubtle": True, "size": "Small", "wrap": True})
+    return {
+        "type": "message",
+        "summary": card.summary,
+        "attachments": [{
+            "contentType": "application/vnd.microsoft.card.adaptive",
+            "content": {
+                "$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+                "type": "AdaptiveCard",
+                "version": "1.4",
+                "body": body,
+            },
+        }],
+    }
@@ -0,0 +1,253 @@
+"""
+Webhook delivery
+
+Events are delivered to each subscribed webhook of their tenant by a
+background worker. Connection errors, timeouts, 429 and 5xx responses are
+retried with exponential backoff (2s, 4s, 8s, ... by default, at most
+five minutes apart) until max_attempts; other responses are final.
+"""
+
+import heapq
+import itertools
+import json
+import logging
+import threading
+import time
+import uuid
+from typing import Callable, List, NamedTuple, Optional
+
+import requests
+
+from ..metrics import record_webhook_delivery
+from ..store import Store, StoreError, WebhookRecord
+from .cards import event_card, slack_blocks, teams_card
+from .models import DeliveryResult, Event, FORMAT_SLACK, FORMAT_SLACK_BLOCKS, FORMAT_TEAMS, subscribed
+from .signing import delivery_headers
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_TIMEOUT = 10.0
+DEFAULT_MAX_ATTEMPTS = 5
+DEFAULT_BACKOFF = 2.0
+MAX_BACKOFF = 300.0
+# Deliveries waiting beyond this are dropped, so an unreachable receiver cannot exhaust memory
+MAX_PENDING = 1000
+
+# Sends a body to a URL with headers and a timeout, returning the HTTP status
+Sender = Callable[[str, bytes, dict, float], int]
+
+
+def http_send(url: str, body: bytes, headers: dict, timeout: float) -> int:
+    """POST a payload with requests"""
+    return requests.post(url, data=body, headers=headers, timeout=timeout).status_code
+
+
+def payload(event: Event, format: str) -> bytes:
+    """Request body of an event in a webhook's format"""
+    if format == FORMAT_SLACK:
+        document = {"text": f"[{event.type}] {event.summary}"}
+    elif format == FORMAT_SLACK_BLOCKS:
+        document = slack_blocks(event_card(event))
+    elif format == FORMAT_TEAMS:
+        document = teams_card(event_card(event))
+    else:
+        document = json.loads(event.json())
+    return json.dumps(document, sort_keys=True).encode()
+
+
+def retryable(status_code: Optional[int]) -> bool:
+    """Whether a failed attempt is worth retrying; None means no response"""
+    return status_code is None or status_code == 429 or status_code >= 500
+
+
+class _Delivery(NamedTuple):
+    id: str
+    webhook: WebhookRecord
+    event: Event
+    attempt: int
+
+
+class NotificationDispatcher:
+    """Delivers events to tenants' webhooks with signing and retries"""
+
+    def __init__(
+        self,
+        store: Store,
+        timeout: float = DEFAULT_TIMEOUT,
+        max_attempts: int = DEFAULT_MAX_ATTEMPTS,
+        backoff: float = DEFAULT_BACKOFF,
+        send: Sender = http_send,
+        clock: Callable[[], float] = time.monotonic,
+    ):
+        """
+        Initialize dispatcher
+
+        Args:
+            store: Store holding webhooks
+            timeout: Seconds to wait for a receiver's response
+            max_attempts: Attempts per delivery, including the first
+            backoff: Seconds before the first retry; doubled for each further retry
+            send: Sends one request (replaced in tests)
+            clock: Monotonic time deliveries are scheduled by
+        """
+        self.store = store
+        self.timeout = timeout
+        self.max_attempts = max(1, max_attempts)
+        self.backoff = backoff
+        self.send = send
+        self.clock = clock
+
+        self._queue: list = []
+        self._sequence = itertools.count()
+        self._cond = threading.Condition()
+        self._thread: Optional[threading.Thread] = None
+        self._stopping = False
+
+    @property
+    def pending(self) -> int:
+        """Deliveries waiting for their first attempt or a retry"""
+        with self._cond:
+            return len(self._queue)
+
+    @property
+    def running(self) -> bool:
+        """Whether the background delivery worker is alive"""
+        thread = self._thread
+        return thread is not None and thread.is_alive()
+
+    def publish(self, event: Event) -> int:
+        """
+        Queue an event for every subscribed webhook of its tenant
+
+        Failures are logged rather than raised so notifications never fail
+        the operation that raised the event.
+
+        Returns:
+            Number of deliveries queued
+        """
+        try:
+            webhooks = [w for w in self.store.list_webhooks(event.tenant_id) if subscribed(w, event.type)]
+        except StoreError as e:
+            logger.error(f"Webhooks of tenant {event.tenant_id} not loaded for {event.type}: {e}")
+            return 0
+
+        for webhook in webhooks:
+            self._schedule(_Delivery(str(uuid.uuid4()), webhook, event, 1), self.clock())
+        return len(webhooks)
+
+    def deliver_now(self, webhook: WebhookRecord, event: Event) -> DeliveryResult:
+        """Attempt one delivery right away, without retries"""
+        return self._attempt(_Delivery(str(uuid.uuid4()), webhook, event, 1), final=True)
+
+    def retry_delay(self, attempt: int) -> float:
+        """Seconds between a failed attempt and the next one"""
+        return min(MAX_BACKOFF, self.backoff * 2 ** (attempt - 1))
+
+    def run_pending(self) -> List[DeliveryResult]:
+        """Attempt every delivery that is due, rescheduling retryable failures"""
+        now = self.clock()
+        due = []
+        with self._cond:
+            while self._queue and self._queue[0][0] <= now:
+                due.append(heapq.heappop(self._queue)[2])
+
+        results = []
+        for delivery in due:
+            result = self._attempt(delivery)
+            if result.retry:
+                self._schedule(
+                    delivery._replace(attempt=delivery.attempt + 1),
+                    self.clock() + self.retry_delay(delivery.attempt),
+                )
+            results.append(result)
+        return results
+
+    def start(self) -> None:
+        """Start the background delivery worker"""
+        with self._cond:
+            if self._thread is not None:
+                return
+            self._stopping = False
+            self._thread = threading.Thread(target=self._run, name="webhook-dispatcher", daemon=True)
+            self._thread.start()
+
+    def stop(self, timeout: Optional[float] = None) -> None:
+        """Stop the worker; deliveries still waiting for a retry are dropped"""
+        with self._cond:
+            thread, self._thread = self._thread, None
+            self._stopping = True
+            dropped = len(self._queue)
+            self._queue.clear()
+            self._cond.notify_all()
+        if thread is not None:
+            thread.join(timeout)
+        if dropped:
+            logger.warning(f"{dropped} webhook deliveries dropped on shutdown")
+
+    def _schedule(self, delivery: _Delivery, due: float) -> None:
+        with self._cond:
+            if len(self._queue) >= MAX_PENDING:
+                logger.error(
+                    f"Webhook delivery {delivery.id} of {delivery.event.type} to {delivery.webhook.name} "
+                    f"dropped: {MAX_PENDING} deliveries pending"
+                )
+                record_webhook_delivery("dropped")
+                return
+            heapq.heappush(self._queue, (due, next(self._sequence), delivery))
+            self._cond.notify_all()
+
+    def _run(self) -> None:
+        while True:
+            with self._cond:
+                while not self._stopping:
+                    now = self.clock()
+                    if self._queue and self._queue[0][0] <= now:
+                        break
+                    self._cond.wait(self._queue[0][0] - now if self._queue else None)
+                if self._stopping:
+                    return
+            self.run_pending()
+
+    def _attempt(self, delivery: _Delivery, final: bool = False) -> DeliveryResult:
+        webhook, event = delivery.webhook, delivery.event
+        body = payload(event, webhook.format)
+        headers = delivery_headers(webhook.secret, int(time.time()), body, event.type, delivery.id)
+
+        status_code, error = None, None
+        try:
+            status_code = self.send(webhook.url, body, headers, self.timeout)
+        except Exception as e:
+            error = str(e)
+
+        delivered = status_code is not None and 200 <= status_code < 300
+        if not delivered and error is None:
+            error = f"HTTP {status_code}"
+        retry = (
+            not delivered and not final
+            and retryable(status_code) and delivery.attempt < self.max_attempts
+        )
+
+        if delivered:
+            record_webhook_delivery("delivered")
+        elif retry:
+            record_webhook_delivery("retried")
+            logger.warning(
+                f"Webhook {webhook.name} ({webhook.id}) delivery of {event.type} failed "
+                f"(attempt {delivery.attempt}/{self.max_attempts}): {error}; retrying"
+            )
+        else:
+            record_webhook_delivery("failed")
+            logger.error(
+                f"Webhook {webhook.name} ({webhook.id}) delivery of {event.type} failed "
+                f"(attempt {delivery.attempt}): {error}"
+            )
+
+        return DeliveryResult(
+            webhook_id=webhook.id,
+            event_id=event.id,
+            attempt=delivery.attempt,
+            status_code=status_code,
+            error=None if delivered else error,
+            delivered=delivered,
+            retry=retry,
+        )
@@ -0,0 +1,143 @@
+"""
+Data models for webhooks and notification events
+"""
+
+import secrets
+import uuid
+from datetime import datetime, timezone
+from typing import Any, Dict, List, Optional
+
+from pydantic import BaseModel, Field, validator
+
+from ..store import WebhookRecord, DEFAULT_TENANT
+
+# Event types
+EVENT_BUDGET_THRESHOLD = "budget.threshold_reached"
+EVENT_ANOMALY = "anomaly.detected"
+EVENT_CATALOG_REFRESH_FAILED = "catalog.refresh_failed"
+EVENT_PRICE_CHANGED = "catalog.price_changed"
+EVENT_COST_DIFF = "estimate.diff"
+EVENT_ESTIMATE_REVIEWED = "estimate.reviewed"
+EVENT_TEST = "webhook.test"
+EVENT_TYPES = (
+    EVENT_BUDGET_THRESHOLD,
+    EVENT_ANOMALY,
+    EVENT_CATALOG_REFRESH_FAILED,
+    EVENT_PRICE_CHANGED,
+    EVENT_COST_DIFF,
+    EVENT_ESTIMATE_REVIEWED,
+    EVENT_TEST,
+)
+
+# Payload formats
+FORMAT_JSON = "json"
+# {"text": ...} accepted by Slack incoming webhooks and compatible chat tools
+FORMAT_SLACK = "slack"
+# Block Kit cards for Slack incoming webhooks
+FORMAT_SLACK_BLOCKS = "slack_blocks"
+# Adaptive Cards for Microsoft Teams incoming webhooks and workflows
+FORMAT_TEAMS = "teams"
+FORMATS = (FORMAT_JSON, FORMAT_SLACK, FORMAT_SLACK_BLOCKS, FORMAT_TEAMS)
+
+SECRET_PREFIX = "whsec_"
+
+
+def generate_secret() -> str:
+    """New random signing secret"""
+    return SECRET_PREFIX + secrets.token_urlsafe(24)
+
+
+class WebhookSpec(BaseModel):
+    """Webhook as created or replaced through /webhooks"""
+
+    name: str = Field(..., min_length=1, max_length=100)
+    url: str = Field(..., description="http(s) URL receiving POSTed events")
+    events: List[str] = Field(default_factory=list, description="Event types delivered; empty for all")
+    format: str = Field(
+        FORMAT_JSON,
+        description="json, slack for Slack-compatible {\"text\"} payloads, slack_blocks or teams for cards",
+    )
+    secret: Optional[str] = Field(
+        None,
+        min_length=16,
+        description="Key signing payloads; generated on create when missing, kept on replace when missing"
+    )
+    enabled: bool = True
+
+    @validator("url")
+    def validate_url(cls, v):
+        v = v.strip()
+        if not v.startswith(("http://", "https://")):
+            raise ValueError("url must be an http:// or https:// URL")
+        return v
+
+    @validator("events")
+    def validate_events(cls, v):
+        unknown = [e for e in v if e not in EVENT_TYPES]
+        if unknown:
+            raise ValueError(f"Unknown event types: {', '.join(unknown)}; expected {', '.join(EVENT_TYPES)}")
+        return sorted(set(v))
+
+    @validator("format")
+    def validate_format(cls, v):
+        v = v.strip().lower()
+        if v not in FORMATS:
+            raise ValueError(f"format must be one of: {', '.join(FORMATS)}")
+        return v
+
+    def to_record(self, tenant_id: str, webhook_id: Optional[str] = None, secret: str = "") -> WebhookRecord:
+        return WebhookRecord(
+            id=webhook_id,
+            tenant_id=tenant_id,
+            secret=self.secret or secret,
+            **self.dict(exclude={"secret"}),
+        )
+
+
+class Webhook(BaseModel):
+    """Stored webhook; the secret is only returned when it is generated"""
+
+    id: str
+    tenant_id: str = DEFAULT_TENANT
+    name: str
+    url: str
+    events: List[str] = Field(default_factory=list)
+    format: str = FORMAT_JSON
+    enabled: bool = True
+    has_secret: bool = False
+    created_at: Optional[datetime] = None
+    updated_at: Optional[datetime] = None
+
+    @classmethod
+    def from_record(cls, record: WebhookRecord) -> "Webhook":
+        return cls(has_secret=bool(record.secret), **record.dict(exclude={"secret"}))
+
+
+def subscribed(record: WebhookRecord, event_type: str) -> bool:
+    """Whether a webhook receives events of a type; test events reach every webhook"""
+    return record.enabled and (
+        event_type == EVENT_TEST or not record.events or event_type in record.events
+    )
+
+
+class Event(BaseModel):
+    """Something a tenant's webhooks are notified of"""
+
+    id: str = Field(default_factory=lambda: str(uuid.uuid4()))
+    type: str
+    tenant_id: str = DEFAULT_TENANT
+    occurred_at: datetime = Field(default_factory=lambda: datetime.now(timezone.utc))
+    summary: str = Field(..., description="One line for humans, used as the Slack message")
+    data: Dict[str, Any] = Field(default_factory=dict)
+
+
+class DeliveryResult(BaseModel):
+    """Outcome of one delivery attempt"""
+
+    webhook_id: str
+    event_id: str
+    attempt: int
+    status_code: Optional[int] = Field(None, description="HTTP status, None when no response was received")
+    error: Optional[str] = None
+    delivered: bool
+    retry: bool = Field(False, description="Whether the attempt will be retried")
@@ -0,0 +1,42 @@
+"""
+Webhook payload signing
+
+Each delivery carries X-Kcloud-Signature: sha256=<hex>, the HMAC-SHA256 of
+"<X-Kcloud-Timestamp>.<body>" under the webhook's secret. Receivers
+recompute it (see verify) and reject stale timestamps to stop replays.
+"""
+
+import hashlib
+import hmac
+from typing import Dict
+
+SIGNATURE_HEADER = "X-Kcloud-Signature"
+TIMESTAMP_HEADER = "X-Kcloud-Timestamp"
+EVENT_HEADER = "X-Kcloud-Event"
+DELIVERY_HEADER = "X-Kcloud-Delivery"
+
+_SCHEME = "sha256="
+
+
+def sign(secret: str, timestamp: int, body: bytes) -> str:
+    """Signature header value of a payload"""
+    message = str(timestamp).encode() + b"." + body
+    return _SCHEME + hmac.new(secret.encode(), message, hashlib.sha256).hexdigest()
+
+
+def verify(secret: str, timestamp: int, body: bytes, signature: str) -> bool:
+    """Whether a signature header value matches a payload"""
+    return hmac.compare_digest(sign(secret, timestamp, body), signature)
+
+
+def delivery_headers(secret: str, timestamp: int, body: bytes, event_type: str, delivery_id: str) -> Dict[str, str]:
+    """Headers of a delivery; unsigned when the webhook has no secret"""
+    headers = {
+        "Content-Type": "application/json",
+        TIMESTAMP_HEADER: str(timestamp),
+        EVENT_HEADER: event_type,
+        DELIVERY_HEADER: delivery_id,
+    }
+    if secret:
+        headers[SIGNATURE_HEADER] = sign(secret, timestamp, body)
+    return headers
@@ -0,0 +1,43 @@
+"""
+Policies Module
+
+This module evaluates admins' Rego cost policies over estimate results
+and diffs, e.g. a monthly cost limit per resource or spot pricing for dev
+environments, and returns their deny and warn messages as a verdict.
+"""
+
+from .models import PolicyVerdict
+from .engine import (
+    DEFAULT_PACKAGE,
+    ENGINE_EMBEDDED,
+    ENGINE_OPA,
+    POLICY_ENGINES,
+    EmbeddedEvaluator,
+    OpaEvaluator,
+    PolicyEngine,
+    PolicyError,
+    PolicyUnavailableError,
+    diff_input,
+    estimate_input,
+    load_policies,
+    query_value,
+)
+from .factory import build_policy_engine
+
+__all__ = [
+    "PolicyVerdict",
+    "DEFAULT_PACKAGE",
+    "ENGINE_EMBEDDED",
+    "ENGINE_OPA",
+    "POLICY_ENGINES",
+    "EmbeddedEvaluator",
+    "OpaEvaluator",
+    "PolicyEngine",
+    "PolicyError",
+    "PolicyUnavailableError",
+    "diff_input",
+    "estimate_input",
+    "load_policies",
+    "query_value",
+    "build_policy_engine",
+]
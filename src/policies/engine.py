@@ -0,0 +1,290 @@
+"""
+Cost policy engine
+
+Admins write Rego policies over estimate results, e.g. "deny any single
+resource over $500/month" or "require spot for dev environments", and
+every estimate and diff is answered with the policies' verdict. Policies
+are the .rego files of POLICY_PATH; their package (kcloud.cost by
+default) defines deny and warn rules whose messages make the verdict,
+as in conftest:
+
+    package kcloud.cost
+
+    deny contains msg if {
+        some item in input.estimate.line_items
+        item.monthly_cost > 500
+        msg := sprintf("%s costs $%.2f/month", [item.name, item.monthly_cost])
+    }
+
+The input is {"kind", "project", "labels", "estimate"} for estimates and
+{"kind": "diff", "labels", "diff"} for diffs, amounts in USD. Policies are
+evaluated in process by the embedded rego-cpp interpreter (regopy) or
+with the opa CLI. A policy that fails to evaluate denies, so a broken
+policy is noticed rather than silently passing everything.
+"""
+
+import json
+import logging
+import os
+import subprocess
+import tempfile
+from typing import Any, Dict, List, Mapping, Optional
+
+from .models import PolicyVerdict
+
+logger = logging.getLogger(__name__)
+
+ENGINE_EMBEDDED = "embedded"
+ENGINE_OPA = "opa"
+POLICY_ENGINES = (ENGINE_EMBEDDED, ENGINE_OPA)
+
+DEFAULT_PACKAGE = "kcloud.cost"
+
+KIND_DIFF = "diff"
+
+
+class PolicyError(ValueError):
+    """Raised when policies cannot be loaded or evaluated"""
+
+
+class PolicyUnavailableError(RuntimeError):
+    """Raised when the policy engine cannot be run"""
+
+
+def load_policies(path: str) -> Dict[str, str]:
+    """
+    Rego modules of a .rego file or of the .rego files under a directory
+
+    Test files (*_test.rego) are left out.
+
+    Returns:
+        Source of each module by its path relative to the directory
+
+    Raises:
+        PolicyError: If the path holds no policy
+    """
+    if os.path.isfile(path):
+        with open(path, encoding="utf-8") as f:
+            return {os.path.basename(path): f.read()}
+
+    modules = {}
+    for root, _, files in os.walk(path):
+        for name in sorted(files):
+            if not name.endswith(".rego") or name.endswith("_test.rego"):
+                continue
+            file_path = os.path.join(root, name)
+            with open(file_path, encoding="utf-8") as f:
+                modules[os.path.relpath(file_path, path)] = f.read()
+    if not modules:
+        raise PolicyError(f"No .rego policies found in {path}")
+    return dict(sorted(modules.items()))
+
+
+def estimate_input(
+    kind: str,
+    estimate: Dict[str, Any],
+    project: Optional[str] = None,
+    labels: Optional[Dict[str, str]] = None,
+) -> Dict[str, Any]:
+    """Policy input of an estimate result (USD)"""
+    return {"kind": kind, "project": project, "labels": labels or {}, "estimate": estimate}
+
+
+def diff_input(diff: Dict[str, Any], labels: Optional[Dict[str, str]] = None) -> Dict[str, Any]:
+    """Policy input of an estimate diff (USD); labels are those of the head side"""
+    return {"kind": KIND_DIFF, "labels": labels or {}, "diff": diff}
+
+
+def query_value(document: Mapping[str, Any]) -> Any:
+    """
+    Value of a query from its JSON result, None if undefined
+
+    Accepts the result of `opa eval` ({"result": [{"expressions": [...]}]})
+    and of rego-cpp ({"expressions": [...]} or {"bindings": {...}}).
+    """
+    if "result" in document:
+        results = document["result"]
+        return query_value(results[0]) if results else None
+    expressions = document.get("expressions")
+    if expressions:
+        return expressions[0].get("value")
+    bindings = document.get("bindings")
+    if bindings:
+        return next(iter(bindings.values()))
+    return None
+
+
+class EmbeddedEvaluator:
+    """Evaluates Rego in process with the rego-cpp interpreter (regopy)"""
+
+    def check(self) -> None:
+        """
+        Check the interpreter can be loaded
+
+        Raises:
+            PolicyUnavailableError: If regopy is not installed
+        """
+        self._interpreter()
+
+    def evaluate(self, modules: Mapping[str, str], document: Dict[str, Any], query: str) -> Any:
+        """
+        Value of query over the modules with document as input
+
+        Raises:
+            PolicyError: If a module or the query is invalid
+            PolicyUnavailableError: If regopy is not installed
+        """
+        interpreter = self._interpreter()
+        try:
+            for name, source in modules.items():
+                interpreter.add_module(name, source)
+            interpreter.set_input_json(json.dumps(document))
+            output = interpreter.query(query)
+        except Exception as e:
+            raise PolicyError(f"Policy evaluation failed: {e}") from None
+        if hasattr(output, "ok") and not output.ok():
+            raise PolicyError(f"Policy evaluation failed: {output}")
+        try:
+            return query_value(json.loads(str(output)))
+        except ValueError:
+            raise PolicyError(f"Policy evaluation failed: {output}") from None
+
+    def _interpreter(self):
+        """New rego-cpp interpreter"""
+        try:
+            from regopy import Interpreter
+        except ImportError:
+            raise PolicyUnavailableError("Embedded policy evaluation needs regopy (pip install regopy)") from None
+        return Interpreter()
+
+
+class OpaEvaluator:
+    """Evaluates Rego with `opa eval`"""
+
+    def __init__(self, opa_binary: str = "opa", timeout_seconds: float = 5.0):
+        """
+        Initialize OPA evaluator
+
+        Args:
+            opa_binary: Path or name of the opa executable
+            timeout_seconds: Maximum time of one evaluation
+        """
+        self.opa_binary = opa_binary
+        self.timeout_seconds = timeout_seconds
+
+    def check(self) -> None:
+        """
+        Check opa can be run
+
+        Raises:
+            PolicyUnavailableError: If the opa binary is missing or fails
+        """
+        try:
+            completed = subprocess.run(
+                [self.opa_binary, "version"], capture_output=True, text=True, timeout=self.timeout_seconds
+            )
+        except FileNotFoundError:
+            raise PolicyUnavailableError(f"opa binary not found: {self.opa_binary}") from None
+        except subprocess.TimeoutExpired:
+            raise PolicyUnavailableError(f"opa version timed out after {self.timeout_seconds}s") from None
+        if completed.returncode != 0:
+            raise PolicyUnavailableError(f"opa cannot be run: {(completed.stderr or completed.stdout).strip()[:200]}")
+
+    def evaluate(self, modules: Mapping[str, str], document: Dict[str, Any], query: str) -> Any:
+        """
+        Value of query over the modules with document as input
+
+        Raises:
+            PolicyError: If a module or the query is invalid
+            PolicyUnavailableError: If opa cannot be run
+        """
+        with tempfile.TemporaryDirectory(prefix="kcloud-policy-") as workdir:
+            args = [self.opa_binary, "eval", "--format", "json", "--stdin-input"]
+            for i, source in enumerate(modules.values()):
+                module_path = os.path.join(workdir, f"policy{i}.rego")
+                with open(module_path, "w", encoding="utf-8") as f:
+                    f.write(source)
+                args += ["--data", module_path]
+            args.append(query)
+
+            try:
+                completed = subprocess.run(
+                    args,
+                    input=json.dumps(document),
+                    capture_output=True,
+                    text=True,
+                    timeout=self.timeout_seconds,
+                    cwd=workdir,
+                )
+            except FileNotFoundError:
+                raise PolicyUnavailableError(f"opa binary not found: {self.opa_binary}") from None
+            except subprocess.TimeoutExpired:
+                raise PolicyError(f"Policy evaluation timed out after {self.timeout_seconds}s") from None
+
+        if completed.returncode != 0:
+            message = (completed.stderr or completed.stdout).strip()
+            raise PolicyError(f"Policy evaluation failed: {message[:1000]}")
+        try:
+            return query_value(json.loads(completed.stdout))
+        except ValueError:
+            raise PolicyError(f"opa returned unreadable output: {completed.stdout[:200]}") from None
+
+
+def _messages(rule: Any) -> List[str]:
+    """Messages of a deny or warn rule: strings, objects with msg, or anything else as JSON"""
+    if rule is None:
+        return []
+    if isinstance(rule, (str, dict)):
+        rule = [rule]
+    messages = []
+    for entry in rule:
+        if isinstance(entry, dict) and isinstance(entry.get("msg"), str):
+            messages.append(entry["msg"])
+        elif isinstance(entry, str):
+            messages.append(entry)
+        else:
+            messages.append(json.dumps(entry, sort_keys=True))
+    return messages
+
+
+class PolicyEngine:
+    """Evaluates the cost policies on estimates and diffs"""
+
+    def __init__(self, modules: Mapping[str, str], evaluator, package: str = DEFAULT_PACKAGE):
+        """
+        Initialize policy engine
+
+        Args:
+            modules: Rego source by module name
+            evaluator: EmbeddedEvaluator, OpaEvaluator or another with evaluate(modules, input, query)
+            package: Package whose deny and warn rules make the verdict
+        """
+        self.modules = dict(modules)
+        self.evaluator = evaluator
+        self.package = package
+
+    def evaluate(self, document: Dict[str, Any]) -> PolicyVerdict:
+        """
+        Verdict of the policies on an input document
+
+        Failures are logged and returned as a denying verdict; they never
+        fail the estimate itself.
+        """
+        policies = list(self.modules)
+        try:
+            value = self.evaluator.evaluate(self.modules, document, f"data.{self.package}")
+        except (PolicyError, PolicyUnavailableError) as e:
+            logger.error(f"Cost policies could not be evaluated: {e}")
+            return PolicyVerdict(allowed=False, policies=policies, error=str(e))
+
+        if value is not None and not isinstance(value, dict):
+            error = f"data.{self.package} is not a package with deny and warn rules"
+            return PolicyVerdict(allowed=False, policies=policies, error=error)
+        value = value or {}
+        denials = _messages(value.get("deny"))
+        return PolicyVerdict(
+            allowed=not denials,
+            denials=denials,
+            warnings=_messages(value.get("warn")),
+            policies=policies,
+        )
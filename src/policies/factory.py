@@ -0,0 +1,30 @@
+"""
+Policy engine from application settings
+"""
+
+from typing import Optional
+
+from .engine import ENGINE_OPA, POLICY_ENGINES, EmbeddedEvaluator, OpaEvaluator, PolicyEngine, load_policies
+
+
+def build_policy_engine(settings) -> Optional[PolicyEngine]:
+    """
+    Policy engine over the policies of POLICY_PATH, None when no path is set
+
+    The evaluator is checked up front, so a missing interpreter fails
+    startup instead of denying every estimate.
+
+    Raises:
+        ValueError: If the engine is unknown or the path holds no policy
+        PolicyUnavailableError: If the engine's evaluator cannot be run
+    """
+    if not settings.policy_path:
+        return None
+    if settings.policy_engine not in POLICY_ENGINES:
+        raise ValueError(f"POLICY_ENGINE must be one of: {', '.join(POLICY_ENGINES)}")
+    if settings.policy_engine == ENGINE_OPA:
+        evaluator = OpaEvaluator(settings.policy_opa_binary, timeout_seconds=settings.policy_timeout_seconds)
+    else:
+        evaluator = EmbeddedEvaluator()
+    evaluator.check()
+    return PolicyEngine(load_policies(settings.policy_path), evaluator, package=settings.policy_package)
@@ -0,0 +1,17 @@
+"""
+Data models for cost policies
+"""
+
+from typing import List, Optional
+
+from pydantic import BaseModel, Field
+
+
+class PolicyVerdict(BaseModel):
+    """Verdict of the cost policies on an estimate or diff"""
+
+    allowed: bool = Field(..., description="False if a policy denies the input or could not be evaluated")
+    denials: List[str] = Field(default_factory=list, description="Messages of the deny rules")
+    warnings: List[str] = Field(default_factory=list, description="Messages of the warn rules")
+    policies: List[str] = Field(default_factory=list, description="Policy files evaluated")
+    error: Optional[str] = Field(None, description="Why the policies could not be evaluated")
@@ -0,0 +1,19 @@
+"""
+Price Changes Module
+
+This module compares the stored price catalogs with their daily snapshots
+of an earlier day, lists the SKUs whose prices changed and by how much,
+and finds the stored estimates whose projected spend the changes move.
+"""
+
+from .models import AffectedEstimate, AffectedItem, PriceChange, PriceChangeReport
+from .tracker import PriceChangeTracker, catalog_prices
+
+__all__ = [
+    "AffectedEstimate",
+    "AffectedItem",
+    "PriceChange",
+    "PriceChangeReport",
+    "PriceChangeTracker",
+    "catalog_prices",
+]
@@ -0,0 +1,63 @@
+"""
+Data models for catalog price changes
+"""
+
+from datetime import date, datetime
+from typing import List, Optional
+
+from pydantic import BaseModel, Field
+
+
+class PriceChange(BaseModel):
+    """A catalog price that differs between two days"""
+
+    provider: str
+    catalog: str = Field(..., description="Catalog key, e.g. AmazonEC2-us-east-1")
+    service: Optional[str] = Field(None, description="Service of the price, None for catalogs keyed otherwise")
+    region: Optional[str] = None
+    sku: str
+    qualifier: str = Field("", description="Engine, license or commitment term the price is qualified by")
+    unit: Optional[str] = None
+    currency: str = "USD"
+    old_price: float
+    new_price: float
+    change: float = Field(..., description="New price minus old price")
+    change_percent: Optional[float] = Field(None, description="Change as a percentage of the old price")
+
+
+class AffectedItem(BaseModel):
+    """Part of a stored estimate priced at a price that has since changed"""
+
+    provider: str
+    region: str
+    sku: str
+    old_price: float = Field(..., description="Unit price the estimate was priced at")
+    new_price: float
+    monthly_cost: float = Field(..., description="Recorded monthly cost of the item")
+    monthly_delta: float = Field(..., description="Monthly cost change at the new price")
+
+
+class AffectedEstimate(BaseModel):
+    """A stored estimate whose projected spend the price changes move"""
+
+    estimate_id: str
+    kind: str
+    project: Optional[str] = None
+    created_at: Optional[datetime] = None
+    monthly_cost: float = Field(..., description="Recorded monthly cost")
+    monthly_delta: float = Field(..., description="Monthly cost change at the new prices")
+    items: List[AffectedItem]
+
+
+class PriceChangeReport(BaseModel):
+    """Catalog prices changed since a day and the estimates they affect"""
+
+    provider: Optional[str] = Field(None, description="Provider compared, None for every stored catalog")
+    since: date = Field(..., description="Day of the catalog snapshots compared against")
+    until: date
+    changed: int = Field(..., description="Prices changed, including those past the listed limit")
+    added: int = Field(..., description="Prices in today's catalogs only")
+    removed: int = Field(..., description="Prices in the snapshots only")
+    changes: List[PriceChange] = Field(..., description="Changed prices, largest relative change first")
+    affected_estimates: List[AffectedEstimate] = Field(..., description="Largest monthly change first")
+    monthly_delta: float = Field(..., description="Monthly cost change of the affected estimates")
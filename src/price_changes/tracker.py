@@ -0,0 +1,267 @@
+"""
+Catalog price change tracking
+
+Compares the stored catalogs with their snapshots of an earlier day, SKU
+by SKU, and finds the stored estimates priced at a price that has since
+changed. An estimate item is affected when it names its provider, region
+and SKU and its recorded unit price is the old price of a change; its
+monthly cost is scaled by the change. Items priced from a tenant's price
+list, or by a pricing model other than on-demand, are not affected by
+public price changes. Catalog prices not in USD (NCP) are listed but not
+matched against estimates, which are recorded in USD.
+"""
+
+import math
+from datetime import date, datetime, timedelta, timezone
+from typing import Any, Callable, Dict, List, Optional, Tuple
+
+from ..money import round_money, sum_money
+from ..pricing import PRICING_ON_DEMAND
+from ..store import Store
+from ..tenancy import OVERRIDE_SOURCE
+from .models import AffectedEstimate, AffectedItem, PriceChange, PriceChangeReport
+
+# (service, region, sku, qualifier) of a catalog price
+PriceKey = Tuple[Optional[str], Optional[str], str, str]
+
+# Alibaba Cloud quotes are kept per region, under quotes-<region>
+_QUOTES_PREFIX = "quotes-"
+
+# Result fields naming the SKU and the unit price an item was priced at
+_SKU_FIELDS = ("sku", "instance_type", "instance_class")
+_PRICE_FIELDS = ("unit_price_hourly", "unit_price", "hourly_price")
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+def catalog_prices(key: str, document: Dict[str, Any]) -> Dict[PriceKey, Dict[str, Any]]:
+    """
+    Prices of a stored catalog document by service, region, SKU and qualifier
+
+    Entries keyed "service|region|sku[|qualifier]" and Alibaba Cloud quotes
+    keyed "service|sku" are split into their parts; other keys are taken as
+    the SKU. Documents without entries (OpenStack rate cards) and entries
+    without a price have no prices.
+    """
+    if "entries" in document:
+        items, region = document.get("entries") or {}, None
+    elif "quotes" in document:
+        items = document.get("quotes") or {}
+        region = key[len(_QUOTES_PREFIX):] if key.startswith(_QUOTES_PREFIX) else None
+    else:
+        return {}
+
+    prices = {}
+    for entry_key, entry in items.items():
+        if not isinstance(entry, dict) or not isinstance(entry.get("price"), (int, float)):
+            continue
+        parts = entry_key.split("|")
+        if len(parts) >= 3:
+            price_key = (parts[0], parts[1], parts[2], "|".join(parts[3:]))
+        elif len(parts) == 2 and region is not None:
+            price_key = (parts[0], region, parts[1], "")
+        else:
+            price_key = (None, None, entry_key, "")
+        prices[price_key] = entry
+    return prices
+
+
+class PriceChangeTracker:
+    """Finds catalog price changes and the stored estimates they affect"""
+
+    def __init__(
+        self,
+        store: Store,
+        estimate_limit: int = 500,
+        tolerance: float = 0.001,
+        clock: Callable[[], datetime] = utcnow,
+    ):
+        """
+        Initialize tracker
+
+        Args:
+            store: Store keeping catalogs, their daily snapshots and estimates
+            estimate_limit: Most recent estimates of a tenant checked for affected items
+            tolerance: Relative difference within which a recorded unit price is the old price
+            clock: Current time (aware UTC)
+        """
+        self.store = store
+        self.estimate_limit = estimate_limit
+        self.tolerance = tolerance
+        self.clock = clock
+
+    def changes(self, since: date, provider: Optional[str] = None) -> Tuple[List[PriceChange], int, int]:
+        """
+        Prices changed between the snapshots of a day and the current catalogs
+
+        Args:
+            since: Day whose snapshots (the newest on or before it) are compared against
+            provider: Only catalogs of this provider
+
+        Returns:
+            Changes, largest relative change first; prices added; prices removed
+        """
+        changes, added, removed = [], 0, 0
+        for record in self.store.list_catalogs(provider):
+            snapshot = self.store.load_catalog_snapshot(record.provider, record.key, since)
+            old = catalog_prices(record.key, snapshot.document) if snapshot is not None else {}
+            new = catalog_prices(record.key, record.document)
+            added += len(new.keys() - old.keys())
+            removed += len(old.keys() - new.keys())
+
+            for price_key in new.keys() & old.keys():
+                old_price, new_price = float(old[price_key]["price"]), float(new[price_key]["price"])
+                if old_price == new_price:
+                    continue
+                service, region, sku, qualifier = price_key
+                entry = new[price_key]
+                changes.append(PriceChange(
+                    provider=record.provider,
+                    catalog=record.key,
+                    service=service,
+                    region=region,
+                    sku=sku,
+                    qualifier=qualifier,
+                    unit=entry.get("unit"),
+                    currency=entry.get("currency") or "USD",
+                    old_price=old_price,
+                    new_price=new_price,
+                    change=new_price - old_price,
+                    change_percent=round((new_price - old_price) / old_price * 100, 2) if old_price else None,
+                ))
+
+        changes.sort(key=lambda c: (
+            -(math.inf if c.change_percent is None else abs(c.change_percent)),
+            c.provider, c.catalog, c.sku, c.qualifier,
+        ))
+        return changes, added, removed
+
+    def affected(self, tenant_id: str, changes: List[PriceChange]) -> List[AffectedEstimate]:
+        """
+        A tenant's recent estimates priced at the old price of a change, largest monthly change first
+
+        Only the tenant's estimate_limit most recent estimates are checked.
+        """
+        index: Dict[Tuple[str, str, str], List[PriceChange]] = {}
+        for change in changes:
+            if change.region is not None and change.currency == "USD" and change.old_price > 0:
+                index.setdefault((change.provider, change.region, change.sku), []).append(change)
+        if not index:
+            return []
+
+        affected = []
+        for record in self.store.list_estimates(limit=self.estimate_limit, tenant_id=tenant_id):
+            if record.currency != "USD":
+                continue
+            items: List[AffectedItem] = []
+            self._walk(record.result, None, None, index, items)
+            if not items:
+                continue
+            affected.append(AffectedEstimate(
+                estimate_id=record.id,
+                kind=record.kind,
+                project=record.project,
+                created_at=record.created_at,
+                monthly_cost=record.monthly_cost,
+                monthly_delta=sum_money(item.monthly_delta for item in items),
+                items=items,
+            ))
+
+        affected.sort(key=lambda e: -abs(e.monthly_delta))
+        return affected
+
+    def report(
+        self,
+        tenant_id: str,
+        days: int = 30,
+        provider: Optional[str] = None,
+        limit: Optional[int] = None,
+    ) -> PriceChangeReport:
+        """
+        Prices changed over the last days and the tenant's estimates they affect
+
+        Args:
+            tenant_id: Tenant whose estimates are checked
+            days: Days back to the snapshots compared against
+            provider: Only catalogs of this provider
+            limit: Most changes listed, every change if not provided
+        """
+        until = self.clock().date()
+        since = until - timedelta(days=days)
+        changes, added, removed = self.changes(since, provider)
+        affected = self.affected(tenant_id, changes)
+
+        return PriceChangeReport(
+            provider=provider,
+            since=since,
+            until=until,
+            changed=len(changes),
+            added=added,
+            removed=removed,
+            changes=changes[:limit] if limit is not None else changes,
+            affected_estimates=affected,
+            monthly_delta=sum_money(estimate.monthly_delta for estimate in affected),
+        )
+
+    def _walk(
+        self,
+        node: Any,
+        provider: Optional[str],
+        region: Optional[str],
+        index: Dict[Tuple[str, str, str], List[PriceChange]],
+        items: List[AffectedItem],
+    ) -> None:
+        """Collect the affected items of a recorded result, inheriting provider and region from enclosing items"""
+        if isinstance(node, list):
+            for value in node:
+                self._walk(value, provider, region, index, items)
+            return
+        if not isinstance(node, dict):
+            return
+
+        if isinstance(node.get("provider"), str):
+            provider = node["provider"]
+        if isinstance(node.get("region"), str):
+            region = node["region"]
+        item = self._match(node, provider, region, index)
+        if item is not None:
+            items.append(item)
+            return
+        for value in node.values():
+            if isinstance(value, (dict, list)):
+                self._walk(value, provider, region, index, items)
+
+    def _match(
+        self,
+        node: Dict[str, Any],
+        provider: Optional[str],
+        region: Optional[str],
+        index: Dict[Tuple[str, str, str], List[PriceChange]],
+    ) -> Optional[AffectedItem]:
+        """Affected item of a priced result node, None if its price has not changed"""
+        sku = next((node[f] for f in _SKU_FIELDS if isinstance(node.get(f), str)), None)
+        price = next((node[f] for f in _PRICE_FIELDS if isinstance(node.get(f), (int, float))), None)
+        monthly_cost = node.get("monthly_cost")
+        if provider is None or region is None or sku is None or price is None:
+            return None
+        if not isinstance(monthly_cost, (int, float)):
+            return None
+        if node.get("pricing_model", PRICING_ON_DEMAND) != PRICING_ON_DEMAND:
+            return None
+        if node.get("price_source") == OVERRIDE_SOURCE:
+            return None
+
+        for change in index.get((provider, region, sku), ()):
+            if math.isclose(price, change.old_price, rel_tol=self.tolerance):
+                return AffectedItem(
+                    provider=provider,
+                    region=region,
+                    sku=sku,
+                    old_price=change.old_price,
+                    new_price=change.new_price,
+                    monthly_cost=monthly_cost,
+                    monthly_delta=round_money(monthly_cost * (change.new_price / change.old_price - 1)),
+                )
+        return None
@@ -0,0 +1,312 @@
+"""
+Pricing Module
+
+Pluggable pricing providers, the registry that routes
+price lookups to them by cloud, the scheduler that keeps
+their catalogs fresh, the formulas (tiers, free
+allowances, minimums) usage is priced with, the
+discovery of the regions and instance types they price,
+fuzzy matching of instance types that are not found, and
+the plugins adding out-of-tree providers.
+"""
+
+from .models import (
+    Price,
+    PriceQuery,
+    PriceTier,
+    UsageDiscount,
+    TIER_MODE_GRADUATED,
+    TIER_MODE_VOLUME,
+    TIER_MODES,
+    SERVICE_COMPUTE,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_OBJECT_STORAGE,
+    SERVICE_OBJECT_REQUESTS,
+    SERVICE_OBJECT_RETRIEVAL,
+    SERVICE_DATABASE,
+    SERVICE_DATABASE_STORAGE,
+    SERVICE_DATABASE_IOPS,
+    SERVICE_DATABASE_BACKUP,
+    SERVICE_DATA_TRANSFER,
+    SERVICE_KUBERNETES,
+    SERVICE_KUBERNETES_PODS,
+    SERVICE_ACCELERATOR,
+    SERVICE_SERVERLESS_REQUESTS,
+    SERVICE_SERVERLESS_MEMORY,
+    SERVICE_SERVERLESS_CPU,
+    PRICING_ON_DEMAND,
+    PRICING_SPOT,
+    PRICING_MODELS,
+    normalize_pricing_model,
+    CatalogStatus,
+    CATALOG_PENDING,
+    CATALOG_OK,
+    CATALOG_DEGRADED,
+)
+from .formula import (
+    billed_quantity,
+    usage_amount,
+    usage_cost,
+    effective_unit_price,
+    tier_usage,
+    normalize_tier_mode,
+    validate_tiers,
+    parse_tiers,
+)
+from .provider import PricingProvider, PriceNotFoundError, ANY_REGION, instance_types_of_keys
+from .registry import (
+    ProviderRegistry,
+    ProviderNotFoundError,
+    PriceOverrideLookup,
+    register_factory,
+    available_providers,
+    build_registry,
+)
+from .events import (
+    RefreshFailureListener,
+    add_refresh_failure_listener,
+    remove_refresh_failure_listener,
+    report_refresh_failure,
+)
+from .scheduler import RefreshScheduler
+from .catalog import PriceCatalog
+from .instance_types import (
+    InstanceShape,
+    get_instance_shape,
+    register_shape,
+    register_shape_resolver,
+    known_instance_types,
+)
+from .accelerators import AcceleratorType, ACCELERATOR_TYPES, normalize_accelerator, accelerator_types
+from .static import StaticProvider
+from .commitment import (
+    PRICING_RESERVED,
+    PRICING_SAVINGS_PLAN,
+    COMMITMENT_MODELS,
+    TERMS,
+    PAYMENT_OPTIONS,
+    NO_UPFRONT,
+    PARTIAL_UPFRONT,
+    ALL_UPFRONT,
+    ATTR_TERM,
+    ATTR_PAYMENT_OPTION,
+    term_hours,
+    commitment_qualifier,
+    split_upfront,
+)
+from .database import (
+    ENGINES,
+    DEPLOYMENTS,
+    DEPLOYMENT_SINGLE_AZ,
+    DEPLOYMENT_MULTI_AZ,
+    ATTR_ENGINE,
+    ATTR_DEPLOYMENT,
+    BACKUP_SKU,
+    normalize_engine,
+    deployment_of,
+)
+from .object_storage import (
+    STORAGE_CLASSES,
+    STORAGE_CLASS_STANDARD,
+    STORAGE_CLASS_INFREQUENT,
+    STORAGE_CLASS_ARCHIVE,
+    OPERATIONS,
+    OPERATION_WRITE,
+    OPERATION_READ,
+    OPERATION_TRANSITION,
+    ATTR_OPERATION,
+    normalize_storage_class,
+    storage_class_sku,
+)
+from .kubernetes import (
+    CLUSTER_TIERS,
+    TIER_SELF_MANAGED,
+    TIER_FREE,
+    TIER_STANDARD,
+    TIER_EXTENDED,
+    TIER_PREMIUM,
+    TIER_AUTOPILOT,
+    POD_SKU_VCPU,
+    POD_SKU_MEMORY,
+    normalize_cluster_tier,
+    default_cluster_tier,
+)
+from .serverless import (
+    SERVERLESS_PLATFORMS,
+    PLATFORM_LAMBDA,
+    PLATFORM_CLOUD_RUN,
+    PLATFORM_CLOUD_FUNCTIONS,
+    PLATFORM_AZURE_FUNCTIONS,
+    ARCH_X86_64,
+    ARCH_ARM64,
+    ATTR_ARCHITECTURE,
+    normalize_platform,
+    default_platform,
+)
+from .spot import DEFAULT_SPOT_WINDOW_DAYS, time_weighted_average, window_average, update_history
+from .matching import (
+    DEFAULT_MIN_CONFIDENCE,
+    SkuMatch,
+    fuzzy_matching,
+    fuzzy_min_confidence,
+    similarity,
+    closest_skus,
+    resolve_sku,
+    did_you_mean,
+)
+from .plugins import ExecPricingProvider, PluginError, PROTOCOL_VERSION, load_plugins, plugin_executables
+from .discovery import (
+    CloudCapabilities,
+    RegionInfo,
+    InstanceTypeInfo,
+    cloud_capabilities,
+    list_regions,
+    list_instance_types,
+    paginate,
+)
+
+__all__ = [
+    "Price",
+    "PriceQuery",
+    "PriceTier",
+    "UsageDiscount",
+    "TIER_MODE_GRADUATED",
+    "TIER_MODE_VOLUME",
+    "TIER_MODES",
+    "billed_quantity",
+    "usage_amount",
+    "usage_cost",
+    "effective_unit_price",
+    "tier_usage",
+    "normalize_tier_mode",
+    "validate_tiers",
+    "parse_tiers",
+    "SERVICE_COMPUTE",
+    "SERVICE_BLOCK_STORAGE",
+    "SERVICE_OBJECT_STORAGE",
+    "SERVICE_OBJECT_REQUESTS",
+    "SERVICE_OBJECT_RETRIEVAL",
+    "SERVICE_DATABASE",
+    "SERVICE_DATABASE_STORAGE",
+    "SERVICE_DATABASE_IOPS",
+    "SERVICE_DATABASE_BACKUP",
+    "SERVICE_DATA_TRANSFER",
+    "SERVICE_KUBERNETES",
+    "SERVICE_KUBERNETES_PODS",
+    "SERVICE_ACCELERATOR",
+    "SERVICE_SERVERLESS_REQUESTS",
+    "SERVICE_SERVERLESS_MEMORY",
+    "SERVICE_SERVERLESS_CPU",
+    "PRICING_ON_DEMAND",
+    "PRICING_SPOT",
+    "PRICING_MODELS",
+    "normalize_pricing_model",
+    "CatalogStatus",
+    "CATALOG_PENDING",
+    "CATALOG_OK",
+    "CATALOG_DEGRADED",
+    "PricingProvider",
+    "PriceNotFoundError",
+    "ANY_REGION",
+    "instance_types_of_keys",
+    "ProviderRegistry",
+    "ProviderNotFoundError",
+    "PriceOverrideLookup",
+    "register_factory",
+    "available_providers",
+    "build_registry",
+    "RefreshFailureListener",
+    "add_refresh_failure_listener",
+    "remove_refresh_failure_listener",
+    "report_refresh_failure",
+    "RefreshScheduler",
+    "PriceCatalog",
+    "InstanceShape",
+    "get_instance_shape",
+    "register_shape",
+    "register_shape_resolver",
+    "known_instance_types",
+    "AcceleratorType",
+    "ACCELERATOR_TYPES",
+    "normalize_accelerator",
+    "accelerator_types",
+    "StaticProvider",
+    "PRICING_RESERVED",
+    "PRICING_SAVINGS_PLAN",
+    "COMMITMENT_MODELS",
+    "TERMS",
+    "PAYMENT_OPTIONS",
+    "NO_UPFRONT",
+    "PARTIAL_UPFRONT",
+    "ALL_UPFRONT",
+    "ATTR_TERM",
+    "ATTR_PAYMENT_OPTION",
+    "term_hours",
+    "commitment_qualifier",
+    "split_upfront",
+    "ENGINES",
+    "DEPLOYMENTS",
+    "DEPLOYMENT_SINGLE_AZ",
+    "DEPLOYMENT_MULTI_AZ",
+    "ATTR_ENGINE",
+    "ATTR_DEPLOYMENT",
+    "BACKUP_SKU",
+    "normalize_engine",
+    "deployment_of",
+    "STORAGE_CLASSES",
+    "STORAGE_CLASS_STANDARD",
+    "STORAGE_CLASS_INFREQUENT",
+    "STORAGE_CLASS_ARCHIVE",
+    "OPERATIONS",
+    "OPERATION_WRITE",
+    "OPERATION_READ",
+    "OPERATION_TRANSITION",
+    "ATTR_OPERATION",
+    "normalize_storage_class",
+    "storage_class_sku",
+    "CLUSTER_TIERS",
+    "TIER_SELF_MANAGED",
+    "TIER_FREE",
+    "TIER_STANDARD",
+    "TIER_EXTENDED",
+    "TIER_PREMIUM",
+    "TIER_AUTOPILOT",
+    "POD_SKU_VCPU",
+    "POD_SKU_MEMORY",
+    "normalize_cluster_tier",
+    "default_cluster_tier",
+    "SERVERLESS_PLATFORMS",
+    "PLATFORM_LAMBDA",
+    "PLATFORM_CLOUD_RUN",
+    "PLATFORM_CLOUD_FUNCTIONS",
+    "PLATFORM_AZURE_FUNCTIONS",
+    "ARCH_X86_64",
+    "ARCH_ARM64",
+    "ATTR_ARCHITECTURE",
+    "normalize_platform",
+    "default_platform",
+    "DEFAULT_SPOT_WINDOW_DAYS",
+    "time_weighted_average",
+    "window_average",
+    "update_history",
+    "DEFAULT_MIN_CONFIDENCE",
+    "SkuMatch",
+    "fuzzy_matching",
+    "fuzzy_min_confidence",
+    "similarity",
+    "closest_skus",
+    "resolve_sku",
+    "did_you_mean",
+    "ExecPricingProvider",
+    "PluginError",
+    "PROTOCOL_VERSION",
+    "load_plugins",
+    "plugin_executables",
+    "CloudCapabilities",
+    "RegionInfo",
+    "InstanceTypeInfo",
+    "cloud_capabilities",
+    "list_regions",
+    "list_instance_types",
+    "paginate",
+]
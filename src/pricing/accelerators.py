@@ -0,0 +1,68 @@
+"""
+Accelerator types
+
+GPUs are either built into an instance type (AWS P and G families, Azure
+NC and ND series, GCP A2, A3 and G2 machine types) or attached to a
+machine (GCP N1 machines with guest accelerators):
+
+- accelerator: accelerator type per hour of one attached GPU, on-demand
+  or spot
+
+Accelerator types are named by their GCP accelerator names. The reference
+price of a type (GCP us-central1 list price of one GPU, AWS-derived for
+the A10G) weighs GPUs against vCPUs and memory when a GPU node's price is
+split between them.
+"""
+
+from typing import Dict, List, NamedTuple
+
+
+class AcceleratorType(NamedTuple):
+    """One GPU model"""
+
+    name: str
+    vendor: str
+    model: str
+    memory_gb: float
+    reference_hourly: float
+
+
+ACCELERATOR_TYPES: Dict[str, AcceleratorType] = {
+    accelerator.name: accelerator for accelerator in (
+        AcceleratorType("nvidia-tesla-t4", "nvidia", "T4", 16, 0.35),
+        AcceleratorType("nvidia-tesla-p4", "nvidia", "P4", 8, 0.60),
+        AcceleratorType("nvidia-tesla-p100", "nvidia", "P100", 16, 1.46),
+        AcceleratorType("nvidia-tesla-v100", "nvidia", "V100", 16, 2.48),
+        AcceleratorType("nvidia-l4", "nvidia", "L4", 24, 0.56),
+        AcceleratorType("nvidia-a10g", "nvidia", "A10G", 24, 0.80),
+        AcceleratorType("nvidia-tesla-a100", "nvidia", "A100", 40, 2.93),
+        AcceleratorType("nvidia-a100-80gb", "nvidia", "A100 80GB", 80, 3.93),
+        AcceleratorType("nvidia-h100-80gb", "nvidia", "H100 80GB", 80, 9.80),
+    )
+}
+
+_ACCELERATOR_ALIASES = {
+    "t4": "nvidia-tesla-t4",
+    "p4": "nvidia-tesla-p4",
+    "p100": "nvidia-tesla-p100",
+    "v100": "nvidia-tesla-v100",
+    "l4": "nvidia-l4",
+    "a10g": "nvidia-a10g",
+    "a100": "nvidia-tesla-a100",
+    "a100-80gb": "nvidia-a100-80gb",
+    "h100": "nvidia-h100-80gb",
+}
+
+
+def normalize_accelerator(value: str) -> str:
+    """Normalize an accelerator type name for request validators"""
+    name = value.strip().lower()
+    name = _ACCELERATOR_ALIASES.get(name, name)
+    if name not in ACCELERATOR_TYPES:
+        raise ValueError(f"accelerator must be one of: {', '.join(ACCELERATOR_TYPES)}")
+    return name
+
+
+def accelerator_types() -> List[AcceleratorType]:
+    """Known accelerator types"""
+    return list(ACCELERATOR_TYPES.values())
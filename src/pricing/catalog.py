@@ -0,0 +1,649 @@
+"""
+Static on-demand price catalog
+
+Holds hourly USD prices keyed by provider, region and instance type, plus
+block storage, object storage, managed database, managed Kubernetes,
+attached GPU and serverless rates. A small built-in rate card is used unless a JSON
+catalog file is supplied.
+"""
+
+import json
+import logging
+from typing import Any, Dict, Optional
+
+from .provider import PriceNotFoundError
+
+logger = logging.getLogger(__name__)
+
+
+# Linux on-demand list prices (USD per instance-hour)
+DEFAULT_PRICES: Dict[str, Dict[str, Dict[str, float]]] = {
+    "aws": {
+        "us-east-1": {
+            "t3.micro": 0.0104,
+            "t3.small": 0.0208,
+            "t3.medium": 0.0416,
+            "t3.large": 0.0832,
+            "m5.large": 0.096,
+            "m5.xlarge": 0.192,
+            "m5.2xlarge": 0.384,
+            "c5.large": 0.085,
+            "c5.xlarge": 0.17,
+            "r5.large": 0.126,
+            "g4dn.xlarge": 0.526,
+            "g4dn.2xlarge": 0.752,
+            "g5.xlarge": 1.006,
+            "g6.xlarge": 0.8048,
+            "p3.2xlarge": 3.06,
+            "p4d.24xlarge": 32.7726,
+        },
+        "ap-northeast-2": {
+            "t3.micro": 0.013,
+            "t3.medium": 0.052,
+            "m5.large": 0.118,
+            "m5.xlarge": 0.236,
+            "m5.2xlarge": 0.472,
+            "c5.large": 0.096,
+            "r5.large": 0.152,
+            "g4dn.xlarge": 0.647,
+            "p3.2xlarge": 4.234,
+        },
+    },
+    "gcp": {
+        "us-central1": {
+            "e2-micro": 0.008376,
+            "e2-medium": 0.033503,
+            "e2-standard-2": 0.067006,
+            "e2-standard-4": 0.134012,
+            "n2-standard-2": 0.097118,
+            "n2-standard-4": 0.194236,
+            "n1-standard-4": 0.189999,
+            "n1-standard-8": 0.379998,
+            "g2-standard-4": 0.706832,
+            "a2-highgpu-1g": 3.673385,
+        },
+        "asia-northeast3": {
+            "e2-standard-2": 0.086132,
+            "n2-standard-2": 0.124848,
+        },
+    },
+    "azure": {
+        "eastus": {
+            "Standard_B2s": 0.0416,
+            "Standard_D2s_v3": 0.096,
+            "Standard_D4s_v3": 0.192,
+            "Standard_E2s_v3": 0.126,
+            "Standard_NC4as_T4_v3": 0.526,
+            "Standard_NC6s_v3": 3.06,
+            "Standard_NC24ads_A100_v4": 3.673,
+        },
+        "koreacentral": {
+            "Standard_B2s": 0.048,
+            "Standard_D2s_v3": 0.115,
+            "Standard_D4s_v3": 0.23,
+        },
+    },
+}
+
+
+# Typical spot/preemptible prices (USD per instance-hour); real spot prices
+# vary over time, so live providers should be preferred for spot estimates
+DEFAULT_SPOT_PRICES: Dict[str, Dict[str, Dict[str, float]]] = {
+    "aws": {
+        "us-east-1": {
+            "t3.medium": 0.0156,
+            "t3.large": 0.0312,
+            "m5.large": 0.0384,
+            "m5.xlarge": 0.0768,
+            "m5.2xlarge": 0.1536,
+            "c5.large": 0.0357,
+            "c5.xlarge": 0.0714,
+            "r5.large": 0.0441,
+            "g4dn.xlarge": 0.1578,
+            "g5.xlarge": 0.3018,
+        },
+        "ap-northeast-2": {
+            "m5.large": 0.0354,
+            "m5.xlarge": 0.0708,
+            "c5.large": 0.0298,
+        },
+    },
+    "gcp": {
+        "us-central1": {
+            "e2-standard-2": 0.020102,
+            "e2-standard-4": 0.040204,
+            "n2-standard-2": 0.023504,
+            "n2-standard-4": 0.047008,
+            "n1-standard-4": 0.04,
+        },
+    },
+    "azure": {
+        "eastus": {
+            "Standard_D2s_v3": 0.0168,
+            "Standard_D4s_v3": 0.0336,
+            "Standard_E2s_v3": 0.0221,
+        },
+    },
+}
+
+
+# GPUs attached to GCP N1 machines (USD per GPU-hour) by pricing model;
+# accelerator-optimized machine types are priced with their GPUs above
+DEFAULT_ACCELERATOR_PRICES: Dict[str, Dict[str, Dict[str, Dict[str, float]]]] = {
+    "gcp": {
+        "us-central1": {
+            "on_demand": {
+                "nvidia-tesla-t4": 0.35,
+                "nvidia-tesla-p4": 0.60,
+                "nvidia-tesla-p100": 1.46,
+                "nvidia-tesla-v100": 2.48,
+            },
+            "spot": {
+                "nvidia-tesla-t4": 0.14,
+                "nvidia-tesla-p4": 0.24,
+                "nvidia-tesla-p100": 0.584,
+                "nvidia-tesla-v100": 0.992,
+            },
+        },
+        "asia-northeast3": {
+            "on_demand": {"nvidia-tesla-t4": 0.35},
+            "spot": {"nvidia-tesla-t4": 0.14},
+        },
+    },
+}
+
+
+# Typical effective discount off on-demand per commitment model, term and
+# payment option; the static provider applies them to its on-demand prices
+DEFAULT_COMMITMENT_DISCOUNTS: Dict[str, Dict[str, Dict[str, Dict[str, float]]]] = {
+    "aws": {
+        "reserved": {
+            "1yr": {"no_upfront": 0.375, "partial_upfront": 0.405, "all_upfront": 0.418},
+            "3yr": {"no_upfront": 0.573, "partial_upfront": 0.593, "all_upfront": 0.618},
+        },
+        "savings_plan": {
+            "1yr": {"no_upfront": 0.29, "partial_upfront": 0.32, "all_upfront": 0.33},
+            "3yr": {"no_upfront": 0.51, "partial_upfront": 0.53, "all_upfront": 0.55},
+        },
+    },
+    "gcp": {
+        # Committed use discounts are billed monthly
+        "reserved": {"1yr": {"no_upfront": 0.37}, "3yr": {"no_upfront": 0.55}},
+    },
+    "azure": {
+        # Reservations cost the same paid upfront or monthly
+        "reserved": {
+            "1yr": {"no_upfront": 0.40, "all_upfront": 0.40},
+            "3yr": {"no_upfront": 0.60, "all_upfront": 0.60},
+        },
+    },
+}
+
+
+# Block storage list prices: GB-month for AWS/GCP, per provisioned disk-month for Azure tiers
+DEFAULT_STORAGE_PRICES: Dict[str, Dict[str, Dict[str, Dict[str, Any]]]] = {
+    "aws": {
+        "us-east-1": {
+            "gp3": {"price": 0.08, "unit": "GB-month"},
+            "gp2": {"price": 0.10, "unit": "GB-month"},
+            "io1": {"price": 0.125, "unit": "GB-month"},
+            "st1": {"price": 0.045, "unit": "GB-month"},
+            "sc1": {"price": 0.015, "unit": "GB-month"},
+        },
+        "ap-northeast-2": {
+            "gp3": {"price": 0.0912, "unit": "GB-month"},
+            "gp2": {"price": 0.114, "unit": "GB-month"},
+            "io1": {"price": 0.1296, "unit": "GB-month"},
+        },
+    },
+    "gcp": {
+        "us-central1": {
+            "pd-standard": {"price": 0.04, "unit": "GB-month"},
+            "pd-balanced": {"price": 0.10, "unit": "GB-month"},
+            "pd-ssd": {"price": 0.17, "unit": "GB-month"},
+        },
+        "asia-northeast3": {
+            "pd-standard": {"price": 0.052, "unit": "GB-month"},
+            "pd-balanced": {"price": 0.13, "unit": "GB-month"},
+            "pd-ssd": {"price": 0.221, "unit": "GB-month"},
+        },
+    },
+    "azure": {
+        "eastus": {
+            "E4 LRS": {"price": 2.40, "unit": "month"},
+            "E10 LRS": {"price": 9.60, "unit": "month"},
+            "E15 LRS": {"price": 19.20, "unit": "month"},
+            "E20 LRS": {"price": 38.40, "unit": "month"},
+            "E30 LRS": {"price": 76.80, "unit": "month"},
+            "P4 LRS": {"price": 5.28, "unit": "month"},
+            "P10 LRS": {"price": 19.71, "unit": "month"},
+            "P15 LRS": {"price": 38.02, "unit": "month"},
+            "P20 LRS": {"price": 73.22, "unit": "month"},
+            "P30 LRS": {"price": 135.17, "unit": "month"},
+        },
+        "koreacentral": {
+            "E10 LRS": {"price": 11.52, "unit": "month"},
+            "E20 LRS": {"price": 46.08, "unit": "month"},
+            "P10 LRS": {"price": 22.67, "unit": "month"},
+            "P20 LRS": {"price": 84.20, "unit": "month"},
+        },
+    },
+}
+
+
+# Managed database list prices of single-AZ deployments: instance classes in
+# USD per hour by engine, storage and backup per GB-month, provisioned IOPS
+# per IOPS-month, by storage type
+DEFAULT_DATABASE_PRICES: Dict[str, Dict[str, Dict[str, Any]]] = {
+    "aws": {
+        "us-east-1": {
+            "instances": {
+                "db.t3.micro": {"mysql": 0.017, "postgres": 0.018, "mariadb": 0.017},
+                "db.t3.medium": {"mysql": 0.068, "postgres": 0.072, "mariadb": 0.068},
+                "db.m5.large": {"mysql": 0.171, "postgres": 0.178, "mariadb": 0.171},
+                "db.m5.xlarge": {"mysql": 0.342, "postgres": 0.356, "mariadb": 0.342},
+                "db.m6g.large": {"mysql": 0.152, "postgres": 0.159, "mariadb": 0.152},
+                "db.r5.large": {"mysql": 0.24, "postgres": 0.25, "mariadb": 0.24},
+            },
+            "storage": {"gp2": 0.115, "gp3": 0.115, "io1": 0.125},
+            "iops": {"gp3": 0.02, "io1": 0.10},
+            "backup": 0.095,
+        },
+        "ap-northeast-2": {
+            "instances": {
+                "db.t3.medium": {"mysql": 0.084, "postgres": 0.088, "mariadb": 0.084},
+                "db.m5.large": {"mysql": 0.214, "postgres": 0.223, "mariadb": 0.214},
+                "db.r5.large": {"mysql": 0.29, "postgres": 0.3, "mariadb": 0.29},
+            },
+            "storage": {"gp2": 0.131, "gp3": 0.131, "io1": 0.15},
+            "iops": {"gp3": 0.024, "io1": 0.117},
+            "backup": 0.095,
+        },
+    },
+    "gcp": {
+        # Cloud SQL Enterprise edition, MySQL and PostgreSQL cost the same
+        "us-central1": {
+            "instances": {
+                "db-f1-micro": {"mysql": 0.0105, "postgres": 0.0105},
+                "db-g1-small": {"mysql": 0.035, "postgres": 0.035},
+                "db-custom-2-7680": {"mysql": 0.1351, "postgres": 0.1351},
+                "db-custom-4-15360": {"mysql": 0.2702, "postgres": 0.2702},
+                "db-custom-8-30720": {"mysql": 0.5404, "postgres": 0.5404},
+            },
+            "storage": {"ssd": 0.17, "hdd": 0.09},
+            "iops": {},
+            "backup": 0.08,
+        },
+        "asia-northeast3": {
+            "instances": {
+                "db-custom-2-7680": {"mysql": 0.1743, "postgres": 0.1743},
+                "db-custom-4-15360": {"mysql": 0.3486, "postgres": 0.3486},
+            },
+            "storage": {"ssd": 0.221, "hdd": 0.117},
+            "iops": {},
+            "backup": 0.104,
+        },
+    },
+    "azure": {
+        # Azure Database for MySQL / PostgreSQL flexible server
+        "eastus": {
+            "instances": {
+                "Standard_B1ms": {"mysql": 0.0207, "postgres": 0.0253},
+                "Standard_B2s": {"mysql": 0.0828, "postgres": 0.101},
+                "Standard_D2ds_v4": {"mysql": 0.137, "postgres": 0.178},
+                "Standard_D4ds_v4": {"mysql": 0.274, "postgres": 0.356},
+                "Standard_E2ds_v4": {"mysql": 0.178, "postgres": 0.254},
+            },
+            "storage": {"premium_ssd": 0.115},
+            "iops": {"premium_ssd": 0.05},
+            "backup": 0.095,
+        },
+        "koreacentral": {
+            "instances": {
+                "Standard_B1ms": {"mysql": 0.0238, "postgres": 0.0291},
+                "Standard_D2ds_v4": {"mysql": 0.158, "postgres": 0.205},
+                "Standard_D4ds_v4": {"mysql": 0.315, "postgres": 0.409},
+            },
+            "storage": {"premium_ssd": 0.132},
+            "iops": {"premium_ssd": 0.058},
+            "backup": 0.11,
+        },
+    },
+}
+
+
+# Object storage list prices by storage class SKU: storage tiers as
+# [[monthly GB the tier ends at (None for no end), USD/GB-month], ...],
+# write, read and lifecycle transition requests per 1000, retrieval per GB
+DEFAULT_OBJECT_STORAGE_PRICES: Dict[str, Dict[str, Dict[str, Dict[str, Any]]]] = {
+    "aws": {
+        "us-east-1": {
+            "Standard": {"storage": [[51200, 0.023], [512000, 0.022], [None, 0.021]],
+                         "write": 0.005, "read": 0.0004, "transition": 0.0, "retrieval": 0.0},
+            "Standard - Infrequent Access": {"storage": [[None, 0.0125]],
+                                             "write": 0.01, "read": 0.001, "transition": 0.01, "retrieval": 0.01},
+            "Amazon Glacier": {"storage": [[None, 0.0036]],
+                               "write": 0.03, "read": 0.0004, "transition": 0.03, "retrieval": 0.01},
+        },
+        "ap-northeast-2": {
+            "Standard": {"storage": [[51200, 0.025], [512000, 0.024], [None, 0.023]],
+                         "write": 0.0045, "read": 0.00035, "transition": 0.0, "retrieval": 0.0},
+            "Standard - Infrequent Access": {"storage": [[None, 0.0138]],
+                                             "write": 0.01, "read": 0.001, "transition": 0.01, "retrieval": 0.01},
+            "Amazon Glacier": {"storage": [[None, 0.0045]],
+                               "write": 0.0355, "read": 0.00035, "transition": 0.0355, "retrieval": 0.0114},
+        },
+    },
+    "gcp": {
+        # Lifecycle SetStorageClass is billed as a class A operation of the new class
+        "us-central1": {
+            "STANDARD": {"storage": [[None, 0.02]],
+                         "write": 0.005, "read": 0.0004, "transition": 0.005, "retrieval": 0.0},
+            "NEARLINE": {"storage": [[None, 0.01]],
+                         "write": 0.01, "read": 0.001, "transition": 0.01, "retrieval": 0.01},
+            "ARCHIVE": {"storage": [[None, 0.0012]],
+                        "write": 0.05, "read": 0.05, "transition": 0.05, "retrieval": 0.05},
+        },
+        "asia-northeast3": {
+            "STANDARD": {"storage": [[None, 0.023]],
+                         "write": 0.005, "read": 0.0004, "transition": 0.005, "retrieval": 0.0},
+            "NEARLINE": {"storage": [[None, 0.016]],
+                         "write": 0.01, "read": 0.001, "transition": 0.01, "retrieval": 0.01},
+            "ARCHIVE": {"storage": [[None, 0.0025]],
+                        "write": 0.05, "read": 0.05, "transition": 0.05, "retrieval": 0.05},
+        },
+    },
+    "azure": {
+        # Setting a blob's tier is billed as a write operation of the new tier
+        "eastus": {
+            "Hot LRS": {"storage": [[51200, 0.0184], [512000, 0.0177], [None, 0.017]],
+                        "write": 0.0065, "read": 0.0005, "transition": 0.0065, "retrieval": 0.0},
+            "Cool LRS": {"storage": [[None, 0.01]],
+                         "write": 0.01, "read": 0.001, "transition": 0.01, "retrieval": 0.01},
+            "Archive LRS": {"storage": [[None, 0.00099]], "write": 0.013, "read": 0.5, "transition": 0.013,
+                            "retrieval": 0.02},
+        },
+        "koreacentral": {
+            "Hot LRS": {"storage": [[51200, 0.0213], [512000, 0.0205], [None, 0.0197]],
+                        "write": 0.0065, "read": 0.0005, "transition": 0.0065, "retrieval": 0.0},
+            "Cool LRS": {"storage": [[None, 0.0116]], "write": 0.013, "read": 0.0013, "transition": 0.013,
+                         "retrieval": 0.01},
+            "Archive LRS": {"storage": [[None, 0.00203]], "write": 0.0143, "read": 0.55, "transition": 0.0143,
+                            "retrieval": 0.022},
+        },
+    },
+}
+
+# Managed Kubernetes list prices: control plane per cluster-hour by tier,
+# and GKE Autopilot pod requests per vCPU-hour and GB-hour by pricing model
+DEFAULT_KUBERNETES_PRICES: Dict[str, Dict[str, Dict[str, Any]]] = {
+    "aws": {
+        "us-east-1": {"control_plane": {"standard": 0.10, "extended": 0.60}},
+        "ap-northeast-2": {"control_plane": {"standard": 0.10, "extended": 0.60}},
+    },
+    "gcp": {
+        "us-central1": {
+            "control_plane": {"standard": 0.10, "autopilot": 0.10},
+            "pods": {
+                "on_demand": {"vcpu": 0.0445, "memory": 0.0049225},
+                "spot": {"vcpu": 0.0133, "memory": 0.0014767},
+            },
+        },
+        "asia-northeast3": {
+            "control_plane": {"standard": 0.10, "autopilot": 0.10},
+            "pods": {
+                "on_demand": {"vcpu": 0.0571, "memory": 0.0063175},
+                "spot": {"vcpu": 0.0171, "memory": 0.0018953},
+            },
+        },
+    },
+    "azure": {
+        "eastus": {"control_plane": {"free": 0.0, "standard": 0.10, "premium": 0.60}},
+        "koreacentral": {"control_plane": {"free": 0.0, "standard": 0.10, "premium": 0.60}},
+    },
+}
+
+# Serverless list prices by platform: USD per million invocations ("requests"),
+# GB-second ("memory", "memory_arm64" for Graviton) and vCPU-second ("cpu").
+# Cloud Run functions are billed at the Cloud Run rates of their region's tier.
+_CLOUD_RUN_TIER_1 = {"requests": 0.40, "memory": 0.0000025, "cpu": 0.000024}
+_CLOUD_RUN_TIER_2 = {"requests": 0.40, "memory": 0.0000035, "cpu": 0.0000336}
+_LAMBDA = {"requests": 0.20, "memory": 0.0000166667, "memory_arm64": 0.0000133334}
+_AZURE_FUNCTIONS = {"requests": 0.20, "memory": 0.000016}
+
+DEFAULT_SERVERLESS_PRICES: Dict[str, Dict[str, Dict[str, Dict[str, float]]]] = {
+    "aws": {
+        "us-east-1": {"lambda": _LAMBDA},
+        "ap-northeast-2": {"lambda": _LAMBDA},
+    },
+    "gcp": {
+        "us-central1": {"cloud_run": _CLOUD_RUN_TIER_1, "cloud_functions": _CLOUD_RUN_TIER_1},
+        "asia-northeast3": {"cloud_run": _CLOUD_RUN_TIER_2, "cloud_functions": _CLOUD_RUN_TIER_2},
+    },
+    "azure": {
+        "eastus": {"azure_functions": _AZURE_FUNCTIONS},
+        "koreacentral": {"azure_functions": _AZURE_FUNCTIONS},
+    },
+}
+
+
+class PriceCatalog:
+    """In-memory hourly price lookup table"""
+
+    def __init__(
+        self,
+        prices: Optional[Dict[str, Dict[str, Dict[str, float]]]] = None,
+        storage_prices: Optional[Dict[str, Dict[str, Dict[str, Dict[str, Any]]]]] = None,
+        spot_prices: Optional[Dict[str, Dict[str, Dict[str, float]]]] = None,
+        commitment_discounts: Optional[Dict[str, Dict[str, Dict[str, Dict[str, float]]]]] = None,
+        database_prices: Optional[Dict[str, Dict[str, Dict[str, Any]]]] = None,
+        object_storage_prices: Optional[Dict[str, Dict[str, Dict[str, Dict[str, Any]]]]] = None,
+        kubernetes_prices: Optional[Dict[str, Dict[str, Dict[str, Any]]]] = None,
+        accelerator_prices: Optional[Dict[str, Dict[str, Dict[str, Dict[str, float]]]]] = None,
+        serverless_prices: Optional[Dict[str, Dict[str, Dict[str, Dict[str, float]]]]] = None,
+    ):
+        """
+        Initialize price catalog
+
+        Args:
+            prices: Nested mapping provider -> region -> instance_type -> USD/hour.
+                    Uses the built-in rate card if not provided.
+            storage_prices: Nested mapping provider -> region -> volume_type -> {"price", "unit"}.
+                    Uses the built-in block storage rates if not provided.
+            spot_prices: Same nesting as prices, for spot capacity.
+                    Uses the built-in spot rates if not provided.
+            commitment_discounts: Nested mapping provider -> model -> term -> payment option ->
+                    discount off on-demand. Uses the built-in rates if not provided.
+            database_prices: Nested mapping provider -> region -> {"instances", "storage", "iops", "backup"}
+                    as DEFAULT_DATABASE_PRICES. Uses the built-in database rates if not provided.
+            object_storage_prices: Nested mapping provider -> region -> storage class SKU ->
+                    {"storage", "write", "read", "transition", "retrieval"} as DEFAULT_OBJECT_STORAGE_PRICES.
+                    Uses the built-in object storage rates if not provided.
+            kubernetes_prices: Nested mapping provider -> region -> {"control_plane", "pods"} as
+                    DEFAULT_KUBERNETES_PRICES. Uses the built-in managed Kubernetes rates if not provided.
+            accelerator_prices: Nested mapping provider -> region -> pricing model -> accelerator type ->
+                    USD/GPU-hour. Uses the built-in attached GPU rates if not provided.
+            serverless_prices: Nested mapping provider -> region -> platform -> component -> USD
+                    as DEFAULT_SERVERLESS_PRICES. Uses the built-in serverless rates if not provided.
+        """
+        self.prices = prices if prices is not None else DEFAULT_PRICES
+        self.storage_prices = storage_prices if storage_prices is not None else DEFAULT_STORAGE_PRICES
+        self.spot_prices = spot_prices if spot_prices is not None else DEFAULT_SPOT_PRICES
+        self.commitment_discounts = (
+            commitment_discounts if commitment_discounts is not None else DEFAULT_COMMITMENT_DISCOUNTS
+        )
+        self.database_prices = database_prices if database_prices is not None else DEFAULT_DATABASE_PRICES
+        self.object_storage_prices = (
+            object_storage_prices if object_storage_prices is not None else DEFAULT_OBJECT_STORAGE_PRICES
+        )
+        self.kubernetes_prices = kubernetes_prices if kubernetes_prices is not None else DEFAULT_KUBERNETES_PRICES
+        self.accelerator_prices = accelerator_prices if accelerator_prices is not None else DEFAULT_ACCELERATOR_PRICES
+        self.serverless_prices = serverless_prices if serverless_prices is not None else DEFAULT_SERVERLESS_PRICES
+
+    @classmethod
+    def from_file(cls, path: str) -> "PriceCatalog":
+        """Load a catalog from a JSON file with the same nesting as DEFAULT_PRICES"""
+        with open(path, "r", encoding="utf-8") as f:
+            prices = json.load(f)
+
+        if not isinstance(prices, dict):
+            raise ValueError(f"Price catalog {path} must be a JSON object")
+
+        logger.info(f"Price catalog loaded from {path}")
+        return cls(prices)
+
+    def get_hourly_price(self, provider: str, region: str, instance_type: str) -> float:
+        """
+        Look up the hourly price of one instance
+
+        Raises:
+            PriceNotFoundError: If the provider, region or instance type is unknown
+        """
+        try:
+            return float(self.prices[provider][region][instance_type])
+        except KeyError:
+            raise PriceNotFoundError(
+                f"No price for {provider}/{region}/{instance_type}"
+            ) from None
+
+    def get_storage_price(self, provider: str, region: str, volume_type: str) -> Dict[str, Any]:
+        """
+        Look up a block storage price as {"price", "unit"}
+
+        Raises:
+            PriceNotFoundError: If the provider, region or volume type is unknown
+        """
+        try:
+            return self.storage_prices[provider][region][volume_type]
+        except KeyError:
+            raise PriceNotFoundError(
+                f"No storage price for {provider}/{region}/{volume_type}"
+            ) from None
+
+    def get_spot_price(self, provider: str, region: str, instance_type: str) -> float:
+        """
+        Look up the hourly spot price of one instance
+
+        Raises:
+            PriceNotFoundError: If no spot price is listed for the instance type
+        """
+        try:
+            return float(self.spot_prices[provider][region][instance_type])
+        except KeyError:
+            raise PriceNotFoundError(
+                f"No spot price for {provider}/{region}/{instance_type}"
+            ) from None
+
+    def get_commitment_discount(self, provider: str, model: str, term: str, payment_option: str) -> float:
+        """
+        Look up the discount off on-demand of a commitment option
+
+        Raises:
+            PriceNotFoundError: If the provider does not offer the option
+        """
+        try:
+            return float(self.commitment_discounts[provider][model][term][payment_option])
+        except KeyError:
+            raise PriceNotFoundError(
+                f"No {model} {term} {payment_option} rate for {provider}"
+            ) from None
+
+    def get_database_price(self, provider: str, region: str, component: str, sku: str, engine: str = "") -> float:
+        """
+        Look up a single-AZ managed database price
+
+        Args:
+            component: instances, storage, iops or backup
+            sku: Instance class or storage type (ignored for backup)
+            engine: Database engine of instance prices
+
+        Raises:
+            PriceNotFoundError: If the provider, region, class, engine or storage type is unknown
+        """
+        try:
+            rates = self.database_prices[provider][region][component]
+            if component == "backup":
+                return float(rates)
+            if component == "instances":
+                return float(rates[sku][engine])
+            return float(rates[sku])
+        except KeyError:
+            detail = f"{sku} {engine}".strip() if component == "instances" else sku
+            raise PriceNotFoundError(
+                f"No database {component} price for {provider}/{region}/{detail}"
+            ) from None
+
+    def get_object_storage_rate(self, provider: str, region: str, sku: str, component: str) -> Any:
+        """
+        Look up an object storage rate: storage tiers, or the price of write,
+        read and transition requests (per 1000) or retrieval (per GB)
+
+        Raises:
+            PriceNotFoundError: If the provider, region, storage class or component is unknown
+        """
+        try:
+            return self.object_storage_prices[provider][region][sku][component]
+        except KeyError:
+            raise PriceNotFoundError(
+                f"No object storage {component} price for {provider}/{region}/{sku}"
+            ) from None
+
+    def get_control_plane_price(self, provider: str, region: str, tier: str) -> float:
+        """
+        Look up the hourly control plane fee of a managed cluster tier
+
+        Raises:
+            PriceNotFoundError: If the provider, region or tier is unknown
+        """
+        try:
+            return float(self.kubernetes_prices[provider][region]["control_plane"][tier])
+        except KeyError:
+            raise PriceNotFoundError(
+                f"No {tier} control plane price for {provider}/{region}"
+            ) from None
+
+    def get_pod_price(self, provider: str, region: str, sku: str, pricing_model: str) -> float:
+        """
+        Look up the hourly price of one vCPU or GB of pod requests on a per-pod billed cluster
+
+        Raises:
+            PriceNotFoundError: If the provider or region bills no pods, or the SKU is unknown
+        """
+        try:
+            return float(self.kubernetes_prices[provider][region]["pods"][pricing_model][sku])
+        except KeyError:
+            raise PriceNotFoundError(
+                f"No {pricing_model} pod {sku} price for {provider}/{region}"
+            ) from None
+
+    def get_accelerator_price(self, provider: str, region: str, accelerator: str, pricing_model: str) -> float:
+        """
+        Look up the hourly price of one attached GPU
+
+        Raises:
+            PriceNotFoundError: If the provider or region does not attach the accelerator type
+        """
+        try:
+            return float(self.accelerator_prices[provider][region][pricing_model][accelerator])
+        except KeyError:
+            raise PriceNotFoundError(
+                f"No {pricing_model} {accelerator} price for {provider}/{region}"
+            ) from None
+
+    def get_serverless_price(self, provider: str, region: str, platform: str, component: str, arch: str = "") -> float:
+        """
+        Look up a serverless rate: per million invocations, GB-second or vCPU-second
+
+        The architecture's own rate is used where one is listed (Lambda on arm64).
+
+        Raises:
+            PriceNotFoundError: If the provider, region or platform is unknown, or the platform
+                does not bill the component
+        """
+        try:
+            rates = self.serverless_prices[provider][region][platform]
+            return float(rates.get(f"{component}_{arch}", rates[component]) if arch else rates[component])
+        except KeyError:
+            raise PriceNotFoundError(
+                f"No serverless {component} price for {provider}/{region}/{platform}"
+            ) from None
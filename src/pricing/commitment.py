@@ -0,0 +1,59 @@
+"""
+Commitment-based purchase options
+
+Reserved instances (and GCP committed use / Azure reservations, modeled as
+reserved) and AWS Savings Plans trade a 1 or 3 year commitment for a lower
+rate. A commitment price is a recurring hourly rate plus an upfront fee,
+both per instance; which share is paid upfront depends on the payment option.
+"""
+
+from typing import Tuple
+
+from ..units import hours_per_year
+
+PRICING_RESERVED = "reserved"
+PRICING_SAVINGS_PLAN = "savings_plan"
+COMMITMENT_MODELS = (PRICING_RESERVED, PRICING_SAVINGS_PLAN)
+
+TERM_1YR = "1yr"
+TERM_3YR = "3yr"
+TERMS = {TERM_1YR: 1, TERM_3YR: 3}
+
+NO_UPFRONT = "no_upfront"
+PARTIAL_UPFRONT = "partial_upfront"
+ALL_UPFRONT = "all_upfront"
+PAYMENT_OPTIONS = (NO_UPFRONT, PARTIAL_UPFRONT, ALL_UPFRONT)
+
+# PriceQuery.attributes keys of a commitment lookup
+ATTR_TERM = "term"
+ATTR_PAYMENT_OPTION = "payment_option"
+
+
+def term_hours(term: str) -> float:
+    """Billable hours of a commitment term, years of the time basis"""
+    try:
+        return TERMS[term] * hours_per_year()
+    except KeyError:
+        raise ValueError(f"term must be one of: {', '.join(TERMS)}") from None
+
+
+def commitment_qualifier(model: str, term: str, payment_option: str) -> str:
+    """Entry key qualifier of a commitment price, e.g. reserved/1yr/no_upfront"""
+    return f"{model}/{term}/{payment_option}"
+
+
+def split_upfront(effective_hourly: float, term: str, payment_option: str) -> Tuple[float, float]:
+    """
+    Split an effective hourly rate into (recurring hourly, upfront fee)
+
+    Partial upfront pays half the commitment at purchase, matching the
+    usual AWS split.
+    """
+    hours = term_hours(term)
+    if payment_option == ALL_UPFRONT:
+        return 0.0, effective_hourly * hours
+    if payment_option == PARTIAL_UPFRONT:
+        return effective_hourly / 2, effective_hourly * hours / 2
+    if payment_option == NO_UPFRONT:
+        return effective_hourly, 0.0
+    raise ValueError(f"payment_option must be one of: {', '.join(PAYMENT_OPTIONS)}")
@@ -0,0 +1,59 @@
+"""
+Managed relational database pricing
+
+A managed database (RDS, Cloud SQL, Azure Database) is billed as separate
+components: instance hours of its class, provisioned storage, provisioned
+IOPS beyond what the storage type includes, and backup storage beyond the
+free allowance. Each component is looked up as its own service:
+
+- database: instance class per hour, qualified by engine and deployment
+- database_storage: storage type per GB-month, qualified by deployment
+- database_iops: storage type per provisioned IOPS-month, qualified by deployment
+- database_backup: backup storage per GB-month
+
+Engines and deployments use the provider-neutral names below; providers
+translate them to their own vocabulary. A high availability deployment
+(multi-AZ standby, regional Cloud SQL, zone-redundant HA) bills a standby
+copy of the instance and its storage.
+"""
+
+ENGINE_MYSQL = "mysql"
+ENGINE_POSTGRES = "postgres"
+ENGINE_MARIADB = "mariadb"
+ENGINE_SQLSERVER = "sqlserver"
+ENGINES = (ENGINE_MYSQL, ENGINE_POSTGRES, ENGINE_MARIADB, ENGINE_SQLSERVER)
+
+DEPLOYMENT_SINGLE_AZ = "single_az"
+DEPLOYMENT_MULTI_AZ = "multi_az"
+DEPLOYMENTS = (DEPLOYMENT_SINGLE_AZ, DEPLOYMENT_MULTI_AZ)
+
+# PriceQuery.attributes keys of a database lookup
+ATTR_ENGINE = "engine"
+ATTR_DEPLOYMENT = "deployment"
+
+# SKU of backup storage prices
+BACKUP_SKU = "backup"
+
+# Cost of a high availability instance, storage or IOPS relative to single-AZ
+HIGH_AVAILABILITY_MULTIPLIER = 2.0
+
+_ENGINE_ALIASES = {
+    "postgresql": ENGINE_POSTGRES,
+    "sql-server": ENGINE_SQLSERVER,
+    "sql_server": ENGINE_SQLSERVER,
+    "mssql": ENGINE_SQLSERVER,
+}
+
+
+def normalize_engine(value: str) -> str:
+    """Normalize a database engine name for request validators"""
+    engine = value.strip().lower()
+    engine = _ENGINE_ALIASES.get(engine, engine)
+    if engine not in ENGINES:
+        raise ValueError(f"engine must be one of: {', '.join(ENGINES)}")
+    return engine
+
+
+def deployment_of(high_availability: bool) -> str:
+    """Deployment of a database with or without a standby"""
+    return DEPLOYMENT_MULTI_AZ if high_availability else DEPLOYMENT_SINGLE_AZ
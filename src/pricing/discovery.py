@@ -0,0 +1,184 @@
+"""
+SKU discovery
+
+Lists what the enabled pricing providers can price, so clients can offer
+a cloud's regions and instance types instead of hard-coding them: each
+cloud with its providers and capabilities, its regions, and its instance
+types with their shapes. Only providers holding a catalog can list it;
+a cloud priced by quoting each lookup (Alibaba) is reported as not
+listed, and its prices are still found by estimates.
+"""
+
+import re
+from typing import Dict, List, Optional, Set, Tuple, TypeVar
+
+from pydantic import BaseModel, Field
+
+from .instance_types import get_instance_shape
+from .provider import ANY_REGION
+from .registry import ProviderRegistry
+from .serverless import SERVERLESS_PLATFORMS
+
+T = TypeVar("T")
+
+_FAMILY = re.compile(r"^[^.\-_]+")
+
+
+class CloudCapabilities(BaseModel):
+    """What can be priced for one cloud"""
+
+    provider: str = Field(..., description="Cloud as named in estimate requests, e.g. aws")
+    pricing_providers: List[str] = Field(..., description="Pricing providers consulted, in lookup order")
+    listed: bool = Field(..., description="Whether the providers can list the cloud's regions and instance types")
+    regions: int
+    instance_types: int
+    serverless_platforms: List[str] = Field(default_factory=list)
+
+
+class RegionInfo(BaseModel):
+    """A region with priced instance types"""
+
+    region: str = Field(..., description="Region code, * for rate cards pricing every region")
+    instance_types: int
+
+
+class InstanceTypeInfo(BaseModel):
+    """A priced instance type and its shape"""
+
+    instance_type: str
+    vcpus: Optional[float] = Field(None, description="None if the shape is not known")
+    memory_gb: Optional[float] = None
+    gpus: int = 0
+    accelerator: Optional[str] = None
+    arch: Optional[str] = None
+    regions: List[str] = Field(..., description="Regions the instance type is priced in")
+
+
+def cloud_capabilities(registry: ProviderRegistry) -> List[CloudCapabilities]:
+    """Every cloud with an enabled provider"""
+    clouds = []
+    for cloud in registry.clouds():
+        regions = registry.instance_types(cloud)
+        clouds.append(CloudCapabilities(
+            provider=cloud,
+            pricing_providers=[provider.name for provider in registry.providers_of(cloud)],
+            listed=bool(regions),
+            regions=len(regions),
+            instance_types=len(set().union(*regions.values())) if regions else 0,
+            serverless_platforms=list(SERVERLESS_PLATFORMS.get(cloud, ())),
+        ))
+    return clouds
+
+
+def list_regions(registry: ProviderRegistry, cloud: str, search: Optional[str] = None) -> List[RegionInfo]:
+    """
+    Regions of a cloud in name order, optionally those containing a search text
+
+    Raises:
+        ProviderNotFoundError: If no provider is enabled for the cloud
+    """
+    needle = (search or "").lower()
+    return [
+        RegionInfo(region=region, instance_types=len(instance_types))
+        for region, instance_types in sorted(registry.instance_types(cloud).items())
+        if needle in region.lower()
+    ]
+
+
+def list_instance_types(
+    registry: ProviderRegistry,
+    cloud: str,
+    region: Optional[str] = None,
+    search: Optional[str] = None,
+    min_vcpus: Optional[float] = None,
+    max_vcpus: Optional[float] = None,
+    min_memory_gb: Optional[float] = None,
+    max_memory_gb: Optional[float] = None,
+    arch: Optional[str] = None,
+    gpu: Optional[bool] = None,
+) -> List[InstanceTypeInfo]:
+    """
+    Instance types of a cloud by family and size, filtered
+
+    Args:
+        registry: Registry of enabled pricing providers
+        cloud: Cloud as named in estimate requests
+        region: Only instance types priced in this region
+        search: Only instance types containing this text
+        min_vcpus, max_vcpus, min_memory_gb, max_memory_gb: Shape bounds;
+            instance types of unknown shape are left out when set
+        arch: Only this architecture, x86_64 or arm64
+        gpu: Only instance types with (True) or without (False) GPUs
+
+    Raises:
+        ProviderNotFoundError: If no provider is enabled for the cloud
+    """
+    regions_of: Dict[str, Set[str]] = {}
+    for priced_region, instance_types in registry.instance_types(cloud).items():
+        if region is not None and priced_region not in (region, ANY_REGION):
+            continue
+        for instance_type in instance_types:
+            regions_of.setdefault(instance_type, set()).add(priced_region)
+
+    needle = (search or "").lower()
+    bounded = any(bound is not None for bound in (min_vcpus, max_vcpus, min_memory_gb, max_memory_gb, arch, gpu))
+    infos = []
+    for instance_type, priced_regions in regions_of.items():
+        if needle not in instance_type.lower():
+            continue
+        info = _instance_type_info(cloud, instance_type, sorted(priced_regions))
+        if bounded and not _within(info, min_vcpus, max_vcpus, min_memory_gb, max_memory_gb, arch, gpu):
+            continue
+        infos.append(info)
+    return sorted(infos, key=_size_order)
+
+
+def paginate(items: List[T], limit: int, offset: int = 0) -> Tuple[List[T], Optional[int]]:
+    """A page of items and the offset of the next page, None after the last"""
+    page = items[offset:offset + limit]
+    next_offset = offset + limit if offset + limit < len(items) else None
+    return page, next_offset
+
+
+def _instance_type_info(cloud: str, instance_type: str, regions: List[str]) -> InstanceTypeInfo:
+    try:
+        shape = get_instance_shape(cloud, instance_type)
+    except KeyError:
+        return InstanceTypeInfo(instance_type=instance_type, regions=regions)
+    return InstanceTypeInfo(
+        instance_type=instance_type,
+        vcpus=shape.vcpus,
+        memory_gb=shape.memory_gb,
+        gpus=shape.gpus,
+        accelerator=shape.accelerator or None,
+        arch=shape.arch,
+        regions=regions,
+    )
+
+
+def _within(
+    info: InstanceTypeInfo,
+    min_vcpus: Optional[float],
+    max_vcpus: Optional[float],
+    min_memory_gb: Optional[float],
+    max_memory_gb: Optional[float],
+    arch: Optional[str],
+    gpu: Optional[bool],
+) -> bool:
+    if info.vcpus is None:
+        return False
+    if (min_vcpus is not None and info.vcpus < min_vcpus) or (max_vcpus is not None and info.vcpus > max_vcpus):
+        return False
+    if (min_memory_gb is not None and info.memory_gb < min_memory_gb) or (
+        max_memory_gb is not None and info.memory_gb > max_memory_gb
+    ):
+        return False
+    if arch is not None and info.arch != arch.strip().lower():
+        return False
+    return gpu is None or (info.gpus > 0) == gpu
+
+
+def _size_order(info: InstanceTypeInfo) -> tuple:
+    """Family, then vCPUs and memory, then name: m5.large before m5.xlarge before m5.2xlarge"""
+    family = _FAMILY.match(info.instance_type)
+    return (family.group(0) if family else "", info.vcpus or 0.0, info.memory_gb or 0.0, info.instance_type)
@@ -0,0 +1,37 @@
+"""
+Catalog refresh failure reporting
+
+Providers report price catalogs they could not download. Listeners, such
+as webhook notifications, are called with the provider name, the catalog
+key and the error; a failing listener never fails the refresh.
+"""
+
+import logging
+from typing import Callable, List
+
+logger = logging.getLogger(__name__)
+
+# Called with (provider, catalog key, error message)
+RefreshFailureListener = Callable[[str, str, str], None]
+
+_listeners: List[RefreshFailureListener] = []
+
+
+def add_refresh_failure_listener(listener: RefreshFailureListener) -> None:
+    """Call a listener for every catalog refresh failure"""
+    _listeners.append(listener)
+
+
+def remove_refresh_failure_listener(listener: RefreshFailureListener) -> None:
+    """Stop calling a listener"""
+    if listener in _listeners:
+        _listeners.remove(listener)
+
+
+def report_refresh_failure(provider: str, catalog: str, error: Exception) -> None:
+    """Report a catalog that could not be refreshed"""
+    for listener in list(_listeners):
+        try:
+            listener(provider, catalog, str(error))
+        except Exception as e:
+            logger.error(f"Refresh failure listener failed: {e}")
@@ -0,0 +1,157 @@
+"""
+Pricing formulas
+
+Usage-based services are rarely a flat rate per unit. A price carries
+its formula as optional fields, applied to a month's quantity in order:
+
+1. free allowance: the first free_quantity units cost nothing
+2. per unit with minimum: the rest is billed as at least minimum_quantity
+3. tiers: graduated tiers bill each band at its own price (first 10 TB
+   at $0.09/GB, next 40 TB at $0.085); volume tiers bill every unit at
+   the price of the band the quantity ends in. Without tiers, price
+   applies to every unit.
+4. minimum charge: a billed month costs at least minimum_charge
+
+Tiers count the billed quantity, after the free allowance. Providers
+fill the fields from their catalogs and tenants set them in price
+sheets, e.g. tiers "10240:0.09;51200:0.085;:0.07".
+"""
+
+from decimal import Decimal
+from typing import Any, List, Optional, Tuple
+
+from ..money import add_amounts, to_decimal
+from .models import Price, PriceTier, TIER_MODE_VOLUME, TIER_MODES
+
+
+def billed_quantity(price: Price, quantity: float) -> float:
+    """Quantity a price bills: beyond the free allowance, raised to the minimum quantity"""
+    billed = max(quantity - price.free_quantity, 0.0)
+    if billed > 0:
+        billed = max(billed, price.minimum_quantity)
+    return billed
+
+
+def usage_amount(price: Price, quantity: float) -> Decimal:
+    """Monthly cost of a quantity under a price's formula, in decimal"""
+    billed = billed_quantity(price, quantity)
+    if billed <= 0:
+        return Decimal(0)
+    if not price.tiers:
+        cost = to_decimal(price.price) * to_decimal(billed)
+    elif price.tier_mode == TIER_MODE_VOLUME:
+        cost = to_decimal(_volume_tier(price.tiers, billed).price) * to_decimal(billed)
+    else:
+        cost = add_amounts(to_decimal(used) * to_decimal(tier.price) for tier, used in tier_usage(price, billed))
+    return max(cost, to_decimal(price.minimum_charge))
+
+
+def usage_cost(price: Price, quantity: float) -> float:
+    """Monthly cost of a quantity under a price's formula"""
+    return float(usage_amount(price, quantity))
+
+
+def effective_unit_price(price: Price, quantity: float) -> float:
+    """Average price per unit of a quantity, free units included"""
+    return usage_cost(price, quantity) / quantity if quantity > 0 else price.price
+
+
+def tier_usage(price: Price, billed: float) -> List[Tuple[PriceTier, float]]:
+    """(tier, quantity) of each graduated tier a billed quantity reaches"""
+    usage = []
+    for tier in price.tiers:
+        if billed <= tier.begin:
+            break
+        usage.append((tier, (billed if tier.end is None else min(billed, tier.end)) - tier.begin))
+    return usage
+
+
+def _volume_tier(tiers: List[PriceTier], billed: float) -> PriceTier:
+    for tier in tiers:
+        if tier.end is None or billed <= tier.end:
+            return tier
+    return tiers[-1]
+
+
+def normalize_tier_mode(value: str) -> str:
+    """
+    Lower-cased tier mode
+
+    Raises:
+        ValueError: If the mode is not graduated or volume
+    """
+    mode = value.strip().lower()
+    if mode not in TIER_MODES:
+        raise ValueError(f"tier_mode must be one of: {', '.join(TIER_MODES)}")
+    return mode
+
+
+def validate_tiers(tiers: List[PriceTier]) -> List[PriceTier]:
+    """
+    Check tiers start at 0 and follow each other without gaps
+
+    Raises:
+        ValueError: If the tiers are not contiguous
+    """
+    expected = 0.0
+    for index, tier in enumerate(tiers):
+        if tier.begin != expected:
+            raise ValueError(f"Tier {index + 1} begins at {tier.begin:g}, expected {expected:g}")
+        if tier.end is None:
+            if index != len(tiers) - 1:
+                raise ValueError("Only the last tier may be open-ended")
+            break
+        if tier.end <= tier.begin:
+            raise ValueError(f"Tier {index + 1} must end after it begins")
+        expected = tier.end
+    return tiers
+
+
+def parse_tiers(value: Any) -> List[PriceTier]:
+    """
+    Tiers of a price sheet cell or JSON field
+
+    Accepts "end:price" bands separated by semicolons, each starting where
+    the previous ended and the last with an empty end for open-ended, e.g.
+    "10240:0.09;51200:0.085;:0.07", or a list of {"begin", "end", "price"}
+    (or {"up_to", "price"}) objects.
+
+    Raises:
+        ValueError: If a band cannot be read
+    """
+    if value in (None, "", []):
+        return []
+    if isinstance(value, list):
+        return _tiers_of_objects(value)
+    if not isinstance(value, str):
+        raise ValueError("tiers must be a string of end:price bands or a list of tiers")
+
+    tiers, begin = [], 0.0
+    for band in (b.strip() for b in value.split(";")):
+        if not band:
+            continue
+        end, sep, amount = band.partition(":")
+        if not sep:
+            raise ValueError(f"Tier '{band}' must be end:price")
+        try:
+            tier = PriceTier(begin=begin, end=float(end) if end.strip() else None, price=float(amount))
+        except ValueError:
+            raise ValueError(f"Tier '{band}' must be end:price with numbers") from None
+        tiers.append(tier)
+        begin = tier.end if tier.end is not None else begin
+    return tiers
+
+
+def _tiers_of_objects(items: List[Any]) -> List[PriceTier]:
+    tiers: List[PriceTier] = []
+    for item in items:
+        if isinstance(item, PriceTier):
+            tiers.append(item)
+            continue
+        if not isinstance(item, dict):
+            raise ValueError("Each tier must be an object with price and end (or up_to)")
+        item = dict(item)
+        end: Optional[float] = item.pop("up_to", item.pop("end", None))
+        begin = item.pop("begin", tiers[-1].end if tiers and tiers[-1].end is not None else 0.0)
+        tiers.append(PriceTier(begin=begin, end=end, **item))
+    return tiers
@@ -0,0 +1,140 @@
+"""
+Instance type shapes
+
+vCPU, memory and accelerator counts of instance types, used to map
+abstract resource requests (Kubernetes requests, workload sizes)
+onto priced instances.
+"""
+
+from typing import Callable, Dict, NamedTuple, Optional
+
+
+class InstanceShape(NamedTuple):
+    """Hardware shape of an instance type"""
+
+    vcpus: float
+    memory_gb: float
+    gpus: int = 0
+    arch: str = "x86_64"
+    accelerator: str = ""
+
+
+# Size suffix -> vCPU count for AWS families sized large..16xlarge
+_AWS_SIZES = {
+    "large": 2, "xlarge": 4, "2xlarge": 8, "4xlarge": 16,
+    "8xlarge": 32, "12xlarge": 48, "16xlarge": 64,
+}
+
+# AWS family -> (GB per vCPU, architecture)
+_AWS_FAMILIES = {
+    "m5": (4, "x86_64"), "m6i": (4, "x86_64"), "m7i": (4, "x86_64"),
+    "m6g": (4, "arm64"), "m7g": (4, "arm64"),
+    "c5": (2, "x86_64"), "c6i": (2, "x86_64"), "c7i": (2, "x86_64"),
+    "c6g": (2, "arm64"), "c7g": (2, "arm64"),
+    "r5": (8, "x86_64"), "r6i": (8, "x86_64"), "r7i": (8, "x86_64"),
+    "r6g": (8, "arm64"), "r7g": (8, "arm64"),
+}
+
+# Burstable families with their own size table
+_AWS_BURSTABLE = {
+    "micro": (2, 1), "small": (2, 2), "medium": (2, 4),
+    "large": (2, 8), "xlarge": (4, 16), "2xlarge": (8, 32),
+}
+
+# GPU instance types: (vCPUs, memory GB, GPUs, accelerator type)
+_AWS_GPU = {
+    "g4dn.xlarge": (4, 16, 1, "nvidia-tesla-t4"),
+    "g4dn.2xlarge": (8, 32, 1, "nvidia-tesla-t4"),
+    "g4dn.4xlarge": (16, 64, 1, "nvidia-tesla-t4"),
+    "g4dn.12xlarge": (48, 192, 4, "nvidia-tesla-t4"),
+    "g5.xlarge": (4, 16, 1, "nvidia-a10g"),
+    "g5.2xlarge": (8, 32, 1, "nvidia-a10g"),
+    "g5.12xlarge": (48, 192, 4, "nvidia-a10g"),
+    "g5.48xlarge": (192, 768, 8, "nvidia-a10g"),
+    "g6.xlarge": (4, 16, 1, "nvidia-l4"),
+    "g6.2xlarge": (8, 32, 1, "nvidia-l4"),
+    "p3.2xlarge": (8, 61, 1, "nvidia-tesla-v100"),
+    "p3.8xlarge": (32, 244, 4, "nvidia-tesla-v100"),
+    "p3.16xlarge": (64, 488, 8, "nvidia-tesla-v100"),
+    "p4d.24xlarge": (96, 1152, 8, "nvidia-tesla-a100"),
+    "p5.48xlarge": (192, 2048, 8, "nvidia-h100-80gb"),
+}
+
+_AZURE_GPU = {
+    "Standard_NC4as_T4_v3": (4, 28, 1, "nvidia-tesla-t4"),
+    "Standard_NC8as_T4_v3": (8, 56, 1, "nvidia-tesla-t4"),
+    "Standard_NC64as_T4_v3": (64, 440, 4, "nvidia-tesla-t4"),
+    "Standard_NC6s_v3": (6, 112, 1, "nvidia-tesla-v100"),
+    "Standard_NC24s_v3": (24, 448, 4, "nvidia-tesla-v100"),
+    "Standard_NC24ads_A100_v4": (24, 220, 1, "nvidia-a100-80gb"),
+    "Standard_ND96asr_v4": (96, 900, 8, "nvidia-tesla-a100"),
+}
+
+_SHAPES: Dict[str, Dict[str, InstanceShape]] = {"aws": {}, "azure": {}}
+
+for _family, (_ratio, _arch) in _AWS_FAMILIES.items():
+    for _size, _vcpus in _AWS_SIZES.items():
+        _SHAPES["aws"][f"{_family}.{_size}"] = InstanceShape(_vcpus, _vcpus * _ratio, 0, _arch)
+
+for _family, _arch in (("t3", "x86_64"), ("t3a", "x86_64"), ("t4g", "arm64")):
+    for _size, (_vcpus, _memory) in _AWS_BURSTABLE.items():
+        _SHAPES["aws"][f"{_family}.{_size}"] = InstanceShape(_vcpus, _memory, 0, _arch)
+
+# Azure sizes: (name pattern, GB per vCPU, architecture)
+for _pattern, _ratio, _arch in (
+    ("Standard_D{n}s_v3", 4, "x86_64"),
+    ("Standard_D{n}s_v4", 4, "x86_64"),
+    ("Standard_D{n}s_v5", 4, "x86_64"),
+    ("Standard_D{n}ps_v5", 4, "arm64"),
+    ("Standard_E{n}s_v3", 8, "x86_64"),
+    ("Standard_E{n}s_v5", 8, "x86_64"),
+    ("Standard_F{n}s_v2", 2, "x86_64"),
+):
+    for _vcpus in (2, 4, 8, 16, 32, 64):
+        _SHAPES["azure"][_pattern.format(n=_vcpus)] = InstanceShape(_vcpus, _vcpus * _ratio, 0, _arch)
+
+for _provider, _types in (("aws", _AWS_GPU), ("azure", _AZURE_GPU)):
+    for _name, (_vcpus, _memory, _gpus, _accelerator) in _types.items():
+        _SHAPES[_provider][_name] = InstanceShape(_vcpus, _memory, _gpus, "x86_64", _accelerator)
+
+_SHAPES["azure"].update({
+    "Standard_B1s": InstanceShape(1, 1),
+    "Standard_B2s": InstanceShape(2, 4),
+    "Standard_B2ms": InstanceShape(2, 8),
+    "Standard_B4ms": InstanceShape(4, 16),
+    "Standard_B8ms": InstanceShape(8, 32),
+})
+
+ShapeResolver = Callable[[str], Optional[InstanceShape]]
+
+_resolvers: Dict[str, ShapeResolver] = {}
+
+
+def register_shape_resolver(provider: str, resolver: ShapeResolver) -> None:
+    """Register a function that resolves instance shapes for a provider"""
+    _resolvers[provider] = resolver
+
+
+def register_shape(provider: str, instance_type: str, shape: InstanceShape) -> None:
+    """Add or override the shape of one instance type"""
+    _SHAPES.setdefault(provider, {})[instance_type] = shape
+
+
+def get_instance_shape(provider: str, instance_type: str) -> InstanceShape:
+    """
+    Look up the shape of an instance type
+
+    Raises:
+        KeyError: If the instance type is unknown
+    """
+    shape = _SHAPES.get(provider, {}).get(instance_type)
+    if shape is None and provider in _resolvers:
+        shape = _resolvers[provider](instance_type)
+    if shape is None:
+        raise KeyError(f"Unknown instance type {provider}/{instance_type}")
+    return shape
+
+
+def known_instance_types(provider: str) -> Dict[str, InstanceShape]:
+    """All statically known instance types of a provider"""
+    return dict(_SHAPES.get(provider, {}))
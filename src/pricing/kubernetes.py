@@ -0,0 +1,56 @@
+"""
+Managed Kubernetes pricing
+
+Managed clusters (EKS, GKE, AKS) bill a fee per cluster-hour for the
+control plane, set by the cluster's tier, on top of the nodes they run.
+GKE Autopilot does not bill nodes; pods are billed for their resource
+requests instead:
+
+- kubernetes: cluster tier per hour
+- kubernetes_pods: Autopilot pod requests per vCPU-hour (sku vcpu) and
+  GB-hour (sku memory), on-demand or spot
+
+Cluster tiers use the provider-neutral names below. Self-managed clusters
+(kops, kubeadm) pay for their control plane nodes like any other node.
+"""
+
+TIER_SELF_MANAGED = "self_managed"
+TIER_FREE = "free"
+TIER_STANDARD = "standard"
+# EKS versions past standard support
+TIER_EXTENDED = "extended"
+# AKS Premium (long-term support)
+TIER_PREMIUM = "premium"
+TIER_AUTOPILOT = "autopilot"
+CLUSTER_TIERS = (TIER_SELF_MANAGED, TIER_FREE, TIER_STANDARD, TIER_EXTENDED, TIER_PREMIUM, TIER_AUTOPILOT)
+
+POD_SKU_VCPU = "vcpu"
+POD_SKU_MEMORY = "memory"
+
+# Tier of managed clusters that set none (AKS clusters are created on the free tier)
+DEFAULT_CLUSTER_TIERS = {
+    "aws": TIER_STANDARD,
+    "gcp": TIER_STANDARD,
+    "azure": TIER_FREE,
+}
+
+_CLUSTER_TIER_ALIASES = {
+    "self-managed": TIER_SELF_MANAGED,
+    "none": TIER_SELF_MANAGED,
+    "extended_support": TIER_EXTENDED,
+    "lts": TIER_PREMIUM,
+}
+
+
+def normalize_cluster_tier(value: str) -> str:
+    """Normalize a cluster tier name for request validators"""
+    tier = value.strip().lower()
+    tier = _CLUSTER_TIER_ALIASES.get(tier, tier)
+    if tier not in CLUSTER_TIERS:
+        raise ValueError(f"cluster_tier must be one of: {', '.join(CLUSTER_TIERS)}")
+    return tier
+
+
+def default_cluster_tier(provider: str) -> str:
+    """Tier of a managed cluster of a provider, self-managed for providers without a managed service"""
+    return DEFAULT_CLUSTER_TIERS.get(provider, TIER_SELF_MANAGED)
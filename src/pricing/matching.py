@@ -0,0 +1,168 @@
+"""
+Fuzzy SKU matching
+
+Instance types that are not found are compared with the ones a region
+prices, so errors can suggest the closest and, when fuzzy matching is
+turned on for a request, a close enough match is priced instead. The
+confidence of a match combines the edit distance of the whole names with
+that of their family (m5, standard) and size (large, d2s_v5) parts; a
+family or size that another candidate has is taken as meant, not as a
+typo, so m5.2xlarge is not matched to m5.xlarge nor m4.large to
+m5.large. Names differing only in case or separators, like m5-large
+for m5.large, match with confidence 0.98.
+"""
+
+import re
+from contextlib import contextmanager
+from contextvars import ContextVar
+from typing import AbstractSet, Iterable, Iterator, List, Optional, Tuple
+
+from pydantic import BaseModel, Field
+
+# Least confidence an instance type is priced as a match of, by default
+DEFAULT_MIN_CONFIDENCE = 0.8
+# Least confidence of the instance types suggested for one not found
+SUGGESTION_MIN_CONFIDENCE = 0.5
+# Instance types suggested for one not found
+MAX_SUGGESTIONS = 3
+
+# Confidence of names that are equal but for case and separators
+_SAME_NORMALIZED = 0.98
+# Confidence of names equal but for a prefix, like D2s_v5 for Standard_D2s_v5
+_PREFIX_DROPPED = 0.9
+
+# Least lead of a resolved match over the next best, closer matches being ambiguous
+_MIN_LEAD = 0.05
+
+# Weights of the whole name, family and size similarity
+_NAME_WEIGHT, _FAMILY_WEIGHT, _SIZE_WEIGHT = 0.5, 0.3, 0.2
+
+_SEPARATORS = re.compile(r"[\s._\-/]+")
+
+# Least confidence of the requests whose lookups may be matched, None while matching is off
+_min_confidence: ContextVar[Optional[float]] = ContextVar("fuzzy_min_confidence", default=None)
+
+
+class SkuMatch(BaseModel):
+    """An instance type resembling one that was not found"""
+
+    sku: str
+    confidence: float = Field(..., ge=0, le=1, description="1 for the same name, lower for less alike names")
+
+
+@contextmanager
+def fuzzy_matching(min_confidence: float = DEFAULT_MIN_CONFIDENCE) -> Iterator[None]:
+    """Price instance types that are not found as their closest match of at least min_confidence"""
+    token = _min_confidence.set(min_confidence)
+    try:
+        yield
+    finally:
+        _min_confidence.reset(token)
+
+
+def fuzzy_min_confidence() -> Optional[float]:
+    """Least confidence of matches priced in the current context, None if fuzzy matching is off"""
+    return _min_confidence.get()
+
+
+def _normalized(name: str) -> str:
+    return _SEPARATORS.sub("", name.lower())
+
+
+def _parts(name: str) -> Tuple[str, str]:
+    """(family, size) of an instance type: m5.large -> (m5, large), Standard_D2s_v5 -> (standard, d2s_v5)"""
+    parts = _SEPARATORS.split(name.lower().strip(), maxsplit=1)
+    return parts[0], parts[1] if len(parts) > 1 else ""
+
+
+def _distance(a: str, b: str) -> int:
+    """Edit distance counting insertions, deletions, substitutions and adjacent transpositions"""
+    previous, current = None, list(range(len(b) + 1))
+    for i in range(1, len(a) + 1):
+        before, previous, current = previous, current, [i] + [0] * len(b)
+        for j in range(1, len(b) + 1):
+            cost = 0 if a[i - 1] == b[j - 1] else 1
+            current[j] = min(previous[j] + 1, current[j - 1] + 1, previous[j - 1] + cost)
+            if i > 1 and j > 1 and a[i - 1] == b[j - 2] and a[i - 2] == b[j - 1]:
+                current[j] = min(current[j], before[j - 2] + 1)
+    return current[len(b)]
+
+
+def _ratio(a: str, b: str) -> float:
+    if not a and not b:
+        return 1.0
+    return 1.0 - _distance(a, b) / max(len(a), len(b))
+
+
+def similarity(
+    requested: str,
+    candidate: str,
+    families: AbstractSet[str] = frozenset(),
+    sizes: AbstractSet[str] = frozenset(),
+) -> float:
+    """
+    Confidence, from 0 to 1, that candidate is the instance type meant by requested
+
+    Args:
+        requested: Instance type that was not found
+        candidate: Instance type that is priced
+        families, sizes: Family and size parts of the candidates; a requested
+            family or size among them must be the candidate's to count
+    """
+    if requested == candidate:
+        return 1.0
+    a, b = _normalized(requested), _normalized(candidate)
+    if a == b:
+        return _SAME_NORMALIZED
+    shorter, longer = sorted((a, b), key=len)
+    if len(shorter) >= 4 and any(c.isdigit() for c in shorter) and longer.endswith(shorter):
+        return _PREFIX_DROPPED
+
+    (family_a, size_a), (family_b, size_b) = _parts(requested), _parts(candidate)
+    family = 0.0 if family_a != family_b and family_a in families else _ratio(family_a, family_b)
+    size = 0.0 if size_a != size_b and size_a in sizes else _ratio(size_a, size_b)
+    return round(_NAME_WEIGHT * _ratio(a, b) + _FAMILY_WEIGHT * family + _SIZE_WEIGHT * size, 4)
+
+
+def closest_skus(
+    requested: str,
+    candidates: Iterable[str],
+    limit: int = MAX_SUGGESTIONS,
+    min_confidence: float = SUGGESTION_MIN_CONFIDENCE,
+) -> List[SkuMatch]:
+    """Candidates most like requested, most confident first"""
+    candidates = set(candidates)
+    parts = [_parts(candidate) for candidate in candidates]
+    families = {family for family, _ in parts}
+    sizes = {size for _, size in parts}
+    matches = [
+        SkuMatch(sku=candidate, confidence=similarity(requested, candidate, families, sizes))
+        for candidate in candidates
+    ]
+    matches = [match for match in matches if match.confidence >= min_confidence]
+    matches.sort(key=lambda match: (-match.confidence, match.sku))
+    return matches[:limit]
+
+
+def resolve_sku(requested: str, candidates: Iterable[str], min_confidence: float) -> Optional[SkuMatch]:
+    """
+    The candidate meant by requested, None if no candidate is confident enough
+
+    A best match whose lead over the next best is under 0.05 is ambiguous
+    and not resolved.
+    """
+    matches = closest_skus(requested, candidates, limit=2, min_confidence=0.0)
+    if not matches or matches[0].confidence < min_confidence:
+        return None
+    if len(matches) > 1 and matches[0].confidence - matches[1].confidence < _MIN_LEAD:
+        return None
+    return matches[0]
+
+
+def did_you_mean(matches: List[SkuMatch]) -> str:
+    """Suggestion of matches for a message, e.g. "did you mean m5.large or m5.xlarge?", empty without matches"""
+    skus = [match.sku for match in matches]
+    if not skus:
+        return ""
+    listed = skus[0] if len(skus) == 1 else f"{', '.join(skus[:-1])} or {skus[-1]}"
+    return f"did you mean {listed}?"
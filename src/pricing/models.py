@@ -0,0 +1,125 @@
+"""
+Data models for price lookups
+"""
+
+from typing import Dict, List, Optional
+from datetime import datetime
+from pydantic import BaseModel, Field
+
+# Service families understood by pricing providers
+SERVICE_COMPUTE = "compute"
+SERVICE_BLOCK_STORAGE = "block_storage"
+SERVICE_OBJECT_STORAGE = "object_storage"
+SERVICE_OBJECT_REQUESTS = "object_requests"
+SERVICE_OBJECT_RETRIEVAL = "object_retrieval"
+SERVICE_DATABASE = "database"
+SERVICE_DATABASE_STORAGE = "database_storage"
+SERVICE_DATABASE_IOPS = "database_iops"
+SERVICE_DATABASE_BACKUP = "database_backup"
+SERVICE_DATA_TRANSFER = "data_transfer"
+SERVICE_KUBERNETES = "kubernetes"
+SERVICE_KUBERNETES_PODS = "kubernetes_pods"
+SERVICE_ACCELERATOR = "accelerator"
+SERVICE_SERVERLESS_REQUESTS = "serverless_requests"
+SERVICE_SERVERLESS_MEMORY = "serverless_memory"
+SERVICE_SERVERLESS_CPU = "serverless_cpu"
+
+# Purchase options a compute price can be quoted for
+PRICING_ON_DEMAND = "on_demand"
+PRICING_SPOT = "spot"
+PRICING_MODELS = (PRICING_ON_DEMAND, PRICING_SPOT)
+
+
+def normalize_pricing_model(value: str) -> str:
+    """Normalize a pricing model name for request validators"""
+    value = value.strip().lower()
+    if value not in PRICING_MODELS:
+        raise ValueError(f"pricing_model must be one of: {', '.join(PRICING_MODELS)}")
+    return value
+
+
+class PriceQuery(BaseModel):
+    """Identifies a single billable unit to be priced"""
+
+    provider: str = Field(..., description="Cloud provider, e.g. aws")
+    region: str = Field(..., description="Provider region")
+    sku: str = Field(..., description="Instance type, volume type or provider SKU")
+    service: str = Field(default=SERVICE_COMPUTE, description="Service family, see SERVICE_* constants")
+    pricing_model: str = Field(
+        default=PRICING_ON_DEMAND,
+        description="on_demand, spot, or a commitment model (see pricing.commitment)"
+    )
+    attributes: Dict[str, str] = Field(default_factory=dict, description="Provider specific filters")
+
+
+# How a quantity is priced over a price's tiers: each band at its own
+# price, or every unit at the price of the band the quantity ends in
+TIER_MODE_GRADUATED = "graduated"
+TIER_MODE_VOLUME = "volume"
+TIER_MODES = (TIER_MODE_GRADUATED, TIER_MODE_VOLUME)
+
+
+class PriceTier(BaseModel):
+    """Volume band of a tiered price"""
+
+    begin: float = Field(..., ge=0, description="Monthly quantity the tier starts at")
+    end: Optional[float] = Field(None, description="Monthly quantity the tier ends at, None for no end")
+    price: float = Field(..., ge=0, description="Price per unit within the tier")
+
+
+class Price(BaseModel):
+    """Unit price returned by a pricing provider"""
+
+    provider: str
+    region: str
+    sku: str
+    service: str = SERVICE_COMPUTE
+    unit: str = Field(default="hour", description="Billing unit: hour, GB-month, request, ...")
+    price: float = Field(..., ge=0, description="Price per unit")
+    currency: str = "USD"
+    source: str = Field(..., description="Name of the pricing provider that supplied the price")
+    pricing_model: str = PRICING_ON_DEMAND
+    upfront: float = Field(default=0.0, ge=0, description="One-time fee per unit for commitment prices (USD)")
+    averaged_over_days: Optional[float] = Field(
+        None,
+        description="Length of price history averaged into a spot price (None for point-in-time prices)"
+    )
+    effective_date: Optional[datetime] = None
+    tiers: List[PriceTier] = Field(
+        default_factory=list,
+        description="Volume tiers in ascending order, empty for flat prices; price is the first priced tier's"
+    )
+    tier_mode: str = Field(TIER_MODE_GRADUATED, description="graduated or volume")
+    free_quantity: float = Field(0.0, ge=0, description="Monthly quantity free before the tiers apply")
+    minimum_quantity: float = Field(0.0, ge=0, description="Least billed monthly quantity once usage is billed")
+    minimum_charge: float = Field(0.0, ge=0, description="Least monthly charge once usage is billed")
+    requested_sku: Optional[str] = Field(None, description="SKU looked up, if the price is a fuzzy match's")
+    match_confidence: Optional[float] = Field(None, description="Confidence of the fuzzy match priced instead")
+
+
+class UsageDiscount(BaseModel):
+    """Provider-defined discount that depends on monthly usage (e.g. GCP sustained use)"""
+
+    name: str
+    rate: float = Field(..., ge=0, le=1, description="Fraction of the gross cost that is discounted")
+    description: Optional[str] = None
+
+
+# States of a provider's price catalog
+CATALOG_PENDING = "pending"
+CATALOG_OK = "ok"
+CATALOG_DEGRADED = "degraded"
+
+
+class CatalogStatus(BaseModel):
+    """Refresh status of a provider's price catalog"""
+
+    provider: str
+    state: str = Field(..., description="pending (not refreshed yet), ok or degraded (older than the max age)")
+    refreshing: bool = False
+    last_attempt: Optional[datetime] = None
+    last_success: Optional[datetime] = None
+    last_error: Optional[str] = None
+    consecutive_failures: int = 0
+    age_seconds: Optional[float] = Field(None, description="Seconds since the last successful refresh")
+    next_refresh: Optional[datetime] = Field(None, description="None when no further refresh is scheduled")
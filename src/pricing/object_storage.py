@@ -0,0 +1,79 @@
+"""
+Object storage pricing
+
+Object storage (S3, Cloud Storage, Azure Blob) is billed per storage class
+for the data stored, write requests (PUT, COPY, POST, LIST), read requests
+(GET and the rest), lifecycle transitions into the class and data
+retrieved from infrequent access and archive classes:
+
+- object_storage: storage class per GB-month, often in volume tiers
+- object_requests: storage class per 1000 requests, qualified by operation
+- object_retrieval: storage class per GB retrieved
+
+Storage classes use the provider-neutral names below; lookups carry the
+provider's SKU of the class (e.g. Standard - Infrequent Access, NEARLINE,
+Cool LRS).
+"""
+
+STORAGE_CLASS_STANDARD = "standard"
+STORAGE_CLASS_INFREQUENT = "infrequent"
+STORAGE_CLASS_ARCHIVE = "archive"
+STORAGE_CLASSES = (STORAGE_CLASS_STANDARD, STORAGE_CLASS_INFREQUENT, STORAGE_CLASS_ARCHIVE)
+
+OPERATION_WRITE = "write"
+OPERATION_READ = "read"
+OPERATION_TRANSITION = "transition"
+OPERATIONS = (OPERATION_WRITE, OPERATION_READ, OPERATION_TRANSITION)
+
+# PriceQuery.attributes key of a request lookup
+ATTR_OPERATION = "operation"
+
+# Provider SKU of each storage class (AWS volumeType, GCS class, Azure tier)
+STORAGE_CLASS_SKUS = {
+    "aws": {
+        STORAGE_CLASS_STANDARD: "Standard",
+        STORAGE_CLASS_INFREQUENT: "Standard - Infrequent Access",
+        STORAGE_CLASS_ARCHIVE: "Amazon Glacier",
+    },
+    "gcp": {
+        STORAGE_CLASS_STANDARD: "STANDARD",
+        STORAGE_CLASS_INFREQUENT: "NEARLINE",
+        STORAGE_CLASS_ARCHIVE: "ARCHIVE",
+    },
+    "azure": {
+        STORAGE_CLASS_STANDARD: "Hot LRS",
+        STORAGE_CLASS_INFREQUENT: "Cool LRS",
+        STORAGE_CLASS_ARCHIVE: "Archive LRS",
+    },
+}
+
+_STORAGE_CLASS_ALIASES = {
+    "hot": STORAGE_CLASS_STANDARD,
+    "ia": STORAGE_CLASS_INFREQUENT,
+    "infrequent_access": STORAGE_CLASS_INFREQUENT,
+    "nearline": STORAGE_CLASS_INFREQUENT,
+    "cool": STORAGE_CLASS_INFREQUENT,
+    "glacier": STORAGE_CLASS_ARCHIVE,
+}
+
+
+def normalize_storage_class(value: str) -> str:
+    """Normalize a storage class name for request validators"""
+    storage_class = value.strip().lower()
+    storage_class = _STORAGE_CLASS_ALIASES.get(storage_class, storage_class)
+    if storage_class not in STORAGE_CLASSES:
+        raise ValueError(f"storage_class must be one of: {', '.join(STORAGE_CLASSES)}")
+    return storage_class
+
+
+def storage_class_sku(provider: str, storage_class: str) -> str:
+    """
+    Provider SKU of a storage class
+
+    Raises:
+        ValueError: If the provider has no object storage classes
+    """
+    try:
+        return STORAGE_CLASS_SKUS[provider][storage_class]
+    except KeyError:
+        raise ValueError(f"No {storage_class} object storage class for {provider}") from None
@@ -0,0 +1,291 @@
+"""
+Out-of-tree pricing providers
+
+Organizations add proprietary rate cards without forking the estimator
+in one of two ways, both enabled through PRICING_PROVIDERS like the
+built-in providers:
+
+- Python plugins: modules named in PRICING_PLUGIN_MODULES are imported
+  and call register_factory() as built-in providers do; installed
+  packages may instead expose factories as entry points of the
+  kcloud_cost_estimator.pricing_providers group (entry point name ->
+  provider name).
+- Executable plugins: every executable file in PRICING_PLUGIN_DIR is a
+  provider named after the file (without extension). It is started
+  once and answers JSON requests, one per line on stdin, with one JSON
+  response per line on stdout; stderr is passed through to the logs:
+
+      -> {"id": 1, "method": "describe", "params": {}}
+      <- {"id": 1, "result": {"protocol": 1, "clouds": ["acme"]}}
+      -> {"id": 2, "method": "get_price", "params": {"provider": "acme", "region": "dc1", "sku": "gold", ...}}
+      <- {"id": 2, "result": {"price": 0.21, "unit": "hour"}}
+      <- {"id": 2, "error": {"code": "not_found", "message": "no gold in dc1"}}
+
+  Methods are describe, get_price (params: a PriceQuery, result: a
+  Price whose provider, region and sku default to the query's),
+  instance_types (params: {"cloud"}, result: region -> instance types)
+  and refresh. Plugins that do not implement an optional method answer
+  with the error code not_implemented; they exit when stdin is closed.
+  A plugin that crashes or does not answer within PRICING_PLUGIN_TIMEOUT
+  is restarted on the next request; its prices are not found meanwhile.
+"""
+
+import importlib
+import json
+import logging
+import os
+import selectors
+import subprocess
+import threading
+import time
+from importlib.metadata import entry_points
+from typing import Any, Dict, List, Optional, Set
+
+from .models import Price, PriceQuery
+from .provider import PricingProvider, PriceNotFoundError
+from .registry import available_providers, register_factory
+
+logger = logging.getLogger(__name__)
+
+# Version of the executable plugin protocol, answered by describe
+PROTOCOL_VERSION = 1
+
+ENTRY_POINT_GROUP = "kcloud_cost_estimator.pricing_providers"
+
+# Error codes of plugin responses
+ERROR_NOT_FOUND = "not_found"
+ERROR_NOT_IMPLEMENTED = "not_implemented"
+
+DEFAULT_TIMEOUT = 10.0
+
+# Providers registered by load_plugins(), which may load them again
+_loaded: Set[str] = set()
+
+
+class PluginError(RuntimeError):
+    """Raised when an executable plugin cannot be started or answers out of protocol"""
+
+
+class ExecPricingProvider(PricingProvider):
+    """Pricing provider answered by an executable plugin process"""
+
+    def __init__(self, name: str, command: List[str], timeout: float = DEFAULT_TIMEOUT):
+        """
+        Initialize provider, starting the plugin to learn the clouds it prices
+
+        Args:
+            name: Provider name used in PRICING_PROVIDERS
+            command: Plugin executable and its arguments
+            timeout: Seconds a plugin has to answer a request
+
+        Raises:
+            PluginError: If the plugin does not start or speaks another protocol
+        """
+        self.name = name
+        self.command = command
+        self.timeout = timeout
+        self._lock = threading.Lock()
+        self._process: Optional[subprocess.Popen] = None
+        self._buffer = b""
+        self._next_id = 0
+        try:
+            description = self._call("describe", {}) or {}
+        except _PluginErrorResponse as e:
+            self.close()
+            raise PluginError(f"Plugin {name} describe failed: {e.message}") from None
+        protocol = description.get("protocol")
+        if protocol != PROTOCOL_VERSION:
+            self.close()
+            raise PluginError(f"Plugin {name} speaks protocol {protocol}, expected {PROTOCOL_VERSION}")
+        self._clouds = [str(cloud) for cloud in description.get("clouds") or []]
+        if not self._clouds:
+            self.close()
+            raise PluginError(f"Plugin {name} prices no clouds")
+
+    @property
+    def clouds(self) -> List[str]:
+        return list(self._clouds)
+
+    def get_price(self, query: PriceQuery) -> Price:
+        try:
+            result = self._call("get_price", json.loads(query.json()))
+        except _PluginErrorResponse as e:
+            if e.code != ERROR_NOT_FOUND:
+                logger.warning(f"Pricing plugin {self.name} answered {e.code}: {e.message}")
+            raise PriceNotFoundError(e.message or f"No {self.name} price for {query.sku}") from None
+        except PluginError as e:
+            logger.warning(f"Pricing plugin {self.name} failed: {e}")
+            raise PriceNotFoundError(f"Pricing plugin {self.name} failed: {e}") from None
+        if not isinstance(result, dict):
+            raise PriceNotFoundError(f"Pricing plugin {self.name} answered no price for {query.sku}")
+        fields = {"provider": query.provider, "region": query.region, "sku": query.sku,
+                  "service": query.service, "pricing_model": query.pricing_model}
+        fields.update(result)
+        # Usage discounts are looked up by the price's source
+        fields["source"] = self.name
+        try:
+            return Price.parse_obj(fields)
+        except ValueError as e:
+            raise PriceNotFoundError(f"Pricing plugin {self.name} answered an invalid price: {e}") from None
+
+    def instance_types(self, cloud: str) -> Dict[str, Set[str]]:
+        try:
+            result = self._call("instance_types", {"cloud": cloud}) or {}
+        except _PluginErrorResponse:
+            return {}
+        except PluginError as e:
+            logger.warning(f"Pricing plugin {self.name} failed: {e}")
+            return {}
+        return {region: set(types) for region, types in result.items()}
+
+    def refresh(self) -> None:
+        try:
+            self._call("refresh", {})
+        except _PluginErrorResponse as e:
+            if e.code != ERROR_NOT_IMPLEMENTED:
+                raise PluginError(f"Plugin {self.name} refresh failed: {e.message}") from None
+
+    def close(self) -> None:
+        """Stop the plugin process"""
+        with self._lock:
+            self._stop()
+
+    def _call(self, method: str, params: Dict[str, Any]) -> Any:
+        """
+        Send a request to the plugin and wait for its result
+
+        Raises:
+            _PluginErrorResponse: If the plugin answers with an error
+            PluginError: If the plugin cannot be started, crashes or times out
+        """
+        with self._lock:
+            if self._process is None or self._process.poll() is not None:
+                self._start()
+            self._next_id += 1
+            request_id = self._next_id
+            line = json.dumps({"id": request_id, "method": method, "params": params}) + "\n"
+            try:
+                self._process.stdin.write(line.encode())
+                self._process.stdin.flush()
+                response = self._read_response(request_id)
+            except (OSError, ValueError, PluginError) as e:
+                # The next request starts a new process
+                self._stop()
+                raise PluginError(str(e)) from None
+        error = response.get("error")
+        if error:
+            raise _PluginErrorResponse(str(error.get("code", "")), str(error.get("message", "")))
+        return response.get("result")
+
+    def _start(self) -> None:
+        self._stop()
+        try:
+            self._process = subprocess.Popen(
+                self.command, stdin=subprocess.PIPE, stdout=subprocess.PIPE, bufsize=0
+            )
+        except OSError as e:
+            raise PluginError(f"Cannot start plugin {self.name}: {e}") from None
+        logger.info(f"Pricing plugin {self.name} started (pid {self._process.pid})")
+
+    def _stop(self) -> None:
+        process, self._process, self._buffer = self._process, None, b""
+        if process is None:
+            return
+        try:
+            process.stdin.close()
+            process.wait(timeout=1)
+        except (OSError, subprocess.TimeoutExpired):
+            process.kill()
+            process.wait()
+
+    def _read_response(self, request_id: int) -> Dict[str, Any]:
+        """Next response of the plugin, skipping answers to earlier requests that timed out"""
+        deadline = time.monotonic() + self.timeout
+        while True:
+            response = json.loads(self._read_line(deadline))
+            if not isinstance(response, dict):
+                raise PluginError(f"Plugin {self.name} answered {response!r}")
+            if response.get("id") == request_id:
+                return response
+
+    def _read_line(self, deadline: float) -> bytes:
+        stdout = self._process.stdout
+        with selectors.DefaultSelector() as selector:
+            selector.register(stdout, selectors.EVENT_READ)
+            while b"\n" not in self._buffer:
+                left = deadline - time.monotonic()
+                if left <= 0 or not selector.select(left):
+                    raise PluginError(f"Plugin {self.name} did not answer within {self.timeout:g}s")
+                chunk = os.read(stdout.fileno(), 65536)
+                if not chunk:
+                    raise PluginError(f"Plugin {self.name} exited with status {self._process.wait()}")
+                self._buffer += chunk
+        line, self._buffer = self._buffer.split(b"\n", 1)
+        return line
+
+
+class _PluginErrorResponse(Exception):
+    """Error answered by a plugin"""
+
+    def __init__(self, code: str, message: str):
+        super().__init__(message)
+        self.code = code
+        self.message = message
+
+
+def plugin_executables(directory: str) -> Dict[str, str]:
+    """Provider name -> path of the executable files in a directory"""
+    if not directory or not os.path.isdir(directory):
+        return {}
+    plugins = {}
+    for entry in sorted(os.listdir(directory)):
+        path = os.path.join(directory, entry)
+        if entry.startswith(".") or not os.path.isfile(path) or not os.access(path, os.X_OK):
+            continue
+        plugins[os.path.splitext(entry)[0]] = path
+    return plugins
+
+
+def load_plugins(settings: Any) -> List[str]:
+    """
+    Register the factories of the configured pricing plugins
+
+    Plugins are registered, not enabled: PRICING_PROVIDERS enables them.
+    Plugins named like a built-in provider are skipped.
+
+    Returns:
+        Names of the providers registered by plugins
+
+    Raises:
+        ImportError: If a module of PRICING_PLUGIN_MODULES cannot be imported
+    """
+    before = set(available_providers()) - _loaded
+    for module in getattr(settings, "pricing_plugin_modules", []):
+        importlib.import_module(module)
+        logger.info(f"Pricing plugin module loaded: {module}")
+
+    for entry_point in entry_points(group=ENTRY_POINT_GROUP):
+        if entry_point.name in before:
+            logger.warning(f"Pricing plugin {entry_point.name} ({entry_point.value}) shadows a provider, skipped")
+            continue
+        try:
+            register_factory(entry_point.name, entry_point.load())
+        except Exception as e:
+            logger.error(f"Cannot load pricing plugin {entry_point.name} ({entry_point.value}): {e}")
+
+    timeout = float(getattr(settings, "pricing_plugin_timeout", DEFAULT_TIMEOUT))
+    for name, path in plugin_executables(getattr(settings, "pricing_plugin_dir", "")).items():
+        if name in before:
+            logger.warning(f"Pricing plugin {path} shadows the {name} provider, skipped")
+            continue
+        register_factory(name, _exec_factory(name, path, timeout))
+
+    loaded = sorted(set(available_providers()) - before)
+    _loaded.update(loaded)
+    return loaded
+
+
+def _exec_factory(name: str, path: str, timeout: float):
+    def build(settings) -> ExecPricingProvider:
+        return ExecPricingProvider(name, [path], timeout=timeout)
+    return build
@@ -0,0 +1,66 @@
+"""
+Pricing provider interface
+"""
+
+from abc import ABC, abstractmethod
+from typing import Dict, Iterable, List, Optional, Set
+
+from .models import Price, PriceQuery, UsageDiscount, SERVICE_COMPUTE
+
+# Region of instance types a rate card prices in every region
+ANY_REGION = "*"
+
+
+class PriceNotFoundError(LookupError):
+    """Raised when no price is known for a query"""
+
+
+class PricingProvider(ABC):
+    """
+    Source of unit prices for one or more clouds
+
+    Implementations answer get_price() from local data and may
+    download or rebuild that data in refresh().
+    """
+
+    #: Unique provider name used in configuration (e.g. "static", "aws")
+    name: str = ""
+
+    @property
+    @abstractmethod
+    def clouds(self) -> List[str]:
+        """Cloud identifiers this provider can price (matched against PriceQuery.provider)"""
+
+    @abstractmethod
+    def get_price(self, query: PriceQuery) -> Price:
+        """
+        Look up the unit price for a query
+
+        Raises:
+            PriceNotFoundError: If the provider has no price for the query
+        """
+
+    def usage_discount(self, price: Price, hours_per_month: float) -> Optional[UsageDiscount]:
+        """Automatic discount earned by running a priced resource for the given hours (none by default)"""
+        return None
+
+    def instance_types(self, cloud: str) -> Dict[str, Set[str]]:
+        """
+        Instance types priced in each region of a cloud, for SKU discovery
+
+        Providers that quote prices on demand cannot list them and return none.
+        """
+        return {}
+
+    def refresh(self) -> None:
+        """Reload price data from the upstream source (no-op by default)"""
+
+
+def instance_types_of_keys(keys: Iterable[str]) -> Dict[str, Set[str]]:
+    """Instance types by region of price entry keys service|region|sku[|qualifier], on-demand entries only"""
+    regions: Dict[str, Set[str]] = {}
+    for key in keys:
+        parts = key.split("|")
+        if len(parts) >= 3 and parts[0] == SERVICE_COMPUTE and not any(parts[3:]):
+            regions.setdefault(parts[1], set()).add(parts[2])
+    return regions
@@ -0,0 +1,243 @@
+"""
+Pricing provider registry
+
+Providers register a factory under a unique name at import time; the
+application builds a ProviderRegistry from the names enabled in config.
+Lookups for a cloud are tried against its providers in registration
+order, so more specific sources can be placed ahead of generic ones.
+An override lookup (negotiated prices) is consulted before any provider.
+Providers may price in their own currency (NCP in KRW); their prices are
+converted to USD with the exchange rates set on the registry.
+While fuzzy matching is on, an instance type no provider prices is
+priced as its closest match among the instance types of its region.
+"""
+
+import logging
+from typing import Any, Callable, Dict, List, Optional, Set
+
+from ..currency.models import BASE_CURRENCY
+from .models import Price, PriceQuery, UsageDiscount, PRICING_ON_DEMAND, SERVICE_COMPUTE
+from .events import report_refresh_failure
+from .instance_types import known_instance_types
+from .matching import MAX_SUGGESTIONS, SkuMatch, closest_skus, fuzzy_min_confidence, resolve_sku
+from .provider import ANY_REGION, PricingProvider, PriceNotFoundError
+
+logger = logging.getLogger(__name__)
+
+ProviderFactory = Callable[[Any], PricingProvider]
+
+# Returns the price to use instead of the providers', or None to price normally
+PriceOverrideLookup = Callable[[PriceQuery], Optional[Price]]
+
+# Currency -> units of it per USD; raises ValueError without a rate
+ExchangeRateLookup = Callable[[str], float]
+
+_factories: Dict[str, ProviderFactory] = {}
+
+
+class ProviderNotFoundError(LookupError):
+    """Raised when no provider is registered under a name or for a cloud"""
+
+
+def register_factory(name: str, factory: ProviderFactory) -> None:
+    """
+    Register a provider factory
+
+    Args:
+        name: Provider name used in PRICING_PROVIDERS
+        factory: Callable taking application settings and returning a provider
+    """
+    _factories[name] = factory
+
+
+def available_providers() -> List[str]:
+    """Names of all provider factories compiled into this build"""
+    return sorted(_factories.keys())
+
+
+def build_registry(names: List[str], settings: Any = None) -> "ProviderRegistry":
+    """
+    Create a registry with the named providers enabled
+
+    Raises:
+        ProviderNotFoundError: If a name has no registered factory
+    """
+    registry = ProviderRegistry()
+    for name in names:
+        factory = _factories.get(name)
+        if factory is None:
+            raise ProviderNotFoundError(
+                f"Unknown pricing provider '{name}' "
+                f"(available: {', '.join(available_providers())})"
+            )
+        registry.register(factory(settings))
+        logger.info(f"Pricing provider enabled: {name}")
+    return registry
+
+
+class ProviderRegistry:
+    """Routes price lookups to the providers enabled for each cloud"""
+
+    def __init__(self):
+        self._providers: Dict[str, PricingProvider] = {}
+        self._by_cloud: Dict[str, List[PricingProvider]] = {}
+        self._overrides: Optional[PriceOverrideLookup] = None
+        self._exchange_rates: Optional[ExchangeRateLookup] = None
+
+    def register(self, provider: PricingProvider) -> None:
+        """Add a provider; it is consulted after providers registered earlier"""
+        self._providers[provider.name] = provider
+        for cloud in provider.clouds:
+            self._by_cloud.setdefault(cloud, []).append(provider)
+
+    def set_overrides(self, lookup: Optional[PriceOverrideLookup]) -> None:
+        """Consult lookup before the providers on every price lookup (None removes it)"""
+        self._overrides = lookup
+
+    def set_exchange_rates(self, lookup: Optional[ExchangeRateLookup]) -> None:
+        """Convert prices of providers not pricing in USD with lookup (None leaves them unpriced)"""
+        self._exchange_rates = lookup
+
+    def get(self, name: str) -> PricingProvider:
+        """Get an enabled provider by name"""
+        try:
+            return self._providers[name]
+        except KeyError:
+            raise ProviderNotFoundError(f"Pricing provider '{name}' is not enabled") from None
+
+    def providers(self) -> List[PricingProvider]:
+        """All enabled providers"""
+        return list(self._providers.values())
+
+    def clouds(self) -> List[str]:
+        """Clouds that have at least one enabled provider"""
+        return sorted(self._by_cloud.keys())
+
+    def providers_of(self, cloud: str) -> List[PricingProvider]:
+        """
+        Providers enabled for a cloud, in lookup order
+
+        Raises:
+            ProviderNotFoundError: If no provider is enabled for the cloud
+        """
+        chain = self._by_cloud.get(cloud)
+        if not chain:
+            raise ProviderNotFoundError(f"No pricing provider enabled for '{cloud}'")
+        return list(chain)
+
+    def instance_types(self, cloud: str) -> Dict[str, Set[str]]:
+        """
+        Instance types by region that the cloud's providers can list
+
+        Raises:
+            ProviderNotFoundError: If no provider is enabled for the cloud
+        """
+        regions: Dict[str, Set[str]] = {}
+        for provider in self.providers_of(cloud):
+            for region, instance_types in provider.instance_types(cloud).items():
+                regions.setdefault(region, set()).update(instance_types)
+        return regions
+
+    def suggest_instance_types(
+        self, cloud: str, region: str, instance_type: str, limit: int = MAX_SUGGESTIONS
+    ) -> List[SkuMatch]:
+        """
+        Instance types of a region most like one that is not priced, most confident first
+
+        Raises:
+            ProviderNotFoundError: If no provider is enabled for the cloud
+        """
+        return closest_skus(instance_type, self._candidates(cloud, region), limit=limit)
+
+    def _candidates(self, cloud: str, region: str) -> Set[str]:
+        """Instance types priced in a region, the shape table's for clouds whose providers cannot list them"""
+        regions = self.instance_types(cloud)
+        if not regions:
+            return set(known_instance_types(cloud))
+        return regions.get(region, set()) | regions.get(ANY_REGION, set())
+
+    def get_price(self, query: PriceQuery) -> Price:
+        """
+        Look up a price from the first provider that knows it
+
+        While fuzzy matching is on, an instance type that is not found is
+        priced as its closest match, the price naming the requested SKU.
+
+        Raises:
+            ProviderNotFoundError: If no provider is enabled for the cloud
+            PriceNotFoundError: If no enabled provider has a price
+        """
+        try:
+            return self._lookup(query)
+        except PriceNotFoundError:
+            min_confidence = fuzzy_min_confidence()
+            if min_confidence is None or query.service != SERVICE_COMPUTE:
+                raise
+            match = resolve_sku(query.sku, self._candidates(query.provider, query.region), min_confidence)
+            if match is None:
+                raise
+        price = self._lookup(query.copy(update={"sku": match.sku}))
+        logger.info(f"Priced {query.provider} {query.sku} as {match.sku} (confidence {match.confidence})")
+        return price.copy(update={"requested_sku": query.sku, "match_confidence": match.confidence})
+
+    def _lookup(self, query: PriceQuery) -> Price:
+        if self._overrides is not None:
+            price = self._overrides(query)
+            if price is not None:
+                return price
+
+        chain = self._by_cloud.get(query.provider)
+        if not chain:
+            raise ProviderNotFoundError(f"No pricing provider enabled for '{query.provider}'")
+
+        for provider in chain:
+            try:
+                price = provider.get_price(query)
+            except PriceNotFoundError:
+                continue
+            # Providers without spot support answer with on-demand prices
+            if price.pricing_model != query.pricing_model:
+                continue
+            if price.currency != BASE_CURRENCY:
+                converted = self._in_base_currency(price)
+                if converted is None:
+                    continue
+                price = converted
+            return price
+
+        kind = query.service if query.pricing_model == PRICING_ON_DEMAND else f"{query.service}, {query.pricing_model}"
+        raise PriceNotFoundError(
+            f"No price for {query.provider}/{query.region}/{query.sku} ({kind})"
+        )
+
+    def _in_base_currency(self, price: Price) -> Optional[Price]:
+        """A price converted to USD, None if its currency has no exchange rate"""
+        try:
+            rate = self._exchange_rates(price.currency) if self._exchange_rates is not None else None
+        except ValueError as e:
+            logger.warning(f"Cannot convert {price.source} price of {price.sku}: {e}")
+            return None
+        if not rate:
+            return None
+        return price.copy(update={
+            "price": price.price / rate,
+            "upfront": price.upfront / rate,
+            "currency": BASE_CURRENCY,
+            "tiers": [tier.copy(update={"price": tier.price / rate}) for tier in price.tiers],
+        })
+
+    def usage_discount(self, price: Price, hours_per_month: float) -> Optional[UsageDiscount]:
+        """Ask the provider that supplied a price for its usage-based discount"""
+        provider = self._providers.get(price.source)
+        if provider is None:
+            return None
+        return provider.usage_discount(price, hours_per_month)
+
+    def refresh(self) -> None:
+        """Refresh every enabled provider; failures are reported and re-raised"""
+        for provider in self._providers.values():
+            try:
+                provider.refresh()
+            except Exception as e:
+                report_refresh_failure(provider.name, "*", e)
+                raise
@@ -0,0 +1,203 @@
+"""
+Price catalog refresh scheduling
+
+Each provider's catalog is refreshed on its own schedule: every interval
+after a successful refresh, and after a failure with exponential backoff
+(backoff, 2 x backoff, ... up to max_backoff) until one succeeds. Every
+delay is spread by a random jitter so replicas started together do not
+download catalogs at the same moment.
+
+A catalog whose last successful refresh is older than the max age, or
+that has not been refreshed successfully within the max age of start,
+is degraded: prices are still served from it, but may be outdated.
+
+With locks shared by the replicas, a catalog is refreshed by one replica
+at a time; the others postpone their refresh by the backoff and pick up
+the downloaded catalog from the shared catalog cache.
+"""
+
+import logging
+import random
+import threading
+from datetime import datetime, timedelta, timezone
+from typing import Callable, Dict, List, Optional
+
+from .events import report_refresh_failure
+from .models import CATALOG_DEGRADED, CATALOG_OK, CATALOG_PENDING, CatalogStatus
+from .registry import ProviderRegistry
+
+logger = logging.getLogger(__name__)
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+class _ProviderState:
+    def __init__(self, next_refresh: datetime):
+        self.next_refresh: Optional[datetime] = next_refresh
+        self.refreshing = False
+        self.last_attempt: Optional[datetime] = None
+        self.last_success: Optional[datetime] = None
+        self.last_error: Optional[str] = None
+        self.failures = 0
+
+
+class RefreshScheduler:
+    """Refreshes the catalogs of a registry's providers when they are due"""
+
+    def __init__(
+        self,
+        registry: ProviderRegistry,
+        interval: float = 86400,
+        jitter: float = 0.1,
+        backoff: float = 60,
+        max_backoff: float = 3600,
+        max_age: float = 172800,
+        clock: Callable[[], datetime] = utcnow,
+        rng: Optional[random.Random] = None,
+        locks=None,
+        lock_ttl: float = 300,
+    ):
+        """
+        Initialize scheduler; every catalog is due immediately
+
+        Args:
+            registry: Registry whose providers are refreshed
+            interval: Seconds between successful refreshes (0: refresh once)
+            jitter: Fraction every delay is randomly lengthened or shortened by
+            backoff: Seconds before the first retry of a failed refresh
+            max_backoff: Longest delay between retries
+            max_age: Seconds after which a catalog is degraded (0: never)
+            clock: Current time (aware UTC)
+            rng: Random source of the jitter
+            locks: Locks (src.cache.Locks) held while a catalog is refreshed
+            lock_ttl: Seconds a refresh lock outlives a replica that died holding it
+        """
+        if interval < 0 or backoff <= 0 or max_backoff < backoff or max_age < 0:
+            raise ValueError("Catalog refresh intervals must be positive and max_backoff at least backoff")
+        if not 0 <= jitter < 1:
+            raise ValueError("Catalog refresh jitter must be between 0 and 1")
+        self.registry = registry
+        self.interval = interval
+        self.jitter = jitter
+        self.backoff = backoff
+        self.max_backoff = max_backoff
+        self.max_age = max_age
+        self.clock = clock
+        self.rng = rng or random.Random()
+        self.locks = locks
+        self.lock_ttl = lock_ttl
+        self.started_at = clock()
+        self._lock = threading.Lock()
+        self._states: Dict[str, _ProviderState] = {
+            provider.name: _ProviderState(self.started_at) for provider in registry.providers()
+        }
+
+    def run_due(self) -> List[str]:
+        """Refresh the catalogs that are due, returning the providers refreshed"""
+        now = self.clock()
+        with self._lock:
+            due = [
+                name for name, state in self._states.items()
+                if not state.refreshing and state.next_refresh is not None and state.next_refresh <= now
+            ]
+        return [name for name in due if self._refresh(name)]
+
+    def refresh(self) -> None:
+        """Refresh every catalog now, e.g. on request; refreshes already running are skipped"""
+        for name in list(self._states):
+            self._refresh(name)
+
+    def seconds_until_due(self) -> Optional[float]:
+        """Seconds until the next catalog is due, None when none is scheduled"""
+        with self._lock:
+            pending = [s.next_refresh for s in self._states.values() if s.next_refresh is not None and not s.refreshing]
+        if not pending:
+            return None
+        return max(0.0, (min(pending) - self.clock()).total_seconds())
+
+    def status(self) -> List[CatalogStatus]:
+        """Refresh status of every provider's catalog"""
+        now = self.clock()
+        with self._lock:
+            return [self._status(name, state, now) for name, state in self._states.items()]
+
+    def degraded(self) -> List[str]:
+        """Providers whose catalog is older than the max age"""
+        return [s.provider for s in self.status() if s.state == CATALOG_DEGRADED]
+
+    def _refresh(self, name: str) -> bool:
+        """Refresh a catalog; False if it was already being refreshed here or by another replica"""
+        with self._lock:
+            state = self._states[name]
+            if state.refreshing:
+                return False
+            state.refreshing = True
+            state.last_attempt = self.clock()
+
+        provider = self.registry.get(name)
+        error: Optional[Exception] = None
+        held = True
+        try:
+            if self.locks is None:
+                provider.refresh()
+            else:
+                with self.locks.hold(f"catalog:{name}", self.lock_ttl) as held:
+                    if held:
+                        provider.refresh()
+        except Exception as e:
+            error = e
+
+        if not held:
+            with self._lock:
+                state.refreshing = False
+                state.next_refresh = self.clock() + self._delay(self.backoff)
+            logger.info(f"Price catalog of {name} is being refreshed by another replica")
+            return False
+
+        with self._lock:
+            now = self.clock()
+            state.refreshing = False
+            if error is None:
+                state.last_success, state.last_error, state.failures = now, None, 0
+                state.next_refresh = now + self._delay(self.interval) if self.interval > 0 else None
+            else:
+                state.last_error = str(error)
+                state.failures += 1
+                retry = self._delay(min(self.max_backoff, self.backoff * 2 ** (state.failures - 1)))
+                state.next_refresh = now + retry
+        if error is None:
+            logger.info(f"Price catalog of {name} refreshed")
+        else:
+            logger.warning(
+                f"Price catalog refresh of {name} failed ({state.failures} in a row), "
+                f"retrying in {(state.next_refresh - now).total_seconds():.0f}s: {error}"
+            )
+            report_refresh_failure(name, "*", error)
+        return True
+
+    def _delay(self, seconds: float) -> timedelta:
+        """A delay spread by the jitter"""
+        return timedelta(seconds=seconds * (1 + self.rng.uniform(-self.jitter, self.jitter)))
+
+    def _status(self, name: str, state: _ProviderState, now: datetime) -> CatalogStatus:
+        age = (now - state.last_success).total_seconds() if state.last_success else None
+        unrefreshed = (now - (state.last_success or self.started_at)).total_seconds()
+        if self.max_age and unrefreshed > self.max_age:
+            catalog_state = CATALOG_DEGRADED
+        elif state.last_success is None:
+            catalog_state = CATALOG_PENDING
+        else:
+            catalog_state = CATALOG_OK
+        return CatalogStatus(
+            provider=name,
+            state=catalog_state,
+            refreshing=state.refreshing,
+            last_attempt=state.last_attempt,
+            last_success=state.last_success,
+            last_error=state.last_error,
+            consecutive_failures=state.failures,
+            age_seconds=None if age is None else round(age, 1),
+            next_refresh=state.next_refresh,
+        )
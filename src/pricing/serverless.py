@@ -0,0 +1,68 @@
+"""
+Serverless pricing
+
+Functions and serverless containers (AWS Lambda, Cloud Run, Cloud Run
+functions, Azure Functions on the consumption plan) are billed per
+invocation and for the resources allocated while requests run:
+
+- serverless_requests: platform per million invocations
+- serverless_memory: platform per GB-second of allocated memory, Lambda
+  qualified by architecture
+- serverless_cpu: platform per vCPU-second (Cloud Run, Cloud Run functions)
+
+Platforms are named below and used as the SKU of their prices.
+"""
+
+from typing import Dict, Tuple
+
+PLATFORM_LAMBDA = "lambda"
+PLATFORM_CLOUD_RUN = "cloud_run"
+# Cloud Functions (2nd gen) are deployed and billed as Cloud Run services
+PLATFORM_CLOUD_FUNCTIONS = "cloud_functions"
+PLATFORM_AZURE_FUNCTIONS = "azure_functions"
+
+# Platforms of each provider, the first being the default
+SERVERLESS_PLATFORMS: Dict[str, Tuple[str, ...]] = {
+    "aws": (PLATFORM_LAMBDA,),
+    "gcp": (PLATFORM_CLOUD_FUNCTIONS, PLATFORM_CLOUD_RUN),
+    "azure": (PLATFORM_AZURE_FUNCTIONS,),
+}
+
+ARCH_X86_64 = "x86_64"
+ARCH_ARM64 = "arm64"
+
+# PriceQuery.attributes key of a memory lookup
+ATTR_ARCHITECTURE = "architecture"
+
+_PLATFORM_ALIASES = {
+    "aws_lambda": PLATFORM_LAMBDA,
+    "cloudrun": PLATFORM_CLOUD_RUN,
+    "cloud-run": PLATFORM_CLOUD_RUN,
+    "cloud-functions": PLATFORM_CLOUD_FUNCTIONS,
+    "gcf": PLATFORM_CLOUD_FUNCTIONS,
+    "functions": PLATFORM_AZURE_FUNCTIONS,
+    "azure-functions": PLATFORM_AZURE_FUNCTIONS,
+}
+
+
+def normalize_platform(value: str) -> str:
+    """Normalize a serverless platform name for request validators"""
+    platform = value.strip().lower()
+    platform = _PLATFORM_ALIASES.get(platform, platform)
+    platforms = [name for names in SERVERLESS_PLATFORMS.values() for name in names]
+    if platform not in platforms:
+        raise ValueError(f"platform must be one of: {', '.join(platforms)}")
+    return platform
+
+
+def default_platform(provider: str) -> str:
+    """
+    Functions platform of a provider
+
+    Raises:
+        ValueError: If the provider has no serverless platform
+    """
+    try:
+        return SERVERLESS_PLATFORMS[provider][0]
+    except KeyError:
+        raise ValueError(f"No serverless platform for {provider}") from None
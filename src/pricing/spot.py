@@ -0,0 +1,121 @@
+"""
+Spot price averaging
+
+Spot prices move over time, so estimates use the time-weighted average
+over a trailing window rather than the latest price. Providers whose APIs
+only return the current price accumulate observations in their cached
+catalogs with append_observation().
+"""
+
+import time
+from typing import Dict, List, Optional, Sequence, Tuple
+
+# (unix timestamp, price); a price holds until the next point
+SpotPricePoint = Tuple[float, float]
+
+DEFAULT_SPOT_WINDOW_DAYS = 30
+
+_SECONDS_PER_DAY = 86400.0
+
+
+def time_weighted_average(points: Sequence[SpotPricePoint], start: float, end: float) -> Optional[float]:
+    """
+    Average price over [start, end], weighting each price by how long it held
+
+    The last point before start carries into the window. If the history
+    begins inside the window, the average covers only the observed part.
+
+    Returns:
+        Average price, or None if there are no points before end
+    """
+    ordered = sorted(p for p in points if p[0] <= end)
+    if not ordered:
+        return None
+
+    # Drop points superseded before the window opens
+    first = 0
+    for index, (timestamp, _) in enumerate(ordered):
+        if timestamp <= start:
+            first = index
+    ordered = ordered[first:]
+
+    total = weight = 0.0
+    for index, (timestamp, price) in enumerate(ordered):
+        period_start = max(timestamp, start)
+        period_end = ordered[index + 1][0] if index + 1 < len(ordered) else end
+        duration = max(0.0, min(period_end, end) - period_start)
+        total += price * duration
+        weight += duration
+
+    if weight <= 0:
+        return ordered[-1][1]
+    return total / weight
+
+
+def append_observation(
+    history: List[List[float]],
+    timestamp: float,
+    price: float,
+    window_seconds: float,
+) -> List[List[float]]:
+    """
+    Record an observed price and drop points no longer needed for the window
+
+    Unchanged prices are not recorded again. The newest point older than the
+    window is kept since its price still holds at the start of the window.
+    """
+    points = sorted([list(p) for p in history])
+    if not points or points[-1][1] != price:
+        points.append([timestamp, price])
+
+    cutoff = timestamp - window_seconds
+    older = [p for p in points if p[0] <= cutoff]
+    recent = [p for p in points if p[0] > cutoff]
+    return older[-1:] + recent
+
+
+def window_average(
+    points: Sequence[SpotPricePoint],
+    window_days: float,
+    now: Optional[float] = None,
+) -> Optional[Tuple[float, float]]:
+    """
+    Average price over the trailing window ending now
+
+    Returns:
+        (average price, days of history actually covered), or None without history
+    """
+    end = time.time() if now is None else now
+    start = end - window_days * _SECONDS_PER_DAY
+    average = time_weighted_average(points, start, end)
+    if average is None:
+        return None
+
+    first = min(p[0] for p in points)
+    covered_days = (end - max(first, start)) / _SECONDS_PER_DAY
+    return average, round(max(covered_days, 0.0), 2)
+
+
+def update_history(
+    history: Dict[str, List[List[float]]],
+    entries: Dict[str, Dict[str, float]],
+    window_days: float,
+    now: Optional[float] = None,
+) -> Dict[str, List[List[float]]]:
+    """
+    Add the current price of each spot entry to its history
+
+    Args:
+        history: Previous history per entry key
+        entries: Current spot entries as entry key -> {"price", ...}
+        window_days: Averaging window; older observations are pruned
+
+    Returns:
+        New history per entry key (entries no longer listed are dropped)
+    """
+    timestamp = time.time() if now is None else now
+    window_seconds = window_days * _SECONDS_PER_DAY
+    return {
+        key: append_observation(history.get(key, []), timestamp, float(entry["price"]), window_seconds)
+        for key, entry in entries.items()
+    }
@@ -0,0 +1,195 @@
+"""
+Static pricing provider
+
+Serves compute (on-demand, spot and commitment), block storage, object
+storage, managed database, managed Kubernetes, attached GPU and
+serverless prices from an in-memory PriceCatalog (built-in rate card or JSON file). High
+availability database deployments cost the single-AZ rate times
+HIGH_AVAILABILITY_MULTIPLIER.
+"""
+
+from typing import Dict, List, Optional, Set
+
+from .catalog import PriceCatalog
+from .commitment import COMMITMENT_MODELS, ATTR_TERM, ATTR_PAYMENT_OPTION, split_upfront
+from .database import (
+    ATTR_DEPLOYMENT,
+    ATTR_ENGINE,
+    DEPLOYMENT_MULTI_AZ,
+    ENGINE_MYSQL,
+    HIGH_AVAILABILITY_MULTIPLIER,
+)
+from .models import (
+    Price,
+    PriceQuery,
+    PriceTier,
+    SERVICE_COMPUTE,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_OBJECT_STORAGE,
+    SERVICE_OBJECT_REQUESTS,
+    SERVICE_OBJECT_RETRIEVAL,
+    SERVICE_DATABASE,
+    SERVICE_DATABASE_STORAGE,
+    SERVICE_DATABASE_IOPS,
+    SERVICE_DATABASE_BACKUP,
+    SERVICE_KUBERNETES,
+    SERVICE_KUBERNETES_PODS,
+    SERVICE_ACCELERATOR,
+    SERVICE_SERVERLESS_REQUESTS,
+    SERVICE_SERVERLESS_MEMORY,
+    SERVICE_SERVERLESS_CPU,
+    PRICING_SPOT,
+)
+from .kubernetes import POD_SKU_MEMORY, POD_SKU_VCPU
+from .object_storage import ATTR_OPERATION, OPERATIONS
+from .serverless import ATTR_ARCHITECTURE
+from .provider import PricingProvider, PriceNotFoundError
+from .registry import register_factory
+
+# Database services -> (catalog component, unit)
+_DATABASE_COMPONENTS = {
+    SERVICE_DATABASE: ("instances", "hour"),
+    SERVICE_DATABASE_STORAGE: ("storage", "GB-month"),
+    SERVICE_DATABASE_IOPS: ("iops", "IOPS-month"),
+    SERVICE_DATABASE_BACKUP: ("backup", "GB-month"),
+}
+
+# Autopilot pod request SKUs -> unit
+_POD_UNITS = {POD_SKU_VCPU: "vCPU-hour", POD_SKU_MEMORY: "GB-hour"}
+
+# Serverless services -> (catalog component, unit)
+_SERVERLESS_COMPONENTS = {
+    SERVICE_SERVERLESS_REQUESTS: ("requests", "1M requests"),
+    SERVICE_SERVERLESS_MEMORY: ("memory", "GB-second"),
+    SERVICE_SERVERLESS_CPU: ("cpu", "vCPU-second"),
+}
+
+
+class StaticProvider(PricingProvider):
+    """Pricing provider backed by a static hourly rate card"""
+
+    name = "static"
+
+    def __init__(self, catalog: Optional[PriceCatalog] = None):
+        """
+        Initialize static provider
+
+        Args:
+            catalog: Price catalog to serve. Uses the built-in rate card if not provided.
+        """
+        self.catalog = catalog or PriceCatalog()
+
+    @property
+    def clouds(self) -> List[str]:
+        return sorted(set(self.catalog.prices) | set(self.catalog.storage_prices))
+
+    def instance_types(self, cloud: str) -> Dict[str, Set[str]]:
+        return {region: set(prices) for region, prices in self.catalog.prices.get(cloud, {}).items()}
+
+    def get_price(self, query: PriceQuery) -> Price:
+        upfront = 0.0
+        tiers: List[PriceTier] = []
+        if query.pricing_model in COMMITMENT_MODELS:
+            if query.service != SERVICE_COMPUTE:
+                raise PriceNotFoundError(f"Static catalog has no {query.pricing_model} {query.service} prices")
+            price, upfront = self._commitment_price(query)
+            unit = "hour"
+        elif query.service == SERVICE_ACCELERATOR:
+            price = self.catalog.get_accelerator_price(query.provider, query.region, query.sku, query.pricing_model)
+            unit = "hour"
+        elif query.service == SERVICE_KUBERNETES_PODS:
+            if query.sku not in _POD_UNITS:
+                raise PriceNotFoundError(f"Unknown pod SKU '{query.sku}'")
+            price = self.catalog.get_pod_price(query.provider, query.region, query.sku, query.pricing_model)
+            unit = _POD_UNITS[query.sku]
+        elif query.pricing_model == PRICING_SPOT:
+            if query.service != SERVICE_COMPUTE:
+                raise PriceNotFoundError(f"Static catalog has no spot {query.service} prices")
+            price = self.catalog.get_spot_price(query.provider, query.region, query.sku)
+            unit = "hour"
+        elif query.service == SERVICE_COMPUTE:
+            price = self.catalog.get_hourly_price(query.provider, query.region, query.sku)
+            unit = "hour"
+        elif query.service == SERVICE_BLOCK_STORAGE:
+            entry = self.catalog.get_storage_price(query.provider, query.region, query.sku)
+            price, unit = float(entry["price"]), entry["unit"]
+        elif query.service in _DATABASE_COMPONENTS:
+            price, unit = self._database_price(query)
+        elif query.service == SERVICE_OBJECT_STORAGE:
+            rates = self.catalog.get_object_storage_rate(query.provider, query.region, query.sku, "storage")
+            tiers = _price_tiers(rates)
+            price, unit = tiers[0].price, "GB-month"
+            tiers = tiers if len(tiers) > 1 else []
+        elif query.service == SERVICE_OBJECT_REQUESTS:
+            operation = query.attributes.get(ATTR_OPERATION, "")
+            if operation not in OPERATIONS:
+                raise PriceNotFoundError(f"Unknown object storage operation '{operation}'")
+            price = float(self.catalog.get_object_storage_rate(query.provider, query.region, query.sku, operation))
+            unit = "1K requests"
+        elif query.service == SERVICE_KUBERNETES:
+            price = self.catalog.get_control_plane_price(query.provider, query.region, query.sku)
+            unit = "hour"
+        elif query.service in _SERVERLESS_COMPONENTS:
+            component, unit = _SERVERLESS_COMPONENTS[query.service]
+            price = self.catalog.get_serverless_price(
+                query.provider, query.region, query.sku, component, query.attributes.get(ATTR_ARCHITECTURE, "")
+            )
+        elif query.service == SERVICE_OBJECT_RETRIEVAL:
+            price = float(self.catalog.get_object_storage_rate(query.provider, query.region, query.sku, "retrieval"))
+            unit = "GB"
+        else:
+            raise PriceNotFoundError(
+                f"Static catalog has no {query.service} prices"
+            )
+
+        return Price(
+            provider=query.provider,
+            region=query.region,
+            sku=query.sku,
+            service=query.service,
+            unit=unit,
+            price=price,
+            source=self.name,
+            pricing_model=query.pricing_model,
+            upfront=upfront,
+            tiers=tiers,
+        )
+
+    def _database_price(self, query: PriceQuery) -> tuple:
+        """Single-AZ catalog rate, doubled for high availability, as (price, unit)"""
+        component, unit = _DATABASE_COMPONENTS[query.service]
+        price = self.catalog.get_database_price(
+            query.provider, query.region, component, query.sku, query.attributes.get(ATTR_ENGINE, ENGINE_MYSQL)
+        )
+        if query.service != SERVICE_DATABASE_BACKUP and query.attributes.get(ATTR_DEPLOYMENT) == DEPLOYMENT_MULTI_AZ:
+            price *= HIGH_AVAILABILITY_MULTIPLIER
+        return price, unit
+
+    def _commitment_price(self, query: PriceQuery) -> tuple:
+        """On-demand price less the catalog discount, as (hourly, upfront)"""
+        term = query.attributes.get(ATTR_TERM, "")
+        payment_option = query.attributes.get(ATTR_PAYMENT_OPTION, "")
+        discount = self.catalog.get_commitment_discount(
+            query.provider, query.pricing_model, term, payment_option
+        )
+        on_demand = self.catalog.get_hourly_price(query.provider, query.region, query.sku)
+        return split_upfront(on_demand * (1 - discount), term, payment_option)
+
+
+def _price_tiers(rates: list) -> List[PriceTier]:
+    """[[tier end or None, price], ...] as price tiers"""
+    tiers, begin = [], 0.0
+    for end, price in rates:
+        tiers.append(PriceTier(begin=begin, end=None if end is None else float(end), price=float(price)))
+        begin = float(end) if end is not None else begin
+    return tiers
+
+
+def _build_static_provider(settings) -> StaticProvider:
+    """Create the static provider from application settings"""
+    path = getattr(settings, "price_catalog_path", "")
+    catalog = PriceCatalog.from_file(path) if path else PriceCatalog()
+    return StaticProvider(catalog=catalog)
+
+
+register_factory(StaticProvider.name, _build_static_provider)
@@ -0,0 +1,30 @@
+"""
+Usage Profiles Module
+
+This module keeps named usage profiles of workload patterns, such as
+business hours only (8x5) or bursty batch work (20% duty cycle), that
+resources and databases reference by name to scale their running hours.
+Built-in profiles are available to every tenant; tenants define their
+own through the /profiles API.
+"""
+
+from .models import (
+    BUILTIN_PROFILES,
+    HOURS_PER_WEEK,
+    WEEKS_PER_MONTH,
+    UsageProfile,
+    UsageProfileSpec,
+    validate_profile_name,
+)
+from .resolver import UnknownProfileError, UsageProfiles
+
+__all__ = [
+    "BUILTIN_PROFILES",
+    "HOURS_PER_WEEK",
+    "WEEKS_PER_MONTH",
+    "UsageProfile",
+    "UsageProfileSpec",
+    "validate_profile_name",
+    "UnknownProfileError",
+    "UsageProfiles",
+]
@@ -0,0 +1,129 @@
+"""
+Data models for usage profiles
+"""
+
+import re
+from datetime import datetime
+from typing import Dict, Optional
+
+from pydantic import BaseModel, Field, root_validator
+
+from ..store import UsageProfileRecord
+
+HOURS_PER_WEEK = 24 * 7
+# Average weeks in a month, for the runs of weekly schedules
+WEEKS_PER_MONTH = 365.25 / 12 / 7
+
+_NAME = re.compile(r"^[a-z0-9][a-z0-9._-]{0,62}$")
+
+
+class UsageProfileSpec(BaseModel):
+    """Usage profile as created or replaced through PUT /profiles/{name}"""
+
+    description: str = Field("", max_length=500)
+    hours_per_day: Optional[float] = Field(None, gt=0, le=24, description="Running hours of each running day")
+    days_per_week: Optional[float] = Field(None, gt=0, le=7, description="Running days of each week")
+    duty_cycle: Optional[float] = Field(
+        None, gt=0, le=1, description="Fraction of the time running, instead of a weekly schedule"
+    )
+    runs_per_month: Optional[int] = Field(
+        None,
+        ge=1,
+        description="Runs the running hours are split into, billed by the cloud's granularity; "
+                    "default one per running day of schedules shorter than a day",
+    )
+
+    @root_validator(skip_on_failure=True)
+    def require_schedule_or_duty_cycle(cls, values):
+        schedule = values.get("hours_per_day") is not None, values.get("days_per_week") is not None
+        if values.get("duty_cycle") is not None:
+            if any(schedule):
+                raise ValueError("A profile has either a duty_cycle or a weekly schedule, not both")
+        elif not all(schedule):
+            raise ValueError("A profile needs a duty_cycle, or both hours_per_day and days_per_week")
+        return values
+
+    def fraction(self) -> float:
+        """Fraction of the month running"""
+        if self.duty_cycle is not None:
+            return self.duty_cycle
+        return self.hours_per_day * self.days_per_week / HOURS_PER_WEEK
+
+    def runs(self) -> Optional[int]:
+        """Runs per month of the running hours, None for continuous running"""
+        if self.runs_per_month is not None:
+            return self.runs_per_month
+        if self.hours_per_day is not None and self.hours_per_day < 24:
+            return round(self.days_per_week * WEEKS_PER_MONTH)
+        return None
+
+    def to_record(self, tenant_id: str, name: str) -> UsageProfileRecord:
+        return UsageProfileRecord(
+            tenant_id=tenant_id,
+            name=name,
+            description=self.description,
+            definition=self.dict(exclude={"description"}),
+        )
+
+
+class UsageProfile(UsageProfileSpec):
+    """Usage profile referenced by name from resources and databases"""
+
+    name: str
+    builtin: bool = Field(False, description="Built-in profiles are available to every tenant and cannot be changed")
+    fraction_of_month: float = Field(..., description="Fraction of the month running, the hours are scaled by")
+    monthly_runs: Optional[int] = Field(None, description="Runs per month the hours are split into")
+    created_at: Optional[datetime] = None
+    updated_at: Optional[datetime] = None
+
+    @classmethod
+    def of(cls, name: str, spec: UsageProfileSpec, builtin: bool = False, **times) -> "UsageProfile":
+        return cls(
+            **spec.dict(),
+            name=name,
+            builtin=builtin,
+            fraction_of_month=round(spec.fraction(), 6),
+            monthly_runs=spec.runs(),
+            **times,
+        )
+
+    @classmethod
+    def from_record(cls, record: UsageProfileRecord) -> "UsageProfile":
+        spec = UsageProfileSpec(description=record.description, **record.definition)
+        return cls.of(record.name, spec, created_at=record.created_at, updated_at=record.updated_at)
+
+    def scale(self, hours: float) -> float:
+        """Running hours of a resource running the given hours per month without the profile"""
+        return round(hours * self.fraction_of_month, 6)
+
+
+def validate_profile_name(name: str) -> str:
+    """
+    Normalized profile name, e.g. business-hours
+
+    Raises:
+        ValueError: If the name is not lowercase letters, digits, dots, dashes and underscores
+    """
+    normalized = name.strip().lower()
+    if not _NAME.match(normalized):
+        raise ValueError(
+            f"Invalid usage profile name '{name}': up to 63 lowercase letters, digits, '.', '-' or '_'"
+        )
+    return normalized
+
+
+# Profiles every tenant can reference, e.g. "usage_profile": "business-hours"
+BUILTIN_PROFILES: Dict[str, UsageProfile] = {
+    name: UsageProfile.of(name, spec, builtin=True)
+    for name, spec in {
+        "always-on": UsageProfileSpec(description="Running around the clock", duty_cycle=1.0),
+        "business-hours": UsageProfileSpec(
+            description="Business hours only: 8 hours a day, 5 days a week", hours_per_day=8, days_per_week=5
+        ),
+        "extended-hours": UsageProfileSpec(
+            description="Extended business hours: 12 hours a day, 5 days a week", hours_per_day=12, days_per_week=5
+        ),
+        "bursty-batch": UsageProfileSpec(description="Bursty batch work: 20% duty cycle", duty_cycle=0.2),
+    }.items()
+}
+
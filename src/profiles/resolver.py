@@ -0,0 +1,89 @@
+"""
+Usage profile lookups
+
+Resources and databases reference usage profiles by name. A tenant's
+profiles are read from the store and cached for a short TTL, like its
+discount rules; the built-in profiles are available to every tenant, and
+without a store they are the only ones.
+"""
+
+import threading
+import time
+from typing import Callable, Dict, List, Optional, Tuple
+
+from ..store import Store
+from ..tenancy import current_tenant
+from .models import BUILTIN_PROFILES, UsageProfile
+
+
+class UnknownProfileError(ValueError):
+    """Raised when a resource references a usage profile its tenant does not have"""
+
+    def __init__(self, name: str):
+        super().__init__(f"Unknown usage profile '{name}'")
+        self.name = name
+
+
+class UsageProfiles:
+    """Usage profiles of the current tenant"""
+
+    def __init__(
+        self,
+        store: Optional[Store] = None,
+        ttl: float = 60.0,
+        clock: Callable[[], float] = time.monotonic,
+    ):
+        """
+        Initialize profiles
+
+        Args:
+            store: Store holding the tenants' profiles; only built-in profiles without one
+            ttl: Seconds a tenant's profiles are cached
+            clock: Monotonic time source in seconds
+        """
+        self.store = store
+        self.ttl = ttl
+        self.clock = clock
+        # Tenant -> (loaded at, profiles by name)
+        self._profiles: Dict[str, Tuple[float, Dict[str, UsageProfile]]] = {}
+        self._lock = threading.Lock()
+
+    def profiles(self, tenant_id: Optional[str] = None) -> Dict[str, UsageProfile]:
+        """Profiles of a tenant (default: the current one) by name, built-in ones included"""
+        if self.store is None:
+            return dict(BUILTIN_PROFILES)
+        tenant_id = tenant_id or current_tenant()
+        now = self.clock()
+        with self._lock:
+            cached = self._profiles.get(tenant_id)
+        if cached is not None and now - cached[0] < self.ttl:
+            return cached[1]
+
+        profiles = dict(BUILTIN_PROFILES)
+        for record in self.store.list_usage_profiles(tenant_id):
+            profiles.setdefault(record.name, UsageProfile.from_record(record))
+        with self._lock:
+            self._profiles[tenant_id] = (now, profiles)
+        return profiles
+
+    def get(self, name: str, tenant_id: Optional[str] = None) -> UsageProfile:
+        """
+        Profile of a tenant by name
+
+        Raises:
+            UnknownProfileError: If the tenant has no profile of that name
+        """
+        profile = self.profiles(tenant_id).get(name.strip().lower())
+        if profile is None:
+            raise UnknownProfileError(name)
+        return profile
+
+    def list(self, tenant_id: Optional[str] = None) -> List[UsageProfile]:
+        """Built-in profiles, then the tenant's own, each ordered by name"""
+        profiles = self.profiles(tenant_id).values()
+        return sorted(profiles, key=lambda profile: (not profile.builtin, profile.name))
+
+    def invalidate(self, tenant_id: str) -> None:
+        """Drop a tenant's cached profiles after they changed"""
+        with self._lock:
+            self._profiles.pop(tenant_id, None)
@@ -0,0 +1,15 @@
+"""
+Cloud Pricing Providers
+
+Importing this package registers the factory of every provider
+compiled into the build so they can be enabled via PRICING_PROVIDERS.
+"""
+
+from . import aws  # noqa: F401
+from . import gcp  # noqa: F401
+from . import azure  # noqa: F401
+from . import openstack  # noqa: F401
+from . import ncp  # noqa: F401
+from . import alibaba  # noqa: F401
+
+__all__ = ["aws", "gcp", "azure", "openstack", "ncp", "alibaba"]
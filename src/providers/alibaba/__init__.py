@@ -0,0 +1,19 @@
+"""
+Alibaba Cloud Pricing Provider
+
+ECS instance, disk and OSS prices quoted by the BSS OpenAPI.
+"""
+
+from .client import AlibabaApiError, BssClient, DEFAULT_BSS_ENDPOINT
+from .instance_types import ecs_shape
+from .provider import AlibabaPricingProvider
+from .quotes import quote_for
+
+__all__ = [
+    "AlibabaApiError",
+    "BssClient",
+    "DEFAULT_BSS_ENDPOINT",
+    "AlibabaPricingProvider",
+    "ecs_shape",
+    "quote_for",
+]
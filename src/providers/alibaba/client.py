@@ -0,0 +1,129 @@
+"""
+Alibaba Cloud BSS OpenAPI client
+"""
+
+import base64
+import hashlib
+import hmac
+import logging
+import uuid
+from datetime import datetime, timezone
+from typing import Any, Dict, Optional
+from urllib.parse import quote
+
+import requests
+
+from ...metrics import observe_pricing_api
+
+logger = logging.getLogger(__name__)
+
+# International site endpoint; the China site is https://business.aliyuncs.com
+DEFAULT_BSS_ENDPOINT = "https://business.ap-southeast-1.aliyuncs.com"
+
+API_VERSION = "2017-12-14"
+
+# Error code prefixes of calls that failed, as opposed to configurations the API could not price
+_CALL_ERRORS = ("InvalidAccessKeyId", "SignatureDoesNotMatch", "Forbidden", "Throttling", "ServiceUnavailable",
+                "InternalError")
+
+
+class AlibabaApiError(RuntimeError):
+    """Raised when the BSS API rejects a request"""
+
+    def __init__(self, code: str, message: str):
+        super().__init__(f"{code}: {message}")
+        self.code = code
+
+    @property
+    def rejected(self) -> bool:
+        """Whether the quoted configuration was rejected rather than the call failing"""
+        return not self.code.startswith(_CALL_ERRORS)
+
+
+def _encode(value: str) -> str:
+    return quote(value, safe="-_.~")
+
+
+def rpc_signature(secret: str, method: str, params: Dict[str, str]) -> str:
+    """RPC signature (HMAC-SHA1) over the sorted, percent-encoded parameters"""
+    canonical = "&".join(f"{_encode(k)}={_encode(v)}" for k, v in sorted(params.items()))
+    string_to_sign = f"{method}&{_encode('/')}&{_encode(canonical)}"
+    digest = hmac.new(f"{secret}&".encode("utf-8"), string_to_sign.encode("utf-8"), hashlib.sha1).digest()
+    return base64.b64encode(digest).decode("ascii")
+
+
+class BssClient:
+    """Client for the BSS OpenAPI price quotes (AccessKey authentication)"""
+
+    def __init__(
+        self,
+        access_key_id: str,
+        access_key_secret: str,
+        endpoint: str = DEFAULT_BSS_ENDPOINT,
+        timeout: int = 30,
+    ):
+        """
+        Initialize BSS client
+
+        Args:
+            access_key_id: RAM user AccessKey ID with bss:GetPayAsYouGoPrice
+            access_key_secret: AccessKey secret
+            endpoint: BSS OpenAPI endpoint of the account's site
+            timeout: Per-request timeout in seconds
+        """
+        self.access_key_id = access_key_id
+        self.access_key_secret = access_key_secret
+        self.endpoint = endpoint.rstrip("/")
+        self.timeout = timeout
+        self.session = requests.Session()
+
+    def pay_as_you_go_price(
+        self,
+        region: str,
+        product_code: str,
+        module_code: str,
+        config: str,
+        price_type: str = "Hour",
+        product_type: Optional[str] = None,
+    ) -> Dict[str, Any]:
+        """
+        Quote the list price of one module
+
+        Returns:
+            {"cost": original cost per price_type, "currency": ...}
+
+        Raises:
+            AlibabaApiError: If the API rejects the quote (e.g. unknown instance type)
+        """
+        params = {
+            "Action": "GetPayAsYouGoPrice",
+            "Version": API_VERSION,
+            "Format": "JSON",
+            "AccessKeyId": self.access_key_id,
+            "SignatureMethod": "HMAC-SHA1",
+            "SignatureVersion": "1.0",
+            "SignatureNonce": uuid.uuid4().hex,
+            "Timestamp": datetime.now(timezone.utc).strftime("%Y-%m-%dT%H:%M:%SZ"),
+            "ProductCode": product_code,
+            "SubscriptionType": "PayAsYouGo",
+            "Region": region,
+            "ModuleList.1.ModuleCode": module_code,
+            "ModuleList.1.Config": config,
+            "ModuleList.1.PriceType": price_type,
+        }
+        if product_type:
+            params["ProductType"] = product_type
+        params["Signature"] = rpc_signature(self.access_key_secret, "GET", params)
+
+        with observe_pricing_api("alibaba", "get_pay_as_you_go_price"):
+            response = self.session.get(self.endpoint, params=params, timeout=self.timeout)
+            data = response.json() if response.content else {}
+            if not data.get("Success", response.ok):
+                raise AlibabaApiError(data.get("Code", str(response.status_code)), data.get("Message", ""))
+            response.raise_for_status()
+
+        result = data.get("Data") or {}
+        details = (result.get("ModuleDetails") or {}).get("ModuleDetail") or []
+        cost = sum(float(detail.get("OriginalCost", 0)) for detail in details)
+        logger.debug(f"Alibaba quote {region}/{product_code}/{config}: {cost} {result.get('Currency')}")
+        return {"cost": cost, "currency": result.get("Currency") or "USD"}
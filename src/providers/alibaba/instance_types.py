@@ -0,0 +1,43 @@
+"""
+ECS instance types
+
+Shapes of the general purpose (g), compute (c) and memory (r) ECS
+families, named ecs.<family>.<size>, enumerated as known instance types.
+Burstable (t) and GPU families are priced but not enumerated.
+"""
+
+import re
+from typing import Optional
+
+from ...pricing.instance_types import InstanceShape, register_shape, register_shape_resolver
+
+# Family -> (GB of memory per vCPU, architecture)
+_FAMILIES = {
+    "g6": (4, "x86_64"), "c6": (2, "x86_64"), "r6": (8, "x86_64"),
+    "g7": (4, "x86_64"), "c7": (2, "x86_64"), "r7": (8, "x86_64"),
+    "g8i": (4, "x86_64"), "c8i": (2, "x86_64"), "r8i": (8, "x86_64"),
+    "g8y": (4, "arm64"), "c8y": (2, "arm64"), "r8y": (8, "arm64"),
+}
+
+_SIZES = {"large": 2, "xlarge": 4, "2xlarge": 8, "3xlarge": 12, "4xlarge": 16, "6xlarge": 24, "8xlarge": 32,
+          "16xlarge": 64}
+
+_INSTANCE_TYPE = re.compile(r"^ecs\.([a-z0-9]+)\.([0-9]*x?large)$")
+
+
+def ecs_shape(instance_type: str) -> Optional[InstanceShape]:
+    """Shape of an ecs.<family>.<size> type of a known family, None otherwise"""
+    match = _INSTANCE_TYPE.match(instance_type)
+    if match is None or match.group(1) not in _FAMILIES or match.group(2) not in _SIZES:
+        return None
+    ratio, arch = _FAMILIES[match.group(1)]
+    vcpus = _SIZES[match.group(2)]
+    return InstanceShape(vcpus, vcpus * ratio, 0, arch)
+
+
+register_shape_resolver("alibaba", ecs_shape)
+
+for _family in _FAMILIES:
+    for _size in _SIZES:
+        _name = f"ecs.{_family}.{_size}"
+        register_shape("alibaba", _name, ecs_shape(_name))
@@ -0,0 +1,180 @@
+"""
+Alibaba Cloud pricing provider
+
+Serves ECS instance, disk and OSS pay-as-you-go list prices quoted by the
+BSS OpenAPI. The API prices one configuration per call, so SKUs are quoted
+when first looked up and kept per region in the catalog cache; a refresh
+re-quotes the SKUs whose quote is older than the TTL. Quotes are in the
+account's site currency (USD on the international site, CNY in China),
+which the registry converts to USD. Spot and subscription prices are not
+served.
+"""
+
+import logging
+import threading
+import time
+from typing import Any, Dict, List, Optional
+
+from ...pricing import (
+    Price,
+    PriceQuery,
+    PricingProvider,
+    PriceNotFoundError,
+    register_factory,
+    report_refresh_failure,
+    PRICING_ON_DEMAND,
+)
+from ..cache import CatalogCache, catalog_cache
+from .client import AlibabaApiError, BssClient
+from .quotes import quote_for
+
+logger = logging.getLogger(__name__)
+
+
+def entry_key(service: str, sku: str) -> str:
+    """Lookup key of a quoted price within a region"""
+    return f"{service}|{sku}"
+
+
+class AlibabaPricingProvider(PricingProvider):
+    """Pricing provider backed by BSS OpenAPI price quotes"""
+
+    name = "alibaba"
+
+    def __init__(
+        self,
+        regions: List[str],
+        client: Optional[BssClient] = None,
+        cache: Optional[CatalogCache] = None,
+        quote_ttl: int = 86400,
+    ):
+        """
+        Initialize Alibaba Cloud provider
+
+        Args:
+            regions: Region IDs to price, e.g. ap-southeast-1
+            client: BSS client; only cached quotes are served without one (no AccessKey)
+            cache: Local catalog cache keeping quotes across restarts
+            quote_ttl: Seconds a quote is used before it is requested again
+        """
+        self.regions = regions
+        self.client = client
+        self.cache = cache
+        self.quote_ttl = quote_ttl
+
+        # Region -> entry_key() -> {"price", "unit", "currency", "quoted_at"}; price None for unknown SKUs
+        self._quotes: Dict[str, Dict[str, Dict[str, Any]]] = {}
+        self._lock = threading.Lock()
+
+        self._load_cached()
+
+    @property
+    def clouds(self) -> List[str]:
+        return ["alibaba"]
+
+    def get_price(self, query: PriceQuery) -> Price:
+        if query.region not in self.regions:
+            raise PriceNotFoundError(f"Alibaba Cloud region {query.region} is not priced")
+        if query.pricing_model != PRICING_ON_DEMAND:
+            raise PriceNotFoundError(f"Alibaba Cloud has no {query.pricing_model} prices")
+
+        key = entry_key(query.service, query.sku)
+        entry = self._quotes.get(query.region, {}).get(key)
+        if entry is None or self._expired(entry):
+            try:
+                entry = self._quote(query.region, query.service, query.sku) or entry
+            except Exception as e:
+                # An expired quote is still served while the API is unreachable
+                logger.error(f"Alibaba Cloud quote failed for {query.region}/{query.sku}: {e}")
+        if entry is None or entry["price"] is None:
+            raise PriceNotFoundError(f"No Alibaba Cloud {query.service} price for {query.region}/{query.sku}")
+
+        return Price(
+            provider="alibaba",
+            region=query.region,
+            sku=query.sku,
+            service=query.service,
+            unit=entry["unit"],
+            price=entry["price"],
+            currency=entry["currency"],
+            source=self.name,
+            pricing_model=query.pricing_model,
+        )
+
+    def refresh(self) -> None:
+        """Re-quote every SKU whose quote expired"""
+        if self.client is None:
+            logger.warning("Alibaba Cloud refresh skipped: no AccessKey configured")
+            return
+
+        for region in self.regions:
+            stale = [key for key, entry in self._quotes.get(region, {}).items() if self._expired(entry)]
+            for key in stale:
+                service, sku = key.split("|", 1)
+                try:
+                    self._quote(region, service, sku)
+                except Exception as e:
+                    logger.error(f"Alibaba Cloud quote failed for {region}/{sku}: {e}")
+                    report_refresh_failure(self.name, region, e)
+                    break
+            if stale:
+                logger.info(f"Alibaba Cloud quotes refreshed: {region} ({len(stale)} SKUs)")
+
+    def _expired(self, entry: Dict[str, Any]) -> bool:
+        return time.time() - entry["quoted_at"] > self.quote_ttl
+
+    def _quote(self, region: str, service: str, sku: str) -> Optional[Dict[str, Any]]:
+        """Request and keep the price of a SKU; None if it is not quotable or no client is set"""
+        quote = quote_for(service, sku)
+        if quote is None or self.client is None:
+            return None
+
+        try:
+            result = self.client.pay_as_you_go_price(
+                region, quote.product_code, quote.module_code, quote.config, product_type=quote.product_type,
+            )
+            entry = {"price": result["cost"] * quote.scale, "unit": quote.unit, "currency": result["currency"]}
+        except AlibabaApiError as e:
+            if not e.rejected:
+                raise
+            # Rejected configurations (unknown instance types, disks not sold in the region) are remembered
+            logger.info(f"Alibaba Cloud has no price for {region}/{sku}: {e}")
+            entry = {"price": None, "unit": quote.unit, "currency": ""}
+
+        entry["quoted_at"] = time.time()
+        with self._lock:
+            quotes = dict(self._quotes.get(region, {}))
+            quotes[entry_key(service, sku)] = entry
+            self._quotes[region] = quotes
+        if self.cache is not None:
+            self.cache.save(f"quotes-{region}", {"quotes": quotes})
+        return entry
+
+    def _load_cached(self) -> None:
+        """Use quotes kept before a restart, expired ones until they are re-quoted"""
+        if self.cache is None:
+            return
+        for region in self.regions:
+            document = self.cache.load(f"quotes-{region}", allow_stale=True)
+            if document is not None:
+                self._quotes[region] = document.get("quotes", {})
+
+
+def _build_alibaba_provider(settings) -> AlibabaPricingProvider:
+    """Create the Alibaba Cloud provider from application settings"""
+    client = None
+    if settings.alibaba_access_key_id and settings.alibaba_access_key_secret and not settings.offline:
+        client = BssClient(
+            access_key_id=settings.alibaba_access_key_id,
+            access_key_secret=settings.alibaba_access_key_secret,
+            endpoint=settings.alibaba_bss_endpoint,
+        )
+    return AlibabaPricingProvider(
+        regions=settings.alibaba_pricing_regions,
+        client=client,
+        cache=catalog_cache(settings, "alibaba", settings.alibaba_pricing_cache_ttl),
+        quote_ttl=settings.alibaba_pricing_cache_ttl,
+    )
+
+
+register_factory(AlibabaPricingProvider.name, _build_alibaba_provider)
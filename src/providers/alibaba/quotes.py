@@ -0,0 +1,64 @@
+"""
+BSS price quotes of the priced services
+
+Each service is quoted as one pay-as-you-go module:
+
+- compute: ECS instance type per hour (Linux, I/O optimized)
+- block_storage: data disk category per GB-month, SKUs cloud_essd (PL1),
+  cloud_essd_pl0 to cloud_essd_pl3, cloud_auto, cloud_ssd and
+  cloud_efficiency
+- object_storage: OSS standard (LRS) storage per GB-month, SKU standard
+
+Storage is quoted per hour for QUOTE_GB and spread per GB-month.
+"""
+
+import re
+from typing import NamedTuple, Optional
+
+from ...pricing import SERVICE_BLOCK_STORAGE, SERVICE_COMPUTE, SERVICE_OBJECT_STORAGE
+from ...units import hours_per_month
+
+QUOTE_GB = 100
+
+OSS_STANDARD = "standard"
+
+_ESSD_LEVEL = re.compile(r"^cloud_essd_(pl[0-3])$")
+_DISK_CATEGORIES = ("cloud_essd", "cloud_auto", "cloud_ssd", "cloud_efficiency")
+
+
+class Quote(NamedTuple):
+    """GetPayAsYouGoPrice parameters of a SKU and how to read the cost"""
+
+    product_code: str
+    module_code: str
+    config: str
+    unit: str
+    # Multiplies the quoted hourly cost into a price per unit
+    scale: float
+    product_type: Optional[str] = None
+
+
+def quote_for(service: str, sku: str) -> Optional[Quote]:
+    """Quote of a SKU, None if the service or SKU is not priced"""
+    if service == SERVICE_COMPUTE:
+        if not sku.startswith("ecs."):
+            return None
+        return Quote("ecs", "InstanceType", f"InstanceType:{sku},IoOptimized:IoOptimized,ImageOs:linux", "hour", 1.0)
+
+    if service == SERVICE_BLOCK_STORAGE:
+        level = _ESSD_LEVEL.match(sku)
+        if level is not None:
+            category, performance = "cloud_essd", level.group(1).upper()
+        elif sku in _DISK_CATEGORIES:
+            category, performance = sku, "PL1" if sku == "cloud_essd" else ""
+        else:
+            return None
+        config = f"DataDisk.Category:{category},DataDisk.Size:{QUOTE_GB}"
+        if performance:
+            config += f",DataDisk.PerformanceLevel:{performance}"
+        return Quote("ecs", "DataDisk", config, "GB-month", hours_per_month() / QUOTE_GB)
+
+    if service == SERVICE_OBJECT_STORAGE and sku == OSS_STANDARD:
+        return Quote("oss", "Storage", f"Storage:{QUOTE_GB}", "GB-month", hours_per_month() / QUOTE_GB, "oss")
+
+    return None
@@ -0,0 +1,21 @@
+"""
+AWS Pricing Provider
+
+On-demand EC2, EBS, S3 and RDS prices, EC2 reserved instance and Savings
+Plans rates from the AWS Price List Bulk API, and EC2 spot prices averaged
+from the spot price history.
+"""
+
+from .client import PriceListClient
+from .parser import parse_offer, parse_savings_plan_offer, instance_family
+from .provider import AWSPricingProvider
+from .spot import SpotPriceClient
+
+__all__ = [
+    "PriceListClient",
+    "AWSPricingProvider",
+    "SpotPriceClient",
+    "parse_offer",
+    "parse_savings_plan_offer",
+    "instance_family",
+]
@@ -0,0 +1,212 @@
+"""
+AWS Price List Bulk API client
+
+Downloads per-region offer files instead of the multi-GB global catalog.
+Offer files are read as a stream, keeping only the products and terms the
+parser prices, so a region's EC2 offer is never held in memory whole.
+"""
+
+import logging
+import os
+import tempfile
+from contextlib import contextmanager
+from typing import Any, BinaryIO, Dict, Iterable, Iterator, Optional, Tuple
+
+import ijson
+import requests
+
+from ...metrics import observe_pricing_api
+from .parser import priced_product, priced_savings_plan_product, priced_savings_plan_rate
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_PRICE_LIST_URL = "https://pricing.us-east-1.amazonaws.com"
+
+# Savings Plans rates are published under their own bulk API path
+SAVINGS_PLAN_CODES = ("AWSComputeSavingsPlan",)
+
+# ijson event -> change of nesting depth
+_DEPTH = {"start_map": 1, "start_array": 1, "end_map": -1, "end_array": -1}
+
+
+class PriceListClient:
+    """Client for the public AWS Price List Bulk API (no credentials needed)"""
+
+    def __init__(self, base_url: str = DEFAULT_PRICE_LIST_URL, timeout: int = 300):
+        """
+        Initialize Price List client
+
+        Args:
+            base_url: Bulk API endpoint
+            timeout: Per-request timeout in seconds
+        """
+        self.base_url = base_url.rstrip("/")
+        self.timeout = timeout
+        self.session = requests.Session()
+
+    def get_region_index(self, service_code: str) -> Dict[str, Any]:
+        """Fetch the region index listing per-region offer file URLs for a service"""
+        url = f"{self.base_url}/offers/v1.0/aws/{service_code}/current/region_index.json"
+        with observe_pricing_api("aws", "region_index"):
+            response = self.session.get(url, timeout=self.timeout)
+            response.raise_for_status()
+            return response.json()
+
+    def get_region_offer(
+        self, service_code: str, region: str, instance_families: Optional[Iterable[str]] = None
+    ) -> Dict[str, Any]:
+        """
+        Download the offer file for one service in one region
+
+        The file is streamed to disk, then read product by product; only
+        products parse_offer prices and their on-demand and reserved terms
+        are kept.
+
+        Args:
+            service_code: Price List service code, e.g. AmazonEC2
+            region: Region code
+            instance_families: Only keep these EC2/RDS instance families (all if empty)
+
+        Raises:
+            LookupError: If the service is not offered in the region
+        """
+        index = self.get_region_index(service_code)
+        entry = index.get("regions", {}).get(region)
+        if entry is None:
+            raise LookupError(f"{service_code} has no offer file for region {region}")
+
+        families = set(instance_families or [])
+        with self._download(service_code, f"{self.base_url}{entry['currentVersionUrl']}") as f:
+            return _read_offer(f, region, families)
+
+    def get_savings_plan_offer(
+        self, plan_code: str, region: str, instance_families: Optional[Iterable[str]] = None
+    ) -> Dict[str, Any]:
+        """
+        Download the Savings Plans rate file for one region
+
+        Only plans and rates parse_savings_plan_offer prices are kept.
+
+        Raises:
+            LookupError: If the plan has no rate file for the region
+        """
+        url = f"{self.base_url}/savingsPlan/v1.0/aws/{plan_code}/current/region_index.json"
+        with observe_pricing_api("aws", "region_index"):
+            response = self.session.get(url, timeout=self.timeout)
+            response.raise_for_status()
+
+        for entry in response.json().get("regions", []):
+            if entry.get("regionCode") == region:
+                families = set(instance_families or [])
+                with self._download(plan_code, f"{self.base_url}{entry['versionUrl']}") as f:
+                    return _read_savings_plan_offer(f, families)
+        raise LookupError(f"{plan_code} has no rate file for region {region}")
+
+    @contextmanager
+    def _download(self, service_code: str, url: str) -> Iterator[BinaryIO]:
+        """Stream an offer file to a temporary file, then open it for reading"""
+        logger.info(f"Downloading AWS offer file: {url}")
+
+        fd, tmp_path = tempfile.mkstemp(prefix=f"aws-{service_code}-", suffix=".json")
+        try:
+            with os.fdopen(fd, "wb") as f, observe_pricing_api("aws", "offer_file"):
+                with self.session.get(url, stream=True, timeout=self.timeout) as response:
+                    response.raise_for_status()
+                    for chunk in response.iter_content(chunk_size=1 << 20):
+                        f.write(chunk)
+
+            with open(tmp_path, "rb") as f:
+                yield f
+        finally:
+            os.unlink(tmp_path)
+
+
+def _read_offer(f, region: str, families: set) -> Dict[str, Any]:
+    """Products parse_offer prices and their on-demand and reserved terms, read from an offer file stream"""
+    offer: Dict[str, Any] = {"version": "", "products": {}, "terms": {"OnDemand": {}, "Reserved": {}}}
+    products = offer["products"]
+
+    # Offer files list products before terms, so terms of dropped products are skipped unread
+    def wanted(path: str, sku: Optional[str]) -> bool:
+        return path == "products" or sku in products
+
+    for path, sku, item in _stream_items(f, ("version", "products", "terms.OnDemand", "terms.Reserved"), wanted):
+        if path == "version":
+            offer["version"] = item
+        elif path == "products":
+            if priced_product(item, region, families):
+                products[sku] = item
+        else:
+            offer["terms"][path.split(".", 1)[1]][sku] = item
+    return offer
+
+
+def _read_savings_plan_offer(f, families: set) -> Dict[str, Any]:
+    """Plans parse_savings_plan_offer prices with their EC2 rates, read from a rate file stream"""
+    offer: Dict[str, Any] = {"version": "", "products": [], "terms": {"savingsPlan": []}}
+    skus = set()
+
+    for path, _, item in _stream_items(f, ("version", "products", "terms.savingsPlan")):
+        if path == "version":
+            offer["version"] = item
+        elif path == "products":
+            if priced_savings_plan_product(item):
+                offer["products"].append(item)
+                skus.add(item.get("sku"))
+        elif item.get("sku") in skus:
+            item["rates"] = [rate for rate in item.get("rates", []) if priced_savings_plan_rate(rate, families)]
+            offer["terms"]["savingsPlan"].append(item)
+    return offer
+
+
+def _stream_items(f, paths: Iterable[str], wanted=None) -> Iterator[Tuple[str, Optional[str], Any]]:
+    """
+    Yield (path, key, item) for the members of JSON objects or arrays at the given paths
+
+    Each item is built on its own, so a caller only holds the items it
+    keeps. Array items have no key; a scalar at one of the paths is yielded
+    as an item itself.
+
+    Args:
+        f: Binary file object of the JSON document
+        paths: Dotted paths of the containers, e.g. "terms.OnDemand"
+        wanted: Called with (path, key) before an item is built; items it rejects are skipped
+    """
+    paths = set(paths)
+    keys: Dict[str, str] = {}
+    builder = current = None
+    depth = 0
+
+    for prefix, event, value in ijson.parse(f, use_float=True):
+        if depth:
+            # Inside an item being built or skipped
+            depth += _DEPTH.get(event, 0)
+            if builder is not None:
+                builder.event(event, value)
+                if not depth:
+                    yield current[0], current[1], builder.value
+                    builder = None
+            continue
+
+        if prefix in paths:
+            if event == "map_key":
+                keys[prefix] = value
+            elif event not in _DEPTH:
+                yield prefix, None, value
+            continue
+
+        path = prefix.rpartition(".")[0]
+        if path not in paths:
+            continue
+        # Array items have no map_key event at the container
+        key = keys.get(path)
+        if wanted is not None and not wanted(path, key):
+            depth = _DEPTH.get(event, 0)
+            continue
+        if event in _DEPTH:
+            builder = ijson.ObjectBuilder()
+            builder.event(event, value)
+            current = (path, key)
+            depth = 1
+        else:
+            yield path, key, value
@@ -0,0 +1,323 @@
+"""
+AWS offer file parser
+
+Reduces a Price List offer file to the on-demand prices the estimator
+needs, keyed so that lookups are a single dictionary access. RDS instances
+are keyed by engine, deployment option, license model and edition, as SQL
+Server editions share everything else; RDS storage and provisioned IOPS
+are keyed by deployment option only, as their prices do not depend on the
+engine. EC2 standard
+reserved instance terms and Savings Plans rates are kept under commitment
+qualifiers (e.g. "reserved/1yr/no_upfront").
+"""
+
+from typing import Any, Dict, Iterable, List, Optional
+
+from ...pricing import (
+    SERVICE_COMPUTE,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_OBJECT_STORAGE,
+    SERVICE_DATABASE,
+    SERVICE_DATABASE_STORAGE,
+    SERVICE_DATABASE_IOPS,
+    SERVICE_DATABASE_BACKUP,
+    BACKUP_SKU,
+    PRICING_RESERVED,
+    PRICING_SAVINGS_PLAN,
+    TERMS,
+    commitment_qualifier,
+    split_upfront,
+)
+
+# Default RDS qualifiers when a query does not specify them
+DEFAULT_DB_ENGINE = "MySQL"
+DEFAULT_DB_DEPLOYMENT = "Single-AZ"
+
+# RDS licenseModel values priced -> qualifier part; bring-your-own-license prices are not
+LICENSE_INCLUDED = "license-included"
+_RDS_LICENSE_MODELS = {None: "", "No license required": "", "License included": LICENSE_INCLUDED}
+
+# RDS Database Storage volumeType -> storage type
+_RDS_STORAGE_TYPES = {
+    "General Purpose": "gp2",
+    "General Purpose-GP3": "gp3",
+    "Provisioned IOPS": "io1",
+    "Provisioned IOPS-IO2": "io2",
+    "Magnetic": "standard",
+}
+
+# Price List PurchaseOption values -> payment options
+_PURCHASE_OPTIONS = {
+    "No Upfront": "no_upfront",
+    "Partial Upfront": "partial_upfront",
+    "All Upfront": "all_upfront",
+}
+
+
+def instance_family(instance_type: str) -> str:
+    """Instance family of an EC2 or RDS type (m5.large -> m5, db.r6g.xlarge -> r6g)"""
+    parts = instance_type.split(".")
+    if parts[0] == "db" and len(parts) > 1:
+        return parts[1]
+    return parts[0]
+
+
+def entry_key(service: str, region: str, sku: str, qualifier: str = "") -> str:
+    """Lookup key for a parsed price entry"""
+    return "|".join([service, region, sku, qualifier])
+
+
+def database_qualifier(engine: str, deployment: str, license_model: str = "", edition: str = "") -> str:
+    """Qualifier of an RDS instance price, e.g. PostgreSQL/Multi-AZ or SQL Server/Single-AZ/license-included/Standard"""
+    return "/".join(part for part in (engine, deployment, license_model, edition) if part)
+
+
+def parse_offer(
+    offer: Dict[str, Any],
+    region: str,
+    instance_families: Optional[Iterable[str]] = None,
+) -> Dict[str, Dict[str, Any]]:
+    """
+    Extract on-demand prices from an offer file
+
+    Handles EC2 instances, EBS volumes, S3 storage classes and RDS instances,
+    storage, provisioned IOPS and backup storage, plus standard reserved
+    terms of EC2 instances.
+
+    Args:
+        offer: Parsed offer file JSON
+        region: Region code of the offer file
+        instance_families: Only keep these EC2/RDS families (all if empty)
+
+    Returns:
+        Mapping of entry_key() -> {"price", "unit", "tiers"}; reserved entries
+        carry "upfront" instead of "tiers"
+    """
+    families = set(instance_families or [])
+    on_demand = offer.get("terms", {}).get("OnDemand", {})
+    reserved = offer.get("terms", {}).get("Reserved", {})
+    entries: Dict[str, Dict[str, Any]] = {}
+
+    for sku, product in offer.get("products", {}).items():
+        key = _product_key(product, region, families)
+        if key is None:
+            continue
+
+        tiers = _on_demand_tiers(on_demand.get(sku, {}))
+        if not tiers:
+            continue
+
+        entries[key] = {
+            "price": tiers[0]["price"],
+            "unit": tiers[0]["unit"],
+            "tiers": tiers if len(tiers) > 1 else [],
+        }
+
+        if product.get("productFamily") == "Compute Instance":
+            instance_type = product["attributes"]["instanceType"]
+            for qualifier, entry in _reserved_entries(reserved.get(sku, {})).items():
+                entries[entry_key(SERVICE_COMPUTE, region, instance_type, qualifier)] = entry
+
+    return entries
+
+
+def parse_savings_plan_offer(
+    offer: Dict[str, Any],
+    region: str,
+    instance_families: Optional[Iterable[str]] = None,
+) -> Dict[str, Dict[str, Any]]:
+    """
+    Extract Compute Savings Plans rates for Linux shared-tenancy EC2 instances
+
+    Savings Plans rates are effective hourly rates; the upfront share of
+    partial and all upfront plans is split out as for reserved instances.
+
+    Returns:
+        Mapping of entry_key() -> {"price", "unit", "upfront"}
+    """
+    families = set(instance_families or [])
+    plans = {}
+    for product in offer.get("products", []):
+        option = _savings_plan_option(product)
+        if option is not None:
+            plans[product.get("sku")] = option
+
+    entries: Dict[str, Dict[str, Any]] = {}
+    for plan in offer.get("terms", {}).get("savingsPlan", []):
+        option = plans.get(plan.get("sku"))
+        if option is None:
+            continue
+        term, payment_option = option
+
+        for rate in plan.get("rates", []):
+            instance_type = _rate_instance_type(rate, families)
+            if instance_type is None:
+                continue
+
+            effective = float(rate.get("discountedRate", {}).get("price", 0))
+            hourly, upfront = split_upfront(effective, term, payment_option)
+            qualifier = commitment_qualifier(PRICING_SAVINGS_PLAN, term, payment_option)
+            entries[entry_key(SERVICE_COMPUTE, region, instance_type, qualifier)] = {
+                "price": hourly, "unit": "hour", "upfront": upfront,
+            }
+
+    return entries
+
+
+def priced_product(product: Dict[str, Any], region: str, families: set) -> bool:
+    """Whether parse_offer keeps a product of an offer file"""
+    return _product_key(product, region, families) is not None
+
+
+def priced_savings_plan_product(product: Dict[str, Any]) -> bool:
+    """Whether parse_savings_plan_offer keeps a product of a Savings Plans rate file"""
+    return _savings_plan_option(product) is not None
+
+
+def priced_savings_plan_rate(rate: Dict[str, Any], families: set) -> bool:
+    """Whether parse_savings_plan_offer keeps a rate of a Savings Plans term"""
+    return _rate_instance_type(rate, families) is not None
+
+
+def _savings_plan_option(product: Dict[str, Any]) -> Optional[tuple]:
+    """(term, payment option) of a Savings Plans product, None if not offered by us"""
+    attrs = product.get("attributes", {})
+    term = _lease_term(attrs.get("purchaseTerm", ""))
+    payment_option = _PURCHASE_OPTIONS.get(attrs.get("purchaseOption", ""))
+    if term is None or payment_option is None:
+        return None
+    return term, payment_option
+
+
+def _rate_instance_type(rate: Dict[str, Any], families: set) -> Optional[str]:
+    """Instance type of a Linux shared-tenancy EC2 Savings Plans rate, None for other rates"""
+    usage_type = rate.get("discountedUsageType", "")
+    if (
+        rate.get("discountedServiceCode", "AmazonEC2") != "AmazonEC2"
+        or rate.get("discountedOperation") != "RunInstances"
+        or "BoxUsage:" not in usage_type
+    ):
+        return None
+    instance_type = rate.get("discountedInstanceType") or usage_type.split("BoxUsage:", 1)[1]
+    if families and instance_family(instance_type) not in families:
+        return None
+    return instance_type
+
+
+def _lease_term(value: str) -> Optional[str]:
+    """Normalize "1yr"/"1 yr"/"3yr" lease lengths, None if not offered by us"""
+    term = value.replace(" ", "").lower()
+    return term if term in TERMS else None
+
+
+def _reserved_entries(terms: Dict[str, Any]) -> Dict[str, Dict[str, Any]]:
+    """Standard reserved terms of a product keyed by commitment qualifier"""
+    entries = {}
+    for term in terms.values():
+        attrs = term.get("termAttributes", {})
+        if attrs.get("OfferingClass", "standard") != "standard":
+            continue
+        lease = _lease_term(attrs.get("LeaseContractLength", ""))
+        payment_option = _PURCHASE_OPTIONS.get(attrs.get("PurchaseOption", ""))
+        if lease is None or payment_option is None:
+            continue
+
+        hourly = upfront = 0.0
+        for dimension in term.get("priceDimensions", {}).values():
+            usd = float(dimension.get("pricePerUnit", {}).get("USD", 0) or 0)
+            if dimension.get("unit") == "Quantity":
+                upfront += usd
+            else:
+                hourly += usd
+
+        qualifier = commitment_qualifier(PRICING_RESERVED, lease, payment_option)
+        entries[qualifier] = {"price": hourly, "unit": "hour", "upfront": upfront}
+    return entries
+
+
+def _product_key(product: Dict[str, Any], region: str, families: set) -> Optional[str]:
+    """Build the lookup key for a product, or None if it is not priced by us"""
+    family = product.get("productFamily", "")
+    attrs = product.get("attributes", {})
+
+    if attrs.get("regionCode", region) != region:
+        return None
+
+    if family == "Compute Instance":
+        if (
+            attrs.get("operatingSystem") != "Linux"
+            or attrs.get("tenancy") != "Shared"
+            or attrs.get("preInstalledSw") != "NA"
+            or attrs.get("capacitystatus") != "Used"
+        ):
+            return None
+        instance_type = attrs.get("instanceType", "")
+        if families and instance_family(instance_type) not in families:
+            return None
+        return entry_key(SERVICE_COMPUTE, region, instance_type)
+
+    if family == "Storage" and "volumeApiName" in attrs:
+        return entry_key(SERVICE_BLOCK_STORAGE, region, attrs["volumeApiName"])
+
+    if family == "Storage" and attrs.get("servicecode") == "AmazonS3":
+        return entry_key(SERVICE_OBJECT_STORAGE, region, attrs.get("volumeType", ""))
+
+    if family == "Database Instance":
+        instance_type = attrs.get("instanceType", "")
+        if families and instance_family(instance_type) not in families:
+            return None
+        if attrs.get("licenseModel") not in _RDS_LICENSE_MODELS:
+            return None
+        qualifier = database_qualifier(
+            attrs.get("databaseEngine", ""),
+            attrs.get("deploymentOption", ""),
+            _RDS_LICENSE_MODELS[attrs.get("licenseModel")],
+            attrs.get("databaseEdition", ""),
+        )
+        return entry_key(SERVICE_DATABASE, region, instance_type, qualifier)
+
+    if family == "Database Storage":
+        storage_type = _RDS_STORAGE_TYPES.get(attrs.get("volumeType", ""))
+        if storage_type is None:
+            return None
+        return entry_key(SERVICE_DATABASE_STORAGE, region, storage_type, attrs.get("deploymentOption", ""))
+
+    if family == "Provisioned IOPS":
+        usage_type = attrs.get("usagetype", "")
+        storage_type = "gp3" if "GP3" in usage_type else "io2" if "IO2" in usage_type else "io1"
+        return entry_key(SERVICE_DATABASE_IOPS, region, storage_type, attrs.get("deploymentOption", ""))
+
+    if family == "Storage Snapshot" and attrs.get("usagetype", "").endswith("ChargedBackupUsage"):
+        return entry_key(SERVICE_DATABASE_BACKUP, region, BACKUP_SKU)
+
+    return None
+
+
+def _on_demand_tiers(terms: Dict[str, Any]) -> List[Dict[str, Any]]:
+    """Flatten the USD price dimensions of an on-demand term, ordered by tier start"""
+    tiers = []
+    for term in terms.values():
+        for dimension in term.get("priceDimensions", {}).values():
+            usd = dimension.get("pricePerUnit", {}).get("USD")
+            if usd is None:
+                continue
+            end = dimension.get("endRange", "Inf")
+            tiers.append({
+                "begin": float(dimension.get("beginRange", 0)),
+                "end": None if end == "Inf" else float(end),
+                "price": float(usd),
+                "unit": _normalize_unit(dimension.get("unit", "")),
+            })
+    tiers.sort(key=lambda t: t["begin"])
+    return tiers
+
+
+def _normalize_unit(unit: str) -> str:
+    """Map AWS unit names to the units used in Price"""
+    return {
+        "Hrs": "hour",
+        "GB-Mo": "GB-month",
+        "GB-month": "GB-month",
+        "IOPS-Mo": "IOPS-month",
+        "Requests": "request",
+    }.get(unit, unit)
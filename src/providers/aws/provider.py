@@ -0,0 +1,320 @@
+"""
+AWS pricing provider
+
+Serves EC2, EBS, S3 and RDS on-demand prices parsed from the
+AWS Price List Bulk API, cached locally per service and region.
+RDS lookups take provider-neutral engine and deployment names (postgres,
+multi_az) as well as Price List names (PostgreSQL, Multi-AZ); SQL Server
+is priced as Standard Edition with the license included.
+EC2 reserved instance and Compute Savings Plans rates come from the
+same API; spot prices are averaged from the EC2 spot price history of
+each region, read when catalogs are refreshed so lookups never call EC2.
+"""
+
+import logging
+import threading
+import time
+from typing import Any, Dict, List, Optional, Set
+
+from ...pricing import (
+    Price,
+    PriceQuery,
+    PriceTier,
+    PricingProvider,
+    PriceNotFoundError,
+    instance_types_of_keys,
+    register_factory,
+    report_refresh_failure,
+    window_average,
+    SERVICE_COMPUTE,
+    SERVICE_DATABASE,
+    SERVICE_DATABASE_STORAGE,
+    SERVICE_DATABASE_IOPS,
+    ATTR_ENGINE,
+    ATTR_DEPLOYMENT,
+    DEPLOYMENT_SINGLE_AZ,
+    DEPLOYMENT_MULTI_AZ,
+    PRICING_SPOT,
+    COMMITMENT_MODELS,
+    ATTR_TERM,
+    ATTR_PAYMENT_OPTION,
+    commitment_qualifier,
+    DEFAULT_SPOT_WINDOW_DAYS,
+)
+from ..cache import CatalogCache, catalog_cache
+from .client import PriceListClient, SAVINGS_PLAN_CODES
+from .spot import SpotPriceClient
+from .parser import (
+    parse_offer,
+    parse_savings_plan_offer,
+    entry_key,
+    database_qualifier,
+    DEFAULT_DB_ENGINE,
+    DEFAULT_DB_DEPLOYMENT,
+    LICENSE_INCLUDED,
+)
+
+logger = logging.getLogger(__name__)
+
+# Provider-neutral engine and deployment names -> Price List values
+_RDS_ENGINES = {
+    "mysql": "MySQL",
+    "postgres": "PostgreSQL",
+    "mariadb": "MariaDB",
+    "aurora-mysql": "Aurora MySQL",
+    "aurora-postgresql": "Aurora PostgreSQL",
+    "sqlserver": "SQL Server",
+}
+# Engines priced with a license included -> (license model, edition) qualifier parts
+_RDS_EDITIONS = {"sqlserver": (LICENSE_INCLUDED, "Standard"), "SQL Server": (LICENSE_INCLUDED, "Standard")}
+_RDS_DEPLOYMENTS = {DEPLOYMENT_SINGLE_AZ: "Single-AZ", DEPLOYMENT_MULTI_AZ: "Multi-AZ"}
+
+# Offer codes covering the supported services (EBS prices live in AmazonEC2)
+DEFAULT_SERVICE_CODES = ["AmazonEC2", "AmazonS3", "AmazonRDS", "AWSComputeSavingsPlan"]
+
+
+class AWSPricingProvider(PricingProvider):
+    """Pricing provider backed by the AWS Price List Bulk API"""
+
+    name = "aws"
+
+    def __init__(
+        self,
+        regions: List[str],
+        client: Optional[PriceListClient] = None,
+        cache: Optional[CatalogCache] = None,
+        service_codes: Optional[List[str]] = None,
+        instance_families: Optional[List[str]] = None,
+        spot_client: Optional[SpotPriceClient] = None,
+        spot_window_days: float = DEFAULT_SPOT_WINDOW_DAYS,
+        spot_cache_ttl: int = 3600,
+    ):
+        """
+        Initialize AWS provider
+
+        Args:
+            regions: Region codes to download prices for
+            client: Price List API client
+            cache: Local catalog cache. Catalogs are not persisted if not provided.
+            service_codes: AWS offer codes to load
+            instance_families: Only keep these EC2/RDS instance families (all if empty)
+            spot_client: EC2 spot price history client. Spot prices are unavailable if not provided.
+            spot_window_days: Days of spot price history averaged into spot prices
+            spot_cache_ttl: Seconds a region's averaged spot prices are reused before a refresh reads them again
+        """
+        self.regions = regions
+        self.client = client or PriceListClient()
+        self.cache = cache
+        self.service_codes = service_codes or DEFAULT_SERVICE_CODES
+        self.instance_families = instance_families or []
+        self.spot_client = spot_client
+        self.spot_window_days = spot_window_days
+        self.spot_cache_ttl = spot_cache_ttl
+
+        self._entries: Dict[str, Dict[str, Any]] = {}
+        # "region|instance type" -> (price, days averaged)
+        self._spot_prices: Dict[str, tuple] = {}
+        # Region -> time its spot history was last read
+        self._spot_fetched: Dict[str, float] = {}
+        self._versions: Dict[str, str] = {}
+        self._lock = threading.Lock()
+
+        self._load_cached()
+
+    @property
+    def clouds(self) -> List[str]:
+        return ["aws"]
+
+    @property
+    def loaded(self) -> bool:
+        """Whether any price data is available"""
+        return bool(self._entries)
+
+    def instance_types(self, cloud: str) -> Dict[str, Set[str]]:
+        return instance_types_of_keys(list(self._entries))
+
+    def get_price(self, query: PriceQuery) -> Price:
+        if query.pricing_model == PRICING_SPOT:
+            return self._spot_price(query)
+
+        if not self._entries:
+            raise PriceNotFoundError("AWS price catalog not loaded yet")
+
+        qualifier = ""
+        if query.pricing_model in COMMITMENT_MODELS:
+            if query.service != SERVICE_COMPUTE:
+                raise PriceNotFoundError(f"AWS {query.pricing_model} prices are only loaded for EC2")
+            qualifier = commitment_qualifier(
+                query.pricing_model,
+                query.attributes.get(ATTR_TERM, ""),
+                query.attributes.get(ATTR_PAYMENT_OPTION, ""),
+            )
+        elif query.service in (SERVICE_DATABASE, SERVICE_DATABASE_STORAGE, SERVICE_DATABASE_IOPS):
+            deployment = query.attributes.get(ATTR_DEPLOYMENT, DEFAULT_DB_DEPLOYMENT)
+            qualifier = _RDS_DEPLOYMENTS.get(deployment, deployment)
+            if query.service == SERVICE_DATABASE:
+                engine = query.attributes.get(ATTR_ENGINE, DEFAULT_DB_ENGINE)
+                license_model, edition = _RDS_EDITIONS.get(engine, ("", ""))
+                qualifier = database_qualifier(_RDS_ENGINES.get(engine, engine), qualifier, license_model, edition)
+
+        entry = self._entries.get(entry_key(query.service, query.region, query.sku, qualifier))
+        if entry is None:
+            kind = qualifier if query.pricing_model in COMMITMENT_MODELS else query.service
+            raise PriceNotFoundError(
+                f"No AWS {kind} price for {query.region}/{query.sku}"
+            )
+
+        return Price(
+            provider="aws",
+            region=query.region,
+            sku=query.sku,
+            service=query.service,
+            unit=entry["unit"],
+            price=entry["price"],
+            source=self.name,
+            pricing_model=query.pricing_model,
+            upfront=entry.get("upfront", 0.0),
+            tiers=[PriceTier(begin=t["begin"], end=t["end"], price=t["price"]) for t in entry.get("tiers", [])],
+        )
+
+    def _spot_price(self, query: PriceQuery) -> Price:
+        """Spot price averaged over the window per zone, then across zones, as of the last refresh"""
+        if query.service != SERVICE_COMPUTE:
+            raise PriceNotFoundError(f"AWS has no spot {query.service} prices")
+        if self.spot_client is None:
+            raise PriceNotFoundError("AWS spot prices are not enabled")
+        if query.region not in self._spot_fetched:
+            raise PriceNotFoundError(f"AWS spot prices of {query.region} not loaded yet")
+
+        averaged = self._spot_prices.get(f"{query.region}|{query.sku}")
+        if averaged is None:
+            raise PriceNotFoundError(f"No AWS spot price for {query.region}/{query.sku}")
+        price, averaged_over_days = averaged
+
+        return Price(
+            provider="aws",
+            region=query.region,
+            sku=query.sku,
+            service=SERVICE_COMPUTE,
+            unit="hour",
+            price=price,
+            source=self.name,
+            pricing_model=PRICING_SPOT,
+            averaged_over_days=averaged_over_days,
+        )
+
+    def _refresh_spot_prices(self) -> None:
+        """Average the spot history of regions last read more than spot_cache_ttl seconds ago"""
+        if self.spot_client is None:
+            return
+        for region in self.regions:
+            fetched = self._spot_fetched.get(region)
+            if fetched is not None and time.time() - fetched < self.spot_cache_ttl:
+                continue
+            try:
+                history = self.spot_client.region_history(region, self.spot_window_days, self.instance_families)
+            except Exception as e:
+                # Averages of an earlier refresh are kept
+                logger.warning(f"AWS spot price history unavailable for {region}: {e}")
+                continue
+
+            averages = {}
+            for instance_type, zones in history.items():
+                averaged = self._average_zones(zones)
+                if averaged is not None:
+                    averages[f"{region}|{instance_type}"] = averaged
+            with self._lock:
+                prices = {key: value for key, value in self._spot_prices.items() if not key.startswith(f"{region}|")}
+                prices.update(averages)
+                self._spot_prices = prices
+                self._spot_fetched[region] = time.time()
+            logger.info(f"AWS spot prices refreshed: {region} ({len(averages)} instance types)")
+
+    def _average_zones(self, zones: Dict[str, List[List[float]]]) -> Optional[tuple]:
+        """(price, days averaged) of per-zone histories, or None if there is no price in the window"""
+        averages = [
+            averaged for averaged in
+            (window_average(points, self.spot_window_days) for points in zones.values() if points)
+            if averaged is not None
+        ]
+        if not averages:
+            return None
+
+        price = sum(a[0] for a in averages) / len(averages)
+        return price, max(a[1] for a in averages)
+
+    def refresh(self) -> None:
+        """Download offer files whose cache entry is missing or stale, and read the spot price histories"""
+        for service_code in self.service_codes:
+            for region in self.regions:
+                key = f"{service_code}-{region}"
+                cached = self.cache.load(key) if self.cache is not None else None
+                if cached is not None:
+                    # Fresh, possibly downloaded by another replica sharing the cache
+                    self._merge(key, cached)
+                    continue
+
+                savings_plan = service_code in SAVINGS_PLAN_CODES
+                try:
+                    if savings_plan:
+                        offer = self.client.get_savings_plan_offer(service_code, region, self.instance_families)
+                    else:
+                        offer = self.client.get_region_offer(service_code, region, self.instance_families)
+                except LookupError as e:
+                    logger.info(f"Skipping AWS offer: {e}")
+                    continue
+                except Exception as e:
+                    logger.error(f"AWS offer download failed for {key}: {e}")
+                    report_refresh_failure(self.name, key, e)
+                    continue
+
+                parse = parse_savings_plan_offer if savings_plan else parse_offer
+                document = {
+                    "version": offer.get("version", ""),
+                    "entries": parse(offer, region, self.instance_families),
+                }
+                if self.cache is not None:
+                    self.cache.save(key, document)
+                self._merge(key, document)
+
+                logger.info(
+                    f"AWS catalog refreshed: {key} "
+                    f"({len(document['entries'])} prices, version {document['version']})"
+                )
+
+        self._refresh_spot_prices()
+
+    def _load_cached(self) -> None:
+        """Populate prices from any cached catalogs, even stale ones"""
+        if self.cache is None:
+            return
+        for service_code in self.service_codes:
+            for region in self.regions:
+                key = f"{service_code}-{region}"
+                document = self.cache.load(key, allow_stale=True)
+                if document is not None:
+                    self._merge(key, document)
+
+    def _merge(self, key: str, document: Dict[str, Any]) -> None:
+        with self._lock:
+            entries = dict(self._entries)
+            entries.update(document.get("entries", {}))
+            self._entries = entries
+            self._versions[key] = document.get("version", "")
+
+
+def _build_aws_provider(settings) -> AWSPricingProvider:
+    """Create the AWS provider from application settings"""
+    return AWSPricingProvider(
+        regions=settings.aws_pricing_regions,
+        client=PriceListClient(base_url=settings.aws_price_list_url),
+        cache=catalog_cache(settings, "aws", settings.pricing_cache_ttl),
+        service_codes=settings.aws_pricing_services,
+        instance_families=settings.aws_instance_families,
+        spot_client=None if settings.offline else SpotPriceClient(),
+        spot_window_days=settings.spot_price_window_days,
+        spot_cache_ttl=settings.spot_price_cache_ttl,
+    )
+
+
+register_factory(AWSPricingProvider.name, _build_aws_provider)
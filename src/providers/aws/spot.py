@@ -0,0 +1,86 @@
+"""
+EC2 Spot price history client
+
+Spot prices are not part of the Price List API; they come from the EC2
+DescribeSpotPriceHistory call, which needs AWS credentials. A region's
+history is read in one paginated call when catalogs are refreshed.
+"""
+
+import logging
+from datetime import datetime, timedelta, timezone
+from typing import Any, Dict, List, Optional
+
+from ...metrics import observe_pricing_api
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_PRODUCT_DESCRIPTION = "Linux/UNIX"
+
+
+class SpotPriceClient:
+    """Client for EC2 spot price history (uses the default boto3 credential chain)"""
+
+    def __init__(self, session: Optional[Any] = None, product_description: str = DEFAULT_PRODUCT_DESCRIPTION):
+        """
+        Initialize spot price client
+
+        Args:
+            session: boto3 Session. A default session is created on first use if not provided.
+            product_description: Operating system the prices are for
+        """
+        self._session = session
+        self.product_description = product_description
+        self._clients: Dict[str, Any] = {}
+
+    def _client(self, region: str):
+        client = self._clients.get(region)
+        if client is None:
+            if self._session is None:
+                import boto3
+                self._session = boto3.session.Session()
+            client = self._session.client("ec2", region_name=region)
+            self._clients[region] = client
+        return client
+
+    def region_history(
+        self, region: str, window_days: float, instance_families: Optional[List[str]] = None
+    ) -> Dict[str, Dict[str, List[List[float]]]]:
+        """
+        Spot price changes of a region's instance types per availability zone
+
+        Includes the price in effect at the start of the window, so each
+        zone's history can be averaged over the whole window.
+
+        Args:
+            region: Region code
+            window_days: Days of history
+            instance_families: Only these instance families, e.g. m5 (all if empty)
+
+        Returns:
+            Mapping of instance type -> availability zone -> [[unix timestamp, USD/hour], ...]
+        """
+        end = datetime.now(timezone.utc)
+        start = end - timedelta(days=window_days)
+        paginator = self._client(region).get_paginator("describe_spot_price_history")
+        params: Dict[str, Any] = {
+            "ProductDescriptions": [self.product_description], "StartTime": start, "EndTime": end,
+        }
+        if instance_families:
+            params["Filters"] = [{"Name": "instance-type", "Values": [f"{family}.*" for family in instance_families]}]
+
+        history: Dict[str, Dict[str, List[List[float]]]] = {}
+        with observe_pricing_api("aws", "spot_price_history"):
+            for page in paginator.paginate(**params):
+                for record in page.get("SpotPriceHistory", []):
+                    zones = history.setdefault(record["InstanceType"], {})
+                    timestamp = record["Timestamp"].timestamp()
+                    zones.setdefault(record.get("AvailabilityZone", ""), []).append(
+                        [timestamp, float(record["SpotPrice"])]
+                    )
+
+        logger.debug(
+            f"AWS spot history {region}: "
+            f"{sum(len(points) for zones in history.values() for points in zones.values())} points "
+            f"of {len(history)} instance types"
+        )
+        return history
@@ -0,0 +1,15 @@
+"""
+Azure Pricing Provider
+
+VM, managed disk and blob storage prices from the Azure Retail Prices API.
+"""
+
+from .client import RetailPricesClient
+from .parser import parse_items
+from .provider import AzurePricingProvider
+
+__all__ = [
+    "RetailPricesClient",
+    "AzurePricingProvider",
+    "parse_items",
+]
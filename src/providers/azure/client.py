@@ -0,0 +1,73 @@
+"""
+Azure Retail Prices API client
+"""
+
+import logging
+from typing import Any, Dict, List, Optional
+
+import requests
+
+from ...metrics import observe_pricing_api
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_RETAIL_PRICES_URL = "https://prices.azure.com/api/retail/prices"
+
+
+class RetailPricesClient:
+    """Client for the public Azure Retail Prices API (no credentials needed)"""
+
+    def __init__(self, base_url: str = DEFAULT_RETAIL_PRICES_URL, timeout: int = 60, max_pages: int = 1000):
+        """
+        Initialize Retail Prices client
+
+        Args:
+            base_url: Retail Prices API endpoint
+            timeout: Per-request timeout in seconds
+            max_pages: Safety limit on followed NextPageLink pages per query
+        """
+        self.base_url = base_url
+        self.timeout = timeout
+        self.max_pages = max_pages
+        self.session = requests.Session()
+
+    def query(self, odata_filter: str, currency: str = "USD") -> List[Dict[str, Any]]:
+        """
+        Fetch all price items matching an OData filter
+
+        Follows NextPageLink until the result set is exhausted.
+        """
+        url: Optional[str] = self.base_url
+        params: Optional[Dict[str, str]] = {"$filter": odata_filter, "currencyCode": currency}
+        items: List[Dict[str, Any]] = []
+        pages = 0
+
+        while url and pages < self.max_pages:
+            with observe_pricing_api("azure", "retail_prices", {"pricing.page": pages + 1}) as current:
+                response = self.session.get(url, params=params, timeout=self.timeout)
+                response.raise_for_status()
+                data = response.json()
+                if current is not None:
+                    current.set_attribute("pricing.items", len(data.get("Items", [])))
+
+            items.extend(data.get("Items", []))
+            # NextPageLink already carries the filter and skip token
+            url = data.get("NextPageLink")
+            params = None
+            pages += 1
+
+        if url:
+            logger.warning(f"Azure price query truncated after {pages} pages: {odata_filter}")
+
+        logger.info(f"Fetched {len(items)} Azure price items for: {odata_filter}")
+        return items
+
+    def region_prices(
+        self, region: str, service_name: str, price_type: str = "Consumption"
+    ) -> List[Dict[str, Any]]:
+        """Fetch prices of one price type (Consumption or Reservation) for one service in one armRegionName"""
+        odata_filter = (
+            f"armRegionName eq '{region}' and serviceName eq '{service_name}' "
+            f"and priceType eq '{price_type}'"
+        )
+        return self.query(odata_filter)
@@ -0,0 +1,105 @@
+"""
+Azure Retail Prices item parser
+
+Reduces Retail Prices items to pay-as-you-go Linux VM hourly prices,
+managed disk monthly prices and blob storage GB-month prices. Linux Spot
+VM prices are kept under the "spot" qualifier, VM reservations (total price
+per instance for the term) under "reserved/1yr" and "reserved/3yr".
+"""
+
+from typing import Any, Dict, Iterable, Optional
+
+from ...pricing import SERVICE_COMPUTE, SERVICE_BLOCK_STORAGE, SERVICE_OBJECT_STORAGE, PRICING_RESERVED
+
+# Retail Prices service names for the supported services
+VIRTUAL_MACHINES = "Virtual Machines"
+STORAGE = "Storage"
+
+SPOT_QUALIFIER = "spot"
+
+# Reservation terms as reported in reservationTerm
+_RESERVATION_TERMS = {"1 Year": "1yr", "3 Years": "3yr"}
+
+
+def reservation_qualifier(term: str) -> str:
+    """Entry key qualifier of a VM reservation price"""
+    return f"{PRICING_RESERVED}/{term}"
+
+
+def entry_key(service: str, region: str, sku: str, qualifier: str = "") -> str:
+    """Lookup key for a parsed price entry"""
+    parts = [service, region, sku]
+    if qualifier:
+        parts.append(qualifier)
+    return "|".join(parts)
+
+
+def parse_items(items: Iterable[Dict[str, Any]]) -> Dict[str, Dict[str, Any]]:
+    """
+    Extract VM, managed disk and blob storage prices
+
+    Returns:
+        Mapping of entry_key() -> {"price", "unit"}; reservations have unit "term"
+    """
+    entries: Dict[str, Dict[str, Any]] = {}
+
+    for item in items:
+        if item.get("type", "Consumption") == "Reservation":
+            reservation = _reservation(item)
+            if reservation is not None:
+                key, entry = reservation
+                entries[key] = entry
+            continue
+        if item.get("type", "Consumption") != "Consumption":
+            continue
+        # Only the base price tier; higher tiers are volume discounts
+        if item.get("tierMinimumUnits", 0) not in (0, 0.0):
+            continue
+
+        parsed = _classify(item)
+        if parsed is None:
+            continue
+
+        service, sku, unit, qualifier = parsed
+        key = entry_key(service, item.get("armRegionName", ""), sku, qualifier)
+        entries[key] = {"price": float(item.get("retailPrice", 0)), "unit": unit}
+
+    return entries
+
+
+def _reservation(item: Dict[str, Any]) -> Optional[tuple]:
+    """(key, entry) of a Linux VM reservation item, or None"""
+    term = _RESERVATION_TERMS.get(item.get("reservationTerm", ""))
+    arm_sku = item.get("armSkuName")
+    if item.get("serviceName") != VIRTUAL_MACHINES or term is None or not arm_sku:
+        return None
+    key = entry_key(SERVICE_COMPUTE, item.get("armRegionName", ""), arm_sku, reservation_qualifier(term))
+    return key, {"price": float(item.get("retailPrice", 0)), "unit": "term"}
+
+
+def _classify(item: Dict[str, Any]) -> Optional[tuple]:
+    """Map an item to (service, sku, unit, qualifier), or None if it is not priced by us"""
+    service_name = item.get("serviceName")
+    product = item.get("productName", "")
+    sku_name = item.get("skuName", "")
+    meter = item.get("meterName", "")
+
+    if service_name == VIRTUAL_MACHINES:
+        if "Windows" in product or "Low Priority" in sku_name:
+            return None
+        arm_sku = item.get("armSkuName")
+        if not arm_sku or item.get("unitOfMeasure") != "1 Hour":
+            return None
+        qualifier = SPOT_QUALIFIER if "Spot" in sku_name else ""
+        return SERVICE_COMPUTE, arm_sku, "hour", qualifier
+
+    if service_name == STORAGE:
+        # Managed disks are priced per disk per month (e.g. "P10 LRS Disk")
+        if "Managed Disks" in product and meter.endswith(" Disk"):
+            return SERVICE_BLOCK_STORAGE, sku_name, "month", ""
+        # Blob storage capacity (e.g. "Hot LRS Data Stored")
+        is_blob = "Blob Storage" in product or "Block Blob" in product
+        if is_blob and meter.endswith("Data Stored") and "GB/Month" in item.get("unitOfMeasure", ""):
+            return SERVICE_OBJECT_STORAGE, sku_name, "GB-month", ""
+
+    return None
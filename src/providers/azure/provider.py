@@ -0,0 +1,235 @@
+"""
+Azure pricing provider
+
+Serves VM, managed disk and blob storage pay-as-you-go prices from the
+Azure Retail Prices API, cached per service and region with a TTL.
+Spot VM prices are averaged over the rates observed at each refresh;
+VM reservations are served as reserved prices.
+"""
+
+import logging
+import threading
+from typing import Any, Dict, List, Optional, Set
+
+from ...pricing import (
+    Price,
+    PriceQuery,
+    PricingProvider,
+    PriceNotFoundError,
+    instance_types_of_keys,
+    register_factory,
+    report_refresh_failure,
+    update_history,
+    window_average,
+    SERVICE_COMPUTE,
+    PRICING_SPOT,
+    PRICING_RESERVED,
+    ATTR_TERM,
+    ATTR_PAYMENT_OPTION,
+    NO_UPFRONT,
+    ALL_UPFRONT,
+    term_hours,
+    DEFAULT_SPOT_WINDOW_DAYS,
+)
+from ..cache import CatalogCache, catalog_cache
+from .client import RetailPricesClient
+from .parser import (
+    parse_items,
+    entry_key,
+    reservation_qualifier,
+    VIRTUAL_MACHINES,
+    STORAGE,
+    SPOT_QUALIFIER,
+)
+
+logger = logging.getLogger(__name__)
+
+
+class AzurePricingProvider(PricingProvider):
+    """Pricing provider backed by the Azure Retail Prices API"""
+
+    name = "azure"
+
+    def __init__(
+        self,
+        regions: List[str],
+        client: Optional[RetailPricesClient] = None,
+        cache: Optional[CatalogCache] = None,
+        service_names: Optional[List[str]] = None,
+        spot_window_days: float = DEFAULT_SPOT_WINDOW_DAYS,
+    ):
+        """
+        Initialize Azure provider
+
+        Args:
+            regions: armRegionName values to load prices for
+            client: Retail Prices API client
+            cache: Local catalog cache; its TTL decides when prices are re-fetched
+            service_names: Retail Prices service names to load
+            spot_window_days: Days of observed spot prices averaged into spot prices
+        """
+        self.regions = regions
+        self.client = client or RetailPricesClient()
+        self.cache = cache
+        self.service_names = service_names or [VIRTUAL_MACHINES, STORAGE]
+        self.spot_window_days = spot_window_days
+
+        self._entries: Dict[str, Dict[str, Any]] = {}
+        self._spot_history: Dict[str, List[List[float]]] = {}
+        self._lock = threading.Lock()
+
+        self._load_cached()
+
+    @property
+    def clouds(self) -> List[str]:
+        return ["azure"]
+
+    @property
+    def loaded(self) -> bool:
+        """Whether any price data is available"""
+        return bool(self._entries)
+
+    def instance_types(self, cloud: str) -> Dict[str, Set[str]]:
+        return instance_types_of_keys(list(self._entries))
+
+    def get_price(self, query: PriceQuery) -> Price:
+        if not self._entries:
+            raise PriceNotFoundError("Azure price catalog not loaded yet")
+
+        if query.pricing_model == PRICING_RESERVED:
+            return self._reservation_price(query)
+
+        spot = query.pricing_model == PRICING_SPOT
+        if spot and query.service != SERVICE_COMPUTE:
+            raise PriceNotFoundError(f"Azure has no spot {query.service} prices")
+
+        key = entry_key(query.service, query.region, query.sku, SPOT_QUALIFIER if spot else "")
+        entry = self._entries.get(key)
+        if entry is None:
+            kind = "spot" if spot else query.service
+            raise PriceNotFoundError(
+                f"No Azure {kind} price for {query.region}/{query.sku}"
+            )
+
+        price, averaged_over_days = entry["price"], None
+        if spot:
+            averaged = window_average(self._spot_history.get(key, []), self.spot_window_days)
+            if averaged is not None:
+                price, averaged_over_days = averaged
+
+        return Price(
+            provider="azure",
+            region=query.region,
+            sku=query.sku,
+            service=query.service,
+            unit=entry["unit"],
+            price=price,
+            source=self.name,
+            pricing_model=query.pricing_model,
+            averaged_over_days=averaged_over_days,
+        )
+
+    def _reservation_price(self, query: PriceQuery) -> Price:
+        """Reservation total spread per hour (monthly payments) or paid upfront"""
+        term = query.attributes.get(ATTR_TERM, "")
+        payment_option = query.attributes.get(ATTR_PAYMENT_OPTION, "")
+        entry = None
+        if query.service == SERVICE_COMPUTE and payment_option in (NO_UPFRONT, ALL_UPFRONT):
+            entry = self._entries.get(entry_key(query.service, query.region, query.sku, reservation_qualifier(term)))
+        if entry is None:
+            raise PriceNotFoundError(
+                f"No Azure {term} {payment_option} reservation price for {query.region}/{query.sku}"
+            )
+
+        total = entry["price"]
+        upfront = total if payment_option == ALL_UPFRONT else 0.0
+        return Price(
+            provider="azure",
+            region=query.region,
+            sku=query.sku,
+            service=query.service,
+            unit="hour",
+            price=0.0 if upfront else total / term_hours(term),
+            source=self.name,
+            pricing_model=PRICING_RESERVED,
+            upfront=upfront,
+        )
+
+    def refresh(self) -> None:
+        """Page through prices of every service/region whose cache entry expired"""
+        for service_name in self.service_names:
+            for region in self.regions:
+                key = f"{service_name}-{region}"
+                cached = self.cache.load(key) if self.cache is not None else None
+                if cached is not None:
+                    # Fresh, possibly downloaded by another replica sharing the cache
+                    self._merge(cached)
+                    continue
+
+                try:
+                    items = self.client.region_prices(region, service_name)
+                except Exception as e:
+                    logger.error(f"Azure price download failed for {key}: {e}")
+                    report_refresh_failure(self.name, key, e)
+                    continue
+
+                if service_name == VIRTUAL_MACHINES:
+                    try:
+                        items = items + self.client.region_prices(region, service_name, price_type="Reservation")
+                    except Exception as e:
+                        logger.warning(f"Azure reservation prices unavailable for {key}: {e}")
+
+                entries = parse_items(items)
+                document = {
+                    "entries": entries,
+                    "spot_history": self._next_spot_history(key, entries),
+                }
+                if self.cache is not None:
+                    self.cache.save(key, document)
+                self._merge(document)
+
+                logger.info(f"Azure catalog refreshed: {key} ({len(document['entries'])} prices)")
+
+    def _next_spot_history(self, key: str, entries: Dict[str, Dict[str, Any]]) -> Dict[str, List[List[float]]]:
+        """Spot price history of a refreshed catalog, continued from the cached one"""
+        previous = {}
+        if self.cache is not None:
+            cached = self.cache.load(key, allow_stale=True)
+            if cached is not None:
+                previous = cached.get("spot_history", {})
+
+        suffix = f"|{SPOT_QUALIFIER}"
+        spot_entries = {k: entry for k, entry in entries.items() if k.endswith(suffix)}
+        return update_history(previous, spot_entries, self.spot_window_days)
+
+    def _load_cached(self) -> None:
+        """Populate prices from any cached catalogs, even expired ones"""
+        if self.cache is None:
+            return
+        for service_name in self.service_names:
+            for region in self.regions:
+                document = self.cache.load(f"{service_name}-{region}", allow_stale=True)
+                if document is not None:
+                    self._merge(document)
+
+    def _merge(self, document: Dict[str, Any]) -> None:
+        with self._lock:
+            entries = dict(self._entries)
+            entries.update(document.get("entries", {}))
+            self._entries = entries
+            history = dict(self._spot_history)
+            history.update(document.get("spot_history", {}))
+            self._spot_history = history
+
+
+def _build_azure_provider(settings) -> AzurePricingProvider:
+    """Create the Azure provider from application settings"""
+    return AzurePricingProvider(
+        regions=settings.azure_pricing_regions,
+        client=RetailPricesClient(base_url=settings.azure_retail_prices_url),
+        cache=catalog_cache(settings, "azure", settings.azure_pricing_cache_ttl),
+        spot_window_days=settings.spot_price_window_days,
+    )
+
+
+register_factory(AzurePricingProvider.name, _build_azure_provider)
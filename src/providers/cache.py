@@ -0,0 +1,203 @@
+"""
+Cache for downloaded price catalogs
+
+Each catalog is stored as one JSON document with the time it was fetched,
+so providers can skip re-downloading catalogs that are still fresh.
+Catalogs live in the persistent store when one is configured, otherwise
+in JSON files under PRICING_CACHE_DIR. Providers built for estimates
+pinned to a catalog snapshot read the store's snapshot of that day.
+"""
+
+import json
+import logging
+import os
+import time
+from contextlib import contextmanager
+from contextvars import ContextVar
+from datetime import date
+from typing import Any, Dict, Iterator, Optional
+
+from ..metrics import CACHE_HIT, CACHE_MISS, CACHE_STALE, record_cache_lookup
+from ..store import Store, StoreError
+
+logger = logging.getLogger(__name__)
+
+# Store used by catalog_cache(), set at application startup
+_catalog_store: Optional[Store] = None
+# Snapshot day catalog_cache() reads from, set while pinned providers are built
+_pinned_snapshot: ContextVar[Optional[date]] = ContextVar("pinned_snapshot", default=None)
+
+
+def set_catalog_store(store: Optional[Store]) -> None:
+    """Persist provider catalogs in a store instead of local files"""
+    global _catalog_store
+    _catalog_store = store
+
+
+@contextmanager
+def pinned_catalogs(snapshot: date) -> Iterator[None]:
+    """
+    Have catalog_cache() return read-only caches of a snapshot day
+
+    Raises:
+        ValueError: If catalogs are not kept in a store
+    """
+    if _catalog_store is None:
+        raise ValueError("Catalog snapshots need a store (STORE_URL)")
+    token = _pinned_snapshot.set(snapshot)
+    try:
+        yield
+    finally:
+        _pinned_snapshot.reset(token)
+
+
+def catalog_cache(settings, provider: str, ttl_seconds: int):
+    """Catalog cache for a provider factory: store-backed if configured, else on disk"""
+    snapshot = _pinned_snapshot.get()
+    if snapshot is not None:
+        return SnapshotCatalogCache(_catalog_store, provider, snapshot)
+    if _catalog_store is not None:
+        return StoreCatalogCache(_catalog_store, provider, ttl_seconds)
+    return CatalogCache(
+        cache_dir=f"{settings.pricing_cache_dir}/{provider}",
+        ttl_seconds=ttl_seconds,
+        provider=provider,
+    )
+
+
+def _is_usable(fetched_at: float, ttl_seconds: int, allow_stale: bool, provider: str) -> bool:
+    """Whether a cached catalog may be returned, counting the lookup result"""
+    if time.time() - fetched_at <= ttl_seconds:
+        record_cache_lookup(provider, CACHE_HIT)
+        return True
+    record_cache_lookup(provider, CACHE_STALE if allow_stale else CACHE_MISS)
+    return allow_stale
+
+
+class CatalogCache:
+    """JSON file cache with a freshness TTL"""
+
+    def __init__(self, cache_dir: str, ttl_seconds: int = 86400, provider: Optional[str] = None):
+        """
+        Initialize catalog cache
+
+        Args:
+            cache_dir: Directory holding cached catalog files
+            ttl_seconds: Age after which a cached catalog is considered stale
+            provider: Provider name used in cache metrics. Defaults to the directory name.
+        """
+        self.cache_dir = cache_dir
+        self.ttl_seconds = ttl_seconds
+        self.provider = provider or os.path.basename(os.path.normpath(cache_dir))
+
+    def _path(self, key: str) -> str:
+        safe_key = "".join(c if c.isalnum() or c in "-_." else "_" for c in key)
+        return os.path.join(self.cache_dir, f"{safe_key}.json")
+
+    def load(self, key: str, allow_stale: bool = False) -> Optional[Dict[str, Any]]:
+        """
+        Load a cached catalog
+
+        Args:
+            key: Cache key
+            allow_stale: Return the document even if it is older than the TTL
+
+        Returns:
+            Cached document, or None if missing, stale or unreadable
+        """
+        path = self._path(key)
+        try:
+            with open(path, "r", encoding="utf-8") as f:
+                document = json.load(f)
+        except FileNotFoundError:
+            record_cache_lookup(self.provider, CACHE_MISS)
+            return None
+        except (OSError, ValueError) as e:
+            logger.warning(f"Ignoring unreadable catalog cache {path}: {e}")
+            record_cache_lookup(self.provider, CACHE_MISS)
+            return None
+
+        if not _is_usable(document.get("fetched_at", 0), self.ttl_seconds, allow_stale, self.provider):
+            return None
+        return document
+
+    def save(self, key: str, data: Dict[str, Any]) -> None:
+        """Store a catalog, stamping it with the current time"""
+        os.makedirs(self.cache_dir, exist_ok=True)
+        document = dict(data, fetched_at=time.time())
+
+        # Write atomically so readers never see a partial file
+        path = self._path(key)
+        tmp_path = f"{path}.tmp"
+        with open(tmp_path, "w", encoding="utf-8") as f:
+            json.dump(document, f)
+        os.replace(tmp_path, path)
+
+
+class StoreCatalogCache:
+    """Catalog cache persisted in the catalogs table of a Store"""
+
+    def __init__(self, store: Store, provider: str, ttl_seconds: int = 86400):
+        """
+        Initialize store-backed catalog cache
+
+        Args:
+            store: Persistent store
+            provider: Provider name the catalogs belong to
+            ttl_seconds: Age after which a cached catalog is considered stale
+        """
+        self.store = store
+        self.provider = provider
+        self.ttl_seconds = ttl_seconds
+
+    def load(self, key: str, allow_stale: bool = False) -> Optional[Dict[str, Any]]:
+        """Load a cached catalog; same semantics as CatalogCache.load"""
+        try:
+            record = self.store.load_catalog(self.provider, key)
+        except StoreError as e:
+            logger.warning(f"Ignoring unreadable catalog {self.provider}/{key}: {e}")
+            record_cache_lookup(self.provider, CACHE_MISS)
+            return None
+        if record is None:
+            record_cache_lookup(self.provider, CACHE_MISS)
+            return None
+
+        fetched_at = record.fetched_at.timestamp()
+        if not _is_usable(fetched_at, self.ttl_seconds, allow_stale, self.provider):
+            return None
+        return dict(record.document, fetched_at=fetched_at)
+
+    def save(self, key: str, data: Dict[str, Any]) -> None:
+        """Store a catalog, stamping it with the current time"""
+        self.store.save_catalog(self.provider, key, data)
+
+
+class SnapshotCatalogCache:
+    """Read-only catalog cache over the store's snapshots as of a day"""
+
+    def __init__(self, store: Store, provider: str, snapshot: date):
+        """
+        Initialize snapshot catalog cache
+
+        Args:
+            store: Persistent store
+            provider: Provider name the catalogs belong to
+            snapshot: Day whose catalogs are read
+        """
+        self.store = store
+        self.provider = provider
+        self.snapshot = snapshot
+
+    def load(self, key: str, allow_stale: bool = False) -> Optional[Dict[str, Any]]:
+        """Load a catalog as of the snapshot day, however old it was then"""
+        try:
+            record = self.store.load_catalog_snapshot(self.provider, key, self.snapshot)
+        except StoreError as e:
+            logger.warning(f"Ignoring unreadable catalog snapshot {self.provider}/{key}@{self.snapshot}: {e}")
+            return None
+        if record is None:
+            return None
+        return dict(record.document, fetched_at=record.fetched_at.timestamp())
+
+    def save(self, key: str, data: Dict[str, Any]) -> None:
+        """Snapshots are never changed by the providers reading them"""
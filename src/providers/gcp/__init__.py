@@ -0,0 +1,19 @@
+"""
+GCP Pricing Provider
+
+Compute Engine, Persistent Disk and Cloud Storage prices from the
+Cloud Billing Catalog API, with sustained-use discounts.
+"""
+
+from .client import CloudBillingClient
+from .machine_types import machine_shape, sustained_use_discount
+from .parser import parse_skus
+from .provider import GCPPricingProvider
+
+__all__ = [
+    "CloudBillingClient",
+    "GCPPricingProvider",
+    "machine_shape",
+    "sustained_use_discount",
+    "parse_skus",
+]
@@ -0,0 +1,57 @@
+"""
+GCP Cloud Billing Catalog API client
+"""
+
+import logging
+from typing import Any, Dict, List
+
+import requests
+
+from ...metrics import observe_pricing_api
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_BILLING_API_URL = "https://cloudbilling.googleapis.com"
+
+# Public service IDs in the Cloud Billing Catalog
+COMPUTE_ENGINE_SERVICE_ID = "6F81-5844-456A"
+CLOUD_STORAGE_SERVICE_ID = "95FF-2EF5-5EA1"
+
+
+class CloudBillingClient:
+    """Client for the Cloud Billing Catalog API (API key authentication)"""
+
+    def __init__(self, api_key: str, base_url: str = DEFAULT_BILLING_API_URL, timeout: int = 60):
+        """
+        Initialize Cloud Billing client
+
+        Args:
+            api_key: GCP API key with access to the Cloud Billing API
+            base_url: Catalog API endpoint
+            timeout: Per-request timeout in seconds
+        """
+        self.api_key = api_key
+        self.base_url = base_url.rstrip("/")
+        self.timeout = timeout
+        self.session = requests.Session()
+
+    def list_skus(self, service_id: str, currency: str = "USD") -> List[Dict[str, Any]]:
+        """Fetch every SKU of a service, following page tokens"""
+        url = f"{self.base_url}/v1/services/{service_id}/skus"
+        params = {"key": self.api_key, "currencyCode": currency, "pageSize": 5000}
+        skus: List[Dict[str, Any]] = []
+
+        while True:
+            with observe_pricing_api("gcp", "list_skus"):
+                response = self.session.get(url, params=params, timeout=self.timeout)
+                response.raise_for_status()
+                data = response.json()
+
+            skus.extend(data.get("skus", []))
+            token = data.get("nextPageToken")
+            if not token:
+                break
+            params["pageToken"] = token
+
+        logger.info(f"Fetched {len(skus)} SKUs for GCP service {service_id}")
+        return skus
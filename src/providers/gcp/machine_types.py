@@ -0,0 +1,187 @@
+"""
+GCP machine type shapes and sustained-use discounts
+
+Compute Engine bills predefined machine types as vCPU-hours plus
+GB-of-RAM-hours of their family, so a machine type is priced from its shape.
+Accelerator-optimized machine types (A2, A3, G2) add the GPUs they are
+built with, billed per GPU-hour of their accelerator type.
+"""
+
+import re
+from typing import Dict, List, NamedTuple, Optional, Tuple
+
+from ...pricing.instance_types import InstanceShape, register_shape, register_shape_resolver
+
+# GB of memory per vCPU for each family and machine class
+_MEMORY_PER_VCPU: Dict[str, Dict[str, float]] = {
+    "n1": {"standard": 3.75, "highmem": 6.5, "highcpu": 0.9},
+    "n2": {"standard": 4.0, "highmem": 8.0, "highcpu": 1.0},
+    "n2d": {"standard": 4.0, "highmem": 8.0, "highcpu": 1.0},
+    "e2": {"standard": 4.0, "highmem": 8.0, "highcpu": 1.0},
+    "c2": {"standard": 4.0},
+    "c3": {"standard": 4.0, "highmem": 8.0, "highcpu": 2.0},
+    "t2d": {"standard": 4.0},
+    "t2a": {"standard": 4.0},
+}
+
+# Shared-core machine types: billed vCPU fraction and memory GB
+_SHARED_CORE: Dict[str, tuple] = {
+    "e2-micro": ("e2", 0.25, 1.0),
+    "e2-small": ("e2", 0.5, 2.0),
+    "e2-medium": ("e2", 1.0, 4.0),
+    "f1-micro": ("n1", 0.2, 0.6),
+    "g1-small": ("n1", 0.5, 1.7),
+}
+
+# Incremental price multiplier for each successive quarter of the month
+_SUSTAINED_USE_TIERS: Dict[str, List[float]] = {
+    "n1": [1.0, 0.8, 0.6, 0.4],
+    "n2": [1.0, 0.8678, 0.7356, 0.6034],
+    "n2d": [1.0, 0.8678, 0.7356, 0.6034],
+    "c2": [1.0, 0.8678, 0.7356, 0.6034],
+}
+
+# Accelerator-optimized machine types: (family, vCPUs, memory GB, GPUs, accelerator type)
+_ACCELERATOR_OPTIMIZED: Dict[str, tuple] = {
+    "a2-highgpu-1g": ("a2", 12, 85, 1, "nvidia-tesla-a100"),
+    "a2-highgpu-2g": ("a2", 24, 170, 2, "nvidia-tesla-a100"),
+    "a2-highgpu-4g": ("a2", 48, 340, 4, "nvidia-tesla-a100"),
+    "a2-highgpu-8g": ("a2", 96, 680, 8, "nvidia-tesla-a100"),
+    "a2-ultragpu-1g": ("a2", 12, 170, 1, "nvidia-a100-80gb"),
+    "a2-ultragpu-8g": ("a2", 96, 1360, 8, "nvidia-a100-80gb"),
+    "a3-highgpu-8g": ("a3", 208, 1872, 8, "nvidia-h100-80gb"),
+    "g2-standard-4": ("g2", 4, 16, 1, "nvidia-l4"),
+    "g2-standard-8": ("g2", 8, 32, 1, "nvidia-l4"),
+    "g2-standard-12": ("g2", 12, 48, 1, "nvidia-l4"),
+    "g2-standard-24": ("g2", 24, 96, 2, "nvidia-l4"),
+    "g2-standard-48": ("g2", 48, 192, 4, "nvidia-l4"),
+    "g2-standard-96": ("g2", 96, 384, 8, "nvidia-l4"),
+}
+
+# vCPU counts of each family's predefined machine types, listed by catalog discovery
+_PREDEFINED_VCPUS: Dict[str, Tuple[int, ...]] = {
+    "n1": (1, 2, 4, 8, 16, 32, 64, 96),
+    "n2": (2, 4, 8, 16, 32, 48, 64, 80, 96, 128),
+    "n2d": (2, 4, 8, 16, 32, 48, 64, 80, 96),
+    "e2": (2, 4, 8, 16, 32),
+    "c2": (4, 8, 16, 30, 60),
+    "c3": (4, 8, 22, 44, 88, 176),
+    "t2d": (1, 2, 4, 8, 16, 32, 48, 60),
+    "t2a": (1, 2, 4, 8, 16, 32, 48),
+}
+
+_MACHINE_TYPE = re.compile(r"^(?P<family>[a-z0-9]+)-(?P<klass>standard|highmem|highcpu)-(?P<vcpus>\d+)$")
+
+
+class MachineShape(NamedTuple):
+    """Billable shape of a machine type"""
+
+    family: str
+    vcpus: float
+    memory_gb: float
+
+
+def machine_shape(machine_type: str) -> MachineShape:
+    """
+    Resolve a predefined machine type to its family, vCPUs and memory
+
+    Raises:
+        ValueError: If the machine type is unknown
+    """
+    if machine_type in _SHARED_CORE:
+        family, vcpus, memory_gb = _SHARED_CORE[machine_type]
+        return MachineShape(family, vcpus, memory_gb)
+    if machine_type in _ACCELERATOR_OPTIMIZED:
+        family, vcpus, memory_gb, _, _ = _ACCELERATOR_OPTIMIZED[machine_type]
+        return MachineShape(family, vcpus, memory_gb)
+
+    match = _MACHINE_TYPE.match(machine_type)
+    if match is None:
+        raise ValueError(f"Unknown GCP machine type: {machine_type}")
+
+    family = match.group("family")
+    ratios = _MEMORY_PER_VCPU.get(family, {})
+    ratio = ratios.get(match.group("klass"))
+    if ratio is None:
+        raise ValueError(f"Unknown GCP machine type: {machine_type}")
+
+    vcpus = float(match.group("vcpus"))
+    return MachineShape(family, vcpus, vcpus * ratio)
+
+
+def predefined_machine_types(family: str) -> List[str]:
+    """Predefined, shared-core and accelerator-optimized machine types of a family"""
+    names = [
+        f"{family}-{klass}-{vcpus}"
+        for klass in _MEMORY_PER_VCPU.get(family, {})
+        for vcpus in _PREDEFINED_VCPUS.get(family, ())
+    ]
+    names += [name for name, shape in _SHARED_CORE.items() if shape[0] == family]
+    names += [name for name, shape in _ACCELERATOR_OPTIMIZED.items() if shape[0] == family]
+    return names
+
+
+def machine_gpus(machine_type: str) -> Tuple[int, str]:
+    """(GPUs, accelerator type) an accelerator-optimized machine type is built with, (0, "") for others"""
+    if machine_type not in _ACCELERATOR_OPTIMIZED:
+        return 0, ""
+    _, _, _, gpus, accelerator = _ACCELERATOR_OPTIMIZED[machine_type]
+    return gpus, accelerator
+
+
+def sustained_use_discount(family: str, usage_fraction: float) -> float:
+    """
+    Sustained-use discount rate for a month of partial usage
+
+    Args:
+        family: Machine family (n1, n2, ...)
+        usage_fraction: Share of the month the instance runs (0-1)
+
+    Returns:
+        Fraction of the on-demand cost that is discounted (0 if not eligible)
+    """
+    tiers = _SUSTAINED_USE_TIERS.get(family)
+    if not tiers or usage_fraction <= 0:
+        return 0.0
+
+    usage_fraction = min(usage_fraction, 1.0)
+    billed = 0.0
+    for index, multiplier in enumerate(tiers):
+        start = index * 0.25
+        billed += max(0.0, min(usage_fraction - start, 0.25)) * multiplier
+
+    return 1.0 - billed / usage_fraction
+
+
+def _instance_shape(machine_type: str) -> Optional[InstanceShape]:
+    """Shape resolver used by pricing.instance_types"""
+    try:
+        shape = machine_shape(machine_type)
+    except ValueError:
+        return None
+    arch = "arm64" if shape.family.startswith("t2a") else "x86_64"
+    gpus, accelerator = machine_gpus(machine_type)
+    return InstanceShape(shape.vcpus, shape.memory_gb, gpus, arch, accelerator)
+
+
+register_shape_resolver("gcp", _instance_shape)
+
+# Predefined sizes enumerated as known instance types (used to search for
+# machines matching abstract requirements); other sizes still resolve above
+_CANDIDATE_VCPUS: Dict[str, tuple] = {
+    "e2": (2, 4, 8, 16, 32),
+    "n2": (2, 4, 8, 16, 32, 48, 64, 80, 96),
+    "n2d": (2, 4, 8, 16, 32, 48, 64, 80, 96),
+    "c3": (4, 8, 22, 44, 88, 176),
+    "t2d": (1, 2, 4, 8, 16, 32, 48, 60),
+    "t2a": (1, 2, 4, 8, 16, 32, 48),
+}
+
+for _family, _sizes in _CANDIDATE_VCPUS.items():
+    for _klass in _MEMORY_PER_VCPU[_family]:
+        for _vcpus in _sizes:
+            _name = f"{_family}-{_klass}-{_vcpus}"
+            register_shape("gcp", _name, _instance_shape(_name))
+
+for _name in ("e2-micro", "e2-small", "e2-medium", *_ACCELERATOR_OPTIMIZED):
+    register_shape("gcp", _name, _instance_shape(_name))
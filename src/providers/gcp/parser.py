@@ -0,0 +1,232 @@
+"""
+Cloud Billing Catalog SKU parser
+
+Reduces Compute Engine and Cloud Storage SKUs to on-demand unit prices:
+per-family vCPU and RAM hourly rates, Persistent Disk and Cloud Storage
+GB-month rates and GPU hourly rates by accelerator type, keyed per
+region. Spot (preemptible) vCPU and RAM rates are kept under the
+"spot/core" and "spot/ram" qualifiers, spot GPU rates under "spot", 1 and
+3 year committed use rates under "reserved/<term>/no_upfront/core" and
+".../ram".
+"""
+
+import re
+from typing import Any, Dict, Iterable, List, Optional
+
+from ...pricing import (
+    SERVICE_COMPUTE,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_OBJECT_STORAGE,
+    SERVICE_ACCELERATOR,
+    PRICING_RESERVED,
+    NO_UPFRONT,
+    commitment_qualifier,
+)
+
+# SKU description prefixes of predefined vCPU / RAM prices per family
+_COMPUTE_PREFIXES = [
+    ("N1 Predefined Instance", "n1"),
+    ("N2D AMD Instance", "n2d"),
+    ("N2 Instance", "n2"),
+    ("E2 Instance", "e2"),
+    ("Compute optimized", "c2"),
+    ("C3 Instance", "c3"),
+    ("T2D AMD Instance", "t2d"),
+    ("A2 Instance", "a2"),
+    ("A3 Instance", "a3"),
+    ("G2 Instance", "g2"),
+]
+
+# SKU description prefixes of GPUs -> accelerator types, most specific first
+_GPU_PREFIXES = [
+    ("Nvidia Tesla A100 80GB GPU", "nvidia-a100-80gb"),
+    ("Nvidia Tesla A100 GPU", "nvidia-tesla-a100"),
+    ("Nvidia H100 80GB GPU", "nvidia-h100-80gb"),
+    ("Nvidia L4 GPU", "nvidia-l4"),
+    ("Nvidia Tesla T4 GPU", "nvidia-tesla-t4"),
+    ("Nvidia Tesla P4 GPU", "nvidia-tesla-p4"),
+    ("Nvidia Tesla P100 GPU", "nvidia-tesla-p100"),
+    ("Nvidia Tesla V100 GPU", "nvidia-tesla-v100"),
+]
+
+# SKU description prefixes of zonal Persistent Disk capacity
+_DISK_PREFIXES = [
+    ("Storage PD Capacity", "pd-standard"),
+    ("SSD backed PD Capacity", "pd-ssd"),
+    ("Balanced PD Capacity", "pd-balanced"),
+]
+
+# Cloud Storage resource groups to storage classes
+_STORAGE_CLASSES = {
+    "RegionalStorage": "STANDARD",
+    "NearlineStorage": "NEARLINE",
+    "ColdlineStorage": "COLDLINE",
+    "ArchiveStorage": "ARCHIVE",
+}
+
+# Spot VM SKUs usually carry one of these prefixes before the on-demand description
+_SPOT_PREFIXES = ("Spot Preemptible ", "Preemptible ")
+
+SPOT_QUALIFIER_PREFIX = "spot/"
+SPOT_ACCELERATOR_QUALIFIER = "spot"
+
+# Committed use usage types -> terms; commitments are billed monthly (no upfront)
+_COMMIT_TERMS = {"Commit1Yr": "1yr", "Commit3Yr": "3yr"}
+
+# e.g. "Commitment v1: N2D AMD Cpu in Americas for 1 Year"
+_COMMITMENT = re.compile(r"^Commitment(?: v\d+)?: (?P<family>[A-Z0-9]+)\b.*\b(?P<resource>Cpu|Ram)\b")
+
+_UNITS = {"h": "hour", "GiBy.h": "GB-hour", "GiBy.mo": "GB-month"}
+
+
+def entry_key(service: str, region: str, sku: str, qualifier: str = "") -> str:
+    """Lookup key for a parsed price entry"""
+    return "|".join([service, region, sku, qualifier])
+
+
+def parse_skus(skus: List[Dict[str, Any]], regions: Optional[Iterable[str]] = None) -> Dict[str, Dict[str, Any]]:
+    """
+    Extract on-demand Compute Engine and Cloud Storage prices
+
+    Args:
+        skus: Catalog SKUs of one or more services
+        regions: Only keep prices for these regions (all if empty)
+
+    Returns:
+        Mapping of entry_key() -> {"price", "unit"}. Compute entries are keyed
+        by family with qualifier "core" or "ram", prefixed for Spot VMs and commitments.
+    """
+    wanted = set(regions or [])
+    entries: Dict[str, Dict[str, Any]] = {}
+
+    for sku in skus:
+        category = sku.get("category", {})
+        description = sku.get("description", "")
+        usage_type = category.get("usageType")
+
+        if usage_type == "OnDemand":
+            key_parts = _classify(description, category)
+        elif usage_type == "Preemptible":
+            key_parts = _classify_spot(description, category)
+        elif usage_type in _COMMIT_TERMS:
+            key_parts = _classify_commitment(description, _COMMIT_TERMS[usage_type])
+        else:
+            continue
+        if key_parts is None:
+            continue
+
+        rate = _unit_rate(sku)
+        if rate is None:
+            continue
+        price, unit = rate
+        entry = {"price": price, "unit": unit}
+        tiers = _tiers(sku)
+        if tiers:
+            entry["tiers"] = tiers
+
+        service, name, qualifier = key_parts
+        for region in sku.get("serviceRegions", []):
+            if wanted and region not in wanted:
+                continue
+            entries[entry_key(service, region, name, qualifier)] = entry
+
+    return entries
+
+
+def _classify(description: str, category: Dict[str, Any]) -> Optional[tuple]:
+    """Map a SKU to (service, name, qualifier), or None if it is not priced by us"""
+    family = category.get("resourceFamily")
+
+    if family == "Compute":
+        lowered = description.lower()
+        if "custom" in lowered or "sole tenancy" in lowered:
+            return None
+        if category.get("resourceGroup") == "GPU":
+            accelerator = next((name for prefix, name in _GPU_PREFIXES if description.startswith(prefix)), None)
+            return (SERVICE_ACCELERATOR, accelerator, "") if accelerator else None
+        for prefix, machine_family in _COMPUTE_PREFIXES:
+            if description.startswith(prefix):
+                if " Core " in description:
+                    return SERVICE_COMPUTE, machine_family, "core"
+                if " Ram " in description:
+                    return SERVICE_COMPUTE, machine_family, "ram"
+        return None
+
+    if family == "Storage":
+        if "Regional" in description and "PD" in description:
+            return None
+        for prefix, disk_type in _DISK_PREFIXES:
+            if description.startswith(prefix):
+                return SERVICE_BLOCK_STORAGE, disk_type, ""
+        storage_class = _STORAGE_CLASSES.get(category.get("resourceGroup", ""))
+        if storage_class is not None:
+            return SERVICE_OBJECT_STORAGE, storage_class, ""
+
+    return None
+
+
+def _classify_spot(description: str, category: Dict[str, Any]) -> Optional[tuple]:
+    """Map a Spot VM SKU to its family's spot core/ram key"""
+    for prefix in _SPOT_PREFIXES:
+        if description.startswith(prefix):
+            description = description[len(prefix):]
+            break
+
+    key_parts = _classify(description, category)
+    if key_parts is not None and key_parts[0] == SERVICE_ACCELERATOR:
+        return key_parts[0], key_parts[1], SPOT_ACCELERATOR_QUALIFIER
+    if key_parts is None or key_parts[0] != SERVICE_COMPUTE:
+        return None
+    service, machine_family, qualifier = key_parts
+    return service, machine_family, SPOT_QUALIFIER_PREFIX + qualifier
+
+
+def _classify_commitment(description: str, term: str) -> Optional[tuple]:
+    """Map a committed use SKU to its family's commitment core/ram key"""
+    match = _COMMITMENT.match(description)
+    if match is None:
+        return None
+    machine_family = match.group("family").lower()
+    if machine_family not in {family for _, family in _COMPUTE_PREFIXES}:
+        return None
+    resource = "core" if match.group("resource") == "Cpu" else "ram"
+    return SERVICE_COMPUTE, machine_family, f"{commitment_qualifier(PRICING_RESERVED, term, NO_UPFRONT)}/{resource}"
+
+
+def _unit_rate(sku: Dict[str, Any]) -> Optional[tuple]:
+    """First non-zero tier price of the current pricing info as (price, unit)"""
+    pricing_info = sku.get("pricingInfo", [])
+    if not pricing_info:
+        return None
+
+    expression = pricing_info[-1].get("pricingExpression", {})
+    unit = _UNITS.get(expression.get("usageUnit", ""))
+    if unit is None:
+        return None
+
+    rates = sorted(expression.get("tieredRates", []), key=lambda r: r.get("startUsageAmount", 0))
+    for rate in rates:
+        money = rate.get("unitPrice", {})
+        price = float(money.get("units", 0) or 0) + money.get("nanos", 0) / 1e9
+        if price > 0:
+            return price, unit
+
+    # Free-tier only SKUs are recorded as free
+    return (0.0, unit) if rates else None
+
+
+def _tiers(sku: Dict[str, Any]) -> List[Dict[str, Any]]:
+    """Graduated tiers of a SKU priced in several bands, e.g. a free first GiB; empty for one rate"""
+    expression = sku.get("pricingInfo", [{}])[-1].get("pricingExpression", {})
+    rates = sorted(expression.get("tieredRates", []), key=lambda r: r.get("startUsageAmount", 0))
+    if len(rates) < 2:
+        return []
+    tiers = []
+    for index, rate in enumerate(rates):
+        money = rate.get("unitPrice", {})
+        tiers.append({
+            "begin": float(rate.get("startUsageAmount", 0)),
+            "end": float(rates[index + 1].get("startUsageAmount", 0)) if index + 1 < len(rates) else None,
+            "price": float(money.get("units", 0) or 0) + money.get("nanos", 0) / 1e9,
+        })
+    return tiers
@@ -0,0 +1,307 @@
+"""
+GCP pricing provider
+
+Serves Compute Engine, Persistent Disk, GPU and Cloud Storage on-demand
+prices from the Cloud Billing Catalog API and applies sustained-use
+discounts. Accelerator-optimized machine types include the GPUs they are
+built with. Spot VM prices are averaged over the rates observed at each
+refresh; 1 and 3 year committed use discounts are served as reserved
+prices (not for GPUs).
+"""
+
+import logging
+import threading
+from typing import Any, Dict, List, Optional, Set
+
+from ...pricing import (
+    Price,
+    PriceQuery,
+    PriceTier,
+    PricingProvider,
+    PriceNotFoundError,
+    UsageDiscount,
+    register_factory,
+    report_refresh_failure,
+    update_history,
+    window_average,
+    SERVICE_COMPUTE,
+    SERVICE_ACCELERATOR,
+    PRICING_ON_DEMAND,
+    PRICING_SPOT,
+    COMMITMENT_MODELS,
+    ATTR_TERM,
+    ATTR_PAYMENT_OPTION,
+    commitment_qualifier,
+    DEFAULT_SPOT_WINDOW_DAYS,
+)
+from ...units import hours_per_month as full_month_hours
+from ..cache import CatalogCache, catalog_cache
+from .client import CloudBillingClient, COMPUTE_ENGINE_SERVICE_ID, CLOUD_STORAGE_SERVICE_ID
+from .machine_types import machine_gpus, machine_shape, predefined_machine_types, sustained_use_discount
+from .parser import parse_skus, entry_key, SPOT_ACCELERATOR_QUALIFIER, SPOT_QUALIFIER_PREFIX
+
+logger = logging.getLogger(__name__)
+
+
+class GCPPricingProvider(PricingProvider):
+    """Pricing provider backed by the Cloud Billing Catalog API"""
+
+    name = "gcp"
+
+    def __init__(
+        self,
+        regions: List[str],
+        client: Optional[CloudBillingClient] = None,
+        cache: Optional[CatalogCache] = None,
+        service_ids: Optional[List[str]] = None,
+        spot_window_days: float = DEFAULT_SPOT_WINDOW_DAYS,
+    ):
+        """
+        Initialize GCP provider
+
+        Args:
+            regions: Regions to keep prices for
+            client: Cloud Billing Catalog client (required for refresh)
+            cache: Local catalog cache. Catalogs are not persisted if not provided.
+            service_ids: Catalog service IDs to load
+            spot_window_days: Days of observed spot rates averaged into spot prices
+        """
+        self.regions = regions
+        self.client = client
+        self.cache = cache
+        self.service_ids = service_ids or [COMPUTE_ENGINE_SERVICE_ID, CLOUD_STORAGE_SERVICE_ID]
+        self.spot_window_days = spot_window_days
+
+        self._entries: Dict[str, Dict[str, Any]] = {}
+        self._spot_history: Dict[str, List[List[float]]] = {}
+        self._lock = threading.Lock()
+
+        self._load_cached()
+
+    @property
+    def clouds(self) -> List[str]:
+        return ["gcp"]
+
+    @property
+    def loaded(self) -> bool:
+        """Whether any price data is available"""
+        return bool(self._entries)
+
+    def instance_types(self, cloud: str) -> Dict[str, Set[str]]:
+        """Predefined machine types of the families with on-demand vCPU and RAM rates in each region"""
+        rates: Dict[tuple, Set[str]] = {}
+        for key in list(self._entries):
+            service, region, family, qualifier = key.split("|", 3)
+            if service == SERVICE_COMPUTE and qualifier in ("core", "ram"):
+                rates.setdefault((region, family), set()).add(qualifier)
+        regions: Dict[str, Set[str]] = {}
+        for (region, family), priced in rates.items():
+            if len(priced) == 2:
+                regions.setdefault(region, set()).update(predefined_machine_types(family))
+        return regions
+
+    def get_price(self, query: PriceQuery) -> Price:
+        if not self._entries:
+            raise PriceNotFoundError("GCP price catalog not loaded yet")
+
+        averaged_over_days = None
+        tiers = []
+        if query.pricing_model in COMMITMENT_MODELS:
+            if query.service != SERVICE_COMPUTE:
+                raise PriceNotFoundError(f"GCP has no {query.pricing_model} {query.service} prices")
+            qualifier = commitment_qualifier(
+                query.pricing_model,
+                query.attributes.get(ATTR_TERM, ""),
+                query.attributes.get(ATTR_PAYMENT_OPTION, ""),
+            )
+            price, unit = self._machine_price(query.region, query.sku, qualifier + "/"), "hour"
+        elif query.pricing_model == PRICING_SPOT:
+            if query.service == SERVICE_ACCELERATOR:
+                price, unit = self._accelerator_price(query.region, query.sku, SPOT_ACCELERATOR_QUALIFIER), "hour"
+            elif query.service != SERVICE_COMPUTE:
+                raise PriceNotFoundError(f"GCP has no spot {query.service} prices")
+            else:
+                price, averaged_over_days = self._spot_machine_price(query.region, query.sku)
+                unit = "hour"
+        elif query.service == SERVICE_COMPUTE:
+            price, unit = self._machine_price(query.region, query.sku), "hour"
+        else:
+            entry = self._entries.get(entry_key(query.service, query.region, query.sku))
+            if entry is None:
+                raise PriceNotFoundError(
+                    f"No GCP {query.service} price for {query.region}/{query.sku}"
+                )
+            price, unit = entry["price"], entry["unit"]
+            tiers = [PriceTier(**tier) for tier in entry.get("tiers", [])]
+
+        return Price(
+            provider="gcp",
+            region=query.region,
+            sku=query.sku,
+            service=query.service,
+            unit=unit,
+            price=price,
+            source=self.name,
+            pricing_model=query.pricing_model,
+            averaged_over_days=averaged_over_days,
+            tiers=tiers,
+        )
+
+    def usage_discount(self, price: Price, hours_per_month: float) -> Optional[UsageDiscount]:
+        """Sustained-use discount for eligible machine families and attached GPUs (at the N1 rates)"""
+        if price.service not in (SERVICE_COMPUTE, SERVICE_ACCELERATOR) or price.pricing_model != PRICING_ON_DEMAND:
+            return None
+
+        if price.service == SERVICE_COMPUTE:
+            family = machine_shape(price.sku).family
+            label = family.upper()
+        else:
+            family, label = "n1", price.sku
+        rate = sustained_use_discount(family, hours_per_month / full_month_hours())
+        if rate <= 0:
+            return None
+
+        return UsageDiscount(
+            name="gcp-sustained-use",
+            rate=rate,
+            description=f"Sustained use discount for {label} at {hours_per_month:.0f}h/month",
+        )
+
+    def _machine_price(self, region: str, machine_type: str, qualifier_prefix: str = "") -> float:
+        """Hourly price of a predefined machine type from its vCPU and RAM rates"""
+        try:
+            shape = machine_shape(machine_type)
+        except ValueError as e:
+            raise PriceNotFoundError(str(e)) from None
+
+        core = self._entries.get(entry_key(SERVICE_COMPUTE, region, shape.family, qualifier_prefix + "core"))
+        ram = self._entries.get(entry_key(SERVICE_COMPUTE, region, shape.family, qualifier_prefix + "ram"))
+        if core is None or ram is None:
+            kind = qualifier_prefix.rstrip("/") or "compute"
+            raise PriceNotFoundError(f"No GCP {kind} price for {region}/{machine_type}")
+
+        return shape.vcpus * core["price"] + shape.memory_gb * ram["price"] + self._gpu_price(
+            region, machine_type, qualifier_prefix.rstrip("/")
+        )
+
+    def _gpu_price(self, region: str, machine_type: str, qualifier: str = "") -> float:
+        """Hourly price of the GPUs an accelerator-optimized machine type is built with"""
+        gpus, accelerator = machine_gpus(machine_type)
+        return gpus * self._accelerator_price(region, accelerator, qualifier) if gpus else 0.0
+
+    def _accelerator_price(self, region: str, accelerator: str, qualifier: str = "") -> float:
+        """Hourly price of one GPU of an accelerator type"""
+        entry = self._entries.get(entry_key(SERVICE_ACCELERATOR, region, accelerator, qualifier))
+        if entry is None:
+            kind = qualifier or "on-demand"
+            raise PriceNotFoundError(f"No GCP {kind} price for {region}/{accelerator}")
+        return entry["price"]
+
+    def _spot_machine_price(self, region: str, machine_type: str) -> tuple:
+        """
+        Average hourly spot price of a machine type
+
+        Returns:
+            (price, days averaged), days is None when no history was recorded yet
+        """
+        try:
+            shape = machine_shape(machine_type)
+        except ValueError as e:
+            raise PriceNotFoundError(str(e)) from None
+
+        rates = []
+        for qualifier in ("core", "ram"):
+            key = entry_key(SERVICE_COMPUTE, region, shape.family, SPOT_QUALIFIER_PREFIX + qualifier)
+            entry = self._entries.get(key)
+            if entry is None:
+                raise PriceNotFoundError(f"No GCP spot price for {region}/{machine_type}")
+            averaged = window_average(self._spot_history.get(key, []), self.spot_window_days)
+            rates.append(averaged if averaged is not None else (entry["price"], None))
+
+        (core, core_days), (ram, ram_days) = rates
+        days = None if core_days is None or ram_days is None else min(core_days, ram_days)
+        gpus = self._gpu_price(region, machine_type, SPOT_ACCELERATOR_QUALIFIER)
+        return shape.vcpus * core + shape.memory_gb * ram + gpus, days
+
+    def refresh(self) -> None:
+        """Download SKUs of every service whose cache entry is missing or stale"""
+        if self.client is None:
+            logger.warning("GCP catalog refresh skipped: no Cloud Billing API key configured")
+            return
+
+        for service_id in self.service_ids:
+            cached = self.cache.load(service_id) if self.cache is not None else None
+            if cached is not None:
+                # Fresh, possibly downloaded by another replica sharing the cache
+                self._merge(cached)
+                continue
+
+            try:
+                skus = self.client.list_skus(service_id)
+            except Exception as e:
+                logger.error(f"GCP SKU download failed for {service_id}: {e}")
+                report_refresh_failure(self.name, service_id, e)
+                continue
+
+            entries = parse_skus(skus, self.regions)
+            document = {
+                "entries": entries,
+                "spot_history": self._next_spot_history(service_id, entries),
+            }
+            if self.cache is not None:
+                self.cache.save(service_id, document)
+            self._merge(document)
+
+            logger.info(f"GCP catalog refreshed: {service_id} ({len(document['entries'])} prices)")
+
+    def _next_spot_history(self, service_id: str, entries: Dict[str, Dict[str, Any]]) -> Dict[str, List[List[float]]]:
+        """Spot rate history of a refreshed catalog, continued from the cached one"""
+        previous = {}
+        if self.cache is not None:
+            cached = self.cache.load(service_id, allow_stale=True)
+            if cached is not None:
+                previous = cached.get("spot_history", {})
+
+        spot_entries = {
+            key: entry for key, entry in entries.items()
+            if f"|{SPOT_QUALIFIER_PREFIX}" in key
+        }
+        return update_history(previous, spot_entries, self.spot_window_days)
+
+    def _load_cached(self) -> None:
+        """Populate prices from any cached catalogs, even stale ones"""
+        if self.cache is None:
+            return
+        for service_id in self.service_ids:
+            document = self.cache.load(service_id, allow_stale=True)
+            if document is not None:
+                self._merge(document)
+
+    def _merge(self, document: Dict[str, Any]) -> None:
+        with self._lock:
+            entries = dict(self._entries)
+            entries.update(document.get("entries", {}))
+            self._entries = entries
+            history = dict(self._spot_history)
+            history.update(document.get("spot_history", {}))
+            self._spot_history = history
+
+
+def _build_gcp_provider(settings) -> GCPPricingProvider:
+    """Create the GCP provider from application settings"""
+    client = None
+    if settings.gcp_billing_api_key:
+        client = CloudBillingClient(
+            api_key=settings.gcp_billing_api_key,
+            base_url=settings.gcp_billing_api_url,
+        )
+
+    return GCPPricingProvider(
+        regions=settings.gcp_pricing_regions,
+        client=client,
+        cache=catalog_cache(settings, "gcp", settings.pricing_cache_ttl),
+        spot_window_days=settings.spot_price_window_days,
+    )
+
+
+register_factory(GCPPricingProvider.name, _build_gcp_provider)
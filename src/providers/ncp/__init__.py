@@ -0,0 +1,20 @@
+"""
+NCP Pricing Provider
+
+Naver Cloud Platform server, block storage and object storage prices
+(KRW) from the NCP Billing API, public or gov cloud.
+"""
+
+from .client import BillingApiClient, DEFAULT_BILLING_API_URL, GOV_BILLING_API_URL
+from .parser import parse_products
+from .provider import NcpPricingProvider
+from .servers import server_shape
+
+__all__ = [
+    "BillingApiClient",
+    "DEFAULT_BILLING_API_URL",
+    "GOV_BILLING_API_URL",
+    "NcpPricingProvider",
+    "parse_products",
+    "server_shape",
+]
@@ -0,0 +1,98 @@
+"""
+NCP Billing API client
+"""
+
+import base64
+import hashlib
+import hmac
+import logging
+import time
+from typing import Any, Dict, List
+from urllib.parse import urlencode
+
+import requests
+
+from ...metrics import observe_pricing_api
+
+logger = logging.getLogger(__name__)
+
+DEFAULT_BILLING_API_URL = "https://billingapi.apigw.ntruss.com"
+# Gov cloud (ncloud.gov) endpoint, set as NCP_BILLING_API_URL
+GOV_BILLING_API_URL = "https://billingapi.apigw.gov-ntruss.com"
+
+PRICE_LIST_PATH = "/billing/v1/product/getProductPriceList"
+
+# productCategoryCode values of the priced products
+CATEGORY_COMPUTE = "COMPUTE"
+CATEGORY_STORAGE = "STORAGE"
+
+
+def signature(secret_key: str, method: str, uri: str, timestamp: str, access_key: str) -> str:
+    """API Gateway signature v2 of a request (uri includes the query string)"""
+    message = f"{method} {uri}\n{timestamp}\n{access_key}"
+    digest = hmac.new(secret_key.encode("utf-8"), message.encode("utf-8"), hashlib.sha256).digest()
+    return base64.b64encode(digest).decode("ascii")
+
+
+class BillingApiClient:
+    """Client for the NCP Billing API (IAM access key authentication)"""
+
+    def __init__(
+        self,
+        access_key: str,
+        secret_key: str,
+        base_url: str = DEFAULT_BILLING_API_URL,
+        timeout: int = 60,
+        page_size: int = 1000,
+    ):
+        """
+        Initialize Billing API client
+
+        Args:
+            access_key: NCP API access key
+            secret_key: NCP API secret key
+            base_url: Billing API endpoint (GOV_BILLING_API_URL for the gov cloud)
+            timeout: Per-request timeout in seconds
+            page_size: Products requested per page
+        """
+        self.access_key = access_key
+        self.secret_key = secret_key
+        self.base_url = base_url.rstrip("/")
+        self.timeout = timeout
+        self.page_size = page_size
+        self.session = requests.Session()
+
+    def product_prices(self, region: str, category: str, currency: str = "KRW") -> List[Dict[str, Any]]:
+        """Fetch every product of a category in a region with its prices, following pages"""
+        products: List[Dict[str, Any]] = []
+        page = 1
+
+        while True:
+            params = {
+                "regionCode": region,
+                "productCategoryCode": category,
+                "payCurrencyCode": currency,
+                "pageNo": page,
+                "pageSize": self.page_size,
+                "responseFormatType": "json",
+            }
+            uri = f"{PRICE_LIST_PATH}?{urlencode(params)}"
+            timestamp = str(int(time.time() * 1000))
+            headers = {
+                "x-ncp-apigw-timestamp": timestamp,
+                "x-ncp-iam-access-key": self.access_key,
+                "x-ncp-apigw-signature-v2": signature(self.secret_key, "GET", uri, timestamp, self.access_key),
+            }
+            with observe_pricing_api("ncp", "get_product_price_list", {"pricing.page": page}):
+                response = self.session.get(f"{self.base_url}{uri}", headers=headers, timeout=self.timeout)
+                response.raise_for_status()
+                data = response.json().get("getProductPriceListResponse", {})
+
+            batch = data.get("productPriceList", [])
+            products.extend(batch)
+            if not batch or len(products) >= int(data.get("totalRows", 0)):
+                break
+            page += 1
+
+        logger.info(f"Fetched {len(products)} NCP {category} products for {region}")
+        return products
@@ -0,0 +1,79 @@
+"""
+NCP product price parser
+
+Reduces Billing API products to hourly server prices, block storage
+GB-month prices by disk type (SSD, HDD) and object storage GB-month
+prices. Servers are keyed by their server spec code (KVM) or product code
+(XEN). Prices are kept in the currency they were listed in, KRW by default.
+"""
+
+from typing import Any, Dict, Iterable, Optional, Tuple
+
+from ...pricing import SERVICE_BLOCK_STORAGE, SERVICE_COMPUTE, SERVICE_OBJECT_STORAGE
+
+# productItemKind codes of the priced products
+KIND_SERVER = "VSVR"
+KIND_GPU_SERVER = "GSVR"
+KIND_BLOCK_STORAGE = "BST"
+KIND_OBJECT_STORAGE = "OBSTR"
+
+# Object storage has a single storage class
+OBJECT_STORAGE_SKU = "STANDARD"
+
+_UNITS = {"HOUR": "hour", "GB": "GB-month", "GB_MONTH": "GB-month"}
+
+
+def entry_key(service: str, region: str, sku: str) -> str:
+    """Lookup key for a parsed price entry"""
+    return "|".join([service, region, sku])
+
+
+def _code(product: Dict[str, Any], field: str) -> str:
+    value = product.get(field)
+    return value.get("code", "") if isinstance(value, dict) else str(value or "")
+
+
+def _classify(product: Dict[str, Any]) -> Optional[Tuple[str, str, str]]:
+    """Map a product to (service, sku, unit), or None if it is not priced by us"""
+    kind = _code(product, "productItemKind")
+    if kind in (KIND_SERVER, KIND_GPU_SERVER):
+        sku = product.get("serverSpecCode") or product.get("productCode")
+        return (SERVICE_COMPUTE, sku, "hour") if sku else None
+    if kind == KIND_BLOCK_STORAGE:
+        disk_type = _code(product, "diskDetailType") or _code(product, "productType")
+        return (SERVICE_BLOCK_STORAGE, disk_type, "GB-month") if disk_type else None
+    if kind == KIND_OBJECT_STORAGE:
+        return SERVICE_OBJECT_STORAGE, OBJECT_STORAGE_SKU, "GB-month"
+    return None
+
+
+def parse_products(products: Iterable[Dict[str, Any]]) -> Dict[str, Dict[str, Any]]:
+    """
+    Extract server, block storage and object storage prices
+
+    Returns:
+        Mapping of entry_key() -> {"price", "unit", "currency"}
+    """
+    entries: Dict[str, Dict[str, Any]] = {}
+
+    for product in products:
+        parsed = _classify(product)
+        if parsed is None:
+            continue
+        service, sku, unit = parsed
+
+        for price in product.get("priceList") or []:
+            if _UNITS.get(_code(price, "unit")) != unit:
+                continue
+            region = (price.get("region") or {}).get("regionCode", "")
+            key = entry_key(service, region, sku)
+            # The first price listed is the base rate; later ones are volume bands
+            if key in entries:
+                continue
+            entries[key] = {
+                "price": float(price.get("price", 0)),
+                "unit": unit,
+                "currency": _code(price, "payCurrency") or "KRW",
+            }
+
+    return entries
@@ -0,0 +1,161 @@
+"""
+NCP pricing provider
+
+Serves Naver Cloud Platform server, block storage and object storage
+pay-as-you-go prices from the NCP Billing API, cached per product
+category and region with a TTL. Prices are listed in KRW; the registry
+converts them to USD. NCP has no spot or reservation prices.
+"""
+
+import logging
+import threading
+from typing import Any, Dict, List, Optional, Set
+
+from ...pricing import (
+    Price,
+    PriceQuery,
+    PricingProvider,
+    PriceNotFoundError,
+    instance_types_of_keys,
+    register_factory,
+    report_refresh_failure,
+    PRICING_ON_DEMAND,
+)
+from ..cache import CatalogCache, catalog_cache
+from .client import BillingApiClient, CATEGORY_COMPUTE, CATEGORY_STORAGE
+from .parser import entry_key, parse_products
+
+logger = logging.getLogger(__name__)
+
+
+class NcpPricingProvider(PricingProvider):
+    """Pricing provider backed by the NCP Billing API"""
+
+    name = "ncp"
+
+    def __init__(
+        self,
+        regions: List[str],
+        client: Optional[BillingApiClient] = None,
+        cache: Optional[CatalogCache] = None,
+        categories: Optional[List[str]] = None,
+        currency: str = "KRW",
+    ):
+        """
+        Initialize NCP provider
+
+        Args:
+            regions: regionCode values to load prices for, e.g. KR
+            client: Billing API client; refresh is skipped without one (no access key)
+            cache: Local catalog cache; its TTL decides when prices are re-fetched
+            categories: productCategoryCode values to load
+            currency: payCurrencyCode prices are requested in
+        """
+        self.regions = regions
+        self.client = client
+        self.cache = cache
+        self.categories = categories or [CATEGORY_COMPUTE, CATEGORY_STORAGE]
+        self.currency = currency
+
+        self._entries: Dict[str, Dict[str, Any]] = {}
+        self._lock = threading.Lock()
+
+        self._load_cached()
+
+    @property
+    def clouds(self) -> List[str]:
+        return ["ncp"]
+
+    @property
+    def loaded(self) -> bool:
+        """Whether any price data is available"""
+        return bool(self._entries)
+
+    def instance_types(self, cloud: str) -> Dict[str, Set[str]]:
+        return instance_types_of_keys(list(self._entries))
+
+    def get_price(self, query: PriceQuery) -> Price:
+        if not self._entries:
+            raise PriceNotFoundError("NCP price catalog not loaded yet")
+        if query.pricing_model != PRICING_ON_DEMAND:
+            raise PriceNotFoundError(f"NCP has no {query.pricing_model} prices")
+
+        entry = self._entries.get(entry_key(query.service, query.region, query.sku))
+        if entry is None:
+            raise PriceNotFoundError(f"No NCP {query.service} price for {query.region}/{query.sku}")
+
+        return Price(
+            provider="ncp",
+            region=query.region,
+            sku=query.sku,
+            service=query.service,
+            unit=entry["unit"],
+            price=entry["price"],
+            currency=entry["currency"],
+            source=self.name,
+            pricing_model=query.pricing_model,
+        )
+
+    def refresh(self) -> None:
+        """Download products of every category/region whose cache entry expired"""
+        if self.client is None:
+            logger.warning("NCP catalog refresh skipped: no API access key configured")
+            return
+
+        for category in self.categories:
+            for region in self.regions:
+                key = f"{category}-{region}"
+                cached = self.cache.load(key) if self.cache is not None else None
+                if cached is not None:
+                    # Fresh, possibly downloaded by another replica sharing the cache
+                    self._merge(cached)
+                    continue
+
+                try:
+                    products = self.client.product_prices(region, category, self.currency)
+                except Exception as e:
+                    logger.error(f"NCP price download failed for {key}: {e}")
+                    report_refresh_failure(self.name, key, e)
+                    continue
+
+                document = {"entries": parse_products(products)}
+                if self.cache is not None:
+                    self.cache.save(key, document)
+                self._merge(document)
+
+                logger.info(f"NCP catalog refreshed: {key} ({len(document['entries'])} prices)")
+
+    def _load_cached(self) -> None:
+        """Populate prices from any cached catalogs, even expired ones"""
+        if self.cache is None:
+            return
+        for category in self.categories:
+            for region in self.regions:
+                document = self.cache.load(f"{category}-{region}", allow_stale=True)
+                if document is not None:
+                    self._merge(document)
+
+    def _merge(self, document: Dict[str, Any]) -> None:
+        with self._lock:
+            entries = dict(self._entries)
+            entries.update(document.get("entries", {}))
+            self._entries = entries
+
+
+def _build_ncp_provider(settings) -> NcpPricingProvider:
+    """Create the NCP provider from application settings"""
+    client = None
+    if settings.ncp_access_key and settings.ncp_secret_key:
+        client = BillingApiClient(
+            access_key=settings.ncp_access_key,
+            secret_key=settings.ncp_secret_key,
+            base_url=settings.ncp_billing_api_url,
+        )
+    return NcpPricingProvider(
+        regions=settings.ncp_pricing_regions,
+        client=client,
+        cache=catalog_cache(settings, "ncp", settings.ncp_pricing_cache_ttl),
+    )
+
+
+register_factory(NcpPricingProvider.name, _build_ncp_provider)
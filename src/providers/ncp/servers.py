@@ -0,0 +1,35 @@
+"""
+NCP server specs
+
+vCPUs and memory of NCP servers, read from their spec codes:
+
+- XEN servers by product code, e.g. SVR.VSVR.STAND.C002.M008.NET.SSD.B050.G002
+  (2 vCPUs, 8 GB)
+- KVM servers by server spec code <family><vcpus>-g<generation>, e.g. s2-g3,
+  with 2 (c, compute), 4 (s, standard) or 8 (m, memory) GB per vCPU
+"""
+
+import re
+from typing import Optional
+
+from ...pricing.instance_types import InstanceShape, register_shape_resolver
+
+_PRODUCT_CODE = re.compile(r"\.C(\d{3})\.M(\d{3})\.")
+_SPEC_CODE = re.compile(r"^([csm])(\d+)-g(\d+)$")
+
+_MEMORY_PER_VCPU = {"c": 2.0, "s": 4.0, "m": 8.0}
+
+
+def server_shape(code: str) -> Optional[InstanceShape]:
+    """Shape of a server product or spec code, None if it is not one"""
+    match = _PRODUCT_CODE.search(code)
+    if match is not None:
+        return InstanceShape(float(int(match.group(1))), float(int(match.group(2))))
+    match = _SPEC_CODE.match(code.lower())
+    if match is not None:
+        vcpus = float(match.group(2))
+        return InstanceShape(vcpus, vcpus * _MEMORY_PER_VCPU[match.group(1)])
+    return None
+
+
+register_shape_resolver("ncp", server_shape)
@@ -0,0 +1,16 @@
+"""
+OpenStack Pricing Provider
+
+Flavor and Cinder volume prices of private clouds from an administrator
+defined rate card.
+"""
+
+from .rates import Flavor, PrivateCloudRates, load_rates
+from .provider import OpenStackPricingProvider
+
+__all__ = [
+    "Flavor",
+    "PrivateCloudRates",
+    "load_rates",
+    "OpenStackPricingProvider",
+]
@@ -0,0 +1,158 @@
+"""
+OpenStack pricing provider
+
+Serves Nova flavor and Cinder volume prices of a private cloud from its
+rate card. The rate card comes from OPENSTACK_RATES_PATH and can be
+replaced through the API; replaced rates are kept in the catalog cache
+so they survive restarts until they are reset to the configured ones.
+Private clouds have no spot or commitment prices.
+"""
+
+import logging
+import threading
+from typing import Dict, List, Optional, Set
+
+from ...pricing import (
+    ANY_REGION,
+    Price,
+    PriceQuery,
+    PricingProvider,
+    PriceNotFoundError,
+    register_factory,
+    PRICING_ON_DEMAND,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_COMPUTE,
+)
+from ...pricing.instance_types import InstanceShape, register_shape_resolver
+from ...units import MB_PER_GB
+from ..cache import CatalogCache, catalog_cache
+from .rates import PrivateCloudRates, load_rates
+
+logger = logging.getLogger(__name__)
+
+# Catalog cache key of rates set through the API
+RATES_KEY = "rates"
+
+RATES_SOURCE_CONFIG = "config"
+RATES_SOURCE_API = "api"
+
+
+class OpenStackPricingProvider(PricingProvider):
+    """Pricing provider backed by a private cloud's rate card"""
+
+    name = "openstack"
+
+    def __init__(self, rates: Optional[PrivateCloudRates] = None, cache: Optional[CatalogCache] = None):
+        """
+        Initialize OpenStack provider
+
+        Args:
+            rates: Configured rate card. Nothing is priced until rates are set if not provided.
+            cache: Catalog cache keeping rates set through the API
+        """
+        self.configured = rates
+        self.cache = cache
+        self._rates = rates
+        self._source = RATES_SOURCE_CONFIG if rates is not None else None
+        self._lock = threading.Lock()
+
+        self._load_saved()
+        register_shape_resolver(self.name, self._flavor_shape)
+
+    @property
+    def clouds(self) -> List[str]:
+        return ["openstack"]
+
+    def instance_types(self, cloud: str) -> Dict[str, Set[str]]:
+        """Flavors of the rate card, in ANY_REGION if it prices every region"""
+        rates = self._rates
+        if rates is None:
+            return {}
+        return {region: set(rates.flavors) for region in rates.regions or [ANY_REGION]}
+
+    @property
+    def rates(self) -> Optional[PrivateCloudRates]:
+        """Rate card in use, None if none was configured or set"""
+        return self._rates
+
+    @property
+    def rates_source(self) -> Optional[str]:
+        """config or api, None without a rate card"""
+        return self._source
+
+    def set_rates(self, rates: PrivateCloudRates) -> None:
+        """Replace the rate card, keeping it over the configured one"""
+        if self.cache is not None:
+            self.cache.save(RATES_KEY, {"rates": rates.dict()})
+        with self._lock:
+            self._rates, self._source = rates, RATES_SOURCE_API
+        logger.info(f"OpenStack rate card replaced ({len(rates.flavors)} flavors)")
+
+    def reset_rates(self) -> None:
+        """Drop rates set through the API and use the configured rate card again"""
+        if self.cache is not None:
+            self.cache.save(RATES_KEY, {"rates": None})
+        with self._lock:
+            self._rates = self.configured
+            self._source = RATES_SOURCE_CONFIG if self.configured is not None else None
+
+    def get_price(self, query: PriceQuery) -> Price:
+        rates = self._rates
+        if rates is None:
+            raise PriceNotFoundError("No OpenStack rate card configured")
+        if rates.regions and query.region not in rates.regions:
+            raise PriceNotFoundError(f"OpenStack region {query.region} is not priced")
+        if query.pricing_model != PRICING_ON_DEMAND:
+            raise PriceNotFoundError(f"OpenStack has no {query.pricing_model} prices")
+
+        if query.service == SERVICE_COMPUTE:
+            price = rates.flavor_hourly(query.sku)
+            if price is None:
+                raise PriceNotFoundError(f"Unknown OpenStack flavor '{query.sku}'")
+            unit = "hour"
+        elif query.service == SERVICE_BLOCK_STORAGE:
+            price, unit = rates.volume_gb_monthly(query.sku), "GB-month"
+        else:
+            raise PriceNotFoundError(f"OpenStack has no {query.service} prices")
+
+        return Price(
+            provider="openstack",
+            region=query.region,
+            sku=query.sku,
+            service=query.service,
+            unit=unit,
+            price=price,
+            source=self.name,
+            pricing_model=query.pricing_model,
+        )
+
+    def _flavor_shape(self, name: str) -> Optional[InstanceShape]:
+        """vCPUs and memory of a flavor of the rate card"""
+        flavor = self._rates.flavors.get(name) if self._rates is not None else None
+        if flavor is None:
+            return None
+        return InstanceShape(flavor.vcpus, flavor.ram_mb / MB_PER_GB)
+
+    def _load_saved(self) -> None:
+        """Use rates set through the API before a restart"""
+        if self.cache is None:
+            return
+        document = self.cache.load(RATES_KEY, allow_stale=True)
+        if document is None or document.get("rates") is None:
+            return
+        try:
+            self._rates, self._source = PrivateCloudRates(**document["rates"]), RATES_SOURCE_API
+        except ValueError as e:
+            logger.warning(f"Ignoring invalid saved OpenStack rate card: {e}")
+
+
+def _build_openstack_provider(settings) -> OpenStackPricingProvider:
+    """Create the OpenStack provider from application settings"""
+    path = getattr(settings, "openstack_rates_path", "")
+    return OpenStackPricingProvider(
+        rates=load_rates(path) if path else None,
+        cache=catalog_cache(settings, "openstack", settings.pricing_cache_ttl),
+    )
+
+
+register_factory(OpenStackPricingProvider.name, _build_openstack_provider)
@@ -0,0 +1,77 @@
+"""
+Private cloud rate card
+
+Administrators price their cloud's capacity per vCPU-hour, GB-RAM-hour and
+GB storage-month. A flavor costs its vCPUs, RAM and root plus ephemeral
+disk at those rates unless it sets its own hourly price; a Cinder volume
+type costs its own GB-month rate, or the storage rate.
+"""
+
+import json
+import logging
+from typing import Dict, List, Optional
+
+from pydantic import BaseModel, Field
+
+from ...units import INTERVAL_HOUR, INTERVAL_MONTH, MB_PER_GB, convert_rate
+
+logger = logging.getLogger(__name__)
+
+
+class Flavor(BaseModel):
+    """Nova flavor, sized as in `openstack flavor show`"""
+
+    vcpus: int = Field(..., gt=0)
+    ram_mb: int = Field(..., gt=0)
+    disk_gb: float = Field(default=0.0, ge=0, description="Root disk")
+    ephemeral_gb: float = Field(default=0.0, ge=0)
+    hourly: Optional[float] = Field(None, ge=0, description="Price of the flavor instead of its resources' rates")
+
+
+class PrivateCloudRates(BaseModel):
+    """Rate card of a private cloud"""
+
+    vcpu_hourly: float = Field(..., ge=0, description="Price per vCPU-hour")
+    memory_gb_hourly: float = Field(..., ge=0, description="Price per GB of RAM per hour")
+    storage_gb_monthly: float = Field(
+        ..., ge=0, description="Price per GB-month of flavor disks and volume types without a rate"
+    )
+    regions: List[str] = Field(default_factory=list, description="Regions priced, any region if empty")
+    flavors: Dict[str, Flavor] = Field(default_factory=dict)
+    volume_types: Dict[str, float] = Field(default_factory=dict, description="Cinder volume type -> price per GB-month")
+
+    def flavor_hourly(self, name: str) -> Optional[float]:
+        """Hourly price of a flavor, None if it is not defined"""
+        flavor = self.flavors.get(name)
+        if flavor is None:
+            return None
+        if flavor.hourly is not None:
+            return flavor.hourly
+        disk_gb = flavor.disk_gb + flavor.ephemeral_gb
+        return (
+            flavor.vcpus * self.vcpu_hourly
+            + flavor.ram_mb / MB_PER_GB * self.memory_gb_hourly
+            + disk_gb * convert_rate(self.storage_gb_monthly, INTERVAL_MONTH, INTERVAL_HOUR)
+        )
+
+    def volume_gb_monthly(self, volume_type: str) -> float:
+        """GB-month price of a volume type"""
+        return self.volume_types.get(volume_type, self.storage_gb_monthly)
+
+
+def load_rates(path: str) -> PrivateCloudRates:
+    """
+    Load a rate card from a JSON file
+
+    Raises:
+        ValueError: If the file is not a valid rate card
+    """
+    with open(path, "r", encoding="utf-8") as f:
+        document = json.load(f)
+
+    if not isinstance(document, dict):
+        raise ValueError(f"OpenStack rate card {path} must be a JSON object")
+
+    rates = PrivateCloudRates(**document)
+    logger.info(f"OpenStack rate card loaded from {path} ({len(rates.flavors)} flavors)")
+    return rates
@@ -0,0 +1,31 @@
+"""
+Pulumi Preview Cost Estimation Module
+
+Estimates the monthly cost delta of `pulumi preview --json` output by
+mapping the previewed resources of Pulumi's bridged providers onto the
+Terraform resource mappers.
+"""
+
+from .models import PulumiEstimateRequest
+from .preview import (
+    PROVIDER_PACKAGES,
+    PULUMI_RESOURCE_TYPES,
+    load_preview,
+    preview_providers,
+    resource_changes,
+    terraform_type,
+    terraform_values,
+)
+from .estimator import PulumiEstimator
+
+__all__ = [
+    "PulumiEstimateRequest",
+    "PROVIDER_PACKAGES",
+    "PULUMI_RESOURCE_TYPES",
+    "load_preview",
+    "preview_providers",
+    "resource_changes",
+    "terraform_type",
+    "terraform_values",
+    "PulumiEstimator",
+]
@@ -0,0 +1,47 @@
+"""
+Pulumi preview cost estimator
+
+Prices the resource changes of a preview with the Terraform estimator,
+whose resource mappers and discount handling apply to the Terraform
+resources Pulumi's bridged providers wrap.
+"""
+
+import logging
+
+from ..terraform import TerraformEstimateResult, TerraformEstimator
+from .models import PulumiEstimateRequest
+from .preview import load_preview, resource_changes
+
+logger = logging.getLogger(__name__)
+
+
+class PulumiEstimator:
+    """Estimates the monthly cost delta of a Pulumi preview"""
+
+    def __init__(self, terraform: TerraformEstimator):
+        """
+        Initialize Pulumi estimator
+
+        Args:
+            terraform: Estimator pricing the changes by their Terraform resource type
+        """
+        self.terraform = terraform
+
+    def estimate(self, request: PulumiEstimateRequest) -> TerraformEstimateResult:
+        """
+        Estimate the cost delta of the request's preview
+
+        Resources without a mapper, or whose prices are unavailable, are
+        reported as unpriced rather than failing the estimate.
+
+        Raises:
+            ValueError: If the preview is not valid `pulumi preview --json` output
+        """
+        preview = load_preview(request.preview)
+        result = self.terraform.estimate_changes(resource_changes(preview), request.region, request.hours)
+
+        logger.info(
+            f"Pulumi estimate: +{len(result.added)} ~{len(result.changed)} -{len(result.destroyed)} "
+            f"({len(result.unpriced)} unpriced), delta ${result.monthly_delta:.2f}/month"
+        )
+        return result
@@ -0,0 +1,19 @@
+"""
+Data models for Pulumi preview cost estimation
+"""
+
+from typing import Any, Dict, Optional, Union
+
+from pydantic import BaseModel, Field
+
+from ..units import hours_per_month
+
+
+class PulumiEstimateRequest(BaseModel):
+    """Request model for the Pulumi estimate"""
+
+    preview: Union[Dict[str, Any], str] = Field(..., description="Output of `pulumi preview --json`")
+    region: Optional[str] = Field(None, description="Fallback region when the stack configuration sets none")
+    hours: float = Field(default_factory=hours_per_month, gt=0, le=744, description="Running hours per month")
+    project: Optional[str] = Field(None, description="Project the estimate is recorded under")
+    labels: Dict[str, str] = Field(default_factory=dict, description="Metadata kept with the estimate, e.g. CI run URL")
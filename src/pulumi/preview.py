@@ -0,0 +1,224 @@
+"""
+Pulumi preview reader
+
+Extracts resource changes from `pulumi preview --json` output. The
+resources of Pulumi's bridged providers (aws, gcp, azure, openstack) wrap
+Terraform resources, so each change is typed as the Terraform resource it
+wraps and priced by the Terraform resource mappers: Pulumi's camelCase
+inputs are renamed to Terraform attributes and nested objects become
+one-block lists. Values only known after the update runs are left out.
+"""
+
+import json
+import re
+from typing import Any, Dict, List, Optional, Union
+
+from ..terraform import ResourceChange, get_mapper
+from ..terraform.models import ACTION_CREATE, ACTION_DELETE, ACTION_REPLACE, ACTION_UPDATE
+
+# Pulumi package -> Terraform provider it bridges
+PROVIDER_PACKAGES = {
+    "aws": "aws",
+    "gcp": "google",
+    "azure": "azurerm",
+    "openstack": "openstack",
+}
+
+# Resource types whose Terraform name does not follow from the Pulumi token
+PULUMI_RESOURCE_TYPES = {
+    "aws:rds/instance:Instance": "aws_db_instance",
+    "openstack:compute/instance:Instance": "openstack_compute_instance_v2",
+}
+
+# Block lists Pulumi names in the plural
+_LIST_BLOCKS = {
+    "ebs_block_devices": "ebs_block_device",
+    "guest_accelerators": "guest_accelerator",
+    "block_devices": "block_device",
+}
+
+# Provider configuration keys holding the region
+_REGION_KEYS = ("region", "location", "zone")
+
+# Placeholder of values not known until the update runs
+UNKNOWN_VALUE = "04da6b54-80e4-46f7-96ec-b56ff0331ba9"
+# Key marking a secret value, whose plaintext is in "value" if shown
+_SECRET_SIGNATURE = "4dabf18193072939515e22adb298388d"
+
+_OP_ACTIONS = {
+    "create": ACTION_CREATE,
+    "update": ACTION_UPDATE,
+    "delete": ACTION_DELETE,
+    "replace": ACTION_REPLACE,
+    "create-replacement": ACTION_REPLACE,
+    "delete-replaced": ACTION_REPLACE,
+}
+
+_PROVIDER_TYPE_PREFIX = "pulumi:providers:"
+_CAMEL = re.compile(r"(?<=[a-z0-9])([A-Z])")
+
+
+def load_preview(preview: Union[Dict[str, Any], str]) -> Dict[str, Any]:
+    """
+    Decode a preview document
+
+    Raises:
+        ValueError: If the preview is not valid JSON or not a preview object
+    """
+    if isinstance(preview, str):
+        try:
+            preview = json.loads(preview)
+        except json.JSONDecodeError as e:
+            raise ValueError(f"Invalid preview JSON: {e}") from None
+
+    if not isinstance(preview, dict) or not isinstance(preview.get("steps"), list):
+        raise ValueError("Not a Pulumi preview: expected `pulumi preview --json` output with steps")
+    return preview
+
+
+def terraform_type(pulumi_type: str) -> Optional[str]:
+    """
+    Terraform resource type a Pulumi resource type wraps, None if it has no mapper
+
+    Tokens not listed in PULUMI_RESOURCE_TYPES are named as the bridge
+    names them, e.g. aws:ebs/volume:Volume -> aws_ebs_volume or
+    aws:eks/nodeGroup:NodeGroup -> aws_eks_node_group.
+    """
+    known = PULUMI_RESOURCE_TYPES.get(pulumi_type)
+    if known is not None:
+        return known
+    parts = pulumi_type.split(":")
+    if len(parts) != 3 or parts[0] not in PROVIDER_PACKAGES:
+        return None
+    prefix = PROVIDER_PACKAGES[parts[0]]
+    module = _snake(parts[1].split("/", 1)[0])
+    name = _snake(parts[2])
+    for candidate in (f"{prefix}_{module}_{name}", f"{prefix}_{name}"):
+        if get_mapper(candidate) is not None:
+            return candidate
+    return None
+
+
+def terraform_values(inputs: Dict[str, Any]) -> Dict[str, Any]:
+    """Pulumi resource inputs as the Terraform attribute values mappers read"""
+    values = {}
+    for key, value in inputs.items():
+        value = _known(value)
+        if value is None:
+            continue
+        name = _snake(key)
+        if isinstance(value, dict):
+            values[name] = [terraform_values(value)]
+        elif isinstance(value, list):
+            values[_LIST_BLOCKS.get(name, name)] = [
+                terraform_values(item) if isinstance(item, dict) else item
+                for item in (_known(item) for item in value) if item is not None
+            ]
+        else:
+            values[name] = value
+    return values
+
+
+def resource_changes(preview: Dict[str, Any]) -> List[ResourceChange]:
+    """
+    Changes of a decoded preview's resources, in preview order
+
+    The steps replacing a resource are merged into one replace change.
+    Providers and component resources are left out.
+    """
+    regions = _provider_regions(preview)
+    steps: Dict[str, List[Dict[str, Any]]] = {}
+    for step in preview.get("steps") or []:
+        if isinstance(step, dict) and step.get("op") in _OP_ACTIONS and step.get("urn"):
+            steps.setdefault(step["urn"], []).append(step)
+
+    changes = []
+    for urn, resource_steps in steps.items():
+        old = next((s["oldState"] for s in resource_steps if isinstance(s.get("oldState"), dict)), None)
+        new = next((s["newState"] for s in resource_steps if isinstance(s.get("newState"), dict)), None)
+        state = new or old or {}
+        pulumi_type = state.get("type") or _urn_type(urn)
+        if pulumi_type.startswith("pulumi:") or state.get("custom") is False:
+            continue
+
+        ops = {s["op"] for s in resource_steps}
+        action = ACTION_REPLACE if len(ops) > 1 else _OP_ACTIONS[ops.pop()]
+        package = pulumi_type.split(":", 1)[0]
+        provider = state.get("provider") or ""
+        changes.append(ResourceChange(
+            address=urn.split("::", 2)[-1],
+            type=terraform_type(pulumi_type) or pulumi_type,
+            action=action,
+            provider=PROVIDER_PACKAGES.get(package, package),
+            region=regions.get(provider.rsplit("::", 1)[0], regions.get(package)),
+            before=_values(old, old_state=True) if action != ACTION_CREATE else None,
+            after=_values(new) if action != ACTION_DELETE else None,
+        ))
+    return changes
+
+
+def preview_providers(preview: Dict[str, Any]) -> List[str]:
+    """Terraform provider of each resource step of a preview, for metrics"""
+    providers = []
+    for step in preview.get("steps") or []:
+        if isinstance(step, dict) and isinstance(step.get("urn"), str):
+            package = _urn_type(step["urn"]).split(":", 1)[0]
+            providers.append(PROVIDER_PACKAGES.get(package, package))
+    return providers
+
+
+def _values(state: Optional[Dict[str, Any]], old_state: bool = False) -> Optional[Dict[str, Any]]:
+    """Attribute values of a resource state; deployed resources' outputs include defaults the inputs leave out"""
+    if state is None:
+        return None
+    values = (state.get("outputs") if old_state else None) or state.get("inputs") or {}
+    return terraform_values(values) if isinstance(values, dict) else {}
+
+
+def _provider_regions(preview: Dict[str, Any]) -> Dict[str, str]:
+    """Provider URN, and package of default providers from the stack configuration -> region"""
+    config: Dict[str, Dict[str, Any]] = {}
+    for key, value in (preview.get("config") or {}).items():
+        package, _, name = key.partition(":")
+        config.setdefault(package, {})[name] = value
+    regions = {}
+    for package, settings in config.items():
+        region = _region(settings)
+        if region is not None:
+            regions[package] = region
+
+    for step in preview.get("steps") or []:
+        state = (step.get("newState") or step.get("oldState") or {}) if isinstance(step, dict) else {}
+        if not str(state.get("type", "")).startswith(_PROVIDER_TYPE_PREFIX):
+            continue
+        region = _region(state.get("inputs") or {})
+        if region is not None:
+            regions[step.get("urn", "")] = region
+    return regions
+
+
+def _region(settings: Dict[str, Any]) -> Optional[str]:
+    """Region of provider settings, None if they set none"""
+    for key in _REGION_KEYS:
+        value = settings.get(key)
+        if isinstance(value, str) and value and value != UNKNOWN_VALUE:
+            # A zone (us-central1-a) is in the region its name starts with
+            return value.rsplit("-", 1)[0] if key == "zone" else value
+    return None
+
+
+def _known(value: Any) -> Any:
+    """A value with secrets unwrapped, None if not known until the update runs"""
+    if isinstance(value, dict) and _SECRET_SIGNATURE in value:
+        value = value.get("value")
+    return None if value == UNKNOWN_VALUE else value
+
+
+def _urn_type(urn: str) -> str:
+    """Type of a resource URN, urn:pulumi:<stack>::<project>::<parent types$>type::<name>"""
+    parts = urn.split("::")
+    return parts[2].rsplit("$", 1)[-1] if len(parts) >= 4 else ""
+
+
+def _snake(name: str) -> str:
+    return _CAMEL.sub(r"_\1", name).lower()
@@ -0,0 +1,114 @@
+"""
+Reports Module
+
+This module reports on recorded estimates against the actual spend
+ingested from billing exports, so teams can see how far the estimator's
+figures were from what they were billed, and produces the monthly
+showback/chargeback statements teams are charged from, FOCUS exports
+for other FinOps tools and the weekly or monthly cost reports tenants
+schedule to be mailed.
+"""
+
+from .models import (
+    AccuracyEntry,
+    AccuracyReport,
+    GroupAccuracy,
+    ChargebackGroup,
+    ChargebackLineItem,
+    ChargebackReport,
+    ChargebackReportRequest,
+    BudgetLine,
+    CostChangeLine,
+    CostReport,
+    RecommendationLine,
+    ReportSchedule,
+    ReportScheduleSpec,
+    GROUP_BY_PROJECT,
+    GROUP_BY_LABEL_PREFIX,
+    FREQUENCIES,
+    FREQUENCY_MONTHLY,
+    FREQUENCY_WEEKLY,
+    REPORT_SECTIONS,
+    SECTION_BUDGETS,
+    SECTION_COST_CHANGES,
+    SECTION_RECOMMENDATIONS,
+)
+from .accuracy import AccuracyReporter, estimated_monthly_cost, LOOKBACK_DAYS
+from .chargeback import ChargebackReporter, render_csv, render_pdf
+from .focus import (
+    FocusExporter,
+    focus_csv,
+    focus_parquet,
+    render_focus,
+    FOCUS_COLUMNS,
+    FOCUS_FORMATS,
+    FOCUS_VERSION,
+    DATASETS,
+    DATASET_ACTUALS,
+    DATASET_ESTIMATES,
+    DATASET_ALL,
+)
+from .mail import ChargebackSchedule, Mailer, CHECK_INTERVAL_SECONDS
+from .scheduled import (
+    CostReportBuilder,
+    ReportScheduler,
+    is_due,
+    render_report_csv,
+    render_report_html,
+    render_report_text,
+    report_period,
+    DEFAULT_TOP_ITEMS,
+)
+
+__all__ = [
+    "AccuracyEntry",
+    "AccuracyReport",
+    "GroupAccuracy",
+    "ChargebackGroup",
+    "ChargebackLineItem",
+    "ChargebackReport",
+    "ChargebackReportRequest",
+    "BudgetLine",
+    "CostChangeLine",
+    "CostReport",
+    "RecommendationLine",
+    "ReportSchedule",
+    "ReportScheduleSpec",
+    "GROUP_BY_PROJECT",
+    "GROUP_BY_LABEL_PREFIX",
+    "FREQUENCIES",
+    "FREQUENCY_MONTHLY",
+    "FREQUENCY_WEEKLY",
+    "REPORT_SECTIONS",
+    "SECTION_BUDGETS",
+    "SECTION_COST_CHANGES",
+    "SECTION_RECOMMENDATIONS",
+    "AccuracyReporter",
+    "estimated_monthly_cost",
+    "LOOKBACK_DAYS",
+    "ChargebackReporter",
+    "render_csv",
+    "render_pdf",
+    "FocusExporter",
+    "focus_csv",
+    "focus_parquet",
+    "render_focus",
+    "FOCUS_COLUMNS",
+    "FOCUS_FORMATS",
+    "FOCUS_VERSION",
+    "DATASETS",
+    "DATASET_ACTUALS",
+    "DATASET_ESTIMATES",
+    "DATASET_ALL",
+    "ChargebackSchedule",
+    "Mailer",
+    "CHECK_INTERVAL_SECONDS",
+    "CostReportBuilder",
+    "ReportScheduler",
+    "is_due",
+    "render_report_csv",
+    "render_report_html",
+    "render_report_text",
+    "report_period",
+    "DEFAULT_TOP_ITEMS",
+]
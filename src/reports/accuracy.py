@@ -0,0 +1,238 @@
+"""
+Estimate accuracy
+
+Correlates recorded estimates with the actual spend later ingested for
+the same project (or label value). For every completed month of the
+report period, the estimate in effect for a group is the latest one
+recorded before the month ended, at most LOOKBACK_DAYS before it began;
+Terraform estimates count with the plan's total monthly cost after apply
+rather than its delta. Months are reported once they are over, so
+partial spend is never compared with a full month's estimate.
+"""
+
+import logging
+from datetime import date, datetime, time, timedelta, timezone
+from typing import Callable, Dict, List, Optional, Tuple
+
+from ..billing import month_bounds, next_month
+from ..money import round_money
+from ..store import ActualCostRecord, EstimateRecord, KIND_PULUMI, KIND_TERRAFORM, Store
+from .models import AccuracyEntry, AccuracyReport, GroupAccuracy, GROUP_BY_PROJECT, GROUP_BY_LABEL_PREFIX
+
+logger = logging.getLogger(__name__)
+
+# Oldest estimate still considered in effect, in days before a month began
+LOOKBACK_DAYS = 90
+# Estimates read for one report
+MAX_ESTIMATES = 10000
+# Months one report may cover
+MAX_MONTHS = 24
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+def _at(day: date) -> datetime:
+    return datetime.combine(day, time.min, tzinfo=timezone.utc)
+
+
+def estimated_monthly_cost(record: EstimateRecord) -> float:
+    """Monthly cost an estimate predicts; for Terraform plans and Pulumi previews, the cost after apply"""
+    if record.kind in (KIND_TERRAFORM, KIND_PULUMI):
+        return float(record.result.get("after_monthly_cost", record.monthly_cost))
+    return record.monthly_cost
+
+
+def _percent(error: float, actual: float) -> Optional[float]:
+    if actual == 0:
+        return None
+    return round(error / abs(actual) * 100, 2)
+
+
+class AccuracyReporter:
+    """Builds estimate-vs-actual accuracy reports from the store"""
+
+    def __init__(self, store: Store, clock: Callable[[], datetime] = utcnow):
+        """
+        Initialize reporter
+
+        Args:
+            store: Store holding estimates and actual costs
+            clock: Current time (aware UTC)
+        """
+        self.store = store
+        self.clock = clock
+
+    def report(
+        self,
+        tenant_id: str,
+        since: Optional[str] = None,
+        until: Optional[str] = None,
+        project: Optional[str] = None,
+        labels: Optional[Dict[str, str]] = None,
+        group_by: str = GROUP_BY_PROJECT,
+    ) -> AccuracyReport:
+        """
+        Accuracy of a tenant's estimates over completed months
+
+        Args:
+            tenant_id: Tenant the estimates and costs belong to
+            since: First month (YYYY-MM), default two months before until
+            until: Last month (YYYY-MM), default the previous month
+            project: Only estimates and costs of this project
+            labels: Only estimates and costs carrying all these labels
+            group_by: "project" or "label:<key>"
+
+        Raises:
+            ValueError: Malformed months or grouping, or a period that is not over
+        """
+        labels = labels or {}
+        group_of = _grouping(group_by)
+        start, end = self._period(since, until)
+
+        estimates = [
+            record for record in self.store.list_estimates(
+                project=project,
+                since=_at(start - timedelta(days=LOOKBACK_DAYS)),
+                until=_at(end),
+                limit=MAX_ESTIMATES,
+                tenant_id=tenant_id,
+            )
+            if _matches(record.labels, labels) and group_of(record.project, record.labels) is not None
+        ]
+        if len(estimates) == MAX_ESTIMATES:
+            logger.warning(f"Accuracy report of tenant {tenant_id} limited to the newest {MAX_ESTIMATES} estimates")
+        costs = [
+            cost for cost in self.store.list_actual_costs(tenant_id, start, end, project=project)
+            if _matches(cost.labels, labels)
+        ]
+
+        actuals = _actuals_by_month(costs, group_of)
+        entries = []
+        unestimated = 0.0
+        month = start
+        while month < end:
+            key = f"{month:%Y-%m}"
+            in_effect = _in_effect(estimates, group_of, month)
+            spend = actuals.get(key, {})
+            for group in sorted(set(in_effect) | set(spend)):
+                actual = spend.get(group, 0.0)
+                record = in_effect.get(group)
+                if record is None:
+                    unestimated += actual
+                    continue
+                estimated = estimated_monthly_cost(record)
+                entries.append(AccuracyEntry(
+                    group=group,
+                    month=key,
+                    estimate_id=record.id,
+                    estimate_kind=record.kind,
+                    estimated_at=record.created_at,
+                    estimated=round_money(estimated),
+                    actual=round_money(actual),
+                    error=round_money(estimated - actual),
+                    error_percent=_percent(estimated - actual, actual),
+                ))
+            month = next_month(month)
+
+        return AccuracyReport(
+            period_start=start,
+            period_end=end,
+            group_by=group_by,
+            entries=entries,
+            groups=_group_summaries(entries),
+            mean_absolute_percent_error=_mean_absolute(entries),
+            unestimated_actual=round_money(unestimated),
+        )
+
+    def _period(self, since: Optional[str], until: Optional[str]) -> Tuple[date, date]:
+        current = self.clock().date().replace(day=1)
+        end = month_bounds(until)[1] if until else current
+        if end > current:
+            raise ValueError(f"Month {until} is not over yet")
+        start = month_bounds(since)[0] if since else _months_before(end, 3)
+        if start >= end:
+            raise ValueError("'since' must not be after 'until'")
+        if start < _months_before(end, MAX_MONTHS):
+            raise ValueError(f"A report covers at most {MAX_MONTHS} months")
+        return start, end
+
+
+def _grouping(group_by: str) -> Callable[[Optional[str], Dict[str, str]], Optional[str]]:
+    """Group of an estimate or cost by its project and labels"""
+    if group_by == GROUP_BY_PROJECT:
+        return lambda project, labels: project
+    if group_by.startswith(GROUP_BY_LABEL_PREFIX) and len(group_by) > len(GROUP_BY_LABEL_PREFIX):
+        key = group_by[len(GROUP_BY_LABEL_PREFIX):]
+        return lambda project, labels: labels.get(key)
+    raise ValueError(f"Invalid group_by {group_by!r}, expected project or label:<key>")
+
+
+def _matches(labels: Dict[str, str], required: Dict[str, str]) -> bool:
+    return all(labels.get(key) == value for key, value in required.items())
+
+
+def _months_before(month: date, count: int) -> date:
+    year, index = divmod(month.year * 12 + month.month - 1 - count, 12)
+    return date(year, index + 1, 1)
+
+
+def _in_effect(
+    estimates: List[EstimateRecord],
+    group_of: Callable[[Optional[str], Dict[str, str]], Optional[str]],
+    month: date,
+) -> Dict[str, EstimateRecord]:
+    """Latest estimate of each group recorded before a month ended"""
+    earliest, end = _at(month - timedelta(days=LOOKBACK_DAYS)), _at(next_month(month))
+    latest: Dict[str, EstimateRecord] = {}
+    for record in estimates:
+        if record.created_at is None or not earliest <= record.created_at < end:
+            continue
+        group = group_of(record.project, record.labels)
+        current = latest.get(group)
+        if current is None or record.created_at > current.created_at:
+            latest[group] = record
+    return latest
+
+
+def _actuals_by_month(
+    costs: List[ActualCostRecord],
+    group_of: Callable[[Optional[str], Dict[str, str]], Optional[str]],
+) -> Dict[str, Dict[str, float]]:
+    """Spend of each group by month; costs outside every group are left out"""
+    totals: Dict[str, Dict[str, float]] = {}
+    for cost in costs:
+        group = group_of(cost.project, cost.labels)
+        if group is None:
+            continue
+        month = totals.setdefault(f"{cost.usage_date:%Y-%m}", {})
+        month[group] = month.get(group, 0.0) + cost.amount
+    return totals
+
+
+def _group_summaries(entries: List[AccuracyEntry]) -> List[GroupAccuracy]:
+    by_group: Dict[str, List[AccuracyEntry]] = {}
+    for entry in entries:
+        by_group.setdefault(entry.group, []).append(entry)
+
+    summaries = []
+    for group, group_entries in sorted(by_group.items()):
+        estimated = sum(e.estimated for e in group_entries)
+        actual = sum(e.actual for e in group_entries)
+        summaries.append(GroupAccuracy(
+            group=group,
+            months=len(group_entries),
+            estimated=round_money(estimated),
+            actual=round_money(actual),
+            mean_absolute_percent_error=_mean_absolute(group_entries),
+            bias_percent=_percent(estimated - actual, actual),
+        ))
+    return summaries
+
+
+def _mean_absolute(entries: List[AccuracyEntry]) -> Optional[float]:
+    errors = [abs(e.error_percent) for e in entries if e.error_percent is not None]
+    if not errors:
+        return None
+    return round(sum(errors) / len(errors), 2)
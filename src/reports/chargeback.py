@@ -0,0 +1,288 @@
+"""
+Showback / chargeback statements
+
+A tenant's actual spend of a month is rolled up into line items, one per
+provider SKU, for each group (team, project or namespace) the spend is
+tagged with. The tenant's discount rules are applied to the line items in
+a fixed order, groups by name and their items by SKU, so tiered rules
+count usage over the whole month. Spend without a group is shared and
+split between the groups as in cost allocation. Statements render as
+CSV or as a PDF for distribution.
+
+Statements are invoicing-grade: every line item is rounded once to the
+minor unit of the statement's currency (after conversion from USD), and
+the groups' shares of shared spend are allocated so they add up to it,
+so group and statement totals are the exact sums of the amounts shown.
+"""
+
+import csv
+import io
+from datetime import datetime, timezone
+from decimal import Decimal
+from typing import Callable, Dict, Iterable, List, Optional, Tuple
+
+from ..allocation import AllocationEngine, split_shared
+from ..billing import month_bounds
+from ..discounts import DiscountEngine, DiscountSession
+from ..currency import BASE_CURRENCY
+from ..money import allocate, currency_rounding, round_currency, to_decimal
+from ..pricing import Price
+from ..store import ActualCostRecord, Store
+from .models import ChargebackGroup, ChargebackLineItem, ChargebackReport
+
+CSV_COLUMNS = [
+    "group", "type", "provider", "service", "region", "sku",
+    "usage_quantity", "usage_unit", "cost", "discount", "net_cost", "discounts",
+]
+
+_LineKey = Tuple[str, str, str, str, str]
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+class ChargebackReporter:
+    """Builds monthly chargeback statements from the store"""
+
+    def __init__(
+        self,
+        store: Store,
+        allocation: AllocationEngine,
+        discounts: Optional[DiscountEngine] = None,
+        clock: Callable[[], datetime] = utcnow,
+    ):
+        """
+        Initialize reporter
+
+        Args:
+            store: Store holding actual costs
+            allocation: Engine resolving groups and splitting shared spend
+            discounts: Discount rules applied to line items; none if not provided
+            clock: Current time (aware UTC)
+        """
+        self.store = store
+        self.allocation = allocation
+        self.discounts = discounts
+        self.clock = clock
+
+    def report(
+        self,
+        tenant_id: str,
+        period: str,
+        group_by: str,
+        split: Optional[str] = None,
+        weights: Optional[Dict[str, float]] = None,
+        currency: str = BASE_CURRENCY,
+        rate: float = 1.0,
+    ) -> ChargebackReport:
+        """
+        Chargeback statement of a tenant's month
+
+        Args:
+            tenant_id: Tenant the spend belongs to
+            period: Month (YYYY-MM); the running month is reported to date
+            group_by: "project", "namespace" or "label:<key>"
+            split: How shared spend is split, default the allocation engine's
+            weights: Group weights for the weighted split
+            currency: Currency of the statement's amounts
+            rate: Exchange rate from USD to the currency
+
+        Raises:
+            ValueError: Malformed period, grouping, split or weights, or a future month
+        """
+        start, end = month_bounds(period)
+        now = self.clock()
+        if start > now.date():
+            raise ValueError(f"Month {period} has not started yet")
+        split = split or self.allocation.default_split
+        group_of = self.allocation.actual_group(group_by)
+
+        lines: Dict[Optional[str], Dict[_LineKey, List[ActualCostRecord]]] = {}
+        for cost in self.store.list_actual_costs(tenant_id, start, end):
+            key = (cost.provider, cost.service, cost.region, cost.sku, cost.usage_unit)
+            lines.setdefault(group_of(cost), {}).setdefault(key, []).append(cost)
+
+        session = self.discounts.session(tenant_id) if self.discounts is not None else None
+        items: Dict[Optional[str], List[ChargebackLineItem]] = {}
+        # Shared spend last, after every group's usage counted against tiers
+        for group in sorted(lines, key=lambda g: (g is None, g or "")):
+            items[group] = [
+                _line_item(key, costs, session, currency, rate)
+                for key, costs in sorted(lines[group].items())
+            ]
+
+        shared_items = items.pop(None, [])
+        shared = _total(item.net_cost for item in shared_items)
+        net = {group: _total(item.net_cost for item in group_items) for group, group_items in items.items()}
+        split_shares = split_shared(float(shared), {group: float(n) for group, n in net.items()}, split, weights)
+        shares = allocate(shared, split_shares, currency)
+
+        groups = []
+        for group in sorted(set(items) | set(shares)):
+            group_items = sorted(items.get(group, []), key=lambda item: -item.net_cost)
+            cost = _total(item.cost for item in group_items)
+            discount = _total(item.discount for item in group_items)
+            share = shares.get(group, Decimal(0))
+            groups.append(ChargebackGroup(
+                group=group,
+                line_items=group_items,
+                cost=float(cost),
+                discount=float(discount),
+                net_cost=float(cost - discount),
+                shared_cost=float(share),
+                total=float(cost - discount + share),
+            ))
+
+        every_item = shared_items + [item for group_items in items.values() for item in group_items]
+        cost = _total(item.cost for item in every_item)
+        discount = _total(item.discount for item in every_item)
+        return ChargebackReport(
+            tenant_id=tenant_id,
+            period=f"{start:%Y-%m}",
+            period_start=start,
+            period_end=end,
+            complete=end <= now.date(),
+            group_by=group_by,
+            split=split,
+            groups=groups,
+            shared_line_items=sorted(shared_items, key=lambda item: -item.net_cost),
+            unallocated_shared=float(shared if not shares else 0),
+            cost=float(cost),
+            discount=float(discount),
+            total=float(cost - discount),
+            currency=currency,
+            generated_at=now,
+        )
+
+
+def _total(amounts: Iterable[float]) -> Decimal:
+    """Exact sum of amounts already rounded to the statement's currency"""
+    return sum((to_decimal(amount) for amount in amounts), Decimal(0))
+
+
+def _line_item(
+    key: _LineKey, costs: List[ActualCostRecord], session: Optional[DiscountSession], currency: str, rate: float
+) -> ChargebackLineItem:
+    provider, service, region, sku, unit = key
+    cost = _total(c.amount for c in costs)
+    quantity = sum(c.usage_quantity for c in costs)
+
+    granted, net = [], cost
+    if session is not None and cost > 0:
+        price = Price(
+            provider=provider,
+            region=region,
+            sku=sku,
+            service=service,
+            unit=unit or "unit",
+            price=float(cost) / quantity if quantity > 0 else 0.0,
+            source="billing",
+        )
+        granted, net = session.apply(price, quantity, cost)
+
+    billed = round_currency(to_decimal(cost) * to_decimal(rate), currency)
+    net_cost = round_currency(to_decimal(net) * to_decimal(rate), currency)
+    return ChargebackLineItem(
+        provider=provider,
+        service=service,
+        region=region,
+        sku=sku,
+        usage_quantity=round(quantity, 4),
+        usage_unit=unit,
+        cost=float(billed),
+        discount=float(billed - net_cost),
+        net_cost=float(net_cost),
+        discounts=[d.rule.name for d in granted],
+    )
+
+
+def render_csv(report: ChargebackReport) -> str:
+    """Statement as CSV: every group's line items, then its shared cost"""
+    out = io.StringIO()
+    writer = csv.writer(out)
+    writer.writerow(CSV_COLUMNS)
+
+    def line(group: str, kind: str, item: ChargebackLineItem) -> None:
+        writer.writerow([
+            group, kind, item.provider, item.service, item.region, item.sku,
+            item.usage_quantity, item.usage_unit, item.cost, item.discount, item.net_cost,
+            ";".join(item.discounts),
+        ])
+
+    for group in report.groups:
+        for item in group.line_items:
+            line(group.group, "direct", item)
+        if group.shared_cost:
+            writer.writerow([group.group, "shared", "", "", "", "", "", "", "", "", group.shared_cost, ""])
+    for item in report.shared_line_items:
+        line("", "shared_source", item)
+    if report.unallocated_shared:
+        writer.writerow(["", "unallocated", "", "", "", "", "", "", "", "", report.unallocated_shared, ""])
+    return out.getvalue()
+
+
+def render_pdf(report: ChargebackReport) -> bytes:
+    """Statement as a PDF: a summary table of the groups, then each group's line items"""
+    from reportlab.lib import colors
+    from reportlab.lib.pagesizes import A4, landscape
+    from reportlab.lib.styles import getSampleStyleSheet
+    from reportlab.platypus import Paragraph, SimpleDocTemplate, Spacer, Table, TableStyle
+
+    styles = getSampleStyleSheet()
+    style = TableStyle([
+        ("BACKGROUND", (0, 0), (-1, 0), colors.lightgrey),
+        ("FONTNAME", (0, 0), (-1, 0), "Helvetica-Bold"),
+        ("FONTSIZE", (0, 0), (-1, -1), 8),
+        ("ALIGN", (1, 0), (-1, -1), "RIGHT"),
+        ("GRID", (0, 0), (-1, -1), 0.25, colors.grey),
+    ])
+    def amount(value: float) -> str:
+        return format_amount(value, report.currency)
+
+    status = "final" if report.complete else f"to date, generated {report.generated_at:%Y-%m-%d %H:%M} UTC"
+
+    story = [
+        Paragraph(f"Chargeback {report.period} - {report.tenant_id}", styles["Title"]),
+        Paragraph(
+            f"Grouped by {report.group_by}, shared spend split {report.split} ({status})", styles["Normal"]
+        ),
+        Spacer(1, 12),
+    ]
+
+    summary = [["Group", "Cost", "Discount", "Net", "Shared", "Total"]]
+    summary += [
+        [g.group, amount(g.cost), amount(g.discount), amount(g.net_cost), amount(g.shared_cost), amount(g.total)]
+        for g in report.groups
+    ]
+    if report.unallocated_shared:
+        unallocated = amount(report.unallocated_shared)
+        summary.append(["(unallocated)", "", "", "", unallocated, unallocated])
+    summary.append(["Total", amount(report.cost), amount(report.discount), "", "", amount(report.total)])
+    story += [Table(summary, style=style, repeatRows=1), Spacer(1, 18)]
+
+    for group in report.groups:
+        if not group.line_items:
+            continue
+        story.append(Paragraph(group.group, styles["Heading2"]))
+        rows = [["Provider", "Service", "Region", "SKU", "Usage", "Cost", "Discount", "Net", "Rules"]]
+        rows += [
+            [
+                item.provider, item.service, item.region, item.sku,
+                f"{item.usage_quantity:,.2f} {item.usage_unit}".strip(),
+                amount(item.cost), amount(item.discount), amount(item.net_cost), ", ".join(item.discounts),
+            ]
+            for item in group.line_items
+        ]
+        story += [Table(rows, style=style, repeatRows=1), Spacer(1, 12)]
+
+    out = io.BytesIO()
+    SimpleDocTemplate(out, pagesize=landscape(A4), title=f"Chargeback {report.period}").build(story)
+    return out.getvalue()
+
+
+def format_amount(value: float, currency: str = BASE_CURRENCY) -> str:
+    """Amount of a statement for reading, $1,234.50 or 1,234 KRW"""
+    if currency == BASE_CURRENCY:
+        return f"${value:,.2f}"
+    return f"{value:,.{currency_rounding(currency).places}f} {currency}"
@@ -0,0 +1,471 @@
+"""
+FOCUS export
+
+Writes a tenant's ingested actual costs and recorded estimates as FOCUS
+1.0 (FinOps Open Cost and Usage Specification) rows, in CSV or Parquet,
+so FinOps tools reading FOCUS datasets take our data as is.
+
+Actual costs become one row per stored daily cost, charged over the
+usage day. Estimates become one row per line item (compute, transfer,
+database, object storage, serverless), or one row for the total of
+estimates without line items, charged over the month they were made in
+and marked with x_Estimated. Stored costs are net of discounts and no
+list price is recorded, so list, contracted, effective and billed cost
+are the same amount. Columns prefixed x_ are our own, as FOCUS allows.
+"""
+
+import csv
+import io
+import json
+import logging
+from datetime import date, datetime, time, timedelta, timezone
+from typing import Any, Callable, Dict, List, Optional, Tuple
+
+from ..billing import month_bounds, next_month
+from ..pricing import (
+    COMMITMENT_MODELS,
+    PRICING_SPOT,
+    SERVICE_ACCELERATOR,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_COMPUTE,
+    SERVICE_DATA_TRANSFER,
+    SERVICE_DATABASE,
+    SERVICE_DATABASE_BACKUP,
+    SERVICE_DATABASE_IOPS,
+    SERVICE_DATABASE_STORAGE,
+    SERVICE_KUBERNETES,
+    SERVICE_KUBERNETES_PODS,
+    SERVICE_OBJECT_REQUESTS,
+    SERVICE_OBJECT_RETRIEVAL,
+    SERVICE_OBJECT_STORAGE,
+    SERVICE_SERVERLESS_CPU,
+    SERVICE_SERVERLESS_MEMORY,
+    SERVICE_SERVERLESS_REQUESTS,
+)
+from ..store import ActualCostRecord, EstimateRecord, Store
+
+logger = logging.getLogger(__name__)
+
+DATASET_ACTUALS = "actuals"
+DATASET_ESTIMATES = "estimates"
+DATASET_ALL = "all"
+DATASETS = (DATASET_ACTUALS, DATASET_ESTIMATES, DATASET_ALL)
+
+FORMAT_CSV = "csv"
+FORMAT_PARQUET = "parquet"
+FOCUS_FORMATS = (FORMAT_CSV, FORMAT_PARQUET)
+
+FOCUS_VERSION = "1.0"
+
+# Estimates read for one export
+MAX_ESTIMATES = 10000
+# Months one export may cover
+MAX_MONTHS = 24
+
+# FOCUS 1.0 columns, in specification order
+FOCUS_COLUMNS = (
+    "AvailabilityZone",
+    "BilledCost",
+    "BillingAccountId",
+    "BillingAccountName",
+    "BillingCurrency",
+    "BillingPeriodEnd",
+    "BillingPeriodStart",
+    "ChargeCategory",
+    "ChargeClass",
+    "ChargeDescription",
+    "ChargeFrequency",
+    "ChargePeriodEnd",
+    "ChargePeriodStart",
+    "CommitmentDiscountCategory",
+    "CommitmentDiscountId",
+    "CommitmentDiscountName",
+    "CommitmentDiscountStatus",
+    "CommitmentDiscountType",
+    "ConsumedQuantity",
+    "ConsumedUnit",
+    "ContractedCost",
+    "ContractedUnitPrice",
+    "EffectiveCost",
+    "InvoiceIssuerName",
+    "ListCost",
+    "ListUnitPrice",
+    "PricingCategory",
+    "PricingQuantity",
+    "PricingUnit",
+    "ProviderName",
+    "PublisherName",
+    "RegionId",
+    "RegionName",
+    "ResourceId",
+    "ResourceName",
+    "ResourceType",
+    "ServiceCategory",
+    "ServiceName",
+    "SkuId",
+    "SkuPriceId",
+    "SubAccountId",
+    "SubAccountName",
+    "Tags",
+)
+
+CUSTOM_COLUMNS = ("x_TenantId", "x_Project", "x_Source", "x_Estimated", "x_EstimateId", "x_EstimateKind")
+
+COLUMNS = FOCUS_COLUMNS + CUSTOM_COLUMNS
+
+_DECIMAL_COLUMNS = {
+    "BilledCost", "ConsumedQuantity", "ContractedCost", "ContractedUnitPrice", "EffectiveCost",
+    "ListCost", "ListUnitPrice", "PricingQuantity",
+}
+_DATETIME_COLUMNS = {"BillingPeriodEnd", "BillingPeriodStart", "ChargePeriodEnd", "ChargePeriodStart"}
+_BOOLEAN_COLUMNS = {"x_Estimated"}
+
+_PROVIDER_NAMES = {"aws": "AWS", "gcp": "Google Cloud", "azure": "Microsoft Azure"}
+
+_SERVICE_CATEGORIES = {
+    SERVICE_COMPUTE: "Compute",
+    SERVICE_ACCELERATOR: "Compute",
+    SERVICE_KUBERNETES: "Compute",
+    SERVICE_KUBERNETES_PODS: "Compute",
+    SERVICE_SERVERLESS_REQUESTS: "Compute",
+    SERVICE_SERVERLESS_MEMORY: "Compute",
+    SERVICE_SERVERLESS_CPU: "Compute",
+    SERVICE_BLOCK_STORAGE: "Storage",
+    SERVICE_OBJECT_STORAGE: "Storage",
+    SERVICE_OBJECT_REQUESTS: "Storage",
+    SERVICE_OBJECT_RETRIEVAL: "Storage",
+    SERVICE_DATABASE: "Databases",
+    SERVICE_DATABASE_STORAGE: "Databases",
+    SERVICE_DATABASE_IOPS: "Databases",
+    SERVICE_DATABASE_BACKUP: "Databases",
+    SERVICE_DATA_TRANSFER: "Networking",
+}
+
+# Service names of our services by provider; other services keep the name of the billing export
+_SERVICE_NAMES = {
+    "aws": {
+        SERVICE_COMPUTE: "Amazon Elastic Compute Cloud",
+        SERVICE_BLOCK_STORAGE: "Amazon Elastic Block Store",
+        SERVICE_OBJECT_STORAGE: "Amazon Simple Storage Service",
+        SERVICE_DATABASE: "Amazon Relational Database Service",
+        SERVICE_DATA_TRANSFER: "AWS Data Transfer",
+        SERVICE_KUBERNETES: "Amazon Elastic Kubernetes Service",
+        SERVICE_SERVERLESS_REQUESTS: "AWS Lambda",
+    },
+    "gcp": {
+        SERVICE_COMPUTE: "Compute Engine",
+        SERVICE_BLOCK_STORAGE: "Compute Engine",
+        SERVICE_OBJECT_STORAGE: "Cloud Storage",
+        SERVICE_DATABASE: "Cloud SQL",
+        SERVICE_DATA_TRANSFER: "Networking",
+        SERVICE_KUBERNETES: "Kubernetes Engine",
+        SERVICE_SERVERLESS_REQUESTS: "Cloud Run",
+    },
+    "azure": {
+        SERVICE_COMPUTE: "Virtual Machines",
+        SERVICE_BLOCK_STORAGE: "Storage",
+        SERVICE_OBJECT_STORAGE: "Storage",
+        SERVICE_DATABASE: "Azure Database",
+        SERVICE_DATA_TRANSFER: "Bandwidth",
+        SERVICE_KUBERNETES: "Azure Kubernetes Service",
+        SERVICE_SERVERLESS_REQUESTS: "Functions",
+    },
+}
+
+# Line item lists of an estimate result: (result field, service, field holding the SKU)
+_ESTIMATE_ITEMS = (
+    ("line_items", SERVICE_COMPUTE, "instance_type"),
+    ("transfer_items", SERVICE_DATA_TRANSFER, "direction"),
+    ("database_items", SERVICE_DATABASE, "instance_class"),
+    ("object_storage_items", SERVICE_OBJECT_STORAGE, "sku"),
+    ("serverless_items", SERVICE_SERVERLESS_REQUESTS, "platform"),
+)
+
+
+def utcnow() -> datetime:
+    return datetime.now(timezone.utc)
+
+
+def _at(day: date) -> datetime:
+    return datetime.combine(day, time.min, tzinfo=timezone.utc)
+
+
+def _month_start(day: date) -> date:
+    return day.replace(day=1)
+
+
+class FocusExporter:
+    """Exports actual costs and estimates of a tenant as FOCUS rows"""
+
+    def __init__(self, store: Store, clock: Callable[[], datetime] = utcnow):
+        """
+        Initialize exporter
+
+        Args:
+            store: Store holding estimates and actual costs
+            clock: Current time (aware UTC)
+        """
+        self.store = store
+        self.clock = clock
+
+    def period(self, since: Optional[str] = None, until: Optional[str] = None) -> Tuple[date, date]:
+        """
+        (first day, day after the last) of the months exported
+
+        Args:
+            since: First month (YYYY-MM), default until
+            until: Last month (YYYY-MM), default the running month
+
+        Raises:
+            ValueError: Malformed months, or a period ending before it starts or longer than MAX_MONTHS
+        """
+        if until:
+            _, end = month_bounds(until)
+        else:
+            end = next_month(self.clock().date())
+        if since:
+            start, _ = month_bounds(since)
+        else:
+            start = _month_start(end - timedelta(days=1))
+        if start >= end:
+            raise ValueError("Export period must start before it ends")
+        months = (end.year - start.year) * 12 + end.month - start.month
+        if months > MAX_MONTHS:
+            raise ValueError(f"Export period covers {months} months, at most {MAX_MONTHS} allowed")
+        return start, end
+
+    def rows(
+        self,
+        tenant_id: str,
+        since: Optional[str] = None,
+        until: Optional[str] = None,
+        dataset: str = DATASET_ALL,
+        project: Optional[str] = None,
+    ) -> List[Dict[str, Any]]:
+        """
+        FOCUS rows of a tenant's months, actual costs first
+
+        Args:
+            tenant_id: Tenant the costs and estimates belong to
+            since: First month (YYYY-MM), default until
+            until: Last month (YYYY-MM), default the running month
+            dataset: actuals, estimates or all
+            project: Only costs and estimates of this project
+
+        Raises:
+            ValueError: Unknown dataset or malformed period
+        """
+        if dataset not in DATASETS:
+            raise ValueError(f"Unknown dataset {dataset!r}, expected one of: {', '.join(DATASETS)}")
+        start, end = self.period(since, until)
+
+        rows: List[Dict[str, Any]] = []
+        if dataset in (DATASET_ACTUALS, DATASET_ALL):
+            rows.extend(
+                actual_cost_row(record)
+                for record in self.store.list_actual_costs(tenant_id, start, end, project=project)
+            )
+        if dataset in (DATASET_ESTIMATES, DATASET_ALL):
+            records = self.store.list_estimates(
+                project=project, since=_at(start), until=_at(end), limit=MAX_ESTIMATES, tenant_id=tenant_id
+            )
+            if len(records) == MAX_ESTIMATES:
+                logger.warning(f"FOCUS export of tenant {tenant_id} limited to the newest {MAX_ESTIMATES} estimates")
+            for record in reversed(records):
+                rows.extend(estimate_rows(record))
+        return rows
+
+
+def _row(**values: Any) -> Dict[str, Any]:
+    row: Dict[str, Any] = dict.fromkeys(COLUMNS)
+    row.update(values)
+    return row
+
+
+def _provider_columns(provider: str) -> Dict[str, Any]:
+    name = _PROVIDER_NAMES.get(provider, provider)
+    return {"ProviderName": name, "PublisherName": name, "InvoiceIssuerName": name}
+
+
+def _service_columns(provider: str, service: str) -> Dict[str, Any]:
+    return {
+        "ServiceCategory": _SERVICE_CATEGORIES.get(service, "Other"),
+        "ServiceName": _SERVICE_NAMES.get(provider, {}).get(service, service),
+    }
+
+
+def _cost_columns(cost: float, quantity: Optional[float] = None, unit: Optional[str] = None) -> Dict[str, Any]:
+    unit_price = cost / quantity if quantity else None
+    return {
+        "BilledCost": cost,
+        "EffectiveCost": cost,
+        "ListCost": cost,
+        "ContractedCost": cost,
+        "ListUnitPrice": unit_price,
+        "ContractedUnitPrice": unit_price,
+        "ConsumedQuantity": quantity,
+        "ConsumedUnit": unit,
+        "PricingQuantity": quantity,
+        "PricingUnit": unit,
+    }
+
+
+def _tags(labels: Dict[str, str]) -> Optional[str]:
+    return json.dumps(labels, sort_keys=True) if labels else None
+
+
+def actual_cost_row(record: ActualCostRecord) -> Dict[str, Any]:
+    """FOCUS row of one daily actual cost"""
+    billing_start = _month_start(record.usage_date)
+    quantity = record.usage_quantity or None
+    return _row(
+        **_cost_columns(record.amount, quantity, (record.usage_unit or None) if quantity else None),
+        **_provider_columns(record.provider),
+        **_service_columns(record.provider, record.service),
+        BillingAccountId=record.account_id or record.tenant_id,
+        BillingCurrency=record.currency,
+        BillingPeriodStart=_at(billing_start),
+        BillingPeriodEnd=_at(next_month(billing_start)),
+        ChargeCategory="Credit" if record.amount < 0 else "Usage",
+        ChargeDescription=record.sku or record.service,
+        ChargeFrequency="Usage-Based",
+        ChargePeriodStart=_at(record.usage_date),
+        ChargePeriodEnd=_at(record.usage_date + timedelta(days=1)),
+        PricingCategory="Standard",
+        RegionId=record.region or None,
+        RegionName=record.region or None,
+        SkuId=record.sku or None,
+        SubAccountId=record.account_id or None,
+        Tags=_tags(record.labels),
+        x_TenantId=record.tenant_id,
+        x_Project=record.project,
+        x_Source=record.source,
+        x_Estimated=False,
+    )
+
+
+def _pricing_category(pricing_model: Optional[str]) -> str:
+    if pricing_model == PRICING_SPOT:
+        return "Dynamic"
+    if pricing_model in COMMITMENT_MODELS:
+        return "Committed"
+    return "Standard"
+
+
+def estimate_rows(record: EstimateRecord) -> List[Dict[str, Any]]:
+    """FOCUS rows of one estimate: a row per line item, or one for the total"""
+    created = record.created_at or utcnow()
+    billing_start = _month_start(created.date())
+    common = dict(
+        BillingAccountId=record.tenant_id,
+        BillingCurrency=record.currency,
+        BillingPeriodStart=_at(billing_start),
+        BillingPeriodEnd=_at(next_month(billing_start)),
+        ChargeCategory="Usage",
+        ChargeFrequency="Usage-Based",
+        ChargePeriodStart=_at(billing_start),
+        ChargePeriodEnd=_at(next_month(billing_start)),
+        Tags=_tags(record.labels),
+        x_TenantId=record.tenant_id,
+        x_Project=record.project,
+        x_Source="estimate",
+        x_Estimated=True,
+        x_EstimateId=record.id,
+        x_EstimateKind=record.kind,
+    )
+
+    rows = []
+    for field, service, sku_field in _ESTIMATE_ITEMS:
+        for item in record.result.get(field) or []:
+            provider = item.get("provider", "")
+            sku = item.get(sku_field)
+            quantity = unit = None
+            if field == "line_items":
+                quantity, unit = item.get("count", 0) * item.get("hours", 0.0) or None, "Hours"
+            elif field == "transfer_items":
+                quantity, unit = item.get("gb_per_month") or None, "GB"
+            row = _row(
+                **common,
+                **_cost_columns(float(item.get("monthly_cost", 0.0)), quantity, unit if quantity else None),
+                **_provider_columns(provider),
+                **_service_columns(provider, service),
+                ChargeDescription=" ".join(str(part) for part in (item.get("name"), sku) if part) or field,
+                PricingCategory=_pricing_category(item.get("pricing_model")),
+                RegionId=item.get("region"),
+                RegionName=item.get("region"),
+                ResourceName=item.get("name"),
+                SkuId=sku,
+            )
+            if field == "line_items":
+                row["ListUnitPrice"] = row["ContractedUnitPrice"] = item.get("unit_price_hourly")
+            rows.append(row)
+
+    if not rows:
+        rows.append(_row(
+            **common,
+            **_cost_columns(record.monthly_cost),
+            **_provider_columns(""),
+            ChargeDescription=f"{record.kind} estimate",
+            PricingCategory="Standard",
+            ServiceCategory="Other",
+            ServiceName=record.kind,
+        ))
+    return rows
+
+
+def _text(column: str, value: Any) -> str:
+    if value is None:
+        return ""
+    if column in _DATETIME_COLUMNS:
+        return value.astimezone(timezone.utc).strftime("%Y-%m-%dT%H:%M:%SZ")
+    if isinstance(value, bool):
+        return "true" if value else "false"
+    return str(value)
+
+
+def focus_csv(rows: List[Dict[str, Any]]) -> str:
+    """FOCUS rows as CSV, a header row of the column names first"""
+    out = io.StringIO()
+    writer = csv.writer(out)
+    writer.writerow(COLUMNS)
+    for row in rows:
+        writer.writerow([_text(column, row.get(column)) for column in COLUMNS])
+    return out.getvalue()
+
+
+def focus_parquet(rows: List[Dict[str, Any]]) -> bytes:
+    """FOCUS rows as a Parquet file, timestamps in UTC and amounts as doubles"""
+    import pyarrow as pa
+    import pyarrow.parquet as pq
+
+    fields = []
+    for column in COLUMNS:
+        if column in _DECIMAL_COLUMNS:
+            kind = pa.float64()
+        elif column in _DATETIME_COLUMNS:
+            kind = pa.timestamp("s", tz="UTC")
+        elif column in _BOOLEAN_COLUMNS:
+            kind = pa.bool_()
+        else:
+            kind = pa.string()
+        fields.append(pa.field(column, kind))
+    schema = pa.schema(fields, metadata={"focus_version": FOCUS_VERSION})
+    table = pa.Table.from_pydict({column: [row.get(column) for row in rows] for column in COLUMNS}, schema=schema)
+
+    out = io.BytesIO()
+    pq.write_table(table, out)
+    return out.getvalue()
+
+
+def render_focus(rows: List[Dict[str, Any]], format: str) -> bytes:
+    """
+    FOCUS rows in a file format
+
+    Raises:
+        ValueError: If the format is not csv or parquet
+    """
+    if format == FORMAT_CSV:
+        return focus_csv(rows).encode("utf-8")
+    if format == FORMAT_PARQUET:
+        return focus_parquet(rows)
+    raise ValueError(f"Unknown FOCUS format {format!r}, expected one of: {', '.join(FOCUS_FORMATS)}")
@@ -0,0 +1,199 @@
+"""
+Scheduled chargeback mail
+
+On CHARGEBACK_EMAIL_DAY of every month, the previous month's statement
+of a tenant is sent as CSV and PDF attachments. Which months were sent is
+kept per replica, so a restart on that day may send a statement again.
+"""
+
+import logging
+import smtplib
+import threading
+from datetime import datetime
+from email.message import EmailMessage
+from typing import Callable, List, Optional, Set, Tuple
+
+from ..billing import previous_month
+from .chargeback import ChargebackReporter, format_amount, render_csv, render_pdf, utcnow
+
+logger = logging.getLogger(__name__)
+
+# Seconds between checks whether a statement is due
+CHECK_INTERVAL_SECONDS = 3600
+
+
+class Mailer:
+    """Sends mail through an SMTP relay"""
+
+    def __init__(
+        self,
+        host: str,
+        port: int = 587,
+        sender: str = "",
+        username: str = "",
+        password: str = "",
+        starttls: bool = True,
+        timeout: float = 30.0,
+        smtp: Callable[..., smtplib.SMTP] = smtplib.SMTP,
+    ):
+        """
+        Initialize mailer
+
+        Args:
+            host: SMTP relay host
+            port: SMTP relay port
+            sender: From address
+            username: SMTP login, no login if empty
+            password: SMTP password
+            starttls: Upgrade the connection with STARTTLS before logging in
+            timeout: Seconds to wait for the relay
+            smtp: SMTP client factory
+        """
+        self.host = host
+        self.port = port
+        self.sender = sender
+        self.username = username
+        self.password = password
+        self.starttls = starttls
+        self.timeout = timeout
+        self.smtp = smtp
+
+    def send(
+        self,
+        recipients: List[str],
+        subject: str,
+        body: str,
+        attachments: Optional[List[Tuple[str, bytes, str]]] = None,
+        html: Optional[str] = None,
+    ) -> None:
+        """
+        Send a message
+
+        Args:
+            recipients: To addresses
+            subject: Subject line
+            body: Plain text body
+            attachments: (filename, content, MIME type) of each attachment
+            html: HTML body, sent with the plain text one as its alternative
+
+        Raises:
+            smtplib.SMTPException, OSError: If the relay refused or could not be reached
+        """
+        message = EmailMessage()
+        message["From"] = self.sender
+        message["To"] = ", ".join(recipients)
+        message["Subject"] = subject
+        message.set_content(body)
+        if html is not None:
+            message.add_alternative(html, subtype="html")
+        for filename, content, mime_type in attachments or []:
+            maintype, _, subtype = mime_type.partition("/")
+            message.add_attachment(content, maintype=maintype, subtype=subtype, filename=filename)
+
+        with self.smtp(self.host, self.port, timeout=self.timeout) as client:
+            if self.starttls:
+                client.starttls()
+            if self.username:
+                client.login(self.username, self.password)
+            client.send_message(message)
+
+
+class ChargebackSchedule:
+    """Mails a tenant's chargeback statement of the previous month once a month"""
+
+    def __init__(
+        self,
+        reporter: ChargebackReporter,
+        mailer: Mailer,
+        tenant_id: str,
+        recipients: List[str],
+        group_by: str,
+        day: int = 3,
+        clock: Callable[[], datetime] = utcnow,
+        locks=None,
+    ):
+        """
+        Initialize schedule
+
+        Args:
+            reporter: Reporter building the statements
+            mailer: Mailer sending them
+            tenant_id: Tenant whose statements are sent
+            recipients: To addresses
+            group_by: Grouping of the statements
+            day: Day of the month the previous month's statement is sent,
+                leaving billing exports time to settle
+            clock: Current time (aware UTC)
+            locks: Locks (src.cache.Locks) claimed per statement so one replica sends it
+        """
+        if not 1 <= day <= 28:
+            raise ValueError("The chargeback mail day must be between 1 and 28")
+        self.reporter = reporter
+        self.mailer = mailer
+        self.tenant_id = tenant_id
+        self.recipients = recipients
+        self.group_by = group_by
+        self.day = day
+        self.clock = clock
+        self.locks = locks
+        self._sent: Set[str] = set()
+        self._lock = threading.Lock()
+
+    def send_due(self) -> Optional[str]:
+        """
+        Send the previous month's statement if today is the mail day and it was not sent yet
+
+        Failures are logged rather than raised, and retried at the next check that day.
+
+        Returns:
+            Period sent, or None
+        """
+        today = self.clock().date()
+        if today.day != self.day:
+            return None
+        period = f"{previous_month(today):%Y-%m}"
+        with self._lock:
+            if period in self._sent:
+                return None
+
+        lock = f"chargeback-mail:{self.tenant_id}:{period}"
+        token = None
+        if self.locks is not None:
+            # Kept past the mail day, so replicas do not send a statement twice
+            token = self.locks.acquire(lock, 2 * 86400)
+            if token is None:
+                return None
+
+        try:
+            self.send(period)
+        except Exception as e:
+            logger.error(f"Chargeback statement {period} of tenant {self.tenant_id} not sent: {e}")
+            if token is not None:
+                self.locks.release(lock, token)
+            return None
+
+        with self._lock:
+            self._sent.add(period)
+        return period
+
+    def send(self, period: str) -> None:
+        """Send the statement of a month"""
+        report = self.reporter.report(self.tenant_id, period, self.group_by)
+        lines = [f"Chargeback statement of {report.tenant_id} for {period}, grouped by {report.group_by}.", ""]
+        lines += [f"{g.group}: {format_amount(g.total, report.currency)}" for g in report.groups]
+        if report.unallocated_shared:
+            lines.append(f"(unallocated): {format_amount(report.unallocated_shared, report.currency)}")
+        total, discount = format_amount(report.total, report.currency), format_amount(report.discount, report.currency)
+        lines += ["", f"Total: {total} (discounts {discount})"]
+
+        name = f"chargeback-{report.tenant_id}-{period}"
+        self.mailer.send(
+            self.recipients,
+            f"Chargeback {period} - {report.tenant_id}",
+            "\n".join(lines),
+            [
+                (f"{name}.csv", render_csv(report).encode("utf-8"), "text/csv"),
+                (f"{name}.pdf", render_pdf(report), "application/pdf"),
+            ],
+        )
+        logger.info(f"Chargeback statement {period} of tenant {self.tenant_id} sent to {len(self.recipients)} recipients")
@@ -0,0 +1,234 @@
+"""
+Data models for estimate accuracy, chargeback and scheduled cost reports
+"""
+
+from datetime import date, datetime
+from typing import Dict, List, Optional
+
+from pydantic import BaseModel, Field, validator
+
+from ..store import DEFAULT_TENANT, ReportScheduleRecord
+
+# Report groupings: by project, or by the value of a label ("label:team")
+GROUP_BY_PROJECT = "project"
+GROUP_BY_LABEL_PREFIX = "label:"
+
+FREQUENCY_WEEKLY = "weekly"
+FREQUENCY_MONTHLY = "monthly"
+FREQUENCIES = (FREQUENCY_WEEKLY, FREQUENCY_MONTHLY)
+
+# Sections of scheduled cost reports
+SECTION_BUDGETS = "budgets"
+SECTION_COST_CHANGES = "cost_changes"
+SECTION_RECOMMENDATIONS = "recommendations"
+REPORT_SECTIONS = (SECTION_BUDGETS, SECTION_COST_CHANGES, SECTION_RECOMMENDATIONS)
+
+
+class AccuracyEntry(BaseModel):
+    """Estimate of one group in effect for a month, against the month's actual spend"""
+
+    group: str = Field(..., description="Project or label value")
+    month: str = Field(..., description="YYYY-MM")
+    estimate_id: str = Field(..., description="Latest estimate made before the end of the month")
+    estimate_kind: str
+    estimated_at: Optional[datetime] = None
+    estimated: float = Field(..., description="Monthly cost of the estimate (USD)")
+    actual: float = Field(..., description="Actual spend of the month (USD)")
+    error: float = Field(..., description="Estimated minus actual")
+    error_percent: Optional[float] = Field(None, description="Error as a percentage of actual; None without spend")
+
+
+class GroupAccuracy(BaseModel):
+    """Accuracy of a group's estimates over the report period"""
+
+    group: str
+    months: int = Field(..., description="Months with an estimate in effect")
+    estimated: float
+    actual: float
+    mean_absolute_percent_error: Optional[float] = Field(
+        None, description="Mean of the months' absolute error percentages"
+    )
+    bias_percent: Optional[float] = Field(
+        None, description="Total error as a percentage of total actual spend; positive overestimates"
+    )
+
+
+class AccuracyReport(BaseModel):
+    """Estimates correlated with realized spend, per group and month"""
+
+    period_start: date
+    period_end: date = Field(..., description="First day after the period")
+    group_by: str
+    entries: List[AccuracyEntry]
+    groups: List[GroupAccuracy]
+    mean_absolute_percent_error: Optional[float] = Field(None, description="Over all entries with spend")
+    unestimated_actual: float = Field(
+        0.0, description="Actual spend of groups and months without an estimate in effect"
+    )
+
+
+class ChargebackLineItem(BaseModel):
+    """A month's spend on one provider SKU, with the discount rules applied to it"""
+
+    provider: str = ""
+    service: str = ""
+    region: str = ""
+    sku: str = ""
+    usage_quantity: float = 0.0
+    usage_unit: str = ""
+    cost: float = Field(..., description="Billed spend in the statement's currency; credits are negative")
+    discount: float = Field(0.0, description="Discount granted by the tenant's discount rules")
+    net_cost: float = Field(..., description="Cost minus discount")
+    discounts: List[str] = Field(default_factory=list, description="Names of the rules that granted a discount")
+
+
+class ChargebackGroup(BaseModel):
+    """What one team, project or namespace is charged for a month"""
+
+    group: str
+    line_items: List[ChargebackLineItem]
+    cost: float = Field(..., description="Billed spend carrying the group")
+    discount: float = 0.0
+    net_cost: float = Field(..., description="Direct spend after discounts")
+    shared_cost: float = Field(0.0, description="Allocated share of the spend without a group, after discounts")
+    total: float = Field(..., description="Net cost plus shared cost")
+
+
+class ChargebackReportRequest(BaseModel):
+    """Chargeback statement built in the background by a chargeback_report job"""
+
+    period: str = Field(..., description="Month, YYYY-MM")
+    group_by: Optional[str] = Field(None, description="project, namespace or label:<key>, default CHARGEBACK_GROUP_BY")
+    split: Optional[str] = Field(None, description="proportional, even or weighted")
+    weights: Optional[Dict[str, float]] = Field(None, description="Group weights of the weighted split")
+
+
+class ChargebackReport(BaseModel):
+    """Showback/chargeback statement of a tenant's month"""
+
+    tenant_id: str
+    period: str = Field(..., description="YYYY-MM")
+    period_start: date
+    period_end: date = Field(..., description="First day after the period")
+    complete: bool = Field(..., description="False while the month is still running")
+    group_by: str
+    split: str = Field(..., description="How shared spend was split: proportional, even or weighted")
+    groups: List[ChargebackGroup]
+    shared_line_items: List[ChargebackLineItem] = Field(
+        default_factory=list, description="Spend without a group, split between the groups"
+    )
+    unallocated_shared: float = Field(0.0, description="Shared spend left unsplit because no group has spend")
+    cost: float = 0.0
+    discount: float = 0.0
+    total: float = Field(0.0, description="Spend after discounts")
+    currency: str = Field("USD", description="Currency of the amounts, each rounded to its minor unit")
+    generated_at: datetime
+
+
+class ReportScheduleSpec(BaseModel):
+    """Cost report schedule as created or replaced through /reports/schedules"""
+
+    name: str = Field(..., min_length=1, max_length=100)
+    frequency: str = Field(..., description="weekly (the previous Monday to Sunday) or monthly (the previous month)")
+    day: int = Field(1, description="Weekday the report is sent, 1 Monday to 7 Sunday, or day of the month, 1-28")
+    recipients: List[str] = Field(..., min_items=1, max_items=50)
+    sections: List[str] = Field(
+        default_factory=list, description="budgets, cost_changes, recommendations; empty for all"
+    )
+    enabled: bool = True
+
+    @validator("frequency")
+    def validate_frequency(cls, v):
+        v = v.strip().lower()
+        if v not in FREQUENCIES:
+            raise ValueError(f"frequency must be one of: {', '.join(FREQUENCIES)}")
+        return v
+
+    @validator("day")
+    def validate_day(cls, v, values):
+        if values.get("frequency") == FREQUENCY_WEEKLY and not 1 <= v <= 7:
+            raise ValueError("day of weekly reports must be between 1 (Monday) and 7 (Sunday)")
+        if values.get("frequency") == FREQUENCY_MONTHLY and not 1 <= v <= 28:
+            raise ValueError("day of monthly reports must be between 1 and 28")
+        return v
+
+    @validator("recipients")
+    def validate_recipients(cls, v):
+        v = [address.strip() for address in v]
+        invalid = [address for address in v if "@" not in address or " " in address]
+        if invalid:
+            raise ValueError(f"Invalid email addresses: {', '.join(invalid)}")
+        return list(dict.fromkeys(v))
+
+    @validator("sections")
+    def validate_sections(cls, v):
+        unknown = [section for section in v if section not in REPORT_SECTIONS]
+        if unknown:
+            raise ValueError(f"Unknown sections: {', '.join(unknown)}; expected {', '.join(REPORT_SECTIONS)}")
+        return [section for section in REPORT_SECTIONS if section in v]
+
+    def to_record(self, tenant_id: str, schedule_id: Optional[str] = None) -> ReportScheduleRecord:
+        return ReportScheduleRecord(id=schedule_id, tenant_id=tenant_id, **self.dict())
+
+
+class ReportSchedule(ReportScheduleSpec):
+    """Stored cost report schedule"""
+
+    id: str
+    tenant_id: str = DEFAULT_TENANT
+    last_period: Optional[str] = Field(None, description="Period last sent, e.g. 2026-W41 or 2026-09")
+    created_at: Optional[datetime] = None
+    updated_at: Optional[datetime] = None
+
+    @classmethod
+    def from_record(cls, record: ReportScheduleRecord) -> "ReportSchedule":
+        return cls(**record.dict())
+
+
+class BudgetLine(BaseModel):
+    """A budget's spend in the current month"""
+
+    name: str
+    amount: float
+    actual_to_date: float
+    projected_actual: float
+    utilization: float = Field(..., description="Projected spend as a fraction of the amount")
+    threshold_reached: Optional[float] = None
+
+
+class CostChangeLine(BaseModel):
+    """Spend of a project and service in the period against the period before"""
+
+    project: str = Field(..., description="Project, empty for spend without one")
+    service: str
+    previous_cost: float
+    cost: float
+    delta: float
+    delta_percent: Optional[float] = Field(None, description="Change in percent, None without previous spend")
+
+
+class RecommendationLine(BaseModel):
+    """A way to spend less"""
+
+    kind: str = Field(..., description="commitment or idle_resource")
+    summary: str
+    monthly_savings: Optional[float] = Field(None, description="Estimated savings, None if they could not be priced")
+
+
+class CostReport(BaseModel):
+    """Weekly or monthly cost report of a tenant"""
+
+    tenant_id: str
+    name: str = Field("", description="Schedule the report was built for")
+    frequency: str
+    period: str = Field(..., description="ISO week (2026-W41) or month (2026-09)")
+    period_start: date
+    period_end: date = Field(..., description="First day after the period")
+    cost: float = Field(..., description="Actual spend of the period")
+    previous_cost: float = Field(..., description="Actual spend of the period before")
+    sections: List[str]
+    budgets: List[BudgetLine] = Field(default_factory=list)
+    cost_changes: List[CostChangeLine] = Field(default_factory=list, description="Largest changes first")
+    recommendations: List[RecommendationLine] = Field(default_factory=list, description="Largest savings first")
+    currency: str = "USD"
+    generated_at: datetime
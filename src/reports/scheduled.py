@@ -0,0 +1,451 @@
+"""
+Scheduled cost reports
+
+A tenant schedules weekly or monthly reports to a list of recipients:
+the status of its budgets, the projects and services whose actual spend
+changed most against the period before, and recommendations to spend
+less (commitment purchases, idle resources). Each report is mailed as
+HTML with a plain text alternative and the CSV report attached.
+
+Weekly reports cover the previous Monday to Sunday and are sent from the
+schedule's weekday on; monthly reports cover the previous month and are
+sent from the schedule's day of the month on. The period last sent is
+kept with the schedule, so a report missed while the service was down is
+sent at the next check and a restart never sends one twice.
+"""
+
+import csv
+import html
+import io
+import logging
+from datetime import date, datetime, timedelta
+from typing import Callable, Dict, List, Optional, Tuple
+
+from ..billing import next_month, previous_month
+from ..budgets import Budget, BudgetEvaluator
+from ..commitments import CommitmentRecommender, CommitmentRequest
+from ..inventory import IdleDetector
+from ..money import round_money
+from ..store import ReportScheduleRecord, Store
+from .chargeback import format_amount, utcnow
+from .mail import Mailer
+from .models import (
+    FREQUENCY_WEEKLY,
+    REPORT_SECTIONS,
+    SECTION_BUDGETS,
+    SECTION_COST_CHANGES,
+    SECTION_RECOMMENDATIONS,
+    BudgetLine,
+    CostChangeLine,
+    CostReport,
+    RecommendationLine,
+)
+
+logger = logging.getLogger(__name__)
+
+CSV_COLUMNS = [
+    "section", "item", "cost", "previous_cost", "delta", "delta_percent",
+    "budget", "utilization", "monthly_savings", "detail",
+]
+
+# Cost changes and recommendations listed in a report
+DEFAULT_TOP_ITEMS = 10
+
+RECOMMENDATION_COMMITMENT = "commitment"
+RECOMMENDATION_IDLE = "idle_resource"
+
+
+def report_period(frequency: str, today: date) -> Tuple[str, date, date]:
+    """
+    Last complete week or month before a day
+
+    Returns:
+        (period, first day, first day after the period), the period named
+        by its ISO week (2026-W41) or month (2026-09)
+    """
+    if frequency == FREQUENCY_WEEKLY:
+        start = today - timedelta(days=today.weekday() + 7)
+        year, week, _ = start.isocalendar()
+        return f"{year}-W{week:02d}", start, start + timedelta(days=7)
+    start = previous_month(today)
+    return f"{start:%Y-%m}", start, next_month(start)
+
+
+def is_due(schedule: ReportScheduleRecord, today: date) -> bool:
+    """Whether the report of the last complete period is to be sent: not sent yet and the send day has come"""
+    if not schedule.enabled:
+        return False
+    period, _, _ = report_period(schedule.frequency, today)
+    if schedule.last_period == period:
+        return False
+    if schedule.frequency == FREQUENCY_WEEKLY:
+        return today.isoweekday() >= schedule.day
+    return today.day >= schedule.day
+
+
+class CostReportBuilder:
+    """Builds a tenant's cost report of a week or month"""
+
+    def __init__(
+        self,
+        store: Store,
+        budgets: Optional[BudgetEvaluator] = None,
+        commitments: Optional[CommitmentRecommender] = None,
+        idle: Optional[IdleDetector] = None,
+        top_items: int = DEFAULT_TOP_ITEMS,
+        clock: Callable[[], datetime] = utcnow,
+    ):
+        """
+        Initialize builder
+
+        Args:
+            store: Store holding budgets and actual costs
+            budgets: Evaluator of the budget status, no budgets section without it
+            commitments: Recommender of commitment purchases
+            idle: Detector of idle resources
+            top_items: Cost changes and recommendations listed
+            clock: Current time (aware UTC)
+        """
+        self.store = store
+        self.budgets = budgets
+        self.commitments = commitments
+        self.idle = idle
+        self.top_items = top_items
+        self.clock = clock
+
+    def build(
+        self,
+        tenant_id: str,
+        frequency: str,
+        sections: Optional[List[str]] = None,
+        name: str = "",
+        today: Optional[date] = None,
+    ) -> CostReport:
+        """
+        Report of the last complete week or month
+
+        Args:
+            tenant_id: Tenant reported on
+            frequency: weekly or monthly
+            sections: Sections included, all when empty
+            name: Schedule the report is built for
+            today: Day the report is built on, today when omitted
+
+        Raises:
+            StoreError: If actual costs cannot be read
+        """
+        sections = [section for section in REPORT_SECTIONS if not sections or section in sections]
+        period, start, end = report_period(frequency, today or self.clock().date())
+        previous_start = start - (end - start) if frequency == FREQUENCY_WEEKLY else previous_month(start)
+
+        current = self._spend(tenant_id, start, end)
+        previous = self._spend(tenant_id, previous_start, start)
+        report = CostReport(
+            tenant_id=tenant_id,
+            name=name,
+            frequency=frequency,
+            period=period,
+            period_start=start,
+            period_end=end,
+            cost=round_money(sum(current.values()), 2),
+            previous_cost=round_money(sum(previous.values()), 2),
+            sections=sections,
+            generated_at=self.clock(),
+        )
+        if SECTION_BUDGETS in sections:
+            report.budgets = self._budget_lines(tenant_id)
+        if SECTION_COST_CHANGES in sections:
+            report.cost_changes = self._cost_changes(current, previous)
+        if SECTION_RECOMMENDATIONS in sections:
+            report.recommendations = self._recommendations(tenant_id)
+        return report
+
+    def _spend(self, tenant_id: str, start: date, end: date) -> Dict[Tuple[str, str], float]:
+        spend: Dict[Tuple[str, str], float] = {}
+        for cost in self.store.list_actual_costs(tenant_id, start, end):
+            key = (cost.project or "", cost.service)
+            spend[key] = spend.get(key, 0.0) + cost.amount
+        return spend
+
+    def _budget_lines(self, tenant_id: str) -> List[BudgetLine]:
+        if self.budgets is None:
+            return []
+        lines = []
+        for record in self.store.list_budgets(tenant_id):
+            status = self.budgets.status(Budget.from_record(record))
+            lines.append(BudgetLine(
+                name=record.name,
+                amount=record.amount,
+                actual_to_date=status.actual_to_date,
+                projected_actual=status.projected_actual,
+                utilization=status.utilization,
+                threshold_reached=status.threshold_reached,
+            ))
+        return sorted(lines, key=lambda line: -line.utilization)
+
+    def _cost_changes(
+        self, current: Dict[Tuple[str, str], float], previous: Dict[Tuple[str, str], float]
+    ) -> List[CostChangeLine]:
+        changes = []
+        for project, service in set(current) | set(previous):
+            cost, before = current.get((project, service), 0.0), previous.get((project, service), 0.0)
+            delta = round_money(cost - before, 2)
+            if delta == 0:
+                continue
+            changes.append(CostChangeLine(
+                project=project,
+                service=service,
+                previous_cost=round_money(before, 2),
+                cost=round_money(cost, 2),
+                delta=delta,
+                delta_percent=round(delta / before * 100, 1) if before else None,
+            ))
+        changes.sort(key=lambda change: (-abs(change.delta), change.project, change.service))
+        return changes[:self.top_items]
+
+    def _recommendations(self, tenant_id: str) -> List[RecommendationLine]:
+        """Commitment purchases and idle resources; a failing source is logged and left out"""
+        lines = []
+        if self.commitments is not None:
+            try:
+                report = self.commitments.recommend(tenant_id, CommitmentRequest())
+                lines += [
+                    RecommendationLine(
+                        kind=RECOMMENDATION_COMMITMENT,
+                        summary=recommendation.summary,
+                        monthly_savings=recommendation.monthly_savings_cost,
+                    )
+                    for recommendation in report.recommendations
+                ]
+            except Exception as e:
+                logger.error(f"Commitment recommendations of tenant {tenant_id} left out of the report: {e}")
+        if self.idle is not None:
+            try:
+                report = self.idle.detect(tenant_id)
+                lines += [
+                    RecommendationLine(
+                        kind=RECOMMENDATION_IDLE,
+                        summary=f"Remove {finding.name or finding.resource_id}: {finding.detail}",
+                        monthly_savings=finding.waste_monthly_cost,
+                    )
+                    for finding in report.findings
+                ]
+            except Exception as e:
+                logger.error(f"Idle resources of tenant {tenant_id} left out of the report: {e}")
+        lines.sort(key=lambda line: -(line.monthly_savings or 0.0))
+        return lines[:self.top_items]
+
+
+def _percent(value: Optional[float]) -> str:
+    return "new" if value is None else f"{value:+.1f}%"
+
+
+def _title(report: CostReport) -> str:
+    title = f"{report.frequency.capitalize()} cost report {report.period} - {report.tenant_id}"
+    return f"{title} ({report.name})" if report.name else title
+
+
+def render_report_csv(report: CostReport) -> str:
+    """Report as CSV: one row per budget, cost change and recommendation"""
+    out = io.StringIO()
+    writer = csv.writer(out)
+    writer.writerow(CSV_COLUMNS)
+    writer.writerow(["total", report.period, report.cost, report.previous_cost,
+                     round_money(report.cost - report.previous_cost, 2), "", "", "", "", ""])
+    for budget in report.budgets:
+        writer.writerow([SECTION_BUDGETS, budget.name, budget.projected_actual, "", "", "",
+                         budget.amount, budget.utilization, "", f"{budget.actual_to_date} to date"])
+    for change in report.cost_changes:
+        percent = "" if change.delta_percent is None else change.delta_percent
+        writer.writerow([SECTION_COST_CHANGES, f"{change.project or '(no project)'}/{change.service}", change.cost,
+                         change.previous_cost, change.delta, percent, "", "", "", ""])
+    for recommendation in report.recommendations:
+        savings = "" if recommendation.monthly_savings is None else recommendation.monthly_savings
+        writer.writerow([SECTION_RECOMMENDATIONS, recommendation.kind, "", "", "", "",
+                         "", "", savings, recommendation.summary])
+    return out.getvalue()
+
+
+def render_report_text(report: CostReport) -> str:
+    """Report as plain text, the alternative of mail clients that do not show HTML"""
+    end = report.period_end - timedelta(days=1)
+    lines = [
+        _title(report),
+        f"{report.period_start} to {end}",
+        "",
+        f"Spend: {format_amount(report.cost)} ({_percent(_change_percent(report))} "
+        f"from {format_amount(report.previous_cost)})",
+    ]
+    if SECTION_BUDGETS in report.sections:
+        lines += ["", "Budgets this month:"]
+        lines += [
+            f"- {b.name}: {format_amount(b.projected_actual)} projected of {format_amount(b.amount)} "
+            f"({b.utilization:.0%})"
+            for b in report.budgets
+        ] or ["- No budgets"]
+    if SECTION_COST_CHANGES in report.sections:
+        lines += ["", "Top cost changes:"]
+        lines += [
+            f"- {c.project or '(no project)'} / {c.service}: {format_amount(c.previous_cost)} -> "
+            f"{format_amount(c.cost)} ({_percent(c.delta_percent)})"
+            for c in report.cost_changes
+        ] or ["- No changes"]
+    if SECTION_RECOMMENDATIONS in report.sections:
+        lines += ["", "Recommendations:"]
+        lines += [
+            f"- {r.summary}" + (f" (save {format_amount(r.monthly_savings)}/month)" if r.monthly_savings else "")
+            for r in report.recommendations
+        ] or ["- No recommendations"]
+    return "\n".join(lines)
+
+
+def _change_percent(report: CostReport) -> Optional[float]:
+    if not report.previous_cost:
+        return None
+    return round((report.cost - report.previous_cost) / report.previous_cost * 100, 1)
+
+
+def _table(headers: List[str], rows: List[List[str]], empty: str) -> str:
+    if not rows:
+        return f'<p style="color:#666">{html.escape(empty)}</p>'
+    cell = 'style="padding:4px 8px;border-bottom:1px solid #ddd;text-align:{align}"'
+    head = "".join(f"<th {cell.format(align='left' if i == 0 else 'right')}>{html.escape(h)}</th>"
+                   for i, h in enumerate(headers))
+    body = "".join(
+        "<tr>" + "".join(
+            f"<td {cell.format(align='left' if i == 0 else 'right')}>{html.escape(value)}</td>"
+            for i, value in enumerate(row)
+        ) + "</tr>"
+        for row in rows
+    )
+    return f'<table style="border-collapse:collapse"><tr>{head}</tr>{body}</table>'
+
+
+def render_report_html(report: CostReport) -> str:
+    """Report as an HTML mail body, styled inline for mail clients"""
+    end = report.period_end - timedelta(days=1)
+    parts = [
+        f"<h2>{html.escape(_title(report))}</h2>",
+        f"<p>{report.period_start} to {end}</p>",
+        f"<p><b>Spend: {html.escape(format_amount(report.cost))}</b> "
+        f"({html.escape(_percent(_change_percent(report)))} "
+        f"from {html.escape(format_amount(report.previous_cost))})</p>",
+    ]
+    if SECTION_BUDGETS in report.sections:
+        parts.append("<h3>Budgets this month</h3>")
+        parts.append(_table(
+            ["Budget", "Amount", "Actual to date", "Projected", "Utilization"],
+            [
+                [b.name, format_amount(b.amount), format_amount(b.actual_to_date),
+                 format_amount(b.projected_actual), f"{b.utilization:.0%}"]
+                for b in report.budgets
+            ],
+            "No budgets",
+        ))
+    if SECTION_COST_CHANGES in report.sections:
+        parts.append("<h3>Top cost changes</h3>")
+        parts.append(_table(
+            ["Project / service", "Previous", "Current", "Change"],
+            [
+                [f"{c.project or '(no project)'} / {c.service}", format_amount(c.previous_cost),
+                 format_amount(c.cost), f"{format_amount(c.delta)} ({_percent(c.delta_percent)})"]
+                for c in report.cost_changes
+            ],
+            "No changes",
+        ))
+    if SECTION_RECOMMENDATIONS in report.sections:
+        parts.append("<h3>Recommendations</h3>")
+        parts.append(_table(
+            ["Recommendation", "Savings / month"],
+            [
+                [r.summary, format_amount(r.monthly_savings) if r.monthly_savings is not None else "-"]
+                for r in report.recommendations
+            ],
+            "No recommendations",
+        ))
+    body = "\n".join(parts)
+    return f'<html><body style="font-family:Arial,sans-serif;font-size:14px">\n{body}\n</body></html>'
+
+
+class ReportScheduler:
+    """Mails the cost reports of every tenant's schedules when they are due"""
+
+    def __init__(
+        self,
+        store: Store,
+        builder: CostReportBuilder,
+        mailer: Mailer,
+        clock: Callable[[], datetime] = utcnow,
+        locks=None,
+    ):
+        """
+        Initialize scheduler
+
+        Args:
+            store: Store holding the schedules
+            builder: Builder of the reports
+            mailer: Mailer sending them
+            clock: Current time (aware UTC)
+            locks: Locks (src.cache.Locks) claimed per report so one replica sends it
+        """
+        self.store = store
+        self.builder = builder
+        self.mailer = mailer
+        self.clock = clock
+        self.locks = locks
+
+    def send_due(self) -> List[str]:
+        """
+        Send the reports that are due
+
+        Failures are logged rather than raised, and retried at the next check.
+
+        Returns:
+            "<schedule id>:<period>" of each report sent
+        """
+        today = self.clock().date()
+        sent = []
+        for schedule in self.store.list_report_schedules():
+            if not is_due(schedule, today):
+                continue
+            period, _, _ = report_period(schedule.frequency, today)
+            lock = f"cost-report:{schedule.id}:{period}"
+            token = None
+            if self.locks is not None:
+                token = self.locks.acquire(lock, 2 * 86400)
+                if token is None:
+                    continue
+            try:
+                self.send(schedule, today)
+                self.store.mark_report_sent(schedule.id, period)
+            except Exception as e:
+                logger.error(f"Cost report {period} of schedule {schedule.id} ({schedule.tenant_id}) not sent: {e}")
+                if token is not None:
+                    self.locks.release(lock, token)
+                continue
+            sent.append(f"{schedule.id}:{period}")
+        return sent
+
+    def send(self, schedule: ReportScheduleRecord, today: Optional[date] = None) -> CostReport:
+        """
+        Send a schedule's report of the last complete period, whether due or not
+
+        Raises:
+            smtplib.SMTPException, OSError: If the relay refused or could not be reached
+        """
+        report = self.builder.build(
+            schedule.tenant_id, schedule.frequency, schedule.sections, name=schedule.name, today=today
+        )
+        self.mailer.send(
+            schedule.recipients,
+            _title(report),
+            render_report_text(report),
+            [(f"cost-report-{report.tenant_id}-{report.period}.csv", render_report_csv(report).encode("utf-8"),
+              "text/csv")],
+            html=render_report_html(report),
+        )
+        logger.info(
+            f"Cost report {report.period} of schedule {schedule.id} ({schedule.tenant_id}) "
+            f"sent to {len(schedule.recipients)} recipients"
+        )
+        return report
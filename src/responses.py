@@ -0,0 +1,740 @@
+"""
+Response models of the HTTP API
+
+Declared as endpoint response models so the OpenAPI spec served at
+/openapi.json is generated from the same types the handlers return.
+"""
+
+from datetime import date, datetime
+from typing import Dict, List, Optional
+
+from pydantic import BaseModel, Field
+
+from .allocation import AllocationReport
+from .approvals import EstimateReview
+from .anomalies import AnomalyReport
+from .audit import AuditEvent
+from .billing import CompactionResult, IngestResult
+from .budgets import Budget, BudgetStatus, BudgetWarning
+from .commitments import CommitmentReport
+from .compare import CompareResult
+from .diff import DiffResult
+from .discounts import DiscountRule
+from .estimator import BatchEstimateResult, EstimateResult, ItemExplanation, LicenseRate, PRICING_MODEL_VERSION
+from .forecast import Forecast
+from .iam import RoleAssignment
+from .inventory import CollectResult, IdleReport, RegionMigrationResult
+from .k8s import (
+    ArmMigrationResult,
+    ClusterEstimateResult,
+    KubernetesEstimateResult,
+    NodePoolRecommendation,
+    RightsizingResult,
+    UsageEstimateResult,
+)
+from .notifications import DeliveryResult, Webhook
+from .price_changes import PriceChangeReport
+from .policies import PolicyVerdict
+from .pricing import CatalogStatus, CloudCapabilities, InstanceTypeInfo, RegionInfo
+from .profiles import UsageProfile
+from .providers.openstack import PrivateCloudRates
+from .server import CheckResult
+from .reports import AccuracyReport, ChargebackReport, CostReport, ReportSchedule
+from .scenarios import Scenario, ScenarioComparison
+from .store import EstimateRecord, JobRecord, TenantRecord
+from .tenancy import PriceSheetEntry
+from .terraform import TerraformEstimateResult
+
+
+class ExchangeRate(BaseModel):
+    """Exchange rate applied to a converted response"""
+
+    base: str = Field(..., description="Currency the estimate was computed in (USD)")
+    currency: str
+    rate: float = Field(..., description="Units of currency per base unit")
+    source: str = Field(..., description="Rate source: ecb, static or identity")
+    as_of: datetime = Field(..., description="When the rate was published")
+
+
+class BatchEstimateResponse(BaseModel):
+    """POST /estimate/batch"""
+
+    estimate: BatchEstimateResult
+    exchange_rate: Optional[ExchangeRate] = Field(None, description="Set when a currency was requested")
+    timestamp: str
+
+
+class EstimateResponse(BaseModel):
+    """POST /estimate"""
+
+    estimate_id: Optional[str] = Field(None, description="History id, None if the store is not configured")
+    estimate: EstimateResult
+    exchange_rate: Optional[ExchangeRate] = Field(None, description="Set when a currency was requested")
+    budget_warnings: List[BudgetWarning] = Field(
+        default_factory=list,
+        description="Budgets the estimate's projected spend reaches a threshold of (USD)"
+    )
+    policy: Optional[PolicyVerdict] = Field(None, description="Verdict of the cost policies, None without policies")
+    catalog_version: Optional[date] = Field(
+        None, description="Catalog snapshot the estimate was priced from, None without a store"
+    )
+    pricing_model_version: int = PRICING_MODEL_VERSION
+    explanation: Optional[List[ItemExplanation]] = Field(
+        None, description="Derivation of each line item's cost (USD), with explain=true"
+    )
+    timestamp: str
+
+
+class KubernetesEstimateResponse(BaseModel):
+    """POST /estimate/kubernetes"""
+
+    estimate_id: Optional[str] = None
+    estimate: KubernetesEstimateResult
+    exchange_rate: Optional[ExchangeRate] = None
+    budget_warnings: List[BudgetWarning] = Field(default_factory=list)
+    timestamp: str
+
+
+class KubernetesUsageEstimateResponse(BaseModel):
+    """GET /estimate/kubernetes/usage"""
+
+    estimate: UsageEstimateResult
+    exchange_rate: Optional[ExchangeRate] = None
+    timestamp: str
+
+
+class RightsizingResponse(BaseModel):
+    """GET /recommendations/rightsizing"""
+
+    recommendations: RightsizingResult
+    exchange_rate: Optional[ExchangeRate] = None
+    timestamp: str
+
+
+class NodePoolRecommendationResponse(BaseModel):
+    """GET /recommendations/nodepools"""
+
+    recommendation: NodePoolRecommendation
+    exchange_rate: Optional[ExchangeRate] = None
+    timestamp: str
+
+
+class ArmMigrationResponse(BaseModel):
+    """GET /recommendations/arm"""
+
+    recommendations: ArmMigrationResult
+    exchange_rate: Optional[ExchangeRate] = None
+    timestamp: str
+
+
+class CommitmentsResponse(BaseModel):
+    """GET /recommendations/commitments"""
+
+    recommendations: CommitmentReport
+    exchange_rate: Optional[ExchangeRate] = None
+    timestamp: str
+
+
+class IdleResourcesResponse(BaseModel):
+    """GET /recommendations/idle"""
+
+    report: IdleReport
+    exchange_rate: Optional[ExchangeRate] = None
+    timestamp: str
+
+
+class InventoryResponse(BaseModel):
+    """POST /inventory"""
+
+    source: str
+    recorded: int
+    timestamp: str
+
+
+class RegionMigrationResponse(BaseModel):
+    """POST /analyze/region-migration"""
+
+    analysis: RegionMigrationResult
+    exchange_rate: Optional[ExchangeRate] = None
+    timestamp: str
+
+
+class ClusterEstimateResponse(BaseModel):
+    """POST /estimate/cluster"""
+
+    estimate_id: Optional[str] = None
+    estimate: ClusterEstimateResult
+    exchange_rate: Optional[ExchangeRate] = None
+    timestamp: str
+
+
+class HelmChartInfo(BaseModel):
+    """Chart a Helm estimate was rendered from"""
+
+    source: str = Field(..., description="Uploaded archive name or chart reference")
+    repo_url: Optional[str] = None
+    version: Optional[str] = None
+    release_name: str
+    namespace: str
+
+
+class HelmEstimateResponse(KubernetesEstimateResponse):
+    """POST /estimate/helm"""
+
+    chart: HelmChartInfo
+
+
+class TerraformEstimateResponse(BaseModel):
+    """POST /estimate/terraform"""
+
+    estimate_id: Optional[str] = None
+    estimate: TerraformEstimateResult
+    exchange_rate: Optional[ExchangeRate] = None
+    budget_warnings: List[BudgetWarning] = Field(default_factory=list)
+    timestamp: str
+
+
+class PulumiEstimateResponse(BaseModel):
+    """POST /estimate/pulumi"""
+
+    estimate_id: Optional[str] = None
+    estimate: TerraformEstimateResult
+    exchange_rate: Optional[ExchangeRate] = None
+    budget_warnings: List[BudgetWarning] = Field(default_factory=list)
+    timestamp: str
+
+
+class CloudFormationEstimateResponse(BaseModel):
+    """POST /estimate/cloudformation"""
+
+    estimate_id: Optional[str] = None
+    estimate: TerraformEstimateResult
+    exchange_rate: Optional[ExchangeRate] = None
+    budget_warnings: List[BudgetWarning] = Field(default_factory=list)
+    timestamp: str
+
+
+class CrossplaneEstimateResponse(BaseModel):
+    """POST /estimate/crossplane"""
+
+    estimate_id: Optional[str] = None
+    estimate: TerraformEstimateResult
+    exchange_rate: Optional[ExchangeRate] = None
+    budget_warnings: List[BudgetWarning] = Field(default_factory=list)
+    timestamp: str
+
+
+class TerraformResourceTypesResponse(BaseModel):
+    """GET /estimate/terraform/resource-types"""
+
+    resource_types: List[str]
+    timestamp: str
+
+
+class EstimateDiffResponse(BaseModel):
+    """POST /estimate/diff"""
+
+    diff: DiffResult
+    exchange_rate: Optional[ExchangeRate] = None
+    policy: Optional[PolicyVerdict] = Field(None, description="Verdict of the cost policies, None without policies")
+    timestamp: str
+
+
+class CompareResponse(BaseModel):
+    """POST /compare"""
+
+    comparison: CompareResult
+    exchange_rate: Optional[ExchangeRate] = None
+    timestamp: str
+
+
+class EstimateRecordResponse(BaseModel):
+    """GET /estimates/{estimate_id}"""
+
+    estimate: EstimateRecord
+    exchange_rate: Optional[ExchangeRate] = None
+    timestamp: str
+
+
+class EstimateSummary(BaseModel):
+    """Recorded estimate without its request and result"""
+
+    id: str
+    tenant_id: str
+    kind: str
+    project: Optional[str] = None
+    monthly_cost: float
+    currency: str
+    labels: Dict[str, str] = Field(default_factory=dict)
+    created_at: datetime
+    catalog_version: Optional[date] = None
+    pricing_model_version: Optional[int] = None
+
+
+class EstimateRerunResponse(BaseModel):
+    """POST /estimates/{estimate_id}/rerun"""
+
+    estimate_id: str
+    estimate: EstimateResult
+    catalog_version: date = Field(..., description="Catalog snapshot the estimate was re-run against")
+    pricing_model_version: int
+    recorded_monthly_cost: float
+    recorded_pricing_model_version: Optional[int] = None
+    monthly_delta: float = Field(..., description="Re-run monthly cost minus the recorded one")
+    exchange_rate: Optional[ExchangeRate] = None
+    timestamp: str
+
+
+class EstimateReviewResponse(BaseModel):
+    """GET /estimates/{estimate_id}/review and the comment, approve and reject endpoints"""
+
+    review: EstimateReview
+    timestamp: str
+
+
+class EstimateListResponse(BaseModel):
+    """GET /estimates"""
+
+    estimates: List[EstimateSummary]
+    count: int
+    timestamp: str
+
+
+class JobResponse(BaseModel):
+    """POST /jobs, GET /jobs/{job_id}"""
+
+    job: JobRecord
+    timestamp: str
+
+
+class JobSummary(BaseModel):
+    """Estimation job without its request and result"""
+
+    id: str
+    tenant_id: str
+    kind: str
+    state: str
+    progress: float
+    progress_detail: Optional[str] = None
+    error: Optional[str] = None
+    estimate_id: Optional[str] = None
+    attempts: int
+    created_at: datetime
+    updated_at: datetime
+    started_at: Optional[datetime] = None
+    finished_at: Optional[datetime] = None
+    run_after: Optional[datetime] = None
+
+
+class JobListResponse(BaseModel):
+    """GET /jobs"""
+
+    jobs: List[JobSummary]
+    count: int
+    timestamp: str
+
+
+class HealthResponse(BaseModel):
+    """GET /healthz, GET /readyz"""
+
+    status: str = Field(..., description="ok, degraded, failing, starting or shutting_down")
+    checks: Dict[str, CheckResult] = Field(..., description="Health of each dependency")
+    timestamp: str
+
+
+class EnabledProvider(BaseModel):
+    """Pricing provider enabled in PRICING_PROVIDERS"""
+
+    name: str
+    clouds: List[str]
+
+
+class PricingProvidersResponse(BaseModel):
+    """GET /pricing/providers"""
+
+    available: List[str] = Field(..., description="Compiled-in provider names")
+    enabled: List[EnabledProvider]
+    offline: bool
+    timestamp: str
+
+
+class OpenStackRatesResponse(BaseModel):
+    """GET, PUT and DELETE /pricing/openstack/rates"""
+
+    rates: Optional[PrivateCloudRates] = Field(None, description="Rate card in use, None until one is set")
+    source: Optional[str] = Field(None, description="config (OPENSTACK_RATES_PATH) or api")
+    timestamp: str
+
+
+class AcceleratorTypeInfo(BaseModel):
+    """Accelerator type known to estimates"""
+
+    name: str = Field(..., description="GCP accelerator name, e.g. nvidia-tesla-t4")
+    vendor: str
+    model: str
+    memory_gb: float
+    reference_hourly: float = Field(..., description="List price of one GPU weighing it against vCPUs and memory")
+
+
+class AcceleratorTypesResponse(BaseModel):
+    """GET /pricing/accelerators"""
+
+    accelerators: List[AcceleratorTypeInfo]
+    timestamp: str
+
+
+class LicenseRatesResponse(BaseModel):
+    """GET /pricing/licenses"""
+
+    rates: Dict[str, LicenseRate] = Field(..., description="License name or <provider>/<name> -> rate")
+    timestamp: str
+
+
+class CatalogRefreshResponse(BaseModel):
+    """POST /catalog/refresh"""
+
+    message: str
+    providers: List[str]
+    timestamp: str
+
+
+class CatalogStatusResponse(BaseModel):
+    """GET /catalog/status"""
+
+    catalogs: List[CatalogStatus]
+    degraded: List[str] = Field(..., description="Providers whose catalog is older than max_age_seconds")
+    refresh_interval_seconds: float
+    max_age_seconds: float
+    timestamp: str
+
+
+class CatalogProvidersResponse(BaseModel):
+    """GET /catalog/providers"""
+
+    providers: List[CloudCapabilities]
+    offline: bool
+    timestamp: str
+
+
+class CatalogRegionsResponse(BaseModel):
+    """GET /catalog/{provider}/regions"""
+
+    provider: str
+    regions: List[RegionInfo]
+    total: int = Field(..., description="Regions matching the filters")
+    limit: int
+    offset: int
+    next_offset: Optional[int] = Field(None, description="Offset of the next page, None on the last page")
+    timestamp: str
+
+
+class CatalogInstanceTypesResponse(BaseModel):
+    """GET /catalog/{provider}/instance-types"""
+
+    provider: str
+    instance_types: List[InstanceTypeInfo]
+    total: int = Field(..., description="Instance types matching the filters")
+    limit: int
+    offset: int
+    next_offset: Optional[int] = Field(None, description="Offset of the next page, None on the last page")
+    timestamp: str
+
+
+class PriceChangesResponse(BaseModel):
+    """GET /catalog/changes"""
+
+    changes: PriceChangeReport
+    timestamp: str
+
+
+class CallerResponse(BaseModel):
+    """GET /iam/me"""
+
+    subject: Optional[str] = Field(None, description="Subject roles are assigned to, None without authentication")
+    method: Optional[str] = None
+    tenant_id: str
+    role: Optional[str] = Field(None, description="Assigned role, else the role equivalent to the scopes")
+    assigned: bool = Field(..., description="Whether the role is assigned in the tenant")
+    scopes: List[str]
+    timestamp: str
+
+
+class RoleAssignmentResponse(BaseModel):
+    """PUT/DELETE /iam/roles/{subject}"""
+
+    assignment: RoleAssignment
+    timestamp: str
+
+
+class RoleAssignmentListResponse(BaseModel):
+    """GET /iam/roles"""
+
+    assignments: List[RoleAssignment]
+    count: int
+    timestamp: str
+
+
+class AuditEventListResponse(BaseModel):
+    """GET /audit"""
+
+    events: List[AuditEvent]
+    count: int
+    timestamp: str
+
+
+class ApiKeyInfo(BaseModel):
+    """Issued API key without its hash"""
+
+    id: str
+    tenant_id: str
+    name: str
+    prefix: str = Field(..., description="First characters of the key")
+    scopes: List[str]
+    rate_limit: Optional[int] = None
+    created_at: datetime
+    revoked_at: Optional[datetime] = None
+
+
+class ApiKeyCreatedResponse(BaseModel):
+    """POST /admin/api-keys"""
+
+    api_key: ApiKeyInfo
+    key: str = Field(..., description="The API key; it is not stored and cannot be shown again")
+    timestamp: str
+
+
+class ApiKeyListResponse(BaseModel):
+    """GET /admin/api-keys"""
+
+    api_keys: List[ApiKeyInfo]
+    count: int
+    timestamp: str
+
+
+class ApiKeyRevokedResponse(BaseModel):
+    """DELETE /admin/api-keys/{key_id}"""
+
+    api_key: ApiKeyInfo
+    timestamp: str
+
+
+class TenantResponse(BaseModel):
+    """POST /admin/tenants"""
+
+    tenant: TenantRecord
+    timestamp: str
+
+
+class TenantListResponse(BaseModel):
+    """GET /admin/tenants"""
+
+    tenants: List[TenantRecord]
+    count: int
+    timestamp: str
+
+
+class PriceOverride(PriceSheetEntry):
+    """Stored entry of a tenant's price sheet"""
+
+    updated_at: datetime
+
+
+class PriceSheetResponse(BaseModel):
+    """PUT and GET /admin/tenants/{tenant_id}/prices"""
+
+    tenant_id: str
+    prices: List[PriceOverride]
+    count: int
+    timestamp: str
+
+
+class DiscountRuleResponse(BaseModel):
+    """POST /discounts, and GET, PUT and DELETE /discounts/{rule_id}"""
+
+    rule: DiscountRule
+    timestamp: str
+
+
+class DiscountRuleListResponse(BaseModel):
+    """GET /discounts"""
+
+    rules: List[DiscountRule] = Field(..., description="Rules in evaluation order")
+    count: int
+    timestamp: str
+
+
+class UsageProfileResponse(BaseModel):
+    """GET, PUT and DELETE /profiles/{name}"""
+
+    profile: UsageProfile
+    timestamp: str
+
+
+class UsageProfileListResponse(BaseModel):
+    """GET /profiles"""
+
+    profiles: List[UsageProfile] = Field(..., description="Built-in profiles, then the tenant's own")
+    count: int
+    timestamp: str
+
+
+class BudgetInfo(Budget):
+    """Budget with its spend this month"""
+
+    status: Optional[BudgetStatus] = None
+
+
+class BudgetResponse(BaseModel):
+    """POST /budgets, and GET, PUT and DELETE /budgets/{budget_id}"""
+
+    budget: BudgetInfo
+    timestamp: str
+
+
+class BudgetListResponse(BaseModel):
+    """GET /budgets"""
+
+    budgets: List[BudgetInfo]
+    count: int
+    timestamp: str
+
+
+class ActualCostsResponse(BaseModel):
+    """POST /actuals"""
+
+    recorded: int
+    total_amount: float
+    timestamp: str
+
+
+class ScenarioResponse(BaseModel):
+    """POST /scenarios, and GET, PUT and DELETE /scenarios/{scenario_id}"""
+
+    scenario: Scenario
+    timestamp: str
+
+
+class ScenarioListResponse(BaseModel):
+    """GET /scenarios"""
+
+    scenarios: List[Scenario]
+    count: int
+    timestamp: str
+
+
+class ScenarioComparisonResponse(BaseModel):
+    """GET /scenarios/{scenario_id}/compare"""
+
+    comparison: ScenarioComparison
+    exchange_rate: Optional[ExchangeRate] = Field(None, description="Set when a currency was requested")
+    timestamp: str
+
+
+class AccuracyReportResponse(BaseModel):
+    """GET /reports/accuracy"""
+
+    report: AccuracyReport
+    timestamp: str
+
+
+class AllocationResponse(BaseModel):
+    """GET /allocation"""
+
+    allocation: AllocationReport
+    timestamp: str
+
+
+class ForecastResponse(BaseModel):
+    """GET /forecast"""
+
+    forecast: Forecast
+    timestamp: str
+
+
+class AnomaliesResponse(BaseModel):
+    """GET /anomalies"""
+
+    report: AnomalyReport
+    timestamp: str
+
+
+class ChargebackReportResponse(BaseModel):
+    """GET /reports/chargeback/{period} (format=json)"""
+
+    report: ChargebackReport
+    exchange_rate: Optional[ExchangeRate] = Field(None, description="Set when a currency was requested")
+    timestamp: str
+
+
+class BillingIngestResponse(BaseModel):
+    """POST /admin/billing/ingest"""
+
+    ingestion: IngestResult
+    timestamp: str
+
+
+class ActualsCompactResponse(BaseModel):
+    """POST /admin/actuals/compact"""
+
+    compaction: CompactionResult
+    timestamp: str
+
+
+class InventoryCollectResponse(BaseModel):
+    """POST /admin/inventory/collect"""
+
+    collection: CollectResult
+    timestamp: str
+
+
+class WebhookCreatedResponse(BaseModel):
+    """POST /webhooks"""
+
+    webhook: Webhook
+    secret: Optional[str] = Field(None, description="Generated signing secret, shown only once")
+    timestamp: str
+
+
+class ReportScheduleResponse(BaseModel):
+    """POST /reports/schedules, GET, PUT and DELETE /reports/schedules/{schedule_id}"""
+
+    schedule: ReportSchedule
+    timestamp: str
+
+
+class ReportScheduleListResponse(BaseModel):
+    """GET /reports/schedules"""
+
+    schedules: List[ReportSchedule]
+    count: int
+    timestamp: str
+
+
+class ReportSentResponse(BaseModel):
+    """POST /reports/schedules/{schedule_id}/send"""
+
+    report: CostReport
+    recipients: List[str]
+    timestamp: str
+
+
+class WebhookResponse(BaseModel):
+    """GET, PUT and DELETE /webhooks/{webhook_id}"""
+
+    webhook: Webhook
+    timestamp: str
+
+
+class WebhookListResponse(BaseModel):
+    """GET /webhooks"""
+
+    webhooks: List[Webhook]
+    count: int
+    timestamp: str
+
+
+class WebhookTestResponse(BaseModel):
+    """POST /webhooks/{webhook_id}/test"""
+
+    delivery: DeliveryResult
+    timestamp: str
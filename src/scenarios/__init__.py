@@ -0,0 +1,33 @@
+"""
+Scenarios Module
+
+This module keeps named variations of a baseline estimate request (more
+traffic, spot instances, another region) and compares their costs as a
+matrix of totals and per-item costs.
+"""
+
+from .models import (
+    BASELINE,
+    Scenario,
+    ScenarioComparison,
+    ScenarioCost,
+    ScenarioRow,
+    ScenarioSpec,
+    ScenarioTotal,
+    Variation,
+)
+from .variations import apply_variation
+from .comparer import ScenarioComparer
+
+__all__ = [
+    "BASELINE",
+    "Scenario",
+    "ScenarioComparison",
+    "ScenarioCost",
+    "ScenarioRow",
+    "ScenarioSpec",
+    "ScenarioTotal",
+    "Variation",
+    "apply_variation",
+    "ScenarioComparer",
+]
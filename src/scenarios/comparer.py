@@ -0,0 +1,124 @@
+"""
+Scenario comparison
+
+Prices the baseline and each variation as a separate estimate and lays
+the results out as a matrix: one column per scenario, one row per item
+of the baseline request. A scenario that cannot be priced (e.g. no spot
+price in a target region) is reported in its total and left empty in the
+rows instead of failing the comparison.
+"""
+
+import logging
+from typing import Dict, List, Optional
+
+from ..estimator import CostEstimator, EstimateRequest, EstimateResult, traffic_volumes
+from ..money import round_money
+from ..pricing import PriceNotFoundError, ProviderNotFoundError
+from .models import BASELINE, Scenario, ScenarioComparison, ScenarioCost, ScenarioRow, ScenarioTotal
+from .variations import apply_variation
+
+logger = logging.getLogger(__name__)
+
+# Request list -> (row kind, result list, baseline field labelling unnamed items)
+_ITEM_LISTS = (
+    ("resources", "resource", "line_items", "instance_type"),
+    ("databases", "database", "database_items", "instance_class"),
+    ("object_storage", "object_storage", "object_storage_items", "storage_class"),
+    ("serverless", "serverless", "serverless_items", "platform"),
+)
+
+
+def _item_costs(request: EstimateRequest, result: EstimateResult) -> Dict[str, float]:
+    """Row key -> monthly cost of an estimate's items"""
+    costs = {}
+    for name, _, items, _ in _ITEM_LISTS:
+        for index, item in enumerate(getattr(result, items)):
+            costs[f"{name}[{index}]"] = item.monthly_cost
+    # Each traffic item yields one line item per direction with traffic, in order
+    transfer_items = iter(result.transfer_items)
+    for index, traffic in enumerate(request.traffic):
+        for direction in traffic_volumes(traffic):
+            costs[f"traffic[{index}].{direction}"] = next(transfer_items).monthly_cost
+    return costs
+
+
+def _rows(baseline: EstimateRequest) -> List[ScenarioRow]:
+    """Rows of a comparison, labelled from the baseline's items"""
+    rows = []
+    for name, kind, _, field in _ITEM_LISTS:
+        for index, item in enumerate(getattr(baseline, name)):
+            label = item.name or "/".join(part for part in (item.provider, item.region, getattr(item, field)) if part)
+            rows.append(ScenarioRow(key=f"{name}[{index}]", kind=kind, label=label))
+    for index, traffic in enumerate(baseline.traffic):
+        for direction in traffic_volumes(traffic):
+            label = f"{traffic.name or f'{traffic.provider}/{traffic.region}'} {direction}"
+            rows.append(ScenarioRow(key=f"traffic[{index}].{direction}", kind="transfer", label=label))
+    return rows
+
+
+class ScenarioComparer:
+    """Compares the costs of a scenario's baseline and variations"""
+
+    def __init__(self, estimator: CostEstimator):
+        """
+        Initialize scenario comparer
+
+        Args:
+            estimator: Estimator pricing each scenario
+        """
+        self.estimator = estimator
+
+    def compare(self, scenario: Scenario) -> ScenarioComparison:
+        """Price every scenario and build the cost matrix"""
+        requests: Dict[str, Optional[EstimateRequest]] = {BASELINE: scenario.baseline}
+        errors: Dict[str, str] = {}
+        for variation in scenario.variations:
+            try:
+                requests[variation.name] = apply_variation(scenario.baseline, variation)
+            except ValueError as e:
+                requests[variation.name], errors[variation.name] = None, f"Invalid variation: {e}"
+
+        results: Dict[str, EstimateResult] = {}
+        for name, request in requests.items():
+            if request is None:
+                continue
+            try:
+                results[name] = self.estimator.estimate(request)
+            except (PriceNotFoundError, ProviderNotFoundError, ValueError) as e:
+                errors[name] = str(e)
+
+        rows = _rows(scenario.baseline)
+        for name in requests:
+            costs = _item_costs(requests[name], results[name]) if name in results else {}
+            for row in rows:
+                cost = costs.get(row.key)
+                monthly_cost = round_money(cost) if cost is not None else None
+                row.costs.append(ScenarioCost(scenario=name, monthly_cost=monthly_cost))
+
+        base = results.get(BASELINE)
+        totals = []
+        for name in requests:
+            result = results.get(name)
+            if result is None:
+                totals.append(ScenarioTotal(scenario=name, error=errors.get(name)))
+                continue
+            total = ScenarioTotal(scenario=name, monthly_cost=result.monthly_cost, yearly_cost=result.yearly_cost)
+            if base is not None:
+                total.monthly_delta = round_money(result.monthly_cost - base.monthly_cost)
+                if base.monthly_cost:
+                    total.delta_percent = round(total.monthly_delta / base.monthly_cost * 100, 2)
+            totals.append(total)
+
+        priced = [total for total in totals if total.monthly_cost is not None]
+        cheapest = min(priced, key=lambda total: total.monthly_cost).scenario if priced else None
+        logger.info(
+            f"Compared scenario {scenario.id} ({scenario.name}): {len(priced)} of {len(totals)} scenarios priced"
+        )
+        return ScenarioComparison(
+            scenario_id=scenario.id,
+            name=scenario.name,
+            scenarios=list(requests),
+            totals=totals,
+            rows=rows,
+            cheapest=cheapest,
+        )
@@ -0,0 +1,118 @@
+"""
+Data models for scenarios and scenario comparisons
+"""
+
+from datetime import datetime
+from typing import Dict, List, Optional
+
+from pydantic import BaseModel, Field, validator
+
+from ..estimator import EstimateRequest
+from ..pricing import normalize_pricing_model
+from ..store import DEFAULT_TENANT, ScenarioRecord
+
+# Column of the unmodified request in comparisons; variations cannot take the name
+BASELINE = "baseline"
+MAX_VARIATIONS = 20
+
+
+class Variation(BaseModel):
+    """Named change applied to every item of the baseline request"""
+
+    name: str = Field(..., min_length=1, max_length=100)
+    description: Optional[str] = None
+    capacity_scale: float = Field(
+        default=1.0, gt=0, le=100, description="Multiplies resource and database counts (at least 1 remains)"
+    )
+    usage_scale: float = Field(
+        default=1.0, gt=0, le=100,
+        description="Multiplies traffic, object storage operations and retrieval, and serverless invocations",
+    )
+    storage_scale: float = Field(default=1.0, gt=0, le=100, description="Multiplies object storage and database GB")
+    pricing_model: Optional[str] = Field(None, description="Pricing model of every resource, e.g. spot")
+    regions: Dict[str, str] = Field(
+        default_factory=dict, description="Region moves, e.g. {\"ap-northeast-2\": \"us-east-1\"}"
+    )
+    hours: Optional[float] = Field(None, gt=0, le=744, description="Running hours per month of resources and databases")
+
+    @validator("name")
+    def validate_name(cls, v):
+        if v.strip().lower() == BASELINE:
+            raise ValueError(f"'{BASELINE}' is the name of the unmodified request")
+        return v.strip()
+
+    @validator("pricing_model")
+    def validate_pricing_model(cls, v):
+        return normalize_pricing_model(v) if v is not None else None
+
+
+class ScenarioSpec(BaseModel):
+    """Scenario as created or replaced through /scenarios"""
+
+    name: str = Field(..., min_length=1, max_length=100)
+    description: Optional[str] = None
+    baseline: EstimateRequest
+    variations: List[Variation] = Field(..., min_items=1, max_items=MAX_VARIATIONS)
+
+    @validator("variations")
+    def unique_names(cls, v):
+        names = [variation.name for variation in v]
+        duplicates = sorted({name for name in names if names.count(name) > 1})
+        if duplicates:
+            raise ValueError(f"Variation names must be unique: {', '.join(duplicates)}")
+        return v
+
+    def to_record(self, tenant_id: str, scenario_id: Optional[str] = None) -> ScenarioRecord:
+        return ScenarioRecord(id=scenario_id, tenant_id=tenant_id, **self.dict())
+
+
+class Scenario(ScenarioSpec):
+    """Stored scenario"""
+
+    id: str
+    tenant_id: str = DEFAULT_TENANT
+    created_at: Optional[datetime] = None
+    updated_at: Optional[datetime] = None
+
+    @classmethod
+    def from_record(cls, record: ScenarioRecord) -> "Scenario":
+        return cls(**record.dict())
+
+
+class ScenarioTotal(BaseModel):
+    """Totals of one column of a comparison"""
+
+    scenario: str
+    monthly_cost: Optional[float] = Field(None, description="None if the scenario could not be priced")
+    yearly_cost: Optional[float] = None
+    monthly_delta: Optional[float] = Field(None, description="Monthly cost minus the baseline's")
+    delta_percent: Optional[float] = Field(None, description="Monthly delta in percent of the baseline")
+    error: Optional[str] = Field(None, description="Why the scenario could not be priced")
+
+
+class ScenarioCost(BaseModel):
+    """Monthly cost of an item in one scenario"""
+
+    scenario: str
+    monthly_cost: Optional[float] = Field(None, description="None if the scenario could not be priced")
+
+
+class ScenarioRow(BaseModel):
+    """Monthly cost of one item of the baseline request in every scenario"""
+
+    key: str = Field(..., description="Position of the item in the request, e.g. resources[0], traffic[1].inter_az")
+    kind: str = Field(..., description="resource, database, object_storage, serverless or transfer")
+    label: str = Field(..., description="Item name, or its SKU in the baseline")
+    costs: List[ScenarioCost] = Field(default_factory=list, description="Costs in the order of the scenarios")
+
+
+class ScenarioComparison(BaseModel):
+    """Cost matrix of a baseline and its variations"""
+
+    scenario_id: str
+    name: str
+    scenarios: List[str] = Field(..., description="Columns: the baseline followed by the variations")
+    totals: List[ScenarioTotal]
+    rows: List[ScenarioRow]
+    cheapest: Optional[str] = Field(None, description="Scenario with the lowest monthly cost")
+    currency: str = "USD"
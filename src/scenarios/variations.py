@@ -0,0 +1,65 @@
+"""
+Applying variations to a baseline request
+
+A variation rewrites every item of the request the same way, so item i of
+a list in the varied request still corresponds to item i of the baseline.
+Counts are scaled and rounded, keeping at least one of each item.
+"""
+
+from typing import Any, Dict
+
+from ..estimator import EstimateRequest
+from .models import Variation
+
+# Request list -> fields scaled by usage_scale
+_USAGE_FIELDS = {
+    "traffic": ("inter_az_gb", "inter_region_gb", "internet_egress_gb"),
+    "object_storage": ("write_requests", "read_requests", "retrieval_gb", "transition_in_gb"),
+    "serverless": ("invocations",),
+}
+# Request list -> fields scaled by storage_scale
+_STORAGE_FIELDS = {
+    "object_storage": ("storage_gb",),
+    "databases": ("storage_gb", "backup_storage_gb"),
+}
+_INTEGER_FIELDS = {"write_requests", "read_requests", "invocations"}
+
+
+def _scale(item: Dict[str, Any], field: str, factor: float) -> None:
+    value = item[field] * factor
+    item[field] = round(value) if field in _INTEGER_FIELDS else value
+
+
+def apply_variation(baseline: EstimateRequest, variation: Variation) -> EstimateRequest:
+    """
+    The baseline request with a variation applied
+
+    Raises:
+        ValueError: If the varied request is invalid
+    """
+    request = baseline.dict()
+
+    for name in ("resources", "databases"):
+        for item in request[name]:
+            item["count"] = max(1, round(item["count"] * variation.capacity_scale))
+            if variation.hours is not None:
+                item["hours"] = variation.hours
+
+    if variation.pricing_model is not None:
+        for item in request["resources"]:
+            item["pricing_model"] = variation.pricing_model
+
+    for scale, fields in ((variation.usage_scale, _USAGE_FIELDS), (variation.storage_scale, _STORAGE_FIELDS)):
+        if scale == 1.0:
+            continue
+        for name, names in fields.items():
+            for item in request[name]:
+                for field in names:
+                    _scale(item, field, scale)
+
+    if variation.regions:
+        for name in ("resources", "databases", "object_storage", "serverless", "traffic"):
+            for item in request[name]:
+                item["region"] = variation.regions.get(item["region"], item["region"])
+
+    return EstimateRequest(**request)
@@ -0,0 +1,46 @@
+"""
+Server Module
+
+Lifecycle of the API server: request and background job tracking for
+graceful shutdown, dependency health checks for the Kubernetes probes,
+load shedding of expensive routes, and the uvicorn runner.
+"""
+
+from .lifecycle import ServerLifecycle
+from .health import (
+    HealthChecker,
+    HealthReport,
+    CheckResult,
+    CheckOutcome,
+    store_check,
+    catalog_check,
+    jobs_check,
+    STATUS_OK,
+    STATUS_DEGRADED,
+    STATUS_FAILING,
+    PROBE_LIVENESS,
+    PROBE_READINESS,
+)
+from .shedding import ConcurrencyLimit, LoadShedder, Overloaded, parse_concurrency_limits
+from .runner import serve
+
+__all__ = [
+    "ServerLifecycle",
+    "HealthChecker",
+    "HealthReport",
+    "CheckResult",
+    "CheckOutcome",
+    "store_check",
+    "catalog_check",
+    "jobs_check",
+    "STATUS_OK",
+    "STATUS_DEGRADED",
+    "STATUS_FAILING",
+    "PROBE_LIVENESS",
+    "PROBE_READINESS",
+    "ConcurrencyLimit",
+    "LoadShedder",
+    "Overloaded",
+    "parse_concurrency_limits",
+    "serve",
+]
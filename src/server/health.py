@@ -0,0 +1,201 @@
+"""
+Dependency health checks
+
+Every check reports its dependency as ok, degraded or failing, with
+detail for operators. A failing check only fails the probes it is
+critical for: liveness (/healthz) fails on what a restart fixes, such as
+a crashed background job, and readiness (/readyz) on what requests need,
+such as the store, so Kubernetes stops routing traffic to the pod until
+the dependency recovers instead of restarting it. Degraded dependencies
+never fail a probe.
+"""
+
+import asyncio
+import inspect
+import logging
+import time
+from typing import Any, Awaitable, Callable, Dict, List, NamedTuple, Optional, Union
+
+from pydantic import BaseModel, Field
+
+from ..pricing import CATALOG_DEGRADED
+
+logger = logging.getLogger(__name__)
+
+STATUS_OK = "ok"
+STATUS_DEGRADED = "degraded"
+STATUS_FAILING = "failing"
+
+PROBE_LIVENESS = "liveness"
+PROBE_READINESS = "readiness"
+PROBES = (PROBE_LIVENESS, PROBE_READINESS)
+
+# Seconds a check may take before it counts as failing
+DEFAULT_CHECK_TIMEOUT = 2.0
+
+
+class CheckOutcome(NamedTuple):
+    """What a check found"""
+
+    status: str
+    detail: Optional[str] = None
+    info: Dict[str, Any] = {}
+
+
+Check = Callable[[], Union[CheckOutcome, Awaitable[CheckOutcome]]]
+
+
+class CheckResult(BaseModel):
+    """Health of one dependency"""
+
+    status: str = Field(..., description="ok, degraded or failing")
+    critical: bool = Field(..., description="Whether failing fails the probe")
+    detail: Optional[str] = None
+    latency_ms: float
+    info: Dict[str, Any] = Field(default_factory=dict)
+
+
+class HealthReport(BaseModel):
+    """Health of the service and its dependencies"""
+
+    status: str = Field(..., description="failing when a critical check fails, degraded when any check is not ok")
+    checks: Dict[str, CheckResult]
+
+
+class _Registered(NamedTuple):
+    check: Check
+    probes: frozenset
+
+
+class HealthChecker:
+    """Runs dependency checks for the liveness and readiness probes"""
+
+    def __init__(self, timeout: float = DEFAULT_CHECK_TIMEOUT):
+        """
+        Initialize health checker
+
+        Args:
+            timeout: Seconds each check may take
+        """
+        self.timeout = timeout
+        self._checks: Dict[str, _Registered] = {}
+
+    def register(self, name: str, check: Check, critical_for: tuple = ()) -> None:
+        """
+        Add a check; synchronous checks run in a worker thread
+
+        Args:
+            name: Dependency name in reports
+            check: Returns the dependency's CheckOutcome, may be a coroutine function
+            critical_for: Probes failed when the check fails (PROBE_LIVENESS, PROBE_READINESS)
+        """
+        unknown = [p for p in critical_for if p not in PROBES]
+        if unknown:
+            raise ValueError(f"Unknown probe '{unknown[0]}', expected one of {', '.join(PROBES)}")
+        self._checks[name] = _Registered(check, frozenset(critical_for))
+
+    def names(self) -> List[str]:
+        """Registered check names"""
+        return list(self._checks)
+
+    async def run(self, probe: Optional[str] = None) -> HealthReport:
+        """
+        Run every check concurrently
+
+        Args:
+            probe: Probe whose critical checks decide a failing status, None for any
+        """
+        names = list(self._checks)
+        results = await asyncio.gather(*(self._run_one(self._checks[name].check) for name in names))
+        checks: Dict[str, CheckResult] = {}
+        for name, (outcome, latency) in zip(names, results):
+            probes = self._checks[name].probes
+            checks[name] = CheckResult(
+                status=outcome.status,
+                critical=bool(probes) if probe is None else probe in probes,
+                detail=outcome.detail,
+                latency_ms=round(latency * 1000, 1),
+                info=outcome.info,
+            )
+
+        if any(c.critical and c.status == STATUS_FAILING for c in checks.values()):
+            status = STATUS_FAILING
+        elif any(c.status != STATUS_OK for c in checks.values()):
+            status = STATUS_DEGRADED
+        else:
+            status = STATUS_OK
+        return HealthReport(status=status, checks=checks)
+
+    async def _run_one(self, check: Check):
+        started = time.monotonic()
+        try:
+            if inspect.iscoroutinefunction(check):
+                outcome = await asyncio.wait_for(check(), self.timeout)
+            else:
+                outcome = await asyncio.wait_for(asyncio.to_thread(check), self.timeout)
+        except asyncio.TimeoutError:
+            outcome = CheckOutcome(STATUS_FAILING, f"Check timed out after {self.timeout:g}s")
+        except Exception as e:
+            logger.warning(f"Health check failed: {e}")
+            outcome = CheckOutcome(STATUS_FAILING, str(e))
+        return outcome, time.monotonic() - started
+
+
+def store_check(store) -> Check:
+    """Check that the store answers queries"""
+
+    def check() -> CheckOutcome:
+        version = store.schema_version()
+        return CheckOutcome(STATUS_OK, info={"dialect": store.dialect, "schema_version": version})
+
+    return check
+
+
+def catalog_check(scheduler) -> Check:
+    """Check the freshness of each provider's price catalog; stale catalogs are degraded"""
+
+    def check() -> CheckOutcome:
+        catalogs = scheduler.status()
+        states = {c.provider: c.state for c in catalogs}
+        degraded = [c.provider for c in catalogs if c.state == CATALOG_DEGRADED]
+        if degraded:
+            detail = f"Price catalogs older than the max age: {', '.join(degraded)}"
+            return CheckOutcome(STATUS_DEGRADED, detail, states)
+        return CheckOutcome(STATUS_OK, info=states)
+
+    return check
+
+
+def jobs_check(jobs: Callable[[], Dict[str, Any]], lifecycle=None) -> Check:
+    """
+    Check that scheduled background jobs are still running
+
+    Args:
+        jobs: Returns the asyncio tasks of periodic jobs and the objects of
+            worker threads (with a running property) by name; None for disabled jobs
+        lifecycle: Server lifecycle whose pending background jobs are reported
+    """
+
+    async def check() -> CheckOutcome:
+        states: Dict[str, Any] = {}
+        stopped: List[str] = []
+        for name, job in jobs().items():
+            if job is None:
+                continue
+            if isinstance(job, asyncio.Future):
+                if not job.done():
+                    states[name] = "running"
+                    continue
+                error = None if job.cancelled() else job.exception()
+                states[name] = f"failed: {error}" if error else "stopped"
+            else:
+                states[name] = "running" if job.running else "stopped"
+            if states[name] != "running":
+                stopped.append(name)
+        if lifecycle is not None:
+            states["pending_jobs"] = lifecycle.pending_jobs
+        if stopped:
+            return CheckOutcome(STATUS_FAILING, f"Background jobs not running: {', '.join(stopped)}", states)
+        return CheckOutcome(STATUS_OK, info=states)
+
+    return check
@@ -0,0 +1,93 @@
+"""
+Server lifecycle
+
+Tracks in-flight requests and background jobs (price catalog refreshes)
+so shutdown can stop taking new work and wait for running work to finish.
+"""
+
+import asyncio
+import logging
+import time
+from contextlib import asynccontextmanager
+from typing import Callable, Set
+
+logger = logging.getLogger(__name__)
+
+# Poll interval while draining
+_DRAIN_POLL_SECONDS = 0.05
+
+
+class ServerLifecycle:
+    """In-flight work of a running server"""
+
+    def __init__(self):
+        self._draining = False
+        self._in_flight = 0
+        self._jobs: Set[asyncio.Future] = set()
+
+    @property
+    def draining(self) -> bool:
+        """Whether shutdown has started; new requests should be refused"""
+        return self._draining
+
+    @property
+    def in_flight(self) -> int:
+        """Requests currently being handled"""
+        return self._in_flight
+
+    @property
+    def pending_jobs(self) -> int:
+        """Background jobs still running"""
+        return sum(1 for job in self._jobs if not job.done())
+
+    @asynccontextmanager
+    async def track(self):
+        """Count a request as in flight while the block runs"""
+        self._in_flight += 1
+        try:
+            yield
+        finally:
+            self._in_flight -= 1
+
+    def run_in_background(self, fn: Callable[[], None], name: str) -> asyncio.Future:
+        """
+        Run a blocking job in the default executor and wait for it on shutdown
+
+        Failures are logged; the job's exception is not re-raised.
+        """
+        job = asyncio.get_running_loop().run_in_executor(None, fn)
+        self._jobs.add(job)
+
+        def _done(future: asyncio.Future) -> None:
+            self._jobs.discard(future)
+            if not future.cancelled() and future.exception() is not None:
+                logger.error(f"Background job {name} failed: {future.exception()}")
+
+        job.add_done_callback(_done)
+        return job
+
+    def begin_shutdown(self) -> None:
+        """Refuse new requests from now on"""
+        self._draining = True
+
+    async def drain(self, timeout: float) -> bool:
+        """
+        Wait for in-flight requests and background jobs
+
+        Jobs running in executor threads cannot be interrupted; any still
+        running after the timeout are abandoned when the process exits.
+
+        Returns:
+            True if everything finished within the timeout
+        """
+        self.begin_shutdown()
+        deadline = time.monotonic() + timeout
+        while self._in_flight or self.pending_jobs:
+            if time.monotonic() >= deadline:
+                logger.warning(
+                    f"Shutdown drain timed out after {timeout}s: "
+                    f"{self._in_flight} requests, {self.pending_jobs} background jobs still running"
+                )
+                return False
+            await asyncio.sleep(_DRAIN_POLL_SECONDS)
+        return True
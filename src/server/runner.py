@@ -0,0 +1,34 @@
+"""
+HTTP server runner
+
+Runs the API under uvicorn with timeouts from settings. uvicorn handles
+SIGTERM/SIGINT: it stops accepting connections, lets open requests finish
+for up to SERVER_SHUTDOWN_TIMEOUT seconds, then runs the application's
+shutdown handler, which drains background jobs.
+"""
+
+import uvicorn
+
+
+def serve(app: str, settings, reload: bool = False) -> None:
+    """
+    Serve an ASGI application until terminated
+
+    Args:
+        app: Import string of the application, e.g. src.main:app
+        settings: Application settings (listen address and server timeouts)
+        reload: Restart on code changes (development only)
+    """
+    config = uvicorn.Config(
+        app,
+        host=settings.api_host,
+        port=settings.api_port,
+        reload=reload,
+        timeout_keep_alive=settings.server_keepalive_timeout,
+        timeout_graceful_shutdown=settings.server_shutdown_timeout,
+        log_level=settings.log_level.lower(),
+        # Keep the application's log format; requests are logged by its middleware
+        log_config=None,
+        access_log=False,
+    )
+    uvicorn.Server(config).run()
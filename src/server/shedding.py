@@ -0,0 +1,158 @@
+"""
+Load shedding
+
+Expensive routes (cluster scans, large Terraform plans, Helm renders)
+handle a bounded number of requests at once. Requests over the limit wait
+in a bounded queue for a while and are refused with 503 and a Retry-After
+once the queue is full or their wait times out, so a burst of CI jobs
+queues briefly instead of exhausting the replica's memory.
+"""
+
+import asyncio
+import math
+import time
+from contextlib import asynccontextmanager
+from typing import Callable, Dict, List, Optional
+
+# Weight of the latest request in the average time requests hold a slot
+_HOLD_SMOOTHING = 0.2
+
+
+class Overloaded(Exception):
+    """Raised when a route's requests in flight and queue are full"""
+
+    def __init__(self, route: str, retry_after: int):
+        super().__init__(f"Too many concurrent {route} requests, retry in {retry_after}s")
+        self.route = route
+        self.retry_after = retry_after
+
+
+def parse_concurrency_limits(entries: List[str]) -> Dict[str, int]:
+    """
+    Parse route limits such as ["/estimate/cluster=2", "/estimate/terraform=4"]
+
+    Raises:
+        ValueError: If an entry is malformed or its limit is not positive
+    """
+    limits: Dict[str, int] = {}
+    for entry in entries:
+        route, sep, limit = entry.strip().partition("=")
+        route = route.strip()
+        try:
+            if not sep or not route.startswith("/"):
+                raise ValueError
+            value = int(limit)
+        except ValueError:
+            raise ValueError(f"Invalid concurrency limit '{entry}', expected /path=limit") from None
+        if value < 1:
+            raise ValueError(f"Concurrency limit of {route} must be at least 1")
+        limits[route.rstrip("/") or "/"] = value
+    return limits
+
+
+class ConcurrencyLimit:
+    """Requests of one route handled at once, with a bounded queue for the rest"""
+
+    def __init__(
+        self,
+        route: str,
+        limit: int,
+        max_queue: int,
+        queue_timeout: float,
+        clock: Callable[[], float] = time.monotonic,
+    ):
+        """
+        Initialize the limit
+
+        Args:
+            route: Path of the route
+            limit: Requests handled at once
+            max_queue: Requests waiting for a slot at once (0 refuses without waiting)
+            queue_timeout: Seconds a request waits for a slot (0 refuses without waiting)
+            clock: Monotonic time source
+        """
+        self.route = route
+        self.limit = limit
+        self.max_queue = max_queue
+        self.queue_timeout = queue_timeout
+        self._clock = clock
+        self._semaphore = asyncio.Semaphore(limit)
+        self._active = 0
+        self._queued = 0
+        # Until a request finished, assume one takes as long as a queued request may wait
+        self._hold = max(queue_timeout, 1.0)
+
+    @property
+    def active(self) -> int:
+        """Requests being handled"""
+        return self._active
+
+    @property
+    def queued(self) -> int:
+        """Requests waiting for a slot"""
+        return self._queued
+
+    def retry_after(self) -> int:
+        """Seconds until the queue ahead of a new request is likely handled"""
+        rounds = (self._queued + 1) / self.limit
+        return max(1, math.ceil(self._hold * rounds))
+
+    @asynccontextmanager
+    async def slot(self):
+        """
+        Hold a slot while the block runs, waiting for one in the queue
+
+        Raises:
+            Overloaded: If the queue is full or no slot freed up in time
+        """
+        if self._semaphore.locked():
+            if self._queued >= self.max_queue or self.queue_timeout <= 0:
+                raise Overloaded(self.route, self.retry_after())
+            self._queued += 1
+            try:
+                await asyncio.wait_for(self._semaphore.acquire(), self.queue_timeout)
+            except asyncio.TimeoutError:
+                raise Overloaded(self.route, self.retry_after()) from None
+            finally:
+                self._queued -= 1
+        else:
+            await self._semaphore.acquire()
+
+        self._active += 1
+        start = self._clock()
+        try:
+            yield
+        finally:
+            self._active -= 1
+            self._hold += _HOLD_SMOOTHING * (self._clock() - start - self._hold)
+            self._semaphore.release()
+
+    def status(self) -> Dict[str, int]:
+        """Limit, requests handled and requests queued"""
+        return {"limit": self.limit, "active": self._active, "queued": self._queued}
+
+
+class LoadShedder:
+    """Concurrency limits of the expensive routes"""
+
+    def __init__(self, limits: Dict[str, int], max_queue: int, queue_timeout: float):
+        """
+        Initialize the shedder
+
+        Args:
+            limits: Requests handled at once by route path
+            max_queue: Requests of each route waiting for a slot at once
+            queue_timeout: Seconds a request waits for a slot
+        """
+        self._limits = {
+            route: ConcurrencyLimit(route, limit, max_queue, queue_timeout)
+            for route, limit in limits.items()
+        }
+
+    def limit_for(self, path: str) -> Optional[ConcurrencyLimit]:
+        """Limit of a request path, None for routes without one"""
+        return self._limits.get(path.rstrip("/") or "/")
+
+    def status(self) -> Dict[str, Dict[str, int]]:
+        """Limit, requests handled and requests queued by route"""
+        return {route: limit.status() for route, limit in self._limits.items()}
@@ -0,0 +1,25 @@
+"""
+Pricing Snapshot Module
+
+Exports and imports provider price catalogs so the estimator can run
+offline in air-gapped environments, and prices estimates pinned to the
+catalogs stored on a past day.
+"""
+
+from .snapshot import (
+    SNAPSHOT_FORMAT_VERSION,
+    export_snapshot,
+    import_snapshot,
+    read_snapshot,
+)
+from .pinned import PinnedEstimators, build_pinned_registry, check_catalog_version
+
+__all__ = [
+    "SNAPSHOT_FORMAT_VERSION",
+    "export_snapshot",
+    "import_snapshot",
+    "read_snapshot",
+    "PinnedEstimators",
+    "build_pinned_registry",
+    "check_catalog_version",
+]
@@ -0,0 +1,102 @@
+"""
+Pricing snapshot command line
+
+    python -m src.snapshot export pricing-snapshot.json --refresh
+    python -m src.snapshot import pricing-snapshot.json
+    python -m src.snapshot info pricing-snapshot.db
+
+The store is opened from STORE_URL, like the API server.
+"""
+
+import argparse
+import logging
+import sys
+from typing import List, Optional
+
+from ..store import StoreError, open_store
+from .snapshot import export_snapshot, import_snapshot, read_snapshot
+
+logger = logging.getLogger(__name__)
+
+
+def _open_store(settings):
+    if not settings.store_url:
+        raise SystemExit("STORE_URL is not set")
+    store = open_store(settings.store_url)
+    store.migrate()
+    return store
+
+
+def _export(args, settings) -> int:
+    store = _open_store(settings)
+    try:
+        if args.refresh:
+            # Download fresh catalogs into the store before exporting
+            from .. import providers  # noqa: F401  (registers pricing provider factories)
+            from ..pricing import build_registry, load_plugins
+            from ..providers.cache import set_catalog_store
+
+            set_catalog_store(store)
+            load_plugins(settings)
+            build_registry(settings.pricing_providers, settings).refresh()
+
+        count = export_snapshot(store, args.output, args.provider or None)
+    finally:
+        store.close()
+    print(f"Exported {count} catalogs to {args.output}")
+    return 0 if count else 1
+
+
+def _import(args, settings) -> int:
+    store = _open_store(settings)
+    try:
+        count = import_snapshot(store, args.input)
+    finally:
+        store.close()
+    print(f"Imported {count} catalogs from {args.input}")
+    return 0
+
+
+def _info(args, settings) -> int:
+    for record in read_snapshot(args.input):
+        entries = record.document.get("entries") or {}
+        print(f"{record.provider:8} {record.key:40} {len(entries):>8} prices  "
+              f"fetched {record.fetched_at.isoformat()}  {record.version}")
+    return 0
+
+
+def build_parser() -> argparse.ArgumentParser:
+    parser = argparse.ArgumentParser(prog="python -m src.snapshot", description="Pricing snapshot export/import")
+    commands = parser.add_subparsers(dest="command", required=True)
+
+    export = commands.add_parser("export", help="Write stored catalogs to a snapshot file")
+    export.add_argument("output", help="Snapshot file (.db/.sqlite for SQLite, otherwise JSON)")
+    export.add_argument("--provider", action="append", help="Only export this provider (repeatable)")
+    export.add_argument("--refresh", action="store_true", help="Download catalogs of PRICING_PROVIDERS first")
+    export.set_defaults(handler=_export)
+
+    imp = commands.add_parser("import", help="Load a snapshot file into the store")
+    imp.add_argument("input", help="Snapshot file")
+    imp.set_defaults(handler=_import)
+
+    info = commands.add_parser("info", help="List the catalogs in a snapshot file")
+    info.add_argument("input", help="Snapshot file")
+    info.set_defaults(handler=_info)
+
+    return parser
+
+
+def main(argv: Optional[List[str]] = None, settings=None) -> int:
+    """Run the snapshot command line"""
+    logging.basicConfig(level=logging.INFO)
+    args = build_parser().parse_args(argv)
+
+    if settings is None:
+        from config.settings import get_settings
+        settings = get_settings()
+
+    try:
+        return args.handler(args, settings)
+    except (OSError, ValueError, StoreError) as e:
+        print(f"error: {e}", file=sys.stderr)
+        return 1
@@ -0,0 +1,90 @@
+"""
+Estimates pinned to a catalog snapshot
+
+The store keeps each catalog as it stood at the end of every day it was
+saved. An estimate pinned to a day is priced by providers built from the
+catalogs of that day, in offline mode so no price is fetched live.
+Building them parses every catalog, so the providers of the most
+recently pinned days are kept loaded.
+"""
+
+import logging
+import threading
+from collections import OrderedDict
+from datetime import date
+from typing import Any, Callable, List
+
+from ..estimator import Estimator
+from ..pricing import ProviderRegistry, build_registry
+from ..providers.cache import pinned_catalogs
+from ..store import Store
+
+logger = logging.getLogger(__name__)
+
+
+class _OfflineSettings:
+    """Application settings with cloud pricing API calls disabled"""
+
+    offline = True
+
+    def __init__(self, settings: Any):
+        self._settings = settings
+
+    def __getattr__(self, name: str) -> Any:
+        return getattr(self._settings, name)
+
+
+def build_pinned_registry(names: List[str], settings: Any, snapshot: date) -> ProviderRegistry:
+    """
+    Create a registry of the named providers reading a day's catalog snapshot
+
+    Raises:
+        ValueError: If catalogs are not kept in a store
+    """
+    with pinned_catalogs(snapshot):
+        return build_registry(names, _OfflineSettings(settings))
+
+
+def check_catalog_version(store: Store, version: date, today: date) -> None:
+    """
+    Check that estimates can be pinned to a day
+
+    Raises:
+        ValueError: If the day is in the future
+        LookupError: If no catalog was stored on or before the day
+    """
+    if version > today:
+        raise ValueError(f"catalog_version {version} is in the future")
+    if not any(snapshot <= version for snapshot in store.list_catalog_snapshots()):
+        raise LookupError(f"No catalog snapshot on or before {version}")
+
+
+class PinnedEstimators:
+    """Estimators of catalog snapshots, the most recently used kept loaded"""
+
+    def __init__(self, build: Callable[[date], Estimator], max_snapshots: int = 4):
+        """
+        Initialize pinned estimators
+
+        Args:
+            build: Creates the estimator of a snapshot day
+            max_snapshots: Snapshot days whose estimators are kept loaded
+        """
+        self.build = build
+        self.max_snapshots = max_snapshots
+
+        self._estimators: "OrderedDict[date, Estimator]" = OrderedDict()
+        self._lock = threading.Lock()
+
+    def get(self, snapshot: date) -> Estimator:
+        """Estimator of a snapshot day, built on first use"""
+        with self._lock:
+            estimator = self._estimators.pop(snapshot, None)
+            if estimator is None:
+                estimator = self.build(snapshot)
+                logger.info(f"Loaded pricing providers of catalog snapshot {snapshot}")
+            self._estimators[snapshot] = estimator
+            while len(self._estimators) > self.max_snapshots:
+                evicted, _ = self._estimators.popitem(last=False)
+                logger.info(f"Unloaded pricing providers of catalog snapshot {evicted}")
+            return estimator
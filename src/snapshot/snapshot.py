@@ -0,0 +1,109 @@
+"""
+Pricing snapshots
+
+A snapshot is a portable copy of the provider catalogs held in the store,
+written either as a JSON document or as a SQLite database. Snapshots are
+exported where the cloud pricing APIs are reachable and imported into
+air-gapped installations running in offline mode.
+"""
+
+import json
+import logging
+import os
+from datetime import datetime, timezone
+from typing import List, Optional
+
+from ..store import CatalogRecord, SQLiteStore, Store
+
+logger = logging.getLogger(__name__)
+
+SNAPSHOT_FORMAT_VERSION = 1
+
+# File extensions written and read as SQLite databases; anything else is JSON
+SQLITE_EXTENSIONS = (".db", ".sqlite", ".sqlite3")
+
+
+def _is_sqlite(path: str) -> bool:
+    return path.lower().endswith(SQLITE_EXTENSIONS)
+
+
+def export_snapshot(store: Store, path: str, providers: Optional[List[str]] = None) -> int:
+    """
+    Write the store's catalogs to a snapshot file
+
+    Args:
+        store: Store holding downloaded catalogs
+        path: Output file (.db/.sqlite for SQLite, otherwise JSON)
+        providers: Only export these providers (all if not set)
+
+    Returns:
+        Number of catalogs exported
+    """
+    records = [r for r in store.list_catalogs() if not providers or r.provider in providers]
+
+    if _is_sqlite(path):
+        if os.path.exists(path):
+            os.remove(path)
+        target = SQLiteStore(path)
+        try:
+            target.migrate()
+            for record in records:
+                target.save_catalog(record.provider, record.key, record.document, record.fetched_at)
+        finally:
+            target.close()
+    else:
+        document = {
+            "format_version": SNAPSHOT_FORMAT_VERSION,
+            "created_at": datetime.now(timezone.utc).isoformat(),
+            "catalogs": [json.loads(record.json()) for record in records],
+        }
+        tmp_path = f"{path}.tmp"
+        with open(tmp_path, "w", encoding="utf-8") as f:
+            json.dump(document, f)
+        os.replace(tmp_path, path)
+
+    logger.info(f"Exported {len(records)} catalogs to snapshot {path}")
+    return len(records)
+
+
+def read_snapshot(path: str) -> List[CatalogRecord]:
+    """
+    Read catalogs from a snapshot file
+
+    Raises:
+        ValueError: If the file is not a supported snapshot
+    """
+    if _is_sqlite(path):
+        if not os.path.exists(path):
+            raise FileNotFoundError(path)
+        source = SQLiteStore(path)
+        try:
+            return source.list_catalogs()
+        finally:
+            source.close()
+
+    with open(path, "r", encoding="utf-8") as f:
+        try:
+            document = json.load(f)
+        except json.JSONDecodeError as e:
+            raise ValueError(f"Invalid snapshot {path}: {e}") from None
+
+    version = document.get("format_version") if isinstance(document, dict) else None
+    if version != SNAPSHOT_FORMAT_VERSION:
+        raise ValueError(f"Unsupported snapshot format version {version!r} in {path}")
+    return [CatalogRecord(**catalog) for catalog in document.get("catalogs", [])]
+
+
+def import_snapshot(store: Store, path: str) -> int:
+    """
+    Load a snapshot's catalogs into the store, keeping their original fetch times
+
+    Returns:
+        Number of catalogs imported
+    """
+    records = read_snapshot(path)
+    for record in records:
+        store.save_catalog(record.provider, record.key, record.document, record.fetched_at)
+
+    logger.info(f"Imported {len(records)} catalogs from snapshot {path}")
+    return len(records)
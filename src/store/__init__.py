@@ -0,0 +1,102 @@
+"""
+Persistence Module
+
+Stores price catalogs, estimate history and review annotations, API
+keys, tenants, tenant price overrides, discount rules, budgets, actual costs, resource
+inventory, webhooks, report schedules, estimation jobs, scenarios, usage profiles,
+role assignments and the audit log in PostgreSQL or SQLite, with schema migrations
+applied at startup.
+"""
+
+from .models import (
+    ActualCostRecord,
+    ApiKeyRecord,
+    AuditEventRecord,
+    BudgetRecord,
+    CatalogRecord,
+    DiscountRuleRecord,
+    EstimateAnnotationRecord,
+    EstimateRecord,
+    InventoryRecord,
+    JobRecord,
+    PriceOverrideRecord,
+    ReportScheduleRecord,
+    RoleAssignmentRecord,
+    UsageProfileRecord,
+    ScenarioRecord,
+    TenantRecord,
+    WebhookRecord,
+    DEFAULT_TENANT,
+    RESOLUTION_RAW,
+    RESOLUTION_DAILY,
+    RESOLUTION_MONTHLY,
+    RESOLUTIONS,
+    JOB_QUEUED,
+    JOB_RUNNING,
+    JOB_SUCCEEDED,
+    JOB_FAILED,
+    JOB_DEAD_LETTER,
+    JOB_STATES,
+)
+from .base import Store, StoreError
+from .migrations import MIGRATIONS, Migration, latest_version
+from .sqlite import SQLiteStore
+from .factory import open_store
+from .history import (
+    record_estimate,
+    KIND_RESOURCES,
+    KIND_KUBERNETES,
+    KIND_TERRAFORM,
+    KIND_HELM,
+    KIND_PULUMI,
+    KIND_CLOUDFORMATION,
+    KIND_CROSSPLANE,
+    KIND_CLUSTER,
+)
+
+__all__ = [
+    "ActualCostRecord",
+    "ApiKeyRecord",
+    "AuditEventRecord",
+    "BudgetRecord",
+    "CatalogRecord",
+    "DiscountRuleRecord",
+    "EstimateAnnotationRecord",
+    "EstimateRecord",
+    "InventoryRecord",
+    "JobRecord",
+    "PriceOverrideRecord",
+    "ReportScheduleRecord",
+    "RoleAssignmentRecord",
+    "UsageProfileRecord",
+    "ScenarioRecord",
+    "TenantRecord",
+    "WebhookRecord",
+    "DEFAULT_TENANT",
+    "RESOLUTION_RAW",
+    "RESOLUTION_DAILY",
+    "RESOLUTION_MONTHLY",
+    "RESOLUTIONS",
+    "JOB_QUEUED",
+    "JOB_RUNNING",
+    "JOB_SUCCEEDED",
+    "JOB_FAILED",
+    "JOB_DEAD_LETTER",
+    "JOB_STATES",
+    "Store",
+    "StoreError",
+    "MIGRATIONS",
+    "Migration",
+    "latest_version",
+    "SQLiteStore",
+    "open_store",
+    "record_estimate",
+    "KIND_RESOURCES",
+    "KIND_KUBERNETES",
+    "KIND_TERRAFORM",
+    "KIND_HELM",
+    "KIND_PULUMI",
+    "KIND_CLOUDFORMATION",
+    "KIND_CROSSPLANE",
+    "KIND_CLUSTER",
+]
@@ -0,0 +1,480 @@
+"""
+Store interface
+
+Persists price catalogs and estimates so they survive restarts.
+PostgreSQL is used in production; SQLite for local development and tests.
+"""
+
+from abc import ABC, abstractmethod
+from datetime import date, datetime
+from typing import Any, Dict, List, Optional, Sequence
+
+from .models import (
+    ActualCostRecord,
+    ApiKeyRecord,
+    AuditEventRecord,
+    BudgetRecord,
+    CatalogRecord,
+    DiscountRuleRecord,
+    EstimateAnnotationRecord,
+    EstimateRecord,
+    InventoryRecord,
+    JobRecord,
+    PriceOverrideRecord,
+    ReportScheduleRecord,
+    RoleAssignmentRecord,
+    UsageProfileRecord,
+    ScenarioRecord,
+    TenantRecord,
+    WebhookRecord,
+)
+
+
+class StoreError(Exception):
+    """Raised when the backing database cannot be used"""
+
+
+class Store(ABC):
+    """Abstract persistence layer"""
+
+    dialect: str = ""
+
+    @abstractmethod
+    def migrate(self) -> List[int]:
+        """
+        Apply pending schema migrations
+
+        Returns:
+            Versions applied by this call (empty if up to date)
+        """
+
+    @abstractmethod
+    def schema_version(self) -> int:
+        """Highest applied migration version (0 for an empty database)"""
+
+    @abstractmethod
+    def save_catalog(
+        self,
+        provider: str,
+        key: str,
+        document: Dict[str, Any],
+        fetched_at: Optional[datetime] = None,
+    ) -> CatalogRecord:
+        """Insert or replace a provider's catalog, stamped with fetched_at (default now)"""
+
+    @abstractmethod
+    def load_catalog(self, provider: str, key: str) -> Optional[CatalogRecord]:
+        """Load a catalog, or None if it was never saved"""
+
+    @abstractmethod
+    def list_catalogs(self, provider: Optional[str] = None) -> List[CatalogRecord]:
+        """Stored catalogs, optionally for one provider"""
+
+    @abstractmethod
+    def load_catalog_snapshot(self, provider: str, key: str, snapshot: date) -> Optional[CatalogRecord]:
+        """
+        Load a catalog as it was stored at the end of a day
+
+        Every save_catalog also keeps a copy as that day's snapshot (the
+        day of its fetch time); the newest snapshot on or before the day is
+        returned, None if the catalog was first saved later.
+        """
+
+    @abstractmethod
+    def list_catalog_snapshots(self) -> List[date]:
+        """Days with at least one catalog snapshot, newest first"""
+
+    @abstractmethod
+    def save_estimate(self, record: EstimateRecord) -> EstimateRecord:
+        """Persist an estimate, assigning id and created_at when missing"""
+
+    @abstractmethod
+    def get_estimate(self, estimate_id: str, tenant_id: Optional[str] = None) -> Optional[EstimateRecord]:
+        """Load an estimate by id, or None if it does not exist (or belongs to another tenant)"""
+
+    @abstractmethod
+    def list_estimates(
+        self,
+        project: Optional[str] = None,
+        since: Optional[datetime] = None,
+        until: Optional[datetime] = None,
+        limit: int = 100,
+        tenant_id: Optional[str] = None,
+    ) -> List[EstimateRecord]:
+        """
+        Estimate history, newest first
+
+        Args:
+            project: Only estimates of this project
+            since: Only estimates created at or after this time
+            until: Only estimates created before this time
+            limit: Maximum number of records
+            tenant_id: Only estimates of this tenant
+        """
+
+    @abstractmethod
+    def append_estimate_annotation(self, record: EstimateAnnotationRecord) -> EstimateAnnotationRecord:
+        """Append a comment or review decision to an estimate, assigning id and created_at"""
+
+    @abstractmethod
+    def list_estimate_annotations(
+        self, estimate_id: str, tenant_id: Optional[str] = None
+    ) -> List[EstimateAnnotationRecord]:
+        """An estimate's comments and review decisions, oldest first"""
+
+    @abstractmethod
+    def save_api_key(self, record: ApiKeyRecord) -> ApiKeyRecord:
+        """Persist a new API key, assigning id and created_at when missing"""
+
+    @abstractmethod
+    def find_api_key(self, key_hash: str) -> Optional[ApiKeyRecord]:
+        """Load an API key by the hash of the key, or None if unknown"""
+
+    @abstractmethod
+    def list_api_keys(self, include_revoked: bool = False, tenant_id: Optional[str] = None) -> List[ApiKeyRecord]:
+        """Issued API keys, oldest first, optionally of one tenant"""
+
+    @abstractmethod
+    def revoke_api_key(self, key_id: str, tenant_id: Optional[str] = None) -> Optional[ApiKeyRecord]:
+        """
+        Revoke an API key, optionally only if it belongs to a tenant
+
+        Returns:
+            The revoked key (unchanged if already revoked), or None if it does not exist
+        """
+
+    @abstractmethod
+    def save_tenant(self, record: TenantRecord) -> TenantRecord:
+        """
+        Create a tenant, assigning created_at when missing
+
+        Raises:
+            StoreError: If the tenant already exists
+        """
+
+    @abstractmethod
+    def get_tenant(self, tenant_id: str) -> Optional[TenantRecord]:
+        """Load a tenant, or None if it does not exist"""
+
+    @abstractmethod
+    def list_tenants(self) -> List[TenantRecord]:
+        """Tenants ordered by id"""
+
+    @abstractmethod
+    def replace_price_overrides(
+        self, tenant_id: str, overrides: List[PriceOverrideRecord]
+    ) -> List[PriceOverrideRecord]:
+        """Replace a tenant's price overrides (an empty list removes them)"""
+
+    @abstractmethod
+    def list_price_overrides(self, tenant_id: str) -> List[PriceOverrideRecord]:
+        """A tenant's price overrides"""
+
+    @abstractmethod
+    def save_discount_rule(self, record: DiscountRuleRecord) -> DiscountRuleRecord:
+        """
+        Insert a discount rule, or replace the rule with its id, assigning id and created_at when missing
+
+        A rule with the id of another tenant's rule is left unchanged.
+        """
+
+    @abstractmethod
+    def get_discount_rule(self, rule_id: str, tenant_id: Optional[str] = None) -> Optional[DiscountRuleRecord]:
+        """Load a discount rule, or None if it does not exist (or belongs to another tenant)"""
+
+    @abstractmethod
+    def list_discount_rules(self, tenant_id: str) -> List[DiscountRuleRecord]:
+        """A tenant's discount rules in evaluation order: by priority, then id"""
+
+    @abstractmethod
+    def delete_discount_rule(self, rule_id: str, tenant_id: Optional[str] = None) -> bool:
+        """Delete a discount rule; False if it does not exist (or belongs to another tenant)"""
+
+    @abstractmethod
+    def save_budget(self, record: BudgetRecord) -> BudgetRecord:
+        """
+        Insert a budget, or replace the budget with its id, assigning id and created_at when missing
+
+        A budget with the id of another tenant's budget is left unchanged.
+        """
+
+    @abstractmethod
+    def get_budget(self, budget_id: str, tenant_id: Optional[str] = None) -> Optional[BudgetRecord]:
+        """Load a budget, or None if it does not exist (or belongs to another tenant)"""
+
+    @abstractmethod
+    def list_budgets(self, tenant_id: str) -> List[BudgetRecord]:
+        """A tenant's budgets ordered by name"""
+
+    @abstractmethod
+    def delete_budget(self, budget_id: str, tenant_id: Optional[str] = None) -> bool:
+        """Delete a budget; False if it does not exist (or belongs to another tenant)"""
+
+    @abstractmethod
+    def save_scenario(self, record: ScenarioRecord) -> ScenarioRecord:
+        """
+        Insert a scenario, or replace the scenario with its id, assigning id and created_at when missing
+
+        A scenario with the id of another tenant's scenario is left unchanged.
+        """
+
+    @abstractmethod
+    def get_scenario(self, scenario_id: str, tenant_id: Optional[str] = None) -> Optional[ScenarioRecord]:
+        """Load a scenario, or None if it does not exist (or belongs to another tenant)"""
+
+    @abstractmethod
+    def list_scenarios(self, tenant_id: str) -> List[ScenarioRecord]:
+        """A tenant's scenarios ordered by name"""
+
+    @abstractmethod
+    def delete_scenario(self, scenario_id: str, tenant_id: Optional[str] = None) -> bool:
+        """Delete a scenario; False if it does not exist (or belongs to another tenant)"""
+
+    @abstractmethod
+    def save_role_assignment(self, record: RoleAssignmentRecord) -> RoleAssignmentRecord:
+        """Assign a subject's role in a tenant, replacing its current role"""
+
+    @abstractmethod
+    def get_role_assignment(self, tenant_id: str, subject: str) -> Optional[RoleAssignmentRecord]:
+        """A subject's role in a tenant, or None if it has none"""
+
+    @abstractmethod
+    def list_role_assignments(self, tenant_id: str) -> List[RoleAssignmentRecord]:
+        """A tenant's role assignments ordered by subject"""
+
+    @abstractmethod
+    def delete_role_assignment(self, tenant_id: str, subject: str) -> bool:
+        """Remove a subject's role in a tenant; False if it had none"""
+
+    @abstractmethod
+    def save_usage_profile(self, record: UsageProfileRecord) -> UsageProfileRecord:
+        """Create or replace a tenant's usage profile of the record's name"""
+
+    @abstractmethod
+    def get_usage_profile(self, tenant_id: str, name: str) -> Optional[UsageProfileRecord]:
+        """A tenant's usage profile by name, or None if it has none of that name"""
+
+    @abstractmethod
+    def list_usage_profiles(self, tenant_id: str) -> List[UsageProfileRecord]:
+        """A tenant's usage profiles ordered by name"""
+
+    @abstractmethod
+    def delete_usage_profile(self, tenant_id: str, name: str) -> bool:
+        """Delete a tenant's usage profile; False if it has none of that name"""
+
+    @abstractmethod
+    def append_audit_event(self, record: AuditEventRecord) -> AuditEventRecord:
+        """Append an audit event, assigning its ID and time; audit events are never updated or deleted"""
+
+    @abstractmethod
+    def list_audit_events(
+        self,
+        tenant_id: Optional[str] = None,
+        actor: Optional[str] = None,
+        resource_type: Optional[str] = None,
+        resource_id: Optional[str] = None,
+        action: Optional[str] = None,
+        since: Optional[datetime] = None,
+        until: Optional[datetime] = None,
+        limit: int = 100,
+    ) -> List[AuditEventRecord]:
+        """
+        Audit events, newest first
+
+        Args:
+            tenant_id: Only events of this tenant
+            actor: Only events of this caller
+            resource_type: Only changes of this kind of resource
+            resource_id: Only changes of this resource
+            action: Only events of this action
+            since: Only events at or after this time
+            until: Only events before this time
+            limit: Maximum number of records
+        """
+
+    @abstractmethod
+    def add_actual_costs(self, records: List[ActualCostRecord]) -> List[ActualCostRecord]:
+        """Append actual costs, stamped with recorded_at"""
+
+    @abstractmethod
+    def replace_actual_costs(
+        self,
+        tenant_id: str,
+        source: str,
+        since: date,
+        until: date,
+        records: List[ActualCostRecord],
+    ) -> List[ActualCostRecord]:
+        """
+        Replace a tenant's actual costs of one source in a usage date range
+
+        Re-ingesting a billing export for a period restates its costs
+        instead of adding them twice.
+
+        Args:
+            tenant_id: Tenant the costs belong to
+            source: Source whose costs are replaced, e.g. aws-cur
+            since: First usage date replaced
+            until: First usage date not replaced
+            records: Costs of the source in the range
+        """
+
+    @abstractmethod
+    def list_actual_costs(
+        self,
+        tenant_id: str,
+        since: date,
+        until: date,
+        project: Optional[str] = None,
+    ) -> List[ActualCostRecord]:
+        """
+        A tenant's actual costs by usage date
+
+        Costs are returned at the resolution they are kept at. Monthly
+        roll-ups of months the range covers only partly are prorated by
+        the days covered and dated the first of them.
+
+        Args:
+            tenant_id: Tenant the costs belong to
+            since: First usage date included
+            until: First usage date excluded
+            project: Only costs of this project
+        """
+
+    @abstractmethod
+    def earliest_actual_cost(self, resolution: str, until: date) -> Optional[date]:
+        """Earliest usage date of any tenant's actual costs of a resolution before until, None without any"""
+
+    @abstractmethod
+    def scan_actual_costs(self, resolutions: Sequence[str], since: date, until: date) -> List[ActualCostRecord]:
+        """Every tenant's actual costs of the given resolutions in a usage date range"""
+
+    @abstractmethod
+    def replace_actual_cost_rollups(
+        self,
+        resolutions: Sequence[str],
+        since: date,
+        until: date,
+        records: List[ActualCostRecord],
+    ) -> int:
+        """
+        Replace every tenant's actual costs of the given resolutions in a usage date range by their roll-ups
+
+        Returns:
+            Number of costs replaced
+        """
+
+    @abstractmethod
+    def delete_actual_costs(self, resolution: str, until: date) -> int:
+        """Delete every tenant's actual costs of a resolution before a usage date, returning how many"""
+
+    @abstractmethod
+    def replace_inventory(self, tenant_id: str, source: str, records: List[InventoryRecord]) -> List[InventoryRecord]:
+        """
+        Replace the resources a tenant's inventory source reported, stamping observed_at when missing
+
+        Each report is a full snapshot, so resources the source no longer
+        reports are removed.
+        """
+
+    @abstractmethod
+    def list_inventory(self, tenant_id: str, kind: Optional[str] = None) -> List[InventoryRecord]:
+        """A tenant's inventoried resources ordered by kind and id, optionally of one kind"""
+
+    def close(self) -> None:
+        """Release database connections"""
+
+    @abstractmethod
+    def save_webhook(self, record: WebhookRecord) -> WebhookRecord:
+        """
+        Insert a webhook, or replace the webhook with its id, assigning id and created_at when missing
+
+        A webhook with the id of another tenant's webhook is left unchanged.
+        """
+
+    @abstractmethod
+    def get_webhook(self, webhook_id: str, tenant_id: Optional[str] = None) -> Optional[WebhookRecord]:
+        """Load a webhook, or None if it does not exist (or belongs to another tenant)"""
+
+    @abstractmethod
+    def list_webhooks(self, tenant_id: str) -> List[WebhookRecord]:
+        """A tenant's webhooks ordered by name"""
+
+    @abstractmethod
+    def delete_webhook(self, webhook_id: str, tenant_id: Optional[str] = None) -> bool:
+        """Delete a webhook; False if it does not exist (or belongs to another tenant)"""
+
+    @abstractmethod
+    def save_report_schedule(self, record: ReportScheduleRecord) -> ReportScheduleRecord:
+        """
+        Insert a report schedule, or replace the schedule with its id, assigning id and created_at when missing
+
+        A schedule with the id of another tenant's schedule is left unchanged;
+        the period last sent is kept.
+        """
+
+    @abstractmethod
+    def get_report_schedule(self, schedule_id: str, tenant_id: Optional[str] = None) -> Optional[ReportScheduleRecord]:
+        """Load a report schedule, or None if it does not exist (or belongs to another tenant)"""
+
+    @abstractmethod
+    def list_report_schedules(self, tenant_id: Optional[str] = None) -> List[ReportScheduleRecord]:
+        """Report schedules ordered by name, of one tenant or of every tenant"""
+
+    @abstractmethod
+    def delete_report_schedule(self, schedule_id: str, tenant_id: Optional[str] = None) -> bool:
+        """Delete a report schedule; False if it does not exist (or belongs to another tenant)"""
+
+    @abstractmethod
+    def mark_report_sent(self, schedule_id: str, period: str) -> bool:
+        """Record the period a schedule's report was last sent for; False if the schedule does not exist"""
+
+    @abstractmethod
+    def create_job(self, record: JobRecord) -> JobRecord:
+        """Insert a job, assigning id, created_at and updated_at"""
+
+    @abstractmethod
+    def get_job(self, job_id: str, tenant_id: Optional[str] = None) -> Optional[JobRecord]:
+        """Load a job, or None if it does not exist (or belongs to another tenant)"""
+
+    @abstractmethod
+    def list_jobs(
+        self,
+        tenant_id: Optional[str] = None,
+        states: Optional[List[str]] = None,
+        limit: int = 100,
+    ) -> List[JobRecord]:
+        """Jobs newest first, optionally of one tenant and in some states"""
+
+    @abstractmethod
+    def claim_job(self, job_id: str, worker: str, stale_before: datetime) -> Optional[JobRecord]:
+        """
+        Start a job on a worker, counting the attempt
+
+        Only a queued job, or a running job whose last write is older than
+        stale_before (its worker is gone), can be claimed; of concurrent
+        claims one wins.
+
+        Returns:
+            The claimed job, or None if it cannot be claimed
+        """
+
+    @abstractmethod
+    def update_job(self, record: JobRecord) -> bool:
+        """
+        Write a running job's state, progress and outcome, refreshing updated_at
+
+        Returns:
+            False, leaving the job unchanged, unless the job is still
+            running on the record's worker
+        """
+
+    @abstractmethod
+    def requeue_job(self, job_id: str, tenant_id: Optional[str] = None) -> Optional[JobRecord]:
+        """
+        Queue a failed or dead-lettered job again with its attempts reset
+
+        Returns:
+            The queued job, or None if it does not exist (or belongs to
+            another tenant) or has not failed
+        """
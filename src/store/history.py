@@ -0,0 +1,71 @@
+"""
+Estimate history
+
+Records every estimate served by the API so it can be retrieved by id,
+listed per project over time and linked from CI runs. Each estimate keeps
+the catalog snapshot and pricing rules version it was priced with, so it
+can be re-run against the same prices.
+"""
+
+import logging
+from datetime import date, datetime, timezone
+from typing import Any, Dict, Optional
+
+from .base import Store, StoreError
+from .models import EstimateRecord, DEFAULT_TENANT
+
+logger = logging.getLogger(__name__)
+
+# Estimate kinds, one per estimate endpoint
+KIND_RESOURCES = "resources"
+KIND_KUBERNETES = "kubernetes"
+KIND_TERRAFORM = "terraform"
+KIND_HELM = "helm"
+KIND_PULUMI = "pulumi"
+KIND_CLOUDFORMATION = "cloudformation"
+KIND_CROSSPLANE = "crossplane"
+KIND_CLUSTER = "cluster"
+
+
+def record_estimate(
+    store: Optional[Store],
+    kind: str,
+    request: Dict[str, Any],
+    result: Dict[str, Any],
+    monthly_cost: float,
+    project: Optional[str] = None,
+    labels: Optional[Dict[str, str]] = None,
+    currency: str = "USD",
+    tenant_id: str = DEFAULT_TENANT,
+    catalog_version: Optional[date] = None,
+    pricing_model_version: Optional[int] = None,
+) -> Optional[EstimateRecord]:
+    """
+    Persist an estimate
+
+    Failures are logged rather than raised so an unavailable database
+    never fails the estimate itself. Without a catalog version the estimate
+    was priced from the current catalogs, i.e. today's snapshot.
+
+    Returns:
+        Saved record with its generated id, or None if not persisted
+    """
+    if store is None:
+        return None
+
+    try:
+        return store.save_estimate(EstimateRecord(
+            tenant_id=tenant_id,
+            kind=kind,
+            project=project,
+            request=request,
+            result=result,
+            monthly_cost=monthly_cost,
+            currency=currency,
+            labels=labels or {},
+            catalog_version=catalog_version or datetime.now(timezone.utc).date(),
+            pricing_model_version=pricing_model_version,
+        ))
+    except StoreError as e:
+        logger.error(f"Failed to record {kind} estimate: {e}")
+        return None
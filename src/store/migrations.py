@@ -0,0 +1,775 @@
+"""
+Schema migrations
+
+Migrations are applied in version order at startup and recorded in
+schema_migrations. Each migration carries the statements for every
+supported dialect; never edit a released migration, add a new one.
+"""
+
+from typing import Dict, List, NamedTuple
+
+DIALECT_SQLITE = "sqlite"
+DIALECT_POSTGRES = "postgres"
+
+
+class Migration(NamedTuple):
+    """One schema change, expressed per dialect"""
+
+    version: int
+    description: str
+    statements: Dict[str, List[str]]
+
+
+MIGRATIONS: List[Migration] = [
+    Migration(
+        version=1,
+        description="price catalogs and estimates",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE catalogs (
+                    provider    TEXT NOT NULL,
+                    key         TEXT NOT NULL,
+                    version     TEXT NOT NULL DEFAULT '',
+                    document    TEXT NOT NULL,
+                    fetched_at  TEXT NOT NULL,
+                    PRIMARY KEY (provider, key)
+                )
+                """,
+                """
+                CREATE TABLE estimates (
+                    id            TEXT PRIMARY KEY,
+                    kind          TEXT NOT NULL,
+                    project       TEXT,
+                    request       TEXT NOT NULL,
+                    result        TEXT NOT NULL,
+                    monthly_cost  REAL NOT NULL,
+                    currency      TEXT NOT NULL,
+                    labels        TEXT NOT NULL,
+                    created_at    TEXT NOT NULL
+                )
+                """,
+                "CREATE INDEX estimates_project_created_at ON estimates (project, created_at)",
+                "CREATE INDEX estimates_created_at ON estimates (created_at)",
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE catalogs (
+                    provider    TEXT NOT NULL,
+                    key         TEXT NOT NULL,
+                    version     TEXT NOT NULL DEFAULT '',
+                    document    JSONB NOT NULL,
+                    fetched_at  TIMESTAMPTZ NOT NULL,
+                    PRIMARY KEY (provider, key)
+                )
+                """,
+                """
+                CREATE TABLE estimates (
+                    id            TEXT PRIMARY KEY,
+                    kind          TEXT NOT NULL,
+                    project       TEXT,
+                    request       JSONB NOT NULL,
+                    result        JSONB NOT NULL,
+                    monthly_cost  DOUBLE PRECISION NOT NULL,
+                    currency      TEXT NOT NULL,
+                    labels        JSONB NOT NULL,
+                    created_at    TIMESTAMPTZ NOT NULL
+                )
+                """,
+                "CREATE INDEX estimates_project_created_at ON estimates (project, created_at)",
+                "CREATE INDEX estimates_created_at ON estimates (created_at)",
+            ],
+        },
+    ),
+    Migration(
+        version=2,
+        description="api keys",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE api_keys (
+                    id          TEXT PRIMARY KEY,
+                    name        TEXT NOT NULL,
+                    key_hash    TEXT NOT NULL UNIQUE,
+                    prefix      TEXT NOT NULL,
+                    scopes      TEXT NOT NULL,
+                    rate_limit  INTEGER,
+                    created_at  TEXT NOT NULL,
+                    revoked_at  TEXT
+                )
+                """,
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE api_keys (
+                    id          TEXT PRIMARY KEY,
+                    name        TEXT NOT NULL,
+                    key_hash    TEXT NOT NULL UNIQUE,
+                    prefix      TEXT NOT NULL,
+                    scopes      JSONB NOT NULL,
+                    rate_limit  INTEGER,
+                    created_at  TIMESTAMPTZ NOT NULL,
+                    revoked_at  TIMESTAMPTZ
+                )
+                """,
+            ],
+        },
+    ),
+    Migration(
+        version=3,
+        description="tenants and tenant price overrides",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE tenants (
+                    id          TEXT PRIMARY KEY,
+                    name        TEXT NOT NULL,
+                    created_at  TEXT NOT NULL
+                )
+                """,
+                """
+                CREATE TABLE price_overrides (
+                    tenant_id      TEXT NOT NULL,
+                    provider       TEXT NOT NULL,
+                    service        TEXT NOT NULL,
+                    region         TEXT NOT NULL,
+                    sku            TEXT NOT NULL,
+                    pricing_model  TEXT NOT NULL,
+                    unit           TEXT NOT NULL,
+                    price          REAL NOT NULL,
+                    description    TEXT NOT NULL,
+                    updated_at     TEXT NOT NULL,
+                    PRIMARY KEY (tenant_id, provider, service, region, sku, pricing_model)
+                )
+                """,
+                "ALTER TABLE estimates ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default'",
+                "CREATE INDEX estimates_tenant_created_at ON estimates (tenant_id, created_at)",
+                "ALTER TABLE api_keys ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default'",
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE tenants (
+                    id          TEXT PRIMARY KEY,
+                    name        TEXT NOT NULL,
+                    created_at  TIMESTAMPTZ NOT NULL
+                )
+                """,
+                """
+                CREATE TABLE price_overrides (
+                    tenant_id      TEXT NOT NULL,
+                    provider       TEXT NOT NULL,
+                    service        TEXT NOT NULL,
+                    region         TEXT NOT NULL,
+                    sku            TEXT NOT NULL,
+                    pricing_model  TEXT NOT NULL,
+                    unit           TEXT NOT NULL,
+                    price          DOUBLE PRECISION NOT NULL,
+                    description    TEXT NOT NULL,
+                    updated_at     TIMESTAMPTZ NOT NULL,
+                    PRIMARY KEY (tenant_id, provider, service, region, sku, pricing_model)
+                )
+                """,
+                "ALTER TABLE estimates ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default'",
+                "CREATE INDEX estimates_tenant_created_at ON estimates (tenant_id, created_at)",
+                "ALTER TABLE api_keys ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default'",
+            ],
+        },
+    ),
+    Migration(
+        version=4,
+        description="discount rules",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE discount_rules (
+                    id          TEXT PRIMARY KEY,
+                    tenant_id   TEXT NOT NULL,
+                    name        TEXT NOT NULL,
+                    description TEXT NOT NULL,
+                    priority    INTEGER NOT NULL,
+                    enabled     INTEGER NOT NULL,
+                    definition  TEXT NOT NULL,
+                    created_at  TEXT NOT NULL,
+                    updated_at  TEXT NOT NULL
+                )
+                """,
+                "CREATE INDEX discount_rules_tenant_priority ON discount_rules (tenant_id, priority)",
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE discount_rules (
+                    id          TEXT PRIMARY KEY,
+                    tenant_id   TEXT NOT NULL,
+                    name        TEXT NOT NULL,
+                    description TEXT NOT NULL,
+                    priority    INTEGER NOT NULL,
+                    enabled     BOOLEAN NOT NULL,
+                    definition  JSONB NOT NULL,
+                    created_at  TIMESTAMPTZ NOT NULL,
+                    updated_at  TIMESTAMPTZ NOT NULL
+                )
+                """,
+                "CREATE INDEX discount_rules_tenant_priority ON discount_rules (tenant_id, priority)",
+            ],
+        },
+    ),
+    Migration(
+        version=5,
+        description="budgets and actual costs",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE budgets (
+                    id          TEXT PRIMARY KEY,
+                    tenant_id   TEXT NOT NULL,
+                    name        TEXT NOT NULL,
+                    amount      REAL NOT NULL,
+                    project     TEXT,
+                    labels      TEXT NOT NULL,
+                    thresholds  TEXT NOT NULL,
+                    created_at  TEXT NOT NULL,
+                    updated_at  TEXT NOT NULL
+                )
+                """,
+                "CREATE INDEX budgets_tenant ON budgets (tenant_id)",
+                """
+                CREATE TABLE actual_costs (
+                    tenant_id    TEXT NOT NULL,
+                    source       TEXT NOT NULL,
+                    usage_date   TEXT NOT NULL,
+                    provider     TEXT NOT NULL,
+                    service      TEXT NOT NULL,
+                    project      TEXT,
+                    labels       TEXT NOT NULL,
+                    amount       REAL NOT NULL,
+                    currency     TEXT NOT NULL,
+                    recorded_at  TEXT NOT NULL
+                )
+                """,
+                "CREATE INDEX actual_costs_tenant_date ON actual_costs (tenant_id, usage_date)",
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE budgets (
+                    id          TEXT PRIMARY KEY,
+                    tenant_id   TEXT NOT NULL,
+                    name        TEXT NOT NULL,
+                    amount      DOUBLE PRECISION NOT NULL,
+                    project     TEXT,
+                    labels      JSONB NOT NULL,
+                    thresholds  JSONB NOT NULL,
+                    created_at  TIMESTAMPTZ NOT NULL,
+                    updated_at  TIMESTAMPTZ NOT NULL
+                )
+                """,
+                "CREATE INDEX budgets_tenant ON budgets (tenant_id)",
+                """
+                CREATE TABLE actual_costs (
+                    tenant_id    TEXT NOT NULL,
+                    source       TEXT NOT NULL,
+                    usage_date   DATE NOT NULL,
+                    provider     TEXT NOT NULL,
+                    service      TEXT NOT NULL,
+                    project      TEXT,
+                    labels       JSONB NOT NULL,
+                    amount       DOUBLE PRECISION NOT NULL,
+                    currency     TEXT NOT NULL,
+                    recorded_at  TIMESTAMPTZ NOT NULL
+                )
+                """,
+                "CREATE INDEX actual_costs_tenant_date ON actual_costs (tenant_id, usage_date)",
+            ],
+        },
+    ),
+    Migration(
+        version=6,
+        description="webhooks",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE webhooks (
+                    id          TEXT PRIMARY KEY,
+                    tenant_id   TEXT NOT NULL,
+                    name        TEXT NOT NULL,
+                    url         TEXT NOT NULL,
+                    events      TEXT NOT NULL,
+                    format      TEXT NOT NULL,
+                    secret      TEXT NOT NULL,
+                    enabled     INTEGER NOT NULL,
+                    created_at  TEXT NOT NULL,
+                    updated_at  TEXT NOT NULL
+                )
+                """,
+                "CREATE INDEX webhooks_tenant ON webhooks (tenant_id)",
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE webhooks (
+                    id          TEXT PRIMARY KEY,
+                    tenant_id   TEXT NOT NULL,
+                    name        TEXT NOT NULL,
+                    url         TEXT NOT NULL,
+                    events      JSONB NOT NULL,
+                    format      TEXT NOT NULL,
+                    secret      TEXT NOT NULL,
+                    enabled     BOOLEAN NOT NULL,
+                    created_at  TIMESTAMPTZ NOT NULL,
+                    updated_at  TIMESTAMPTZ NOT NULL
+                )
+                """,
+                "CREATE INDEX webhooks_tenant ON webhooks (tenant_id)",
+            ],
+        },
+    ),
+    Migration(
+        version=7,
+        description="billing export details of actual costs",
+        statements={
+            DIALECT_SQLITE: [
+                "ALTER TABLE actual_costs ADD COLUMN region TEXT NOT NULL DEFAULT ''",
+                "ALTER TABLE actual_costs ADD COLUMN account_id TEXT NOT NULL DEFAULT ''",
+                "ALTER TABLE actual_costs ADD COLUMN sku TEXT NOT NULL DEFAULT ''",
+                "ALTER TABLE actual_costs ADD COLUMN usage_quantity REAL NOT NULL DEFAULT 0",
+                "ALTER TABLE actual_costs ADD COLUMN usage_unit TEXT NOT NULL DEFAULT ''",
+                "CREATE INDEX actual_costs_tenant_source_date ON actual_costs (tenant_id, source, usage_date)",
+            ],
+            DIALECT_POSTGRES: [
+                "ALTER TABLE actual_costs ADD COLUMN region TEXT NOT NULL DEFAULT ''",
+                "ALTER TABLE actual_costs ADD COLUMN account_id TEXT NOT NULL DEFAULT ''",
+                "ALTER TABLE actual_costs ADD COLUMN sku TEXT NOT NULL DEFAULT ''",
+                "ALTER TABLE actual_costs ADD COLUMN usage_quantity DOUBLE PRECISION NOT NULL DEFAULT 0",
+                "ALTER TABLE actual_costs ADD COLUMN usage_unit TEXT NOT NULL DEFAULT ''",
+                "CREATE INDEX actual_costs_tenant_source_date ON actual_costs (tenant_id, source, usage_date)",
+            ],
+        },
+    ),
+    Migration(
+        version=8,
+        description="resource inventory",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE inventory (
+                    tenant_id    TEXT NOT NULL,
+                    source       TEXT NOT NULL,
+                    resource_id  TEXT NOT NULL,
+                    kind         TEXT NOT NULL,
+                    provider     TEXT NOT NULL,
+                    region       TEXT NOT NULL,
+                    sku          TEXT NOT NULL,
+                    name         TEXT NOT NULL,
+                    state        TEXT NOT NULL,
+                    attached_to  TEXT NOT NULL,
+                    size_gb      REAL NOT NULL,
+                    attributes   TEXT NOT NULL,
+                    labels       TEXT NOT NULL,
+                    observed_at  TEXT NOT NULL,
+                    PRIMARY KEY (tenant_id, source, resource_id)
+                )
+                """,
+                "CREATE INDEX inventory_tenant_kind ON inventory (tenant_id, kind)",
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE inventory (
+                    tenant_id    TEXT NOT NULL,
+                    source       TEXT NOT NULL,
+                    resource_id  TEXT NOT NULL,
+                    kind         TEXT NOT NULL,
+                    provider     TEXT NOT NULL,
+                    region       TEXT NOT NULL,
+                    sku          TEXT NOT NULL,
+                    name         TEXT NOT NULL,
+                    state        TEXT NOT NULL,
+                    attached_to  TEXT NOT NULL,
+                    size_gb      DOUBLE PRECISION NOT NULL,
+                    attributes   JSONB NOT NULL,
+                    labels       JSONB NOT NULL,
+                    observed_at  TIMESTAMPTZ NOT NULL,
+                    PRIMARY KEY (tenant_id, source, resource_id)
+                )
+                """,
+                "CREATE INDEX inventory_tenant_kind ON inventory (tenant_id, kind)",
+            ],
+        },
+    ),
+    Migration(
+        version=9,
+        description="estimation jobs",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE jobs (
+                    id               TEXT PRIMARY KEY,
+                    tenant_id        TEXT NOT NULL,
+                    kind             TEXT NOT NULL,
+                    state            TEXT NOT NULL,
+                    request          TEXT NOT NULL,
+                    result           TEXT,
+                    error            TEXT,
+                    progress         REAL NOT NULL,
+                    progress_detail  TEXT,
+                    estimate_id      TEXT,
+                    attempts         INTEGER NOT NULL,
+                    worker           TEXT,
+                    created_at       TEXT NOT NULL,
+                    updated_at       TEXT NOT NULL,
+                    started_at       TEXT,
+                    finished_at      TEXT
+                )
+                """,
+                "CREATE INDEX jobs_tenant_created ON jobs (tenant_id, created_at)",
+                "CREATE INDEX jobs_state ON jobs (state)",
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE jobs (
+                    id               TEXT PRIMARY KEY,
+                    tenant_id        TEXT NOT NULL,
+                    kind             TEXT NOT NULL,
+                    state            TEXT NOT NULL,
+                    request          JSONB NOT NULL,
+                    result           JSONB,
+                    error            TEXT,
+                    progress         DOUBLE PRECISION NOT NULL,
+                    progress_detail  TEXT,
+                    estimate_id      TEXT,
+                    attempts         INTEGER NOT NULL,
+                    worker           TEXT,
+                    created_at       TIMESTAMPTZ NOT NULL,
+                    updated_at       TIMESTAMPTZ NOT NULL,
+                    started_at       TIMESTAMPTZ,
+                    finished_at      TIMESTAMPTZ
+                )
+                """,
+                "CREATE INDEX jobs_tenant_created ON jobs (tenant_id, created_at)",
+                "CREATE INDEX jobs_state ON jobs (state)",
+            ],
+        },
+    ),
+    Migration(
+        version=10,
+        description="scenarios",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE scenarios (
+                    id           TEXT PRIMARY KEY,
+                    tenant_id    TEXT NOT NULL,
+                    name         TEXT NOT NULL,
+                    description  TEXT,
+                    baseline     TEXT NOT NULL,
+                    variations   TEXT NOT NULL,
+                    created_at   TEXT NOT NULL,
+                    updated_at   TEXT NOT NULL
+                )
+                """,
+                "CREATE INDEX scenarios_tenant ON scenarios (tenant_id)",
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE scenarios (
+                    id           TEXT PRIMARY KEY,
+                    tenant_id    TEXT NOT NULL,
+                    name         TEXT NOT NULL,
+                    description  TEXT,
+                    baseline     JSONB NOT NULL,
+                    variations   JSONB NOT NULL,
+                    created_at   TIMESTAMPTZ NOT NULL,
+                    updated_at   TIMESTAMPTZ NOT NULL
+                )
+                """,
+                "CREATE INDEX scenarios_tenant ON scenarios (tenant_id)",
+            ],
+        },
+    ),
+    Migration(
+        version=11,
+        description="catalog snapshots and estimate versions",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE catalog_snapshots (
+                    snapshot    TEXT NOT NULL,
+                    provider    TEXT NOT NULL,
+                    key         TEXT NOT NULL,
+                    version     TEXT NOT NULL DEFAULT '',
+                    document    TEXT NOT NULL,
+                    fetched_at  TEXT NOT NULL,
+                    PRIMARY KEY (provider, key, snapshot)
+                )
+                """,
+                "CREATE INDEX catalog_snapshots_snapshot ON catalog_snapshots (snapshot)",
+                "ALTER TABLE estimates ADD COLUMN catalog_version TEXT",
+                "ALTER TABLE estimates ADD COLUMN pricing_model_version INTEGER",
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE catalog_snapshots (
+                    snapshot    DATE NOT NULL,
+                    provider    TEXT NOT NULL,
+                    key         TEXT NOT NULL,
+                    version     TEXT NOT NULL DEFAULT '',
+                    document    JSONB NOT NULL,
+                    fetched_at  TIMESTAMPTZ NOT NULL,
+                    PRIMARY KEY (provider, key, snapshot)
+                )
+                """,
+                "CREATE INDEX catalog_snapshots_snapshot ON catalog_snapshots (snapshot)",
+                "ALTER TABLE estimates ADD COLUMN catalog_version DATE",
+                "ALTER TABLE estimates ADD COLUMN pricing_model_version INTEGER",
+            ],
+        },
+    ),
+    Migration(
+        version=12,
+        description="role assignments",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE role_assignments (
+                    tenant_id   TEXT NOT NULL,
+                    subject     TEXT NOT NULL,
+                    role        TEXT NOT NULL,
+                    created_at  TEXT NOT NULL,
+                    updated_at  TEXT NOT NULL,
+                    PRIMARY KEY (tenant_id, subject)
+                )
+                """,
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE role_assignments (
+                    tenant_id   TEXT NOT NULL,
+                    subject     TEXT NOT NULL,
+                    role        TEXT NOT NULL,
+                    created_at  TIMESTAMPTZ NOT NULL,
+                    updated_at  TIMESTAMPTZ NOT NULL,
+                    PRIMARY KEY (tenant_id, subject)
+                )
+                """,
+            ],
+        },
+    ),
+    Migration(
+        version=13,
+        description="audit log",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE audit_events (
+                    id             TEXT PRIMARY KEY,
+                    tenant_id      TEXT NOT NULL,
+                    actor          TEXT,
+                    actor_method   TEXT,
+                    role           TEXT,
+                    client         TEXT,
+                    action         TEXT NOT NULL,
+                    resource_type  TEXT NOT NULL,
+                    resource_id    TEXT,
+                    method         TEXT NOT NULL,
+                    path           TEXT NOT NULL,
+                    status         INTEGER NOT NULL,
+                    request_id     TEXT,
+                    created_at     TEXT NOT NULL
+                )
+                """,
+                "CREATE INDEX audit_events_tenant_created ON audit_events (tenant_id, created_at)",
+                # Append-only: the log is evidence of changes, not a resource of its own
+                """
+                CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+                BEGIN SELECT RAISE(ABORT, 'audit events are append-only'); END
+                """,
+                """
+                CREATE TRIGGER audit_events_no_delete BEFORE DELETE ON audit_events
+                BEGIN SELECT RAISE(ABORT, 'audit events are append-only'); END
+                """,
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE audit_events (
+                    id             TEXT PRIMARY KEY,
+                    tenant_id      TEXT NOT NULL,
+                    actor          TEXT,
+                    actor_method   TEXT,
+                    role           TEXT,
+                    client         TEXT,
+                    action         TEXT NOT NULL,
+                    resource_type  TEXT NOT NULL,
+                    resource_id    TEXT,
+                    method         TEXT NOT NULL,
+                    path           TEXT NOT NULL,
+                    status         INTEGER NOT NULL,
+                    request_id     TEXT,
+                    created_at     TIMESTAMPTZ NOT NULL
+                )
+                """,
+                "CREATE INDEX audit_events_tenant_created ON audit_events (tenant_id, created_at)",
+                """
+                CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+                BEGIN
+                    RAISE EXCEPTION 'audit events are append-only';
+                END
+                $$ LANGUAGE plpgsql
+                """,
+                """
+                CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+                FOR EACH ROW EXECUTE FUNCTION audit_events_append_only()
+                """,
+            ],
+        },
+    ),
+    Migration(
+        version=14,
+        description="job retries",
+        statements={
+            DIALECT_SQLITE: [
+                "ALTER TABLE jobs ADD COLUMN run_after TEXT",
+            ],
+            DIALECT_POSTGRES: [
+                "ALTER TABLE jobs ADD COLUMN run_after TIMESTAMPTZ",
+            ],
+        },
+    ),
+    Migration(
+        version=15,
+        description="price sheet formulas",
+        statements={
+            DIALECT_SQLITE: [
+                "ALTER TABLE price_overrides ADD COLUMN formula TEXT",
+            ],
+            DIALECT_POSTGRES: [
+                "ALTER TABLE price_overrides ADD COLUMN formula JSONB",
+            ],
+        },
+    ),
+    Migration(
+        version=16,
+        description="report schedules",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE report_schedules (
+                    id           TEXT PRIMARY KEY,
+                    tenant_id    TEXT NOT NULL,
+                    name         TEXT NOT NULL,
+                    frequency    TEXT NOT NULL,
+                    day          INTEGER NOT NULL,
+                    recipients   TEXT NOT NULL,
+                    sections     TEXT NOT NULL,
+                    enabled      INTEGER NOT NULL,
+                    last_period  TEXT,
+                    created_at   TEXT NOT NULL,
+                    updated_at   TEXT NOT NULL
+                )
+                """,
+                "CREATE INDEX report_schedules_tenant ON report_schedules (tenant_id)",
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE report_schedules (
+                    id           TEXT PRIMARY KEY,
+                    tenant_id    TEXT NOT NULL,
+                    name         TEXT NOT NULL,
+                    frequency    TEXT NOT NULL,
+                    day          INTEGER NOT NULL,
+                    recipients   JSONB NOT NULL,
+                    sections     JSONB NOT NULL,
+                    enabled      BOOLEAN NOT NULL,
+                    last_period  TEXT,
+                    created_at   TIMESTAMPTZ NOT NULL,
+                    updated_at   TIMESTAMPTZ NOT NULL
+                )
+                """,
+                "CREATE INDEX report_schedules_tenant ON report_schedules (tenant_id)",
+            ],
+        },
+    ),
+    Migration(
+        version=17,
+        description="estimate annotations",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE estimate_annotations (
+                    id           TEXT PRIMARY KEY,
+                    tenant_id    TEXT NOT NULL,
+                    estimate_id  TEXT NOT NULL,
+                    author       TEXT NOT NULL,
+                    decision     TEXT,
+                    comment      TEXT NOT NULL,
+                    created_at   TEXT NOT NULL
+                )
+                """,
+                "CREATE INDEX estimate_annotations_estimate "
+                "ON estimate_annotations (tenant_id, estimate_id, created_at)",
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE estimate_annotations (
+                    id           TEXT PRIMARY KEY,
+                    tenant_id    TEXT NOT NULL,
+                    estimate_id  TEXT NOT NULL,
+                    author       TEXT NOT NULL,
+                    decision     TEXT,
+                    comment      TEXT NOT NULL,
+                    created_at   TIMESTAMPTZ NOT NULL
+                )
+                """,
+                "CREATE INDEX estimate_annotations_estimate "
+                "ON estimate_annotations (tenant_id, estimate_id, created_at)",
+            ],
+        },
+    ),
+    Migration(
+        version=18,
+        description="usage profiles",
+        statements={
+            DIALECT_SQLITE: [
+                """
+                CREATE TABLE usage_profiles (
+                    tenant_id    TEXT NOT NULL,
+                    name         TEXT NOT NULL,
+                    description  TEXT NOT NULL,
+                    definition   TEXT NOT NULL,
+                    created_at   TEXT NOT NULL,
+                    updated_at   TEXT NOT NULL,
+                    PRIMARY KEY (tenant_id, name)
+                )
+                """,
+            ],
+            DIALECT_POSTGRES: [
+                """
+                CREATE TABLE usage_profiles (
+                    tenant_id    TEXT NOT NULL,
+                    name         TEXT NOT NULL,
+                    description  TEXT NOT NULL,
+                    definition   JSONB NOT NULL,
+                    created_at   TIMESTAMPTZ NOT NULL,
+                    updated_at   TIMESTAMPTZ NOT NULL,
+                    PRIMARY KEY (tenant_id, name)
+                )
+                """,
+            ],
+        },
+    ),
+    Migration(
+        version=19,
+        description="actual cost resolutions",
+        statements={
+            DIALECT_SQLITE: [
+                "ALTER TABLE actual_costs ADD COLUMN resolution TEXT NOT NULL DEFAULT 'raw'",
+                "CREATE INDEX actual_costs_resolution_date ON actual_costs (resolution, usage_date)",
+            ],
+            DIALECT_POSTGRES: [
+                "ALTER TABLE actual_costs ADD COLUMN resolution TEXT NOT NULL DEFAULT 'raw'",
+                "CREATE INDEX actual_costs_resolution_date ON actual_costs (resolution, usage_date)",
+            ],
+        },
+    ),
+]
+
+
+def latest_version() -> int:
+    """Schema version after all migrations are applied"""
+    return max(m.version for m in MIGRATIONS)
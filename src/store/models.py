@@ -0,0 +1,292 @@
+"""
+Data models for persisted records
+"""
+
+from typing import Any, Dict, List, Optional
+from datetime import date, datetime
+from pydantic import BaseModel, Field
+
+# Tenant of records created before tenants existed, and of callers that name none
+DEFAULT_TENANT = "default"
+
+# Resolutions of stored actual costs: as recorded, and rolled up once they age
+RESOLUTION_RAW = "raw"
+RESOLUTION_DAILY = "daily"
+RESOLUTION_MONTHLY = "monthly"
+RESOLUTIONS = (RESOLUTION_RAW, RESOLUTION_DAILY, RESOLUTION_MONTHLY)
+
+
+class CatalogRecord(BaseModel):
+    """A downloaded price catalog as stored by a provider"""
+
+    provider: str = Field(..., description="Pricing provider name, e.g. aws")
+    key: str = Field(..., description="Provider specific catalog key, e.g. AmazonEC2-us-east-1")
+    version: str = ""
+    document: Dict[str, Any] = Field(default_factory=dict)
+    fetched_at: datetime
+
+
+class EstimateRecord(BaseModel):
+    """A persisted estimate request and its result"""
+
+    id: Optional[str] = Field(None, description="Generated on save if not set")
+    tenant_id: str = Field(DEFAULT_TENANT, description="Tenant the estimate was made for")
+    kind: str = Field(..., description="Estimate type: resources, kubernetes, terraform, pulumi, helm, cluster")
+    project: Optional[str] = Field(None, description="Project or repository the estimate belongs to")
+    request: Dict[str, Any] = Field(default_factory=dict)
+    result: Dict[str, Any] = Field(default_factory=dict)
+    monthly_cost: float = 0.0
+    currency: str = "USD"
+    labels: Dict[str, str] = Field(default_factory=dict, description="Free-form metadata, e.g. CI run URL")
+    created_at: Optional[datetime] = Field(None, description="Set on save if not set")
+    catalog_version: Optional[date] = Field(
+        None, description="Catalog snapshot the estimate was priced from, None if not recorded"
+    )
+    pricing_model_version: Optional[int] = Field(
+        None, description="Version of the estimator's pricing rules, None if not recorded"
+    )
+
+
+class EstimateAnnotationRecord(BaseModel):
+    """A comment on or review decision about a recorded estimate; appended once and never changed"""
+
+    id: Optional[str] = Field(None, description="Assigned on append")
+    tenant_id: str = DEFAULT_TENANT
+    estimate_id: str
+    author: str = Field(..., description="Subject of the caller who commented or decided")
+    decision: Optional[str] = Field(None, description="approved or rejected, None for comments")
+    comment: str = ""
+    created_at: Optional[datetime] = Field(None, description="Set on append")
+
+
+class ApiKeyRecord(BaseModel):
+    """An API key issued through the admin API; only its hash is stored"""
+
+    id: Optional[str] = Field(None, description="Generated on save if not set")
+    tenant_id: str = Field(DEFAULT_TENANT, description="Tenant the key acts for")
+    name: str = Field(..., description="Who or what the key was issued to, e.g. ci-pipeline")
+    key_hash: str = Field(..., description="SHA-256 of the key")
+    prefix: str = Field("", description="First characters of the key, to recognize it in listings")
+    scopes: List[str] = Field(default_factory=list)
+    rate_limit: Optional[int] = Field(None, description="Requests per minute, None for the default limit")
+    created_at: Optional[datetime] = Field(None, description="Set on save if not set")
+    revoked_at: Optional[datetime] = None
+
+    @property
+    def revoked(self) -> bool:
+        return self.revoked_at is not None
+
+
+class TenantRecord(BaseModel):
+    """A tenant (organization) that estimates, keys and price overrides belong to"""
+
+    id: str = Field(..., description="Tenant ID, e.g. acme")
+    name: str = Field("", description="Display name")
+    created_at: Optional[datetime] = Field(None, description="Set on save if not set")
+
+
+class PriceOverrideRecord(BaseModel):
+    """A tenant's negotiated price, used instead of the public list price"""
+
+    tenant_id: str
+    provider: str = Field(..., description="Cloud provider, e.g. aws")
+    service: str = Field("compute", description="Service family, as in price queries")
+    region: str = Field(..., description="Provider region, or * for every region")
+    sku: str = Field(..., description="Instance type, volume type or provider SKU")
+    pricing_model: str = "on_demand"
+    unit: str = "hour"
+    price: float = Field(..., ge=0, description="Price per unit (USD)")
+    description: str = ""
+    tiers: List[Dict[str, Any]] = Field(default_factory=list, description="Volume tiers: begin, end and price")
+    tier_mode: str = "graduated"
+    free_quantity: float = 0.0
+    minimum_quantity: float = 0.0
+    minimum_charge: float = 0.0
+    updated_at: Optional[datetime] = Field(None, description="Set on save")
+
+
+class DiscountRuleRecord(BaseModel):
+    """A tenant's discount rule; the match and discount definition are kept as a document"""
+
+    id: Optional[str] = Field(None, description="Assigned on first save")
+    tenant_id: str = DEFAULT_TENANT
+    name: str
+    description: str = ""
+    priority: int = 100
+    enabled: bool = True
+    definition: Dict[str, Any] = Field(default_factory=dict)
+    created_at: Optional[datetime] = Field(None, description="Set on first save")
+    updated_at: Optional[datetime] = Field(None, description="Set on save")
+
+
+class BudgetRecord(BaseModel):
+    """A monthly spend limit of a tenant, a project or a set of labels"""
+
+    id: Optional[str] = Field(None, description="Assigned on first save")
+    tenant_id: str = DEFAULT_TENANT
+    name: str
+    amount: float = Field(..., gt=0, description="Monthly amount (USD)")
+    project: Optional[str] = Field(None, description="Only spend of this project, None for the whole tenant")
+    labels: Dict[str, str] = Field(default_factory=dict, description="Only spend carrying all these labels")
+    thresholds: List[float] = Field(default_factory=list, description="Fractions of the amount that raise warnings")
+    created_at: Optional[datetime] = Field(None, description="Set on first save")
+    updated_at: Optional[datetime] = Field(None, description="Set on save")
+
+
+class ActualCostRecord(BaseModel):
+    """Spend incurred on one day, as reported by a billing export or recorded through the API"""
+
+    tenant_id: str = DEFAULT_TENANT
+    source: str = Field("manual", description="Where the cost came from, e.g. manual or aws-cur")
+    usage_date: date
+    provider: str = ""
+    service: str = ""
+    project: Optional[str] = None
+    labels: Dict[str, str] = Field(default_factory=dict)
+    amount: float = Field(..., description="Cost (USD); credits are negative")
+    currency: str = "USD"
+    region: str = ""
+    account_id: str = Field("", description="Billing account, subscription or project the cost was incurred in")
+    sku: str = Field("", description="Provider usage type or SKU")
+    usage_quantity: float = 0.0
+    usage_unit: str = ""
+    resolution: str = Field(
+        RESOLUTION_RAW, description="raw as recorded, or a daily or monthly (dated the 1st) roll-up of aged costs"
+    )
+    recorded_at: Optional[datetime] = Field(None, description="Set on save")
+
+
+class InventoryRecord(BaseModel):
+    """A cloud resource as last reported by an inventory collector"""
+
+    tenant_id: str = DEFAULT_TENANT
+    source: str = Field("manual", description="Collector that reported the resource, e.g. aws-config")
+    resource_id: str = Field(..., description="Provider ID, e.g. vol-0abc or an ARN")
+    kind: str = Field(..., description="instance, volume, load_balancer or node_pool")
+    provider: str = ""
+    region: str = ""
+    sku: str = Field("", description="Instance, volume or load balancer type")
+    name: str = ""
+    state: str = Field("", description="Provider state, e.g. running, stopped, available, in-use")
+    attached_to: str = Field("", description="Resource this one is attached to, e.g. a volume's instance")
+    size_gb: float = 0.0
+    attributes: Dict[str, Any] = Field(default_factory=dict, description="Kind-specific counts, e.g. targets or pods")
+    labels: Dict[str, str] = Field(default_factory=dict)
+    observed_at: Optional[datetime] = Field(None, description="Set on save if not set")
+
+
+class WebhookRecord(BaseModel):
+    """An HTTP endpoint notified of a tenant's budget, anomaly and catalog events"""
+
+    id: Optional[str] = Field(None, description="Assigned on first save")
+    tenant_id: str = DEFAULT_TENANT
+    name: str
+    url: str
+    events: List[str] = Field(default_factory=list, description="Event types delivered, empty for all")
+    format: str = Field("json", description="Payload format: json, slack, slack_blocks or teams")
+    secret: str = Field("", description="Key signing the payloads (HMAC-SHA256)")
+    enabled: bool = True
+    created_at: Optional[datetime] = Field(None, description="Set on first save")
+    updated_at: Optional[datetime] = Field(None, description="Set on save")
+
+
+class ReportScheduleRecord(BaseModel):
+    """A cost report mailed to a tenant's recipients every week or month"""
+
+    id: Optional[str] = Field(None, description="Assigned on first save")
+    tenant_id: str = DEFAULT_TENANT
+    name: str
+    frequency: str = Field(..., description="weekly or monthly")
+    day: int = Field(..., description="Weekday (1 Monday to 7 Sunday) or day of the month the report is sent")
+    recipients: List[str]
+    sections: List[str] = Field(default_factory=list, description="Report sections, empty for all")
+    enabled: bool = True
+    last_period: Optional[str] = Field(None, description="Period last sent, e.g. 2026-W41 or 2026-09")
+    created_at: Optional[datetime] = Field(None, description="Set on first save")
+    updated_at: Optional[datetime] = Field(None, description="Set on save")
+
+
+class ScenarioRecord(BaseModel):
+    """A baseline estimate request and named variations of it"""
+
+    id: Optional[str] = Field(None, description="Assigned on first save")
+    tenant_id: str = DEFAULT_TENANT
+    name: str
+    description: Optional[str] = None
+    baseline: Dict[str, Any] = Field(..., description="Estimate request the variations are applied to")
+    variations: List[Dict[str, Any]] = Field(default_factory=list)
+    created_at: Optional[datetime] = Field(None, description="Set on first save")
+    updated_at: Optional[datetime] = Field(None, description="Set on save")
+
+
+class RoleAssignmentRecord(BaseModel):
+    """A caller's role in a tenant, taking the place of its credential's scopes there"""
+
+    tenant_id: str
+    subject: str = Field(..., description="Issued API key ID, static key name or OIDC token subject")
+    role: str = Field(..., description="viewer, editor or admin")
+    created_at: Optional[datetime] = Field(None, description="Set on first save")
+    updated_at: Optional[datetime] = Field(None, description="Set on save")
+
+
+class UsageProfileRecord(BaseModel):
+    """A tenant's named usage profile, scaling the running hours of the resources referencing it"""
+
+    tenant_id: str
+    name: str
+    description: str = ""
+    definition: Dict[str, Any] = Field(default_factory=dict, description="Schedule or duty cycle")
+    created_at: Optional[datetime] = Field(None, description="Set on first save")
+    updated_at: Optional[datetime] = Field(None, description="Set on save")
+
+
+class AuditEventRecord(BaseModel):
+    """A change made through the API; appended once and never updated or deleted"""
+
+    id: Optional[str] = Field(None, description="Assigned on append")
+    tenant_id: str = DEFAULT_TENANT
+    actor: Optional[str] = Field(None, description="Subject of the caller, None without authentication")
+    actor_method: Optional[str] = Field(None, description="api_key, static_key or oidc")
+    role: Optional[str] = Field(None, description="Caller's role in the tenant, if assigned one")
+    client: Optional[str] = Field(None, description="Client address")
+    action: str = Field(..., description="create, update, delete or the operation done, e.g. refresh")
+    resource_type: str = Field(..., description="Kind of resource changed, e.g. budget")
+    resource_id: Optional[str] = None
+    method: str
+    path: str
+    status: int = Field(..., description="Response status code")
+    request_id: Optional[str] = None
+    created_at: Optional[datetime] = Field(None, description="Set on append")
+
+
+# Job states; queued and running jobs are picked up again after a restart.
+# A job of a retried kind that failed every attempt is dead-lettered: kept
+# for inspection until it is requeued by hand.
+JOB_QUEUED = "queued"
+JOB_RUNNING = "running"
+JOB_SUCCEEDED = "succeeded"
+JOB_FAILED = "failed"
+JOB_DEAD_LETTER = "dead_letter"
+JOB_STATES = (JOB_QUEUED, JOB_RUNNING, JOB_SUCCEEDED, JOB_FAILED, JOB_DEAD_LETTER)
+
+
+class JobRecord(BaseModel):
+    """An estimate computed in the background, with its progress and outcome"""
+
+    id: Optional[str] = Field(None, description="Assigned on creation")
+    tenant_id: str = DEFAULT_TENANT
+    kind: str = Field(..., description="Estimate kind, e.g. terraform or cluster")
+    state: str = JOB_QUEUED
+    request: Dict[str, Any] = Field(default_factory=dict)
+    result: Optional[Dict[str, Any]] = None
+    error: Optional[str] = None
+    progress: float = Field(0.0, description="Fraction of the work done, 0 to 1")
+    progress_detail: Optional[str] = None
+    estimate_id: Optional[str] = Field(None, description="Recorded estimate of a succeeded job")
+    attempts: int = Field(0, description="Times the job was started")
+    worker: Optional[str] = Field(None, description="Replica holding the job while it runs")
+    created_at: Optional[datetime] = Field(None, description="Set on creation")
+    updated_at: Optional[datetime] = Field(None, description="Set on every write; a running job's heartbeat")
+    started_at: Optional[datetime] = None
+    finished_at: Optional[datetime] = None
+    run_after: Optional[datetime] = Field(None, description="Earliest start of a queued job waiting to be retried")
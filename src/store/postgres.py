@@ -0,0 +1,56 @@
+"""
+PostgreSQL store for production deployments
+"""
+
+import logging
+from contextlib import contextmanager
+from typing import Iterator
+
+from .migrations import DIALECT_POSTGRES
+from .sql import SQLStore
+
+logger = logging.getLogger(__name__)
+
+# pg_advisory_xact_lock key held while migrating, so replicas starting
+# together apply each migration once
+MIGRATION_LOCK_ID = 7_007_001
+
+
+class PostgresStore(SQLStore):
+    """Store backed by PostgreSQL through a psycopg2 connection pool"""
+
+    dialect = DIALECT_POSTGRES
+    placeholder = "%s"
+
+    def __init__(self, dsn: str, min_connections: int = 1, max_connections: int = 10):
+        """
+        Initialize PostgreSQL store
+
+        Args:
+            dsn: libpq connection string or postgresql:// URL
+            min_connections: Connections opened up front
+            max_connections: Upper bound of pooled connections
+        """
+        import psycopg2
+        from psycopg2.pool import ThreadedConnectionPool
+
+        self.driver_error = psycopg2.Error
+        self._pool = ThreadedConnectionPool(min_connections, max_connections, dsn)
+
+    @contextmanager
+    def _connect(self) -> Iterator:
+        conn = self._pool.getconn()
+        try:
+            yield conn
+            conn.commit()
+        except BaseException:
+            conn.rollback()
+            raise
+        finally:
+            self._pool.putconn(conn)
+
+    def _lock_migrations(self, cursor) -> None:
+        cursor.execute("SELECT pg_advisory_xact_lock(%s)", (MIGRATION_LOCK_ID,))
+
+    def close(self) -> None:
+        self._pool.closeall()
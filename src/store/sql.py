@@ -0,0 +1,1473 @@
+"""
+DB-API store implementation shared by SQLite and PostgreSQL
+
+Queries are written with "?" placeholders and JSON stored as text;
+dialect subclasses adapt placeholders, value encoding and connections.
+"""
+
+import json
+import logging
+import uuid
+from abc import abstractmethod
+from contextlib import contextmanager
+from datetime import date, datetime, timezone
+from typing import Any, Dict, Iterator, List, Optional, Sequence
+
+from ..tracing import span, tracing_enabled, KIND_CLIENT
+from .base import Store, StoreError
+from .migrations import MIGRATIONS
+from .models import (
+    ActualCostRecord,
+    ApiKeyRecord,
+    AuditEventRecord,
+    BudgetRecord,
+    CatalogRecord,
+    DiscountRuleRecord,
+    EstimateAnnotationRecord,
+    EstimateRecord,
+    InventoryRecord,
+    JOB_DEAD_LETTER,
+    JOB_FAILED,
+    JOB_QUEUED,
+    JOB_RUNNING,
+    JobRecord,
+    PriceOverrideRecord,
+    RESOLUTION_MONTHLY,
+    ReportScheduleRecord,
+    RoleAssignmentRecord,
+    UsageProfileRecord,
+    ScenarioRecord,
+    TenantRecord,
+    WebhookRecord,
+)
+
+logger = logging.getLogger(__name__)
+
+_CATALOG_COLUMNS = "provider, key, version, document, fetched_at"
+_ESTIMATE_COLUMNS = (
+    "id, kind, project, request, result, monthly_cost, currency, labels, created_at, tenant_id, "
+    "catalog_version, pricing_model_version"
+)
+_ESTIMATE_ANNOTATION_COLUMNS = "id, tenant_id, estimate_id, author, decision, comment, created_at"
+_API_KEY_COLUMNS = "id, name, key_hash, prefix, scopes, rate_limit, created_at, revoked_at, tenant_id"
+_TENANT_COLUMNS = "id, name, created_at"
+_PRICE_OVERRIDE_COLUMNS = (
+    "tenant_id, provider, service, region, sku, pricing_model, unit, price, description, updated_at, formula"
+)
+# Formula fields of price overrides, kept together in one JSON column
+_PRICE_FORMULA_FIELDS = ("tiers", "tier_mode", "free_quantity", "minimum_quantity", "minimum_charge")
+_DISCOUNT_RULE_COLUMNS = (
+    "id, tenant_id, name, description, priority, enabled, definition, created_at, updated_at"
+)
+_BUDGET_COLUMNS = "id, tenant_id, name, amount, project, labels, thresholds, created_at, updated_at"
+_SCENARIO_COLUMNS = "id, tenant_id, name, description, baseline, variations, created_at, updated_at"
+_ROLE_ASSIGNMENT_COLUMNS = "tenant_id, subject, role, created_at, updated_at"
+_USAGE_PROFILE_COLUMNS = "tenant_id, name, description, definition, created_at, updated_at"
+_AUDIT_EVENT_COLUMNS = (
+    "id, tenant_id, actor, actor_method, role, client, action, resource_type, resource_id, method, path, "
+    "status, request_id, created_at"
+)
+_ACTUAL_COST_COLUMNS = (
+    "tenant_id, source, usage_date, provider, service, project, labels, amount, currency, recorded_at, "
+    "region, account_id, sku, usage_quantity, usage_unit, resolution"
+)
+_INVENTORY_COLUMNS = (
+    "tenant_id, source, resource_id, kind, provider, region, sku, name, state, attached_to, size_gb, "
+    "attributes, labels, observed_at"
+)
+_WEBHOOK_COLUMNS = "id, tenant_id, name, url, events, format, secret, enabled, created_at, updated_at"
+_REPORT_SCHEDULE_COLUMNS = (
+    "id, tenant_id, name, frequency, day, recipients, sections, enabled, last_period, created_at, updated_at"
+)
+_JOB_COLUMNS = (
+    "id, tenant_id, kind, state, request, result, error, progress, progress_detail, estimate_id, attempts, "
+    "worker, created_at, updated_at, started_at, finished_at, run_after"
+)
+
+_CREATE_MIGRATIONS_TABLE = (
+    "CREATE TABLE IF NOT EXISTS schema_migrations ("
+    "version INTEGER PRIMARY KEY, description TEXT NOT NULL, applied_at TEXT NOT NULL)"
+)
+
+
+class _TracedCursor:
+    """DB-API cursor recording a span per statement"""
+
+    def __init__(self, cursor, dialect: str):
+        self._cursor = cursor
+        self._dialect = dialect
+
+    def execute(self, query: str, *args):
+        with self._span(query):
+            return self._cursor.execute(query, *args)
+
+    def executemany(self, query: str, *args):
+        with self._span(query):
+            return self._cursor.executemany(query, *args)
+
+    def __getattr__(self, name: str):
+        return getattr(self._cursor, name)
+
+    def __iter__(self):
+        return iter(self._cursor)
+
+    def _span(self, query: str):
+        # Statements carry placeholders only, never parameter values
+        operation = query.split(None, 1)[0].upper() if query.strip() else "QUERY"
+        return span(
+            f"{self._dialect} {operation}",
+            {"db.system": self._dialect, "db.operation": operation, "db.statement": query},
+            kind=KIND_CLIENT,
+        )
+
+
+def utcnow() -> datetime:
+    """Current time as an aware UTC datetime"""
+    return datetime.now(timezone.utc)
+
+
+class SQLStore(Store):
+    """Store over a DB-API 2.0 connection"""
+
+    placeholder = "?"
+    # Base class of the driver's exceptions, wrapped into StoreError
+    driver_error: type = Exception
+
+    @abstractmethod
+    def _connect(self):
+        """Context manager yielding a connection; commits on success, rolls back on error"""
+
+    def _lock_migrations(self, cursor) -> None:
+        """Serialize concurrent migrators (no-op where the database does not need it)"""
+
+    def _encode_time(self, value: datetime) -> Any:
+        return value
+
+    def _decode_time(self, value: Any) -> datetime:
+        return value
+
+    def _encode_date(self, value: date) -> Any:
+        return value
+
+    def _decode_date(self, value: Any) -> date:
+        return value
+
+    def _encode_json(self, value: Dict[str, Any]) -> Any:
+        return json.dumps(value, default=str)
+
+    def _decode_json(self, value: Any) -> Dict[str, Any]:
+        if isinstance(value, (str, bytes)):
+            return json.loads(value)
+        return value
+
+    @contextmanager
+    def _cursor(self) -> Iterator[Any]:
+        try:
+            with self._connect() as conn:
+                cursor = conn.cursor()
+                try:
+                    yield _TracedCursor(cursor, self.dialect) if tracing_enabled() else cursor
+                finally:
+                    cursor.close()
+        except self.driver_error as e:
+            raise StoreError(f"{self.dialect} store error: {e}") from e
+
+    def _sql(self, query: str) -> str:
+        return query if self.placeholder == "?" else query.replace("?", self.placeholder)
+
+    # Migrations
+
+    def migrate(self) -> List[int]:
+        applied = []
+        with self._cursor() as cur:
+            self._lock_migrations(cur)
+            cur.execute(_CREATE_MIGRATIONS_TABLE)
+            cur.execute("SELECT version FROM schema_migrations")
+            done = {row[0] for row in cur.fetchall()}
+
+            for migration in sorted(MIGRATIONS, key=lambda m: m.version):
+                if migration.version in done:
+                    continue
+                for statement in migration.statements[self.dialect]:
+                    cur.execute(statement)
+                cur.execute(
+                    self._sql("INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)"),
+                    (migration.version, migration.description, utcnow().isoformat()),
+                )
+                applied.append(migration.version)
+                logger.info(f"Applied {self.dialect} migration {migration.version}: {migration.description}")
+        return applied
+
+    def schema_version(self) -> int:
+        with self._cursor() as cur:
+            cur.execute(_CREATE_MIGRATIONS_TABLE)
+            cur.execute("SELECT MAX(version) FROM schema_migrations")
+            row = cur.fetchone()
+        return (row[0] or 0) if row else 0
+
+    # Catalogs
+
+    def save_catalog(
+        self,
+        provider: str,
+        key: str,
+        document: Dict[str, Any],
+        fetched_at: Optional[datetime] = None,
+    ) -> CatalogRecord:
+        record = CatalogRecord(
+            provider=provider,
+            key=key,
+            version=str(document.get("version", "")),
+            document=document,
+            fetched_at=_as_utc(fetched_at) if fetched_at else utcnow(),
+        )
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO catalogs ({_CATALOG_COLUMNS}) VALUES (?, ?, ?, ?, ?) "
+                    "ON CONFLICT (provider, key) DO UPDATE SET "
+                    "version = excluded.version, document = excluded.document, fetched_at = excluded.fetched_at"
+                ),
+                (record.provider, record.key, record.version,
+                 self._encode_json(record.document), self._encode_time(record.fetched_at)),
+            )
+            # The last catalog saved on a day is that day's snapshot
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO catalog_snapshots (snapshot, {_CATALOG_COLUMNS}) VALUES (?, ?, ?, ?, ?, ?) "
+                    "ON CONFLICT (provider, key, snapshot) DO UPDATE SET "
+                    "version = excluded.version, document = excluded.document, fetched_at = excluded.fetched_at"
+                ),
+                (self._encode_date(record.fetched_at.date()), record.provider, record.key, record.version,
+                 self._encode_json(record.document), self._encode_time(record.fetched_at)),
+            )
+        return record
+
+    def load_catalog(self, provider: str, key: str) -> Optional[CatalogRecord]:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(f"SELECT {_CATALOG_COLUMNS} FROM catalogs WHERE provider = ? AND key = ?"),
+                (provider, key),
+            )
+            row = cur.fetchone()
+        return self._catalog(row) if row else None
+
+    def list_catalogs(self, provider: Optional[str] = None) -> List[CatalogRecord]:
+        query = f"SELECT {_CATALOG_COLUMNS} FROM catalogs"
+        params: tuple = ()
+        if provider is not None:
+            query += " WHERE provider = ?"
+            params = (provider,)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query + " ORDER BY provider, key"), params)
+            rows = cur.fetchall()
+        return [self._catalog(row) for row in rows]
+
+    def load_catalog_snapshot(self, provider: str, key: str, snapshot: date) -> Optional[CatalogRecord]:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"SELECT {_CATALOG_COLUMNS} FROM catalog_snapshots "
+                    "WHERE provider = ? AND key = ? AND snapshot <= ? ORDER BY snapshot DESC LIMIT 1"
+                ),
+                (provider, key, self._encode_date(snapshot)),
+            )
+            row = cur.fetchone()
+        return self._catalog(row) if row else None
+
+    def list_catalog_snapshots(self) -> List[date]:
+        with self._cursor() as cur:
+            cur.execute("SELECT DISTINCT snapshot FROM catalog_snapshots ORDER BY snapshot DESC")
+            rows = cur.fetchall()
+        return [self._decode_date(row[0]) for row in rows]
+
+    def _catalog(self, row) -> CatalogRecord:
+        provider, key, version, document, fetched_at = row
+        return CatalogRecord(
+            provider=provider,
+            key=key,
+            version=version,
+            document=self._decode_json(document),
+            fetched_at=self._decode_time(fetched_at),
+        )
+
+    # Estimates
+
+    def save_estimate(self, record: EstimateRecord) -> EstimateRecord:
+        record = record.copy(update={
+            "id": record.id or str(uuid.uuid4()),
+            "created_at": record.created_at or utcnow(),
+        })
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO estimates ({_ESTIMATE_COLUMNS}) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+                ),
+                (record.id, record.kind, record.project,
+                 self._encode_json(record.request), self._encode_json(record.result),
+                 record.monthly_cost, record.currency, self._encode_json(record.labels),
+                 self._encode_time(record.created_at), record.tenant_id,
+                 self._encode_date(record.catalog_version) if record.catalog_version else None,
+                 record.pricing_model_version),
+            )
+        return record
+
+    def get_estimate(self, estimate_id: str, tenant_id: Optional[str] = None) -> Optional[EstimateRecord]:
+        query, params = f"SELECT {_ESTIMATE_COLUMNS} FROM estimates WHERE id = ?", [estimate_id]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            row = cur.fetchone()
+        return self._estimate(row) if row else None
+
+    def list_estimates(
+        self,
+        project: Optional[str] = None,
+        since: Optional[datetime] = None,
+        until: Optional[datetime] = None,
+        limit: int = 100,
+        tenant_id: Optional[str] = None,
+    ) -> List[EstimateRecord]:
+        conditions, params = [], []
+        if tenant_id is not None:
+            conditions.append("tenant_id = ?")
+            params.append(tenant_id)
+        if project is not None:
+            conditions.append("project = ?")
+            params.append(project)
+        if since is not None:
+            conditions.append("created_at >= ?")
+            params.append(self._encode_time(_as_utc(since)))
+        if until is not None:
+            conditions.append("created_at < ?")
+            params.append(self._encode_time(_as_utc(until)))
+
+        query = f"SELECT {_ESTIMATE_COLUMNS} FROM estimates"
+        if conditions:
+            query += " WHERE " + " AND ".join(conditions)
+        query += " ORDER BY created_at DESC, id LIMIT ?"
+        params.append(limit)
+
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            rows = cur.fetchall()
+        return [self._estimate(row) for row in rows]
+
+    def _estimate(self, row) -> EstimateRecord:
+        (estimate_id, kind, project, request, result,
+         monthly_cost, currency, labels, created_at, tenant_id, catalog_version, pricing_model_version) = row
+        return EstimateRecord(
+            id=estimate_id,
+            tenant_id=tenant_id,
+            kind=kind,
+            project=project,
+            request=self._decode_json(request),
+            result=self._decode_json(result),
+            monthly_cost=monthly_cost,
+            currency=currency,
+            labels=self._decode_json(labels),
+            created_at=self._decode_time(created_at),
+            catalog_version=self._decode_date(catalog_version) if catalog_version else None,
+            pricing_model_version=pricing_model_version,
+        )
+
+    # Estimate annotations
+
+    def append_estimate_annotation(self, record: EstimateAnnotationRecord) -> EstimateAnnotationRecord:
+        record = record.copy(update={"id": record.id or str(uuid.uuid4()), "created_at": utcnow()})
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO estimate_annotations ({_ESTIMATE_ANNOTATION_COLUMNS}) VALUES (?, ?, ?, ?, ?, ?, ?)"
+                ),
+                (record.id, record.tenant_id, record.estimate_id, record.author, record.decision,
+                 record.comment, self._encode_time(record.created_at)),
+            )
+        return record
+
+    def list_estimate_annotations(
+        self, estimate_id: str, tenant_id: Optional[str] = None
+    ) -> List[EstimateAnnotationRecord]:
+        query = f"SELECT {_ESTIMATE_ANNOTATION_COLUMNS} FROM estimate_annotations WHERE estimate_id = ?"
+        params = [estimate_id]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        query += " ORDER BY created_at, id"
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            rows = cur.fetchall()
+        return [self._estimate_annotation(row) for row in rows]
+
+    def _estimate_annotation(self, row) -> EstimateAnnotationRecord:
+        annotation_id, tenant_id, estimate_id, author, decision, comment, created_at = row
+        return EstimateAnnotationRecord(
+            id=annotation_id,
+            tenant_id=tenant_id,
+            estimate_id=estimate_id,
+            author=author,
+            decision=decision,
+            comment=comment,
+            created_at=self._decode_time(created_at),
+        )
+
+    # API keys
+
+    def save_api_key(self, record: ApiKeyRecord) -> ApiKeyRecord:
+        record = record.copy(update={
+            "id": record.id or str(uuid.uuid4()),
+            "created_at": record.created_at or utcnow(),
+        })
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(f"INSERT INTO api_keys ({_API_KEY_COLUMNS}) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"),
+                (record.id, record.name, record.key_hash, record.prefix,
+                 self._encode_json(record.scopes), record.rate_limit,
+                 self._encode_time(record.created_at),
+                 self._encode_time(record.revoked_at) if record.revoked_at else None,
+                 record.tenant_id),
+            )
+        return record
+
+    def find_api_key(self, key_hash: str) -> Optional[ApiKeyRecord]:
+        with self._cursor() as cur:
+            cur.execute(self._sql(f"SELECT {_API_KEY_COLUMNS} FROM api_keys WHERE key_hash = ?"), (key_hash,))
+            row = cur.fetchone()
+        return self._api_key(row) if row else None
+
+    def list_api_keys(self, include_revoked: bool = False, tenant_id: Optional[str] = None) -> List[ApiKeyRecord]:
+        conditions, params = [], []
+        if not include_revoked:
+            conditions.append("revoked_at IS NULL")
+        if tenant_id is not None:
+            conditions.append("tenant_id = ?")
+            params.append(tenant_id)
+
+        query = f"SELECT {_API_KEY_COLUMNS} FROM api_keys"
+        if conditions:
+            query += " WHERE " + " AND ".join(conditions)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query + " ORDER BY created_at, id"), tuple(params))
+            rows = cur.fetchall()
+        return [self._api_key(row) for row in rows]
+
+    def revoke_api_key(self, key_id: str, tenant_id: Optional[str] = None) -> Optional[ApiKeyRecord]:
+        condition, params = "id = ?", [key_id]
+        if tenant_id is not None:
+            condition += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(f"UPDATE api_keys SET revoked_at = ? WHERE {condition} AND revoked_at IS NULL"),
+                (self._encode_time(utcnow()), *params),
+            )
+            cur.execute(self._sql(f"SELECT {_API_KEY_COLUMNS} FROM api_keys WHERE {condition}"), tuple(params))
+            row = cur.fetchone()
+        return self._api_key(row) if row else None
+
+    def _api_key(self, row) -> ApiKeyRecord:
+        (key_id, name, key_hash, prefix, scopes,
+         rate_limit, created_at, revoked_at, tenant_id) = row
+        return ApiKeyRecord(
+            id=key_id,
+            tenant_id=tenant_id,
+            name=name,
+            key_hash=key_hash,
+            prefix=prefix,
+            scopes=self._decode_json(scopes),
+            rate_limit=rate_limit,
+            created_at=self._decode_time(created_at),
+            revoked_at=self._decode_time(revoked_at) if revoked_at is not None else None,
+        )
+
+    # Tenants
+
+    def save_tenant(self, record: TenantRecord) -> TenantRecord:
+        record = record.copy(update={"created_at": record.created_at or utcnow()})
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(f"INSERT INTO tenants ({_TENANT_COLUMNS}) VALUES (?, ?, ?)"),
+                (record.id, record.name, self._encode_time(record.created_at)),
+            )
+        return record
+
+    def get_tenant(self, tenant_id: str) -> Optional[TenantRecord]:
+        with self._cursor() as cur:
+            cur.execute(self._sql(f"SELECT {_TENANT_COLUMNS} FROM tenants WHERE id = ?"), (tenant_id,))
+            row = cur.fetchone()
+        return self._tenant(row) if row else None
+
+    def list_tenants(self) -> List[TenantRecord]:
+        with self._cursor() as cur:
+            cur.execute(f"SELECT {_TENANT_COLUMNS} FROM tenants ORDER BY id")
+            rows = cur.fetchall()
+        return [self._tenant(row) for row in rows]
+
+    def _tenant(self, row) -> TenantRecord:
+        tenant_id, name, created_at = row
+        return TenantRecord(id=tenant_id, name=name, created_at=self._decode_time(created_at))
+
+    # Tenant price overrides
+
+    def replace_price_overrides(
+        self, tenant_id: str, overrides: List[PriceOverrideRecord]
+    ) -> List[PriceOverrideRecord]:
+        now = utcnow()
+        records = [o.copy(update={"tenant_id": tenant_id, "updated_at": now}) for o in overrides]
+        with self._cursor() as cur:
+            cur.execute(self._sql("DELETE FROM price_overrides WHERE tenant_id = ?"), (tenant_id,))
+            for o in records:
+                cur.execute(
+                    self._sql(
+                        f"INSERT INTO price_overrides ({_PRICE_OVERRIDE_COLUMNS}) "
+                        "VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+                    ),
+                    (o.tenant_id, o.provider, o.service, o.region, o.sku, o.pricing_model,
+                     o.unit, o.price, o.description, self._encode_time(o.updated_at), self._price_formula(o)),
+                )
+        return records
+
+    def list_price_overrides(self, tenant_id: str) -> List[PriceOverrideRecord]:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"SELECT {_PRICE_OVERRIDE_COLUMNS} FROM price_overrides WHERE tenant_id = ? "
+                    "ORDER BY provider, service, region, sku, pricing_model"
+                ),
+                (tenant_id,),
+            )
+            rows = cur.fetchall()
+        return [self._price_override(row) for row in rows]
+
+    def _price_formula(self, override: PriceOverrideRecord) -> Any:
+        """Formula column of an override, NULL for flat prices"""
+        if not (override.tiers or override.free_quantity or override.minimum_quantity or override.minimum_charge):
+            return None
+        return self._encode_json(override.dict(include=set(_PRICE_FORMULA_FIELDS)))
+
+    def _price_override(self, row) -> PriceOverrideRecord:
+        (tenant_id, provider, service, region, sku,
+         pricing_model, unit, price, description, updated_at, formula) = row
+        return PriceOverrideRecord(
+            **(self._decode_json(formula) if formula else {}),
+            tenant_id=tenant_id,
+            provider=provider,
+            service=service,
+            region=region,
+            sku=sku,
+            pricing_model=pricing_model,
+            unit=unit,
+            price=price,
+            description=description,
+            updated_at=self._decode_time(updated_at),
+        )
+
+
+    # Discount rules
+
+    def save_discount_rule(self, record: DiscountRuleRecord) -> DiscountRuleRecord:
+        now = utcnow()
+        record = record.copy(update={
+            "id": record.id or str(uuid.uuid4()),
+            "created_at": record.created_at or now,
+            "updated_at": now,
+        })
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO discount_rules ({_DISCOUNT_RULE_COLUMNS}) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) "
+                    "ON CONFLICT (id) DO UPDATE SET "
+                    "name = excluded.name, description = excluded.description, priority = excluded.priority, "
+                    "enabled = excluded.enabled, definition = excluded.definition, updated_at = excluded.updated_at "
+                    "WHERE discount_rules.tenant_id = excluded.tenant_id"
+                ),
+                (record.id, record.tenant_id, record.name, record.description, record.priority,
+                 record.enabled, self._encode_json(record.definition),
+                 self._encode_time(record.created_at), self._encode_time(record.updated_at)),
+            )
+        return record
+
+    def get_discount_rule(self, rule_id: str, tenant_id: Optional[str] = None) -> Optional[DiscountRuleRecord]:
+        query, params = f"SELECT {_DISCOUNT_RULE_COLUMNS} FROM discount_rules WHERE id = ?", [rule_id]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            row = cur.fetchone()
+        return self._discount_rule(row) if row else None
+
+    def list_discount_rules(self, tenant_id: str) -> List[DiscountRuleRecord]:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"SELECT {_DISCOUNT_RULE_COLUMNS} FROM discount_rules WHERE tenant_id = ? "
+                    "ORDER BY priority, id"
+                ),
+                (tenant_id,),
+            )
+            rows = cur.fetchall()
+        return [self._discount_rule(row) for row in rows]
+
+    def delete_discount_rule(self, rule_id: str, tenant_id: Optional[str] = None) -> bool:
+        query, params = "DELETE FROM discount_rules WHERE id = ?", [rule_id]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            deleted = cur.rowcount
+        return deleted > 0
+
+    def _discount_rule(self, row) -> DiscountRuleRecord:
+        (rule_id, tenant_id, name, description, priority,
+         enabled, definition, created_at, updated_at) = row
+        return DiscountRuleRecord(
+            id=rule_id,
+            tenant_id=tenant_id,
+            name=name,
+            description=description,
+            priority=priority,
+            enabled=bool(enabled),
+            definition=self._decode_json(definition),
+            created_at=self._decode_time(created_at),
+            updated_at=self._decode_time(updated_at),
+        )
+
+
+    # Budgets
+
+    def save_budget(self, record: BudgetRecord) -> BudgetRecord:
+        now = utcnow()
+        record = record.copy(update={
+            "id": record.id or str(uuid.uuid4()),
+            "created_at": record.created_at or now,
+            "updated_at": now,
+        })
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO budgets ({_BUDGET_COLUMNS}) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) "
+                    "ON CONFLICT (id) DO UPDATE SET "
+                    "name = excluded.name, amount = excluded.amount, project = excluded.project, "
+                    "labels = excluded.labels, thresholds = excluded.thresholds, updated_at = excluded.updated_at "
+                    "WHERE budgets.tenant_id = excluded.tenant_id"
+                ),
+                (record.id, record.tenant_id, record.name, record.amount, record.project,
+                 self._encode_json(record.labels), self._encode_json(record.thresholds),
+                 self._encode_time(record.created_at), self._encode_time(record.updated_at)),
+            )
+        return record
+
+    def get_budget(self, budget_id: str, tenant_id: Optional[str] = None) -> Optional[BudgetRecord]:
+        query, params = f"SELECT {_BUDGET_COLUMNS} FROM budgets WHERE id = ?", [budget_id]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            row = cur.fetchone()
+        return self._budget(row) if row else None
+
+    def list_budgets(self, tenant_id: str) -> List[BudgetRecord]:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(f"SELECT {_BUDGET_COLUMNS} FROM budgets WHERE tenant_id = ? ORDER BY name, id"),
+                (tenant_id,),
+            )
+            rows = cur.fetchall()
+        return [self._budget(row) for row in rows]
+
+    def delete_budget(self, budget_id: str, tenant_id: Optional[str] = None) -> bool:
+        query, params = "DELETE FROM budgets WHERE id = ?", [budget_id]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            deleted = cur.rowcount
+        return deleted > 0
+
+    def _budget(self, row) -> BudgetRecord:
+        (budget_id, tenant_id, name, amount, project,
+         labels, thresholds, created_at, updated_at) = row
+        return BudgetRecord(
+            id=budget_id,
+            tenant_id=tenant_id,
+            name=name,
+            amount=amount,
+            project=project,
+            labels=self._decode_json(labels),
+            thresholds=self._decode_json(thresholds),
+            created_at=self._decode_time(created_at),
+            updated_at=self._decode_time(updated_at),
+        )
+
+    # Scenarios
+
+    def save_scenario(self, record: ScenarioRecord) -> ScenarioRecord:
+        now = utcnow()
+        record = record.copy(update={
+            "id": record.id or str(uuid.uuid4()),
+            "created_at": record.created_at or now,
+            "updated_at": now,
+        })
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO scenarios ({_SCENARIO_COLUMNS}) VALUES (?, ?, ?, ?, ?, ?, ?, ?) "
+                    "ON CONFLICT (id) DO UPDATE SET "
+                    "name = excluded.name, description = excluded.description, baseline = excluded.baseline, "
+                    "variations = excluded.variations, updated_at = excluded.updated_at "
+                    "WHERE scenarios.tenant_id = excluded.tenant_id"
+                ),
+                (record.id, record.tenant_id, record.name, record.description,
+                 self._encode_json(record.baseline), self._encode_json(record.variations),
+                 self._encode_time(record.created_at), self._encode_time(record.updated_at)),
+            )
+        return record
+
+    def get_scenario(self, scenario_id: str, tenant_id: Optional[str] = None) -> Optional[ScenarioRecord]:
+        query, params = f"SELECT {_SCENARIO_COLUMNS} FROM scenarios WHERE id = ?", [scenario_id]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            row = cur.fetchone()
+        return self._scenario(row) if row else None
+
+    def list_scenarios(self, tenant_id: str) -> List[ScenarioRecord]:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(f"SELECT {_SCENARIO_COLUMNS} FROM scenarios WHERE tenant_id = ? ORDER BY name, id"),
+                (tenant_id,),
+            )
+            rows = cur.fetchall()
+        return [self._scenario(row) for row in rows]
+
+    def delete_scenario(self, scenario_id: str, tenant_id: Optional[str] = None) -> bool:
+        query, params = "DELETE FROM scenarios WHERE id = ?", [scenario_id]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            deleted = cur.rowcount
+        return deleted > 0
+
+    def _scenario(self, row) -> ScenarioRecord:
+        (scenario_id, tenant_id, name, description, baseline, variations, created_at, updated_at) = row
+        return ScenarioRecord(
+            id=scenario_id,
+            tenant_id=tenant_id,
+            name=name,
+            description=description,
+            baseline=self._decode_json(baseline),
+            variations=self._decode_json(variations),
+            created_at=self._decode_time(created_at),
+            updated_at=self._decode_time(updated_at),
+        )
+
+    # Role assignments
+
+    def save_role_assignment(self, record: RoleAssignmentRecord) -> RoleAssignmentRecord:
+        now = utcnow()
+        current = self.get_role_assignment(record.tenant_id, record.subject)
+        record = record.copy(update={
+            "created_at": current.created_at if current is not None else record.created_at or now,
+            "updated_at": now,
+        })
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO role_assignments ({_ROLE_ASSIGNMENT_COLUMNS}) VALUES (?, ?, ?, ?, ?) "
+                    "ON CONFLICT (tenant_id, subject) DO UPDATE SET "
+                    "role = excluded.role, updated_at = excluded.updated_at"
+                ),
+                (record.tenant_id, record.subject, record.role,
+                 self._encode_time(record.created_at), self._encode_time(record.updated_at)),
+            )
+        return record
+
+    def get_role_assignment(self, tenant_id: str, subject: str) -> Optional[RoleAssignmentRecord]:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"SELECT {_ROLE_ASSIGNMENT_COLUMNS} FROM role_assignments WHERE tenant_id = ? AND subject = ?"
+                ),
+                (tenant_id, subject),
+            )
+            row = cur.fetchone()
+        return self._role_assignment(row) if row else None
+
+    def list_role_assignments(self, tenant_id: str) -> List[RoleAssignmentRecord]:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"SELECT {_ROLE_ASSIGNMENT_COLUMNS} FROM role_assignments WHERE tenant_id = ? ORDER BY subject"
+                ),
+                (tenant_id,),
+            )
+            rows = cur.fetchall()
+        return [self._role_assignment(row) for row in rows]
+
+    def delete_role_assignment(self, tenant_id: str, subject: str) -> bool:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql("DELETE FROM role_assignments WHERE tenant_id = ? AND subject = ?"),
+                (tenant_id, subject),
+            )
+            deleted = cur.rowcount
+        return deleted > 0
+
+    def _role_assignment(self, row) -> RoleAssignmentRecord:
+        tenant_id, subject, role, created_at, updated_at = row
+        return RoleAssignmentRecord(
+            tenant_id=tenant_id,
+            subject=subject,
+            role=role,
+            created_at=self._decode_time(created_at),
+            updated_at=self._decode_time(updated_at),
+        )
+
+    # Usage profiles
+
+    def save_usage_profile(self, record: UsageProfileRecord) -> UsageProfileRecord:
+        now = utcnow()
+        current = self.get_usage_profile(record.tenant_id, record.name)
+        record = record.copy(update={
+            "created_at": current.created_at if current is not None else record.created_at or now,
+            "updated_at": now,
+        })
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO usage_profiles ({_USAGE_PROFILE_COLUMNS}) VALUES (?, ?, ?, ?, ?, ?) "
+                    "ON CONFLICT (tenant_id, name) DO UPDATE SET "
+                    "description = excluded.description, definition = excluded.definition, "
+                    "updated_at = excluded.updated_at"
+                ),
+                (record.tenant_id, record.name, record.description, self._encode_json(record.definition),
+                 self._encode_time(record.created_at), self._encode_time(record.updated_at)),
+            )
+        return record
+
+    def get_usage_profile(self, tenant_id: str, name: str) -> Optional[UsageProfileRecord]:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(f"SELECT {_USAGE_PROFILE_COLUMNS} FROM usage_profiles WHERE tenant_id = ? AND name = ?"),
+                (tenant_id, name),
+            )
+            row = cur.fetchone()
+        return self._usage_profile(row) if row else None
+
+    def list_usage_profiles(self, tenant_id: str) -> List[UsageProfileRecord]:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(f"SELECT {_USAGE_PROFILE_COLUMNS} FROM usage_profiles WHERE tenant_id = ? ORDER BY name"),
+                (tenant_id,),
+            )
+            rows = cur.fetchall()
+        return [self._usage_profile(row) for row in rows]
+
+    def delete_usage_profile(self, tenant_id: str, name: str) -> bool:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql("DELETE FROM usage_profiles WHERE tenant_id = ? AND name = ?"),
+                (tenant_id, name),
+            )
+            deleted = cur.rowcount
+        return deleted > 0
+
+    def _usage_profile(self, row) -> UsageProfileRecord:
+        tenant_id, name, description, definition, created_at, updated_at = row
+        return UsageProfileRecord(
+            tenant_id=tenant_id,
+            name=name,
+            description=description,
+            definition=self._decode_json(definition),
+            created_at=self._decode_time(created_at),
+            updated_at=self._decode_time(updated_at),
+        )
+
+    # Audit log
+
+    def append_audit_event(self, record: AuditEventRecord) -> AuditEventRecord:
+        record = record.copy(update={"id": record.id or str(uuid.uuid4()), "created_at": utcnow()})
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO audit_events ({_AUDIT_EVENT_COLUMNS}) "
+                    "VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+                ),
+                (record.id, record.tenant_id, record.actor, record.actor_method, record.role, record.client,
+                 record.action, record.resource_type, record.resource_id, record.method, record.path,
+                 record.status, record.request_id, self._encode_time(record.created_at)),
+            )
+        return record
+
+    def list_audit_events(
+        self,
+        tenant_id: Optional[str] = None,
+        actor: Optional[str] = None,
+        resource_type: Optional[str] = None,
+        resource_id: Optional[str] = None,
+        action: Optional[str] = None,
+        since: Optional[datetime] = None,
+        until: Optional[datetime] = None,
+        limit: int = 100,
+    ) -> List[AuditEventRecord]:
+        conditions, params = [], []
+        for column, value in (
+            ("tenant_id", tenant_id),
+            ("actor", actor),
+            ("resource_type", resource_type),
+            ("resource_id", resource_id),
+            ("action", action),
+        ):
+            if value is not None:
+                conditions.append(f"{column} = ?")
+                params.append(value)
+        if since is not None:
+            conditions.append("created_at >= ?")
+            params.append(self._encode_time(_as_utc(since)))
+        if until is not None:
+            conditions.append("created_at < ?")
+            params.append(self._encode_time(_as_utc(until)))
+
+        query = f"SELECT {_AUDIT_EVENT_COLUMNS} FROM audit_events"
+        if conditions:
+            query += " WHERE " + " AND ".join(conditions)
+        query += " ORDER BY created_at DESC, id LIMIT ?"
+        params.append(limit)
+
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            rows = cur.fetchall()
+        return [self._audit_event(row) for row in rows]
+
+    def _audit_event(self, row) -> AuditEventRecord:
+        (event_id, tenant_id, actor, actor_method, role, client, action, resource_type, resource_id,
+         method, path, status, request_id, created_at) = row
+        return AuditEventRecord(
+            id=event_id,
+            tenant_id=tenant_id,
+            actor=actor,
+            actor_method=actor_method,
+            role=role,
+            client=client,
+            action=action,
+            resource_type=resource_type,
+            resource_id=resource_id,
+            method=method,
+            path=path,
+            status=status,
+            request_id=request_id,
+            created_at=self._decode_time(created_at),
+        )
+
+    # Actual costs
+
+    def add_actual_costs(self, records: List[ActualCostRecord]) -> List[ActualCostRecord]:
+        now = utcnow()
+        records = [r.copy(update={"recorded_at": now}) for r in records]
+        with self._cursor() as cur:
+            self._insert_actual_costs(cur, records)
+        return records
+
+    def replace_actual_costs(
+        self,
+        tenant_id: str,
+        source: str,
+        since: date,
+        until: date,
+        records: List[ActualCostRecord],
+    ) -> List[ActualCostRecord]:
+        now = utcnow()
+        records = [r.copy(update={"tenant_id": tenant_id, "source": source, "recorded_at": now}) for r in records]
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    "DELETE FROM actual_costs "
+                    "WHERE tenant_id = ? AND source = ? AND usage_date >= ? AND usage_date < ?"
+                ),
+                (tenant_id, source, self._encode_date(since), self._encode_date(until)),
+            )
+            self._insert_actual_costs(cur, records)
+        return records
+
+    def _insert_actual_costs(self, cur, records: List[ActualCostRecord]) -> None:
+        for r in records:
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO actual_costs ({_ACTUAL_COST_COLUMNS}) "
+                    "VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+                ),
+                (r.tenant_id, r.source, self._encode_date(r.usage_date), r.provider, r.service,
+                 r.project, self._encode_json(r.labels), r.amount, r.currency,
+                 self._encode_time(r.recorded_at),
+                 r.region, r.account_id, r.sku, r.usage_quantity, r.usage_unit, r.resolution),
+            )
+
+    def list_actual_costs(
+        self,
+        tenant_id: str,
+        since: date,
+        until: date,
+        project: Optional[str] = None,
+    ) -> List[ActualCostRecord]:
+        # The monthly roll-up of the month since falls in is dated before it
+        query = (
+            f"SELECT {_ACTUAL_COST_COLUMNS} FROM actual_costs "
+            "WHERE tenant_id = ? AND (usage_date >= ? OR (resolution = ? AND usage_date >= ?)) AND usage_date < ?"
+        )
+        params = [
+            tenant_id, self._encode_date(since), RESOLUTION_MONTHLY, self._encode_date(since.replace(day=1)),
+            self._encode_date(until),
+        ]
+        if project is not None:
+            query += " AND project = ?"
+            params.append(project)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query + " ORDER BY usage_date"), tuple(params))
+            rows = cur.fetchall()
+        records = [self._actual_cost(row) for row in rows]
+        return [_prorate(r, since, until) if r.resolution == RESOLUTION_MONTHLY else r for r in records]
+
+    def earliest_actual_cost(self, resolution: str, until: date) -> Optional[date]:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql("SELECT MIN(usage_date) FROM actual_costs WHERE resolution = ? AND usage_date < ?"),
+                (resolution, self._encode_date(until)),
+            )
+            row = cur.fetchone()
+        return self._decode_date(row[0]) if row and row[0] is not None else None
+
+    def scan_actual_costs(self, resolutions: Sequence[str], since: date, until: date) -> List[ActualCostRecord]:
+        placeholders = ", ".join("?" for _ in resolutions)
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"SELECT {_ACTUAL_COST_COLUMNS} FROM actual_costs "
+                    f"WHERE resolution IN ({placeholders}) AND usage_date >= ? AND usage_date < ? "
+                    "ORDER BY tenant_id, usage_date"
+                ),
+                (*resolutions, self._encode_date(since), self._encode_date(until)),
+            )
+            rows = cur.fetchall()
+        return [self._actual_cost(row) for row in rows]
+
+    def replace_actual_cost_rollups(
+        self,
+        resolutions: Sequence[str],
+        since: date,
+        until: date,
+        records: List[ActualCostRecord],
+    ) -> int:
+        now = utcnow()
+        records = [r.copy(update={"recorded_at": r.recorded_at or now}) for r in records]
+        placeholders = ", ".join("?" for _ in resolutions)
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"DELETE FROM actual_costs "
+                    f"WHERE resolution IN ({placeholders}) AND usage_date >= ? AND usage_date < ?"
+                ),
+                (*resolutions, self._encode_date(since), self._encode_date(until)),
+            )
+            replaced = cur.rowcount
+            self._insert_actual_costs(cur, records)
+        return replaced
+
+    def delete_actual_costs(self, resolution: str, until: date) -> int:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql("DELETE FROM actual_costs WHERE resolution = ? AND usage_date < ?"),
+                (resolution, self._encode_date(until)),
+            )
+            deleted = cur.rowcount
+        return deleted
+
+    def _actual_cost(self, row) -> ActualCostRecord:
+        (tenant_id, source, usage_date, provider, service, project, labels, amount, currency,
+         recorded_at, region, account_id, sku, usage_quantity, usage_unit, resolution) = row
+        return ActualCostRecord(
+            tenant_id=tenant_id,
+            source=source,
+            usage_date=self._decode_date(usage_date),
+            provider=provider,
+            service=service,
+            project=project,
+            labels=self._decode_json(labels),
+            amount=amount,
+            currency=currency,
+            recorded_at=self._decode_time(recorded_at),
+            region=region,
+            account_id=account_id,
+            sku=sku,
+            usage_quantity=usage_quantity,
+            usage_unit=usage_unit,
+            resolution=resolution,
+        )
+
+    # Inventory
+
+    def replace_inventory(self, tenant_id: str, source: str, records: List[InventoryRecord]) -> List[InventoryRecord]:
+        now = utcnow()
+        records = [
+            r.copy(update={"tenant_id": tenant_id, "source": source, "observed_at": r.observed_at or now})
+            for r in records
+        ]
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql("DELETE FROM inventory WHERE tenant_id = ? AND source = ?"), (tenant_id, source)
+            )
+            for r in records:
+                cur.execute(
+                    self._sql(
+                        f"INSERT INTO inventory ({_INVENTORY_COLUMNS}) "
+                        "VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+                    ),
+                    (r.tenant_id, r.source, r.resource_id, r.kind, r.provider, r.region, r.sku, r.name,
+                     r.state, r.attached_to, r.size_gb, self._encode_json(r.attributes),
+                     self._encode_json(r.labels), self._encode_time(r.observed_at)),
+                )
+        return records
+
+    def list_inventory(self, tenant_id: str, kind: Optional[str] = None) -> List[InventoryRecord]:
+        query, params = f"SELECT {_INVENTORY_COLUMNS} FROM inventory WHERE tenant_id = ?", [tenant_id]
+        if kind is not None:
+            query += " AND kind = ?"
+            params.append(kind)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query + " ORDER BY kind, resource_id, source"), tuple(params))
+            rows = cur.fetchall()
+        return [self._inventory(row) for row in rows]
+
+    def _inventory(self, row) -> InventoryRecord:
+        (tenant_id, source, resource_id, kind, provider, region, sku, name, state,
+         attached_to, size_gb, attributes, labels, observed_at) = row
+        return InventoryRecord(
+            tenant_id=tenant_id,
+            source=source,
+            resource_id=resource_id,
+            kind=kind,
+            provider=provider,
+            region=region,
+            sku=sku,
+            name=name,
+            state=state,
+            attached_to=attached_to,
+            size_gb=size_gb,
+            attributes=self._decode_json(attributes),
+            labels=self._decode_json(labels),
+            observed_at=self._decode_time(observed_at),
+        )
+
+    # Webhooks
+
+    def save_webhook(self, record: WebhookRecord) -> WebhookRecord:
+        now = utcnow()
+        record = record.copy(update={
+            "id": record.id or str(uuid.uuid4()),
+            "created_at": record.created_at or now,
+            "updated_at": now,
+        })
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO webhooks ({_WEBHOOK_COLUMNS}) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) "
+                    "ON CONFLICT (id) DO UPDATE SET "
+                    "name = excluded.name, url = excluded.url, events = excluded.events, "
+                    "format = excluded.format, secret = excluded.secret, enabled = excluded.enabled, "
+                    "updated_at = excluded.updated_at "
+                    "WHERE webhooks.tenant_id = excluded.tenant_id"
+                ),
+                (record.id, record.tenant_id, record.name, record.url,
+                 self._encode_json(record.events), record.format, record.secret, record.enabled,
+                 self._encode_time(record.created_at), self._encode_time(record.updated_at)),
+            )
+        return record
+
+    def get_webhook(self, webhook_id: str, tenant_id: Optional[str] = None) -> Optional[WebhookRecord]:
+        query, params = f"SELECT {_WEBHOOK_COLUMNS} FROM webhooks WHERE id = ?", [webhook_id]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            row = cur.fetchone()
+        return self._webhook(row) if row else None
+
+    def list_webhooks(self, tenant_id: str) -> List[WebhookRecord]:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(f"SELECT {_WEBHOOK_COLUMNS} FROM webhooks WHERE tenant_id = ? ORDER BY name, id"),
+                (tenant_id,),
+            )
+            rows = cur.fetchall()
+        return [self._webhook(row) for row in rows]
+
+    def delete_webhook(self, webhook_id: str, tenant_id: Optional[str] = None) -> bool:
+        query, params = "DELETE FROM webhooks WHERE id = ?", [webhook_id]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            deleted = cur.rowcount
+        return deleted > 0
+
+    def _webhook(self, row) -> WebhookRecord:
+        (webhook_id, tenant_id, name, url, events, format_,
+         secret, enabled, created_at, updated_at) = row
+        return WebhookRecord(
+            id=webhook_id,
+            tenant_id=tenant_id,
+            name=name,
+            url=url,
+            events=self._decode_json(events),
+            format=format_,
+            secret=secret,
+            enabled=bool(enabled),
+            created_at=self._decode_time(created_at),
+            updated_at=self._decode_time(updated_at),
+        )
+
+    # Report schedules
+
+    def save_report_schedule(self, record: ReportScheduleRecord) -> ReportScheduleRecord:
+        now = utcnow()
+        record = record.copy(update={
+            "id": record.id or str(uuid.uuid4()),
+            "created_at": record.created_at or now,
+            "updated_at": now,
+        })
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO report_schedules ({_REPORT_SCHEDULE_COLUMNS}) "
+                    "VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) "
+                    "ON CONFLICT (id) DO UPDATE SET "
+                    "name = excluded.name, frequency = excluded.frequency, day = excluded.day, "
+                    "recipients = excluded.recipients, sections = excluded.sections, enabled = excluded.enabled, "
+                    "updated_at = excluded.updated_at "
+                    "WHERE report_schedules.tenant_id = excluded.tenant_id"
+                ),
+                (record.id, record.tenant_id, record.name, record.frequency, record.day,
+                 self._encode_json(record.recipients), self._encode_json(record.sections), record.enabled,
+                 record.last_period, self._encode_time(record.created_at), self._encode_time(record.updated_at)),
+            )
+        return record
+
+    def get_report_schedule(self, schedule_id: str, tenant_id: Optional[str] = None) -> Optional[ReportScheduleRecord]:
+        query, params = f"SELECT {_REPORT_SCHEDULE_COLUMNS} FROM report_schedules WHERE id = ?", [schedule_id]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            row = cur.fetchone()
+        return self._report_schedule(row) if row else None
+
+    def list_report_schedules(self, tenant_id: Optional[str] = None) -> List[ReportScheduleRecord]:
+        query, params = f"SELECT {_REPORT_SCHEDULE_COLUMNS} FROM report_schedules", []
+        if tenant_id is not None:
+            query += " WHERE tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query + " ORDER BY name, id"), tuple(params))
+            rows = cur.fetchall()
+        return [self._report_schedule(row) for row in rows]
+
+    def delete_report_schedule(self, schedule_id: str, tenant_id: Optional[str] = None) -> bool:
+        query, params = "DELETE FROM report_schedules WHERE id = ?", [schedule_id]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            deleted = cur.rowcount
+        return deleted > 0
+
+    def mark_report_sent(self, schedule_id: str, period: str) -> bool:
+        with self._cursor() as cur:
+            cur.execute(self._sql("UPDATE report_schedules SET last_period = ? WHERE id = ?"), (period, schedule_id))
+            updated = cur.rowcount
+        return updated > 0
+
+    def _report_schedule(self, row) -> ReportScheduleRecord:
+        (schedule_id, tenant_id, name, frequency, day, recipients, sections,
+         enabled, last_period, created_at, updated_at) = row
+        return ReportScheduleRecord(
+            id=schedule_id,
+            tenant_id=tenant_id,
+            name=name,
+            frequency=frequency,
+            day=day,
+            recipients=self._decode_json(recipients),
+            sections=self._decode_json(sections),
+            enabled=bool(enabled),
+            last_period=last_period,
+            created_at=self._decode_time(created_at),
+            updated_at=self._decode_time(updated_at),
+        )
+
+    # Jobs
+
+    def create_job(self, record: JobRecord) -> JobRecord:
+        now = utcnow()
+        record = record.copy(update={"id": record.id or str(uuid.uuid4()), "created_at": now, "updated_at": now})
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    f"INSERT INTO jobs ({_JOB_COLUMNS}) "
+                    "VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+                ),
+                (record.id, record.tenant_id, record.kind, record.state, self._encode_json(record.request),
+                 self._encode_json(record.result) if record.result is not None else None,
+                 record.error, record.progress, record.progress_detail, record.estimate_id, record.attempts,
+                 record.worker, self._encode_time(record.created_at), self._encode_time(record.updated_at),
+                 self._encode_time(record.started_at) if record.started_at else None,
+                 self._encode_time(record.finished_at) if record.finished_at else None,
+                 self._encode_time(record.run_after) if record.run_after else None),
+            )
+        return record
+
+    def get_job(self, job_id: str, tenant_id: Optional[str] = None) -> Optional[JobRecord]:
+        query, params = f"SELECT {_JOB_COLUMNS} FROM jobs WHERE id = ?", [job_id]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            row = cur.fetchone()
+        return self._job(row) if row else None
+
+    def list_jobs(
+        self,
+        tenant_id: Optional[str] = None,
+        states: Optional[List[str]] = None,
+        limit: int = 100,
+    ) -> List[JobRecord]:
+        conditions, params = [], []
+        if tenant_id is not None:
+            conditions.append("tenant_id = ?")
+            params.append(tenant_id)
+        if states:
+            conditions.append(f"state IN ({', '.join('?' for _ in states)})")
+            params += states
+
+        query = f"SELECT {_JOB_COLUMNS} FROM jobs"
+        if conditions:
+            query += " WHERE " + " AND ".join(conditions)
+        query += " ORDER BY created_at DESC, id LIMIT ?"
+        params.append(limit)
+
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            rows = cur.fetchall()
+        return [self._job(row) for row in rows]
+
+    def claim_job(self, job_id: str, worker: str, stale_before: datetime) -> Optional[JobRecord]:
+        now = self._encode_time(utcnow())
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    "UPDATE jobs SET state = ?, worker = ?, attempts = attempts + 1, "
+                    "started_at = ?, updated_at = ? "
+                    "WHERE id = ? AND ((state = ? AND (run_after IS NULL OR run_after <= ?)) "
+                    "OR (state = ? AND updated_at < ?))"
+                ),
+                (JOB_RUNNING, worker, now, now, job_id, JOB_QUEUED, now, JOB_RUNNING,
+                 self._encode_time(_as_utc(stale_before))),
+            )
+            if cur.rowcount == 0:
+                return None
+            cur.execute(self._sql(f"SELECT {_JOB_COLUMNS} FROM jobs WHERE id = ?"), (job_id,))
+            row = cur.fetchone()
+        return self._job(row) if row else None
+
+    def update_job(self, record: JobRecord) -> bool:
+        with self._cursor() as cur:
+            cur.execute(
+                self._sql(
+                    "UPDATE jobs SET state = ?, request = ?, result = ?, error = ?, progress = ?, "
+                    "progress_detail = ?, estimate_id = ?, finished_at = ?, run_after = ?, updated_at = ? "
+                    "WHERE id = ? AND worker = ? AND state = ?"
+                ),
+                (record.state, self._encode_json(record.request),
+                 self._encode_json(record.result) if record.result is not None else None,
+                 record.error, record.progress, record.progress_detail, record.estimate_id,
+                 self._encode_time(record.finished_at) if record.finished_at else None,
+                 self._encode_time(record.run_after) if record.run_after else None, self._encode_time(utcnow()),
+                 record.id, record.worker, JOB_RUNNING),
+            )
+            updated = cur.rowcount
+        return updated > 0
+
+    def requeue_job(self, job_id: str, tenant_id: Optional[str] = None) -> Optional[JobRecord]:
+        query = (
+            "UPDATE jobs SET state = ?, error = NULL, progress = 0, progress_detail = NULL, attempts = 0, "
+            "worker = NULL, finished_at = NULL, run_after = NULL, updated_at = ? "
+            "WHERE id = ? AND state IN (?, ?)"
+        )
+        params = [JOB_QUEUED, self._encode_time(utcnow()), job_id, JOB_FAILED, JOB_DEAD_LETTER]
+        if tenant_id is not None:
+            query += " AND tenant_id = ?"
+            params.append(tenant_id)
+        with self._cursor() as cur:
+            cur.execute(self._sql(query), tuple(params))
+            if cur.rowcount == 0:
+                return None
+            cur.execute(self._sql(f"SELECT {_JOB_COLUMNS} FROM jobs WHERE id = ?"), (job_id,))
+            row = cur.fetchone()
+        return self._job(row) if row else None
+
+    def _job(self, row) -> JobRecord:
+        (job_id, tenant_id, kind, state, request, result, error, progress, progress_detail, estimate_id,
+         attempts, worker, created_at, updated_at, started_at, finished_at, run_after) = row
+        return JobRecord(
+            id=job_id,
+            tenant_id=tenant_id,
+            kind=kind,
+            state=state,
+            request=self._decode_json(request),
+            result=None if result is None else self._decode_json(result),
+            error=error,
+            progress=progress,
+            progress_detail=progress_detail,
+            estimate_id=estimate_id,
+            attempts=attempts,
+            worker=worker,
+            created_at=self._decode_time(created_at),
+            updated_at=self._decode_time(updated_at),
+            started_at=None if started_at is None else self._decode_time(started_at),
+            finished_at=None if finished_at is None else self._decode_time(finished_at),
+            run_after=None if run_after is None else self._decode_time(run_after),
+        )
+
+
+def _as_utc(value: datetime) -> datetime:
+    """Treat naive datetimes as UTC"""
+    if value.tzinfo is None:
+        return value.replace(tzinfo=timezone.utc)
+    return value.astimezone(timezone.utc)
+
+
+def _prorate(record: ActualCostRecord, since: date, until: date) -> ActualCostRecord:
+    """Share of a monthly roll-up in the days of its month from since to until"""
+    start = record.usage_date
+    end = date(start.year + start.month // 12, start.month % 12 + 1, 1)
+    first, last = max(start, since), min(end, until)
+    if (first, last) == (start, end):
+        return record
+    share = (last - first).days / (end - start).days
+    return record.copy(update={
+        "usage_date": first,
+        "amount": round(record.amount * share, 6),
+        "usage_quantity": round(record.usage_quantity * share, 6),
+    })
@@ -0,0 +1,65 @@
+"""
+SQLite store for local development and single-replica deployments
+"""
+
+import os
+import sqlite3
+import threading
+from contextlib import contextmanager
+from datetime import date, datetime, timezone
+from typing import Any, Iterator
+
+from .migrations import DIALECT_SQLITE
+from .sql import SQLStore
+
+# Fixed-width UTC timestamps so text comparison matches time order
+_TIME_FORMAT = "%Y-%m-%dT%H:%M:%S.%fZ"
+
+
+class SQLiteStore(SQLStore):
+    """Store backed by a single SQLite database file"""
+
+    dialect = DIALECT_SQLITE
+    driver_error = sqlite3.Error
+
+    def __init__(self, path: str):
+        """
+        Initialize SQLite store
+
+        Args:
+            path: Database file, created if missing (":memory:" for a private in-memory database)
+        """
+        self.path = path
+        if path != ":memory:" and os.path.dirname(path):
+            os.makedirs(os.path.dirname(path), exist_ok=True)
+
+        # One shared connection; sqlite3 serializes access through the lock
+        self._conn = sqlite3.connect(path, check_same_thread=False)
+        self._conn.execute("PRAGMA journal_mode=WAL")
+        self._lock = threading.Lock()
+
+    @contextmanager
+    def _connect(self) -> Iterator[sqlite3.Connection]:
+        with self._lock:
+            try:
+                yield self._conn
+                self._conn.commit()
+            except BaseException:
+                self._conn.rollback()
+                raise
+
+    def _encode_time(self, value: datetime) -> Any:
+        return value.astimezone(timezone.utc).strftime(_TIME_FORMAT)
+
+    def _decode_time(self, value: Any) -> datetime:
+        return datetime.strptime(value, _TIME_FORMAT).replace(tzinfo=timezone.utc)
+
+    def _encode_date(self, value: date) -> Any:
+        return value.isoformat()
+
+    def _decode_date(self, value: Any) -> date:
+        return date.fromisoformat(value)
+
+    def close(self) -> None:
+        with self._lock:
+            self._conn.close()
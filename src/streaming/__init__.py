@@ -0,0 +1,36 @@
+"""
+Streaming Module
+
+This module streams long batch and cluster estimates as Server-Sent
+Events, one event per resource as it is priced, so CLIs and UIs show
+progressive output instead of waiting for the whole result.
+"""
+
+from .models import (
+    StreamEstimateRequest,
+    STREAM_BATCH,
+    STREAM_CLUSTER,
+    STREAM_KINDS,
+    EVENT_START,
+    EVENT_ITEM,
+    EVENT_RESULT,
+    EVENT_ERROR,
+)
+from .sse import MEDIA_TYPE, HEARTBEAT_SECONDS, Emit, StreamClosedError, format_event, stream_events
+
+__all__ = [
+    "StreamEstimateRequest",
+    "STREAM_BATCH",
+    "STREAM_CLUSTER",
+    "STREAM_KINDS",
+    "EVENT_START",
+    "EVENT_ITEM",
+    "EVENT_RESULT",
+    "EVENT_ERROR",
+    "MEDIA_TYPE",
+    "HEARTBEAT_SECONDS",
+    "Emit",
+    "StreamClosedError",
+    "format_event",
+    "stream_events",
+]
@@ -0,0 +1,34 @@
+"""
+Data models for streamed estimates
+"""
+
+from typing import Any, Dict
+
+from pydantic import BaseModel, Field, validator
+
+# Estimates that can be streamed
+STREAM_BATCH = "batch"
+STREAM_CLUSTER = "cluster"
+STREAM_KINDS = (STREAM_BATCH, STREAM_CLUSTER)
+
+# Events of a stream: start, an item per resource priced, then the result or an error
+EVENT_START = "start"
+EVENT_ITEM = "item"
+EVENT_RESULT = "result"
+EVENT_ERROR = "error"
+
+
+class StreamEstimateRequest(BaseModel):
+    """An estimate whose resources are streamed as they are priced"""
+
+    kind: str = Field(..., description="batch or cluster")
+    request: Dict[str, Any] = Field(
+        default_factory=dict, description="Request body of POST /estimate/batch or /estimate/cluster"
+    )
+
+    @validator("kind")
+    def validate_kind(cls, v):
+        v = v.lower()
+        if v not in STREAM_KINDS:
+            raise ValueError(f"kind must be one of {', '.join(STREAM_KINDS)}")
+        return v
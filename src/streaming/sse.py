@@ -0,0 +1,100 @@
+"""
+Server-Sent Events
+
+An estimate streamed to a client runs on a worker thread and emits an
+event for every resource it prices. Events are queued to the event loop
+and written as text/event-stream messages, numbered from 1, with a
+comment line sent whenever the estimate is quiet for the heartbeat so
+proxies keep the connection open. A client that disconnects stops the
+estimate at its next event.
+"""
+
+import asyncio
+import json
+import logging
+import threading
+from typing import Any, AsyncIterator, Callable, Optional
+
+from .models import EVENT_ERROR, EVENT_RESULT
+
+logger = logging.getLogger(__name__)
+
+MEDIA_TYPE = "text/event-stream"
+
+# Sent when nothing else was for this many seconds
+HEARTBEAT_SECONDS = 15.0
+
+# Emits an event; raises StreamClosedError once the client is gone
+Emit = Callable[[str, Any], None]
+
+_END = object()
+
+
+class StreamClosedError(Exception):
+    """Raised into an estimate whose client disconnected"""
+
+
+def format_event(event: str, data: Any, event_id: Optional[int] = None) -> str:
+    """A text/event-stream message carrying data as JSON on one line"""
+    lines = [] if event_id is None else [f"id: {event_id}"]
+    lines += [f"event: {event}", f"data: {json.dumps(data, default=str, separators=(',', ':'))}"]
+    return "\n".join(lines) + "\n\n"
+
+
+async def stream_events(
+    work: Callable[[Emit], Any], heartbeat: float = HEARTBEAT_SECONDS
+) -> AsyncIterator[str]:
+    """
+    Run work on a worker thread, yielding the events it emits as messages
+
+    work is called with the emit function and returns the data of the
+    final result event. A ValueError it raises ends the stream with an
+    error event of status 400, any other exception with status 500.
+    """
+    loop = asyncio.get_running_loop()
+    queue: asyncio.Queue = asyncio.Queue()
+    closed = threading.Event()
+
+    def put(item) -> None:
+        try:
+            loop.call_soon_threadsafe(queue.put_nowait, item)
+        except RuntimeError:
+            # The loop is gone with the client
+            closed.set()
+
+    def emit(event: str, data: Any) -> None:
+        if closed.is_set():
+            raise StreamClosedError()
+        put((event, data))
+
+    def run() -> None:
+        try:
+            put((EVENT_RESULT, work(emit)))
+        except StreamClosedError:
+            logger.info("Streamed estimate stopped: client disconnected")
+        except ValueError as e:
+            put((EVENT_ERROR, {"status": 400, "detail": str(e)}))
+        except Exception as e:
+            logger.error(f"Streamed estimate failed: {e}")
+            put((EVENT_ERROR, {"status": 500, "detail": f"Estimation failed: {e}"}))
+        finally:
+            put(_END)
+
+    # The worker thread sees the request's tenant and request ID; the task
+    # is referenced until the stream ends so it is not collected
+    worker = asyncio.ensure_future(asyncio.to_thread(run))
+    event_id = 0
+    try:
+        while True:
+            try:
+                item = await asyncio.wait_for(queue.get(), heartbeat)
+            except asyncio.TimeoutError:
+                yield ": keep-alive\n\n"
+                continue
+            if item is _END:
+                break
+            event_id += 1
+            yield format_event(item[0], item[1], event_id)
+    finally:
+        closed.set()
+        del worker
@@ -0,0 +1,48 @@
+"""
+Tenancy Module
+
+Tenants (organizations) scope estimates, API keys and price overrides.
+Each request is bound to a tenant, and a tenant's negotiated price sheet,
+uploaded as JSON or a CSV/JSON file, overrides public list prices in its
+estimates.
+"""
+
+from .context import (
+    TENANT_HEADER,
+    current_tenant,
+    tenant_context,
+    resolve_tenant,
+    is_operator,
+    validate_tenant_id,
+)
+from .models import TenantCreateRequest, PriceSheet, PriceSheetEntry, ANY_REGION
+from .overrides import TenantPriceOverrides, OVERRIDE_SOURCE
+from .sheets import (
+    parse_price_sheet,
+    merge_sheets,
+    detect_format,
+    FORMAT_CSV,
+    FORMAT_JSON,
+    SHEET_FORMATS,
+)
+
+__all__ = [
+    "TENANT_HEADER",
+    "current_tenant",
+    "tenant_context",
+    "resolve_tenant",
+    "is_operator",
+    "validate_tenant_id",
+    "TenantCreateRequest",
+    "PriceSheet",
+    "PriceSheetEntry",
+    "ANY_REGION",
+    "TenantPriceOverrides",
+    "OVERRIDE_SOURCE",
+    "parse_price_sheet",
+    "merge_sheets",
+    "detect_format",
+    "FORMAT_CSV",
+    "FORMAT_JSON",
+    "SHEET_FORMATS",
+]
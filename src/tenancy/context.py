@@ -0,0 +1,77 @@
+"""
+Tenant of the request being handled
+
+Requests are bound to a tenant for their duration, like request IDs, so
+price lookups and recorded estimates deep in the estimators see it
+without every call passing it along.
+"""
+
+import contextvars
+import re
+from contextlib import contextmanager
+from typing import Optional
+
+from ..auth import Principal, SCOPE_ADMIN
+from ..store import DEFAULT_TENANT
+
+TENANT_HEADER = "X-Tenant-ID"
+
+_TENANT_ID = re.compile(r"^[a-z0-9][a-z0-9-]{0,62}$")
+
+_tenant: contextvars.ContextVar[str] = contextvars.ContextVar("tenant", default=DEFAULT_TENANT)
+
+
+def validate_tenant_id(tenant_id: str) -> str:
+    """
+    Check a tenant ID: lower case letters, digits and dashes, up to 63 characters
+
+    Raises:
+        ValueError: If the ID is malformed
+    """
+    if not _TENANT_ID.match(tenant_id):
+        raise ValueError(f"Invalid tenant ID '{tenant_id}' (lower case letters, digits and dashes)")
+    return tenant_id
+
+
+def current_tenant() -> str:
+    """Tenant of the request being handled, the default tenant outside a request"""
+    return _tenant.get()
+
+
+@contextmanager
+def tenant_context(tenant_id: str):
+    """Bind a tenant for the duration of the block"""
+    token = _tenant.set(tenant_id)
+    try:
+        yield tenant_id
+    finally:
+        _tenant.reset(token)
+
+
+def is_operator(principal: Optional[Principal]) -> bool:
+    """
+    Whether a caller may act for any tenant
+
+    Admins of the default tenant operate the service; without
+    authentication every caller does.
+    """
+    return principal is None or (principal.tenant_id == DEFAULT_TENANT and principal.has_scope(SCOPE_ADMIN))
+
+
+def resolve_tenant(requested: Optional[str], principal: Optional[Principal] = None) -> str:
+    """
+    Tenant a request acts for
+
+    Callers act for the tenant of their credentials; operators may name
+    another one in the X-Tenant-ID header.
+
+    Raises:
+        ValueError: If the requested tenant ID is malformed
+        PermissionError: If a caller names a tenant it does not belong to
+    """
+    own = principal.tenant_id if principal is not None else DEFAULT_TENANT
+    if not requested or requested == own:
+        return own
+    if not is_operator(principal):
+        raise PermissionError(f"'{principal.subject}' cannot act for tenant '{requested}'")
+    return validate_tenant_id(requested)
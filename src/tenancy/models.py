@@ -0,0 +1,98 @@
+"""
+Data models for tenants and their price sheets
+"""
+
+from typing import List
+
+from pydantic import BaseModel, Field, validator
+
+from ..pricing import (
+    PRICING_MODELS,
+    COMMITMENT_MODELS,
+    SERVICE_COMPUTE,
+    PRICING_ON_DEMAND,
+    PriceTier,
+    TIER_MODE_GRADUATED,
+    normalize_tier_mode,
+    parse_tiers,
+    validate_tiers,
+)
+from ..store import PriceOverrideRecord
+from .context import validate_tenant_id
+
+# Region of overrides that apply in every region of the provider
+ANY_REGION = "*"
+
+
+class TenantCreateRequest(BaseModel):
+    """Request to create a tenant"""
+
+    id: str = Field(..., description="Tenant ID: lower case letters, digits and dashes")
+    name: str = Field("", description="Display name")
+
+    @validator("id")
+    def validate_id(cls, v):
+        return validate_tenant_id(v)
+
+
+class PriceSheetEntry(BaseModel):
+    """Negotiated price of one SKU"""
+
+    provider: str = Field(..., description="Cloud provider, e.g. aws")
+    service: str = Field(SERVICE_COMPUTE, description="compute, block_storage, object_storage or database")
+    region: str = Field(..., description=f"Provider region, or {ANY_REGION} for every region")
+    sku: str = Field(..., description="Instance type, volume type or provider SKU")
+    pricing_model: str = PRICING_ON_DEMAND
+    unit: str = Field("hour", description="Billing unit of the price: hour, GB-month, ...")
+    price: float = Field(..., ge=0, description="Price per unit (USD)")
+    description: str = Field("", description="e.g. EA 2026 discount")
+    tier_mode: str = Field(TIER_MODE_GRADUATED, description="graduated (each band at its price) or volume")
+    tiers: List[PriceTier] = Field(
+        default_factory=list,
+        description='Volume tiers of a usage-based price, e.g. "10240:0.09;51200:0.085;:0.07" (end:price bands)'
+    )
+    free_quantity: float = Field(0.0, ge=0, description="Monthly quantity free before the price applies")
+    minimum_quantity: float = Field(0.0, ge=0, description="Least billed monthly quantity once usage is billed")
+    minimum_charge: float = Field(0.0, ge=0, description="Least monthly charge once usage is billed (USD)")
+
+    @validator("provider", "service", "pricing_model")
+    def normalize(cls, v):
+        return v.strip().lower()
+
+    @validator("tier_mode")
+    def validate_tier_mode(cls, v):
+        return normalize_tier_mode(v)
+
+    @validator("tiers", pre=True)
+    def read_tiers(cls, v):
+        return parse_tiers(v)
+
+    @validator("tiers")
+    def contiguous_tiers(cls, v):
+        return validate_tiers(v)
+
+    @validator("pricing_model")
+    def validate_pricing_model(cls, v):
+        if v not in PRICING_MODELS + COMMITMENT_MODELS:
+            raise ValueError(f"pricing_model must be one of: {', '.join(PRICING_MODELS + COMMITMENT_MODELS)}")
+        return v
+
+    def to_record(self, tenant_id: str) -> PriceOverrideRecord:
+        return PriceOverrideRecord(tenant_id=tenant_id, **self.dict())
+
+
+class PriceSheet(BaseModel):
+    """A tenant's negotiated prices, replacing any previous sheet"""
+
+    prices: List[PriceSheetEntry] = Field(default_factory=list)
+
+    @validator("prices")
+    def unique_entries(cls, v):
+        seen = set()
+        for entry in v:
+            key = (entry.provider, entry.service, entry.region, entry.sku, entry.pricing_model)
+            if key in seen:
+                raise ValueError(f"Duplicate price for {'/'.join(key)}")
+            seen.add(key)
+        return v
+
@@ -0,0 +1,97 @@
+"""
+Tenant price overrides
+
+Negotiated prices from a tenant's price sheet take precedence over public
+list prices in every estimate made for the tenant. Sheets are read from
+the store and cached per tenant for a short TTL, so a sheet uploaded on
+one replica applies on the others within the TTL.
+"""
+
+import threading
+import time
+from typing import Callable, Dict, Optional, Tuple
+
+from ..pricing import Price, PriceQuery
+from ..store import Store, PriceOverrideRecord
+from .context import current_tenant
+from .models import ANY_REGION
+
+# Price.source of overridden prices
+OVERRIDE_SOURCE = "price_sheet"
+
+_Key = Tuple[str, str, str, str, str]
+
+
+def _key(provider: str, service: str, region: str, sku: str, pricing_model: str) -> _Key:
+    return provider, service, region, sku, pricing_model
+
+
+class TenantPriceOverrides:
+    """Price override lookup for the current tenant, installed on the provider registry"""
+
+    def __init__(self, store: Store, ttl: float = 60.0, clock: Callable[[], float] = time.monotonic):
+        """
+        Initialize overrides
+
+        Args:
+            store: Store holding the price sheets
+            ttl: Seconds a tenant's sheet is cached
+            clock: Monotonic time source in seconds
+        """
+        self.store = store
+        self.ttl = ttl
+        self.clock = clock
+        # Tenant -> (loaded at, overrides by key)
+        self._sheets: Dict[str, Tuple[float, Dict[_Key, PriceOverrideRecord]]] = {}
+        self._lock = threading.Lock()
+
+    def _sheet(self, tenant_id: str) -> Dict[_Key, PriceOverrideRecord]:
+        now = self.clock()
+        with self._lock:
+            cached = self._sheets.get(tenant_id)
+        if cached is not None and now - cached[0] < self.ttl:
+            return cached[1]
+
+        sheet = {
+            _key(o.provider, o.service, o.region, o.sku, o.pricing_model): o
+            for o in self.store.list_price_overrides(tenant_id)
+        }
+        with self._lock:
+            self._sheets[tenant_id] = (now, sheet)
+        return sheet
+
+    def invalidate(self, tenant_id: str) -> None:
+        """Drop a tenant's cached sheet after it changed"""
+        with self._lock:
+            self._sheets.pop(tenant_id, None)
+
+    def lookup(self, query: PriceQuery) -> Optional[Price]:
+        """Negotiated price of the current tenant for a query, None to use list prices"""
+        sheet = self._sheet(current_tenant())
+        if not sheet:
+            return None
+
+        override = None
+        for region in (query.region, ANY_REGION):
+            override = sheet.get(_key(query.provider, query.service, region, query.sku, query.pricing_model))
+            if override is not None:
+                break
+        if override is None:
+            return None
+
+        return Price(
+            provider=query.provider,
+            region=query.region,
+            sku=query.sku,
+            service=query.service,
+            unit=override.unit,
+            price=override.price,
+            source=OVERRIDE_SOURCE,
+            pricing_model=query.pricing_model,
+            effective_date=override.updated_at,
+            tiers=override.tiers,
+            tier_mode=override.tier_mode,
+            free_quantity=override.free_quantity,
+            minimum_quantity=override.minimum_quantity,
+            minimum_charge=override.minimum_charge,
+        )
@@ -0,0 +1,128 @@
+"""
+Price sheet files
+
+Parses negotiated price lists exported from spreadsheets (CSV) or other
+tools (JSON) into a PriceSheet. Each row prices one SKU in one region:
+
+    provider,region,sku,unit,price,service,pricing_model,description
+    aws,us-east-1,m5.large,hour,0.081,compute,on_demand,EA 2026
+
+Only sku, region, unit and price are required; provider may instead be
+given for the whole file, and service follows from the unit when absent
+(hour -> compute, GB-month -> block_storage). Usage-based prices add
+their formula in the tiers, tier_mode, free_quantity, minimum_quantity
+and minimum_charge columns.
+"""
+
+import csv
+import io
+import json
+from typing import Any, Dict, List, Optional
+
+from pydantic import ValidationError
+
+from ..pricing import SERVICE_COMPUTE, SERVICE_BLOCK_STORAGE
+from .models import PriceSheet, PriceSheetEntry
+
+FORMAT_CSV = "csv"
+FORMAT_JSON = "json"
+SHEET_FORMATS = (FORMAT_CSV, FORMAT_JSON)
+
+_REQUIRED_COLUMNS = ("sku", "region", "unit", "price")
+
+# Service of rows without one, by unit
+_UNIT_SERVICES = {
+    "hour": SERVICE_COMPUTE,
+    "gb-month": SERVICE_BLOCK_STORAGE,
+}
+
+
+def detect_format(filename: Optional[str], content_type: Optional[str] = None) -> str:
+    """
+    Sheet format from an upload's file name or content type
+
+    Raises:
+        ValueError: If neither identifies CSV or JSON
+    """
+    name = (filename or "").lower()
+    content_type = (content_type or "").lower()
+    if name.endswith(".csv") or "csv" in content_type:
+        return FORMAT_CSV
+    if name.endswith(".json") or "json" in content_type:
+        return FORMAT_JSON
+    raise ValueError("Cannot tell the price sheet format, name the file .csv or .json or pass format")
+
+
+def _entry(row: Dict[str, Any], number: int, provider: Optional[str]) -> PriceSheetEntry:
+    row = {str(k).strip().lower(): v for k, v in row.items() if k is not None}
+    row = {k: v.strip() if isinstance(v, str) else v for k, v in row.items() if v not in (None, "")}
+
+    missing = [c for c in _REQUIRED_COLUMNS if c not in row]
+    if "provider" not in row and not provider:
+        missing.append("provider")
+    if missing:
+        raise ValueError(f"Row {number}: missing {', '.join(missing)}")
+
+    row.setdefault("provider", provider)
+    row.setdefault("service", _UNIT_SERVICES.get(str(row["unit"]).lower(), SERVICE_COMPUTE))
+    try:
+        return PriceSheetEntry(**row)
+    except ValidationError as e:
+        errors = "; ".join(f"{'.'.join(str(p) for p in err['loc'])}: {err['msg']}" for err in e.errors())
+        raise ValueError(f"Row {number}: {errors}") from None
+
+
+def _rows(content: str, sheet_format: str) -> List[Dict[str, Any]]:
+    if sheet_format == FORMAT_CSV:
+        reader = csv.DictReader(io.StringIO(content))
+        if not reader.fieldnames:
+            raise ValueError("CSV price sheet has no header row")
+        return list(reader)
+
+    try:
+        document = json.loads(content)
+    except json.JSONDecodeError as e:
+        raise ValueError(f"Invalid JSON price sheet: {e}") from None
+    if isinstance(document, dict):
+        document = document.get("prices")
+    if not isinstance(document, list) or not all(isinstance(row, dict) for row in document):
+        raise ValueError('JSON price sheet must be a list of prices or {"prices": [...]}')
+    return document
+
+
+def parse_price_sheet(content: bytes, sheet_format: str, provider: Optional[str] = None) -> PriceSheet:
+    """
+    Parse a CSV or JSON price sheet
+
+    Args:
+        content: File content (UTF-8, a byte order mark is ignored)
+        sheet_format: csv or json
+        provider: Provider of rows without a provider column
+
+    Raises:
+        ValueError: If the file is malformed; the message names the offending row
+    """
+    if sheet_format not in SHEET_FORMATS:
+        raise ValueError(f"Unsupported price sheet format '{sheet_format}' (expected csv or json)")
+    try:
+        text = content.decode("utf-8-sig")
+    except UnicodeDecodeError:
+        raise ValueError("Price sheet is not UTF-8 text") from None
+
+    # CSV data rows start at line 2, after the header
+    first = 2 if sheet_format == FORMAT_CSV else 1
+    entries = [_entry(row, number, provider) for number, row in enumerate(_rows(text, sheet_format), first)]
+    try:
+        return PriceSheet(prices=entries)
+    except ValidationError as e:
+        raise ValueError("; ".join(err["msg"] for err in e.errors())) from None
+
+
+def merge_sheets(current: PriceSheet, update: PriceSheet) -> PriceSheet:
+    """Sheet with the update's prices added to, or replacing, the current ones"""
+    def key(entry: PriceSheetEntry):
+        return entry.provider, entry.service, entry.region, entry.sku, entry.pricing_model
+
+    merged = {key(entry): entry for entry in current.prices}
+    merged.update({key(entry): entry for entry in update.prices})
+    return PriceSheet(prices=list(merged.values()))
@@ -0,0 +1,34 @@
+"""
+Terraform Plan Cost Estimation Module
+
+Estimates the monthly cost delta of `terraform show -json` plans from
+pluggable per-resource-type mappers.
+"""
+
+from .models import (
+    UsageComponent,
+    ResourceChange,
+    ResourceChangeCost,
+    UnpricedResource,
+    TerraformEstimateRequest,
+    TerraformEstimateResult,
+)
+from .plan import load_plan, resource_changes
+from .mappers import ResourceMapper, register_mapper, get_mapper, supported_resource_types
+from .estimator import TerraformEstimator
+
+__all__ = [
+    "UsageComponent",
+    "ResourceChange",
+    "ResourceChangeCost",
+    "UnpricedResource",
+    "TerraformEstimateRequest",
+    "TerraformEstimateResult",
+    "load_plan",
+    "resource_changes",
+    "ResourceMapper",
+    "register_mapper",
+    "get_mapper",
+    "supported_resource_types",
+    "TerraformEstimator",
+]
@@ -0,0 +1,206 @@
+"""
+Terraform plan cost estimator
+
+Prices each changed resource before and after the change using the
+registered resource mappers, applies the tenant's discount rules, and
+reports the projected monthly delta. Resources of instance types that
+are not priced are reported with the closest ones of their region.
+"""
+
+import logging
+from decimal import Decimal
+from typing import List, Optional
+
+from ..discounts import DiscountEngine, DiscountSession
+from ..estimator import apply_discount_rules, coverage_of
+from ..money import add_amounts, round_money, to_decimal
+from ..pricing import PriceNotFoundError, PriceQuery, ProviderRegistry, SERVICE_COMPUTE, did_you_mean, usage_amount
+from ..units import hours_per_month
+from .mappers import get_mapper
+from .models import (
+    ComponentCost,
+    ResourceChange,
+    ResourceChangeCost,
+    TerraformEstimateRequest,
+    TerraformEstimateResult,
+    UnpricedResource,
+    UsageComponent,
+    ACTION_CREATE,
+    ACTION_DELETE,
+)
+from .plan import load_plan, resource_changes
+
+logger = logging.getLogger(__name__)
+
+
+class TerraformEstimator:
+    """Estimates the monthly cost delta of a Terraform plan"""
+
+    def __init__(self, registry: ProviderRegistry, discounts: Optional[DiscountEngine] = None):
+        """
+        Initialize Terraform estimator
+
+        Args:
+            registry: Registry of enabled pricing providers
+            discounts: Discount rules applied to components. No rules apply if not provided.
+        """
+        self.registry = registry
+        self.discounts = discounts
+
+    def estimate(self, request: TerraformEstimateRequest) -> TerraformEstimateResult:
+        """
+        Estimate the cost delta of the request's plan
+
+        Resources without a mapper, or whose prices are unavailable, are
+        reported as unpriced rather than failing the estimate.
+
+        Raises:
+            ValueError: If the plan is not valid `terraform show -json` output
+        """
+        plan = load_plan(request.plan)
+        result = self.estimate_changes(resource_changes(plan), request.region, request.hours)
+
+        logger.info(
+            f"Terraform estimate: +{len(result.added)} ~{len(result.changed)} -{len(result.destroyed)} "
+            f"({len(result.unpriced)} unpriced), delta ${result.monthly_delta:.2f}/month"
+        )
+        return result
+
+    def estimate_changes(
+        self,
+        changes: List[ResourceChange],
+        region: Optional[str] = None,
+        hours: Optional[float] = None,
+    ) -> TerraformEstimateResult:
+        """
+        Estimate the cost delta of resource changes, from a Terraform plan or any other source
+
+        Args:
+            changes: Changes of resources typed as Terraform resources
+            region: Region of changes whose provider sets none
+            hours: Running hours per month, the time basis' by default
+        """
+        hours = hours or hours_per_month()
+        result = TerraformEstimateResult()
+        # Tier usage is counted separately for the state before and after the changes
+        before, after = self._discount_session(), self._discount_session()
+        before_cost = after_cost = Decimal(0)
+
+        for change in changes:
+            if change.region is None:
+                change.region = region
+
+            try:
+                cost = self._change_cost(change, hours, before, after)
+            except (LookupError, ValueError, TypeError) as e:
+                result.unpriced.append(UnpricedResource(
+                    address=change.address, type=change.type, action=change.action, reason=_reason(e),
+                ))
+                continue
+
+            if change.action == ACTION_CREATE:
+                result.added.append(cost)
+            elif change.action == ACTION_DELETE:
+                result.destroyed.append(cost)
+            else:
+                result.changed.append(cost)
+
+            before_cost += to_decimal(cost.before_monthly_cost)
+            after_cost += to_decimal(cost.after_monthly_cost)
+
+        result.before_monthly_cost = round_money(before_cost)
+        result.after_monthly_cost = round_money(after_cost)
+        result.monthly_delta = round_money(after_cost - before_cost)
+        priced = len(result.added) + len(result.changed) + len(result.destroyed)
+        result.coverage = coverage_of(priced, len(result.unpriced))
+        return result
+
+    def _discount_session(self) -> Optional[DiscountSession]:
+        return self.discounts.session() if self.discounts is not None else None
+
+    def _change_cost(
+        self,
+        change: ResourceChange,
+        hours: float,
+        before_discounts: Optional[DiscountSession] = None,
+        after_discounts: Optional[DiscountSession] = None,
+    ) -> ResourceChangeCost:
+        mapper = get_mapper(change.type)
+        if mapper is None:
+            raise LookupError(f"No cost mapper for resource type {change.type}")
+
+        before, after = [], []
+        if change.before is not None:
+            before = self._price_components(mapper(change.before, change.region), hours, before_discounts)
+        if change.after is not None:
+            after = self._price_components(mapper(change.after, change.region), hours, after_discounts)
+
+        before_cost = add_amounts(c.monthly_cost for c in before)
+        after_cost = add_amounts(c.monthly_cost for c in after)
+
+        return ResourceChangeCost(
+            address=change.address,
+            type=change.type,
+            action=change.action,
+            before_monthly_cost=round_money(before_cost),
+            after_monthly_cost=round_money(after_cost),
+            monthly_delta=round_money(after_cost - before_cost),
+            components=after if change.after is not None else before,
+        )
+
+    def _price_components(
+        self,
+        components: List[UsageComponent],
+        hours: float,
+        discounts: Optional[DiscountSession] = None,
+    ) -> List[ComponentCost]:
+        costs = []
+        for component in components:
+            try:
+                price = self.registry.get_price(PriceQuery(
+                    provider=component.provider,
+                    region=component.region,
+                    sku=component.sku,
+                    service=component.service,
+                    pricing_model=component.pricing_model,
+                    attributes=component.attributes,
+                ))
+            except PriceNotFoundError as e:
+                if component.service != SERVICE_COMPUTE:
+                    raise
+                suggestions = self.registry.suggest_instance_types(component.provider, component.region, component.sku)
+                if not suggestions:
+                    raise
+                raise PriceNotFoundError(f"{e}, {did_you_mean(suggestions)}") from e
+
+            if price.unit == "hour":
+                quantity = hours * component.count
+            elif price.unit == "GB-month":
+                quantity = (component.size_gb or 0.0) * component.count
+            else:
+                quantity = component.count
+            gross = usage_amount(price, quantity)
+            applied, monthly = apply_discount_rules(discounts, price, quantity, gross, gross)
+
+            costs.append(ComponentCost(
+                name=component.name,
+                sku=component.sku if price.requested_sku is None else price.sku,
+                requested_sku=price.requested_sku,
+                match_confidence=price.match_confidence,
+                region=component.region,
+                pricing_model=component.pricing_model,
+                unit=price.unit,
+                unit_price=price.price,
+                count=component.count,
+                size_gb=component.size_gb,
+                monthly_cost=round_money(monthly),
+                discounts=applied,
+            ))
+        return costs
+
+
+def _reason(error: Exception) -> str:
+    """Human readable reason a resource was not priced"""
+    if isinstance(error, KeyError):
+        return f"Missing attribute {error} (known only after apply?)"
+    return str(error)
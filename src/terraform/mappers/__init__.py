@@ -0,0 +1,21 @@
+"""
+Terraform resource mappers
+
+Importing this package registers the built-in aws_*, google_*,
+azurerm_* and openstack_* mappers.
+"""
+
+from .registry import (
+    ResourceMapper,
+    register_mapper,
+    get_mapper,
+    supported_resource_types,
+)
+from . import aws, google, azurerm, openstack  # noqa: F401
+
+__all__ = [
+    "ResourceMapper",
+    "register_mapper",
+    "get_mapper",
+    "supported_resource_types",
+]
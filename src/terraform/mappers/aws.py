@@ -0,0 +1,116 @@
+"""
+AWS resource mappers (EC2, EBS, RDS, EKS clusters and node groups)
+"""
+
+from typing import Any, Dict, List, Optional
+
+from ...pricing import (
+    SERVICE_COMPUTE,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_KUBERNETES,
+    PRICING_ON_DEMAND,
+    PRICING_SPOT,
+    TIER_STANDARD,
+)
+from ..models import UsageComponent
+from .registry import register_mapper, required_region, first_block, database_components
+
+# RDS CreateDBInstance default when storage_type is not set (io1 when iops is set)
+DEFAULT_RDS_STORAGE_TYPE = "gp2"
+
+# EBS CreateVolume default when type is not set
+DEFAULT_EBS_VOLUME_TYPE = "gp2"
+
+
+def _zone_region(zone: Optional[str]) -> Optional[str]:
+    """us-east-1a -> us-east-1"""
+    if zone and zone[-1].isalpha():
+        return zone[:-1]
+    return None
+
+
+def _volume(name: str, region: str, block: Dict[str, Any], size_key: str, type_key: str) -> Optional[UsageComponent]:
+    size = block.get(size_key)
+    if not size:
+        return None
+    return UsageComponent(
+        name=name,
+        provider="aws",
+        region=region,
+        service=SERVICE_BLOCK_STORAGE,
+        sku=block.get(type_key) or DEFAULT_EBS_VOLUME_TYPE,
+        size_gb=float(size),
+    )
+
+
+def map_instance(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """aws_instance: instance hours plus sized root and EBS block devices"""
+    region = required_region(region or _zone_region(values.get("availability_zone")), "aws_instance")
+    market = first_block(values, "instance_market_options").get("market_type")
+    components = [UsageComponent(
+        name="instance", provider="aws", region=region, sku=values["instance_type"],
+        pricing_model=PRICING_SPOT if market == "spot" else PRICING_ON_DEMAND,
+    )]
+
+    root = _volume("root_volume", region, first_block(values, "root_block_device"), "volume_size", "volume_type")
+    if root is not None:
+        components.append(root)
+    for device in values.get("ebs_block_device") or []:
+        volume = _volume(f"ebs_volume {device.get('device_name', '')}".strip(), region, device,
+                         "volume_size", "volume_type")
+        if volume is not None:
+            components.append(volume)
+    return components
+
+
+def map_ebs_volume(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """aws_ebs_volume: provisioned GB-months"""
+    region = required_region(_zone_region(values.get("availability_zone")) or region, "aws_ebs_volume")
+    volume = _volume("volume", region, values, "size", "type")
+    return [volume] if volume is not None else []
+
+
+def map_db_instance(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """aws_db_instance: instance hours by engine and deployment option, storage and provisioned IOPS"""
+    region = required_region(region, "aws_db_instance")
+    iops = values.get("iops")
+    storage_type = values.get("storage_type") or ("io1" if iops else DEFAULT_RDS_STORAGE_TYPE)
+    return database_components(
+        "aws", region, values["instance_class"], values.get("engine") or "mysql", bool(values.get("multi_az")),
+        storage_type, float(values.get("allocated_storage") or 0), iops,
+    )
+
+
+def map_eks_cluster(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """aws_eks_cluster: control plane hours (standard support; extended support is priced per version)"""
+    region = required_region(region, "aws_eks_cluster")
+    return [UsageComponent(
+        name="control_plane",
+        provider="aws",
+        region=region,
+        service=SERVICE_KUBERNETES,
+        sku=TIER_STANDARD,
+    )]
+
+
+def map_eks_node_group(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """aws_eks_node_group: desired nodes of the first instance type"""
+    region = required_region(region, "aws_eks_node_group")
+    instance_types = values.get("instance_types") or ["t3.medium"]
+    scaling = first_block(values, "scaling_config")
+    return [UsageComponent(
+        name="nodes",
+        provider="aws",
+        region=region,
+        service=SERVICE_COMPUTE,
+        sku=instance_types[0],
+        pricing_model=PRICING_SPOT if values.get("capacity_type") == "SPOT" else PRICING_ON_DEMAND,
+        count=float(scaling.get("desired_size") or 1),
+    )]
+
+
+register_mapper("aws_instance", map_instance)
+register_mapper("aws_ebs_volume", map_ebs_volume)
+register_mapper("aws_db_instance", map_db_instance)
+register_mapper("aws_eks_cluster", map_eks_cluster)
+register_mapper("aws_eks_node_group", map_eks_node_group)
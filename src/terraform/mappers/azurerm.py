@@ -0,0 +1,116 @@
+"""
+Azure resource mappers (Linux VMs, managed disks, AKS default node pools,
+MySQL and PostgreSQL flexible servers)
+"""
+
+from typing import Any, Dict, List, Optional
+
+from ...k8s.storage import volume_sku
+from ...pricing import (
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_KUBERNETES,
+    PRICING_ON_DEMAND,
+    PRICING_SPOT,
+    TIER_FREE,
+    normalize_cluster_tier,
+)
+from ..models import UsageComponent
+from .registry import register_mapper, required_region, first_block, database_components
+
+# Platform image OS disk size when disk_size_gb is not set
+DEFAULT_OS_DISK_GB = 30
+
+# Flexible server storage when not set (PostgreSQL storage_mb, MySQL storage.size_gb)
+DEFAULT_FLEXIBLE_STORAGE_GB = {"postgres": 32.0, "mysql": 20.0}
+
+
+def normalize_location(location: Optional[str]) -> Optional[str]:
+    """East US -> eastus"""
+    if not location:
+        return None
+    return location.replace(" ", "").lower()
+
+
+def _disk(name: str, region: str, storage_account_type: Optional[str], size_gb: float) -> UsageComponent:
+    disk_type = storage_account_type or "StandardSSD_LRS"
+    return UsageComponent(
+        name=name,
+        provider="azure",
+        region=region,
+        service=SERVICE_BLOCK_STORAGE,
+        sku=volume_sku("azure", disk_type, size_gb),
+        size_gb=size_gb,
+    )
+
+
+def map_linux_virtual_machine(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """azurerm_linux_virtual_machine: VM hours plus the OS disk tier"""
+    region = required_region(normalize_location(values.get("location")) or region,
+                             "azurerm_linux_virtual_machine")
+    os_disk = first_block(values, "os_disk")
+    return [
+        UsageComponent(
+            name="instance", provider="azure", region=region, sku=values["size"],
+            pricing_model=PRICING_SPOT if values.get("priority") == "Spot" else PRICING_ON_DEMAND,
+        ),
+        _disk("os_disk", region, os_disk.get("storage_account_type"),
+              float(os_disk.get("disk_size_gb") or DEFAULT_OS_DISK_GB)),
+    ]
+
+
+def map_managed_disk(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """azurerm_managed_disk: monthly price of the size tier"""
+    region = required_region(normalize_location(values.get("location")) or region, "azurerm_managed_disk")
+    return [_disk("disk", region, values.get("storage_account_type"), float(values["disk_size_gb"]))]
+
+
+def map_kubernetes_cluster(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """azurerm_kubernetes_cluster: default node pool VMs and the control plane hours of the SKU tier"""
+    region = required_region(normalize_location(values.get("location")) or region,
+                             "azurerm_kubernetes_cluster")
+    pool = first_block(values, "default_node_pool")
+    return [UsageComponent(
+        name="default_node_pool",
+        provider="azure",
+        region=region,
+        sku=pool["vm_size"],
+        count=float(pool.get("node_count") or pool.get("min_count") or 1),
+    ), UsageComponent(
+        name="control_plane",
+        provider="azure",
+        region=region,
+        service=SERVICE_KUBERNETES,
+        sku=normalize_cluster_tier(values.get("sku_tier") or TIER_FREE),
+    )]
+
+
+def _flexible_server(engine: str, resource_type: str, values: Dict[str, Any], region: Optional[str],
+                     storage_gb: Optional[float], iops: Optional[int]) -> List[UsageComponent]:
+    region = required_region(normalize_location(values.get("location")) or region, resource_type)
+    # sku_name is <tier>_<size>, e.g. GP_Standard_D2ds_v4
+    instance_class = values["sku_name"].split("_", 1)[-1]
+    return database_components(
+        "azure", region, instance_class, engine, bool(first_block(values, "high_availability").get("mode")),
+        "premium_ssd", storage_gb or DEFAULT_FLEXIBLE_STORAGE_GB[engine], iops,
+    )
+
+
+def map_postgresql_flexible_server(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """azurerm_postgresql_flexible_server: server hours, with an HA standby, plus storage"""
+    storage_mb = values.get("storage_mb")
+    return _flexible_server("postgres", "azurerm_postgresql_flexible_server", values, region,
+                            storage_mb / 1024 if storage_mb else None, None)
+
+
+def map_mysql_flexible_server(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """azurerm_mysql_flexible_server: server hours, with an HA standby, plus storage and extra IOPS"""
+    storage = first_block(values, "storage")
+    return _flexible_server("mysql", "azurerm_mysql_flexible_server", values, region,
+                            storage.get("size_gb"), storage.get("iops"))
+
+
+register_mapper("azurerm_linux_virtual_machine", map_linux_virtual_machine)
+register_mapper("azurerm_managed_disk", map_managed_disk)
+register_mapper("azurerm_kubernetes_cluster", map_kubernetes_cluster)
+register_mapper("azurerm_postgresql_flexible_server", map_postgresql_flexible_server)
+register_mapper("azurerm_mysql_flexible_server", map_mysql_flexible_server)
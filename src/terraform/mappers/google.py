@@ -0,0 +1,163 @@
+"""
+Google Cloud resource mappers (Compute Engine, Persistent Disk, GKE clusters and node pools,
+Cloud SQL)
+
+Guest accelerators attached to instances and node pools are priced per GPU.
+"""
+
+from typing import Any, Dict, List, Optional
+
+from ...pricing import (
+    SERVICE_ACCELERATOR,
+    SERVICE_BLOCK_STORAGE,
+    SERVICE_KUBERNETES,
+    PRICING_ON_DEMAND,
+    PRICING_SPOT,
+    TIER_AUTOPILOT,
+    TIER_STANDARD,
+)
+from ..models import UsageComponent
+from .registry import register_mapper, required_region, first_block, database_components
+
+DEFAULT_DISK_TYPE = "pd-standard"
+DEFAULT_NODE_MACHINE_TYPE = "e2-medium"
+
+# Cloud SQL database_version prefixes -> engines
+_SQL_ENGINES = {"MYSQL": "mysql", "POSTGRES": "postgres", "SQLSERVER": "sqlserver"}
+# Cloud SQL disk_type -> storage type
+_SQL_DISK_TYPES = {"PD_SSD": "ssd", "PD_HDD": "hdd"}
+# Cloud SQL disk size when disk_size is not set
+DEFAULT_SQL_DISK_GB = 10
+
+# Zones a regional GKE node pool spans when node_locations is not set
+DEFAULT_REGIONAL_ZONES = 3
+
+
+def _is_zone(location: str) -> bool:
+    """us-central1-a is a zone, us-central1 a region"""
+    return location.count("-") >= 2
+
+
+def _location_region(location: Optional[str]) -> Optional[str]:
+    if not location:
+        return None
+    return location.rsplit("-", 1)[0] if _is_zone(location) else location
+
+
+def _pricing_model(config: Dict[str, Any]) -> str:
+    """Spot for Spot VMs (provisioning_model) and legacy preemptible VMs"""
+    if config.get("spot") or config.get("preemptible") or config.get("provisioning_model") == "SPOT":
+        return PRICING_SPOT
+    return PRICING_ON_DEMAND
+
+
+def _guest_accelerators(
+    config: Dict[str, Any], region: str, pricing_model: str, machines: float = 1.0
+) -> List[UsageComponent]:
+    """One component per guest_accelerator block, counted per GPU"""
+    return [
+        UsageComponent(
+            name="accelerator",
+            provider="gcp",
+            region=region,
+            service=SERVICE_ACCELERATOR,
+            # type may be a full acceleratorTypes URL
+            sku=accelerator["type"].rsplit("/", 1)[-1],
+            pricing_model=pricing_model,
+            count=float(accelerator.get("count") or 1) * machines,
+        )
+        for accelerator in config.get("guest_accelerator") or []
+        if accelerator.get("type")
+    ]
+
+
+def map_compute_instance(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """google_compute_instance: machine hours, attached GPUs and a sized boot disk"""
+    region = required_region(_location_region(values.get("zone")) or region, "google_compute_instance")
+    pricing_model = _pricing_model(first_block(values, "scheduling"))
+    components = [UsageComponent(
+        name="instance", provider="gcp", region=region, sku=values["machine_type"], pricing_model=pricing_model,
+    )]
+    components.extend(_guest_accelerators(values, region, pricing_model))
+
+    params = first_block(first_block(values, "boot_disk"), "initialize_params")
+    if params.get("size"):
+        components.append(UsageComponent(
+            name="boot_disk",
+            provider="gcp",
+            region=region,
+            service=SERVICE_BLOCK_STORAGE,
+            sku=params.get("type") or DEFAULT_DISK_TYPE,
+            size_gb=float(params["size"]),
+        ))
+    return components
+
+
+def map_compute_disk(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """google_compute_disk: provisioned GB-months"""
+    region = required_region(_location_region(values.get("zone")) or region, "google_compute_disk")
+    if not values.get("size"):
+        return []
+    return [UsageComponent(
+        name="disk",
+        provider="gcp",
+        region=region,
+        service=SERVICE_BLOCK_STORAGE,
+        sku=values.get("type") or DEFAULT_DISK_TYPE,
+        size_gb=float(values["size"]),
+    )]
+
+
+def map_container_cluster(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """google_container_cluster: control plane hours, Standard or Autopilot (pods are not known from the plan)"""
+    region = required_region(_location_region(values.get("location")) or region, "google_container_cluster")
+    return [UsageComponent(
+        name="control_plane",
+        provider="gcp",
+        region=region,
+        service=SERVICE_KUBERNETES,
+        sku=TIER_AUTOPILOT if values.get("enable_autopilot") else TIER_STANDARD,
+    )]
+
+
+def map_container_node_pool(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """google_container_node_pool: nodes per zone times zones, with their attached GPUs"""
+    location = values.get("location")
+    region = required_region(_location_region(location) or region, "google_container_node_pool")
+
+    zones = len(values.get("node_locations") or [])
+    if not zones:
+        zones = 1 if location and _is_zone(location) else DEFAULT_REGIONAL_ZONES
+    per_zone = values.get("node_count") or values.get("initial_node_count") or 1
+
+    node_config = first_block(values, "node_config")
+    pricing_model = _pricing_model(node_config)
+    nodes = float(per_zone * zones)
+    return [UsageComponent(
+        name="nodes",
+        provider="gcp",
+        region=region,
+        sku=node_config.get("machine_type") or DEFAULT_NODE_MACHINE_TYPE,
+        pricing_model=pricing_model,
+        count=nodes,
+    )] + _guest_accelerators(node_config, region, pricing_model, nodes)
+
+
+def map_sql_database_instance(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """google_sql_database_instance: tier hours, zonal or regional, plus disk GB-months"""
+    region = required_region(values.get("region") or region, "google_sql_database_instance")
+    settings = first_block(values, "settings")
+    version = values.get("database_version") or "MYSQL_8_0"
+    return database_components(
+        "gcp", region, settings["tier"], _SQL_ENGINES.get(version.split("_", 1)[0], "mysql"),
+        settings.get("availability_type") == "REGIONAL",
+        _SQL_DISK_TYPES.get(settings.get("disk_type") or "PD_SSD", "ssd"),
+        float(settings.get("disk_size") or DEFAULT_SQL_DISK_GB),
+    )
+
+
+register_mapper("google_compute_instance", map_compute_instance)
+register_mapper("google_compute_disk", map_compute_disk)
+register_mapper("google_container_cluster", map_container_cluster)
+register_mapper("google_container_node_pool", map_container_node_pool)
+register_mapper("google_sql_database_instance", map_sql_database_instance)
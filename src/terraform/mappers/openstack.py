@@ -0,0 +1,44 @@
+"""
+OpenStack resource mappers (Nova instances and Cinder volumes)
+"""
+
+from typing import Any, Dict, List, Optional
+
+from ...pricing import SERVICE_BLOCK_STORAGE
+from ..models import UsageComponent
+from .registry import register_mapper, required_region
+
+# Cinder volume type of volumes that set none
+DEFAULT_VOLUME_TYPE = "__DEFAULT__"
+
+
+def _volume(name: str, region: str, volume_type: Optional[str], size_gb: float) -> UsageComponent:
+    return UsageComponent(
+        name=name,
+        provider="openstack",
+        region=region,
+        service=SERVICE_BLOCK_STORAGE,
+        sku=volume_type or DEFAULT_VOLUME_TYPE,
+        size_gb=size_gb,
+    )
+
+
+def map_compute_instance(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """openstack_compute_instance_v2: flavor hours plus volumes created from block devices"""
+    region = required_region(values.get("region") or region, "openstack_compute_instance_v2")
+    components = [UsageComponent(name="instance", provider="openstack", region=region, sku=values["flavor_name"])]
+    for index, device in enumerate(values.get("block_device") or []):
+        if device.get("destination_type") == "volume" and device.get("source_type") != "volume":
+            components.append(_volume(f"block_device_{index}", region, device.get("volume_type"),
+                                      float(device.get("volume_size") or 0)))
+    return components
+
+
+def map_blockstorage_volume(values: Dict[str, Any], region: Optional[str]) -> List[UsageComponent]:
+    """openstack_blockstorage_volume_v3: GB-months of the volume type"""
+    region = required_region(values.get("region") or region, "openstack_blockstorage_volume_v3")
+    return [_volume("volume", region, values.get("volume_type"), float(values["size"]))]
+
+
+register_mapper("openstack_compute_instance_v2", map_compute_instance)
+register_mapper("openstack_blockstorage_volume_v3", map_blockstorage_volume)
@@ -0,0 +1,96 @@
+"""
+Terraform resource mapper registry
+
+A mapper turns the planned attribute values of one Terraform resource
+type into the usage components that pricing providers can price.
+Mappers register under the resource type at import time.
+"""
+
+from typing import Any, Callable, Dict, List, Optional
+
+from ...estimator.database import billable_iops
+from ...pricing import (
+    ATTR_DEPLOYMENT,
+    ATTR_ENGINE,
+    SERVICE_DATABASE,
+    SERVICE_DATABASE_IOPS,
+    SERVICE_DATABASE_STORAGE,
+    deployment_of,
+)
+from ..models import UsageComponent
+
+# (values, provider region) -> usage components
+ResourceMapper = Callable[[Dict[str, Any], Optional[str]], List[UsageComponent]]
+
+_mappers: Dict[str, ResourceMapper] = {}
+
+
+def register_mapper(resource_type: str, mapper: ResourceMapper) -> None:
+    """
+    Register a mapper for a Terraform resource type
+
+    Args:
+        resource_type: Terraform type, e.g. aws_instance
+        mapper: Callable taking the resource values and the provider region
+    """
+    _mappers[resource_type] = mapper
+
+
+def get_mapper(resource_type: str) -> Optional[ResourceMapper]:
+    """Mapper registered for a resource type, if any"""
+    return _mappers.get(resource_type)
+
+
+def supported_resource_types() -> List[str]:
+    """Resource types that have a registered mapper"""
+    return sorted(_mappers.keys())
+
+
+def required_region(region: Optional[str], resource_type: str) -> str:
+    """
+    Region a mapper prices in
+
+    Raises:
+        ValueError: If neither the resource nor its provider sets a region
+    """
+    if not region:
+        raise ValueError(f"Cannot determine region for {resource_type}")
+    return region
+
+
+def first_block(values: Dict[str, Any], name: str) -> Dict[str, Any]:
+    """First element of a nested block list, or an empty dict"""
+    blocks = values.get(name) or []
+    if isinstance(blocks, list) and blocks and isinstance(blocks[0], dict):
+        return blocks[0]
+    return {}
+
+
+def database_components(
+    provider: str,
+    region: str,
+    instance_class: str,
+    engine: str,
+    high_availability: bool,
+    storage_type: str,
+    storage_gb: float,
+    iops: Optional[int] = None,
+) -> List[UsageComponent]:
+    """Instance hours, storage GB-months and IOPS beyond the baseline of a managed database"""
+    attributes = {ATTR_ENGINE: engine, ATTR_DEPLOYMENT: deployment_of(high_availability)}
+    components = [UsageComponent(
+        name="db_instance", provider=provider, region=region, service=SERVICE_DATABASE,
+        sku=instance_class, attributes=attributes,
+    )]
+    if storage_gb:
+        components.append(UsageComponent(
+            name="db_storage", provider=provider, region=region, service=SERVICE_DATABASE_STORAGE,
+            sku=storage_type, attributes=attributes, size_gb=storage_gb,
+        ))
+    extra_iops = billable_iops(provider, storage_type, storage_gb, iops)
+    if extra_iops > 0:
+        components.append(UsageComponent(
+            name="db_iops", provider=provider, region=region, service=SERVICE_DATABASE_IOPS,
+            sku=storage_type, attributes=attributes, count=extra_iops,
+        ))
+    return components
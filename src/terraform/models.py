@@ -0,0 +1,108 @@
+"""
+Data models for Terraform plan cost estimation
+"""
+
+from typing import Any, Dict, List, Optional, Union
+from pydantic import BaseModel, Field
+
+from ..estimator import AppliedDiscount, Coverage
+from ..pricing import SERVICE_COMPUTE, PRICING_ON_DEMAND
+from ..units import TimeBasis, current_time_basis, hours_per_month
+
+# Change actions reported per resource
+ACTION_CREATE = "create"
+ACTION_UPDATE = "update"
+ACTION_REPLACE = "replace"
+ACTION_DELETE = "delete"
+
+
+class UsageComponent(BaseModel):
+    """A billable unit a Terraform resource will consume"""
+
+    name: str = Field(..., description="Component label, e.g. instance or root_volume")
+    provider: str
+    region: str
+    service: str = SERVICE_COMPUTE
+    sku: str
+    pricing_model: str = PRICING_ON_DEMAND
+    attributes: Dict[str, str] = Field(default_factory=dict)
+    count: float = Field(default=1.0, ge=0, description="Number of instances, nodes or disks")
+    size_gb: Optional[float] = Field(None, ge=0, description="Provisioned size for GB-month prices")
+
+
+class ResourceChange(BaseModel):
+    """A managed resource change extracted from a plan"""
+
+    address: str
+    type: str
+    action: str
+    provider: str = Field(..., description="Short provider name, e.g. aws, google, azurerm")
+    region: Optional[str] = Field(None, description="Region from the provider configuration")
+    before: Optional[Dict[str, Any]] = None
+    after: Optional[Dict[str, Any]] = None
+
+
+class ComponentCost(BaseModel):
+    """Monthly cost of one usage component"""
+
+    name: str
+    sku: str
+    requested_sku: Optional[str] = Field(None, description="SKU of the mapping, if priced as its fuzzy match sku")
+    match_confidence: Optional[float] = Field(None, description="Confidence of the fuzzy match")
+    region: str
+    pricing_model: str = PRICING_ON_DEMAND
+    unit: str
+    unit_price: float
+    count: float
+    size_gb: Optional[float] = None
+    monthly_cost: float
+    discounts: List[AppliedDiscount] = Field(default_factory=list)
+
+
+class ResourceChangeCost(BaseModel):
+    """Cost before and after a resource change"""
+
+    address: str
+    type: str
+    action: str
+    before_monthly_cost: float = 0.0
+    after_monthly_cost: float = 0.0
+    monthly_delta: float = 0.0
+    components: List[ComponentCost] = Field(
+        default_factory=list,
+        description="Components after the change (before it, for deletions)"
+    )
+
+
+class UnpricedResource(BaseModel):
+    """A changed resource that could not be priced"""
+
+    address: str
+    type: str
+    action: str
+    reason: str
+
+
+class TerraformEstimateRequest(BaseModel):
+    """Request model for the Terraform estimate"""
+
+    plan: Union[Dict[str, Any], str] = Field(..., description="Output of `terraform show -json`")
+    region: Optional[str] = Field(None, description="Fallback region when the plan does not set one")
+    hours: float = Field(default_factory=hours_per_month, gt=0, le=744, description="Running hours per month")
+    project: Optional[str] = Field(None, description="Project the estimate is recorded under")
+    labels: Dict[str, str] = Field(default_factory=dict, description="Metadata kept with the estimate, e.g. CI run URL")
+
+
+class TerraformEstimateResult(BaseModel):
+    """Projected monthly cost delta of a plan"""
+
+    added: List[ResourceChangeCost] = Field(default_factory=list)
+    changed: List[ResourceChangeCost] = Field(default_factory=list)
+    destroyed: List[ResourceChangeCost] = Field(default_factory=list)
+    unpriced: List[UnpricedResource] = Field(default_factory=list)
+    before_monthly_cost: float = 0.0
+    after_monthly_cost: float = 0.0
+    monthly_delta: float = 0.0
+    currency: str = "USD"
+    time_basis: TimeBasis = Field(default_factory=current_time_basis, description="Hours per month of the costs")
+    coverage: Optional[Coverage] = Field(None, description="Resource changes priced and unpriced")
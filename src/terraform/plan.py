@@ -0,0 +1,118 @@
+"""
+Terraform JSON plan reader
+
+Extracts managed resource changes from `terraform show -json` output and
+resolves the region configured on each resource's provider block.
+"""
+
+import json
+import re
+from typing import Any, Dict, List, Optional, Union
+
+from .models import (
+    ResourceChange,
+    ACTION_CREATE,
+    ACTION_UPDATE,
+    ACTION_REPLACE,
+    ACTION_DELETE,
+)
+
+_INDEX = re.compile(r"\[[^\]]*\]")
+
+
+def load_plan(plan: Union[Dict[str, Any], str]) -> Dict[str, Any]:
+    """
+    Decode a plan document
+
+    Raises:
+        ValueError: If the plan is not valid JSON or not a plan object
+    """
+    if isinstance(plan, str):
+        try:
+            plan = json.loads(plan)
+        except json.JSONDecodeError as e:
+            raise ValueError(f"Invalid plan JSON: {e}") from None
+
+    if not isinstance(plan, dict) or "resource_changes" not in plan:
+        raise ValueError("Not a Terraform plan: expected `terraform show -json` output with resource_changes")
+    return plan
+
+
+def change_action(actions: List[str]) -> Optional[str]:
+    """Collapse Terraform's action list into one action (None for no-op/read)"""
+    if actions == ["create"]:
+        return ACTION_CREATE
+    if actions == ["delete"]:
+        return ACTION_DELETE
+    if actions == ["update"]:
+        return ACTION_UPDATE
+    if sorted(actions) == ["create", "delete"]:
+        return ACTION_REPLACE
+    return None
+
+
+def provider_short_name(provider_name: str) -> str:
+    """registry.terraform.io/hashicorp/aws -> aws"""
+    return provider_name.rstrip("/").rsplit("/", 1)[-1]
+
+
+def resource_changes(plan: Dict[str, Any]) -> List[ResourceChange]:
+    """Managed resource changes of a decoded plan, in plan order"""
+    regions = _provider_regions(plan)
+    provider_keys = _resource_provider_keys(plan)
+
+    changes = []
+    for rc in plan.get("resource_changes") or []:
+        if rc.get("mode", "managed") != "managed":
+            continue
+
+        change = rc.get("change") or {}
+        action = change_action(change.get("actions") or [])
+        if action is None:
+            continue
+
+        provider = provider_short_name(rc.get("provider_name", ""))
+        address = rc.get("address", "")
+        key = provider_keys.get(_INDEX.sub("", address), provider)
+
+        changes.append(ResourceChange(
+            address=address,
+            type=rc.get("type", ""),
+            action=action,
+            provider=provider,
+            region=regions.get(key, regions.get(provider)),
+            before=change.get("before") if action != ACTION_CREATE else None,
+            after=change.get("after") if action != ACTION_DELETE else None,
+        ))
+    return changes
+
+
+def _provider_regions(plan: Dict[str, Any]) -> Dict[str, str]:
+    """Provider config key (aws, aws.west, ...) -> constant region"""
+    regions = {}
+    provider_config = (plan.get("configuration") or {}).get("provider_config") or {}
+    for key, config in provider_config.items():
+        expression = (config.get("expressions") or {}).get("region") or {}
+        region = expression.get("constant_value")
+        if isinstance(region, str) and region:
+            regions[key] = region
+    return regions
+
+
+def _resource_provider_keys(plan: Dict[str, Any]) -> Dict[str, str]:
+    """Unindexed resource address -> provider config key"""
+    keys: Dict[str, str] = {}
+    root = (plan.get("configuration") or {}).get("root_module") or {}
+    _collect_provider_keys(root, "", keys)
+    return keys
+
+
+def _collect_provider_keys(module: Dict[str, Any], prefix: str, keys: Dict[str, str]) -> None:
+    for resource in module.get("resources") or []:
+        if resource.get("provider_config_key"):
+            # Keys inside modules are qualified as "<module>:<provider>"
+            key = resource["provider_config_key"].rsplit(":", 1)[-1]
+            keys[prefix + resource.get("address", "")] = key
+
+    for name, call in (module.get("module_calls") or {}).items():
+        _collect_provider_keys(call.get("module") or {}, f"{prefix}module.{name}.", keys)
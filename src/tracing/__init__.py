@@ -0,0 +1,38 @@
+"""
+Tracing Module
+
+This module records OpenTelemetry spans of requests, estimates, upstream
+pricing API calls and store queries, and exports them over OTLP.
+"""
+
+from .tracer import (
+    configure_tracing,
+    current_trace_id,
+    server_span,
+    set_response_status,
+    shutdown_tracing,
+    span,
+    tracing_enabled,
+    KIND_CLIENT,
+    KIND_INTERNAL,
+    KIND_SERVER,
+    PROTOCOL_GRPC,
+    PROTOCOL_HTTP,
+    PROTOCOLS,
+)
+
+__all__ = [
+    "configure_tracing",
+    "current_trace_id",
+    "server_span",
+    "set_response_status",
+    "shutdown_tracing",
+    "span",
+    "tracing_enabled",
+    "KIND_CLIENT",
+    "KIND_INTERNAL",
+    "KIND_SERVER",
+    "PROTOCOL_GRPC",
+    "PROTOCOL_HTTP",
+    "PROTOCOLS",
+]
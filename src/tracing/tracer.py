@@ -0,0 +1,181 @@
+"""
+OpenTelemetry tracing
+
+When enabled, spans of HTTP requests, estimates, upstream pricing API calls
+and store queries are exported over OTLP to a collector (Jaeger, Tempo,
+an OpenTelemetry Collector). Incoming W3C traceparent headers are honored,
+so the spans join the caller's trace.
+
+The OpenTelemetry SDK is optional: without it, or with tracing disabled,
+span() yields None and costs a function call.
+"""
+
+import logging
+from contextlib import contextmanager
+from typing import Any, Dict, Iterator, Mapping, Optional
+
+logger = logging.getLogger(__name__)
+
+PROTOCOL_GRPC = "grpc"
+PROTOCOL_HTTP = "http/protobuf"
+PROTOCOLS = (PROTOCOL_GRPC, PROTOCOL_HTTP)
+
+KIND_INTERNAL = "internal"
+KIND_SERVER = "server"
+KIND_CLIENT = "client"
+
+_tracer = None
+_provider = None
+
+
+def configure_tracing(
+    service: str,
+    endpoint: str = "",
+    protocol: str = PROTOCOL_GRPC,
+    sample_ratio: float = 1.0,
+    exporter=None,
+) -> bool:
+    """
+    Export spans of this process to an OTLP endpoint
+
+    Args:
+        service: service.name of the spans
+        endpoint: OTLP endpoint, the exporter's default (OTEL_EXPORTER_OTLP_ENDPOINT) if empty
+        protocol: PROTOCOL_GRPC (port 4317) or PROTOCOL_HTTP (port 4318)
+        sample_ratio: Share of new traces recorded; traces started by callers keep their decision
+        exporter: Span exporter replacing the OTLP one
+
+    Returns:
+        Whether tracing is enabled, False if the OpenTelemetry SDK is not installed
+
+    Raises:
+        ValueError: If the protocol or sample ratio is invalid
+    """
+    global _tracer, _provider
+    if protocol not in PROTOCOLS:
+        raise ValueError(f"OTLP protocol must be one of: {', '.join(PROTOCOLS)}")
+    if not 0 <= sample_ratio <= 1:
+        raise ValueError("Trace sample ratio must be between 0 and 1")
+
+    try:
+        from opentelemetry import trace
+        from opentelemetry.sdk.resources import Resource
+        from opentelemetry.sdk.trace import TracerProvider
+        from opentelemetry.sdk.trace.export import BatchSpanProcessor
+        from opentelemetry.sdk.trace.sampling import ParentBased, TraceIdRatioBased
+    except ImportError:
+        logger.error("Tracing enabled but opentelemetry-sdk is not installed, spans are not exported")
+        return False
+
+    if exporter is None:
+        exporter = _otlp_exporter(endpoint, protocol)
+        if exporter is None:
+            return False
+
+    provider = TracerProvider(
+        resource=Resource.create({"service.name": service}),
+        sampler=ParentBased(TraceIdRatioBased(sample_ratio)),
+    )
+    provider.add_span_processor(BatchSpanProcessor(exporter))
+    trace.set_tracer_provider(provider)
+    _provider = provider
+    _tracer = provider.get_tracer(service)
+    logger.info(f"Tracing enabled, exporting over OTLP/{protocol} to {endpoint or 'the default endpoint'}")
+    return True
+
+
+def shutdown_tracing() -> None:
+    """Export the spans still buffered and stop tracing"""
+    global _tracer, _provider
+    if _provider is not None:
+        _provider.shutdown()
+    _tracer = None
+    _provider = None
+
+
+def tracing_enabled() -> bool:
+    """Whether spans are recorded"""
+    return _tracer is not None
+
+
+@contextmanager
+def span(name: str, attributes: Optional[Dict[str, Any]] = None, kind: str = KIND_INTERNAL) -> Iterator[Any]:
+    """
+    Record a span for the duration of the block, as a child of the current one
+
+    Exceptions propagate and are recorded on the span with an error status.
+
+    Yields:
+        The span, None when tracing is disabled
+    """
+    if _tracer is None:
+        yield None
+        return
+    from opentelemetry.trace import SpanKind
+
+    with _tracer.start_as_current_span(
+        name, kind=SpanKind[kind.upper()], attributes=_clean(attributes)
+    ) as current:
+        yield current
+
+
+@contextmanager
+def server_span(name: str, headers: Mapping[str, str], attributes: Optional[Dict[str, Any]] = None) -> Iterator[Any]:
+    """Record the span of a request, continuing the trace of its traceparent header"""
+    if _tracer is None:
+        yield None
+        return
+    from opentelemetry.propagate import extract
+    from opentelemetry.trace import SpanKind
+
+    with _tracer.start_as_current_span(
+        name, context=extract(dict(headers)), kind=SpanKind.SERVER, attributes=_clean(attributes)
+    ) as current:
+        yield current
+
+
+def set_response_status(current, method: str, status_code: int, route: Optional[str] = None) -> None:
+    """
+    Record the response of a request on its server span
+
+    Names the span after the route template once routing matched it, and
+    marks 5xx responses as errors.
+    """
+    if current is None:
+        return
+    from opentelemetry.trace import StatusCode
+
+    if route:
+        current.update_name(f"{method} {route}")
+        current.set_attribute("http.route", route)
+    current.set_attribute("http.response.status_code", status_code)
+    if status_code >= 500:
+        current.set_status(StatusCode.ERROR)
+
+
+def current_trace_id() -> Optional[str]:
+    """Hex ID of the current trace, None outside a recorded span"""
+    if _tracer is None:
+        return None
+    from opentelemetry import trace
+
+    context = trace.get_current_span().get_span_context()
+    return format(context.trace_id, "032x") if context.is_valid else None
+
+
+def _clean(attributes: Optional[Dict[str, Any]]) -> Dict[str, Any]:
+    """Span attributes without None values, which OpenTelemetry rejects"""
+    return {key: value for key, value in (attributes or {}).items() if value is not None}
+
+
+def _otlp_exporter(endpoint: str, protocol: str):
+    kwargs = {"endpoint": endpoint} if endpoint else {}
+    try:
+        if protocol == PROTOCOL_GRPC:
+            from opentelemetry.exporter.otlp.proto.grpc.trace_exporter import OTLPSpanExporter
+        else:
+            from opentelemetry.exporter.otlp.proto.http.trace_exporter import OTLPSpanExporter
+    except ImportError:
+        logger.error(f"Tracing enabled but the OTLP/{protocol} exporter is not installed, spans are not exported")
+        return None
+    return OTLPSpanExporter(**kwargs)
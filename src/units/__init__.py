@@ -0,0 +1,79 @@
+"""
+Units Module
+
+This module normalizes the intervals and sizes prices and estimates are
+expressed in. A process-wide time basis (730 or 720 hours per month, or
+the calendar days of the current month) converts hourly, daily, monthly
+and yearly figures the same way for every provider, and sizes convert
+between B, MB, GB/GiB and TB/TiB in the binary units providers bill.
+"""
+
+from .size import (
+    MB_PER_GB,
+    KB_PER_GB,
+    BYTES_PER_GB,
+    GB_PER_TB,
+    gb_per_unit,
+    convert_size,
+    parse_size,
+    size_field,
+)
+from .time_basis import (
+    BASIS_730,
+    BASIS_720,
+    BASIS_CALENDAR,
+    TIME_BASES,
+    HOURS_PER_DAY,
+    MONTHS_PER_YEAR,
+    HOURS_PER_MONTH,
+    INTERVAL_HOUR,
+    INTERVAL_DAY,
+    INTERVAL_MONTH,
+    INTERVAL_YEAR,
+    INTERVALS,
+    TimeBasis,
+    normalize_time_basis,
+    configure_time_basis,
+    time_basis_name,
+    hours_per_month,
+    hours_per_year,
+    months_per_year,
+    interval_hours,
+    convert_rate,
+    current_time_basis,
+    recorded_hours_per_month,
+)
+
+__all__ = [
+    "MB_PER_GB",
+    "KB_PER_GB",
+    "BYTES_PER_GB",
+    "GB_PER_TB",
+    "gb_per_unit",
+    "convert_size",
+    "parse_size",
+    "size_field",
+    "BASIS_730",
+    "BASIS_720",
+    "BASIS_CALENDAR",
+    "TIME_BASES",
+    "HOURS_PER_DAY",
+    "MONTHS_PER_YEAR",
+    "HOURS_PER_MONTH",
+    "INTERVAL_HOUR",
+    "INTERVAL_DAY",
+    "INTERVAL_MONTH",
+    "INTERVAL_YEAR",
+    "INTERVALS",
+    "TimeBasis",
+    "normalize_time_basis",
+    "configure_time_basis",
+    "time_basis_name",
+    "hours_per_month",
+    "hours_per_year",
+    "months_per_year",
+    "interval_hours",
+    "convert_rate",
+    "current_time_basis",
+    "recorded_hours_per_month",
+]
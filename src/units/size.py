@@ -0,0 +1,81 @@
+"""
+Data sizes
+
+Cloud providers bill storage, memory and transfer in binary units
+whatever they call them: a GB is 1024 MB (a GiB) and a TB 1024 GB.
+Figures are kept in GB; sizes in other units convert with the factors
+below, decimal and binary names alike. Kubernetes quantities keep their
+own decimal (G) and binary (Gi) suffixes, see k8s.quantity.
+"""
+
+import re
+
+MB_PER_GB = 1024.0
+KB_PER_GB = 1024.0 * 1024.0
+BYTES_PER_GB = 1024.0 ** 3
+GB_PER_TB = 1024.0
+
+# GB in one unit of each size unit, by upper-cased name
+_GB_PER_UNIT = {
+    "B": 1 / BYTES_PER_GB,
+    "KB": 1 / KB_PER_GB,
+    "MB": 1 / MB_PER_GB,
+    "GB": 1.0,
+    "TB": GB_PER_TB,
+    "PB": GB_PER_TB * 1024.0,
+}
+for _unit in ("K", "M", "G", "T", "P"):
+    _GB_PER_UNIT[f"{_unit}IB"] = _GB_PER_UNIT[f"{_unit}B"]
+    _GB_PER_UNIT[_unit] = _GB_PER_UNIT[f"{_unit}B"]
+
+_SIZE = re.compile(r"^\s*(\d+(?:\.\d+)?|\.\d+)\s*([a-z]*)\s*$", re.IGNORECASE)
+
+
+def gb_per_unit(unit: str) -> float:
+    """
+    GB in one unit, e.g. 1024 for TB or TiB
+
+    Raises:
+        ValueError: If the unit is not a size unit
+    """
+    try:
+        return _GB_PER_UNIT[unit.strip().upper()]
+    except KeyError:
+        raise ValueError(f"Unknown size unit {unit!r}, expected B, KB, MB, GB, TB or PB") from None
+
+
+def convert_size(value: float, from_unit: str, to_unit: str = "GB") -> float:
+    """
+    A size in another unit, e.g. 2 TB -> 2048 GB
+
+    Raises:
+        ValueError: If a unit is not a size unit
+    """
+    return value * gb_per_unit(from_unit) / gb_per_unit(to_unit)
+
+
+def parse_size(value: str) -> float:
+    """
+    GB of a size with an optional unit, e.g. "500GiB", "2 TB" or "100" (GB)
+
+    Raises:
+        ValueError: If the size cannot be read
+    """
+    match = _SIZE.match(value)
+    if match is None:
+        raise ValueError(f"Invalid size {value!r}, expected a number and a unit, e.g. 2TB")
+    number, unit = match.groups()
+    return float(number) * gb_per_unit(unit or "GB")
+
+
+def size_field(value, unit: str = "GB"):
+    """
+    Value of a size field in `unit`: strings are read with parse_size, e.g.
+    "2TB" for a GB field, and numbers are left to the field's validation
+
+    Raises:
+        ValueError: If a string size cannot be read
+    """
+    if isinstance(value, str):
+        return convert_size(parse_size(value), "GB", unit)
+    return value
@@ -0,0 +1,168 @@
+"""
+Time basis
+
+Prices are quoted per hour, per month or per year and estimates report
+each of them, so every conversion between intervals depends on how many
+hours a month has. The time basis fixes that number for all providers
+and estimates at once:
+
+- 730: 8760 / 12, the average month of the AWS, Azure and GCP calculators
+- 720: a 30-day month
+- calendar: the days of the current month, from 672 to 744 hours
+
+A year is 12 months of the basis, or the days of the current year with
+the calendar basis, so a month's cost makes a year's as its hourly cost
+over the hours of the year. Estimates report the basis they were priced with.
+"""
+
+import calendar
+import threading
+from datetime import date, datetime
+from typing import Any, Callable, Dict, Optional
+
+from pydantic import BaseModel, Field
+
+BASIS_730 = "730"
+BASIS_720 = "720"
+BASIS_CALENDAR = "calendar"
+TIME_BASES = (BASIS_730, BASIS_720, BASIS_CALENDAR)
+
+HOURS_PER_DAY = 24.0
+MONTHS_PER_YEAR = 12
+# Hours of a month with the default basis (8760 / 12)
+HOURS_PER_MONTH = 730.0
+
+INTERVAL_HOUR = "hour"
+INTERVAL_DAY = "day"
+INTERVAL_MONTH = "month"
+INTERVAL_YEAR = "year"
+INTERVALS = (INTERVAL_HOUR, INTERVAL_DAY, INTERVAL_MONTH, INTERVAL_YEAR)
+
+_FIXED_HOURS = {BASIS_730: HOURS_PER_MONTH, BASIS_720: 720.0}
+
+
+class TimeBasis(BaseModel):
+    """Hours per month an estimate was priced with"""
+
+    basis: str = Field(..., description="730, 720 or calendar")
+    hours_per_month: float
+    month: Optional[str] = Field(None, description="Month counted by the calendar basis, YYYY-MM")
+
+
+def _utc_today() -> date:
+    return datetime.utcnow().date()
+
+
+_lock = threading.Lock()
+_basis = BASIS_730
+_clock: Callable[[], date] = _utc_today
+
+
+def normalize_time_basis(value: str) -> str:
+    """
+    Time basis of a setting value, e.g. "730" or "Calendar"
+
+    Raises:
+        ValueError: If the value is not a known basis
+    """
+    basis = str(value).strip().lower()
+    if basis.endswith(".0"):
+        basis = basis[:-2]
+    if basis not in TIME_BASES:
+        raise ValueError(f"hours per month must be one of: {', '.join(TIME_BASES)}")
+    return basis
+
+
+def configure_time_basis(basis: str, clock: Optional[Callable[[], date]] = None) -> str:
+    """
+    Set the time basis of every estimate in this process
+
+    Args:
+        basis: 730, 720 or calendar (UNITS_HOURS_PER_MONTH)
+        clock: Today's date, for the calendar basis
+
+    Returns:
+        The basis set
+
+    Raises:
+        ValueError: If the basis is not known
+    """
+    global _basis, _clock
+    normalized = normalize_time_basis(basis)
+    with _lock:
+        _basis = normalized
+        _clock = clock or _utc_today
+    return normalized
+
+
+def time_basis_name() -> str:
+    """Time basis in use"""
+    return _basis
+
+
+def hours_per_month(on: Optional[date] = None) -> float:
+    """Hours in a month of the time basis, the month of `on` (default today) for the calendar basis"""
+    basis = _basis
+    if basis != BASIS_CALENDAR:
+        return _FIXED_HOURS[basis]
+    day = on or _clock()
+    return calendar.monthrange(day.year, day.month)[1] * HOURS_PER_DAY
+
+
+def hours_per_year(on: Optional[date] = None) -> float:
+    """Hours in a year of the time basis, the year of `on` (default today) for the calendar basis"""
+    basis = _basis
+    if basis != BASIS_CALENDAR:
+        return _FIXED_HOURS[basis] * MONTHS_PER_YEAR
+    day = on or _clock()
+    return (366 if calendar.isleap(day.year) else 365) * HOURS_PER_DAY
+
+
+def months_per_year(on: Optional[date] = None) -> float:
+    """Months of the time basis in a year: 12, or the current year's hours over the current month's (calendar)"""
+    return hours_per_year(on) / hours_per_month(on)
+
+
+def interval_hours(interval: str, on: Optional[date] = None) -> float:
+    """
+    Hours in an interval of the time basis
+
+    Raises:
+        ValueError: If the interval is not hour, day, month or year
+    """
+    if interval == INTERVAL_HOUR:
+        return 1.0
+    if interval == INTERVAL_DAY:
+        return HOURS_PER_DAY
+    if interval == INTERVAL_MONTH:
+        return hours_per_month(on)
+    if interval == INTERVAL_YEAR:
+        return hours_per_year(on)
+    raise ValueError(f"interval must be one of: {', '.join(INTERVALS)}")
+
+
+def convert_rate(amount: float, from_interval: str, to_interval: str, on: Optional[date] = None) -> float:
+    """
+    An amount per interval as an amount per another interval, e.g. $/month -> $/hour
+
+    Raises:
+        ValueError: If an interval is not known
+    """
+    if from_interval == to_interval:
+        return amount
+    return amount / interval_hours(from_interval, on) * interval_hours(to_interval, on)
+
+
+def current_time_basis(on: Optional[date] = None) -> TimeBasis:
+    """Time basis in use, as reported with estimates"""
+    basis = _basis
+    if basis != BASIS_CALENDAR:
+        return TimeBasis(basis=basis, hours_per_month=_FIXED_HOURS[basis])
+    day = on or _clock()
+    return TimeBasis(basis=basis, hours_per_month=hours_per_month(day), month=f"{day.year:04d}-{day.month:02d}")
+
+
+def recorded_hours_per_month(result: Dict[str, Any]) -> float:
+    """Hours per month of a recorded estimate result, the current basis' for results recorded without one"""
+    recorded = (result.get("time_basis") or {}).get("hours_per_month")
+    return float(recorded) if recorded else hours_per_month()
@@ -0,0 +1,56 @@
+"""
+Validation Module
+
+This module reports invalid requests as RFC 7807 problem details
+(application/problem+json) listing every violation with the path of its
+field in the request body. Schema errors of any request body and the
+items of an estimate request the estimator cannot price (unsupported
+clouds, unknown instance types, regions that do not offer them) are
+converted to violations with a machine-readable code.
+"""
+
+from .problems import (
+    PROBLEM_MEDIA_TYPE,
+    PROBLEM_TYPE_VALIDATION,
+    CODE_MISSING,
+    CODE_INVALID,
+    CODE_OUT_OF_RANGE,
+    CODE_NEGATIVE_QUANTITY,
+    CODE_UNSUPPORTED_KIND,
+    CODE_UNSUPPORTED_PROVIDER,
+    CODE_UNKNOWN_INSTANCE_TYPE,
+    CODE_INVALID_REGION,
+    CODE_UNPRICED,
+    CODE_UNKNOWN_USAGE_PROFILE,
+    Violation,
+    Problem,
+    ValidationProblem,
+    summarize,
+    field_path,
+    violations_of,
+    problem_of,
+)
+from .estimate import validate_estimate
+
+__all__ = [
+    "PROBLEM_MEDIA_TYPE",
+    "PROBLEM_TYPE_VALIDATION",
+    "CODE_MISSING",
+    "CODE_INVALID",
+    "CODE_OUT_OF_RANGE",
+    "CODE_NEGATIVE_QUANTITY",
+    "CODE_UNSUPPORTED_KIND",
+    "CODE_UNSUPPORTED_PROVIDER",
+    "CODE_UNKNOWN_INSTANCE_TYPE",
+    "CODE_INVALID_REGION",
+    "CODE_UNPRICED",
+    "CODE_UNKNOWN_USAGE_PROFILE",
+    "Violation",
+    "Problem",
+    "ValidationProblem",
+    "summarize",
+    "field_path",
+    "violations_of",
+    "problem_of",
+    "validate_estimate",
+]
@@ -0,0 +1,111 @@
+"""
+Validation of estimate requests
+
+An estimate stops at the first item it cannot price. Checking a request
+item by item instead finds every item the estimator would reject: clouds
+no pricing provider serves, unknown instance types (with the closest
+ones the region offers), instance types a region does not offer, usage
+profiles the tenant does not have and items with components that have
+no price.
+"""
+
+from typing import Any, Callable, List
+
+from ..estimator import CostEstimator, EstimateRequest
+from ..estimator.serverless import FreeTier
+from ..pricing import PriceNotFoundError, ProviderNotFoundError, did_you_mean, get_instance_shape
+from ..profiles import UnknownProfileError
+from .problems import (
+    CODE_INVALID,
+    CODE_INVALID_REGION,
+    CODE_UNKNOWN_INSTANCE_TYPE,
+    CODE_UNKNOWN_USAGE_PROFILE,
+    CODE_UNPRICED,
+    CODE_UNSUPPORTED_PROVIDER,
+    Violation,
+)
+
+
+def _known_instance_type(provider: str, instance_type: str) -> bool:
+    try:
+        get_instance_shape(provider, instance_type)
+        return True
+    except KeyError:
+        return False
+
+
+def validate_estimate(estimator: CostEstimator, request: EstimateRequest, prefix: str = "") -> List[Violation]:
+    """
+    Every violation the estimator would fail a request on
+
+    Args:
+        estimator: Estimator the request is priced with
+        request: Request already validated against its schema
+        prefix: Path of the request in an enclosing body
+
+    Returns:
+        Violations in request order, none if every item can be priced
+    """
+    base = f"{prefix}." if prefix else ""
+    session = estimator.discount_session()
+    violations: List[Violation] = []
+
+    def check(field: str, priced: Callable[[], Any]) -> None:
+        try:
+            priced()
+        except UnknownProfileError as e:
+            violations.append(Violation(
+                field=f"{field}.usage_profile", code=CODE_UNKNOWN_USAGE_PROFILE, message=str(e)
+            ))
+        except ProviderNotFoundError as e:
+            violations.append(Violation(field=f"{field}.provider", code=CODE_UNSUPPORTED_PROVIDER, message=str(e)))
+        except PriceNotFoundError as e:
+            violations.append(Violation(field=field, code=CODE_UNPRICED, message=str(e)))
+        except ValueError as e:
+            violations.append(Violation(field=field, code=CODE_INVALID, message=str(e)))
+
+    for i, resource in enumerate(request.resources):
+        field = f"{base}resources[{i}]"
+        check(field, lambda: estimator.usage_profile(resource.usage_profile))
+        try:
+            price = estimator.lookup_price(resource)
+        except ProviderNotFoundError as e:
+            violations.append(Violation(field=f"{field}.provider", code=CODE_UNSUPPORTED_PROVIDER, message=str(e)))
+            continue
+        except PriceNotFoundError:
+            if _known_instance_type(resource.provider, resource.instance_type):
+                violations.append(Violation(
+                    field=f"{field}.region",
+                    code=CODE_INVALID_REGION,
+                    message=f"{resource.provider} {resource.instance_type} is not offered in region {resource.region}",
+                ))
+            else:
+                suggestions = estimator.suggest_instance_types(resource)
+                message = f"Unknown {resource.provider} instance type {resource.instance_type}"
+                violations.append(Violation(
+                    field=f"{field}.instance_type",
+                    code=CODE_UNKNOWN_INSTANCE_TYPE,
+                    message=f"{message}, {did_you_mean(suggestions)}" if suggestions else message,
+                    suggestions=[match.sku for match in suggestions],
+                ))
+            continue
+        if resource.accelerator_type is not None:
+            check(f"{field}.accelerator_type", lambda: estimator.price_resource(resource, session, price))
+        if resource.licenses:
+            check(f"{field}.licenses", lambda: estimator.licenses.price(
+                resource.provider, price.sku, resource.licenses, resource.count, resource.hours
+            ))
+
+    for i, traffic in enumerate(request.traffic):
+        check(f"{base}traffic[{i}]", lambda: estimator.price_traffic(traffic, session))
+    for i, database in enumerate(request.databases):
+        check(f"{base}databases[{i}]", lambda: estimator.price_database(database, session))
+    for i, spec in enumerate(request.object_storage):
+        check(f"{base}object_storage[{i}]", lambda: estimator.price_object_storage(spec, session))
+    free_tier = FreeTier()
+    for i, spec in enumerate(request.serverless):
+        check(f"{base}serverless[{i}]", lambda: estimator.price_serverless(spec, session, free_tier))
+    # The DR strategy prices the same items again in their recovery regions
+    if request.disaster_recovery is not None and not violations:
+        check(f"{base}disaster_recovery", lambda: estimator.price_disaster_recovery(request, session=session))
+    return violations
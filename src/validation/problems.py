@@ -0,0 +1,123 @@
+"""
+Problem details of invalid requests
+
+Invalid requests are answered with RFC 7807 problem details
+(application/problem+json) that list every violation found, not only the
+first. Each violation names the offending field by its path in the
+request body, e.g. resources[2].instance_type, with a code clients can
+act on and a message for people.
+"""
+
+from typing import Any, Dict, Iterable, List, Optional, Sequence
+
+from pydantic import BaseModel, Field
+
+PROBLEM_MEDIA_TYPE = "application/problem+json"
+PROBLEM_TYPE_VALIDATION = "urn:kcloud-cost-estimator:problem:validation"
+
+CODE_MISSING = "missing"
+CODE_INVALID = "invalid"
+CODE_OUT_OF_RANGE = "out_of_range"
+CODE_NEGATIVE_QUANTITY = "negative_quantity"
+CODE_UNSUPPORTED_KIND = "unsupported_kind"
+CODE_UNSUPPORTED_PROVIDER = "unsupported_provider"
+CODE_UNKNOWN_INSTANCE_TYPE = "unknown_instance_type"
+CODE_INVALID_REGION = "invalid_region"
+CODE_UNPRICED = "unpriced"
+CODE_UNKNOWN_USAGE_PROFILE = "unknown_usage_profile"
+
+# Error types of pydantic v1 and v2 and the code of their violations
+_MISSING_TYPES = {"value_error.missing", "missing"}
+_EXTRA_TYPES = {"value_error.extra", "extra_forbidden"}
+_MINIMUM_TYPES = {"value_error.number.not_ge", "greater_than_equal"}
+_BOUND_TYPES = {
+    "value_error.number.not_gt", "value_error.number.not_le", "value_error.number.not_lt",
+    "greater_than", "less_than_equal", "less_than",
+}
+
+
+class Violation(BaseModel):
+    """One invalid field of a request"""
+
+    field: str = Field(..., description="Path of the field in the request body, e.g. resources[0].count")
+    code: str = Field(..., description="missing, invalid, out_of_range, negative_quantity, unsupported_kind, ...")
+    message: str
+    suggestions: List[str] = Field(default_factory=list, description="Closest valid values of unknown instance types")
+
+
+class Problem(BaseModel):
+    """RFC 7807 problem details of an invalid request"""
+
+    type: str = PROBLEM_TYPE_VALIDATION
+    title: str = "Invalid request"
+    status: int = 422
+    detail: str
+    instance: Optional[str] = Field(None, description="Path of the request")
+    violations: List[Violation] = Field(default_factory=list)
+
+
+class ValidationProblem(Exception):
+    """Raised with every violation of a request"""
+
+    def __init__(self, violations: List[Violation]):
+        self.violations = violations
+        super().__init__(summarize(violations))
+
+
+def summarize(violations: Sequence[Violation]) -> str:
+    """One-line summary of violations, naming the first"""
+    if not violations:
+        return "The request is invalid"
+    first = violations[0]
+    more = f" (and {len(violations) - 1} more)" if len(violations) > 1 else ""
+    return f"{first.field}: {first.message}{more}"
+
+
+def field_path(loc: Iterable[Any], prefix: str = "") -> str:
+    """
+    Path of a field from an error location, e.g. ("body", "resources", 0, "count") -> resources[0].count
+
+    The request body is the root; query and path parameters keep their
+    location, e.g. query.currency.
+    """
+    path = prefix
+    for i, part in enumerate(loc):
+        if i == 0 and part == "body":
+            continue
+        if part == "__root__":
+            continue
+        if isinstance(part, int):
+            path += f"[{part}]"
+        else:
+            path += f".{part}" if path else str(part)
+    return path
+
+
+def _code(error: Dict[str, Any]) -> str:
+    error_type = error.get("type", "")
+    if error_type in _MISSING_TYPES:
+        return CODE_MISSING
+    if error_type in _EXTRA_TYPES:
+        return CODE_UNSUPPORTED_KIND
+    if error_type in _MINIMUM_TYPES:
+        # Quantities that may be zero but not negative
+        ctx = error.get("ctx") or {}
+        return CODE_NEGATIVE_QUANTITY if ctx.get("limit_value", ctx.get("ge")) == 0 else CODE_OUT_OF_RANGE
+    if error_type in _BOUND_TYPES:
+        return CODE_OUT_OF_RANGE
+    return CODE_INVALID
+
+
+def violations_of(errors: Iterable[Dict[str, Any]], prefix: str = "") -> List[Violation]:
+    """Violations of pydantic or FastAPI request validation errors"""
+    violations = []
+    for error in errors:
+        code = _code(error)
+        message = "Unsupported resource kind" if code == CODE_UNSUPPORTED_KIND else error.get("msg", "Invalid value")
+        violations.append(Violation(field=field_path(error.get("loc", ()), prefix), code=code, message=message))
+    return violations
+
+
+def problem_of(violations: List[Violation], instance: Optional[str] = None) -> Problem:
+    """Problem details listing violations"""
+    return Problem(detail=summarize(violations), instance=instance, violations=violations)